@@ -0,0 +1,198 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables the command line tools read credentials from
+const (
+	EnvUsername        = "IMMUDB_USERNAME"
+	EnvPassword        = "IMMUDB_PASSWORD"
+	EnvPasswordFile    = "IMMUDB_PASSWORD_FILE"
+	EnvTokenFile       = "IMMUDB_TOKEN_FILE"
+	EnvProfile         = "IMMUDB_PROFILE"
+	EnvCredentialsFile = "IMMUDB_CREDENTIALS_FILE"
+)
+
+// DefaultProfile is the profile of the credentials file used when none is selected
+const DefaultProfile = "default"
+
+var ErrProfileNotFound = errors.New("credentials profile not found")
+
+// Credentials used to login without being prompted for a password
+type Credentials struct {
+	Username string
+	Password string
+	Database string
+	// TokenFile holds the token of a previous login, it's used when no password is set
+	TokenFile string
+}
+
+// DefaultCredentialsFile returns the path of the credentials file, $HOME/.immudb/credentials
+func DefaultCredentialsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".immudb", "credentials")
+}
+
+// LoadCredentials returns the credentials set in the environment, the ones left unset are taken
+// from the profile of the credentials file. The profile is the one selected by the IMMUDB_PROFILE
+// environment variable when empty, the default one when that's not set either.
+// A missing credentials file or profile is an error only when the profile was explicitly selected.
+func LoadCredentials(profile string) (*Credentials, error) {
+	creds := &Credentials{
+		Username:  os.Getenv(EnvUsername),
+		Password:  os.Getenv(EnvPassword),
+		TokenFile: os.Getenv(EnvTokenFile),
+	}
+
+	if creds.Password == "" && os.Getenv(EnvPasswordFile) != "" {
+		password, err := readPasswordFile(os.Getenv(EnvPasswordFile))
+		if err != nil {
+			return nil, err
+		}
+		creds.Password = password
+	}
+
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	selected := profile != ""
+	if !selected {
+		profile = DefaultProfile
+	}
+
+	path := os.Getenv(EnvCredentialsFile)
+	if path == "" {
+		path = DefaultCredentialsFile()
+	}
+
+	fromFile, err := ReadCredentialsFile(path, profile)
+	if (os.IsNotExist(err) || errors.Is(err, ErrProfileNotFound)) && !selected {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.Username == "" {
+		creds.Username = fromFile.Username
+	}
+	if creds.Password == "" {
+		creds.Password = fromFile.Password
+	}
+	if creds.TokenFile == "" {
+		creds.TokenFile = fromFile.TokenFile
+	}
+	creds.Database = fromFile.Database
+
+	return creds, nil
+}
+
+// ReadCredentialsFile returns the credentials of profile held in the file at path.
+// The file holds a section per profile, each one made of key = value lines:
+//
+//	[default]
+//	username = immudb
+//	password-file = /run/secrets/immudb
+//	database = defaultdb
+//
+// The password can be set in place with the password key as well. Profiles without a password can
+// set token-file to the token file written by a previous login instead.
+func ReadCredentialsFile(path, profile string) (*Credentials, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var creds *Credentials
+	var section string
+
+	scanner := bufio.NewScanner(f)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == profile {
+				creds = &Credentials{}
+			}
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s:%d: malformed line, it must be 'key = value'", path, n)
+		}
+		if section != profile {
+			continue
+		}
+
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		switch key {
+		case "username":
+			creds.Username = value
+		case "password":
+			creds.Password = value
+		case "password-file":
+			password, err := readPasswordFile(value)
+			if err != nil {
+				return nil, err
+			}
+			creds.Password = password
+		case "database":
+			creds.Database = value
+		case "token-file":
+			creds.TokenFile = value
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown key '%s'", path, n, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if creds == nil {
+		return nil, fmt.Errorf("%w: '%s' is not in %s", ErrProfileNotFound, profile, path)
+	}
+
+	return creds, nil
+}
+
+func readPasswordFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCredentialsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	passwordFile := filepath.Join(dir, "secret")
+	require.NoError(t, ioutil.WriteFile(passwordFile, []byte("s3cr3t\n"), 0600))
+
+	path := filepath.Join(dir, "credentials")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+# local server
+[default]
+username = immudb
+password = immudb
+
+[prod]
+username = admin
+password-file = `+passwordFile+`
+database = db1
+
+[ci]
+username = admin
+token-file = /run/secrets/immudb_token
+`), 0600))
+
+	creds, err := ReadCredentialsFile(path, "default")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{Username: "immudb", Password: "immudb"}, creds)
+
+	creds, err = ReadCredentialsFile(path, "prod")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{Username: "admin", Password: "s3cr3t", Database: "db1"}, creds)
+
+	creds, err = ReadCredentialsFile(path, "ci")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{Username: "admin", TokenFile: "/run/secrets/immudb_token"}, creds)
+
+	_, err = ReadCredentialsFile(path, "staging")
+	require.ErrorIs(t, err, ErrProfileNotFound)
+
+	_, err = ReadCredentialsFile(filepath.Join(dir, "missing"), "default")
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("[default]\nusername\n"), 0600))
+	_, err = ReadCredentialsFile(path, "default")
+	require.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("[default]\ntoken = abc\n"), 0600))
+	_, err = ReadCredentialsFile(path, "default")
+	require.Error(t, err)
+}
+
+func TestLoadCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials")
+	require.NoError(t, ioutil.WriteFile(path, []byte("[default]\nusername = immudb\npassword = immudb\n\n[prod]\nusername = admin\npassword = admin\ndatabase = db1\n"), 0600))
+
+	for _, env := range []string{EnvUsername, EnvPassword, EnvPasswordFile, EnvTokenFile, EnvProfile, EnvCredentialsFile} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+
+	os.Setenv(EnvCredentialsFile, filepath.Join(dir, "missing"))
+
+	creds, err := LoadCredentials("")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{}, creds)
+
+	_, err = LoadCredentials("prod")
+	require.Error(t, err)
+
+	os.Setenv(EnvCredentialsFile, path)
+
+	creds, err = LoadCredentials("")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{Username: "immudb", Password: "immudb"}, creds)
+
+	creds, err = LoadCredentials("prod")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{Username: "admin", Password: "admin", Database: "db1"}, creds)
+
+	os.Setenv(EnvProfile, "prod")
+	creds, err = LoadCredentials("")
+	require.NoError(t, err)
+	require.Equal(t, "admin", creds.Username)
+
+	os.Setenv(EnvProfile, "staging")
+	_, err = LoadCredentials("")
+	require.ErrorIs(t, err, ErrProfileNotFound)
+	os.Unsetenv(EnvProfile)
+
+	os.Setenv(EnvUsername, "user1")
+	os.Setenv(EnvPassword, "pass1")
+	creds, err = LoadCredentials("")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{Username: "user1", Password: "pass1"}, creds)
+
+	passwordFile := filepath.Join(dir, "secret")
+	require.NoError(t, ioutil.WriteFile(passwordFile, []byte("pass2\n"), 0600))
+	os.Unsetenv(EnvPassword)
+	os.Setenv(EnvPasswordFile, passwordFile)
+	creds, err = LoadCredentials("")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{Username: "user1", Password: "pass2"}, creds)
+
+	os.Setenv(EnvPasswordFile, filepath.Join(dir, "missing"))
+	_, err = LoadCredentials("")
+	require.Error(t, err)
+	os.Unsetenv(EnvPasswordFile)
+
+	os.Setenv(EnvTokenFile, filepath.Join(dir, "token"))
+	creds, err = LoadCredentials("")
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{Username: "user1", Password: "immudb", TokenFile: filepath.Join(dir, "token")}, creds)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"errors"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+)
+
+var ErrMultipleKeySources = errors.New("only one of passphrase file, key file and key environment variable can be provided")
+
+// NewKeyProvider returns the provider of the master key used to encrypt backups, the key is either derived
+// from the passphrase held in passphraseFile, read hex-encoded from keyFile or from the environment variable keyEnv.
+// No provider is returned when none of them is set.
+func NewKeyProvider(passphraseFile, keyFile, keyEnv string) (encryption.MasterKeyProvider, error) {
+	sources := 0
+	for _, s := range []string{passphraseFile, keyFile, keyEnv} {
+		if s != "" {
+			sources++
+		}
+	}
+
+	switch {
+	case sources > 1:
+		return nil, ErrMultipleKeySources
+	case passphraseFile != "":
+		return encryption.NewPassphraseFileKeyProvider(passphraseFile)
+	case keyFile != "":
+		return encryption.NewFileKeyProvider(keyFile)
+	case keyEnv != "":
+		return encryption.NewEnvKeyProvider(keyEnv)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "key_provider")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	passphraseFile := filepath.Join(dir, "passphrase")
+	require.NoError(t, ioutil.WriteFile(passphraseFile, []byte("secret\n"), 0600))
+
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("0101010101010101010101010101010101010101010101010101010101010101"), 0600))
+
+	os.Setenv("IMMUDB_TEST_BACKUP_KEY", "0202020202020202020202020202020202020202020202020202020202020202")
+	defer os.Unsetenv("IMMUDB_TEST_BACKUP_KEY")
+
+	kp, err := NewKeyProvider("", "", "")
+	require.NoError(t, err)
+	require.Nil(t, kp)
+
+	_, err = NewKeyProvider(passphraseFile, keyFile, "")
+	require.ErrorIs(t, err, ErrMultipleKeySources)
+
+	_, err = NewKeyProvider("", keyFile, "IMMUDB_TEST_BACKUP_KEY")
+	require.ErrorIs(t, err, ErrMultipleKeySources)
+
+	for _, c := range []struct {
+		passphraseFile string
+		keyFile        string
+		keyEnv         string
+		masterKeyID    string
+	}{
+		{passphraseFile: passphraseFile, masterKeyID: encryption.PassphraseKeyID},
+		{keyFile: keyFile, masterKeyID: "key"},
+		{keyEnv: "IMMUDB_TEST_BACKUP_KEY", masterKeyID: "IMMUDB_TEST_BACKUP_KEY"},
+	} {
+		kp, err := NewKeyProvider(c.passphraseFile, c.keyFile, c.keyEnv)
+		require.NoError(t, err)
+
+		masterKeyID, _, err := kp.WrapKey(make([]byte, encryption.KeySize))
+		require.NoError(t, err)
+		require.Equal(t, c.masterKeyID, masterKeyID)
+	}
+
+	_, err = NewKeyProvider(filepath.Join(dir, "missing"), "", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of the results of the commands
+const (
+	// OutputTable is the human readable output, tables and messages
+	OutputTable = "table"
+	// OutputJSON marshals results as JSON documents
+	OutputJSON = "json"
+	// OutputYAML marshals results as YAML documents
+	OutputYAML = "yaml"
+)
+
+// OutputFlagUsage is the usage of the flag selecting the output format
+const OutputFlagUsage = "output format of the results: table, json or yaml"
+
+// Message is the result of the commands whose outcome is just a message, i.e. a confirmation
+type Message struct {
+	Message string `json:"message"`
+}
+
+// ValidateOutputFormat returns an error when format is not a supported output format
+func ValidateOutputFormat(format string) error {
+	switch format {
+	case OutputTable, OutputJSON, OutputYAML:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format '%s', expected one of table, json or yaml", format)
+}
+
+// IsMachineReadable returns true when results must be marshalled instead of printed as text
+func IsMachineReadable(format string) bool {
+	return format == OutputJSON || format == OutputYAML
+}
+
+// MarshalOutput marshals v in a machine-readable format. Protobuf messages are marshalled according to
+// their JSON mapping, including the fields holding default values, other values according to encoding/json
+func MarshalOutput(format string, v interface{}) ([]byte, error) {
+	var b []byte
+	var err error
+
+	if m, ok := v.(proto.Message); ok {
+		b, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(m)
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case OutputJSON:
+		var indented bytes.Buffer
+		err = json.Indent(&indented, b, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		indented.WriteByte('\n')
+		return indented.Bytes(), nil
+	case OutputYAML:
+		y, err := jsonToYAML(b)
+		if err != nil {
+			return nil, err
+		}
+		// commands printing several results, i.e. progress notifications, print a document each
+		return append([]byte("---\n"), y...), nil
+	}
+
+	return nil, ValidateOutputFormat(format)
+}
+
+// PrintOutput writes v to w in a machine-readable format
+func PrintOutput(w io.Writer, format string, v interface{}) error {
+	b, err := MarshalOutput(format, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// jsonToYAML converts a JSON document to YAML keeping the order of the fields
+func jsonToYAML(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(v)
+}
+
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := t.(type) {
+	case json.Delim:
+		if t == '{' {
+			m := yaml.MapSlice{}
+
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				v, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				m = append(m, yaml.MapItem{Key: k, Value: v})
+			}
+
+			_, err = dec.Token()
+			return m, err
+		}
+
+		l := []interface{}{}
+
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			l = append(l, v)
+		}
+
+		_, err = dec.Token()
+		return l, err
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+
+	return t, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOutputFormat(t *testing.T) {
+	require.NoError(t, ValidateOutputFormat(OutputTable))
+	require.NoError(t, ValidateOutputFormat(OutputJSON))
+	require.NoError(t, ValidateOutputFormat(OutputYAML))
+	require.Error(t, ValidateOutputFormat("xml"))
+
+	require.False(t, IsMachineReadable(OutputTable))
+	require.True(t, IsMachineReadable(OutputJSON))
+	require.True(t, IsMachineReadable(OutputYAML))
+}
+
+func TestPrintOutput(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	// default values of protobuf messages are included
+	err := PrintOutput(b, OutputJSON, &schema.Database{})
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"databaseName\": \"\"\n}\n", b.String())
+
+	b.Reset()
+
+	err = PrintOutput(b, OutputJSON, &Message{Message: "done"})
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"message\": \"done\"\n}\n", b.String())
+
+	b.Reset()
+
+	err = PrintOutput(b, OutputYAML, &schema.DatabaseListResponse{Databases: []*schema.Database{
+		{DatabaseName: "defaultdb"},
+		{DatabaseName: "db1"},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, "---\ndatabases:\n- databaseName: defaultdb\n- databaseName: db1\n", b.String())
+
+	b.Reset()
+
+	// fields are kept in order and numbers are not quoted
+	err = PrintOutput(b, OutputYAML, struct {
+		Name  string  `json:"name"`
+		Count int     `json:"count"`
+		Ratio float64 `json:"ratio"`
+	}{Name: "name1", Count: 3, Ratio: 0.5})
+	require.NoError(t, err)
+	require.Equal(t, "---\nname: name1\ncount: 3\nratio: 0.5\n", b.String())
+
+	err = PrintOutput(b, "xml", &Message{})
+	require.Error(t, err)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+// parseReportTime parses an optional RFC3339 date into a unix timestamp, zero when not set
+func parseReportTime(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date '%s', expected RFC3339 i.e. 2022-01-01T00:00:00Z: %v", s, err)
+	}
+
+	return t.Unix(), nil
+}
+
+func (cl *commandline) auditReport(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:   "audit-report",
+		Short: "Generate the audit report of a database signed by the server",
+		Long: "Generate the report of a range of transactions of a database: transaction counts, verification results, " +
+			"auditor checkpoints, erasures, legal holds and admin actions. The report is printed as plain text, " +
+			"the JSON document signed by the server and its signature are saved when an output file is set.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "audit-report {database_name} --since 2022-01-01T00:00:00Z --until 2022-03-31T23:59:59Z --output report.json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+
+			sinceTx, err := flags.GetUint64("since-tx")
+			if err != nil {
+				return err
+			}
+
+			untilTx, err := flags.GetUint64("until-tx")
+			if err != nil {
+				return err
+			}
+
+			since, err := flags.GetString("since")
+			if err != nil {
+				return err
+			}
+
+			until, err := flags.GetString("until")
+			if err != nil {
+				return err
+			}
+
+			output, err := flags.GetString("output")
+			if err != nil {
+				return err
+			}
+
+			req := &schema.AuditReportRequest{
+				Database: args[0],
+				SinceTx:  sinceTx,
+				UntilTx:  untilTx,
+				Rendered: true,
+			}
+
+			req.Since, err = parseReportTime(since)
+			if err != nil {
+				return err
+			}
+
+			req.Until, err = parseReportTime(until)
+			if err != nil {
+				return err
+			}
+
+			report, err := cl.immuClient.GenerateAuditReport(cl.context, req)
+			if err != nil {
+				return err
+			}
+
+			err = cl.printResult(cmd.OutOrStdout(), json.RawMessage(report.Report), func() {
+				fmt.Fprint(cmd.OutOrStdout(), report.Rendered)
+			})
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				return nil
+			}
+
+			if err := ioutil.WriteFile(output, report.Report, 0600); err != nil {
+				return err
+			}
+
+			if err := ioutil.WriteFile(output+".sig", report.Signature.Signature, 0600); err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "\nsigned report saved to '%s', its signature to '%s.sig'\n", output, output)
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	ccmd.Flags().Uint64("since-tx", 0, "first transaction covered by the report")
+	ccmd.Flags().Uint64("until-tx", 0, "last transaction covered by the report")
+	ccmd.Flags().String("since", "", "cover the transactions committed at or after the given RFC3339 date")
+	ccmd.Flags().String("until", "", "cover the transactions committed at or before the given RFC3339 date")
+	ccmd.Flags().String("output", "", "file to save the JSON report signed by the server to")
+
+	cmd.AddCommand(ccmd)
+}
@@ -17,34 +17,43 @@ limitations under the License.
 package immuadmin
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	stdos "os"
-	"path"
-	"runtime"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	daem "github.com/takama/daemon"
+	"google.golang.org/grpc/metadata"
 
 	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/client/homedir"
 	"github.com/codenotary/immudb/pkg/client/tokenservice"
-	"github.com/codenotary/immudb/pkg/fs"
 	"github.com/codenotary/immudb/pkg/immuos"
 	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/verifier"
 )
 
+var ErrBackupMismatch = errors.New("backup does not match the database it was taken from")
+var ErrBackupChain = verifier.ErrBackupChain
+var ErrTxNotInBackups = errors.New("transaction not included in the backups")
+var ErrBackupStateMismatch = verifier.ErrBackupStateMismatch
+var ErrInvalidStateSignature = verifier.ErrInvalidStateSignature
+var ErrBackupEncrypted = verifier.ErrBackupEncrypted
+
 type backupper struct {
 	daemon daem.Daemon
 	os     immuos.OS
-	copier fs.Copier
-	tarer  fs.Tarer
-	ziper  fs.Ziper
 }
 
 func newBackupper(os immuos.OS) (*backupper, error) {
@@ -55,18 +64,15 @@ func newBackupper(os immuos.OS) (*backupper, error) {
 	return &backupper{
 			daemon: d,
 			os:     os,
-			copier: fs.NewStandardCopier(),
-			tarer:  fs.NewStandardTarer(),
-			ziper:  fs.NewStandardZiper()},
+		},
 		nil
 }
 
 // Backupper ...
 type Backupper interface {
-	mustNotBeWorkingDir(p string) error
 	stopImmudbService() (func(), error)
-	offlineBackup(src string, uncompressed bool, manualStopStart bool) (string, error)
-	offlineRestore(src string, dst string, manualStopStart bool) (string, error)
+	offlineRestore(dbName string, files []string, provider encryption.MasterKeyProvider, dbDir string, upToTx uint64, manualStopStart bool) (*store.BackupManifest, string, error)
+	offlineArchiveRestore(dbName string, archiveDir string, dbDir string, upToTx uint64, manualStopStart bool) (*store.BackupManifest, string, error)
 }
 
 type commandlineBck struct {
@@ -91,9 +97,10 @@ func newCommandlineBck(os immuos.OS) (*commandlineBck, error) {
 }
 
 func (clb *commandlineBck) Register(rootCmd *cobra.Command) *cobra.Command {
-	clb.dumpToFile(rootCmd)
 	clb.backup(rootCmd)
 	clb.restore(rootCmd)
+	clb.restoreArchive(rootCmd)
+	clb.verifyBackup(rootCmd)
 	return rootCmd
 }
 
@@ -112,60 +119,296 @@ func (cl *commandlineBck) ConfigChain(post func(cmd *cobra.Command, args []strin
 	}
 }
 
-func (cl *commandlineBck) dumpToFile(cmd *cobra.Command) {
+func (cl *commandlineBck) backup(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
-		Use:               "dump [file]",
-		Short:             "Dump database content to a file",
+		Use:   "backup <db_name> [--output] [--since] [--uncompressed] [--progress-bar] [--encryption-passphrase-file|--encryption-key-file|--encryption-key-env]",
+		Short: "Make a consistent copy of a database without stopping the server",
+		Long: "Stream a snapshot of the database files from the running server into an archive (tar.gz or uncompressed tar). " +
+			"An incremental backup holding only the transactions committed after a previous backup is taken " +
+			"when the previous backup is provided. The written archive is read back and checked against the server. " +
+			"The archive is encrypted when a passphrase or a key is provided, the same passphrase or key is then required to restore it.",
 		PersistentPreRunE: cl.ConfigChain(cl.checkLoggedInAndConnect),
 		PersistentPostRun: cl.disconnect,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			filename := fmt.Sprint("immudb_" + time.Now().Format("2006-01-02_15-04-05") + ".bkp")
-			if len(args) > 0 {
-				filename = args[0]
+			dbName := args[0]
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				cl.quit(err)
+				return nil
 			}
-			file, err := cl.os.Create(filename)
-			defer file.Close()
+			sincePath, err := cmd.Flags().GetString("since")
 			if err != nil {
 				cl.quit(err)
 				return nil
 			}
-			ctx := cl.context
-			response, err := cl.immuClient.Dump(ctx, file)
+			uncompressed, err := cmd.Flags().GetBool("uncompressed")
 			if err != nil {
-				color.Set(color.FgHiBlue, color.Bold)
-				fmt.Println("Backup failed.")
-				color.Unset()
-				cl.os.Remove(filename)
 				cl.quit(err)
 				return nil
-			} else if response == 0 {
-				fmt.Println("Database is empty.")
-				cl.os.Remove(filename)
+			}
+			progress, err := cmd.Flags().GetBool("progress-bar")
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+			provider, err := keyProviderFromFlags(cmd)
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+
+			var since *store.BackupManifest
+			if sincePath != "" {
+				if since, err = verifier.ReadBackupFile(sincePath, provider); err != nil {
+					cl.quit(fmt.Errorf("error reading previous backup %s: %v", sincePath, err))
+					return nil
+				}
+			}
+
+			if output == "" {
+				output = dbName + "_" + time.Now().Format("2006-01-02_15-04-05") + ".backup.tar"
+				if !uncompressed {
+					output += ".gz"
+				}
+				if provider != nil {
+					output += ".enc"
+				}
+			}
+
+			var progressOut io.Writer
+			if progress {
+				progressOut = cmd.ErrOrStderr()
+			}
+
+			manifest, err := cl.onlineBackup(dbName, output, since, uncompressed, provider, progressOut)
+			if err != nil {
+				cl.quit(err)
 				return nil
 			}
-			fmt.Printf("SUCCESS: %d key-value entries were backed-up to file %s\n", response, filename)
-			return nil
+			return cl.printResult(cmd.OutOrStdout(), backupOutputOf(output, manifest), func() {
+				fmt.Fprintf(cmd.OutOrStdout(), "Database backup created: %s (transactions %d to %d)\n", output, manifest.SinceTxID+1, manifest.TxID)
+			})
 		},
-		Args: cobra.MaximumNArgs(1),
+		Args: cobra.ExactArgs(1),
 	}
+	ccmd.Flags().StringP("output", "o", "", "backup file (default <db_name>_<timestamp>.backup.tar.gz)")
+	ccmd.Flags().String("since", "", "previous backup of the database, only transactions committed after it are backed up")
+	ccmd.Flags().BoolP("uncompressed", "u", false, "create an uncompressed backup")
+	ccmd.Flags().Bool("progress-bar", false, "show progress indicator")
+	encryptionFlags(ccmd)
 	cmd.AddCommand(ccmd)
 }
 
-func (cl *commandlineBck) backup(cmd *cobra.Command) {
+func encryptionFlags(cmd *cobra.Command) {
+	cmd.Flags().String("encryption-passphrase-file", "", "file holding the passphrase the key of encrypted backups is derived from")
+	cmd.Flags().String("encryption-key-file", "", "file holding the hex-encoded 256-bit key of encrypted backups")
+	cmd.Flags().String("encryption-key-env", "", "environment variable holding the hex-encoded 256-bit key of encrypted backups")
+}
+
+// keyProviderFromFlags returns the provider of the key of encrypted backups, no provider is returned
+// when backups are not encrypted
+func keyProviderFromFlags(cmd *cobra.Command) (encryption.MasterKeyProvider, error) {
+	passphraseFile, err := cmd.Flags().GetString("encryption-passphrase-file")
+	if err != nil {
+		return nil, err
+	}
+	keyFile, err := cmd.Flags().GetString("encryption-key-file")
+	if err != nil {
+		return nil, err
+	}
+	keyEnv, err := cmd.Flags().GetString("encryption-key-env")
+	if err != nil {
+		return nil, err
+	}
+
+	return c.NewKeyProvider(passphraseFile, keyFile, keyEnv)
+}
+
+// onlineBackup writes a backup of the database into output and checks the written file,
+// the backup is encrypted when a key provider is set. The backup is written next to output
+// and only renamed to it once verified, so that no unverified backup is ever left at output
+func (cl *commandlineBck) onlineBackup(dbName, output string, since *store.BackupManifest, uncompressed bool, provider encryption.MasterKeyProvider, progressOut io.Writer) (*store.BackupManifest, error) {
+	if _, err := cl.os.Stat(output); err == nil {
+		return nil, fmt.Errorf("backup file %s already exists", output)
+	}
+
+	udr, err := cl.immuClient.UseDatabase(cl.context, &schema.Database{DatabaseName: dbName})
+	if err != nil {
+		return nil, err
+	}
+	ctx := metadata.NewOutgoingContext(cl.context, metadata.Pairs("authorization", udr.GetToken()))
+
+	req := &schema.BackupRequest{}
+	if since != nil {
+		req.SinceTx = since.TxID
+		req.SinceAlh = since.Alh[:]
+	}
+
+	partial := output + ".partial"
+
+	f, err := cl.os.OpenFile(partial, stdos.O_CREATE|stdos.O_EXCL|stdos.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeBackup(f, uncompressed, provider, progressOut, func(w io.Writer) error {
+		return cl.immuClient.Backup(ctx, req, w)
+	})
+	if err != nil {
+		cl.os.Remove(partial)
+		return nil, fmt.Errorf("backup failed: %v", err)
+	}
+
+	manifest, err := cl.verifyOnlineBackup(ctx, partial, output, provider)
+	if err != nil {
+		cl.os.Remove(partial)
+		return nil, err
+	}
+
+	if err = cl.os.Rename(partial, output); err != nil {
+		cl.os.Remove(partial)
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// verifyOnlineBackup checks the backup written into file against the server, output being the name it's reported with
+func (cl *commandlineBck) verifyOnlineBackup(ctx context.Context, file, output string, provider encryption.MasterKeyProvider) (*store.BackupManifest, error) {
+	manifest, err := verifier.ReadBackupFile(file, provider)
+	if err != nil {
+		return nil, fmt.Errorf("backup %s is not readable: %v", output, err)
+	}
+
+	tx, err := cl.immuClient.VerifiedTxByID(ctx, manifest.TxID)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying backup %s: %v", output, err)
+	}
+	hdr, err := schema.TxHeaderFromProto(tx.Header)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying backup %s: %v", output, err)
+	}
+	if hdr.Alh() != manifest.Alh {
+		return nil, fmt.Errorf("error verifying backup %s: %w", output, ErrBackupMismatch)
+	}
+
+	return manifest, nil
+}
+
+// writeBackup writes the archive written by backup into f, the archive is compressed before being encrypted
+func writeBackup(f *stdos.File, uncompressed bool, provider encryption.MasterKeyProvider, progressOut io.Writer, backup func(w io.Writer) error) error {
+	defer f.Close()
+
+	w := io.Writer(f)
+	if progressOut != nil {
+		pw := &progressWriter{w: w, out: progressOut}
+		defer pw.done()
+		w = pw
+	}
+
+	var ew io.WriteCloser
+	if provider != nil {
+		var err error
+		if ew, err = encryption.NewStreamWriter(w, provider); err != nil {
+			return err
+		}
+		w = ew
+	}
+
+	if uncompressed {
+		if err := backup(w); err != nil {
+			return err
+		}
+	} else {
+		gw := gzip.NewWriter(w)
+		if err := backup(gw); err != nil {
+			return err
+		}
+		if err := gw.Close(); err != nil {
+			return err
+		}
+	}
+
+	if ew != nil {
+		if err := ew.Close(); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
+// backupOutput is a backup as marshalled in machine-readable output formats
+type backupOutput struct {
+	File    string `json:"file,omitempty"`
+	SinceTx uint64 `json:"sinceTx"`
+	Tx      uint64 `json:"tx"`
+	Alh     string `json:"alh"`
+}
+
+func backupOutputOf(file string, manifest *store.BackupManifest) *backupOutput {
+	return &backupOutput{
+		File:    file,
+		SinceTx: manifest.SinceTxID,
+		Tx:      manifest.TxID,
+		Alh:     hex.EncodeToString(manifest.Alh[:]),
+	}
+}
+
+// progressWriter reports the amount of data written so far
+type progressWriter struct {
+	w       io.Writer
+	out     io.Writer
+	written int64
+	last    time.Time
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.written += int64(n)
+	if time.Since(pw.last) >= time.Second {
+		pw.last = time.Now()
+		fmt.Fprintf(pw.out, "\rWritten %s", formatBytes(pw.written))
+	}
+	return n, err
+}
+
+func (pw *progressWriter) done() {
+	fmt.Fprintf(pw.out, "\rWritten %s\n", formatBytes(pw.written))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func (cl *commandlineBck) restore(cmd *cobra.Command) {
 	defaultDbDir := server.DefaultOptions().Dir
 	ccmd := &cobra.Command{
-		Use:   "backup [--dbdir] [--manual-stop-start] [--uncompressed]",
-		Short: "Make a copy of the database files and folders",
-		Long: "Pause the immudb server, create and save on the server machine a snapshot " +
-			"of the database files and folders (zip on Windows, tar.gz on Linux or uncompressed).",
+		Use:   "restore <db_name> <backup_file> [<incremental_backup_file>...] [--dbdir] [--up-to-tx] [--manual-stop-start] [--encryption-passphrase-file|--encryption-key-file|--encryption-key-env]",
+		Short: "Restore a database from a full backup and the incremental backups taken after it",
+		Long: "Pause the immudb server and replace the database files with the ones restored from backup files " +
+			"residing on the server machine. Incremental backups are applied in the provided order, " +
+			"transactions committed after --up-to-tx are not restored. The restored database is verified " +
+			"against the backups before replacing the current one. Encrypted backups are only restored " +
+			"with the passphrase or key used to encrypt them.",
 		PersistentPreRunE: cl.ConfigChain(nil),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			dbName := args[0]
 			dbDir, err := cmd.Flags().GetString("dbdir")
 			if err != nil {
 				cl.quit(err)
 				return nil
 			}
-			if err = cl.mustNotBeWorkingDir(dbDir); err != nil {
+			upToTx, err := cmd.Flags().GetUint64("up-to-tx")
+			if err != nil {
 				cl.quit(err)
 				return nil
 			}
@@ -174,46 +417,57 @@ func (cl *commandlineBck) backup(cmd *cobra.Command) {
 				cl.quit(err)
 				return nil
 			}
-			uncompressed, err := cmd.Flags().GetBool("uncompressed")
+			provider, err := keyProviderFromFlags(cmd)
 			if err != nil {
 				cl.quit(err)
 				return nil
 			}
-			if err := cl.askUserConfirmation("backup", manualStopStart); err != nil {
+			if err := cl.askUserConfirmation("restore", manualStopStart); err != nil {
 				cl.quit(err)
 				return nil
 			}
-			backupPath, err := cl.offlineBackup(dbDir, uncompressed, manualStopStart)
+			manifest, autoBackupPath, err := cl.offlineRestore(dbName, args[1:], provider, dbDir, upToTx, manualStopStart)
 			if err != nil {
 				cl.quit(err)
 				return nil
 			}
-			fmt.Printf("Database backup created: %s\n", backupPath)
-			return nil
+			msg := fmt.Sprintf("Database %s restored up to transaction %d\n", dbName, manifest.TxID)
+			if autoBackupPath != "" {
+				msg += fmt.Sprintf("The files of the previous database have been moved to %s\n", autoBackupPath)
+			}
+			return cl.printMessage(cmd.OutOrStdout(), "%s", msg)
 		},
-		Args: cobra.NoArgs,
+		Args: cobra.MinimumNArgs(2),
 	}
-	ccmd.Flags().String("dbdir", defaultDbDir, fmt.Sprintf("path to the server database directory to backup (default %s)", defaultDbDir))
-	ccmd.Flags().Bool("manual-stop-start", false, "server stop before and restart after the backup are to be handled manually by the user (default false)")
-	ccmd.Flags().BoolP("uncompressed", "u", false, "create an uncompressed backup (i.e. make just a copy of the db directory)")
+	ccmd.Flags().String("dbdir", defaultDbDir, fmt.Sprintf("path to the server database directory (default %s)", defaultDbDir))
+	ccmd.Flags().Uint64("up-to-tx", 0, "last transaction to restore (default all the transactions in the backups)")
+	ccmd.Flags().Bool("manual-stop-start", false, "server stop before and restart after the restore are to be handled manually by the user (default false)")
+	encryptionFlags(ccmd)
 	cmd.AddCommand(ccmd)
 }
 
-func (cl *commandlineBck) restore(cmd *cobra.Command) {
+func (cl *commandlineBck) restoreArchive(cmd *cobra.Command) {
 	defaultDbDir := server.DefaultOptions().Dir
 	ccmd := &cobra.Command{
-		Use:   "restore snapshot-path [--dbdir] [--manual-stop-start]",
-		Short: "Restore the database from a snapshot archive or folder",
-		Long: "Pause the immudb server and restore the database files and folders from a snapshot " +
-			"file (zip or tar.gz) or folder (uncompressed) residing on the server machine.",
+		Use:   "restore-archive <db_name> <archive_dir> [--dbdir] [--up-to-tx] [--manual-stop-start]",
+		Short: "Restore a database from the segments archived by the server",
+		Long: "Pause the immudb server and replace the database files with the ones restored from the segments " +
+			"archived by the server into <archive_dir> (the --archive-dir of immudb) residing on the server machine. " +
+			"Transactions are restored up to --up-to-tx, or up to the last one whose segments have been archived, " +
+			"enabling point-in-time recovery.",
 		PersistentPreRunE: cl.ConfigChain(nil),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			snapshotPath := args[0]
+			dbName := args[0]
 			dbDir, err := cmd.Flags().GetString("dbdir")
 			if err != nil {
 				cl.quit(err)
 				return nil
 			}
+			upToTx, err := cmd.Flags().GetUint64("up-to-tx")
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
 			manualStopStart, err := cmd.Flags().GetBool("manual-stop-start")
 			if err != nil {
 				cl.quit(err)
@@ -223,19 +477,22 @@ func (cl *commandlineBck) restore(cmd *cobra.Command) {
 				cl.quit(err)
 				return nil
 			}
-			autoBackupPath, err := cl.offlineRestore(snapshotPath, dbDir, manualStopStart)
+			manifest, autoBackupPath, err := cl.offlineArchiveRestore(dbName, filepath.Join(args[1], dbName), dbDir, upToTx, manualStopStart)
 			if err != nil {
 				cl.quit(err)
 				return nil
 			}
-			fmt.Printf("Database restored from backup %s\n", snapshotPath)
-			fmt.Printf("A backup of the previous database has been also created: %s\n", autoBackupPath)
-			return nil
+			msg := fmt.Sprintf("Database %s restored up to transaction %d\n", dbName, manifest.TxID)
+			if autoBackupPath != "" {
+				msg += fmt.Sprintf("The files of the previous database have been moved to %s\n", autoBackupPath)
+			}
+			return cl.printMessage(cmd.OutOrStdout(), "%s", msg)
 		},
-		Args: cobra.ExactArgs(1),
+		Args: cobra.ExactArgs(2),
 	}
-	ccmd.Flags().String("dbdir", defaultDbDir, fmt.Sprintf("path to the server database directory which will be replaced by the backup (default %s)", defaultDbDir))
-	ccmd.Flags().Bool("manual-stop-start", false, "server stop before and restart after the backup are to be handled manually by the user (default false)")
+	ccmd.Flags().String("dbdir", defaultDbDir, fmt.Sprintf("path to the server database directory (default %s)", defaultDbDir))
+	ccmd.Flags().Uint64("up-to-tx", 0, "last transaction to restore (default the last transaction whose segments have been archived)")
+	ccmd.Flags().Bool("manual-stop-start", false, "server stop before and restart after the restore are to be handled manually by the user (default false)")
 	cmd.AddCommand(ccmd)
 }
 
@@ -270,26 +527,6 @@ func (cl *commandlineBck) askUserConfirmation(process string, manualStopStart bo
 	return nil
 }
 
-func (b *backupper) mustNotBeWorkingDir(p string) error {
-	currDir, err := b.os.Getwd()
-	if err != nil {
-		return err
-	}
-	pathAbs, err := b.os.Abs(p)
-	if err != nil {
-		return err
-	}
-	currDirAbs, err := b.os.Abs(currDir)
-	if err != nil {
-		return err
-	}
-	if pathAbs == currDirAbs {
-		return fmt.Errorf(
-			"cannot backup the current directory, please specify a subdirectory, for example ./data")
-	}
-	return nil
-}
-
 func (b *backupper) stopImmudbService() (func(), error) {
 	if _, err := b.daemon.Stop(); err != nil {
 		return nil, fmt.Errorf("error stopping immudb server: %v", err)
@@ -301,142 +538,134 @@ func (b *backupper) stopImmudbService() (func(), error) {
 	}, nil
 }
 
-func (b *backupper) offlineBackup(src string, uncompressed bool, manualStopStart bool) (string, error) {
-	srcInfo, err := b.os.Stat(src)
+// offlineRestore restores the database dbName in dbDir from a full backup followed by incremental backups.
+func (b *backupper) offlineRestore(dbName string, files []string, provider encryption.MasterKeyProvider, dbDir string, upToTx uint64, manualStopStart bool) (*store.BackupManifest, string, error) {
+	manifests, err := verifier.ReadBackupChain(files, provider)
 	if err != nil {
-		return "", err
-	}
-	if !srcInfo.IsDir() {
-		return "", fmt.Errorf("%s is not a directory", src)
+		return nil, "", err
 	}
 
-	if !manualStopStart {
-		startImmudbService, err := b.stopImmudbService()
-		if err != nil {
-			return "", err
-		}
-		defer startImmudbService()
+	if upToTx > manifests[len(manifests)-1].TxID {
+		return nil, "", fmt.Errorf("transaction %d: %w", upToTx, ErrTxNotInBackups)
 	}
 
-	srcBase := b.os.Base(src)
-	snapshotPath := srcBase + "_bkp_" + time.Now().Format("2006-01-02_15-04-05")
-	if err = b.copier.CopyDir(src, snapshotPath); err != nil {
-		return "", err
-	}
-	// remove the immudb.identifier file from the backup
-	if err = b.os.Remove(snapshotPath + "/" + server.IDENTIFIER_FNAME); err != nil {
-		fmt.Fprintf(stdos.Stderr,
-			"error removing immudb identifier file %s from db snapshot %s: %v",
-			server.IDENTIFIER_FNAME, snapshotPath, err)
-	}
-	if uncompressed {
-		absSnapshotPath, err := b.os.Abs(snapshotPath)
-		if err != nil {
-			fmt.Fprintf(stdos.Stderr,
-				"error converting to absolute path the rel path %s of the uncompressed backup: %v",
-				snapshotPath, err)
-			absSnapshotPath = snapshotPath
-		}
-		return absSnapshotPath, nil
-	}
+	return b.replaceWithRestored(dbName, dbDir, manualStopStart, func(restorePath string) (*store.BackupManifest, error) {
+		return verifier.RestoreBackups(restorePath, files, provider, manifests, upToTx)
+	})
+}
 
-	var archivePath string
-	var archiveErr error
-	if runtime.GOOS != "windows" {
-		archivePath = snapshotPath + ".tar.gz"
-		archiveErr = b.tarer.TarIt(snapshotPath, archivePath)
-	} else {
-		archivePath = snapshotPath + ".zip"
-		archiveErr = b.ziper.ZipIt(snapshotPath, archivePath, fs.ZipDefaultCompression)
-	}
-	if archiveErr != nil {
-		return "", fmt.Errorf(
-			"database copied successfully to %s, but compression to %s failed: %v",
-			snapshotPath, archivePath, archiveErr)
-	}
-	if err = b.os.RemoveAll(snapshotPath); err != nil {
-		fmt.Fprintf(stdos.Stderr,
-			"error removing db snapshot dir %s after successfully compressing it to %s: %v",
-			snapshotPath, archivePath, err)
+// offlineArchiveRestore restores the database dbName in dbDir from the segments archived by the server
+// into archiveDir, up to the transaction upToTx or up to the last restorable one when it's zero
+func (b *backupper) offlineArchiveRestore(dbName string, archiveDir string, dbDir string, upToTx uint64, manualStopStart bool) (*store.BackupManifest, string, error) {
+	if _, err := b.os.Stat(archiveDir); err != nil {
+		return nil, "", fmt.Errorf("archive %s is not readable: %v", archiveDir, err)
 	}
 
-	absArchivePath, err := b.os.Abs(archivePath)
-	if err != nil {
-		fmt.Fprintf(stdos.Stderr,
-			"error converting to absolute path the rel path %s of the archived backup: %v",
-			archivePath, err)
-		absArchivePath = archivePath
-	}
+	archive := store.NewDirArchive(archiveDir, store.DefaultFileMode)
 
-	return absArchivePath, nil
+	return b.replaceWithRestored(dbName, dbDir, manualStopStart, func(restorePath string) (*store.BackupManifest, error) {
+		return store.RestoreArchiveUpTo(restorePath, archive, store.DefaultOptions(), upToTx)
+	})
 }
 
-func (b *backupper) offlineRestore(src string, dst string, manualStopStart bool) (string, error) {
-	snapshotPath := src
-	_, err := b.os.Stat(snapshotPath)
-	if err != nil {
-		return "", err
-	}
-	snapshotExt := b.os.Ext(snapshotPath)
-	snapshotName := b.os.Base(snapshotPath)
-	snapshotNameNoExt := strings.TrimSuffix(snapshotName, snapshotExt)
-	if strings.ToLower(snapshotExt) == ".gz" {
-		snapshotExt = b.os.Ext(snapshotNameNoExt) + snapshotExt
-		snapshotNameNoExt = strings.TrimSuffix(snapshotName, snapshotExt)
-	}
-	dbParentDir := b.os.Dir(dst) + string(stdos.PathSeparator)
-	extractedSnapshotDir := dbParentDir + snapshotNameNoExt
-	now := time.Now().Format("2006-01-02_15-04-05")
-	var extract func(string, string) error
-	switch snapshotExt {
-	case ".tar.gz":
-		extract = b.tarer.UnTarIt
-	case ".zip":
-		extract = b.ziper.UnZipIt
-	case "": // uncompressed
-		// TODO OGG: this will result in the backup being renamed directly to the db folder
-		if dbParentDir != b.os.Dir(snapshotPath)+string(stdos.PathSeparator) {
-			extract = b.copier.CopyDir
-		}
-	default:
-		return "", fmt.Errorf(
-			"snapshot %s has unsupported format %s; supported formats: .tar.gz, .zip or none (uncompressed)",
-			snapshotPath, snapshotExt)
-	}
-
+// replaceWithRestored rebuilds the database dbName next to the current one, which is moved aside
+// only once the restore succeeds
+func (b *backupper) replaceWithRestored(
+	dbName string,
+	dbDir string,
+	manualStopStart bool,
+	restore func(restorePath string) (*store.BackupManifest, error),
+) (*store.BackupManifest, string, error) {
 	if !manualStopStart {
 		startImmudbService, err := b.stopImmudbService()
 		if err != nil {
-			return "", err
+			return nil, "", err
 		}
 		defer startImmudbService()
 	}
 
-	if extract != nil {
-		if err = extract(snapshotPath, dbParentDir); err != nil {
-			return "", err
-		}
-	}
-	// keep the same db identifier
-	serverIDSrc := path.Join(dst, server.IDENTIFIER_FNAME)
-	serverIDDst := path.Join(extractedSnapshotDir, server.IDENTIFIER_FNAME)
-	if err = b.copier.CopyFile(serverIDSrc, serverIDDst); err != nil {
-		fmt.Fprintf(stdos.Stderr,
-			"error copying immudb identifier file %s to %s: %v",
-			serverIDSrc, serverIDDst, err)
+	now := time.Now().Format("2006-01-02_15-04-05")
+	dbPath := filepath.Join(dbDir, dbName)
+	restorePath := dbPath + "_restore_" + now
+
+	manifest, err := restore(restorePath)
+	if err != nil {
+		b.os.RemoveAll(restorePath)
+		return nil, "", err
 	}
 
-	dbDirAutoBackupPath := dst + "_bkp_before_restore_" + now
-	if err = b.os.Rename(dst, dbDirAutoBackupPath); err != nil {
-		return "", fmt.Errorf(
-			"error renaming previous db dir %s to %s during restore: %v",
-			dst, dbDirAutoBackupPath, err)
+	var dbAutoBackupPath string
+
+	if _, err := b.os.Stat(dbPath); err == nil {
+		dbAutoBackupPath = dbPath + "_bkp_before_restore_" + now
+		if err = b.os.Rename(dbPath, dbAutoBackupPath); err != nil {
+			return nil, "", fmt.Errorf(
+				"error renaming previous db dir %s to %s during restore: %v",
+				dbPath, dbAutoBackupPath, err)
+		}
 	}
-	if err = b.os.Rename(extractedSnapshotDir, dst); err != nil {
-		return "", fmt.Errorf(
-			"error renaming new tmp snapshot dir %s to db dir %s during restore: %v",
-			extractedSnapshotDir, dst, err)
+	if err = b.os.Rename(restorePath, dbPath); err != nil {
+		return nil, "", fmt.Errorf(
+			"error renaming restored db dir %s to %s during restore: %v",
+			restorePath, dbPath, err)
 	}
 
-	return dbDirAutoBackupPath, nil
+	return manifest, dbAutoBackupPath, nil
+}
+
+func (cl *commandlineBck) verifyBackup(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:   "verify-backup <backup_file> [<incremental_backup_file>...] [--state] [--public-key] [--encryption-passphrase-file|--encryption-key-file|--encryption-key-env]",
+		Short: "Check a full backup and the incremental backups taken after it can be restored and are untampered",
+		Long: "Restore the backups into a temporary directory and recompute the accumulative linear hash of every transaction. " +
+			"The restored database is compared against a trusted state when provided, the state is the JSON document " +
+			"returned by the /db/state endpoint of the server and its signature is checked when the public key of the server is provided.",
+		PersistentPreRunE: cl.ConfigChain(nil),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statePath, err := cmd.Flags().GetString("state")
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+			publicKeyPath, err := cmd.Flags().GetString("public-key")
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+
+			var trusted *schema.ImmutableState
+			if statePath != "" {
+				if trusted, err = verifier.ReadTrustedState(statePath, publicKeyPath); err != nil {
+					cl.quit(err)
+					return nil
+				}
+			} else if publicKeyPath != "" {
+				cl.quit(errors.New("the public key is used to check the signature of the trusted state, which is not provided"))
+				return nil
+			}
+
+			provider, err := keyProviderFromFlags(cmd)
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+
+			manifest, err := verifier.VerifyBackups(args, provider, trusted)
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+			return cl.printResult(cmd.OutOrStdout(), backupOutputOf("", manifest), func() {
+				fmt.Fprintf(cmd.OutOrStdout(), "Backup verified: transactions 1 to %d\n", manifest.TxID)
+				if trusted != nil {
+					fmt.Fprintf(cmd.OutOrStdout(), "Backup matches the trusted state at transaction %d\n", trusted.TxId)
+				}
+			})
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+	ccmd.Flags().String("state", "", "file holding the trusted state of the database as JSON")
+	ccmd.Flags().String("public-key", "", "public key of the server, used to check the signature of the trusted state")
+	encryptionFlags(ccmd)
+	cmd.AddCommand(ccmd)
 }
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"time"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) backupSchedule(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "backup-schedule",
+		Short:             "Manage the backups taken by the server on a schedule",
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"set", "delete", "list"},
+	}
+
+	cs := &cobra.Command{
+		Use:   "set",
+		Short: "Schedule backups of a database",
+		Long: "Make the server take backups of a database at the times set by a cron expression. " +
+			"Backups are written as compressed files into a folder of the server, the oldest ones are removed " +
+			"once there are more than retention of them.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "set {database_name} --cron '0 3 * * *' --target /var/backups/immudb [--retention 7] [--notification-url {url}]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cron, err := cmd.Flags().GetString("cron")
+			if err != nil {
+				return err
+			}
+			target, err := cmd.Flags().GetString("target")
+			if err != nil {
+				return err
+			}
+			retention, err := cmd.Flags().GetUint32("retention")
+			if err != nil {
+				return err
+			}
+			notificationURL, err := cmd.Flags().GetString("notification-url")
+			if err != nil {
+				return err
+			}
+
+			sch := &schema.BackupSchedule{
+				Database:        args[0],
+				Cron:            cron,
+				Retention:       retention,
+				Target:          target,
+				NotificationURL: notificationURL,
+			}
+
+			if err := cl.immuClient.SetBackupSchedule(cl.context, sch); err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "backups of database '%s' successfully scheduled at '%s'\n", args[0], cron)
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cs.Flags().String("cron", "", "cron expression (minute hour day-of-month month day-of-week) or descriptor (i.e. @daily) setting when backups are taken")
+	cs.Flags().String("target", "", "folder of the server where backups are written")
+	cs.Flags().Uint32("retention", 0, "number of backups to keep, all backups are kept when not set")
+	cs.Flags().String("notification-url", "", "url receiving a POST request when a backup fails")
+	cs.MarkFlagRequired("cron")
+	cs.MarkFlagRequired("target")
+
+	cd := &cobra.Command{
+		Use:               "delete",
+		Short:             "Stop taking scheduled backups of a database, backups already taken are kept",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "delete {database_name}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cl.immuClient.DeleteBackupSchedule(cl.context, &schema.Database{DatabaseName: args[0]}); err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "scheduled backups of database '%s' successfully removed\n", args[0])
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	cls := &cobra.Command{
+		Use:               "list",
+		Short:             "List the scheduled backups along with the outcome of their last run",
+		Aliases:           []string{"l"},
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := cl.immuClient.ListBackupSchedules(cl.context)
+			if err != nil {
+				return err
+			}
+			return cl.printResult(cmd.OutOrStdout(), resp, func() {
+				c.PrintTable(
+					cmd.OutOrStdout(),
+					[]string{"Database Name", "Cron", "Retention", "Target", "Last Run", "Last Error"},
+					len(resp.Schedules),
+					func(i int) []string {
+						sch := resp.Schedules[i]
+
+						lastRun := "-"
+						if sch.LastRunAt > 0 {
+							lastRun = time.Unix(sch.LastRunAt, 0).Format(time.RFC3339)
+						}
+
+						return []string{
+							sch.Database,
+							sch.Cron,
+							fmt.Sprintf("%d", sch.Retention),
+							sch.Target,
+							lastRun,
+							sch.LastError,
+						}
+					},
+					fmt.Sprintf("%d backup schedule(s)", len(resp.Schedules)),
+				)
+			})
+		},
+		Args: cobra.ExactArgs(0),
+	}
+
+	ccmd.AddCommand(cs)
+	ccmd.AddCommand(cd)
+	ccmd.AddCommand(cls)
+	cmd.AddCommand(ccmd)
+}
@@ -16,846 +16,447 @@ limitations under the License.
 
 package immuadmin
 
-/*
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
-	stdos "os"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
-	"github.com/codenotary/immudb/cmd/helper"
-	"github.com/stretchr/testify/assert"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
 
-	"github.com/codenotary/immudb/cmd/cmdtest"
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
 	"github.com/codenotary/immudb/pkg/client/clienttest"
-	"github.com/codenotary/immudb/pkg/fs"
 	"github.com/codenotary/immudb/pkg/immuos"
-	"github.com/codenotary/immudb/pkg/server"
-	"github.com/spf13/cobra"
-	"github.com/takama/daemon"
-
-	"github.com/stretchr/testify/require"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/codenotary/immudb/pkg/verifier"
 )
 
-type daemonMock struct {
-	GetTemplateF func() string
-	SetTemplateF func(string) error
-	InstallF     func(...string) (string, error)
-	RemoveF      func() (string, error)
-	StartF       func() (string, error)
-	StopF        func() (string, error)
-	StatusF      func() (string, error)
-	RunF         func(daemon.Executable) (string, error)
-}
-
-func (dm *daemonMock) GetTemplate() string {
-	if dm.GetTemplateF == nil {
-		return ""
-	}
-	return dm.GetTemplateF()
-}
-func (dm *daemonMock) SetTemplate(t string) error {
-	if dm.SetTemplateF == nil {
-		return nil
-	}
-	return dm.SetTemplateF(t)
-}
-func (dm *daemonMock) Install(args ...string) (string, error) {
-	if dm.InstallF == nil {
-		return "", nil
-	}
-	return dm.InstallF(args...)
-}
-func (dm *daemonMock) Remove() (string, error) {
-	if dm.RemoveF == nil {
-		return "", nil
-	}
-	return dm.RemoveF()
-}
-func (dm *daemonMock) Start() (string, error) {
-	if dm.StartF == nil {
-		return "", nil
+func newTestCommandlineBck(t *testing.T) (*commandlineBck, *cobra.Command) {
+	cl := getCmdline()
+	require.NotNil(t, cl)
+
+	clb := &commandlineBck{
+		commandline: *cl,
+		Backupper:   &backupper{os: immuos.NewStandardOS()},
+		TerminalReader: &clienttest.TerminalReaderMock{
+			ReadFromTerminalYNF: func(def string) (selected string, err error) {
+				return "Y", nil
+			},
+		},
 	}
-	return dm.StartF()
-}
-func (dm *daemonMock) Stop() (string, error) {
-	if dm.StopF == nil {
-		return "", nil
+	clb.os = immuos.NewStandardOS()
+	clb.onError = failOnQuit(t)
+
+	cmd := &cobra.Command{}
+	clb.Register(cmd)
+
+	// the client of the test server is used instead of the configured one
+	for _, ccmd := range cmd.Commands() {
+		ccmd.PersistentPreRunE = nil
+		ccmd.PersistentPostRun = nil
 	}
-	return dm.StopF()
+
+	return clb, cmd
 }
-func (dm *daemonMock) Status() (string, error) {
-	if dm.StatusF == nil {
-		return "", nil
-	}
-	return dm.StatusF()
+
+// unverifiableClient fails the verification of any transaction
+type unverifiableClient struct {
+	client.ImmuClient
 }
-func (dm *daemonMock) Run(e daemon.Executable) (string, error) {
-	if dm.RunF == nil {
-		return "", nil
-	}
-	return dm.RunF(e)
+
+func (c *unverifiableClient) VerifiedTxByID(ctx context.Context, tx uint64) (*schema.Tx, error) {
+	return nil, errors.New("verification failed")
 }
 
-func defaultDaemonMock() *daemonMock {
-	return &daemonMock{
-		GetTemplateF: func() string {
-			return ""
-		},
-		SetTemplateF: func(t string) error {
-			return nil
-		},
-		InstallF: func(args ...string) (string, error) {
-			return "", nil
-		},
-		RemoveF: func() (string, error) {
-			return "", nil
-		},
-		StartF: func() (string, error) {
-			return "", nil
-		},
-		StopF: func() (string, error) {
-			return "", nil
-		},
-		StatusF: func() (string, error) {
-			return "", nil
-		},
-		RunF: func(e daemon.Executable) (string, error) {
-			return "", nil
-		},
+func failOnQuit(t *testing.T) func(msg interface{}) {
+	return func(msg interface{}) {
+		require.FailNow(t, fmt.Sprint(msg))
 	}
 }
 
-func TestDumpToFile(t *testing.T) {
-	os := immuos.NewStandardOS()
-	clb, err := newCommandlineBck(os)
+func TestBackupAndRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immuadmin_backup")
 	require.NoError(t, err)
-	clb.options = client.DefaultOptions()
+	defer os.RemoveAll(dir)
 
-	immuClientMock := &clienttest.ImmuClientMock{}
-	clb.immuClient = immuClientMock
-	clb.newImmuClient = func(*client.Options) (client.ImmuClient, error) {
-		return immuClientMock, nil
-	}
-	immuClientMock.DisconnectF = func() error {
-		return nil
-	}
+	clb, cmd := newTestCommandlineBck(t)
 
-	clb.passwordReader = &clienttest.PasswordReaderMock{}
-	clb.context = context.Background()
-
-	hds := clienttest.DefaultHomedirServiceMock()
-	hds.FileExistsInUserHomeDirF = func(string) (bool, error) {
-		return true, nil
-	}
-	clb.ts = tokenservice.NewTokenService().WithHds(hds).WithTokenFileName("testTokenFile")
-
-	daemMock := defaultDaemonMock()
-	clb.Backupper = &backupper{
-		daemon: daemMock,
-		os:     os,
-		copier: fs.NewStandardCopier(),
-		tarer:  fs.NewStandardTarer(),
-		ziper:  fs.NewStandardZiper(),
-	}
+	err = clb.immuClient.CreateDatabase(clb.context, &schema.DatabaseSettings{DatabaseName: "bcktest"})
+	require.NoError(t, err)
 
-	termReaderMock := &clienttest.TerminalReaderMock{
-		ReadFromTerminalYNF: func(def string) (selected string, err error) {
-			return "Y", nil
-		},
-	}
-	clb.TerminalReader = termReaderMock
+	udr, err := clb.immuClient.UseDatabase(clb.context, &schema.Database{DatabaseName: "bcktest"})
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(clb.context, metadata.Pairs("authorization", udr.GetToken()))
 
-	dumpFile := "backup_test_dump_output.bkp"
-	defer stdos.Remove(dumpFile)
-	errDump := errors.New("dump error")
-	immuClientMock.DumpF = func(ctx context.Context, f io.WriteSeeker) (int64, error) {
-		return 0, errDump
+	setKeys := func(from, to int) {
+		for i := from; i < to; i++ {
+			_, err := clb.immuClient.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+			require.NoError(t, err)
+		}
 	}
 
-	collector := new(cmdtest.StdOutCollector)
-	clb.onError = func(msg interface{}) {
-		dumpLog, err := collector.Stop()
-		require.Equal(t, errDump, msg.(error))
-		require.NoError(t, err)
-		require.Equal(t, "Backup failed.\n", dumpLog)
-	}
-	cl := commandline{}
-	cmd, _ := cl.NewCmd()
-	cmd.SetArgs([]string{"dump", dumpFile})
-	clb.dumpToFile(cmd)
+	setKeys(0, 10)
 
-	require.NoError(t, collector.Start())
+	fullBackup := filepath.Join(dir, "full.backup.tar.gz")
+	incBackup := filepath.Join(dir, "inc.backup.tar")
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	cmdlist := cmd.Commands()[0]
-	cmdlist.PersistentPreRunE = nil
+	var out bytes.Buffer
+	cmd.SetOut(&out)
 
+	cmd.SetArgs([]string{"backup", "bcktest", "-o", fullBackup})
 	require.NoError(t, cmd.Execute())
-	collector.Stop()
+	require.Contains(t, out.String(), "Database backup created: "+fullBackup)
 
-	immuClientMock.DumpF = func(ctx context.Context, f io.WriteSeeker) (int64, error) {
-		return 0, nil
-	}
-	require.NoError(t, collector.Start())
-	require.Nil(t, cmd.Execute())
-	dumpLog, err := collector.Stop()
-	require.Nil(t, err)
-	require.Equal(t, "Database is empty.\n", dumpLog)
-
-	immuClientMock.DumpF = func(ctx context.Context, f io.WriteSeeker) (int64, error) {
-		return 1, nil
-	}
-	require.NoError(t, collector.Start())
-	require.Nil(t, cmd.Execute())
-	dumpLog, err = collector.Stop()
-	require.Nil(t, err)
-	require.Equal(t, fmt.Sprintf("SUCCESS: 1 key-value entries were backed-up to file %s\n", dumpFile), dumpLog)
-}
-
-func deleteBackupFiles(prefix string) {
-	files, _ := filepath.Glob(fmt.Sprintf("./%s_bkp_*", prefix))
-	for _, f := range files {
-		stdos.RemoveAll(f)
-	}
-}
+	fullManifest, err := verifier.ReadBackupFile(fullBackup, nil)
+	require.NoError(t, err)
+	require.False(t, fullManifest.IsIncremental())
 
-func TestBackup(t *testing.T) {
-	os := immuos.NewStandardOS()
-	clb, err := newCommandlineBck(os)
+	f, err := os.Open(fullBackup)
 	require.NoError(t, err)
-	clb.options = client.DefaultOptions()
+	_, err = gzip.NewReader(f)
+	require.NoError(t, err)
+	f.Close()
 
-	loginFOK := func(context.Context, []byte, []byte) (*schema.LoginResponse, error) {
-		return &schema.LoginResponse{Token: "token"}, nil
-	}
-	immuClientMock := &clienttest.ImmuClientMock{
-		LoginF: loginFOK,
-	}
-	clb.immuClient = immuClientMock
-	clb.newImmuClient = func(*client.Options) (client.ImmuClient, error) {
-		return immuClientMock, nil
-	}
-	immuClientMock.DisconnectF = func() error {
-		return nil
-	}
+	setKeys(10, 20)
 
-	pwReaderMock := &clienttest.PasswordReaderMock{}
-	clb.passwordReader = pwReaderMock
-	clb.context = context.Background()
+	cmd.SetArgs([]string{"backup", "bcktest", "-o", incBackup, "--since", fullBackup, "--uncompressed", "--progress-bar"})
+	require.NoError(t, cmd.Execute())
 
-	hds := clienttest.DefaultHomedirServiceMock()
-	hds.FileExistsInUserHomeDirF = func(string) (bool, error) {
-		return true, nil
-	}
-	clb.ts = tokenservice.NewTokenService().WithHds(hds).WithTokenFileName("testTokenFile")
-
-	daemMock := defaultDaemonMock()
-	clb.Backupper = &backupper{
-		daemon: daemMock,
-		os:     os,
-		copier: fs.NewStandardCopier(),
-		tarer:  fs.NewStandardTarer(),
-		ziper:  fs.NewStandardZiper(),
-	}
+	incManifest, err := verifier.ReadBackupFile(incBackup, nil)
+	require.NoError(t, err)
+	require.True(t, incManifest.IsIncremental())
+	require.Equal(t, fullManifest.TxID, incManifest.SinceTxID)
+	require.Equal(t, fullManifest.TxID+10, incManifest.TxID)
+
+	t.Run("backup files are not overwritten", func(st *testing.T) {
+		var quitMsg interface{}
+		clb.onError = func(msg interface{}) { quitMsg = msg }
+		defer func() { clb.onError = failOnQuit(t) }()
+
+		cmd.SetArgs([]string{"backup", "bcktest", "-o", fullBackup})
+		require.NoError(st, cmd.Execute())
+		require.Contains(st, fmt.Sprint(quitMsg), "already exists")
+	})
 
-	okReadFromTerminalYNF := func(def string) (selected string, err error) {
-		return "Y", nil
-	}
-	termReaderMock := &clienttest.TerminalReaderMock{
-		ReadFromTerminalYNF: okReadFromTerminalYNF,
-	}
-	clb.TerminalReader = termReaderMock
+	t.Run("unverified backups are not kept", func(st *testing.T) {
+		var quitMsg interface{}
+		clb.onError = func(msg interface{}) { quitMsg = msg }
+		defer func() { clb.onError = failOnQuit(t) }()
 
-	dbDir := "backup_test_db_dir"
-	require.NoError(t, stdos.Mkdir(dbDir, 0755))
-	defer stdos.Remove(dbDir)
+		immuClient := clb.immuClient
+		clb.immuClient = &unverifiableClient{ImmuClient: immuClient}
+		defer func() { clb.immuClient = immuClient }()
 
-	collector := new(cmdtest.StdOutCollector)
-	clb.onError = func(msg interface{}) {
-		require.Empty(t, msg.(error))
-	}
+		unverified := filepath.Join(dir, "unverified.backup.tar")
 
-	// success
-	cl := commandline{}
-	cmd, _ := cl.NewCmd()
-	cmd.SetArgs([]string{
-		"backup",
-		fmt.Sprintf("--dbdir=%s", dbDir),
-	})
-	defer deleteBackupFiles(dbDir)
-	clb.backup(cmd)
+		cmd.SetArgs([]string{"backup", "bcktest", "-o", unverified})
+		require.NoError(st, cmd.Execute())
+		require.Contains(st, fmt.Sprint(quitMsg), "error verifying backup "+unverified)
 
-	require.NoError(t, collector.Start())
+		_, err := os.Stat(unverified)
+		require.True(st, os.IsNotExist(err))
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd := cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+		_, err = os.Stat(unverified + ".partial")
+		require.True(st, os.IsNotExist(err))
+	})
 
-	require.Nil(t, cmd.Execute())
-	backupLog, err := collector.Stop()
-	require.NoError(t, err)
-	require.Contains(t, backupLog, "Database backup created: ")
+	t.Run("corrupted backup is detected", func(t *testing.T) {
+		content, err := ioutil.ReadFile(fullBackup)
+		require.NoError(t, err)
+		content[len(content)-5] ^= 0xff
 
-	cmd = &cobra.Command{}
-	cmd.SetArgs([]string{
-		"backup",
-		fmt.Sprintf("--dbdir=%s", dbDir),
-		"--uncompressed",
+		corrupted := filepath.Join(dir, "corrupted.backup.tar.gz")
+		require.NoError(t, ioutil.WriteFile(corrupted, content, 0600))
+
+		_, err = verifier.ReadBackupFile(corrupted, nil)
+		require.Error(t, err)
 	})
-	clb.backup(cmd)
-	require.NoError(t, collector.Start())
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	t.Run("verify backups", func(t *testing.T) {
+		state, err := clb.immuClient.CurrentState(ctx)
+		require.NoError(t, err)
+		require.Equal(t, incManifest.TxID, state.TxId)
 
-	require.Nil(t, cmd.Execute())
-	backupLog, err = collector.Stop()
-	require.NoError(t, err)
-	require.Contains(t, backupLog, "Database backup created: ")
-
-	// Abs error (uncompressed backup)
-	deleteBackupFiles(dbDir)
-	absFOK := os.AbsF
-	errAbsUncompressed := "Abs error uncompressed"
-	nbAbsCalls := 0
-	os.AbsF = func(path string) (string, error) {
-		nbAbsCalls++
-		if nbAbsCalls > 2 {
-			return "", errors.New(errAbsUncompressed)
-		}
-		return absFOK(path)
-	}
-	collector.CaptureStderr = true
-	require.NoError(t, collector.Start())
+		sig, err := signer.NewSigner("./../../../test/signer/ec1.key")
+		require.NoError(t, err)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+		writeState := func(name string, txID uint64, alh [32]byte) string {
+			state := &schema.ImmutableState{Db: "bcktest", TxId: txID, TxHash: alh[:]}
 
-	require.NoError(t, cmd.Execute())
-	backupLog, err = collector.Stop()
-	require.NoError(t, err)
-	require.Contains(t, backupLog, errAbsUncompressed)
-	collector.CaptureStderr = false
-	os.AbsF = absFOK
-
-	// reset command
-	deleteBackupFiles(dbDir)
-	cl = commandline{}
-	cmd, _ = cl.NewCmd()
-	cmd.SetArgs([]string{
-		"backup",
-		fmt.Sprintf("--dbdir=%s", dbDir),
-	})
-	clb.backup(cmd)
+			signature, publicKey, err := sig.Sign(state.ToBytes())
+			require.NoError(t, err)
+			state.Signature = &schema.Signature{Signature: signature, PublicKey: publicKey}
 
-	// Getwd error
-	getwdFOK := os.GetwdF
-	errGetwd := errors.New("Getwd error")
-	os.GetwdF = func() (string, error) {
-		return "", errGetwd
-	}
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, errGetwd, msg)
-	}
+			js, err := (&jsonpb.Marshaler{}).MarshalToString(state)
+			require.NoError(t, err)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+			path := filepath.Join(dir, name)
+			require.NoError(t, ioutil.WriteFile(path, []byte(js), 0600))
+			return path
+		}
 
-	require.NoError(t, cmd.Execute())
-	os.GetwdF = getwdFOK
+		out.Reset()
+		cmd.SetArgs([]string{"verify-backup", fullBackup, incBackup})
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, out.String(), fmt.Sprintf("Backup verified: transactions 1 to %d", incManifest.TxID))
 
-	// Abs error
-	errAbs1 := errors.New("Abs error 1")
-	os.AbsF = func(path string) (string, error) {
-		return "", errAbs1
-	}
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, errAbs1, msg)
-	}
+		trustedState := writeState("state.json", state.TxId, schema.DigestFromProto(state.TxHash))
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+		out.Reset()
+		cmd.SetArgs([]string{"verify-backup", fullBackup, incBackup, "--state", trustedState, "--public-key", "./../../../test/signer/ec1.pub"})
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, out.String(), fmt.Sprintf("Backup matches the trusted state at transaction %d", state.TxId))
 
-	require.NoError(t, cmd.Execute())
+		// the trusted state may be older than the backups
+		olderState := writeState("older_state.json", fullManifest.TxID, fullManifest.Alh)
 
-	errAbs2 := errors.New("Abs error 2")
-	nbAbsCalls = 0
-	os.AbsF = func(path string) (string, error) {
-		nbAbsCalls++
-		if nbAbsCalls == 1 {
-			return absFOK(path)
-		}
-		return "", errAbs2
-	}
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, errAbs2, msg)
-	}
+		_, err = verifier.VerifyBackups([]string{fullBackup, incBackup}, nil, mustReadTrustedState(t, olderState, ""))
+		require.NoError(t, err)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+		_, err = verifier.VerifyBackups([]string{fullBackup}, nil, mustReadTrustedState(t, trustedState, ""))
+		require.ErrorIs(t, err, ErrBackupStateMismatch)
 
-	require.NoError(t, cmd.Execute())
+		tamperedState := writeState("tampered_state.json", fullManifest.TxID, incManifest.Alh)
 
-	os.AbsF = absFOK
+		_, err = verifier.VerifyBackups([]string{fullBackup, incBackup}, nil, mustReadTrustedState(t, tamperedState, ""))
+		require.ErrorIs(t, err, ErrBackupStateMismatch)
 
-	// RemoveAll error
-	removeAllFOK := os.RemoveAllF
-	errRemoveAll := "RemoveAll error"
-	os.RemoveAllF = func(path string) error {
-		return errors.New(errRemoveAll)
-	}
-	clb.onError = func(msg interface{}) {
-		require.Empty(t, msg)
-	}
-	collector.CaptureStderr = true
-	require.NoError(t, collector.Start())
+		_, err = verifier.ReadTrustedState(trustedState, "./../../../test/signer/ec3.pub")
+		require.ErrorIs(t, err, ErrInvalidStateSignature)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+		_, err = verifier.VerifyBackups([]string{incBackup}, nil, nil)
+		require.ErrorIs(t, err, ErrBackupChain)
+	})
 
-	require.NoError(t, cmd.Execute())
-	backupLog, err = collector.Stop()
-	require.NoError(t, err)
-	require.Contains(t, backupLog, errRemoveAll)
-	collector.CaptureStderr = false
-	os.RemoveAllF = removeAllFOK
-
-	// Abs error again
-	deleteBackupFiles(dbDir)
-	errAbs3 := "Abs error 3"
-	nbAbsCalls = 0
-	os.AbsF = func(path string) (string, error) {
-		nbAbsCalls++
-		if nbAbsCalls <= 2 {
-			return absFOK(path)
+	dbDir := filepath.Join(dir, "data")
+	require.NoError(t, os.MkdirAll(filepath.Join(dbDir, "restored"), 0700))
+
+	// the files of the replaced database are moved aside using a timestamp with a resolution of seconds
+	removeReplaced := func() {
+		replaced, err := filepath.Glob(filepath.Join(dbDir, "restored_bkp_before_restore_*"))
+		require.NoError(t, err)
+		for _, path := range replaced {
+			require.NoError(t, os.RemoveAll(path))
 		}
-		return "", errors.New(errAbs3)
 	}
-	collector.CaptureStderr = true
-	require.NoError(t, collector.Start())
-
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
 
-	require.NoError(t, cmd.Execute())
-	backupLog, err = collector.Stop()
-	require.NoError(t, err)
-	require.Contains(t, backupLog, errAbs3)
-	collector.CaptureStderr = false
-	os.AbsF = absFOK
+	requireRestored := func(manifest *store.BackupManifest) {
+		st, err := store.Open(filepath.Join(dbDir, "restored"), store.DefaultOptions())
+		require.NoError(t, err)
+		defer st.Close()
 
-	// canceled
-	nokReadFromTerminalYNF := func(def string) (selected string, err error) {
-		return "N", nil
-	}
-	termReaderMock.ReadFromTerminalYNF = nokReadFromTerminalYNF
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, "Canceled", msg.(error).Error())
+		txHolder := st.NewTxHolder()
+		require.NoError(t, st.ReadTx(st.TxCount(), txHolder))
+		require.Equal(t, manifest.TxID, st.TxCount())
+		require.Equal(t, manifest.Alh, txHolder.Header().Alh())
 	}
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	t.Run("restore", func(t *testing.T) {
+		out.Reset()
+		cmd.SetArgs([]string{"restore", "restored", fullBackup, incBackup, "--dbdir", dbDir, "--manual-stop-start"})
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, out.String(), fmt.Sprintf("Database restored restored up to transaction %d", incManifest.TxID))
+		require.Contains(t, out.String(), "The files of the previous database have been moved to")
 
-	require.NoError(t, cmd.Execute())
-	cmd.SetArgs([]string{
-		"backup",
-		fmt.Sprintf("--dbdir=%s", dbDir),
-		"--manual-stop-start",
+		requireRestored(incManifest)
+		removeReplaced()
 	})
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, "Canceled", msg.(error).Error())
-	}
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	t.Run("restore up to a transaction", func(t *testing.T) {
+		for _, txID := range []uint64{fullManifest.TxID - 1, fullManifest.TxID + 2} {
+			cmd.SetArgs([]string{"restore", "restored", fullBackup, incBackup,
+				"--dbdir", dbDir, "--up-to-tx", fmt.Sprint(txID), "--manual-stop-start"})
+			require.NoError(t, cmd.Execute())
 
-	require.NoError(t, cmd.Execute())
-	termReaderMock.ReadFromTerminalYNF = okReadFromTerminalYNF
-
-	// password read error
-	cl = commandline{}
-	cmd, _ = cl.NewCmd()
-	cmd.SetArgs([]string{
-		"backup",
-		fmt.Sprintf("--dbdir=%s", dbDir),
+			st, err := store.Open(filepath.Join(dbDir, "restored"), store.DefaultOptions())
+			require.NoError(t, err)
+			require.Equal(t, txID, st.TxCount())
+			require.NoError(t, st.Close())
+
+			removeReplaced()
+		}
 	})
-	pwReadErr := errors.New("password read error")
-	errPwReadF := func(msg string) ([]byte, error) {
-		return nil, pwReadErr
-	}
-	pwReaderMock.ReadF = errPwReadF
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, pwReadErr, msg)
-	}
-	clb.backup(cmd)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	t.Run("restore with invalid backups", func(t *testing.T) {
+		_, _, err := clb.offlineRestore("restored", []string{incBackup}, nil, dbDir, 0, true)
+		require.ErrorIs(t, err, ErrBackupChain)
 
-	require.NoError(t, cmd.Execute())
-	pwReaderMock.ReadF = nil
+		_, _, err = clb.offlineRestore("restored", []string{fullBackup, fullBackup}, nil, dbDir, 0, true)
+		require.ErrorIs(t, err, ErrBackupChain)
 
-	// login error
-	errLogin := errors.New("login error")
-	immuClientMock.LoginF = func(context.Context, []byte, []byte) (*schema.LoginResponse, error) {
-		return nil, errLogin
-	}
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, errLogin, msg)
-	}
+		_, _, err = clb.offlineRestore("restored", []string{fullBackup}, nil, dbDir, incManifest.TxID, true)
+		require.ErrorIs(t, err, ErrTxNotInBackups)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+		requireRestored(&store.BackupManifest{TxID: fullManifest.TxID + 2, Alh: readAlh(t, filepath.Join(dbDir, "restored"), fullManifest.TxID+2)})
+	})
+}
 
-	require.NoError(t, cmd.Execute())
-	immuClientMock.LoginF = loginFOK
+func TestEncryptedBackupAndRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immuadmin_encrypted_backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
 
-	cmd.SetArgs([]string{
-		"backup",
-		fmt.Sprintf("--dbdir=%s", "."),
-	})
-	expectedErrMsg :=
-		"cannot backup the current directory, please specify a subdirectory, for example ./data"
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, expectedErrMsg, msg.(error).Error())
-	}
+	clb, cmd := newTestCommandlineBck(t)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	err = clb.immuClient.CreateDatabase(clb.context, &schema.DatabaseSettings{DatabaseName: "enctest"})
+	require.NoError(t, err)
 
-	require.NoError(t, cmd.Execute())
+	udr, err := clb.immuClient.UseDatabase(clb.context, &schema.Database{DatabaseName: "enctest"})
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(clb.context, metadata.Pairs("authorization", udr.GetToken()))
 
-	// dbdir not exists
-	notADir := dbDir + "_not_a_dir"
-	cmd.SetArgs([]string{
-		"backup",
-		fmt.Sprintf("--dbdir=%s", notADir),
-	})
-	expectedErrMsg =
-		"stat backup_test_db_dir_not_a_dir: no such file or directory"
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, expectedErrMsg, msg.(error).Error())
+	for i := 0; i < 10; i++ {
+		_, err := clb.immuClient.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
 	}
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	passphraseFile := filepath.Join(dir, "passphrase")
+	require.NoError(t, ioutil.WriteFile(passphraseFile, []byte("backup passphrase\n"), 0600))
 
-	require.NoError(t, cmd.Execute())
+	wrongPassphraseFile := filepath.Join(dir, "wrong_passphrase")
+	require.NoError(t, ioutil.WriteFile(wrongPassphraseFile, []byte("wrong passphrase\n"), 0600))
 
-	// dbdir not a dir
-	_, err = stdos.Create(notADir)
+	provider, err := encryption.NewPassphraseFileKeyProvider(passphraseFile)
 	require.NoError(t, err)
-	defer stdos.Remove(notADir)
-	expectedErrMsg =
-		"backup_test_db_dir_not_a_dir is not a directory"
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, expectedErrMsg, msg.(error).Error())
-	}
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	wrongProvider, err := encryption.NewPassphraseFileKeyProvider(wrongPassphraseFile)
+	require.NoError(t, err)
 
-	require.NoError(t, cmd.Execute())
+	backup := filepath.Join(dir, "enc.backup.tar.gz.enc")
 
-	// daemon stop error
-	cmd.SetArgs([]string{
-		"backup",
-		fmt.Sprintf("--dbdir=%s", dbDir),
-	})
-	daemMock.StopF = func() (string, error) {
-		return "", errors.New("daemon stop error")
-	}
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, "error stopping immudb server: daemon stop error", msg.(error).Error())
-	}
-	clb.backup(cmd)
-
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	var out bytes.Buffer
+	cmd.SetOut(&out)
 
+	cmd.SetArgs([]string{"backup", "enctest", "-o", backup, "--encryption-passphrase-file", passphraseFile})
 	require.NoError(t, cmd.Execute())
-	daemMock.StopF = nil
-
-	// daemon start error
-	daemMock.StartF = func() (string, error) {
-		return "", errors.New("daemon start error")
-	}
-	clb.onError = func(msg interface{}) {
-	}
-	clb.backup(cmd)
-	collector.CaptureStderr = true
-	require.NoError(t, collector.Start())
+	require.Contains(t, out.String(), "Database backup created: "+backup)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
-
-	require.NoError(t, cmd.Execute())
-	backupLog, err = collector.Stop()
+	content, err := ioutil.ReadFile(backup)
 	require.NoError(t, err)
-	require.Contains(t, backupLog, "error restarting immudb server: daemon start error")
-	daemMock.StartF = nil
-	collector.CaptureStderr = false
-}
+	require.False(t, bytes.Contains(content, []byte("value1")))
 
-func TestRestore(t *testing.T) {
-	os := immuos.NewStandardOS()
-	clb, err := newCommandlineBck(os)
+	f, err := os.Open(backup)
 	require.NoError(t, err)
-	clb.options = client.DefaultOptions()
+	_, err = gzip.NewReader(f)
+	require.Error(t, err)
+	f.Close()
 
-	loginFOK := func(context.Context, []byte, []byte) (*schema.LoginResponse, error) {
-		return &schema.LoginResponse{Token: "token"}, nil
-	}
-	immuClientMock := &clienttest.ImmuClientMock{
-		LoginF: loginFOK,
-	}
-	clb.immuClient = immuClientMock
-	clb.newImmuClient = func(*client.Options) (client.ImmuClient, error) {
-		return immuClientMock, nil
-	}
-	immuClientMock.DisconnectF = func() error {
-		return nil
-	}
+	_, err = verifier.ReadBackupFile(backup, nil)
+	require.ErrorIs(t, err, ErrBackupEncrypted)
 
-	pwReaderMock := &clienttest.PasswordReaderMock{}
-	clb.passwordReader = pwReaderMock
-	clb.context = context.Background()
+	_, err = verifier.ReadBackupFile(backup, wrongProvider)
+	require.ErrorIs(t, err, encryption.ErrWrongKey)
 
-	hds := clienttest.DefaultHomedirServiceMock()
-	hds.FileExistsInUserHomeDirF = func(string) (bool, error) {
-		return true, nil
-	}
-	clb.ts = tokenservice.NewTokenService().WithHds(hds).WithTokenFileName("testTokenFile")
-
-	daemMock := defaultDaemonMock()
-	bckpr := &backupper{
-		daemon: daemMock,
-		os:     os,
-		copier: fs.NewStandardCopier(),
-		tarer:  fs.NewStandardTarer(),
-		ziper:  fs.NewStandardZiper(),
-	}
-	clb.Backupper = bckpr
+	manifest, err := verifier.ReadBackupFile(backup, provider)
+	require.NoError(t, err)
 
-	okReadFromTerminalYNF := func(def string) (selected string, err error) {
-		return "Y", nil
-	}
-	termReaderMock := &clienttest.TerminalReaderMock{
-		ReadFromTerminalYNF: okReadFromTerminalYNF,
-	}
-	clb.TerminalReader = termReaderMock
-
-	dbDirSrc := "restore_test_db_dir_src_bkp_1"
-	dbDirDst := "restore_test_db_dir_dst"
-	stdos.RemoveAll(dbDirSrc)
-	stdos.RemoveAll(dbDirDst)
-	deleteBackupFiles(dbDirDst)
-	defer stdos.RemoveAll(dbDirSrc)
-	defer stdos.RemoveAll(dbDirDst)
-	defer deleteBackupFiles(dbDirDst)
-	require.NoError(t, stdos.Mkdir(dbDirSrc, 0755))
-	ioutil.WriteFile(filepath.Join(dbDirSrc, server.IDENTIFIER_FNAME), []byte("src"), 0644)
-	require.NoError(t, stdos.Mkdir(dbDirDst, 0755))
-	ioutil.WriteFile(filepath.Join(dbDirDst, server.IDENTIFIER_FNAME), []byte("dst"), 0644)
-
-	clb.onError = func(msg interface{}) {
-		require.Empty(t, msg.(error))
-	}
+	_, err = verifier.VerifyBackups([]string{backup}, nil, nil)
+	require.ErrorIs(t, err, ErrBackupEncrypted)
 
-	backupFile := dbDirSrc + ".tar.gz"
-	stdos.Remove(backupFile)
-	require.NoError(t, bckpr.tarer.TarIt(dbDirSrc, backupFile))
-	defer stdos.Remove(backupFile)
-
-	backupFile2 := dbDirSrc + ".zip"
-	stdos.Remove(backupFile2)
-	require.NoError(t, bckpr.ziper.ZipIt(dbDirSrc, backupFile2, fs.ZipNoCompression))
-	defer stdos.Remove(backupFile2)
-
-	// from .tar.gz
-	cl := commandline{}
-	cmd, _ := cl.NewCmd()
-	cmd.SetArgs([]string{
-		"restore",
-		backupFile,
-		fmt.Sprintf("--dbdir=./%s", dbDirDst),
-	})
-	clb.restore(cmd)
+	out.Reset()
+	cmd.SetArgs([]string{"verify-backup", backup, "--encryption-passphrase-file", passphraseFile})
+	require.NoError(t, cmd.Execute())
+	require.Contains(t, out.String(), fmt.Sprintf("Backup verified: transactions 1 to %d", manifest.TxID))
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd := cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	dbDir := filepath.Join(dir, "data")
 
-	require.NoError(t, cmd.Execute())
-	deleteBackupFiles(dbDirDst)
+	_, _, err = clb.offlineRestore("restored", []string{backup}, nil, dbDir, 0, true)
+	require.ErrorIs(t, err, ErrBackupEncrypted)
 
-	// from .zip
-	cmd.SetArgs([]string{
-		"restore",
-		backupFile2,
-		fmt.Sprintf("--dbdir=./%s", dbDirDst),
-	})
-	clb.restore(cmd)
+	_, _, err = clb.offlineRestore("restored", []string{backup}, wrongProvider, dbDir, 0, true)
+	require.ErrorIs(t, err, encryption.ErrWrongKey)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	_, err = os.Stat(filepath.Join(dbDir, "restored"))
+	require.True(t, os.IsNotExist(err))
 
+	out.Reset()
+	cmd.SetArgs([]string{"restore", "restored", backup, "--dbdir", dbDir, "--manual-stop-start", "--encryption-passphrase-file", passphraseFile})
 	require.NoError(t, cmd.Execute())
+	require.Contains(t, out.String(), fmt.Sprintf("Database restored restored up to transaction %d", manifest.TxID))
 
-	// stat error
-	statFOK := os.StatF
-	errStat := errors.New("Stat error")
-	os.StatF = func(name string) (stdos.FileInfo, error) {
-		return nil, errStat
-	}
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, errStat, msg)
-	}
-
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	require.Equal(t, manifest.Alh, readAlh(t, filepath.Join(dbDir, "restored"), manifest.TxID))
 
-	require.NoError(t, cmd.Execute())
-	os.StatF = statFOK
+	t.Run("one key source at a time", func(t *testing.T) {
+		var quitMsg interface{}
+		clb.onError = func(msg interface{}) { quitMsg = msg }
+		defer func() { clb.onError = failOnQuit(t) }()
 
-	// daemon stop error
-	daemMock.StopF = func() (string, error) {
-		return "", errors.New("daemon stop error")
-	}
-	clb.onError = func(msg interface{}) {
-		require.Equal(t, "error stopping immudb server: daemon stop error", fmt.Sprintf("%v", msg))
-	}
+		cmd.SetArgs([]string{"verify-backup", backup, "--encryption-passphrase-file", passphraseFile, "--encryption-key-env", "IMMUDB_BACKUP_KEY"})
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, fmt.Sprint(quitMsg), "only one of")
+	})
+}
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+func TestRestoreArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immuadmin_archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
 
-	require.NoError(t, cmd.Execute())
-	daemMock.StopF = nil
+	archiveDir := filepath.Join(dir, "archive")
 
-	// Rename errors
-	renameFOK := os.RenameF
+	st, err := store.Open(filepath.Join(dir, "archived"), store.DefaultOptions().
+		WithFileSize(1024).
+		WithArchive(store.NewDirArchive(filepath.Join(archiveDir, "archived"), store.DefaultFileMode)).
+		WithArchiveFrequency(time.Hour))
+	require.NoError(t, err)
 
-	os.RenameF = func(oldpath, newpath string) error {
-		return errors.New("Rename error 1")
-	}
-	clb.onError = func(msg interface{}) {
-		require.Contains(t, msg.(error).Error(), "Rename error 1")
+	for i := 0; i < 50; i++ {
+		tx, err := st.NewWriteOnlyTx()
+		require.NoError(t, err)
+		require.NoError(t, tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%050d", i))))
+		_, err = tx.Commit()
+		require.NoError(t, err)
 	}
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	_, err = st.ArchiveSegments()
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
 
-	require.NoError(t, cmd.Execute())
+	clb, cmd := newTestCommandlineBck(t)
 
-	nbCalls := 0
-	os.RenameF = func(oldpath, newpath string) error {
-		nbCalls++
-		if nbCalls == 1 {
-			return nil
-		}
-		return errors.New("Rename error 2")
-	}
-	clb.onError = func(msg interface{}) {
-		require.Contains(t, msg.(error).Error(), "Rename error 2")
-	}
+	var out bytes.Buffer
+	cmd.SetOut(&out)
 
-	// remove ConfigChain method to avoid override options
-	cmd.PersistentPreRunE = nil
-	innercmd = cmd.Commands()[0]
-	innercmd.PersistentPreRunE = nil
+	dbDir := filepath.Join(dir, "data")
 
+	cmd.SetArgs([]string{"restore-archive", "archived", archiveDir, "--dbdir", dbDir, "--up-to-tx", "5", "--manual-stop-start"})
 	require.NoError(t, cmd.Execute())
+	require.Contains(t, out.String(), "Database archived restored up to transaction 5")
 
-	os.RenameF = renameFOK
-}
-
-func TestCommandlineBck_Register(t *testing.T) {
-	c := commandlineBck{}
-	cmd := c.Register(&cobra.Command{})
-	assert.IsType(t, &cobra.Command{}, cmd)
-}
-
-func TestNewCommandLineBck(t *testing.T) {
-	cml, err := newCommandlineBck(immuos.NewStandardOS())
-	assert.IsType(t, &commandlineBck{}, cml)
-	assert.Nil(t, err)
-}
+	restored, err := store.Open(filepath.Join(dbDir, "archived"), store.DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), restored.TxCount())
+	require.NoError(t, restored.Close())
 
-func TestCommandlineBck_ConfigChain(t *testing.T) {
-	cmd := &cobra.Command{}
-	c := commandlineBck{
-		commandline: commandline{config: helper.Config{Name: "test"}},
-	}
-	f := func(cmd *cobra.Command, args []string) error {
-		return nil
-	}
-	cmd.Flags().StringVar(&c.config.CfgFn, "config", "", "config file")
-	cc := c.ConfigChain(f)
-	err := cc(cmd, []string{})
-	assert.Nil(t, err)
+	_, _, err = clb.offlineArchiveRestore("archived", filepath.Join(archiveDir, "missing"), dbDir, 0, true)
+	require.Error(t, err)
 }
 
-func TestCommandlineBck_ConfigChainErr(t *testing.T) {
-	cmd := &cobra.Command{}
+func readAlh(t *testing.T, path string, txID uint64) [32]byte {
+	st, err := store.Open(path, store.DefaultOptions())
+	require.NoError(t, err)
+	defer st.Close()
 
-	c := commandlineBck{}
-	f := func(cmd *cobra.Command, args []string) error {
-		return nil
-	}
+	txHolder := st.NewTxHolder()
+	require.NoError(t, st.ReadTx(txID, txHolder))
 
-	cc := c.ConfigChain(f)
+	return txHolder.Header().Alh()
+}
 
-	err := cc(cmd, []string{})
-	assert.Error(t, err)
+func mustReadTrustedState(t *testing.T, path, publicKeyPath string) *schema.ImmutableState {
+	state, err := verifier.ReadTrustedState(path, publicKeyPath)
+	require.NoError(t, err)
+	return state
 }
-*/
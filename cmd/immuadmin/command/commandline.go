@@ -19,13 +19,16 @@ package immuadmin
 import (
 	"context"
 	"fmt"
-	"github.com/codenotary/immudb/pkg/client/homedir"
-	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"io"
+	"strings"
 
 	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/homedir"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
 	"github.com/codenotary/immudb/pkg/immuos"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // Commandline ...
@@ -73,8 +76,14 @@ func (cl *commandline) ConfigChain(post func(cmd *cobra.Command, args []string)
 		if err = cl.config.LoadConfig(cmd); err != nil {
 			return err
 		}
+		if err = c.ValidateOutputFormat(viper.GetString("output")); err != nil {
+			return err
+		}
 		// here all command line options and services need to be configured by options retrieved from viper
 		cl.options = Options()
+		if err = cl.loadCredentials(); err != nil {
+			return err
+		}
 		cl.ts = tokenservice.NewFileTokenService().WithHds(homedir.NewHomedirService()).WithTokenFileName(cl.options.TokenFileName)
 		if post != nil {
 			return post(cmd, args)
@@ -85,12 +94,20 @@ func (cl *commandline) ConfigChain(post func(cmd *cobra.Command, args []string)
 
 func (cl *commandline) Register(rootCmd *cobra.Command) *cobra.Command {
 	cl.user(rootCmd)
+	cl.sessions(rootCmd)
+	cl.runtimeConfig(rootCmd)
 	cl.login(rootCmd)
 	cl.logout(rootCmd)
 	cl.status(rootCmd)
 	cl.stats(rootCmd)
 	cl.serverConfig(rootCmd)
 	cl.database(rootCmd)
+	cl.replication(rootCmd)
+	cl.backupSchedule(rootCmd)
+	cl.verificationSchedule(rootCmd)
+	cl.retentionPolicy(rootCmd)
+	cl.auditReport(rootCmd)
+	cl.copyDatabase(rootCmd)
 	return rootCmd
 }
 
@@ -107,16 +124,34 @@ func (cl *commandline) disconnect(cmd *cobra.Command, args []string) {
 	}
 }
 
+// connect connects to the server, logging in when credentials are provided by the environment
+// or the credentials file so that no prior login is needed
 func (cl *commandline) connect(cmd *cobra.Command, args []string) (err error) {
+	if err = cl.dial(cmd, args); err != nil {
+		return err
+	}
+	if !cl.hasCredentials() {
+		return nil
+	}
+	if _, err = cl.loginClient(cl.context, []byte(cl.options.Username), []byte(cl.options.Password)); err != nil {
+		cl.quit(err)
+	}
+	return
+}
+
+func (cl *commandline) dial(cmd *cobra.Command, args []string) (err error) {
 	if cl.immuClient, err = client.NewImmuClient(cl.options); err != nil {
 		cl.quit(err)
 	}
-	cl.immuClient.WithTokenService(tokenservice.NewFileTokenService().WithTokenFileName("token_admin"))
+	cl.immuClient.WithTokenService(tokenservice.NewFileTokenService().WithTokenFileName(cl.options.TokenFileName))
 	return
 
 }
 
 func (cl *commandline) checkLoggedIn(cmd *cobra.Command, args []string) (err error) {
+	if cl.hasCredentials() {
+		return nil
+	}
 	possiblyLoggedIn, err2 := cl.ts.IsTokenPresent()
 	if err2 != nil {
 		fmt.Println("error checking if token file exists:", err2)
@@ -136,3 +171,22 @@ func (cl *commandline) checkLoggedInAndConnect(cmd *cobra.Command, args []string
 	}
 	return
 }
+
+// printResult writes the result of a command, marshalled when a machine-readable output format is requested
+// and using printText otherwise
+func (cl *commandline) printResult(w io.Writer, result interface{}, printText func()) error {
+	format := viper.GetString("output")
+	if !c.IsMachineReadable(format) {
+		printText()
+		return nil
+	}
+	return c.PrintOutput(w, format, result)
+}
+
+// printMessage writes the outcome of a command having no result other than a message
+func (cl *commandline) printMessage(w io.Writer, format string, a ...interface{}) error {
+	msg := fmt.Sprintf(format, a...)
+	return cl.printResult(w, &c.Message{Message: strings.TrimSpace(msg)}, func() {
+		fmt.Fprint(w, msg)
+	})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/dbcopy"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+)
+
+func (cl *commandline) copyDatabase(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:   "copydb <source_db> <target_db>",
+		Short: "Copy a database into a new database of another server",
+		Long: "Stream a database of the server immuadmin is logged in to into a new database of the destination server, " +
+			"authenticating with the given user on it. The copied state is proven against the source server " +
+			"before the copy is completed on the destination one, no copy is created when the proof fails.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "copydb mydb mydb --dest-address staging.example.com [--dest-port 3322] [--dest-username immudb]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, err := cmd.Flags().GetString("dest-address")
+			if err != nil {
+				return err
+			}
+			port, err := cmd.Flags().GetInt("dest-port")
+			if err != nil {
+				return err
+			}
+			username, err := cmd.Flags().GetString("dest-username")
+			if err != nil {
+				return err
+			}
+
+			udr, err := cl.immuClient.UseDatabase(cl.context, &schema.Database{DatabaseName: args[0]})
+			if err != nil {
+				return err
+			}
+			srcCtx := metadata.NewOutgoingContext(cl.context, metadata.Pairs("authorization", udr.GetToken()))
+
+			pass, err := cl.passwordReader.Read(fmt.Sprintf("Password of %s on the destination server:", username))
+			if err != nil {
+				return err
+			}
+
+			dst, err := client.NewImmuClient(client.DefaultOptions().WithAddress(address).WithPort(port))
+			if err != nil {
+				return err
+			}
+			defer dst.Disconnect()
+
+			// the token of the destination server must not replace the one of the source server
+			dst.WithTokenService(tokenservice.NewInmemoryTokenService())
+
+			lr, err := dst.Login(cl.context, []byte(username), pass)
+			if err != nil {
+				return err
+			}
+			dstCtx := metadata.NewOutgoingContext(cl.context, metadata.Pairs("authorization", lr.GetToken()))
+			defer dst.Logout(dstCtx)
+
+			state, err := dbcopy.Copy(srcCtx, cl.immuClient, dstCtx, dst, args[1])
+			if err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "database '%s' successfully copied into '%s' at %s:%d up to transaction %d\n",
+				args[0], args[1], address, port, state.TxId)
+		},
+		Args: cobra.ExactArgs(2),
+	}
+	ccmd.Flags().String("dest-address", "", "address of the destination server")
+	ccmd.Flags().Int("dest-port", client.DefaultOptions().Port, "port of the destination server")
+	ccmd.Flags().String("dest-username", "immudb", "user creating the database on the destination server, it must be a system admin")
+	ccmd.MarkFlagRequired("dest-address")
+
+	cmd.AddCommand(ccmd)
+}
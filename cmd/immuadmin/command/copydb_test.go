@@ -0,0 +1,125 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/clienttest"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCopyDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immuadmin_copydb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// destination server
+	dstOpts := server.DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false)
+
+	dstServer := server.DefaultServer().WithOptions(dstOpts).(*server.ImmuServer)
+
+	err = dstServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		dstServer.Start()
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	defer dstServer.Stop()
+
+	dstPort := dstServer.Listener.Addr().(*net.TCPAddr).Port
+
+	cl := getCmdline()
+	require.NotNil(t, cl)
+	cl.onError = failOnQuit(t)
+	cl.passwordReader = &clienttest.PasswordReaderMock{
+		ReadF: func(msg string) ([]byte, error) {
+			return []byte(auth.SysAdminPassword), nil
+		},
+	}
+
+	err = cl.immuClient.CreateDatabase(cl.context, &schema.DatabaseSettings{DatabaseName: "copysrc"})
+	require.NoError(t, err)
+
+	udr, err := cl.immuClient.UseDatabase(cl.context, &schema.Database{DatabaseName: "copysrc"})
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(cl.context, metadata.Pairs("authorization", udr.GetToken()))
+
+	for i := 0; i < 10; i++ {
+		_, err = cl.immuClient.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	cmd := &cobra.Command{}
+	cl.copyDatabase(cmd)
+
+	// the client of the test server is used instead of the configured one
+	for _, ccmd := range cmd.Commands() {
+		ccmd.PersistentPreRunE = nil
+		ccmd.PersistentPostRun = nil
+	}
+
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+
+	cmd.SetArgs([]string{"copydb", "copysrc", "copydst", "--dest-address", "127.0.0.1", "--dest-port", fmt.Sprint(dstPort)})
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "database 'copysrc' successfully copied into 'copydst'")
+
+	cmd.SetArgs([]string{"copydb", "copysrc", "copydst", "--dest-address", "127.0.0.1", "--dest-port", fmt.Sprint(dstPort)})
+	err = cmd.Execute()
+	require.Error(t, err)
+
+	dst, err := client.NewImmuClient(client.DefaultOptions().WithPort(dstPort).WithDir(dir))
+	require.NoError(t, err)
+	defer dst.Disconnect()
+
+	lr, err := dst.Login(context.Background(), []byte(auth.SysAdminUsername), []byte(auth.SysAdminPassword))
+	require.NoError(t, err)
+	dstCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.GetToken()))
+
+	udr, err = dst.UseDatabase(dstCtx, &schema.Database{DatabaseName: "copydst"})
+	require.NoError(t, err)
+	dstCtx = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", udr.GetToken()))
+
+	entry, err := dst.Get(dstCtx, []byte("key9"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value9"), entry.Value)
+}
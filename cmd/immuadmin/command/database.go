@@ -18,22 +18,24 @@ package immuadmin
 
 import (
 	"fmt"
+	"io"
+	"time"
 
 	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	immuErrors "github.com/codenotary/immudb/pkg/client/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 func (cl *commandline) database(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
 		Use:     "database",
 		Short:   "Issue all database commands",
-		Aliases: []string{"d"},
+		Aliases: []string{"d", "db"},
 		//PersistentPreRunE: cl.ConfigChain(cl.connect),
 		PersistentPostRun: cl.disconnect,
-		ValidArgs:         []string{"list", "create", "update", "use", "clean"},
+		ValidArgs:         []string{"list", "create", "update", "use", "clean", "rebuild-index", "usage"},
 	}
 
 	ccd := &cobra.Command{
@@ -47,21 +49,22 @@ func (cl *commandline) database(cmd *cobra.Command) {
 			if err != nil {
 				return err
 			}
-			c.PrintTable(
-				cmd.OutOrStdout(),
-				[]string{"Database Name"},
-				len(resp.Databases),
-				func(i int) []string {
-					row := make([]string, 1)
-					if cl.options.CurrentDatabase == resp.Databases[i].DatabaseName {
-						row[0] += fmt.Sprintf("*")
-					}
-					row[0] += fmt.Sprintf("%s", resp.Databases[i].DatabaseName)
-					return row
-				},
-				fmt.Sprintf("%d database(s)", len(resp.Databases)),
-			)
-			return nil
+			return cl.printResult(cmd.OutOrStdout(), resp, func() {
+				c.PrintTable(
+					cmd.OutOrStdout(),
+					[]string{"Database Name"},
+					len(resp.Databases),
+					func(i int) []string {
+						row := make([]string, 1)
+						if cl.options.CurrentDatabase == resp.Databases[i].DatabaseName {
+							row[0] += fmt.Sprintf("*")
+						}
+						row[0] += fmt.Sprintf("%s", resp.Databases[i].DatabaseName)
+						return row
+					},
+					fmt.Sprintf("%d database(s)", len(resp.Databases)),
+				)
+			})
 		},
 		Args: cobra.ExactArgs(0),
 	}
@@ -78,24 +81,111 @@ func (cl *commandline) database(cmd *cobra.Command) {
 				return err
 			}
 
+			settings.SeedBackups, err = cmd.Flags().GetStringSlice("replication-seed-backup")
+			if err != nil {
+				return err
+			}
+
 			if err := cl.immuClient.CreateDatabase(cl.context, settings); err != nil {
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(),
+			return cl.printMessage(cmd.OutOrStdout(),
 				"database '%s' {replica: %v, exclude-commit-time: %v} successfully created\n", args[0], settings.Replica, settings.ExcludeCommitTime)
-			return nil
 		},
 		Args: cobra.ExactArgs(1),
 	}
 	cc.Flags().Bool("exclude-commit-time", false,
 		"do not include server-side timestamps in commit checksums, useful when reproducibility is a desired feature")
+	cc.Flags().String("worm-retain-until", "",
+		"enable the WORM compliance mode until the given RFC3339 date, i.e. 2030-01-01T00:00:00Z. "+
+			"Data can not be deleted, erased, truncated, reclaimed nor expire before it and the date can only be extended")
+	cc.Flags().Duration("gc-frequency", 0,
+		"interval between collections of the space used by superseded values, i.e. 1h (value garbage collection is disabled when not set)")
+	cc.Flags().Duration("gc-safety-window", 0,
+		"values superseded within the safety window are kept by garbage collection, i.e. 24h")
 	cc.Flags().Bool("replication-enabled", false, "set database as a replica")
 	cc.Flags().String("replication-master-database", "", "set master database to be replicated")
 	cc.Flags().String("replication-master-address", "127.0.0.1", "set master address")
 	cc.Flags().Uint32("replication-master-port", 3322, "set master port")
 	cc.Flags().String("replication-follower-username", "", "set username used for replication")
 	cc.Flags().String("replication-follower-password", "", "set password used for replication")
+	cc.Flags().StringSlice("replication-seed-backup", nil,
+		"backup file, residing on the server machine, the replica is seeded from before replicating the following transactions. "+
+			"Repeat it to seed the replica from a full backup followed by the incremental backups taken after it")
+
+	ccl := &cobra.Command{
+		Use:               "clone",
+		Short:             "Create a new database as a copy of an existing one",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "clone {source_database_name} {database_name} [--at-tx {tx_id}]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			atTx, err := cmd.Flags().GetUint64("at-tx")
+			if err != nil {
+				return err
+			}
+
+			req := &schema.CloneDatabaseRequest{
+				SourceDatabase: args[0],
+				TargetDatabase: args[1],
+				AtTx:           atTx,
+			}
+
+			if err := cl.immuClient.CloneDatabase(cl.context, req); err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "database '%s' successfully cloned into '%s'\n", args[0], args[1])
+		},
+		Args: cobra.ExactArgs(2),
+	}
+	ccl.Flags().Uint64("at-tx", 0, "id of the last transaction included in the clone, all the transactions are cloned when not set")
+
+	crs := &cobra.Command{
+		Use:   "restore-selected",
+		Short: "Restore only selected key prefixes and SQL tables from backups into a new or existing database",
+		Long: "Restore the entries under the given key prefixes and the rows of the given SQL tables from backup files " +
+			"residing on the server machine. Incremental backups are applied in the provided order. The database is created " +
+			"when it doesn't exist, existing entries and rows are overwritten.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "restore-selected {database_name} {backup_file} [{incremental_backup_file}...] [--prefix {key_prefix}...] [--table {table_name}...]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prefixes, err := cmd.Flags().GetStringArray("prefix")
+			if err != nil {
+				return err
+			}
+			tables, err := cmd.Flags().GetStringSlice("table")
+			if err != nil {
+				return err
+			}
+			if len(prefixes) == 0 && len(tables) == 0 {
+				return fmt.Errorf("at least a key prefix or a table to be restored must be provided")
+			}
+
+			req := &schema.SelectiveRestoreRequest{
+				Database: args[0],
+				Backups:  args[1:],
+				Tables:   tables,
+			}
+			for _, prefix := range prefixes {
+				req.Prefixes = append(req.Prefixes, []byte(prefix))
+			}
+
+			res, err := cl.immuClient.SelectiveRestore(cl.context, req)
+			if err != nil {
+				return err
+			}
+
+			return cl.printResult(cmd.OutOrStdout(), res, func() {
+				fmt.Fprintf(cmd.OutOrStdout(), "%d entries and %d rows successfully restored into database '%s'\n", res.Entries, res.Rows, args[0])
+			})
+		},
+		Args: cobra.MinimumNArgs(2),
+	}
+	crs.Flags().StringArray("prefix", nil, "prefix of the keys to be restored, it can be repeated")
+	crs.Flags().StringSlice("table", nil, "name of the SQL table to be restored, it can be repeated")
 
 	cu := &cobra.Command{
 		Use:               "update",
@@ -113,14 +203,20 @@ func (cl *commandline) database(cmd *cobra.Command) {
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(),
+			return cl.printMessage(cmd.OutOrStdout(),
 				"database '%s' {replica: %v, exclude-commit-time: %v} successfully updated\n", args[0], settings.Replica, settings.ExcludeCommitTime)
-			return nil
 		},
 		Args: cobra.ExactArgs(1),
 	}
 	cu.Flags().Bool("exclude-commit-time", false,
 		"do not include server-side timestamps in commit checksums, useful when reproducibility is a desired feature")
+	cu.Flags().String("worm-retain-until", "",
+		"enable the WORM compliance mode until the given RFC3339 date, i.e. 2030-01-01T00:00:00Z. "+
+			"Data can not be deleted, erased, truncated, reclaimed nor expire before it and the date can only be extended")
+	cu.Flags().Duration("gc-frequency", 0,
+		"interval between collections of the space used by superseded values, i.e. 1h (value garbage collection is disabled when not set)")
+	cu.Flags().Duration("gc-safety-window", 0,
+		"values superseded within the safety window are kept by garbage collection, i.e. 24h")
 	cu.Flags().Bool("replication-enabled", false, "set database as a replica")
 	cu.Flags().String("replication-master-database", "", "set master database to be replicated")
 	cu.Flags().String("replication-master-address", "127.0.0.1", "set master address")
@@ -150,48 +246,321 @@ func (cl *commandline) database(cmd *cobra.Command) {
 				return err
 			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), "Now using %s\n", args[0])
-			return nil
+			return cl.printMessage(cmd.OutOrStdout(), "Now using %s\n", args[0])
 		},
 		Args: cobra.MaximumNArgs(2),
 	}
 
 	ccc := &cobra.Command{
-		Use:               "compact command",
-		Short:             "Compact database index",
-		Example:           "compact",
-		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		Use:   "compact",
+		Short: "Compact database index and value logs",
+		Long: "Compact the index of a database, reclaiming the space taken by the nodes no longer reachable " +
+			"from its latest snapshot, then reclaim the space of the values superseded by newer transactions. " +
+			"Values superseded within the garbage collection safety window of the database and legally held ones are kept. " +
+			"The space the index compaction would reclaim is estimated first, which is all that is done on dry-runs. " +
+			"Compactions can be scheduled at a time of the day with low load, the command waits until then before connecting. " +
+			"The index of the selected database is compacted when no database is provided.",
+		Example:           "compact [{database_name}] [--dry-run] [--at 03:00]",
+		PersistentPreRunE: cl.ConfigChain(cl.waitAndConnect),
 		PersistentPostRun: cl.disconnect,
 		ValidArgs:         []string{"databasename"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := cl.immuClient.CompactIndex(cl.context, &emptypb.Empty{})
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+
+			var database string
+
+			if len(args) == 1 {
+				database = args[0]
+			} else {
+				state, err := cl.immuClient.CurrentState(cl.context)
+				if err != nil {
+					return err
+				}
+				database = state.Db
+			}
+
+			return cl.compactDatabase(cmd.OutOrStdout(), database, dryRun)
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+	ccc.Flags().Bool("dry-run", false, "only estimate the space the compaction would reclaim")
+	ccc.Flags().String("at", "", "time of the day (HH:MM, local time) the index compaction is started at, i.e. during off-hours")
+
+	cri := &cobra.Command{
+		Use:               "rebuild-index",
+		Short:             "Rebuild database index from the transaction log",
+		Example:           "rebuild-index",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var printErr error
+
+			err := cl.immuClient.RebuildIndex(cl.context, func(p *schema.IndexRebuildProgress) {
+				if printErr != nil {
+					return
+				}
+				printErr = cl.printResult(cmd.OutOrStdout(), p, func() {
+					fmt.Fprintf(cmd.OutOrStdout(), "%d/%d transactions indexed\n", p.IndexedTx, p.TargetTx)
+				})
+			})
 			if err != nil {
 				cl.quit(err)
 			}
 			if err != nil {
 				return err
 			}
+			if printErr != nil {
+				return printErr
+			}
 
-			fmt.Fprintf(cmd.OutOrStdout(), "Database index successfully compacted\n")
-			return nil
+			return cl.printMessage(cmd.OutOrStdout(), "Database index successfully rebuilt\n")
 		},
 		Args: cobra.ExactArgs(0),
 	}
 
+	cus := &cobra.Command{
+		Use:               "usage",
+		Short:             "Show the bytes on disk, the number of transactions and entries and the growth rate of databases",
+		Long:              "Show the bytes on disk of the transaction log, the value logs and the index of databases, along with the number of transactions and entries and their average growth per day since the first transaction. Every database is reported when none is specified.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "usage [database_name]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var db string
+			if len(args) > 0 {
+				db = args[0]
+			}
+
+			resp, err := cl.immuClient.DatabaseUsage(cl.context, db)
+			if err != nil {
+				return err
+			}
+
+			return cl.printResult(cmd.OutOrStdout(), resp, func() {
+				printDatabaseUsage(cmd.OutOrStdout(), resp)
+			})
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+
 	ccmd.AddCommand(ccc)
+	ccmd.AddCommand(cri)
 	ccmd.AddCommand(ccu)
 	ccmd.AddCommand(ccd)
 	ccmd.AddCommand(cc)
+	ccmd.AddCommand(ccl)
+	ccmd.AddCommand(crs)
 	ccmd.AddCommand(cu)
+	ccmd.AddCommand(cus)
 	cmd.AddCommand(ccmd)
 }
 
+func printDatabaseUsage(w io.Writer, resp *schema.DatabaseUsageList) {
+	c.PrintTable(
+		w,
+		[]string{"Database", "Tx Log", "Value Logs", "Index", "Other", "Total", "Txs", "Entries", "Growth/Day"},
+		len(resp.Usages),
+		func(i int) []string {
+			u := resp.Usages[i]
+
+			growth := "-"
+			if u.Since > 0 {
+				growth = fmt.Sprintf("%s, %.1f txs, %.1f entries", formatBytes(int64(u.BytesPerDay)), u.TxsPerDay, u.EntriesPerDay)
+			}
+
+			return []string{
+				u.Database,
+				formatBytes(u.TxLogSize),
+				formatBytes(u.VLogSize),
+				formatBytes(u.IndexSize),
+				formatBytes(u.OtherSize),
+				formatBytes(u.TotalSize),
+				fmt.Sprintf("%d", u.TxCount),
+				fmt.Sprintf("%d", u.EntryCount),
+				growth,
+			}
+		},
+		fmt.Sprintf("%d database(s)", len(resp.Usages)),
+	)
+}
+
+// compactDatabase compacts the index of a database printing the progress of the compaction,
+// only the space the compaction would reclaim is printed on dry-runs
+func (cl *commandline) compactDatabase(w io.Writer, database string, dryRun bool) error {
+	var estimate *schema.CompactionProgress
+	var printErr error
+
+	err := cl.immuClient.CompactDatabase(cl.context, &schema.CompactDatabaseRequest{Database: database, DryRun: dryRun}, func(p *schema.CompactionProgress) {
+		if printErr != nil {
+			return
+		}
+
+		if estimate == nil {
+			estimate = p
+			printErr = cl.printResult(w, p, func() { printCompactionEstimate(w, database, p) })
+			return
+		}
+
+		printErr = cl.printResult(w, p, func() {
+			percentage := float64(100)
+			if p.CompactedIndexSize > 0 && p.DumpedBytes < p.CompactedIndexSize {
+				percentage = float64(p.DumpedBytes) * 100 / float64(p.CompactedIndexSize)
+			}
+
+			fmt.Fprintf(w, "%s/%s of the compacted index written (%.1f%%)\n",
+				formatBytes(p.DumpedBytes), formatBytes(p.CompactedIndexSize), percentage)
+		})
+	})
+	if printErr != nil {
+		return printErr
+	}
+	if err != nil && errorCode(err) != immuErrors.CodObjectNotInPrerequisiteState {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+
+	if err != nil {
+		err = cl.printMessage(w, "compaction of the index of database '%s' not needed yet\n", database)
+	} else {
+		err = cl.printMessage(w, "Database index successfully compacted\n")
+	}
+	if err != nil {
+		return err
+	}
+
+	return cl.collectGarbage(w, database)
+}
+
+// collectGarbage reclaims the space used by the values of a database superseded by newer transactions
+func (cl *commandline) collectGarbage(w io.Writer, database string) error {
+	res, err := cl.immuClient.CollectGarbage(cl.context, &schema.CollectGarbageRequest{Database: database})
+	if err != nil {
+		switch errorCode(err) {
+		case immuErrors.CodFeatureNotSupported:
+			return cl.printMessage(w, "values of database '%s' can not be garbage collected\n", database)
+		case immuErrors.CodObjectNotInPrerequisiteState:
+			return cl.printMessage(w, "values of database '%s' not garbage collected: %s\n", database, err.Error())
+		}
+		return err
+	}
+
+	return cl.printResult(w, res, func() {
+		fmt.Fprintf(w, "%s of superseded values reclaimed\n", formatBytes(res.ReclaimedBytes))
+	})
+}
+
+// errorCode returns the immudb error code of the errors returned by the client, if any
+func errorCode(err error) immuErrors.Code {
+	if immuErr, ok := err.(immuErrors.ImmuError); ok {
+		return immuErr.Code()
+	}
+	return ""
+}
+
+func printCompactionEstimate(w io.Writer, database string, p *schema.CompactionProgress) {
+	reclaimed := p.IndexSize - p.CompactedIndexSize
+	if reclaimed < 0 {
+		reclaimed = 0
+	}
+
+	percentage := float64(0)
+	if p.IndexSize > 0 {
+		percentage = float64(reclaimed) * 100 / float64(p.IndexSize)
+	}
+
+	fmt.Fprintf(w, "index of database '%s' takes %s, %s once compacted: %s (%.1f%%) to be reclaimed\n",
+		database, formatBytes(p.IndexSize), formatBytes(p.CompactedIndexSize), formatBytes(reclaimed), percentage)
+
+	threshold := "reached"
+	if !p.ThresholdReached {
+		threshold = "not reached, the index is not compacted"
+	}
+
+	fmt.Fprintf(w, "%d snapshot(s) stored since the last compaction, compaction threshold %s\n", p.Snapshots, threshold)
+}
+
+// waitAndConnect waits until the time of the day set with --at, if any, before connecting to the server,
+// so that no connection is kept idle until then
+func (cl *commandline) waitAndConnect(cmd *cobra.Command, args []string) error {
+	at, err := cmd.Flags().GetString("at")
+	if err != nil {
+		return err
+	}
+
+	if at != "" {
+		wait, err := durationUntil(at, time.Now())
+		if err != nil {
+			return err
+		}
+
+		database := "selected"
+		if len(args) == 1 {
+			database = fmt.Sprintf("'%s'", args[0])
+		}
+
+		err = cl.printMessage(cmd.OutOrStdout(), "index compaction of %s database scheduled at %s\n",
+			database, time.Now().Add(wait).Format("2006-01-02 15:04"))
+		if err != nil {
+			return err
+		}
+
+		time.Sleep(wait)
+	}
+
+	return cl.connect(cmd, args)
+}
+
+// durationUntil returns the time to wait from now until the next occurrence of a time of the day in HH:MM format
+func durationUntil(timeOfDay string, now time.Time) (time.Duration, error) {
+	t, err := time.Parse("15:04", timeOfDay)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of the day '%s', expected HH:MM", timeOfDay)
+	}
+
+	at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+
+	return at.Sub(now), nil
+}
+
 func prepareDatabaseSettings(db string, flags *pflag.FlagSet) (*schema.DatabaseSettings, error) {
 	excludeCommitTime, err := flags.GetBool("exclude-commit-time")
 	if err != nil {
 		return nil, err
 	}
 
+	wormRetainUntil, err := flags.GetString("worm-retain-until")
+	if err != nil {
+		return nil, err
+	}
+
+	var wormRetainUntilTs int64
+
+	if wormRetainUntil != "" {
+		t, err := time.Parse(time.RFC3339, wormRetainUntil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WORM retention date '%s': %v", wormRetainUntil, err)
+		}
+		wormRetainUntilTs = t.Unix()
+	}
+
+	gcFrequency, err := flags.GetDuration("gc-frequency")
+	if err != nil {
+		return nil, err
+	}
+
+	gcSafetyWindow, err := flags.GetDuration("gc-safety-window")
+	if err != nil {
+		return nil, err
+	}
+
 	replicationEnabled, err := flags.GetBool("replication-enabled")
 	if err != nil {
 		return nil, err
@@ -201,6 +570,9 @@ func prepareDatabaseSettings(db string, flags *pflag.FlagSet) (*schema.DatabaseS
 		return &schema.DatabaseSettings{
 			DatabaseName:      db,
 			ExcludeCommitTime: excludeCommitTime,
+			WormRetainUntil:   wormRetainUntilTs,
+			GcFrequency:       uint64(gcFrequency.Milliseconds()),
+			GcSafetyWindow:    uint64(gcSafetyWindow.Milliseconds()),
 		}, nil
 	}
 
@@ -238,5 +610,8 @@ func prepareDatabaseSettings(db string, flags *pflag.FlagSet) (*schema.DatabaseS
 		MasterPort:        masterPort,
 		FollowerUsername:  followerUsername,
 		FollowerPassword:  followerPassword,
+		WormRetainUntil:   wormRetainUntilTs,
+		GcFrequency:       uint64(gcFrequency.Milliseconds()),
+		GcSafetyWindow:    uint64(gcSafetyWindow.Milliseconds()),
 	}, nil
 }
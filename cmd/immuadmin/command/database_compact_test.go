@@ -0,0 +1,189 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/tbtree"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/clienttest"
+	immuErrors "github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurationUntil(t *testing.T) {
+	now := time.Date(2022, 3, 1, 22, 30, 0, 0, time.UTC)
+
+	wait, err := durationUntil("23:00", now)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, wait)
+
+	// next day when the time of the day already passed
+	wait, err = durationUntil("03:15", now)
+	require.NoError(t, err)
+	require.Equal(t, 4*time.Hour+45*time.Minute, wait)
+
+	wait, err = durationUntil("22:30", now)
+	require.NoError(t, err)
+	require.Equal(t, 24*time.Hour, wait)
+
+	_, err = durationUntil("3am", now)
+	require.Error(t, err)
+}
+
+func TestPrintCompactionEstimate(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	printCompactionEstimate(b, "db1", &schema.CompactionProgress{
+		IndexSize:          4096,
+		CompactedIndexSize: 1024,
+		Snapshots:          3,
+		ThresholdReached:   true,
+	})
+
+	require.Equal(t,
+		"index of database 'db1' takes 4.0 KB, 1.0 KB once compacted: 3.0 KB (75.0%) to be reclaimed\n"+
+			"3 snapshot(s) stored since the last compaction, compaction threshold reached\n",
+		b.String())
+}
+
+type compactionClientMock struct {
+	*clienttest.ImmuClientMock
+	progress []*schema.CompactionProgress
+	err      error
+	gcCalled bool
+	gcRes    *schema.CollectGarbageResponse
+	gcErr    error
+}
+
+func (m *compactionClientMock) CompactDatabase(ctx context.Context, req *schema.CompactDatabaseRequest, progress func(*schema.CompactionProgress)) error {
+	for _, p := range m.progress {
+		progress(p)
+	}
+	return m.err
+}
+
+func (m *compactionClientMock) CollectGarbage(ctx context.Context, req *schema.CollectGarbageRequest) (*schema.CollectGarbageResponse, error) {
+	m.gcCalled = true
+	return m.gcRes, m.gcErr
+}
+
+func TestCompactDatabase(t *testing.T) {
+	notReached := &schema.CompactionProgress{IndexSize: 4096, CompactedIndexSize: 4096, Snapshots: 1}
+
+	t.Run("threshold not reached", func(t *testing.T) {
+		cl := &commandline{
+			context: context.Background(),
+			immuClient: &compactionClientMock{
+				progress: []*schema.CompactionProgress{notReached},
+				err: immuErrors.New(tbtree.ErrCompactionThresholdNotReached.Error()).
+					WithCode(immuErrors.CodObjectNotInPrerequisiteState),
+				gcRes: &schema.CollectGarbageResponse{ReclaimedBytes: 2048},
+			},
+		}
+
+		b := &bytes.Buffer{}
+		err := cl.compactDatabase(b, "db1", false)
+		require.NoError(t, err)
+		require.Contains(t, b.String(), "compaction of the index of database 'db1' not needed yet\n")
+		require.Contains(t, b.String(), "2.0 KB of superseded values reclaimed\n")
+	})
+
+	t.Run("threshold message is not enough", func(t *testing.T) {
+		cl := &commandline{
+			context: context.Background(),
+			immuClient: &compactionClientMock{
+				progress: []*schema.CompactionProgress{notReached},
+				err:      errors.New("rpc error: code = Unknown desc = " + tbtree.ErrCompactionThresholdNotReached.Error()),
+			},
+		}
+
+		err := cl.compactDatabase(&bytes.Buffer{}, "db1", false)
+		require.Error(t, err)
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		m := &compactionClientMock{progress: []*schema.CompactionProgress{notReached}}
+		cl := &commandline{context: context.Background(), immuClient: m}
+
+		b := &bytes.Buffer{}
+		err := cl.compactDatabase(b, "db1", true)
+		require.NoError(t, err)
+		require.Contains(t, b.String(), "compaction threshold not reached")
+		require.False(t, m.gcCalled)
+	})
+
+	t.Run("errors are returned", func(t *testing.T) {
+		rpcErr := errors.New("rpc error: code = Unavailable desc = connection closed")
+
+		cl := &commandline{
+			context: context.Background(),
+			immuClient: &compactionClientMock{
+				progress: []*schema.CompactionProgress{notReached},
+				err:      rpcErr,
+			},
+		}
+
+		b := &bytes.Buffer{}
+		err := cl.compactDatabase(b, "db1", false)
+		require.ErrorIs(t, err, rpcErr)
+		require.NotContains(t, b.String(), "not needed yet")
+		require.False(t, cl.immuClient.(*compactionClientMock).gcCalled)
+	})
+
+	t.Run("compacted", func(t *testing.T) {
+		cl := &commandline{
+			context: context.Background(),
+			immuClient: &compactionClientMock{
+				progress: []*schema.CompactionProgress{
+					{IndexSize: 4096, CompactedIndexSize: 1024, Snapshots: 3, ThresholdReached: true},
+					{IndexSize: 4096, CompactedIndexSize: 1024, Snapshots: 3, ThresholdReached: true, DumpedBytes: 1024},
+				},
+				gcRes: &schema.CollectGarbageResponse{ReclaimedBytes: 512},
+			},
+		}
+
+		b := &bytes.Buffer{}
+		err := cl.compactDatabase(b, "db1", false)
+		require.NoError(t, err)
+		require.Contains(t, b.String(), "1.0 KB/1.0 KB of the compacted index written (100.0%)\n")
+		require.Contains(t, b.String(), "Database index successfully compacted\n")
+		require.Contains(t, b.String(), "512 B of superseded values reclaimed\n")
+	})
+
+	t.Run("value garbage collection unsupported", func(t *testing.T) {
+		cl := &commandline{
+			context: context.Background(),
+			immuClient: &compactionClientMock{
+				progress: []*schema.CompactionProgress{notReached},
+				err: immuErrors.New(tbtree.ErrCompactionThresholdNotReached.Error()).
+					WithCode(immuErrors.CodObjectNotInPrerequisiteState),
+				gcErr: immuErrors.New("value garbage collection is unsupported").WithCode(immuErrors.CodFeatureNotSupported),
+			},
+		}
+
+		b := &bytes.Buffer{}
+		err := cl.compactDatabase(b, "db1", false)
+		require.NoError(t, err)
+		require.Contains(t, b.String(), "values of database 'db1' can not be garbage collected\n")
+	})
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintDatabaseUsage(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	printDatabaseUsage(b, &schema.DatabaseUsageList{Usages: []*schema.DatabaseUsage{
+		{
+			Database:      "defaultdb",
+			TxLogSize:     2048,
+			VLogSize:      3 * 1024 * 1024,
+			IndexSize:     512,
+			OtherSize:     100,
+			TotalSize:     2048 + 3*1024*1024 + 512 + 100,
+			TxCount:       42,
+			EntryCount:    420,
+			Since:         1646172000,
+			BytesPerDay:   1024 * 1024,
+			TxsPerDay:     10.5,
+			EntriesPerDay: 105,
+		},
+		{Database: "db1"},
+	}})
+
+	require.Contains(t, b.String(), "2 database(s)")
+	require.Regexp(t, `defaultdb\s+2.0 KB\s+3.0 MB\s+512 B\s+100 B\s+3.0 MB\s+42\s+420\s+1.0 MB, 10.5 txs, 105.0 entries`, b.String())
+	require.Regexp(t, `db1\s+0 B\s+0 B\s+0 B\s+0 B\s+0 B\s+0\s+0\s+-`, b.String())
+}
@@ -25,6 +25,7 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"strings"
 
 	"google.golang.org/grpc/metadata"
 
@@ -32,6 +33,10 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/azure"
+	"github.com/codenotary/immudb/embedded/remotestorage/gcs"
+	"github.com/codenotary/immudb/embedded/remotestorage/s3"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/immuos"
 )
@@ -39,6 +44,10 @@ import (
 const (
 	prefix            = "IMMUBACKUP"
 	latestFileVersion = 1
+
+	s3Scheme    = "s3://"
+	azureScheme = "azure://"
+	gcsScheme   = "gs://"
 )
 
 const (
@@ -57,6 +66,9 @@ var ErrTxNotInFile = errors.New("last known transaction not in file")
 type commandlineHotBck struct {
 	commandline
 	cmd *cobra.Command
+
+	// opens the storage backups are uploaded to, the one of the scheme of the target unless set
+	openStorage func(flags *pflag.FlagSet, scheme, bucket, prefix string) (remotestorage.MultipartStorage, error)
 }
 
 func newCommandlineHotBck(os immuos.OS) (*commandlineHotBck, error) {
@@ -79,18 +91,30 @@ type backupParams struct {
 	startTx  uint64
 	append   bool
 	progress bool
+	verify   bool
+
+	// set when backing up to an object storage, the bucket is the container of azure targets
+	scheme       string
+	bucket       string
+	prefix       string
+	resumeUpload string
 }
 
 func (cl *commandlineHotBck) hotBackup(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
 		Use:   "hot-backup <db_name>",
 		Short: "Make a copy of the database without stopping",
-		Long: "Backup a database to file/stream without stopping the database engine. " +
-			"Backup can run from the beginning or starting from arbitrary transaction.",
+		Long: "Backup a database to file/stream or to an s3, azure blob storage or google cloud storage bucket without stopping the database engine. " +
+			"Backup can run from the beginning or starting from arbitrary transaction. " +
+			"Backups to a file are resumed with --append, uploads interrupted before completion with --resume-upload. " +
+			"Once completed, the backup is read back and its transactions checked against the database.",
+		Example: "hot-backup mydb --to mydb.backup --progress-bar\n" +
+			"hot-backup mydb --to s3://bucket/backups/mydb.backup --s3-endpoint https://s3.amazonaws.com --s3-location us-east-1\n" +
+			"hot-backup mydb --to azure://container/backups/mydb.backup --azure-account myaccount --azure-account-key <key>\n" +
+			"hot-backup mydb --to gs://bucket/backups/mydb.backup --gcs-access-key-id <id> --gcs-secret <secret>",
 		PersistentPreRunE: cl.ConfigChain(cl.connect),
 		PersistentPostRun: cl.disconnect,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			file := io.Writer(os.Stdout)
 			params, err := prepareBackupParams(cmd.Flags())
 			if err != nil {
 				return err
@@ -102,23 +126,47 @@ func (cl *commandlineHotBck) hotBackup(cmd *cobra.Command) {
 			}
 			cl.context = metadata.NewOutgoingContext(cl.context, metadata.Pairs("authorization", udr.GetToken()))
 
-			if params.output != "-" {
-				f, err := cl.verifyOrCreateBackupFile(params)
-				if err != nil {
-					return err
-				}
-				defer f.Close()
-				file = f
+			dest, err := cl.openBackupDestination(cmd.Flags(), params)
+			if err != nil {
+				return err
 			}
 
-			return cl.runHotBackup(file, params.startTx, params.progress)
+			res, err := cl.runHotBackup(dest, params.startTx, params.progress)
+			if err != nil || res.interrupted {
+				dest.Abort()
+				return err
+			}
+
+			err = dest.Close()
+			if err != nil {
+				dest.Abort()
+				return err
+			}
+
+			if !params.verify || res.lastTx == 0 {
+				return nil
+			}
+
+			return cl.verifyBackup(dest, res.lastTx)
 		},
 		Args: cobra.ExactArgs(1),
 	}
 	ccmd.Flags().StringP("output", "o", "-", "output file, \"-\" for stdout")
+	ccmd.Flags().String("to", "", "destination of the backup, either a file or an object (s3://<bucket>/<path>, azure://<container>/<path> or gs://<bucket>/<path>)")
 	ccmd.Flags().Uint64("start-tx", 1, "Transaction ID to start from")
 	ccmd.Flags().Bool("progress-bar", false, "show progress indicator")
 	ccmd.Flags().Bool("append", false, "append to file, if it already exists (for file output only)")
+	ccmd.Flags().Bool("skip-verification", false, "do not read back the backup to check it once completed")
+	ccmd.Flags().String("resume-upload", "", "id of an interrupted upload to be resumed, the backup must be run again with the same options")
+	ccmd.Flags().String("s3-endpoint", "", "s3 endpoint")
+	ccmd.Flags().String("s3-access-key-id", "", "s3 access key id")
+	ccmd.Flags().String("s3-secret-key", "", "s3 secret access key")
+	ccmd.Flags().String("s3-location", "", "s3 location (region)")
+	ccmd.Flags().String("azure-endpoint", "", "azure blob storage endpoint, https://<account>.blob.core.windows.net when not set")
+	ccmd.Flags().String("azure-account", "", "azure storage account")
+	ccmd.Flags().String("azure-account-key", "", "azure storage account key")
+	ccmd.Flags().String("gcs-access-key-id", "", "google cloud storage HMAC key access id")
+	ccmd.Flags().String("gcs-secret", "", "google cloud storage HMAC key secret")
 	cmd.AddCommand(ccmd)
 	cl.cmd = cmd
 }
@@ -143,6 +191,31 @@ func prepareBackupParams(flags *pflag.FlagSet) (*backupParams, error) {
 	if err != nil {
 		return nil, err
 	}
+	skipVerification, err := flags.GetBool("skip-verification")
+	if err != nil {
+		return nil, err
+	}
+	params.verify = !skipVerification
+	params.resumeUpload, err = flags.GetString("resume-upload")
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := flags.GetString("to")
+	if err != nil {
+		return nil, err
+	}
+
+	if to != "" {
+		if flags.Changed("output") {
+			return nil, errors.New("don't use --to and --output options together")
+		}
+
+		params.scheme, params.bucket, params.prefix, params.output, err = parseBackupTarget(to)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if params.startTx > 1 && params.append {
 		return nil, errors.New("don't use --append and --start-tx options together")
@@ -152,9 +225,193 @@ func prepareBackupParams(flags *pflag.FlagSet) (*backupParams, error) {
 		return nil, errors.New("--append option can be used only when outputting to the file")
 	}
 
+	if params.bucket != "" && params.append {
+		return nil, errors.New("--append option can't be used with object storages, use --resume-upload to resume an interrupted upload")
+	}
+
+	if params.bucket == "" && params.resumeUpload != "" {
+		return nil, errors.New("--resume-upload option can be used only when uploading to an object storage")
+	}
+
 	return &params, nil
 }
 
+// parseBackupTarget returns the scheme, the bucket, the path prefix and the name of the object an object storage target
+// (i.e. s3://<bucket>/<path>) refers to, a target not starting with a known scheme is returned as the name of a file
+func parseBackupTarget(to string) (scheme, bucket, prefix, name string, err error) {
+	for _, sch := range []string{s3Scheme, azureScheme, gcsScheme} {
+		if strings.HasPrefix(to, sch) {
+			scheme = sch
+			break
+		}
+	}
+	if scheme == "" {
+		return "", "", "", to, nil
+	}
+
+	path := strings.TrimPrefix(to, scheme)
+
+	i := strings.Index(path, "/")
+	if i <= 0 || strings.HasSuffix(path, "/") {
+		return "", "", "", "", fmt.Errorf("invalid target '%s', it must be %s<bucket>/<path>", to, scheme)
+	}
+	bucket, path = path[:i], path[i+1:]
+
+	if j := strings.LastIndex(path, "/"); j >= 0 {
+		prefix, name = path[:j], path[j+1:]
+	} else {
+		name = path
+	}
+
+	return scheme, bucket, prefix, name, nil
+}
+
+// backupDestination is where the transactions are written to, either stdout, a file or an upload to an object storage
+type backupDestination interface {
+	io.Writer
+
+	// Close completes the backup
+	Close() error
+
+	// Abort leaves the backup as it is, uploads not completed can then be resumed
+	Abort()
+
+	// Open reads the completed backup, nil is returned when it can't be read back
+	Open() (io.ReadCloser, error)
+
+	String() string
+}
+
+type stdoutBackup struct {
+	io.Writer
+}
+
+func (b *stdoutBackup) Close() error                 { return nil }
+func (b *stdoutBackup) Abort()                       {}
+func (b *stdoutBackup) Open() (io.ReadCloser, error) { return nil, nil }
+func (b *stdoutBackup) String() string               { return "stdout" }
+
+type fileBackup struct {
+	*os.File
+}
+
+func (b *fileBackup) Abort() {
+	b.File.Close()
+}
+
+func (b *fileBackup) Open() (io.ReadCloser, error) {
+	return os.Open(b.Name())
+}
+
+func (b *fileBackup) String() string {
+	return b.Name()
+}
+
+type uploadBackup struct {
+	*remotestorage.UploadWriter
+	cmd     *cobra.Command
+	st      remotestorage.MultipartStorage
+	name    string
+	written int64
+}
+
+func (b *uploadBackup) Write(p []byte) (int, error) {
+	n, err := b.UploadWriter.Write(p)
+	b.written += int64(n)
+	return n, err
+}
+
+// Close completes the upload, an upload nothing was written to is discarded instead of creating an empty object
+func (b *uploadBackup) Close() error {
+	if b.written == 0 {
+		return b.UploadWriter.Abort()
+	}
+	return b.UploadWriter.Close()
+}
+
+func (b *uploadBackup) Abort() {
+	fmt.Fprintf(b.cmd.ErrOrStderr(), "Upload of %s not completed, resume it running the backup again with --resume-upload %s\n",
+		b.name, b.UploadID())
+}
+
+func (b *uploadBackup) Open() (io.ReadCloser, error) {
+	return b.st.Get(context.Background(), b.name, 0, -1)
+}
+
+func (b *uploadBackup) String() string {
+	return fmt.Sprintf("%s/%s", b.st, b.name)
+}
+
+func (cl *commandlineHotBck) openBackupDestination(flags *pflag.FlagSet, params *backupParams) (backupDestination, error) {
+	if params.bucket == "" {
+		if params.output == "-" {
+			return &stdoutBackup{Writer: os.Stdout}, nil
+		}
+
+		f, err := cl.verifyOrCreateBackupFile(params)
+		if err != nil {
+			return nil, err
+		}
+
+		return &fileBackup{File: f}, nil
+	}
+
+	openStorage := cl.openStorage
+	if openStorage == nil {
+		openStorage = openRemoteStorage
+	}
+
+	st, err := openStorage(flags, params.scheme, params.bucket, params.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var w *remotestorage.UploadWriter
+
+	if params.resumeUpload != "" {
+		fmt.Fprintf(cl.cmd.ErrOrStderr(), "Resuming upload %s, parts already uploaded are checked and skipped\n", params.resumeUpload)
+		w, err = remotestorage.ResumeUploadWriter(context.Background(), st, params.output, params.resumeUpload, remotestorage.DefaultUploadOptions())
+	} else {
+		w, err = remotestorage.NewUploadWriter(context.Background(), st, params.output, remotestorage.DefaultUploadOptions())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &uploadBackup{UploadWriter: w, cmd: cl.cmd, st: st, name: params.output}, nil
+}
+
+func openRemoteStorage(flags *pflag.FlagSet, scheme, bucket, prefix string) (remotestorage.MultipartStorage, error) {
+	var st remotestorage.Storage
+	var err error
+
+	switch scheme {
+	case azureScheme:
+		endpoint, _ := flags.GetString("azure-endpoint")
+		account, _ := flags.GetString("azure-account")
+		accountKey, _ := flags.GetString("azure-account-key")
+
+		st, err = azure.Open(endpoint, account, accountKey, bucket, prefix)
+	case gcsScheme:
+		accessKeyID, _ := flags.GetString("gcs-access-key-id")
+		secret, _ := flags.GetString("gcs-secret")
+
+		st, err = gcs.Open("", accessKeyID, secret, bucket, prefix)
+	default:
+		endpoint, _ := flags.GetString("s3-endpoint")
+		accessKeyID, _ := flags.GetString("s3-access-key-id")
+		secretKey, _ := flags.GetString("s3-secret-key")
+		location, _ := flags.GetString("s3-location")
+
+		st, err = s3.Open(endpoint, accessKeyID, secretKey, bucket, location, prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return st.(remotestorage.MultipartStorage), nil
+}
+
 func (cl *commandlineHotBck) verifyOrCreateBackupFile(params *backupParams) (*os.File, error) {
 	var f *os.File
 
@@ -176,7 +433,12 @@ func (cl *commandlineHotBck) verifyOrCreateBackupFile(params *backupParams) (*os
 		if err != nil {
 			return nil, fmt.Errorf("cannot find file's last transaction %d in database: %v", last, err)
 		}
-		alh := schema.TxHeaderFromProto(txn.Header).Alh()
+		hdr, err := schema.TxHeaderFromProto(txn.Header)
+		if err != nil {
+			return nil, err
+		}
+
+		alh := hdr.Alh()
 		if !bytes.Equal(fileChecksum, alh[:]) {
 			return nil, fmt.Errorf("checksums for transaction %d in backup file and database differ - probably file was created from different database", last)
 		}
@@ -193,16 +455,22 @@ func (cl *commandlineHotBck) verifyOrCreateBackupFile(params *backupParams) (*os
 	return f, nil
 }
 
-func (cl *commandlineHotBck) runHotBackup(output io.Writer, startTx uint64, progress bool) error {
+// backupResult tells the transactions written by a backup
+type backupResult struct {
+	lastTx      uint64
+	interrupted bool
+}
+
+func (cl *commandlineHotBck) runHotBackup(output io.Writer, startTx uint64, progress bool) (*backupResult, error) {
 	state, err := cl.immuClient.CurrentState(cl.context)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	latestTx := state.TxId
 
 	if latestTx < startTx {
 		fmt.Fprintf(cl.cmd.ErrOrStderr(), "All backed up, nothing to do\n")
-		return nil
+		return &backupResult{}, nil
 	}
 
 	if startTx == latestTx {
@@ -233,11 +501,11 @@ func (cl *commandlineHotBck) runHotBackup(output io.Writer, startTx uint64, prog
 	for i := startTx; i <= latestTx; i++ {
 		if stop {
 			fmt.Fprintf(cl.cmd.ErrOrStderr(), "Terminated by signal - stopped after tx %d\n", i-1)
-			return nil
+			return &backupResult{lastTx: i - 1, interrupted: true}, nil
 		}
 		err = cl.backupTx(i, output)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if bar != nil {
 			bar.Add(1)
@@ -245,6 +513,75 @@ func (cl *commandlineHotBck) runHotBackup(output io.Writer, startTx uint64, prog
 	}
 
 	fmt.Fprintf(cl.cmd.ErrOrStderr(), "Done\n")
+	return &backupResult{lastTx: latestTx}, nil
+}
+
+// verifyBackup reads back the backup checking its transactions are in sequence up to lastTx,
+// and the checksums of the first and last ones match the ones of the database
+func (cl *commandlineHotBck) verifyBackup(dest backupDestination, lastTx uint64) error {
+	r, err := dest.Open()
+	if err != nil {
+		return fmt.Errorf("cannot read back %s to verify it: %w", dest, err)
+	}
+	if r == nil {
+		return nil
+	}
+	defer r.Close()
+
+	firstTx, firstChecksum, _, err := nextTx(r)
+	if err != nil {
+		return fmt.Errorf("backup verification failed: %w", err)
+	}
+
+	last, lastChecksum := firstTx, firstChecksum
+
+	for {
+		tx, checksum, _, err := nextTx(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("backup verification failed: %w", err)
+		}
+		if tx != last+1 {
+			return fmt.Errorf("backup verification failed: %w", ErrTxWrongOrder)
+		}
+		last, lastChecksum = tx, checksum
+	}
+
+	if last != lastTx {
+		return fmt.Errorf("backup verification failed: last transaction is %d instead of %d", last, lastTx)
+	}
+
+	for _, tx := range []struct {
+		id       uint64
+		checksum []byte
+	}{{firstTx, firstChecksum}, {last, lastChecksum}} {
+		err = cl.checkTxChecksum(tx.id, tx.checksum)
+		if err != nil {
+			return fmt.Errorf("backup verification failed: %w", err)
+		}
+	}
+
+	fmt.Fprintf(cl.cmd.ErrOrStderr(), "Backup %s verified: transactions from %d to %d\n", dest, firstTx, last)
+	return nil
+}
+
+func (cl *commandlineHotBck) checkTxChecksum(tx uint64, checksum []byte) error {
+	txn, err := cl.immuClient.TxByID(cl.context, tx)
+	if err != nil {
+		return err
+	}
+	hdr, err := schema.TxHeaderFromProto(txn.Header)
+	if err != nil {
+		return err
+	}
+
+	alh := hdr.Alh()
+	if !bytes.Equal(checksum, alh[:]) {
+		return fmt.Errorf("checksums for transaction %d in backup and database differ", tx)
+	}
+
 	return nil
 }
 
@@ -278,7 +615,12 @@ func (cl *commandlineHotBck) backupTx(tx uint64, output io.Writer) error {
 	if err != nil {
 		return err
 	}
-	alh := schema.TxHeaderFromProto(txn.Header).Alh()
+	hdr, err := schema.TxHeaderFromProto(txn.Header)
+	if err != nil {
+		return err
+	}
+
+	alh := hdr.Alh()
 	err = outputTx(tx, output, alh[:], content)
 	if err != nil {
 		return err
@@ -525,7 +867,12 @@ func (cl *commandlineHotBck) useDb(name string, replica bool) (uint64, []byte, e
 		return 0, nil, err
 	}
 
-	alh := schema.TxHeaderFromProto(txn.Header).Alh()
+	hdr, err := schema.TxHeaderFromProto(txn.Header)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	alh := hdr.Alh()
 	return state.TxId, alh[:], nil
 }
 
@@ -626,7 +973,12 @@ func (cl *commandlineHotBck) restoreTx(checksum, payload []byte) error {
 	if err != nil {
 		return err
 	}
-	alh := schema.TxHeaderFromProto(metadata).Alh()
+	hdr, err := schema.TxHeaderFromProto(metadata)
+	if err != nil {
+		return err
+	}
+
+	alh := hdr.Alh()
 	if !bytes.Equal(checksum, alh[:]) {
 		return fmt.Errorf("transaction checksums don't match")
 	}
@@ -28,9 +28,13 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/memory"
 	"github.com/codenotary/immudb/pkg/client"
 	"github.com/codenotary/immudb/pkg/server"
 	"github.com/codenotary/immudb/pkg/server/servertest"
@@ -42,14 +46,19 @@ var bs = servertest.NewBufconnServer(options)
 var ErrExpectedFailure = errors.New("expected failure")
 
 func TestMain(m *testing.M) {
+	// the local state of a previous run would not match the new server
 	os.RemoveAll("data")
+	os.Remove(".state-")
+
 	bs.Start()
-	defer bs.Stop()
 
-	defer os.RemoveAll(options.Dir)
-	defer os.Remove(".state-")
+	code := m.Run()
+
+	bs.Stop()
+	os.RemoveAll(options.Dir)
+	os.Remove(".state-")
 
-	os.Exit(m.Run())
+	os.Exit(code)
 }
 
 func getCmdline() *commandline {
@@ -331,3 +340,148 @@ func TestBackup(t *testing.T) {
 	}
 	assert.Contains(t, string(out), "Error: checksums for transaction 14 in backup file and database differ - probably file was created from different database")
 }
+
+func TestParseBackupTarget(t *testing.T) {
+	scheme, bucket, prefix, name, err := parseBackupTarget("full.backup")
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "", "", "full.backup"}, []string{scheme, bucket, prefix, name})
+
+	scheme, bucket, prefix, name, err = parseBackupTarget("s3://bucket1/backups/db1/full.backup")
+	require.NoError(t, err)
+	require.Equal(t, []string{s3Scheme, "bucket1", "backups/db1", "full.backup"}, []string{scheme, bucket, prefix, name})
+
+	scheme, bucket, prefix, name, err = parseBackupTarget("s3://bucket1/full.backup")
+	require.NoError(t, err)
+	require.Equal(t, []string{s3Scheme, "bucket1", "", "full.backup"}, []string{scheme, bucket, prefix, name})
+
+	scheme, bucket, prefix, name, err = parseBackupTarget("azure://container1/backups/full.backup")
+	require.NoError(t, err)
+	require.Equal(t, []string{azureScheme, "container1", "backups", "full.backup"}, []string{scheme, bucket, prefix, name})
+
+	scheme, bucket, prefix, name, err = parseBackupTarget("gs://bucket1/full.backup")
+	require.NoError(t, err)
+	require.Equal(t, []string{gcsScheme, "bucket1", "", "full.backup"}, []string{scheme, bucket, prefix, name})
+
+	for _, to := range []string{"s3://bucket1", "s3:///full.backup", "s3://bucket1/backups/", "azure://container1", "gs:///full.backup"} {
+		_, _, _, _, err = parseBackupTarget(to)
+		require.Error(t, err, to)
+	}
+}
+
+func TestOpenRemoteStorage(t *testing.T) {
+	cl := commandlineHotBck{}
+	cmd, _ := cl.NewCmd()
+	cl.hotBackup(cmd)
+
+	backupCmd, _, err := cmd.Find([]string{"hot-backup"})
+	require.NoError(t, err)
+
+	flags := backupCmd.Flags()
+	require.NoError(t, flags.Set("azure-account", "devstoreaccount1"))
+	require.NoError(t, flags.Set("azure-account-key", "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="))
+
+	st, err := openRemoteStorage(flags, azureScheme, "container1", "backups")
+	require.NoError(t, err)
+	require.Equal(t, "azure:https://devstoreaccount1.blob.core.windows.net/container1/backups/", st.String())
+
+	st, err = openRemoteStorage(flags, gcsScheme, "bucket1", "backups")
+	require.NoError(t, err)
+	require.Equal(t, "gcs:https://storage.googleapis.com/bucket1/backups/", st.String())
+
+	require.NoError(t, flags.Set("s3-endpoint", "https://s3.amazonaws.com"))
+
+	st, err = openRemoteStorage(flags, s3Scheme, "bucket1", "")
+	require.NoError(t, err)
+	require.Equal(t, "s3:https://s3.amazonaws.com/bucket1/", st.String())
+}
+
+func TestBackupToS3(t *testing.T) {
+	st := memory.Open()
+
+	// flags keep their values across executions, a new command is created for each of them
+	execute := func(args ...string) (string, error) {
+		cl := commandlineHotBck{}
+		cmd, _ := cl.NewCmd()
+
+		cmdl := commandlineHotBck{
+			commandline: *getCmdline(),
+			openStorage: func(flags *pflag.FlagSet, scheme, bucket, prefix string) (remotestorage.MultipartStorage, error) {
+				return st, nil
+			},
+		}
+		cmdl.hotBackup(cmd)
+		cmdl.hotRestore(cmd)
+
+		output := bytes.NewBufferString("")
+		cmd.SetOut(output)
+		cmd.SetErr(output)
+
+		// disable connects/disconnects, cmd already contains connected immudb client
+		for _, c := range cmd.Commands() {
+			c.PersistentPreRunE = nil
+			c.PersistentPostRun = nil
+		}
+
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+
+		return output.String(), err
+	}
+
+	_, err := execute("hot-restore", "tests3", "-i", "testdata/1-10.backup")
+	require.NoError(t, err)
+
+	_, err = execute("hot-backup", "tests3", "--to", "s3://bucket1/backups/full.backup", "--append")
+	require.Error(t, err)
+
+	_, err = execute("hot-backup", "tests3", "--to", "s3://bucket1/backups/full.backup", "-o", "full.backup")
+	require.Error(t, err)
+
+	out, err := execute("hot-backup", "tests3", "--to", "s3://bucket1/backups/full.backup")
+	require.NoError(t, err)
+	require.Contains(t, out, "Backing up transactions from 1 to 10")
+	require.Contains(t, out, "verified: transactions from 1 to 10")
+
+	expected, err := ioutil.ReadFile("testdata/1-10.backup")
+	require.NoError(t, err)
+
+	r, err := st.Get(context.Background(), "full.backup", 0, -1)
+	require.NoError(t, err)
+	uploaded, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, expected, uploaded)
+
+	t.Run("resume an interrupted upload", func(t *testing.T) {
+		uploadID, err := st.CreateUpload(context.Background(), "resumed.backup", remotestorage.DefaultUploadOptions())
+		require.NoError(t, err)
+
+		_, err = st.UploadPart(context.Background(), "resumed.backup", uploadID, 1, []byte("not a backup"))
+		require.NoError(t, err)
+
+		// the part uploaded doesn't match the backup
+		out, err := execute("hot-backup", "tests3", "--to", "s3://bucket1/resumed.backup", "--resume-upload", uploadID)
+		require.ErrorIs(t, err, remotestorage.ErrUploadMismatch)
+		require.Contains(t, out, "resume it running the backup again with --resume-upload "+uploadID)
+
+		uploadID, err = st.CreateUpload(context.Background(), "resumed.backup", remotestorage.DefaultUploadOptions())
+		require.NoError(t, err)
+
+		_, err = execute("hot-backup", "tests3", "--to", "s3://bucket1/resumed.backup", "--resume-upload", uploadID)
+		require.NoError(t, err)
+
+		r, err := st.Get(context.Background(), "resumed.backup", 0, -1)
+		require.NoError(t, err)
+		uploaded, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, expected, uploaded)
+	})
+
+	t.Run("nothing to upload", func(t *testing.T) {
+		_, err := execute("hot-backup", "tests3", "--to", "s3://bucket1/empty.backup", "--start-tx", "11")
+		require.NoError(t, err)
+
+		exists, err := st.Exists(context.Background(), "empty.backup")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"strings"
 
+	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -63,12 +64,15 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 			client.AdminTokenFileSuffix,
 			client.DefaultOptions().TokenFileName,
 			client.AdminTokenFileSuffix))
+	cmd.PersistentFlags().String("profile", "", fmt.Sprintf("profile of the credentials file (%s, or the file set by %s) used to login without being prompted for a password; "+
+		"the credentials can also be set with the %s, %s and %s environment variables, or a token file of a previous login with %s", c.DefaultCredentialsFile(), c.EnvCredentialsFile, c.EnvUsername, c.EnvPassword, c.EnvPasswordFile, c.EnvTokenFile))
 	cmd.PersistentFlags().StringVar(&cl.config.CfgFn, "config", "", "config file (default path is configs or $HOME; default filename is immuadmin.toml)")
 	cmd.PersistentFlags().BoolP("mtls", "m", client.DefaultOptions().MTLs, "enable mutual tls")
 	cmd.PersistentFlags().String("servername", client.DefaultMTLsOptions().Servername, "used to verify the hostname on the returned certificates")
 	cmd.PersistentFlags().String("certificate", client.DefaultMTLsOptions().Certificate, "server certificate file path")
 	cmd.PersistentFlags().String("pkey", client.DefaultMTLsOptions().Pkey, "server private key path")
 	cmd.PersistentFlags().String("clientcas", client.DefaultMTLsOptions().ClientCAs, "clients certificates list. Aka certificate authority")
+	cmd.PersistentFlags().String("output", c.OutputTable, c.OutputFlagUsage)
 	if err := viper.BindPFlag("immudb-port", cmd.PersistentFlags().Lookup("immudb-port")); err != nil {
 		return err
 	}
@@ -78,6 +82,9 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	if err := viper.BindPFlag("tokenfile", cmd.PersistentFlags().Lookup("tokenfile")); err != nil {
 		return err
 	}
+	if err := viper.BindPFlag("profile", cmd.PersistentFlags().Lookup("profile")); err != nil {
+		return err
+	}
 	if err := viper.BindPFlag("mtls", cmd.PersistentFlags().Lookup("mtls")); err != nil {
 		return err
 	}
@@ -93,14 +100,19 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	if err := viper.BindPFlag("clientcas", cmd.PersistentFlags().Lookup("clientcas")); err != nil {
 		return err
 	}
+	if err := viper.BindPFlag("output", cmd.PersistentFlags().Lookup("output")); err != nil {
+		return err
+	}
 	viper.SetDefault("immudb-port", client.DefaultOptions().Port)
 	viper.SetDefault("immudb-address", client.DefaultOptions().Address)
 	viper.SetDefault("tokenfile", client.DefaultOptions().TokenFileName+client.AdminTokenFileSuffix)
+	viper.SetDefault("profile", "")
 	viper.SetDefault("mtls", client.DefaultOptions().MTLs)
 	viper.SetDefault("servername", client.DefaultMTLsOptions().Servername)
 	viper.SetDefault("certificate", client.DefaultMTLsOptions().Certificate)
 	viper.SetDefault("pkey", client.DefaultMTLsOptions().Pkey)
 	viper.SetDefault("clientcas", client.DefaultMTLsOptions().ClientCAs)
+	viper.SetDefault("output", c.OutputTable)
 
 	return nil
 }
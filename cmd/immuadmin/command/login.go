@@ -18,22 +18,34 @@ package immuadmin
 
 import (
 	"context"
+	"errors"
 	"fmt"
+
 	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func (cl *commandline) login(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
-		Use:               "login username (you will be prompted for password)",
+		Use:               "login [username] (you will be prompted for password unless credentials are provided by the environment or the credentials file)",
 		Short:             fmt.Sprintf("Login using the specified username and password (admin username is %s)", auth.SysAdminUsername),
 		Aliases:           []string{"l"},
-		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPreRunE: cl.ConfigChain(cl.dial),
 		PersistentPostRun: cl.disconnect,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cl.context
-			userStr := args[0]
+
+			userStr := cl.options.Username
+			if len(args) > 0 {
+				userStr = args[0]
+			}
+			if userStr == "" {
+				err := errors.New("please specify a username")
+				cl.quit(err)
+				return err
+			}
 			if userStr != auth.SysAdminUsername {
 				err := fmt.Errorf("Permission denied: user %s has no admin rights", userStr)
 				cl.quit(err)
@@ -41,10 +53,16 @@ func (cl *commandline) login(cmd *cobra.Command) {
 			}
 
 			user := []byte(userStr)
-			pass, err := cl.passwordReader.Read("Password:")
-			if err != nil {
-				cl.quit(err)
-				return err
+
+			prompted := cl.options.Password == "" || userStr != cl.options.Username
+			pass := []byte(cl.options.Password)
+			if prompted {
+				var err error
+				pass, err = cl.passwordReader.Read("Password:")
+				if err != nil {
+					cl.quit(err)
+					return err
+				}
 			}
 
 			responseWarning, err := cl.loginClient(ctx, user, pass)
@@ -58,6 +76,11 @@ func (cl *commandline) login(cmd *cobra.Command) {
 			if string(responseWarning) == auth.WarnDefaultAdminPassword {
 				c.PrintfColorW(cmd.OutOrStdout(), c.Yellow, "SECURITY WARNING: %s\n", responseWarning)
 
+				// the password can't be changed interactively when it's not typed in either
+				if !prompted {
+					return nil
+				}
+
 				changedPassMsg, newPass, err := cl.changeUserPassword(userStr, pass)
 				if err != nil {
 					cl.quit(err)
@@ -69,12 +92,12 @@ func (cl *commandline) login(cmd *cobra.Command) {
 					return err
 				}
 
-				fmt.Fprint(cmd.OutOrStdout(), changedPassMsg)
+				return cl.printMessage(cmd.OutOrStdout(), "%s", changedPassMsg)
 			}
 
 			return nil
 		},
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MaximumNArgs(1),
 	}
 	cmd.AddCommand(ccmd)
 }
@@ -91,6 +114,31 @@ func (cl *commandline) loginClient(
 	return string(response.GetWarning()), nil
 }
 
+// loadCredentials sets the username, password and token file of the options to the ones loaded from the
+// environment or from the profile of the credentials file, if any
+func (cl *commandline) loadCredentials() error {
+	creds, err := c.LoadCredentials(viper.GetString("profile"))
+	if err != nil {
+		return err
+	}
+
+	password, err := auth.DecodeBase64Password(creds.Password)
+	if err != nil {
+		return err
+	}
+
+	cl.options.WithUsername(creds.Username).WithPassword(password)
+	if creds.TokenFile != "" {
+		cl.options.WithTokenFileName(creds.TokenFile)
+	}
+	return nil
+}
+
+// hasCredentials tells whether commands can login on their own, without being prompted for a password
+func (cl *commandline) hasCredentials() bool {
+	return cl.options != nil && cl.options.Username != "" && cl.options.Password != ""
+}
+
 func (cl *commandline) logout(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
 		Use:               "logout",
@@ -102,8 +150,7 @@ func (cl *commandline) logout(cmd *cobra.Command) {
 				cl.quit(err)
 				return err
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "logged out\n")
-			return nil
+			return cl.printMessage(cmd.OutOrStdout(), "logged out\n")
 		},
 		Args: cobra.NoArgs,
 	}
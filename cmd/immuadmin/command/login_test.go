@@ -26,6 +26,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/codenotary/immudb/cmd/helper"
@@ -256,3 +257,117 @@ func newHomedirServiceMock() *clienttest.HomedirServiceMock {
 	}
 	return h
 }
+
+func TestCommandLine_LoginWithCredentials(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	err := bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	for _, env := range []string{helper.EnvUsername, helper.EnvPassword, helper.EnvCredentialsFile} {
+		defer os.Setenv(env, os.Getenv(env))
+	}
+	os.Setenv(helper.EnvUsername, "immudb")
+	os.Setenv(helper.EnvPassword, "immudb")
+	os.Setenv(helper.EnvCredentialsFile, cmdtest.RandString())
+
+	cmdl := commandline{
+		context: context.Background(),
+		options: Options().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}),
+		passwordReader: &clienttest.PasswordReaderMock{
+			ReadF: func(msg string) ([]byte, error) {
+				require.Fail(t, "password must not be prompted")
+				return nil, nil
+			},
+		},
+		ts: tokenservice.NewFileTokenService().WithHds(clienttest.DefaultHomedirServiceMock()).WithTokenFileName(cmdtest.RandString()),
+	}
+	require.NoError(t, cmdl.loadCredentials())
+	require.True(t, cmdl.hasCredentials())
+
+	require.NoError(t, cmdl.checkLoggedIn(&cobra.Command{}, nil))
+
+	require.NoError(t, cmdl.connect(&cobra.Command{}, nil))
+	_, err = cmdl.immuClient.ListUsers(context.Background())
+	require.NoError(t, err)
+	cmdl.disconnect(&cobra.Command{}, nil)
+
+	cl := commandline{}
+	cmd, _ := cl.NewCmd()
+	cmdl.login(cmd)
+
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"login"})
+
+	// remove ConfigChain method to avoid override options
+	cmd.PersistentPreRunE = nil
+	logincmd := cmd.Commands()[0]
+	logincmd.PersistentPreRunE = cmdl.dial
+
+	require.NoError(t, cmd.Execute())
+	require.Contains(t, b.String(), "logged in")
+	require.Contains(t, b.String(), "SECURITY WARNING")
+}
+
+func TestCommandLine_LoginWithTokenFile(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	err := bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	dir, err := ioutil.TempDir("", "token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+
+	for _, env := range []string{helper.EnvUsername, helper.EnvPassword, helper.EnvPasswordFile, helper.EnvTokenFile, helper.EnvCredentialsFile} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+	os.Setenv(helper.EnvUsername, "immudb")
+	os.Setenv(helper.EnvPassword, "immudb")
+	os.Setenv(helper.EnvTokenFile, tokenFile)
+	os.Setenv(helper.EnvCredentialsFile, cmdtest.RandString())
+
+	dialOptions := []grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}
+
+	cmdl := commandline{
+		context: context.Background(),
+		options: Options().WithDialOptions(dialOptions),
+	}
+	require.NoError(t, cmdl.loadCredentials())
+	require.Equal(t, tokenFile, cmdl.options.TokenFileName)
+
+	require.NoError(t, cmdl.connect(&cobra.Command{}, nil))
+	cmdl.disconnect(&cobra.Command{}, nil)
+	require.FileExists(t, tokenFile)
+
+	os.Unsetenv(helper.EnvUsername)
+	os.Unsetenv(helper.EnvPassword)
+
+	cmdl = commandline{
+		context: context.Background(),
+		options: Options().WithDialOptions(dialOptions),
+	}
+	require.NoError(t, cmdl.loadCredentials())
+	require.False(t, cmdl.hasCredentials())
+	cmdl.ts = tokenservice.NewFileTokenService().WithHds(homedir.NewHomedirService()).WithTokenFileName(cmdl.options.TokenFileName)
+
+	require.NoError(t, cmdl.checkLoggedIn(&cobra.Command{}, nil))
+
+	require.NoError(t, cmdl.connect(&cobra.Command{}, nil))
+	defer cmdl.disconnect(&cobra.Command{}, nil)
+	_, err = cmdl.immuClient.ListUsers(context.Background())
+	require.NoError(t, err)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func (cl *commandline) replication(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "replication",
+		Short:             "Inspect and manage the replication of databases",
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"status", "promote", "demote"},
+	}
+
+	cs := &cobra.Command{
+		Use:               "status",
+		Short:             "Show the role of each database, the master replicas follow and how far behind they are",
+		Aliases:           []string{"s"},
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := cl.immuClient.ReplicationStatus(cl.context)
+			if err != nil {
+				return err
+			}
+
+			return cl.printResult(cmd.OutOrStdout(), resp, func() {
+				printReplicationStatus(cmd.OutOrStdout(), resp)
+			})
+		},
+		Args: cobra.ExactArgs(0),
+	}
+
+	cp := &cobra.Command{
+		Use:               "promote",
+		Short:             "Stop replicating a database so that it accepts writes",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "promote {database_name}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cl.immuClient.UpdateDatabase(cl.context, &schema.DatabaseSettings{DatabaseName: args[0], Replica: false})
+			if err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "database '%s' successfully promoted, it's no longer a replica\n", args[0])
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	cd := &cobra.Command{
+		Use:               "demote",
+		Short:             "Turn a database into a replica of a master database",
+		Long:              "Turn a database into a replica of a master database. The database must not diverge from the master, the transactions it already holds must be the first ones of the master.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "demote {database_name} --replication-master-database {master_database} --replication-master-address {address} --replication-follower-username {username} --replication-follower-password {password}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := prepareReplicaSettings(args[0], cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			err = cl.immuClient.UpdateDatabase(cl.context, settings)
+			if err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "database '%s' successfully demoted, it's now a replica of '%s@%s:%d'\n",
+				args[0], settings.MasterDatabase, settings.MasterAddress, settings.MasterPort)
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cd.Flags().String("replication-master-database", "", "set master database to be replicated")
+	cd.Flags().String("replication-master-address", "127.0.0.1", "set master address")
+	cd.Flags().Uint32("replication-master-port", 3322, "set master port")
+	cd.Flags().String("replication-follower-username", "", "set username used for replication")
+	cd.Flags().String("replication-follower-password", "", "set password used for replication")
+
+	ccmd.AddCommand(cs)
+	ccmd.AddCommand(cp)
+	ccmd.AddCommand(cd)
+	cmd.AddCommand(ccmd)
+}
+
+func prepareReplicaSettings(db string, flags *pflag.FlagSet) (*schema.DatabaseSettings, error) {
+	masterDatabase, err := flags.GetString("replication-master-database")
+	if err != nil {
+		return nil, err
+	}
+	if masterDatabase == "" {
+		return nil, fmt.Errorf("the master database must be provided")
+	}
+
+	masterAddress, err := flags.GetString("replication-master-address")
+	if err != nil {
+		return nil, err
+	}
+
+	masterPort, err := flags.GetUint32("replication-master-port")
+	if err != nil {
+		return nil, err
+	}
+
+	followerUsername, err := flags.GetString("replication-follower-username")
+	if err != nil {
+		return nil, err
+	}
+
+	followerPassword, err := flags.GetString("replication-follower-password")
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.DatabaseSettings{
+		DatabaseName:     db,
+		Replica:          true,
+		MasterDatabase:   masterDatabase,
+		MasterAddress:    masterAddress,
+		MasterPort:       masterPort,
+		FollowerUsername: followerUsername,
+		FollowerPassword: followerPassword,
+	}, nil
+}
+
+func printReplicationStatus(w io.Writer, resp *schema.ReplicationStatusList) {
+	c.PrintTable(
+		w,
+		[]string{"Database", "Role", "Master", "State", "Tx ID", "Master Tx ID", "Lag", "Last Error"},
+		len(resp.Statuses),
+		func(i int) []string {
+			st := resp.Statuses[i]
+
+			if !st.Replica {
+				return []string{st.Database, "master", "-", "-", fmt.Sprintf("%d", st.TxId), "-", "-", "-"}
+			}
+
+			state := "stopped"
+			if st.Running && st.Connected {
+				state = "replicating"
+			} else if st.Running {
+				state = "connecting"
+			}
+
+			masterTx, lag := "unknown", "unknown"
+			if st.MasterTxId > 0 {
+				masterTx = fmt.Sprintf("%d", st.MasterTxId)
+				lag = fmt.Sprintf("%d", st.Lag)
+			}
+
+			lastError := "-"
+			if st.LastError != "" {
+				lastError = fmt.Sprintf("%s: %s", time.Unix(st.LastErrorAt, 0).Format(time.RFC3339), st.LastError)
+			}
+
+			return []string{
+				st.Database,
+				"replica",
+				fmt.Sprintf("%s@%s:%d", st.MasterDatabase, st.MasterAddress, st.MasterPort),
+				state,
+				fmt.Sprintf("%d", st.TxId),
+				masterTx,
+				lag,
+				lastError,
+			}
+		},
+		fmt.Sprintf("%d database(s)", len(resp.Statuses)),
+	)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintReplicationStatus(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	lastErrorAt := time.Date(2022, 3, 1, 22, 30, 0, 0, time.UTC)
+
+	printReplicationStatus(b, &schema.ReplicationStatusList{Statuses: []*schema.ReplicationStatus{
+		{Database: "defaultdb", TxId: 10},
+		{
+			Database:       "db1",
+			Replica:        true,
+			MasterDatabase: "defaultdb",
+			MasterAddress:  "10.0.0.1",
+			MasterPort:     3322,
+			Running:        true,
+			Connected:      true,
+			TxId:           7,
+			MasterTxId:     10,
+			Lag:            3,
+		},
+		{
+			Database:       "db2",
+			Replica:        true,
+			MasterDatabase: "db2",
+			MasterAddress:  "10.0.0.2",
+			MasterPort:     3322,
+			Running:        true,
+			LastError:      "connection refused",
+			LastErrorAt:    lastErrorAt.Unix(),
+		},
+	}})
+
+	require.Contains(t, b.String(), "3 database(s)")
+	require.Regexp(t, `defaultdb\s+master\s+-\s+-\s+10\s+`, b.String())
+	require.Regexp(t, `db1\s+replica\s+defaultdb@10.0.0.1:3322\s+replicating\s+7\s+10\s+3\s+-`, b.String())
+	require.Regexp(t, `db2\s+replica\s+db2@10.0.0.2:3322\s+connecting\s+0\s+unknown\s+unknown\s+`, b.String())
+	require.Contains(t, b.String(), time.Unix(lastErrorAt.Unix(), 0).Format(time.RFC3339)+": connection refused")
+}
+
+func TestPrepareReplicaSettings(t *testing.T) {
+	flags := pflag.NewFlagSet("demote", pflag.ContinueOnError)
+	flags.String("replication-master-database", "", "")
+	flags.String("replication-master-address", "127.0.0.1", "")
+	flags.Uint32("replication-master-port", 3322, "")
+	flags.String("replication-follower-username", "", "")
+	flags.String("replication-follower-password", "", "")
+
+	_, err := prepareReplicaSettings("db1", flags)
+	require.EqualError(t, err, "the master database must be provided")
+
+	err = flags.Parse([]string{
+		"--replication-master-database", "defaultdb",
+		"--replication-master-port", "3323",
+		"--replication-follower-username", "follower",
+		"--replication-follower-password", "pwd",
+	})
+	require.NoError(t, err)
+
+	settings, err := prepareReplicaSettings("db1", flags)
+	require.NoError(t, err)
+	require.Equal(t, &schema.DatabaseSettings{
+		DatabaseName:     "db1",
+		Replica:          true,
+		MasterDatabase:   "defaultdb",
+		MasterAddress:    "127.0.0.1",
+		MasterPort:       3323,
+		FollowerUsername: "follower",
+		FollowerPassword: "pwd",
+	}, settings)
+}
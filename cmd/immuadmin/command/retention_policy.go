@@ -0,0 +1,192 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+// parseRetentionRule parses a rule written as {prefix}={duration}, i.e. pii/=61320h
+func parseRetentionRule(s string) (*schema.RetentionRule, error) {
+	i := strings.LastIndex(s, "=")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid retention rule '%s', expected {prefix}={duration}", s)
+	}
+
+	erasableAfter, err := time.ParseDuration(s[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention rule '%s': %v", s, err)
+	}
+
+	return &schema.RetentionRule{
+		Prefix:        []byte(s[:i]),
+		ErasableAfter: int64(erasableAfter / time.Second),
+	}, nil
+}
+
+func (cl *commandline) retentionPolicy(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "retention-policy",
+		Short:             "Manage the retention policies enforced by the server",
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"set", "delete", "list", "enforcements"},
+	}
+
+	cs := &cobra.Command{
+		Use:   "set",
+		Short: "Set the retention policy of a database",
+		Long: "Make the server erase the values of the keys under the prefix of a rule once they are older than the rule allows. " +
+			"Transaction headers and digests are kept forever so proofs still verify, every erasure and enforcement is recorded.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "set {database_name} --rule 'pii/=61320h' [--rule 'logs/=720h']",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules, err := cmd.Flags().GetStringArray("rule")
+			if err != nil {
+				return err
+			}
+
+			policy := &schema.RetentionPolicy{Database: args[0]}
+
+			for _, r := range rules {
+				rule, err := parseRetentionRule(r)
+				if err != nil {
+					return err
+				}
+
+				policy.Rules = append(policy.Rules, rule)
+			}
+
+			if err := cl.immuClient.SetRetentionPolicy(cl.context, policy); err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "retention policy of database '%s' successfully set\n", args[0])
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cs.Flags().StringArray("rule", nil, "rule as {prefix}={duration}, values of the keys under prefix are erased once older than duration (i.e. 61320h)")
+	cs.MarkFlagRequired("rule")
+
+	cd := &cobra.Command{
+		Use:               "delete",
+		Short:             "Stop enforcing the retention policy of a database, erased values are not restored",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "delete {database_name}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cl.immuClient.DeleteRetentionPolicy(cl.context, &schema.Database{DatabaseName: args[0]}); err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "retention policy of database '%s' successfully removed\n", args[0])
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	cls := &cobra.Command{
+		Use:               "list",
+		Short:             "List the retention rules along with the progress of their enforcement",
+		Aliases:           []string{"l"},
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := cl.immuClient.ListRetentionPolicies(cl.context)
+			if err != nil {
+				return err
+			}
+
+			var rows [][]string
+
+			for _, policy := range resp.Policies {
+				lastRun := "-"
+				if policy.LastRunAt > 0 {
+					lastRun = time.Unix(policy.LastRunAt, 0).Format(time.RFC3339)
+				}
+
+				for _, rule := range policy.Rules {
+					rows = append(rows, []string{
+						policy.Database,
+						string(rule.Prefix),
+						(time.Duration(rule.ErasableAfter) * time.Second).String(),
+						fmt.Sprintf("%d", rule.EnforcedUptoTx),
+						lastRun,
+						policy.LastError,
+					})
+				}
+			}
+
+			return cl.printResult(cmd.OutOrStdout(), resp, func() {
+				c.PrintTable(
+					cmd.OutOrStdout(),
+					[]string{"Database Name", "Prefix", "Erasable After", "Enforced Up To Tx", "Last Run", "Last Error"},
+					len(rows),
+					func(i int) []string { return rows[i] },
+					fmt.Sprintf("%d retention policy(ies)", len(resp.Policies)),
+				)
+			})
+		},
+		Args: cobra.ExactArgs(0),
+	}
+
+	ce := &cobra.Command{
+		Use:               "enforcements",
+		Short:             "List the enforcements of the retention policy of a database",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "enforcements {database_name}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := cl.immuClient.ListRetentionEnforcements(cl.context, &schema.Database{DatabaseName: args[0]})
+			if err != nil {
+				return err
+			}
+			return cl.printResult(cmd.OutOrStdout(), resp, func() {
+				c.PrintTable(
+					cmd.OutOrStdout(),
+					[]string{"Enforced At", "Prefix", "Erasable After", "Txs", "Erased", "Error"},
+					len(resp.Enforcements),
+					func(i int) []string {
+						e := resp.Enforcements[i]
+
+						return []string{
+							time.Unix(e.EnforcedAt, 0).Format(time.RFC3339),
+							string(e.Prefix),
+							(time.Duration(e.ErasableAfter) * time.Second).String(),
+							fmt.Sprintf("%d-%d", e.FromTx, e.ToTx),
+							fmt.Sprintf("%d", e.Erased),
+							e.Error,
+						}
+					},
+					fmt.Sprintf("%d enforcement(s)", len(resp.Enforcements)),
+				)
+			})
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	ccmd.AddCommand(cs)
+	ccmd.AddCommand(cd)
+	ccmd.AddCommand(cls)
+	ccmd.AddCommand(ce)
+	cmd.AddCommand(ccmd)
+}
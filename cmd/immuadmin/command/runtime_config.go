@@ -0,0 +1,96 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"io"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) runtimeConfig(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "config",
+		Short:             "Get and set the options of the server that can be changed while it's running",
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"get", "set"},
+	}
+
+	cg := &cobra.Command{
+		Use:               "get",
+		Short:             "Show the current value of the options that can be changed at runtime, or of a single one",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "get [{key}]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var key string
+			if len(args) == 1 {
+				key = args[0]
+			}
+
+			config, err := cl.immuClient.GetRuntimeConfig(cl.context, key)
+			if err != nil {
+				return err
+			}
+
+			printRuntimeConfig(cmd.OutOrStdout(), config)
+			return nil
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cs := &cobra.Command{
+		Use:   "set",
+		Short: "Change an option of the running server",
+		Long: "Change an option of the running server, i.e. log-level, max-backup-rate, max-replication-rate or txlog-cache-size. " +
+			"Changes are stored in the system database, so they are kept across restarts.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "set log-level debug",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			setting, err := cl.immuClient.SetRuntimeConfig(cl.context, args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			return cl.printResult(cmd.OutOrStdout(), setting, func() {
+				fmt.Fprintf(cmd.OutOrStdout(), "option '%s' successfully set to '%s'\n", setting.Key, setting.Value)
+			})
+		},
+		Args: cobra.ExactArgs(2),
+	}
+
+	ccmd.AddCommand(cg)
+	ccmd.AddCommand(cs)
+	cmd.AddCommand(ccmd)
+}
+
+func printRuntimeConfig(w io.Writer, config *schema.RuntimeConfig) {
+	c.PrintTable(
+		w,
+		[]string{"Key", "Value", "Description"},
+		len(config.Settings),
+		func(i int) []string {
+			setting := config.Settings[i]
+			return []string{setting.Key, setting.Value, setting.Description}
+		},
+		fmt.Sprintf("%d option(s)", len(config.Settings)),
+	)
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintRuntimeConfig(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	printRuntimeConfig(b, &schema.RuntimeConfig{Settings: []*schema.RuntimeSetting{
+		{Key: "log-level", Value: "debug", Description: "level of the messages logged"},
+		{Key: "max-backup-rate", Value: "0", Description: "max bytes per second read by backups"},
+	}})
+
+	require.Contains(t, b.String(), "2 option(s)")
+	require.Regexp(t, `log-level\s+debug\s+level of the messages logged`, b.String())
+	require.Regexp(t, `max-backup-rate\s+0\s+max bytes per second read by backups`, b.String())
+}
@@ -49,7 +49,7 @@ func (cl commandline) serverConfig(cmd *cobra.Command) {
 				if err := cl.immuClient.UpdateAuthConfig(ctx, authKind); err != nil {
 					return err
 				}
-				fmt.Fprintf(cmd.OutOrStdout(), "Server auth config updated\n")
+				return cl.printMessage(cmd.OutOrStdout(), "Server auth config updated\n")
 
 			case "mtls":
 				enabled, err := strconv.ParseBool(v)
@@ -59,12 +59,11 @@ func (cl commandline) serverConfig(cmd *cobra.Command) {
 				if err := cl.immuClient.UpdateMTLSConfig(ctx, enabled); err != nil {
 					return err
 				}
-				fmt.Fprintf(cmd.OutOrStdout(), "Server MTLS config updated\n")
+				return cl.printMessage(cmd.OutOrStdout(), "Server MTLS config updated\n")
 			default:
 				return fmt.Errorf(
 					"unsupported %s config item, supported config items: auth or mtls", configItem)
 			}
-			return nil
 		},
 		Args: cobra.ExactArgs(2),
 	}
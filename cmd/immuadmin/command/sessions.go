@@ -0,0 +1,126 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) sessions(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "sessions",
+		Short:             "Manage the sessions opened against the server",
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"list", "kill"},
+	}
+
+	cls := &cobra.Command{
+		Use:               "list",
+		Short:             "List the sessions opened against the server along with the address they were opened from",
+		Aliases:           []string{"l"},
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "list [--user {username}]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			user, err := cmd.Flags().GetString("user")
+			if err != nil {
+				return err
+			}
+
+			return cl.listSessions(cmd.OutOrStdout(), user)
+		},
+		Args: cobra.ExactArgs(0),
+	}
+	cls.Flags().String("user", "", "only list the sessions of this user")
+
+	ck := &cobra.Command{
+		Use:   "kill",
+		Short: "Close a session, or all the sessions of a user",
+		Long: "Close a session rolling back its ongoing transactions. When a user is provided instead, all its sessions " +
+			"are closed and its logins revoked, so the user is forced to log in again, i.e. once its password was changed.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "kill {session_id} | kill --user {username}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			user, err := cmd.Flags().GetString("user")
+			if err != nil {
+				return err
+			}
+
+			req := &schema.KillSessionRequest{User: user}
+
+			if len(args) == 1 {
+				req.SessionID = args[0]
+			}
+
+			if (req.SessionID == "") == (req.User == "") {
+				return fmt.Errorf("either a session id or a user must be provided")
+			}
+
+			resp, err := cl.immuClient.KillSession(cl.context, req)
+			if err != nil {
+				return err
+			}
+
+			if req.User != "" {
+				return cl.printMessage(cmd.OutOrStdout(), "%d session(s) of user '%s' successfully killed and its logins revoked\n", resp.Killed, req.User)
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "session '%s' successfully killed\n", req.SessionID)
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+	ck.Flags().String("user", "", "close all the sessions of this user and revoke its logins")
+
+	ccmd.AddCommand(cls)
+	ccmd.AddCommand(ck)
+	cmd.AddCommand(ccmd)
+}
+
+func (cl *commandline) listSessions(w io.Writer, user string) error {
+	resp, err := cl.immuClient.ListSessions(cl.context, &schema.ListSessionsRequest{User: user})
+	if err != nil {
+		return err
+	}
+
+	return cl.printResult(w, resp, func() {
+		c.PrintTable(
+			w,
+			[]string{"ID", "User", "Database", "Client Address", "Created At", "Last Activity"},
+			len(resp.Sessions),
+			func(i int) []string {
+				sess := resp.Sessions[i]
+
+				return []string{
+					sess.Id,
+					sess.User,
+					sess.Database,
+					sess.ClientAddress,
+					time.Unix(sess.CreatedAt, 0).Format(time.RFC3339),
+					time.Unix(sess.LastActivityAt, 0).Format(time.RFC3339),
+				}
+			},
+			fmt.Sprintf("%d session(s)", len(resp.Sessions)),
+		)
+	})
+}
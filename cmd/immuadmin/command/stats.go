@@ -36,8 +36,7 @@ func (cl *commandline) status(cmd *cobra.Command) {
 			if err := cl.immuClient.HealthCheck(ctx); err != nil {
 				c.QuitWithUserError(err)
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "OK - server is reachable and responding to queries\n")
-			return nil
+			return cl.printMessage(cmd.OutOrStdout(), "OK - server is reachable and responding to queries\n")
 		},
 		Args: cobra.NoArgs,
 	}
@@ -63,6 +62,16 @@ func (cl *commandline) stats(cmd *cobra.Command) {
 				}
 				return nil
 			}
+			live, err := cmd.Flags().GetBool("live")
+			if err != nil {
+				c.QuitToStdErr(err)
+			}
+			if live {
+				if err := stats.ShowMetricsLive(options.Address); err != nil {
+					c.QuitToStdErr(err)
+				}
+				return nil
+			}
 			text, err := cmd.Flags().GetBool("text")
 			if err != nil {
 				c.QuitToStdErr(err)
@@ -82,5 +91,6 @@ func (cl *commandline) stats(cmd *cobra.Command) {
 	}
 	ccmd.Flags().BoolP("text", "t", false, "show statistics as text instead of the default graphical view")
 	ccmd.Flags().BoolP("raw", "r", false, "show raw statistics")
+	ccmd.Flags().BoolP("live", "l", false, "show per-database tx rate, entries, disk usage, index lag, cache hit rate and sessions refreshed in place")
 	cmd.AddCommand(ccmd)
 }
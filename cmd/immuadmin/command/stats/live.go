@@ -0,0 +1,131 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+var liveHeader = []string{"DATABASE", "TX", "TX/S", "ENTRIES", "SIZE", "INDEX LAG", "CACHE HITS", "SESSIONS"}
+
+// liveController renders per-database statistics as a table refreshed in place, like top does for processes.
+// Rates are computed between consecutive renderings
+type liveController struct {
+	Table *widgets.Table
+	tui   Tui
+
+	prev   *metrics
+	prevAt time.Time
+}
+
+func newLiveController(tui Tui) Controller {
+	ui.Theme.Block.Title.Fg = ui.ColorGreen
+
+	ctl := &liveController{
+		Table: widgets.NewTable(),
+		tui:   tui,
+	}
+	ctl.Table.Title = " Exit: q, Esc or Ctrl-C "
+	ctl.Table.TextAlignment = ui.AlignRight
+	ctl.Table.RowSeparator = false
+	ctl.Table.RowStyles[0] = ui.NewStyle(ui.ColorWhite, ui.ColorClear, ui.ModifierBold)
+	ctl.resize()
+
+	return ctl
+}
+
+func (p *liveController) Resize() {
+	p.resize()
+	p.tui.Render(p.Table)
+}
+
+func (p *liveController) resize() {
+	termWidth, termHeight := p.tui.TerminalDimensions()
+	p.Table.SetRect(0, 0, termWidth, termHeight)
+}
+
+func (p *liveController) Render(ms *metrics) {
+	now := time.Now()
+
+	uptime, _ := time.ParseDuration(fmt.Sprintf("%.4fh", ms.uptimeHours))
+	p.Table.Title = fmt.Sprintf(" immudb stats at %s - uptime %s, %d clients - Exit: q, Esc or Ctrl-C ",
+		now.Format("15:04:05"), uptime, ms.nbClients)
+
+	p.Table.Rows = liveRows(ms, p.prev, now.Sub(p.prevAt))
+
+	p.prev = ms
+	p.prevAt = now
+
+	p.tui.Render(p.Table)
+}
+
+// liveRows returns the header and a row per database, sorted by name.
+// Tx rates and cache hit rates are computed since the previous metrics, when provided,
+// otherwise cache hit rates are computed since the server was started
+func liveRows(ms, prev *metrics, elapsed time.Duration) [][]string {
+	names := make([]string, 0, len(ms.dbs))
+	for name := range ms.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := [][]string{liveHeader}
+
+	for _, name := range names {
+		db := ms.dbs[name]
+
+		txRate := "-"
+		hits, misses := db.cacheHits, db.cacheMisses
+
+		if prev != nil && elapsed > 0 {
+			if prevDB, ok := prev.dbs[name]; ok {
+				if db.lastCommittedTx >= prevDB.lastCommittedTx {
+					txRate = fmt.Sprintf("%.1f", float64(db.lastCommittedTx-prevDB.lastCommittedTx)/elapsed.Seconds())
+				}
+				if hits >= prevDB.cacheHits && misses >= prevDB.cacheMisses {
+					hits -= prevDB.cacheHits
+					misses -= prevDB.cacheMisses
+				}
+			}
+		}
+
+		cacheHitRate := "-"
+		if hits+misses > 0 {
+			cacheHitRate = fmt.Sprintf("%.1f%%", float64(hits)*100/float64(hits+misses))
+		}
+
+		size, _ := byteCountBinary(db.totalBytes)
+
+		rows = append(rows, []string{
+			name,
+			fmt.Sprintf("%d", db.lastCommittedTx),
+			txRate,
+			fmt.Sprintf("%d", db.nbEntries),
+			size,
+			fmt.Sprintf("%d", db.indexingLag),
+			cacheHitRate,
+			fmt.Sprintf("%d", db.activeSessions),
+		})
+	}
+
+	return rows
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+)
+
+func liveMetrics(t *testing.T, lastCommittedTx, hits, misses string) *metrics {
+	response := `# TYPE immudb_db_size_bytes gauge
+immudb_db_size_bytes{db="defaultdb"} 2048
+immudb_db_size_bytes{db="systemdb"} 1024
+# TYPE immudb_number_of_stored_entries gauge
+immudb_number_of_stored_entries{db="defaultdb"} 100
+# TYPE immudb_last_committed_trx_id gauge
+immudb_last_committed_trx_id{db="defaultdb"} ` + lastCommittedTx + `
+# TYPE immudb_indexing_lag_trxs gauge
+immudb_indexing_lag_trxs{db="defaultdb"} 3
+# TYPE immudb_active_sessions gauge
+immudb_active_sessions{db="defaultdb"} 2
+# TYPE immudb_store_cache_requests counter
+immudb_store_cache_requests{cache="txlog",db="defaultdb",result="hit"} ` + hits + `
+immudb_store_cache_requests{cache="txlog",db="defaultdb",result="miss"} ` + misses + `
+`
+
+	textParser := expfmt.TextParser{}
+	metricsFamilies, err := textParser.TextToMetricFamilies(strings.NewReader(response))
+	require.NoError(t, err)
+
+	ms := &metrics{}
+	ms.populateFrom(&metricsFamilies)
+	return ms
+}
+
+func TestLiveMetrics(t *testing.T) {
+	ms := liveMetrics(t, "10", "30", "10")
+
+	require.Equal(t, dbInfo{
+		name:            "defaultdb",
+		totalBytes:      2048,
+		nbEntries:       100,
+		lastCommittedTx: 10,
+		indexingLag:     3,
+		activeSessions:  2,
+		cacheHits:       30,
+		cacheMisses:     10,
+	}, ms.dbs["defaultdb"])
+
+	require.Equal(t, dbInfo{name: "systemdb", totalBytes: 1024}, ms.dbs["systemdb"])
+}
+
+func TestLiveRows(t *testing.T) {
+	prev := liveMetrics(t, "10", "30", "10")
+
+	rows := liveRows(prev, nil, 0)
+	require.Equal(t, [][]string{
+		liveHeader,
+		{"defaultdb", "10", "-", "100", "2.0 kB", "3", "75.0%", "2"},
+		{"systemdb", "0", "-", "0", "1.0 kB", "0", "-", "0"},
+	}, rows)
+
+	// rates are computed since the previous metrics
+	ms := liveMetrics(t, "30", "40", "40")
+
+	rows = liveRows(ms, prev, 2*time.Second)
+	require.Equal(t, []string{"defaultdb", "30", "10.0", "100", "2.0 kB", "3", "25.0%", "2"}, rows[1])
+	require.Equal(t, []string{"systemdb", "0", "0.0", "0", "1.0 kB", "0", "-", "0"}, rows[2])
+}
+
+func TestLiveController(t *testing.T) {
+	c := newLiveController(tuiMock{})
+	require.IsType(t, &liveController{}, c)
+
+	c.Render(liveMetrics(t, "10", "30", "10"))
+	c.Render(liveMetrics(t, "20", "30", "10"))
+	c.Resize()
+
+	require.Len(t, c.(*liveController).Table.Rows, 3)
+}
+
+func TestRunLiveUI(t *testing.T) {
+	sui := statsui{Loader: metricsLoaderMock{}, Tui: tuiMock{}, Live: true}
+	require.NoError(t, sui.runUI(true))
+}
@@ -73,9 +73,14 @@ type rpcDuration struct {
 }
 
 type dbInfo struct {
-	name       string
-	totalBytes uint64
-	nbEntries  uint64
+	name            string
+	totalBytes      uint64
+	nbEntries       uint64
+	lastCommittedTx uint64
+	indexingLag     uint64
+	activeSessions  uint64
+	cacheHits       uint64
+	cacheMisses     uint64
 }
 
 type operations struct {
@@ -190,20 +195,64 @@ func (ms *metrics) withDBInfo(metricsFamilies *map[string]*dto.MetricFamily) {
 		"db",
 		&nbsEntries)
 
+	// Indexing progress
+	lastCommittedTxs := make(map[string]uint64)
+	getGaugeVecPerLabel(
+		(*metricsFamilies)["immudb_last_committed_trx_id"].GetMetric(),
+		"db",
+		&lastCommittedTxs)
+
+	indexingLags := make(map[string]uint64)
+	getGaugeVecPerLabel(
+		(*metricsFamilies)["immudb_indexing_lag_trxs"].GetMetric(),
+		"db",
+		&indexingLags)
+
+	// Sessions
+	activeSessions := make(map[string]uint64)
+	getGaugeVecPerLabel(
+		(*metricsFamilies)["immudb_active_sessions"].GetMetric(),
+		"db",
+		&activeSessions)
+
+	// Cache lookups of all the store caches
+	cacheHits := make(map[string]uint64)
+	cacheMisses := make(map[string]uint64)
+	for _, m := range (*metricsFamilies)["immudb_store_cache_requests"].GetMetric() {
+		var db, result string
+		for _, labelPair := range m.GetLabel() {
+			switch labelPair.GetName() {
+			case "db":
+				db = labelPair.GetValue()
+			case "result":
+				result = labelPair.GetValue()
+			}
+		}
+		switch result {
+		case "hit":
+			cacheHits[db] += uint64(m.GetCounter().GetValue())
+		case "miss":
+			cacheMisses[db] += uint64(m.GetCounter().GetValue())
+		}
+	}
+
 	// aggregate all metrics to db info structs
 	dbInfos := make(map[string]dbInfo, int(math.Max(float64(len(dbSizes)), float64(len(nbsEntries)))))
-	for db, dbSize := range dbSizes {
-		currDBInfo := dbInfos[db]
-		currDBInfo.name = db
-		currDBInfo.totalBytes = dbSize
-		dbInfos[db] = currDBInfo
-	}
-	for db, nbEntries := range nbsEntries {
-		currDBInfo := dbInfos[db]
-		currDBInfo.name = db
-		currDBInfo.nbEntries = nbEntries
-		dbInfos[db] = currDBInfo
+	aggregate := func(values map[string]uint64, set func(info *dbInfo, v uint64)) {
+		for db, v := range values {
+			currDBInfo := dbInfos[db]
+			currDBInfo.name = db
+			set(&currDBInfo, v)
+			dbInfos[db] = currDBInfo
+		}
 	}
+	aggregate(dbSizes, func(info *dbInfo, v uint64) { info.totalBytes = v })
+	aggregate(nbsEntries, func(info *dbInfo, v uint64) { info.nbEntries = v })
+	aggregate(lastCommittedTxs, func(info *dbInfo, v uint64) { info.lastCommittedTx = v })
+	aggregate(indexingLags, func(info *dbInfo, v uint64) { info.indexingLag = v })
+	aggregate(activeSessions, func(info *dbInfo, v uint64) { info.activeSessions = v })
+	aggregate(cacheHits, func(info *dbInfo, v uint64) { info.cacheHits = v })
+	aggregate(cacheMisses, func(info *dbInfo, v uint64) { info.cacheMisses = v })
 
 	ms.dbs = dbInfos
 }
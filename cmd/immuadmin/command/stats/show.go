@@ -112,3 +112,9 @@ func ShowMetricsVisually(serverAddress string) error {
 	su := statsui{Loader: newMetricsLoader(metricsURL(serverAddress)), Tui: tui{}}
 	return su.runUI(false)
 }
+
+// ShowMetricsLive shows the statistics of every database as a table refreshed in place
+func ShowMetricsLive(serverAddress string) error {
+	su := statsui{Loader: newMetricsLoader(metricsURL(serverAddress)), Tui: tui{}, Live: true}
+	return su.runUI(false)
+}
@@ -31,6 +31,8 @@ type statsui struct {
 	cntrl  Controller
 	Loader MetricsLoader
 	Tui    Tui
+	// Live renders the per-database statistics table instead of the plots
+	Live bool
 }
 
 func (s statsui) loadAndRender() error {
@@ -52,7 +54,11 @@ func (s statsui) runUI(singleRun bool) error {
 	if err != nil {
 		return err
 	}
-	s.cntrl = newStatsController(ms.isHistogramsDataAvailable(), s.Tui)
+	if s.Live {
+		s.cntrl = newLiveController(s.Tui)
+	} else {
+		s.cntrl = newStatsController(ms.isHistogramsDataAvailable(), s.Tui)
+	}
 	if err := s.loadAndRender(); err != nil {
 		return err
 	}
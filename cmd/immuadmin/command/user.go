@@ -43,12 +43,13 @@ func (cl *commandline) user(cmd *cobra.Command) {
 		Short: "List all users",
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			resp, err := cl.userList(args)
+			userlist, err := cl.immuClient.ListUsers(cl.context)
 			if err != nil {
 				c.QuitToStdErr(err)
 			}
-			fmt.Fprint(cmd.OutOrStdout(), resp)
-			return nil
+			return cl.printResult(cmd.OutOrStdout(), usersOutputOf(userlist), func() {
+				fmt.Fprint(cmd.OutOrStdout(), formatUserList(userlist))
+			})
 		},
 		Args: cobra.MaximumNArgs(0),
 	}
@@ -64,8 +65,7 @@ immuadmin user create user1 admin mydb`,
 			if err != nil {
 				c.QuitToStdErr(err)
 			}
-			fmt.Fprint(cmd.OutOrStdout(), resp)
-			return nil
+			return cl.printMessage(cmd.OutOrStdout(), "%s", resp)
 		},
 		Args: cobra.RangeArgs(2, 3),
 	}
@@ -83,10 +83,10 @@ immuadmin user create user1 admin mydb`,
 					return fmt.Errorf("Error Reading Password")
 				}
 			}
-			if resp, _, err = cl.changeUserPassword(username, oldpass); err == nil {
-				fmt.Fprint(cmd.OutOrStdout(), resp)
+			if resp, _, err = cl.changeUserPassword(username, oldpass); err != nil {
+				return err
 			}
-			return err
+			return cl.printMessage(cmd.OutOrStdout(), "%s", resp)
 		},
 		Args: cobra.ExactArgs(1),
 	}
@@ -95,10 +95,10 @@ immuadmin user create user1 admin mydb`,
 		Short: "Activate a user",
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			var resp string
-			if resp, err = cl.setActiveUser(args, true); err == nil {
-				fmt.Fprint(cmd.OutOrStdout(), resp)
+			if resp, err = cl.setActiveUser(args, true); err != nil {
+				return err
 			}
-			return err
+			return cl.printMessage(cmd.OutOrStdout(), "%s", resp)
 		},
 		Args: cobra.ExactArgs(1),
 	}
@@ -107,10 +107,10 @@ immuadmin user create user1 admin mydb`,
 		Short: "Deactivate a user",
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			var resp string
-			if resp, err = cl.setActiveUser(args, false); err == nil {
-				fmt.Fprint(cmd.OutOrStdout(), resp)
+			if resp, err = cl.setActiveUser(args, false); err != nil {
+				return err
 			}
-			return err
+			return cl.printMessage(cmd.OutOrStdout(), "%s", resp)
 		},
 		Args: cobra.ExactArgs(1),
 	}
@@ -119,19 +119,29 @@ immuadmin user create user1 admin mydb`,
 		Short:   "Set user permission",
 		Example: "immuadmin user permission grant user1 readwrite mydb",
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			if _, err = cl.setUserPermission(args); err == nil {
-				fmt.Fprintf(cmd.OutOrStdout(), "Permission changed successfully")
+			if _, err = cl.setUserPermission(args); err != nil {
+				return err
 			}
-			return err
+			return cl.printMessage(cmd.OutOrStdout(), "Permission changed successfully")
 		},
 		Args: cobra.ExactValidArgs(4),
 	}
+	userSessions := &cobra.Command{
+		Use:     "sessions",
+		Short:   "List the sessions opened by a user",
+		Example: "immuadmin user sessions user1",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cl.listSessions(cmd.OutOrStdout(), args[0])
+		},
+		Args: cobra.ExactArgs(1),
+	}
 	ccmd.AddCommand(userListCmd)
 	ccmd.AddCommand(userCreate)
 	ccmd.AddCommand(userChangePassword)
 	ccmd.AddCommand(userActivate)
 	ccmd.AddCommand(userDeactivate)
 	ccmd.AddCommand(userPermission)
+	ccmd.AddCommand(userSessions)
 	cmd.AddCommand(ccmd)
 }
 
@@ -161,6 +171,46 @@ func (cl *commandline) userList(args []string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return formatUserList(userlist), nil
+}
+
+// userOutput is a user as marshalled in machine-readable output formats
+type userOutput struct {
+	User        string             `json:"user"`
+	Active      bool               `json:"active"`
+	Permissions []permissionOutput `json:"permissions"`
+	CreatedBy   string             `json:"createdBy"`
+	CreatedAt   string             `json:"createdAt"`
+}
+
+type permissionOutput struct {
+	Database   string `json:"database"`
+	Permission string `json:"permission"`
+}
+
+func usersOutputOf(userlist *schema.UserList) []*userOutput {
+	users := make([]*userOutput, 0, len(userlist.GetUsers()))
+
+	for _, user := range userlist.GetUsers() {
+		u := &userOutput{
+			User:        string(user.GetUser()),
+			Active:      user.GetActive(),
+			Permissions: make([]permissionOutput, 0, len(user.GetPermissions())),
+			CreatedBy:   user.Createdby,
+			CreatedAt:   user.Createdat,
+		}
+
+		for _, p := range user.GetPermissions() {
+			u.Permissions = append(u.Permissions, permissionOutput{Database: p.Database, Permission: permissionToString(p.Permission)})
+		}
+
+		users = append(users, u)
+	}
+
+	return users
+}
+
+func formatUserList(userlist *schema.UserList) string {
 	users := userlist.GetUsers()
 	usersAndPermissions := make([][]string, 0, len(users))
 	maxColWidths := make([]int, 6)
@@ -205,7 +255,7 @@ func (cl *commandline) userList(args []string) (string, error) {
 		fmt.Sprintf("%d user(s)", len(users)),
 	)
 	w.Flush()
-	return b.String(), nil
+	return b.String()
 }
 
 func updateMaxLen(maxs []int, strs []string) {
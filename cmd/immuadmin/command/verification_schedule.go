@@ -0,0 +1,180 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"time"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) verificationSchedule(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "verification-schedule",
+		Short:             "Manage the verifications of databases run by the server on a schedule",
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"set", "delete", "list", "runs"},
+	}
+
+	cs := &cobra.Command{
+		Use:   "set",
+		Short: "Schedule verifications of a database",
+		Long: "Make the server verify a database at the times set by a cron expression. Either all the transactions " +
+			"or a sample of them picked at random are checked to be stored as committed and linked to each other, " +
+			"the result of every run is kept in the system database.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "set {database_name} --cron '0 2 * * *' [--sample-size 1000]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cron, err := cmd.Flags().GetString("cron")
+			if err != nil {
+				return err
+			}
+			sampleSize, err := cmd.Flags().GetUint32("sample-size")
+			if err != nil {
+				return err
+			}
+
+			sch := &schema.VerificationSchedule{
+				Database:   args[0],
+				Cron:       cron,
+				SampleSize: sampleSize,
+			}
+
+			if err := cl.immuClient.SetVerificationSchedule(cl.context, sch); err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "verifications of database '%s' successfully scheduled at '%s'\n", args[0], cron)
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cs.Flags().String("cron", "", "cron expression (minute hour day-of-month month day-of-week) or descriptor (i.e. @daily) setting when verifications are run")
+	cs.Flags().Uint32("sample-size", 0, "number of transactions picked at random and verified on each run, all of them are verified when not set")
+	cs.MarkFlagRequired("cron")
+
+	cd := &cobra.Command{
+		Use:               "delete",
+		Short:             "Stop verifying a database on a schedule, the results of past runs are kept",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "delete {database_name}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cl.immuClient.DeleteVerificationSchedule(cl.context, &schema.Database{DatabaseName: args[0]}); err != nil {
+				return err
+			}
+
+			return cl.printMessage(cmd.OutOrStdout(), "scheduled verifications of database '%s' successfully removed\n", args[0])
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	cls := &cobra.Command{
+		Use:               "list",
+		Short:             "List the scheduled verifications along with the outcome of their last run",
+		Aliases:           []string{"l"},
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := cl.immuClient.ListVerificationSchedules(cl.context)
+			if err != nil {
+				return err
+			}
+			return cl.printResult(cmd.OutOrStdout(), resp, func() {
+				c.PrintTable(
+					cmd.OutOrStdout(),
+					[]string{"Database Name", "Cron", "Sample Size", "Last Run", "Last Error"},
+					len(resp.Schedules),
+					func(i int) []string {
+						sch := resp.Schedules[i]
+
+						sampleSize := "all"
+						if sch.SampleSize > 0 {
+							sampleSize = fmt.Sprintf("%d", sch.SampleSize)
+						}
+
+						lastRun := "-"
+						if sch.LastRunAt > 0 {
+							lastRun = time.Unix(sch.LastRunAt, 0).Format(time.RFC3339)
+						}
+
+						return []string{
+							sch.Database,
+							sch.Cron,
+							sampleSize,
+							lastRun,
+							sch.LastError,
+						}
+					},
+					fmt.Sprintf("%d verification schedule(s)", len(resp.Schedules)),
+				)
+			})
+		},
+		Args: cobra.ExactArgs(0),
+	}
+
+	cr := &cobra.Command{
+		Use:               "runs",
+		Short:             "List the results of the scheduled verifications of a database",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		Example:           "runs {database_name}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := cl.immuClient.ListVerificationRuns(cl.context, &schema.Database{DatabaseName: args[0]})
+			if err != nil {
+				return err
+			}
+			return cl.printResult(cmd.OutOrStdout(), resp, func() {
+				c.PrintTable(
+					cmd.OutOrStdout(),
+					[]string{"Started At", "Duration", "Sample Size", "From Tx", "To Tx", "Checked", "Verified", "Error"},
+					len(resp.Runs),
+					func(i int) []string {
+						run := resp.Runs[i]
+
+						sampleSize := "all"
+						if run.SampleSize > 0 {
+							sampleSize = fmt.Sprintf("%d", run.SampleSize)
+						}
+
+						return []string{
+							time.Unix(run.StartedAt, 0).Format(time.RFC3339),
+							(time.Duration(run.Duration) * time.Millisecond).String(),
+							sampleSize,
+							fmt.Sprintf("%d", run.FromTx),
+							fmt.Sprintf("%d", run.ToTx),
+							fmt.Sprintf("%d", run.Checked),
+							fmt.Sprintf("%t", run.Verified),
+							run.Error,
+						}
+					},
+					fmt.Sprintf("%d verification run(s)", len(resp.Runs)),
+				)
+			})
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	ccmd.AddCommand(cs)
+	ccmd.AddCommand(cd)
+	ccmd.AddCommand(cls)
+	ccmd.AddCommand(cr)
+	cmd.AddCommand(ccmd)
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuaudit
+
+import (
+	"crypto/ecdsa"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/codenotary/immudb/cmd/docs/man"
+	"github.com/codenotary/immudb/cmd/version"
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/auditor"
+	"github.com/codenotary/immudb/pkg/client/cache"
+	"github.com/codenotary/immudb/pkg/client/state"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+const alertRequestTimeout = 5 * time.Second
+
+// NewCmd creates a new immuaudit command
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "immuaudit",
+		Short: "Continuously audit the databases of many immudb servers",
+		Long: `Continuously audit the databases of many immudb servers.
+At each interval the current state of a database of every server is fetched and verified
+to be consistent with the last state trusted by the auditor, databases are audited in turn.
+Trusted states are persisted in the history folder, so they survive restarts of the auditor.
+Divergences, e.g. states that are not consistent, that are behind the trusted ones or whose
+signature can't be verified, are raised as alerts posted to the alert webhook, sent by email
+or triggered on PagerDuty.`,
+		Example: `  immuaudit --servers 127.0.0.1:3322
+  immuaudit --servers db1:3322,db2:3322 --databases prod --interval 30s --alert-webhook-url http://alerts.local/immudb`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			servers, err := cmd.Flags().GetStringSlice("servers")
+			if err != nil {
+				return err
+			}
+
+			username, err := cmd.Flags().GetString("username")
+			if err != nil {
+				return err
+			}
+
+			password, err := cmd.Flags().GetString("password")
+			if err != nil {
+				return err
+			}
+
+			databases, err := cmd.Flags().GetStringSlice("databases")
+			if err != nil {
+				return err
+			}
+
+			interval, err := cmd.Flags().GetDuration("interval")
+			if err != nil {
+				return err
+			}
+
+			singleRun, err := cmd.Flags().GetBool("single-run")
+			if err != nil {
+				return err
+			}
+
+			historyDir, err := cmd.Flags().GetString("history-dir")
+			if err != nil {
+				return err
+			}
+
+			alertWebhookURL, err := cmd.Flags().GetString("alert-webhook-url")
+			if err != nil {
+				return err
+			}
+
+			serverSigningPubKey, err := cmd.Flags().GetString("server-signing-pub-key")
+			if err != nil {
+				return err
+			}
+
+			var pk *ecdsa.PublicKey
+			if serverSigningPubKey != "" {
+				pk, err = signer.ParsePublicKeyFile(serverSigningPubKey)
+				if err != nil {
+					return err
+				}
+			}
+
+			cosigningKey, err := cmd.Flags().GetString("cosigning-key")
+			if err != nil {
+				return err
+			}
+
+			smtpAddress, err := cmd.Flags().GetString("alert-smtp-address")
+			if err != nil {
+				return err
+			}
+
+			smtpUsername, err := cmd.Flags().GetString("alert-smtp-username")
+			if err != nil {
+				return err
+			}
+
+			smtpPassword, err := cmd.Flags().GetString("alert-smtp-password")
+			if err != nil {
+				return err
+			}
+
+			emailFrom, err := cmd.Flags().GetString("alert-email-from")
+			if err != nil {
+				return err
+			}
+
+			emailTo, err := cmd.Flags().GetStringSlice("alert-email-to")
+			if err != nil {
+				return err
+			}
+
+			pagerDutyRoutingKey, err := cmd.Flags().GetString("alert-pagerduty-routing-key")
+			if err != nil {
+				return err
+			}
+
+			alerter, err := alert.DefaultOptions().
+				WithWebhookURL(alertWebhookURL).
+				WithSMTPAddress(smtpAddress).
+				WithSMTPCredentials(smtpUsername, smtpPassword).
+				WithEmailFrom(emailFrom).
+				WithEmailTo(emailTo).
+				WithPagerDutyRoutingKey(pagerDutyRoutingKey).
+				WithTimeout(alertRequestTimeout).
+				Alerter()
+			if err != nil {
+				return err
+			}
+
+			var opts []auditor.Option
+			if alerter != nil {
+				opts = append(opts, auditor.WithAlerter(alerter))
+			}
+			if cosigningKey != "" {
+				cosigner, err := signer.NewSigner(cosigningKey)
+				if err != nil {
+					return err
+				}
+				opts = append(opts, auditor.WithCosigner(cosigner))
+			}
+
+			log := logger.NewSimpleLogger("immuaudit ", cmd.ErrOrStderr())
+
+			history := cache.NewHistoryFileCache(historyDir)
+
+			auditors := make([]auditor.Auditor, len(servers))
+
+			for i, address := range servers {
+				dialOptions := []grpc.DialOption{grpc.WithInsecure()}
+
+				conn, err := grpc.Dial(address, dialOptions...)
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+
+				serviceClient := schema.NewImmuServiceClient(conn)
+
+				auditors[i], err = auditor.DefaultAuditor(
+					interval,
+					address,
+					dialOptions,
+					username,
+					password,
+					databases,
+					pk,
+					auditor.AuditNotificationConfig{},
+					serviceClient,
+					state.NewUUIDProvider(serviceClient),
+					history,
+					func(string, string, bool, bool, bool, *schema.ImmutableState, *schema.ImmutableState) {},
+					log,
+					nil,
+					opts...,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			stopc := make(chan struct{})
+
+			terminated := make(chan os.Signal, 1)
+			signal.Notify(terminated, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(terminated)
+
+			go func() {
+				<-terminated
+				close(stopc)
+			}()
+
+			donec := make(chan struct{}, 1)
+
+			return auditor.NewMultiAuditor(auditors...).Run(interval, singleRun, stopc, donec)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().StringSlice("servers", []string{"127.0.0.1:3322"}, "comma separated addresses of the immudb servers to be audited, i.e. host:port")
+	cmd.Flags().String("username", "immudb", "username used to login, it must have access to the audited databases")
+	cmd.Flags().String("password", "immudb", "password used to login, it can be base64 encoded with the enc: prefix")
+	cmd.Flags().StringSlice("databases", nil, "comma separated prefixes of the names of the databases to be audited (all the databases are audited when empty)")
+	cmd.Flags().Duration("interval", time.Minute, "interval between audits, one database per server is audited at each interval")
+	cmd.Flags().Bool("single-run", false, "audit a single database per server and exit")
+	cmd.Flags().String("history-dir", "./immuaudit_history", "folder where the states trusted by the auditor are persisted")
+	cmd.Flags().String("alert-webhook-url", "", "url alerts are posted to as json (alerts are only logged when empty)")
+	cmd.Flags().String("alert-smtp-address", "", "address (host:port) of the SMTP server alerts are sent by email through (email alerts are disabled when empty)")
+	cmd.Flags().String("alert-smtp-username", "", "username used to authenticate to the SMTP server (no authentication when empty)")
+	cmd.Flags().String("alert-smtp-password", "", "password used to authenticate to the SMTP server")
+	cmd.Flags().String("alert-email-from", "", "sender of the alert emails")
+	cmd.Flags().StringSlice("alert-email-to", nil, "comma separated recipients of the alert emails")
+	cmd.Flags().String("alert-pagerduty-routing-key", "", "integration key of the PagerDuty service alerts are triggered on (PagerDuty alerts are disabled when empty)")
+	cmd.Flags().String("server-signing-pub-key", "", "path to the public key verifying the signature of the states sent by the servers")
+	cmd.Flags().String("cosigning-key", "", "path to the private key the states verified by the auditor are cosigned with (cosigning is disabled when empty)")
+
+	cmd.AddCommand(man.Generate(cmd, "immuaudit", "./cmd/docs/man/immuaudit"))
+	cmd.AddCommand(version.VersionCmd())
+
+	return cmd
+}
@@ -36,11 +36,14 @@ type cli struct {
 	helpMessage  string
 	valueOnly    bool
 	isLoggedin   bool
+	historyFile  string
+	recentKeys   []string
 }
 
 // Cli ...
 type Cli interface {
 	Run()
+	RunScript(r io.Reader, w io.Writer) error
 	HelpMessage() string
 }
 
@@ -49,6 +52,7 @@ func Init(immucl immuc.Client) Cli {
 	cli := new(cli)
 	cli.immucl = immucl
 	cli.valueOnly = viper.GetBool("value-only")
+	cli.historyFile = viper.GetString("history-file")
 	cli.commands = make(map[string]*command)
 	cli.commandsList = make([]*command, 0)
 	cli.initCommands()
@@ -100,8 +104,11 @@ func (cli *cli) helpInit() {
 
 func (cli *cli) Run() {
 	l := liner.NewLiner()
-	l.SetCompleter(cli.completer)
+	l.SetWordCompleter(cli.wordCompleter)
+	l.SetTabCompletionStyle(liner.TabPrints)
 	defer l.Close()
+	cli.loadHistory(l)
+	defer cli.persistHistory(l)
 	for {
 		line, err := l.Prompt("immuclient>")
 		if err == liner.ErrInvalidPrompt {
@@ -125,6 +132,7 @@ func (cli *cli) Run() {
 		passed := cli.checkCommand(arrCommandStr, l)
 		if passed {
 			cli.runCommand(arrCommandStr)
+			cli.rememberKeys(arrCommandStr)
 		}
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -138,6 +146,7 @@ func (cli *cli) checkCommand(arrCommandStr []string, l *liner.State) bool {
 			logoutmsg, _ := cli.logout(nil)
 			fmt.Println(logoutmsg)
 		}
+		cli.persistHistory(l)
 		l.Close()
 		os.Exit(0)
 	}
@@ -323,5 +323,5 @@ func TestImmuClient_BackupAndRestoreUX(t *testing.T) {
 
 	_, err = cl.safeGetKey([]string{"key3"})
 
-	require.Equal(t, client.ErrServerStateIsOlder, err)
+	require.ErrorIs(t, err, client.ErrServerStateIsOlder)
 }
@@ -0,0 +1,173 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/peterh/liner"
+)
+
+// maxRecentKeys is the number of recently used keys offered when completing key arguments
+const maxRecentKeys = 100
+
+// loadHistory reads the history persisted by previous runs, if any, and collects the keys used in it
+func (cli *cli) loadHistory(l *liner.State) {
+	if cli.historyFile == "" {
+		return
+	}
+
+	b, err := ioutil.ReadFile(cli.historyFile)
+	if err != nil {
+		return
+	}
+
+	l.ReadHistory(bytes.NewReader(b))
+
+	for _, line := range strings.Split(string(b), "\n") {
+		cli.rememberKeys(strings.Fields(line))
+	}
+}
+
+// saveHistory persists the history so it is available to later runs
+func (cli *cli) saveHistory(l *liner.State) error {
+	if cli.historyFile == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(cli.historyFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = l.WriteHistory(f)
+	return err
+}
+
+func (cli *cli) persistHistory(l *liner.State) {
+	err := cli.saveHistory(l)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: unable to save history: %s \n", err.Error())
+	}
+}
+
+// rememberKeys records the keys provided as arguments of a command line, most recent last
+func (cli *cli) rememberKeys(arrCommandStr []string) {
+	if len(arrCommandStr) < 2 {
+		return
+	}
+
+	cmd, ok := cli.commands[arrCommandStr[0]]
+	if !ok || cmd.variable {
+		return
+	}
+
+	for i, arg := range cmd.args {
+		if i+1 >= len(arrCommandStr) {
+			break
+		}
+		if arg != "key" && arg != "refkey" {
+			continue
+		}
+
+		key := arrCommandStr[i+1]
+
+		for j, k := range cli.recentKeys {
+			if k == key {
+				cli.recentKeys = append(cli.recentKeys[:j], cli.recentKeys[j+1:]...)
+				break
+			}
+		}
+
+		cli.recentKeys = append(cli.recentKeys, key)
+
+		if len(cli.recentKeys) > maxRecentKeys {
+			cli.recentKeys = cli.recentKeys[len(cli.recentKeys)-maxRecentKeys:]
+		}
+	}
+}
+
+// wordCompleter completes the word under the cursor: command names first,
+// then database names for the use command and recently used keys for key arguments
+func (cli *cli) wordCompleter(line string, pos int) (head string, completions []string, tail string) {
+	// pos is expressed in runes
+	runes := []rune(line)
+	head, tail = string(runes[:pos]), string(runes[pos:])
+
+	wordStart := strings.LastIndexAny(head, " \t") + 1
+	word := head[wordStart:]
+	head = head[:wordStart]
+
+	fields := strings.Fields(head)
+	if len(fields) == 0 {
+		return head, cli.completer(word), tail
+	}
+
+	cmd, ok := cli.commands[fields[0]]
+	if !ok || cmd.variable || len(fields) > len(cmd.args) {
+		return head, nil, tail
+	}
+
+	var candidates []string
+
+	switch cmd.args[len(fields)-1] {
+	case "databasename":
+		candidates = cli.databaseNames()
+	case "key", "refkey", "prefix":
+		// most recently used keys are offered first
+		for i := len(cli.recentKeys) - 1; i >= 0; i-- {
+			candidates = append(candidates, cli.recentKeys[i])
+		}
+	}
+
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			completions = append(completions, c)
+		}
+	}
+
+	return head, completions, tail
+}
+
+// databaseNames returns the databases the current user has access to, none when it can't be fetched i.e. not being logged in
+func (cli *cli) databaseNames() []string {
+	if cli.immucl == nil {
+		return nil
+	}
+
+	resp, err := cli.immucl.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		return immuClient.DatabaseList(context.Background())
+	})
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, db := range resp.(*schema.DatabaseListResponse).Databases {
+		names = append(names, db.DatabaseName)
+	}
+
+	return names
+}
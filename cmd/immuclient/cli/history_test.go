@@ -0,0 +1,119 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peterh/liner"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCli() *cli {
+	cli := new(cli)
+	cli.commands = make(map[string]*command)
+	cli.commandsList = make([]*command, 0)
+	cli.initCommands()
+	return cli
+}
+
+func TestRememberKeys(t *testing.T) {
+	cli := newTestCli()
+
+	cli.rememberKeys([]string{"set", "key1", "value1"})
+	cli.rememberKeys([]string{"get", "key2"})
+	cli.rememberKeys([]string{"reference", "ref1", "key1"})
+	cli.rememberKeys([]string{"zadd", "set1", "1", "key3"})
+	cli.rememberKeys([]string{"exec", "select", "key4"})
+	cli.rememberKeys([]string{"unknown", "key5"})
+	cli.rememberKeys([]string{"get"})
+
+	require.Equal(t, []string{"key2", "ref1", "key1", "key3"}, cli.recentKeys)
+
+	for i := 0; i < maxRecentKeys+10; i++ {
+		cli.rememberKeys([]string{"get", fmt.Sprintf("k%d", i)})
+	}
+
+	require.Len(t, cli.recentKeys, maxRecentKeys)
+	require.Equal(t, fmt.Sprintf("k%d", maxRecentKeys+9), cli.recentKeys[maxRecentKeys-1])
+}
+
+func TestWordCompleter(t *testing.T) {
+	cli := newTestCli()
+
+	head, completions, tail := cli.wordCompleter("saf", 3)
+	require.Equal(t, "", head)
+	require.Len(t, completions, 5)
+	require.Equal(t, "", tail)
+
+	cli.rememberKeys([]string{"set", "apple", "v"})
+	cli.rememberKeys([]string{"set", "apricot", "v"})
+	cli.rememberKeys([]string{"set", "banana", "v"})
+
+	head, completions, tail = cli.wordCompleter("get ap", 6)
+	require.Equal(t, "get ", head)
+	require.Equal(t, []string{"apricot", "apple"}, completions)
+	require.Equal(t, "", tail)
+
+	head, completions, tail = cli.wordCompleter("set b value", 5)
+	require.Equal(t, "set ", head)
+	require.Equal(t, []string{"banana"}, completions)
+	require.Equal(t, " value", tail)
+
+	// values are not completed
+	_, completions, _ = cli.wordCompleter("set apple a", 11)
+	require.Empty(t, completions)
+
+	_, completions, _ = cli.wordCompleter("unknown a", 9)
+	require.Empty(t, completions)
+
+	// databases can't be listed without a client
+	_, completions, _ = cli.wordCompleter("use ", 4)
+	require.Empty(t, completions)
+}
+
+func TestHistoryFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immuclient_history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cli := newTestCli()
+	cli.historyFile = filepath.Join(dir, "history")
+
+	l := liner.NewLiner()
+	defer l.Close()
+
+	// nothing to read on the first run
+	cli.loadHistory(l)
+	require.Empty(t, cli.recentKeys)
+
+	l.AppendHistory("set key1 value1")
+	l.AppendHistory("get key2")
+	require.NoError(t, cli.saveHistory(l))
+
+	cli = newTestCli()
+	cli.historyFile = filepath.Join(dir, "history")
+	cli.loadHistory(l)
+	require.Equal(t, []string{"key1", "key2"}, cli.recentKeys)
+
+	cli.historyFile = ""
+	require.NoError(t, cli.saveHistory(l))
+}
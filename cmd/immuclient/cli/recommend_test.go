@@ -31,5 +31,5 @@ func TestCorrect(t *testing.T) {
 	assert.EqualValues(t, 2, len(cm))
 
 	cm = cli.correct("safe")
-	assert.EqualValues(t, 4, len(cm))
+	assert.EqualValues(t, 5, len(cm))
 }
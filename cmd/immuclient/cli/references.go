@@ -23,3 +23,11 @@ func (cli *cli) reference(args []string) (string, error) {
 func (cli *cli) safereference(args []string) (string, error) {
 	return cli.immucl.VerifiedSetReference(args)
 }
+
+func (cli *cli) getReference(args []string) (string, error) {
+	return cli.immucl.GetReference(args)
+}
+
+func (cli *cli) safeGetReference(args []string) (string, error) {
+	return cli.immucl.VerifiedGetReference(args)
+}
@@ -49,6 +49,8 @@ func (cli *cli) initCommands() {
 	// Reference commands
 	cli.Register(&command{"reference", "Add new reference to an existing key", cli.reference, []string{"refkey", "key"}, false})
 	cli.Register(&command{"safereference", "Add and verify new reference to an existing key", cli.safereference, []string{"refkey", "key"}, false})
+	cli.Register(&command{"getreference", "Get a reference and the item it refers to", cli.getReference, []string{"refkey"}, false})
+	cli.Register(&command{"safegetreference", "Get and verify a reference and the item it refers to", cli.safeGetReference, []string{"refkey"}, false})
 
 	// Scannner commands
 	cli.Register(&command{"scan", "Iterate over keys having the specified prefix", cli.scan, []string{"prefix"}, false})
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RunScript executes the commands read from r, one per line, writing their results to w.
+// Empty lines and lines starting with '#' are skipped, lines not starting with a command are
+// executed as SQL statements. The script is aborted at the first line failing, the returned
+// error tells which one it is.
+func (cli *cli) RunScript(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args := strings.Fields(line)
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+
+		result, err := cli.runScriptLine(line, args)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+
+		fmt.Fprintln(w, strings.TrimRight(result, " \n"))
+	}
+
+	return scanner.Err()
+}
+
+func (cli *cli) runScriptLine(line string, args []string) (string, error) {
+	command, ok := cli.commands[args[0]]
+	if !ok {
+		if strings.EqualFold(args[0], "SELECT") {
+			return cli.immucl.SQLQuery([]string{line})
+		}
+		return cli.immucl.SQLExec([]string{line})
+	}
+
+	if len(args[1:]) < len(command.args) {
+		return "", fmt.Errorf("not enough arguments, %s needs %d, have %d", command.name, len(command.args), len(args[1:]))
+	}
+
+	return command.command(args[1:])
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/cmd/cmdtest"
+	test "github.com/codenotary/immudb/cmd/immuclient/immuclienttest"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScript(t *testing.T) {
+	cli := new(cli)
+	cli.commands = make(map[string]*command, 0)
+	cli.commandsList = make([]*command, 0)
+	cli.initCommands()
+
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	tkf := cmdtest.RandString()
+	ts := tokenservice.NewFileTokenService().WithTokenFileName(tkf)
+	ic := test.NewClientTest(&test.PasswordReader{
+		Pass: []string{"immudb"},
+	}, ts)
+	ic.Connect(bs.Dialer)
+	ic.Login("immudb")
+
+	cli.immucl = ic.Imc
+
+	script := `# keys and a table
+set key1 value1
+
+CREATE TABLE t1 (id INTEGER, title VARCHAR, PRIMARY KEY id)
+UPSERT INTO t1 (id, title) VALUES (1, 'title1')
+get key1
+SELECT id, title FROM t1
+`
+
+	w := &bytes.Buffer{}
+	err := cli.RunScript(strings.NewReader(script), w)
+	require.NoError(t, err)
+	require.Contains(t, w.String(), "value:		value1")
+	require.Contains(t, w.String(), "Updated rows: 1")
+	require.Contains(t, w.String(), "title1")
+
+	t.Run("fail fast", func(t *testing.T) {
+		script := `set key2 value2
+UPSERT INTO t2 (id) VALUES (1)
+set key3 value3
+`
+
+		w := &bytes.Buffer{}
+		err := cli.RunScript(strings.NewReader(script), w)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "line 2:")
+		require.Contains(t, w.String(), "value2")
+		require.NotContains(t, w.String(), "value3")
+	})
+
+	t.Run("missing arguments", func(t *testing.T) {
+		err := cli.RunScript(strings.NewReader("set key4\n"), &bytes.Buffer{})
+		require.EqualError(t, err, "line 1: not enough arguments, set needs 2, have 1")
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		w := &bytes.Buffer{}
+		err := cli.RunScript(strings.NewReader("exit\nset key5 value5\n"), w)
+		require.NoError(t, err)
+		require.Empty(t, w.String())
+	})
+}
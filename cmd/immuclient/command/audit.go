@@ -0,0 +1,172 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/auditor"
+	"github.com/codenotary/immudb/pkg/client/cache"
+	"github.com/codenotary/immudb/pkg/client/state"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (cl *commandline) audit(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:   "audit [--interval 1m] [--trust-store dir]",
+		Short: "Continuously verify the consistency of the state of the server",
+		Long: `Continuously verify the state of the databases of the server is consistent with the states trusted so far.
+Databases are audited in turn, one at each interval. Trusted states are persisted in the trust store folder,
+so they survive restarts. The server is accessed with the audit-username and audit-password settings and
+the databases audited can be restricted with the audit-databases one.
+On any divergence, i.e. a state that is not consistent with the trusted one, that is behind it or whose
+signature can't be verified, the divergence is reported on stderr and the command exits with a non-zero
+status, making it suitable for running as a systemd service.`,
+		Example:           "immuclient audit --interval 1m --trust-store /var/lib/immuclient/trust-store",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			interval, _ := cmd.Flags().GetDuration("interval")
+			trustStore, _ := cmd.Flags().GetString("trust-store")
+
+			if interval <= 0 {
+				cl.quit(errors.New("the audit interval must be positive"))
+				return nil
+			}
+
+			username := viper.GetString("audit-username")
+			if username == "" {
+				cl.quit(errors.New("audit-username is required to access the server"))
+				return nil
+			}
+
+			_, err := cl.immucl.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+				return nil, runAudit(immuClient, username, interval, trustStore, cmd.ErrOrStderr())
+			})
+
+			var divergence *auditor.DivergenceError
+			if errors.As(err, &divergence) {
+				fprintln(cmd.ErrOrStderr(), divergenceReport(divergence.Alert))
+			}
+			if err != nil {
+				cl.quit(err)
+			}
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+	ccmd.Flags().Duration("interval", time.Minute, "interval between audits, one database is audited at each interval")
+	ccmd.Flags().String("trust-store", "./immuclient_trust_store", "folder where the trusted states are persisted")
+	cmd.AddCommand(ccmd)
+}
+
+// runAudit audits the server until a divergence is detected or the process is terminated
+func runAudit(immuClient client.ImmuClient, username string, interval time.Duration, trustStore string, logOut io.Writer) error {
+	opts := immuClient.GetOptions()
+
+	var pk *ecdsa.PublicKey
+	if opts.ServerSigningPubKey != "" {
+		var err error
+
+		pk, err = signer.ParsePublicKeyFile(opts.ServerSigningPubKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	var databases []string
+	for _, db := range strings.Split(viper.GetString("audit-databases"), ",") {
+		db = strings.TrimSpace(db)
+		if len(db) > 0 {
+			databases = append(databases, db)
+		}
+	}
+
+	serviceClient := immuClient.GetServiceClient()
+
+	a, err := auditor.DefaultAuditor(
+		interval,
+		fmt.Sprintf("%s:%d", opts.Address, opts.Port),
+		opts.DialOptions,
+		username,
+		viper.GetString("audit-password"),
+		databases,
+		pk,
+		auditor.AuditNotificationConfig{},
+		serviceClient,
+		state.NewUUIDProvider(serviceClient),
+		cache.NewHistoryFileCache(trustStore),
+		func(string, string, bool, bool, bool, *schema.ImmutableState, *schema.ImmutableState) {},
+		logger.NewSimpleLogger("immuclient audit ", logOut),
+		nil,
+		auditor.WithStopOnDivergence(),
+	)
+	if err != nil {
+		return err
+	}
+
+	stopc := make(chan struct{})
+
+	terminated := make(chan os.Signal, 1)
+	signal.Notify(terminated, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(terminated)
+
+	go func() {
+		<-terminated
+		close(stopc)
+	}()
+
+	donec := make(chan struct{}, 1)
+
+	return a.Run(interval, false, stopc, donec)
+}
+
+func divergenceReport(alert *auditor.Alert) string {
+	var b strings.Builder
+
+	b.WriteString("********************************************************************************\n")
+	fmt.Fprintf(&b, "  DIVERGENCE DETECTED: %s\n", strings.ToUpper(strings.Replace(string(alert.Kind), "_", " ", -1)))
+	b.WriteString("********************************************************************************\n")
+	fmt.Fprintf(&b, "  server:         %s @ %s\n", alert.ServerID, alert.ServerAddress)
+	fmt.Fprintf(&b, "  database:       %s\n", alert.DB)
+	fmt.Fprintf(&b, "  detected at:    %s\n", alert.RaisedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "  details:        %s\n", alert.Message)
+
+	if alert.PreviousState != nil {
+		fmt.Fprintf(&b, "  trusted state:  tx %d, hash %s\n", alert.PreviousState.Tx, alert.PreviousState.Hash)
+	}
+	if alert.CurrentState != nil {
+		fmt.Fprintf(&b, "  server state:   tx %d, hash %s\n", alert.CurrentState.Tx, alert.CurrentState.Hash)
+	}
+
+	b.WriteString("********************************************************************************")
+
+	return b.String()
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client/auditor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDivergenceReport(t *testing.T) {
+	report := divergenceReport(&auditor.Alert{
+		Kind:          auditor.AlertInvalidSignature,
+		ServerID:      "server1",
+		ServerAddress: "127.0.0.1:3322",
+		DB:            "defaultdb",
+		RaisedAt:      time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC),
+		Message:       "invalid signature",
+		CurrentState:  &auditor.State{Tx: 2, Hash: "hash-2"},
+	})
+
+	require.Contains(t, report, "DIVERGENCE DETECTED: INVALID SIGNATURE")
+	require.Contains(t, report, "server1 @ 127.0.0.1:3322")
+	require.Contains(t, report, "defaultdb")
+	require.Contains(t, report, "2022-03-15T03:00:00Z")
+	require.Contains(t, report, "server state:   tx 2, hash hash-2")
+	require.NotContains(t, report, "trusted state")
+}
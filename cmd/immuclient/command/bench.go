@@ -0,0 +1,219 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/bench"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) bench(cmd *cobra.Command) {
+	defaults := bench.DefaultOptions()
+
+	ccmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Generate a key-value load against the current database and report the latency of the operations",
+		Long: "Generate a mix of writes, reads and verified reads from concurrent workers against the current database " +
+			"and report the throughput and the latency percentiles of each kind of operation, for capacity planning. " +
+			"The key space is preloaded before the load is generated whenever reads are issued. " +
+			"Benchmarks write to the database, so they should not be run against production databases.",
+		Example:           "immuclient bench --duration 30s --concurrency 16 --read-ratio 0.8 --verified-read-ratio 0.1",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			duration, _ := cmd.Flags().GetDuration("duration")
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			keySize, _ := cmd.Flags().GetInt("key-size")
+			valueSize, _ := cmd.Flags().GetInt("value-size")
+			keys, _ := cmd.Flags().GetInt("keys")
+			readRatio, _ := cmd.Flags().GetFloat64("read-ratio")
+			verifiedReadRatio, _ := cmd.Flags().GetFloat64("verified-read-ratio")
+
+			opts := bench.DefaultOptions().
+				WithDuration(duration).
+				WithConcurrency(concurrency).
+				WithKeySize(keySize).
+				WithValueSize(valueSize).
+				WithKeys(keys).
+				WithReadRatio(readRatio).
+				WithVerifiedReadRatio(verifiedReadRatio)
+
+			report, err := cl.immucl.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+				return bench.Run(context.Background(), immuClient, opts)
+			})
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+
+			cl.printResult(cmd.OutOrStdout(), benchReportOutputOf(report.(*bench.Report)), func() {
+				printBenchReport(cmd.OutOrStdout(), report.(*bench.Report))
+			})
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+	ccmd.Flags().Duration("duration", defaults.Duration, "time the load is generated for")
+	ccmd.Flags().Int("concurrency", defaults.Concurrency, "number of workers issuing operations concurrently")
+	ccmd.Flags().Int("key-size", defaults.KeySize, "size in bytes of the keys")
+	ccmd.Flags().Int("value-size", defaults.ValueSize, "size in bytes of the values being written")
+	ccmd.Flags().Int("keys", defaults.Keys, "number of distinct keys being written and read")
+	ccmd.Flags().Float64("read-ratio", defaults.ReadRatio, "fraction of the operations being reads, from 0 to 1")
+	ccmd.Flags().Float64("verified-read-ratio", defaults.VerifiedReadRatio, "fraction of the reads being verified reads, from 0 to 1")
+
+	verifyDefaults := bench.DefaultVerificationOptions()
+
+	cv := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify proofs of keys and transactions from concurrent workers and report the verification throughput and failures",
+		Long: "Write a set of keys and then have concurrent workers read and verify keys and transactions of the current database, " +
+			"reporting the throughput and the latency percentiles of the verifications along with the proofs that failed to be verified, " +
+			"to validate deployments end-to-end under load. Transactions are picked among all the ones of the database. " +
+			"The command fails when any proof fails to be verified.",
+		Example:           "immuclient bench verify --duration 1m --concurrency 32 --tx-ratio 0.3",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			duration, _ := cmd.Flags().GetDuration("duration")
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			keySize, _ := cmd.Flags().GetInt("key-size")
+			valueSize, _ := cmd.Flags().GetInt("value-size")
+			keys, _ := cmd.Flags().GetInt("keys")
+			txRatio, _ := cmd.Flags().GetFloat64("tx-ratio")
+
+			opts := bench.DefaultVerificationOptions().
+				WithDuration(duration).
+				WithConcurrency(concurrency).
+				WithKeySize(keySize).
+				WithValueSize(valueSize).
+				WithKeys(keys).
+				WithTxRatio(txRatio)
+
+			res, err := cl.immucl.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+				return bench.Verify(context.Background(), immuClient, opts)
+			})
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+
+			report := res.(*bench.Report)
+
+			cl.printResult(cmd.OutOrStdout(), benchReportOutputOf(report), func() {
+				printBenchReport(cmd.OutOrStdout(), report)
+			})
+
+			failures := 0
+			for _, s := range report.Stats {
+				failures += s.Failures
+			}
+			if failures > 0 {
+				cl.quit(fmt.Errorf("%d proofs failed to be verified", failures))
+			}
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+	cv.Flags().Duration("duration", verifyDefaults.Duration, "time proofs are requested and verified for")
+	cv.Flags().Int("concurrency", verifyDefaults.Concurrency, "number of workers requesting proofs concurrently")
+	cv.Flags().Int("key-size", verifyDefaults.KeySize, "size in bytes of the keys")
+	cv.Flags().Int("value-size", verifyDefaults.ValueSize, "size in bytes of the values being written")
+	cv.Flags().Int("keys", verifyDefaults.Keys, "number of distinct keys being written and then verified")
+	cv.Flags().Float64("tx-ratio", verifyDefaults.TxRatio, "fraction of the verifications being of transactions rather than keys, from 0 to 1")
+
+	ccmd.AddCommand(cv)
+	cmd.AddCommand(ccmd)
+}
+
+func printBenchReport(w io.Writer, report *bench.Report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "operation\tcount\terrors\tfailures\tops/s\tp50\tp90\tp99\tmax\t")
+
+	for _, s := range report.Stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n",
+			s.Operation, s.Count, s.Errors, s.Failures, s.Throughput,
+			s.P50.Round(time.Microsecond), s.P90.Round(time.Microsecond),
+			s.P99.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+
+	tw.Flush()
+
+	fprintln(w, fmt.Sprintf("duration: %s", report.Duration.Round(time.Millisecond)))
+
+	if report.Err != nil {
+		fprintln(w, fmt.Sprintf("first error: %v", report.Err))
+	}
+}
+
+// benchReportOutput is the report of a benchmark as marshalled in machine-readable output formats,
+// durations are in milliseconds
+type benchReportOutput struct {
+	Duration float64             `json:"duration"`
+	Stats    []*benchStatsOutput `json:"stats"`
+	Error    string              `json:"error,omitempty"`
+}
+
+type benchStatsOutput struct {
+	Operation  string  `json:"operation"`
+	Count      int     `json:"count"`
+	Errors     int     `json:"errors"`
+	Failures   int     `json:"failures"`
+	Throughput float64 `json:"throughput"`
+	P50        float64 `json:"p50"`
+	P90        float64 `json:"p90"`
+	P99        float64 `json:"p99"`
+	Max        float64 `json:"max"`
+}
+
+func benchReportOutputOf(report *bench.Report) *benchReportOutput {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+
+	out := &benchReportOutput{
+		Duration: ms(report.Duration),
+		Stats:    make([]*benchStatsOutput, len(report.Stats)),
+	}
+
+	for i, s := range report.Stats {
+		out.Stats[i] = &benchStatsOutput{
+			Operation:  string(s.Operation),
+			Count:      s.Count,
+			Errors:     s.Errors,
+			Failures:   s.Failures,
+			Throughput: s.Throughput,
+			P50:        ms(s.P50),
+			P90:        ms(s.P90),
+			P99:        ms(s.P99),
+			Max:        ms(s.Max),
+		}
+	}
+
+	if report.Err != nil {
+		out.Error = report.Err.Error()
+	}
+
+	return out
+}
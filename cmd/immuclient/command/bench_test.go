@@ -0,0 +1,66 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client/bench"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintBenchReport(t *testing.T) {
+	var b bytes.Buffer
+
+	printBenchReport(&b, &bench.Report{
+		Duration: 10 * time.Second,
+		Stats: []*bench.Stats{
+			{
+				Operation:  bench.VerifiedRead,
+				Count:      1000,
+				Errors:     1,
+				Failures:   1,
+				Throughput: 99.9,
+				P50:        2 * time.Millisecond,
+				P90:        3 * time.Millisecond,
+				P99:        5 * time.Millisecond,
+				Max:        8 * time.Millisecond,
+			},
+		},
+		Err: errors.New("some error"),
+	})
+
+	report := b.String()
+
+	require.Contains(t, report, "operation")
+	require.Contains(t, report, "failures")
+	require.Contains(t, report, "verified read")
+	require.Contains(t, report, "99.9")
+	require.Contains(t, report, "5ms")
+	require.Contains(t, report, "duration: 10s")
+	require.Contains(t, report, "first error: some error")
+
+	out := benchReportOutputOf(&bench.Report{
+		Duration: time.Second,
+		Stats:    []*bench.Stats{{Operation: bench.VerifiedTx, Count: 10, Errors: 2, Failures: 2}},
+	})
+	require.Equal(t, "verified tx", out.Stats[0].Operation)
+	require.Equal(t, 2, out.Stats[0].Failures)
+}
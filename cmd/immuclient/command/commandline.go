@@ -1,10 +1,13 @@
 package immuclient
 
 import (
+	"io"
+
 	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/cmd/immuclient/immuc"
 	"github.com/codenotary/immudb/pkg/client"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 type commandline struct {
@@ -26,7 +29,13 @@ func (cl *commandline) ConfigChain(post func(cmd *cobra.Command, args []string)
 		if err = cl.config.LoadConfig(cmd); err != nil {
 			return err
 		}
+		if err = c.ValidateOutputFormat(viper.GetString("output")); err != nil {
+			return err
+		}
 		cl.options = immuc.Options().WithTokenFileName("token")
+		if err = immuc.WithCredentials(cl.options, viper.GetString("profile")); err != nil {
+			return err
+		}
 		cl.immucl, err = immuc.Init(cl.options)
 		if post != nil {
 			return post(cmd, args)
@@ -60,11 +69,15 @@ func (cl *commandline) Register(rootCmd *cobra.Command) *cobra.Command {
 	// references
 	cl.reference(rootCmd)
 	cl.safereference(rootCmd)
+	cl.getReference(rootCmd)
+	cl.safeGetReference(rootCmd)
 	// misc
 	cl.consistency(rootCmd)
+	cl.proof(rootCmd)
 	cl.history(rootCmd)
 	cl.status(rootCmd)
 	cl.auditmode(rootCmd)
+	cl.audit(rootCmd)
 	cl.interactiveCli(rootCmd)
 	cl.use(rootCmd)
 
@@ -73,6 +86,11 @@ func (cl *commandline) Register(rootCmd *cobra.Command) *cobra.Command {
 	cl.listTables(rootCmd)
 	cl.describeTable(rootCmd)
 
+	cl.exportTable(rootCmd)
+	cl.exportPrefix(rootCmd)
+	cl.importFile(rootCmd)
+	cl.bench(rootCmd)
+
 	return rootCmd
 }
 
@@ -90,6 +108,19 @@ func (cl *commandline) disconnect(cmd *cobra.Command, args []string) {
 	}
 }
 
+// printResult writes the result of a command, marshalled when a machine-readable output format is requested
+// and using printText otherwise
+func (cl *commandline) printResult(w io.Writer, result interface{}, printText func()) {
+	format := viper.GetString("output")
+	if !c.IsMachineReadable(format) {
+		printText()
+		return
+	}
+	if err := c.PrintOutput(w, format, result); err != nil {
+		cl.quit(err)
+	}
+}
+
 func (cl *commandline) quit(msg interface{}) {
 	if cl.onError == nil {
 		c.QuitToStdErr(msg)
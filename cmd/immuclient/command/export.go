@@ -0,0 +1,169 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/s3"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/dataexport"
+	"github.com/spf13/cobra"
+)
+
+type exportFunc func(ctx context.Context, c client.ImmuClient, format dataexport.Format, w io.Writer) (*dataexport.Summary, error)
+
+func (cl *commandline) exportTable(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:   "export-table <table> --output <file> [--format csv|parquet]",
+		Short: "Export the rows of a table into a CSV or Parquet file",
+		Long: "Export every row of a table of the current database into a CSV or Parquet file. " +
+			"The file is uploaded to an s3 bucket instead of being written locally when a bucket name is provided.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			table := args[0]
+			cl.runExport(cmd, func(ctx context.Context, c client.ImmuClient, format dataexport.Format, w io.Writer) (*dataexport.Summary, error) {
+				return dataexport.ExportTable(ctx, c, table, format, w)
+			})
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	exportFlags(ccmd)
+	cmd.AddCommand(ccmd)
+}
+
+func (cl *commandline) exportPrefix(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:   "export-prefix <prefix> --output <file> [--format csv|parquet]",
+		Short: "Export the entries of a key prefix into a CSV or Parquet file",
+		Long: "Export the key, value and transaction of every entry of the current database whose key starts with prefix " +
+			"into a CSV or Parquet file. The file is uploaded to an s3 bucket instead of being written locally when a bucket name is provided.",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prefix := []byte(args[0])
+			cl.runExport(cmd, func(ctx context.Context, c client.ImmuClient, format dataexport.Format, w io.Writer) (*dataexport.Summary, error) {
+				return dataexport.ExportPrefix(ctx, c, prefix, format, w)
+			})
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	exportFlags(ccmd)
+	cmd.AddCommand(ccmd)
+}
+
+func exportFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "exported file (object name when uploading to s3)")
+	cmd.Flags().String("format", string(dataexport.CSV), "format of the exported file (csv or parquet)")
+	cmd.Flags().String("s3-endpoint", "", "s3 endpoint")
+	cmd.Flags().String("s3-access-key-id", "", "s3 access key id")
+	cmd.Flags().String("s3-secret-key", "", "s3 secret access key")
+	cmd.Flags().String("s3-bucket-name", "", "s3 bucket name, the exported file is uploaded to it when set")
+	cmd.Flags().String("s3-location", "", "s3 location (region)")
+	cmd.Flags().String("s3-path-prefix", "", "s3 path prefix")
+	cmd.MarkFlagRequired("output")
+}
+
+func (cl *commandline) runExport(cmd *cobra.Command, export exportFunc) {
+	output, _ := cmd.Flags().GetString("output")
+	formatName, _ := cmd.Flags().GetString("format")
+
+	format, err := dataexport.ParseFormat(formatName)
+	if err != nil {
+		cl.quit(err)
+		return
+	}
+
+	out, err := openExportOutput(cmd, output)
+	if err != nil {
+		cl.quit(err)
+		return
+	}
+
+	res, err := cl.immucl.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		return export(context.Background(), immuClient, format, out)
+	})
+	if err != nil {
+		out.Abort()
+		cl.quit(err)
+		return
+	}
+
+	err = out.Close()
+	if err != nil {
+		cl.quit(err)
+		return
+	}
+
+	rows := res.(*dataexport.Summary).Rows
+	cl.printResult(cmd.OutOrStdout(), &exportSummaryOutput{Rows: rows, File: output}, func() {
+		fprintln(cmd.OutOrStdout(), fmt.Sprintf("%d rows exported to %s", rows, output))
+	})
+}
+
+// exportSummaryOutput is the summary of an export as marshalled in machine-readable output formats
+type exportSummaryOutput struct {
+	Rows int    `json:"rows"`
+	File string `json:"file"`
+}
+
+// exportOutput is either a local file or an upload to an object storage
+type exportOutput interface {
+	io.WriteCloser
+	Abort() error
+}
+
+func openExportOutput(cmd *cobra.Command, output string) (exportOutput, error) {
+	bucket, _ := cmd.Flags().GetString("s3-bucket-name")
+	if bucket == "" {
+		f, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return &exportFile{File: f}, nil
+	}
+
+	endpoint, _ := cmd.Flags().GetString("s3-endpoint")
+	accessKeyID, _ := cmd.Flags().GetString("s3-access-key-id")
+	secretKey, _ := cmd.Flags().GetString("s3-secret-key")
+	location, _ := cmd.Flags().GetString("s3-location")
+	prefix, _ := cmd.Flags().GetString("s3-path-prefix")
+
+	st, err := s3.Open(endpoint, accessKeyID, secretKey, bucket, location, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return remotestorage.NewUploadWriter(context.Background(), st.(remotestorage.MultipartStorage), output, remotestorage.DefaultUploadOptions())
+}
+
+// exportFile removes the partially written file when the export fails
+type exportFile struct {
+	*os.File
+}
+
+func (f *exportFile) Abort() error {
+	f.File.Close()
+	return os.Remove(f.Name())
+}
@@ -22,13 +22,22 @@ import (
 
 func (cl *commandline) getTxByID(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
-		Use:               "tx",
-		Short:             "Return a tx by id",
+		Use:   "tx <id> [--verify]",
+		Short: "Return a tx by id",
+		Long: "Return the header, hash and entries of a tx by id. When --verify is provided the tx is proven " +
+			"against the local trusted state, which is updated once the dual proof is verified.",
 		Aliases:           []string{"tx"},
 		PersistentPreRunE: cl.ConfigChain(cl.connect),
 		PersistentPostRun: cl.disconnect,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			resp, err := cl.immucl.GetTxByID(args)
+			verify, _ := cmd.Flags().GetBool("verify")
+
+			getTx := cl.immucl.GetTxByID
+			if verify {
+				getTx = cl.immucl.VerifiedGetTxByID
+			}
+
+			resp, err := getTx(args)
 			if err != nil {
 				cl.quit(err)
 			}
@@ -37,6 +46,7 @@ func (cl *commandline) getTxByID(cmd *cobra.Command) {
 		},
 		Args: cobra.ExactArgs(1),
 	}
+	ccmd.Flags().Bool("verify", false, "verify the dual proof of the tx against the local trusted state")
 	cmd.AddCommand(ccmd)
 }
 
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/dataimport"
+	"github.com/spf13/cobra"
+)
+
+// importProgressInterval is the minimum interval between progress reports of an import
+const importProgressInterval = time.Second
+
+func (cl *commandline) importFile(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:   "import <file> --key-field <field> [--value-field <field>] [--format csv|jsonl]",
+		Short: "Import the records of a CSV or JSON Lines file as key-value entries",
+		Long: "Import every record of a CSV or JSON Lines file into the current database, setting the value of the key field " +
+			"to the value of the value field, or to the whole record as a JSON object when no value field is provided. " +
+			"Records are set in batched transactions, the progress of the import is reported on stderr.",
+		Example:           "immuclient import users.csv --key-field id --value-field name",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			formatName, _ := cmd.Flags().GetString("format")
+			keyField, _ := cmd.Flags().GetString("key-field")
+			valueField, _ := cmd.Flags().GetString("value-field")
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+			skipInvalid, _ := cmd.Flags().GetBool("skip-invalid")
+
+			format, err := dataimport.ParseFormat(formatName)
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+			defer f.Close()
+
+			var lastReport time.Time
+
+			opts := dataimport.DefaultOptions().
+				WithFormat(format).
+				WithKeyField(keyField).
+				WithValueField(valueField).
+				WithBatchSize(batchSize).
+				WithSkipInvalid(skipInvalid).
+				WithProgress(func(summary *dataimport.Summary) {
+					if time.Since(lastReport) < importProgressInterval {
+						return
+					}
+					lastReport = time.Now()
+
+					fprintln(cmd.ErrOrStderr(), fmt.Sprintf("%d records imported in %d transactions", summary.Imported, summary.Txs))
+				})
+
+			// the summary of the records imported so far is reported even when the import is aborted
+			var summary *dataimport.Summary
+
+			_, err = cl.immucl.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+				// the import is restarted from the beginning when retried after logging in again
+				_, err := f.Seek(0, io.SeekStart)
+				if err != nil {
+					return nil, err
+				}
+
+				summary, err = dataimport.Import(context.Background(), immuClient, f, opts)
+				return summary, err
+			})
+
+			if summary != nil {
+				for _, recErr := range summary.Skipped {
+					fprintln(cmd.ErrOrStderr(), fmt.Sprintf("skipped %v", recErr))
+				}
+			}
+
+			if err != nil {
+				if summary != nil {
+					fprintln(cmd.ErrOrStderr(), fmt.Sprintf("import aborted after importing %d records in %d transactions", summary.Imported, summary.Txs))
+				}
+				cl.quit(err)
+				return nil
+			}
+
+			cl.printResult(cmd.OutOrStdout(), importSummaryOutputOf(summary), func() {
+				fprintln(cmd.OutOrStdout(), fmt.Sprintf("%d records imported in %d transactions (last tx %d), %d records skipped",
+					summary.Imported, summary.Txs, summary.LastTx, len(summary.Skipped)))
+			})
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	ccmd.Flags().String("format", string(dataimport.CSV), "format of the imported file (csv or jsonl)")
+	ccmd.Flags().String("key-field", "", "field holding the key of each record")
+	ccmd.Flags().String("value-field", "", "field holding the value of each record (the whole record is imported as JSON when empty)")
+	ccmd.Flags().Int("batch-size", dataimport.DefaultBatchSize, "maximum number of records set in each transaction")
+	ccmd.Flags().Bool("skip-invalid", false, "skip and report invalid records instead of aborting the import")
+	ccmd.MarkFlagRequired("key-field")
+	cmd.AddCommand(ccmd)
+}
+
+// importSummaryOutput is the summary of an import as marshalled in machine-readable output formats
+type importSummaryOutput struct {
+	Records  int      `json:"records"`
+	Imported int      `json:"imported"`
+	Txs      int      `json:"txs"`
+	LastTx   uint64   `json:"lastTx"`
+	Skipped  []string `json:"skipped"`
+}
+
+func importSummaryOutputOf(summary *dataimport.Summary) *importSummaryOutput {
+	out := &importSummaryOutput{
+		Records:  summary.Records,
+		Imported: summary.Imported,
+		Txs:      summary.Txs,
+		LastTx:   summary.LastTx,
+		Skipped:  make([]string, len(summary.Skipped)),
+	}
+	for i, recErr := range summary.Skipped {
+		out.Skipped[i] = recErr.Error()
+	}
+	return out
+}
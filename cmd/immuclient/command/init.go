@@ -19,7 +19,9 @@ package immuclient
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
+	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -32,6 +34,8 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	cmd.PersistentFlags().String("username", "", "immudb username used to login")
 	cmd.PersistentFlags().String("password", "", "immudb password used to login; can be plain-text or base64 encoded (must be prefixed with 'enc:' if it is encoded)")
 	cmd.PersistentFlags().String("database", "", "immudb database to be used")
+	cmd.PersistentFlags().String("profile", "", fmt.Sprintf("profile of the credentials file (%s, or the file set by %s) used to login when username and password are not provided; "+
+		"the credentials can also be set with the %s, %s and %s environment variables, or a token file of a previous login with %s", c.DefaultCredentialsFile(), c.EnvCredentialsFile, c.EnvUsername, c.EnvPassword, c.EnvPasswordFile, c.EnvTokenFile))
 	cmd.PersistentFlags().String(
 		"tokenfile",
 		client.DefaultOptions().TokenFileName,
@@ -56,12 +60,16 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	cmd.PersistentFlags().String("audit-monitoring-host", "0.0.0.0", "Host for the monitoring HTTP server when running in audit mode (serves endpoints like metrics, health and version).")
 	cmd.PersistentFlags().Int("audit-monitoring-port", 9477, "Port for the monitoring HTTP server when running in audit mode (serves endpoints like metrics, health and version).")
 	cmd.PersistentFlags().String("server-signing-pub-key", "", "Path to the public key to verify signatures when presents")
+	cmd.PersistentFlags().String("history-file", defaultHistoryFile(), "file the interactive mode command history is persisted to; history is not persisted when empty")
+	cmd.PersistentFlags().Bool("verify-state-signature", true, "Fail when the server state signature is missing or doesn't match the server signing public key")
+	cmd.PersistentFlags().String("output", c.OutputTable, c.OutputFlagUsage)
 
 	viper.BindPFlag("immudb-port", cmd.PersistentFlags().Lookup("immudb-port"))
 	viper.BindPFlag("immudb-address", cmd.PersistentFlags().Lookup("immudb-address"))
 	viper.BindPFlag("username", cmd.PersistentFlags().Lookup("username"))
 	viper.BindPFlag("password", cmd.PersistentFlags().Lookup("password"))
 	viper.BindPFlag("database", cmd.PersistentFlags().Lookup("database"))
+	viper.BindPFlag("profile", cmd.PersistentFlags().Lookup("profile"))
 	viper.BindPFlag("tokenfile", cmd.PersistentFlags().Lookup("tokenfile"))
 	viper.BindPFlag("mtls", cmd.PersistentFlags().Lookup("mtls"))
 	viper.BindPFlag("max-recv-msg-size", cmd.PersistentFlags().Lookup("max-recv-msg-size"))
@@ -81,12 +89,16 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	viper.BindPFlag("audit-monitoring-host", cmd.PersistentFlags().Lookup("audit-monitoring-host"))
 	viper.BindPFlag("audit-monitoring-port", cmd.PersistentFlags().Lookup("audit-monitoring-port"))
 	viper.BindPFlag("server-signing-pub-key", cmd.PersistentFlags().Lookup("server-signing-pub-key"))
+	viper.BindPFlag("verify-state-signature", cmd.PersistentFlags().Lookup("verify-state-signature"))
+	viper.BindPFlag("history-file", cmd.PersistentFlags().Lookup("history-file"))
+	viper.BindPFlag("output", cmd.PersistentFlags().Lookup("output"))
 
 	viper.SetDefault("immudb-port", client.DefaultOptions().Port)
 	viper.SetDefault("immudb-address", client.DefaultOptions().Address)
 	viper.SetDefault("password", "")
 	viper.SetDefault("username", "")
 	viper.SetDefault("database", "")
+	viper.SetDefault("profile", "")
 	viper.SetDefault("tokenfile", client.DefaultOptions().TokenFileName)
 	viper.SetDefault("mtls", client.DefaultOptions().MTLs)
 	viper.SetDefault("max-recv-msg-size", client.DefaultOptions().MaxRecvMsgSize)
@@ -105,6 +117,17 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	viper.SetDefault("audit-monitoring-host", "0.0.0.0")
 	viper.SetDefault("audit-monitoring-port", 9477)
 	viper.SetDefault("server-signing-pub-key", "")
+	viper.SetDefault("verify-state-signature", client.DefaultOptions().VerifyStateSignature)
 	viper.SetDefault("dir", os.TempDir())
+	viper.SetDefault("history-file", defaultHistoryFile())
+	viper.SetDefault("output", c.OutputTable)
 	return nil
 }
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".immuclient_history")
+}
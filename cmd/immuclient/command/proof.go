@@ -0,0 +1,217 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/proofs"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/codenotary/immudb/pkg/verifier"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) proof(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:       "proof",
+		Short:     "Export proof bundles of entries and verify them offline",
+		ValidArgs: []string{"export", "verify"},
+	}
+
+	ce := &cobra.Command{
+		Use:   "export --key <key> [--key <key>...] --out <file> [--format json|cbor] [--since-tx <tx>|--state <state_file>]",
+		Short: "Write a self-contained proof bundle of the current value of keys",
+		Long: "Write a bundle holding the current value of keys along with the proofs of their inclusion in the current state " +
+			"of the database, so the bundle can be verified later on without connecting to the server. " +
+			"The bundle also proves the consistency of that state with the transaction provided by --since-tx, " +
+			"or with the one of the trusted state provided by --state.",
+		Example:           "immuclient proof export --key key1 --out proof.json --state state.json",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keys, _ := cmd.Flags().GetStringSlice("key")
+			out, _ := cmd.Flags().GetString("out")
+			format, _ := cmd.Flags().GetString("format")
+			sinceTx, _ := cmd.Flags().GetUint64("since-tx")
+			statePath, _ := cmd.Flags().GetString("state")
+
+			if statePath != "" {
+				trusted, err := verifier.ReadTrustedState(statePath, "")
+				if err != nil {
+					cl.quit(err)
+					return nil
+				}
+				sinceTx = trusted.TxId
+			}
+
+			res, err := cl.immucl.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+				return exportProof(context.Background(), immuClient, keys, sinceTx, format, out)
+			})
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+
+			bundle := res.(*proofs.Bundle)
+
+			summary := &proofSummaryOutput{
+				File:     out,
+				Database: bundle.State.Db,
+				TxID:     bundle.State.TxID,
+				Entries:  len(bundle.Entries),
+				SinceTx:  sinceTx,
+			}
+			cl.printResult(cmd.OutOrStdout(), summary, func() {
+				fprintln(cmd.OutOrStdout(), fmt.Sprintf("proof bundle of %d entries at transaction %d of database '%s' written to %s",
+					summary.Entries, summary.TxID, summary.Database, out))
+			})
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+	ce.Flags().StringSlice("key", nil, "key whose current value is proven, it can be repeated")
+	ce.Flags().String("out", "", "file the bundle is written to")
+	ce.Flags().String("format", "json", "format of the bundle (json or cbor)")
+	ce.Flags().Uint64("since-tx", 0, "transaction the state of the bundle is proven to be consistent with")
+	ce.Flags().String("state", "", "file holding the trusted state as JSON, the state of the bundle is proven to be consistent with it")
+	ce.MarkFlagRequired("key")
+	ce.MarkFlagRequired("out")
+
+	cv := &cobra.Command{
+		Use:   "verify <bundle_file> [--state <state_file>] [--public-key <key_file>]",
+		Short: "Verify a proof bundle offline",
+		Long: "Check every entry of the bundle is included in the state the bundle was exported at, " +
+			"and that state is consistent with the trusted one when provided. No connection to the server is made.",
+		Example:           "immuclient proof verify proof.json --state state.json",
+		PersistentPreRunE: cl.ConfigChain(nil),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statePath, _ := cmd.Flags().GetString("state")
+			publicKeyPath, _ := cmd.Flags().GetString("public-key")
+
+			bundle, err := verifyProof(args[0], statePath, publicKeyPath)
+			if err != nil {
+				cl.quit(err)
+				return nil
+			}
+
+			summary := &proofSummaryOutput{
+				File:     args[0],
+				Database: bundle.State.Db,
+				TxID:     bundle.State.TxID,
+				Entries:  len(bundle.Entries),
+				Verified: true,
+			}
+			cl.printResult(cmd.OutOrStdout(), summary, func() {
+				fprintln(cmd.OutOrStdout(), fmt.Sprintf("proof bundle verified: %d entries included in database '%s' at transaction %d",
+					summary.Entries, summary.Database, summary.TxID))
+			})
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cv.Flags().String("state", "", "file holding the trusted state of the database as JSON")
+	cv.Flags().String("public-key", "", "public key of the server, used to check the signature of the states")
+
+	ccmd.AddCommand(ce)
+	ccmd.AddCommand(cv)
+	cmd.AddCommand(ccmd)
+}
+
+// proofSummaryOutput is the outcome of a proof command as marshalled in machine-readable output formats
+type proofSummaryOutput struct {
+	File     string `json:"file"`
+	Database string `json:"database"`
+	TxID     uint64 `json:"txId"`
+	Entries  int    `json:"entries"`
+	SinceTx  uint64 `json:"sinceTx,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+// exportProof writes to path the proof bundle of keys, encoded as format
+func exportProof(ctx context.Context, immuClient client.ImmuClient, keys []string, sinceTx uint64, format, path string) (*proofs.Bundle, error) {
+	var marshal func(interface{}) ([]byte, error)
+
+	switch format {
+	case "json":
+		marshal = proofs.MarshalJSON
+	case "cbor":
+		marshal = proofs.MarshalCBOR
+	default:
+		return nil, fmt.Errorf("unsupported bundle format '%s', it must be either json or cbor", format)
+	}
+
+	bkeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		bkeys[i] = []byte(key)
+	}
+
+	bundle, err := immuClient.ExportProofBundle(ctx, bkeys, sinceTx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// verifyProof verifies the bundle at path against the trusted state, if any, checking the signatures
+// of the states when the public key of the server is provided
+func verifyProof(path, statePath, publicKeyPath string) (*proofs.Bundle, error) {
+	var publicKey *ecdsa.PublicKey
+	var trusted *schema.ImmutableState
+	var err error
+
+	if publicKeyPath != "" {
+		publicKey, err = signer.ParsePublicKeyFile(publicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if statePath != "" {
+		trusted, err = verifier.ReadTrustedState(statePath, publicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bundle, err := verifier.ReadBundle(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifier.VerifyBundle(bundle, trusted, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
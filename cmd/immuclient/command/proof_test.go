@@ -0,0 +1,98 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/codenotary/immudb/pkg/verifier"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestExportAndVerifyProof(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = client.Set(ctx, []byte(`key1`), []byte(`value1`))
+	require.NoError(t, err)
+
+	trusted, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "proof")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	statePath := filepath.Join(dir, "state.json")
+	state := &bytes.Buffer{}
+	require.NoError(t, (&jsonpb.Marshaler{}).Marshal(state, trusted))
+	require.NoError(t, ioutil.WriteFile(statePath, state.Bytes(), 0644))
+
+	_, err = client.Set(ctx, []byte(`key2`), []byte(`value2`))
+	require.NoError(t, err)
+
+	_, err = exportProof(ctx, client, []string{"key1"}, 0, "xml", filepath.Join(dir, "proof.xml"))
+	require.EqualError(t, err, "unsupported bundle format 'xml', it must be either json or cbor")
+
+	for _, format := range []string{"json", "cbor"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(dir, "proof."+format)
+
+			bundle, err := exportProof(ctx, client, []string{"key1", "key2"}, trusted.TxId, format, path)
+			require.NoError(t, err)
+			require.Len(t, bundle.Entries, 2)
+
+			verified, err := verifyProof(path, statePath, "")
+			require.NoError(t, err)
+			require.Equal(t, bundle.State.TxID, verified.State.TxID)
+
+			// without the proof of consistency the bundle can't be checked against the trusted state
+			_, err = exportProof(ctx, client, []string{"key1"}, 0, format, path)
+			require.NoError(t, err)
+
+			_, err = verifyProof(path, statePath, "")
+			require.ErrorIs(t, err, verifier.ErrStateMismatch)
+		})
+	}
+}
@@ -59,3 +59,43 @@ func (cl *commandline) safereference(cmd *cobra.Command) {
 	}
 	cmd.AddCommand(ccmd)
 }
+
+func (cl *commandline) getReference(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "getreference refkey",
+		Short:             "Get a reference and the item it refers to",
+		Aliases:           []string{"gr"},
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := cl.immucl.GetReference(args)
+			if err != nil {
+				cl.quit(err)
+			}
+			fprintln(cmd.OutOrStdout(), resp)
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(ccmd)
+}
+
+func (cl *commandline) safeGetReference(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "safegetreference refkey",
+		Short:             "Get and verify a reference and the item it refers to",
+		Aliases:           []string{"sgr"},
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := cl.immucl.VerifiedGetReference(args)
+			if err != nil {
+				cl.quit(err)
+			}
+			fprintln(cmd.OutOrStdout(), resp)
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(ccmd)
+}
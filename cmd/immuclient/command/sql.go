@@ -17,17 +17,33 @@ limitations under the License.
 package immuclient
 
 import (
+	"io"
+	"os"
+
+	"github.com/codenotary/immudb/cmd/immuclient/cli"
 	"github.com/spf13/cobra"
 )
 
 func (cl *commandline) sqlExec(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
-		Use:               "exec",
-		Short:             "Executes sql statement",
+		Use:   "exec",
+		Short: "Executes sql statement",
+		Long: "Executes a sql statement or, when a script is provided with -f, the commands and sql statements " +
+			"it holds, one per line. Lines starting with '#' are skipped. The script is aborted at the first " +
+			"line failing and immuclient exits with a non-zero code.",
+		Example:           "exec \"CREATE TABLE t (id INTEGER, PRIMARY KEY id)\"\nexec -f script.txt\nexec -f - < script.txt",
 		Aliases:           []string{"x"},
 		PersistentPreRunE: cl.ConfigChain(cl.connect),
 		PersistentPostRun: cl.disconnect,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			script, _ := cmd.Flags().GetString("file")
+			if script != "" {
+				if err := cl.execScript(cmd, script); err != nil {
+					cl.quit(err)
+				}
+				return nil
+			}
+
 			resp, err := cl.immucl.SQLExec(args)
 			if err != nil {
 				cl.quit(err)
@@ -35,8 +51,14 @@ func (cl *commandline) sqlExec(cmd *cobra.Command) {
 			fprintln(cmd.OutOrStdout(), resp)
 			return nil
 		},
-		Args: cobra.MinimumNArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if script, _ := cmd.Flags().GetString("file"); script != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 	}
+	ccmd.Flags().StringP("file", "f", "", "script to be executed, \"-\" to read it from stdin")
 	cmd.AddCommand(ccmd)
 }
 
@@ -100,3 +122,20 @@ func (cl *commandline) describeTable(cmd *cobra.Command) {
 	}
 	cmd.AddCommand(ccmd)
 }
+
+// execScript executes the script at path, or read from stdin when path is "-", failing at the first line failing
+func (cl *commandline) execScript(cmd *cobra.Command, path string) error {
+	var r io.Reader = cmd.InOrStdin()
+
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	return cli.Init(cl.immucl).RunScript(r, cmd.OutOrStdout())
+}
@@ -32,9 +32,9 @@ func (i *immuc) CurrentState(args []string) (string, error) {
 	if err != nil {
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
-	return PrintState(state.(*schema.ImmutableState)), nil
+	return i.printResult(stateOutputOf(state.(*schema.ImmutableState)), PrintState(state.(*schema.ImmutableState)))
 }
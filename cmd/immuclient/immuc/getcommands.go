@@ -46,15 +46,15 @@ func (i *immuc) GetTxByID(args []string) (string, error) {
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "NotFound") {
-			return fmt.Sprintf("no item exists in id:%v", id), nil
+			return i.printMessage(fmt.Sprintf("no item exists in id:%v", id))
 		}
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
-	return PrintTx(tx.(*schema.Tx), false), nil
+	return i.printTx(tx.(*schema.Tx), false)
 }
 
 func (i *immuc) VerifiedGetTxByID(args []string) (string, error) {
@@ -72,15 +72,15 @@ func (i *immuc) VerifiedGetTxByID(args []string) (string, error) {
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "NotFound") {
-			return fmt.Sprintf("no item exists in id:%v", id), nil
+			return i.printMessage(fmt.Sprintf("no item exists in id:%v", id))
 		}
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
-	return PrintTx(tx.(*schema.Tx), true), nil
+	return i.printTx(tx.(*schema.Tx), true)
 }
 
 func (i *immuc) Get(args []string) (string, error) {
@@ -91,17 +91,18 @@ func (i *immuc) Get(args []string) (string, error) {
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "NotFound") {
-			return fmt.Sprintf("key not found: %v ", string(key)), nil
+			return i.printMessage(fmt.Sprintf("key not found: %v ", string(key)))
 		}
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
 
 	entry := response.(*schema.Entry)
-	return PrintKV(entry.Key, entry.Metadata, entry.Value, entry.Tx, false, i.valueOnly), nil
+	return i.printResult(kvOutputOf(entry.Key, entry.Metadata, entry.Value, entry.Tx, false),
+		PrintKV(entry.Key, entry.Metadata, entry.Value, entry.Tx, false, i.valueOnly))
 }
 
 func (i *immuc) VerifiedGet(args []string) (string, error) {
@@ -112,15 +113,16 @@ func (i *immuc) VerifiedGet(args []string) (string, error) {
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "NotFound") {
-			return fmt.Sprintf("key not found: %v ", string(key)), nil
+			return i.printMessage(fmt.Sprintf("key not found: %v ", string(key)))
 		}
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
 
 	entry := response.(*schema.Entry)
-	return PrintKV(entry.Key, entry.Metadata, entry.Value, entry.Tx, true, i.valueOnly), nil
+	return i.printResult(kvOutputOf(entry.Key, entry.Metadata, entry.Value, entry.Tx, true),
+		PrintKV(entry.Key, entry.Metadata, entry.Value, entry.Tx, true, i.valueOnly))
 }
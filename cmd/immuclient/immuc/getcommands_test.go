@@ -17,7 +17,9 @@ limitations under the License.
 package immuc_test
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -47,15 +49,35 @@ func TestGetTxByID(t *testing.T) {
 	ic.Connect(bs.Dialer)
 	ic.Login("immudb")
 
-	_, _ = ic.Imc.VerifiedSet([]string{"key", "val"})
+	msg, err := ic.Imc.VerifiedSet([]string{"key", "val"})
+	if err != nil {
+		t.Fatal("VerifiedSet fail", err)
+	}
 
-	msg, err := ic.Imc.GetTxByID([]string{"1"})
+	var txID uint64
+	if _, err = fmt.Sscanf(msg, "tx: %d", &txID); err != nil {
+		t.Fatalf("VerifiedSet failed to print the tx: %s", msg)
+	}
+	tx := strconv.FormatUint(txID, 10)
+
+	msg, err = ic.Imc.GetTxByID([]string{tx})
 	if err != nil {
 		t.Fatal("GetByIndex fail", err)
 	}
 	if !strings.Contains(msg, "hash") {
 		t.Fatalf("GetByIndex failed: %s", msg)
 	}
+	if !strings.Contains(msg, "key:		key\n") || !strings.Contains(msg, "value hash:") {
+		t.Fatalf("GetByIndex failed to print entries: %s", msg)
+	}
+
+	msg, err = ic.Imc.VerifiedGetTxByID([]string{tx})
+	if err != nil {
+		t.Fatal("VerifiedGetTxByID fail", err)
+	}
+	if !strings.Contains(msg, "key:		key\n") || !strings.Contains(msg, "verified:	true\n") {
+		t.Fatalf("VerifiedGetTxByID failed: %s", msg)
+	}
 }
 func TestGet(t *testing.T) {
 	defer os.Remove(".state")
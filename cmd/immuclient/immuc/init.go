@@ -33,6 +33,7 @@ type immuc struct {
 	ImmuClient     client.ImmuClient
 	passwordReader c.PasswordReader
 	valueOnly      bool
+	output         string
 	options        *client.Options
 	isLoggedin     bool
 }
@@ -53,6 +54,8 @@ type Client interface {
 	History(args []string) (string, error)
 	SetReference(args []string) (string, error)
 	VerifiedSetReference(args []string) (string, error)
+	GetReference(args []string) (string, error)
+	VerifiedGetReference(args []string) (string, error)
 	ZScan(args []string) (string, error)
 	Scan(args []string) (string, error)
 	Count(args []string) (string, error)
@@ -91,9 +94,14 @@ func (i *immuc) Connect(args []string) (err error) {
 	if i.ImmuClient, err = client.NewImmuClient(i.options); err != nil {
 		return err
 	}
-	i.WithFileTokenService(tokenservice.NewFileTokenService())
+	ts := tokenservice.NewFileTokenService()
+	if i.options.TokenFileName != "" {
+		ts = ts.WithTokenFileName(i.options.TokenFileName)
+	}
+	i.WithFileTokenService(ts)
 	i.options.Auth = true
 	i.valueOnly = viper.GetBool("value-only")
+	i.output = viper.GetString("output")
 
 	return nil
 }
@@ -169,7 +177,8 @@ func Options() *client.Options {
 		WithDatabase(viper.GetString("database")).
 		WithTokenFileName(viper.GetString("tokenfile")).
 		WithMTLs(viper.GetBool("mtls")).
-		WithServerSigningPubKey(viper.GetString("server-signing-pub-key"))
+		WithServerSigningPubKey(viper.GetString("server-signing-pub-key")).
+		WithVerifyStateSignature(viper.GetBool("verify-state-signature"))
 
 	if viper.GetBool("mtls") {
 		// todo https://golang.org/src/crypto/x509/root_linux.go
@@ -182,3 +191,31 @@ func Options() *client.Options {
 
 	return options
 }
+
+// WithCredentials sets the username, password and database left unset in options to the ones
+// loaded from the environment or from the profile of the credentials file, as well as the token
+// file when one is set there
+func WithCredentials(options *client.Options, profile string) error {
+	creds, err := c.LoadCredentials(profile)
+	if err != nil {
+		return err
+	}
+
+	if options.Username == "" {
+		options.Username = creds.Username
+	}
+	if options.Password == "" && creds.Password != "" {
+		options.Password, err = auth.DecodeBase64Password(creds.Password)
+		if err != nil {
+			return err
+		}
+	}
+	if options.Database == "" {
+		options.Database = creds.Database
+	}
+	if creds.TokenFile != "" {
+		options.TokenFileName = creds.TokenFile
+	}
+
+	return nil
+}
@@ -18,12 +18,17 @@ package immuc_test
 
 import (
 	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
+	c "github.com/codenotary/immudb/cmd/helper"
 	. "github.com/codenotary/immudb/cmd/immuclient/immuc"
+	"github.com/codenotary/immudb/pkg/client"
 	"github.com/codenotary/immudb/pkg/server"
 	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 )
 
@@ -50,3 +55,36 @@ func TestConnect(t *testing.T) {
 	}
 	imc.WithFileTokenService(tokenservice.NewInmemoryTokenService())
 }
+
+func TestWithCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials")
+	require.NoError(t, ioutil.WriteFile(path, []byte("[ci]\nusername = user1\npassword = enc:UGFzc3cwcmQh\ndatabase = db1\n"), 0600))
+
+	for _, env := range []string{c.EnvUsername, c.EnvPassword, c.EnvPasswordFile, c.EnvTokenFile, c.EnvProfile, c.EnvCredentialsFile} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+	os.Setenv(c.EnvCredentialsFile, path)
+
+	opts := client.DefaultOptions()
+	require.NoError(t, WithCredentials(opts, "ci"))
+	require.Equal(t, "user1", opts.Username)
+	require.Equal(t, "Passw0rd!", opts.Password)
+	require.Equal(t, "db1", opts.Database)
+
+	opts = client.DefaultOptions().WithUsername("user2").WithPassword("pass2")
+	require.NoError(t, WithCredentials(opts, "ci"))
+	require.Equal(t, "user2", opts.Username)
+	require.Equal(t, "pass2", opts.Password)
+
+	require.Error(t, WithCredentials(client.DefaultOptions(), "missing"))
+
+	os.Setenv(c.EnvTokenFile, filepath.Join(dir, "token"))
+	opts = client.DefaultOptions()
+	require.NoError(t, WithCredentials(opts, "ci"))
+	require.Equal(t, filepath.Join(dir, "token"), opts.TokenFileName)
+}
@@ -25,6 +25,7 @@ import (
 
 	"google.golang.org/grpc/status"
 
+	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/client"
@@ -65,7 +66,7 @@ func (i *immuc) Login(args []string) (string, error) {
 	if len(response.Warning) != 0 {
 		successMsg += string(response.Warning)
 	}
-	return successMsg, nil
+	return i.printMessage(successMsg)
 }
 
 func (i *immuc) Logout(args []string) (string, error) {
@@ -74,17 +75,17 @@ func (i *immuc) Logout(args []string) (string, error) {
 	err = i.ImmuClient.Logout(context.TODO())
 	st, ok := status.FromError(err)
 	if ok && st.Message() == "not logged in" {
-		return "User not logged in", nil
+		return i.printMessage("User not logged in")
 	}
 	if err != nil {
 		return "", err
 	}
-	return "Successfully logged out", nil
+	return i.printMessage("Successfully logged out")
 }
 
 func (i *immuc) UserCreate(args []string) (string, error) {
 	if len(args) < 3 {
-		return "incorrect number of parameters for this command. Please type 'user help' for more information", nil
+		return i.printMessage("incorrect number of parameters for this command. Please type 'user help' for more information")
 	}
 	username := args[0]
 	permission := args[1]
@@ -92,17 +93,17 @@ func (i *immuc) UserCreate(args []string) (string, error) {
 
 	pass, err := i.passwordReader.Read(fmt.Sprintf("Choose a password for %s:", username))
 	if err != nil {
-		return "Error Reading Password", nil
+		return i.printMessage("Error Reading Password")
 	}
 	if err = auth.IsStrongPassword(string(pass)); err != nil {
-		return "password does not meet the requirements. It must contain upper and lower case letters, digits, punctuation mark or symbol", nil
+		return i.printMessage("password does not meet the requirements. It must contain upper and lower case letters, digits, punctuation mark or symbol")
 	}
 	pass2, err := i.passwordReader.Read("Confirm password:")
 	if err != nil {
-		return "Error Reading Password", nil
+		return i.printMessage("Error Reading Password")
 	}
 	if !bytes.Equal(pass, pass2) {
-		return "Passwords don't match", nil
+		return i.printMessage("Passwords don't match")
 	}
 	var userpermission uint32
 	switch permission {
@@ -113,7 +114,7 @@ func (i *immuc) UserCreate(args []string) (string, error) {
 	case "readwrite":
 		userpermission = auth.PermissionRW
 	default:
-		return "permission value not recognized. Allowed permissions are read, readwrite, admin", nil
+		return i.printMessage("permission value not recognized. Allowed permissions are read, readwrite, admin")
 	}
 	_, err = i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
 		return nil, immuClient.CreateUser(
@@ -122,7 +123,7 @@ func (i *immuc) UserCreate(args []string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Created user %s", username), nil
+	return i.printMessage(fmt.Sprintf("Created user %s", username))
 }
 
 func (i *immuc) UserList(args []string) (string, error) {
@@ -133,6 +134,9 @@ func (i *immuc) UserList(args []string) (string, error) {
 		return "", err
 	}
 	userList := response.(*schema.UserList)
+	if c.IsMachineReadable(i.output) {
+		return i.printResult(userOutputsOf(userList), "")
+	}
 	ris := "\n"
 	ris += "User\tActive\tCreated By\tCreated At\t\t\t\t\tDatabase\tPermission"
 	for _, val := range userList.Users {
@@ -149,7 +153,7 @@ func (i *immuc) UserList(args []string) (string, error) {
 			case auth.PermissionRW:
 				ris += "Read/Write\n"
 			default:
-				return "permission value not recognized. Allowed permissions are read, write, admin", nil
+				return i.printMessage("permission value not recognized. Allowed permissions are read, write, admin")
 			}
 		}
 		ris += "\n"
@@ -167,22 +171,22 @@ func (i *immuc) ChangeUserPassword(args []string) (string, error) {
 	if username == auth.SysAdminUsername {
 		oldpass, err = i.passwordReader.Read("Old password:")
 		if err != nil {
-			return "Error Reading Password", nil
+			return i.printMessage("Error Reading Password")
 		}
 	}
 	newpass, err := i.passwordReader.Read(fmt.Sprintf("Choose a password for %s:", username))
 	if err != nil {
-		return "Error Reading Password", nil
+		return i.printMessage("Error Reading Password")
 	}
 	if err = auth.IsStrongPassword(string(newpass)); err != nil {
-		return "password does not meet the requirements. It must contain upper and lower case letters, digits, punctuation mark or symbol", nil
+		return i.printMessage("password does not meet the requirements. It must contain upper and lower case letters, digits, punctuation mark or symbol")
 	}
 	pass2, err := i.passwordReader.Read("Confirm password:")
 	if err != nil {
-		return "Error Reading Password", nil
+		return i.printMessage("Error Reading Password")
 	}
 	if !bytes.Equal(newpass, pass2) {
-		return "Passwords don't match", nil
+		return i.printMessage("Passwords don't match")
 	}
 	if _, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
 		return nil, immuClient.ChangePassword(
@@ -190,12 +194,12 @@ func (i *immuc) ChangeUserPassword(args []string) (string, error) {
 	}); err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Password of %s was successfully changed", username), nil
+	return i.printMessage(fmt.Sprintf("Password of %s was successfully changed", username))
 }
 
 func (i *immuc) SetActiveUser(args []string, active bool) (string, error) {
 	if len(args) < 1 {
-		return "incorrect number of parameters for this command. Please type 'user help' for more information", nil
+		return i.printMessage("incorrect number of parameters for this command. Please type 'user help' for more information")
 	}
 	username := args[0]
 	if _, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
@@ -206,12 +210,12 @@ func (i *immuc) SetActiveUser(args []string, active bool) (string, error) {
 	}); err != nil {
 		return "", err
 	}
-	return "user status changed successfully", nil
+	return i.printMessage("user status changed successfully")
 }
 
 func (i *immuc) SetUserPermission(args []string) (string, error) {
 	if len(args) != 4 {
-		return "incorrect number of parameters for this command. Please type 'user help' for more information", nil
+		return i.printMessage("incorrect number of parameters for this command. Please type 'user help' for more information")
 	}
 	var permissionAction schema.PermissionAction
 	switch args[0] {
@@ -220,7 +224,7 @@ func (i *immuc) SetUserPermission(args []string) (string, error) {
 	case "revoke":
 		permissionAction = schema.PermissionAction_REVOKE
 	default:
-		return "wrong permission action. Only grant or revoke are allowed", nil
+		return i.printMessage("wrong permission action. Only grant or revoke are allowed")
 	}
 	username := args[1]
 	var userpermission uint32
@@ -232,7 +236,7 @@ func (i *immuc) SetUserPermission(args []string) (string, error) {
 	case "readwrite":
 		userpermission = auth.PermissionRW
 	default:
-		return "permission value not recognized. Allowed permissions are read, readwrite, admin", nil
+		return i.printMessage("permission value not recognized. Allowed permissions are read, readwrite, admin")
 	}
 
 	dbname := args[3]
@@ -243,5 +247,5 @@ func (i *immuc) SetUserPermission(args []string) (string, error) {
 	}); err != nil {
 		return "", err
 	}
-	return "permission changed successfully", nil
+	return i.printMessage("permission changed successfully")
 }
@@ -19,6 +19,7 @@ import (
 	"context"
 	"strings"
 
+	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
 )
@@ -35,7 +36,7 @@ func (i *immuc) History(args []string) (string, error) {
 	if err != nil {
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
@@ -43,6 +44,9 @@ func (i *immuc) History(args []string) (string, error) {
 	str := strings.Builder{}
 
 	entries := response.(*schema.Entries)
+	if c.IsMachineReadable(i.output) {
+		return i.printResult(kvOutputsOf(entries), "")
+	}
 	if len(entries.Entries) == 0 {
 		str.WriteString("No item found \n")
 		return str.String(), nil
@@ -67,11 +71,11 @@ func (i *immuc) HealthCheck(args []string) (string, error) {
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 
 		return "", err
 	}
 
-	return "Health check OK", nil
+	return i.printMessage("Health check OK")
 }
@@ -0,0 +1,286 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+)
+
+// kvOutput is an entry as marshalled in machine-readable output formats
+type kvOutput struct {
+	Tx       uint64             `json:"tx"`
+	Key      string             `json:"key"`
+	Metadata *schema.KVMetadata `json:"metadata,omitempty"`
+	Value    string             `json:"value"`
+	Verified bool               `json:"verified"`
+}
+
+// referenceOutput is a reference as marshalled in machine-readable output formats
+type referenceOutput struct {
+	Reference   string    `json:"reference"`
+	ReferenceTx uint64    `json:"referenceTx"`
+	AtTx        uint64    `json:"atTx"`
+	Entry       *kvOutput `json:"entry"`
+}
+
+// setItemOutput is a sorted set item as marshalled in machine-readable output formats
+type setItemOutput struct {
+	Tx            uint64  `json:"tx"`
+	Set           string  `json:"set"`
+	ReferencedKey string  `json:"referencedKey"`
+	Score         float64 `json:"score"`
+	Hash          string  `json:"hash"`
+	Verified      bool    `json:"verified"`
+}
+
+// stateOutput is the state of a database as marshalled in machine-readable output formats
+type stateOutput struct {
+	Database string `json:"database"`
+	TxID     uint64 `json:"txId"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// txOutput is a transaction as marshalled in machine-readable output formats
+type txOutput struct {
+	Tx          uint64            `json:"tx"`
+	Time        string            `json:"time"`
+	Entries     int32             `json:"entries"`
+	Hash        string            `json:"hash"`
+	PrevHash    string            `json:"prevHash"`
+	EntriesHash string            `json:"entriesHash"`
+	BlTx        uint64            `json:"blTx"`
+	BlRoot      string            `json:"blRoot"`
+	Version     int32             `json:"version"`
+	Writer      string            `json:"writer,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	TxEntries   []*txEntryOutput  `json:"txEntries"`
+	Verified    bool              `json:"verified"`
+}
+
+type txEntryOutput struct {
+	Key         string             `json:"key"`
+	ValueHash   string             `json:"valueHash"`
+	ValueLength int32              `json:"valueLength"`
+	Metadata    *schema.KVMetadata `json:"metadata,omitempty"`
+}
+
+// userOutput is a user as marshalled in machine-readable output formats
+type userOutput struct {
+	User        string              `json:"user"`
+	Active      bool                `json:"active"`
+	CreatedBy   string              `json:"createdBy"`
+	CreatedAt   string              `json:"createdAt"`
+	Permissions []*permissionOutput `json:"permissions"`
+}
+
+type permissionOutput struct {
+	Database   string `json:"database"`
+	Permission string `json:"permission"`
+}
+
+// sqlOutput is the result of a SQL query as marshalled in machine-readable output formats
+type sqlOutput struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// printResult returns the result of a command marshalled when a machine-readable output format is set,
+// text otherwise
+func (i *immuc) printResult(result interface{}, text string) (string, error) {
+	if !c.IsMachineReadable(i.output) {
+		return text, nil
+	}
+
+	b, err := c.MarshalOutput(i.output, result)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// printMessage returns the outcome of a command having no result other than a message
+func (i *immuc) printMessage(msg string) (string, error) {
+	return i.printResult(&c.Message{Message: strings.TrimSpace(msg)}, msg)
+}
+
+func kvOutputOf(key []byte, md *schema.KVMetadata, value []byte, tx uint64, verified bool) *kvOutput {
+	return &kvOutput{
+		Tx:       tx,
+		Key:      string(key),
+		Metadata: md,
+		Value:    string(value),
+		Verified: verified,
+	}
+}
+
+func kvOutputsOf(entries *schema.Entries) []*kvOutput {
+	out := make([]*kvOutput, len(entries.Entries))
+	for j, entry := range entries.Entries {
+		out[j] = kvOutputOf(entry.Key, entry.Metadata, entry.Value, entry.Tx, false)
+	}
+	return out
+}
+
+func referenceOutputOf(entry *schema.Entry, verified bool) *referenceOutput {
+	return &referenceOutput{
+		Reference:   string(entry.ReferencedBy.Key),
+		ReferenceTx: entry.ReferencedBy.Tx,
+		AtTx:        entry.ReferencedBy.AtTx,
+		Entry:       kvOutputOf(entry.Key, entry.Metadata, entry.Value, entry.Tx, verified),
+	}
+}
+
+func setItemOutputOf(set []byte, referencedkey []byte, score float64, txhdr *schema.TxHeader, verified bool) *setItemOutput {
+	return &setItemOutput{
+		Tx:            txhdr.Id,
+		Set:           string(set),
+		ReferencedKey: string(referencedkey),
+		Score:         score,
+		Hash:          hex.EncodeToString(txhdr.EH),
+		Verified:      verified,
+	}
+}
+
+func stateOutputOf(state *schema.ImmutableState) *stateOutput {
+	out := &stateOutput{
+		Database: state.Db,
+		TxID:     state.TxId,
+	}
+	if state.TxId > 0 {
+		out.Hash = hex.EncodeToString(state.TxHash)
+	}
+	return out
+}
+
+func txOutputOf(tx *schema.Tx, verified bool) (*txOutput, error) {
+	hdr, err := schema.TxHeaderFromProto(tx.Header)
+	if err != nil {
+		return nil, err
+	}
+	alh := hdr.Alh()
+
+	out := &txOutput{
+		Tx:          tx.Header.Id,
+		Time:        time.Unix(tx.Header.Ts, 0).UTC().Format(time.RFC3339),
+		Entries:     tx.Header.Nentries,
+		Hash:        hex.EncodeToString(alh[:]),
+		PrevHash:    hex.EncodeToString(tx.Header.PrevAlh),
+		EntriesHash: hex.EncodeToString(tx.Header.EH),
+		BlTx:        tx.Header.BlTxId,
+		BlRoot:      hex.EncodeToString(tx.Header.BlRoot),
+		Version:     tx.Header.Version,
+		TxEntries:   make([]*txEntryOutput, len(tx.Entries)),
+		Verified:    verified,
+	}
+
+	if md := tx.Header.Metadata; md != nil {
+		out.Writer = md.Writer
+		out.Annotations = md.Annotations
+	}
+
+	for j, e := range tx.Entries {
+		out.TxEntries[j] = &txEntryOutput{
+			Key:         string(e.Key),
+			ValueHash:   hex.EncodeToString(e.HValue),
+			ValueLength: e.VLen,
+			Metadata:    e.Metadata,
+		}
+	}
+
+	return out, nil
+}
+
+// printTx returns a transaction, the error decoding its header is printed in place of its hash
+// when the output is meant to be human readable
+func (i *immuc) printTx(tx *schema.Tx, verified bool) (string, error) {
+	if !c.IsMachineReadable(i.output) {
+		return PrintTx(tx, verified), nil
+	}
+
+	out, err := txOutputOf(tx, verified)
+	if err != nil {
+		return "", err
+	}
+
+	return i.printResult(out, "")
+}
+
+func sqlOutputOf(resp *schema.SQLQueryResult) *sqlOutput {
+	out := &sqlOutput{
+		Columns: make([]string, len(resp.Columns)),
+		Rows:    make([][]string, len(resp.Rows)),
+	}
+
+	for j, col := range resp.Columns {
+		out.Columns[j] = col.Name
+	}
+
+	for j, r := range resp.Rows {
+		row := make([]string, len(r.Values))
+		for k, v := range r.Values {
+			row[k] = schema.RenderValue(v.Value)
+		}
+		out.Rows[j] = row
+	}
+
+	return out
+}
+
+func userOutputsOf(userList *schema.UserList) []*userOutput {
+	out := make([]*userOutput, len(userList.Users))
+
+	for j, u := range userList.Users {
+		out[j] = &userOutput{
+			User:        string(u.User),
+			Active:      u.Active,
+			CreatedBy:   u.Createdby,
+			CreatedAt:   u.Createdat,
+			Permissions: make([]*permissionOutput, len(u.Permissions)),
+		}
+
+		for k, p := range u.Permissions {
+			out[j].Permissions[k] = &permissionOutput{
+				Database:   p.Database,
+				Permission: permissionName(p.Permission),
+			}
+		}
+	}
+
+	return out
+}
+
+func permissionName(permission uint32) string {
+	switch permission {
+	case auth.PermissionAdmin:
+		return "admin"
+	case auth.PermissionSysAdmin:
+		return "sysadmin"
+	case auth.PermissionR:
+		return "read"
+	case auth.PermissionRW:
+		return "readwrite"
+	}
+	return fmt.Sprintf("%d", permission)
+}
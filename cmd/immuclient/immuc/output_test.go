@@ -0,0 +1,83 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/clienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachineReadableOutput(t *testing.T) {
+	immuClientMock := &clienttest.ImmuClientMock{
+		GetF: func(context.Context, []byte) (*schema.Entry, error) {
+			return &schema.Entry{Tx: 3, Key: []byte("key1"), Value: []byte("value1")}, nil
+		},
+		ScanF: func(context.Context, *schema.ScanRequest) (*schema.Entries, error) {
+			return &schema.Entries{Entries: []*schema.Entry{
+				{Tx: 1, Key: []byte("key1"), Value: []byte("value1")},
+				{Tx: 2, Key: []byte("key2"), Value: []byte("value2")},
+			}}, nil
+		},
+		CurrentStateF: func(context.Context) (*schema.ImmutableState, error) {
+			return &schema.ImmutableState{Db: "defaultdb"}, nil
+		},
+	}
+
+	ic := &immuc{ImmuClient: immuClientMock, output: c.OutputJSON}
+
+	resp, err := ic.Get([]string{"key1"})
+	require.NoError(t, err)
+	require.Equal(t, "{\n"+
+		"  \"tx\": 3,\n"+
+		"  \"key\": \"key1\",\n"+
+		"  \"value\": \"value1\",\n"+
+		"  \"verified\": false\n"+
+		"}\n", resp)
+
+	ic.output = c.OutputYAML
+
+	resp, err = ic.Scan([]string{"key"})
+	require.NoError(t, err)
+	require.Equal(t, "---\n"+
+		"- tx: 1\n  key: key1\n  value: value1\n  verified: false\n"+
+		"- tx: 2\n  key: key2\n  value: value2\n  verified: false\n", resp)
+
+	resp, err = ic.CurrentState(nil)
+	require.NoError(t, err)
+	require.Equal(t, "---\ndatabase: defaultdb\ntxId: 0\n", resp)
+
+	immuClientMock.GetF = func(context.Context, []byte) (*schema.Entry, error) {
+		return nil, errors.New("rpc error: code = Unknown desc = key not found")
+	}
+
+	// errors reported as messages are marshalled as well
+	resp, err = ic.Get([]string{"key1"})
+	require.NoError(t, err)
+	require.Equal(t, "---\nmessage: key not found\n", resp)
+
+	ic.output = c.OutputTable
+
+	resp, err = ic.CurrentState(nil)
+	require.NoError(t, err)
+	require.Equal(t, "database defaultdb is empty\n", resp)
+}
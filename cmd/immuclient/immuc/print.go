@@ -18,6 +18,7 @@ package immuc
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -49,6 +50,18 @@ func PrintKV(key []byte, md *schema.KVMetadata, value []byte, tx uint64, verifie
 	return str.String()
 }
 
+// PrintReference prints a reference along with the entry of the key it refers to
+func PrintReference(entry *schema.Entry, verified bool) string {
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("reference:	%s\n", entry.ReferencedBy.Key))
+	str.WriteString(fmt.Sprintf("reference tx:	%d\n", entry.ReferencedBy.Tx))
+	if entry.ReferencedBy.AtTx > 0 {
+		str.WriteString(fmt.Sprintf("at tx:		%d\n", entry.ReferencedBy.AtTx))
+	}
+	str.WriteString(PrintKV(entry.Key, entry.Metadata, entry.Value, entry.Tx, verified, false))
+	return str.String()
+}
+
 // PrintSetItem ...
 func PrintSetItem(set []byte, referencedkey []byte, score float64, txhdr *schema.TxHeader, verified bool) string {
 	return fmt.Sprintf("tx:		%d\nset:		%s\nreferenced key:		%s\nscore:		%f\nhash:		%x\nverified:	%t\n",
@@ -68,15 +81,53 @@ func PrintState(root *schema.ImmutableState) string {
 	return fmt.Sprintf("txID:		%d\nhash:		%x\n", root.TxId, root.TxHash)
 }
 
-// PrintTx ...
+// PrintTx prints the header of the transaction, its hash (Alh) and the digest and metadata of each of its entries
 func PrintTx(tx *schema.Tx, verified bool) string {
 	str := strings.Builder{}
 	str.WriteString(fmt.Sprintf("tx:		%d\n", tx.Header.Id))
 	str.WriteString(fmt.Sprintf("time:		%s\n", time.Unix(int64(tx.Header.Ts), 0)))
 	str.WriteString(fmt.Sprintf("entries:	%d\n", tx.Header.Nentries))
-	str.WriteString(fmt.Sprintf("hash:		%x\n", schema.TxHeaderFromProto(tx.Header).Alh()))
+	if hdr, err := schema.TxHeaderFromProto(tx.Header); err == nil {
+		str.WriteString(fmt.Sprintf("hash:		%x\n", hdr.Alh()))
+	} else {
+		str.WriteString(fmt.Sprintf("hash:		%v\n", err))
+	}
+	str.WriteString(fmt.Sprintf("prev hash:	%x\n", tx.Header.PrevAlh))
+	str.WriteString(fmt.Sprintf("entries hash:	%x\n", tx.Header.EH))
+	str.WriteString(fmt.Sprintf("bl tx:		%d\n", tx.Header.BlTxId))
+	str.WriteString(fmt.Sprintf("bl root:	%x\n", tx.Header.BlRoot))
+	str.WriteString(fmt.Sprintf("version:	%d\n", tx.Header.Version))
+
+	if md := tx.Header.Metadata; md != nil {
+		if md.Writer != "" {
+			str.WriteString(fmt.Sprintf("writer:		%s\n", md.Writer))
+		}
+
+		annotations := make([]string, 0, len(md.Annotations))
+		for k := range md.Annotations {
+			annotations = append(annotations, k)
+		}
+		sort.Strings(annotations)
+
+		for _, k := range annotations {
+			str.WriteString(fmt.Sprintf("annotation:	%s=%s\n", k, md.Annotations[k]))
+		}
+	}
+
+	for i, e := range tx.Entries {
+		str.WriteString(fmt.Sprintf("\nentry:		%d\n", i+1))
+		str.WriteString(fmt.Sprintf("key:		%s\n", e.Key))
+		str.WriteString(fmt.Sprintf("value hash:	%x\n", e.HValue))
+		str.WriteString(fmt.Sprintf("value length:	%d\n", e.VLen))
+
+		if e.Metadata != nil {
+			str.WriteString(fmt.Sprintf("metadata:	{%s}\n", e.Metadata))
+		}
+	}
+
 	if verified {
-		str.WriteString(fmt.Sprintf("verified:	%t \n", verified))
+		str.WriteString("\n")
+		str.WriteString(fmt.Sprintf("verified:	%t\n", verified))
 	}
 
 	return str.String()
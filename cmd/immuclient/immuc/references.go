@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -57,7 +58,7 @@ func (i *immuc) SetReference(args []string) (string, error) {
 	if err != nil {
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
@@ -68,7 +69,8 @@ func (i *immuc) SetReference(args []string) (string, error) {
 	}
 
 	txhdr := response.(*schema.TxHeader)
-	return PrintKV([]byte(args[0]), nil, value, uint64(txhdr.Id), false, false), nil
+	return i.printResult(kvOutputOf([]byte(args[0]), nil, value, txhdr.Id, false),
+		PrintKV([]byte(args[0]), nil, value, txhdr.Id, false, false))
 }
 
 func (i *immuc) VerifiedSetReference(args []string) (string, error) {
@@ -99,7 +101,7 @@ func (i *immuc) VerifiedSetReference(args []string) (string, error) {
 	if err != nil {
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
@@ -110,5 +112,42 @@ func (i *immuc) VerifiedSetReference(args []string) (string, error) {
 	}
 
 	txhdr := response.(*schema.TxHeader)
-	return PrintKV([]byte(args[0]), nil, value, uint64(txhdr.Id), true, false), nil
+	return i.printResult(kvOutputOf([]byte(args[0]), nil, value, txhdr.Id, true),
+		PrintKV([]byte(args[0]), nil, value, txhdr.Id, true, false))
+}
+
+func (i *immuc) GetReference(args []string) (string, error) {
+	return i.getReference(args, false)
+}
+
+func (i *immuc) VerifiedGetReference(args []string) (string, error) {
+	return i.getReference(args, true)
+}
+
+func (i *immuc) getReference(args []string, verified bool) (string, error) {
+	refKey := []byte(args[0])
+	ctx := context.Background()
+	response, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		if verified {
+			return immuClient.VerifiedGet(ctx, refKey)
+		}
+		return immuClient.Get(ctx, refKey)
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "NotFound") {
+			return i.printMessage(fmt.Sprintf("key not found: %v ", string(refKey)))
+		}
+		rpcerrors := strings.SplitAfter(err.Error(), "=")
+		if len(rpcerrors) > 1 {
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
+		}
+		return "", err
+	}
+
+	entry := response.(*schema.Entry)
+	if entry.ReferencedBy == nil {
+		return i.printMessage(fmt.Sprintf("key %s is not a reference", refKey))
+	}
+
+	return i.printResult(referenceOutputOf(entry, verified), PrintReference(entry, verified))
 }
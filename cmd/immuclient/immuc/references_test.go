@@ -58,6 +58,52 @@ func TestReference(t *testing.T) {
 	}
 }
 
+func TestGetReference(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	tkf := cmdtest.RandString()
+	ts := tokenservice.NewFileTokenService().WithTokenFileName(tkf)
+	ic := test.NewClientTest(&test.PasswordReader{
+		Pass: []string{"immudb"},
+	}, ts)
+	ic.Connect(bs.Dialer)
+	ic.Login("immudb")
+
+	_, _ = ic.Imc.Set([]string{"key", "val"})
+	_, _ = ic.Imc.SetReference([]string{"ref", "key"})
+
+	msg, err := ic.Imc.GetReference([]string{"ref"})
+	if err != nil {
+		t.Fatal("GetReference fail", err)
+	}
+	if !strings.Contains(msg, "reference:	ref\n") || !strings.Contains(msg, "key:		key") || !strings.Contains(msg, "value:		val") {
+		t.Fatalf("GetReference failed: %s", msg)
+	}
+
+	msg, err = ic.Imc.VerifiedGetReference([]string{"ref"})
+	if err != nil {
+		t.Fatal("VerifiedGetReference fail", err)
+	}
+	if !strings.Contains(msg, "reference:	ref\n") || !strings.Contains(msg, "verified:	true") {
+		t.Fatalf("VerifiedGetReference failed: %s", msg)
+	}
+
+	msg, err = ic.Imc.GetReference([]string{"key"})
+	if err != nil {
+		t.Fatal("GetReference fail", err)
+	}
+	if msg != "key key is not a reference" {
+		t.Fatalf("GetReference failed: %s", msg)
+	}
+}
+
 func _TestVerifiedSetReference(t *testing.T) {
 	defer os.Remove(".state-")
 	options := server.DefaultOptions().WithAuth(true)
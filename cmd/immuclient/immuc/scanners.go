@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 
+	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
 )
@@ -35,7 +36,7 @@ func (i *immuc) ZScan(args []string) (string, error) {
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 
 		return "", err
@@ -44,6 +45,13 @@ func (i *immuc) ZScan(args []string) (string, error) {
 	str := strings.Builder{}
 
 	zEntries := response.(*schema.ZEntries)
+	if c.IsMachineReadable(i.output) {
+		entries := make([]*kvOutput, len(zEntries.Entries))
+		for j, entry := range zEntries.Entries {
+			entries[j] = kvOutputOf(entry.Entry.Key, entry.Entry.Metadata, entry.Entry.Value, entry.Entry.Tx, false)
+		}
+		return i.printResult(entries, "")
+	}
 	if len(zEntries.Entries) == 0 {
 		str.WriteString("no entries")
 		return str.String(), nil
@@ -70,7 +78,7 @@ func (i *immuc) Scan(args []string) (res string, err error) {
 	if err != nil {
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
@@ -78,6 +86,9 @@ func (i *immuc) Scan(args []string) (res string, err error) {
 	str := strings.Builder{}
 
 	entries := response.(*schema.Entries)
+	if c.IsMachineReadable(i.output) {
+		return i.printResult(kvOutputsOf(entries), "")
+	}
 	if len(entries.Entries) == 0 {
 		str.WriteString("no entries")
 		return str.String(), nil
@@ -104,5 +115,5 @@ func (i *immuc) Count(args []string) (string, error) {
 		return "", err
 	}
 
-	return fmt.Sprint(response.(*schema.EntryCount).Count), nil
+	return i.printResult(response, fmt.Sprint(response.(*schema.EntryCount).Count))
 }
@@ -28,6 +28,7 @@ import (
 	"strings"
 	"time"
 
+	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
 )
@@ -77,7 +78,9 @@ func (i *immuc) Set(args []string) (string, error) {
 		return "", err
 	}
 
-	return PrintKV([]byte(args[0]), nil, value2, scstr.(*schema.Entry).Tx, false, false), nil
+	tx := scstr.(*schema.Entry).Tx
+	return i.printResult(kvOutputOf([]byte(args[0]), nil, value2, tx, false),
+		PrintKV([]byte(args[0]), nil, value2, tx, false, false))
 }
 
 func (i *immuc) VerifiedSet(args []string) (string, error) {
@@ -123,7 +126,9 @@ func (i *immuc) VerifiedSet(args []string) (string, error) {
 		return "", err
 	}
 
-	return PrintKV([]byte(args[0]), nil, value2, vi.(*schema.Entry).Tx, true, false), nil
+	tx := vi.(*schema.Entry).Tx
+	return i.printResult(kvOutputOf([]byte(args[0]), nil, value2, tx, true),
+		PrintKV([]byte(args[0]), nil, value2, tx, true, false))
 }
 
 func (i *immuc) DeleteKey(args []string) (string, error) {
@@ -134,16 +139,16 @@ func (i *immuc) DeleteKey(args []string) (string, error) {
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "NotFound") {
-			return fmt.Sprintf("key not found: %v ", string(key)), nil
+			return i.printMessage(fmt.Sprintf("key not found: %v ", string(key)))
 		}
 		rpcerrors := strings.SplitAfter(err.Error(), "=")
 		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+			return i.printMessage(rpcerrors[len(rpcerrors)-1])
 		}
 		return "", err
 	}
 
-	return "key successfully deleted", nil
+	return i.printMessage("key successfully deleted")
 }
 
 func (i *immuc) ZAdd(args []string) (string, error) {
@@ -185,7 +190,8 @@ func (i *immuc) ZAdd(args []string) (string, error) {
 		return "", err
 	}
 
-	return PrintSetItem(set, key, score, txhdr.(*schema.TxHeader), false), nil
+	return i.printResult(setItemOutputOf(set, key, score, txhdr.(*schema.TxHeader), false),
+		PrintSetItem(set, key, score, txhdr.(*schema.TxHeader), false))
 }
 
 func (i *immuc) VerifiedZAdd(args []string) (string, error) {
@@ -227,9 +233,8 @@ func (i *immuc) VerifiedZAdd(args []string) (string, error) {
 		return "", err
 	}
 
-	resp := PrintSetItem([]byte(args[0]), []byte(args[2]), score, response.(*schema.TxHeader), true)
-
-	return resp, nil
+	return i.printResult(setItemOutputOf([]byte(args[0]), []byte(args[2]), score, response.(*schema.TxHeader), true),
+		PrintSetItem([]byte(args[0]), []byte(args[2]), score, response.(*schema.TxHeader), true))
 }
 
 func (i *immuc) CreateDatabase(args []string) (string, error) {
@@ -247,7 +252,7 @@ func (i *immuc) CreateDatabase(args []string) (string, error) {
 		return "", err
 	}
 
-	return "database successfully created", nil
+	return i.printMessage("database successfully created")
 }
 
 func (i *immuc) DatabaseList(args []string) (string, error) {
@@ -258,6 +263,10 @@ func (i *immuc) DatabaseList(args []string) (string, error) {
 		return "", err
 	}
 
+	if c.IsMachineReadable(i.output) {
+		return i.printResult(resp, "")
+	}
+
 	var dbList string
 
 	for _, val := range resp.(*schema.DatabaseListResponse).Databases {
@@ -292,5 +301,5 @@ func (i *immuc) UseDatabase(args []string) (string, error) {
 
 	i.ImmuClient.GetOptions().CurrentDatabase = dbname
 
-	return fmt.Sprintf("Now using %s", dbname), nil
+	return i.printMessage(fmt.Sprintf("Now using %s", dbname))
 }
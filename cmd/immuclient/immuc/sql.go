@@ -44,7 +44,7 @@ func (i *immuc) SQLExec(args []string) (string, error) {
 		updatedRows += int(tx.UpdatedRows)
 	}
 
-	return fmt.Sprintf("Updated rows: %d", updatedRows), nil
+	return i.printResult(sqlRes, fmt.Sprintf("Updated rows: %d", updatedRows))
 }
 
 func (i *immuc) SQLQuery(args []string) (string, error) {
@@ -55,7 +55,7 @@ func (i *immuc) SQLQuery(args []string) (string, error) {
 		if err != nil {
 			return nil, err
 		}
-		return renderTableResult(resp), nil
+		return i.printResult(sqlOutputOf(resp), renderTableResult(resp))
 	})
 	if err != nil {
 		return "", err
@@ -71,7 +71,7 @@ func (i *immuc) ListTables() (string, error) {
 		if err != nil {
 			return nil, err
 		}
-		return renderTableResult(resp), nil
+		return i.printResult(sqlOutputOf(resp), renderTableResult(resp))
 	})
 	if err != nil {
 		return "", err
@@ -89,7 +89,7 @@ func (i *immuc) DescribeTable(args []string) (string, error) {
 		if err != nil {
 			return nil, err
 		}
-		return renderTableResult(resp), nil
+		return i.printResult(sqlOutputOf(resp), renderTableResult(resp))
 	})
 	if err != nil {
 		return "", err
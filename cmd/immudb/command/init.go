@@ -55,6 +55,10 @@ func (cl *Commandline) setupFlags(cmd *cobra.Command, options *server.Options) {
 	cmd.Flags().Int("web-server-port", options.WebServerPort, "web/console server port")
 	cmd.Flags().Bool("pgsql-server", true, "enable or disable pgsql server")
 	cmd.Flags().Int("pgsql-server-port", 5432, "pgsql server port")
+	cmd.Flags().Bool("resp-server", options.RESPServer, "enable or disable the server speaking the Redis protocol (RESP)")
+	cmd.Flags().Int("resp-server-port", options.RESPServerPort, "resp server port")
+	cmd.Flags().Bool("etcd-server", options.EtcdServer, "enable or disable the server compatible with the etcd v3 KV and Watch API")
+	cmd.Flags().Int("etcd-server-port", options.EtcdServerPort, "etcd server port")
 	cmd.Flags().Bool("s3-storage", false, "enable or disable s3 storage")
 	cmd.Flags().String("s3-endpoint", "", "s3 endpoint")
 	cmd.Flags().String("s3-access-key-id", "", "s3 access key id")
@@ -62,6 +66,38 @@ func (cl *Commandline) setupFlags(cmd *cobra.Command, options *server.Options) {
 	cmd.Flags().String("s3-bucket-name", "", "s3 bucket name")
 	cmd.Flags().String("s3-location", "", "s3 location (region)")
 	cmd.Flags().String("s3-path-prefix", "", "s3 path prefix (multiple immudb instances can share the same bucket if they have different prefixes)")
+	cmd.Flags().String("s3-cache-dir", "", "local folder used to cache chunks read from s3 (must be outside of the data folder)")
+	cmd.Flags().Int64("s3-cache-size", 0, "maximum number of bytes of s3 chunks cached locally per appendable (0 disables the cache)")
+	cmd.Flags().String("archive-dir", "", "folder where sealed segments of the transaction and value logs are archived, enabling point-in-time recovery (archiving is disabled when empty, not supported with s3 storage)")
+	cmd.Flags().String("backup-encryption-passphrase-file", "", "file holding the passphrase the key encrypting the backups scheduled on the server is derived from")
+	cmd.Flags().String("backup-encryption-key-file", "", "file holding the hex-encoded 256-bit key encrypting the backups scheduled on the server")
+	cmd.Flags().String("backup-encryption-key-env", "", "environment variable holding the hex-encoded 256-bit key encrypting the backups scheduled on the server")
+	cmd.Flags().Int64("max-memory", 0, "memory budget in bytes of the immudb process, database buffers are sized to fit into it and requests are rejected while it's exceeded (0 means unlimited)")
+	cmd.Flags().Int64("max-backup-rate", 0, "bytes per second read by all the backups and exports taken from the server, including scheduled backups (0 means unlimited)")
+	cmd.Flags().Int64("max-replication-rate", 0, "bytes per second of all the transactions sent to replicas and received by the replicas running on the server (0 means unlimited)")
+	cmd.Flags().StringSlice("timestamp-authorities", nil, "comma separated urls of the RFC 3161 Time Stamping Authorities the state of the databases is periodically submitted to, tokens are stored in the system database (timestamping is disabled when empty)")
+	cmd.Flags().Duration("timestamp-interval", time.Hour, "interval between submissions of the state of the databases to the Time Stamping Authorities")
+	cmd.Flags().Duration("retention-interval", time.Hour, "interval between enforcements of the retention policies of the databases")
+	cmd.Flags().String("anchor-schedule", options.AnchoringOptions.Schedule, "cron expression of the times the digest of the state of the databases is anchored at")
+	cmd.Flags().StringSlice("anchor-opentimestamps-calendars", nil, "comma separated urls of the OpenTimestamps calendars the state of the databases is anchored to, e.g. https://a.pool.opentimestamps.org")
+	cmd.Flags().String("anchor-ethereum-rpc", "", "url of the Ethereum node the state of the databases is anchored through (anchoring to Ethereum is disabled when empty)")
+	cmd.Flags().String("anchor-ethereum-from", "", "address of the Ethereum account sending the anchoring transactions, it must be unlocked on the node")
+	cmd.Flags().String("anchor-ethereum-contract", "", "address of the Ethereum contract the digest of the state of the databases is passed to")
+	cmd.Flags().String("anchor-ethereum-method", options.AnchoringOptions.EthereumMethod, "signature of the contract method taking the digest as a bytes32 argument")
+	cmd.Flags().StringSlice("cosigners", nil, "comma separated paths to the public keys of the auditors allowed to cosign the state of the databases (cosigning is disabled when empty)")
+	cmd.Flags().Bool("global-state", false, "serve the signed Merkle root aggregating the state of all the databases, attesting the state of the whole server")
+	cmd.Flags().Bool("state-publication", false, "serve the signed state of the databases without authentication at the /states endpoint of the metrics server")
+	cmd.Flags().Duration("state-publication-interval", options.StatePublication.Interval, "interval between refreshes of the published state of the databases")
+	cmd.Flags().String("state-publication-git-dir", "", "git working tree the published state of each database is written to and committed in (disabled when empty)")
+	cmd.Flags().Bool("state-publication-git-push", false, "push the commits of the published states to the upstream of the git working tree")
+	cmd.Flags().String("state-publication-log-url", "", "url of the transparency log the changed states of the databases are posted to as json (disabled when empty)")
+	cmd.Flags().String("alert-webhook-url", "", "url the alerts raised when the verification of a database fails are posted to as json")
+	cmd.Flags().String("alert-smtp-address", "", "address (host:port) of the SMTP server alerts are sent by email through (email alerts are disabled when empty)")
+	cmd.Flags().String("alert-smtp-username", "", "username used to authenticate to the SMTP server (no authentication when empty)")
+	cmd.Flags().String("alert-smtp-password", "", "password used to authenticate to the SMTP server")
+	cmd.Flags().String("alert-email-from", "", "sender of the alert emails")
+	cmd.Flags().StringSlice("alert-email-to", nil, "comma separated recipients of the alert emails")
+	cmd.Flags().String("alert-pagerduty-routing-key", "", "integration key of the PagerDuty service alerts are triggered on (PagerDuty alerts are disabled when empty)")
 	cmd.Flags().Duration("max-session-inactivity-time", 3*time.Minute, "max session inactivity time is a duration after which an active session is declared inactive by the server. A session is kept active if server is still receiving requests from client (keep-alive or other methods)")
 	cmd.Flags().Duration("max-session-age-time", 0, "the current default value is infinity. max session age time is a duration after which session will be forcibly closed")
 	cmd.Flags().Duration("session-timeout", 2*time.Minute, "session timeout is a duration after which an inactive session is forcibly closed by the server")
@@ -93,6 +129,10 @@ func setupDefaults(options *server.Options) {
 	viper.SetDefault("web-server-port", options.WebServerPort)
 	viper.SetDefault("pgsql-server", true)
 	viper.SetDefault("pgsql-server-port", 5432)
+	viper.SetDefault("resp-server", options.RESPServer)
+	viper.SetDefault("resp-server-port", options.RESPServerPort)
+	viper.SetDefault("etcd-server", options.EtcdServer)
+	viper.SetDefault("etcd-server-port", options.EtcdServerPort)
 	viper.SetDefault("s3-storage", false)
 	viper.SetDefault("s3-endpoint", "")
 	viper.SetDefault("s3-access-key-id", "")
@@ -100,6 +140,38 @@ func setupDefaults(options *server.Options) {
 	viper.SetDefault("s3-bucket-name", "")
 	viper.SetDefault("s3-location", "")
 	viper.SetDefault("s3-path-prefix", "")
+	viper.SetDefault("s3-cache-dir", "")
+	viper.SetDefault("s3-cache-size", 0)
+	viper.SetDefault("archive-dir", "")
+	viper.SetDefault("backup-encryption-passphrase-file", "")
+	viper.SetDefault("backup-encryption-key-file", "")
+	viper.SetDefault("backup-encryption-key-env", "")
+	viper.SetDefault("max-memory", 0)
+	viper.SetDefault("max-backup-rate", 0)
+	viper.SetDefault("max-replication-rate", 0)
+	viper.SetDefault("timestamp-authorities", []string{})
+	viper.SetDefault("timestamp-interval", options.TimestampInterval)
+	viper.SetDefault("retention-interval", options.RetentionInterval)
+	viper.SetDefault("anchor-schedule", options.AnchoringOptions.Schedule)
+	viper.SetDefault("anchor-opentimestamps-calendars", []string{})
+	viper.SetDefault("anchor-ethereum-rpc", "")
+	viper.SetDefault("anchor-ethereum-from", "")
+	viper.SetDefault("anchor-ethereum-contract", "")
+	viper.SetDefault("anchor-ethereum-method", options.AnchoringOptions.EthereumMethod)
+	viper.SetDefault("cosigners", []string{})
+	viper.SetDefault("global-state", false)
+	viper.SetDefault("state-publication", false)
+	viper.SetDefault("state-publication-interval", options.StatePublication.Interval)
+	viper.SetDefault("state-publication-git-dir", "")
+	viper.SetDefault("state-publication-git-push", false)
+	viper.SetDefault("state-publication-log-url", "")
+	viper.SetDefault("alert-webhook-url", "")
+	viper.SetDefault("alert-smtp-address", "")
+	viper.SetDefault("alert-smtp-username", "")
+	viper.SetDefault("alert-smtp-password", "")
+	viper.SetDefault("alert-email-from", "")
+	viper.SetDefault("alert-email-to", []string{})
+	viper.SetDefault("alert-pagerduty-routing-key", "")
 	viper.SetDefault("max-session-inactivity-time", 3*time.Minute)
 	viper.SetDefault("max-session-age-time", 0)
 	viper.SetDefault("session-timeout", 2*time.Minute)
@@ -17,6 +17,8 @@ limitations under the License.
 package immudb
 
 import (
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/alert"
 	"github.com/codenotary/immudb/pkg/server"
 	"github.com/codenotary/immudb/pkg/server/sessions"
 	"github.com/spf13/viper"
@@ -65,6 +67,12 @@ func parseOptions() (options *server.Options, err error) {
 	pgsqlServer := viper.GetBool("pgsql-server")
 	pgsqlServerPort := viper.GetInt("pgsql-server-port")
 
+	respServer := viper.GetBool("resp-server")
+	respServerPort := viper.GetInt("resp-server-port")
+
+	etcdServer := viper.GetBool("etcd-server")
+	etcdServerPort := viper.GetInt("etcd-server-port")
+
 	s3Storage := viper.GetBool("s3-storage")
 	s3Endpoint := viper.GetString("s3-endpoint")
 	s3AccessKeyID := viper.GetString("s3-access-key-id")
@@ -72,6 +80,26 @@ func parseOptions() (options *server.Options, err error) {
 	s3BucketName := viper.GetString("s3-bucket-name")
 	s3Location := viper.GetString("s3-location")
 	s3PathPrefix := viper.GetString("s3-path-prefix")
+	s3CacheDir := viper.GetString("s3-cache-dir")
+	s3CacheSize := viper.GetInt64("s3-cache-size")
+
+	archiveDir := viper.GetString("archive-dir")
+
+	backupKeyProvider, err := c.NewKeyProvider(
+		viper.GetString("backup-encryption-passphrase-file"),
+		viper.GetString("backup-encryption-key-file"),
+		viper.GetString("backup-encryption-key-env"),
+	)
+	if err != nil {
+		return options, err
+	}
+
+	maxMemory := viper.GetInt64("max-memory")
+	maxBackupRate := viper.GetInt64("max-backup-rate")
+	maxReplicationRate := viper.GetInt64("max-replication-rate")
+	timestampAuthorities := viper.GetStringSlice("timestamp-authorities")
+	timestampInterval := viper.GetDuration("timestamp-interval")
+	retentionInterval := viper.GetDuration("retention-interval")
 
 	remoteStorageOptions := server.DefaultRemoteStorageOptions().
 		WithS3Storage(s3Storage).
@@ -80,7 +108,32 @@ func parseOptions() (options *server.Options, err error) {
 		WithS3SecretKey(s3SecretKey).
 		WithS3BucketName(s3BucketName).
 		WithS3Location(s3Location).
-		WithS3PathPrefix(s3PathPrefix)
+		WithS3PathPrefix(s3PathPrefix).
+		WithS3CacheDir(s3CacheDir).
+		WithS3CacheSize(s3CacheSize)
+
+	anchoringOptions := server.DefaultAnchoringOptions().
+		WithSchedule(viper.GetString("anchor-schedule")).
+		WithOpenTimestampsCalendars(viper.GetStringSlice("anchor-opentimestamps-calendars")).
+		WithEthereumRPCURL(viper.GetString("anchor-ethereum-rpc")).
+		WithEthereumFrom(viper.GetString("anchor-ethereum-from")).
+		WithEthereumContract(viper.GetString("anchor-ethereum-contract")).
+		WithEthereumMethod(viper.GetString("anchor-ethereum-method"))
+
+	statePublicationOptions := server.DefaultStatePublicationOptions().
+		WithEnabled(viper.GetBool("state-publication")).
+		WithInterval(viper.GetDuration("state-publication-interval")).
+		WithGitDir(viper.GetString("state-publication-git-dir")).
+		WithGitPush(viper.GetBool("state-publication-git-push")).
+		WithTransparencyLogURL(viper.GetString("state-publication-log-url"))
+
+	alertOptions := alert.DefaultOptions().
+		WithWebhookURL(viper.GetString("alert-webhook-url")).
+		WithSMTPAddress(viper.GetString("alert-smtp-address")).
+		WithSMTPCredentials(viper.GetString("alert-smtp-username"), viper.GetString("alert-smtp-password")).
+		WithEmailFrom(viper.GetString("alert-email-from")).
+		WithEmailTo(viper.GetStringSlice("alert-email-to")).
+		WithPagerDutyRoutingKey(viper.GetString("alert-pagerduty-routing-key"))
 
 	sessionOptions := sessions.DefaultOptions().
 		WithSessionGuardCheckInterval(viper.GetDuration("sessions-guard-check-interval")).
@@ -117,7 +170,24 @@ func parseOptions() (options *server.Options, err error) {
 		WithWebServerPort(webServerPort).
 		WithPgsqlServer(pgsqlServer).
 		WithPgsqlServerPort(pgsqlServerPort).
-		WithSessionOptions(sessionOptions)
+		WithRESPServer(respServer).
+		WithRESPServerPort(respServerPort).
+		WithEtcdServer(etcdServer).
+		WithEtcdServerPort(etcdServerPort).
+		WithSessionOptions(sessionOptions).
+		WithMaxMemory(maxMemory).
+		WithArchiveDir(archiveDir).
+		WithBackupKeyProvider(backupKeyProvider).
+		WithMaxBackupRate(maxBackupRate).
+		WithMaxReplicationRate(maxReplicationRate).
+		WithTimestampAuthorities(timestampAuthorities).
+		WithTimestampInterval(timestampInterval).
+		WithRetentionInterval(retentionInterval).
+		WithAnchoringOptions(anchoringOptions).
+		WithStatePublicationOptions(statePublicationOptions).
+		WithGlobalState(viper.GetBool("global-state")).
+		WithCosigners(viper.GetStringSlice("cosigners")).
+		WithAlertOptions(alertOptions)
 
 	return options, nil
 }
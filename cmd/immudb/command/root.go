@@ -62,6 +62,10 @@ Environment variables:
   IMMUDB_TOKEN_EXPIRY_TIME=1440
   IMMUDB_PGSQL_SERVER=true
   IMMUDB_PGSQL_SERVER_PORT=5432
+  IMMUDB_RESP_SERVER=false
+  IMMUDB_RESP_SERVER_PORT=6379
+  IMMUDB_ETCD_SERVER=false
+  IMMUDB_ETCD_SERVER_PORT=2379
   IMMUDB_MAX_SESSION_AGE_TIME=0 (infinity)
   IMMUDB_MAX_SESSION_INACTIVITY_TIME=3m
   IMMUDB_SESSION_TIMEOUT=2m
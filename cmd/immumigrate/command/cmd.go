@@ -0,0 +1,238 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immumigrate
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codenotary/immudb/cmd/docs/man"
+	"github.com/codenotary/immudb/cmd/version"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+// NewCmd creates a new immumigrate command
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "immumigrate",
+		Short: "Migrate a database of a legacy immudb release (v0.8, v0.9) to the current storage format",
+		Long: `Migrate a database of a legacy immudb release (v0.8, v0.9) to the current storage format.
+Entries are replayed in their insertion order into a new database, which is loaded by immudb
+when it's created inside its data directory. The mapping from each legacy index to the
+transaction holding its entries is reported as comma separated values.
+Keys of mutable KV stores, such as Redis and etcd, can be imported with the corresponding commands.`,
+		Example: `  immumigrate --source-dir ./legacy/defaultdb
+  immumigrate --source-dir ./legacy/mydb --dir ./data --database mydb --mapping mydb.csv`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sourceDir, err := cmd.Flags().GetString("source-dir")
+			if err != nil {
+				return err
+			}
+
+			dir, err := cmd.Flags().GetString("dir")
+			if err != nil {
+				return err
+			}
+
+			dbName, err := cmd.Flags().GetString("database")
+			if err != nil {
+				return err
+			}
+
+			mappingFile, err := cmd.Flags().GetString("mapping")
+			if err != nil {
+				return err
+			}
+
+			var mappingOut io.Writer = cmd.OutOrStdout()
+
+			if mappingFile != "" {
+				f, err := os.Create(mappingFile)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				mappingOut = f
+			}
+
+			log := logger.NewSimpleLogger("immumigrate ", cmd.ErrOrStderr())
+
+			db, err := database.NewDB(database.DefaultOption().WithDBRootPath(dir).WithDBName(dbName), log)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			fmt.Fprintln(mappingOut, "legacyIndex,txId")
+
+			stats, err := Migrate(sourceDir, db, func(m Mapping) error {
+				_, err := fmt.Fprintf(mappingOut, "%d,%d\n", m.LegacyIndex, m.TxID)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			log.Infof("%d legacy indexes migrated into database '%s' (%d entries, %d references)",
+				stats.Indexes, dbName, stats.Entries, stats.References)
+
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().String("source-dir", "", "data directory of the legacy database to be migrated")
+	cmd.Flags().String("dir", "./data", "data directory of immudb where the migrated database is created")
+	cmd.Flags().String("database", server.DefaultDBName, "name of the migrated database, it must not exist")
+	cmd.Flags().String("mapping", "", "file where the mapping from legacy indexes to transaction ids is written, standard output is used when not set")
+	cmd.MarkFlagRequired("source-dir")
+
+	cmd.AddCommand(newImportCmd(
+		"redis",
+		"Import the keys of a Redis RDB dump",
+		`Import the keys of a logical database of a Redis RDB dump into a new database.
+String values are imported as they are and every field of a hash is imported as its own key,
+made of the key of the hash and the field joined by --hash-separator. Lists, sets, sorted sets
+and expired keys are skipped.`,
+		`  immumigrate redis --file dump.rdb
+  immumigrate redis --file dump.rdb --redis-db 1 --database cache --key-prefix cache:`,
+		func(cmd *cobra.Command, file string, db database.DB, opts *ImportOptions) (*ImportStats, error) {
+			redisDB, err := cmd.Flags().GetInt("redis-db")
+			if err != nil {
+				return nil, err
+			}
+
+			separator, err := cmd.Flags().GetString("hash-separator")
+			if err != nil {
+				return nil, err
+			}
+
+			f, err := os.Open(file)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+
+			return ImportRedis(f, db, opts.WithRedisDB(redisDB).WithHashFieldSeparator([]byte(separator)))
+		},
+		func(cmd *cobra.Command) {
+			cmd.Flags().Int("redis-db", 0, "logical database of the dump to be imported")
+			cmd.Flags().String("hash-separator", DefaultHashFieldSeparator, "separator joining the key of a hash with each of its fields")
+		},
+	))
+
+	cmd.AddCommand(newImportCmd(
+		"etcd",
+		"Import the keys of an etcd v3 snapshot",
+		`Import the keys alive at the last revision of an etcd v3 snapshot into a new database.
+Keys are written in the order they were last modified, leases are not imported.`,
+		`  immumigrate etcd --file snapshot.db
+  immumigrate etcd --file snapshot.db --database config --batch-size 100`,
+		func(cmd *cobra.Command, file string, db database.DB, opts *ImportOptions) (*ImportStats, error) {
+			return ImportEtcd(file, db, opts)
+		},
+		nil,
+	))
+
+	cmd.AddCommand(man.Generate(cmd, "immumigrate", "./cmd/docs/man/immumigrate"))
+	cmd.AddCommand(version.VersionCmd())
+
+	return cmd
+}
+
+// newImportCmd creates a command importing the keys of a mutable KV store from file into a new database
+func newImportCmd(
+	use, short, long, example string,
+	importFn func(cmd *cobra.Command, file string, db database.DB, opts *ImportOptions) (*ImportStats, error),
+	setupFlags func(cmd *cobra.Command),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          use,
+		Short:        short,
+		Long:         long,
+		Example:      example,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			dir, err := cmd.Flags().GetString("dir")
+			if err != nil {
+				return err
+			}
+
+			dbName, err := cmd.Flags().GetString("database")
+			if err != nil {
+				return err
+			}
+
+			keyPrefix, err := cmd.Flags().GetString("key-prefix")
+			if err != nil {
+				return err
+			}
+
+			batchSize, err := cmd.Flags().GetInt("batch-size")
+			if err != nil {
+				return err
+			}
+
+			log := logger.NewSimpleLogger("immumigrate ", cmd.ErrOrStderr())
+
+			db, err := database.NewDB(database.DefaultOption().WithDBRootPath(dir).WithDBName(dbName), log)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			opts := DefaultImportOptions().
+				WithKeyPrefix([]byte(keyPrefix)).
+				WithBatchSize(batchSize)
+
+			stats, err := importFn(cmd, file, db, opts)
+			if err != nil {
+				return err
+			}
+
+			log.Infof("%d keys imported into database '%s' in %d transactions (%d keys skipped)",
+				stats.Entries, dbName, stats.Transactions, stats.Skipped)
+
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().String("file", "", "file to be imported")
+	cmd.Flags().String("dir", "./data", "data directory of immudb where the database is created")
+	cmd.Flags().String("database", server.DefaultDBName, "name of the database where keys are imported, it must not exist")
+	cmd.Flags().String("key-prefix", "", "prefix prepended to every imported key")
+	cmd.Flags().Int("batch-size", DefaultImportBatchSize, "maximum number of keys written in a single transaction")
+	cmd.MarkFlagRequired("file")
+
+	if setupFlags != nil {
+		setupFlags(cmd)
+	}
+
+	return cmd
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immumigrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/database"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// An etcd v3 snapshot is a bolt database, every revision of the keys is stored in the bucket etcdKeyBucket
+// under the revision it was written at: 8 bytes big-endian main revision, '_' and 8 bytes big-endian sub revision,
+// followed by etcdTombstoneMark when the key was deleted. Values are protobuf encoded mvccpb.KeyValue messages.
+const (
+	etcdKeyBucket     = "key"
+	etcdRevisionSize  = 8 + 1 + 8
+	etcdTombstoneMark = 't'
+
+	etcdFieldKey         = 1
+	etcdFieldModRevision = 3
+	etcdFieldValue       = 5
+)
+
+var ErrInvalidEtcdSnapshot = errors.New("invalid etcd snapshot")
+
+type etcdKeyValue struct {
+	key         []byte
+	value       []byte
+	modRevision int64
+}
+
+// ImportEtcd writes into db the keys alive at the last revision of the etcd v3 snapshot stored at snapshotPath.
+// Keys are written in the order they were last modified, leases are not imported.
+func ImportEtcd(snapshotPath string, db database.DB, opts *ImportOptions) (*ImportStats, error) {
+	w, err := newBatchWriter(db, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bdb, err := bolt.Open(snapshotPath, 0400, &bolt.Options{ReadOnly: true, Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	defer bdb.Close()
+
+	kvs, err := readEtcdKeyValues(bdb)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kv := range kvs {
+		err = w.add(kv.key, kv.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return w.stats, w.flush()
+}
+
+// readEtcdKeyValues returns the last revision of the keys not deleted sorted by their modification revision
+func readEtcdKeyValues(bdb *bolt.DB) ([]*etcdKeyValue, error) {
+	live := make(map[string]*etcdKeyValue)
+
+	err := bdb.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(etcdKeyBucket))
+		if b == nil {
+			return fmt.Errorf("%w: bucket '%s' not found", ErrInvalidEtcdSnapshot, etcdKeyBucket)
+		}
+
+		// revisions are sorted in ascending order
+		return b.ForEach(func(rev, v []byte) error {
+			if len(rev) < etcdRevisionSize {
+				return fmt.Errorf("%w: corrupted revision", ErrInvalidEtcdSnapshot)
+			}
+
+			kv, err := decodeEtcdKeyValue(v)
+			if err != nil {
+				return err
+			}
+
+			if len(rev) > etcdRevisionSize && rev[etcdRevisionSize] == etcdTombstoneMark {
+				delete(live, string(kv.key))
+				return nil
+			}
+
+			live[string(kv.key)] = kv
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kvs := make([]*etcdKeyValue, 0, len(live))
+	for _, kv := range live {
+		kvs = append(kvs, kv)
+	}
+
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].modRevision < kvs[j].modRevision
+	})
+
+	return kvs, nil
+}
+
+// decodeEtcdKeyValue decodes the fields of a mvccpb.KeyValue message required to import it
+func decodeEtcdKeyValue(b []byte) (*etcdKeyValue, error) {
+	kv := &etcdKeyValue{}
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEtcdSnapshot, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		switch {
+		case (num == etcdFieldKey || num == etcdFieldValue) && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidEtcdSnapshot, protowire.ParseError(n))
+			}
+
+			if num == etcdFieldKey {
+				kv.key = v
+			} else {
+				kv.value = v
+			}
+
+			b = b[n:]
+		case num == etcdFieldModRevision && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidEtcdSnapshot, protowire.ParseError(n))
+			}
+
+			kv.modRevision = int64(v)
+
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidEtcdSnapshot, protowire.ParseError(n))
+			}
+
+			b = b[n:]
+		}
+	}
+
+	if len(kv.key) == 0 {
+		return nil, fmt.Errorf("%w: key not found", ErrInvalidEtcdSnapshot)
+	}
+
+	return kv, nil
+}
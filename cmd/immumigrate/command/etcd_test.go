@@ -0,0 +1,196 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immumigrate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type etcdRevision struct {
+	key       string
+	value     string
+	tombstone bool
+}
+
+// makeEtcdSnapshot writes a snapshot at path, each element of revisions is written at its own main revision
+func makeEtcdSnapshot(t *testing.T, path string, revisions []etcdRevision) {
+	bdb, err := bolt.Open(path, 0600, nil)
+	require.NoError(t, err)
+	defer bdb.Close()
+
+	err = bdb.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte(etcdKeyBucket))
+		if err != nil {
+			return err
+		}
+
+		for i, r := range revisions {
+			rev := make([]byte, etcdRevisionSize, etcdRevisionSize+1)
+			binary.BigEndian.PutUint64(rev, uint64(i+2))
+			rev[8] = '_'
+			if r.tombstone {
+				rev = append(rev, etcdTombstoneMark)
+			}
+
+			var kv []byte
+			kv = protowire.AppendTag(kv, etcdFieldKey, protowire.BytesType)
+			kv = protowire.AppendBytes(kv, []byte(r.key))
+			kv = protowire.AppendTag(kv, 2, protowire.VarintType)
+			kv = protowire.AppendVarint(kv, uint64(i+2))
+			kv = protowire.AppendTag(kv, etcdFieldModRevision, protowire.VarintType)
+			kv = protowire.AppendVarint(kv, uint64(i+2))
+
+			if !r.tombstone {
+				kv = protowire.AppendTag(kv, etcdFieldValue, protowire.BytesType)
+				kv = protowire.AppendBytes(kv, []byte(r.value))
+			}
+
+			err = b.Put(rev, kv)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestImportEtcd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immumigrate_etcd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	snapshot := filepath.Join(dir, "snapshot.db")
+
+	makeEtcdSnapshot(t, snapshot, []etcdRevision{
+		{key: "/config/a", value: "1"},
+		{key: "/config/b", value: "2"},
+		{key: "/config/a", value: "3"},
+		{key: "/config/c", value: "4"},
+		{key: "/config/c", tombstone: true},
+	})
+
+	db := newTestDB(t, dir, "etcd")
+	defer db.Close()
+
+	_, err = ImportEtcd(snapshot, nil, DefaultImportOptions())
+	require.Error(t, err)
+
+	_, err = ImportEtcd(filepath.Join(dir, "missing"), db, DefaultImportOptions())
+	require.Error(t, err)
+
+	stats, err := ImportEtcd(snapshot, db, DefaultImportOptions().WithKeyPrefix([]byte("etcd")))
+	require.NoError(t, err)
+	require.Equal(t, &ImportStats{Entries: 2, Transactions: 1}, stats)
+
+	requireValue(t, db, "etcd/config/a", "3")
+	requireValue(t, db, "etcd/config/b", "2")
+
+	_, err = db.Get(&schema.KeyRequest{Key: []byte("etcd/config/c")})
+	require.Error(t, err)
+
+	t.Run("modification order", func(t *testing.T) {
+		db := newTestDB(t, dir, "etcd_ordered")
+		defer db.Close()
+
+		_, err := ImportEtcd(snapshot, db, DefaultImportOptions().WithBatchSize(1))
+		require.NoError(t, err)
+
+		b, err := db.Get(&schema.KeyRequest{Key: []byte("/config/b")})
+		require.NoError(t, err)
+
+		a, err := db.Get(&schema.KeyRequest{Key: []byte("/config/a")})
+		require.NoError(t, err)
+		require.Equal(t, b.Tx+1, a.Tx)
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		empty := filepath.Join(dir, "empty.db")
+
+		bdb, err := bolt.Open(empty, 0600, nil)
+		require.NoError(t, err)
+		require.NoError(t, bdb.Close())
+
+		_, err = ImportEtcd(empty, db, DefaultImportOptions())
+		require.ErrorIs(t, err, ErrInvalidEtcdSnapshot)
+	})
+}
+
+func TestDecodeEtcdKeyValue(t *testing.T) {
+	_, err := decodeEtcdKeyValue([]byte{0xFF})
+	require.ErrorIs(t, err, ErrInvalidEtcdSnapshot)
+
+	var kv []byte
+	kv = protowire.AppendTag(kv, etcdFieldValue, protowire.BytesType)
+	kv = protowire.AppendBytes(kv, []byte("value"))
+
+	_, err = decodeEtcdKeyValue(kv)
+	require.ErrorIs(t, err, ErrInvalidEtcdSnapshot)
+
+	_, err = decodeEtcdKeyValue(kv[:len(kv)-1])
+	require.ErrorIs(t, err, ErrInvalidEtcdSnapshot)
+}
+
+func TestImportCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immumigrate_import")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	snapshot := filepath.Join(dir, "snapshot.db")
+	makeEtcdSnapshot(t, snapshot, []etcdRevision{{key: "key1", value: "value1"}})
+
+	rdb := newRDBBuilder()
+	rdb.op(rdbTypeString).str("key1").str("value1")
+	rdb.op(rdbOpEOF)
+
+	dump := filepath.Join(dir, "dump.rdb")
+	require.NoError(t, ioutil.WriteFile(dump, rdb.Bytes(), 0600))
+
+	for _, args := range [][]string{
+		{"etcd", "--file", snapshot, "--dir", filepath.Join(dir, "data"), "--database", "etcd"},
+		{"redis", "--file", dump, "--dir", filepath.Join(dir, "data"), "--database", "redis", "--redis-db", "0", "--key-prefix", "r:"},
+	} {
+		cmd := NewCmd()
+
+		var errOut bytes.Buffer
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&errOut)
+		cmd.SetArgs(args)
+
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, errOut.String(), "1 keys imported")
+
+		// the database must not exist
+		cmd = NewCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+
+		require.Error(t, cmd.Execute())
+	}
+}
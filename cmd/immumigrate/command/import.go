@@ -0,0 +1,145 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immumigrate
+
+import (
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+const DefaultImportBatchSize = 1000
+const DefaultHashFieldSeparator = ":"
+
+// ImportOptions describes how the keys of a mutable KV store are mapped into immudb
+type ImportOptions struct {
+	// KeyPrefix is prepended to every imported key
+	KeyPrefix []byte
+
+	// BatchSize is the maximum number of keys written in a single transaction,
+	// it can not exceed the maximum number of entries per transaction of the database
+	BatchSize int
+
+	// RedisDB is the logical database of a Redis RDB dump to be imported
+	RedisDB int
+
+	// HashFieldSeparator joins the key of a Redis hash with each of its fields,
+	// every field is imported as its own key
+	HashFieldSeparator []byte
+}
+
+// ImportStats summarizes an import
+type ImportStats struct {
+	Entries      int
+	Transactions int
+	// Skipped counts the keys whose values can not be mapped into immudb entries
+	Skipped int
+}
+
+func DefaultImportOptions() *ImportOptions {
+	return &ImportOptions{
+		BatchSize:          DefaultImportBatchSize,
+		HashFieldSeparator: []byte(DefaultHashFieldSeparator),
+	}
+}
+
+func (opts *ImportOptions) WithKeyPrefix(keyPrefix []byte) *ImportOptions {
+	opts.KeyPrefix = keyPrefix
+	return opts
+}
+
+func (opts *ImportOptions) WithBatchSize(batchSize int) *ImportOptions {
+	opts.BatchSize = batchSize
+	return opts
+}
+
+func (opts *ImportOptions) WithRedisDB(redisDB int) *ImportOptions {
+	opts.RedisDB = redisDB
+	return opts
+}
+
+func (opts *ImportOptions) WithHashFieldSeparator(separator []byte) *ImportOptions {
+	opts.HashFieldSeparator = separator
+	return opts
+}
+
+func validImportOptions(opts *ImportOptions) bool {
+	return opts != nil && opts.BatchSize > 0 && opts.RedisDB >= 0
+}
+
+// batchWriter groups imported keys into transactions of up to opts.BatchSize entries
+type batchWriter struct {
+	db    database.DB
+	opts  *ImportOptions
+	stats *ImportStats
+
+	kvs  []*schema.KeyValue
+	keys map[string]struct{}
+}
+
+func newBatchWriter(db database.DB, opts *ImportOptions) (*batchWriter, error) {
+	if db == nil || !validImportOptions(opts) {
+		return nil, store.ErrIllegalArguments
+	}
+
+	return &batchWriter{
+		db:    db,
+		opts:  opts,
+		stats: &ImportStats{},
+		keys:  make(map[string]struct{}, opts.BatchSize),
+	}, nil
+}
+
+func (w *batchWriter) add(key, value []byte) error {
+	k := make([]byte, len(w.opts.KeyPrefix)+len(key))
+	copy(k, w.opts.KeyPrefix)
+	copy(k[len(w.opts.KeyPrefix):], key)
+
+	// a key can only be written once per transaction
+	_, duplicated := w.keys[string(k)]
+
+	if duplicated || len(w.kvs) == w.opts.BatchSize {
+		err := w.flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	w.kvs = append(w.kvs, &schema.KeyValue{Key: k, Value: value})
+	w.keys[string(k)] = struct{}{}
+
+	return nil
+}
+
+func (w *batchWriter) flush() error {
+	if len(w.kvs) == 0 {
+		return nil
+	}
+
+	_, err := w.db.Set(&schema.SetRequest{KVs: w.kvs})
+	if err != nil {
+		return err
+	}
+
+	w.stats.Entries += len(w.kvs)
+	w.stats.Transactions++
+
+	w.kvs = nil
+	w.keys = make(map[string]struct{}, w.opts.BatchSize)
+
+	return nil
+}
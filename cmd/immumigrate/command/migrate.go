@@ -0,0 +1,227 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immumigrate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/dgraph-io/badger/v2"
+)
+
+// Legacy releases (v0.8 and v0.9) keep every database in a managed badger store.
+// Each legacy index is committed at badger version index+1 and every value is stored
+// together with that version as an 8 bytes big-endian timestamp. Entries of the merkle tree
+// are kept in the same store under keys starting with the reserved byte legacyTreePrefix.
+// References and sorted set members are entries flagged with legacyBitReferenceEntry
+// whose value is the referenced key.
+const (
+	legacyTreePrefix        = byte(0)
+	legacyBitReferenceEntry = byte(1 << 0)
+	legacyBitTreeEntry      = byte(1 << 1)
+
+	legacyTsSize = 8
+)
+
+// ErrNoLegacyEntries is returned when the source directory holds no entries to be migrated
+var ErrNoLegacyEntries = errors.New("no entries found in the legacy data directory")
+
+// Mapping relates an index of the legacy database with the transaction holding its entries
+type Mapping struct {
+	LegacyIndex uint64
+	TxID        uint64
+}
+
+// Stats summarizes a migration
+type Stats struct {
+	Indexes    int
+	Entries    int
+	References int
+}
+
+type legacyEntry struct {
+	key       []byte
+	version   uint64
+	reference bool
+}
+
+// Migrate replays the entries of the legacy database stored at sourceDir into db, preserving
+// their insertion order. Entries committed within the same legacy index are written in a single
+// transaction and onMapping is called once they are committed.
+func Migrate(sourceDir string, db database.DB, onMapping func(m Mapping) error) (*Stats, error) {
+	if db == nil || onMapping == nil {
+		return nil, store.ErrIllegalArguments
+	}
+
+	bdb, err := badger.OpenManaged(badger.DefaultOptions(sourceDir).WithReadOnly(true).WithLogger(nil))
+	if err != nil {
+		return nil, err
+	}
+	defer bdb.Close()
+
+	entries, err := readLegacyEntries(bdb)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrNoLegacyEntries
+	}
+
+	stats := &Stats{}
+
+	for i := 0; i < len(entries); {
+		version := entries[i].version
+
+		j := i
+		for j < len(entries) && entries[j].version == version {
+			j++
+		}
+
+		txID, err := replayLegacyIndex(bdb, db, entries[i:j], stats)
+		if err != nil {
+			return nil, fmt.Errorf("unable to migrate legacy index %d: %w", version-1, err)
+		}
+
+		err = onMapping(Mapping{LegacyIndex: version - 1, TxID: txID})
+		if err != nil {
+			return nil, err
+		}
+
+		stats.Indexes++
+
+		i = j
+	}
+
+	return stats, nil
+}
+
+// readLegacyEntries returns every live version of the user entries sorted by the legacy index they were committed at
+func readLegacyEntries(bdb *badger.DB) ([]*legacyEntry, error) {
+	txn := bdb.NewTransactionAt(math.MaxUint64, false)
+	defer txn.Discard()
+
+	it := txn.NewIterator(badger.IteratorOptions{AllVersions: true})
+	defer it.Close()
+
+	var entries []*legacyEntry
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+
+		if item.IsDeletedOrExpired() || item.UserMeta()&legacyBitTreeEntry != 0 {
+			continue
+		}
+
+		key := item.KeyCopy(nil)
+		if len(key) == 0 || key[0] == legacyTreePrefix {
+			continue
+		}
+
+		entries = append(entries, &legacyEntry{
+			key:       key,
+			version:   item.Version(),
+			reference: item.UserMeta()&legacyBitReferenceEntry != 0,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].version < entries[j].version
+	})
+
+	return entries, nil
+}
+
+// replayLegacyIndex writes the entries committed at the same legacy index and returns the id of the last transaction written
+func replayLegacyIndex(bdb *badger.DB, db database.DB, entries []*legacyEntry, stats *Stats) (uint64, error) {
+	txn := bdb.NewTransactionAt(entries[0].version, false)
+	defer txn.Discard()
+
+	var kvs []*schema.KeyValue
+	var refs []*schema.ReferenceRequest
+
+	for _, e := range entries {
+		item, err := txn.Get(e.key)
+		if err != nil {
+			return 0, err
+		}
+
+		wrappedValue, err := item.ValueCopy(nil)
+		if err != nil {
+			return 0, err
+		}
+
+		value, err := unwrapLegacyValue(wrappedValue, e.version)
+		if err != nil {
+			return 0, fmt.Errorf("key '%s': %w", e.key, err)
+		}
+
+		if e.reference {
+			refs = append(refs, &schema.ReferenceRequest{Key: e.key, ReferencedKey: value})
+			continue
+		}
+
+		kvs = append(kvs, &schema.KeyValue{Key: e.key, Value: value})
+	}
+
+	var txID uint64
+
+	if len(kvs) > 0 {
+		hdr, err := db.Set(&schema.SetRequest{KVs: kvs})
+		if err != nil {
+			return 0, err
+		}
+
+		txID = hdr.Id
+		stats.Entries += len(kvs)
+	}
+
+	for _, ref := range refs {
+		hdr, err := db.SetReference(ref)
+		if err != nil {
+			return 0, err
+		}
+
+		txID = hdr.Id
+		stats.References++
+	}
+
+	return txID, nil
+}
+
+// unwrapLegacyValue takes apart the timestamp stored together with a legacy value
+func unwrapLegacyValue(wrappedValue []byte, version uint64) ([]byte, error) {
+	if len(wrappedValue) < legacyTsSize {
+		return nil, fmt.Errorf("corrupted legacy value")
+	}
+
+	var ts [legacyTsSize]byte
+	binary.BigEndian.PutUint64(ts[:], version)
+
+	if !bytes.Equal(wrappedValue[:legacyTsSize], ts[:]) {
+		return nil, fmt.Errorf("legacy value is not timestamped with its index")
+	}
+
+	return wrappedValue[legacyTsSize:], nil
+}
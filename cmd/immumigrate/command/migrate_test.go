@@ -0,0 +1,184 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immumigrate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type legacyKV struct {
+	key       string
+	value     string
+	reference bool
+}
+
+// makeLegacyDB writes a legacy database at dir, each element of indexes is committed at its own legacy index
+func makeLegacyDB(t *testing.T, dir string, indexes [][]legacyKV) {
+	bdb, err := badger.OpenManaged(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	defer bdb.Close()
+
+	for i, kvs := range indexes {
+		version := uint64(i + 1)
+
+		txn := bdb.NewTransactionAt(version, true)
+
+		for _, kv := range kvs {
+			wrappedValue := make([]byte, legacyTsSize+len(kv.value))
+			binary.BigEndian.PutUint64(wrappedValue, version)
+			copy(wrappedValue[legacyTsSize:], kv.value)
+
+			e := badger.NewEntry([]byte(kv.key), wrappedValue)
+			if kv.reference {
+				e = e.WithMeta(legacyBitReferenceEntry)
+			}
+
+			require.NoError(t, txn.SetEntry(e))
+		}
+
+		// merkle tree entries are not migrated
+		treeKey := make([]byte, 10)
+		treeKey[0] = legacyTreePrefix
+		binary.BigEndian.PutUint64(treeKey[2:], uint64(i))
+		require.NoError(t, txn.SetEntry(badger.NewEntry(treeKey, make([]byte, 32)).WithMeta(legacyBitTreeEntry)))
+
+		require.NoError(t, txn.CommitAt(version, nil))
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immumigrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	legacyDir := filepath.Join(dir, "legacy")
+
+	makeLegacyDB(t, legacyDir, [][]legacyKV{
+		{{key: "key1", value: "value1"}},
+		{{key: "key2", value: "value2"}, {key: "key3", value: "value3"}},
+		{{key: "key1", value: "value1_v2"}},
+		{{key: "ref1", value: "key2", reference: true}},
+	})
+
+	db, err := database.NewDB(database.DefaultOption().WithDBRootPath(filepath.Join(dir, "data")).WithDBName("migrated"),
+		logger.NewSimpleLogger("immumigrate ", os.Stderr))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = Migrate(legacyDir, db, nil)
+	require.Error(t, err)
+
+	var mappings []Mapping
+
+	stats, err := Migrate(legacyDir, db, func(m Mapping) error {
+		mappings = append(mappings, m)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, &Stats{Indexes: 4, Entries: 4, References: 1}, stats)
+
+	require.Len(t, mappings, 4)
+	for i, m := range mappings {
+		require.Equal(t, uint64(i), m.LegacyIndex)
+
+		if i > 0 {
+			require.Greater(t, m.TxID, mappings[i-1].TxID)
+		}
+	}
+
+	entry, err := db.Get(&schema.KeyRequest{Key: []byte("key1"), SinceTx: mappings[3].TxID})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1_v2"), entry.Value)
+	require.Equal(t, mappings[2].TxID, entry.Tx)
+
+	entry, err = db.Get(&schema.KeyRequest{Key: []byte("key1"), AtTx: mappings[0].TxID})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+
+	entry, err = db.Get(&schema.KeyRequest{Key: []byte("key3")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value3"), entry.Value)
+	require.Equal(t, mappings[1].TxID, entry.Tx)
+
+	entry, err = db.Get(&schema.KeyRequest{Key: []byte("ref1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), entry.Value)
+	require.Equal(t, []byte("key2"), entry.Key)
+
+	t.Run("empty legacy database", func(t *testing.T) {
+		emptyDir := filepath.Join(dir, "empty")
+		makeLegacyDB(t, emptyDir, nil)
+
+		_, err := Migrate(emptyDir, db, func(m Mapping) error { return nil })
+		require.ErrorIs(t, err, ErrNoLegacyEntries)
+	})
+}
+
+func TestUnwrapLegacyValue(t *testing.T) {
+	_, err := unwrapLegacyValue([]byte{1, 2}, 1)
+	require.Error(t, err)
+
+	_, err = unwrapLegacyValue([]byte{0, 0, 0, 0, 0, 0, 0, 2, 'v'}, 1)
+	require.Error(t, err)
+
+	v, err := unwrapLegacyValue([]byte{0, 0, 0, 0, 0, 0, 0, 1, 'v'}, 1)
+	require.NoError(t, err)
+	require.Equal(t, []byte("v"), v)
+}
+
+func TestMigrateCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immumigrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	legacyDir := filepath.Join(dir, "legacy")
+
+	makeLegacyDB(t, legacyDir, [][]legacyKV{
+		{{key: "key1", value: "value1"}},
+		{{key: "key2", value: "value2"}},
+	})
+
+	cmd := NewCmd()
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--source-dir", legacyDir, "--dir", filepath.Join(dir, "data"), "--database", "migrated"})
+
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "legacyIndex,txId\n0,")
+
+	// the migrated database must not exist
+	cmd = NewCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--source-dir", legacyDir, "--dir", filepath.Join(dir, "data"), "--database", "migrated"})
+
+	require.Error(t, cmd.Execute())
+}
@@ -0,0 +1,722 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immumigrate
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// An RDB file starts with the magic string "REDIS" followed by a 4 digits version, then a sequence
+// of opcodes and key-value pairs ending with rdbOpEOF and a checksum.
+// See https://github.com/sripathikrishnan/redis-rdb-tools/wiki/Redis-RDB-Dump-File-Format
+const (
+	rdbMagic      = "REDIS"
+	rdbMaxVersion = 10
+
+	rdbOpFunction2    = 0xF5
+	rdbOpModuleAux    = 0xF7
+	rdbOpIdle         = 0xF8
+	rdbOpFreq         = 0xF9
+	rdbOpAux          = 0xFA
+	rdbOpResizeDB     = 0xFB
+	rdbOpExpireTimeMs = 0xFC
+	rdbOpExpireTime   = 0xFD
+	rdbOpSelectDB     = 0xFE
+	rdbOpEOF          = 0xFF
+
+	rdbTypeString         = 0
+	rdbTypeList           = 1
+	rdbTypeSet            = 2
+	rdbTypeZset           = 3
+	rdbTypeHash           = 4
+	rdbTypeZset2          = 5
+	rdbTypeListZiplist    = 10
+	rdbTypeSetIntset      = 11
+	rdbTypeZsetZiplist    = 12
+	rdbTypeHashZiplist    = 13
+	rdbTypeListQuicklist  = 14
+	rdbTypeHashListpack   = 16
+	rdbTypeZsetListpack   = 17
+	rdbTypeListQuicklist2 = 18
+	rdbTypeSetListpack    = 20
+
+	rdbEncInt8  = 0
+	rdbEncInt16 = 1
+	rdbEncInt32 = 2
+	rdbEncLZF   = 3
+)
+
+var ErrInvalidRDB = errors.New("invalid redis rdb file")
+var ErrUnsupportedRDBType = errors.New("unsupported redis value type")
+
+// ImportRedis writes into db the keys of the logical database opts.RedisDB of a Redis RDB dump.
+// String values are imported as they are and every field of a hash is imported as its own key,
+// made of the key of the hash and the field joined by opts.HashFieldSeparator.
+// Lists, sets and sorted sets are skipped, as well as keys already expired.
+func ImportRedis(r io.Reader, db database.DB, opts *ImportOptions) (*ImportStats, error) {
+	w, err := newBatchWriter(db, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rdb := &rdbReader{r: bufio.NewReader(r)}
+
+	err = rdb.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	selectedDB := 0
+	var expireAt *time.Time
+
+	for {
+		op, err := rdb.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case rdbOpEOF:
+			// the checksum following EOF is not verified, it's zero when disabled on the server
+			return w.stats, w.flush()
+		case rdbOpSelectDB:
+			n, err := rdb.readLength()
+			if err != nil {
+				return nil, err
+			}
+			selectedDB = int(n)
+		case rdbOpResizeDB:
+			_, err = rdb.readLength()
+			if err == nil {
+				_, err = rdb.readLength()
+			}
+		case rdbOpAux:
+			_, err = rdb.readString()
+			if err == nil {
+				_, err = rdb.readString()
+			}
+		case rdbOpFunction2:
+			_, err = rdb.readString()
+		case rdbOpIdle:
+			_, err = rdb.readLength()
+		case rdbOpFreq:
+			_, err = rdb.readByte()
+		case rdbOpExpireTime:
+			var secs uint32
+			err = binary.Read(rdb.r, binary.LittleEndian, &secs)
+			t := time.Unix(int64(secs), 0)
+			expireAt = &t
+		case rdbOpExpireTimeMs:
+			var ms uint64
+			err = binary.Read(rdb.r, binary.LittleEndian, &ms)
+			t := time.Unix(0, int64(ms)*int64(time.Millisecond))
+			expireAt = &t
+		case rdbOpModuleAux:
+			return nil, fmt.Errorf("%w: module data", ErrUnsupportedRDBType)
+		default:
+			expired := expireAt != nil && expireAt.Before(now)
+			expireAt = nil
+
+			err = rdb.readEntry(op, func(key []byte, fields [][]byte, value []byte, imported bool) error {
+				if selectedDB != opts.RedisDB {
+					return nil
+				}
+
+				if !imported || expired {
+					w.stats.Skipped++
+					return nil
+				}
+
+				if fields == nil {
+					return w.add(key, value)
+				}
+
+				for i := 0; i+1 < len(fields); i += 2 {
+					fieldKey := make([]byte, 0, len(key)+len(opts.HashFieldSeparator)+len(fields[i]))
+					fieldKey = append(fieldKey, key...)
+					fieldKey = append(fieldKey, opts.HashFieldSeparator...)
+					fieldKey = append(fieldKey, fields[i]...)
+
+					err := w.add(fieldKey, fields[i+1])
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type rdbReader struct {
+	r *bufio.Reader
+}
+
+func (rdb *rdbReader) readHeader() error {
+	header := make([]byte, len(rdbMagic)+4)
+
+	_, err := io.ReadFull(rdb.r, header)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRDB, err)
+	}
+
+	if string(header[:len(rdbMagic)]) != rdbMagic {
+		return fmt.Errorf("%w: magic string not found", ErrInvalidRDB)
+	}
+
+	version, err := strconv.Atoi(string(header[len(rdbMagic):]))
+	if err != nil || version < 1 || version > rdbMaxVersion {
+		return fmt.Errorf("%w: unsupported version '%s'", ErrInvalidRDB, header[len(rdbMagic):])
+	}
+
+	return nil
+}
+
+func (rdb *rdbReader) readByte() (byte, error) {
+	b, err := rdb.r.ReadByte()
+	if err == io.EOF {
+		return 0, fmt.Errorf("%w: unexpected end of file", ErrInvalidRDB)
+	}
+	return b, err
+}
+
+func (rdb *rdbReader) readBytes(n uint64) ([]byte, error) {
+	b := make([]byte, n)
+
+	_, err := io.ReadFull(rdb.r, b)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("%w: unexpected end of file", ErrInvalidRDB)
+	}
+
+	return b, err
+}
+
+// readLengthOrEncoding returns either a length or, when encoded is true, the special encoding of a string
+func (rdb *rdbReader) readLengthOrEncoding() (n uint64, encoded bool, err error) {
+	b, err := rdb.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3F), false, nil
+	case 1:
+		next, err := rdb.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b&0x3F)<<8 | uint64(next), false, nil
+	case 2:
+		var size uint64
+
+		switch b {
+		case 0x80:
+			size = 4
+		case 0x81:
+			size = 8
+		default:
+			return 0, false, fmt.Errorf("%w: unknown length encoding %x", ErrInvalidRDB, b)
+		}
+
+		bs, err := rdb.readBytes(size)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if size == 4 {
+			return uint64(binary.BigEndian.Uint32(bs)), false, nil
+		}
+		return binary.BigEndian.Uint64(bs), false, nil
+	default:
+		return uint64(b & 0x3F), true, nil
+	}
+}
+
+func (rdb *rdbReader) readLength() (uint64, error) {
+	n, encoded, err := rdb.readLengthOrEncoding()
+	if err != nil {
+		return 0, err
+	}
+	if encoded {
+		return 0, fmt.Errorf("%w: length expected", ErrInvalidRDB)
+	}
+	return n, nil
+}
+
+func (rdb *rdbReader) readString() ([]byte, error) {
+	n, encoded, err := rdb.readLengthOrEncoding()
+	if err != nil {
+		return nil, err
+	}
+
+	if !encoded {
+		return rdb.readBytes(n)
+	}
+
+	switch n {
+	case rdbEncInt8, rdbEncInt16, rdbEncInt32:
+		bs, err := rdb.readBytes(1 << n)
+		if err != nil {
+			return nil, err
+		}
+
+		var v int64
+
+		switch n {
+		case rdbEncInt8:
+			v = int64(int8(bs[0]))
+		case rdbEncInt16:
+			v = int64(int16(binary.LittleEndian.Uint16(bs)))
+		default:
+			v = int64(int32(binary.LittleEndian.Uint32(bs)))
+		}
+
+		return []byte(strconv.FormatInt(v, 10)), nil
+	case rdbEncLZF:
+		clen, err := rdb.readLength()
+		if err != nil {
+			return nil, err
+		}
+
+		ulen, err := rdb.readLength()
+		if err != nil {
+			return nil, err
+		}
+
+		compressed, err := rdb.readBytes(clen)
+		if err != nil {
+			return nil, err
+		}
+
+		return lzfDecompress(compressed, int(ulen))
+	default:
+		return nil, fmt.Errorf("%w: unknown string encoding %d", ErrInvalidRDB, n)
+	}
+}
+
+// readEntry reads a key-value pair of type valueType, onEntry is called with either the value of a string
+// or the fields of a hash, as a sequence of field names and values. Values of other types are read but not
+// returned and imported is false
+func (rdb *rdbReader) readEntry(valueType byte, onEntry func(key []byte, fields [][]byte, value []byte, imported bool) error) error {
+	key, err := rdb.readString()
+	if err != nil {
+		return err
+	}
+
+	switch valueType {
+	case rdbTypeString:
+		value, err := rdb.readString()
+		if err != nil {
+			return err
+		}
+		return onEntry(key, nil, value, true)
+	case rdbTypeHash:
+		n, err := rdb.readLength()
+		if err != nil {
+			return err
+		}
+
+		fields := make([][]byte, 0, 2*n)
+
+		for i := uint64(0); i < 2*n; i++ {
+			s, err := rdb.readString()
+			if err != nil {
+				return err
+			}
+			fields = append(fields, s)
+		}
+
+		return onEntry(key, fields, nil, true)
+	case rdbTypeHashZiplist, rdbTypeHashListpack:
+		blob, err := rdb.readString()
+		if err != nil {
+			return err
+		}
+
+		var fields [][]byte
+		if valueType == rdbTypeHashZiplist {
+			fields, err = ziplistEntries(blob)
+		} else {
+			fields, err = listpackEntries(blob)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(fields)%2 != 0 {
+			return fmt.Errorf("%w: hash '%s' has a field without value", ErrInvalidRDB, key)
+		}
+
+		return onEntry(key, fields, nil, true)
+	case rdbTypeList, rdbTypeSet, rdbTypeListQuicklist:
+		err = rdb.skipStrings()
+	case rdbTypeZset:
+		n, err := rdb.readLength()
+		if err != nil {
+			return err
+		}
+
+		for i := uint64(0); i < n; i++ {
+			_, err = rdb.readString()
+			if err != nil {
+				return err
+			}
+
+			// scores are stored as strings, lengths from 253 on stand for NaN and infinities
+			l, err := rdb.readByte()
+			if err != nil {
+				return err
+			}
+
+			if l < 253 {
+				_, err = rdb.readBytes(uint64(l))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case rdbTypeZset2:
+		n, err := rdb.readLength()
+		if err != nil {
+			return err
+		}
+
+		for i := uint64(0); i < n; i++ {
+			_, err = rdb.readString()
+			if err != nil {
+				return err
+			}
+
+			_, err = rdb.readBytes(8)
+			if err != nil {
+				return err
+			}
+		}
+	case rdbTypeListZiplist, rdbTypeSetIntset, rdbTypeZsetZiplist, rdbTypeZsetListpack, rdbTypeSetListpack:
+		_, err = rdb.readString()
+	case rdbTypeListQuicklist2:
+		n, err := rdb.readLength()
+		if err != nil {
+			return err
+		}
+
+		for i := uint64(0); i < n; i++ {
+			_, err = rdb.readLength()
+			if err != nil {
+				return err
+			}
+
+			_, err = rdb.readString()
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: type %d of key '%s'", ErrUnsupportedRDBType, valueType, key)
+	}
+	if err != nil {
+		return err
+	}
+
+	return onEntry(key, nil, nil, false)
+}
+
+// skipStrings reads a length followed by that many strings
+func (rdb *rdbReader) skipStrings() error {
+	n, err := rdb.readLength()
+	if err != nil {
+		return err
+	}
+
+	for i := uint64(0); i < n; i++ {
+		_, err = rdb.readString()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ziplistEntries decodes a ziplist, a header of 10 bytes followed by the entries and a terminating 0xFF.
+// Each entry is made of the length of the previous one, its encoding and its content.
+func ziplistEntries(zl []byte) ([][]byte, error) {
+	if len(zl) < 11 {
+		return nil, fmt.Errorf("%w: corrupted ziplist", ErrInvalidRDB)
+	}
+
+	var entries [][]byte
+
+	i := 10
+
+	for {
+		if i >= len(zl) {
+			return nil, fmt.Errorf("%w: corrupted ziplist", ErrInvalidRDB)
+		}
+
+		if zl[i] == 0xFF {
+			return entries, nil
+		}
+
+		// length of the previous entry
+		if zl[i] == 0xFE {
+			i += 5
+		} else {
+			i++
+		}
+
+		if i >= len(zl) {
+			return nil, fmt.Errorf("%w: corrupted ziplist", ErrInvalidRDB)
+		}
+
+		enc := zl[i]
+		i++
+
+		var strLen int
+		var intLen int
+
+		switch {
+		case enc>>6 == 0:
+			strLen = int(enc & 0x3F)
+		case enc>>6 == 1:
+			if i >= len(zl) {
+				return nil, fmt.Errorf("%w: corrupted ziplist", ErrInvalidRDB)
+			}
+			strLen = int(enc&0x3F)<<8 | int(zl[i])
+			i++
+		case enc == 0x80:
+			if i+4 > len(zl) {
+				return nil, fmt.Errorf("%w: corrupted ziplist", ErrInvalidRDB)
+			}
+			strLen = int(binary.BigEndian.Uint32(zl[i:]))
+			i += 4
+		case enc == 0xC0:
+			intLen = 2
+		case enc == 0xD0:
+			intLen = 4
+		case enc == 0xE0:
+			intLen = 8
+		case enc == 0xF0:
+			intLen = 3
+		case enc == 0xFE:
+			intLen = 1
+		case enc >= 0xF1 && enc <= 0xFD:
+			entries = append(entries, []byte(strconv.Itoa(int(enc&0x0F)-1)))
+			continue
+		default:
+			return nil, fmt.Errorf("%w: unknown ziplist encoding %x", ErrInvalidRDB, enc)
+		}
+
+		if intLen > 0 {
+			if i+intLen > len(zl) {
+				return nil, fmt.Errorf("%w: corrupted ziplist", ErrInvalidRDB)
+			}
+			entries = append(entries, []byte(strconv.FormatInt(littleEndianInt(zl[i:i+intLen]), 10)))
+			i += intLen
+			continue
+		}
+
+		if i+strLen > len(zl) {
+			return nil, fmt.Errorf("%w: corrupted ziplist", ErrInvalidRDB)
+		}
+
+		entries = append(entries, zl[i:i+strLen])
+		i += strLen
+	}
+}
+
+// listpackEntries decodes a listpack, a header of 6 bytes followed by the entries and a terminating 0xFF.
+// Each entry is made of its encoding, its content and its own length stored backwards.
+func listpackEntries(lp []byte) ([][]byte, error) {
+	if len(lp) < 7 {
+		return nil, fmt.Errorf("%w: corrupted listpack", ErrInvalidRDB)
+	}
+
+	var entries [][]byte
+
+	i := 6
+
+	for {
+		if i >= len(lp) {
+			return nil, fmt.Errorf("%w: corrupted listpack", ErrInvalidRDB)
+		}
+
+		enc := lp[i]
+		if enc == 0xFF {
+			return entries, nil
+		}
+
+		start := i
+		i++
+
+		var hdrLen, strLen, intLen int
+
+		switch {
+		case enc>>7 == 0:
+			entries = append(entries, []byte(strconv.Itoa(int(enc&0x7F))))
+		case enc>>6 == 2:
+			strLen = int(enc & 0x3F)
+		case enc>>5 == 6:
+			hdrLen = 1
+		case enc>>4 == 14:
+			hdrLen = 1
+		case enc == 0xF0:
+			hdrLen = 4
+		case enc == 0xF1:
+			intLen = 2
+		case enc == 0xF2:
+			intLen = 3
+		case enc == 0xF3:
+			intLen = 4
+		case enc == 0xF4:
+			intLen = 8
+		default:
+			return nil, fmt.Errorf("%w: unknown listpack encoding %x", ErrInvalidRDB, enc)
+		}
+
+		if i+hdrLen > len(lp) {
+			return nil, fmt.Errorf("%w: corrupted listpack", ErrInvalidRDB)
+		}
+
+		switch {
+		case enc>>5 == 6:
+			// 13 bits signed integer
+			v := int64(enc&0x1F)<<8 | int64(lp[i])
+			if v >= 1<<12 {
+				v -= 1 << 13
+			}
+			entries = append(entries, []byte(strconv.FormatInt(v, 10)))
+		case enc>>4 == 14:
+			strLen = int(enc&0x0F)<<8 | int(lp[i])
+		case enc == 0xF0:
+			strLen = int(binary.LittleEndian.Uint32(lp[i:]))
+		}
+
+		i += hdrLen
+
+		if i+strLen+intLen > len(lp) {
+			return nil, fmt.Errorf("%w: corrupted listpack", ErrInvalidRDB)
+		}
+
+		if intLen > 0 {
+			entries = append(entries, []byte(strconv.FormatInt(littleEndianInt(lp[i:i+intLen]), 10)))
+		} else if enc>>6 == 2 || enc>>4 == 14 || enc == 0xF0 {
+			entries = append(entries, lp[i:i+strLen])
+		}
+
+		i += strLen + intLen
+
+		// the length of the entry is stored backwards using 7 bits per byte
+		entryLen := i - start
+
+		switch {
+		case entryLen < 1<<7:
+			i++
+		case entryLen < 1<<14:
+			i += 2
+		case entryLen < 1<<21:
+			i += 3
+		case entryLen < 1<<28:
+			i += 4
+		default:
+			i += 5
+		}
+	}
+}
+
+// littleEndianInt decodes a signed integer of len(b) bytes
+func littleEndianInt(b []byte) int64 {
+	var v uint64
+
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+
+	shift := uint(64 - 8*len(b))
+
+	return int64(v<<shift) >> shift
+}
+
+// lzfDecompress decompresses LZF data made of literal runs and back references into the output
+func lzfDecompress(in []byte, outLen int) ([]byte, error) {
+	out := make([]byte, 0, outLen)
+
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+
+		if ctrl < 1<<5 {
+			n := ctrl + 1
+
+			if i+n > len(in) {
+				return nil, fmt.Errorf("%w: corrupted lzf data", ErrInvalidRDB)
+			}
+
+			out = append(out, in[i:i+n]...)
+			i += n
+
+			continue
+		}
+
+		n := ctrl >> 5
+
+		if n == 7 {
+			if i >= len(in) {
+				return nil, fmt.Errorf("%w: corrupted lzf data", ErrInvalidRDB)
+			}
+			n += int(in[i])
+			i++
+		}
+
+		if i >= len(in) {
+			return nil, fmt.Errorf("%w: corrupted lzf data", ErrInvalidRDB)
+		}
+
+		ref := len(out) - (ctrl&0x1F)<<8 - int(in[i]) - 1
+		i++
+
+		if ref < 0 {
+			return nil, fmt.Errorf("%w: corrupted lzf data", ErrInvalidRDB)
+		}
+
+		// back references may overlap with the bytes being copied
+		for j := 0; j < n+2; j++ {
+			out = append(out, out[ref+j])
+		}
+	}
+
+	if len(out) != outLen {
+		return nil, fmt.Errorf("%w: corrupted lzf data", ErrInvalidRDB)
+	}
+
+	return out, nil
+}
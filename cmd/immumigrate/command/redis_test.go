@@ -0,0 +1,244 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immumigrate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+// rdbBuilder writes RDB files using the plain encodings of lengths and strings
+type rdbBuilder struct {
+	bytes.Buffer
+}
+
+func newRDBBuilder() *rdbBuilder {
+	b := &rdbBuilder{}
+	b.WriteString("REDIS0009")
+	return b
+}
+
+func (b *rdbBuilder) length(n int) *rdbBuilder {
+	switch {
+	case n < 1<<6:
+		b.WriteByte(byte(n))
+	case n < 1<<14:
+		b.WriteByte(0x40 | byte(n>>8))
+		b.WriteByte(byte(n))
+	default:
+		b.WriteByte(0x80)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	}
+	return b
+}
+
+func (b *rdbBuilder) str(s string) *rdbBuilder {
+	b.length(len(s))
+	b.WriteString(s)
+	return b
+}
+
+func (b *rdbBuilder) op(op byte) *rdbBuilder {
+	b.WriteByte(op)
+	return b
+}
+
+func newTestDB(t *testing.T, dir, name string) database.DB {
+	db, err := database.NewDB(database.DefaultOption().WithDBRootPath(filepath.Join(dir, "data")).WithDBName(name),
+		logger.NewSimpleLogger("immumigrate ", os.Stderr))
+	require.NoError(t, err)
+	return db
+}
+
+func requireValue(t *testing.T, db database.DB, key, value string) {
+	entry, err := db.Get(&schema.KeyRequest{Key: []byte(key)})
+	require.NoError(t, err)
+	require.Equal(t, []byte(value), entry.Value)
+}
+
+func TestImportRedis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immumigrate_redis")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rdb := newRDBBuilder()
+	rdb.op(rdbOpAux).str("redis-ver").str("6.2.6")
+	rdb.op(rdbOpSelectDB).length(0)
+	rdb.op(rdbOpResizeDB).length(6).length(1)
+	rdb.op(rdbTypeString).str("key1").str("value1")
+
+	// integer encoded value
+	rdb.op(rdbTypeString).str("counter")
+	rdb.WriteByte(0xC0 | rdbEncInt16)
+	binary.Write(rdb, binary.LittleEndian, int16(-300))
+
+	// lzf compressed value
+	rdb.op(rdbTypeString).str("compressed")
+	rdb.WriteByte(0xC0 | rdbEncLZF)
+	rdb.length(6).length(6)
+	rdb.Write([]byte{2, 'a', 'b', 'c', 0x20, 2})
+
+	rdb.op(rdbTypeHash).str("user").length(2).str("name").str("alice").str("age").str("30")
+
+	rdb.op(rdbOpExpireTimeMs)
+	binary.Write(rdb, binary.LittleEndian, uint64(time.Now().Add(-time.Hour).UnixNano()/int64(time.Millisecond)))
+	rdb.op(rdbTypeString).str("expired").str("value")
+
+	rdb.op(rdbOpExpireTimeMs)
+	binary.Write(rdb, binary.LittleEndian, uint64(time.Now().Add(time.Hour).UnixNano()/int64(time.Millisecond)))
+	rdb.op(rdbTypeString).str("expiring").str("value")
+
+	rdb.op(rdbTypeList).str("list").length(2).str("a").str("b")
+	rdb.op(rdbTypeZset2).str("zset").length(1).str("member")
+	rdb.Write(make([]byte, 8))
+
+	rdb.op(rdbOpSelectDB).length(1)
+	rdb.op(rdbTypeString).str("key1").str("db1")
+	rdb.op(rdbOpEOF)
+	rdb.Write(make([]byte, 8))
+
+	db := newTestDB(t, dir, "redis")
+	defer db.Close()
+
+	stats, err := ImportRedis(bytes.NewReader(rdb.Bytes()), db, DefaultImportOptions().WithKeyPrefix([]byte("redis:")).WithBatchSize(2))
+	require.NoError(t, err)
+	require.Equal(t, &ImportStats{Entries: 6, Transactions: 3, Skipped: 3}, stats)
+
+	requireValue(t, db, "redis:key1", "value1")
+	requireValue(t, db, "redis:counter", "-300")
+	requireValue(t, db, "redis:compressed", "abcabc")
+	requireValue(t, db, "redis:user:name", "alice")
+	requireValue(t, db, "redis:user:age", "30")
+	requireValue(t, db, "redis:expiring", "value")
+
+	_, err = db.Get(&schema.KeyRequest{Key: []byte("redis:expired")})
+	require.Error(t, err)
+
+	t.Run("logical database", func(t *testing.T) {
+		db := newTestDB(t, dir, "redis1")
+		defer db.Close()
+
+		stats, err := ImportRedis(bytes.NewReader(rdb.Bytes()), db, DefaultImportOptions().WithRedisDB(1))
+		require.NoError(t, err)
+		require.Equal(t, &ImportStats{Entries: 1, Transactions: 1}, stats)
+
+		requireValue(t, db, "key1", "db1")
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		_, err := ImportRedis(bytes.NewReader(rdb.Bytes()), db, DefaultImportOptions().WithBatchSize(0))
+		require.Error(t, err)
+
+		_, err = ImportRedis(bytes.NewReader([]byte("REDIX0009")), db, DefaultImportOptions())
+		require.ErrorIs(t, err, ErrInvalidRDB)
+
+		_, err = ImportRedis(bytes.NewReader([]byte("REDIS0099")), db, DefaultImportOptions())
+		require.ErrorIs(t, err, ErrInvalidRDB)
+
+		_, err = ImportRedis(bytes.NewReader(rdb.Bytes()[:len(rdb.Bytes())-20]), db, DefaultImportOptions())
+		require.ErrorIs(t, err, ErrInvalidRDB)
+
+		stream := newRDBBuilder()
+		stream.op(15).str("stream")
+		_, err = ImportRedis(bytes.NewReader(stream.Bytes()), db, DefaultImportOptions())
+		require.ErrorIs(t, err, ErrUnsupportedRDBType)
+	})
+}
+
+func TestZiplistEntries(t *testing.T) {
+	zl := make([]byte, 10)
+	zl = append(zl, 0, 0x04, 'n', 'a', 'm', 'e')
+	zl = append(zl, 6, 0xFE, 0xF6)
+	zl = append(zl, 3, 0xF5)
+	zl = append(zl, 2, 0xC0, 0x10, 0x27)
+	zl = append(zl, 0xFF)
+
+	entries, err := ziplistEntries(zl)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("name"), []byte("-10"), []byte("4"), []byte("10000")}, entries)
+
+	_, err = ziplistEntries(zl[:len(zl)-1])
+	require.ErrorIs(t, err, ErrInvalidRDB)
+}
+
+func TestListpackEntries(t *testing.T) {
+	lp := make([]byte, 6)
+	lp = append(lp, 0x84, 'n', 'a', 'm', 'e', 5)
+	lp = append(lp, 0x1E, 1)
+	lp = append(lp, 0xDF, 0xF6, 2)
+	lp = append(lp, 0xF1, 0x10, 0x27, 3)
+	lp = append(lp, 0xFF)
+
+	entries, err := listpackEntries(lp)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("name"), []byte("30"), []byte("-10"), []byte("10000")}, entries)
+
+	_, err = listpackEntries(lp[:len(lp)-1])
+	require.ErrorIs(t, err, ErrInvalidRDB)
+}
+
+func TestImportRedisHashEncodings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immumigrate_redis")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lp := make([]byte, 6)
+	lp = append(lp, 0x81, 'a', 2, 0x81, '1', 2, 0xFF)
+
+	rdb := newRDBBuilder()
+	rdb.op(rdbTypeHashListpack).str("h1").str(string(lp))
+	rdb.op(rdbTypeHashListpack).str("h2").str(string(lp[:len(lp)-4]) + "\xFF")
+	rdb.op(rdbOpEOF)
+
+	db := newTestDB(t, dir, "redis")
+	defer db.Close()
+
+	_, err = ImportRedis(bytes.NewReader(rdb.Bytes()), db, DefaultImportOptions())
+	require.ErrorIs(t, err, ErrInvalidRDB)
+
+	rdb = newRDBBuilder()
+	rdb.op(rdbTypeHashListpack).str("h1").str(string(lp))
+	rdb.op(rdbOpEOF)
+
+	stats, err := ImportRedis(bytes.NewReader(rdb.Bytes()), db, DefaultImportOptions().WithHashFieldSeparator([]byte("/")))
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Entries)
+
+	requireValue(t, db, "h1/a", "1")
+}
+
+func TestLzfDecompress(t *testing.T) {
+	out, err := lzfDecompress([]byte{0, 'a', 0xE0, 0, 0}, 10)
+	require.NoError(t, err)
+	require.Equal(t, []byte("aaaaaaaaaa"), out)
+
+	_, err = lzfDecompress([]byte{0, 'a', 0xE0, 0, 0}, 9)
+	require.ErrorIs(t, err, ErrInvalidRDB)
+
+	_, err = lzfDecompress([]byte{0x20, 5}, 3)
+	require.ErrorIs(t, err, ErrInvalidRDB)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuverify
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/codenotary/immudb/cmd/docs/man"
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/cmd/version"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/codenotary/immudb/pkg/verifier"
+	"github.com/spf13/cobra"
+)
+
+// NewCmd creates a new immuverify command
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "immuverify",
+		Short: "Verify proof bundles and backups offline",
+		Long: `Verify proof bundles and backups offline, without connecting to any immudb server.
+The proofs are checked against a trusted state, the JSON document returned by the /db/state
+endpoint of the server, whose signature is checked when the public key of the server is provided.`,
+		Example: `  immuverify bundle proofs.json --state state.json --public-key server.pub
+  immuverify backup full.backup.tar.gz incremental.backup.tar.gz --state state.json`,
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(bundleCmd())
+	cmd.AddCommand(backupCmd())
+	cmd.AddCommand(man.Generate(cmd, "immuverify", "./cmd/docs/man/immuverify"))
+	cmd.AddCommand(version.VersionCmd())
+
+	return cmd
+}
+
+func bundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle <bundle_file> [--state] [--public-key]",
+		Short: "Verify a proof bundle exported from a database",
+		Long: "Check every key-value of the bundle is included in the state the bundle was exported at, " +
+			"and that state is consistent with the trusted one. The bundle is read either as JSON or as CBOR.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trusted, publicKey, err := trustedStateFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			bundle, err := verifier.ReadBundle(args[0])
+			if err != nil {
+				return err
+			}
+
+			err = verifier.VerifyBundle(bundle, trusted, publicKey)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Bundle verified: %d entries included in database '%s' at transaction %d\n",
+				len(bundle.Entries), bundle.State.Db, bundle.State.TxID)
+			if trusted != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Bundle matches the trusted state at transaction %d\n", trusted.TxId)
+			}
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	stateFlags(cmd)
+
+	return cmd
+}
+
+func backupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup <backup_file> [<incremental_backup_file>...] [--state] [--public-key] [--encryption-passphrase-file|--encryption-key-file|--encryption-key-env]",
+		Short: "Verify a full backup and the incremental backups taken after it",
+		Long: "Restore the backups into a temporary directory and recompute the accumulative linear hash of every transaction, " +
+			"the restored database is compared against the trusted state when provided.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trusted, _, err := trustedStateFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			passphraseFile, err := cmd.Flags().GetString("encryption-passphrase-file")
+			if err != nil {
+				return err
+			}
+			keyFile, err := cmd.Flags().GetString("encryption-key-file")
+			if err != nil {
+				return err
+			}
+			keyEnv, err := cmd.Flags().GetString("encryption-key-env")
+			if err != nil {
+				return err
+			}
+
+			provider, err := c.NewKeyProvider(passphraseFile, keyFile, keyEnv)
+			if err != nil {
+				return err
+			}
+
+			manifest, err := verifier.VerifyBackups(args, provider, trusted)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Backup verified: transactions 1 to %d\n", manifest.TxID)
+			if trusted != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Backup matches the trusted state at transaction %d\n", trusted.TxId)
+			}
+			return nil
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+	stateFlags(cmd)
+	cmd.Flags().String("encryption-passphrase-file", "", "file holding the passphrase the key of encrypted backups is derived from")
+	cmd.Flags().String("encryption-key-file", "", "file holding the hex-encoded 256-bit key of encrypted backups")
+	cmd.Flags().String("encryption-key-env", "", "environment variable holding the hex-encoded 256-bit key of encrypted backups")
+
+	return cmd
+}
+
+func stateFlags(cmd *cobra.Command) {
+	cmd.Flags().String("state", "", "file holding the trusted state of the database as JSON")
+	cmd.Flags().String("public-key", "", "public key of the server, used to check the signature of the states")
+}
+
+// trustedStateFromFlags returns the trusted state, if any, and the public key of the server when provided
+func trustedStateFromFlags(cmd *cobra.Command) (*schema.ImmutableState, *ecdsa.PublicKey, error) {
+	statePath, err := cmd.Flags().GetString("state")
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKeyPath, err := cmd.Flags().GetString("public-key")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var publicKey *ecdsa.PublicKey
+	if publicKeyPath != "" {
+		publicKey, err = signer.ParsePublicKeyFile(publicKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if statePath == "" {
+		return nil, publicKey, nil
+	}
+
+	trusted, err := verifier.ReadTrustedState(statePath, publicKeyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return trusted, publicKey, nil
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
 	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/multierr"
 )
 
@@ -46,7 +47,8 @@ const NodePrefix = byte(1)
 const Version = 1
 
 const (
-	MetaVersion = "VERSION"
+	MetaVersion       = "VERSION"
+	MetaHashAlgorithm = "HASH_ALGORITHM"
 )
 
 const cLogEntrySize = offsetSize + szSize
@@ -68,6 +70,8 @@ type AHtree struct {
 
 	readOnly bool
 
+	hashAlg hashing.Algorithm
+
 	closed bool
 	mutex  sync.Mutex
 
@@ -95,6 +99,7 @@ func Open(path string, opts *Options) (*AHtree, error) {
 
 	metadata := appendable.NewMetadata(nil)
 	metadata.PutInt(MetaVersion, Version)
+	metadata.PutInt(MetaHashAlgorithm, int(opts.hashAlgorithm))
 
 	appendableOpts := multiapp.DefaultOptions().
 		WithReadOnly(opts.readOnly).
@@ -129,6 +134,15 @@ func Open(path string, opts *Options) (*AHtree, error) {
 		return nil, err
 	}
 
+	// the hash algorithm of an existing tree takes precedence,
+	// trees created by previous versions do not have it stored
+	hashAlg, ok := appendable.NewMetadata(cLog.Metadata()).GetInt(MetaHashAlgorithm)
+	if ok {
+		treeOpts := *opts
+		treeOpts.hashAlgorithm = hashing.Algorithm(hashAlg)
+		opts = &treeOpts
+	}
+
 	return OpenWith(pLog, dLog, cLog, opts)
 }
 
@@ -171,6 +185,7 @@ func OpenWith(pLog, dLog, cLog appendable.Appendable, opts *Options) (*AHtree, e
 		pCache:   pCache,
 		dCache:   dCache,
 		readOnly: opts.readOnly,
+		hashAlg:  opts.hashAlgorithm,
 	}
 
 	if cLogSize == 0 {
@@ -186,7 +201,7 @@ func OpenWith(pLog, dLog, cLog appendable.Appendable, opts *Options) (*AHtree, e
 	pOff := binary.BigEndian.Uint64(b[:])
 	pSize := binary.BigEndian.Uint32(b[offsetSize:])
 
-	t.pLogSize = int64(pOff) + int64(pSize)
+	t.pLogSize = int64(pOff) + int64(szSize) + int64(pSize)
 
 	pLogFileSize, err := pLog.Size()
 	if err != nil {
@@ -257,7 +272,7 @@ func (t *AHtree) Append(d []byte) (n uint64, h [sha256.Size]byte, err error) {
 	b[0] = LeafPrefix
 	copy(b[1:], d) // payload
 
-	h = sha256.Sum256(b)
+	h = t.hashAlg.Sum(b)
 	copy(t._digests[:], h[:])
 	dCount := 1
 
@@ -278,7 +293,7 @@ func (t *AHtree) Append(d []byte) (n uint64, h [sha256.Size]byte, err error) {
 			copy(b[1:], hkl[:])
 			copy(b[1+sha256.Size:], h[:])
 
-			h = sha256.Sum256(b[:])
+			h = t.hashAlg.Sum(b[:])
 
 			copy(t._digests[dCount*sha256.Size:], h[:])
 			dCount++
@@ -391,7 +406,7 @@ func (t *AHtree) ResetSize(newSize uint64) error {
 		pOff := binary.BigEndian.Uint64(b[:])
 		pSize := binary.BigEndian.Uint32(b[offsetSize:])
 
-		pLogSize = int64(pOff) + int64(pSize)
+		pLogSize = int64(pOff) + int64(szSize) + int64(pSize)
 
 		pLogFileSize, err := t.pLog.Size()
 		if err != nil {
@@ -632,6 +647,11 @@ func (t *AHtree) highestNode(i uint64, d int) ([sha256.Size]byte, error) {
 	return t.node(i, l)
 }
 
+// HashAlgorithm returns the hash algorithm used to calculate the digests of the tree
+func (t *AHtree) HashAlgorithm() hashing.Algorithm {
+	return t.hashAlg
+}
+
 func (t *AHtree) Size() uint64 {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -28,6 +28,7 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/stretchr/testify/require"
 )
 
@@ -298,7 +299,7 @@ func TestEdgeCases(t *testing.T) {
 			return cLogEntrySize, nil
 		}
 		pLog.SizeFn = func() (int64, error) {
-			return 8, nil
+			return szSize + 8, nil
 		}
 		dLog.SizeFn = func() (int64, error) {
 			return 0, nil
@@ -598,7 +599,7 @@ func TestIntegrity(t *testing.T) {
 			pd[0] = LeafPrefix
 			copy(pd[1:], d)
 
-			verifies := VerifyInclusion(iproof, j, i, sha256.Sum256(pd), r)
+			verifies := VerifyInclusion(iproof, j, i, sha256.Sum256(pd), r, hashing.SHA256)
 			require.True(t, verifies)
 		}
 	}
@@ -647,7 +648,7 @@ func TestInclusionAndConsistencyProofs(t *testing.T) {
 
 		h := sha256.Sum256([]byte{LeafPrefix, byte(i)})
 
-		verifies := VerifyInclusion(iproof, uint64(i), uint64(i), h, r)
+		verifies := VerifyInclusion(iproof, uint64(i), uint64(i), h, r, hashing.SHA256)
 		require.True(t, verifies)
 	}
 
@@ -667,7 +668,7 @@ func TestInclusionAndConsistencyProofs(t *testing.T) {
 
 			h := sha256.Sum256([]byte{LeafPrefix, byte(i)})
 
-			verifies := VerifyInclusion(iproof, uint64(i), uint64(j), h, jroot)
+			verifies := VerifyInclusion(iproof, uint64(i), uint64(j), h, jroot, hashing.SHA256)
 			require.True(t, verifies)
 
 			cproof, err := tree.ConsistencyProof(uint64(i), uint64(j))
@@ -676,7 +677,7 @@ func TestInclusionAndConsistencyProofs(t *testing.T) {
 			iroot, err := tree.RootAt(uint64(i))
 			require.NoError(t, err)
 
-			verifies = VerifyConsistency(cproof, uint64(i), uint64(j), iroot, jroot)
+			verifies = VerifyConsistency(cproof, uint64(i), uint64(j), iroot, jroot, hashing.SHA256)
 			require.True(t, verifies)
 		}
 	}
@@ -689,7 +690,7 @@ func TestInclusionAndConsistencyProofs(t *testing.T) {
 		root, err := tree.RootAt(uint64(i))
 		require.NoError(t, err)
 
-		verifies := VerifyLastInclusion(iproof, uint64(i), h, root)
+		verifies := VerifyLastInclusion(iproof, uint64(i), h, root, hashing.SHA256)
 
 		if i < N {
 			require.False(t, verifies)
@@ -735,11 +736,18 @@ func TestReOpenningImmudbStore(t *testing.T) {
 
 			h := sha256.Sum256([]byte{LeafPrefix, byte((i - 1) % ACount)})
 
-			verifies := VerifyInclusion(proof, uint64(i), uint64(j), h, root)
+			verifies := VerifyInclusion(proof, uint64(i), uint64(j), h, root, hashing.SHA256)
 			require.True(t, verifies)
 		}
 	}
 
+	// data appended after reopening must not overwrite previously appended data
+	for i := 1; i <= ItCount*ACount; i++ {
+		p, err := tree.DataAt(uint64(i))
+		require.NoError(t, err)
+		require.Equal(t, []byte{byte((i - 1) % ACount)}, p)
+	}
+
 	err = tree.Close()
 	require.NoError(t, err)
 }
@@ -793,7 +801,7 @@ func TestReset(t *testing.T) {
 
 			h := sha256.Sum256([]byte{LeafPrefix, byte(i)})
 
-			verifies := VerifyInclusion(iproof, uint64(i), uint64(j), h, jroot)
+			verifies := VerifyInclusion(iproof, uint64(i), uint64(j), h, jroot, hashing.SHA256)
 			require.True(t, verifies)
 
 			cproof, err := tree.ConsistencyProof(uint64(i), uint64(j))
@@ -802,7 +810,7 @@ func TestReset(t *testing.T) {
 			iroot, err := tree.RootAt(uint64(i))
 			require.NoError(t, err)
 
-			verifies = VerifyConsistency(cproof, uint64(i), uint64(j), iroot, jroot)
+			verifies = VerifyConsistency(cproof, uint64(i), uint64(j), iroot, jroot, hashing.SHA256)
 			require.True(t, verifies)
 		}
 	}
@@ -815,7 +823,7 @@ func TestReset(t *testing.T) {
 		root, err := tree.RootAt(uint64(i))
 		require.NoError(t, err)
 
-		verifies := VerifyLastInclusion(iproof, uint64(i), h, root)
+		verifies := VerifyLastInclusion(iproof, uint64(i), h, root, hashing.SHA256)
 
 		if i < N {
 			require.False(t, verifies)
@@ -878,7 +886,7 @@ func TestResetCornerCases(t *testing.T) {
 
 	t.Run("should fail on cLog read error", func(t *testing.T) {
 		injectedErr := errors.New("injected error")
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer(make([]byte, 12*2))
 		cLog.ReadAtFn = func(bs []byte, off int64) (int, error) {
@@ -900,7 +908,7 @@ func TestResetCornerCases(t *testing.T) {
 
 	t.Run("should fail on getting pLogSize", func(t *testing.T) {
 		injectedErr := errors.New("injected error")
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer(make([]byte, 12*2))
 		tree, err := OpenWith(pLog, dLog, cLog, DefaultOptions())
@@ -916,7 +924,7 @@ func TestResetCornerCases(t *testing.T) {
 	})
 
 	t.Run("should fail on corrupted older cLog entries", func(t *testing.T) {
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer([]byte{
 			1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, // Corrupted entry, offset way outside pLog size
@@ -934,7 +942,7 @@ func TestResetCornerCases(t *testing.T) {
 
 	t.Run("should fail on dLog size error", func(t *testing.T) {
 		injectedErr := errors.New("injected error")
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer(make([]byte, 2*12))
 		tree, err := OpenWith(pLog, dLog, cLog, DefaultOptions())
@@ -950,7 +958,7 @@ func TestResetCornerCases(t *testing.T) {
 	})
 
 	t.Run("should fail on incorrect dlog size", func(t *testing.T) {
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer(make([]byte, 2*12))
 		tree, err := OpenWith(pLog, dLog, cLog, DefaultOptions())
@@ -977,3 +985,54 @@ func BenchmarkAppend(b *testing.B) {
 		}
 	}
 }
+
+func TestHashAlgorithm(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ahtree_test_hash_algorithm")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = Open(dir, DefaultOptions().WithHashAlgorithm(hashing.Algorithm(99)))
+	require.Equal(t, ErrIllegalArguments, err)
+
+	tree, err := Open(dir, DefaultOptions().WithHashAlgorithm(hashing.SHA3_256))
+	require.NoError(t, err)
+	require.Equal(t, hashing.SHA3_256, tree.HashAlgorithm())
+
+	N := 64
+
+	for i := 1; i <= N; i++ {
+		_, _, err := tree.Append([]byte{byte(i)})
+		require.NoError(t, err)
+	}
+
+	err = tree.Close()
+	require.NoError(t, err)
+
+	// the hash algorithm is kept as metadata and can not be changed once the tree is created
+	tree, err = Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, hashing.SHA3_256, tree.HashAlgorithm())
+
+	defer tree.Close()
+
+	root, err := tree.RootAt(uint64(N))
+	require.NoError(t, err)
+
+	for i := 1; i <= N; i++ {
+		iproof, err := tree.InclusionProof(uint64(i), uint64(N))
+		require.NoError(t, err)
+
+		h := hashing.SHA3_256.Sum([]byte{LeafPrefix, byte(i)})
+
+		require.True(t, VerifyInclusion(iproof, uint64(i), uint64(N), h, root, hashing.SHA3_256))
+		require.False(t, VerifyInclusion(iproof, uint64(i), uint64(N), h, root, hashing.SHA256))
+
+		iroot, err := tree.RootAt(uint64(i))
+		require.NoError(t, err)
+
+		cproof, err := tree.ConsistencyProof(uint64(i), uint64(N))
+		require.NoError(t, err)
+
+		require.True(t, VerifyConsistency(cproof, uint64(i), uint64(N), iroot, root, hashing.SHA3_256))
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/hashing"
 )
 
 const DefaultFileSize = multiapp.DefaultFileSize
@@ -28,6 +29,7 @@ const DefaultDataCacheSlots = 1_000
 const DefaultDigestsCacheSlots = 100_000
 const DefaultCompressionFormat = appendable.DefaultCompressionFormat
 const DefaultCompressionLevel = appendable.DefaultCompressionLevel
+const DefaultHashAlgorithm = hashing.DefaultAlgorithm
 
 type AppFactoryFunc func(
 	rootPath string,
@@ -49,6 +51,7 @@ type Options struct {
 	fileSize          int
 	compressionFormat int
 	compressionLevel  int
+	hashAlgorithm     hashing.Algorithm
 }
 
 func DefaultOptions() *Options {
@@ -63,6 +66,7 @@ func DefaultOptions() *Options {
 		fileSize:          DefaultFileSize,
 		compressionFormat: DefaultCompressionFormat,
 		compressionLevel:  DefaultCompressionLevel,
+		hashAlgorithm:     DefaultHashAlgorithm,
 	}
 }
 
@@ -70,7 +74,8 @@ func validOptions(opts *Options) bool {
 	return opts != nil &&
 		opts.fileSize > 0 &&
 		opts.dataCacheSlots > 0 &&
-		opts.digestsCacheSlots > 0
+		opts.digestsCacheSlots > 0 &&
+		opts.hashAlgorithm.Supported()
 }
 
 func (opts *Options) WithReadOnly(readOnly bool) *Options {
@@ -113,6 +118,11 @@ func (opts *Options) WithCompresionLevel(compressionLevel int) *Options {
 	return opts
 }
 
+func (opts *Options) WithHashAlgorithm(hashAlgorithm hashing.Algorithm) *Options {
+	opts.hashAlgorithm = hashAlgorithm
+	return opts
+}
+
 func (opts *Options) WithAppFactory(appFactory AppFactoryFunc) *Options {
 	opts.appFactory = appFactory
 	return opts
@@ -15,19 +15,23 @@ limitations under the License.
 */
 package ahtree
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
 
-func VerifyInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf, jRoot [sha256.Size]byte) bool {
-	if i > j || i == 0 || (i < j && len(iproof) == 0) {
+	"github.com/codenotary/immudb/embedded/hashing"
+)
+
+func VerifyInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf, jRoot [sha256.Size]byte, hashAlg hashing.Algorithm) bool {
+	if i > j || i == 0 || (i < j && len(iproof) == 0) || !hashAlg.Supported() {
 		return false
 	}
 
-	ciRoot := EvalInclusion(iproof, i, j, iLeaf)
+	ciRoot := EvalInclusion(iproof, i, j, iLeaf, hashAlg)
 
 	return jRoot == ciRoot
 }
 
-func EvalInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf [sha256.Size]byte) [sha256.Size]byte {
+func EvalInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf [sha256.Size]byte, hashAlg hashing.Algorithm) [sha256.Size]byte {
 	i1 := i - 1
 	j1 := j - 1
 
@@ -45,7 +49,7 @@ func EvalInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf [sha256.Size]b
 			copy(b[sha256.Size+1:], ciRoot[:])
 		}
 
-		ciRoot = sha256.Sum256(b[:])
+		ciRoot = hashAlg.Sum(b[:])
 
 		i1 >>= 1
 		j1 >>= 1
@@ -54,8 +58,8 @@ func EvalInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf [sha256.Size]b
 	return ciRoot
 }
 
-func VerifyConsistency(cproof [][sha256.Size]byte, i, j uint64, iRoot, jRoot [sha256.Size]byte) bool {
-	if i > j || i == 0 || (i < j && len(cproof) == 0) {
+func VerifyConsistency(cproof [][sha256.Size]byte, i, j uint64, iRoot, jRoot [sha256.Size]byte, hashAlg hashing.Algorithm) bool {
+	if i > j || i == 0 || (i < j && len(cproof) == 0) || !hashAlg.Supported() {
 		return false
 	}
 
@@ -63,12 +67,12 @@ func VerifyConsistency(cproof [][sha256.Size]byte, i, j uint64, iRoot, jRoot [sh
 		return iRoot == jRoot
 	}
 
-	ciRoot, cjRoot := EvalConsistency(cproof, i, j)
+	ciRoot, cjRoot := EvalConsistency(cproof, i, j, hashAlg)
 
 	return iRoot == ciRoot && jRoot == cjRoot
 }
 
-func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte, [sha256.Size]byte) {
+func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64, hashAlg hashing.Algorithm) ([sha256.Size]byte, [sha256.Size]byte) {
 	fn := i - 1
 	sn := j - 1
 
@@ -86,10 +90,10 @@ func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte
 			copy(b[1:], h[:])
 
 			copy(b[1+sha256.Size:], ciRoot[:])
-			ciRoot = sha256.Sum256(b[:])
+			ciRoot = hashAlg.Sum(b[:])
 
 			copy(b[1+sha256.Size:], cjRoot[:])
-			cjRoot = sha256.Sum256(b[:])
+			cjRoot = hashAlg.Sum(b[:])
 
 			for fn%2 == 0 && fn != 0 {
 				fn >>= 1
@@ -98,7 +102,7 @@ func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte
 		} else {
 			copy(b[1:], cjRoot[:])
 			copy(b[1+sha256.Size:], h[:])
-			cjRoot = sha256.Sum256(b[:])
+			cjRoot = hashAlg.Sum(b[:])
 		}
 		fn >>= 1
 		sn >>= 1
@@ -107,15 +111,15 @@ func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte
 	return ciRoot, cjRoot
 }
 
-func VerifyLastInclusion(iproof [][sha256.Size]byte, i uint64, leaf, root [sha256.Size]byte) bool {
-	if i == 0 {
+func VerifyLastInclusion(iproof [][sha256.Size]byte, i uint64, leaf, root [sha256.Size]byte, hashAlg hashing.Algorithm) bool {
+	if i == 0 || !hashAlg.Supported() {
 		return false
 	}
 
-	return root == EvalLastInclusion(iproof, i, leaf)
+	return root == EvalLastInclusion(iproof, i, leaf, hashAlg)
 }
 
-func EvalLastInclusion(iproof [][sha256.Size]byte, i uint64, leaf [sha256.Size]byte) [sha256.Size]byte {
+func EvalLastInclusion(iproof [][sha256.Size]byte, i uint64, leaf [sha256.Size]byte, hashAlg hashing.Algorithm) [sha256.Size]byte {
 	i1 := i - 1
 
 	root := leaf
@@ -127,7 +131,7 @@ func EvalLastInclusion(iproof [][sha256.Size]byte, i uint64, leaf [sha256.Size]b
 		copy(b[1:], h[:])
 		copy(b[sha256.Size+1:], root[:])
 
-		root = sha256.Sum256(b[:])
+		root = hashAlg.Sum(b[:])
 
 		i1 >>= 1
 	}
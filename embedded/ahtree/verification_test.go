@@ -19,13 +19,14 @@ import (
 	"crypto/sha256"
 	"testing"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/stretchr/testify/require"
 )
 
 func TestVerificationEdgeCases(t *testing.T) {
-	require.False(t, VerifyInclusion(nil, 1, 10, sha256.Sum256(nil), sha256.Sum256(nil)))
-	require.False(t, VerifyInclusion(nil, 10, 1, sha256.Sum256(nil), sha256.Sum256(nil)))
+	require.False(t, VerifyInclusion(nil, 1, 10, sha256.Sum256(nil), sha256.Sum256(nil), hashing.SHA256))
+	require.False(t, VerifyInclusion(nil, 10, 1, sha256.Sum256(nil), sha256.Sum256(nil), hashing.SHA256))
 
-	require.False(t, VerifyConsistency(nil, 1, 10, sha256.Sum256(nil), sha256.Sum256(nil)))
-	require.False(t, VerifyConsistency(nil, 10, 1, sha256.Sum256(nil), sha256.Sum256(nil)))
+	require.False(t, VerifyConsistency(nil, 1, 10, sha256.Sum256(nil), sha256.Sum256(nil), hashing.SHA256))
+	require.False(t, VerifyConsistency(nil, 10, 1, sha256.Sum256(nil), sha256.Sum256(nil), hashing.SHA256))
 }
@@ -26,8 +26,37 @@ const (
 	GZipCompression
 	LZWCompression
 	ZLibCompression
+	ZStdCompression
+	LZ4Compression
 )
 
+var compressionFormatNames = map[string]int{
+	"none":  NoCompression,
+	"flate": FlateCompression,
+	"gzip":  GZipCompression,
+	"lzw":   LZWCompression,
+	"zlib":  ZLibCompression,
+	"zstd":  ZStdCompression,
+	"lz4":   LZ4Compression,
+}
+
+// CompressionFormatFromName returns the compression format with the given name
+// i.e. "none", "flate", "gzip", "lzw", "zlib", "zstd" or "lz4"
+func CompressionFormatFromName(name string) (int, bool) {
+	format, ok := compressionFormatNames[name]
+	return format, ok
+}
+
+// CompressionFormatName returns the name of the compression format, empty if the format is unknown
+func CompressionFormatName(format int) string {
+	for name, f := range compressionFormatNames {
+		if f == format {
+			return name
+		}
+	}
+	return ""
+}
+
 const (
 	BestSpeed          = flate.BestSpeed
 	BestCompression    = flate.BestCompression
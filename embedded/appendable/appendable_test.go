@@ -0,0 +1,36 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appendable
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionFormatNames(t *testing.T) {
+	for _, format := range []int{NoCompression, FlateCompression, GZipCompression, LZWCompression, ZLibCompression, ZStdCompression, LZ4Compression} {
+		f, ok := CompressionFormatFromName(CompressionFormatName(format))
+		require.True(t, ok)
+		require.Equal(t, format, f)
+	}
+
+	_, ok := CompressionFormatFromName("snappy")
+	require.False(t, ok)
+
+	require.Empty(t, CompressionFormatName(-1))
+}
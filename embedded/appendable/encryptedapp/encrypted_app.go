@@ -0,0 +1,444 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryptedapp
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/encryption"
+)
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+var ErrAlreadyClosed = errors.New("encrypted appendable already closed")
+var ErrReadOnly = errors.New("cannot append when opened in read-only mode")
+var ErrCompressionUnsupported = errors.New("encryption of compressed appendables is not supported")
+var ErrCorruptedData = errors.New("encrypted data is corrupted")
+
+// MaxRecordSize is the maximum amount of plain data encrypted into a single record
+const MaxRecordSize = 4096
+
+const nonceSize = 12
+const tagSize = 16
+
+// record header: ciphertext length (4) + logical offset (8) + data key id (4) + nonce
+const headerSize = 4 + 8 + 4 + nonceSize
+
+type record struct {
+	off  int64 // logical offset of the plain data
+	pOff int64 // physical offset of the record
+	size int   // size of the plain data
+}
+
+// EncryptedAppendable encrypts the data written to an underlying appendable with AES-GCM.
+// Data is sealed in records holding up to MaxRecordSize bytes, each one bound to its logical offset
+// and identifying the data key it was encrypted with, so keys can be rotated at any time.
+// Offsets exposed by EncryptedAppendable are logical ones, as if data was written in plain.
+type EncryptedAppendable struct {
+	app     appendable.Appendable
+	keyRing *encryption.KeyRing
+
+	readOnly bool
+
+	records []record
+
+	pending    []byte // plain data not yet sealed into a record
+	pendingOff int64  // logical offset of the pending data
+
+	cachedRecord int
+	cachedData   []byte
+
+	closed bool
+
+	mutex sync.Mutex
+}
+
+// Open wraps app, which must be an uncompressed appendable,
+// trailing data not holding a complete record is ignored and eventually overwritten.
+func Open(app appendable.Appendable, keyRing *encryption.KeyRing, readOnly bool) (*EncryptedAppendable, error) {
+	if app == nil || keyRing == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if app.CompressionFormat() != appendable.NoCompression {
+		return nil, ErrCompressionUnsupported
+	}
+
+	size, err := app.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	ea := &EncryptedAppendable{
+		app:          app,
+		keyRing:      keyRing,
+		readOnly:     readOnly,
+		cachedRecord: -1,
+	}
+
+	var pOff int64
+	var hdr [headerSize]byte
+
+	for pOff+headerSize <= size {
+		_, err := app.ReadAt(hdr[:], pOff)
+		if err != nil {
+			return nil, err
+		}
+
+		cLen := int(binary.BigEndian.Uint32(hdr[:]))
+		off := int64(binary.BigEndian.Uint64(hdr[4:]))
+
+		if off != ea.pendingOff || cLen <= tagSize || cLen > MaxRecordSize+tagSize || pOff+headerSize+int64(cLen) > size {
+			break
+		}
+
+		ea.records = append(ea.records, record{off: off, pOff: pOff, size: cLen - tagSize})
+
+		ea.pendingOff += int64(cLen - tagSize)
+		pOff += headerSize + int64(cLen)
+	}
+
+	if pOff < size && !readOnly {
+		err = app.SetOffset(pOff)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ea, nil
+}
+
+func (ea *EncryptedAppendable) Metadata() []byte {
+	return ea.app.Metadata()
+}
+
+func (ea *EncryptedAppendable) CompressionFormat() int {
+	return ea.app.CompressionFormat()
+}
+
+func (ea *EncryptedAppendable) CompressionLevel() int {
+	return ea.app.CompressionLevel()
+}
+
+func (ea *EncryptedAppendable) Size() (int64, error) {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	if ea.closed {
+		return 0, ErrAlreadyClosed
+	}
+
+	return ea.offset(), nil
+}
+
+func (ea *EncryptedAppendable) Offset() int64 {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	return ea.offset()
+}
+
+func (ea *EncryptedAppendable) offset() int64 {
+	return ea.pendingOff + int64(len(ea.pending))
+}
+
+func (ea *EncryptedAppendable) SetOffset(off int64) error {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	if ea.closed {
+		return ErrAlreadyClosed
+	}
+
+	if off < 0 || off > ea.offset() {
+		return ErrIllegalArguments
+	}
+
+	if off >= ea.pendingOff {
+		ea.pending = ea.pending[:off-ea.pendingOff]
+		return nil
+	}
+
+	// the record holding off is replaced by its preceding data, which becomes pending again
+	i := ea.recordAt(off)
+
+	data, err := ea.readRecord(i)
+	if err != nil {
+		return err
+	}
+
+	rec := ea.records[i]
+
+	err = ea.app.SetOffset(rec.pOff)
+	if err != nil {
+		return err
+	}
+
+	ea.pending = append(make([]byte, 0, MaxRecordSize), data[:off-rec.off]...)
+	ea.pendingOff = rec.off
+	ea.records = ea.records[:i]
+	ea.cachedRecord = -1
+
+	return nil
+}
+
+func (ea *EncryptedAppendable) Append(bs []byte) (off int64, n int, err error) {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	if ea.closed {
+		return 0, 0, ErrAlreadyClosed
+	}
+
+	if ea.readOnly {
+		return 0, 0, ErrReadOnly
+	}
+
+	if len(bs) == 0 {
+		return 0, 0, ErrIllegalArguments
+	}
+
+	off = ea.offset()
+
+	for n < len(bs) {
+		d := minInt(MaxRecordSize-len(ea.pending), len(bs)-n)
+
+		ea.pending = append(ea.pending, bs[n:n+d]...)
+		n += d
+
+		if len(ea.pending) == MaxRecordSize {
+			err = ea.seal()
+			if err != nil {
+				return off, n, err
+			}
+		}
+	}
+
+	return off, n, nil
+}
+
+// seal encrypts pending data into a new record
+func (ea *EncryptedAppendable) seal() error {
+	if len(ea.pending) == 0 {
+		return nil
+	}
+
+	keyID, aead := ea.keyRing.CurrentKey()
+
+	b := make([]byte, headerSize, headerSize+len(ea.pending)+tagSize)
+
+	binary.BigEndian.PutUint32(b, uint32(len(ea.pending)+tagSize))
+	binary.BigEndian.PutUint64(b[4:], uint64(ea.pendingOff))
+	binary.BigEndian.PutUint32(b[12:], keyID)
+
+	_, err := rand.Read(b[16:headerSize])
+	if err != nil {
+		return err
+	}
+
+	b = aead.Seal(b, b[16:headerSize], ea.pending, b[:16])
+
+	pOff, _, err := ea.app.Append(b)
+	if err != nil {
+		return err
+	}
+
+	// records are made readable right away
+	err = ea.app.Flush()
+	if err != nil {
+		return err
+	}
+
+	ea.records = append(ea.records, record{off: ea.pendingOff, pOff: pOff, size: len(ea.pending)})
+
+	ea.pendingOff += int64(len(ea.pending))
+	ea.pending = ea.pending[:0]
+
+	return nil
+}
+
+// recordAt returns the index of the record holding the logical offset off
+func (ea *EncryptedAppendable) recordAt(off int64) int {
+	return sort.Search(len(ea.records), func(i int) bool {
+		return ea.records[i].off+int64(ea.records[i].size) > off
+	})
+}
+
+func (ea *EncryptedAppendable) readRecord(i int) ([]byte, error) {
+	if i == ea.cachedRecord {
+		return ea.cachedData, nil
+	}
+
+	rec := ea.records[i]
+
+	b := make([]byte, headerSize+rec.size+tagSize)
+
+	_, err := ea.app.ReadAt(b, rec.pOff)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(binary.BigEndian.Uint64(b[4:])) != rec.off {
+		return nil, ErrCorruptedData
+	}
+
+	aead, err := ea.keyRing.Key(binary.BigEndian.Uint32(b[12:]))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := aead.Open(nil, b[16:headerSize], b[headerSize:], b[:16])
+	if err != nil {
+		return nil, ErrCorruptedData
+	}
+
+	ea.cachedRecord = i
+	ea.cachedData = data
+
+	return data, nil
+}
+
+func (ea *EncryptedAppendable) ReadAt(bs []byte, off int64) (n int, err error) {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	if ea.closed {
+		return 0, ErrAlreadyClosed
+	}
+
+	if bs == nil || off < 0 {
+		return 0, ErrIllegalArguments
+	}
+
+	for n < len(bs) {
+		offn := off + int64(n)
+
+		if offn >= ea.pendingOff {
+			if offn >= ea.offset() {
+				return n, io.EOF
+			}
+
+			n += copy(bs[n:], ea.pending[offn-ea.pendingOff:])
+			continue
+		}
+
+		i := ea.recordAt(offn)
+
+		data, err := ea.readRecord(i)
+		if err != nil {
+			return n, err
+		}
+
+		n += copy(bs[n:], data[offn-ea.records[i].off:])
+	}
+
+	return n, nil
+}
+
+func (ea *EncryptedAppendable) Flush() error {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	if ea.closed {
+		return ErrAlreadyClosed
+	}
+
+	if ea.readOnly {
+		return ErrReadOnly
+	}
+
+	err := ea.seal()
+	if err != nil {
+		return err
+	}
+
+	return ea.app.Flush()
+}
+
+func (ea *EncryptedAppendable) Sync() error {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	if ea.closed {
+		return ErrAlreadyClosed
+	}
+
+	if ea.readOnly {
+		return ErrReadOnly
+	}
+
+	err := ea.seal()
+	if err != nil {
+		return err
+	}
+
+	return ea.app.Sync()
+}
+
+func (ea *EncryptedAppendable) Copy(dstPath string) error {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	if ea.closed {
+		return ErrAlreadyClosed
+	}
+
+	if !ea.readOnly {
+		err := ea.seal()
+		if err != nil {
+			return err
+		}
+	}
+
+	return ea.app.Copy(dstPath)
+}
+
+func (ea *EncryptedAppendable) Close() error {
+	ea.mutex.Lock()
+	defer ea.mutex.Unlock()
+
+	if ea.closed {
+		return ErrAlreadyClosed
+	}
+
+	ea.closed = true
+
+	var err error
+
+	if !ea.readOnly {
+		err = ea.seal()
+	}
+
+	cerr := ea.app.Close()
+	if err != nil {
+		return err
+	}
+
+	return cerr
+}
+
+func minInt(a, b int) int {
+	if a <= b {
+		return a
+	}
+	return b
+}
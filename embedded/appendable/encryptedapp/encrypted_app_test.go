@@ -0,0 +1,217 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryptedapp
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/singleapp"
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeyRing(t *testing.T, dir string) *encryption.KeyRing {
+	kp, err := encryption.NewStaticKeyProvider(map[string][]byte{"mk": bytes.Repeat([]byte{1}, encryption.KeySize)}, "mk")
+	require.NoError(t, err)
+
+	kr, err := encryption.OpenKeyRing(filepath.Join(dir, "keyring"), kp, 0644)
+	require.NoError(t, err)
+
+	return kr
+}
+
+func TestEncryptedApp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryptedapp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	kr := newKeyRing(t, dir)
+
+	path := filepath.Join(dir, "data.aof")
+
+	app, err := singleapp.Open(path, singleapp.DefaultOptions())
+	require.NoError(t, err)
+
+	_, err = Open(nil, kr, false)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	ea, err := Open(app, kr, false)
+	require.NoError(t, err)
+
+	_, _, err = ea.Append(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	data := make([]byte, 3*MaxRecordSize+100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	off, n, err := ea.Append(data[:10])
+	require.NoError(t, err)
+	require.Equal(t, int64(0), off)
+	require.Equal(t, 10, n)
+
+	// pending data is readable
+	b := make([]byte, 10)
+	_, err = ea.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, data[:10], b)
+
+	err = ea.Flush()
+	require.NoError(t, err)
+
+	err = kr.RotateDataKey()
+	require.NoError(t, err)
+
+	off, n, err = ea.Append(data[10:])
+	require.NoError(t, err)
+	require.Equal(t, int64(10), off)
+	require.Equal(t, len(data)-10, n)
+
+	require.Equal(t, int64(len(data)), ea.Offset())
+
+	sz, err := ea.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sz)
+
+	err = ea.Sync()
+	require.NoError(t, err)
+
+	b = make([]byte, len(data))
+	n, err = ea.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, len(data), n)
+	require.Equal(t, data, b)
+
+	n, err = ea.ReadAt(b[:100], int64(len(data))-50)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 50, n)
+
+	err = ea.Close()
+	require.NoError(t, err)
+
+	_, err = ea.ReadAt(b, 0)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	// data is not written in plain
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(raw, data[MaxRecordSize:MaxRecordSize+64]))
+
+	app, err = singleapp.Open(path, singleapp.DefaultOptions())
+	require.NoError(t, err)
+
+	ea, err = Open(app, kr, false)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(len(data)), ea.Offset())
+
+	b = make([]byte, len(data))
+	_, err = ea.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, b)
+
+	// truncation within a sealed record
+	err = ea.SetOffset(MaxRecordSize + 5)
+	require.NoError(t, err)
+
+	_, _, err = ea.Append([]byte{0xff})
+	require.NoError(t, err)
+
+	err = ea.Close()
+	require.NoError(t, err)
+
+	app, err = singleapp.Open(path, singleapp.DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	ea, err = Open(app, kr, true)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(MaxRecordSize+6), ea.Offset())
+
+	b = make([]byte, MaxRecordSize+6)
+	_, err = ea.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, append(data[:MaxRecordSize+5:MaxRecordSize+5], 0xff), b)
+
+	_, _, err = ea.Append([]byte{1})
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = ea.Close()
+	require.NoError(t, err)
+}
+
+func TestEncryptedAppTampering(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryptedapp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	kr := newKeyRing(t, dir)
+
+	path := filepath.Join(dir, "data.aof")
+
+	app, err := singleapp.Open(path, singleapp.DefaultOptions())
+	require.NoError(t, err)
+
+	ea, err := Open(app, kr, false)
+	require.NoError(t, err)
+
+	_, _, err = ea.Append([]byte("some data"))
+	require.NoError(t, err)
+
+	err = ea.Close()
+	require.NoError(t, err)
+
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	raw[len(raw)-1] ^= 1
+
+	err = ioutil.WriteFile(path, raw, 0644)
+	require.NoError(t, err)
+
+	app, err = singleapp.Open(path, singleapp.DefaultOptions())
+	require.NoError(t, err)
+
+	ea, err = Open(app, kr, false)
+	require.NoError(t, err)
+
+	_, err = ea.ReadAt(make([]byte, 4), 0)
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	err = ea.Close()
+	require.NoError(t, err)
+}
+
+func TestEncryptedAppWithCompression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryptedapp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	app, err := singleapp.Open(filepath.Join(dir, "data.aof"), singleapp.DefaultOptions().WithCompressionFormat(appendable.ZLibCompression))
+	require.NoError(t, err)
+	defer app.Close()
+
+	_, err = Open(app, newKeyRing(t, dir), false)
+	require.ErrorIs(t, err, ErrCompressionUnsupported)
+}
@@ -28,20 +28,27 @@ import (
 	"sync"
 
 	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/encryptedapp"
 	"github.com/codenotary/immudb/embedded/appendable/singleapp"
 	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/encryption"
 )
 
 var ErrorPathIsNotADirectory = errors.New("path is not a directory")
 var ErrIllegalArguments = errors.New("illegal arguments")
 var ErrAlreadyClosed = errors.New("multi-appendable already closed")
 var ErrReadOnly = errors.New("cannot append when opened in read-only mode")
+var ErrDiscarded = errors.New("data has been discarded")
 
 const (
 	metaFileSize    = "FILE_SIZE"
 	metaWrappedMeta = "WRAPPED_METADATA"
 )
 
+// discardedFilename holds the id of the first chunk not yet discarded,
+// it's named so that it's listed before any chunk
+const discardedFilename = ".discarded"
+
 //---------------------------------------------------------
 
 type MultiFileAppendableHooks interface {
@@ -54,7 +61,9 @@ type MultiFileAppendableHooks interface {
 }
 
 type DefaultMultiFileAppendableHooks struct {
-	path string
+	path     string
+	keyRing  *encryption.KeyRing
+	readOnly bool
 }
 
 func (d *DefaultMultiFileAppendableHooks) OpenInitialAppendable(opts *Options, singleAppOpts *singleapp.Options) (app appendable.Appendable, appID int64, err error) {
@@ -87,7 +96,12 @@ func (d *DefaultMultiFileAppendableHooks) OpenInitialAppendable(opts *Options, s
 }
 
 func (d *DefaultMultiFileAppendableHooks) OpenAppendable(options *singleapp.Options, appname string, needsWriteAccess bool) (appendable.Appendable, error) {
-	return singleapp.Open(filepath.Join(d.path, appname), options)
+	app, err := singleapp.Open(filepath.Join(d.path, appname), options)
+	if err != nil || d.keyRing == nil {
+		return app, err
+	}
+
+	return encryptedapp.Open(app, d.keyRing, d.readOnly)
 }
 
 type MultiFileAppendable struct {
@@ -96,13 +110,20 @@ type MultiFileAppendable struct {
 	currAppID int64
 	currApp   appendable.Appendable
 
+	// chunks preceding firstAppID have been discarded
+	firstAppID int64
+
 	path     string
 	readOnly bool
 	synced   bool
+	mmap     bool
+	directIO bool
 	fileMode os.FileMode
 	fileSize int
 	fileExt  string
 
+	preallocSize int
+
 	closed bool
 
 	hooks MultiFileAppendableHooks
@@ -111,9 +132,49 @@ type MultiFileAppendable struct {
 }
 
 func Open(path string, opts *Options) (*MultiFileAppendable, error) {
-	return OpenWithHooks(path, &DefaultMultiFileAppendableHooks{
-		path: path,
+	if !opts.Valid() {
+		return nil, ErrIllegalArguments
+	}
+
+	mf, err := OpenWithHooks(path, &DefaultMultiFileAppendableHooks{
+		path:     path,
+		keyRing:  opts.keyRing,
+		readOnly: opts.readOnly,
 	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	mf.firstAppID, err = readFirstAppID(path)
+	if err != nil {
+		mf.Close()
+		return nil, err
+	}
+
+	return mf, nil
+}
+
+func readFirstAppID(path string) (int64, error) {
+	b, err := ioutil.ReadFile(filepath.Join(path, discardedFilename))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(string(b), 10, 64)
+}
+
+func writeFirstAppID(path string, appID int64, fileMode os.FileMode) error {
+	tmpFilename := filepath.Join(path, discardedFilename+".tmp")
+
+	err := ioutil.WriteFile(tmpFilename, []byte(strconv.FormatInt(appID, 10)), fileMode)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, filepath.Join(path, discardedFilename))
 }
 
 func OpenWithHooks(path string, hooks MultiFileAppendableHooks, opts *Options) (*MultiFileAppendable, error) {
@@ -142,7 +203,10 @@ func OpenWithHooks(path string, hooks MultiFileAppendableHooks, opts *Options) (
 	appendableOpts := singleapp.DefaultOptions().
 		WithReadOnly(opts.readOnly).
 		WithSynced(opts.synced).
+		WithMmap(opts.mmap).
+		WithDirectIO(opts.directIO).
 		WithFileMode(opts.fileMode).
+		WithPreallocSize(minInt(opts.preallocSize, opts.fileSize)).
 		WithCompressionFormat(opts.compressionFormat).
 		WithCompresionLevel(opts.compressionLevel).
 		WithMetadata(m.Bytes())
@@ -159,18 +223,24 @@ func OpenWithHooks(path string, hooks MultiFileAppendableHooks, opts *Options) (
 
 	fileSize, _ := appendable.NewMetadata(currApp.Metadata()).GetInt(metaFileSize)
 
+	// the size of chunks is set when the appendable is created
+	preallocSize := minInt(opts.preallocSize, fileSize)
+
 	return &MultiFileAppendable{
-		appendables: appendableLRUCache{cache: cache},
-		currAppID:   currAppID,
-		currApp:     currApp,
-		path:        path,
-		readOnly:    opts.readOnly,
-		synced:      opts.synced,
-		fileMode:    opts.fileMode,
-		fileSize:    fileSize,
-		fileExt:     opts.fileExt,
-		closed:      false,
-		hooks:       hooks,
+		appendables:  appendableLRUCache{cache: cache},
+		currAppID:    currAppID,
+		currApp:      currApp,
+		path:         path,
+		readOnly:     opts.readOnly,
+		synced:       opts.synced,
+		mmap:         opts.mmap,
+		directIO:     opts.directIO,
+		fileMode:     opts.fileMode,
+		fileSize:     fileSize,
+		fileExt:      opts.fileExt,
+		preallocSize: preallocSize,
+		closed:       false,
+		hooks:        hooks,
 	}, nil
 }
 
@@ -346,7 +416,10 @@ func (mf *MultiFileAppendable) openAppendable(appname string, activeChunk bool)
 	appendableOpts := singleapp.DefaultOptions().
 		WithReadOnly(mf.readOnly).
 		WithSynced(mf.synced).
+		WithMmap(mf.mmap).
+		WithDirectIO(mf.directIO).
 		WithFileMode(mf.fileMode).
+		WithPreallocSize(mf.preallocSize).
 		WithCompressionFormat(mf.currApp.CompressionFormat()).
 		WithCompresionLevel(mf.currApp.CompressionLevel()).
 		WithMetadata(mf.currApp.Metadata())
@@ -417,6 +490,10 @@ func (mf *MultiFileAppendable) appendableFor(off int64) (appendable.Appendable,
 		return mf.currApp, nil
 	}
 
+	if appID < mf.firstAppID {
+		return nil, ErrDiscarded
+	}
+
 	app, err := mf.appendables.Get(appID)
 
 	if err != nil {
@@ -560,6 +637,152 @@ func (mf *MultiFileAppendable) Close() error {
 	return mf.currApp.Close()
 }
 
+// DiscardUpto removes the chunks holding data preceding the specified offset.
+// The chunk containing the offset and the current chunk are never removed,
+// reading discarded data returns ErrDiscarded.
+func (mf *MultiFileAppendable) DiscardUpto(off int64) error {
+	mf.mutex.Lock()
+	defer mf.mutex.Unlock()
+
+	if mf.closed {
+		return ErrAlreadyClosed
+	}
+
+	if mf.readOnly {
+		return ErrReadOnly
+	}
+
+	if off < 0 {
+		return ErrIllegalArguments
+	}
+
+	appID := appendableID(off, mf.fileSize)
+	if appID > mf.currAppID {
+		appID = mf.currAppID
+	}
+
+	if appID <= mf.firstAppID {
+		return nil
+	}
+
+	// the new boundary is persisted before removing any chunk,
+	// so discarded data can not be mistaken for missing data
+	err := writeFirstAppID(mf.path, appID, mf.fileMode)
+	if err != nil {
+		return err
+	}
+
+	for ; mf.firstAppID < appID; mf.firstAppID++ {
+		app, err := mf.appendables.Pop(mf.firstAppID)
+		if err == nil {
+			err = app.Close()
+			if err != nil {
+				return err
+			}
+		} else if err != cache.ErrKeyNotFound {
+			return err
+		}
+
+		err = os.Remove(filepath.Join(mf.path, appendableName(mf.firstAppID, mf.fileExt)))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// holePuncher is implemented by chunks able to deallocate ranges of data
+type holePuncher interface {
+	PunchHole(off int64, n int) error
+}
+
+// PunchHole deallocates the space used by the specified range of data while keeping offsets unchanged,
+// reading a deallocated range returns zeroed bytes. Ranges within discarded chunks are ignored.
+func (mf *MultiFileAppendable) PunchHole(off int64, n int) error {
+	if mf.readOnly {
+		return ErrReadOnly
+	}
+
+	if off < 0 || n < 0 {
+		return ErrIllegalArguments
+	}
+
+	p := 0
+
+	for p < n {
+		offp := off + int64(p)
+
+		chunkOff := offp % int64(mf.fileSize)
+		chunkN := minInt(n-p, mf.fileSize-int(chunkOff))
+
+		app, err := mf.appendableFor(offp)
+		if err == ErrDiscarded {
+			p += chunkN
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		hp, ok := app.(holePuncher)
+		if !ok {
+			return singleapp.ErrPunchHoleUnsupported
+		}
+
+		err = hp.PunchHole(chunkOff, chunkN)
+		if err != nil {
+			return err
+		}
+
+		p += chunkN
+	}
+
+	return nil
+}
+
+// overwriter is implemented by chunks able to overwrite already appended data
+type overwriter interface {
+	WriteAt(bs []byte, off int64) (int, error)
+}
+
+// WriteAt overwrites already appended data in place while keeping offsets unchanged,
+// the range may span several chunks but none of them may have been discarded.
+func (mf *MultiFileAppendable) WriteAt(bs []byte, off int64) (n int, err error) {
+	if mf.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	if off < 0 {
+		return 0, ErrIllegalArguments
+	}
+
+	for n < len(bs) {
+		offn := off + int64(n)
+
+		chunkOff := offn % int64(mf.fileSize)
+		chunkN := minInt(len(bs)-n, mf.fileSize-int(chunkOff))
+
+		app, err := mf.appendableFor(offn)
+		if err != nil {
+			return n, err
+		}
+
+		ow, ok := app.(overwriter)
+		if !ok {
+			return n, singleapp.ErrWriteAtUnsupported
+		}
+
+		wn, err := ow.WriteAt(bs[n:n+chunkN], chunkOff)
+		n += wn
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
 func (mf *MultiFileAppendable) CurrApp() (appendable.Appendable, int64) {
 	mf.mutex.Lock()
 	defer mf.mutex.Unlock()
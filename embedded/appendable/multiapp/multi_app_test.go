@@ -24,6 +24,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/singleapp"
+	"github.com/codenotary/immudb/embedded/encryption"
 
 	"github.com/stretchr/testify/require"
 )
@@ -305,6 +306,218 @@ func TestMultiAppAppendableForCurrentChunk(t *testing.T) {
 	require.Equal(t, a.currApp, app)
 }
 
+func TestMultiAppEncryption(t *testing.T) {
+	kp, err := encryption.NewStaticKeyProvider(map[string][]byte{"mk": make([]byte, encryption.KeySize)}, "mk")
+	require.NoError(t, err)
+
+	err = os.MkdirAll("testdata_encryption", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll("testdata_encryption")
+
+	kr, err := encryption.OpenKeyRing(filepath.Join("testdata_encryption", "keyring"), kp, 0644)
+	require.NoError(t, err)
+
+	require.False(t, DefaultOptions().WithKeyRing(kr).WithCompressionFormat(appendable.ZLibCompression).Valid())
+
+	opts := DefaultOptions().WithFileSize(16).WithMaxOpenedFiles(1).WithKeyRing(kr)
+
+	a, err := Open(filepath.Join("testdata_encryption", "data"), opts)
+	require.NoError(t, err)
+
+	data := []byte("some data spanning multiple chunks")
+
+	off, n, err := a.Append(data)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), off)
+	require.Equal(t, len(data), n)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open(filepath.Join("testdata_encryption", "data"), opts)
+	require.NoError(t, err)
+
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sz)
+
+	b := make([]byte, len(data))
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, b)
+
+	// encrypted chunks can not be punched
+	err = a.PunchHole(0, 4)
+	require.ErrorIs(t, err, singleapp.ErrPunchHoleUnsupported)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	chunk, err := ioutil.ReadFile(filepath.Join("testdata_encryption", "data", appendableName(0, opts.fileExt)))
+	require.NoError(t, err)
+	require.NotContains(t, string(chunk), "some data")
+}
+
+func TestMultiAppPunchHole(t *testing.T) {
+	a, err := Open("testdata_punch", DefaultOptions().WithFileSize(4).WithMaxOpenedFiles(1).WithCompressionFormat(appendable.NoCompression))
+	defer os.RemoveAll("testdata_punch")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	err = a.PunchHole(-1, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	// the range spans two chunks
+	err = a.PunchHole(2, 4)
+	if err == singleapp.ErrPunchHoleUnsupported {
+		t.Skip("punching holes is not supported by the underlying file system")
+	}
+	require.NoError(t, err)
+
+	b := make([]byte, 10)
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 1, 0, 0, 0, 0, 6, 7, 8, 9}, b)
+
+	err = a.DiscardUpto(4)
+	require.NoError(t, err)
+
+	// ranges within discarded chunks are ignored
+	err = a.PunchHole(0, 9)
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(b[:6], 4)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0, 0, 0, 0, 9}, b[:6])
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	err = a.PunchHole(8, 1)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	a, err = Open("testdata_punch", DefaultOptions().WithFileSize(4).WithReadOnly(true))
+	require.NoError(t, err)
+
+	err = a.PunchHole(8, 1)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestMultiAppWriteAt(t *testing.T) {
+	a, err := Open("testdata_writeat", DefaultOptions().WithFileSize(4).WithMaxOpenedFiles(1).WithCompressionFormat(appendable.NoCompression))
+	defer os.RemoveAll("testdata_writeat")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	require.NoError(t, err)
+
+	_, err = a.WriteAt([]byte{0}, -1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	// the range spans three chunks
+	n, err := a.WriteAt([]byte{10, 10, 10, 10, 10, 10, 10}, 2)
+	require.NoError(t, err)
+	require.Equal(t, 7, n)
+
+	b := make([]byte, 10)
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 1, 10, 10, 10, 10, 10, 10, 10, 9}, b)
+
+	err = a.DiscardUpto(4)
+	require.NoError(t, err)
+
+	_, err = a.WriteAt([]byte{0}, 0)
+	require.ErrorIs(t, err, ErrDiscarded)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	_, err = a.WriteAt([]byte{0}, 8)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	a, err = Open("testdata_writeat", DefaultOptions().WithFileSize(4).WithReadOnly(true))
+	require.NoError(t, err)
+
+	_, err = a.WriteAt([]byte{0}, 8)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestMultiAppDiscard(t *testing.T) {
+	a, err := Open("testdata_discard", DefaultOptions().WithFileSize(2).WithMaxOpenedFiles(1))
+	defer os.RemoveAll("testdata_discard")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{0, 1, 2, 3, 4, 5, 6, 7})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	b := make([]byte, 1)
+
+	// caches the first chunk
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+
+	err = a.DiscardUpto(-1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = a.DiscardUpto(5)
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join("testdata_discard", appendableName(1, DefaultOptions().fileExt)))
+	require.True(t, os.IsNotExist(err))
+
+	_, err = a.ReadAt(b, 0)
+	require.ErrorIs(t, err, ErrDiscarded)
+
+	_, err = a.ReadAt(b, 3)
+	require.ErrorIs(t, err, ErrDiscarded)
+
+	_, err = a.ReadAt(b, 4)
+	require.NoError(t, err)
+	require.Equal(t, []byte{4}, b)
+
+	// the current chunk is never discarded
+	err = a.DiscardUpto(100)
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(b, 7)
+	require.NoError(t, err)
+	require.Equal(t, []byte{7}, b)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_discard", DefaultOptions().WithFileSize(2).WithMaxOpenedFiles(1))
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(b, 5)
+	require.ErrorIs(t, err, ErrDiscarded)
+
+	_, err = a.ReadAt(b, 6)
+	require.NoError(t, err)
+	require.Equal(t, []byte{6}, b)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	err = a.DiscardUpto(0)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
 func TestMultiappOpenIncorrectPath(t *testing.T) {
 	require.NoError(t, ioutil.WriteFile("testfile", []byte{}, 0777))
 	defer os.Remove("testfile")
@@ -323,3 +536,79 @@ func TestMultiappOpenFolderWithBogusFiles(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, a)
 }
+
+func TestMultiAppMmap(t *testing.T) {
+	defer os.RemoveAll("testdata_mmap")
+
+	opts := DefaultOptions().WithFileSize(16).WithMaxOpenedFiles(1).WithMmap(true)
+
+	a, err := Open("testdata_mmap", opts)
+	require.NoError(t, err)
+
+	data := []byte("some data spanning multiple chunks")
+
+	_, _, err = a.Append(data)
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	b := make([]byte, len(data))
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, b)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_mmap", opts.WithReadOnly(true))
+	require.NoError(t, err)
+
+	b = make([]byte, len(data))
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, b)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestMultiAppPreallocation(t *testing.T) {
+	defer os.RemoveAll("testdata_prealloc")
+
+	_, err := Open("testdata_prealloc", DefaultOptions().WithPreallocSize(-1))
+	require.Equal(t, ErrIllegalArguments, err)
+
+	opts := DefaultOptions().
+		WithFileSize(16).
+		WithMaxOpenedFiles(1).
+		WithCompressionFormat(appendable.NoCompression).
+		WithPreallocSize(1 << 20)
+
+	a, err := Open("testdata_prealloc", opts)
+	require.NoError(t, err)
+
+	data := []byte("some data spanning multiple chunks")
+
+	off, _, err := a.Append(data)
+	require.NoError(t, err)
+	require.Zero(t, off)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_prealloc", opts)
+	require.NoError(t, err)
+
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sz)
+
+	b := make([]byte, len(data))
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, b)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
@@ -19,6 +19,7 @@ import (
 	"os"
 
 	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/encryption"
 )
 
 const DefaultFileSize = 1 << 26 // 64Mb
@@ -30,13 +31,17 @@ const DefaultCompressionLevel = appendable.DefaultCompressionLevel
 type Options struct {
 	readOnly          bool
 	synced            bool
+	mmap              bool
+	directIO          bool
 	fileMode          os.FileMode
 	fileSize          int
+	preallocSize      int
 	fileExt           string
 	metadata          []byte
 	maxOpenedFiles    int
 	compressionFormat int
 	compressionLevel  int
+	keyRing           *encryption.KeyRing
 }
 
 func DefaultOptions() *Options {
@@ -55,8 +60,10 @@ func DefaultOptions() *Options {
 func (opts *Options) Valid() bool {
 	return opts != nil &&
 		opts.fileSize > 0 &&
+		opts.preallocSize >= 0 &&
 		opts.maxOpenedFiles > 0 &&
-		opts.fileExt != ""
+		opts.fileExt != "" &&
+		(opts.keyRing == nil || opts.compressionFormat == appendable.NoCompression)
 }
 
 func (opt *Options) WithReadOnly(readOnly bool) *Options {
@@ -69,6 +76,18 @@ func (opt *Options) WithSynced(synced bool) *Options {
 	return opt
 }
 
+// WithMmap enables memory-mapped reads of uncompressed chunks
+func (opt *Options) WithMmap(mmap bool) *Options {
+	opt.mmap = mmap
+	return opt
+}
+
+// WithDirectIO enables writing chunks through direct I/O, bypassing the page cache
+func (opt *Options) WithDirectIO(directIO bool) *Options {
+	opt.directIO = directIO
+	return opt
+}
+
 func (opt *Options) WithFileMode(fileMode os.FileMode) *Options {
 	opt.fileMode = fileMode
 	return opt
@@ -84,6 +103,13 @@ func (opt *Options) WithFileSize(fileSize int) *Options {
 	return opt
 }
 
+// WithPreallocSize sets the disk space reserved for each chunk when it's created, reducing
+// fragmentation and file system metadata updates while appending. It's capped to the file size
+func (opt *Options) WithPreallocSize(preallocSize int) *Options {
+	opt.preallocSize = preallocSize
+	return opt
+}
+
 func (opt *Options) WithFileExt(fileExt string) *Options {
 	opt.fileExt = fileExt
 	return opt
@@ -104,6 +130,13 @@ func (opt *Options) WithCompresionLevel(compressionLevel int) *Options {
 	return opt
 }
 
+// WithKeyRing enables the encryption of the chunks with the keys held by keyRing,
+// encryption is only supported for uncompressed chunks
+func (opt *Options) WithKeyRing(keyRing *encryption.KeyRing) *Options {
+	opt.keyRing = keyRing
+	return opt
+}
+
 func (opt *Options) GetFileExt() string {
 	return opt.fileExt
 }
@@ -44,6 +44,9 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, DefaultCompressionLevel, opts.WithCompresionLevel(DefaultCompressionLevel).compressionLevel)
 
 	require.True(t, opts.WithSynced(true).synced)
+	require.True(t, opts.WithMmap(true).mmap)
+	require.Equal(t, 1024, opts.WithPreallocSize(1024).preallocSize)
+	require.True(t, opts.WithDirectIO(true).directIO)
 
 	require.False(t, opts.WithReadOnly(false).readOnly)
 	require.True(t, opts.Valid())
@@ -51,6 +51,12 @@ func (r *Reader) Offset() int64 {
 	return r.offset
 }
 
+// ReadOffset returns the offset of the next byte to be read, bytes fetched
+// into the internal buffer but not read yet are not taken into account
+func (r *Reader) ReadOffset() int64 {
+	return r.offset - int64(r.dataIndex-r.readIndex)
+}
+
 func (r *Reader) Read(bs []byte) (n int, err error) {
 	l := 0
 
@@ -16,6 +16,7 @@ limitations under the License.
 package appendable
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"testing"
@@ -82,3 +83,18 @@ func TestMockedReader(t *testing.T) {
 	_, err := r.ReadByte()
 	require.Error(t, err)
 }
+
+func TestReaderReadOffset(t *testing.T) {
+	r := NewReaderFrom(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}), 2, 4)
+	require.Equal(t, int64(2), r.ReadOffset())
+
+	b, err := r.ReadByte()
+	require.NoError(t, err)
+	require.Equal(t, byte(3), b)
+	require.Equal(t, int64(3), r.ReadOffset())
+
+	_, err = r.ReadUint32()
+	require.NoError(t, err)
+	require.Equal(t, int64(7), r.ReadOffset())
+	require.Equal(t, int64(10), r.Offset())
+}
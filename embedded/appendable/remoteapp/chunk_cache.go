@@ -0,0 +1,199 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteapp
+
+import (
+	"container/list"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const cacheTmpSuffix = ".tmp_cache"
+
+type chunkCacheEntry struct {
+	name string
+	size int64
+}
+
+// chunkCache is a persistent read-through cache of remote chunks kept
+// on the local disk. Once the total size of cached chunks exceeds the
+// configured limit, least recently used chunks are evicted.
+type chunkCache struct {
+	path     string
+	fileMode os.FileMode
+	maxSize  int64
+
+	mutex   sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+func openChunkCache(path string, maxSize int64, fileMode os.FileMode) (*chunkCache, error) {
+	if path == "" || maxSize <= 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	err := os.MkdirAll(path, fileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &chunkCache{
+		path:     path,
+		fileMode: fileMode,
+		maxSize:  maxSize,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	// Restore the cache content, oldest files are the first ones to be evicted
+	sort.Slice(fis, func(i, j int) bool { return fis[i].ModTime().Before(fis[j].ModTime()) })
+
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+
+		if strings.HasSuffix(fi.Name(), cacheTmpSuffix) {
+			// Leftover of an interrupted download
+			_ = os.Remove(filepath.Join(path, fi.Name()))
+			continue
+		}
+
+		c.entries[fi.Name()] = c.lru.PushFront(&chunkCacheEntry{name: fi.Name(), size: fi.Size()})
+		c.size += fi.Size()
+	}
+
+	c.evict()
+
+	return c, nil
+}
+
+// get returns the path of the cached copy of the chunk if present
+func (c *chunkCache) get(name string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[name]
+	if !ok {
+		return "", false
+	}
+
+	c.lru.MoveToFront(e)
+
+	return filepath.Join(c.path, name), true
+}
+
+// put stores the content of the chunk in the cache and returns the path
+// of the cached copy
+func (c *chunkCache) put(name string, rd io.Reader) (string, error) {
+	fl, err := ioutil.TempFile(c.path, name+".*"+cacheTmpSuffix)
+	if err != nil {
+		return "", err
+	}
+	tmpName := fl.Name()
+	defer os.Remove(tmpName)
+
+	size, err := io.Copy(fl, rd)
+	if err == nil {
+		err = fl.Sync()
+	}
+	if err == nil {
+		err = fl.Chmod(c.fileMode)
+	}
+	cerr := fl.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	fileName := filepath.Join(c.path, name)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	err = os.Rename(tmpName, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	if e, ok := c.entries[name]; ok {
+		c.size -= e.Value.(*chunkCacheEntry).size
+		c.lru.Remove(e)
+	}
+
+	c.entries[name] = c.lru.PushFront(&chunkCacheEntry{name: name, size: size})
+	c.size += size
+
+	c.evict()
+
+	return fileName, nil
+}
+
+// remove drops the cached copy of the chunk, if any
+func (c *chunkCache) remove(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[name]
+	if !ok {
+		return nil
+	}
+
+	return c.removeEntry(e)
+}
+
+func (c *chunkCache) removeEntry(e *list.Element) error {
+	entry := e.Value.(*chunkCacheEntry)
+
+	c.lru.Remove(e)
+	delete(c.entries, entry.name)
+	c.size -= entry.size
+
+	metricsCacheEvictions.Inc()
+
+	// Readers already holding the file open can still use it
+	err := os.Remove(filepath.Join(c.path, entry.name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// evict removes least recently used chunks until the cache fits into its
+// size limit, the most recently used chunk is always kept
+func (c *chunkCache) evict() {
+	for c.size > c.maxSize && c.lru.Len() > 1 {
+		_ = c.removeEntry(c.lru.Back())
+	}
+
+	metricsCacheBytes.WithLabelValues(c.path).Set(float64(c.size))
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteapp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chunk_cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = openChunkCache("", 10, 0755)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = openChunkCache(dir, 0, 0755)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	c, err := openChunkCache(dir, 10, 0755)
+	require.NoError(t, err)
+
+	_, found := c.get("00000000.tst")
+	require.False(t, found)
+
+	fileName, err := c.put("00000000.tst", bytes.NewReader([]byte("abcd")))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "00000000.tst"), fileName)
+
+	_, err = c.put("00000001.tst", bytes.NewReader([]byte("efgh")))
+	require.NoError(t, err)
+
+	// Touch the first chunk so that the second one is evicted first
+	_, found = c.get("00000000.tst")
+	require.True(t, found)
+
+	_, err = c.put("00000002.tst", bytes.NewReader([]byte("ijkl")))
+	require.NoError(t, err)
+
+	_, found = c.get("00000001.tst")
+	require.False(t, found)
+	require.NoFileExists(t, filepath.Join(dir, "00000001.tst"))
+
+	_, found = c.get("00000000.tst")
+	require.True(t, found)
+
+	err = c.remove("00000000.tst")
+	require.NoError(t, err)
+	require.NoFileExists(t, filepath.Join(dir, "00000000.tst"))
+
+	err = c.remove("00000000.tst")
+	require.NoError(t, err)
+
+	// Cache content is restored after reopening, leftovers are removed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "00000003.tst.1"+cacheTmpSuffix), []byte("x"), 0644))
+
+	c, err = openChunkCache(dir, 10, 0755)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, c.size)
+
+	_, found = c.get("00000002.tst")
+	require.True(t, found)
+	require.NoFileExists(t, filepath.Join(dir, "00000003.tst.1"+cacheTmpSuffix))
+
+	// A single chunk exceeding the limit is still cached
+	_, err = c.put("00000004.tst", bytes.NewReader([]byte("0123456789abcdef")))
+	require.NoError(t, err)
+
+	_, found = c.get("00000004.tst")
+	require.True(t, found)
+
+	_, found = c.get("00000002.tst")
+	require.False(t, found)
+}
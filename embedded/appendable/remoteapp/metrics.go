@@ -95,6 +95,23 @@ var (
 	metricsDownloadRetried   = metricsDownloadEvents.WithLabelValues("retried")
 	metricsDownloadSucceeded = metricsDownloadEvents.WithLabelValues("succeeded")
 
+	// ---- Local chunk cache --------------------------------
+
+	metricsCacheEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_remoteapp_cache_events",
+		Help: "Local chunk cache event counters for immudb remote storage",
+	}, []string{"event"})
+
+	metricsCacheHits      = metricsCacheEvents.WithLabelValues("hits")
+	metricsCacheMisses    = metricsCacheEvents.WithLabelValues("misses")
+	metricsCacheErrors    = metricsCacheEvents.WithLabelValues("errors")
+	metricsCacheEvictions = metricsCacheEvents.WithLabelValues("evictions")
+
+	metricsCacheBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "immudb_remoteapp_cache_bytes",
+		Help: "Total number of bytes stored in the local chunk cache",
+	}, []string{"path"})
+
 	// ---- Chunk statistics --------------------------------
 
 	metricsChunkCounts = promauto.NewGaugeVec(prometheus.GaugeOpts{
@@ -29,6 +29,9 @@ type Options struct {
 	retryMaxDelay    time.Duration
 	retryDelayExp    float64
 	retryDelayJitter float64
+
+	cachePath string
+	cacheSize int64
 }
 
 func DefaultOptions() *Options {
@@ -67,6 +70,19 @@ func (opts *Options) WithRetryDelayJitter(retryDelayJitter float64) *Options {
 	return opts
 }
 
+// WithCachePath sets the local folder used to cache chunks read from the remote storage,
+// the cache is only used if its size is set as well
+func (opts *Options) WithCachePath(cachePath string) *Options {
+	opts.cachePath = cachePath
+	return opts
+}
+
+// WithCacheSize sets the maximum number of bytes kept in the local chunk cache, 0 disables the cache
+func (opts *Options) WithCacheSize(cacheSize int64) *Options {
+	opts.cacheSize = cacheSize
+	return opts
+}
+
 func (opts *Options) Valid() bool {
 	// TODO: Compression is not supported ATM, this must be disabled
 	return opts != nil &&
@@ -75,5 +91,6 @@ func (opts *Options) Valid() bool {
 		opts.parallelUploads < 100000 &&
 		opts.retryMinDelay > 0 &&
 		opts.retryMaxDelay > 0 &&
-		opts.retryDelayExp > 1
+		opts.retryDelayExp > 1 &&
+		opts.cacheSize >= 0
 }
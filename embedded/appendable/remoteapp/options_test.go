@@ -39,6 +39,10 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, 7*time.Second, opts.WithRetryMaxDelay(7*time.Second).retryMaxDelay)
 	require.Equal(t, 1.3, opts.WithRetryDelayExp(1.3).retryDelayExp)
 	require.Equal(t, 0.2, opts.WithRetryDelayJitter(0.2).retryDelayJitter)
+	require.Equal(t, "cache", opts.WithCachePath("cache").cachePath)
+	require.EqualValues(t, 1<<20, opts.WithCacheSize(1<<20).cacheSize)
 
 	require.True(t, opts.Valid())
+
+	require.False(t, opts.WithCacheSize(-1).Valid())
 }
@@ -51,6 +51,7 @@ type RemoteStorageAppendable struct {
 	fileExt    string
 	fileMode   os.FileMode
 	remotePath string
+	cache      *chunkCache
 
 	mutex             sync.Mutex
 	chunkInfos        []chunkInfo // keys are are chunk IDs
@@ -103,6 +104,14 @@ func Open(path string, remotePath string, storage remotestorage.Storage, opts *O
 		mainCancelFunc:  mainCancelFunc,
 		uploadThrottler: make(chan struct{}, opts.parallelUploads),
 	}
+	if opts.cachePath != "" && opts.cacheSize > 0 {
+		c, err := openChunkCache(opts.cachePath, opts.cacheSize, opts.GetFileMode())
+		if err != nil {
+			return nil, err
+		}
+		ret.cache = c
+	}
+
 	ret.chunkUploadFinished = sync.NewCond(&ret.mutex)
 	ret.chunkDownloadFinished = sync.NewCond(&ret.mutex)
 
@@ -231,6 +240,14 @@ func (r *RemoteStorageAppendable) uploadChunk(chunkID int64, dontRemoveFile bool
 			return true, nil
 		})
 
+		// Drop any stale copy of the chunk from the local cache
+		cp.Step(func() error {
+			if r.cache == nil {
+				return nil
+			}
+			return r.cache.remove(appName)
+		})
+
 		// Open new appendable from the remote storage
 		cp.RetryableStep(func(retries int, delay time.Duration) (bool, error) {
 			app, err := r.openRemoteAppendableReader(appName)
@@ -601,12 +618,49 @@ func (r *RemoteStorageAppendable) OpenInitialAppendable(opts *multiapp.Options,
 }
 
 func (r *RemoteStorageAppendable) openRemoteAppendableReader(name string) (appendable.Appendable, error) {
+	if r.cache != nil {
+		app, err := r.openCachedAppendableReader(name)
+		if err == nil {
+			return app, nil
+		}
+
+		// The chunk is still readable directly from the remote storage
+		log.Printf("Reading chunk %s through the local cache failed: %v", name, err)
+		metricsCacheErrors.Inc()
+	}
+
 	return openRemoteStorageReader(
 		r.rStorage,
 		r.remotePath+name,
 	)
 }
 
+func (r *RemoteStorageAppendable) openCachedAppendableReader(name string) (appendable.Appendable, error) {
+	fileName, found := r.cache.get(name)
+	if found {
+		metricsCacheHits.Inc()
+	} else {
+		metricsCacheMisses.Inc()
+
+		data, err := r.rStorage.Get(r.mainContext, r.remotePath+name, 0, -1)
+		if err != nil {
+			return nil, err
+		}
+		defer data.Close()
+
+		fileName, err = r.cache.put(name, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := singleapp.DefaultOptions().
+		WithReadOnly(true).
+		WithFileMode(r.fileMode)
+
+	return singleapp.Open(fileName, opts)
+}
+
 func (r *RemoteStorageAppendable) startStatsUpdater() {
 	r.statsUpdaterWaitGroup.Add(1)
 	go func() {
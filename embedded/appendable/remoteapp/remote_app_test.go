@@ -939,3 +939,81 @@ func TestRemoteStorageOpenInitialAppendableCorruptedLocalFile(t *testing.T) {
 	require.Equal(t, ErrInvalidRemoteStorage, err)
 	require.Nil(t, app)
 }
+
+func TestRemoteStorageLocalCache(t *testing.T) {
+	require.NoError(t, os.RemoveAll("testdata"))
+	defer os.RemoveAll("testdata")
+	require.NoError(t, os.RemoveAll("testdata_cache"))
+	defer os.RemoveAll("testdata_cache")
+
+	mem := memory.Open()
+	opts := DefaultOptions()
+	opts.WithFileExt("tst")
+	opts.WithFileSize(10)
+	opts.WithCachePath("testdata_cache")
+	opts.WithCacheSize(1 << 20)
+
+	app, err := Open("testdata", "", mem, opts)
+	require.NoError(t, err)
+
+	dataWritten := []byte("Some pretty long string to cross a chunk boundary")
+
+	_, _, err = app.Append(dataWritten)
+	require.NoError(t, err)
+	require.True(t, waitForRemoval("testdata/00000003.tst"))
+
+	err = app.Close()
+	require.NoError(t, err)
+
+	// Uploaded chunks are kept in the local cache
+	require.FileExists(t, "testdata_cache/00000000.tst")
+	require.FileExists(t, "testdata_cache/00000003.tst")
+
+	err = os.RemoveAll("testdata")
+	require.NoError(t, err)
+
+	var remoteReads int64
+	storage := &remoteStorageMockingWrapper{
+		wrapped: mem,
+		fnGet: func(ctx context.Context, name string, offs, size int64, next func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+			atomic.AddInt64(&remoteReads, 1)
+			return next()
+		},
+	}
+
+	hits := testutil.ToFloat64(metricsCacheHits)
+
+	app, err = Open("testdata", "", storage, opts)
+	require.NoError(t, err)
+	defer app.Close()
+
+	require.True(t, waitForFile("testdata/00000004.tst", time.Second))
+
+	dataRead := make([]byte, len(dataWritten))
+	n, err := app.ReadAt(dataRead, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, len(dataWritten), n)
+	require.Equal(t, dataWritten, dataRead)
+
+	// Only the active chunk had to be downloaded
+	require.EqualValues(t, 1, atomic.LoadInt64(&remoteReads))
+	require.Greater(t, testutil.ToFloat64(metricsCacheHits), hits)
+}
+
+func TestRemoteStorageLocalCacheInvalidPath(t *testing.T) {
+	require.NoError(t, os.RemoveAll("testdata"))
+	defer os.RemoveAll("testdata")
+
+	// Cache path points to a regular file, the cache can not be used
+	require.NoError(t, ioutil.WriteFile("testdata_cache_file", []byte{}, 0644))
+	defer os.Remove("testdata_cache_file")
+
+	mem := memory.Open()
+	opts := DefaultOptions()
+	opts.WithFileExt("tst")
+	opts.WithCachePath("testdata_cache_file")
+	opts.WithCacheSize(1 << 20)
+
+	_, err := Open("testdata", "", mem, opts)
+	require.Error(t, err)
+}
@@ -0,0 +1,37 @@
+// +build linux
+
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singleapp
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// openDirect opens the file for writing bypassing the page cache
+func openDirect(fileName string, fileMode os.FileMode) (*os.File, error) {
+	f, err := os.OpenFile(fileName, os.O_WRONLY|unix.O_DIRECT, fileMode)
+	if errors.Is(err, unix.EINVAL) {
+		// the file system doesn't support direct I/O
+		return nil, ErrDirectIOUnsupported
+	}
+
+	return f, err
+}
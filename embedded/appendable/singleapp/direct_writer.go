@@ -0,0 +1,183 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package singleapp
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// directIOAlignment is the alignment of offsets, sizes and memory buffers required by direct I/O
+const directIOAlignment = 4096
+
+const directWriterBufSize = 256 * directIOAlignment
+
+// writer buffers appended data before writing it into the file
+type writer interface {
+	io.Writer
+	Flush() error
+}
+
+// directWriter writes aligned blocks through a file opened for direct I/O.
+// The last block written is usually partial: it's kept in the buffer and rewritten
+// on the next flush, while the zero padding written after it is truncated.
+type directWriter struct {
+	f  *os.File // opened for direct I/O
+	rf *os.File // regular file, used to read partially written blocks and to truncate the padding
+
+	buf []byte
+
+	blockOff int64 // file offset of the first byte in the buffer, always aligned
+	n        int   // bytes in the buffer
+	written  int   // bytes in the buffer already written into the file
+
+	fileSize int64
+}
+
+func newDirectWriter(f, rf *os.File, off int64) (*directWriter, error) {
+	stat, err := rf.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &directWriter{
+		f:        f,
+		rf:       rf,
+		buf:      alignedBuffer(directWriterBufSize),
+		fileSize: stat.Size(),
+	}
+
+	err = w.reset(off)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// alignedBuffer allocates a buffer whose address is aligned as required by direct I/O
+func alignedBuffer(size int) []byte {
+	b := make([]byte, size+directIOAlignment)
+
+	o := 0
+	if rem := int(uintptr(unsafe.Pointer(&b[0])) & (directIOAlignment - 1)); rem > 0 {
+		o = directIOAlignment - rem
+	}
+
+	return b[o : o+size]
+}
+
+// reset sets the file offset where data is appended, buffered data must have been already flushed
+func (w *directWriter) reset(off int64) error {
+	w.blockOff = off - off%directIOAlignment
+	w.n = int(off - w.blockOff)
+	w.written = w.n
+
+	if w.n == 0 {
+		return nil
+	}
+
+	// the block being appended is rewritten as a whole
+	_, err := w.rf.ReadAt(w.buf[:w.n], w.blockOff)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+func (w *directWriter) Write(bs []byte) (int, error) {
+	written := 0
+
+	for written < len(bs) {
+		c := copy(w.buf[w.n:], bs[written:])
+		w.n += c
+		written += c
+
+		if w.n == len(w.buf) {
+			err := w.writeBuffer()
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+func (w *directWriter) Flush() error {
+	return w.writeBuffer()
+}
+
+// writeBuffer writes the buffered blocks, keeping the trailing partial one into the buffer
+func (w *directWriter) writeBuffer() error {
+	if w.n == w.written {
+		return nil
+	}
+
+	padded := (w.n + directIOAlignment - 1) / directIOAlignment * directIOAlignment
+
+	end := w.blockOff + int64(w.n)
+
+	// padding must preserve data previously written after the appended one
+	p := w.n
+	if end < w.fileSize {
+		rn, err := w.rf.ReadAt(w.buf[w.n:padded], end)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		p += rn
+	}
+
+	for i := p; i < padded; i++ {
+		w.buf[i] = 0
+	}
+
+	_, err := w.f.WriteAt(w.buf[:padded], w.blockOff)
+	if err != nil {
+		return err
+	}
+
+	if w.blockOff+int64(padded) > w.fileSize {
+		// padding beyond previously written data is discarded
+		newSize := end
+		if newSize < w.fileSize {
+			newSize = w.fileSize
+		}
+
+		err = w.rf.Truncate(newSize)
+		if err != nil {
+			return err
+		}
+
+		w.fileSize = newSize
+	}
+
+	full := w.n - w.n%directIOAlignment
+
+	copy(w.buf, w.buf[full:w.n])
+
+	w.blockOff += int64(full)
+	w.n -= full
+	w.written = w.n
+
+	return nil
+}
+
+func (w *directWriter) Close() error {
+	return w.f.Close()
+}
@@ -29,6 +29,10 @@ type Options struct {
 	readOnly bool
 	synced   bool
 	fileMode os.FileMode
+	mmap     bool
+	directIO bool
+
+	preallocSize int
 
 	compressionFormat int
 	compressionLevel  int
@@ -47,7 +51,8 @@ func DefaultOptions() *Options {
 }
 
 func (opts *Options) Valid() bool {
-	return opts != nil
+	return opts != nil &&
+		opts.preallocSize >= 0
 }
 
 func (opts *Options) WithReadOnly(readOnly bool) *Options {
@@ -60,6 +65,27 @@ func (opts *Options) WithSynced(synced bool) *Options {
 	return opts
 }
 
+// WithMmap enables memory-mapped reads of uncompressed files, reads fall back
+// to regular file reads when mmap is not available on the platform
+func (opts *Options) WithMmap(mmap bool) *Options {
+	opts.mmap = mmap
+	return opts
+}
+
+// WithPreallocSize sets the amount of disk space reserved when a new file is created,
+// preallocation is skipped when the platform or file system doesn't support it
+func (opts *Options) WithPreallocSize(preallocSize int) *Options {
+	opts.preallocSize = preallocSize
+	return opts
+}
+
+// WithDirectIO enables writing through direct I/O, bypassing the page cache.
+// Buffered writes are used when direct I/O is not available on the platform or file system
+func (opts *Options) WithDirectIO(directIO bool) *Options {
+	opts.directIO = directIO
+	return opts
+}
+
 func (opts *Options) WithFileMode(fileMode os.FileMode) *Options {
 	opts.fileMode = fileMode
 	return opts
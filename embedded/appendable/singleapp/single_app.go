@@ -25,10 +25,13 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
 	"sync"
 
 	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
 )
 
 var ErrorPathIsNotADirectory = errors.New("path is not a directory")
@@ -36,6 +39,15 @@ var ErrIllegalArguments = errors.New("illegal arguments")
 var ErrAlreadyClosed = errors.New("single-file appendable already closed")
 var ErrReadOnly = errors.New("cannot append when opened in read-only mode")
 var ErrCorruptedMetadata = errors.New("corrupted metadata")
+var ErrPunchHoleUnsupported = errors.New("punching holes is not supported")
+var ErrMmapUnsupported = errors.New("mmap is not supported")
+var ErrWriteAtUnsupported = errors.New("overwriting data is not supported")
+var ErrPreallocationUnsupported = errors.New("preallocation is not supported")
+var ErrDirectIOUnsupported = errors.New("direct I/O is not supported")
+
+// mmapRemapThld is the minimum growth of the file required to remap it,
+// reads of data appended since the last mapping are served from the file meanwhile
+const mmapRemapThld = 1 << 20
 
 const (
 	metaCompressionFormat = "COMPRESSION_FORMAT"
@@ -56,7 +68,10 @@ type AppendableFile struct {
 
 	closed bool
 
-	w *bufio.Writer
+	mmap    bool
+	mmapped []byte
+
+	w writer
 
 	baseOffset int64
 	offset     int64
@@ -126,6 +141,14 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 		metadata = opts.metadata
 
 		baseOffset = int64(4 + len(mBs))
+
+		if opts.preallocSize > 0 {
+			// space is reserved beyond the end of the file, so appending doesn't need to allocate blocks
+			err = preallocate(f, baseOffset, int64(opts.preallocSize))
+			if err != nil && err != ErrPreallocationUnsupported {
+				return nil, err
+			}
+		}
 	} else {
 		r := bufio.NewReader(f)
 
@@ -168,8 +191,23 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 		return nil, err
 	}
 
-	var w *bufio.Writer
-	if !opts.readOnly {
+	var w writer
+
+	if !opts.readOnly && opts.directIO {
+		df, err := openDirect(fileName, opts.fileMode)
+		if err == nil {
+			w, err = newDirectWriter(df, f, off)
+			if err != nil {
+				df.Close()
+			}
+		}
+		if err != nil && err != ErrDirectIOUnsupported {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	if !opts.readOnly && w == nil {
 		w = bufio.NewWriter(f)
 	}
 
@@ -180,6 +218,7 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 		metadata:          metadata,
 		readOnly:          opts.readOnly,
 		synced:            opts.synced,
+		mmap:              opts.mmap && compressionFormat == appendable.NoCompression,
 		w:                 w,
 		baseOffset:        baseOffset,
 		offset:            off - baseOffset,
@@ -266,6 +305,18 @@ func (aof *AppendableFile) SetOffset(off int64) error {
 		return err
 	}
 
+	if dw, ok := aof.w.(*directWriter); ok {
+		err = dw.Flush()
+		if err != nil {
+			return err
+		}
+
+		err = dw.reset(off + aof.baseOffset)
+		if err != nil {
+			return err
+		}
+	}
+
 	aof.offset = off
 	return nil
 }
@@ -280,10 +331,35 @@ func (aof *AppendableFile) writer(w io.Writer) (cw io.Writer, err error) {
 		cw = lzw.NewWriter(w, lzw.MSB, 8)
 	case appendable.ZLibCompression:
 		cw, err = zlib.NewWriterLevel(w, aof.compressionLevel)
+	case appendable.ZStdCompression:
+		cw, err = zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel(aof.compressionLevel)), zstd.WithEncoderConcurrency(1))
+	case appendable.LZ4Compression:
+		lw := lz4.NewWriter(w)
+		err = lw.Apply(lz4.CompressionLevelOption(lz4Level(aof.compressionLevel)))
+		cw = lw
 	}
 	return
 }
 
+// zstdLevel maps flate-like compression levels into zstd encoder levels
+func zstdLevel(level int) zstd.EncoderLevel {
+	if level < appendable.BestSpeed {
+		return zstd.SpeedDefault
+	}
+	return zstd.EncoderLevelFromZstd(level)
+}
+
+// lz4Level maps flate-like compression levels into lz4 compression levels
+func lz4Level(level int) lz4.CompressionLevel {
+	if level < appendable.BestSpeed {
+		return lz4.Fast
+	}
+	if level > appendable.BestCompression {
+		return lz4.Level9
+	}
+	return lz4.CompressionLevel(1 << (8 + level))
+}
+
 func (aof *AppendableFile) reader(r io.Reader) (reader io.ReadCloser, err error) {
 	switch aof.compressionFormat {
 	case appendable.FlateCompression:
@@ -294,6 +370,14 @@ func (aof *AppendableFile) reader(r io.Reader) (reader io.ReadCloser, err error)
 		reader = lzw.NewReader(r, lzw.MSB, 8)
 	case appendable.ZLibCompression:
 		reader, err = zlib.NewReader(r)
+	case appendable.ZStdCompression:
+		var zr *zstd.Decoder
+		zr, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err == nil {
+			reader = zr.IOReadCloser()
+		}
+	case appendable.LZ4Compression:
+		reader = ioutil.NopCloser(lz4.NewReader(r))
 	}
 	return
 }
@@ -370,6 +454,10 @@ func (aof *AppendableFile) ReadAt(bs []byte, off int64) (n int, err error) {
 	}
 
 	if aof.compressionFormat == appendable.NoCompression {
+		if aof.mmap && aof.readMmapped(bs, off+aof.baseOffset) {
+			return len(bs), nil
+		}
+
 		return aof.f.ReadAt(bs, off+aof.baseOffset)
 	}
 
@@ -419,6 +507,181 @@ func (aof *AppendableFile) ReadAt(bs []byte, off int64) (n int, err error) {
 	return
 }
 
+// readMmapped copies into bs the mapped content starting at the file offset off,
+// it returns false when the requested range can not be read from the mapping
+func (aof *AppendableFile) readMmapped(bs []byte, off int64) bool {
+	if len(bs) == 0 || off < 0 {
+		return false
+	}
+
+	end := off + int64(len(bs))
+
+	if end > int64(len(aof.mmapped)) {
+		err := aof.remap(end)
+		if err != nil {
+			// Unable to map the file, stick to regular reads
+			aof.mmap = false
+			return false
+		}
+
+		if end > int64(len(aof.mmapped)) {
+			return false
+		}
+	}
+
+	copy(bs, aof.mmapped[off:end])
+
+	return true
+}
+
+func (aof *AppendableFile) remap(minSize int64) error {
+	stat, err := aof.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := stat.Size()
+
+	if size < minSize {
+		// Not yet written into the file
+		return nil
+	}
+
+	if len(aof.mmapped) > 0 && size-int64(len(aof.mmapped)) < mmapRemapThld {
+		return nil
+	}
+
+	mmapped, err := mmapFile(aof.f, int(size))
+	if err != nil {
+		return err
+	}
+
+	err = aof.unmap()
+	if err != nil {
+		munmapFile(mmapped)
+		return err
+	}
+
+	aof.mmapped = mmapped
+
+	return nil
+}
+
+func (aof *AppendableFile) unmap() error {
+	if aof.mmapped == nil {
+		return nil
+	}
+
+	err := munmapFile(aof.mmapped)
+	if err != nil {
+		return err
+	}
+
+	aof.mmapped = nil
+
+	return nil
+}
+
+// PunchHole deallocates the space used by the specified range of data while keeping offsets unchanged,
+// reading a deallocated range returns zeroed bytes. It's only supported when compression is disabled.
+func (aof *AppendableFile) PunchHole(off int64, n int) error {
+	aof.mutex.Lock()
+	defer aof.mutex.Unlock()
+
+	if aof.closed {
+		return ErrAlreadyClosed
+	}
+
+	if aof.readOnly {
+		return ErrReadOnly
+	}
+
+	if off < 0 || n < 0 || off+int64(n) > aof.offset {
+		return ErrIllegalArguments
+	}
+
+	if aof.compressionFormat != appendable.NoCompression {
+		return ErrPunchHoleUnsupported
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	// buffered data must be written before deallocating it
+	err := aof.w.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = punchHole(aof.f, off+aof.baseOffset, int64(n))
+	if err != nil {
+		return err
+	}
+
+	return aof.reloadPartialBlock()
+}
+
+// WriteAt overwrites already appended data in place while keeping offsets unchanged,
+// it's meant to repair damaged ranges and it's only supported when compression is disabled.
+func (aof *AppendableFile) WriteAt(bs []byte, off int64) (n int, err error) {
+	aof.mutex.Lock()
+	defer aof.mutex.Unlock()
+
+	if aof.closed {
+		return 0, ErrAlreadyClosed
+	}
+
+	if aof.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	if off < 0 || off+int64(len(bs)) > aof.offset {
+		return 0, ErrIllegalArguments
+	}
+
+	if aof.compressionFormat != appendable.NoCompression {
+		return 0, ErrWriteAtUnsupported
+	}
+
+	if len(bs) == 0 {
+		return 0, nil
+	}
+
+	// buffered data must be written before being overwritten
+	err = aof.w.Flush()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err = aof.f.WriteAt(bs, off+aof.baseOffset)
+	if err != nil {
+		return n, err
+	}
+
+	err = aof.reloadPartialBlock()
+	if err != nil {
+		return n, err
+	}
+
+	if aof.synced {
+		return n, aof.f.Sync()
+	}
+
+	return n, nil
+}
+
+// reloadPartialBlock re-reads the partially written block kept by the direct writer,
+// otherwise data written into the file bypassing the writer would be lost on its next flush
+func (aof *AppendableFile) reloadPartialBlock() error {
+	dw, ok := aof.w.(*directWriter)
+	if !ok {
+		return nil
+	}
+
+	return dw.reset(aof.offset + aof.baseOffset)
+}
+
 func (aof *AppendableFile) Flush() error {
 	aof.mutex.Lock()
 	defer aof.mutex.Unlock()
@@ -481,6 +744,18 @@ func (aof *AppendableFile) Close() error {
 		}
 	}
 
+	err := aof.unmap()
+	if err != nil {
+		return err
+	}
+
+	if dw, ok := aof.w.(*directWriter); ok {
+		err = dw.Close()
+		if err != nil {
+			return err
+		}
+	}
+
 	aof.closed = true
 
 	return aof.f.Close()
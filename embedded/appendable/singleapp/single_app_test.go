@@ -378,6 +378,56 @@ func TestSingleAppLZWCompression(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestSingleAppZStdCompression(t *testing.T) {
+	for _, level := range []int{appendable.DefaultCompression, appendable.BestSpeed, appendable.BestCompression} {
+		opts := DefaultOptions().WithCompressionFormat(appendable.ZStdCompression).WithCompresionLevel(level)
+		a, err := Open("testdata.aof", opts)
+		require.NoError(t, err)
+
+		off, _, err := a.Append([]byte{1, 2, 3})
+		require.NoError(t, err)
+		require.Equal(t, int64(0), off)
+
+		err = a.Flush()
+		require.NoError(t, err)
+
+		bs := make([]byte, 3)
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, []byte{1, 2, 3}, bs)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		os.Remove("testdata.aof")
+	}
+}
+
+func TestSingleAppLZ4Compression(t *testing.T) {
+	for _, level := range []int{appendable.DefaultCompression, appendable.BestSpeed, appendable.BestCompression} {
+		opts := DefaultOptions().WithCompressionFormat(appendable.LZ4Compression).WithCompresionLevel(level)
+		a, err := Open("testdata.aof", opts)
+		require.NoError(t, err)
+
+		off, _, err := a.Append([]byte{1, 2, 3})
+		require.NoError(t, err)
+		require.Equal(t, int64(0), off)
+
+		err = a.Flush()
+		require.NoError(t, err)
+
+		bs := make([]byte, 3)
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, []byte{1, 2, 3}, bs)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		os.Remove("testdata.aof")
+	}
+}
+
 func TestSingleAppCantCreateFile(t *testing.T) {
 	dir, err := ioutil.TempDir(os.TempDir(), "singleapp")
 	defer os.RemoveAll(dir)
@@ -393,3 +443,357 @@ func TestSingleAppCantCreateFile(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "exists")
 }
+
+func TestSingleAppWriteAt(t *testing.T) {
+	a, err := Open("testdata_writeat.aof", DefaultOptions().WithCompressionFormat(appendable.NoCompression))
+	defer os.Remove("testdata_writeat.aof")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	require.NoError(t, err)
+
+	_, err = a.WriteAt([]byte{0}, -1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = a.WriteAt([]byte{0, 0}, 7)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	n, err := a.WriteAt([]byte{0, 0, 0}, 2)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	bs := make([]byte, 8)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 0, 0, 0, 6, 7, 8}, bs)
+
+	off, _, err := a.Append([]byte{9})
+	require.NoError(t, err)
+	require.Equal(t, int64(8), off)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	_, err = a.WriteAt([]byte{0}, 0)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	a, err = Open("testdata_writeat.aof", DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 0, 0, 0, 6, 7, 8}, bs)
+
+	_, err = a.WriteAt([]byte{0}, 0)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_writeat_compressed.aof", DefaultOptions().WithCompressionFormat(appendable.FlateCompression))
+	defer os.Remove("testdata_writeat_compressed.aof")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	_, err = a.WriteAt([]byte{0}, 0)
+	require.ErrorIs(t, err, ErrWriteAtUnsupported)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppPunchHole(t *testing.T) {
+	a, err := Open("testdata_punch.aof", DefaultOptions().WithCompressionFormat(appendable.NoCompression))
+	defer os.Remove("testdata_punch.aof")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	require.NoError(t, err)
+
+	err = a.PunchHole(-1, 2)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	err = a.PunchHole(4, 8)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	err = a.PunchHole(2, 0)
+	require.NoError(t, err)
+
+	err = a.PunchHole(2, 4)
+	if err == ErrPunchHoleUnsupported {
+		t.Skip("punching holes is not supported by the underlying file system")
+	}
+	require.NoError(t, err)
+
+	bs := make([]byte, 8)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 0, 0, 0, 0, 7, 8}, bs)
+
+	off, _, err := a.Append([]byte{9})
+	require.NoError(t, err)
+	require.Equal(t, int64(8), off)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	err = a.PunchHole(0, 1)
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	a, err = Open("testdata_punch.aof", DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	err = a.PunchHole(0, 1)
+	require.Equal(t, ErrReadOnly, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_punch_compressed.aof", DefaultOptions().WithCompressionFormat(appendable.ZLibCompression))
+	defer os.Remove("testdata_punch_compressed.aof")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = a.PunchHole(0, 1)
+	require.Equal(t, ErrPunchHoleUnsupported, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppPreallocation(t *testing.T) {
+	_, err := Open("testdata_prealloc.aof", DefaultOptions().WithPreallocSize(-1))
+	require.Equal(t, ErrIllegalArguments, err)
+
+	opts := DefaultOptions().
+		WithCompressionFormat(appendable.NoCompression).
+		WithPreallocSize(1 << 20)
+
+	a, err := Open("testdata_prealloc.aof", opts)
+	defer os.Remove("testdata_prealloc.aof")
+	require.NoError(t, err)
+
+	// preallocated space is not part of the appendable
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Zero(t, sz)
+	require.Zero(t, a.Offset())
+
+	off, _, err := a.Append([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	require.NoError(t, err)
+	require.Zero(t, off)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_prealloc.aof", opts)
+	require.NoError(t, err)
+
+	sz, err = a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(8), sz)
+	require.Equal(t, int64(8), a.Offset())
+
+	bs := make([]byte, 8)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppDirectIO(t *testing.T) {
+	opts := DefaultOptions().
+		WithCompressionFormat(appendable.NoCompression).
+		WithDirectIO(true)
+
+	a, err := Open("testdata_direct_io.aof", opts)
+	defer os.Remove("testdata_direct_io.aof")
+	require.NoError(t, err)
+
+	var data []byte
+
+	// appended data is not aligned and spans several blocks and buffers
+	for i := 0; len(data) < 3*directWriterBufSize; i++ {
+		bs := make([]byte, 1+i%(2*directIOAlignment))
+		for j := range bs {
+			bs[j] = byte(i + j)
+		}
+
+		off, n, err := a.Append(bs)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), off)
+		require.Equal(t, len(bs), n)
+
+		data = append(data, bs...)
+
+		if i%10 == 0 {
+			err = a.Flush()
+			require.NoError(t, err)
+		}
+	}
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sz)
+
+	bs := make([]byte, len(data))
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	// overwriting from a previous offset keeps the size of the file
+	err = a.SetOffset(10)
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	copy(data[10:], []byte{1, 2, 3})
+
+	sz, err = a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sz)
+
+	err = a.SetOffset(int64(len(data)))
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{4, 5, 6})
+	require.NoError(t, err)
+
+	data = append(data, 4, 5, 6)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_direct_io.aof", opts)
+	require.NoError(t, err)
+
+	sz, err = a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sz)
+
+	bs = make([]byte, len(data))
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppDirectIOWriteAt(t *testing.T) {
+	opts := DefaultOptions().
+		WithCompressionFormat(appendable.NoCompression).
+		WithDirectIO(true)
+
+	a, err := Open("testdata_direct_io_writeat.aof", opts)
+	defer os.Remove("testdata_direct_io_writeat.aof")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	require.NoError(t, err)
+
+	// data is rewritten within the partial block kept by the writer
+	_, err = a.WriteAt([]byte{0, 0, 0}, 2)
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{9})
+	require.NoError(t, err)
+
+	err = a.PunchHole(7, 1)
+	if err == ErrPunchHoleUnsupported {
+		t.Skip("punching holes is not supported by the underlying file system")
+	}
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{10})
+	require.NoError(t, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_direct_io_writeat.aof", opts)
+	require.NoError(t, err)
+
+	bs := make([]byte, 10)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 0, 0, 0, 6, 7, 0, 9, 10}, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppMmap(t *testing.T) {
+	opts := DefaultOptions().
+		WithCompressionFormat(appendable.NoCompression).
+		WithMmap(true)
+
+	a, err := Open("testdata_mmap.aof", opts)
+	defer os.Remove("testdata_mmap.aof")
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	bs := make([]byte, 4)
+	_, err = a.ReadAt(bs, 2)
+	require.NoError(t, err)
+	require.Equal(t, []byte{3, 4, 5, 6}, bs)
+
+	// Data appended after the file was mapped is still readable
+	_, _, err = a.Append([]byte{9, 10})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(bs, 6)
+	require.NoError(t, err)
+	require.Equal(t, []byte{7, 8, 9, 10}, bs)
+
+	_, err = a.ReadAt(bs, 8)
+	require.Equal(t, io.EOF, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(bs, 0)
+	require.Equal(t, ErrAlreadyClosed, err)
+
+	a, err = Open("testdata_mmap.aof", opts.WithReadOnly(true))
+	require.NoError(t, err)
+
+	bs = make([]byte, 10)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppMmapIgnoredWithCompression(t *testing.T) {
+	a, err := Open("testdata_mmap_compressed.aof", DefaultOptions().WithCompressionFormat(appendable.GZipCompression).WithMmap(true))
+	defer os.Remove("testdata_mmap_compressed.aof")
+	require.NoError(t, err)
+	require.False(t, a.mmap)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ARCCache implements the Adaptive Replacement Cache policy.
+// Resident entries are held in t1 (seen once recently) or t2 (seen at least twice),
+// keys recently evicted from them are remembered in the ghost lists b1 and b2.
+// Hits on ghost keys adapt the target size p of t1.
+type ARCCache struct {
+	data map[interface{}]*arcEntry
+
+	t1, t2 *list.List
+	b1, b2 *list.List
+
+	p    int
+	size int
+
+	mutex sync.Mutex
+}
+
+type arcEntry struct {
+	value interface{}
+	list  *list.List
+	order *list.Element
+}
+
+func NewARCCache(size int) (*ARCCache, error) {
+	if size < 1 {
+		return nil, ErrIllegalArguments
+	}
+
+	return &ARCCache{
+		data: make(map[interface{}]*arcEntry, size),
+		t1:   list.New(),
+		t2:   list.New(),
+		b1:   list.New(),
+		b2:   list.New(),
+		size: size,
+	}, nil
+}
+
+func (c *ARCCache) moveTo(key interface{}, e *arcEntry, l *list.List) {
+	if e.list != nil {
+		e.list.Remove(e.order)
+	}
+
+	e.list = l
+	e.order = l.PushBack(key)
+}
+
+func (c *ARCCache) removeLRU(l *list.List) {
+	lruEntry := l.Front()
+	delete(c.data, lruEntry.Value)
+	l.Remove(lruEntry)
+}
+
+// replace evicts a resident entry into the corresponding ghost list
+func (c *ARCCache) replace(inB2 bool) (rkey interface{}, rvalue interface{}) {
+	var from, to *list.List
+
+	if c.t1.Len() > 0 && (c.t1.Len() > c.p || (inB2 && c.t1.Len() == c.p)) {
+		from, to = c.t1, c.b1
+	} else {
+		from, to = c.t2, c.b2
+	}
+
+	if from.Len() == 0 {
+		from, to = c.t1, c.b1
+		if from.Len() == 0 {
+			from, to = c.t2, c.b2
+		}
+	}
+
+	if from.Len() == 0 {
+		return nil, nil
+	}
+
+	rkey = from.Front().Value
+	e := c.data[rkey]
+	rvalue = e.value
+
+	e.value = nil
+	c.moveTo(rkey, e, to)
+
+	return rkey, rvalue
+}
+
+func (c *ARCCache) Put(key interface{}, value interface{}) (rkey interface{}, rvalue interface{}, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key == nil || value == nil {
+		return nil, nil, ErrIllegalArguments
+	}
+
+	e, ok := c.data[key]
+
+	if ok && (e.list == c.t1 || e.list == c.t2) {
+		e.value = value
+		c.moveTo(key, e, c.t2)
+		return nil, nil, nil
+	}
+
+	full := c.t1.Len()+c.t2.Len() >= c.size
+
+	if ok && e.list == c.b1 {
+		c.p = minInt(c.size, c.p+maxInt(c.b2.Len()/c.b1.Len(), 1))
+
+		if full {
+			rkey, rvalue = c.replace(false)
+		}
+
+		e.value = value
+		c.moveTo(key, e, c.t2)
+
+		return rkey, rvalue, nil
+	}
+
+	if ok && e.list == c.b2 {
+		c.p = maxInt(0, c.p-maxInt(c.b1.Len()/c.b2.Len(), 1))
+
+		if full {
+			rkey, rvalue = c.replace(true)
+		}
+
+		e.value = value
+		c.moveTo(key, e, c.t2)
+
+		return rkey, rvalue, nil
+	}
+
+	if c.t1.Len()+c.b1.Len() >= c.size {
+		if c.t1.Len() < c.size {
+			c.removeLRU(c.b1)
+
+			if full {
+				rkey, rvalue = c.replace(false)
+			}
+		} else {
+			rkey = c.t1.Front().Value
+			rvalue = c.data[rkey].value
+			c.removeLRU(c.t1)
+		}
+	} else {
+		if c.t1.Len()+c.t2.Len()+c.b1.Len()+c.b2.Len() >= 2*c.size && c.b2.Len() > 0 {
+			c.removeLRU(c.b2)
+		}
+
+		if full {
+			rkey, rvalue = c.replace(false)
+		}
+	}
+
+	e = &arcEntry{value: value}
+	c.data[key] = e
+	c.moveTo(key, e, c.t1)
+
+	return rkey, rvalue, nil
+}
+
+func (c *ARCCache) Get(key interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	e, ok := c.data[key]
+	if !ok || (e.list != c.t1 && e.list != c.t2) {
+		return nil, ErrKeyNotFound
+	}
+
+	c.moveTo(key, e, c.t2)
+
+	return e.value, nil
+}
+
+func (c *ARCCache) Pop(key interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	e, ok := c.data[key]
+	if !ok || (e.list != c.t1 && e.list != c.t2) {
+		return nil, ErrKeyNotFound
+	}
+
+	e.list.Remove(e.order)
+	delete(c.data, key)
+
+	return e.value, nil
+}
+
+func (c *ARCCache) Replace(key interface{}, value interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	e, ok := c.data[key]
+	if !ok || (e.list != c.t1 && e.list != c.t2) {
+		return nil, ErrKeyNotFound
+	}
+
+	oldV := e.value
+	e.value = value
+
+	return oldV, nil
+}
+
+func (c *ARCCache) Resize(size int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for size < c.t1.Len()+c.t2.Len() {
+		c.replace(false)
+	}
+
+	for c.b1.Len() > 0 && c.t1.Len()+c.b1.Len() > size {
+		c.removeLRU(c.b1)
+	}
+
+	for c.b2.Len() > 0 && c.t1.Len()+c.t2.Len()+c.b1.Len()+c.b2.Len() > 2*size {
+		c.removeLRU(c.b2)
+	}
+
+	c.size = size
+	c.p = minInt(c.p, size)
+}
+
+func (c *ARCCache) Size() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.size
+}
+
+func (c *ARCCache) EntriesCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.t1.Len() + c.t2.Len()
+}
+
+func (c *ARCCache) Apply(fun func(k interface{}, v interface{}) error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for k, e := range c.data {
+		if e.list != c.t1 && e.list != c.t2 {
+			continue
+		}
+
+		err := fun(k, e.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func minInt(a, b int) int {
+	if a <= b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a >= b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestARCCacheCreation(t *testing.T) {
+	_, err := NewARCCache(0)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	cacheSize := 10
+	cache, err := NewARCCache(cacheSize)
+	require.NoError(t, err)
+	require.Equal(t, cacheSize, cache.Size())
+
+	_, err = cache.Get(nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, _, err = cache.Put(nil, nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = cache.Pop(nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = cache.Replace(nil, nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	for i := 0; i < cacheSize; i++ {
+		rk, _, err := cache.Put(i, 10*i)
+		require.NoError(t, err)
+		require.Nil(t, rk)
+	}
+	require.Equal(t, cacheSize, cache.EntriesCount())
+
+	for i := 0; i < cacheSize; i++ {
+		v, err := cache.Get(i)
+		require.NoError(t, err)
+		require.Equal(t, 10*i, v)
+	}
+
+	rk, rv, err := cache.Put(cacheSize, 10*cacheSize)
+	require.NoError(t, err)
+	require.NotNil(t, rk)
+	require.Equal(t, 10*rk.(int), rv)
+	require.Equal(t, cacheSize, cache.EntriesCount())
+
+	_, err = cache.Get(rk)
+	require.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestARCCacheScanResistance(t *testing.T) {
+	cacheSize := 10
+	cache, err := NewARCCache(cacheSize)
+	require.NoError(t, err)
+
+	// frequently used entries
+	for i := 0; i < cacheSize/2; i++ {
+		_, _, err = cache.Put(i, i)
+		require.NoError(t, err)
+
+		_, err = cache.Get(i)
+		require.NoError(t, err)
+	}
+
+	// a scan of entries used only once
+	for i := 100; i < 200; i++ {
+		_, _, err = cache.Put(i, i)
+		require.NoError(t, err)
+		require.LessOrEqual(t, cache.EntriesCount(), cacheSize)
+	}
+
+	for i := 0; i < cacheSize/2; i++ {
+		v, err := cache.Get(i)
+		require.NoError(t, err)
+		require.Equal(t, i, v)
+	}
+}
+
+func TestARCCacheGhostHits(t *testing.T) {
+	cacheSize := 4
+	cache, err := NewARCCache(cacheSize)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		_, _, err = cache.Put(i%7, i)
+		require.NoError(t, err)
+		require.LessOrEqual(t, cache.EntriesCount(), cacheSize)
+
+		_, _, err = cache.Put(i%3, i)
+		require.NoError(t, err)
+		require.LessOrEqual(t, cache.EntriesCount(), cacheSize)
+
+		require.LessOrEqual(t, cache.b1.Len()+cache.b2.Len()+cache.EntriesCount(), 2*cacheSize)
+	}
+}
+
+func TestARCCacheOperations(t *testing.T) {
+	cache, err := NewARCCache(5)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, _, err = cache.Put(i, i)
+		require.NoError(t, err)
+	}
+
+	_, err = cache.Replace(10, 10)
+	require.Equal(t, ErrKeyNotFound, err)
+
+	old, err := cache.Replace(1, 11)
+	require.NoError(t, err)
+	require.Equal(t, 1, old)
+
+	v, err := cache.Pop(1)
+	require.NoError(t, err)
+	require.Equal(t, 11, v)
+
+	_, err = cache.Pop(1)
+	require.Equal(t, ErrKeyNotFound, err)
+	require.Equal(t, 4, cache.EntriesCount())
+
+	count := 0
+	err = cache.Apply(func(k, v interface{}) error {
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+
+	errApply := errors.New("apply error")
+	err = cache.Apply(func(k, v interface{}) error {
+		return errApply
+	})
+	require.Equal(t, errApply, err)
+
+	cache.Resize(2)
+	require.Equal(t, 2, cache.Size())
+	require.Equal(t, 2, cache.EntriesCount())
+}
+
+func TestNewCache(t *testing.T) {
+	require.True(t, ValidEvictionPolicy(LRU))
+	require.True(t, ValidEvictionPolicy(ARC))
+	require.False(t, ValidEvictionPolicy("fifo"))
+
+	c, err := NewCache(LRU, 10)
+	require.NoError(t, err)
+	require.IsType(t, &LRUCache{}, c)
+
+	c, err = NewCache(ARC, 10)
+	require.NoError(t, err)
+	require.IsType(t, &ARCCache{}, c)
+
+	_, err = NewCache("fifo", 10)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import "fmt"
+
+// EvictionPolicy determines which entry is evicted when a cache is full
+type EvictionPolicy string
+
+const (
+	// LRU evicts the least recently used entry
+	LRU EvictionPolicy = "lru"
+	// ARC (Adaptive Replacement Cache) balances recency and frequency of use,
+	// it's resistant to scans which would otherwise flush frequently used entries
+	ARC EvictionPolicy = "arc"
+)
+
+const DefaultEvictionPolicy = LRU
+
+// Cache is implemented by every cache provided by this package
+type Cache interface {
+	Put(key interface{}, value interface{}) (rkey interface{}, rvalue interface{}, err error)
+	Get(key interface{}) (interface{}, error)
+	Pop(key interface{}) (interface{}, error)
+	Replace(key interface{}, value interface{}) (interface{}, error)
+	Resize(size int)
+	Size() int
+	EntriesCount() int
+	Apply(fun func(k interface{}, v interface{}) error) error
+}
+
+var _ Cache = (*LRUCache)(nil)
+var _ Cache = (*ARCCache)(nil)
+
+// ValidEvictionPolicy returns true if the policy is supported
+func ValidEvictionPolicy(policy EvictionPolicy) bool {
+	return policy == LRU || policy == ARC
+}
+
+// NewCache creates a cache of the specified size using the given eviction policy
+func NewCache(policy EvictionPolicy, size int) (Cache, error) {
+	switch policy {
+	case LRU:
+		return NewLRUCache(size)
+	case ARC:
+		return NewARCCache(size)
+	}
+
+	return nil, fmt.Errorf("%w: unsupported eviction policy '%s'", ErrIllegalArguments, policy)
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// MasterKeyProvider wraps and unwraps the data-encryption keys used to encrypt appendable files.
+// Master keys never leave the provider, only wrapped data keys are persisted.
+type MasterKeyProvider interface {
+	// WrapKey encrypts a data key returning the id of the master key being used
+	WrapKey(dataKey []byte) (masterKeyID string, wrappedKey []byte, err error)
+	// UnwrapKey decrypts a data key previously wrapped with the master key identified by masterKeyID
+	UnwrapKey(masterKeyID string, wrappedKey []byte) (dataKey []byte, err error)
+}
+
+type staticKeyProvider struct {
+	keys         map[string]cipher.AEAD
+	currentKeyID string
+}
+
+// NewStaticKeyProvider returns a MasterKeyProvider holding the master keys in memory.
+// Data keys are always wrapped with the master key identified by currentKeyID,
+// while the remaining ones are kept to unwrap data keys wrapped before a rotation.
+func NewStaticKeyProvider(keys map[string][]byte, currentKeyID string) (MasterKeyProvider, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, fmt.Errorf("%w: current master key '%s' not provided", ErrIllegalArguments, currentKeyID)
+	}
+
+	kp := &staticKeyProvider{
+		keys:         make(map[string]cipher.AEAD, len(keys)),
+		currentKeyID: currentKeyID,
+	}
+
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		kp.keys[id] = aead
+	}
+
+	return kp, nil
+}
+
+// NewFileKeyProvider returns a MasterKeyProvider reading hex-encoded master keys from files.
+// Each key is identified by the name of its file, data keys are wrapped with the key read from
+// currentKeyFile while the keys read from previousKeyFiles are only used to unwrap data keys.
+func NewFileKeyProvider(currentKeyFile string, previousKeyFiles ...string) (MasterKeyProvider, error) {
+	keys := make(map[string][]byte, 1+len(previousKeyFiles))
+
+	for _, keyFile := range append([]string{currentKeyFile}, previousKeyFiles...) {
+		b, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := decodeKey(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("%w: master key file '%s'", err, keyFile)
+		}
+
+		keys[filepath.Base(keyFile)] = key
+	}
+
+	return NewStaticKeyProvider(keys, filepath.Base(currentKeyFile))
+}
+
+// NewEnvKeyProvider returns a MasterKeyProvider reading hex-encoded master keys from environment variables.
+// Each key is identified by the name of its variable, data keys are wrapped with the key read from
+// currentKeyVar while the keys read from previousKeyVars are only used to unwrap data keys.
+func NewEnvKeyProvider(currentKeyVar string, previousKeyVars ...string) (MasterKeyProvider, error) {
+	keys := make(map[string][]byte, 1+len(previousKeyVars))
+
+	for _, keyVar := range append([]string{currentKeyVar}, previousKeyVars...) {
+		v, ok := os.LookupEnv(keyVar)
+		if !ok {
+			return nil, fmt.Errorf("%w: environment variable '%s' not set", ErrKeyNotFound, keyVar)
+		}
+
+		key, err := decodeKey(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: environment variable '%s'", err, keyVar)
+		}
+
+		keys[keyVar] = key
+	}
+
+	return NewStaticKeyProvider(keys, currentKeyVar)
+}
+
+// PassphraseKeyID identifies the master keys derived from a passphrase
+const PassphraseKeyID = "passphrase"
+
+// scrypt parameters used to derive master keys from passphrases
+const (
+	passphraseSaltSize = 16
+	passphraseScryptN  = 1 << 15
+	passphraseScryptR  = 8
+	passphraseScryptP  = 1
+)
+
+type passphraseKeyProvider struct {
+	passphrase []byte
+}
+
+// NewPassphraseKeyProvider returns a MasterKeyProvider deriving master keys from a passphrase.
+// A new salt is generated every time a data key is wrapped and it's stored along with the wrapped key,
+// so the passphrase is all that's needed to unwrap it.
+func NewPassphraseKeyProvider(passphrase string) (MasterKeyProvider, error) {
+	if passphrase == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	return &passphraseKeyProvider{passphrase: []byte(passphrase)}, nil
+}
+
+// NewPassphraseFileKeyProvider returns a MasterKeyProvider deriving master keys from the passphrase
+// held in a file, trailing line breaks are not part of the passphrase
+func NewPassphraseFileKeyProvider(passphraseFile string) (MasterKeyProvider, error) {
+	b, err := ioutil.ReadFile(passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase := strings.TrimRight(string(b), "\r\n")
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w: empty passphrase file '%s'", ErrIllegalArguments, passphraseFile)
+	}
+
+	return NewPassphraseKeyProvider(passphrase)
+}
+
+func (kp *passphraseKeyProvider) deriveAEAD(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(kp.passphrase, salt, passphraseScryptN, passphraseScryptR, passphraseScryptP, KeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAEAD(key)
+}
+
+func (kp *passphraseKeyProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	salt := make([]byte, passphraseSaltSize)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	aead, err := kp.deriveAEAD(salt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", nil, err
+	}
+
+	wrappedKey := append(salt, nonce...)
+
+	return PassphraseKeyID, aead.Seal(wrappedKey, nonce, dataKey, []byte(PassphraseKeyID)), nil
+}
+
+func (kp *passphraseKeyProvider) UnwrapKey(masterKeyID string, wrappedKey []byte) ([]byte, error) {
+	if masterKeyID != PassphraseKeyID {
+		return nil, fmt.Errorf("%w: master key '%s'", ErrKeyNotFound, masterKeyID)
+	}
+
+	if len(wrappedKey) < passphraseSaltSize {
+		return nil, ErrCorruptedKeyRing
+	}
+
+	aead, err := kp.deriveAEAD(wrappedKey[:passphraseSaltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey = wrappedKey[passphraseSaltSize:]
+
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, ErrCorruptedKeyRing
+	}
+
+	dataKey, err := aead.Open(nil, wrappedKey[:aead.NonceSize()], wrappedKey[aead.NonceSize():], []byte(PassphraseKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong passphrase", ErrWrongKey)
+	}
+
+	return dataKey, nil
+}
+
+// KMS is implemented by key management services holding the master keys
+type KMS interface {
+	Encrypt(keyID string, plaintext []byte) (ciphertext []byte, err error)
+	Decrypt(keyID string, ciphertext []byte) (plaintext []byte, err error)
+}
+
+type kmsKeyProvider struct {
+	kms   KMS
+	keyID string
+}
+
+// NewKMSKeyProvider returns a MasterKeyProvider delegating to a key management service.
+// Data keys are wrapped with the master key identified by keyID.
+func NewKMSKeyProvider(kms KMS, keyID string) (MasterKeyProvider, error) {
+	if kms == nil || keyID == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	return &kmsKeyProvider{kms: kms, keyID: keyID}, nil
+}
+
+func (kp *kmsKeyProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	wrappedKey, err := kp.kms.Encrypt(kp.keyID, dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return kp.keyID, wrappedKey, nil
+}
+
+func (kp *kmsKeyProvider) UnwrapKey(masterKeyID string, wrappedKey []byte) ([]byte, error) {
+	return kp.kms.Decrypt(masterKeyID, wrappedKey)
+}
+
+func (kp *staticKeyProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	aead := kp.keys[kp.currentKeyID]
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return kp.currentKeyID, aead.Seal(nonce, nonce, dataKey, []byte(kp.currentKeyID)), nil
+}
+
+func (kp *staticKeyProvider) UnwrapKey(masterKeyID string, wrappedKey []byte) ([]byte, error) {
+	aead, ok := kp.keys[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: master key '%s'", ErrKeyNotFound, masterKeyID)
+	}
+
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, ErrCorruptedKeyRing
+	}
+
+	dataKey, err := aead.Open(nil, wrappedKey[:aead.NonceSize()], wrappedKey[aead.NonceSize():], []byte(masterKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: master key '%s'", ErrWrongKey, masterKeyID)
+	}
+
+	return dataKey, nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+var ErrKeyNotFound = errors.New("key not found")
+var ErrInvalidKey = errors.New("invalid key, a hex-encoded 256-bit key is expected")
+var ErrCorruptedKeyRing = errors.New("corrupted key ring")
+var ErrWrongKey = errors.New("wrong key, data key could not be unwrapped")
+
+// KeySize is the size in bytes of both master and data-encryption keys (AES-256)
+const KeySize = 32
+
+// KeyRing holds the data-encryption keys used to encrypt appendable files.
+// Data is always encrypted with the current key, rotating it only affects data written afterwards
+// while previous keys are kept to decrypt existing data. Keys are persisted wrapped by a master key.
+type KeyRing struct {
+	path     string
+	fileMode os.FileMode
+	provider MasterKeyProvider
+
+	keys         map[uint32]cipher.AEAD
+	wrappedKeys  []*wrappedKey
+	currentKeyID uint32
+
+	mutex sync.RWMutex
+}
+
+type wrappedKey struct {
+	ID          uint32 `json:"id"`
+	MasterKeyID string `json:"masterKeyId"`
+	Key         []byte `json:"key"`
+}
+
+type keyRingFile struct {
+	CurrentKeyID uint32        `json:"currentKeyId"`
+	Keys         []*wrappedKey `json:"keys"`
+}
+
+// OpenKeyRing loads the key ring stored at path, a new key ring holding a single
+// data-encryption key is created if there is no file at path.
+func OpenKeyRing(path string, provider MasterKeyProvider, fileMode os.FileMode) (*KeyRing, error) {
+	if provider == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	kr := &KeyRing{
+		path:     path,
+		fileMode: fileMode,
+		provider: provider,
+		keys:     make(map[uint32]cipher.AEAD),
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		err = kr.rotateDataKey()
+		if err != nil {
+			return nil, err
+		}
+
+		return kr, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var krf keyRingFile
+
+	err = json.Unmarshal(b, &krf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedKeyRing, err)
+	}
+
+	for _, wk := range krf.Keys {
+		dataKey, err := provider.UnwrapKey(wk.MasterKeyID, wk.Key)
+		if err != nil {
+			return nil, fmt.Errorf("could not unwrap data key %d: %w", wk.ID, err)
+		}
+
+		aead, err := newAEAD(dataKey)
+		if err != nil {
+			return nil, err
+		}
+
+		kr.keys[wk.ID] = aead
+	}
+
+	if _, ok := kr.keys[krf.CurrentKeyID]; !ok {
+		return nil, fmt.Errorf("%w: current data key %d not found", ErrCorruptedKeyRing, krf.CurrentKeyID)
+	}
+
+	kr.wrappedKeys = krf.Keys
+	kr.currentKeyID = krf.CurrentKeyID
+
+	return kr, nil
+}
+
+// CurrentKey returns the key data must be encrypted with and its id
+func (kr *KeyRing) CurrentKey() (uint32, cipher.AEAD) {
+	kr.mutex.RLock()
+	defer kr.mutex.RUnlock()
+
+	return kr.currentKeyID, kr.keys[kr.currentKeyID]
+}
+
+// Key returns the data-encryption key identified by keyID
+func (kr *KeyRing) Key(keyID uint32) (cipher.AEAD, error) {
+	kr.mutex.RLock()
+	defer kr.mutex.RUnlock()
+
+	aead, ok := kr.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: data key %d", ErrKeyNotFound, keyID)
+	}
+
+	return aead, nil
+}
+
+// RotateDataKey generates a new data-encryption key used to encrypt data from now on,
+// existing data is not re-encrypted
+func (kr *KeyRing) RotateDataKey() error {
+	kr.mutex.Lock()
+	defer kr.mutex.Unlock()
+
+	return kr.rotateDataKey()
+}
+
+// RotateMasterKey wraps all the data-encryption keys with the current master key of the provider,
+// master keys previously in use are no longer needed afterwards
+func (kr *KeyRing) RotateMasterKey() error {
+	kr.mutex.Lock()
+	defer kr.mutex.Unlock()
+
+	wrappedKeys := make([]*wrappedKey, len(kr.wrappedKeys))
+
+	for i, wk := range kr.wrappedKeys {
+		dataKey, err := kr.provider.UnwrapKey(wk.MasterKeyID, wk.Key)
+		if err != nil {
+			return err
+		}
+
+		masterKeyID, key, err := kr.provider.WrapKey(dataKey)
+		if err != nil {
+			return err
+		}
+
+		wrappedKeys[i] = &wrappedKey{ID: wk.ID, MasterKeyID: masterKeyID, Key: key}
+	}
+
+	err := kr.persist(kr.currentKeyID, wrappedKeys)
+	if err != nil {
+		return err
+	}
+
+	kr.wrappedKeys = wrappedKeys
+
+	return nil
+}
+
+// rotateDataKey persists a new data key before making it the current one,
+// so no data can be encrypted with a key that could be lost
+func (kr *KeyRing) rotateDataKey() error {
+	dataKey := make([]byte, KeySize)
+
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	masterKeyID, key, err := kr.provider.WrapKey(dataKey)
+	if err != nil {
+		return err
+	}
+
+	keyID := kr.currentKeyID + 1
+
+	wrappedKeys := make([]*wrappedKey, len(kr.wrappedKeys), len(kr.wrappedKeys)+1)
+	copy(wrappedKeys, kr.wrappedKeys)
+	wrappedKeys = append(wrappedKeys, &wrappedKey{ID: keyID, MasterKeyID: masterKeyID, Key: key})
+
+	err = kr.persist(keyID, wrappedKeys)
+	if err != nil {
+		return err
+	}
+
+	kr.keys[keyID] = aead
+	kr.wrappedKeys = wrappedKeys
+	kr.currentKeyID = keyID
+
+	return nil
+}
+
+func (kr *KeyRing) persist(currentKeyID uint32, wrappedKeys []*wrappedKey) error {
+	b, err := json.Marshal(&keyRingFile{
+		CurrentKeyID: currentKeyID,
+		Keys:         wrappedKeys,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmpPath := kr.path + ".tmp"
+
+	err = ioutil.WriteFile(tmpPath, b, kr.fileMode)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, kr.path)
+}
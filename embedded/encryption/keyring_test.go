@@ -0,0 +1,297 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyring")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keyring")
+
+	kp1, err := NewStaticKeyProvider(map[string][]byte{"mk1": bytes.Repeat([]byte{1}, KeySize)}, "mk1")
+	require.NoError(t, err)
+
+	_, err = OpenKeyRing(path, nil, 0644)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	kr, err := OpenKeyRing(path, kp1, 0644)
+	require.NoError(t, err)
+
+	keyID, aead := kr.CurrentKey()
+	require.Equal(t, uint32(1), keyID)
+
+	sealed := aead.Seal(nil, make([]byte, aead.NonceSize()), []byte("data"), nil)
+
+	err = kr.RotateDataKey()
+	require.NoError(t, err)
+
+	keyID, _ = kr.CurrentKey()
+	require.Equal(t, uint32(2), keyID)
+
+	_, err = kr.Key(3)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	kp2, err := NewStaticKeyProvider(map[string][]byte{
+		"mk1": bytes.Repeat([]byte{1}, KeySize),
+		"mk2": bytes.Repeat([]byte{2}, KeySize),
+	}, "mk2")
+	require.NoError(t, err)
+
+	kr, err = OpenKeyRing(path, kp2, 0644)
+	require.NoError(t, err)
+
+	keyID, _ = kr.CurrentKey()
+	require.Equal(t, uint32(2), keyID)
+
+	aead, err = kr.Key(1)
+	require.NoError(t, err)
+
+	data, err := aead.Open(nil, make([]byte, aead.NonceSize()), sealed, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), data)
+
+	err = kr.RotateMasterKey()
+	require.NoError(t, err)
+
+	// data keys are no longer wrapped by the first master key
+	kp3, err := NewStaticKeyProvider(map[string][]byte{"mk2": bytes.Repeat([]byte{2}, KeySize)}, "mk2")
+	require.NoError(t, err)
+
+	kr, err = OpenKeyRing(path, kp3, 0644)
+	require.NoError(t, err)
+
+	aead, err = kr.Key(1)
+	require.NoError(t, err)
+
+	data, err = aead.Open(nil, make([]byte, aead.NonceSize()), sealed, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), data)
+
+	_, err = OpenKeyRing(path, kp1, 0644)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestCorruptedKeyRing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyring")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keyring")
+
+	kp, err := NewStaticKeyProvider(map[string][]byte{"mk": bytes.Repeat([]byte{1}, KeySize)}, "mk")
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(path, []byte("{"), 0644)
+	require.NoError(t, err)
+
+	_, err = OpenKeyRing(path, kp, 0644)
+	require.ErrorIs(t, err, ErrCorruptedKeyRing)
+
+	err = ioutil.WriteFile(path, []byte(`{"currentKeyId":1,"keys":[]}`), 0644)
+	require.NoError(t, err)
+
+	_, err = OpenKeyRing(path, kp, 0644)
+	require.ErrorIs(t, err, ErrCorruptedKeyRing)
+}
+
+type mockedKMS struct {
+	keys map[string][]byte
+}
+
+func (kms *mockedKMS) Encrypt(keyID string, plaintext []byte) ([]byte, error) {
+	key, ok := kms.keys[keyID]
+	if !ok {
+		return nil, errors.New("unknown key")
+	}
+
+	b := make([]byte, len(plaintext))
+	for i := range plaintext {
+		b[i] = plaintext[i] ^ key[i%len(key)]
+	}
+	return b, nil
+}
+
+func (kms *mockedKMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	return kms.Encrypt(keyID, ciphertext)
+}
+
+func TestKeyProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyproviders")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	hexKey1 := "0101010101010101010101010101010101010101010101010101010101010101"
+	hexKey2 := "0202020202020202020202020202020202020202020202020202020202020202"
+
+	_, err = NewStaticKeyProvider(map[string][]byte{"mk": []byte{1}}, "mk")
+	require.Error(t, err)
+
+	_, err = NewStaticKeyProvider(nil, "mk")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("file", func(t *testing.T) {
+		err := ioutil.WriteFile(filepath.Join(dir, "key1"), []byte(hexKey1+"\n"), 0600)
+		require.NoError(t, err)
+
+		err = ioutil.WriteFile(filepath.Join(dir, "key2"), []byte(hexKey2), 0600)
+		require.NoError(t, err)
+
+		err = ioutil.WriteFile(filepath.Join(dir, "invalid"), []byte("0102"), 0600)
+		require.NoError(t, err)
+
+		_, err = NewFileKeyProvider(filepath.Join(dir, "missing"))
+		require.Error(t, err)
+
+		_, err = NewFileKeyProvider(filepath.Join(dir, "invalid"))
+		require.ErrorIs(t, err, ErrInvalidKey)
+
+		kp1, err := NewFileKeyProvider(filepath.Join(dir, "key1"))
+		require.NoError(t, err)
+
+		masterKeyID, wrappedKey, err := kp1.WrapKey([]byte("data key"))
+		require.NoError(t, err)
+		require.Equal(t, "key1", masterKeyID)
+
+		kp2, err := NewFileKeyProvider(filepath.Join(dir, "key2"), filepath.Join(dir, "key1"))
+		require.NoError(t, err)
+
+		dataKey, err := kp2.UnwrapKey(masterKeyID, wrappedKey)
+		require.NoError(t, err)
+		require.Equal(t, []byte("data key"), dataKey)
+
+		masterKeyID, _, err = kp2.WrapKey([]byte("data key"))
+		require.NoError(t, err)
+		require.Equal(t, "key2", masterKeyID)
+
+		_, err = kp2.UnwrapKey("key3", wrappedKey)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		_, err = kp2.UnwrapKey("key1", nil)
+		require.ErrorIs(t, err, ErrCorruptedKeyRing)
+
+		err = ioutil.WriteFile(filepath.Join(dir, "key1"), []byte(hexKey2), 0600)
+		require.NoError(t, err)
+
+		kp3, err := NewFileKeyProvider(filepath.Join(dir, "key1"))
+		require.NoError(t, err)
+
+		_, err = kp3.UnwrapKey("key1", wrappedKey)
+		require.ErrorIs(t, err, ErrWrongKey)
+	})
+
+	t.Run("passphrase", func(t *testing.T) {
+		_, err := NewPassphraseKeyProvider("")
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = ioutil.WriteFile(filepath.Join(dir, "passphrase"), []byte("correct horse battery staple\n"), 0600)
+		require.NoError(t, err)
+
+		err = ioutil.WriteFile(filepath.Join(dir, "empty_passphrase"), []byte("\n"), 0600)
+		require.NoError(t, err)
+
+		_, err = NewPassphraseFileKeyProvider(filepath.Join(dir, "missing"))
+		require.Error(t, err)
+
+		_, err = NewPassphraseFileKeyProvider(filepath.Join(dir, "empty_passphrase"))
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		kp, err := NewPassphraseFileKeyProvider(filepath.Join(dir, "passphrase"))
+		require.NoError(t, err)
+
+		masterKeyID, wrappedKey, err := kp.WrapKey([]byte("data key"))
+		require.NoError(t, err)
+		require.Equal(t, PassphraseKeyID, masterKeyID)
+
+		// every key is wrapped using a different salt
+		_, wrappedKey2, err := kp.WrapKey([]byte("data key"))
+		require.NoError(t, err)
+		require.NotEqual(t, wrappedKey, wrappedKey2)
+
+		kp2, err := NewPassphraseKeyProvider("correct horse battery staple")
+		require.NoError(t, err)
+
+		dataKey, err := kp2.UnwrapKey(masterKeyID, wrappedKey)
+		require.NoError(t, err)
+		require.Equal(t, []byte("data key"), dataKey)
+
+		_, err = kp2.UnwrapKey("mk", wrappedKey)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		_, err = kp2.UnwrapKey(masterKeyID, wrappedKey[:passphraseSaltSize+1])
+		require.ErrorIs(t, err, ErrCorruptedKeyRing)
+
+		kp3, err := NewPassphraseKeyProvider("wrong passphrase")
+		require.NoError(t, err)
+
+		_, err = kp3.UnwrapKey(masterKeyID, wrappedKey)
+		require.ErrorIs(t, err, ErrWrongKey)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		os.Setenv("IMMUDB_TEST_MASTER_KEY1", hexKey1)
+		defer os.Unsetenv("IMMUDB_TEST_MASTER_KEY1")
+
+		_, err := NewEnvKeyProvider("IMMUDB_TEST_MASTER_KEY_MISSING")
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		kp, err := NewEnvKeyProvider("IMMUDB_TEST_MASTER_KEY1")
+		require.NoError(t, err)
+
+		masterKeyID, wrappedKey, err := kp.WrapKey([]byte("data key"))
+		require.NoError(t, err)
+		require.Equal(t, "IMMUDB_TEST_MASTER_KEY1", masterKeyID)
+
+		dataKey, err := kp.UnwrapKey(masterKeyID, wrappedKey)
+		require.NoError(t, err)
+		require.Equal(t, []byte("data key"), dataKey)
+	})
+
+	t.Run("kms", func(t *testing.T) {
+		_, err := NewKMSKeyProvider(nil, "mk")
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		kp, err := NewKMSKeyProvider(&mockedKMS{keys: map[string][]byte{"mk": []byte{7}}}, "mk")
+		require.NoError(t, err)
+
+		masterKeyID, wrappedKey, err := kp.WrapKey([]byte("data key"))
+		require.NoError(t, err)
+		require.Equal(t, "mk", masterKeyID)
+		require.NotEqual(t, []byte("data key"), wrappedKey)
+
+		dataKey, err := kp.UnwrapKey(masterKeyID, wrappedKey)
+		require.NoError(t, err)
+		require.Equal(t, []byte("data key"), dataKey)
+
+		kp, err = NewKMSKeyProvider(&mockedKMS{}, "mk")
+		require.NoError(t, err)
+
+		_, _, err = kp.WrapKey([]byte("data key"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+var ErrCorruptedStream = errors.New("encrypted stream is corrupted or truncated")
+
+// streamMagic starts every encrypted stream, so encrypted data can be told apart from plain data
+const streamMagic = "IMMUENC"
+
+const streamVersion = 1
+
+// StreamChunkSize is the maximum amount of plain data sealed in every chunk of an encrypted stream
+const StreamChunkSize = 64 * 1024
+
+const streamNoncePrefixSize = 4
+
+// streamWriter encrypts a stream with a data key generated for it, which is stored wrapped by a master key
+// in the header of the stream. Data is sealed in chunks, nonces are derived from the position of each chunk
+// and the last one is flagged, so reordered, removed or truncated chunks are detected when decrypting.
+type streamWriter struct {
+	w    io.Writer
+	aead cipher.AEAD
+
+	noncePrefix [streamNoncePrefixSize]byte
+	counter     uint64
+
+	buf []byte
+
+	closed bool
+}
+
+// NewStreamWriter returns a writer encrypting the data written into w, the stream can be decrypted
+// with NewStreamReader as long as the master key used to wrap its data key is available.
+// Close must be called to write the end of the stream.
+func NewStreamWriter(w io.Writer, provider MasterKeyProvider) (io.WriteCloser, error) {
+	if w == nil || provider == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	dataKey := make([]byte, KeySize)
+
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	masterKeyID, wrappedKey, err := provider.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(masterKeyID) > math.MaxUint16 || len(wrappedKey) > math.MaxUint16 {
+		return nil, ErrIllegalArguments
+	}
+
+	sw := &streamWriter{
+		w:    w,
+		aead: aead,
+		buf:  make([]byte, 0, StreamChunkSize),
+	}
+
+	_, err = rand.Read(sw.noncePrefix[:])
+	if err != nil {
+		return nil, err
+	}
+
+	hdr := append([]byte(streamMagic), streamVersion)
+	hdr = appendWithLen(hdr, []byte(masterKeyID))
+	hdr = appendWithLen(hdr, wrappedKey)
+	hdr = append(hdr, sw.noncePrefix[:]...)
+
+	_, err = w.Write(hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+func appendWithLen(b, data []byte) []byte {
+	var lb [2]byte
+	binary.BigEndian.PutUint16(lb[:], uint16(len(data)))
+
+	return append(append(b, lb[:]...), data...)
+}
+
+func (sw *streamWriter) nonce() []byte {
+	nonce := make([]byte, sw.aead.NonceSize())
+	copy(nonce, sw.noncePrefix[:])
+	binary.BigEndian.PutUint64(nonce[streamNoncePrefixSize:], sw.counter)
+
+	return nonce
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	if sw.closed {
+		return 0, ErrIllegalArguments
+	}
+
+	n := 0
+
+	for n < len(p) {
+		c := copy(sw.buf[len(sw.buf):cap(sw.buf)], p[n:])
+		sw.buf = sw.buf[:len(sw.buf)+c]
+		n += c
+
+		if len(sw.buf) == cap(sw.buf) {
+			err := sw.writeChunk(false)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func (sw *streamWriter) writeChunk(final bool) error {
+	var flag [1]byte
+	if final {
+		flag[0] = 1
+	}
+
+	sealed := sw.aead.Seal(nil, sw.nonce(), sw.buf, flag[:])
+
+	var chunkHdr [5]byte
+	chunkHdr[0] = flag[0]
+	binary.BigEndian.PutUint32(chunkHdr[1:], uint32(len(sealed)))
+
+	_, err := sw.w.Write(chunkHdr[:])
+	if err != nil {
+		return err
+	}
+
+	_, err = sw.w.Write(sealed)
+	if err != nil {
+		return err
+	}
+
+	sw.counter++
+	sw.buf = sw.buf[:0]
+
+	return nil
+}
+
+// Close writes the last chunk of the stream, the underlying writer is not closed
+func (sw *streamWriter) Close() error {
+	if sw.closed {
+		return ErrIllegalArguments
+	}
+
+	sw.closed = true
+
+	return sw.writeChunk(true)
+}
+
+type streamReader struct {
+	r    io.Reader
+	aead cipher.AEAD
+
+	noncePrefix [streamNoncePrefixSize]byte
+	counter     uint64
+
+	buf []byte
+	eof bool
+}
+
+// NewStreamReader returns a reader decrypting a stream written with NewStreamWriter,
+// the data key of the stream is unwrapped by the provider
+func NewStreamReader(r io.Reader, provider MasterKeyProvider) (io.Reader, error) {
+	if r == nil || provider == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	var magic [len(streamMagic) + 1]byte
+
+	_, err := io.ReadFull(r, magic[:])
+	if err != nil {
+		return nil, ErrCorruptedStream
+	}
+
+	if string(magic[:len(streamMagic)]) != streamMagic || magic[len(streamMagic)] != streamVersion {
+		return nil, ErrCorruptedStream
+	}
+
+	masterKeyID, err := readWithLen(r)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := readWithLen(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := provider.UnwrapKey(string(masterKeyID), wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sr := &streamReader{
+		r:    r,
+		aead: aead,
+	}
+
+	_, err = io.ReadFull(r, sr.noncePrefix[:])
+	if err != nil {
+		return nil, ErrCorruptedStream
+	}
+
+	return sr, nil
+}
+
+// IsEncryptedStream tells whether the data read from r has been written with NewStreamWriter,
+// no data is consumed from r
+func IsEncryptedStream(r *bufio.Reader) bool {
+	magic, err := r.Peek(len(streamMagic))
+	return err == nil && bytes.Equal(magic, []byte(streamMagic))
+}
+
+func readWithLen(r io.Reader) ([]byte, error) {
+	var lb [2]byte
+
+	_, err := io.ReadFull(r, lb[:])
+	if err != nil {
+		return nil, ErrCorruptedStream
+	}
+
+	b := make([]byte, binary.BigEndian.Uint16(lb[:]))
+
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return nil, ErrCorruptedStream
+	}
+
+	return b, nil
+}
+
+func (sr *streamReader) Read(p []byte) (int, error) {
+	for len(sr.buf) == 0 {
+		if sr.eof {
+			return 0, io.EOF
+		}
+
+		err := sr.readChunk()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, sr.buf)
+	sr.buf = sr.buf[n:]
+
+	return n, nil
+}
+
+func (sr *streamReader) readChunk() error {
+	var chunkHdr [5]byte
+
+	_, err := io.ReadFull(sr.r, chunkHdr[:])
+	if err != nil {
+		return ErrCorruptedStream
+	}
+
+	size := binary.BigEndian.Uint32(chunkHdr[1:])
+	if chunkHdr[0] > 1 || size > StreamChunkSize+uint32(sr.aead.Overhead()) {
+		return ErrCorruptedStream
+	}
+
+	sealed := make([]byte, size)
+
+	_, err = io.ReadFull(sr.r, sealed)
+	if err != nil {
+		return ErrCorruptedStream
+	}
+
+	nonce := make([]byte, sr.aead.NonceSize())
+	copy(nonce, sr.noncePrefix[:])
+	binary.BigEndian.PutUint64(nonce[streamNoncePrefixSize:], sr.counter)
+
+	sr.buf, err = sr.aead.Open(sealed[:0], nonce, sealed, chunkHdr[:1])
+	if err != nil {
+		return ErrCorruptedStream
+	}
+
+	sr.counter++
+	sr.eof = chunkHdr[0] == 1
+
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamEncryption(t *testing.T) {
+	kp, err := NewStaticKeyProvider(map[string][]byte{"mk1": bytes.Repeat([]byte{1}, KeySize)}, "mk1")
+	require.NoError(t, err)
+
+	_, err = NewStreamWriter(nil, kp)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewStreamReader(bytes.NewReader(nil), nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	encrypt := func(data []byte) []byte {
+		var buf bytes.Buffer
+
+		w, err := NewStreamWriter(&buf, kp)
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+
+		err = w.Close()
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		return buf.Bytes()
+	}
+
+	decrypt := func(enc []byte) ([]byte, error) {
+		r, err := NewStreamReader(bytes.NewReader(enc), kp)
+		if err != nil {
+			return nil, err
+		}
+
+		return ioutil.ReadAll(r)
+	}
+
+	for _, size := range []int{0, 1, StreamChunkSize, 3*StreamChunkSize + 10} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+
+		enc := encrypt(data)
+		require.False(t, size > 1 && bytes.Contains(enc, data))
+		require.True(t, IsEncryptedStream(bufio.NewReader(bytes.NewReader(enc))))
+
+		dec, err := decrypt(enc)
+		require.NoError(t, err)
+		require.Equal(t, data, append([]byte{}, dec...))
+	}
+
+	data := bytes.Repeat([]byte("immudb"), StreamChunkSize/2)
+	enc := encrypt(data)
+
+	t.Run("truncated stream", func(t *testing.T) {
+		// the stream is cut at the end of a chunk which is not the last one
+		_, err := decrypt(enc[:len(enc)-(5+16+len(data)%StreamChunkSize)])
+		require.ErrorIs(t, err, ErrCorruptedStream)
+
+		_, err = decrypt(enc[:len(enc)-1])
+		require.ErrorIs(t, err, ErrCorruptedStream)
+	})
+
+	t.Run("tampered stream", func(t *testing.T) {
+		tampered := append([]byte{}, enc...)
+		tampered[len(tampered)/2]++
+
+		_, err := decrypt(tampered)
+		require.ErrorIs(t, err, ErrCorruptedStream)
+	})
+
+	t.Run("plain stream", func(t *testing.T) {
+		require.False(t, IsEncryptedStream(bufio.NewReader(bytes.NewReader(data))))
+		require.False(t, IsEncryptedStream(bufio.NewReader(bytes.NewReader(nil))))
+
+		_, err := decrypt(data)
+		require.ErrorIs(t, err, ErrCorruptedStream)
+	})
+
+	t.Run("wrong master key", func(t *testing.T) {
+		kp2, err := NewStaticKeyProvider(map[string][]byte{"mk1": bytes.Repeat([]byte{2}, KeySize)}, "mk1")
+		require.NoError(t, err)
+
+		_, err = NewStreamReader(bytes.NewReader(enc), kp2)
+		require.ErrorIs(t, err, ErrWrongKey)
+	})
+
+	t.Run("unknown master key", func(t *testing.T) {
+		kp2, err := NewStaticKeyProvider(map[string][]byte{"mk2": bytes.Repeat([]byte{2}, KeySize)}, "mk2")
+		require.NoError(t, err)
+
+		_, err = NewStreamReader(bytes.NewReader(enc), kp2)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashing
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+	"lukechampine.com/blake3"
+)
+
+// Size is the size of the digests produced by every supported algorithm
+const Size = sha256.Size
+
+// Algorithm identifies the hash function used to calculate digests.
+// Its value is persisted, so existing algorithms must never be renumbered
+type Algorithm int
+
+const (
+	SHA256 Algorithm = iota
+	SHA3_256
+	BLAKE3
+)
+
+const DefaultAlgorithm = SHA256
+
+var algorithmNames = map[string]Algorithm{
+	"sha256":   SHA256,
+	"sha3-256": SHA3_256,
+	"blake3":   BLAKE3,
+}
+
+// AlgorithmFromName returns the hash algorithm with the given name
+// i.e. "sha256", "sha3-256" or "blake3"
+func AlgorithmFromName(name string) (Algorithm, bool) {
+	alg, ok := algorithmNames[name]
+	return alg, ok
+}
+
+// String returns the name of the hash algorithm, empty if the algorithm is unknown
+func (alg Algorithm) String() string {
+	for name, a := range algorithmNames {
+		if a == alg {
+			return name
+		}
+	}
+	return ""
+}
+
+// Supported returns true if digests can be calculated with the hash algorithm
+func (alg Algorithm) Supported() bool {
+	return alg >= SHA256 && alg <= BLAKE3
+}
+
+// Sum returns the digest of b, it panics if the algorithm is not supported
+func (alg Algorithm) Sum(b []byte) [Size]byte {
+	switch alg {
+	case SHA256:
+		return sha256.Sum256(b)
+	case SHA3_256:
+		return sha3.Sum256(b)
+	case BLAKE3:
+		return blake3.Sum256(b)
+	}
+
+	panic(fmt.Errorf("unsupported hash algorithm %d", alg))
+}
+
+// New returns a hash.Hash computing digests with the hash algorithm, it panics if the algorithm is not supported
+func (alg Algorithm) New() hash.Hash {
+	switch alg {
+	case SHA256:
+		return sha256.New()
+	case SHA3_256:
+		return sha3.New256()
+	case BLAKE3:
+		return blake3.New(Size, nil)
+	}
+
+	panic(fmt.Errorf("unsupported hash algorithm %d", alg))
+}
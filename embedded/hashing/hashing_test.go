@@ -0,0 +1,66 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashing
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlgorithms(t *testing.T) {
+	for _, c := range []struct {
+		alg    Algorithm
+		name   string
+		digest string
+	}{
+		{SHA256, "sha256", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{SHA3_256, "sha3-256", "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a"},
+		{BLAKE3, "blake3", "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			require.True(t, c.alg.Supported())
+			require.Equal(t, c.name, c.alg.String())
+
+			alg, ok := AlgorithmFromName(c.name)
+			require.True(t, ok)
+			require.Equal(t, c.alg, alg)
+
+			digest := c.alg.Sum(nil)
+			require.Equal(t, c.digest, hex.EncodeToString(digest[:]))
+
+			h := c.alg.New()
+			require.Equal(t, Size, h.Size())
+
+			h.Write([]byte("immudb"))
+			digest = c.alg.Sum([]byte("immudb"))
+			require.Equal(t, digest[:], h.Sum(nil))
+		})
+	}
+
+	require.Equal(t, SHA256, DefaultAlgorithm)
+
+	_, ok := AlgorithmFromName("md5")
+	require.False(t, ok)
+
+	unsupported := Algorithm(99)
+	require.False(t, unsupported.Supported())
+	require.Empty(t, unsupported.String())
+	require.Panics(t, func() { unsupported.Sum(nil) })
+	require.Panics(t, func() { unsupported.New() })
+}
@@ -19,6 +19,8 @@ import (
 	"crypto/sha256"
 	"errors"
 	"math/bits"
+
+	"github.com/codenotary/immudb/embedded/hashing"
 )
 
 var ErrMaxWidthExceeded = errors.New("max width exceeded")
@@ -33,6 +35,7 @@ type HTree struct {
 	maxWidth int
 	width    int
 	root     [sha256.Size]byte
+	hashAlg  hashing.Algorithm
 }
 
 type InclusionProof struct {
@@ -41,8 +44,8 @@ type InclusionProof struct {
 	Terms [][sha256.Size]byte
 }
 
-func New(maxWidth int) (*HTree, error) {
-	if maxWidth < 1 {
+func New(maxWidth int, hashAlg hashing.Algorithm) (*HTree, error) {
+	if maxWidth < 1 || !hashAlg.Supported() {
 		return nil, ErrIllegalArguments
 	}
 
@@ -61,6 +64,7 @@ func New(maxWidth int) (*HTree, error) {
 	return &HTree{
 		levels:   levels,
 		maxWidth: maxWidth,
+		hashAlg:  hashAlg,
 	}, nil
 }
 
@@ -76,7 +80,7 @@ func (t *HTree) BuildWith(digests [][sha256.Size]byte) error {
 	for i, d := range digests {
 		leaf := [1 + sha256.Size]byte{LeafPrefix}
 		copy(leaf[1:], d[:])
-		t.levels[0][i] = sha256.Sum256(leaf[:])
+		t.levels[0][i] = t.hashAlg.Sum(leaf[:])
 	}
 
 	l := 0
@@ -90,7 +94,7 @@ func (t *HTree) BuildWith(digests [][sha256.Size]byte) error {
 		for i := 0; i+1 < w; i += 2 {
 			copy(b[1:], t.levels[l][i][:])
 			copy(b[1+sha256.Size:], t.levels[l][i+1][:])
-			t.levels[l+1][wn] = sha256.Sum256(b[:])
+			t.levels[l+1][wn] = t.hashAlg.Sum(b[:])
 			wn++
 		}
 
@@ -164,15 +168,15 @@ func (t *HTree) InclusionProof(i int) (proof *InclusionProof, err error) {
 	}
 }
 
-func VerifyInclusion(proof *InclusionProof, digest, root [sha256.Size]byte) bool {
-	if proof == nil {
+func VerifyInclusion(proof *InclusionProof, digest, root [sha256.Size]byte, hashAlg hashing.Algorithm) bool {
+	if proof == nil || !hashAlg.Supported() {
 		return false
 	}
 
 	leaf := [1 + sha256.Size]byte{LeafPrefix}
 	copy(leaf[1:], digest[:])
 
-	calcRoot := sha256.Sum256(leaf[:])
+	calcRoot := hashAlg.Sum(leaf[:])
 	i := proof.Leaf
 	r := proof.Width - 1
 
@@ -187,7 +191,7 @@ func VerifyInclusion(proof *InclusionProof, digest, root [sha256.Size]byte) bool
 			copy(b[1+sha256.Size:], calcRoot[:])
 		}
 
-		calcRoot = sha256.Sum256(b[:])
+		calcRoot = hashAlg.Sum(b[:])
 		i /= 2
 		r /= 2
 	}
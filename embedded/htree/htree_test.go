@@ -20,16 +20,17 @@ import (
 	"encoding/binary"
 	"testing"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/stretchr/testify/require"
 )
 
 func TestHTree(t *testing.T) {
 	const maxWidth = 1000
 
-	_, err := New(0)
+	_, err := New(0, hashing.SHA256)
 	require.Equal(t, ErrIllegalArguments, err)
 
-	tree, err := New(maxWidth)
+	tree, err := New(maxWidth, hashing.SHA256)
 	require.NoError(t, err)
 	require.NotNil(t, tree)
 
@@ -55,20 +56,20 @@ func TestHTree(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, proof)
 
-		verifies := VerifyInclusion(proof, digests[i], root)
+		verifies := VerifyInclusion(proof, digests[i], root, hashing.SHA256)
 		require.True(t, verifies)
 
-		verifies = VerifyInclusion(proof, sha256.Sum256(digests[i][:]), root)
+		verifies = VerifyInclusion(proof, sha256.Sum256(digests[i][:]), root, hashing.SHA256)
 		require.False(t, verifies)
 
-		verifies = VerifyInclusion(proof, digests[i], sha256.Sum256(root[:]))
+		verifies = VerifyInclusion(proof, digests[i], sha256.Sum256(root[:]), hashing.SHA256)
 		require.False(t, verifies)
 
 		proof.Terms = nil
-		verifies = VerifyInclusion(proof, digests[i], root)
+		verifies = VerifyInclusion(proof, digests[i], root, hashing.SHA256)
 		require.False(t, verifies)
 
-		verifies = VerifyInclusion(nil, digests[i], root)
+		verifies = VerifyInclusion(nil, digests[i], root, hashing.SHA256)
 		require.False(t, verifies)
 	}
 
@@ -81,3 +82,41 @@ func TestHTree(t *testing.T) {
 	_, err = tree.InclusionProof(maxWidth)
 	require.Equal(t, ErrIllegalArguments, err)
 }
+
+func TestHTreeHashAlgorithms(t *testing.T) {
+	_, err := New(1, hashing.Algorithm(99))
+	require.Equal(t, ErrIllegalArguments, err)
+
+	digests := make([][sha256.Size]byte, 10)
+
+	for i := 0; i < len(digests); i++ {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(i))
+		digests[i] = sha256.Sum256(b[:])
+	}
+
+	roots := make(map[[sha256.Size]byte]hashing.Algorithm)
+
+	for _, hashAlg := range []hashing.Algorithm{hashing.SHA256, hashing.SHA3_256, hashing.BLAKE3} {
+		tree, err := New(len(digests), hashAlg)
+		require.NoError(t, err)
+
+		err = tree.BuildWith(digests)
+		require.NoError(t, err)
+
+		root, err := tree.Root()
+		require.NoError(t, err)
+
+		_, duplicated := roots[root]
+		require.False(t, duplicated)
+		roots[root] = hashAlg
+
+		for i := 0; i < len(digests); i++ {
+			proof, err := tree.InclusionProof(i)
+			require.NoError(t, err)
+
+			require.True(t, VerifyInclusion(proof, digests[i], root, hashAlg))
+			require.False(t, VerifyInclusion(proof, digests[i], root, hashing.Algorithm(99)))
+		}
+	}
+}
@@ -0,0 +1,610 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+)
+
+// Storage stores objects as block blobs of an Azure Blob Storage container,
+// requests are authorized with the shared key of the storage account
+type Storage struct {
+	endpoint   string
+	account    string
+	key        []byte
+	container  string
+	prefix     string
+	httpClient *http.Client
+}
+
+var (
+	ErrInvalidArguments = errors.New("invalid arguments")
+	ErrInvalidResponse  = errors.New("invalid response code")
+)
+
+// apiVersion is the version of the Blob service REST API requests are made with
+const apiVersion = "2020-10-02"
+
+// uploadIDLen is the length of the random part of upload ids, block ids must all have the same length
+const uploadIDLen = 16
+
+// Open returns the storage of the container of an account, the endpoint of the account
+// (https://<account>.blob.core.windows.net) is used when endpoint is empty.
+// The account key is the base64 encoded one provided by Azure.
+func Open(
+	endpoint string,
+	account string,
+	accountKey string,
+	container string,
+	prefix string,
+) (remotestorage.Storage, error) {
+
+	if account == "" {
+		return nil, ErrInvalidArguments
+	}
+
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: account key must be base64 encoded", ErrInvalidArguments)
+	}
+
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+
+	// Endpoint must always end with '/'
+	endpoint = strings.TrimRight(endpoint, "/") + "/"
+
+	// Container must have no '/' at all
+	container = strings.Trim(container, "/")
+	if container == "" || strings.Contains(container, "/") {
+		return nil, ErrInvalidArguments
+	}
+
+	// if prefix is not empty, it must end with '/'
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix = prefix + "/"
+	}
+
+	return &Storage{
+		endpoint:   endpoint,
+		account:    account,
+		key:        key,
+		container:  container,
+		prefix:     prefix,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+func (s *Storage) String() string {
+	return "azure:" + s.blobURL("")
+}
+
+func (s *Storage) blobURL(name string) string {
+	return s.endpoint + s.container + "/" + s.prefix + name
+}
+
+// stringToSign builds the string signed with the account key as described in
+// https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (s *Storage) stringToSign(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	msHeaders := []string{}
+	for h := range req.Header {
+		if strings.HasPrefix(strings.ToLower(h), "x-ms-") {
+			msHeaders = append(msHeaders, strings.ToLower(h)+":"+strings.TrimSpace(req.Header.Get(h))+"\n")
+		}
+	}
+	sort.Strings(msHeaders)
+
+	resource := "/" + s.account + req.URL.EscapedPath()
+
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for p := range query {
+		params = append(params, p)
+	}
+	sort.Strings(params)
+
+	for _, p := range params {
+		values := query[p]
+		sort.Strings(values)
+		resource = resource + "\n" + strings.ToLower(p) + ":" + strings.Join(values, ",")
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is set instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}, "\n") + "\n" + strings.Join(msHeaders, "") + resource
+}
+
+func (s *Storage) signedRequest(
+	ctx context.Context,
+	method string,
+	reqURL string,
+	body io.Reader,
+	contentLength int64,
+	setupRequest func(req *http.Request),
+	t time.Time,
+) (*http.Request, error) {
+
+	if contentLength > 0 {
+		body = &metricsCountingReadCloser{
+			r: ioutil.NopCloser(body),
+			c: metricsUploadBytes,
+		}
+	} else {
+		// empty bodies are not sent chunked
+		body = nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = contentLength
+
+	req.Header.Set("X-Ms-Date", t.Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", apiVersion)
+
+	if setupRequest != nil {
+		setupRequest(req)
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(s.stringToSign(req)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", s.account, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+
+	return req, nil
+}
+
+func (s *Storage) request(
+	ctx context.Context,
+	method string,
+	reqURL string,
+	validStatusCodes []int,
+	body io.Reader,
+	contentLength int64,
+	setupRequest func(req *http.Request),
+) (*http.Response, error) {
+
+	req, err := s.signedRequest(ctx, method, reqURL, body, contentLength, setupRequest, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Azure %s %s", req.Method, req.URL)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		log.Printf("Azure %s %s failed: %v", req.Method, req.URL, err)
+		return nil, err
+	}
+
+	for _, validStatus := range validStatusCodes {
+		if resp.StatusCode == validStatus {
+			log.Printf("Azure %s %s %s", req.Method, req.URL, resp.Status)
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+
+	log.Printf(
+		"Azure %s %s failed with status code %d (%s)",
+		req.Method,
+		req.URL,
+		resp.StatusCode,
+		resp.Status,
+	)
+	return nil, ErrInvalidResponse
+}
+
+func validName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "/") && !strings.HasSuffix(name, "/")
+}
+
+// Get opens a remote blob
+func (s *Storage) Get(ctx context.Context, name string, offs, size int64) (io.ReadCloser, error) {
+	if offs < 0 || size == 0 || !validName(name) {
+		return nil, ErrInvalidArguments
+	}
+
+	resp, err := s.request(ctx, "GET", s.blobURL(name), []int{200, 206}, nil, 0, func(req *http.Request) {
+		if size < 0 {
+			req.Header.Set("X-Ms-Range", fmt.Sprintf("bytes=%d-", offs))
+		} else {
+			req.Header.Set("X-Ms-Range", fmt.Sprintf("bytes=%d-%d", offs, offs+size-1))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &metricsCountingReadCloser{
+		r: resp.Body,
+		c: metricsDownloadBytes,
+	}, nil
+}
+
+// Put writes a local file as a remote blob
+func (s *Storage) Put(ctx context.Context, name string, fileName string) error {
+	if !validName(name) {
+		return ErrInvalidArguments
+	}
+
+	fl, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+	flStat, err := fl.Stat()
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.request(ctx, "PUT", s.blobURL(name), []int{201}, fl, flStat.Size(), func(req *http.Request) {
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// Exists checks if a remote blob exists and can be read
+func (s *Storage) Exists(ctx context.Context, name string) (bool, error) {
+	if !validName(name) {
+		return false, ErrInvalidArguments
+	}
+
+	resp, err := s.request(ctx, "HEAD", s.blobURL(name), []int{200, 404}, nil, 0, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == 200, nil
+}
+
+func (s *Storage) ListEntries(ctx context.Context, path string) ([]remotestorage.EntryInfo, []string, error) {
+	if path != "" {
+		if !strings.HasSuffix(path, "/") ||
+			strings.Contains(path, "//") {
+			return nil, nil, ErrInvalidArguments
+		}
+	}
+
+	prefix := s.prefix + path
+
+	urlValues := url.Values{}
+	urlValues.Set("restype", "container")
+	urlValues.Set("comp", "list")
+	urlValues.Set("delimiter", "/")
+	urlValues.Set("prefix", prefix)
+
+	entries := []remotestorage.EntryInfo{}
+	subPaths := []string{}
+
+	for {
+		resp, err := s.request(ctx, "GET", s.endpoint+s.container+"?"+urlValues.Encode(), []int{200}, nil, 0, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		respParsed := struct {
+			Blobs struct {
+				Blob []struct {
+					Name       string
+					Properties struct {
+						ContentLength int64 `xml:"Content-Length"`
+					}
+				}
+				BlobPrefix []struct{ Name string }
+			}
+			NextMarker string
+		}{}
+
+		err = xml.NewDecoder(resp.Body).Decode(&respParsed)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, blob := range respParsed.Blobs.Blob {
+			entries = append(entries, remotestorage.EntryInfo{
+				Name: strings.TrimPrefix(blob.Name, prefix),
+				Size: blob.Properties.ContentLength,
+			})
+		}
+		for _, subPath := range respParsed.Blobs.BlobPrefix {
+			if !strings.HasPrefix(subPath.Name, prefix) || !strings.HasSuffix(subPath.Name, "/") {
+				return nil, nil, ErrInvalidResponse
+			}
+
+			p := subPath.Name[len(prefix) : len(subPath.Name)-1]
+			if p == "." || p == ".." || strings.ContainsAny(p, "\\/:") {
+				// Avoid exploitation by a malicious server
+				return nil, nil, ErrInvalidResponse
+			}
+
+			subPaths = append(subPaths, p)
+		}
+
+		if respParsed.NextMarker == "" {
+			break
+		}
+
+		urlValues.Set("marker", respParsed.NextMarker)
+	}
+
+	if !sort.SliceIsSorted(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name }) ||
+		!sort.StringsAreSorted(subPaths) {
+		return nil, nil, ErrInvalidResponse
+	}
+
+	return entries, subPaths, nil
+}
+
+// Multipart uploads are made of uncommitted blocks of the blob, committed together once the upload is completed.
+// Block ids carry the upload id, the number of the part and the md5 digest of its content,
+// so the parts of an upload can be listed and checked when the upload is resumed.
+// The encryption scope the blocks are encrypted with, if any, follows the random part of the upload id.
+
+func splitUploadID(uploadID string) (id, encryptionScope string, err error) {
+	if len(uploadID) < uploadIDLen {
+		return "", "", ErrInvalidArguments
+	}
+
+	id, encryptionScope = uploadID[:uploadIDLen], strings.TrimPrefix(uploadID[uploadIDLen:], "/")
+
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", "", ErrInvalidArguments
+	}
+
+	return id, encryptionScope, nil
+}
+
+func blockID(id string, number int, etag string) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%05d-%s", id, number, etag)))
+}
+
+func setEncryptionScope(req *http.Request, encryptionScope string) {
+	if encryptionScope != "" {
+		req.Header.Set("X-Ms-Encryption-Scope", encryptionScope)
+	}
+}
+
+// CreateUpload starts a multipart upload of a blob, nothing is sent to the storage until parts are uploaded.
+// Blobs are always encrypted at rest, the encryption key id is taken as the name of the encryption scope to be used
+func (s *Storage) CreateUpload(ctx context.Context, name string, opts *remotestorage.UploadOptions) (string, error) {
+	if !validName(name) {
+		return "", ErrInvalidArguments
+	}
+
+	if opts != nil && opts.ServerSideEncryption != "" && opts.ServerSideEncryption != "AES256" {
+		return "", fmt.Errorf("%w: blobs are encrypted with AES256", ErrInvalidArguments)
+	}
+
+	var id [uploadIDLen / 2]byte
+
+	_, err := rand.Read(id[:])
+	if err != nil {
+		return "", err
+	}
+
+	uploadID := hex.EncodeToString(id[:])
+
+	if opts != nil && opts.ServerSideEncryptionKeyID != "" {
+		uploadID = uploadID + "/" + opts.ServerSideEncryptionKeyID
+	}
+
+	return uploadID, nil
+}
+
+// UploadPart uploads a part of a multipart upload as a block of the blob
+func (s *Storage) UploadPart(ctx context.Context, name string, uploadID string, number int, data []byte) (string, error) {
+	if !validName(name) || number < 1 {
+		return "", ErrInvalidArguments
+	}
+
+	id, encryptionScope, err := splitUploadID(uploadID)
+	if err != nil {
+		return "", err
+	}
+
+	digest := md5.Sum(data)
+	etag := hex.EncodeToString(digest[:])
+
+	query := url.Values{
+		"comp":    {"block"},
+		"blockid": {blockID(id, number, etag)},
+	}
+
+	resp, err := s.request(ctx, "PUT", s.blobURL(name)+"?"+query.Encode(), []int{201}, bytes.NewReader(data), int64(len(data)), func(req *http.Request) {
+		// the content is checked by the storage against its digest
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(digest[:]))
+		setEncryptionScope(req, encryptionScope)
+	})
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	return etag, nil
+}
+
+// ListParts returns the parts uploaded so far to a multipart upload
+func (s *Storage) ListParts(ctx context.Context, name string, uploadID string) ([]remotestorage.Part, error) {
+	if !validName(name) {
+		return nil, ErrInvalidArguments
+	}
+
+	id, _, err := splitUploadID(uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"comp":          {"blocklist"},
+		"blocklisttype": {"uncommitted"},
+	}
+
+	resp, err := s.request(ctx, "GET", s.blobURL(name)+"?"+query.Encode(), []int{200, 404}, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	parts := []remotestorage.Part{}
+
+	if resp.StatusCode == 404 {
+		// no block was uploaded yet
+		return parts, nil
+	}
+
+	respParsed := struct {
+		UncommittedBlocks struct {
+			Block []struct {
+				Name string
+				Size int64
+			}
+		}
+	}{}
+
+	err = xml.NewDecoder(resp.Body).Decode(&respParsed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range respParsed.UncommittedBlocks.Block {
+		decoded, err := base64.StdEncoding.DecodeString(b.Name)
+		if err != nil {
+			// blocks of uploads made by other clients
+			continue
+		}
+
+		fields := strings.Split(string(decoded), "-")
+		if len(fields) != 3 || fields[0] != id {
+			continue
+		}
+
+		number, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, ErrInvalidResponse
+		}
+
+		parts = append(parts, remotestorage.Part{
+			Number: number,
+			ETag:   fields[2],
+			Size:   b.Size,
+		})
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+
+	return parts, nil
+}
+
+// CompleteUpload commits the blocks of the uploaded parts as the content of the blob
+func (s *Storage) CompleteUpload(ctx context.Context, name string, uploadID string, parts []remotestorage.Part) error {
+	if !validName(name) {
+		return ErrInvalidArguments
+	}
+
+	id, encryptionScope, err := splitUploadID(uploadID)
+	if err != nil {
+		return err
+	}
+
+	reqBody := struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{}
+
+	for _, p := range parts {
+		reqBody.Latest = append(reqBody.Latest, blockID(id, p.Number, p.ETag))
+	}
+
+	body, err := xml.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.request(ctx, "PUT", s.blobURL(name)+"?comp=blocklist", []int{201}, bytes.NewReader(body), int64(len(body)), func(req *http.Request) {
+		req.Header.Set("Content-Type", "application/xml")
+		setEncryptionScope(req, encryptionScope)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// AbortUpload discards a multipart upload, the storage has no way to discard uncommitted blocks
+// but they are garbage collected a week after being uploaded
+func (s *Storage) AbortUpload(ctx context.Context, name string, uploadID string) error {
+	if !validName(name) {
+		return ErrInvalidArguments
+	}
+
+	_, _, err := splitUploadID(uploadID)
+	return err
+}
+
+var _ remotestorage.Storage = (*Storage)(nil)
+var _ remotestorage.MultipartStorage = (*Storage)(nil)
@@ -0,0 +1,406 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/stretchr/testify/require"
+)
+
+// the well-known credentials of the Azure storage emulator
+const (
+	testAccount    = "devstoreaccount1"
+	testAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// fakeBlobService serves the subset of the Blob service REST API used by the storage,
+// the signature of every request is checked
+type fakeBlobService struct {
+	t      *testing.T
+	st     *Storage
+	mutex  sync.Mutex
+	blobs  map[string][]byte
+	blocks map[string]map[string][]byte
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	mac := hmac.New(sha256.New, f.st.key)
+	mac.Write([]byte(f.st.stringToSign(req)))
+	expectedAuth := "SharedKey " + testAccount + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if req.Header.Get("Authorization") != expectedAuth || req.Header.Get("X-Ms-Version") != apiVersion {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	path := req.URL.Path
+	query := req.URL.Query()
+
+	body, err := ioutil.ReadAll(req.Body)
+	require.NoError(f.t, err)
+
+	switch {
+	case req.Method == "GET" && query.Get("restype") == "container" && query.Get("comp") == "list":
+		f.list(w, query.Get("prefix"))
+
+	case req.Method == "PUT" && query.Get("comp") == "block":
+		digest := md5.Sum(body)
+		if req.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(digest[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if f.blocks[path] == nil {
+			f.blocks[path] = map[string][]byte{}
+		}
+		f.blocks[path][query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+
+	case req.Method == "GET" && query.Get("comp") == "blocklist":
+		blocks, ok := f.blocks[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "<BlockList><UncommittedBlocks>")
+		for id, data := range blocks {
+			fmt.Fprintf(w, "<Block><Name>%s</Name><Size>%d</Size></Block>", id, len(data))
+		}
+		fmt.Fprint(w, "</UncommittedBlocks></BlockList>")
+
+	case req.Method == "PUT" && query.Get("comp") == "blocklist":
+		blockList := struct {
+			Latest []string
+		}{}
+		require.NoError(f.t, xml.Unmarshal(body, &blockList))
+
+		var blob []byte
+		for _, id := range blockList.Latest {
+			data, ok := f.blocks[path][id]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			blob = append(blob, data...)
+		}
+		f.blobs[path] = blob
+		delete(f.blocks, path)
+		w.WriteHeader(http.StatusCreated)
+
+	case req.Method == "PUT":
+		if req.Header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[path] = body
+		w.WriteHeader(http.StatusCreated)
+
+	case req.Method == "HEAD":
+		if _, ok := f.blobs[path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+	case req.Method == "GET":
+		blob, ok := f.blobs[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var start, end int
+		_, err := fmt.Sscanf(req.Header.Get("X-Ms-Range"), "bytes=%d-%d", &start, &end)
+		if err != nil || end >= len(blob) {
+			end = len(blob) - 1
+		}
+
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(blob[start : end+1])
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeBlobService) list(w http.ResponseWriter, prefix string) {
+	containerPath := "/" + testAccount + "/" + f.st.container + "/"
+
+	names := []string{}
+	for path := range f.blobs {
+		names = append(names, strings.TrimPrefix(path, containerPath))
+	}
+	sort.Strings(names)
+
+	fmt.Fprint(w, "<EnumerationResults><Blobs>")
+
+	lastSubPath := ""
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		if i := strings.Index(name[len(prefix):], "/"); i >= 0 {
+			subPath := name[:len(prefix)+i+1]
+			if subPath != lastSubPath {
+				fmt.Fprintf(w, "<BlobPrefix><Name>%s</Name></BlobPrefix>", subPath)
+				lastSubPath = subPath
+			}
+			continue
+		}
+
+		fmt.Fprintf(w, "<Blob><Name>%s</Name><Properties><Content-Length>%d</Content-Length></Properties></Blob>",
+			name, len(f.blobs[containerPath+name]))
+	}
+
+	fmt.Fprint(w, "</Blobs><NextMarker /></EnumerationResults>")
+}
+
+func openTestStorage(t *testing.T) *Storage {
+	fake := &fakeBlobService{
+		t:      t,
+		blobs:  map[string][]byte{},
+		blocks: map[string]map[string][]byte{},
+	}
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	// the emulator takes the account as the first segment of the path
+	st, err := Open(srv.URL+"/"+testAccount, testAccount, testAccountKey, "immudb", "backups")
+	require.NoError(t, err)
+
+	fake.st = st.(*Storage)
+
+	return fake.st
+}
+
+func TestOpen(t *testing.T) {
+	_, err := Open("", "", testAccountKey, "immudb", "")
+	require.ErrorIs(t, err, ErrInvalidArguments)
+
+	_, err = Open("", testAccount, "not base64!", "immudb", "")
+	require.ErrorIs(t, err, ErrInvalidArguments)
+
+	_, err = Open("", testAccount, testAccountKey, "immudb/backups", "")
+	require.ErrorIs(t, err, ErrInvalidArguments)
+
+	st, err := Open("", testAccount, testAccountKey, "/immudb/", "/backups/db1/")
+	require.NoError(t, err)
+	require.Equal(t, "azure:https://devstoreaccount1.blob.core.windows.net/immudb/backups/db1/", st.String())
+}
+
+func TestStringToSign(t *testing.T) {
+	st, err := Open("", testAccount, testAccountKey, "immudb", "")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("PUT", "https://devstoreaccount1.blob.core.windows.net/immudb/full.backup?comp=block&blockid=YWJj%2B", bytes.NewReader([]byte("data")))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-MD5", "jZQYK6cTAN+Jz4Chwbu5OA==")
+	req.Header.Set("X-Ms-Date", "Fri, 26 Jun 2015 23:39:12 GMT")
+	req.Header.Set("X-Ms-Version", apiVersion)
+
+	require.Equal(t,
+		"PUT\n\n\n4\njZQYK6cTAN+Jz4Chwbu5OA==\n\n\n\n\n\n\n\n"+
+			"x-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\n"+
+			"x-ms-version:2020-10-02\n"+
+			"/devstoreaccount1/immudb/full.backup\n"+
+			"blockid:YWJj+\n"+
+			"comp:block",
+		st.(*Storage).stringToSign(req),
+	)
+}
+
+func TestStorage(t *testing.T) {
+	st := openTestStorage(t)
+
+	ctx := context.Background()
+
+	fl, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(fl.Name())
+
+	fmt.Fprintf(fl, "Hello world")
+	fl.Close()
+
+	exists, err := st.Exists(ctx, "db1/full.backup")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	err = st.Put(ctx, "db1/full.backup", fl.Name())
+	require.NoError(t, err)
+
+	err = st.Put(ctx, "hello", fl.Name())
+	require.NoError(t, err)
+
+	exists, err = st.Exists(ctx, "db1/full.backup")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	r, err := st.Get(ctx, "db1/full.backup", 6, 5)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, []byte("world"), data)
+
+	entries, subPaths, err := st.ListEntries(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, []remotestorage.EntryInfo{{Name: "hello", Size: 11}}, entries)
+	require.Equal(t, []string{"db1"}, subPaths)
+
+	entries, subPaths, err = st.ListEntries(ctx, "db1/")
+	require.NoError(t, err)
+	require.Equal(t, []remotestorage.EntryInfo{{Name: "full.backup", Size: 11}}, entries)
+	require.Empty(t, subPaths)
+
+	_, err = st.Get(ctx, "/db1/full.backup", 0, -1)
+	require.ErrorIs(t, err, ErrInvalidArguments)
+
+	_, err = st.Get(ctx, "missing", 0, -1)
+	require.ErrorIs(t, err, ErrInvalidResponse)
+
+	_, _, err = st.ListEntries(ctx, "db1")
+	require.ErrorIs(t, err, ErrInvalidArguments)
+}
+
+func TestMultipartUpload(t *testing.T) {
+	st := openTestStorage(t)
+
+	ctx := context.Background()
+	opts := remotestorage.DefaultUploadOptions().WithPartSize(4)
+
+	_, err := st.CreateUpload(ctx, "full.backup", remotestorage.DefaultUploadOptions().WithServerSideEncryption("aws:kms", ""))
+	require.ErrorIs(t, err, ErrInvalidArguments)
+
+	w, err := remotestorage.NewUploadWriter(ctx, st, "full.backup", opts)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("0123456789"))
+	require.NoError(t, err)
+
+	// two parts were uploaded, the last one is uploaded on close
+	parts, err := st.ListParts(ctx, "full.backup", w.UploadID())
+	require.NoError(t, err)
+	require.Len(t, parts, 2)
+	require.Equal(t, 1, parts[0].Number)
+	require.Equal(t, int64(4), parts[1].Size)
+
+	// blocks of other uploads of the same blob are not parts of this upload
+	other, err := st.CreateUpload(ctx, "full.backup", opts)
+	require.NoError(t, err)
+	_, err = st.UploadPart(ctx, "full.backup", other, 1, []byte("zzzz"))
+	require.NoError(t, err)
+
+	// the upload is resumed, the parts already uploaded are checked
+	resumed, err := remotestorage.ResumeUploadWriter(ctx, st, "full.backup", w.UploadID(), opts)
+	require.NoError(t, err)
+
+	_, err = resumed.Write([]byte("0123456789"))
+	require.NoError(t, err)
+
+	err = resumed.Close()
+	require.NoError(t, err)
+
+	r, err := st.Get(ctx, "full.backup", 0, -1)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, []byte("0123456789"), data)
+
+	t.Run("encryption scope", func(t *testing.T) {
+		uploadID, err := st.CreateUpload(ctx, "encrypted.backup", remotestorage.DefaultUploadOptions().WithServerSideEncryption("AES256", "scope1"))
+		require.NoError(t, err)
+		require.True(t, strings.HasSuffix(uploadID, "/scope1"))
+
+		_, err = st.UploadPart(ctx, "encrypted.backup", uploadID, 1, []byte("data"))
+		require.NoError(t, err)
+
+		parts, err := st.ListParts(ctx, "encrypted.backup", uploadID)
+		require.NoError(t, err)
+		require.Len(t, parts, 1)
+
+		err = st.CompleteUpload(ctx, "encrypted.backup", uploadID, parts)
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid upload ids", func(t *testing.T) {
+		_, err := st.UploadPart(ctx, "full.backup", "short", 1, []byte("data"))
+		require.ErrorIs(t, err, ErrInvalidArguments)
+
+		_, err = st.ListParts(ctx, "full.backup", strings.Repeat("x", uploadIDLen))
+		require.ErrorIs(t, err, ErrInvalidArguments)
+
+		err = st.AbortUpload(ctx, "full.backup", other)
+		require.NoError(t, err)
+	})
+
+	t.Run("nothing uploaded", func(t *testing.T) {
+		uploadID, err := st.CreateUpload(ctx, "empty.backup", opts)
+		require.NoError(t, err)
+
+		parts, err := st.ListParts(ctx, "empty.backup", uploadID)
+		require.NoError(t, err)
+		require.Empty(t, parts)
+	})
+
+	t.Run("resumed with different data", func(t *testing.T) {
+		w, err := remotestorage.NewUploadWriter(ctx, st, "changed.backup", opts)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("0123"))
+		require.NoError(t, err)
+
+		resumed, err := remotestorage.ResumeUploadWriter(ctx, st, "changed.backup", w.UploadID(), opts)
+		require.NoError(t, err)
+
+		_, err = resumed.Write([]byte("4567"))
+		require.ErrorIs(t, err, remotestorage.ErrUploadMismatch)
+	})
+}
+
+func TestSignedRequestDate(t *testing.T) {
+	st, err := Open("", testAccount, testAccountKey, "immudb", "")
+	require.NoError(t, err)
+
+	date := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	req, err := st.(*Storage).signedRequest(context.Background(), "HEAD", st.(*Storage).blobURL("full.backup"), nil, 0, nil, date)
+	require.NoError(t, err)
+	require.Equal(t, "Tue, 01 Mar 2022 10:00:00 GMT", req.Header.Get("X-Ms-Date"))
+	require.True(t, strings.HasPrefix(req.Header.Get("Authorization"), "SharedKey devstoreaccount1:"))
+	require.Nil(t, req.Body)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/s3"
+)
+
+// DefaultEndpoint is the endpoint of the XML API of Google Cloud Storage
+const DefaultEndpoint = "https://storage.googleapis.com"
+
+// location requests are signed for, the bucket location is not required by Google Cloud Storage
+const location = "auto"
+
+// Storage stores objects in a Google Cloud Storage bucket through its XML API,
+// which is compatible with the s3 one when requests are authorized with HMAC keys
+type Storage struct {
+	*s3.Storage
+}
+
+// Open returns the storage of a bucket, requests are authorized with the HMAC key of a service account.
+// The endpoint of Google Cloud Storage is used when endpoint is empty
+func Open(
+	endpoint string,
+	accessKeyID string,
+	secret string,
+	bucket string,
+	prefix string,
+) (remotestorage.Storage, error) {
+
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+
+	st, err := s3.Open(endpoint, accessKeyID, secret, bucket, location, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Storage{Storage: st.(*s3.Storage)}, nil
+}
+
+func (s *Storage) String() string {
+	return "gcs" + strings.TrimPrefix(s.Storage.String(), "s3")
+}
+
+// CreateUpload starts a multipart upload of an object, objects are encrypted at rest with the default key of the bucket
+func (s *Storage) CreateUpload(ctx context.Context, name string, opts *remotestorage.UploadOptions) (string, error) {
+	if opts != nil && (opts.ServerSideEncryption != "" || opts.ServerSideEncryptionKeyID != "") {
+		return "", fmt.Errorf("%w: objects are encrypted with the default key of the bucket", s3.ErrInvalidArguments)
+	}
+
+	return s.Storage.CreateUpload(ctx, name, opts)
+}
+
+var _ remotestorage.Storage = (*Storage)(nil)
+var _ remotestorage.MultipartStorage = (*Storage)(nil)
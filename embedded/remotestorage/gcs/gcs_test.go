@@ -0,0 +1,71 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/s3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	st, err := Open("", "GOOG1EXAMPLE", "secret", "bucket1", "backups")
+	require.NoError(t, err)
+	require.Equal(t, "gcs:https://storage.googleapis.com/bucket1/backups/", st.String())
+
+	_, err = Open("", "GOOG1EXAMPLE", "secret", "bucket1/backups", "")
+	require.ErrorIs(t, err, s3.ErrInvalidArguments)
+}
+
+func TestCreateUpload(t *testing.T) {
+	var requests []*http.Request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req)
+		fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>upload1</UploadId></InitiateMultipartUploadResult>")
+	}))
+	defer srv.Close()
+
+	st, err := Open(srv.URL, "GOOG1EXAMPLE", "secret", "bucket1", "backups")
+	require.NoError(t, err)
+
+	_, err = st.(remotestorage.MultipartStorage).CreateUpload(context.Background(), "full.backup",
+		remotestorage.DefaultUploadOptions().WithServerSideEncryption("aws:kms", "key1"))
+	require.ErrorIs(t, err, s3.ErrInvalidArguments)
+	require.Empty(t, requests)
+
+	uploadID, err := st.(remotestorage.MultipartStorage).CreateUpload(context.Background(), "full.backup", remotestorage.DefaultUploadOptions())
+	require.NoError(t, err)
+	require.Equal(t, "upload1", uploadID)
+
+	require.Len(t, requests, 1)
+	require.Equal(t, "POST", requests[0].Method)
+	require.Equal(t, "/bucket1/backups/full.backup", requests[0].URL.Path)
+	require.Equal(t, "uploads", requests[0].URL.RawQuery)
+
+	// requests are signed with the HMAC key for any location
+	auth := requests[0].Header.Get("Authorization")
+	require.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=GOOG1EXAMPLE/"), auth)
+	require.Contains(t, auth, "/auto/s3/aws4_request")
+}
@@ -18,6 +18,8 @@ package memory
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -33,12 +35,15 @@ import (
 
 var (
 	ErrInvalidArguments = errors.New("invalid arguments")
+	ErrNoSuchUpload     = errors.New("no such upload")
 )
 
 // Storage implements a simple in-memory remote storage
 type Storage struct {
 	mutex   sync.RWMutex
 	objects map[string][]byte
+	uploads map[string]*upload
+	nextID  int
 
 	randomPutDelayMinMs, randomPutDelayMaxMs int
 }
@@ -46,6 +51,7 @@ type Storage struct {
 func Open() *Storage {
 	return &Storage{
 		objects: map[string][]byte{},
+		uploads: map[string]*upload{},
 	}
 }
 
@@ -173,4 +179,120 @@ func (r *Storage) SetRandomPutDelays(minMs, maxMs int) {
 	r.randomPutDelayMaxMs = maxMs
 }
 
-var _ remotestorage.Storage = (*Storage)(nil)
+type upload struct {
+	name  string
+	parts map[int][]byte
+}
+
+func (r *Storage) CreateUpload(ctx context.Context, name string, opts *remotestorage.UploadOptions) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.nextID++
+	uploadID := fmt.Sprintf("upload%d", r.nextID)
+
+	r.uploads[uploadID] = &upload{
+		name:  name,
+		parts: map[int][]byte{},
+	}
+
+	return uploadID, nil
+}
+
+func (r *Storage) getUpload(name, uploadID string) (*upload, error) {
+	u, ok := r.uploads[uploadID]
+	if !ok || u.name != name {
+		return nil, ErrNoSuchUpload
+	}
+
+	return u, nil
+}
+
+func (r *Storage) UploadPart(ctx context.Context, name string, uploadID string, number int, data []byte) (string, error) {
+	if number < 1 {
+		return "", ErrInvalidArguments
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, err := r.getUpload(name, uploadID)
+	if err != nil {
+		return "", err
+	}
+
+	u.parts[number] = append([]byte{}, data...)
+
+	return partETag(data), nil
+}
+
+func partETag(data []byte) string {
+	digest := md5.Sum(data)
+	return "\"" + hex.EncodeToString(digest[:]) + "\""
+}
+
+func (r *Storage) ListParts(ctx context.Context, name string, uploadID string) ([]remotestorage.Part, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	u, err := r.getUpload(name, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]remotestorage.Part, 0, len(u.parts))
+
+	for number, data := range u.parts {
+		parts = append(parts, remotestorage.Part{
+			Number: number,
+			ETag:   partETag(data),
+			Size:   int64(len(data)),
+		})
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+
+	return parts, nil
+}
+
+func (r *Storage) CompleteUpload(ctx context.Context, name string, uploadID string, parts []remotestorage.Part) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, err := r.getUpload(name, uploadID)
+	if err != nil {
+		return err
+	}
+
+	var object []byte
+
+	for _, p := range parts {
+		data, ok := u.parts[p.Number]
+		if !ok || partETag(data) != p.ETag {
+			return ErrInvalidArguments
+		}
+
+		object = append(object, data...)
+	}
+
+	r.objects[name] = object
+	delete(r.uploads, uploadID)
+
+	return nil
+}
+
+func (r *Storage) AbortUpload(ctx context.Context, name string, uploadID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, err := r.getUpload(name, uploadID)
+	if err != nil {
+		return err
+	}
+
+	delete(r.uploads, uploadID)
+
+	return nil
+}
+
+var _ remotestorage.MultipartStorage = (*Storage)(nil)
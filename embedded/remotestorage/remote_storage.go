@@ -49,3 +49,31 @@ type Storage interface {
 	// Entries must be sorted alphabetically
 	ListEntries(ctx context.Context, path string) (entries []EntryInfo, subPaths []string, err error)
 }
+
+// Part describes a part of a multipart upload
+type Part struct {
+	Number int
+	ETag   string
+	Size   int64
+}
+
+// MultipartStorage is implemented by storages able to receive objects in parts,
+// so objects of unknown size can be uploaded without a local copy of them
+type MultipartStorage interface {
+	Storage
+
+	// CreateUpload starts a multipart upload of the object, returning the id of the upload
+	CreateUpload(ctx context.Context, name string, opts *UploadOptions) (uploadID string, err error)
+
+	// UploadPart uploads a part of the object, parts are numbered starting from 1
+	UploadPart(ctx context.Context, name string, uploadID string, number int, data []byte) (etag string, err error)
+
+	// ListParts returns the parts already uploaded sorted by their number
+	ListParts(ctx context.Context, name string, uploadID string) ([]Part, error)
+
+	// CompleteUpload assembles the uploaded parts into the object
+	CompleteUpload(ctx context.Context, name string, uploadID string, parts []Part) error
+
+	// AbortUpload discards the upload and the parts uploaded so far
+	AbortUpload(ctx context.Context, name string, uploadID string) error
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package s3
 
 import (
+	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha1"
@@ -32,6 +33,7 @@ import (
 	"net/url"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -272,8 +274,33 @@ func (s *Storage) s3SignedRequestV2(
 		signedPath = "/" + s.bucket + signedPath
 	}
 
+	// sub-resources of multipart uploads are part of the signed resource
+	query := req.URL.Query()
+	subResources := []string{}
+	for _, r := range []string{"partNumber", "uploadId", "uploads"} {
+		if _, ok := query[r]; !ok {
+			continue
+		}
+		if v := query.Get(r); v != "" {
+			subResources = append(subResources, r+"="+v)
+		} else {
+			subResources = append(subResources, r)
+		}
+	}
+	if len(subResources) > 0 {
+		signedPath = signedPath + "?" + strings.Join(subResources, "&")
+	}
+
+	amzHeaders := []string{}
+	for h := range req.Header {
+		if strings.HasPrefix(strings.ToLower(h), "x-amz-") {
+			amzHeaders = append(amzHeaders, strings.ToLower(h)+":"+req.Header.Get(h)+"\n")
+		}
+	}
+	sort.Strings(amzHeaders)
+
 	mac := hmac.New(sha1.New, []byte(s.secretKey))
-	fmt.Fprintf(mac, "%s\n\n%s\n%s\n%s", method, contentType, date, signedPath)
+	fmt.Fprintf(mac, "%s\n\n%s\n%s\n%s%s", method, contentType, date, strings.Join(amzHeaders, ""), signedPath)
 	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
 
 	req.Header.Set(
@@ -594,4 +621,242 @@ func (s *Storage) ListEntries(ctx context.Context, path string) ([]remotestorage
 	return entries, subPaths, nil
 }
 
+func (s *Storage) uploadURL(name string, query url.Values) (string, error) {
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return "", ErrInvalidArguments
+	}
+
+	objectURL, err := s.originalRequestURL(name)
+	if err != nil {
+		return "", err
+	}
+
+	// uploads sub-resource has no value
+	return objectURL + "?" + strings.TrimSuffix(query.Encode(), "="), nil
+}
+
+// CreateUpload starts a multipart upload of an object
+func (s *Storage) CreateUpload(ctx context.Context, name string, opts *remotestorage.UploadOptions) (string, error) {
+	createURL, err := s.uploadURL(name, url.Values{"uploads": {""}})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.requestWithRedirects(
+		ctx, "POST", createURL,
+		[]int{200},
+		func() (io.Reader, string, error) { return nil, "application/octet-stream", nil },
+		func(req *http.Request) error {
+			if opts != nil && opts.ServerSideEncryption != "" {
+				req.Header.Set("X-Amz-Server-Side-Encryption", opts.ServerSideEncryption)
+			}
+			if opts != nil && opts.ServerSideEncryptionKeyID != "" {
+				req.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", opts.ServerSideEncryptionKeyID)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respParsed := struct {
+		UploadId string
+	}{}
+
+	err = xml.NewDecoder(resp.Body).Decode(&respParsed)
+	if err != nil {
+		return "", err
+	}
+
+	if respParsed.UploadId == "" {
+		return "", ErrInvalidResponse
+	}
+
+	return respParsed.UploadId, nil
+}
+
+// UploadPart uploads a part of a multipart upload
+func (s *Storage) UploadPart(ctx context.Context, name string, uploadID string, number int, data []byte) (string, error) {
+	partURL, err := s.uploadURL(name, url.Values{
+		"partNumber": {strconv.Itoa(number)},
+		"uploadId":   {uploadID},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.requestWithRedirects(
+		ctx, "PUT", partURL,
+		[]int{200},
+		func() (io.Reader, string, error) {
+			return &metricsCountingReadCloser{
+					r: ioutil.NopCloser(bytes.NewReader(data)),
+					c: metricsUploadBytes,
+				},
+				"application/octet-stream",
+				nil
+		},
+		func(req *http.Request) error {
+			req.ContentLength = int64(len(data))
+			return nil
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", ErrInvalidResponse
+	}
+
+	return etag, nil
+}
+
+// ListParts returns the parts uploaded so far to a multipart upload
+func (s *Storage) ListParts(ctx context.Context, name string, uploadID string) ([]remotestorage.Part, error) {
+	query := url.Values{"uploadId": {uploadID}}
+
+	parts := []remotestorage.Part{}
+
+	for {
+		listURL, err := s.uploadURL(name, query)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.requestWithRedirects(
+			ctx, "GET", listURL,
+			[]int{200},
+			func() (io.Reader, string, error) { return nil, "", nil },
+			func(req *http.Request) error { return nil },
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		respParsed := struct {
+			Part []struct {
+				PartNumber int
+				ETag       string
+				Size       int64
+			}
+			IsTruncated          bool
+			NextPartNumberMarker string
+		}{}
+
+		err = xml.NewDecoder(resp.Body).Decode(&respParsed)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range respParsed.Part {
+			parts = append(parts, remotestorage.Part{
+				Number: p.PartNumber,
+				ETag:   p.ETag,
+				Size:   p.Size,
+			})
+		}
+
+		if !respParsed.IsTruncated {
+			break
+		}
+
+		query.Set("part-number-marker", respParsed.NextPartNumberMarker)
+	}
+
+	if !sort.SliceIsSorted(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number }) {
+		return nil, ErrInvalidResponse
+	}
+
+	return parts, nil
+}
+
+// CompleteUpload assembles the uploaded parts into the object
+func (s *Storage) CompleteUpload(ctx context.Context, name string, uploadID string, parts []remotestorage.Part) error {
+	completeURL, err := s.uploadURL(name, url.Values{"uploadId": {uploadID}})
+	if err != nil {
+		return err
+	}
+
+	type completedPart struct {
+		PartNumber int
+		ETag       string
+	}
+
+	reqBody := struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Part    []completedPart `xml:"Part"`
+	}{}
+
+	for _, p := range parts {
+		reqBody.Part = append(reqBody.Part, completedPart{PartNumber: p.Number, ETag: p.ETag})
+	}
+
+	body, err := xml.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.requestWithRedirects(
+		ctx, "POST", completeURL,
+		[]int{200},
+		func() (io.Reader, string, error) {
+			return bytes.NewReader(body), "application/xml", nil
+		},
+		func(req *http.Request) error {
+			req.ContentLength = int64(len(body))
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// errors may be reported after the response status has been sent
+	respParsed := struct {
+		XMLName xml.Name
+		Code    string
+	}{}
+
+	err = xml.NewDecoder(resp.Body).Decode(&respParsed)
+	if err != nil {
+		return err
+	}
+
+	if respParsed.XMLName.Local == "Error" {
+		log.Printf("S3 complete upload of %s failed: %s", name, respParsed.Code)
+		return ErrInvalidResponse
+	}
+
+	return nil
+}
+
+// AbortUpload discards a multipart upload and its parts
+func (s *Storage) AbortUpload(ctx context.Context, name string, uploadID string) error {
+	abortURL, err := s.uploadURL(name, url.Values{"uploadId": {uploadID}})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.requestWithRedirects(
+		ctx, "DELETE", abortURL,
+		[]int{204},
+		func() (io.Reader, string, error) { return nil, "", nil },
+		func(req *http.Request) error { return nil },
+	)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
 var _ remotestorage.Storage = (*Storage)(nil)
+var _ remotestorage.MultipartStorage = (*Storage)(nil)
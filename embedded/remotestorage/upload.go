@@ -0,0 +1,264 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotestorage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrIllegalArguments = errors.New("illegal arguments")
+	ErrAlreadyClosed    = errors.New("already closed")
+	ErrUploadMismatch   = errors.New("data does not match the parts already uploaded")
+)
+
+const DefaultPartSize = 16 << 20 // 16MB, parts other than the last one can not be smaller than 5MB in S3
+const DefaultUploadRetries = 3
+
+// UploadOptions holds the settings of a multipart upload
+type UploadOptions struct {
+	// PartSize is the size of every part but the last one
+	PartSize int
+
+	// MaxRetries is the number of times the upload of a part is retried before failing
+	MaxRetries int
+
+	// ServerSideEncryption is the algorithm used by the storage to encrypt the object at rest (i.e. AES256 or aws:kms)
+	ServerSideEncryption string
+
+	// ServerSideEncryptionKeyID is the key used by the storage when encryption is done by a key management service
+	ServerSideEncryptionKeyID string
+}
+
+func DefaultUploadOptions() *UploadOptions {
+	return &UploadOptions{
+		PartSize:   DefaultPartSize,
+		MaxRetries: DefaultUploadRetries,
+	}
+}
+
+func (opts *UploadOptions) WithPartSize(partSize int) *UploadOptions {
+	opts.PartSize = partSize
+	return opts
+}
+
+func (opts *UploadOptions) WithMaxRetries(maxRetries int) *UploadOptions {
+	opts.MaxRetries = maxRetries
+	return opts
+}
+
+func (opts *UploadOptions) WithServerSideEncryption(algorithm, keyID string) *UploadOptions {
+	opts.ServerSideEncryption = algorithm
+	opts.ServerSideEncryptionKeyID = keyID
+	return opts
+}
+
+func (opts *UploadOptions) valid() bool {
+	return opts != nil && opts.PartSize > 0 && opts.MaxRetries >= 0
+}
+
+// UploadWriter uploads the data written into it as an object of a multipart storage,
+// only the part being filled is kept in memory.
+// Parts are retried on failure, and an upload interrupted before being completed can be resumed
+// by writing the same data again, parts already uploaded are checked and skipped.
+type UploadWriter struct {
+	ctx      context.Context
+	st       MultipartStorage
+	name     string
+	uploadID string
+	opts     *UploadOptions
+
+	buf   []byte
+	parts []Part
+
+	// parts found when resuming an upload
+	uploadedParts []Part
+
+	closed bool
+}
+
+// NewUploadWriter starts a multipart upload of the object name
+func NewUploadWriter(ctx context.Context, st MultipartStorage, name string, opts *UploadOptions) (*UploadWriter, error) {
+	if st == nil || name == "" || !opts.valid() {
+		return nil, ErrIllegalArguments
+	}
+
+	uploadID, err := st.CreateUpload(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newUploadWriter(ctx, st, name, uploadID, opts, nil), nil
+}
+
+// ResumeUploadWriter continues the multipart upload uploadID of the object name
+func ResumeUploadWriter(ctx context.Context, st MultipartStorage, name string, uploadID string, opts *UploadOptions) (*UploadWriter, error) {
+	if st == nil || name == "" || uploadID == "" || !opts.valid() {
+		return nil, ErrIllegalArguments
+	}
+
+	parts, err := st.ListParts(ctx, name, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range parts {
+		if p.Number != i+1 {
+			// parts are uploaded in order, a gap is left when the upload of the last one was interrupted
+			parts = parts[:i]
+			break
+		}
+	}
+
+	return newUploadWriter(ctx, st, name, uploadID, opts, parts), nil
+}
+
+func newUploadWriter(ctx context.Context, st MultipartStorage, name, uploadID string, opts *UploadOptions, uploadedParts []Part) *UploadWriter {
+	return &UploadWriter{
+		ctx:           ctx,
+		st:            st,
+		name:          name,
+		uploadID:      uploadID,
+		opts:          opts,
+		buf:           make([]byte, 0, opts.PartSize),
+		uploadedParts: uploadedParts,
+	}
+}
+
+// UploadID returns the id required to resume the upload
+func (w *UploadWriter) UploadID() string {
+	return w.uploadID
+}
+
+func (w *UploadWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrAlreadyClosed
+	}
+
+	n := 0
+
+	for n < len(p) {
+		c := copy(w.buf[len(w.buf):cap(w.buf)], p[n:])
+		w.buf = w.buf[:len(w.buf)+c]
+		n += c
+
+		if len(w.buf) == cap(w.buf) {
+			err := w.flushPart()
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func (w *UploadWriter) flushPart() error {
+	number := len(w.parts) + 1
+
+	if number <= len(w.uploadedParts) {
+		part := w.uploadedParts[number-1]
+
+		if !partMatches(part, w.buf) {
+			return ErrUploadMismatch
+		}
+
+		w.parts = append(w.parts, part)
+		w.buf = w.buf[:0]
+
+		return nil
+	}
+
+	var etag string
+	var err error
+
+	for i := 0; i <= w.opts.MaxRetries; i++ {
+		etag, err = w.st.UploadPart(w.ctx, w.name, w.uploadID, number, w.buf)
+		if err == nil || w.ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	w.parts = append(w.parts, Part{Number: number, ETag: etag, Size: int64(len(w.buf))})
+	w.buf = w.buf[:0]
+
+	return nil
+}
+
+// partMatches checks an uploaded part holds data, the content is compared when its etag is the md5 digest of it
+func partMatches(part Part, data []byte) bool {
+	if part.Size != int64(len(data)) {
+		return false
+	}
+
+	etag := strings.Trim(part.ETag, "\"")
+
+	if len(etag) != 2*md5.Size {
+		// i.e. parts encrypted by a key management service
+		return true
+	}
+
+	digest := md5.Sum(data)
+
+	return strings.EqualFold(etag, hex.EncodeToString(digest[:]))
+}
+
+// Close uploads the last part and completes the upload, the upload is left incomplete
+// when an error is returned so it can be either resumed or aborted
+func (w *UploadWriter) Close() error {
+	if w.closed {
+		return ErrAlreadyClosed
+	}
+
+	if len(w.buf) > 0 || len(w.parts) == 0 {
+		err := w.flushPart()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(w.parts) < len(w.uploadedParts) {
+		return ErrUploadMismatch
+	}
+
+	err := w.st.CompleteUpload(w.ctx, w.name, w.uploadID, w.parts)
+	if err != nil {
+		return err
+	}
+
+	w.closed = true
+
+	return nil
+}
+
+// Abort discards the upload and the parts uploaded so far
+func (w *UploadWriter) Abort() error {
+	if w.closed {
+		return ErrAlreadyClosed
+	}
+
+	w.closed = true
+
+	return w.st.AbortUpload(w.ctx, w.name, w.uploadID)
+}
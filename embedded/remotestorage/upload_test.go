@@ -0,0 +1,171 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotestorage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errInjected = errors.New("injected error")
+
+// flakyStorage fails the upload of parts as long as failures is positive
+type flakyStorage struct {
+	*memory.Storage
+	failures int
+}
+
+func (s *flakyStorage) UploadPart(ctx context.Context, name string, uploadID string, number int, data []byte) (string, error) {
+	if s.failures > 0 {
+		s.failures--
+		return "", errInjected
+	}
+
+	return s.Storage.UploadPart(ctx, name, uploadID, number, data)
+}
+
+func testData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func readObject(t *testing.T, st remotestorage.Storage, name string) []byte {
+	rc, err := st.Get(context.Background(), name, 0, -1)
+	require.NoError(t, err)
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	require.NoError(t, err)
+
+	return data
+}
+
+func TestUploadWriter(t *testing.T) {
+	ctx := context.Background()
+	st := &flakyStorage{Storage: memory.Open()}
+
+	_, err := remotestorage.NewUploadWriter(ctx, nil, "backup", remotestorage.DefaultUploadOptions())
+	require.ErrorIs(t, err, remotestorage.ErrIllegalArguments)
+
+	_, err = remotestorage.NewUploadWriter(ctx, st, "backup", remotestorage.DefaultUploadOptions().WithPartSize(0))
+	require.ErrorIs(t, err, remotestorage.ErrIllegalArguments)
+
+	opts := remotestorage.DefaultUploadOptions().WithPartSize(100).WithMaxRetries(2)
+
+	t.Run("upload", func(t *testing.T) {
+		data := testData(1050)
+
+		w, err := remotestorage.NewUploadWriter(ctx, st, "backup", opts)
+		require.NoError(t, err)
+
+		st.failures = 2
+
+		_, err = w.Write(data)
+		require.NoError(t, err)
+
+		err = w.Close()
+		require.NoError(t, err)
+
+		_, err = w.Write(data)
+		require.ErrorIs(t, err, remotestorage.ErrAlreadyClosed)
+
+		require.Equal(t, data, readObject(t, st, "backup"))
+	})
+
+	t.Run("empty upload", func(t *testing.T) {
+		w, err := remotestorage.NewUploadWriter(ctx, st, "empty", opts)
+		require.NoError(t, err)
+
+		err = w.Close()
+		require.NoError(t, err)
+
+		require.Empty(t, readObject(t, st, "empty"))
+	})
+
+	t.Run("resume upload", func(t *testing.T) {
+		data := testData(450)
+
+		w, err := remotestorage.NewUploadWriter(ctx, st, "resumed", opts)
+		require.NoError(t, err)
+
+		_, err = w.Write(data[:100])
+		require.NoError(t, err)
+
+		// the upload fails once retries are exhausted
+		st.failures = 3
+
+		_, err = w.Write(data[100:250])
+		require.ErrorIs(t, err, errInjected)
+
+		exists, err := st.Exists(ctx, "resumed")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		_, err = remotestorage.ResumeUploadWriter(ctx, st, "resumed", "", opts)
+		require.ErrorIs(t, err, remotestorage.ErrIllegalArguments)
+
+		// different data is not accepted when resuming the upload
+		w2, err := remotestorage.ResumeUploadWriter(ctx, st, "resumed", w.UploadID(), opts)
+		require.NoError(t, err)
+
+		_, err = w2.Write(bytes.Repeat([]byte{1}, 250))
+		require.ErrorIs(t, err, remotestorage.ErrUploadMismatch)
+
+		w2, err = remotestorage.ResumeUploadWriter(ctx, st, "resumed", w.UploadID(), opts)
+		require.NoError(t, err)
+
+		_, err = w2.Write(data)
+		require.NoError(t, err)
+
+		err = w2.Close()
+		require.NoError(t, err)
+
+		require.Equal(t, data, readObject(t, st, "resumed"))
+	})
+
+	t.Run("abort upload", func(t *testing.T) {
+		w, err := remotestorage.NewUploadWriter(ctx, st, "aborted", opts)
+		require.NoError(t, err)
+
+		_, err = w.Write(testData(250))
+		require.NoError(t, err)
+
+		err = w.Abort()
+		require.NoError(t, err)
+
+		err = w.Abort()
+		require.ErrorIs(t, err, remotestorage.ErrAlreadyClosed)
+
+		exists, err := st.Exists(ctx, "aborted")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		_, err = remotestorage.ResumeUploadWriter(ctx, st, "aborted", w.UploadID(), opts)
+		require.ErrorIs(t, err, memory.ErrNoSuchUpload)
+	})
+}
@@ -15,6 +15,8 @@ limitations under the License.
 */
 package sql
 
+import "sort"
+
 type Catalog struct {
 	dbsByID   map[uint32]*Database
 	dbsByName map[string]*Database
@@ -208,6 +210,20 @@ func (t *Table) IndexesByColID(colID uint32) []*Index {
 	return t.indexesByColID[colID]
 }
 
+// GetIndexes returns the indexes of the table in the order they were created
+func (t *Table) GetIndexes() []*Index {
+	indexes := make([]*Index, 0, len(t.indexes))
+	for _, index := range t.indexes {
+		indexes = append(indexes, index)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].id < indexes[j].id
+	})
+
+	return indexes
+}
+
 func (t *Table) GetColumnByName(name string) (*Column, error) {
 	col, exists := t.colsByName[name]
 	if !exists {
@@ -1097,6 +1097,12 @@ func (e *Engine) Exec(sql string, params map[string]interface{}, tx *SQLTx) (ntx
 }
 
 func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{}, tx *SQLTx) (ntx *SQLTx, committedTxs []*SQLTx, err error) {
+	return e.ExecPreparedStmtsWithTxMetadata(stmts, params, nil, tx)
+}
+
+// ExecPreparedStmtsWithTxMetadata executes the statements as ExecPreparedStmts does, attaching the metadata
+// to the transactions they are executed in. Metadata is not changed when md is nil
+func (e *Engine) ExecPreparedStmtsWithTxMetadata(stmts []SQLStmt, params map[string]interface{}, md *store.TxMetadata, tx *SQLTx) (ntx *SQLTx, committedTxs []*SQLTx, err error) {
 	if len(stmts) == 0 {
 		return nil, nil, ErrIllegalArguments
 	}
@@ -1122,6 +1128,10 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 			}
 		}
 
+		if md != nil {
+			currTx.tx.WithMetadata(md)
+		}
+
 		ntx, err := stmt.execAt(currTx, nparams)
 		if err != nil {
 			currTx.Cancel()
@@ -1185,6 +1195,11 @@ func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface
 		if err != nil {
 			return nil, err
 		}
+		defer func() {
+			if err != nil {
+				qtx.Cancel()
+			}
+		}()
 	}
 
 	// TODO: eval params at once
@@ -1160,6 +1160,59 @@ func TestEncodeValue(t *testing.T) {
 	require.Nil(t, b)
 }
 
+func TestQueryBeforeTime(t *testing.T) {
+	now := time.Date(2021, 10, 15, 10, 0, 0, 0, time.UTC)
+
+	st, err := store.Open("sqldata_q_before_time", store.DefaultOptions().WithTimeFunc(func() time.Time { return now }))
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_q_before_time")
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, nil)
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		now = now.Add(time.Hour)
+
+		_, _, err = engine.Exec("INSERT INTO table1 (id) VALUES (@id)", map[string]interface{}{"id": i}, nil)
+		require.NoError(t, err)
+	}
+
+	countRows := func(query string) int {
+		r, err := engine.Query(query, nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		n := 0
+
+		for {
+			_, err := r.Read()
+			if err == ErrNoMoreRows {
+				return n
+			}
+			require.NoError(t, err)
+
+			n++
+		}
+	}
+
+	require.Equal(t, 0, countRows("SELECT id FROM table1 BEFORE '2021-10-15 10:30'"))
+	require.Equal(t, 1, countRows("SELECT id FROM table1 BEFORE '2021-10-15 12:00'"))
+	require.Equal(t, 2, countRows("SELECT id FROM table1 BEFORE '2021-10-15 12:30'"))
+	require.Equal(t, 3, countRows("SELECT id FROM db1.table1 BEFORE '2021-10-16' AS t"))
+
+	_, err = engine.Query("SELECT id FROM table1 BEFORE 'yesterday'", nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
 func TestQuery(t *testing.T) {
 	st, err := store.Open("sqldata_q", store.DefaultOptions())
 	require.NoError(t, err)
@@ -1512,6 +1565,27 @@ func TestQuery(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestQueryErrorsReleaseImplicitTx(t *testing.T) {
+	st, err := store.Open("sqldata_q_err", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_q_err")
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.Query("SELECT id FROM table1", nil, nil)
+	require.Equal(t, ErrTableDoesNotExist, err)
+
+	err = st.Close()
+	require.NoError(t, err)
+}
+
 func TestQueryDistinct(t *testing.T) {
 	st, err := store.Open("sqldata_qd", store.DefaultOptions())
 	require.NoError(t, err)
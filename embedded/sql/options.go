@@ -15,7 +15,7 @@ limitations under the License.
 */
 package sql
 
-var defultDistinctLimit = 1 << 20 // ~ 1mi rows
+const DefaultDistinctLimit = 1 << 20 // ~ 1mi rows
 
 type Options struct {
 	prefix        []byte
@@ -25,7 +25,7 @@ type Options struct {
 
 func DefaultOptions() *Options {
 	return &Options{
-		distinctLimit: defultDistinctLimit,
+		distinctLimit: DefaultDistinctLimit,
 	}
 }
 
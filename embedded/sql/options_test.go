@@ -30,8 +30,8 @@ func TestOptions(t *testing.T) {
 	opts.WithDistinctLimit(0)
 	require.False(t, ValidOpts(opts))
 
-	opts.WithDistinctLimit(defultDistinctLimit)
-	require.Equal(t, defultDistinctLimit, opts.distinctLimit)
+	opts.WithDistinctLimit(DefaultDistinctLimit)
+	require.Equal(t, DefaultDistinctLimit, opts.distinctLimit)
 
 	opts.WithPrefix([]byte("sqlPrefix"))
 	require.Equal(t, []byte("sqlPrefix"), opts.prefix)
@@ -710,6 +710,32 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id, title FROM db1.table1 BEFORE TX 10 AS t1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "title"},
+					},
+					ds: &tableRef{db: "db1", table: "table1", asBefore: 10, as: "t1"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id, title FROM db1.table1 BEFORE '2021-10-15 10:00' AS t1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "title"},
+					},
+					ds: &tableRef{db: "db1", table: "table1", asBeforeTs: "2021-10-15 10:00", as: "t1"},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT t1.id, title FROM db1.table1 t1",
 			expectedOutput: []SQLStmt{
@@ -541,6 +541,13 @@ ds:
         $1.as = $3
         $$ = $1
     }
+|
+    tableRef BEFORE VARCHAR opt_as
+    {
+        $1.asBeforeTs = $3
+        $1.as = $4
+        $$ = $1
+    }
 |
     '(' dqlstmt ')' opt_as
     {
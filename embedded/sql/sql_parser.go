@@ -214,96 +214,96 @@ var yyExca = [...]int{
 	1, -1,
 	-2, 0,
 	-1, 94,
-	50, 123,
-	53, 123,
-	-2, 112,
+	50, 124,
+	53, 124,
+	-2, 113,
 	-1, 154,
-	39, 90,
-	-2, 85,
-	-1, 187,
-	39, 90,
-	-2, 87,
+	39, 91,
+	-2, 86,
+	-1, 188,
+	39, 91,
+	-2, 88,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 333
+const yyLast = 337
 
 var yyAct = [...]int{
-	226, 269, 54, 132, 91, 200, 203, 114, 225, 6,
-	75, 186, 67, 199, 123, 61, 70, 88, 17, 238,
-	242, 130, 130, 130, 196, 251, 130, 246, 245, 243,
-	220, 197, 244, 96, 131, 241, 98, 209, 191, 204,
-	110, 108, 106, 109, 183, 32, 159, 107, 158, 102,
-	103, 104, 105, 55, 205, 201, 96, 97, 129, 98,
-	116, 208, 101, 110, 108, 106, 109, 164, 148, 93,
+	229, 272, 54, 132, 91, 202, 205, 114, 228, 6,
+	75, 187, 67, 123, 201, 61, 88, 70, 17, 241,
+	245, 130, 130, 130, 198, 254, 130, 249, 248, 246,
+	223, 199, 247, 96, 131, 244, 98, 211, 193, 206,
+	110, 108, 106, 109, 184, 32, 160, 107, 159, 102,
+	103, 104, 105, 55, 207, 203, 96, 97, 129, 98,
+	116, 210, 101, 110, 108, 106, 109, 165, 148, 93,
 	107, 141, 102, 103, 104, 105, 55, 146, 139, 140,
-	97, 120, 111, 125, 90, 101, 141, 80, 78, 135,
-	136, 138, 137, 139, 140, 19, 181, 144, 145, 66,
-	65, 128, 147, 141, 135, 136, 138, 137, 219, 79,
-	160, 149, 79, 49, 153, 223, 151, 268, 263, 154,
-	168, 210, 242, 138, 137, 99, 156, 141, 157, 152,
-	222, 155, 161, 141, 139, 140, 170, 171, 172, 173,
-	174, 175, 141, 163, 68, 135, 136, 138, 137, 182,
-	140, 135, 136, 138, 137, 184, 180, 56, 56, 53,
-	135, 136, 138, 137, 55, 56, 190, 130, 119, 51,
-	74, 55, 222, 127, 112, 85, 194, 230, 77, 207,
-	193, 202, 198, 162, 56, 89, 192, 166, 71, 150,
-	124, 126, 121, 76, 118, 82, 72, 57, 211, 212,
-	117, 32, 214, 44, 41, 36, 113, 189, 218, 177,
-	237, 206, 236, 81, 38, 217, 176, 229, 228, 141,
-	143, 233, 234, 227, 178, 124, 58, 179, 239, 270,
-	271, 255, 133, 262, 249, 232, 68, 248, 250, 213,
-	84, 63, 62, 253, 73, 30, 37, 34, 17, 256,
-	260, 252, 258, 240, 48, 167, 165, 29, 261, 115,
-	264, 10, 11, 28, 20, 266, 267, 215, 86, 64,
-	39, 272, 12, 2, 273, 259, 31, 7, 169, 8,
-	9, 13, 14, 83, 59, 15, 16, 60, 45, 46,
-	47, 17, 21, 35, 134, 40, 27, 22, 24, 23,
-	43, 25, 26, 92, 18, 221, 69, 142, 216, 235,
-	254, 265, 195, 231, 95, 94, 247, 188, 187, 185,
-	42, 33, 52, 50, 100, 224, 257, 87, 122, 5,
-	4, 3, 1,
+	97, 120, 111, 90, 125, 101, 141, 80, 78, 135,
+	136, 138, 137, 139, 140, 19, 182, 144, 145, 79,
+	128, 149, 147, 66, 135, 136, 138, 137, 65, 161,
+	79, 56, 141, 49, 153, 226, 151, 55, 99, 154,
+	271, 212, 51, 68, 266, 245, 157, 141, 158, 222,
+	152, 155, 138, 137, 139, 140, 171, 172, 173, 174,
+	175, 176, 164, 141, 169, 135, 136, 138, 137, 183,
+	141, 140, 53, 112, 56, 185, 181, 225, 162, 130,
+	84, 135, 136, 138, 137, 119, 191, 233, 135, 136,
+	138, 137, 225, 74, 127, 56, 196, 77, 85, 163,
+	209, 55, 200, 204, 195, 190, 56, 89, 192, 194,
+	167, 71, 76, 117, 150, 124, 126, 121, 118, 213,
+	214, 82, 72, 216, 217, 57, 32, 44, 41, 36,
+	113, 221, 240, 208, 178, 239, 81, 141, 220, 232,
+	231, 177, 38, 236, 230, 237, 258, 179, 143, 124,
+	180, 242, 58, 273, 274, 133, 265, 252, 235, 68,
+	251, 253, 215, 84, 63, 156, 256, 62, 73, 37,
+	30, 34, 259, 17, 263, 261, 255, 243, 48, 168,
+	166, 264, 115, 267, 10, 11, 29, 28, 269, 270,
+	20, 218, 86, 39, 275, 12, 2, 276, 64, 31,
+	7, 262, 8, 9, 13, 14, 170, 83, 15, 16,
+	60, 45, 46, 47, 17, 21, 35, 59, 134, 40,
+	22, 24, 23, 27, 43, 25, 26, 92, 18, 224,
+	69, 142, 219, 238, 257, 268, 197, 234, 95, 94,
+	250, 189, 188, 186, 42, 33, 52, 50, 100, 227,
+	260, 87, 122, 5, 4, 3, 1,
 }
 
 var yyPact = [...]int{
-	257, -1000, -1000, 18, -1000, -1000, -1000, 243, -1000, -1000,
-	286, 295, 285, 237, 231, 209, 138, 212, -1000, 257,
-	-1000, 142, 163, 163, 282, 141, 292, 140, 138, 138,
-	138, 224, 37, 95, -1000, -1000, -1000, 134, 177, 270,
-	163, -1000, 205, 203, 253, 22, 21, 195, 125, 133,
-	208, -1000, 99, 130, -1000, 10, 36, 9, 161, 132,
-	269, -1000, 202, 110, 251, 122, 122, 298, 7, 103,
-	-1000, 144, -1000, -18, 102, -1000, -1000, 131, 94, 129,
-	127, -1000, 5, 128, 108, -1000, 127, -21, 96, -1000,
-	-45, 188, 281, 32, 171, -1000, 7, 7, -1, -1000,
-	-1000, 7, -1000, -1000, -1000, -1000, -10, 33, 126, -1000,
-	-1000, 298, 125, 7, 298, 205, 214, 130, -1000, -31,
-	-33, 34, 61, -1000, 119, 122, -11, -1000, -1000, 229,
-	124, 228, -1000, 55, 264, 7, 7, 7, 7, 7,
-	7, 160, 174, -1000, 88, 49, 214, 17, 7, -35,
-	-1000, 188, -1000, 32, 147, 130, -41, -1000, -1000, -1000,
-	123, 162, -56, -48, 122, -23, -1000, -23, -1000, -24,
-	49, 49, 165, 165, 88, 79, -1000, 155, 7, -17,
-	-42, -1000, 73, -1000, -1000, 195, -1000, 147, 200, -1000,
-	-1000, 130, -1000, 248, -1000, 159, 43, -1000, -49, 101,
-	-1000, 7, 59, -1000, -1000, 122, -1000, 88, -16, -1000,
-	113, 193, -1000, -18, -1000, -24, 157, -1000, 154, -62,
-	-1000, -1000, -23, 222, -44, 51, 32, -50, -47, -51,
-	-52, 197, 191, 298, -54, -1000, -1000, -1000, -1000, -1000,
-	219, -1000, 7, -1000, -1000, -1000, -1000, 186, 7, 121,
-	261, -1000, 217, 32, 188, 190, 32, 47, -1000, 7,
-	-1000, -1000, 121, 121, 32, 46, 183, -1000, 121, -1000,
-	-1000, -1000, 183, -1000,
+	260, -1000, -1000, 18, -1000, -1000, -1000, 249, -1000, -1000,
+	289, 299, 292, 241, 240, 214, 143, 216, -1000, 260,
+	-1000, 146, 171, 171, 286, 145, 296, 144, 143, 143,
+	143, 228, 37, 48, -1000, -1000, -1000, 142, 183, 283,
+	171, -1000, 210, 206, 262, 30, 25, 198, 128, 139,
+	212, -1000, 102, 129, -1000, 10, 34, 9, 164, 138,
+	273, -1000, 205, 113, 255, 124, 124, 302, 7, 82,
+	-1000, 148, -1000, -18, 112, -1000, -1000, 135, 91, 134,
+	132, -1000, 6, 133, 109, -1000, 132, -21, 88, -1000,
+	-45, 191, 285, 32, 179, -1000, 7, 7, -1, -1000,
+	-1000, 7, -1000, -1000, -1000, -1000, -10, 23, 131, -1000,
+	-1000, 302, 128, 7, 302, 208, 219, 129, -1000, -31,
+	-33, 33, 87, -1000, 115, 124, -11, -1000, -1000, 233,
+	127, 232, -1000, 79, 272, 7, 7, 7, 7, 7,
+	7, 165, 177, -1000, 89, 58, 219, 17, 7, -35,
+	-1000, 191, -1000, 32, 125, 129, 122, -41, -1000, -1000,
+	-1000, 126, 166, -56, -48, 124, -23, -1000, -23, -1000,
+	-24, 58, 58, 163, 163, 89, 96, -1000, 157, 7,
+	-17, -42, -1000, 73, -1000, -1000, 198, -1000, 125, 203,
+	-1000, -1000, 129, 129, -1000, 252, -1000, 162, 64, -1000,
+	-49, 101, -1000, 7, 86, -1000, -1000, 124, -1000, 89,
+	-16, -1000, 103, 196, -1000, -18, -1000, -1000, -24, 160,
+	-1000, 156, -62, -1000, -1000, -23, 226, -44, 54, 32,
+	-50, -47, -51, -52, 200, 194, 302, -54, -1000, -1000,
+	-1000, -1000, -1000, 224, -1000, 7, -1000, -1000, -1000, -1000,
+	181, 7, 123, 267, -1000, 221, 32, 191, 193, 32,
+	53, -1000, 7, -1000, -1000, 123, 123, 32, 49, 187,
+	-1000, 123, -1000, -1000, -1000, 187, -1000,
 }
 
 var yyPgo = [...]int{
-	0, 332, 273, 331, 330, 9, 329, 328, 14, 17,
-	6, 327, 326, 13, 5, 8, 325, 324, 125, 323,
-	322, 2, 321, 7, 259, 320, 15, 319, 11, 318,
-	317, 0, 12, 316, 315, 314, 313, 3, 312, 10,
-	311, 310, 1, 4, 246, 309, 308, 307, 16, 306,
-	305, 304,
+	0, 336, 276, 335, 334, 9, 333, 332, 13, 16,
+	6, 331, 330, 14, 5, 8, 329, 328, 118, 327,
+	326, 2, 325, 7, 262, 324, 15, 323, 11, 322,
+	321, 0, 12, 320, 319, 318, 317, 3, 316, 10,
+	315, 314, 1, 4, 249, 313, 312, 311, 17, 310,
+	309, 308,
 }
 
 var yyR1 = [...]int{
@@ -315,12 +315,12 @@ var yyR1 = [...]int{
 	17, 17, 17, 17, 17, 17, 7, 7, 8, 38,
 	38, 45, 45, 46, 46, 46, 5, 22, 22, 19,
 	19, 20, 20, 18, 18, 18, 21, 21, 21, 23,
-	23, 24, 24, 26, 26, 27, 27, 28, 28, 29,
-	30, 30, 32, 32, 36, 36, 33, 33, 37, 37,
-	41, 41, 43, 43, 40, 40, 42, 42, 42, 39,
-	39, 39, 31, 31, 31, 31, 31, 31, 31, 31,
-	34, 34, 34, 47, 47, 35, 35, 35, 35, 35,
-	35, 35, 35,
+	23, 23, 24, 24, 26, 26, 27, 27, 28, 28,
+	29, 30, 30, 32, 32, 36, 36, 33, 33, 37,
+	37, 41, 41, 43, 43, 40, 40, 42, 42, 42,
+	39, 39, 39, 31, 31, 31, 31, 31, 31, 31,
+	31, 34, 34, 34, 47, 47, 35, 35, 35, 35,
+	35, 35, 35, 35,
 }
 
 var yyR2 = [...]int{
@@ -332,12 +332,12 @@ var yyR2 = [...]int{
 	1, 6, 3, 2, 1, 1, 1, 3, 5, 0,
 	3, 0, 1, 0, 1, 2, 12, 0, 1, 1,
 	1, 2, 4, 1, 4, 4, 1, 3, 5, 3,
-	4, 1, 3, 0, 3, 0, 1, 1, 2, 6,
-	0, 1, 0, 2, 0, 3, 0, 2, 0, 2,
-	0, 3, 0, 4, 2, 4, 0, 1, 1, 0,
-	1, 2, 1, 1, 2, 2, 4, 4, 6, 6,
-	1, 1, 3, 0, 1, 3, 3, 3, 3, 3,
-	3, 3, 4,
+	4, 4, 1, 3, 0, 3, 0, 1, 1, 2,
+	6, 0, 1, 0, 2, 0, 3, 0, 2, 0,
+	2, 0, 3, 0, 4, 2, 4, 0, 1, 1,
+	0, 1, 2, 1, 1, 2, 2, 4, 4, 6,
+	6, 1, 1, 3, 0, 1, 3, 3, 3, 3,
+	3, 3, 3, 4,
 }
 
 var yyChk = [...]int{
@@ -356,50 +356,50 @@ var yyChk = [...]int{
 	-21, 63, -7, -8, 63, 78, 63, 65, -8, 79,
 	71, 79, -37, 44, 13, 72, 73, 75, 74, 61,
 	62, 54, -47, 49, -31, -31, 78, -31, 78, 78,
-	63, -43, -48, -31, -43, -26, -5, -39, 79, 79,
-	76, 71, 64, -9, 78, 27, 63, 27, 65, 14,
-	-31, -31, -31, -31, -31, -31, 56, 49, 50, 53,
-	-5, 79, -31, 79, -37, -27, -28, -29, -30, 60,
-	-39, 79, 63, 18, -8, -38, 80, 79, -9, -13,
-	-14, 78, -13, -10, 63, 78, 56, -31, 78, 79,
-	48, -32, -28, 39, -39, 19, -46, 56, 49, 65,
-	79, -50, 71, 14, -16, -15, -31, -9, -5, -15,
-	64, -36, 42, -23, -10, -45, 55, 56, 81, -14,
-	31, 79, 71, 79, 79, 79, 79, -33, 40, 43,
-	-43, 79, 32, -31, -41, 45, -31, -12, -21, 14,
-	33, -37, 43, 71, -31, -40, -21, -21, 71, -42,
-	46, 47, -21, -42,
+	63, -43, -48, -31, -43, -26, 37, -5, -39, 79,
+	79, 76, 71, 64, -9, 78, 27, 63, 27, 65,
+	14, -31, -31, -31, -31, -31, -31, 56, 49, 50,
+	53, -5, 79, -31, 79, -37, -27, -28, -29, -30,
+	60, -39, 66, 79, 63, 18, -8, -38, 80, 79,
+	-9, -13, -14, 78, -13, -10, 63, 78, 56, -31,
+	78, 79, 48, -32, -28, 39, -39, -39, 19, -46,
+	56, 49, 65, 79, -50, 71, 14, -16, -15, -31,
+	-9, -5, -15, 64, -36, 42, -23, -10, -45, 55,
+	56, 81, -14, 31, 79, 71, 79, 79, 79, 79,
+	-33, 40, 43, -43, 79, 32, -31, -41, 45, -31,
+	-12, -21, 14, 33, -37, 43, 71, -31, -40, -21,
+	-21, 71, -42, 46, 47, -21, -42,
 }
 
 var yyDef = [...]int{
 	0, -2, 1, 4, 6, 7, 8, 0, 10, 11,
 	0, 0, 0, 0, 0, 0, 0, 67, 2, 5,
 	9, 0, 21, 21, 0, 0, 19, 0, 0, 0,
-	0, 0, 81, 0, 68, 3, 12, 0, 0, 0,
-	21, 13, 83, 0, 0, 0, 0, 92, 0, 0,
-	0, 69, 70, 109, 73, 0, 76, 0, 0, 0,
-	0, 14, 0, 0, 0, 34, 0, 102, 0, 92,
-	31, 0, 82, 0, 0, 71, 110, 0, 0, 0,
+	0, 0, 82, 0, 68, 3, 12, 0, 0, 0,
+	21, 13, 84, 0, 0, 0, 0, 93, 0, 0,
+	0, 69, 70, 110, 73, 0, 76, 0, 0, 0,
+	0, 14, 0, 0, 0, 34, 0, 103, 0, 93,
+	31, 0, 83, 0, 0, 71, 111, 0, 0, 0,
 	0, 22, 0, 0, 0, 20, 0, 0, 35, 39,
-	0, 98, 0, 93, -2, 113, 0, 0, 0, 120,
-	121, 0, 47, 48, 49, 50, 0, 76, 0, 54,
-	55, 102, 0, 0, 102, 83, 0, 109, 111, 0,
-	0, 77, 0, 56, 0, 0, 0, 84, 18, 0,
+	0, 99, 0, 94, -2, 114, 0, 0, 0, 121,
+	122, 0, 47, 48, 49, 50, 0, 76, 0, 54,
+	55, 103, 0, 0, 103, 84, 0, 110, 112, 0,
+	0, 77, 0, 56, 0, 0, 0, 85, 18, 0,
 	0, 0, 27, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 124, 114, 115, 0, 0, 0, 0,
-	53, 98, 32, 33, -2, 109, 0, 72, 74, 75,
-	0, 0, 59, 0, 0, 0, 40, 0, 99, 0,
-	125, 126, 127, 128, 129, 130, 131, 0, 0, 0,
-	0, 122, 0, 52, 28, 92, 86, -2, 0, 91,
-	79, 109, 78, 0, 57, 63, 0, 16, 0, 29,
-	36, 43, 26, 103, 23, 0, 132, 116, 0, 117,
-	0, 94, 88, 0, 80, 0, 61, 64, 0, 0,
-	17, 25, 0, 0, 0, 44, 45, 0, 0, 0,
-	0, 96, 0, 102, 0, 58, 62, 65, 60, 37,
-	0, 38, 0, 24, 118, 119, 51, 100, 0, 0,
-	0, 15, 0, 46, 98, 0, 97, 95, 41, 0,
-	30, 66, 0, 0, 89, 101, 106, 42, 0, 104,
-	107, 108, 106, 105,
+	0, 0, 0, 125, 115, 116, 0, 0, 0, 0,
+	53, 99, 32, 33, -2, 110, 0, 0, 72, 74,
+	75, 0, 0, 59, 0, 0, 0, 40, 0, 100,
+	0, 126, 127, 128, 129, 130, 131, 132, 0, 0,
+	0, 0, 123, 0, 52, 28, 93, 87, -2, 0,
+	92, 79, 110, 110, 78, 0, 57, 63, 0, 16,
+	0, 29, 36, 43, 26, 104, 23, 0, 133, 117,
+	0, 118, 0, 95, 89, 0, 80, 81, 0, 61,
+	64, 0, 0, 17, 25, 0, 0, 0, 44, 45,
+	0, 0, 0, 0, 97, 0, 103, 0, 58, 62,
+	65, 60, 37, 0, 38, 0, 24, 119, 120, 51,
+	101, 0, 0, 0, 15, 0, 46, 99, 0, 98,
+	96, 41, 0, 30, 66, 0, 0, 90, 102, 107,
+	42, 0, 105, 108, 109, 107, 106,
 }
 
 var yyTok1 = [...]int{
@@ -1155,267 +1155,274 @@ yydefault:
 			yyVAL.ds = yyDollar[1].tableRef
 		}
 	case 80:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyDollar[1].tableRef.asBeforeTs = yyDollar[3].str
+			yyDollar[1].tableRef.as = yyDollar[4].id
+			yyVAL.ds = yyDollar[1].tableRef
+		}
+	case 81:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyDollar[2].stmt.(*SelectStmt).as = yyDollar[4].id
 			yyVAL.ds = yyDollar[2].stmt.(DataSource)
 		}
-	case 81:
+	case 82:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{table: yyDollar[1].id}
 		}
-	case 82:
+	case 83:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{db: yyDollar[1].id, table: yyDollar[3].id}
 		}
-	case 83:
+	case 84:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 84:
+	case 85:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[3].number
 		}
-	case 85:
+	case 86:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joins = nil
 		}
-	case 86:
+	case 87:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = yyDollar[1].joins
 		}
-	case 87:
+	case 88:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = []*JoinSpec{yyDollar[1].join}
 		}
-	case 88:
+	case 89:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.joins = append([]*JoinSpec{yyDollar[1].join}, yyDollar[2].joins...)
 		}
-	case 89:
+	case 90:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.join = &JoinSpec{joinType: yyDollar[1].joinType, ds: yyDollar[3].ds, indexOn: yyDollar[4].ids, cond: yyDollar[6].exp}
 		}
-	case 90:
+	case 91:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joinType = InnerJoin
 		}
-	case 91:
+	case 92:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joinType = yyDollar[1].joinType
 		}
-	case 92:
+	case 93:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 93:
+	case 94:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 94:
+	case 95:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.cols = nil
 		}
-	case 95:
+	case 96:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.cols = yyDollar[3].cols
 		}
-	case 96:
+	case 97:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 97:
+	case 98:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 98:
+	case 99:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 99:
+	case 100:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 100:
+	case 101:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ordcols = nil
 		}
-	case 101:
+	case 102:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ordcols = yyDollar[3].ordcols
 		}
-	case 102:
+	case 103:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 103:
+	case 104:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[4].ids
 		}
-	case 104:
+	case 105:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.ordcols = []*OrdCol{{sel: yyDollar[1].col, descOrder: yyDollar[2].opt_ord}}
 		}
-	case 105:
+	case 106:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ordcols = append(yyDollar[1].ordcols, &OrdCol{sel: yyDollar[3].col, descOrder: yyDollar[4].opt_ord})
 		}
-	case 106:
+	case 107:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 107:
+	case 108:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 108:
+	case 109:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = true
 		}
-	case 109:
+	case 110:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.id = ""
 		}
-	case 110:
+	case 111:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.id = yyDollar[1].id
 		}
-	case 111:
+	case 112:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.id = yyDollar[2].id
 		}
-	case 112:
+	case 113:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].exp
 		}
-	case 113:
+	case 114:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].binExp
 		}
-	case 114:
+	case 115:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NotBoolExp{exp: yyDollar[2].exp}
 		}
-	case 115:
+	case 116:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NumExp{left: &Number{val: 0}, op: SUBSOP, right: yyDollar[2].exp}
 		}
-	case 116:
+	case 117:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &LikeBoolExp{val: yyDollar[1].exp, notLike: yyDollar[2].boolean, pattern: yyDollar[4].exp}
 		}
-	case 117:
+	case 118:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &ExistsBoolExp{q: (yyDollar[3].stmt).(*SelectStmt)}
 		}
-	case 118:
+	case 119:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InSubQueryExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, q: yyDollar[5].stmt.(*SelectStmt)}
 		}
-	case 119:
+	case 120:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InListExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, values: yyDollar[5].values}
 		}
-	case 120:
+	case 121:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].sel
 		}
-	case 121:
+	case 122:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].value
 		}
-	case 122:
+	case 123:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 123:
+	case 124:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 124:
+	case 125:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 125:
+	case 126:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: ADDOP, right: yyDollar[3].exp}
 		}
-	case 126:
+	case 127:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: SUBSOP, right: yyDollar[3].exp}
 		}
-	case 127:
+	case 128:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: DIVOP, right: yyDollar[3].exp}
 		}
-	case 128:
+	case 129:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: MULTOP, right: yyDollar[3].exp}
 		}
-	case 129:
+	case 130:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &BinBoolExp{left: yyDollar[1].exp, op: yyDollar[2].logicOp, right: yyDollar[3].exp}
 		}
-	case 130:
+	case 131:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: yyDollar[2].cmpOp, right: yyDollar[3].exp}
 		}
-	case 131:
+	case 132:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: EQ, right: &NullValue{t: AnyType}}
 		}
-	case 132:
+	case 133:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: NE, right: &NullValue{t: AnyType}}
@@ -1041,6 +1041,10 @@ type DeleteFromStmt struct {
 	limit    int
 }
 
+func (stmt *DeleteFromStmt) Table() string {
+	return stmt.tableRef.table
+}
+
 func (stmt *DeleteFromStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
 	selectStmt := &SelectStmt{
 		ds:    stmt.tableRef,
@@ -1741,27 +1745,12 @@ func (c *Cast) getConverter(src, dst SQLValueType) (converterFunc, error) {
 					return &NullValue{t: TimestampType}, nil
 				}
 
-				str := val.Value().(string)
-				for _, layout := range []string{
-					"2006-01-02 15:04:05.999999",
-					"2006-01-02 15:04",
-					"2006-01-02",
-				} {
-					t, err := time.ParseInLocation(layout, str, time.UTC)
-					if err == nil {
-						return &Timestamp{val: t.UTC()}, nil
-					}
+				t, err := parseTimestamp(val.Value().(string))
+				if err != nil {
+					return nil, err
 				}
 
-				if len(str) > 30 {
-					str = str[:30] + "..."
-				}
-
-				return nil, fmt.Errorf(
-					"%w: can not cast string '%s' as a TIMESTAMP",
-					ErrIllegalArguments,
-					str,
-				)
+				return &Timestamp{val: t}, nil
 			}, nil
 		}
 
@@ -1778,6 +1767,29 @@ func (c *Cast) getConverter(src, dst SQLValueType) (converterFunc, error) {
 	)
 }
 
+func parseTimestamp(str string) (time.Time, error) {
+	for _, layout := range []string{
+		"2006-01-02 15:04:05.999999",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	} {
+		t, err := time.ParseInLocation(layout, str, time.UTC)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	if len(str) > 30 {
+		str = str[:30] + "..."
+	}
+
+	return time.Time{}, fmt.Errorf(
+		"%w: can not cast string '%s' as a TIMESTAMP",
+		ErrIllegalArguments,
+		str,
+	)
+}
+
 func (c *Cast) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
 	valType, err := c.val.inferType(cols, params, implicitDB, implicitTable)
 	if err != nil {
@@ -2192,10 +2204,11 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 }
 
 type tableRef struct {
-	db       string
-	table    string
-	asBefore uint64
-	as       string
+	db         string
+	table      string
+	asBefore   uint64
+	asBeforeTs string
+	as         string
 }
 
 func (stmt *tableRef) referencedTable(tx *SQLTx) (*Table, error) {
@@ -2240,7 +2253,24 @@ func (stmt *tableRef) Resolve(tx *SQLTx, params map[string]interface{}, scanSpec
 		return nil, err
 	}
 
-	return newRawRowReader(tx, table, stmt.asBefore, stmt.as, scanSpecs)
+	asBefore := stmt.asBefore
+
+	if stmt.asBeforeTs != "" {
+		ts, err := parseTimestamp(stmt.asBeforeTs)
+		if err != nil {
+			return nil, err
+		}
+
+		// rows are read as they were before the first transaction committed at or after ts
+		txID, err := tx.engine.store.FirstTxSince(ts)
+		if err != nil && err != store.ErrTxNotFound {
+			return nil, err
+		}
+
+		asBefore = txID
+	}
+
+	return newRawRowReader(tx, table, asBefore, stmt.as, scanSpecs)
 }
 
 func (stmt *tableRef) Alias() string {
@@ -0,0 +1,540 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/hashing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const DefaultArchiveFrequency = 1 * time.Minute
+
+var ErrInvalidArchive = errors.New("invalid archive")
+
+var metricsArchivedSegments = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_archived_segments",
+	Help: "Number of sealed log segments shipped to the archive",
+}, []string{
+	"db",
+})
+
+// SegmentArchive is the destination where sealed segments of the transaction and value logs are shipped.
+// Segments are identified by their slash separated path relative to the store folder, i.e. "tx/00000000.tx"
+type SegmentArchive interface {
+	// Archive stores a copy of the segment file at filename, archiving an already archived segment
+	// must not fail
+	Archive(name string, filename string) error
+	// Segments returns the names of the archived segments
+	Segments() ([]string, error)
+	// Open returns a reader of the content of an archived segment
+	Open(name string) (io.ReadCloser, error)
+}
+
+// DirArchive is a SegmentArchive keeping the archived segments in a local folder,
+// i.e. a mount point of a network or removable storage
+type DirArchive struct {
+	path     string
+	fileMode os.FileMode
+}
+
+func NewDirArchive(path string, fileMode os.FileMode) *DirArchive {
+	return &DirArchive{path: path, fileMode: fileMode}
+}
+
+// Archive copies the segment into a temporary file which is renamed once synced,
+// so partially copied segments are never seen as archived
+func (a *DirArchive) Archive(name string, filename string) error {
+	dst := filepath.Join(a.path, filepath.FromSlash(name))
+
+	err := os.MkdirAll(filepath.Dir(dst), a.fileMode)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".archiving-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, src)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (a *DirArchive) Segments() ([]string, error) {
+	var names []string
+
+	err := filepath.Walk(a.path, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		name, err := filepath.Rel(a.path, filename)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, filepath.ToSlash(name))
+
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return names, err
+}
+
+func (a *DirArchive) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(a.path, filepath.FromSlash(name)))
+}
+
+// archivedLogs returns the folder and file extension of the logs whose segments are archived.
+// The commit log is not archived, it's rebuilt from the transaction log when restoring
+func archivedLogs(vLogCount int, withBlobLog bool) map[string]string {
+	logs := map[string]string{"tx": "tx"}
+
+	for i := 0; i < vLogCount; i++ {
+		logs[fmt.Sprintf("val_%d", i)] = "val"
+	}
+
+	if withBlobLog {
+		logs[blobDirname] = "blob"
+	}
+
+	return logs
+}
+
+// segmentIDs returns the ids of the segments found in a log folder sorted in ascending order
+func segmentIDs(dir, ext string) ([]int64, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != "."+ext {
+			continue
+		}
+
+		id, err := strconv.ParseInt(strings.TrimSuffix(e.Name(), "."+ext), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, nil
+}
+
+// ArchiveSegments ships to the archive the segments sealed since the last call and returns how many were archived.
+// A segment is sealed once the log moves to the next one, segments of the transaction log are also required
+// to only hold committed transactions so they are never rewritten after being archived.
+func (s *ImmuStore) ArchiveSegments() (int, error) {
+	if s.archive == nil {
+		return 0, ErrIllegalArguments
+	}
+
+	s.archiveMutex.Lock()
+	defer s.archiveMutex.Unlock()
+
+	s.mutex.Lock()
+	closed := s.closed
+	s.mutex.Unlock()
+
+	if closed {
+		return 0, ErrAlreadyClosed
+	}
+
+	if s.archived == nil {
+		names, err := s.archive.Segments()
+		if err != nil {
+			return 0, err
+		}
+
+		s.archived = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			s.archived[name] = struct{}{}
+		}
+	}
+
+	_, _, committedTxLogSize := s.commitState()
+
+	_, withBlobLog := s.vLogs[blobLogID-1]
+
+	archived := 0
+
+	for dir, ext := range archivedLogs(s.maxIOConcurrency, withBlobLog) {
+		ids, err := segmentIDs(filepath.Join(s.path, dir), ext)
+		if err != nil {
+			return archived, err
+		}
+
+		// the last segment is still being written
+		for i := 0; i < len(ids)-1; i++ {
+			if dir == "tx" && (ids[i]+1)*int64(s.fileSize) > committedTxLogSize {
+				break
+			}
+
+			filename := fmt.Sprintf("%08d.%s", ids[i], ext)
+			name := path.Join(dir, filename)
+
+			if _, ok := s.archived[name]; ok {
+				continue
+			}
+
+			err := s.archive.Archive(name, filepath.Join(s.path, dir, filename))
+			if err != nil {
+				return archived, fmt.Errorf("unable to archive segment '%s': %w", name, err)
+			}
+
+			s.archived[name] = struct{}{}
+			s.metricsArchivedSegments.Inc()
+
+			archived++
+		}
+	}
+
+	return archived, nil
+}
+
+// startArchiver periodically ships sealed segments to the archive until the store is closed.
+// Segments failing to be archived are retried in the next round.
+func (s *ImmuStore) startArchiver(frequency time.Duration) {
+	s.archiverDone = make(chan struct{})
+
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(frequency)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				n, err := s.ArchiveSegments()
+				if err == ErrAlreadyClosed {
+					return
+				}
+				if err != nil {
+					s.log.Warningf("%v: while archiving segments of '%s'", err, s.path)
+				}
+				if n > 0 {
+					s.log.Infof("%d segments of '%s' archived", n, s.path)
+				}
+			}
+		}
+	}(s.archiverDone)
+}
+
+// RestoreArchive restores into path, which must not exist or be empty, the transactions fully
+// included in the segments of an archive. See RestoreArchiveUpTo.
+func RestoreArchive(path string, archive SegmentArchive, opts *Options) (*BackupManifest, error) {
+	return RestoreArchiveUpTo(path, archive, opts, 0)
+}
+
+// RestoreArchiveUpTo restores into path, which must not exist or be empty, the transactions
+// up to txID from the segments of an archive, enabling point-in-time recovery.
+// The commit log is rebuilt from the archived transaction log, transactions are restored as long as
+// they chain to the previous one and their values are archived as well.
+// The returned manifest describes the restored store. When txID is zero, every restorable
+// transaction is restored.
+func RestoreArchiveUpTo(path string, archive SegmentArchive, opts *Options, txID uint64) (*BackupManifest, error) {
+	if archive == nil || !validOptions(opts) || opts.KeyProvider != nil {
+		return nil, ErrIllegalArguments
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("%w: restore path '%s' is not empty", ErrIllegalArguments, path)
+	}
+
+	err = os.MkdirAll(path, opts.FileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := archive.Segments()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		r, err := archive.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		err = restoreFile(path, name, r, opts.FileMode)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, err := rebuildCommitLog(path, opts, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	committedTxID, committedAlh, _ := st.commitState()
+
+	err = st.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if committedTxID != manifest.TxID || committedAlh != manifest.Alh {
+		return nil, fmt.Errorf("%w: restored store does not match the archived transactions", ErrInvalidArchive)
+	}
+
+	return manifest, nil
+}
+
+// rebuildCommitLog creates the commit log of the restored logs at path, transactions are read
+// sequentially from the transaction log until one can not be restored or txID is reached
+func rebuildCommitLog(path string, opts *Options, txID uint64) (*BackupManifest, error) {
+	appFactory := appendableFactory(opts)
+
+	_, err := os.Stat(filepath.Join(path, "tx"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: no transaction log segment found", ErrInvalidArchive)
+		}
+		return nil, err
+	}
+
+	txLogOpts := appendableOptions(opts).
+		WithReadOnly(true).
+		WithFileExt("tx").
+		WithCompressionFormat(appendable.NoCompression)
+
+	txLog, err := appFactory(path, "tx", txLogOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer txLog.Close()
+
+	// logs are described by the metadata stored along with every segment
+	metadata := appendable.NewMetadata(txLog.Metadata())
+
+	maxTxEntries, ok := metadata.GetInt(metaMaxTxEntries)
+	if !ok {
+		return nil, fmt.Errorf("%w: corrupted transaction log metadata", ErrInvalidArchive)
+	}
+
+	maxKeyLen, ok := metadata.GetInt(metaMaxKeyLen)
+	if !ok {
+		return nil, fmt.Errorf("%w: corrupted transaction log metadata", ErrInvalidArchive)
+	}
+
+	hashAlg := hashing.SHA256
+
+	metaHashAlg, ok := metadata.GetInt(metaHashAlgorithm)
+	if ok {
+		hashAlg = hashing.Algorithm(metaHashAlg)
+	}
+
+	vLogOpts := appendableOptions(opts).
+		WithReadOnly(true).
+		WithCompressionFormat(opts.CompressionFormat).
+		WithCompresionLevel(opts.CompressionLevel)
+
+	vLogs := make(map[byte]appendable.Appendable)
+	defer func() {
+		for _, vLog := range vLogs {
+			vLog.Close()
+		}
+	}()
+
+	// value logs are opened as they are referenced, only the archived ones are available
+	valueAvailable := func(e *TxEntry) (bool, error) {
+		if e.vLen == 0 {
+			return true, nil
+		}
+
+		vLogID, offset := decodeOffset(e.vOff)
+
+		vLog, ok := vLogs[vLogID]
+		if !ok {
+			subPath := fmt.Sprintf("val_%d", vLogID-1)
+			if vLogID == blobLogID {
+				subPath = blobDirname
+				vLogOpts.WithFileExt("blob")
+			} else {
+				vLogOpts.WithFileExt("val")
+			}
+
+			_, err := os.Stat(filepath.Join(path, subPath))
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+
+			vLog, err = appFactory(path, subPath, vLogOpts)
+			if err != nil {
+				return false, err
+			}
+
+			vLogs[vLogID] = vLog
+		}
+
+		_, err := vLog.ReadAt(make([]byte, e.vLen), offset)
+
+		return err == nil, nil
+	}
+
+	cLogOpts := withCommitLogOptions(appendableOptions(opts), opts).WithMetadata(txLog.Metadata())
+
+	cLog, err := appFactory(path, "commit", cLogOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cLog.Close()
+
+	txLogSize, err := txLog.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := newTx(maxTxEntries, maxKeyLen, hashAlg)
+
+	// reads are bounded to the restored segments
+	r := appendable.NewReaderFrom(
+		io.NewSectionReader(txLog, 0, txLogSize),
+		0,
+		maxTxSize(maxTxEntries, maxKeyLen, maxTxMetadataLen, maxKVMetadataLen),
+	)
+
+	manifest := &BackupManifest{Alh: hashAlg.Sum(nil)}
+
+	cb := make([]byte, cLogEntrySize)
+
+	for txID == 0 || manifest.TxID < txID {
+		txOff := r.ReadOffset()
+
+		// the last archived transaction may span over a segment not archived yet
+		err = tx.readFrom(r)
+		if err != nil {
+			break
+		}
+
+		if tx.header.ID != manifest.TxID+1 || tx.header.PrevAlh != manifest.Alh {
+			break
+		}
+
+		restorable := true
+
+		for _, e := range tx.Entries() {
+			restorable, err = valueAvailable(e)
+			if err != nil {
+				return nil, err
+			}
+			if !restorable {
+				break
+			}
+		}
+
+		if !restorable {
+			break
+		}
+
+		binary.BigEndian.PutUint64(cb, uint64(txOff))
+		binary.BigEndian.PutUint32(cb[txIDSize:], uint32(r.ReadOffset()-txOff))
+
+		_, _, err = cLog.Append(cb)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.TxID = tx.header.ID
+		manifest.Alh = tx.header.Alh()
+	}
+
+	if manifest.TxID == 0 {
+		return nil, fmt.Errorf("%w: no transaction can be restored", ErrInvalidArchive)
+	}
+
+	if txID > manifest.TxID {
+		return nil, fmt.Errorf("%w: tx %d is not included in the archive", ErrIllegalArguments, txID)
+	}
+
+	err = cLog.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, cLog.Close()
+}
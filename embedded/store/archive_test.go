@@ -0,0 +1,207 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreArchive(t *testing.T) {
+	defer os.RemoveAll("data_archive")
+	defer os.RemoveAll("data_archive_segments")
+	defer os.RemoveAll("data_archive_restored")
+	defer os.RemoveAll("data_archive_restored_upto")
+
+	archive := NewDirArchive("data_archive_segments", DefaultFileMode)
+
+	opts := DefaultOptions().
+		WithMaxConcurrency(5).
+		WithFileSize(1024).
+		WithArchive(archive).
+		WithArchiveFrequency(time.Hour)
+
+	immuStore, err := Open("data_archive", opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%050d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	n, err := immuStore.ArchiveSegments()
+	require.NoError(t, err)
+	require.Greater(t, n, 0)
+
+	// segments are archived only once
+	n, err = immuStore.ArchiveSegments()
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	segments, err := archive.Segments()
+	require.NoError(t, err)
+	require.Contains(t, segments, "tx/00000000.tx")
+	require.Contains(t, segments, "val_0/00000000.val")
+
+	for _, name := range segments {
+		require.NotContains(t, name, "commit")
+	}
+
+	alhs := make(map[uint64][32]byte)
+
+	tx := immuStore.NewTxHolder()
+
+	for i := uint64(1); i <= immuStore.TxCount(); i++ {
+		err = immuStore.ReadTx(i, tx)
+		require.NoError(t, err)
+
+		alhs[i] = tx.header.Alh()
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	_, err = immuStore.ArchiveSegments()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	t.Run("restore every archived transaction", func(t *testing.T) {
+		_, err := RestoreArchive("data_archive", archive, opts)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		manifest, err := RestoreArchive("data_archive_restored", archive, opts)
+		require.NoError(t, err)
+		require.Greater(t, manifest.TxID, uint64(0))
+		require.Less(t, manifest.TxID, uint64(100))
+		require.Equal(t, alhs[manifest.TxID], manifest.Alh)
+
+		restoredStore, err := Open("data_archive_restored", DefaultOptions())
+		require.NoError(t, err)
+		defer restoredStore.Close()
+
+		require.Equal(t, manifest.TxID, restoredStore.TxCount())
+
+		err = restoredStore.WaitForIndexingUpto(manifest.TxID, nil)
+		require.NoError(t, err)
+
+		for i := 0; i < int(manifest.TxID); i++ {
+			valRef, err := restoredStore.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%050d", i)), val)
+		}
+
+		tx := restoredStore.NewTxHolder()
+
+		for i := uint64(1); i <= manifest.TxID; i++ {
+			err = restoredStore.CheckTx(i, tx)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("restore up to a transaction", func(t *testing.T) {
+		manifest, err := RestoreArchiveUpTo("data_archive_restored_upto", archive, opts, 10)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), manifest.TxID)
+		require.Equal(t, alhs[10], manifest.Alh)
+
+		restoredStore, err := Open("data_archive_restored_upto", DefaultOptions())
+		require.NoError(t, err)
+
+		require.Equal(t, uint64(10), restoredStore.TxCount())
+
+		err = restoredStore.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("restore a transaction not included in the archive", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "archive")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		_, err = RestoreArchiveUpTo(dir, archive, opts, 100)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("restore an empty archive", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "archive")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		_, err = RestoreArchive(dir, NewDirArchive(dir+"_missing", DefaultFileMode), opts)
+		require.ErrorIs(t, err, ErrInvalidArchive)
+
+		_, err = RestoreArchive(dir, nil, opts)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
+func TestImmudbStoreArchiver(t *testing.T) {
+	defer os.RemoveAll("data_archiver")
+	defer os.RemoveAll("data_archiver_segments")
+
+	archive := NewDirArchive("data_archiver_segments", DefaultFileMode)
+
+	_, err := Open("data_archiver", DefaultOptions().WithArchive(archive).WithArchiveFrequency(0))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	immuStore, err := Open("data_archiver", DefaultOptions().
+		WithFileSize(1024).
+		WithArchive(archive).
+		WithArchiveFrequency(10*time.Millisecond))
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	for i := 0; i < 20; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%050d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		segments, err := archive.Segments()
+		return err == nil && len(segments) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestImmudbStoreArchiveSegmentsWithoutArchive(t *testing.T) {
+	defer os.RemoveAll("data_no_archive")
+
+	immuStore, err := Open("data_no_archive", DefaultOptions())
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	_, err = immuStore.ArchiveSegments()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// WriteRetryDelay is the suggested delay before retrying a commit rejected with ErrWriteBackpressure
+const WriteRetryDelay = 100 * time.Millisecond
+
+var metricsBackpressureRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_write_backpressure_rejections",
+	Help: "Number of commits rejected because indexing or syncing can not keep up with writes",
+}, []string{
+	"db",
+	"reason",
+})
+
+// admitCommit reserves a slot in the commit queue, ErrWriteBackpressure is returned when the
+// commit queue is full or when indexing lags too far behind. Admitted commits must be released.
+func (s *ImmuStore) admitCommit() error {
+	depth := atomic.AddInt32(&s.commitQueueDepth, 1)
+
+	if s.maxCommitQueueDepth > 0 && int(depth) > s.maxCommitQueueDepth {
+		atomic.AddInt32(&s.commitQueueDepth, -1)
+		s.metricsCommitQueueRejections.Inc()
+		return fmt.Errorf("%w: commit queue depth limit (%d) reached", ErrWriteBackpressure, s.maxCommitQueueDepth)
+	}
+
+	if s.maxIndexingLag > 0 && s.IndexingLag() > uint64(s.maxIndexingLag) {
+		atomic.AddInt32(&s.commitQueueDepth, -1)
+		s.metricsIndexingLagRejections.Inc()
+		return fmt.Errorf("%w: indexing lag limit (%d transactions) exceeded", ErrWriteBackpressure, s.maxIndexingLag)
+	}
+
+	return nil
+}
+
+func (s *ImmuStore) releaseCommit() {
+	atomic.AddInt32(&s.commitQueueDepth, -1)
+}
+
+// CommitQueueDepth returns the number of commits in progress
+func (s *ImmuStore) CommitQueueDepth() int {
+	return int(atomic.LoadInt32(&s.commitQueueDepth))
+}
@@ -0,0 +1,827 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrBackupUnsupported = errors.New("backup is unsupported when remote storage is used")
+var ErrInvalidBackup = errors.New("invalid backup")
+var ErrBackupChainMismatch = errors.New("backup does not chain to the previous one")
+var ErrUnsupportedBackupVersion = errors.New("unsupported backup version")
+
+const backupManifestName = "backup.manifest"
+
+// backupVersion is the version of the manifests written by this version of the store,
+// manifests written by older versions are upgraded when read
+const backupVersion = 2
+
+const backupManifestV1Size = 1 + 2*(txIDSize+sha256.Size)
+const backupManifestSize = backupManifestV1Size + 2*sszSize
+
+// backupManifestDecoders holds a decoder for every supported version of the manifest
+var backupManifestDecoders = map[byte]func(b []byte) (*BackupManifest, error){
+	1: decodeBackupManifestV1,
+	2: decodeBackupManifestV2,
+}
+
+const backupTxsDirname = "txs"
+
+// BackupManifest identifies the transaction a backup was pinned at. Incremental backups
+// chain to the previous backup through the id and Alh of the transaction they start after,
+// which are zero for full backups
+type BackupManifest struct {
+	SinceTxID uint64
+	SinceAlh  [sha256.Size]byte
+	TxID      uint64
+	Alh       [sha256.Size]byte
+
+	// StoreVersion is the version of the store data files included in full backups
+	StoreVersion int
+	// TxHeaderVersion is the version of the headers of the backed up transactions
+	TxHeaderVersion int
+}
+
+// IsIncremental returns true when the backup only includes transactions after a previous backup
+func (m *BackupManifest) IsIncremental() bool {
+	return m.SinceTxID > 0
+}
+
+func (m *BackupManifest) Bytes() []byte {
+	var b [backupManifestSize]byte
+	i := 0
+
+	b[i] = backupVersion
+	i++
+
+	binary.BigEndian.PutUint64(b[i:], m.SinceTxID)
+	i += txIDSize
+
+	copy(b[i:], m.SinceAlh[:])
+	i += sha256.Size
+
+	binary.BigEndian.PutUint64(b[i:], m.TxID)
+	i += txIDSize
+
+	copy(b[i:], m.Alh[:])
+	i += sha256.Size
+
+	binary.BigEndian.PutUint16(b[i:], uint16(m.StoreVersion))
+	i += sszSize
+
+	binary.BigEndian.PutUint16(b[i:], uint16(m.TxHeaderVersion))
+
+	return b[:]
+}
+
+// Backup writes a consistent copy of the store into w as a tar stream while it keeps serving
+// reads and writes. Backed up data is pinned at the last transaction committed when the commit
+// log is captured: commit logs are always copied before the logs they refer to, so the remaining
+// logs only need to be copied up to their current size. The binary linking tree is not included,
+// it's rebuilt from the transaction log when the restored store is opened.
+func (s *ImmuStore) Backup(w io.Writer) (*BackupManifest, error) {
+	if w == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if s.compactionDisabled {
+		return nil, ErrBackupUnsupported
+	}
+
+	// reclaiming values rewrites already copied ranges of the value logs
+	s.gcMutex.Lock()
+	defer s.gcMutex.Unlock()
+
+	tw := tar.NewWriter(w)
+
+	entries, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		err = backupFile(tw, filepath.Join(s.path, e.Name()), e.Name(), -1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the index may lag behind but it never gets ahead of the commit log captured below
+	err = backupDir(tw, s.path, indexDirname)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, cLogFiles, err := s.pinCommitLog()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range cLogFiles {
+		err = backupFile(tw, filepath.Join(s.path, f.name), f.name, f.size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == indexDirname || e.Name() == ahtDirname || e.Name() == "commit" {
+			continue
+		}
+
+		err = backupDir(tw, s.path, e.Name())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = backupBytes(tw, backupManifestName, manifest.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// IncrementalBackup writes into w the transactions committed after the last one included in
+// a previous backup, up to the last transaction committed when the backup starts.
+// The manifest of the previous backup is required so the chain of backups can be checked
+// both when taking and when restoring the incremental backup.
+func (s *ImmuStore) IncrementalBackup(w io.Writer, since *BackupManifest) (*BackupManifest, error) {
+	if w == nil || since == nil || since.TxID == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	txID, alh, _ := s.commitState()
+
+	if since.TxID > txID {
+		return nil, fmt.Errorf("%w: previous backup is ahead of the store", ErrBackupChainMismatch)
+	}
+
+	tx := s.NewTxHolder()
+
+	err := s.ReadTx(since.TxID, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.header.Alh() != since.Alh {
+		return nil, ErrBackupChainMismatch
+	}
+
+	manifest := &BackupManifest{
+		SinceTxID:       since.TxID,
+		SinceAlh:        since.Alh,
+		TxID:            txID,
+		Alh:             alh,
+		StoreVersion:    Version,
+		TxHeaderVersion: TxHeaderVersion,
+	}
+
+	tw := tar.NewWriter(w)
+
+	// the manifest goes first so the chain is checked before restoring any transaction
+	err = backupBytes(tw, backupManifestName, manifest.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	for id := since.TxID + 1; id <= txID; id++ {
+		etx, err := s.ExportTx(id, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		err = backupBytes(tw, path.Join(backupTxsDirname, fmt.Sprintf("%020d", id)), etx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func backupBytes(tw *tar.Writer, name string, b []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(b)),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(b)
+	return err
+}
+
+type backupFileSize struct {
+	name string
+	size int64
+}
+
+// pinCommitLog syncs committed data and captures the size of the commit log files while
+// commits are blocked, so exactly the transactions committed so far are included in the backup
+func (s *ImmuStore) pinCommitLog() (*BackupManifest, []backupFileSize, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return nil, nil, ErrAlreadyClosed
+	}
+
+	err := s.syncLogs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txID, alh, _ := s.commitState()
+
+	files, err := ioutil.ReadDir(filepath.Join(s.path, "commit"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sizes []backupFileSize
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		sizes = append(sizes, backupFileSize{
+			name: path.Join("commit", f.Name()),
+			size: f.Size(),
+		})
+	}
+
+	return &BackupManifest{
+		TxID:            txID,
+		Alh:             alh,
+		StoreVersion:    Version,
+		TxHeaderVersion: TxHeaderVersion,
+	}, sizes, nil
+}
+
+// backupDir copies the content of a folder, commit logs are copied before any sibling
+// so the copied data referenced by them is complete
+func backupDir(tw *tar.Writer, root, name string) error {
+	entries, err := ioutil.ReadDir(filepath.Join(root, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.HasPrefix(entries[i].Name(), "commit") && !strings.HasPrefix(entries[j].Name(), "commit")
+	})
+
+	for _, e := range entries {
+		entryName := path.Join(name, e.Name())
+
+		if e.IsDir() {
+			err = backupDir(tw, root, entryName)
+		} else {
+			err = backupFile(tw, filepath.Join(root, filepath.FromSlash(entryName)), entryName, -1)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backupFile copies up to size bytes of a file, or its current content when size is negative.
+// Files removed in the meantime (i.e. discarded by truncation) are skipped
+func backupFile(tw *tar.Writer, filename, name string, size int64) error {
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if size < 0 {
+		finfo, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		size = finfo.Size()
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0600,
+		Size:     size,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(tw, f, size)
+	return err
+}
+
+// RestoreBackup extracts a backup produced by Backup into path, which must not exist or be empty.
+// The restored store is opened with the provided options to check it holds the backed up transactions.
+func RestoreBackup(path string, r io.Reader, opts *Options) (*BackupManifest, error) {
+	return RestoreBackupUpTo(path, r, opts, 0)
+}
+
+// RestoreBackupUpTo is like RestoreBackup but the restored store holds the transactions up to txID,
+// the ones committed after it are truncated from the restored copy. The returned manifest
+// describes the restored store. All the transactions in the backup are restored when txID is zero.
+func RestoreBackupUpTo(path string, r io.Reader, opts *Options, txID uint64) (*BackupManifest, error) {
+	if r == nil || !validOptions(opts) {
+		return nil, ErrIllegalArguments
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("%w: restore path '%s' is not empty", ErrIllegalArguments, path)
+	}
+
+	err = os.MkdirAll(path, opts.FileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest *BackupManifest
+
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if manifest != nil || hdr.Typeflag != tar.TypeReg {
+			return nil, ErrInvalidBackup
+		}
+
+		if hdr.Name == backupManifestName {
+			manifest, err = readBackupManifest(tr)
+			if err != nil {
+				return nil, err
+			}
+
+			if manifest.IsIncremental() {
+				return nil, fmt.Errorf("%w: incremental backups must be restored on top of the previous one", ErrInvalidBackup)
+			}
+
+			continue
+		}
+
+		err = restoreFile(path, hdr.Name, tr, opts.FileMode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest == nil {
+		return nil, ErrInvalidBackup
+	}
+
+	if txID > manifest.TxID {
+		return nil, fmt.Errorf("%w: tx %d is not included in the backup", ErrIllegalArguments, txID)
+	}
+
+	st, err := Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	committedTxID, committedAlh, _ := st.commitState()
+
+	if committedTxID != manifest.TxID || committedAlh != manifest.Alh {
+		st.Close()
+		return nil, fmt.Errorf("%w: restored store does not match the backup manifest", ErrInvalidBackup)
+	}
+
+	if txID == 0 || txID == manifest.TxID {
+		return manifest, st.Close()
+	}
+
+	tx := st.NewTxHolder()
+
+	err = st.ReadTx(txID, tx)
+	if err != nil {
+		st.Close()
+		return nil, err
+	}
+
+	err = st.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = truncateCommitLog(path, opts, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the index can not be rolled back, it's built again when it's ahead of the restored transactions
+	if st.indexer.Ts() > txID {
+		err = os.RemoveAll(filepath.Join(path, indexDirname))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	st, err = Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	committedTxID, committedAlh, _ = st.commitState()
+
+	err = st.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if committedTxID != txID || committedAlh != tx.header.Alh() {
+		return nil, fmt.Errorf("%w: restored store does not match the requested transaction", ErrInvalidBackup)
+	}
+
+	return &BackupManifest{
+		TxID:            txID,
+		Alh:             committedAlh,
+		StoreVersion:    manifest.StoreVersion,
+		TxHeaderVersion: manifest.TxHeaderVersion,
+	}, nil
+}
+
+// truncateCommitLog rewrites the commit log of the closed store at path so only the first txCount
+// transactions remain committed, data of later transactions is overwritten as new ones are committed
+func truncateCommitLog(path string, opts *Options, txCount uint64) error {
+	opts, err := openKeyRing(path, opts)
+	if err != nil {
+		return err
+	}
+
+	appFactory := appendableFactory(opts)
+
+	cLog, err := appFactory(path, "commit", withCommitLogOptions(appendableOptions(opts), opts))
+	if err != nil {
+		return err
+	}
+	defer cLog.Close()
+
+	truncatedCLog, err := appFactory(path, "commit_truncated", withCommitLogOptions(appendableOptions(opts), opts))
+	if err != nil {
+		return err
+	}
+	defer truncatedCLog.Close()
+
+	r := io.NewSectionReader(cLog, 0, int64(txCount*cLogEntrySize))
+
+	b := make([]byte, 1024*cLogEntrySize)
+
+	for {
+		n, err := io.ReadFull(r, b)
+		if n > 0 {
+			_, _, aErr := truncatedCLog.Append(b[:n])
+			if aErr != nil {
+				return aErr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err = truncatedCLog.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = truncatedCLog.Close()
+	if err != nil {
+		return err
+	}
+
+	err = cLog.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(filepath.Join(path, "commit"))
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(filepath.Join(path, "commit_truncated"), filepath.Join(path, "commit"))
+}
+
+// RestoreIncrementalBackup applies an incremental backup on top of the store at path,
+// which must hold exactly the transactions included up to the previous backup
+func RestoreIncrementalBackup(path string, r io.Reader, opts *Options) (*BackupManifest, error) {
+	return RestoreIncrementalBackupUpTo(path, r, opts, 0)
+}
+
+// RestoreIncrementalBackupUpTo is like RestoreIncrementalBackup but transactions after txID
+// are not restored. The returned manifest describes the restored store.
+// All the transactions in the backup are restored when txID is zero.
+func RestoreIncrementalBackupUpTo(path string, r io.Reader, opts *Options, txID uint64) (*BackupManifest, error) {
+	if r == nil || opts == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	// binary linking is done synchronously so it's always up to date with
+	// the linking of the next restored transaction
+	restoreOpts := *opts
+	restoreOpts.MaxLinearProofLen = 0
+
+	st, err := Open(path, &restoreOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := st.restoreIncrementalBackup(r, txID)
+	if err != nil {
+		st.Close()
+		return nil, err
+	}
+
+	return manifest, st.Close()
+}
+
+func (s *ImmuStore) restoreIncrementalBackup(r io.Reader, uptoTxID uint64) (*BackupManifest, error) {
+	tr := tar.NewReader(r)
+
+	hdr, err := tr.Next()
+	if err == io.EOF || (err == nil && hdr.Name != backupManifestName) {
+		return nil, ErrInvalidBackup
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := readBackupManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !manifest.IsIncremental() {
+		return nil, fmt.Errorf("%w: not an incremental backup", ErrInvalidBackup)
+	}
+
+	if uptoTxID == 0 {
+		uptoTxID = manifest.TxID
+	}
+
+	if uptoTxID < manifest.SinceTxID || uptoTxID > manifest.TxID {
+		return nil, fmt.Errorf("%w: tx %d is not included in the backup", ErrIllegalArguments, uptoTxID)
+	}
+
+	txID, alh, _ := s.commitState()
+
+	if txID != manifest.SinceTxID || alh != manifest.SinceAlh {
+		return nil, ErrBackupChainMismatch
+	}
+
+	for txID < uptoTxID {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg || path.Dir(hdr.Name) != backupTxsDirname {
+			return nil, ErrInvalidBackup
+		}
+
+		etx, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = s.ReplicateTx(etx, false)
+		if err != nil {
+			return nil, err
+		}
+
+		txID, alh, _ = s.commitState()
+	}
+
+	if txID != uptoTxID {
+		return nil, fmt.Errorf("%w: transactions are missing", ErrInvalidBackup)
+	}
+
+	if uptoTxID < manifest.TxID {
+		return &BackupManifest{
+			SinceTxID:       manifest.SinceTxID,
+			SinceAlh:        manifest.SinceAlh,
+			TxID:            txID,
+			Alh:             alh,
+			StoreVersion:    manifest.StoreVersion,
+			TxHeaderVersion: manifest.TxHeaderVersion,
+		}, nil
+	}
+
+	if alh != manifest.Alh {
+		return nil, fmt.Errorf("%w: restored store does not match the backup manifest", ErrInvalidBackup)
+	}
+
+	return manifest, nil
+}
+
+// ReadBackupManifest returns the manifest of a backup, i.e. to take the next incremental backup
+func ReadBackupManifest(r io.Reader) (*BackupManifest, error) {
+	if r == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrInvalidBackup
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Name == backupManifestName {
+			return readBackupManifest(tr)
+		}
+	}
+}
+
+// readBackupManifest reads a manifest of any supported version, returning an error for
+// backups taken by newer versions instead of restoring data it may not understand
+func readBackupManifest(r io.Reader) (*BackupManifest, error) {
+	mb, err := ioutil.ReadAll(io.LimitReader(r, backupManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(mb) == 0 {
+		return nil, ErrInvalidBackup
+	}
+
+	decode, ok := backupManifestDecoders[mb[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w: backup version %d is not supported, the latest supported version is %d",
+			ErrUnsupportedBackupVersion, mb[0], backupVersion)
+	}
+
+	manifest, err := decode(mb)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.StoreVersion > Version || manifest.TxHeaderVersion > TxHeaderVersion {
+		return nil, fmt.Errorf("%w: backup holds data of store version %d and tx header version %d, "+
+			"the latest supported versions are %d and %d",
+			ErrUnsupportedBackupVersion, manifest.StoreVersion, manifest.TxHeaderVersion, Version, TxHeaderVersion)
+	}
+
+	return manifest, nil
+}
+
+func decodeBackupManifestV1(b []byte) (*BackupManifest, error) {
+	if len(b) != backupManifestV1Size {
+		return nil, ErrInvalidBackup
+	}
+
+	manifest := decodeBackupManifestTxs(b[1:])
+
+	// stores writing version 1 manifests only wrote data of the first versions
+	manifest.StoreVersion = 1
+	manifest.TxHeaderVersion = 1
+
+	return manifest, nil
+}
+
+func decodeBackupManifestV2(b []byte) (*BackupManifest, error) {
+	if len(b) != backupManifestSize {
+		return nil, ErrInvalidBackup
+	}
+
+	manifest := decodeBackupManifestTxs(b[1:])
+
+	i := backupManifestV1Size
+
+	manifest.StoreVersion = int(binary.BigEndian.Uint16(b[i:]))
+	i += sszSize
+
+	manifest.TxHeaderVersion = int(binary.BigEndian.Uint16(b[i:]))
+
+	return manifest, nil
+}
+
+// decodeBackupManifestTxs decodes the transactions a manifest refers to, their encoding is shared by all the versions
+func decodeBackupManifestTxs(b []byte) *BackupManifest {
+	i := 0
+
+	manifest := &BackupManifest{}
+
+	manifest.SinceTxID = binary.BigEndian.Uint64(b[i:])
+	i += txIDSize
+
+	copy(manifest.SinceAlh[:], b[i:])
+	i += sha256.Size
+
+	manifest.TxID = binary.BigEndian.Uint64(b[i:])
+	i += txIDSize
+
+	copy(manifest.Alh[:], b[i:])
+
+	return manifest
+}
+
+func restoreFile(root, name string, r io.Reader, dirMode os.FileMode) error {
+	cleanName := path.Clean(name)
+
+	if path.IsAbs(cleanName) || cleanName == ".." || strings.HasPrefix(cleanName, "../") {
+		return fmt.Errorf("%w: illegal file name '%s'", ErrInvalidBackup, name)
+	}
+
+	filename := filepath.Join(root, filepath.FromSlash(cleanName))
+
+	err := os.MkdirAll(filepath.Dir(filename), dirMode)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,488 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreBackup(t *testing.T) {
+	defer os.RemoveAll("data_backup")
+	defer os.RemoveAll("data_backup_restored")
+
+	opts := DefaultOptions().WithMaxConcurrency(5)
+
+	immuStore, err := Open("data_backup", opts)
+	require.NoError(t, err)
+
+	_, err = immuStore.Backup(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	set := func(i int) {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 100; i++ {
+		set(i)
+	}
+
+	err = immuStore.WaitForIndexingUpto(50, nil)
+	require.NoError(t, err)
+
+	// writes keep being served while the backup is taken
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 100; i < 200; i++ {
+			set(i)
+		}
+	}()
+
+	var backup bytes.Buffer
+
+	manifest, err := immuStore.Backup(&backup)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, manifest.TxID, uint64(100))
+
+	wg.Wait()
+
+	tx := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(manifest.TxID, tx)
+	require.NoError(t, err)
+	require.Equal(t, tx.header.Alh(), manifest.Alh)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	_, err = RestoreBackup("data_backup", bytes.NewReader(backup.Bytes()), opts)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	restoredManifest, err := RestoreBackup("data_backup_restored", bytes.NewReader(backup.Bytes()), opts)
+	require.NoError(t, err)
+	require.Equal(t, manifest, restoredManifest)
+
+	restoredStore, err := Open("data_backup_restored", opts)
+	require.NoError(t, err)
+	defer restoredStore.Close()
+
+	require.Equal(t, manifest.TxID, restoredStore.TxCount())
+
+	err = restoredStore.WaitForIndexingUpto(manifest.TxID, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < int(manifest.TxID); i++ {
+		valRef, err := restoredStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+	}
+
+	_, err = restoredStore.Get([]byte(fmt.Sprintf("key%d", manifest.TxID)))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	// proofs are available once the binary linking tree gets rebuilt
+	sourceTx := restoredStore.NewTxHolder()
+
+	err = restoredStore.ReadTx(1, sourceTx)
+	require.NoError(t, err)
+
+	targetTx := restoredStore.NewTxHolder()
+
+	err = restoredStore.ReadTx(manifest.TxID, targetTx)
+	require.NoError(t, err)
+
+	proof, err := restoredStore.DualProof(sourceTx, targetTx)
+	require.NoError(t, err)
+	require.True(t, VerifyDualProof(proof, 1, manifest.TxID, sourceTx.header.Alh(), manifest.Alh))
+}
+
+func TestRestoreInvalidBackup(t *testing.T) {
+	defer os.RemoveAll("data_backup_invalid")
+
+	_, err := RestoreBackup("data_backup_invalid", nil, DefaultOptions())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	var missingManifest bytes.Buffer
+
+	tw := tar.NewWriter(&missingManifest)
+	require.NoError(t, tw.Close())
+
+	_, err = RestoreBackup("data_backup_invalid", &missingManifest, DefaultOptions())
+	require.ErrorIs(t, err, ErrInvalidBackup)
+
+	var illegalName bytes.Buffer
+
+	tw = tar.NewWriter(&illegalName)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../escaped", Mode: 0600, Size: 0, Typeflag: tar.TypeReg}))
+	require.NoError(t, tw.Close())
+
+	os.RemoveAll("data_backup_invalid")
+
+	_, err = RestoreBackup("data_backup_invalid", &illegalName, DefaultOptions())
+	require.ErrorIs(t, err, ErrInvalidBackup)
+}
+
+func TestImmudbStoreIncrementalBackup(t *testing.T) {
+	defer os.RemoveAll("data_incremental_backup")
+	defer os.RemoveAll("data_incremental_backup_restored")
+
+	opts := DefaultOptions()
+
+	immuStore, err := Open("data_incremental_backup", opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	set := func(from, to int) {
+		for i := from; i < to; i++ {
+			tx, err := immuStore.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+			require.NoError(t, err)
+
+			_, err = tx.Commit()
+			require.NoError(t, err)
+		}
+	}
+
+	set(0, 10)
+
+	var fullBackup bytes.Buffer
+
+	fullManifest, err := immuStore.Backup(&fullBackup)
+	require.NoError(t, err)
+	require.False(t, fullManifest.IsIncremental())
+
+	readManifest, err := ReadBackupManifest(bytes.NewReader(fullBackup.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, fullManifest, readManifest)
+
+	set(10, 20)
+
+	_, err = immuStore.IncrementalBackup(&bytes.Buffer{}, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = immuStore.IncrementalBackup(&bytes.Buffer{}, &BackupManifest{TxID: fullManifest.TxID})
+	require.ErrorIs(t, err, ErrBackupChainMismatch)
+
+	_, err = immuStore.IncrementalBackup(&bytes.Buffer{}, &BackupManifest{TxID: 100})
+	require.ErrorIs(t, err, ErrBackupChainMismatch)
+
+	var incBackup1 bytes.Buffer
+
+	incManifest1, err := immuStore.IncrementalBackup(&incBackup1, fullManifest)
+	require.NoError(t, err)
+	require.True(t, incManifest1.IsIncremental())
+	require.Equal(t, fullManifest.TxID, incManifest1.SinceTxID)
+	require.Equal(t, fullManifest.Alh, incManifest1.SinceAlh)
+	require.Equal(t, uint64(20), incManifest1.TxID)
+
+	set(20, 30)
+
+	var incBackup2 bytes.Buffer
+
+	incManifest2, err := immuStore.IncrementalBackup(&incBackup2, incManifest1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(30), incManifest2.TxID)
+
+	_, err = RestoreBackup("data_incremental_backup_restored", bytes.NewReader(incBackup1.Bytes()), opts)
+	require.ErrorIs(t, err, ErrInvalidBackup)
+
+	os.RemoveAll("data_incremental_backup_restored")
+
+	_, err = RestoreBackup("data_incremental_backup_restored", &fullBackup, opts)
+	require.NoError(t, err)
+
+	_, err = RestoreIncrementalBackup("data_incremental_backup_restored", bytes.NewReader(incBackup2.Bytes()), opts)
+	require.ErrorIs(t, err, ErrBackupChainMismatch)
+
+	manifest, err := RestoreIncrementalBackup("data_incremental_backup_restored", &incBackup1, opts)
+	require.NoError(t, err)
+	require.Equal(t, incManifest1, manifest)
+
+	manifest, err = RestoreIncrementalBackup("data_incremental_backup_restored", &incBackup2, opts)
+	require.NoError(t, err)
+	require.Equal(t, incManifest2, manifest)
+
+	restoredStore, err := Open("data_incremental_backup_restored", opts)
+	require.NoError(t, err)
+	defer restoredStore.Close()
+
+	txID, alh := restoredStore.Alh()
+	require.Equal(t, incManifest2.TxID, txID)
+	require.Equal(t, incManifest2.Alh, alh)
+
+	err = restoredStore.WaitForIndexingUpto(txID, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 30; i++ {
+		valRef, err := restoredStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+	}
+}
+
+func TestImmudbStorePointInTimeRestore(t *testing.T) {
+	defer os.RemoveAll("data_pit_backup")
+	defer os.RemoveAll("data_pit_restored")
+	defer os.RemoveAll("data_pit_restored_incremental")
+
+	opts := DefaultOptions()
+
+	immuStore, err := Open("data_pit_backup", opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	// every key is set twice, so the restored copy must hold the values as of the restored tx
+	set := func(from, to int) {
+		for i := from; i < to; i++ {
+			tx, err := immuStore.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			err = tx.Set([]byte(fmt.Sprintf("key%d", i%10)), nil, []byte(fmt.Sprintf("value%d", i)))
+			require.NoError(t, err)
+
+			_, err = tx.Commit()
+			require.NoError(t, err)
+		}
+	}
+
+	set(0, 20)
+
+	var fullBackup bytes.Buffer
+
+	fullManifest, err := immuStore.Backup(&fullBackup)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), fullManifest.TxID)
+
+	set(20, 30)
+
+	var incBackup bytes.Buffer
+
+	_, err = immuStore.IncrementalBackup(&incBackup, fullManifest)
+	require.NoError(t, err)
+
+	tx := immuStore.NewTxHolder()
+
+	requireRestoredAt := func(path string, txID uint64) {
+		st, err := Open(path, opts)
+		require.NoError(t, err)
+		defer st.Close()
+
+		err = immuStore.ReadTx(txID, tx)
+		require.NoError(t, err)
+
+		restoredTxID, alh := st.Alh()
+		require.Equal(t, txID, restoredTxID)
+		require.Equal(t, tx.header.Alh(), alh)
+
+		err = st.WaitForIndexingUpto(txID, nil)
+		require.NoError(t, err)
+
+		for i := int(txID) - 10; i < int(txID); i++ {
+			valRef, err := st.Get([]byte(fmt.Sprintf("key%d", i%10)))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+		}
+
+		// transactions can be committed on top of the restored ones
+		otx, err := st.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = otx.Set([]byte("key0"), nil, []byte("new value"))
+		require.NoError(t, err)
+
+		hdr, err := otx.Commit()
+		require.NoError(t, err)
+		require.Equal(t, txID+1, hdr.ID)
+
+		err = st.ReadTx(txID+1, st.NewTxHolder())
+		require.NoError(t, err)
+	}
+
+	_, err = RestoreBackupUpTo("data_pit_restored", bytes.NewReader(fullBackup.Bytes()), opts, 21)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	os.RemoveAll("data_pit_restored")
+
+	manifest, err := RestoreBackupUpTo("data_pit_restored", bytes.NewReader(fullBackup.Bytes()), opts, 12)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), manifest.TxID)
+
+	requireRestoredAt("data_pit_restored", 12)
+
+	_, err = RestoreBackup("data_pit_restored_incremental", bytes.NewReader(fullBackup.Bytes()), opts)
+	require.NoError(t, err)
+
+	_, err = RestoreIncrementalBackupUpTo("data_pit_restored_incremental", bytes.NewReader(incBackup.Bytes()), opts, 12)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	manifest, err = RestoreIncrementalBackupUpTo("data_pit_restored_incremental", bytes.NewReader(incBackup.Bytes()), opts, 25)
+	require.NoError(t, err)
+	require.Equal(t, uint64(25), manifest.TxID)
+
+	requireRestoredAt("data_pit_restored_incremental", 25)
+}
+
+// withManifest returns a copy of the backup whose manifest is replaced by the given bytes
+func withManifest(t *testing.T, backup []byte, manifest []byte) *bytes.Buffer {
+	var b bytes.Buffer
+
+	tr := tar.NewReader(bytes.NewReader(backup))
+	tw := tar.NewWriter(&b)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+
+		if hdr.Name == backupManifestName {
+			content = manifest
+			hdr.Size = int64(len(manifest))
+		}
+
+		require.NoError(t, tw.WriteHeader(hdr))
+
+		_, err = tw.Write(content)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+
+	return &b
+}
+
+func TestBackupManifestVersions(t *testing.T) {
+	defer os.RemoveAll("data_backup_versions")
+	defer os.RemoveAll("data_backup_versions_restored")
+
+	opts := DefaultOptions()
+
+	immuStore, err := Open("data_backup_versions", opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	set := func(from, to int) {
+		for i := from; i < to; i++ {
+			tx, err := immuStore.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+			require.NoError(t, err)
+
+			_, err = tx.Commit()
+			require.NoError(t, err)
+		}
+	}
+
+	set(0, 10)
+
+	var fullBackup bytes.Buffer
+
+	fullManifest, err := immuStore.Backup(&fullBackup)
+	require.NoError(t, err)
+	require.Equal(t, Version, fullManifest.StoreVersion)
+	require.Equal(t, TxHeaderVersion, fullManifest.TxHeaderVersion)
+
+	set(10, 20)
+
+	var incBackup bytes.Buffer
+
+	incManifest, err := immuStore.IncrementalBackup(&incBackup, fullManifest)
+	require.NoError(t, err)
+
+	t.Run("backups with a version 1 manifest are restored", func(t *testing.T) {
+		v1 := fullManifest.Bytes()[:backupManifestV1Size]
+		v1[0] = 1
+
+		readManifest, err := ReadBackupManifest(withManifest(t, fullBackup.Bytes(), v1))
+		require.NoError(t, err)
+		require.Equal(t, fullManifest, readManifest)
+
+		manifest, err := RestoreBackup("data_backup_versions_restored", withManifest(t, fullBackup.Bytes(), v1), opts)
+		require.NoError(t, err)
+		require.Equal(t, fullManifest.TxID, manifest.TxID)
+
+		incV1 := incManifest.Bytes()[:backupManifestV1Size]
+		incV1[0] = 1
+
+		manifest, err = RestoreIncrementalBackup("data_backup_versions_restored", withManifest(t, incBackup.Bytes(), incV1), opts)
+		require.NoError(t, err)
+		require.Equal(t, incManifest, manifest)
+
+		os.RemoveAll("data_backup_versions_restored")
+	})
+
+	t.Run("backups taken by newer versions are rejected", func(t *testing.T) {
+		newer := fullManifest.Bytes()
+		newer[0] = backupVersion + 1
+
+		_, err := ReadBackupManifest(withManifest(t, fullBackup.Bytes(), newer))
+		require.ErrorIs(t, err, ErrUnsupportedBackupVersion)
+
+		_, err = RestoreBackup("data_backup_versions_restored", withManifest(t, fullBackup.Bytes(), newer), opts)
+		require.ErrorIs(t, err, ErrUnsupportedBackupVersion)
+
+		os.RemoveAll("data_backup_versions_restored")
+
+		newerTxs := *fullManifest
+		newerTxs.TxHeaderVersion = TxHeaderVersion + 1
+
+		_, err = RestoreBackup("data_backup_versions_restored", withManifest(t, fullBackup.Bytes(), newerTxs.Bytes()), opts)
+		require.ErrorIs(t, err, ErrUnsupportedBackupVersion)
+
+		os.RemoveAll("data_backup_versions_restored")
+	})
+
+	t.Run("truncated manifests are rejected", func(t *testing.T) {
+		_, err := ReadBackupManifest(withManifest(t, fullBackup.Bytes(), fullManifest.Bytes()[:backupManifestV1Size]))
+		require.ErrorIs(t, err, ErrInvalidBackup)
+	})
+}
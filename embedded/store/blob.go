@@ -0,0 +1,110 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const blobDirname = "blob"
+
+// blobLogID identifies the blob log within value offsets, it can not be used by regular value logs
+// because value log ids are bounded by MaxIOConcurrency < MaxParallelIO when blob storage is enabled
+const blobLogID = byte(MaxParallelIO)
+
+var metricsBlobBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_blob_bytes",
+	Help: "Number of value bytes written into the blob log",
+}, []string{
+	"db",
+})
+
+// blobLogRequired returns true when the blob log needs to be opened, either because
+// blob storage is enabled or because blobs were stored in a previous run
+func blobLogRequired(path string, opts *Options) bool {
+	if opts.BlobThreshold > 0 {
+		return true
+	}
+
+	finfo, err := os.Stat(filepath.Join(path, blobDirname))
+
+	return err == nil && finfo.IsDir()
+}
+
+// checkVLog returns an error if no value log with the given id was opened
+// i.e. blobs are referenced but the store was opened without its blob log
+func (s *ImmuStore) checkVLog(vLogID byte) error {
+	_, ok := s.vLogs[vLogID-1]
+	if !ok {
+		return fmt.Errorf("%w: value log %d is not available", ErrBlobStorageUnavailable, vLogID)
+	}
+
+	return nil
+}
+
+// appendBlobs stores values larger than the blob threshold into the blob log,
+// offsets of remaining values are left unset. Only the blob location and the
+// value digest are kept in the transaction, so blob integrity is proven as
+// for any other value.
+func (s *ImmuStore) appendBlobs(entries []*EntrySpec, offsets []int64) error {
+	if s.blobThreshold == 0 {
+		return nil
+	}
+
+	var blobLog appendable.Appendable
+
+	for i, e := range entries {
+		if len(e.Value) <= s.blobThreshold {
+			continue
+		}
+
+		if blobLog == nil {
+			blobLog = s.fetchVLog(blobLogID)
+			defer s.releaseVLog(blobLogID)
+		}
+
+		off, _, err := blobLog.Append(e.Value)
+		if err != nil {
+			return err
+		}
+
+		offsets[i] = encodeOffset(off, blobLogID)
+
+		s.metricsBlobBytes.Add(float64(len(e.Value)))
+	}
+
+	if blobLog == nil {
+		return nil
+	}
+
+	err := blobLog.Flush()
+	if err != nil {
+		return err
+	}
+
+	if s.synced {
+		return timedSync(s.metricsVLogSyncDuration, blobLog.Sync)
+	}
+
+	return nil
+}
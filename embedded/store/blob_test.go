@@ -0,0 +1,117 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/mocked"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreBlobs(t *testing.T) {
+	defer os.RemoveAll("data_blobs")
+
+	opts := DefaultOptions().WithSynced(false).WithBlobThreshold(16)
+
+	immuStore, err := Open("data_blobs", opts)
+	require.NoError(t, err)
+
+	blobBytes := testutil.ToFloat64(immuStore.metricsBlobBytes)
+
+	smallValue := []byte("small")
+	blobValue := bytes.Repeat([]byte("blob"), 64)
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("small"), nil, smallValue)
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("blob"), nil, blobValue)
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+
+	require.Equal(t, float64(len(blobValue)), testutil.ToFloat64(immuStore.metricsBlobBytes)-blobBytes)
+
+	checkValues := func(st *ImmuStore) {
+		txHolder := st.NewTxHolder()
+
+		err := st.ReadTx(hdr.ID, txHolder)
+		require.NoError(t, err)
+
+		for _, e := range txHolder.Entries() {
+			vLogID, _ := decodeOffset(e.VOff())
+
+			val, err := st.ReadValue(e)
+			require.NoError(t, err)
+			require.Equal(t, e.HVal(), sha256.Sum256(val))
+
+			if bytes.Equal(e.Key(), []byte("blob")) {
+				require.Equal(t, blobLogID, vLogID)
+				require.Equal(t, blobValue, val)
+			} else {
+				require.NotEqual(t, blobLogID, vLogID)
+				require.Equal(t, smallValue, val)
+			}
+		}
+	}
+
+	checkValues(immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	require.DirExists(t, filepath.Join("data_blobs", blobDirname))
+
+	t.Run("blobs remain readable once blob storage is disabled", func(t *testing.T) {
+		immuStore, err := Open("data_blobs", opts.WithBlobThreshold(0))
+		require.NoError(t, err)
+
+		checkValues(immuStore)
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("blob log id is reserved", func(t *testing.T) {
+		_, err := Open("data_blobs", DefaultOptions().WithMaxIOConcurrency(MaxParallelIO))
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("blob log must be provided", func(t *testing.T) {
+		vLog := &mocked.MockedAppendable{}
+
+		_, err := OpenWith("data_blobs", []appendable.Appendable{vLog}, vLog, vLog, DefaultOptions().WithBlobThreshold(16))
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("blobs are unavailable without the blob log", func(t *testing.T) {
+		immuStore.vLogs = map[byte]*refVLog{0: immuStore.vLogs[0]}
+
+		_, err := immuStore.readValueFromLog(make([]byte, len(blobValue)), encodeOffset(0, blobLogID), sha256.Sum256(blobValue))
+		require.ErrorIs(t, err, ErrBlobStorageUnavailable)
+	})
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DurabilityMode determines when committed data is synced to stable storage
+type DurabilityMode string
+
+const (
+	// DurabilitySync syncs every transaction before its commit returns
+	DurabilitySync DurabilityMode = "sync"
+	// DurabilityGroupCommit syncs committed transactions in groups, at most every GroupCommitMaxLatency.
+	// Transactions committed within that window may be lost on a system crash.
+	DurabilityGroupCommit DurabilityMode = "group"
+	// DurabilityOSBuffered leaves syncing to the operating system, unsynced transactions
+	// may be lost on a system crash but not when only the process crashes
+	DurabilityOSBuffered DurabilityMode = "buffered"
+)
+
+const DefaultDurability = DurabilitySync
+const DefaultGroupCommitMaxLatency = 10 * time.Millisecond
+
+var metricsGroupCommitSyncs = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_group_commit_syncs",
+	Help: "Number of syncs of transactions committed in groups",
+}, []string{
+	"db",
+})
+
+var metricsGroupCommitTxs = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_group_commit_trxs",
+	Help: "Number of transactions synced in groups",
+}, []string{
+	"db",
+})
+
+var metricsSyncDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "immudb_log_sync_duration_seconds",
+	Help:    "Latency of syncing store logs to stable storage",
+	Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+}, []string{
+	"db",
+	"log",
+})
+
+// timedSync syncs the log observing the time it took
+func timedSync(o prometheus.Observer, sync func() error) error {
+	start := time.Now()
+	err := sync()
+	o.Observe(time.Since(start).Seconds())
+	return err
+}
+
+// ValidDurabilityMode returns true if the durability mode is supported
+func ValidDurabilityMode(mode DurabilityMode) bool {
+	return mode == DurabilitySync || mode == DurabilityGroupCommit || mode == DurabilityOSBuffered
+}
+
+// durability returns the durability mode in effect, unsynced stores are always OS buffered
+func (opts *Options) durability() DurabilityMode {
+	if !opts.Synced {
+		return DurabilityOSBuffered
+	}
+	return opts.Durability
+}
+
+// Durability returns the durability mode of the store
+func (s *ImmuStore) Durability() DurabilityMode {
+	return s.durability
+}
+
+// startGroupCommit periodically syncs the transactions committed since the previous sync until the store is closed
+func (s *ImmuStore) startGroupCommit(maxLatency time.Duration) {
+	s.groupCommitDone = make(chan struct{})
+
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(maxLatency)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := s.syncCommitted()
+				if err == ErrAlreadyClosed {
+					return
+				}
+				if err != nil {
+					s.log.Warningf("%v: while syncing committed transactions at '%s'", err, s.path)
+				}
+			}
+		}
+	}(s.groupCommitDone)
+}
+
+// syncCommitted syncs the logs when transactions were committed since the last sync
+func (s *ImmuStore) syncCommitted() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrAlreadyClosed
+	}
+
+	committedTxID, _, _ := s.commitState()
+	if committedTxID <= s.syncedTxID {
+		return nil
+	}
+
+	err := s.syncLogs()
+	if err != nil {
+		return err
+	}
+
+	s.metricsGroupCommitSyncs.Inc()
+	s.metricsGroupCommitTxs.Add(float64(committedTxID - s.syncedTxID))
+
+	s.syncedTxID = committedTxID
+
+	return nil
+}
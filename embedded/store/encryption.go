@@ -0,0 +1,95 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/encryption"
+)
+
+// openKeyRing loads the data keys used to encrypt the store, generating them when the store is created.
+// The returned options hold the key ring instead of the key provider and open all the appendables through it.
+func openKeyRing(path string, opts *Options) (*Options, error) {
+	keyRingPath := filepath.Join(path, keyRingFilename)
+
+	_, err := os.Stat(keyRingPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	encrypted := err == nil
+
+	if opts.KeyProvider == nil {
+		if encrypted {
+			return nil, ErrMissingKeyProvider
+		}
+		return opts, nil
+	}
+
+	if !encrypted {
+		// the transaction log is the first appendable being created
+		_, err = os.Stat(filepath.Join(path, "tx"))
+		if err == nil {
+			return nil, ErrEncryptionUnsupported
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if opts.ReadOnly {
+			return nil, ErrReadOnlyStore
+		}
+	}
+
+	keyRing, err := encryption.OpenKeyRing(keyRingPath, opts.KeyProvider, opts.FileMode)
+	if err != nil {
+		return nil, fmt.Errorf("could not open key ring: %w", err)
+	}
+
+	encOpts := *opts
+	encOpts.KeyProvider = nil
+	encOpts.keyRing = keyRing
+	encOpts.appFactory = func(rootPath, subPath string, appOpts *multiapp.Options) (appendable.Appendable, error) {
+		return multiapp.Open(filepath.Join(rootPath, subPath), appOpts.WithKeyRing(keyRing))
+	}
+
+	return &encOpts, nil
+}
+
+// RotateDataKey makes a new data key be used to encrypt the data written from now on,
+// existing data is not re-encrypted and remains readable
+func (s *ImmuStore) RotateDataKey() error {
+	if s.keyRing == nil {
+		return ErrEncryptionNotEnabled
+	}
+
+	return s.keyRing.RotateDataKey()
+}
+
+// RotateMasterKey wraps the data keys with the current master key of the key provider,
+// so master keys previously in use can be retired
+func (s *ImmuStore) RotateMasterKey() error {
+	if s.keyRing == nil {
+		return ErrEncryptionNotEnabled
+	}
+
+	return s.keyRing.RotateMasterKey()
+}
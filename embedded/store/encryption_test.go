@@ -0,0 +1,170 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreEncryption(t *testing.T) {
+	kp, err := encryption.NewStaticKeyProvider(map[string][]byte{
+		"mk1": bytes.Repeat([]byte{1}, encryption.KeySize),
+		"mk2": bytes.Repeat([]byte{2}, encryption.KeySize),
+	}, "mk1")
+	require.NoError(t, err)
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithFileSize(1024).
+		WithIndexOptions(DefaultIndexOptions().WithFlushThld(10).WithCompactionThld(1)).
+		WithKeyProvider(kp)
+
+	immuStore, err := Open("data_encryption", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_encryption")
+
+	commit := func(st *ImmuStore, from, to int) {
+		for i := from; i < to; i++ {
+			tx, err := st.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("secret-value%08d", i)))
+			require.NoError(t, err)
+
+			_, err = tx.Commit()
+			require.NoError(t, err)
+		}
+	}
+
+	requireValues := func(st *ImmuStore, count int) {
+		err := st.WaitForIndexingUpto(uint64(count), nil)
+		require.NoError(t, err)
+
+		for i := 0; i < count; i++ {
+			valRef, err := st.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("secret-value%08d", i)), val)
+		}
+	}
+
+	commit(immuStore, 0, 50)
+
+	err = immuStore.RotateDataKey()
+	require.NoError(t, err)
+
+	commit(immuStore, 50, 100)
+
+	requireValues(immuStore, 100)
+
+	err = immuStore.CompactIndex()
+	require.NoError(t, err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	// neither values nor keys are written in plain
+	err = filepath.Walk("data_encryption", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		require.False(t, bytes.Contains(b, []byte("secret-value")), path)
+		require.False(t, bytes.Contains(b, []byte("key1")), path)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	kp, err = encryption.NewStaticKeyProvider(map[string][]byte{
+		"mk1": bytes.Repeat([]byte{1}, encryption.KeySize),
+		"mk2": bytes.Repeat([]byte{2}, encryption.KeySize),
+	}, "mk2")
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_encryption", opts.WithKeyProvider(kp))
+	require.NoError(t, err)
+
+	requireValues(immuStore, 100)
+
+	err = immuStore.RotateMasterKey()
+	require.NoError(t, err)
+
+	commit(immuStore, 100, 110)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	// the first master key is no longer needed
+	kp, err = encryption.NewStaticKeyProvider(map[string][]byte{
+		"mk2": bytes.Repeat([]byte{2}, encryption.KeySize),
+	}, "mk2")
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_encryption", opts.WithKeyProvider(kp))
+	require.NoError(t, err)
+
+	requireValues(immuStore, 110)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	_, err = Open("data_encryption", opts.WithKeyProvider(nil))
+	require.ErrorIs(t, err, ErrMissingKeyProvider)
+}
+
+func TestImmudbStoreEncryptionEdgeCases(t *testing.T) {
+	kp, err := encryption.NewStaticKeyProvider(map[string][]byte{
+		"mk": bytes.Repeat([]byte{1}, encryption.KeySize),
+	}, "mk")
+	require.NoError(t, err)
+
+	_, err = Open("data_encryption_edge_cases", DefaultOptions().WithKeyProvider(kp).WithCompressionFormat(appendable.ZLibCompression))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	immuStore, err := Open("data_encryption_edge_cases", DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("data_encryption_edge_cases")
+
+	err = immuStore.RotateDataKey()
+	require.ErrorIs(t, err, ErrEncryptionNotEnabled)
+
+	err = immuStore.RotateMasterKey()
+	require.ErrorIs(t, err, ErrEncryptionNotEnabled)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	_, err = Open("data_encryption_edge_cases", DefaultOptions().WithKeyProvider(kp))
+	require.ErrorIs(t, err, ErrEncryptionUnsupported)
+}
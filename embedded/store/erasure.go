@@ -0,0 +1,188 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/appendable/singleapp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const erasedValuesFilename = "vlog.erased"
+
+var metricsErasedValues = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_erased_values",
+	Help: "Number of values erased on request",
+}, []string{
+	"db",
+})
+
+// EraseValue overwrites with zeros the value of key written by the transaction txID.
+// Transaction headers and entry digests are kept so proofs still verify,
+// reading an erased value returns ErrValueErased. Erasing an already erased value has no effect.
+// The length of the erased value is returned.
+func (s *ImmuStore) EraseValue(txID uint64, key []byte) (int, error) {
+	if txID == 0 || len(key) == 0 {
+		return 0, ErrIllegalArguments
+	}
+
+	if s.readOnly {
+		return 0, ErrReadOnlyStore
+	}
+
+	err := s.CheckRetentionLock()
+	if err != nil {
+		return 0, err
+	}
+
+	s.erasureMutex.Lock()
+	defer s.erasureMutex.Unlock()
+
+	tx := s.NewTxHolder()
+
+	err = s.ReadTx(txID, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	e, err := tx.EntryOf(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if e.vLen == 0 {
+		return 0, nil
+	}
+
+	if !s.isErased(e.vOff) {
+		// the erasure is recorded before overwriting the value, so a value found damaged
+		// after a crash is still reported as erased and can be erased again
+		err = s.recordErasure(e.vOff)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = s.zeroValueAt(e.vOff, e.vLen)
+	if err != nil {
+		return 0, err
+	}
+
+	s.metricsErasedValues.Inc()
+
+	return e.vLen, nil
+}
+
+func (s *ImmuStore) zeroValueAt(off int64, vLen int) error {
+	vLogID, offset := decodeOffset(off)
+
+	err := s.checkVLog(vLogID)
+	if err != nil {
+		return err
+	}
+
+	vLog := s.fetchVLog(vLogID)
+	defer s.releaseVLog(vLogID)
+
+	ow, ok := vLog.(valueOverwriter)
+	if !ok {
+		return ErrErasureUnsupported
+	}
+
+	_, err = ow.WriteAt(make([]byte, vLen), offset)
+	if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed {
+		return ErrAlreadyClosed
+	}
+	if err == multiapp.ErrDiscarded {
+		return ErrValueTruncated
+	}
+	if err == singleapp.ErrWriteAtUnsupported {
+		return ErrErasureUnsupported
+	}
+	if err != nil {
+		return err
+	}
+
+	err = vLog.Sync()
+	if err != nil {
+		return err
+	}
+
+	return s.purgeCachedValue(off)
+}
+
+func (s *ImmuStore) isErased(off int64) bool {
+	s.erasedMutex.RLock()
+	defer s.erasedMutex.RUnlock()
+
+	_, ok := s.erased[off]
+	return ok
+}
+
+func (s *ImmuStore) recordErasure(off int64) error {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(off))
+
+	f, err := os.OpenFile(filepath.Join(s.path, erasedValuesFilename), os.O_APPEND|os.O_CREATE|os.O_WRONLY, s.fileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(b[:])
+	if err != nil {
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+
+	s.erasedMutex.Lock()
+	defer s.erasedMutex.Unlock()
+
+	s.erased[off] = struct{}{}
+
+	return nil
+}
+
+// readErasedValues returns the offsets of the erased values
+func readErasedValues(path string) (map[int64]struct{}, error) {
+	erased := make(map[int64]struct{})
+
+	b, err := ioutil.ReadFile(filepath.Join(path, erasedValuesFilename))
+	if os.IsNotExist(err) {
+		return erased, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a partially written record can only be the last one, the value it refers to was not overwritten yet
+	for i := 0; i+8 <= len(b); i += 8 {
+		erased[int64(binary.BigEndian.Uint64(b[i:]))] = struct{}{}
+	}
+
+	return erased, nil
+}
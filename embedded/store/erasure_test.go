@@ -0,0 +1,132 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreEraseValue(t *testing.T) {
+	t.Run("buffered writes", func(t *testing.T) {
+		testEraseValue(t, DefaultOptions().WithSynced(false).WithVLogCacheSize(10))
+	})
+
+	t.Run("direct I/O", func(t *testing.T) {
+		testEraseValue(t, DefaultOptions().WithSynced(false).WithVLogCacheSize(10).WithDirectIO(true))
+	})
+}
+
+func testEraseValue(t *testing.T, opts *Options) {
+	immuStore, err := Open("data_erasure", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_erasure")
+
+	commit := func(kvs ...string) uint64 {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		for i := 0; i < len(kvs); i += 2 {
+			err = tx.Set([]byte(kvs[i]), nil, []byte(kvs[i+1]))
+			require.NoError(t, err)
+		}
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		return hdr.ID
+	}
+
+	txID1 := commit("key1", "value1_1", "key2", "value2_1")
+	txID2 := commit("key1", "value1_2")
+
+	requireValue := func(st *ImmuStore, txID uint64, key, expectedValue string, expectedErr error) {
+		tx := st.NewTxHolder()
+
+		err := st.ReadTx(txID, tx)
+		require.NoError(t, err)
+
+		entry, err := tx.EntryOf([]byte(key))
+		require.NoError(t, err)
+
+		val, err := st.ReadValue(entry)
+		if expectedErr != nil {
+			require.ErrorIs(t, err, expectedErr)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, expectedValue, string(val))
+	}
+
+	// values are cached before being erased
+	requireValue(immuStore, txID1, "key1", "value1_1", nil)
+
+	_, err = immuStore.EraseValue(0, []byte("key1"))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = immuStore.EraseValue(txID1, []byte("key3"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	n, err := immuStore.EraseValue(txID1, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, len("value1_1"), n)
+
+	requireValue(immuStore, txID1, "key1", "", ErrValueErased)
+	requireValue(immuStore, txID1, "key2", "value2_1", nil)
+	requireValue(immuStore, txID2, "key1", "value1_2", nil)
+
+	// erasing again has no effect
+	_, err = immuStore.EraseValue(txID1, []byte("key1"))
+	require.NoError(t, err)
+
+	// values appended afterwards must not bring the erased one back
+	txID3 := commit("key3", "value3")
+	requireValue(immuStore, txID1, "key1", "", ErrValueErased)
+
+	// proofs over the erased transaction still verify
+	tx1 := immuStore.NewTxHolder()
+	err = immuStore.ReadTx(txID1, tx1)
+	require.NoError(t, err)
+
+	tx2 := immuStore.NewTxHolder()
+	err = immuStore.ReadTx(txID2, tx2)
+	require.NoError(t, err)
+
+	proof, err := immuStore.DualProof(tx1, tx2)
+	require.NoError(t, err)
+	require.True(t, VerifyDualProof(proof, txID1, txID2, tx1.header.Alh(), tx2.header.Alh()))
+
+	// erased values are not reported as corrupted
+	err = immuStore.CheckTx(txID1, immuStore.NewTxHolder())
+	require.NoError(t, err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_erasure", opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	requireValue(immuStore, txID1, "key1", "", ErrValueErased)
+	requireValue(immuStore, txID2, "key1", "value1_2", nil)
+	requireValue(immuStore, txID3, "key3", "value3", nil)
+
+	err = immuStore.CheckTx(txID1, immuStore.NewTxHolder())
+	require.NoError(t, err)
+}
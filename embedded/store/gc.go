@@ -0,0 +1,303 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/appendable/singleapp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const gcStateFilename = "vlog.gc"
+
+// number of transactions processed between persisted garbage collection checkpoints
+const gcCheckpointInterval = 1000
+
+const gcHistoryPageSize = 256
+
+var metricsGCReclaimedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_vlog_gc_reclaimed_bytes",
+	Help: "Number of value bytes reclaimed by garbage collection",
+}, []string{
+	"db",
+})
+
+// GCHolds returns the prefixes of the keys whose values must be kept by garbage collection,
+// e.g. because they are under a legal hold. It's called at the beginning of every collection
+type GCHolds func() ([][]byte, error)
+
+// holePuncher is implemented by value logs able to deallocate ranges of data
+type holePuncher interface {
+	PunchHole(off int64, n int) error
+}
+
+// CollectGarbage reclaims the space used by the values superseded by transactions committed
+// before the safety window. Values are superseded when a newer transaction sets the same key.
+// Transaction headers and entry digests are kept so proofs still verify,
+// reading a reclaimed value returns ErrValueReclaimed. Values of the keys held by the GCHolds option
+// are kept. Only uncompressed value logs are supported.
+// The number of reclaimed bytes is returned, the space actually released by the
+// file system depends on its block size.
+func (s *ImmuStore) CollectGarbage() (int64, error) {
+	if s.compactionDisabled || s.readOnly {
+		return 0, ErrValueGCUnsupported
+	}
+
+	err := s.CheckRetentionLock()
+	if err != nil {
+		return 0, err
+	}
+
+	s.gcMutex.Lock()
+	defer s.gcMutex.Unlock()
+
+	horizonTxID, err := s.firstTxSince(s.timeFunc().Add(-s.gcSafetyWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	// previous versions can only be resolved for indexed transactions
+	uptoTxID := minUint64(horizonTxID-1, s.indexer.Ts())
+
+	gcTxID := atomic.LoadUint64(&s.gcTxID)
+
+	if uptoTxID <= gcTxID {
+		return 0, nil
+	}
+
+	var held [][]byte
+
+	if s.gcHolds != nil {
+		held, err = s.gcHolds()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tx := s.NewTxHolder()
+	prevTx := s.NewTxHolder()
+
+	var reclaimed int64
+
+	for txID := gcTxID + 1; txID <= uptoTxID; txID++ {
+		err := s.ReadTx(txID, tx)
+		if err != nil {
+			return reclaimed, err
+		}
+
+		for _, e := range tx.Entries() {
+			if hasAnyPrefix(e.key(), held) {
+				continue
+			}
+
+			n, err := s.reclaimPreviousVersion(e.key(), txID, prevTx)
+			if err != nil {
+				return reclaimed, err
+			}
+
+			reclaimed += int64(n)
+			s.metricsGCReclaimedBytes.Add(float64(n))
+		}
+
+		if txID == uptoTxID || txID%gcCheckpointInterval == 0 {
+			err = s.setGCTxID(txID)
+			if err != nil {
+				return reclaimed, err
+			}
+		}
+	}
+
+	s.log.Infof("%d bytes of superseded values reclaimed up to tx %d at '%s'", reclaimed, uptoTxID, s.path)
+
+	return reclaimed, nil
+}
+
+// reclaimPreviousVersion reclaims the value of the version of key preceding txID, if any
+func (s *ImmuStore) reclaimPreviousVersion(key []byte, txID uint64, prevTx *Tx) (int, error) {
+	prevTxID, found, err := s.previousVersion(key, txID)
+	if err != nil || !found {
+		return 0, err
+	}
+
+	err = s.ReadTx(prevTxID, prevTx)
+	if err != nil {
+		return 0, err
+	}
+
+	e, err := prevTx.EntryOf(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if e.vLen == 0 {
+		return 0, nil
+	}
+
+	err = s.reclaimValueAt(e.vOff, e.vLen)
+	if err != nil {
+		return 0, err
+	}
+
+	return e.vLen, nil
+}
+
+// previousVersion returns the id of the latest transaction preceding txID which set key
+func (s *ImmuStore) previousVersion(key []byte, txID uint64) (uint64, bool, error) {
+	var offset uint64
+
+	for {
+		txs, err := s.indexer.History(key, offset, true, gcHistoryPageSize)
+		if err == ErrNoMoreEntries {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+
+		for _, t := range txs {
+			if t < txID {
+				return t, true, nil
+			}
+		}
+
+		offset += uint64(len(txs))
+	}
+}
+
+func (s *ImmuStore) reclaimValueAt(off int64, vLen int) error {
+	vLogID, offset := decodeOffset(off)
+
+	err := s.checkVLog(vLogID)
+	if err != nil {
+		return err
+	}
+
+	vLog := s.fetchVLog(vLogID)
+	defer s.releaseVLog(vLogID)
+
+	hp, ok := vLog.(holePuncher)
+	if !ok {
+		return ErrValueGCUnsupported
+	}
+
+	err = hp.PunchHole(offset, vLen)
+	if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed {
+		return ErrAlreadyClosed
+	}
+	if err == singleapp.ErrPunchHoleUnsupported {
+		return ErrValueGCUnsupported
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.purgeCachedValue(off)
+}
+
+func hasAnyPrefix(key []byte, prefixes [][]byte) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isZeroed(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func readGCTxID(path string) (uint64, error) {
+	b, err := ioutil.ReadFile(filepath.Join(path, gcStateFilename))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if len(b) != 8 {
+		return 0, ErrCorruptedData
+	}
+
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (s *ImmuStore) setGCTxID(txID uint64) error {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], txID)
+
+	tmpFilename := filepath.Join(s.path, gcStateFilename+".tmp")
+
+	err := ioutil.WriteFile(tmpFilename, b[:], s.fileMode)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpFilename, filepath.Join(s.path, gcStateFilename))
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreUint64(&s.gcTxID, txID)
+
+	return nil
+}
+
+// startGC periodically reclaims the space used by superseded values until the store is closed
+func (s *ImmuStore) startGC(gcFrequency time.Duration) {
+	s.gcDone = make(chan struct{})
+
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(gcFrequency)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_, err := s.CollectGarbage()
+				if err == ErrAlreadyClosed {
+					return
+				}
+				if errors.Is(err, ErrRetentionLocked) {
+					// values are removed once the retention date is reached
+					continue
+				}
+				if err != nil {
+					s.log.Warningf("%v: while reclaiming superseded values at '%s'", err, s.path)
+				}
+			}
+		}
+	}(s.gcDone)
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreGC(t *testing.T) {
+	now := time.Now()
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithFileSize(64).
+		WithGCSafetyWindow(time.Hour).
+		WithTimeFunc(func() time.Time { return now })
+
+	immuStore, err := Open("data_gc", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_gc")
+
+	commit := func(kvs ...string) uint64 {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		for i := 0; i < len(kvs); i += 2 {
+			err = tx.Set([]byte(kvs[i]), nil, []byte(kvs[i+1]))
+			require.NoError(t, err)
+		}
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		return hdr.ID
+	}
+
+	commit("key1", "value1_1", "key2", "value2_1")
+	commit("key1", "value1_2")
+	lastTxID := commit("key1", "value1_3", "key3", "value3_1")
+
+	err = immuStore.WaitForIndexingUpto(lastTxID, nil)
+	require.NoError(t, err)
+
+	// superseding transactions are within the safety window
+	reclaimed, err := immuStore.CollectGarbage()
+	require.NoError(t, err)
+	require.Zero(t, reclaimed)
+
+	now = now.Add(2 * time.Hour)
+
+	reclaimed, err = immuStore.CollectGarbage()
+	if err == ErrValueGCUnsupported {
+		t.Skip("punching holes is not supported by the underlying file system")
+	}
+	require.NoError(t, err)
+	require.Equal(t, int64(len("value1_1")+len("value1_2")), reclaimed)
+
+	requireValue := func(st *ImmuStore, txID uint64, key, expectedValue string, expectedErr error) {
+		tx := st.NewTxHolder()
+
+		err := st.ReadTx(txID, tx)
+		require.NoError(t, err)
+
+		entry, err := tx.EntryOf([]byte(key))
+		require.NoError(t, err)
+
+		val, err := st.ReadValue(entry)
+		if expectedErr != nil {
+			require.ErrorIs(t, err, expectedErr)
+			return
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, []byte(expectedValue), val)
+	}
+
+	requireValue(immuStore, 1, "key1", "", ErrValueReclaimed)
+	requireValue(immuStore, 1, "key2", "value2_1", nil)
+	requireValue(immuStore, 2, "key1", "", ErrValueReclaimed)
+	requireValue(immuStore, 3, "key1", "value1_3", nil)
+
+	valRef, err := immuStore.Get([]byte("key1"))
+	require.NoError(t, err)
+
+	val, err := valRef.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1_3"), val)
+
+	// proofs over reclaimed transactions still verify
+	tx := immuStore.NewTxHolder()
+	err = immuStore.ReadTx(1, tx)
+	require.NoError(t, err)
+
+	lastTx := immuStore.NewTxHolder()
+	err = immuStore.ReadTx(lastTxID, lastTx)
+	require.NoError(t, err)
+
+	dproof, err := immuStore.DualProof(tx, lastTx)
+	require.NoError(t, err)
+	require.True(t, VerifyDualProof(dproof, 1, lastTxID, tx.Header().Alh(), lastTx.Header().Alh()))
+
+	// values are reclaimed only once
+	reclaimed, err = immuStore.CollectGarbage()
+	require.NoError(t, err)
+	require.Zero(t, reclaimed)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_gc", opts)
+	require.NoError(t, err)
+
+	reclaimed, err = immuStore.CollectGarbage()
+	require.NoError(t, err)
+	require.Zero(t, reclaimed)
+
+	requireValue(immuStore, 1, "key1", "", ErrValueReclaimed)
+	requireValue(immuStore, 1, "key2", "value2_1", nil)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	_, err = immuStore.CollectGarbage()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestImmudbStoreGCUnsupportedWithCompression(t *testing.T) {
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithGCSafetyWindow(0).
+		WithCompressionFormat(appendable.ZLibCompression)
+
+	immuStore, err := Open("data_gc_compressed", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_gc_compressed")
+	defer immuStore.Close()
+
+	for i := 0; i < 2; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte("value"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.WaitForIndexingUpto(2, nil)
+	require.NoError(t, err)
+
+	time.Sleep(1 * time.Second)
+
+	_, err = immuStore.CollectGarbage()
+	require.ErrorIs(t, err, ErrValueGCUnsupported)
+}
+
+func TestImmudbStoreBackgroundGC(t *testing.T) {
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithGCSafetyWindow(0).
+		WithGCFrequency(10 * time.Millisecond)
+
+	immuStore, err := Open("data_background_gc", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_background_gc")
+	defer immuStore.Close()
+
+	for i := 0; i < 2; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte("value"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	tx := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(1, tx)
+	require.NoError(t, err)
+
+	// superseding transactions leave the safety window once a second has elapsed
+	for i := 0; ; i++ {
+		_, err = immuStore.ReadValue(tx.Entries()[0])
+		if err == ErrValueReclaimed {
+			break
+		}
+		require.NoError(t, err)
+
+		if i == 100 {
+			_, err = immuStore.CollectGarbage()
+			if err == ErrValueGCUnsupported {
+				t.Skip("punching holes is not supported by the underlying file system")
+			}
+			require.FailNow(t, "superseded value was not reclaimed")
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestImmudbStoreGCHolds(t *testing.T) {
+	now := time.Now()
+
+	holdsErr := errors.New("holds unavailable")
+	var failHolds bool
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithGCSafetyWindow(time.Hour).
+		WithTimeFunc(func() time.Time { return now }).
+		WithGCHolds(func() ([][]byte, error) {
+			if failHolds {
+				return nil, holdsErr
+			}
+			return [][]byte{[]byte("held")}, nil
+		})
+
+	immuStore, err := Open("data_gc_holds", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_gc_holds")
+	defer immuStore.Close()
+
+	for i := 0; i < 2; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("held_key"), nil, []byte("held_value"))
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte("value"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.WaitForIndexingUpto(2, nil)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+
+	failHolds = true
+
+	_, err = immuStore.CollectGarbage()
+	require.ErrorIs(t, err, holdsErr)
+
+	failHolds = false
+
+	reclaimed, err := immuStore.CollectGarbage()
+	if err == ErrValueGCUnsupported {
+		t.Skip("punching holes is not supported by the underlying file system")
+	}
+	require.NoError(t, err)
+	require.Equal(t, int64(len("value")), reclaimed)
+
+	tx := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(1, tx)
+	require.NoError(t, err)
+
+	entry, err := tx.EntryOf([]byte("held_key"))
+	require.NoError(t, err)
+
+	val, err := immuStore.ReadValue(entry)
+	require.NoError(t, err)
+	require.Equal(t, []byte("held_value"), val)
+
+	entry, err = tx.EntryOf([]byte("key"))
+	require.NoError(t, err)
+
+	_, err = immuStore.ReadValue(entry)
+	require.ErrorIs(t, err, ErrValueReclaimed)
+}
@@ -27,6 +27,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codenotary/immudb/embedded/ahtree"
@@ -34,11 +35,14 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
 	"github.com/codenotary/immudb/embedded/appendable/singleapp"
 	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/multierr"
 	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/codenotary/immudb/embedded/watchers"
 
 	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var ErrIllegalArguments = errors.New("illegal arguments")
@@ -69,12 +73,30 @@ var ErrIllegalState = tbtree.ErrIllegalState
 var ErrOffsetOutOfRange = tbtree.ErrOffsetOutOfRange
 var ErrUnexpectedError = errors.New("unexpected error")
 var ErrUnsupportedTxVersion = errors.New("unsupported tx version")
+var ErrUnsupportedHashAlgorithm = errors.New("unsupported hash algorithm")
 var ErrNewerVersionOrCorruptedData = errors.New("tx created with a newer version or data is corrupted")
 
 var ErrSourceTxNewerThanTargetTx = errors.New("source tx is newer than target tx")
 var ErrLinearProofMaxLenExceeded = errors.New("max linear proof length limit exceeded")
 
 var ErrCompactionUnsupported = errors.New("compaction is unsupported when remote storage is used")
+var ErrIndexRebuildUnsupported = errors.New("index rebuild is unsupported when remote storage is used")
+var ErrTruncationUnsupported = errors.New("truncation is unsupported when remote storage is used")
+var ErrValueTruncated = errors.New("value has been truncated")
+var ErrValueGCUnsupported = errors.New("value garbage collection is unsupported")
+var ErrValueReclaimed = errors.New("value has been reclaimed")
+var ErrValueErased = errors.New("value has been erased")
+var ErrErasureUnsupported = errors.New("value erasure is unsupported")
+var ErrEncryptionNotEnabled = errors.New("encryption is not enabled")
+var ErrMissingKeyProvider = errors.New("store is encrypted, a key provider is required")
+var ErrEncryptionUnsupported = errors.New("encryption can only be enabled when the store is created")
+var ErrRepairUnsupported = errors.New("repair is unsupported")
+var ErrRepairSourceMismatch = errors.New("repair source holds a different transaction")
+var ErrWriteBackpressure = errors.New("writes are throttled, retry later")
+var ErrBlobStorageUnavailable = errors.New("blob storage unavailable")
+var ErrReadOnlyStore = errors.New("store is opened in read-only mode")
+var ErrTxHeaderMismatch = errors.New("tx header does not match the local state")
+var ErrRetentionLocked = errors.New("values can not be removed before the retention date")
 
 const MaxKeyLen = 1024 // assumed to be not lower than hash size
 const MaxParallelIO = 127
@@ -91,15 +113,17 @@ const Version = 1
 const TxHeaderVersion = 1
 
 const (
-	metaVersion      = "VERSION"
-	metaMaxTxEntries = "MAX_TX_ENTRIES"
-	metaMaxKeyLen    = "MAX_KEY_LEN"
-	metaMaxValueLen  = "MAX_VALUE_LEN"
-	metaFileSize     = "FILE_SIZE"
+	metaVersion       = "VERSION"
+	metaMaxTxEntries  = "MAX_TX_ENTRIES"
+	metaMaxKeyLen     = "MAX_KEY_LEN"
+	metaMaxValueLen   = "MAX_VALUE_LEN"
+	metaFileSize      = "FILE_SIZE"
+	metaHashAlgorithm = "HASH_ALGORITHM"
 )
 
 const indexDirname = "index"
 const ahtDirname = "aht"
+const keyRingFilename = "keyring"
 
 type ImmuStore struct {
 	path string
@@ -110,20 +134,30 @@ type ImmuStore struct {
 
 	vLogs            map[byte]*refVLog
 	vLogUnlockedList *list.List
+	blobUnlockedList *list.List
 	vLogsCond        *sync.Cond
 
 	txLog appendable.Appendable
 	cLog  appendable.Appendable
 
-	txLogCache *cache.LRUCache
+	txLogCache        cache.Cache
+	txLogCacheMetrics *cacheMetrics
+
+	vLogCache        cache.Cache // nil when values are not cached
+	vLogCacheMetrics *cacheMetrics
+
+	proofCache cache.Cache // nil when proofs are not cached
 
 	committedTxID      uint64
 	committedAlh       [sha256.Size]byte
 	committedTxLogSize int64
 	commitStateRWMutex sync.RWMutex
 
+	lastCommittedTs int64
+
 	readOnly          bool
-	synced            bool
+	synced            bool // every transaction is synced before its commit returns
+	durability        DurabilityMode
 	maxConcurrency    int
 	maxIOConcurrency  int
 	maxTxEntries      int
@@ -133,6 +167,8 @@ type ImmuStore struct {
 
 	maxTxSize int
 
+	hashAlg hashing.Algorithm
+
 	timeFunc TimeFunc
 
 	_txs     *list.List // pre-allocated txs
@@ -156,6 +192,68 @@ type ImmuStore struct {
 	mutex sync.Mutex
 
 	compactionDisabled bool
+
+	truncationMutex sync.Mutex
+	retentionDone   chan struct{}
+
+	keyRing *encryption.KeyRing
+
+	fileMode       os.FileMode
+	gcSafetyWindow time.Duration
+	gcHolds        GCHolds
+	gcTxID         uint64 // id of the last transaction whose superseded values were reclaimed
+	gcMutex        sync.Mutex
+	gcDone         chan struct{}
+
+	metricsGCReclaimedBytes prometheus.Counter
+
+	syncedTxID      uint64 // id of the last transaction synced by group commit
+	groupCommitDone chan struct{}
+
+	metricsGroupCommitSyncs prometheus.Counter
+	metricsGroupCommitTxs   prometheus.Counter
+
+	scrubberDone      chan struct{}
+	corruptionHandler CorruptionHandler
+
+	metricsScrubbedTxs      prometheus.Counter
+	metricsScrubCorruptions prometheus.Counter
+
+	fileSize     int
+	archive      SegmentArchive
+	archived     map[string]struct{} // names of the segments known to be archived
+	archiveMutex sync.Mutex
+	archiverDone chan struct{}
+
+	metricsArchivedSegments prometheus.Counter
+
+	repairSource      TxFetcher
+	repairSourceMutex sync.RWMutex
+
+	metricsRepairedValues prometheus.Counter
+
+	erased       map[int64]struct{} // offsets of the erased values
+	erasedMutex  sync.RWMutex
+	erasureMutex sync.Mutex
+
+	metricsErasedValues prometheus.Counter
+
+	retainUntil int64 // unix nanoseconds before which no value can be removed, zero when not set
+
+	commitQueueDepth    int32 // number of commits in progress
+	maxCommitQueueDepth int
+	maxIndexingLag      int
+
+	metricsCommitQueueRejections prometheus.Counter
+	metricsIndexingLagRejections prometheus.Counter
+
+	blobThreshold int
+
+	metricsBlobBytes prometheus.Counter
+
+	metricsVLogSyncDuration  prometheus.Observer
+	metricsTxLogSyncDuration prometheus.Observer
+	metricsCLogSyncDuration  prometheus.Observer
 }
 
 type refVLog struct {
@@ -163,6 +261,45 @@ type refVLog struct {
 	unlockedRef *list.Element // unlockedRef == nil <-> vLog is locked
 }
 
+// appendableOptions returns the options shared by every appendable of the store
+func appendableOptions(opts *Options) *multiapp.Options {
+	metadata := appendable.NewMetadata(nil)
+	metadata.PutInt(metaVersion, Version)
+	metadata.PutInt(metaMaxTxEntries, opts.MaxTxEntries)
+	metadata.PutInt(metaMaxKeyLen, opts.MaxKeyLen)
+	metadata.PutInt(metaMaxValueLen, opts.MaxValueLen)
+	metadata.PutInt(metaFileSize, opts.FileSize)
+	metadata.PutInt(metaHashAlgorithm, int(opts.HashAlgorithm))
+
+	return multiapp.DefaultOptions().
+		WithReadOnly(opts.ReadOnly).
+		WithSynced(opts.durability() == DurabilitySync).
+		WithFileSize(opts.FileSize).
+		WithFileMode(opts.FileMode).
+		WithMetadata(metadata.Bytes())
+}
+
+func appendableFactory(opts *Options) AppFactoryFunc {
+	if opts.appFactory != nil {
+		return opts.appFactory
+	}
+
+	return func(rootPath, subPath string, opts *multiapp.Options) (appendable.Appendable, error) {
+		path := filepath.Join(rootPath, subPath)
+		return multiapp.Open(path, opts)
+	}
+}
+
+func withCommitLogOptions(appendableOpts *multiapp.Options, opts *Options) *multiapp.Options {
+	return appendableOpts.
+		WithFileExt("txi").
+		WithCompressionFormat(appendable.NoCompression).
+		WithMaxOpenedFiles(opts.CommitLogMaxOpenedFiles).
+		WithMmap(false).
+		WithPreallocSize(0).
+		WithDirectIO(false)
+}
+
 func Open(path string, opts *Options) (*ImmuStore, error) {
 	if !validOptions(opts) {
 		return nil, ErrIllegalArguments
@@ -170,7 +307,7 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 
 	finfo, err := os.Stat(path)
 	if err != nil {
-		if !os.IsNotExist(err) {
+		if !os.IsNotExist(err) || opts.ReadOnly {
 			return nil, err
 		}
 
@@ -182,40 +319,26 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		return nil, ErrorPathIsNotADirectory
 	}
 
-	metadata := appendable.NewMetadata(nil)
-	metadata.PutInt(metaVersion, Version)
-	metadata.PutInt(metaMaxTxEntries, opts.MaxTxEntries)
-	metadata.PutInt(metaMaxKeyLen, opts.MaxKeyLen)
-	metadata.PutInt(metaMaxValueLen, opts.MaxValueLen)
-	metadata.PutInt(metaFileSize, opts.FileSize)
-
-	appendableOpts := multiapp.DefaultOptions().
-		WithReadOnly(opts.ReadOnly).
-		WithSynced(opts.Synced).
-		WithFileSize(opts.FileSize).
-		WithFileMode(opts.FileMode).
-		WithMetadata(metadata.Bytes())
-
-	appFactory := opts.appFactory
-	if appFactory == nil {
-		appFactory = func(rootPath, subPath string, opts *multiapp.Options) (appendable.Appendable, error) {
-			path := filepath.Join(rootPath, subPath)
-			return multiapp.Open(path, opts)
-		}
+	opts, err = openKeyRing(path, opts)
+	if err != nil {
+		return nil, err
 	}
 
+	appendableOpts := appendableOptions(opts)
+	appFactory := appendableFactory(opts)
+
 	appendableOpts.WithFileExt("tx")
 	appendableOpts.WithCompressionFormat(appendable.NoCompression)
 	appendableOpts.WithMaxOpenedFiles(opts.TxLogMaxOpenedFiles)
+	appendableOpts.WithMmap(opts.Mmap)
+	appendableOpts.WithPreallocSize(opts.PreallocSize)
+	appendableOpts.WithDirectIO(opts.DirectIO)
 	txLog, err := appFactory(path, "tx", appendableOpts)
 	if err != nil {
 		return nil, fmt.Errorf("unable to open transaction log: %w", err)
 	}
 
-	appendableOpts.WithFileExt("txi")
-	appendableOpts.WithCompressionFormat(appendable.NoCompression)
-	appendableOpts.WithMaxOpenedFiles(opts.CommitLogMaxOpenedFiles)
-	cLog, err := appFactory(path, "commit", appendableOpts)
+	cLog, err := appFactory(path, "commit", withCommitLogOptions(appendableOpts, opts))
 	if err != nil {
 		return nil, fmt.Errorf("unable to open commit log: %w", err)
 
@@ -228,6 +351,9 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		appendableOpts.WithCompressionFormat(opts.CompressionFormat)
 		appendableOpts.WithCompresionLevel(opts.CompressionLevel)
 		appendableOpts.WithMaxOpenedFiles(opts.VLogMaxOpenedFiles)
+		appendableOpts.WithMmap(opts.Mmap)
+		appendableOpts.WithPreallocSize(opts.PreallocSize)
+		appendableOpts.WithDirectIO(opts.DirectIO)
 		vLog, err := appFactory(path, fmt.Sprintf("val_%d", i), appendableOpts)
 		if err != nil {
 			return nil, err
@@ -235,14 +361,43 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		vLogs[i] = vLog
 	}
 
-	return OpenWith(path, vLogs, txLog, cLog, opts)
+	var blobLog appendable.Appendable
+
+	if blobLogRequired(path, opts) {
+		if opts.MaxIOConcurrency >= MaxParallelIO {
+			return nil, fmt.Errorf("%w: blob storage requires max IO concurrency below %d", ErrIllegalArguments, MaxParallelIO)
+		}
+
+		appendableOpts.WithSynced(false)
+		appendableOpts.WithFileExt("blob")
+		appendableOpts.WithCompressionFormat(opts.CompressionFormat)
+		appendableOpts.WithCompresionLevel(opts.CompressionLevel)
+		appendableOpts.WithMaxOpenedFiles(opts.VLogMaxOpenedFiles)
+		appendableOpts.WithMmap(opts.Mmap)
+		appendableOpts.WithPreallocSize(opts.PreallocSize)
+		appendableOpts.WithDirectIO(opts.DirectIO)
+		blobLog, err = appFactory(path, blobDirname, appendableOpts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open blob log: %w", err)
+		}
+	}
+
+	return openWith(path, vLogs, blobLog, txLog, cLog, opts)
 }
 
 func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable.Appendable, opts *Options) (*ImmuStore, error) {
+	return openWith(path, vLogs, nil, txLog, cLog, opts)
+}
+
+func openWith(path string, vLogs []appendable.Appendable, blobLog, txLog, cLog appendable.Appendable, opts *Options) (*ImmuStore, error) {
 	if !validOptions(opts) || len(vLogs) == 0 || txLog == nil || cLog == nil {
 		return nil, ErrIllegalArguments
 	}
 
+	if opts.BlobThreshold > 0 && blobLog == nil {
+		return nil, fmt.Errorf("%w: blob threshold set but no blob log provided", ErrIllegalArguments)
+	}
+
 	metadata := appendable.NewMetadata(cLog.Metadata())
 
 	fileSize, ok := metadata.GetInt(metaFileSize)
@@ -266,6 +421,18 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 	}
 
+	// stores created by previous versions do not have a hash algorithm stored
+	hashAlg := hashing.SHA256
+
+	metaHashAlg, ok := metadata.GetInt(metaHashAlgorithm)
+	if ok {
+		hashAlg = hashing.Algorithm(metaHashAlg)
+	}
+
+	if !hashAlg.Supported() {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedHashAlgorithm, hashAlg)
+	}
+
 	cLogSize, err := cLog.Size()
 	if err != nil {
 		return nil, fmt.Errorf("corrupted commit log: could not get size: %w", err)
@@ -313,12 +480,14 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 	// one extra tx pre-allocation for indexing thread
 	for i := 0; i < opts.MaxConcurrency+1; i++ {
-		txs.PushBack(newTx(maxTxEntries, maxKeyLen))
+		txs.PushBack(newTx(maxTxEntries, maxKeyLen, hashAlg))
 	}
 
 	txbs := make([]byte, maxTxSize)
 
-	committedAlh := sha256.Sum256(nil)
+	committedAlh := hashAlg.Sum(nil)
+
+	var lastCommittedTs int64
 
 	if cLogSize > 0 {
 		txReader := appendable.NewReaderFrom(txLog, committedTxOffset, committedTxSize)
@@ -330,6 +499,7 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		}
 
 		committedAlh = tx.header.Alh()
+		lastCommittedTs = tx.header.Ts
 	}
 
 	vLogsMap := make(map[byte]*refVLog, len(vLogs))
@@ -340,13 +510,22 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		vLogsMap[byte(i)] = &refVLog{vLog: vLog, unlockedRef: e}
 	}
 
+	// the blob log is never handed out by fetchAnyVLog, it's kept in its own unlocked list
+	blobLogUnlockedList := list.New()
+
+	if blobLog != nil {
+		e := blobLogUnlockedList.PushBack(blobLogID - 1)
+		vLogsMap[blobLogID-1] = &refVLog{vLog: blobLog, unlockedRef: e}
+	}
+
 	ahtPath := filepath.Join(path, ahtDirname)
 
 	ahtOpts := ahtree.DefaultOptions().
 		WithReadOnly(opts.ReadOnly).
 		WithFileMode(opts.FileMode).
 		WithFileSize(fileSize).
-		WithSynced(opts.Synced) // built from derived data, but temporarily to reduce chances of data inconsistencies
+		WithHashAlgorithm(hashAlg).
+		WithSynced(opts.durability() == DurabilitySync) // built from derived data, but temporarily to reduce chances of data inconsistencies
 
 	if opts.appFactory != nil {
 		ahtOpts.WithAppFactory(func(rootPath, subPath string, appOpts *multiapp.Options) (appendable.Appendable, error) {
@@ -371,26 +550,62 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		blBuffer = make(chan [sha256.Size]byte, opts.MaxLinearProofLen)
 	}
 
-	txLogCache, err := cache.NewLRUCache(opts.TxLogCacheSize)
+	txLogCache, err := cache.NewCache(opts.CacheEvictionPolicy, opts.TxLogCacheSize)
 	if err != nil {
 		return nil, err
 	}
 
+	var vLogCache cache.Cache
+
+	if opts.VLogCacheSize > 0 {
+		vLogCache, err = cache.NewCache(opts.CacheEvictionPolicy, opts.VLogCacheSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var proofCache cache.Cache
+
+	if opts.ProofCacheSize > 0 {
+		proofCache, err = cache.NewCache(opts.CacheEvictionPolicy, opts.ProofCacheSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gcTxID, err := readGCTxID(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read value garbage collection state: %w", err)
+	}
+
+	erased, err := readErasedValues(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read erased values: %w", err)
+	}
+
 	store := &ImmuStore{
 		path:               path,
 		log:                opts.log,
 		txLog:              txLog,
 		txLogCache:         txLogCache,
+		txLogCacheMetrics:  newCacheMetrics(filepath.Base(path), txLogCacheName),
+		vLogCache:          vLogCache,
+		vLogCacheMetrics:   newCacheMetrics(filepath.Base(path), vLogCacheName),
+		proofCache:         proofCache,
 		vLogs:              vLogsMap,
 		vLogUnlockedList:   vLogUnlockedList,
+		blobUnlockedList:   blobLogUnlockedList,
 		vLogsCond:          sync.NewCond(&sync.Mutex{}),
 		cLog:               cLog,
 		committedTxLogSize: committedTxLogSize,
 		committedTxID:      committedTxID,
 		committedAlh:       committedAlh,
 
+		lastCommittedTs: lastCommittedTs,
+
 		readOnly:          opts.ReadOnly,
-		synced:            opts.Synced,
+		synced:            opts.durability() == DurabilitySync,
+		durability:        opts.durability(),
 		maxConcurrency:    opts.MaxConcurrency,
 		maxIOConcurrency:  opts.MaxIOConcurrency,
 		maxTxEntries:      maxTxEntries,
@@ -400,6 +615,8 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 		maxTxSize: maxTxSize,
 
+		hashAlg: hashAlg,
+
 		timeFunc: opts.TimeFunc,
 
 		aht:      aht,
@@ -412,6 +629,50 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		_txbs: txbs,
 
 		compactionDisabled: opts.CompactionDisabled,
+
+		keyRing: opts.keyRing,
+
+		fileMode:       opts.FileMode,
+		gcSafetyWindow: opts.GCSafetyWindow,
+		gcHolds:        opts.GCHolds,
+		gcTxID:         gcTxID,
+
+		metricsGCReclaimedBytes: metricsGCReclaimedBytes.WithLabelValues(filepath.Base(path)),
+
+		syncedTxID: committedTxID,
+
+		metricsGroupCommitSyncs: metricsGroupCommitSyncs.WithLabelValues(filepath.Base(path)),
+		metricsGroupCommitTxs:   metricsGroupCommitTxs.WithLabelValues(filepath.Base(path)),
+
+		metricsScrubbedTxs:      metricsScrubbedTxs.WithLabelValues(filepath.Base(path)),
+		metricsScrubCorruptions: metricsScrubCorruptions.WithLabelValues(filepath.Base(path)),
+
+		corruptionHandler: opts.CorruptionHandler,
+
+		fileSize: fileSize,
+		archive:  opts.Archive,
+
+		metricsArchivedSegments: metricsArchivedSegments.WithLabelValues(filepath.Base(path)),
+
+		metricsRepairedValues: metricsRepairedValues.WithLabelValues(filepath.Base(path)),
+
+		erased:              erased,
+		metricsErasedValues: metricsErasedValues.WithLabelValues(filepath.Base(path)),
+
+		retainUntil: unixNanoOrZero(opts.RetainUntil),
+
+		maxCommitQueueDepth: opts.MaxCommitQueueDepth,
+		maxIndexingLag:      opts.MaxIndexingLag,
+
+		metricsCommitQueueRejections: metricsBackpressureRejections.WithLabelValues(filepath.Base(path), "commit_queue"),
+		metricsIndexingLagRejections: metricsBackpressureRejections.WithLabelValues(filepath.Base(path), "indexing_lag"),
+
+		blobThreshold:    opts.BlobThreshold,
+		metricsBlobBytes: metricsBlobBytes.WithLabelValues(filepath.Base(path)),
+
+		metricsVLogSyncDuration:  metricsSyncDuration.WithLabelValues(filepath.Base(path), "value"),
+		metricsTxLogSyncDuration: metricsSyncDuration.WithLabelValues(filepath.Base(path), "tx"),
+		metricsCLogSyncDuration:  metricsSyncDuration.WithLabelValues(filepath.Base(path), "commit"),
 	}
 
 	err = store.wHub.DoneUpto(committedTxID)
@@ -426,6 +687,7 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		WithFileSize(fileSize).
 		WithSynced(opts.IndexOpts.Synced). // index is built from derived data and can be re-generated
 		WithCacheSize(opts.IndexOpts.CacheSize).
+		WithCacheEvictionPolicy(opts.CacheEvictionPolicy).
 		WithFlushThld(opts.IndexOpts.FlushThld).
 		WithSyncThld(opts.IndexOpts.SyncThld).
 		WithMaxActiveSnapshots(opts.IndexOpts.MaxActiveSnapshots).
@@ -435,7 +697,8 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		WithCommitLogMaxOpenedFiles(opts.IndexOpts.CommitLogMaxOpenedFiles).
 		WithRenewSnapRootAfter(opts.IndexOpts.RenewSnapRootAfter).
 		WithCompactionThld(opts.IndexOpts.CompactionThld).
-		WithDelayDuringCompaction(opts.IndexOpts.DelayDuringCompaction)
+		WithDelayDuringCompaction(opts.IndexOpts.DelayDuringCompaction).
+		WithBloomFilterKeys(opts.IndexOpts.BloomFilterKeys)
 
 	if opts.appFactory != nil {
 		indexOpts.WithAppFactory(func(rootPath, subPath string, appOpts *multiapp.Options) (appendable.Appendable, error) {
@@ -445,11 +708,48 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 	indexPath := filepath.Join(store.path, indexDirname)
 
-	store.indexer, err = newIndexer(indexPath, store, indexOpts, opts.MaxWaitees)
+	store.indexer, err = newIndexer(indexPath, store, indexOpts, opts.MaxWaitees, opts.IndexOpts.IndexingWorkers)
 	if err != nil {
 		return nil, fmt.Errorf("could not open indexer: %w", err)
 	}
 
+	if opts.IndexOpts.AutoCompactionInterval > 0 && !opts.CompactionDisabled && !opts.ReadOnly {
+		store.indexer.startAutoCompaction(opts.IndexOpts.AutoCompactionInterval)
+	}
+
+	if opts.IndexOpts.CheckpointInterval > 0 && !opts.ReadOnly {
+		store.indexer.startCheckpointing(opts.IndexOpts.CheckpointInterval)
+	}
+
+	if opts.ScrubFrequency > 0 {
+		store.startScrubber(opts.ScrubFrequency)
+	}
+
+	if opts.Archive != nil && !opts.ReadOnly {
+		store.startArchiver(opts.ArchiveFrequency)
+	}
+
+	if store.durability == DurabilityGroupCommit && !opts.ReadOnly {
+		store.startGroupCommit(opts.GroupCommitMaxLatency)
+	}
+
+	if opts.RetentionPeriod > 0 && !opts.CompactionDisabled && !opts.ReadOnly {
+		store.startRetention(opts.RetentionPeriod, opts.TruncationFrequency)
+	}
+
+	if opts.GCFrequency > 0 && !opts.CompactionDisabled && !opts.ReadOnly {
+		store.startGC(opts.GCFrequency)
+	}
+
+	// files are left untouched in read-only mode, so they can be inspected while being written by another process
+	if store.readOnly {
+		if store.aht.Size() < store.committedTxID {
+			store.log.Warningf("Binary Linking at '%s' is behind, proofs are limited up to tx %d", path, store.aht.Size())
+		}
+
+		return store, nil
+	}
+
 	if store.aht.Size() > store.committedTxID {
 		err = store.aht.ResetSize(store.committedTxID)
 		if err != nil {
@@ -568,7 +868,7 @@ func (s *ImmuStore) UseTimeFunc(timeFunc TimeFunc) error {
 }
 
 func (s *ImmuStore) NewTxHolder() *Tx {
-	return newTx(s.maxTxEntries, s.maxKeyLen)
+	return newTx(s.maxTxEntries, s.maxKeyLen, s.hashAlg)
 }
 
 func (s *ImmuStore) Snapshot() (*Snapshot, error) {
@@ -682,14 +982,73 @@ func (s *ImmuStore) WaitForTx(txID uint64, cancellation <-chan struct{}) error {
 }
 
 func (s *ImmuStore) WaitForIndexingUpto(txID uint64, cancellation <-chan struct{}) error {
+	if s.readOnly {
+		indexedTxID := s.indexer.Ts()
+		if txID > indexedTxID {
+			return fmt.Errorf("%w: transactions after %d are not indexed", ErrReadOnlyStore, indexedTxID)
+		}
+	}
+
 	return s.indexer.WaitForIndexingUpto(txID, cancellation)
 }
 
+// WaitForIndexing blocks until every transaction committed before the call gets indexed
+func (s *ImmuStore) WaitForIndexing(cancellation <-chan struct{}) error {
+	committedTxID, _, _ := s.commitState()
+	return s.WaitForIndexingUpto(committedTxID, cancellation)
+}
+
+// IndexingLag returns the number of committed transactions not yet indexed
+func (s *ImmuStore) IndexingLag() uint64 {
+	committedTxID, _, _ := s.commitState()
+	indexedTxID := s.indexer.Ts()
+
+	if indexedTxID >= committedTxID {
+		return 0
+	}
+
+	return committedTxID - indexedTxID
+}
+
 func (s *ImmuStore) CompactIndex() error {
+	return s.CompactIndexWithProgress(nil)
+}
+
+// CompactIndexWithProgress compacts the index as CompactIndex does,
+// progress is notified with the number of bytes of the index dumped so far
+func (s *ImmuStore) CompactIndexWithProgress(progress func(dumpedBytes int64)) error {
 	if s.compactionDisabled {
 		return ErrCompactionUnsupported
 	}
-	return s.indexer.CompactIndex()
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+	return s.indexer.CompactIndexWithProgress(progress)
+}
+
+// EstimateIndexCompaction computes the space a compaction of the index would reclaim without compacting it
+func (s *ImmuStore) EstimateIndexCompaction() (*tbtree.CompactionEstimate, error) {
+	if s.compactionDisabled {
+		return nil, ErrCompactionUnsupported
+	}
+	return s.indexer.EstimateCompaction()
+}
+
+// ResizeTxLogCache changes the number of transactions kept in the cache of the transaction log,
+// the least recently used ones are evicted when the cache is shrunk
+func (s *ImmuStore) ResizeTxLogCache(size int) error {
+	if size < 1 {
+		return fmt.Errorf("%w: invalid cache size %d", ErrIllegalArguments, size)
+	}
+
+	s.txLogCache.Resize(size)
+
+	return nil
+}
+
+// TxLogCacheSize returns the number of transactions the cache of the transaction log can hold
+func (s *ImmuStore) TxLogCacheSize() int {
+	return s.txLogCache.Size()
 }
 
 func maxTxSize(maxTxEntries, maxKeyLen, maxTxMetadataLen, maxKVMetadataLen int) int {
@@ -713,6 +1072,12 @@ func maxTxSize(maxTxEntries, maxKeyLen, maxTxMetadataLen, maxKVMetadataLen int)
 		sha256.Size /*txH*/
 }
 
+// HashAlgorithm returns the hash algorithm used to calculate the digests of the store,
+// it is chosen when the store is created and can not be changed afterwards
+func (s *ImmuStore) HashAlgorithm() hashing.Algorithm {
+	return s.hashAlg
+}
+
 func (s *ImmuStore) ReadOnly() bool {
 	return s.readOnly
 }
@@ -798,7 +1163,7 @@ func (s *ImmuStore) fetchVLog(vLogID byte) appendable.Appendable {
 		s.vLogsCond.Wait()
 	}
 
-	s.vLogUnlockedList.Remove(s.vLogs[vLogID-1].unlockedRef)
+	s.unlockedList(vLogID).Remove(s.vLogs[vLogID-1].unlockedRef)
 	s.vLogs[vLogID-1].unlockedRef = nil // locked
 
 	return s.vLogs[vLogID-1].vLog
@@ -806,9 +1171,16 @@ func (s *ImmuStore) fetchVLog(vLogID byte) appendable.Appendable {
 
 func (s *ImmuStore) releaseVLog(vLogID byte) {
 	s.vLogsCond.L.Lock()
-	s.vLogs[vLogID-1].unlockedRef = s.vLogUnlockedList.PushBack(vLogID - 1) // unlocked
+	s.vLogs[vLogID-1].unlockedRef = s.unlockedList(vLogID).PushBack(vLogID - 1) // unlocked
 	s.vLogsCond.L.Unlock()
-	s.vLogsCond.Signal()
+	s.vLogsCond.Broadcast()
+}
+
+func (s *ImmuStore) unlockedList(vLogID byte) *list.List {
+	if vLogID == blobLogID {
+		return s.blobUnlockedList
+	}
+	return s.vLogUnlockedList
 }
 
 type appendableResult struct {
@@ -819,11 +1191,18 @@ type appendableResult struct {
 func (s *ImmuStore) appendData(entries []*EntrySpec, donec chan<- appendableResult) {
 	offsets := make([]int64, len(entries))
 
+	// blobs are appended before fetching a value log so no more than one log is locked at a time
+	err := s.appendBlobs(entries, offsets)
+	if err != nil {
+		donec <- appendableResult{nil, err}
+		return
+	}
+
 	vLogID, vLog := s.fetchAnyVLog()
 	defer s.releaseVLog(vLogID)
 
 	for i := 0; i < len(offsets); i++ {
-		if len(entries[i].Value) == 0 {
+		if len(entries[i].Value) == 0 || offsets[i] != 0 {
 			continue
 		}
 
@@ -835,14 +1214,14 @@ func (s *ImmuStore) appendData(entries []*EntrySpec, donec chan<- appendableResu
 		offsets[i] = encodeOffset(voff, vLogID)
 	}
 
-	err := vLog.Flush()
+	err = vLog.Flush()
 	if err != nil {
 		donec <- appendableResult{nil, err}
 		return
 	}
 
 	if s.synced {
-		err = vLog.Sync()
+		err = timedSync(s.metricsVLogSyncDuration, vLog.Sync)
 		if err != nil {
 			donec <- appendableResult{nil, err}
 			return
@@ -865,11 +1244,26 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 		return nil, ErrIllegalArguments
 	}
 
+	if s.readOnly {
+		return nil, ErrReadOnlyStore
+	}
+
 	err := s.validateEntries(otx.entries)
 	if err != nil {
 		return nil, err
 	}
 
+	err = otx.metadata.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.admitCommit()
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseCommit()
+
 	// early check to reduce amount of garbage when tx is not finally committed
 	s.mutex.Lock()
 	if s.closed {
@@ -888,7 +1282,6 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 	var version int
 
 	if expectedHeader == nil {
-		ts = s.timeFunc().Unix()
 		blTxID = s.aht.Size()
 		version = TxHeaderVersion
 	} else {
@@ -920,12 +1313,18 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 		}
 
 		if currTxID != expectedHeader.ID-1 ||
-			currAlh != expectedHeader.PrevAlh ||
-			blRoot != expectedHeader.BlRoot ||
 			len(otx.entries) != expectedHeader.NEntries {
 			return nil, ErrIllegalArguments
 		}
 
+		if currAlh != expectedHeader.PrevAlh {
+			return nil, fmt.Errorf("%w: previous accumulative linear hash of tx %d does not match", ErrTxHeaderMismatch, expectedHeader.ID)
+		}
+
+		if blRoot != expectedHeader.BlRoot {
+			return nil, fmt.Errorf("%w: binary linking root of tx %d does not match", ErrTxHeaderMismatch, expectedHeader.ID)
+		}
+
 	}
 
 	appendableCh := make(chan appendableResult)
@@ -948,7 +1347,7 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 		txe.setKey(e.Key)
 		txe.md = e.Metadata
 		txe.vLen = len(e.Value)
-		txe.hVal = sha256.Sum256(e.Value)
+		txe.hVal = s.hashAlg.Sum(e.Value)
 	}
 
 	err = tx.BuildHashTree()
@@ -960,7 +1359,7 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 	// TxHeader is validated against current store
 	if expectedHeader != nil && tx.header.Eh != expectedHeader.Eh {
 		<-appendableCh // wait for data to be written
-		return nil, ErrIllegalArguments
+		return nil, fmt.Errorf("%w: entries of tx %d do not match its header", ErrTxHeaderMismatch, expectedHeader.ID)
 	}
 
 	r := <-appendableCh // wait for data to be written
@@ -985,6 +1384,10 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 		tx.entries[i].vOff = r.offsets[i]
 	}
 
+	if expectedHeader == nil {
+		ts = s.commitTs()
+	}
+
 	err = s.performCommit(tx, ts, blTxID)
 	if err != nil {
 		s.mutex.Unlock()
@@ -1003,6 +1406,19 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 	return tx.Header(), nil
 }
 
+// commitTs returns the timestamp of a new transaction. Timestamps are kept non-decreasing
+// so transactions can be looked up by time even if the clock goes backwards.
+// Must be called while holding the store mutex.
+func (s *ImmuStore) commitTs() int64 {
+	ts := s.timeFunc().Unix()
+
+	if ts < s.lastCommittedTs {
+		return s.lastCommittedTs
+	}
+
+	return ts
+}
+
 func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64) error {
 	if s.blErr != nil {
 		return s.blErr
@@ -1122,7 +1538,7 @@ func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64) error {
 		return err
 	}
 
-	_, _, err = s.txLogCache.Put(tx.header.ID, txbs)
+	err = putIntoCache(s.txLogCache, s.txLogCacheMetrics, tx.header.ID, txbs)
 	if err != nil {
 		return err
 	}
@@ -1165,6 +1581,7 @@ func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64) error {
 	}
 
 	committedTxID = s.advanceCommitState(alh, int64(txSize))
+	s.lastCommittedTs = tx.header.Ts
 	s.wHub.DoneUpto(committedTxID)
 
 	return nil
@@ -1189,7 +1606,12 @@ func (s *ImmuStore) commitState() (txID uint64, txAlh [sha256.Size]byte, clogSiz
 }
 
 func (s *ImmuStore) CommitWith(callback func(txID uint64, index KeyIndex) ([]*EntrySpec, error), waitForIndexing bool) (*TxHeader, error) {
-	hdr, err := s.commitWith(callback)
+	return s.CommitWithMetadata(nil, callback, waitForIndexing)
+}
+
+// CommitWithMetadata is like CommitWith but the transaction is committed including the provided metadata
+func (s *ImmuStore) CommitWithMetadata(md *TxMetadata, callback func(txID uint64, index KeyIndex) ([]*EntrySpec, error), waitForIndexing bool) (*TxHeader, error) {
+	hdr, err := s.commitWith(md, callback)
 	if err != nil {
 		return nil, err
 	}
@@ -1221,11 +1643,20 @@ func (index *unsafeIndex) GetWith(key []byte, filters ...FilterFn) (ValueRef, er
 	return index.st.GetWith(key, filters...)
 }
 
-func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*EntrySpec, error)) (*TxHeader, error) {
+func (s *ImmuStore) commitWith(md *TxMetadata, callback func(txID uint64, index KeyIndex) ([]*EntrySpec, error)) (*TxHeader, error) {
 	if callback == nil {
 		return nil, ErrIllegalArguments
 	}
 
+	err := md.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.readOnly {
+		return nil, ErrReadOnlyStore
+	}
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -1260,6 +1691,7 @@ func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*En
 	defer s.releaseAllocTx(tx)
 
 	tx.header.Version = TxHeaderVersion
+	tx.header.Metadata = md
 	tx.header.NEntries = len(entries)
 
 	for i, e := range entries {
@@ -1267,7 +1699,7 @@ func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*En
 		txe.setKey(e.Key)
 		txe.md = e.Metadata
 		txe.vLen = len(e.Value)
-		txe.hVal = sha256.Sum256(e.Value)
+		txe.hVal = s.hashAlg.Sum(e.Value)
 	}
 
 	err = tx.BuildHashTree()
@@ -1286,7 +1718,7 @@ func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*En
 		tx.entries[i].vOff = r.offsets[i]
 	}
 
-	err = s.performCommit(tx, s.timeFunc().Unix(), s.aht.Size())
+	err = s.performCommit(tx, s.commitTs(), s.aht.Size())
 	if err != nil {
 		return nil, err
 	}
@@ -1324,7 +1756,7 @@ func (s *ImmuStore) DualProof(sourceTx, targetTx *Tx) (proof *DualProof, err err
 	}
 
 	if sourceTx.header.ID < targetTx.header.BlTxID {
-		binInclusionProof, err := s.aht.InclusionProof(sourceTx.header.ID, targetTx.header.BlTxID) // must match targetTx.BlRoot
+		binInclusionProof, err := s.ahtInclusionProof(sourceTx.header.ID, targetTx.header.BlTxID) // must match targetTx.BlRoot
 		if err != nil {
 			return nil, err
 		}
@@ -1361,7 +1793,7 @@ func (s *ImmuStore) DualProof(sourceTx, targetTx *Tx) (proof *DualProof, err err
 		proof.TargetBlTxAlh = targetBlTx.header.Alh()
 
 		// Used to validate targetTx.BlRoot is calculated with alh@targetTx.BlTxID as last leaf
-		binLastInclusionProof, err := s.aht.InclusionProof(targetTx.header.BlTxID, targetTx.header.BlTxID) // must match targetTx.BlRoot
+		binLastInclusionProof, err := s.ahtInclusionProof(targetTx.header.BlTxID, targetTx.header.BlTxID) // must match targetTx.BlRoot
 		if err != nil {
 			return nil, err
 		}
@@ -1564,41 +1996,43 @@ func (s *ImmuStore) ExportTx(txID uint64, tx *Tx) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func (s *ImmuStore) ReplicateTx(exportedTx []byte, waitForIndexing bool) (*TxHeader, error) {
+// exportedEntry holds an entry decoded from a transaction produced by ExportTx
+type exportedEntry struct {
+	key   []byte
+	md    *KVMetadata
+	value []byte
+}
+
+func readExportedTx(exportedTx []byte, hashAlg hashing.Algorithm) (*TxHeader, []*exportedEntry, error) {
 	if len(exportedTx) == 0 {
-		return nil, ErrIllegalArguments
+		return nil, nil, ErrIllegalArguments
 	}
 
 	i := 0
 
 	if len(exportedTx) < lszSize {
-		return nil, ErrIllegalArguments
+		return nil, nil, ErrIllegalArguments
 	}
 
 	hdrLen := int(binary.BigEndian.Uint32(exportedTx[i:]))
 	i += lszSize
 
 	if len(exportedTx) < i+hdrLen {
-		return nil, ErrIllegalArguments
+		return nil, nil, ErrIllegalArguments
 	}
 
-	hdr := &TxHeader{}
+	hdr := &TxHeader{HashAlgorithm: hashAlg}
 	err := hdr.ReadFrom(exportedTx[i : i+hdrLen])
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	i += hdrLen
 
-	txSpec, err := s.NewWriteOnlyTx()
-	if err != nil {
-		return nil, err
-	}
-
-	txSpec.metadata = hdr.Metadata
+	entries := make([]*exportedEntry, hdr.NEntries)
 
 	for e := 0; e < hdr.NEntries; e++ {
 		if len(exportedTx) < i+2*sszSize+lszSize {
-			return nil, ErrIllegalArguments
+			return nil, nil, ErrIllegalArguments
 		}
 
 		kLen := int(binary.BigEndian.Uint16(exportedTx[i:]))
@@ -1612,7 +2046,7 @@ func (s *ImmuStore) ReplicateTx(exportedTx []byte, waitForIndexing bool) (*TxHea
 		i += sszSize
 
 		if len(exportedTx) < i+mdLen {
-			return nil, ErrIllegalArguments
+			return nil, nil, ErrIllegalArguments
 		}
 
 		var md *KVMetadata
@@ -1622,7 +2056,7 @@ func (s *ImmuStore) ReplicateTx(exportedTx []byte, waitForIndexing bool) (*TxHea
 
 			err := md.unsafeReadFrom(exportedTx[i : i+mdLen])
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			i += mdLen
 		}
@@ -1631,33 +2065,69 @@ func (s *ImmuStore) ReplicateTx(exportedTx []byte, waitForIndexing bool) (*TxHea
 		i += lszSize
 
 		if len(exportedTx) < i+vLen {
-			return nil, ErrIllegalArguments
+			return nil, nil, ErrIllegalArguments
 		}
 
-		err = txSpec.Set(key, md, exportedTx[i:i+vLen])
-		if err != nil {
-			return nil, err
+		entries[e] = &exportedEntry{
+			key:   key,
+			md:    md,
+			value: exportedTx[i : i+vLen],
 		}
 
 		i += vLen
 	}
 
 	if i != len(exportedTx) {
-		return nil, ErrIllegalArguments
+		return nil, nil, ErrIllegalArguments
+	}
+
+	return hdr, entries, nil
+}
+
+func (s *ImmuStore) ReplicateTx(exportedTx []byte, waitForIndexing bool) (*TxHeader, error) {
+	hdr, entries, err := readExportedTx(exportedTx, s.hashAlg)
+	if err != nil {
+		return nil, err
+	}
+
+	txSpec, err := s.NewWriteOnlyTx()
+	if err != nil {
+		return nil, err
+	}
+
+	txSpec.metadata = hdr.Metadata
+
+	for _, e := range entries {
+		err = txSpec.Set(e.key, e.md, e.value)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return s.commit(txSpec, hdr, waitForIndexing)
 }
 
 func (s *ImmuStore) ReadTx(txID uint64, tx *Tx) error {
-	cacheMiss := false
+	return s.readTx(txID, tx, true)
+}
 
-	txbs, err := s.txLogCache.Get(txID)
-	if err != nil && err != cache.ErrKeyNotFound {
-		return err
-	}
-	if err == cache.ErrKeyNotFound {
-		cacheMiss = true
+// readTx reads the transaction txID, the transaction log cache is bypassed when useCache is false
+func (s *ImmuStore) readTx(txID uint64, tx *Tx, useCache bool) error {
+	cacheMiss := true
+
+	var txbs interface{}
+
+	if useCache {
+		var err error
+
+		txbs, err = s.txLogCache.Get(txID)
+		if err != nil && err != cache.ErrKeyNotFound {
+			return err
+		}
+
+		cacheMiss = err == cache.ErrKeyNotFound
+
+		s.txLogCacheMetrics.lookup(!cacheMiss)
 	}
 
 	txOff, txSize, err := s.txOffsetAndSize(txID)
@@ -1709,9 +2179,37 @@ func (s *ImmuStore) ReadValue(entry *TxEntry) ([]byte, error) {
 }
 
 func (s *ImmuStore) readValueAt(b []byte, off int64, hvalue [sha256.Size]byte) (int, error) {
+	vLogID, _ := decodeOffset(off)
+
+	if vLogID > 0 && s.cachedValueAt(b, off) {
+		return len(b), nil
+	}
+
+	n, err := s.readValueFromLog(b, off, hvalue)
+	if err != nil {
+		return n, err
+	}
+
+	if vLogID > 0 {
+		err := s.cacheValueAt(b, off)
+		if err != nil {
+			return len(b), err
+		}
+	}
+
+	return len(b), nil
+}
+
+// readValueFromLog reads the value stored at off bypassing the value cache
+func (s *ImmuStore) readValueFromLog(b []byte, off int64, hvalue [sha256.Size]byte) (int, error) {
 	vLogID, offset := decodeOffset(off)
 
 	if vLogID > 0 {
+		err := s.checkVLog(vLogID)
+		if err != nil {
+			return 0, err
+		}
+
 		vLog := s.fetchVLog(vLogID)
 		defer s.releaseVLog(vLogID)
 
@@ -1719,12 +2217,21 @@ func (s *ImmuStore) readValueAt(b []byte, off int64, hvalue [sha256.Size]byte) (
 		if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed {
 			return n, ErrAlreadyClosed
 		}
+		if err == multiapp.ErrDiscarded {
+			return n, ErrValueTruncated
+		}
 		if err != nil {
 			return n, err
 		}
 	}
 
-	if hvalue != sha256.Sum256(b) {
+	if hvalue != s.hashAlg.Sum(b) {
+		if s.isErased(off) {
+			return len(b), ErrValueErased
+		}
+		if atomic.LoadUint64(&s.gcTxID) > 0 && isZeroed(b) {
+			return len(b), ErrValueReclaimed
+		}
 		return len(b), ErrCorruptedData
 	}
 
@@ -1753,6 +2260,11 @@ func (s *ImmuStore) validateEntries(entries []*EntrySpec) error {
 			return ErrorMaxValueLenExceeded
 		}
 
+		err := kv.Metadata.validate()
+		if err != nil {
+			return err
+		}
+
 		b64k := base64.StdEncoding.EncodeToString(kv.Key)
 		if _, ok := m[b64k]; ok {
 			return ErrDuplicatedKey
@@ -1770,32 +2282,37 @@ func (s *ImmuStore) Sync() error {
 		return ErrAlreadyClosed
 	}
 
+	err := s.syncLogs()
+	if err != nil {
+		return err
+	}
+
+	return s.indexer.Sync()
+}
+
+// syncLogs syncs every log holding committed data but the index
+func (s *ImmuStore) syncLogs() error {
 	for i := range s.vLogs {
 		vLog := s.fetchVLog(i + 1)
 		defer s.releaseVLog(i + 1)
 
-		err := vLog.Sync()
+		err := timedSync(s.metricsVLogSyncDuration, vLog.Sync)
 		if err != nil {
 			return err
 		}
 	}
 
-	err := s.txLog.Sync()
-	if err != nil {
-		return err
-	}
-
-	err = s.cLog.Sync()
+	err := timedSync(s.metricsTxLogSyncDuration, s.txLog.Sync)
 	if err != nil {
 		return err
 	}
 
-	err = s.aht.Sync()
+	err = timedSync(s.metricsCLogSyncDuration, s.cLog.Sync)
 	if err != nil {
 		return err
 	}
 
-	return s.indexer.Sync()
+	return s.aht.Sync()
 }
 
 func (s *ImmuStore) Close() error {
@@ -1808,8 +2325,32 @@ func (s *ImmuStore) Close() error {
 
 	s.closed = true
 
+	if s.retentionDone != nil {
+		close(s.retentionDone)
+	}
+
+	if s.gcDone != nil {
+		close(s.gcDone)
+	}
+
+	if s.scrubberDone != nil {
+		close(s.scrubberDone)
+	}
+
+	if s.archiverDone != nil {
+		close(s.archiverDone)
+	}
+
 	merr := multierr.NewMultiErr()
 
+	if s.groupCommitDone != nil {
+		close(s.groupCommitDone)
+
+		// transactions committed since the last group sync are synced before closing
+		err := s.syncLogs()
+		merr.Append(err)
+	}
+
 	for i := range s.vLogs {
 		vLog := s.fetchVLog(i + 1)
 
@@ -1860,6 +2401,13 @@ func minInt(a, b int) int {
 	return b
 }
 
+func minUint64(a, b uint64) uint64 {
+	if a <= b {
+		return a
+	}
+	return b
+}
+
 func maxInt(a, b int) int {
 	if a <= b {
 		return b
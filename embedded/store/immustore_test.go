@@ -27,6 +27,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -34,8 +35,13 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/htree"
 	"github.com/codenotary/immudb/embedded/tbtree"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -588,9 +594,9 @@ func TestImmudbStoreEdgeCases(t *testing.T) {
 	_, err = immuStore.DualProof(nil, nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
-	sourceTx := newTx(1, 1)
+	sourceTx := newTx(1, 1, hashing.SHA256)
 	sourceTx.header.ID = 2
-	targetTx := newTx(1, 1)
+	targetTx := newTx(1, 1, hashing.SHA256)
 	targetTx.header.ID = 1
 	_, err = immuStore.DualProof(sourceTx, targetTx)
 	require.Equal(t, ErrSourceTxNewerThanTargetTx, err)
@@ -1424,6 +1430,156 @@ func TestImmudbStoreCompactionFailureForRemoteStorage(t *testing.T) {
 
 	err = immuStore.CompactIndex()
 	require.Equal(t, ErrCompactionUnsupported, err)
+
+	_, err = immuStore.EstimateIndexCompaction()
+	require.Equal(t, ErrCompactionUnsupported, err)
+}
+
+func TestImmudbStoreEstimateIndexCompaction(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1)
+	opts.WithIndexOptions(opts.IndexOpts.WithFlushThld(10).WithCompactionThld(1))
+
+	immuStore, err := Open("data_estimate_compaction", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_estimate_compaction")
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i%3)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.WaitForIndexingUpto(10, nil)
+	require.NoError(t, err)
+
+	estimate, err := immuStore.EstimateIndexCompaction()
+	require.NoError(t, err)
+	require.True(t, estimate.ThresholdReached)
+	require.Greater(t, estimate.CompactedNodesSize, int64(0))
+
+	var dumped int64
+
+	err = immuStore.CompactIndexWithProgress(func(dumpedBytes int64) {
+		dumped = dumpedBytes
+	})
+	require.NoError(t, err)
+	require.Equal(t, estimate.CompactedNodesSize, dumped)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	_, err = immuStore.EstimateIndexCompaction()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestImmudbStoreResizeTxLogCache(t *testing.T) {
+	immuStore, err := Open("data_resize_txlog_cache", DefaultOptions().WithTxLogCacheSize(10))
+	require.NoError(t, err)
+	defer os.RemoveAll("data_resize_txlog_cache")
+	defer immuStore.Close()
+
+	require.Equal(t, 10, immuStore.TxLogCacheSize())
+
+	err = immuStore.ResizeTxLogCache(0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.ResizeTxLogCache(100)
+	require.NoError(t, err)
+	require.Equal(t, 100, immuStore.TxLogCacheSize())
+}
+
+func TestImmudbStoreAutoCompaction(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1)
+	opts.WithIndexOptions(opts.IndexOpts.WithFlushThld(10).WithAutoCompactionInterval(10 * time.Millisecond))
+
+	immuStore, err := Open("data_auto_compaction", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_auto_compaction")
+
+	txCount := 10
+	eCount := 10
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		for j := 0; j < eCount; j++ {
+			k := make([]byte, 8)
+			binary.BigEndian.PutUint64(k, uint64(j))
+
+			v := make([]byte, 8)
+			binary.BigEndian.PutUint64(v, uint64(i))
+
+			err = tx.Set(k, nil, v)
+			require.NoError(t, err)
+		}
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join("data_auto_compaction", indexDirname, fmt.Sprintf("nodes%016d", txCount)))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(eCount-1))
+
+	valRef, err := immuStore.Get(k)
+	require.NoError(t, err)
+	require.Equal(t, uint64(txCount), valRef.Tx())
+	require.Equal(t, uint64(txCount), valRef.HC())
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	err = immuStore.indexer.CompactIndex()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestImmudbStoreIndexCheckpointing(t *testing.T) {
+	defer os.RemoveAll("data_index_checkpointing")
+
+	opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1)
+	opts.WithIndexOptions(opts.IndexOpts.WithSynced(false).WithFlushThld(100).WithSyncThld(1000).WithCheckpointInterval(10 * time.Millisecond))
+
+	immuStore, err := Open("data_index_checkpointing", opts)
+	require.NoError(t, err)
+
+	txCount := 10
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	// the index is persisted while the store is still open, as it would be found after a crash
+	require.Eventually(t, func() bool {
+		roStore, err := Open("data_index_checkpointing", DefaultOptions().WithReadOnly(true))
+		require.NoError(t, err)
+
+		defer roStore.Close()
+
+		return roStore.indexer.Ts() == uint64(txCount)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	err = immuStore.indexer.Checkpoint()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
 }
 
 func TestImmudbStoreInclusionProof(t *testing.T) {
@@ -1483,7 +1639,7 @@ func TestImmudbStoreInclusionProof(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 
-		entrySpecDigest, err := EntrySpecDigestFor(tx.header.Version)
+		entrySpecDigest, err := EntrySpecDigestFor(tx.header.Version, tx.header.HashAlgorithm)
 		require.NoError(t, err)
 		require.NotNil(t, entrySpecDigest)
 
@@ -1517,7 +1673,7 @@ func TestImmudbStoreInclusionProof(t *testing.T) {
 
 			eSpec := &EntrySpec{Key: key, Metadata: NewKVMetadata(), Value: value}
 
-			verifies := htree.VerifyInclusion(proof, entrySpecDigest(eSpec), tx.header.Eh)
+			verifies := htree.VerifyInclusion(proof, entrySpecDigest(eSpec), tx.header.Eh, tx.header.HashAlgorithm)
 			require.True(t, verifies)
 
 			v, err = immuStore.ReadValue(e)
@@ -1869,7 +2025,7 @@ func TestImmudbStoreConsistencyProofReopened(t *testing.T) {
 			lproof, err := immuStore.LinearProof(sourceTxID, targetTxID)
 			require.NoError(t, err)
 
-			verifies := VerifyLinearProof(lproof, sourceTxID, targetTxID, sourceTx.header.Alh(), targetTx.header.Alh())
+			verifies := VerifyLinearProof(lproof, sourceTxID, targetTxID, sourceTx.header.Alh(), targetTx.header.Alh(), immuStore.HashAlgorithm())
 			require.True(t, verifies)
 
 			dproof, err := immuStore.DualProof(sourceTx, targetTx)
@@ -1963,6 +2119,70 @@ func TestReOpenningWithCompressionEnabledImmudbStore(t *testing.T) {
 	}
 }
 
+func TestImmudbStoreCompressedValueProofs(t *testing.T) {
+	for _, compressionFormat := range []int{appendable.ZStdCompression, appendable.LZ4Compression} {
+		path := fmt.Sprintf("data_compression_%s", appendable.CompressionFormatName(compressionFormat))
+		defer os.RemoveAll(path)
+
+		opts := DefaultOptions().
+			WithSynced(false).
+			WithCompressionFormat(compressionFormat).
+			WithCompresionLevel(appendable.BestCompression)
+
+		immuStore, err := Open(path, opts)
+		require.NoError(t, err)
+
+		txCount := 10
+		eCount := 10
+
+		for i := 0; i < txCount; i++ {
+			tx, err := immuStore.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			for j := 0; j < eCount; j++ {
+				err = tx.Set([]byte(fmt.Sprintf("key%d_%d", i, j)), nil, bytes.Repeat([]byte{byte(j)}, 64*j))
+				require.NoError(t, err)
+			}
+
+			_, err = tx.AsyncCommit()
+			require.NoError(t, err)
+		}
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+
+		// digests are computed over uncompressed values so proofs remain valid
+		immuStore, err = Open(path, opts.WithCompressionFormat(appendable.NoCompression))
+		require.NoError(t, err)
+
+		tx := immuStore.NewTxHolder()
+
+		for i := 0; i < txCount; i++ {
+			err = immuStore.ReadTx(uint64(i+1), tx)
+			require.NoError(t, err)
+
+			entrySpecDigest, err := EntrySpecDigestFor(tx.header.Version, tx.header.HashAlgorithm)
+			require.NoError(t, err)
+
+			for _, txe := range tx.Entries() {
+				proof, err := tx.Proof(txe.key())
+				require.NoError(t, err)
+
+				value, err := immuStore.ReadValue(txe)
+				require.NoError(t, err)
+
+				e := &EntrySpec{Key: txe.key(), Value: value}
+
+				verifies := htree.VerifyInclusion(proof, entrySpecDigest(e), tx.header.Eh, tx.header.HashAlgorithm)
+				require.True(t, verifies)
+			}
+		}
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+	}
+}
+
 func TestUncommittedTxOverwriting(t *testing.T) {
 	path := "data_overwriting"
 	err := os.Mkdir(path, 0700)
@@ -2054,7 +2274,7 @@ func TestUncommittedTxOverwriting(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 
-		entrySpecDigest, err := EntrySpecDigestFor(tx.header.Version)
+		entrySpecDigest, err := EntrySpecDigestFor(tx.header.Version, tx.header.HashAlgorithm)
 		require.NoError(t, err)
 		require.NotNil(t, entrySpecDigest)
 
@@ -2071,7 +2291,7 @@ func TestUncommittedTxOverwriting(t *testing.T) {
 
 			e := &EntrySpec{Key: txe.key(), Value: value}
 
-			verifies := htree.VerifyInclusion(proof, entrySpecDigest(e), tx.header.Eh)
+			verifies := htree.VerifyInclusion(proof, entrySpecDigest(e), tx.header.Eh, tx.header.HashAlgorithm)
 			require.True(t, verifies)
 		}
 	}
@@ -2119,6 +2339,35 @@ func TestExportAndReplicateTx(t *testing.T) {
 
 	_, err = replicaStore.ReplicateTx(nil, false)
 	require.Equal(t, ErrIllegalArguments, err)
+
+	// a replica holding a different first transaction diverges from the master
+	divergedStore, err := Open("data_diverged_export_replicate", DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("data_diverged_export_replicate")
+
+	tx, err = divergedStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value2"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.NoError(t, err)
+
+	tx, err = masterStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key2"), nil, []byte("value2"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.NoError(t, err)
+
+	etx, err = masterStore.ExportTx(2, txholder)
+	require.NoError(t, err)
+
+	_, err = divergedStore.ReplicateTx(etx, false)
+	require.ErrorIs(t, err, ErrTxHeaderMismatch)
 }
 
 var errEmulatedAppendableError = errors.New("emulated appendable error")
@@ -2366,3 +2615,806 @@ func TestImmudbStoreTruncatedCommitLog(t *testing.T) {
 	err = immuStore.Close()
 	require.NoError(t, err)
 }
+
+func TestImmudbStorePreallocation(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false).WithFileSize(256).WithPreallocSize(1 << 20)
+
+	immuStore, err := Open("data_prealloc", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_prealloc")
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_prealloc", opts)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), immuStore.TxCount())
+
+	for i := 0; i < 10; i++ {
+		valRef, err := immuStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
+
+func TestImmudbStoreDirectIO(t *testing.T) {
+	opts := DefaultOptions().WithFileSize(1024).WithDirectIO(true)
+
+	immuStore, err := Open("data_direct_io", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_direct_io")
+
+	for i := 0; i < 100; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	requireValues := func(st *ImmuStore) {
+		for i := 0; i < 100; i++ {
+			valRef, err := st.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+		}
+	}
+
+	err = immuStore.WaitForIndexingUpto(100, nil)
+	require.NoError(t, err)
+
+	requireValues(immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_direct_io", opts)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), immuStore.TxCount())
+
+	requireValues(immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
+
+func TestImmudbStoreMmap(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false).WithFileSize(64).WithMmap(true)
+
+	immuStore, err := Open("data_mmap", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_mmap")
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	requireValues := func(st *ImmuStore) {
+		tx := st.NewTxHolder()
+
+		for i := 0; i < 10; i++ {
+			err := st.ReadTx(uint64(i+1), tx)
+			require.NoError(t, err)
+
+			entry, err := tx.EntryOf([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := st.ReadValue(entry)
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+		}
+	}
+
+	requireValues(immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_mmap", opts)
+	require.NoError(t, err)
+
+	requireValues(immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
+
+func TestImmudbStoreValueCache(t *testing.T) {
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithVLogCacheSize(5).
+		WithCacheEvictionPolicy(cache.ARC)
+
+	immuStore, err := Open("data_vlog_cache", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_vlog_cache")
+
+	defer immuStore.Close()
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	tx := immuStore.NewTxHolder()
+
+	for r := 0; r < 2; r++ {
+		for i := 0; i < 10; i++ {
+			err := immuStore.ReadTx(uint64(i+1), tx)
+			require.NoError(t, err)
+
+			entry, err := tx.EntryOf([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := immuStore.ReadValue(entry)
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+		}
+	}
+
+	require.Equal(t, 5, immuStore.vLogCache.EntriesCount())
+
+	err = immuStore.ReadTx(10, tx)
+	require.NoError(t, err)
+
+	entry, err := tx.EntryOf([]byte("key9"))
+	require.NoError(t, err)
+
+	vLogID, off := decodeOffset(entry.vOff)
+
+	err = immuStore.purgeCachedValues(vLogID, off+1)
+	require.NoError(t, err)
+	require.Zero(t, immuStore.vLogCache.EntriesCount())
+
+	val, err := immuStore.ReadValue(entry)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value9"), val)
+	require.Equal(t, 1, immuStore.vLogCache.EntriesCount())
+
+	err = immuStore.purgeCachedValue(entry.vOff)
+	require.NoError(t, err)
+	require.Zero(t, immuStore.vLogCache.EntriesCount())
+
+	_, err = Open("data_vlog_cache", DefaultOptions().WithCacheEvictionPolicy("fifo"))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestImmudbStoreParallelIndexing(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false)
+	opts.IndexOpts.WithIndexingWorkers(4)
+
+	immuStore, err := Open("data_parallel_indexing", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_parallel_indexing")
+
+	defer immuStore.Close()
+
+	immuStore.indexer.Pause()
+
+	for i := 0; i < 100; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i%10)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		if i%7 == 0 {
+			md := NewKVMetadata()
+
+			err = md.AsNonIndexable(true)
+			require.NoError(t, err)
+
+			err = tx.Set([]byte(fmt.Sprintf("nonindexable%d", i)), md, []byte("value"))
+			require.NoError(t, err)
+		}
+
+		_, err = tx.AsyncCommit()
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, uint64(100), immuStore.IndexingLag())
+
+	immuStore.indexer.Resume()
+
+	err = immuStore.WaitForIndexing(nil)
+	require.NoError(t, err)
+	require.Zero(t, immuStore.IndexingLag())
+
+	for i := 0; i < 10; i++ {
+		valRef, err := immuStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, uint64(91+i), valRef.Tx())
+		require.Equal(t, uint64(10), valRef.HC())
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", 90+i)), val)
+	}
+
+	_, err = immuStore.Get([]byte("nonindexable0"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestImmudbStoreDurability(t *testing.T) {
+	defer os.RemoveAll("data_durability")
+
+	_, err := Open("data_durability", DefaultOptions().WithDurability("never"))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	immuStore, err := Open("data_durability", DefaultOptions().WithSynced(false).WithDurability(DurabilitySync))
+	require.NoError(t, err)
+	require.Equal(t, DurabilityOSBuffered, immuStore.Durability())
+	require.False(t, immuStore.Synced())
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	opts := DefaultOptions().
+		WithDurability(DurabilityGroupCommit).
+		WithGroupCommitMaxLatency(time.Millisecond)
+
+	immuStore, err = Open("data_durability", opts)
+	require.NoError(t, err)
+	require.Equal(t, DurabilityGroupCommit, immuStore.Durability())
+	require.False(t, immuStore.Synced())
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		immuStore.mutex.Lock()
+		defer immuStore.mutex.Unlock()
+
+		return immuStore.syncedTxID == 10
+	}, 5*time.Second, time.Millisecond)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_durability", opts)
+	require.NoError(t, err)
+
+	defer immuStore.Close()
+
+	for i := 0; i < 10; i++ {
+		valRef, err := immuStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+	}
+}
+
+func TestImmudbStoreSyncMetrics(t *testing.T) {
+	defer os.RemoveAll("data_sync_metrics")
+
+	immuStore, err := Open("data_sync_metrics", DefaultOptions())
+	require.NoError(t, err)
+
+	defer immuStore.Close()
+
+	syncCount := func(o prometheus.Observer) uint64 {
+		var m dto.Metric
+
+		err := o.(prometheus.Histogram).Write(&m)
+		require.NoError(t, err)
+
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	vLogSyncs := syncCount(immuStore.metricsVLogSyncDuration)
+	txLogSyncs := syncCount(immuStore.metricsTxLogSyncDuration)
+	cLogSyncs := syncCount(immuStore.metricsCLogSyncDuration)
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key"), nil, []byte("value"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.NoError(t, err)
+
+	require.Equal(t, vLogSyncs+1, syncCount(immuStore.metricsVLogSyncDuration))
+
+	err = immuStore.Sync()
+	require.NoError(t, err)
+
+	require.Equal(t, vLogSyncs+2, syncCount(immuStore.metricsVLogSyncDuration))
+	require.Equal(t, txLogSyncs+1, syncCount(immuStore.metricsTxLogSyncDuration))
+	require.Equal(t, cLogSyncs+1, syncCount(immuStore.metricsCLogSyncDuration))
+}
+
+func TestImmudbStoreBackpressure(t *testing.T) {
+	defer os.RemoveAll("data_backpressure")
+
+	_, err := Open("data_backpressure", DefaultOptions().WithMaxIndexingLag(-1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithMaxCommitQueueDepth(2).
+		WithMaxIndexingLag(5)
+
+	immuStore, err := Open("data_backpressure", opts)
+	require.NoError(t, err)
+
+	defer immuStore.Close()
+
+	queueRejections := testutil.ToFloat64(immuStore.metricsCommitQueueRejections)
+	lagRejections := testutil.ToFloat64(immuStore.metricsIndexingLagRejections)
+
+	commit := func(i int) error {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		// commits do not wait for indexing so that indexing can lag behind
+		_, err = tx.AsyncCommit()
+		return err
+	}
+
+	t.Run("commits should be rejected when the commit queue is full", func(t *testing.T) {
+		atomic.StoreInt32(&immuStore.commitQueueDepth, 2)
+
+		err := commit(0)
+		require.ErrorIs(t, err, ErrWriteBackpressure)
+		require.Equal(t, 2, immuStore.CommitQueueDepth())
+
+		atomic.StoreInt32(&immuStore.commitQueueDepth, 0)
+
+		err = commit(0)
+		require.NoError(t, err)
+		require.Zero(t, immuStore.CommitQueueDepth())
+	})
+
+	t.Run("commits should be rejected while indexing lags behind", func(t *testing.T) {
+		immuStore.indexer.Pause()
+
+		i := 1
+		for ; i < 100; i++ {
+			err = commit(i)
+			if err != nil {
+				break
+			}
+		}
+		require.ErrorIs(t, err, ErrWriteBackpressure)
+		require.Greater(t, immuStore.IndexingLag(), uint64(5))
+
+		immuStore.indexer.Resume()
+
+		require.Eventually(t, func() bool {
+			return commit(i) == nil
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+
+	require.Equal(t, queueRejections+1, testutil.ToFloat64(immuStore.metricsCommitQueueRejections))
+	require.Greater(t, testutil.ToFloat64(immuStore.metricsIndexingLagRejections), lagRejections)
+}
+
+func TestImmudbStoreIndexBloomFilter(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false)
+	opts.IndexOpts.WithBloomFilterKeys(100)
+
+	immuStore, err := Open("data_bloom", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_bloom")
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+
+	err = immuStore.WaitForIndexingUpto(hdr.ID, nil)
+	require.NoError(t, err)
+
+	_, err = immuStore.Get([]byte("key1"))
+	require.NoError(t, err)
+
+	_, err = immuStore.Get([]byte("key2"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	require.FileExists(t, filepath.Join("data_bloom", indexDirname, "bloom"))
+}
+
+func TestImmudbStoreReadOnly(t *testing.T) {
+	defer os.RemoveAll("data_read_only")
+
+	_, err := Open("data_read_only", DefaultOptions().WithReadOnly(true))
+	require.True(t, os.IsNotExist(err))
+
+	_, err = os.Stat("data_read_only")
+	require.True(t, os.IsNotExist(err))
+
+	immuStore, err := Open("data_read_only", DefaultOptions())
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	type fileState struct {
+		size    int64
+		modTime time.Time
+	}
+
+	dirState := func() map[string]fileState {
+		files := make(map[string]fileState)
+
+		err := filepath.Walk("data_read_only", func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+			return nil
+		})
+		require.NoError(t, err)
+
+		return files
+	}
+
+	stateBefore := dirState()
+
+	roStore, err := Open("data_read_only", DefaultOptions().WithReadOnly(true).WithMaxWaitees(1))
+	require.NoError(t, err)
+	require.True(t, roStore.ReadOnly())
+	require.Equal(t, uint64(10), roStore.TxCount())
+
+	valRef, err := roStore.Get([]byte("key3"))
+	require.NoError(t, err)
+
+	val, err := valRef.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("value3"), val)
+
+	err = roStore.WaitForIndexingUpto(10, nil)
+	require.NoError(t, err)
+
+	err = roStore.WaitForIndexingUpto(11, nil)
+	require.ErrorIs(t, err, ErrReadOnlyStore)
+
+	tx, err := roStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key10"), nil, []byte("value10"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.ErrorIs(t, err, ErrReadOnlyStore)
+
+	err = roStore.CompactIndex()
+	require.ErrorIs(t, err, ErrReadOnlyStore)
+
+	err = roStore.TruncateUptoTx(5)
+	require.ErrorIs(t, err, ErrReadOnlyStore)
+
+	err = roStore.RebuildIndex(nil)
+	require.ErrorIs(t, err, ErrReadOnlyStore)
+
+	err = roStore.Close()
+	require.NoError(t, err)
+
+	require.Equal(t, stateBefore, dirState())
+
+	t.Run("read-only open of a store in use", func(t *testing.T) {
+		immuStore, err := Open("data_read_only", DefaultOptions())
+		require.NoError(t, err)
+
+		defer immuStore.Close()
+
+		roStore, err := Open("data_read_only", DefaultOptions().WithReadOnly(true))
+		require.NoError(t, err)
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key10"), nil, []byte("value10"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+
+		require.Equal(t, uint64(10), roStore.TxCount())
+
+		txHolder := roStore.NewTxHolder()
+
+		err = roStore.ReadTx(10, txHolder)
+		require.NoError(t, err)
+
+		err = roStore.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestImmudbStoreHashAlgorithms(t *testing.T) {
+	for _, hashAlg := range []hashing.Algorithm{hashing.SHA256, hashing.SHA3_256, hashing.BLAKE3} {
+		t.Run(hashAlg.String(), func(t *testing.T) {
+			dir := fmt.Sprintf("data_hash_%s", hashAlg)
+			defer os.RemoveAll(dir)
+
+			immuStore, err := Open(dir, DefaultOptions().WithHashAlgorithm(hashAlg))
+			require.NoError(t, err)
+			require.Equal(t, hashAlg, immuStore.HashAlgorithm())
+
+			txCount := 5
+
+			for i := 0; i < txCount; i++ {
+				tx, err := immuStore.NewWriteOnlyTx()
+				require.NoError(t, err)
+
+				err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+				require.NoError(t, err)
+
+				txhdr, err := tx.Commit()
+				require.NoError(t, err)
+				require.Equal(t, hashAlg, txhdr.HashAlgorithm)
+			}
+
+			err = immuStore.Close()
+			require.NoError(t, err)
+
+			// the algorithm is taken from the store metadata
+			immuStore, err = Open(dir, DefaultOptions())
+			require.NoError(t, err)
+			require.Equal(t, hashAlg, immuStore.HashAlgorithm())
+
+			defer immuStore.Close()
+
+			sourceTx := immuStore.NewTxHolder()
+			targetTx := immuStore.NewTxHolder()
+
+			err = immuStore.ReadTx(1, sourceTx)
+			require.NoError(t, err)
+
+			err = immuStore.ReadTx(uint64(txCount), targetTx)
+			require.NoError(t, err)
+			require.Equal(t, hashAlg, targetTx.Header().HashAlgorithm)
+
+			dproof, err := immuStore.DualProof(sourceTx, targetTx)
+			require.NoError(t, err)
+
+			verifies := VerifyDualProof(dproof, 1, uint64(txCount), sourceTx.header.Alh(), targetTx.header.Alh())
+			require.True(t, verifies)
+
+			proof, err := targetTx.Proof([]byte(fmt.Sprintf("key%d", txCount-1)))
+			require.NoError(t, err)
+
+			entrySpecDigest, err := EntrySpecDigestFor(targetTx.header.Version, hashAlg)
+			require.NoError(t, err)
+
+			entrySpec := &EntrySpec{
+				Key:   []byte(fmt.Sprintf("key%d", txCount-1)),
+				Value: []byte(fmt.Sprintf("value%d", txCount-1)),
+			}
+
+			verifies = VerifyInclusion(proof, entrySpecDigest(entrySpec), targetTx.header.Eh, hashAlg)
+			require.True(t, verifies)
+
+			if hashAlg != hashing.SHA256 {
+				verifies = VerifyInclusion(proof, entrySpecDigest(entrySpec), targetTx.header.Eh, hashing.SHA256)
+				require.False(t, verifies)
+			}
+		})
+	}
+
+	_, err := Open("data_hash_invalid", DefaultOptions().WithHashAlgorithm(hashing.Algorithm(99)))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestImmudbStoreTxAnnotations(t *testing.T) {
+	opts := DefaultOptions()
+
+	immuStore, err := Open("data_tx_annotations", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_tx_annotations")
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	md := NewTxMetadata().WithAnnotation("source", "ingestion")
+
+	tx.WithMetadata(md)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	hdr1, err := tx.Commit()
+	require.NoError(t, err)
+	require.True(t, md.Equal(hdr1.Metadata))
+
+	t.Run("invalid annotations are rejected", func(t *testing.T) {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		tx.WithMetadata(NewTxMetadata().WithAnnotation("", "value"))
+
+		err = tx.Set([]byte("key2"), nil, []byte("value2"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = immuStore.CommitWithMetadata(NewTxMetadata().WithAnnotation("k", string(make([]byte, maxAnnotationLen+1))),
+			func(txID uint64, index KeyIndex) ([]*EntrySpec, error) {
+				return []*EntrySpec{{Key: []byte("key2"), Value: []byte("value2")}}, nil
+			}, false)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	hdr2, err := immuStore.CommitWithMetadata(NewTxMetadata().WithAnnotation("source", "batch"),
+		func(txID uint64, index KeyIndex) ([]*EntrySpec, error) {
+			return []*EntrySpec{{Key: []byte("key2"), Value: []byte("value2")}}, nil
+		}, true)
+	require.NoError(t, err)
+	require.Equal(t, hdr1.ID+1, hdr2.ID)
+
+	requireAnnotations := func(st *ImmuStore) {
+		txHolder := st.NewTxHolder()
+
+		err := st.ReadTx(hdr1.ID, txHolder)
+		require.NoError(t, err)
+		require.True(t, md.Equal(txHolder.Header().Metadata))
+		require.Equal(t, hdr1.Alh(), txHolder.Header().Alh())
+
+		err = st.ReadTx(hdr2.ID, txHolder)
+		require.NoError(t, err)
+
+		source, ok := txHolder.Header().Metadata.Annotation("source")
+		require.True(t, ok)
+		require.Equal(t, "batch", source)
+		require.Equal(t, hdr2.Alh(), txHolder.Header().Alh())
+
+		lproof, err := st.LinearProof(hdr1.ID, hdr2.ID)
+		require.NoError(t, err)
+		require.True(t, VerifyLinearProof(lproof, hdr1.ID, hdr2.ID, hdr1.Alh(), hdr2.Alh(), st.HashAlgorithm()))
+	}
+
+	requireAnnotations(immuStore)
+
+	// annotations are covered by the tx hash
+	tamperedHdr := *hdr1
+	tamperedHdr.Metadata = NewTxMetadata().WithAnnotation("source", "tampered")
+	require.NotEqual(t, hdr1.Alh(), tamperedHdr.Alh())
+
+	tamperedHdr.Metadata = nil
+	require.NotEqual(t, hdr1.Alh(), tamperedHdr.Alh())
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_tx_annotations", opts)
+	require.NoError(t, err)
+
+	requireAnnotations(immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
+
+func TestImmudbStoreTypedKVMetadata(t *testing.T) {
+	immuStore, err := Open("data_typed_kv_metadata", DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("data_typed_kv_metadata")
+
+	md := NewKVMetadata()
+	md.SetContentType("application/json")
+	md.SetEncoding("gzip")
+	md.SetHeader("schema", "v1")
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), md, []byte("value1"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+
+	err = immuStore.WaitForIndexingUpto(hdr.ID, nil)
+	require.NoError(t, err)
+
+	valRef, err := immuStore.Get([]byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, "application/json", valRef.KVMetadata().ContentType())
+	require.Equal(t, "gzip", valRef.KVMetadata().Encoding())
+	require.Equal(t, map[string]string{"schema": "v1"}, valRef.KVMetadata().Headers())
+
+	txHolder := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(hdr.ID, txHolder)
+	require.NoError(t, err)
+
+	entry := txHolder.Entries()[0]
+	require.Equal(t, md.Bytes(), entry.Metadata().Bytes())
+
+	// typed metadata is covered by the entry digest
+	entrySpecDigest, err := EntrySpecDigestFor(hdr.Version, immuStore.HashAlgorithm())
+	require.NoError(t, err)
+
+	digest := entrySpecDigest(&EntrySpec{Key: []byte("key1"), Metadata: md, Value: []byte("value1")})
+
+	require.Equal(t, TxEntryDigest_v1_2(entry, immuStore.HashAlgorithm()), digest)
+
+	require.NotEqual(t, digest, entrySpecDigest(&EntrySpec{Key: []byte("key1"), Value: []byte("value1")}))
+
+	t.Run("invalid typed metadata is rejected", func(t *testing.T) {
+		md := NewKVMetadata()
+		md.SetContentType(string(make([]byte, maxContentTypeLen+1)))
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key2"), md, []byte("value2"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
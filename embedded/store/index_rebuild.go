@@ -0,0 +1,177 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/tbtree"
+)
+
+const indexRebuildPollInterval = 100 * time.Millisecond
+
+// IndexRebuildProgress is called whenever the number of indexed transactions increases while
+// an index is being rebuilt. Returning an error stops waiting but not the indexing itself.
+type IndexRebuildProgress func(indexedTxID, targetTxID uint64) error
+
+// RebuildIndex discards the index and builds it again from the transaction log, the call returns
+// once every transaction committed before the call is indexed. Lookups relying on the index may
+// return partial results while the index is being rebuilt.
+func (s *ImmuStore) RebuildIndex(progress IndexRebuildProgress) error {
+	if s.compactionDisabled {
+		return ErrIndexRebuildUnsupported
+	}
+
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+
+	s.mutex.Lock()
+	closed := s.closed
+	s.mutex.Unlock()
+
+	if closed {
+		return ErrAlreadyClosed
+	}
+
+	targetTxID := s.TxCount()
+
+	s.log.Infof("Rebuilding index '%s' up to tx %d...", s.path, targetTxID)
+
+	err := s.indexer.rebuildIndex()
+	if err != nil {
+		return err
+	}
+
+	return s.waitForIndexRebuild(targetTxID, progress)
+}
+
+// RebuildIndex brings the index of the store at path up to date while the store is not in use.
+// Indexing resumes from the last valid snapshot of the index so only the transactions not yet
+// indexed are processed, unless discard is set in which case the index is built from scratch.
+func RebuildIndex(path string, opts *Options, discard bool, progress IndexRebuildProgress) error {
+	if !validOptions(opts) {
+		return ErrIllegalArguments
+	}
+
+	if opts.ReadOnly {
+		return ErrReadOnlyStore
+	}
+
+	if discard {
+		if opts.CompactionDisabled {
+			return ErrIndexRebuildUnsupported
+		}
+
+		err := os.RemoveAll(filepath.Join(path, indexDirname))
+		if err != nil {
+			return err
+		}
+	}
+
+	st, err := Open(path, opts)
+	if err != nil {
+		return err
+	}
+
+	err = st.waitForIndexRebuild(st.TxCount(), progress)
+	if err != nil {
+		st.Close()
+		return err
+	}
+
+	return st.Close()
+}
+
+func (s *ImmuStore) waitForIndexRebuild(targetTxID uint64, progress IndexRebuildProgress) error {
+	reported := false
+	var reportedTxID uint64
+
+	for {
+		indexedTxID := s.indexer.Ts()
+		if indexedTxID > targetTxID {
+			indexedTxID = targetTxID
+		}
+
+		if progress != nil && (!reported || indexedTxID > reportedTxID) {
+			err := progress(indexedTxID, targetTxID)
+			if err != nil {
+				return err
+			}
+
+			reported = true
+			reportedTxID = indexedTxID
+		}
+
+		if indexedTxID == targetTxID {
+			s.log.Infof("Index '%s' successfully rebuilt up to tx %d", s.path, targetTxID)
+			return nil
+		}
+
+		s.mutex.Lock()
+		closed := s.closed
+		s.mutex.Unlock()
+
+		if closed {
+			return ErrAlreadyClosed
+		}
+
+		time.Sleep(indexRebuildPollInterval)
+	}
+}
+
+// rebuildIndex replaces the index with an empty one, transactions get indexed again from the first one
+func (idx *indexer) rebuildIndex() error {
+	idx.compactionMutex.Lock()
+	defer idx.compactionMutex.Unlock()
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	if idx.closed {
+		return ErrAlreadyClosed
+	}
+
+	idx.stop()
+	defer idx.resume()
+
+	// transactions being indexed must not be inserted into the new index
+	idx.indexingWg.Wait()
+
+	opts := idx.index.GetOptions()
+
+	err := idx.index.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(idx.path)
+	if err != nil {
+		return err
+	}
+
+	index, err := tbtree.Open(idx.path, opts)
+	if err != nil {
+		return err
+	}
+
+	idx.index = index
+
+	return nil
+}
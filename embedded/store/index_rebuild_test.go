@@ -0,0 +1,123 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreRebuildIndex(t *testing.T) {
+	defer os.RemoveAll("data_index_rebuild")
+
+	opts := DefaultOptions().WithSynced(false)
+
+	immuStore, err := Open("data_index_rebuild", opts)
+	require.NoError(t, err)
+
+	txCount := 20
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	checkIndex := func(st *ImmuStore) {
+		for i := 0; i < txCount; i++ {
+			valRef, err := st.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+			require.Equal(t, uint64(i+1), valRef.Tx())
+		}
+	}
+
+	var reported []uint64
+
+	progress := func(indexedTxID, targetTxID uint64) error {
+		require.Equal(t, uint64(txCount), targetTxID)
+		reported = append(reported, indexedTxID)
+		return nil
+	}
+
+	err = immuStore.RebuildIndex(progress)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, reported)
+	require.Equal(t, uint64(txCount), reported[len(reported)-1])
+
+	for i := 1; i < len(reported); i++ {
+		require.Greater(t, reported[i], reported[i-1])
+	}
+
+	checkIndex(immuStore)
+
+	errStopped := errors.New("stopped")
+
+	err = immuStore.RebuildIndex(func(indexedTxID, targetTxID uint64) error {
+		return errStopped
+	})
+	require.ErrorIs(t, err, errStopped)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	err = immuStore.RebuildIndex(nil)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	t.Run("offline rebuild", func(t *testing.T) {
+		err = RebuildIndex("data_index_rebuild", nil, false, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		for _, discard := range []bool{false, true} {
+			reported = nil
+
+			err = RebuildIndex("data_index_rebuild", opts, discard, progress)
+			require.NoError(t, err)
+			require.Equal(t, uint64(txCount), reported[len(reported)-1])
+
+			immuStore, err := Open("data_index_rebuild", opts)
+			require.NoError(t, err)
+
+			checkIndex(immuStore)
+
+			err = immuStore.Close()
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("rebuild is unsupported with remote storage", func(t *testing.T) {
+		err = RebuildIndex("data_index_rebuild", DefaultOptions().WithCompactionDisabled(true), true, nil)
+		require.ErrorIs(t, err, ErrIndexRebuildUnsupported)
+
+		immuStore, err := Open("data_index_rebuild", opts.WithCompactionDisabled(true))
+		require.NoError(t, err)
+
+		defer immuStore.Close()
+
+		err = immuStore.RebuildIndex(nil)
+		require.ErrorIs(t, err, ErrIndexRebuildUnsupported)
+	})
+}
@@ -32,11 +32,14 @@ type indexer struct {
 	path string
 
 	store *ImmuStore
-	tx    *Tx
+
+	// slots used to prepare transactions in parallel, the first one is always available
+	slots []*indexingSlot
 
 	index *tbtree.TBtree
 
 	cancellation chan struct{}
+	indexingWg   sync.WaitGroup // tracks the running indexing goroutine
 	wHub         *watchers.WatchersHub
 
 	state     int
@@ -44,11 +47,25 @@ type indexer struct {
 
 	closed bool
 
+	autoCompactionDone chan struct{}
+	checkpointingDone  chan struct{}
+
 	compactionMutex sync.Mutex
 	mutex           sync.Mutex
 
 	metricsLastCommittedTrx prometheus.Gauge
 	metricsLastIndexedTrx   prometheus.Gauge
+	metricsIndexingLag      prometheus.Gauge
+}
+
+// indexingSlot holds a transaction being prepared for its insertion into the index
+type indexingSlot struct {
+	tx  *Tx
+	kvs []*tbtree.KV
+
+	indexableEntries int
+	err              error
+	done             chan struct{}
 }
 
 type runningState = int
@@ -72,9 +89,19 @@ var (
 	}, []string{
 		"db",
 	})
+	metricsIndexingLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "immudb_indexing_lag_trxs",
+		Help: "Number of committed transactions not yet indexed",
+	}, []string{
+		"db",
+	})
 )
 
-func newIndexer(path string, store *ImmuStore, indexOpts *tbtree.Options, maxWaitees int) (*indexer, error) {
+func newIndexer(path string, store *ImmuStore, indexOpts *tbtree.Options, maxWaitees int, workers int) (*indexer, error) {
+	if workers < 1 {
+		return nil, ErrIllegalArguments
+	}
+
 	index, err := tbtree.Open(path, indexOpts)
 	if err != nil {
 		return nil, err
@@ -90,9 +117,23 @@ func newIndexer(path string, store *ImmuStore, indexOpts *tbtree.Options, maxWai
 		return nil, err
 	}
 
+	slots := make([]*indexingSlot, workers)
+
+	// the first slot reuses pre-allocated resources, additional ones are only needed for parallel indexing
+	slots[0] = &indexingSlot{tx: tx, kvs: store._kvs}
+
+	for i := 1; i < workers; i++ {
+		kvs := make([]*tbtree.KV, store.maxTxEntries)
+		for j := range kvs {
+			kvs[j] = &tbtree.KV{}
+		}
+
+		slots[i] = &indexingSlot{tx: newTx(store.maxTxEntries, store.maxKeyLen, store.hashAlg), kvs: kvs}
+	}
+
 	indexer := &indexer{
 		store:     store,
-		tx:        tx,
+		slots:     slots,
 		path:      path,
 		index:     index,
 		wHub:      wHub,
@@ -103,8 +144,16 @@ func newIndexer(path string, store *ImmuStore, indexOpts *tbtree.Options, maxWai
 	dbName := filepath.Base(store.path)
 	indexer.metricsLastIndexedTrx = metricsLastIndexedTrxId.WithLabelValues(dbName)
 	indexer.metricsLastCommittedTrx = metricsLastCommittedTrx.WithLabelValues(dbName)
+	indexer.metricsIndexingLag = metricsIndexingLag.WithLabelValues(dbName)
 
-	indexer.resume()
+	// the index is kept as persisted when the store is opened in read-only mode
+	if store.readOnly {
+		if wHub != nil {
+			wHub.DoneUpto(index.Ts())
+		}
+	} else {
+		indexer.resume()
+	}
 
 	return indexer, nil
 }
@@ -193,9 +242,21 @@ func (idx *indexer) Close() error {
 		return ErrAlreadyClosed
 	}
 
-	idx.stop()
+	if idx.autoCompactionDone != nil {
+		close(idx.autoCompactionDone)
+	}
+
+	if idx.checkpointingDone != nil {
+		close(idx.checkpointingDone)
+	}
+
+	// indexing is never started in read-only mode
+	if !idx.store.readOnly {
+		idx.stop()
+	}
+
 	idx.wHub.Close()
-	idx.store.releaseAllocTx(idx.tx)
+	idx.store.releaseAllocTx(idx.slots[0].tx)
 
 	idx.closed = true
 
@@ -211,9 +272,23 @@ func (idx *indexer) WaitForIndexingUpto(txID uint64, cancellation <-chan struct{
 }
 
 func (idx *indexer) CompactIndex() (err error) {
+	return idx.CompactIndexWithProgress(nil)
+}
+
+// CompactIndexWithProgress compacts the index as CompactIndex does,
+// progress is notified with the number of bytes of the index dumped so far
+func (idx *indexer) CompactIndexWithProgress(progress func(dumpedBytes int64)) (err error) {
 	idx.compactionMutex.Lock()
 	defer idx.compactionMutex.Unlock()
 
+	idx.mutex.Lock()
+	closed := idx.closed
+	idx.mutex.Unlock()
+
+	if closed {
+		return ErrAlreadyClosed
+	}
+
 	idx.store.log.Infof("Compacting index '%s'...", idx.store.path)
 
 	defer func() {
@@ -226,7 +301,7 @@ func (idx *indexer) CompactIndex() (err error) {
 		}
 	}()
 
-	_, err = idx.index.Compact()
+	_, err = idx.index.CompactWithProgress(progress)
 	if err != nil {
 		return err
 	}
@@ -234,6 +309,81 @@ func (idx *indexer) CompactIndex() (err error) {
 	return idx.restartIndex()
 }
 
+// EstimateCompaction computes the space a compaction of the index would reclaim without compacting it
+func (idx *indexer) EstimateCompaction() (*tbtree.CompactionEstimate, error) {
+	idx.mutex.Lock()
+	closed := idx.closed
+	idx.mutex.Unlock()
+
+	if closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	return idx.index.EstimateCompaction()
+}
+
+// startAutoCompaction periodically compacts the index in background until the indexer is closed.
+// Compaction is skipped while the threshold of the index is not reached.
+func (idx *indexer) startAutoCompaction(interval time.Duration) {
+	idx.autoCompactionDone = make(chan struct{})
+
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// errors are logged during compaction
+				err := idx.CompactIndex()
+				if err == ErrAlreadyClosed {
+					return
+				}
+			}
+		}
+	}(idx.autoCompactionDone)
+}
+
+// Checkpoint durably persists the index up to the latest indexed transaction
+func (idx *indexer) Checkpoint() error {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	if idx.closed {
+		return ErrAlreadyClosed
+	}
+
+	return idx.index.Checkpoint()
+}
+
+// startCheckpointing periodically persists the index in background until the indexer is closed,
+// bounding the number of transactions to be re-indexed when the store is reopened after a crash.
+func (idx *indexer) startCheckpointing(interval time.Duration) {
+	idx.checkpointingDone = make(chan struct{})
+
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := idx.Checkpoint()
+				if err == ErrAlreadyClosed || err == tbtree.ErrAlreadyClosed {
+					return
+				}
+				if err != nil {
+					idx.store.log.Warningf("%v: while checkpointing index '%s'", err, idx.store.path)
+				}
+			}
+		}
+	}(idx.checkpointingDone)
+}
+
 func (idx *indexer) stop() {
 	idx.stateCond.L.Lock()
 	idx.state = stopped
@@ -248,7 +398,11 @@ func (idx *indexer) resume() {
 	idx.stateCond.L.Lock()
 	idx.state = running
 	idx.cancellation = make(chan struct{})
-	go idx.doIndexing(idx.cancellation)
+	idx.indexingWg.Add(1)
+	go func(cancellation <-chan struct{}) {
+		defer idx.indexingWg.Done()
+		idx.doIndexing(cancellation)
+	}(idx.cancellation)
 	idx.stateCond.L.Unlock()
 
 	idx.store.notify(Info, true, "Indexing in progress at '%s'", idx.store.path)
@@ -307,6 +461,7 @@ func (idx *indexer) doIndexing(cancellation <-chan struct{}) {
 		}
 
 		idx.metricsLastIndexedTrx.Set(float64(lastIndexedTx))
+		idx.metricsIndexingLag.Set(float64(committedTxID - lastIndexedTx))
 
 		err := idx.store.WaitForTx(lastIndexedTx+1, cancellation)
 		if err == watchers.ErrCancellationRequested || err == watchers.ErrAlreadyClosed {
@@ -317,10 +472,11 @@ func (idx *indexer) doIndexing(cancellation <-chan struct{}) {
 			time.Sleep(60 * time.Second)
 		}
 
-		committedTxID, _, _ := idx.store.commitState()
+		committedTxID, _, _ = idx.store.commitState()
 		idx.metricsLastCommittedTrx.Set(float64(committedTxID))
 
 		txsToIndex := committedTxID - lastIndexedTx
+		idx.metricsIndexingLag.Set(float64(txsToIndex))
 		idx.store.notify(Info, false, "%d transaction/s to be indexed at '%s'", txsToIndex, idx.store.path)
 
 		idx.stateCond.L.Lock()
@@ -336,7 +492,7 @@ func (idx *indexer) doIndexing(cancellation <-chan struct{}) {
 		}
 		idx.stateCond.L.Unlock()
 
-		err = idx.indexTX(lastIndexedTx + 1)
+		err = idx.indexTxs(lastIndexedTx+1, committedTxID)
 		if err == ErrAlreadyClosed || err == tbtree.ErrAlreadyClosed {
 			return
 		}
@@ -347,18 +503,69 @@ func (idx *indexer) doIndexing(cancellation <-chan struct{}) {
 	}
 }
 
-func (idx *indexer) indexTX(txID uint64) error {
-	err := idx.store.ReadTx(txID, idx.tx)
+// indexTxs indexes the transactions from txID up to maxTxID, at most one per indexing slot.
+// Transactions are read and prepared in parallel but inserted into the index in order.
+func (idx *indexer) indexTxs(txID, maxTxID uint64) error {
+	n := len(idx.slots)
+	if maxTxID-txID+1 < uint64(n) {
+		n = int(maxTxID - txID + 1)
+	}
+
+	if n <= 1 {
+		slot := idx.slots[0]
+
+		slot.indexableEntries, slot.err = idx.prepareTx(txID, slot.tx, slot.kvs)
+		if slot.err != nil {
+			return slot.err
+		}
+
+		return idx.insertTx(txID, slot)
+	}
+
+	for i := 0; i < n; i++ {
+		slot := idx.slots[i]
+		slot.done = make(chan struct{})
+
+		go func(txID uint64, slot *indexingSlot) {
+			defer close(slot.done)
+			slot.indexableEntries, slot.err = idx.prepareTx(txID, slot.tx, slot.kvs)
+		}(txID+uint64(i), slot)
+	}
+
+	var err error
+
+	for i := 0; i < n; i++ {
+		slot := idx.slots[i]
+
+		// slots are reused, every preparation must be completed before returning
+		<-slot.done
+
+		if err != nil {
+			continue
+		}
+
+		err = slot.err
+		if err == nil {
+			err = idx.insertTx(txID+uint64(i), slot)
+		}
+	}
+
+	return err
+}
+
+// prepareTx reads the transaction txID and encodes its indexable entries into kvs
+func (idx *indexer) prepareTx(txID uint64, tx *Tx, kvs []*tbtree.KV) (int, error) {
+	err := idx.store.ReadTx(txID, tx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	txEntries := idx.tx.Entries()
+	txEntries := tx.Entries()
 
 	var txmd []byte
 
-	if idx.tx.header.Metadata != nil {
-		txmd = idx.tx.header.Metadata.Bytes()
+	if tx.header.Metadata != nil {
+		txmd = tx.header.Metadata.Bytes()
 	}
 
 	txmdLen := len(txmd)
@@ -403,16 +610,22 @@ func (idx *indexer) indexTX(txID uint64) error {
 		copy(b[o:], kvmd)
 		o += kvmdLen
 
-		idx.store._kvs[indexableEntries].K = e.key()
-		idx.store._kvs[indexableEntries].V = b[:o]
+		kvs[indexableEntries].K = e.key()
+		kvs[indexableEntries].V = b[:o]
 
 		indexableEntries++
 	}
 
-	if indexableEntries == 0 {
+	return indexableEntries, nil
+}
+
+func (idx *indexer) insertTx(txID uint64, slot *indexingSlot) error {
+	var err error
+
+	if slot.indexableEntries == 0 {
 		err = idx.index.IncreaseTs(txID)
 	} else {
-		err = idx.index.BulkInsert(idx.store._kvs[:indexableEntries])
+		err = idx.index.BulkInsert(slot.kvs[:slot.indexableEntries])
 	}
 	if err != nil {
 		return err
@@ -29,9 +29,13 @@ import (
 )
 
 func TestNewIndexerFailure(t *testing.T) {
-	indexer, err := newIndexer("data", nil, nil, 0)
+	indexer, err := newIndexer("data", nil, nil, 0, 1)
 	require.Nil(t, indexer)
 	require.Equal(t, tbtree.ErrIllegalArguments, err)
+
+	indexer, err = newIndexer("data", nil, tbtree.DefaultOptions(), 0, 0)
+	require.Nil(t, indexer)
+	require.Equal(t, ErrIllegalArguments, err)
 }
 
 func TestClosedIndexerFailures(t *testing.T) {
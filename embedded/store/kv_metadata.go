@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -30,13 +31,30 @@ const (
 	deletedAttrCode      attributeCode = 0
 	expiresAtAttrCode    attributeCode = 1
 	nonIndexableAttrCode attributeCode = 2
+	contentTypeAttrCode  attributeCode = 3
+	encodingAttrCode     attributeCode = 4
+	headersAttrCode      attributeCode = 5
 )
 
+const maxContentTypeLen = 127
+const maxEncodingLen = 32
+
+// headers are serialized as the number of headers followed by length-prefixed keys and values
+const maxHeadersLen = 255
+
 const deletedAttrSize = 0
 const expiresAtAttrSize = tsSize
 const nonIndexableAttrSize = 0
+const maxContentTypeAttrSize = 1 + maxContentTypeLen
+const maxEncodingAttrSize = 1 + maxEncodingLen
+const maxHeadersAttrSize = 1 + maxHeadersLen
 
-const maxKVMetadataLen = (attrCodeSize + deletedAttrSize) + (attrCodeSize + expiresAtAttrSize) + (attrCodeSize + nonIndexableAttrSize)
+const maxKVMetadataLen = (attrCodeSize + deletedAttrSize) +
+	(attrCodeSize + expiresAtAttrSize) +
+	(attrCodeSize + nonIndexableAttrSize) +
+	(attrCodeSize + maxContentTypeAttrSize) +
+	(attrCodeSize + maxEncodingAttrSize) +
+	(attrCodeSize + maxHeadersAttrSize)
 
 type KVMetadata struct {
 	attributes map[attributeCode]attribute
@@ -97,6 +115,150 @@ func (a *nonIndexableAttribute) deserialize(b []byte) (int, error) {
 	return 0, nil
 }
 
+type contentTypeAttribute struct {
+	contentType string
+}
+
+func (a *contentTypeAttribute) code() attributeCode {
+	return contentTypeAttrCode
+}
+
+func (a *contentTypeAttribute) serialize() []byte {
+	return serializeString(a.contentType)
+}
+
+func (a *contentTypeAttribute) deserialize(b []byte) (int, error) {
+	contentType, n, err := deserializeString(b, maxContentTypeLen)
+	if err != nil {
+		return 0, err
+	}
+
+	a.contentType = contentType
+
+	return n, nil
+}
+
+type encodingAttribute struct {
+	encoding string
+}
+
+func (a *encodingAttribute) code() attributeCode {
+	return encodingAttrCode
+}
+
+func (a *encodingAttribute) serialize() []byte {
+	return serializeString(a.encoding)
+}
+
+func (a *encodingAttribute) deserialize(b []byte) (int, error) {
+	encoding, n, err := deserializeString(b, maxEncodingLen)
+	if err != nil {
+		return 0, err
+	}
+
+	a.encoding = encoding
+
+	return n, nil
+}
+
+type headersAttribute struct {
+	headers map[string]string
+}
+
+func (a *headersAttribute) code() attributeCode {
+	return headersAttrCode
+}
+
+func (a *headersAttribute) serializedSize() int {
+	size := 1
+
+	for k, v := range a.headers {
+		size += 1 + len(k) + 1 + len(v)
+	}
+
+	return size
+}
+
+func (a *headersAttribute) serialize() []byte {
+	keys := make([]string, 0, len(a.headers))
+	for k := range a.headers {
+		keys = append(keys, k)
+	}
+
+	// headers are sorted so the serialization is deterministic
+	sort.Strings(keys)
+
+	b := []byte{byte(len(keys))}
+
+	for _, k := range keys {
+		b = append(b, serializeString(k)...)
+		b = append(b, serializeString(a.headers[k])...)
+	}
+
+	return b
+}
+
+func (a *headersAttribute) deserialize(b []byte) (int, error) {
+	if len(b) < 1 {
+		return 0, ErrCorruptedData
+	}
+
+	count := int(b[0])
+	i := 1
+
+	headers := make(map[string]string, count)
+
+	for j := 0; j < count; j++ {
+		k, n, err := deserializeString(b[i:], maxHeadersLen)
+		if err != nil {
+			return 0, err
+		}
+		i += n
+
+		v, n, err := deserializeString(b[i:], maxHeadersLen)
+		if err != nil {
+			return 0, err
+		}
+		i += n
+
+		_, duplicated := headers[k]
+		if len(k) == 0 || duplicated {
+			return 0, ErrCorruptedData
+		}
+
+		headers[k] = v
+	}
+
+	if i > maxHeadersAttrSize {
+		return 0, ErrCorruptedData
+	}
+
+	a.headers = headers
+
+	return i, nil
+}
+
+func serializeString(s string) []byte {
+	b := make([]byte, 1+len(s))
+	b[0] = byte(len(s))
+	copy(b[1:], s)
+	return b
+}
+
+func deserializeString(b []byte, maxLen int) (string, int, error) {
+	if len(b) < 1 {
+		return "", 0, ErrCorruptedData
+	}
+
+	sLen := int(b[0])
+
+	if sLen > maxLen || len(b) < 1+sLen {
+		return "", 0, ErrCorruptedData
+	}
+
+	return string(b[1 : 1+sLen]), 1 + sLen, nil
+}
+
 func NewKVMetadata() *KVMetadata {
 	return &KVMetadata{
 		attributes: make(map[attributeCode]attribute),
@@ -200,10 +362,138 @@ func (md *KVMetadata) NonIndexable() bool {
 	return ok
 }
 
+// SetContentType sets the media type of the value, an empty content type removes it
+func (md *KVMetadata) SetContentType(contentType string) error {
+	if md.readonly {
+		return ErrReadOnly
+	}
+
+	if contentType == "" {
+		delete(md.attributes, contentTypeAttrCode)
+		return nil
+	}
+
+	md.attributes[contentTypeAttrCode] = &contentTypeAttribute{contentType: contentType}
+
+	return nil
+}
+
+func (md *KVMetadata) ContentType() string {
+	attr, ok := md.attributes[contentTypeAttrCode]
+	if !ok {
+		return ""
+	}
+
+	return attr.(*contentTypeAttribute).contentType
+}
+
+// SetEncoding sets the encoding applied to the value (e.g. gzip), an empty encoding removes it
+func (md *KVMetadata) SetEncoding(encoding string) error {
+	if md.readonly {
+		return ErrReadOnly
+	}
+
+	if encoding == "" {
+		delete(md.attributes, encodingAttrCode)
+		return nil
+	}
+
+	md.attributes[encodingAttrCode] = &encodingAttribute{encoding: encoding}
+
+	return nil
+}
+
+func (md *KVMetadata) Encoding() string {
+	attr, ok := md.attributes[encodingAttrCode]
+	if !ok {
+		return ""
+	}
+
+	return attr.(*encodingAttribute).encoding
+}
+
+// SetHeader sets the value of an application-defined header
+func (md *KVMetadata) SetHeader(key, value string) error {
+	if md.readonly {
+		return ErrReadOnly
+	}
+
+	attr, ok := md.attributes[headersAttrCode]
+	if !ok {
+		attr = &headersAttribute{headers: make(map[string]string)}
+		md.attributes[headersAttrCode] = attr
+	}
+
+	attr.(*headersAttribute).headers[key] = value
+
+	return nil
+}
+
+func (md *KVMetadata) Header(key string) (value string, ok bool) {
+	attr, exists := md.attributes[headersAttrCode]
+	if !exists {
+		return "", false
+	}
+
+	value, ok = attr.(*headersAttribute).headers[key]
+	return
+}
+
+// Headers returns a copy of the application-defined headers
+func (md *KVMetadata) Headers() map[string]string {
+	headers := make(map[string]string)
+
+	attr, ok := md.attributes[headersAttrCode]
+	if ok {
+		for k, v := range attr.(*headersAttribute).headers {
+			headers[k] = v
+		}
+	}
+
+	return headers
+}
+
+// validate checks typed attributes fit into their serialization limits
+func (md *KVMetadata) validate() error {
+	if md == nil {
+		return nil
+	}
+
+	if len(md.ContentType()) > maxContentTypeLen || len(md.Encoding()) > maxEncodingLen {
+		return ErrIllegalArguments
+	}
+
+	attr, ok := md.attributes[headersAttrCode]
+	if !ok {
+		return nil
+	}
+
+	headersAttr := attr.(*headersAttribute)
+
+	for k := range headersAttr.headers {
+		if len(k) == 0 {
+			return ErrIllegalArguments
+		}
+	}
+
+	if headersAttr.serializedSize() > maxHeadersAttrSize {
+		return ErrIllegalArguments
+	}
+
+	return nil
+}
+
 func (md *KVMetadata) Bytes() []byte {
 	var b bytes.Buffer
 
-	for _, attrCode := range []attributeCode{deletedAttrCode, expiresAtAttrCode, nonIndexableAttrCode} {
+	for _, attrCode := range []attributeCode{
+		deletedAttrCode,
+		expiresAtAttrCode,
+		nonIndexableAttrCode,
+		contentTypeAttrCode,
+		encodingAttrCode,
+		headersAttrCode,
+	} {
 		attr, ok := md.attributes[attrCode]
 		if ok {
 			b.WriteByte(byte(attr.code()))
@@ -265,6 +555,18 @@ func newAttribute(attrCode attributeCode) (attribute, error) {
 		{
 			return &nonIndexableAttribute{}, nil
 		}
+	case contentTypeAttrCode:
+		{
+			return &contentTypeAttribute{}, nil
+		}
+	case encodingAttrCode:
+		{
+			return &encodingAttribute{}, nil
+		}
+	case headersAttrCode:
+		{
+			return &headersAttribute{}, nil
+		}
 	default:
 		{
 			return nil, fmt.Errorf("error reading metadata attributes: %w", ErrCorruptedData)
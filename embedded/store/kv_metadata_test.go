@@ -60,6 +60,15 @@ func TestKVMetadata(t *testing.T) {
 
 		err = desmd.AsNonIndexable(true)
 		require.ErrorIs(t, err, ErrReadOnly)
+
+		err = desmd.SetContentType("application/json")
+		require.ErrorIs(t, err, ErrReadOnly)
+
+		err = desmd.SetEncoding("gzip")
+		require.ErrorIs(t, err, ErrReadOnly)
+
+		err = desmd.SetHeader("schema", "v1")
+		require.ErrorIs(t, err, ErrReadOnly)
 	})
 
 	desmd := NewKVMetadata()
@@ -96,7 +105,7 @@ func TestKVMetadata(t *testing.T) {
 
 	bs = desmd.Bytes()
 	require.NotNil(t, bs)
-	require.Len(t, bs, maxKVMetadataLen)
+	require.Len(t, bs, (attrCodeSize+deletedAttrSize)+(attrCodeSize+expiresAtAttrSize)+(attrCodeSize+nonIndexableAttrSize))
 
 	err = desmd.unsafeReadFrom(bs)
 	require.NoError(t, err)
@@ -105,3 +114,102 @@ func TestKVMetadata(t *testing.T) {
 	require.True(t, desmd.ExpiredAt(now))
 	require.True(t, desmd.NonIndexable())
 }
+
+func TestKVMetadataTypedAttributes(t *testing.T) {
+	md := NewKVMetadata()
+
+	require.Empty(t, md.ContentType())
+	require.Empty(t, md.Encoding())
+	require.Empty(t, md.Headers())
+
+	_, ok := md.Header("schema")
+	require.False(t, ok)
+
+	require.NoError(t, md.SetContentType("application/json"))
+	require.NoError(t, md.SetEncoding("gzip"))
+	require.NoError(t, md.SetHeader("schema", "v1"))
+	require.NoError(t, md.SetHeader("origin", ""))
+	require.NoError(t, md.validate())
+
+	require.Equal(t, "application/json", md.ContentType())
+	require.Equal(t, "gzip", md.Encoding())
+
+	v, ok := md.Header("schema")
+	require.True(t, ok)
+	require.Equal(t, "v1", v)
+
+	headers := md.Headers()
+	require.Equal(t, map[string]string{"schema": "v1", "origin": ""}, headers)
+
+	headers["schema"] = "v2"
+	v, _ = md.Header("schema")
+	require.Equal(t, "v1", v)
+
+	desmd := newReadOnlyKVMetadata()
+	err := desmd.unsafeReadFrom(md.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, md.Bytes(), desmd.Bytes())
+	require.Equal(t, "application/json", desmd.ContentType())
+	require.Equal(t, "gzip", desmd.Encoding())
+	require.Equal(t, map[string]string{"schema": "v1", "origin": ""}, desmd.Headers())
+
+	require.NoError(t, md.SetContentType(""))
+	require.NoError(t, md.SetEncoding(""))
+	require.Empty(t, md.ContentType())
+	require.Empty(t, md.Encoding())
+
+	t.Run("validation", func(t *testing.T) {
+		require.NoError(t, (*KVMetadata)(nil).validate())
+
+		md := NewKVMetadata()
+		md.SetContentType(string(make([]byte, maxContentTypeLen+1)))
+		require.ErrorIs(t, md.validate(), ErrIllegalArguments)
+
+		md = NewKVMetadata()
+		md.SetEncoding(string(make([]byte, maxEncodingLen+1)))
+		require.ErrorIs(t, md.validate(), ErrIllegalArguments)
+
+		md = NewKVMetadata()
+		md.SetHeader("", "value")
+		require.ErrorIs(t, md.validate(), ErrIllegalArguments)
+
+		md = NewKVMetadata()
+		md.SetHeader("k1", string(make([]byte, 200)))
+		md.SetHeader("k2", string(make([]byte, 100)))
+		require.ErrorIs(t, md.validate(), ErrIllegalArguments)
+	})
+
+	t.Run("max length", func(t *testing.T) {
+		md := NewKVMetadata()
+		md.AsDeleted(true)
+		md.ExpiresAt(time.Now())
+		md.AsNonIndexable(true)
+		md.SetContentType(string(make([]byte, maxContentTypeLen)))
+		md.SetEncoding(string(make([]byte, maxEncodingLen)))
+		md.SetHeader("k", string(make([]byte, maxHeadersAttrSize-4)))
+		require.NoError(t, md.validate())
+
+		bs := md.Bytes()
+		require.Len(t, bs, maxKVMetadataLen)
+
+		desmd := newReadOnlyKVMetadata()
+		require.NoError(t, desmd.unsafeReadFrom(bs))
+		require.Equal(t, bs, desmd.Bytes())
+	})
+
+	t.Run("corrupted data", func(t *testing.T) {
+		for _, corrupted := range [][]byte{
+			{byte(contentTypeAttrCode)},
+			{byte(contentTypeAttrCode), 2, 'a'},
+			{byte(contentTypeAttrCode), maxContentTypeLen + 1},
+			{byte(encodingAttrCode), maxEncodingLen + 1},
+			{byte(headersAttrCode)},
+			{byte(headersAttrCode), 1, 1, 'k'},
+			{byte(headersAttrCode), 1, 0, 0},
+			{byte(headersAttrCode), 2, 1, 'k', 0, 1, 'k', 0},
+		} {
+			err := newReadOnlyKVMetadata().unsafeReadFrom(corrupted)
+			require.ErrorIs(t, err, ErrCorruptedData)
+		}
+	})
+}
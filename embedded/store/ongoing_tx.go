@@ -17,6 +17,8 @@ package store
 
 import (
 	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/hashing"
 )
 
 //OngoingTx (no-thread safe) represents an interactive or incremental transaction with support of RYOW.
@@ -73,10 +75,11 @@ func newReadWriteTx(s *ImmuStore) (*OngoingTx, error) {
 		entrySpec := tx.entries[keyRef]
 
 		return &ongoingValRef{
-			hc:    valRef.HC(),
-			value: entrySpec.Value,
-			txmd:  tx.metadata,
-			kvmd:  entrySpec.Metadata,
+			hc:      valRef.HC(),
+			value:   entrySpec.Value,
+			txmd:    tx.metadata,
+			kvmd:    entrySpec.Metadata,
+			hashAlg: s.hashAlg,
 		}
 	}
 
@@ -84,10 +87,11 @@ func newReadWriteTx(s *ImmuStore) (*OngoingTx, error) {
 }
 
 type ongoingValRef struct {
-	value []byte
-	hc    uint64
-	txmd  *TxMetadata
-	kvmd  *KVMetadata
+	value   []byte
+	hc      uint64
+	txmd    *TxMetadata
+	kvmd    *KVMetadata
+	hashAlg hashing.Algorithm
 }
 
 func (oref *ongoingValRef) Resolve() (val []byte, err error) {
@@ -111,7 +115,7 @@ func (oref *ongoingValRef) KVMetadata() *KVMetadata {
 }
 
 func (oref *ongoingValRef) HVal() [sha256.Size]byte {
-	return sha256.Sum256(oref.value)
+	return oref.hashAlg.Sum(oref.value)
 }
 
 func (oref *ongoingValRef) Len() uint32 {
@@ -21,6 +21,9 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/codenotary/immudb/pkg/logger"
 )
@@ -35,11 +38,17 @@ const DefaultMaxLinearProofLen = 1 << 10
 const DefaultFileSize = multiapp.DefaultFileSize
 const DefaultCompressionFormat = appendable.DefaultCompressionFormat
 const DefaultCompressionLevel = appendable.DefaultCompressionLevel
+const DefaultHashAlgorithm = hashing.DefaultAlgorithm
 const DefaultTxLogCacheSize = 1000
+const DefaultVLogCacheSize = 0
+const DefaultProofCacheSize = 1000
 const DefaultMaxWaitees = 1000
 const DefaultVLogMaxOpenedFiles = 10
 const DefaultTxLogMaxOpenedFiles = 10
 const DefaultCommitLogMaxOpenedFiles = 10
+const DefaultTruncationFrequency = 1 * time.Hour
+const DefaultGCSafetyWindow = 24 * time.Hour
+const DefaultIndexingWorkers = 1
 
 const MaxFileSize = (1 << 31) - 1 // 2Gb
 
@@ -60,12 +69,44 @@ type Options struct {
 	appFactory         AppFactoryFunc
 	CompactionDisabled bool
 
+	// transaction and value logs are read through memory mappings when possible
+	Mmap bool
+
+	// disk space reserved for each new transaction and value log file, preallocation is disabled when set to 0
+	PreallocSize int
+
+	// transaction and value logs are written bypassing the page cache when possible
+	DirectIO bool
+
+	// determines when committed transactions are synced, it only applies to synced stores.
+	// With group commit, transactions are synced at most every GroupCommitMaxLatency
+	Durability            DurabilityMode
+	GroupCommitMaxLatency time.Duration
+
 	MaxConcurrency    int
 	MaxIOConcurrency  int
 	MaxLinearProofLen int
 
+	// commits are rejected with ErrWriteBackpressure while MaxCommitQueueDepth commits are in progress
+	// or while indexing lags more than MaxIndexingLag transactions behind, 0 disables each limit
+	MaxCommitQueueDepth int
+	MaxIndexingLag      int
+
+	// values larger than BlobThreshold bytes are stored in a dedicated blob log, 0 disables blob storage
+	BlobThreshold int
+
 	TxLogCacheSize int
 
+	// number of values kept in memory, values are not cached when set to 0
+	VLogCacheSize int
+
+	// number of recently computed inclusion proofs into the binary linking tree kept in memory,
+	// proofs are not cached when set to 0
+	ProofCacheSize int
+
+	// eviction policy of the transaction, value and index node caches
+	CacheEvictionPolicy cache.EvictionPolicy
+
 	VLogMaxOpenedFiles      int
 	TxLogMaxOpenedFiles     int
 	CommitLogMaxOpenedFiles int
@@ -74,6 +115,37 @@ type Options struct {
 
 	TimeFunc TimeFunc
 
+	// values of transactions older than the retention period are periodically truncated,
+	// a zero retention period keeps all the values
+	RetentionPeriod     time.Duration
+	TruncationFrequency time.Duration
+
+	// space used by superseded values is periodically reclaimed, values superseded
+	// within the safety window are kept, a zero frequency disables value garbage collection.
+	// Values of the keys held by GCHolds, if any, are never reclaimed
+	GCFrequency    time.Duration
+	GCSafetyWindow time.Duration
+	GCHolds        GCHolds
+
+	// values can not be erased, truncated nor reclaimed before the retain until date (WORM compliance),
+	// a zero date does not lock the values
+	RetainUntil time.Time
+
+	// a randomly selected transaction is verified at the given frequency, 0 disables scrubbing.
+	// Corruptions detected by the scrubber are reported to the CorruptionHandler, if any
+	ScrubFrequency    time.Duration
+	CorruptionHandler CorruptionHandler
+
+	// sealed segments of the transaction and value logs are shipped to the archive at the given frequency,
+	// archiving is not supported together with encryption
+	Archive          SegmentArchive
+	ArchiveFrequency time.Duration
+
+	// appendable files are encrypted with data keys wrapped by the master keys of the provider,
+	// encryption can only be enabled when the store is created
+	KeyProvider encryption.MasterKeyProvider
+	keyRing     *encryption.KeyRing
+
 	// options below are only set during initialization and stored as metadata
 	MaxTxEntries      int
 	MaxKeyLen         int
@@ -81,6 +153,7 @@ type Options struct {
 	FileSize          int
 	CompressionFormat int
 	CompressionLevel  int
+	HashAlgorithm     hashing.Algorithm
 
 	// options below affect indexing
 	IndexOpts *IndexOptions
@@ -95,10 +168,14 @@ type IndexOptions struct {
 	RenewSnapRootAfter       time.Duration
 	CompactionThld           int
 	DelayDuringCompaction    time.Duration
+	AutoCompactionInterval   time.Duration
+	CheckpointInterval       time.Duration
 	Synced                   bool
 	NodesLogMaxOpenedFiles   int
 	HistoryLogMaxOpenedFiles int
 	CommitLogMaxOpenedFiles  int
+	BloomFilterKeys          int
+	IndexingWorkers          int
 }
 
 func DefaultOptions() *Options {
@@ -112,8 +189,15 @@ func DefaultOptions() *Options {
 		MaxIOConcurrency:  DefaultMaxIOConcurrency,
 		MaxLinearProofLen: DefaultMaxLinearProofLen,
 
+		Durability:            DefaultDurability,
+		GroupCommitMaxLatency: DefaultGroupCommitMaxLatency,
+
 		TxLogCacheSize: DefaultTxLogCacheSize,
 
+		VLogCacheSize:       DefaultVLogCacheSize,
+		ProofCacheSize:      DefaultProofCacheSize,
+		CacheEvictionPolicy: cache.DefaultEvictionPolicy,
+
 		VLogMaxOpenedFiles:      DefaultVLogMaxOpenedFiles,
 		TxLogMaxOpenedFiles:     DefaultTxLogMaxOpenedFiles,
 		CommitLogMaxOpenedFiles: DefaultCommitLogMaxOpenedFiles,
@@ -124,6 +208,14 @@ func DefaultOptions() *Options {
 			return time.Now()
 		},
 
+		RetentionPeriod:     0,
+		TruncationFrequency: DefaultTruncationFrequency,
+
+		GCFrequency:    0,
+		GCSafetyWindow: DefaultGCSafetyWindow,
+
+		ArchiveFrequency: DefaultArchiveFrequency,
+
 		// options below are only set during initialization and stored as metadata
 		MaxTxEntries:      DefaultMaxTxEntries,
 		MaxKeyLen:         DefaultMaxKeyLen,
@@ -131,6 +223,7 @@ func DefaultOptions() *Options {
 		FileSize:          DefaultFileSize,
 		CompressionFormat: DefaultCompressionFormat,
 		CompressionLevel:  DefaultCompressionLevel,
+		HashAlgorithm:     DefaultHashAlgorithm,
 
 		IndexOpts: DefaultIndexOptions(),
 	}
@@ -145,10 +238,14 @@ func DefaultIndexOptions() *IndexOptions {
 		RenewSnapRootAfter:       tbtree.DefaultRenewSnapRootAfter,
 		CompactionThld:           tbtree.DefaultCompactionThld,
 		DelayDuringCompaction:    0,
+		AutoCompactionInterval:   0,
+		CheckpointInterval:       0,
 		Synced:                   true,
 		NodesLogMaxOpenedFiles:   tbtree.DefaultNodesLogMaxOpenedFiles,
 		HistoryLogMaxOpenedFiles: tbtree.DefaultHistoryLogMaxOpenedFiles,
 		CommitLogMaxOpenedFiles:  tbtree.DefaultCommitLogMaxOpenedFiles,
+		BloomFilterKeys:          tbtree.DefaultBloomFilterKeys,
+		IndexingWorkers:          DefaultIndexingWorkers,
 	}
 }
 
@@ -159,16 +256,42 @@ func validOptions(opts *Options) bool {
 		opts.MaxIOConcurrency <= MaxParallelIO &&
 		opts.MaxLinearProofLen >= 0 &&
 
+		opts.MaxCommitQueueDepth >= 0 &&
+		opts.MaxIndexingLag >= 0 &&
+
+		opts.BlobThreshold >= 0 &&
+		(opts.BlobThreshold == 0 || opts.MaxIOConcurrency < MaxParallelIO) &&
+
+		ValidDurabilityMode(opts.Durability) &&
+		opts.GroupCommitMaxLatency > 0 &&
+
 		opts.VLogMaxOpenedFiles > 0 &&
 		opts.TxLogMaxOpenedFiles > 0 &&
 		opts.CommitLogMaxOpenedFiles > 0 &&
 
 		opts.TxLogCacheSize >= 0 &&
+		opts.VLogCacheSize >= 0 &&
+		opts.ProofCacheSize >= 0 &&
+		cache.ValidEvictionPolicy(opts.CacheEvictionPolicy) &&
 
 		opts.MaxWaitees >= 0 &&
 
 		opts.TimeFunc != nil &&
 
+		opts.RetentionPeriod >= 0 &&
+		(opts.RetentionPeriod == 0 || opts.TruncationFrequency > 0) &&
+
+		opts.GCFrequency >= 0 &&
+		opts.GCSafetyWindow >= 0 &&
+
+		opts.ScrubFrequency >= 0 &&
+
+		(opts.Archive == nil || (opts.ArchiveFrequency > 0 && opts.KeyProvider == nil)) &&
+
+		opts.PreallocSize >= 0 &&
+
+		(opts.KeyProvider == nil || (opts.appFactory == nil && opts.CompressionFormat == appendable.NoCompression)) &&
+
 		// options below are only set during initialization and stored as metadata
 		opts.MaxTxEntries > 0 &&
 		opts.MaxKeyLen > 0 &&
@@ -176,6 +299,7 @@ func validOptions(opts *Options) bool {
 		opts.MaxValueLen > 0 &&
 		opts.FileSize > 0 &&
 		opts.FileSize < MaxFileSize &&
+		opts.HashAlgorithm.Supported() &&
 		opts.log != nil &&
 		validIndexOptions(opts.IndexOpts)
 }
@@ -187,9 +311,13 @@ func validIndexOptions(opts *IndexOptions) bool {
 		opts.MaxActiveSnapshots > 0 &&
 		opts.MaxNodeSize > 0 &&
 		opts.RenewSnapRootAfter >= 0 &&
+		opts.AutoCompactionInterval >= 0 &&
+		opts.CheckpointInterval >= 0 &&
 		opts.NodesLogMaxOpenedFiles > 0 &&
 		opts.HistoryLogMaxOpenedFiles > 0 &&
-		opts.CommitLogMaxOpenedFiles > 0
+		opts.CommitLogMaxOpenedFiles > 0 &&
+		opts.BloomFilterKeys >= 0 &&
+		opts.IndexingWorkers > 0
 }
 
 func (opts *Options) WithReadOnly(readOnly bool) *Options {
@@ -207,6 +335,25 @@ func (opts *Options) WithFileMode(fileMode os.FileMode) *Options {
 	return opts
 }
 
+func (opts *Options) WithMmap(mmap bool) *Options {
+	opts.Mmap = mmap
+	return opts
+}
+
+// WithPreallocSize sets the disk space reserved when a transaction or value log file is created,
+// it's capped to the file size
+func (opts *Options) WithPreallocSize(preallocSize int) *Options {
+	opts.PreallocSize = preallocSize
+	return opts
+}
+
+// WithDirectIO enables writing transaction and value logs through direct I/O, so data is not
+// held in the page cache besides the store caches. Buffered writes are used when direct I/O is not available
+func (opts *Options) WithDirectIO(directIO bool) *Options {
+	opts.DirectIO = directIO
+	return opts
+}
+
 func (opts *Options) WithLog(log logger.Logger) *Options {
 	opts.log = log
 	return opts
@@ -257,6 +404,21 @@ func (opts *Options) WithTxLogCacheSize(txLogCacheSize int) *Options {
 	return opts
 }
 
+func (opts *Options) WithVLogCacheSize(vLogCacheSize int) *Options {
+	opts.VLogCacheSize = vLogCacheSize
+	return opts
+}
+
+func (opts *Options) WithProofCacheSize(proofCacheSize int) *Options {
+	opts.ProofCacheSize = proofCacheSize
+	return opts
+}
+
+func (opts *Options) WithCacheEvictionPolicy(cacheEvictionPolicy cache.EvictionPolicy) *Options {
+	opts.CacheEvictionPolicy = cacheEvictionPolicy
+	return opts
+}
+
 func (opts *Options) WithFileSize(fileSize int) *Options {
 	opts.FileSize = fileSize
 	return opts
@@ -287,6 +449,86 @@ func (opts *Options) WithTimeFunc(timeFunc TimeFunc) *Options {
 	return opts
 }
 
+func (opts *Options) WithRetentionPeriod(retentionPeriod time.Duration) *Options {
+	opts.RetentionPeriod = retentionPeriod
+	return opts
+}
+
+func (opts *Options) WithTruncationFrequency(truncationFrequency time.Duration) *Options {
+	opts.TruncationFrequency = truncationFrequency
+	return opts
+}
+
+func (opts *Options) WithRetainUntil(retainUntil time.Time) *Options {
+	opts.RetainUntil = retainUntil
+	return opts
+}
+
+func (opts *Options) WithGCFrequency(gcFrequency time.Duration) *Options {
+	opts.GCFrequency = gcFrequency
+	return opts
+}
+
+func (opts *Options) WithDurability(durability DurabilityMode) *Options {
+	opts.Durability = durability
+	return opts
+}
+
+func (opts *Options) WithGroupCommitMaxLatency(groupCommitMaxLatency time.Duration) *Options {
+	opts.GroupCommitMaxLatency = groupCommitMaxLatency
+	return opts
+}
+
+func (opts *Options) WithMaxCommitQueueDepth(maxCommitQueueDepth int) *Options {
+	opts.MaxCommitQueueDepth = maxCommitQueueDepth
+	return opts
+}
+
+func (opts *Options) WithMaxIndexingLag(maxIndexingLag int) *Options {
+	opts.MaxIndexingLag = maxIndexingLag
+	return opts
+}
+
+func (opts *Options) WithBlobThreshold(blobThreshold int) *Options {
+	opts.BlobThreshold = blobThreshold
+	return opts
+}
+
+func (opts *Options) WithScrubFrequency(scrubFrequency time.Duration) *Options {
+	opts.ScrubFrequency = scrubFrequency
+	return opts
+}
+
+func (opts *Options) WithCorruptionHandler(corruptionHandler CorruptionHandler) *Options {
+	opts.CorruptionHandler = corruptionHandler
+	return opts
+}
+
+func (opts *Options) WithArchive(archive SegmentArchive) *Options {
+	opts.Archive = archive
+	return opts
+}
+
+func (opts *Options) WithArchiveFrequency(archiveFrequency time.Duration) *Options {
+	opts.ArchiveFrequency = archiveFrequency
+	return opts
+}
+
+func (opts *Options) WithGCSafetyWindow(gcSafetyWindow time.Duration) *Options {
+	opts.GCSafetyWindow = gcSafetyWindow
+	return opts
+}
+
+func (opts *Options) WithGCHolds(gcHolds GCHolds) *Options {
+	opts.GCHolds = gcHolds
+	return opts
+}
+
+func (opts *Options) WithKeyProvider(keyProvider encryption.MasterKeyProvider) *Options {
+	opts.KeyProvider = keyProvider
+	return opts
+}
+
 func (opts *Options) WithCompressionFormat(compressionFormat int) *Options {
 	opts.CompressionFormat = compressionFormat
 	return opts
@@ -297,6 +539,11 @@ func (opts *Options) WithCompresionLevel(compressionLevel int) *Options {
 	return opts
 }
 
+func (opts *Options) WithHashAlgorithm(hashAlgorithm hashing.Algorithm) *Options {
+	opts.HashAlgorithm = hashAlgorithm
+	return opts
+}
+
 func (opts *Options) WithIndexOptions(indexOptions *IndexOptions) *Options {
 	opts.IndexOpts = indexOptions
 	return opts
@@ -344,6 +591,22 @@ func (opts *IndexOptions) WithDelayDuringCompaction(delayDuringCompaction time.D
 	return opts
 }
 
+// WithAutoCompactionInterval sets how often the index is compacted in background,
+// compaction only takes place when the compaction threshold is reached.
+// A zero interval disables background compaction.
+func (opts *IndexOptions) WithAutoCompactionInterval(autoCompactionInterval time.Duration) *IndexOptions {
+	opts.AutoCompactionInterval = autoCompactionInterval
+	return opts
+}
+
+// WithCheckpointInterval sets how often the index is durably persisted in background,
+// so reopening the store after a crash only re-indexes transactions committed since the last checkpoint.
+// A zero interval disables checkpointing.
+func (opts *IndexOptions) WithCheckpointInterval(checkpointInterval time.Duration) *IndexOptions {
+	opts.CheckpointInterval = checkpointInterval
+	return opts
+}
+
 func (opts *IndexOptions) WithSynced(synced bool) *IndexOptions {
 	opts.Synced = synced
 	return opts
@@ -363,3 +626,17 @@ func (opts *IndexOptions) WithCommitLogMaxOpenedFiles(commitLogMaxOpenedFiles in
 	opts.CommitLogMaxOpenedFiles = commitLogMaxOpenedFiles
 	return opts
 }
+
+// WithBloomFilterKeys enables a bloom filter holding every indexed key so that lookups of absent keys
+// avoid traversing the index. It sets the number of keys held by each filter segment, 0 disables it.
+func (opts *IndexOptions) WithBloomFilterKeys(bloomFilterKeys int) *IndexOptions {
+	opts.BloomFilterKeys = bloomFilterKeys
+	return opts
+}
+
+// WithIndexingWorkers sets the number of transactions read and prepared in parallel ahead of
+// their insertion into the index. Transactions are still inserted in commit order.
+func (opts *IndexOptions) WithIndexingWorkers(indexingWorkers int) *IndexOptions {
+	opts.IndexingWorkers = indexingWorkers
+	return opts
+}
@@ -21,6 +21,8 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,6 +50,12 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, DefaultMaxTxEntries, opts.WithMaxTxEntries(DefaultMaxTxEntries).MaxTxEntries)
 	require.Equal(t, DefaultMaxValueLen, opts.WithMaxValueLen(DefaultMaxValueLen).MaxValueLen)
 	require.Equal(t, DefaultTxLogCacheSize, opts.WithTxLogCacheSize(DefaultOptions().TxLogCacheSize).TxLogCacheSize)
+	require.Equal(t, DefaultVLogCacheSize, opts.WithVLogCacheSize(DefaultOptions().VLogCacheSize).VLogCacheSize)
+	require.Equal(t, DefaultProofCacheSize, opts.WithProofCacheSize(DefaultOptions().ProofCacheSize).ProofCacheSize)
+	require.Equal(t, cache.ARC, opts.WithCacheEvictionPolicy(cache.ARC).CacheEvictionPolicy)
+	require.Equal(t, DurabilityGroupCommit, opts.WithDurability(DurabilityGroupCommit).Durability)
+	require.Equal(t, time.Minute, opts.WithScrubFrequency(time.Minute).ScrubFrequency)
+	require.Equal(t, time.Second, opts.WithGroupCommitMaxLatency(time.Second).GroupCommitMaxLatency)
 	require.Equal(t, 2, opts.WithTxLogMaxOpenedFiles(2).TxLogMaxOpenedFiles)
 	require.Equal(t, 3, opts.WithVLogMaxOpenedFiles(3).VLogMaxOpenedFiles)
 	require.Equal(t, DefaultMaxWaitees, opts.WithMaxWaitees(DefaultMaxWaitees).MaxWaitees)
@@ -58,6 +66,9 @@ func TestValidOptions(t *testing.T) {
 	require.NotNil(t, opts.WithTimeFunc(timeFun).TimeFunc)
 
 	require.True(t, opts.WithSynced(true).Synced)
+	require.True(t, opts.WithMmap(true).Mmap)
+	require.Equal(t, 1<<20, opts.WithPreallocSize(1<<20).PreallocSize)
+	require.True(t, opts.WithDirectIO(true).DirectIO)
 
 	require.NotNil(t, opts.WithIndexOptions(DefaultIndexOptions()).IndexOpts)
 
@@ -69,6 +80,64 @@ func TestValidOptions(t *testing.T) {
 
 	require.True(t, validOptions(opts))
 
+	require.Equal(t, time.Hour, opts.WithRetentionPeriod(time.Hour).RetentionPeriod)
+	require.False(t, validOptions(opts))
+
+	require.Equal(t, time.Minute, opts.WithTruncationFrequency(time.Minute).TruncationFrequency)
+	require.True(t, validOptions(opts))
+
+	require.Equal(t, time.Duration(0), opts.WithRetentionPeriod(0).RetentionPeriod)
+	require.True(t, validOptions(opts))
+
+	retainUntil := time.Now()
+	require.Equal(t, retainUntil, opts.WithRetainUntil(retainUntil).RetainUntil)
+	require.True(t, validOptions(opts))
+
+	require.Equal(t, -time.Minute, opts.WithGCFrequency(-time.Minute).GCFrequency)
+	require.False(t, validOptions(opts))
+
+	require.Equal(t, time.Minute, opts.WithGCFrequency(time.Minute).GCFrequency)
+	require.True(t, validOptions(opts))
+
+	require.Equal(t, -time.Hour, opts.WithGCSafetyWindow(-time.Hour).GCSafetyWindow)
+	require.False(t, validOptions(opts))
+
+	require.Equal(t, time.Hour, opts.WithGCSafetyWindow(time.Hour).GCSafetyWindow)
+	require.True(t, validOptions(opts))
+
+	require.Equal(t, -1, opts.WithMaxCommitQueueDepth(-1).MaxCommitQueueDepth)
+	require.False(t, validOptions(opts))
+
+	require.Equal(t, 10, opts.WithMaxCommitQueueDepth(10).MaxCommitQueueDepth)
+	require.True(t, validOptions(opts))
+
+	require.Equal(t, -1, opts.WithMaxIndexingLag(-1).MaxIndexingLag)
+	require.False(t, validOptions(opts))
+
+	require.Equal(t, 100, opts.WithMaxIndexingLag(100).MaxIndexingLag)
+	require.True(t, validOptions(opts))
+
+	require.Equal(t, -1, opts.WithBlobThreshold(-1).BlobThreshold)
+	require.False(t, validOptions(opts))
+
+	require.Equal(t, 1024, opts.WithBlobThreshold(1024).BlobThreshold)
+	require.True(t, validOptions(opts))
+
+	require.Equal(t, hashing.Algorithm(99), opts.WithHashAlgorithm(hashing.Algorithm(99)).HashAlgorithm)
+	require.False(t, validOptions(opts))
+
+	require.Equal(t, hashing.BLAKE3, opts.WithHashAlgorithm(hashing.BLAKE3).HashAlgorithm)
+	require.True(t, validOptions(opts))
+
+	require.Equal(t, MaxParallelIO, opts.WithMaxIOConcurrency(MaxParallelIO).MaxIOConcurrency)
+	require.False(t, validOptions(opts))
+
+	require.Equal(t, DefaultMaxIOConcurrency, opts.WithMaxIOConcurrency(DefaultMaxIOConcurrency).MaxIOConcurrency)
+	require.True(t, validOptions(opts))
+
+	require.Nil(t, opts.WithKeyProvider(nil).KeyProvider)
+	require.True(t, validOptions(opts))
+
 	require.True(t, opts.WithReadOnly(true).ReadOnly)
 	require.True(t, validOptions(opts))
 
@@ -104,8 +173,11 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, 10, indexOpts.WithNodesLogMaxOpenedFiles(10).NodesLogMaxOpenedFiles)
 	require.Equal(t, 11, indexOpts.WithHistoryLogMaxOpenedFiles(11).HistoryLogMaxOpenedFiles)
 	require.Equal(t, 12, indexOpts.WithCommitLogMaxOpenedFiles(12).CommitLogMaxOpenedFiles)
+	require.Equal(t, 1000, indexOpts.WithBloomFilterKeys(1000).BloomFilterKeys)
+	require.Equal(t, 4, indexOpts.WithIndexingWorkers(4).IndexingWorkers)
 	require.Equal(t, 3, indexOpts.WithCompactionThld(3).CompactionThld)
 	require.Equal(t, 1*time.Millisecond, indexOpts.WithDelayDuringCompaction(1*time.Millisecond).DelayDuringCompaction)
+	require.Equal(t, time.Minute, indexOpts.WithCheckpointInterval(time.Minute).CheckpointInterval)
 	require.Equal(t, true, indexOpts.WithSynced(true).Synced)
 
 	require.True(t, validOptions(opts))
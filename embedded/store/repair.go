@@ -0,0 +1,182 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/appendable/singleapp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var metricsRepairedValues = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_repaired_values",
+	Help: "Number of damaged values restored from a repair source",
+}, []string{
+	"db",
+})
+
+// TxFetcher returns the transaction txID as produced by ExportTx,
+// usually by fetching it from a replica or from the primary database
+type TxFetcher func(txID uint64) ([]byte, error)
+
+// valueOverwriter is implemented by value logs able to overwrite already appended data
+type valueOverwriter interface {
+	WriteAt(bs []byte, off int64) (int, error)
+}
+
+// SetRepairSource sets the source used by the scrubber to repair the damaged values it detects,
+// automatic repair is disabled when fetchTx is nil
+func (s *ImmuStore) SetRepairSource(fetchTx TxFetcher) {
+	s.repairSourceMutex.Lock()
+	defer s.repairSourceMutex.Unlock()
+
+	s.repairSource = fetchTx
+}
+
+func (s *ImmuStore) getRepairSource() TxFetcher {
+	s.repairSourceMutex.RLock()
+	defer s.repairSourceMutex.RUnlock()
+
+	return s.repairSource
+}
+
+// RepairTx restores the damaged values of the transaction txID with the ones fetched with fetchTx.
+// The fetched transaction must have the same accumulative linear hash as the local one and each
+// of its values must match the digest stored locally, otherwise ErrRepairSourceMismatch is returned.
+// Only values can be repaired, damaged transaction headers require a restore.
+// The number of repaired values is returned.
+func (s *ImmuStore) RepairTx(txID uint64, fetchTx TxFetcher) (int, error) {
+	if txID == 0 || fetchTx == nil {
+		return 0, ErrIllegalArguments
+	}
+
+	if s.readOnly {
+		return 0, ErrRepairUnsupported
+	}
+
+	tx := s.NewTxHolder()
+
+	err := s.readTx(txID, tx, false)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.checkBinaryLinking(tx)
+	if err != nil {
+		return 0, fmt.Errorf("%w: header of tx %d is damaged: %v", ErrRepairUnsupported, txID, err)
+	}
+
+	var damaged []int
+
+	for i, e := range tx.Entries() {
+		_, err = s.readValueFromLog(make([]byte, e.vLen), e.vOff, e.hVal)
+		if errors.Is(err, ErrCorruptedData) {
+			damaged = append(damaged, i)
+			continue
+		}
+		if err != nil && err != ErrValueReclaimed && err != ErrValueTruncated && err != ErrValueErased {
+			return 0, err
+		}
+	}
+
+	if len(damaged) == 0 {
+		return 0, nil
+	}
+
+	exportedTx, err := fetchTx(txID)
+	if err != nil {
+		return 0, err
+	}
+
+	hdr, exportedEntries, err := readExportedTx(exportedTx, s.hashAlg)
+	if err != nil {
+		return 0, err
+	}
+
+	if hdr.ID != txID || hdr.Alh() != tx.header.Alh() || len(exportedEntries) != len(tx.Entries()) {
+		return 0, fmt.Errorf("%w: tx %d", ErrRepairSourceMismatch, txID)
+	}
+
+	for _, i := range damaged {
+		e := tx.Entries()[i]
+		ee := exportedEntries[i]
+
+		if string(ee.key) != string(e.key()) || s.hashAlg.Sum(ee.value) != e.hVal {
+			return 0, fmt.Errorf("%w: entry '%s' of tx %d", ErrRepairSourceMismatch, e.key(), txID)
+		}
+	}
+
+	for n, i := range damaged {
+		e := tx.Entries()[i]
+
+		err = s.overwriteValueAt(exportedEntries[i].value, e.vOff)
+		if err != nil {
+			return n, err
+		}
+
+		_, err = s.readValueFromLog(make([]byte, e.vLen), e.vOff, e.hVal)
+		if err != nil {
+			return n, err
+		}
+
+		s.metricsRepairedValues.Inc()
+	}
+
+	return len(damaged), nil
+}
+
+func (s *ImmuStore) overwriteValueAt(val []byte, off int64) error {
+	vLogID, offset := decodeOffset(off)
+
+	if vLogID == 0 {
+		// values of zero length are not stored
+		return nil
+	}
+
+	err := s.checkVLog(vLogID)
+	if err != nil {
+		return err
+	}
+
+	vLog := s.fetchVLog(vLogID)
+	defer s.releaseVLog(vLogID)
+
+	ow, ok := vLog.(valueOverwriter)
+	if !ok {
+		return ErrRepairUnsupported
+	}
+
+	_, err = ow.WriteAt(val, offset)
+	if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed {
+		return ErrAlreadyClosed
+	}
+	if err == multiapp.ErrDiscarded {
+		return ErrValueTruncated
+	}
+	if err == singleapp.ErrWriteAtUnsupported {
+		return ErrRepairUnsupported
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.purgeCachedValue(off)
+}
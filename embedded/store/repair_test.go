@@ -0,0 +1,254 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreRepairTx(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false)
+
+	primary, err := Open("data_repair_primary", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_repair_primary")
+
+	defer primary.Close()
+
+	replica, err := Open("data_repair_replica", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_repair_replica")
+
+	other, err := Open("data_repair_other", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_repair_other")
+
+	defer other.Close()
+
+	txHolder := primary.NewTxHolder()
+
+	for i := 0; i < 10; i++ {
+		tx, err := primary.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		etx, err := primary.ExportTx(hdr.ID, txHolder)
+		require.NoError(t, err)
+
+		_, err = replica.ReplicateTx(etx, false)
+		require.NoError(t, err)
+
+		// headers of following transactions are validated against the binary linking of the replica
+		require.Eventually(t, func() bool {
+			blSize, _ := replica.BlInfo()
+			return blSize == hdr.ID
+		}, 10*time.Second, time.Millisecond)
+
+		tx, err = other.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("other%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	fetchFrom := func(st *ImmuStore) TxFetcher {
+		return func(txID uint64) ([]byte, error) {
+			return st.ExportTx(txID, st.NewTxHolder())
+		}
+	}
+
+	_, err = replica.RepairTx(0, fetchFrom(primary))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = replica.RepairTx(1, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	// nothing to repair
+	n, err := replica.RepairTx(1, fetchFrom(primary))
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	err = replica.Close()
+	require.NoError(t, err)
+
+	// tamper the values set at tx 4 and tx 6
+	vLogPath := filepath.Join("data_repair_replica", "val_0", "00000000.val")
+
+	content, err := ioutil.ReadFile(vLogPath)
+	require.NoError(t, err)
+
+	for _, v := range []string{"value3", "value5"} {
+		i := bytes.Index(content, []byte(v))
+		require.Greater(t, i, 0)
+		content[i] = 'V'
+	}
+
+	err = ioutil.WriteFile(vLogPath, content, 0644)
+	require.NoError(t, err)
+
+	replica, err = Open("data_repair_replica", opts)
+	require.NoError(t, err)
+
+	defer replica.Close()
+
+	tx := replica.NewTxHolder()
+
+	err = replica.CheckTx(4, tx)
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	t.Run("repair from a source holding different transactions should fail", func(t *testing.T) {
+		_, err := replica.RepairTx(4, fetchFrom(other))
+		require.ErrorIs(t, err, ErrRepairSourceMismatch)
+
+		err = replica.CheckTx(4, tx)
+		require.ErrorIs(t, err, ErrCorruptedData)
+	})
+
+	t.Run("repair from the primary should restore damaged values", func(t *testing.T) {
+		n, err := replica.RepairTx(4, fetchFrom(primary))
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		err = replica.CheckTx(4, tx)
+		require.NoError(t, err)
+
+		err = replica.ReadTx(4, tx)
+		require.NoError(t, err)
+
+		v, err := replica.ReadValue(tx.Entries()[0])
+		require.NoError(t, err)
+		require.Equal(t, []byte("value3"), v)
+	})
+
+	t.Run("damaged values should be repaired by the scrubber", func(t *testing.T) {
+		err = replica.CheckTx(6, tx)
+		require.ErrorIs(t, err, ErrCorruptedData)
+
+		repaired := testutil.ToFloat64(replica.metricsRepairedValues)
+
+		replica.SetRepairSource(fetchFrom(primary))
+		replica.startScrubber(time.Millisecond)
+
+		require.Eventually(t, func() bool {
+			return replica.CheckTx(6, tx) == nil
+		}, 10*time.Second, time.Millisecond)
+
+		require.Equal(t, repaired+1, testutil.ToFloat64(replica.metricsRepairedValues))
+	})
+}
+
+func TestImmudbStoreRepairTxDirectIO(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false).WithDirectIO(true)
+
+	primary, err := Open("data_repair_dio_primary", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_repair_dio_primary")
+
+	defer primary.Close()
+
+	replica, err := Open("data_repair_dio_replica", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_repair_dio_replica")
+
+	replicate := func(i int) {
+		tx, err := primary.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		etx, err := primary.ExportTx(hdr.ID, primary.NewTxHolder())
+		require.NoError(t, err)
+
+		_, err = replica.ReplicateTx(etx, false)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			blSize, _ := replica.BlInfo()
+			return blSize == hdr.ID
+		}, 10*time.Second, time.Millisecond)
+	}
+
+	for i := 0; i < 5; i++ {
+		replicate(i)
+	}
+
+	err = replica.Close()
+	require.NoError(t, err)
+
+	vLogPath := filepath.Join("data_repair_dio_replica", "val_0", "00000000.val")
+
+	content, err := ioutil.ReadFile(vLogPath)
+	require.NoError(t, err)
+
+	i := bytes.Index(content, []byte("value3"))
+	require.Greater(t, i, 0)
+	content[i] = 'V'
+
+	err = ioutil.WriteFile(vLogPath, content, 0644)
+	require.NoError(t, err)
+
+	replica, err = Open("data_repair_dio_replica", opts)
+	require.NoError(t, err)
+
+	fetchFromPrimary := func(txID uint64) ([]byte, error) {
+		return primary.ExportTx(txID, primary.NewTxHolder())
+	}
+
+	// the damaged value lies within the partially written block of the value log
+	n, err := replica.RepairTx(4, fetchFromPrimary)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	// values appended afterwards must not bring the damaged value back
+	replicate(5)
+
+	err = replica.Close()
+	require.NoError(t, err)
+
+	replica, err = Open("data_repair_dio_replica", opts)
+	require.NoError(t, err)
+
+	defer replica.Close()
+
+	tx := replica.NewTxHolder()
+
+	for txID := uint64(1); txID <= 6; txID++ {
+		err = replica.CheckTx(txID, tx)
+		require.NoError(t, err)
+	}
+}
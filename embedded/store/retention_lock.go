@@ -0,0 +1,79 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// RetainUntil returns the date before which values can not be erased, truncated nor reclaimed,
+// the zero time when values are not retention locked
+func (s *ImmuStore) RetainUntil() time.Time {
+	retainUntil := atomic.LoadInt64(&s.retainUntil)
+	if retainUntil == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, retainUntil)
+}
+
+// SetRetainUntil locks the values until the given date. The retention date can only be extended,
+// so the lock can neither be shortened nor removed once set
+func (s *ImmuStore) SetRetainUntil(retainUntil time.Time) error {
+	if retainUntil.IsZero() {
+		return ErrIllegalArguments
+	}
+
+	for {
+		curr := atomic.LoadInt64(&s.retainUntil)
+
+		if retainUntil.UnixNano() < curr {
+			return fmt.Errorf("%w: retention date can not be shortened", ErrIllegalArguments)
+		}
+
+		if atomic.CompareAndSwapInt64(&s.retainUntil, curr, retainUntil.UnixNano()) {
+			return nil
+		}
+	}
+}
+
+// CheckRetentionLock returns ErrRetentionLocked when the retention date has not been reached yet.
+// The wall clock is used as the time function of the store may not follow it, i.e. when commit time is excluded
+func (s *ImmuStore) CheckRetentionLock() error {
+	return s.CheckRetentionLockAt(time.Now())
+}
+
+// CheckRetentionLockAt returns ErrRetentionLocked when values removed at the given time,
+// i.e. values expiring then, would be removed before the retention date
+func (s *ImmuStore) CheckRetentionLockAt(t time.Time) error {
+	retainUntil := atomic.LoadInt64(&s.retainUntil)
+
+	if retainUntil != 0 && t.UnixNano() < retainUntil {
+		return fmt.Errorf("%w: values are retained until %s", ErrRetentionLocked, time.Unix(0, retainUntil).UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func unixNanoOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
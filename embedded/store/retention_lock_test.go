@@ -0,0 +1,84 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreRetentionLock(t *testing.T) {
+	retainUntil := time.Unix(time.Now().Unix()+3600, 0)
+
+	opts := DefaultOptions().WithSynced(false).WithRetainUntil(retainUntil)
+
+	immuStore, err := Open("data_retention_lock", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_retention_lock")
+
+	require.Equal(t, retainUntil, immuStore.RetainUntil())
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+
+	_, err = immuStore.EraseValue(hdr.ID, []byte("key1"))
+	require.ErrorIs(t, err, ErrRetentionLocked)
+
+	err = immuStore.TruncateUptoTx(hdr.ID)
+	require.ErrorIs(t, err, ErrRetentionLocked)
+
+	_, err = immuStore.CollectGarbage()
+	require.ErrorIs(t, err, ErrRetentionLocked)
+
+	// values can not expire before the retention date either
+	err = immuStore.CheckRetentionLockAt(retainUntil.Add(-time.Second))
+	require.ErrorIs(t, err, ErrRetentionLocked)
+
+	err = immuStore.CheckRetentionLockAt(retainUntil)
+	require.NoError(t, err)
+
+	err = immuStore.SetRetainUntil(time.Time{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.SetRetainUntil(retainUntil.Add(-time.Minute))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.SetRetainUntil(retainUntil.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, retainUntil.Add(time.Hour), immuStore.RetainUntil())
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	// values can be removed once the retention date is reached
+	immuStore, err = Open("data_retention_lock", opts.WithRetainUntil(time.Now().Add(-time.Second)))
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	n, err := immuStore.EraseValue(hdr.ID, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, len("value1"), n)
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const DefaultScrubFrequency = 1 * time.Second
+
+var metricsScrubbedTxs = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_scrubbed_trxs",
+	Help: "Number of transactions verified by the background scrubber",
+}, []string{
+	"db",
+})
+
+var metricsScrubCorruptions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_scrub_corruptions",
+	Help: "Number of corrupted transactions detected by the background scrubber",
+}, []string{
+	"db",
+})
+
+// CorruptionHandler is notified of the corruptions detected by the scrubber,
+// err wraps one of the errors returned by CheckTx
+type CorruptionHandler func(txID uint64, err error)
+
+// CheckTx verifies the integrity of the transaction txID: its digests are re-computed from the
+// data stored in the transaction and value logs, its accumulative linear hash is checked against
+// the one of the preceding transaction and the binary linking tree, and the index is checked to
+// hold its entries. Errors wrapping ErrorCorruptedTxData, ErrCorruptedData, ErrCorruptedAHtree or
+// ErrCorruptedIndex are returned when an inconsistency is found.
+func (s *ImmuStore) CheckTx(txID uint64, tx *Tx) error {
+	if txID == 0 || tx == nil {
+		return ErrIllegalArguments
+	}
+
+	prevAlh := s.hashAlg.Sum(nil)
+
+	if txID > 1 {
+		// caches are bypassed so that the stored data is actually verified
+		err := s.readTx(txID-1, tx, false)
+		if err != nil {
+			return err
+		}
+
+		prevAlh = tx.header.Alh()
+	}
+
+	err := s.readTx(txID, tx, false)
+	if err != nil {
+		return err
+	}
+
+	if tx.header.PrevAlh != prevAlh {
+		return fmt.Errorf("%w: linear hash chain broken at tx %d", ErrorCorruptedTxData, txID)
+	}
+
+	err = s.checkBinaryLinking(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range tx.Entries() {
+		if e.md != nil && e.md.ExpiredAt(time.Now()) {
+			continue
+		}
+
+		_, err = s.readValueFromLog(make([]byte, e.vLen), e.vOff, e.hVal)
+		if errors.Is(err, ErrCorruptedData) {
+			return fmt.Errorf("%w: value of entry '%s' does not match its digest at tx %d", ErrCorruptedData, e.key(), txID)
+		}
+		if err != nil && err != ErrValueReclaimed && err != ErrValueTruncated && err != ErrValueErased {
+			return err
+		}
+
+		err = s.checkIndexedEntry(txID, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ImmuStore) checkBinaryLinking(tx *Tx) error {
+	blSize, _ := s.BlInfo()
+
+	if tx.header.ID <= blSize {
+		alh, err := s.aht.DataAt(tx.header.ID)
+		if err != nil {
+			return err
+		}
+
+		txAlh := tx.header.Alh()
+
+		if string(alh) != string(txAlh[:]) {
+			return fmt.Errorf("%w: accumulative linear hash of tx %d does not match", ErrCorruptedAHtree, tx.header.ID)
+		}
+	}
+
+	if tx.header.BlTxID > 0 && tx.header.BlTxID <= blSize {
+		blRoot, err := s.aht.RootAt(tx.header.BlTxID)
+		if err != nil {
+			return err
+		}
+
+		if blRoot != tx.header.BlRoot {
+			return fmt.Errorf("%w: binary linking root of tx %d does not match", ErrCorruptedAHtree, tx.header.ID)
+		}
+	}
+
+	return nil
+}
+
+// checkIndexedEntry checks the index holds the entry or a newer version of its key
+func (s *ImmuStore) checkIndexedEntry(txID uint64, e *TxEntry) error {
+	if e.md != nil && e.md.NonIndexable() {
+		return nil
+	}
+
+	if txID > s.indexer.Ts() {
+		// not yet indexed
+		return nil
+	}
+
+	indexedVal, indexedTx, _, err := s.indexer.Get(e.key())
+	if err == ErrKeyNotFound {
+		return fmt.Errorf("%w: key '%s' set at tx %d is not indexed", ErrCorruptedIndex, e.key(), txID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if indexedTx < txID {
+		return fmt.Errorf("%w: key '%s' is indexed at tx %d but it was set at tx %d", ErrCorruptedIndex, e.key(), indexedTx, txID)
+	}
+
+	if indexedTx > txID {
+		return nil
+	}
+
+	valRef, err := s.valueRefFrom(indexedTx, 0, indexedVal)
+	if err != nil {
+		return err
+	}
+
+	if valRef.HVal() != e.hVal || valRef.Len() != uint32(e.vLen) {
+		return fmt.Errorf("%w: indexed value of key '%s' does not match the one set at tx %d", ErrCorruptedIndex, e.key(), txID)
+	}
+
+	return nil
+}
+
+func isCorruptionErr(err error) bool {
+	return errors.Is(err, ErrorCorruptedTxData) ||
+		errors.Is(err, ErrCorruptedData) ||
+		errors.Is(err, ErrCorruptedAHtree) ||
+		errors.Is(err, ErrCorruptedIndex)
+}
+
+// startScrubber periodically verifies a randomly selected transaction until the store is closed.
+// Detected corruptions are reported as errors, through metrics and to the corruption handler,
+// the scrubber keeps running.
+// Damaged values are repaired when a repair source is set.
+func (s *ImmuStore) startScrubber(frequency time.Duration) {
+	s.scrubberDone = make(chan struct{})
+
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(frequency)
+		defer ticker.Stop()
+
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		tx := s.NewTxHolder()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				committedTxID, _, _ := s.commitState()
+				if committedTxID == 0 {
+					continue
+				}
+
+				txID := 1 + uint64(rnd.Int63n(int64(committedTxID)))
+
+				err := s.CheckTx(txID, tx)
+				if err == ErrAlreadyClosed {
+					return
+				}
+				if isCorruptionErr(err) {
+					s.metricsScrubCorruptions.Inc()
+					s.notify(Error, true, "Corruption detected at '%s': %v", s.path, err)
+
+					if s.corruptionHandler != nil {
+						s.corruptionHandler(txID, err)
+					}
+
+					s.repairTx(txID)
+				} else if err != nil {
+					s.log.Warningf("%v: while verifying tx %d at '%s'", err, txID, s.path)
+				}
+
+				s.metricsScrubbedTxs.Inc()
+			}
+		}
+	}(s.scrubberDone)
+}
+
+// repairTx repairs the damaged values of the transaction txID when a repair source is set
+func (s *ImmuStore) repairTx(txID uint64) {
+	fetchTx := s.getRepairSource()
+	if fetchTx == nil {
+		return
+	}
+
+	n, err := s.RepairTx(txID, fetchTx)
+	if err != nil {
+		s.notify(Error, true, "%v: while repairing tx %d at '%s'", err, txID, s.path)
+		return
+	}
+
+	if n > 0 {
+		s.notify(Info, true, "%d damaged values of tx %d repaired at '%s'", n, txID, s.path)
+	}
+}
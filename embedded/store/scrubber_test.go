@@ -0,0 +1,118 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreCheckTx(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false)
+
+	immuStore, err := Open("data_scrubber", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_scrubber")
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i%5)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	tx := immuStore.NewTxHolder()
+
+	err = immuStore.CheckTx(0, tx)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.CheckTx(1, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for i := uint64(1); i <= 10; i++ {
+		err = immuStore.CheckTx(i, tx)
+		require.NoError(t, err)
+	}
+
+	err = immuStore.CheckTx(11, tx)
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	// tamper the value set at tx 6
+	vLogPath := filepath.Join("data_scrubber", "val_0", "00000000.val")
+
+	content, err := ioutil.ReadFile(vLogPath)
+	require.NoError(t, err)
+
+	i := bytes.Index(content, []byte("value5"))
+	require.Greater(t, i, 0)
+	content[i] = 'V'
+
+	err = ioutil.WriteFile(vLogPath, content, 0644)
+	require.NoError(t, err)
+
+	corruptedTxs := make(chan uint64, 1)
+
+	opts.WithScrubFrequency(time.Millisecond).
+		WithCorruptionHandler(func(txID uint64, err error) {
+			require.ErrorIs(t, err, ErrCorruptedData)
+
+			select {
+			case corruptedTxs <- txID:
+			default:
+			}
+		})
+
+	immuStore, err = Open("data_scrubber", opts)
+	require.NoError(t, err)
+
+	defer immuStore.Close()
+
+	err = immuStore.CheckTx(5, tx)
+	require.NoError(t, err)
+
+	err = immuStore.CheckTx(6, tx)
+	require.ErrorIs(t, err, ErrCorruptedData)
+	require.True(t, isCorruptionErr(err))
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(immuStore.metricsScrubCorruptions) > 0
+	}, 10*time.Second, time.Millisecond)
+
+	require.Greater(t, testutil.ToFloat64(immuStore.metricsScrubbedTxs), float64(0))
+
+	select {
+	case txID := <-corruptedTxs:
+		require.Equal(t, uint64(6), txID)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "corruption handler not notified")
+	}
+}
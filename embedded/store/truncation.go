@@ -0,0 +1,161 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+)
+
+// discardableVLog is implemented by value logs able to physically remove their oldest data
+type discardableVLog interface {
+	DiscardUpto(off int64) error
+}
+
+// TruncateUptoTx physically removes, whenever possible, the values written by the transactions preceding txID.
+// Transaction headers and entry digests are kept so proofs over the whole history still verify,
+// reading a removed value returns ErrValueTruncated.
+// Value logs are truncated at file granularity, so values of some transactions preceding txID may still be readable.
+// Values still needed by readers of the index, such as catalog entries, must not precede txID.
+func (s *ImmuStore) TruncateUptoTx(txID uint64) error {
+	if s.compactionDisabled {
+		return ErrTruncationUnsupported
+	}
+
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+
+	err := s.CheckRetentionLock()
+	if err != nil {
+		return err
+	}
+
+	s.truncationMutex.Lock()
+	defer s.truncationMutex.Unlock()
+
+	committedTxID, _, _ := s.commitState()
+
+	if txID == 0 || txID > committedTxID+1 {
+		return ErrIllegalArguments
+	}
+
+	// values are not necessarily appended in the same order transactions are committed,
+	// the lowest offset referenced by any retained transaction is kept for each value log
+	minOffsets := make(map[byte]int64, len(s.vLogs))
+
+	tx := s.NewTxHolder()
+
+	for id := txID; id <= committedTxID; id++ {
+		err := s.ReadTx(id, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range tx.Entries() {
+			if e.vLen == 0 {
+				continue
+			}
+
+			vLogID, off := decodeOffset(e.vOff)
+
+			minOff, ok := minOffsets[vLogID]
+			if !ok || off < minOff {
+				minOffsets[vLogID] = off
+			}
+		}
+	}
+
+	for i := range s.vLogs {
+		err := s.truncateVLog(i+1, minOffsets)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.log.Infof("Values preceding tx %d truncated at '%s'", txID, s.path)
+
+	return nil
+}
+
+func (s *ImmuStore) truncateVLog(vLogID byte, minOffsets map[byte]int64) error {
+	vLog := s.fetchVLog(vLogID)
+	defer s.releaseVLog(vLogID)
+
+	dvLog, ok := vLog.(discardableVLog)
+	if !ok {
+		return ErrTruncationUnsupported
+	}
+
+	off, ok := minOffsets[vLogID]
+	if !ok {
+		// no retained transaction has values in this log
+		off = vLog.Offset()
+	}
+
+	err := dvLog.DiscardUpto(off)
+	if err == multiapp.ErrAlreadyClosed {
+		return ErrAlreadyClosed
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.purgeCachedValues(vLogID, off)
+}
+
+// TruncateBefore physically removes, whenever possible, the values written by the transactions
+// committed before ts. See TruncateUptoTx.
+func (s *ImmuStore) TruncateBefore(ts time.Time) error {
+	txID, err := s.firstTxSince(ts)
+	if err != nil {
+		return err
+	}
+
+	return s.TruncateUptoTx(txID)
+}
+
+// startRetention periodically truncates the values older than the retention period until the store is closed
+func (s *ImmuStore) startRetention(retentionPeriod, truncationFrequency time.Duration) {
+	s.retentionDone = make(chan struct{})
+
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(truncationFrequency)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := s.TruncateBefore(s.timeFunc().Add(-retentionPeriod))
+				if err == ErrAlreadyClosed {
+					return
+				}
+				if errors.Is(err, ErrRetentionLocked) {
+					// values are removed once the retention date is reached
+					continue
+				}
+				if err != nil {
+					s.log.Warningf("%v: while truncating values at '%s'", err, s.path)
+				}
+			}
+		}
+	}(s.retentionDone)
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreTruncation(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false).WithFileSize(64)
+
+	immuStore, err := Open("data_truncation", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_truncation")
+
+	txCount := 20
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%08d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.TruncateUptoTx(0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.TruncateUptoTx(uint64(txCount + 2))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.TruncateUptoTx(10)
+	require.NoError(t, err)
+
+	requireValue := func(st *ImmuStore, txID uint64, expectedErr error) {
+		tx := st.NewTxHolder()
+
+		err := st.ReadTx(txID, tx)
+		require.NoError(t, err)
+
+		entry, err := tx.EntryOf([]byte(fmt.Sprintf("key%d", txID-1)))
+		require.NoError(t, err)
+
+		val, err := st.ReadValue(entry)
+		if expectedErr != nil {
+			require.ErrorIs(t, err, expectedErr)
+			return
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%08d", txID-1)), val)
+	}
+
+	requireValue(immuStore, 1, ErrValueTruncated)
+
+	for txID := uint64(10); txID <= uint64(txCount); txID++ {
+		requireValue(immuStore, txID, nil)
+	}
+
+	_, err = immuStore.Get([]byte("key0"))
+	require.NoError(t, err)
+
+	// headers are kept so proofs over truncated transactions still verify
+	sourceTx := immuStore.NewTxHolder()
+	err = immuStore.ReadTx(1, sourceTx)
+	require.NoError(t, err)
+
+	targetTx := immuStore.NewTxHolder()
+	err = immuStore.ReadTx(uint64(txCount), targetTx)
+	require.NoError(t, err)
+
+	proof, err := immuStore.DualProof(sourceTx, targetTx)
+	require.NoError(t, err)
+	require.True(t, VerifyDualProof(proof, 1, uint64(txCount), sourceTx.header.Alh(), targetTx.header.Alh()))
+
+	_, err = immuStore.ExportTx(1, immuStore.NewTxHolder())
+	require.ErrorIs(t, err, ErrValueTruncated)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_truncation", opts)
+	require.NoError(t, err)
+
+	requireValue(immuStore, 1, ErrValueTruncated)
+	requireValue(immuStore, uint64(txCount), nil)
+
+	err = immuStore.TruncateUptoTx(uint64(txCount + 1))
+	require.NoError(t, err)
+
+	requireValue(immuStore, 10, ErrValueTruncated)
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte(fmt.Sprintf("key%d", txCount)), nil, []byte(fmt.Sprintf("value%08d", txCount)))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.NoError(t, err)
+
+	requireValue(immuStore, uint64(txCount+1), nil)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	err = immuStore.TruncateUptoTx(1)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestImmudbStoreRetention(t *testing.T) {
+	var now int64
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithFileSize(64).
+		WithTimeFunc(func() time.Time { return time.Unix(atomic.LoadInt64(&now), 0) }).
+		WithRetentionPeriod(10 * time.Second).
+		WithTruncationFrequency(10 * time.Millisecond)
+
+	immuStore, err := Open("data_retention", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_retention")
+
+	for i := 0; i < 20; i++ {
+		atomic.StoreInt64(&now, int64(i))
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%08d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	txID, err := immuStore.firstTxSince(time.Unix(9, 0))
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), txID)
+
+	txID, err = immuStore.firstTxSince(time.Unix(100, 0))
+	require.NoError(t, err)
+	require.Equal(t, uint64(21), txID)
+
+	tx := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(1, tx)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, err := immuStore.ReadValue(tx.Entries()[0])
+		return err == ErrValueTruncated
+	}, 5*time.Second, 10*time.Millisecond)
+
+	err = immuStore.ReadTx(20, tx)
+	require.NoError(t, err)
+
+	_, err = immuStore.ReadValue(tx.Entries()[0])
+	require.NoError(t, err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
+
+func TestImmudbStoreTruncationUnsupportedForRemoteStorage(t *testing.T) {
+	opts := DefaultOptions().WithCompactionDisabled(true)
+
+	immuStore, err := Open("data_truncation_remote_storage", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_truncation_remote_storage")
+
+	err = immuStore.TruncateUptoTx(1)
+	require.ErrorIs(t, err, ErrTruncationUnsupported)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
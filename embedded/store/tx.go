@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/htree"
 )
 
@@ -46,9 +47,13 @@ type TxHeader struct {
 
 	NEntries int
 	Eh       [sha256.Size]byte
+
+	// HashAlgorithm is the hash algorithm of the store the transaction belongs to,
+	// it is not part of the serialized header
+	HashAlgorithm hashing.Algorithm
 }
 
-func newTx(nentries int, maxKeyLen int) *Tx {
+func newTx(nentries int, maxKeyLen int, hashAlg hashing.Algorithm) *Tx {
 	entries := make([]*TxEntry, nentries)
 	for i := 0; i < nentries; i++ {
 		entries[i] = &TxEntry{
@@ -56,14 +61,14 @@ func newTx(nentries int, maxKeyLen int) *Tx {
 		}
 	}
 
-	return NewTxWithEntries(entries)
+	return NewTxWithEntries(entries, hashAlg)
 }
 
-func NewTxWithEntries(entries []*TxEntry) *Tx {
-	htree, _ := htree.New(len(entries))
+func NewTxWithEntries(entries []*TxEntry, hashAlg hashing.Algorithm) *Tx {
+	htree, _ := htree.New(len(entries), hashAlg)
 
 	return &Tx{
-		header:  &TxHeader{NEntries: len(entries)},
+		header:  &TxHeader{NEntries: len(entries), HashAlgorithm: hashAlg},
 		entries: entries,
 		htree:   htree,
 	}
@@ -255,7 +260,7 @@ func (hdr *TxHeader) innerHash() [sha256.Size]byte {
 	i += sha256.Size
 
 	// hash(ts + version + (mdLen + md) + nentries + eH + blTxID + blRoot)
-	return sha256.Sum256(b[:i])
+	return hdr.HashAlgorithm.Sum(b[:i])
 }
 
 // Alh calculates the Accumulative Linear Hash up to this transaction
@@ -272,18 +277,16 @@ func (hdr *TxHeader) Alh() [sha256.Size]byte {
 	copy(bi[txIDSize+sha256.Size:], innerHash[:])
 
 	// hash(txID + prevAlh + innerHash)
-	return sha256.Sum256(bi[:])
+	return hdr.HashAlgorithm.Sum(bi[:])
 }
 
 func (tx *Tx) TxEntryDigest() (TxEntryDigest, error) {
-	switch tx.header.Version {
-	case 0:
-		return TxEntryDigest_v1_1, nil
-	case 1:
-		return TxEntryDigest_v1_2, nil
+	txEntryDigest, err := TxEntryDigestFor(tx.header.Version, tx.header.HashAlgorithm)
+	if err != nil {
+		return nil, ErrCorruptedData
 	}
 
-	return nil, ErrCorruptedData
+	return txEntryDigest, nil
 }
 
 func (tx *Tx) BuildHashTree() error {
@@ -345,7 +348,7 @@ func (tx *Tx) Proof(key []byte) (*htree.InclusionProof, error) {
 }
 
 func (tx *Tx) readFrom(r *appendable.Reader) error {
-	tx.header = &TxHeader{}
+	tx.header = &TxHeader{HashAlgorithm: tx.header.HashAlgorithm}
 
 	id, err := r.ReadUint64()
 	if err != nil {
@@ -567,16 +570,31 @@ func (e *TxEntry) VLen() int {
 
 type TxEntryDigest func(e *TxEntry) [sha256.Size]byte
 
-func TxEntryDigest_v1_1(e *TxEntry) [sha256.Size]byte {
+func TxEntryDigestFor(version int, hashAlg hashing.Algorithm) (TxEntryDigest, error) {
+	if !hashAlg.Supported() {
+		return nil, ErrUnsupportedHashAlgorithm
+	}
+
+	switch version {
+	case 0:
+		return func(e *TxEntry) [sha256.Size]byte { return TxEntryDigest_v1_1(e, hashAlg) }, nil
+	case 1:
+		return func(e *TxEntry) [sha256.Size]byte { return TxEntryDigest_v1_2(e, hashAlg) }, nil
+	}
+
+	return nil, ErrUnsupportedTxVersion
+}
+
+func TxEntryDigest_v1_1(e *TxEntry, hashAlg hashing.Algorithm) [sha256.Size]byte {
 	b := make([]byte, e.kLen+sha256.Size)
 
 	copy(b[:], e.k[:e.kLen])
 	copy(b[e.kLen:], e.hVal[:])
 
-	return sha256.Sum256(b)
+	return hashAlg.Sum(b)
 }
 
-func TxEntryDigest_v1_2(e *TxEntry) [sha256.Size]byte {
+func TxEntryDigest_v1_2(e *TxEntry, hashAlg hashing.Algorithm) [sha256.Size]byte {
 	var mdbs []byte
 
 	if e.md != nil {
@@ -603,5 +621,5 @@ func TxEntryDigest_v1_2(e *TxEntry) [sha256.Size]byte {
 	copy(b[i:], e.hVal[:])
 	i += sha256.Size
 
-	return sha256.Sum256(b[:i])
+	return hashAlg.Sum(b[:i])
 }
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/ahtree"
+)
+
+// TxInclusionProof proves a transaction is part of the history of a target transaction, either
+// through the binary linking tree of the target or, when the transaction is not yet linked into
+// it, through the linear linking of the transactions in between.
+// Proving many transactions against the same target only requires a single dual proof of the target
+type TxInclusionProof struct {
+	TxHeader       *TxHeader
+	InclusionProof [][sha256.Size]byte
+	LinearProof    *LinearProof
+}
+
+type ahtProofKey struct {
+	i, j uint64
+}
+
+// ahtInclusionProof returns the inclusion proof of the i-th leaf into the binary linking tree of size j.
+// Such proofs never change, so recently computed ones are cached
+func (s *ImmuStore) ahtInclusionProof(i, j uint64) ([][sha256.Size]byte, error) {
+	if s.proofCache == nil {
+		return s.aht.InclusionProof(i, j)
+	}
+
+	key := ahtProofKey{i: i, j: j}
+
+	cached, err := s.proofCache.Get(key)
+	if err == nil {
+		return cached.([][sha256.Size]byte), nil
+	}
+
+	proof, err := s.aht.InclusionProof(i, j)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = s.proofCache.Put(key, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// TxInclusionProof returns the proof of the transaction with header txHdr being part of the history of targetTxHdr
+func (s *ImmuStore) TxInclusionProof(txHdr, targetTxHdr *TxHeader) (*TxInclusionProof, error) {
+	if txHdr == nil || targetTxHdr == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if txHdr.ID > targetTxHdr.ID {
+		return nil, ErrSourceTxNewerThanTargetTx
+	}
+
+	proof := &TxInclusionProof{TxHeader: txHdr}
+
+	if txHdr.ID < targetTxHdr.BlTxID {
+		inclusionProof, err := s.ahtInclusionProof(txHdr.ID, targetTxHdr.BlTxID) // must match targetTxHdr.BlRoot
+		if err != nil {
+			return nil, err
+		}
+
+		proof.InclusionProof = inclusionProof
+
+		return proof, nil
+	}
+
+	linearProof, err := s.LinearProof(txHdr.ID, targetTxHdr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	proof.LinearProof = linearProof
+
+	return proof, nil
+}
+
+// VerifyTxInclusion returns true when the transaction in the proof is part of the history of the target
+// transaction. The target header must be verified on its own, i.e. with a dual proof
+func VerifyTxInclusion(proof *TxInclusionProof, targetTxHdr *TxHeader) bool {
+	if proof == nil || proof.TxHeader == nil || targetTxHdr == nil {
+		return false
+	}
+
+	if proof.TxHeader.ID == 0 || proof.TxHeader.ID > targetTxHdr.ID {
+		return false
+	}
+
+	hashAlg := proof.TxHeader.HashAlgorithm
+
+	if !hashAlg.Supported() || targetTxHdr.HashAlgorithm != hashAlg {
+		return false
+	}
+
+	txAlh := proof.TxHeader.Alh()
+
+	if proof.TxHeader.ID < targetTxHdr.BlTxID {
+		return ahtree.VerifyInclusion(
+			proof.InclusionProof,
+			proof.TxHeader.ID,
+			targetTxHdr.BlTxID,
+			leafFor(txAlh, hashAlg),
+			targetTxHdr.BlRoot,
+			hashAlg,
+		)
+	}
+
+	return VerifyLinearProof(proof.LinearProof, proof.TxHeader.ID, targetTxHdr.ID, txAlh, targetTxHdr.Alh(), hashAlg)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreTxInclusionProof(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false).WithProofCacheSize(10)
+
+	immuStore, err := Open("data_tx_inclusion", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_tx_inclusion")
+	defer immuStore.Close()
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	targetTx := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(10, targetTx)
+	require.NoError(t, err)
+
+	target := targetTx.Header()
+
+	_, err = immuStore.TxInclusionProof(nil, target)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	tx := immuStore.NewTxHolder()
+
+	for i := uint64(1); i <= 10; i++ {
+		err = immuStore.ReadTx(i, tx)
+		require.NoError(t, err)
+
+		proof, err := immuStore.TxInclusionProof(tx.Header(), target)
+		require.NoError(t, err)
+		require.True(t, VerifyTxInclusion(proof, target))
+
+		if i < target.BlTxID {
+			require.NotNil(t, proof.InclusionProof)
+			require.Nil(t, proof.LinearProof)
+
+			// proofs are served from the cache once computed
+			cachedProof, err := immuStore.TxInclusionProof(tx.Header(), target)
+			require.NoError(t, err)
+			require.Equal(t, proof, cachedProof)
+		} else {
+			require.NotNil(t, proof.LinearProof)
+		}
+
+		// the proof does not hold for a different target
+		if i > 1 {
+			olderTx := immuStore.NewTxHolder()
+
+			err = immuStore.ReadTx(i-1, olderTx)
+			require.NoError(t, err)
+
+			require.False(t, VerifyTxInclusion(proof, olderTx.Header()))
+
+			_, err = immuStore.TxInclusionProof(tx.Header(), olderTx.Header())
+			require.ErrorIs(t, err, ErrSourceTxNewerThanTargetTx)
+		}
+
+		// nor for a tampered transaction
+		tamperedHdr := *tx.Header()
+		tamperedHdr.Ts++
+
+		require.False(t, VerifyTxInclusion(&TxInclusionProof{
+			TxHeader:       &tamperedHdr,
+			InclusionProof: proof.InclusionProof,
+			LinearProof:    proof.LinearProof,
+		}, target))
+	}
+
+	require.False(t, VerifyTxInclusion(nil, target))
+	require.False(t, VerifyTxInclusion(&TxInclusionProof{TxHeader: target}, nil))
+}
@@ -15,26 +15,239 @@ limitations under the License.
 */
 package store
 
-const maxTxMetadataLen = 0
+import (
+	"errors"
+	"sort"
+)
 
+var ErrMaxTxMetadataLenExceeded = errors.New("max tx metadata length exceeded")
+
+const (
+	annotationAttrCode attributeCode = 0
+	writerAttrCode     attributeCode = 1
+)
+
+// annotation keys and values are prefixed by their length
+const maxAnnotationLen = 255
+
+// the writer is prefixed by its length
+const maxWriterLen = 255
+
+// annotations share this budget while the writer has its own one, so the length of a username
+// does not limit the annotations a transaction can have
+const maxTxAnnotationsLen = 256
+
+const maxTxMetadataLen = attrCodeSize + 1 + maxWriterLen + maxTxAnnotationsLen
+
+// TxMetadata holds user-defined annotations and the identity of the writer attached to a transaction
+// when it's committed, they are part of the tx header and thus covered by the tx hash
 type TxMetadata struct {
+	annotations map[string]string
+	writer      string
 }
 
 func NewTxMetadata() *TxMetadata {
-	return &TxMetadata{}
+	return &TxMetadata{
+		annotations: make(map[string]string),
+	}
+}
+
+// WithAnnotation sets the value of the annotation key
+func (md *TxMetadata) WithAnnotation(key, value string) *TxMetadata {
+	if md.annotations == nil {
+		md.annotations = make(map[string]string)
+	}
+
+	md.annotations[key] = value
+
+	return md
+}
+
+// WithWriter sets the identity of the user committing the transaction
+func (md *TxMetadata) WithWriter(writer string) *TxMetadata {
+	md.writer = writer
+	return md
+}
+
+// Writer returns the identity of the user who committed the transaction, if recorded
+func (md *TxMetadata) Writer() string {
+	if md == nil {
+		return ""
+	}
+
+	return md.writer
+}
+
+func (md *TxMetadata) Annotation(key string) (value string, ok bool) {
+	if md == nil {
+		return "", false
+	}
+
+	value, ok = md.annotations[key]
+	return
+}
+
+// Annotations returns a copy of the annotations of the transaction
+func (md *TxMetadata) Annotations() map[string]string {
+	annotations := make(map[string]string, len(md.annotations))
+
+	for k, v := range md.annotations {
+		annotations[k] = v
+	}
+
+	return annotations
+}
+
+func (md *TxMetadata) IsEmpty() bool {
+	return md == nil || (len(md.annotations) == 0 && md.writer == "")
 }
 
 func (md *TxMetadata) Equal(amd *TxMetadata) bool {
+	if md.IsEmpty() || amd.IsEmpty() {
+		return md.IsEmpty() && amd.IsEmpty()
+	}
+
+	if md.writer != amd.writer || len(md.annotations) != len(amd.annotations) {
+		return false
+	}
+
+	for k, v := range md.annotations {
+		av, ok := amd.annotations[k]
+		if !ok || av != v {
+			return false
+		}
+	}
+
 	return true
 }
 
-func (md *TxMetadata) Bytes() []byte {
+func (md *TxMetadata) validate() error {
+	if md.IsEmpty() {
+		return nil
+	}
+
+	if len(md.writer) > maxWriterLen {
+		return ErrIllegalArguments
+	}
+
+	annotationsLen := 0
+
+	for k, v := range md.annotations {
+		if len(k) == 0 || len(k) > maxAnnotationLen || len(v) > maxAnnotationLen {
+			return ErrIllegalArguments
+		}
+
+		annotationsLen += attrCodeSize + 1 + len(k) + 1 + len(v)
+	}
+
+	if annotationsLen > maxTxAnnotationsLen {
+		return ErrMaxTxMetadataLenExceeded
+	}
+
 	return nil
 }
 
+func (md *TxMetadata) Bytes() []byte {
+	if md.IsEmpty() {
+		return nil
+	}
+
+	keys := make([]string, 0, len(md.annotations))
+	for k := range md.annotations {
+		keys = append(keys, k)
+	}
+
+	// annotations are sorted so the serialization is deterministic
+	sort.Strings(keys)
+
+	var b []byte
+
+	if md.writer != "" {
+		b = append(b, byte(writerAttrCode))
+		b = append(b, byte(len(md.writer)))
+		b = append(b, md.writer...)
+	}
+
+	for _, k := range keys {
+		v := md.annotations[k]
+
+		b = append(b, byte(annotationAttrCode))
+		b = append(b, byte(len(k)))
+		b = append(b, k...)
+		b = append(b, byte(len(v)))
+		b = append(b, v...)
+	}
+
+	return b
+}
+
 func (md *TxMetadata) ReadFrom(b []byte) error {
-	if len(b) != 0 {
+	if len(b) > maxTxMetadataLen {
 		return ErrCorruptedData
 	}
+
+	annotations := make(map[string]string)
+	var writer string
+
+	i := 0
+
+	for i < len(b) {
+		if len(b[i:]) < attrCodeSize+1 {
+			return ErrCorruptedData
+		}
+
+		attrCode := attributeCode(b[i])
+		i += attrCodeSize
+
+		if attrCode == writerAttrCode {
+			wLen := int(b[i])
+			i++
+
+			// the writer is only encoded once and before any annotation
+			if wLen == 0 || len(b[i:]) < wLen || writer != "" || len(annotations) > 0 {
+				return ErrCorruptedData
+			}
+
+			writer = string(b[i : i+wLen])
+			i += wLen
+
+			continue
+		}
+
+		if attrCode != annotationAttrCode {
+			return ErrCorruptedData
+		}
+
+		kLen := int(b[i])
+		i++
+
+		if kLen == 0 || len(b[i:]) < kLen+1 {
+			return ErrCorruptedData
+		}
+
+		k := string(b[i : i+kLen])
+		i += kLen
+
+		vLen := int(b[i])
+		i++
+
+		if len(b[i:]) < vLen {
+			return ErrCorruptedData
+		}
+
+		v := string(b[i : i+vLen])
+		i += vLen
+
+		_, duplicated := annotations[k]
+		if duplicated {
+			return ErrCorruptedData
+		}
+
+		annotations[k] = v
+	}
+
+	md.annotations = annotations
+	md.writer = writer
+
 	return nil
 }
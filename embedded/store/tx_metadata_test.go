@@ -39,3 +39,128 @@ func TestTxMetadata(t *testing.T) {
 
 	require.True(t, md.Equal(desmd))
 }
+
+func TestTxMetadataAnnotations(t *testing.T) {
+	md := NewTxMetadata().
+		WithAnnotation("source", "ingestion").
+		WithAnnotation("batch", "42")
+
+	require.False(t, md.IsEmpty())
+	require.NoError(t, md.validate())
+
+	v, ok := md.Annotation("batch")
+	require.True(t, ok)
+	require.Equal(t, "42", v)
+
+	_, ok = md.Annotation("missing")
+	require.False(t, ok)
+
+	annotations := md.Annotations()
+	require.Len(t, annotations, 2)
+
+	annotations["source"] = "changed"
+	v, _ = md.Annotation("source")
+	require.Equal(t, "ingestion", v)
+
+	desmd := &TxMetadata{}
+	err := desmd.ReadFrom(md.Bytes())
+	require.NoError(t, err)
+	require.True(t, md.Equal(desmd))
+	require.Equal(t, md.Bytes(), desmd.Bytes())
+
+	require.False(t, md.Equal(nil))
+	require.False(t, md.Equal(NewTxMetadata().WithAnnotation("source", "ingestion")))
+	require.False(t, md.Equal(NewTxMetadata().WithAnnotation("source", "ingestion").WithAnnotation("batch", "43")))
+
+	var nilmd *TxMetadata
+	require.True(t, nilmd.IsEmpty())
+	require.True(t, nilmd.Equal(NewTxMetadata()))
+
+	_, ok = nilmd.Annotation("source")
+	require.False(t, ok)
+
+	t.Run("validation", func(t *testing.T) {
+		require.ErrorIs(t, NewTxMetadata().WithAnnotation("", "v").validate(), ErrIllegalArguments)
+
+		longStr := string(make([]byte, maxAnnotationLen+1))
+		require.ErrorIs(t, NewTxMetadata().WithAnnotation(longStr, "v").validate(), ErrIllegalArguments)
+		require.ErrorIs(t, NewTxMetadata().WithAnnotation("k", longStr).validate(), ErrIllegalArguments)
+
+		require.ErrorIs(t, NewTxMetadata().
+			WithAnnotation("k1", string(make([]byte, 200))).
+			WithAnnotation("k2", string(make([]byte, 200))).
+			validate(), ErrMaxTxMetadataLenExceeded)
+	})
+
+	t.Run("corrupted data", func(t *testing.T) {
+		bs := NewTxMetadata().WithAnnotation("k", "v").Bytes()
+
+		desmd := &TxMetadata{}
+
+		require.ErrorIs(t, desmd.ReadFrom(bs[:1]), ErrCorruptedData)
+		require.ErrorIs(t, desmd.ReadFrom(bs[:len(bs)-1]), ErrCorruptedData)
+		require.ErrorIs(t, desmd.ReadFrom(append(bs, bs...)), ErrCorruptedData)
+		require.ErrorIs(t, desmd.ReadFrom([]byte{1, 1, 'k', 0}), ErrCorruptedData)
+		require.ErrorIs(t, desmd.ReadFrom([]byte{0, 0, 0}), ErrCorruptedData)
+		require.ErrorIs(t, desmd.ReadFrom(make([]byte, maxTxMetadataLen+1)), ErrCorruptedData)
+	})
+}
+
+func TestTxMetadataWriter(t *testing.T) {
+	md := NewTxMetadata().WithWriter("user1")
+
+	require.False(t, md.IsEmpty())
+	require.NoError(t, md.validate())
+	require.Equal(t, "user1", md.Writer())
+
+	var nilmd *TxMetadata
+	require.Empty(t, nilmd.Writer())
+
+	desmd := &TxMetadata{}
+	err := desmd.ReadFrom(md.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "user1", desmd.Writer())
+	require.True(t, md.Equal(desmd))
+
+	require.False(t, md.Equal(NewTxMetadata().WithWriter("user2")))
+	require.False(t, md.Equal(NewTxMetadata().WithAnnotation("writer", "user1")))
+
+	md.WithAnnotation("source", "ingestion")
+
+	err = desmd.ReadFrom(md.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "user1", desmd.Writer())
+	require.Equal(t, map[string]string{"source": "ingestion"}, desmd.Annotations())
+	require.True(t, md.Equal(desmd))
+
+	t.Run("validation", func(t *testing.T) {
+		require.ErrorIs(t, NewTxMetadata().WithWriter(string(make([]byte, maxWriterLen+1))).validate(), ErrIllegalArguments)
+
+		// the writer does not take space from the annotations
+		md := NewTxMetadata().
+			WithWriter(string(make([]byte, maxWriterLen))).
+			WithAnnotation("k", string(make([]byte, maxTxAnnotationsLen-4)))
+		require.NoError(t, md.validate())
+		require.Len(t, md.Bytes(), maxTxMetadataLen)
+
+		desmd := &TxMetadata{}
+		require.NoError(t, desmd.ReadFrom(md.Bytes()))
+		require.True(t, md.Equal(desmd))
+
+		require.ErrorIs(t, md.WithAnnotation("k2", "").validate(), ErrMaxTxMetadataLenExceeded)
+	})
+
+	t.Run("corrupted data", func(t *testing.T) {
+		bs := NewTxMetadata().WithWriter("user1").Bytes()
+
+		desmd := &TxMetadata{}
+
+		require.ErrorIs(t, desmd.ReadFrom(bs[:len(bs)-1]), ErrCorruptedData)
+		require.ErrorIs(t, desmd.ReadFrom(append(bs, bs...)), ErrCorruptedData)
+		require.ErrorIs(t, desmd.ReadFrom([]byte{byte(writerAttrCode), 0}), ErrCorruptedData)
+
+		// the writer is encoded before the annotations
+		annotated := NewTxMetadata().WithAnnotation("k", "v").Bytes()
+		require.ErrorIs(t, desmd.ReadFrom(append(annotated, bs...)), ErrCorruptedData)
+	})
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
+	"github.com/codenotary/immudb/embedded/hashing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,7 +35,7 @@ func TestReadTxFromCorruptedData(t *testing.T) {
 	r := appendable.NewReaderFrom(a, 0, 1)
 	require.NotNil(t, r)
 
-	tx := newTx(1, 32)
+	tx := newTx(1, 32, hashing.SHA256)
 
 	// Should fail while reading TxID
 	a.ReadAtFn = func(bs []byte, off int64) (int, error) {
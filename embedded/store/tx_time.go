@@ -0,0 +1,82 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import "time"
+
+// FirstTxSince returns the id of the first transaction committed at or after ts.
+// ErrTxNotFound is returned when every transaction was committed before ts.
+func (s *ImmuStore) FirstTxSince(ts time.Time) (uint64, error) {
+	txID, err := s.firstTxSince(ts)
+	if err != nil {
+		return 0, err
+	}
+
+	committedTxID, _, _ := s.commitState()
+
+	if txID > committedTxID {
+		return 0, ErrTxNotFound
+	}
+
+	return txID, nil
+}
+
+// LastTxUntil returns the id of the last transaction committed at or before ts.
+// ErrTxNotFound is returned when every transaction was committed after ts.
+func (s *ImmuStore) LastTxUntil(ts time.Time) (uint64, error) {
+	// timestamps have a precision of seconds
+	txID, err := s.firstTxSince(time.Unix(ts.Unix()+1, 0))
+	if err != nil {
+		return 0, err
+	}
+
+	if txID == 1 {
+		return 0, ErrTxNotFound
+	}
+
+	return txID - 1, nil
+}
+
+// firstTxSince returns the id of the first transaction committed at or after ts,
+// when no such transaction exists the id following the last committed one is returned.
+// Commit timestamps are non-decreasing, thus the tx log is itself a time index
+// and transactions are located with a binary search over their headers.
+func (s *ImmuStore) firstTxSince(ts time.Time) (uint64, error) {
+	committedTxID, _, _ := s.commitState()
+
+	tx := s.NewTxHolder()
+
+	left := uint64(1)
+	right := committedTxID + 1
+
+	for left < right {
+		middle := left + (right-left)/2
+
+		err := s.ReadTx(middle, tx)
+		if err != nil {
+			return 0, err
+		}
+
+		if tx.header.Ts < ts.Unix() {
+			left = middle + 1
+		} else {
+			right = middle
+		}
+	}
+
+	return left, nil
+}
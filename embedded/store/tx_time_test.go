@@ -0,0 +1,116 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreTxByTime(t *testing.T) {
+	var now int64
+
+	opts := DefaultOptions().WithTimeFunc(func() time.Time { return time.Unix(now, 0) })
+
+	immuStore, err := Open("data_tx_by_time", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_tx_by_time")
+
+	_, err = immuStore.FirstTxSince(time.Unix(0, 0))
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	_, err = immuStore.LastTxUntil(time.Unix(1000, 0))
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	// the clock goes backwards before the 4th tx is committed
+	for i, ts := range []int64{100, 100, 200, 150, 300} {
+		now = ts
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	txHolder := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(4, txHolder)
+	require.NoError(t, err)
+	require.Equal(t, int64(200), txHolder.Header().Ts)
+
+	requireTxByTime := func(st *ImmuStore) {
+		for _, c := range []struct {
+			ts        int64
+			firstTxID uint64
+			lastTxID  uint64
+		}{
+			{ts: 50, firstTxID: 1},
+			{ts: 100, firstTxID: 1, lastTxID: 2},
+			{ts: 150, firstTxID: 3, lastTxID: 2},
+			{ts: 200, firstTxID: 3, lastTxID: 4},
+			{ts: 250, firstTxID: 5, lastTxID: 4},
+			{ts: 300, firstTxID: 5, lastTxID: 5},
+			{ts: 350, lastTxID: 5},
+		} {
+			txID, err := st.FirstTxSince(time.Unix(c.ts, 0))
+			if c.firstTxID == 0 {
+				require.ErrorIs(t, err, ErrTxNotFound)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, c.firstTxID, txID)
+			}
+
+			txID, err = st.LastTxUntil(time.Unix(c.ts, 0))
+			if c.lastTxID == 0 {
+				require.ErrorIs(t, err, ErrTxNotFound)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, c.lastTxID, txID)
+			}
+		}
+	}
+
+	requireTxByTime(immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	// commit timestamps remain non-decreasing after reopening the store
+	now = 10
+
+	immuStore, err = Open("data_tx_by_time", opts)
+	require.NoError(t, err)
+
+	requireTxByTime(immuStore)
+
+	hdr, err := immuStore.CommitWith(func(txID uint64, index KeyIndex) ([]*EntrySpec, error) {
+		return []*EntrySpec{{Key: []byte("key5"), Value: []byte("value5")}}, nil
+	}, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(300), hdr.Ts)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var metricsCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_store_cache_requests",
+	Help: "Number of store cache lookups, partitioned by cache and result (hit or miss)",
+}, []string{
+	"db",
+	"cache",
+	"result",
+})
+
+var metricsCacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_store_cache_evictions",
+	Help: "Number of entries evicted from store caches",
+}, []string{
+	"db",
+	"cache",
+})
+
+var metricsCacheEntries = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "immudb_store_cache_entries",
+	Help: "Number of entries held by store caches",
+}, []string{
+	"db",
+	"cache",
+})
+
+const (
+	txLogCacheName = "txlog"
+	vLogCacheName  = "vlog"
+)
+
+// cacheMetrics groups the metrics of a single store cache
+type cacheMetrics struct {
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	evictions prometheus.Counter
+	entries   prometheus.Gauge
+}
+
+func newCacheMetrics(db, name string) *cacheMetrics {
+	return &cacheMetrics{
+		hits:      metricsCacheRequests.WithLabelValues(db, name, "hit"),
+		misses:    metricsCacheRequests.WithLabelValues(db, name, "miss"),
+		evictions: metricsCacheEvictions.WithLabelValues(db, name),
+		entries:   metricsCacheEntries.WithLabelValues(db, name),
+	}
+}
+
+func (m *cacheMetrics) lookup(hit bool) {
+	if hit {
+		m.hits.Inc()
+	} else {
+		m.misses.Inc()
+	}
+}
+
+func putIntoCache(c cache.Cache, m *cacheMetrics, key, value interface{}) error {
+	rkey, _, err := c.Put(key, value)
+	if err != nil {
+		return err
+	}
+
+	if rkey != nil {
+		m.evictions.Inc()
+	}
+
+	m.entries.Set(float64(c.EntriesCount()))
+
+	return nil
+}
+
+// cachedValueAt returns the cached value stored at off, if any
+func (s *ImmuStore) cachedValueAt(b []byte, off int64) bool {
+	if s.vLogCache == nil {
+		return false
+	}
+
+	v, err := s.vLogCache.Get(off)
+	hit := err == nil && len(v.([]byte)) == len(b)
+
+	s.vLogCacheMetrics.lookup(hit)
+
+	if hit {
+		copy(b, v.([]byte))
+	}
+
+	return hit
+}
+
+func (s *ImmuStore) cacheValueAt(b []byte, off int64) error {
+	if s.vLogCache == nil {
+		return nil
+	}
+
+	v := make([]byte, len(b))
+	copy(v, b)
+
+	return putIntoCache(s.vLogCache, s.vLogCacheMetrics, off, v)
+}
+
+// purgeCachedValues removes the cached values of the value log vLogID stored before the offset upto
+func (s *ImmuStore) purgeCachedValues(vLogID byte, upto int64) error {
+	if s.vLogCache == nil {
+		return nil
+	}
+
+	var purged []interface{}
+
+	err := s.vLogCache.Apply(func(k, _ interface{}) error {
+		id, off := decodeOffset(k.(int64))
+		if id == vLogID && off < upto {
+			purged = append(purged, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range purged {
+		_, err = s.vLogCache.Pop(k)
+		if err != nil && err != cache.ErrKeyNotFound {
+			return err
+		}
+	}
+
+	s.vLogCacheMetrics.entries.Set(float64(s.vLogCache.EntriesCount()))
+
+	return nil
+}
+
+func (s *ImmuStore) purgeCachedValue(off int64) error {
+	if s.vLogCache == nil {
+		return nil
+	}
+
+	_, err := s.vLogCache.Pop(off)
+	if err != nil && err != cache.ErrKeyNotFound {
+		return err
+	}
+
+	s.vLogCacheMetrics.entries.Set(float64(s.vLogCache.EntriesCount()))
+
+	return nil
+}
@@ -20,15 +20,16 @@ import (
 	"encoding/binary"
 
 	"github.com/codenotary/immudb/embedded/ahtree"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/htree"
 )
 
-func VerifyInclusion(proof *htree.InclusionProof, entryDigest, root [sha256.Size]byte) bool {
-	return htree.VerifyInclusion(proof, entryDigest, root)
+func VerifyInclusion(proof *htree.InclusionProof, entryDigest, root [sha256.Size]byte, hashAlg hashing.Algorithm) bool {
+	return htree.VerifyInclusion(proof, entryDigest, root, hashAlg)
 }
 
-func VerifyLinearProof(proof *LinearProof, sourceTxID, targetTxID uint64, sourceAlh, targetAlh [sha256.Size]byte) bool {
-	if proof == nil || proof.SourceTxID != sourceTxID || proof.TargetTxID != targetTxID {
+func VerifyLinearProof(proof *LinearProof, sourceTxID, targetTxID uint64, sourceAlh, targetAlh [sha256.Size]byte, hashAlg hashing.Algorithm) bool {
+	if proof == nil || proof.SourceTxID != sourceTxID || proof.TargetTxID != targetTxID || !hashAlg.Supported() {
 		return false
 	}
 
@@ -48,7 +49,7 @@ func VerifyLinearProof(proof *LinearProof, sourceTxID, targetTxID uint64, source
 		binary.BigEndian.PutUint64(bs[:], proof.SourceTxID+uint64(i))
 		copy(bs[txIDSize:], calculatedAlh[:])
 		copy(bs[txIDSize+sha256.Size:], proof.Terms[i][:]) // innerHash = hash(ts + mdLen + md + nentries + eH + blTxID + blRoot)
-		calculatedAlh = sha256.Sum256(bs[:])               // hash(txID + prevAlh + innerHash)
+		calculatedAlh = hashAlg.Sum(bs[:])                 // hash(txID + prevAlh + innerHash)
 	}
 
 	return targetAlh == calculatedAlh
@@ -67,6 +68,13 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 		return false
 	}
 
+	// both transactions must belong to the same store so to be hashed with the same algorithm
+	hashAlg := proof.SourceTxHeader.HashAlgorithm
+
+	if !hashAlg.Supported() || proof.TargetTxHeader.HashAlgorithm != hashAlg {
+		return false
+	}
+
 	cSourceAlh := proof.SourceTxHeader.Alh()
 	if sourceAlh != cSourceAlh {
 		return false
@@ -82,8 +90,9 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 			proof.InclusionProof,
 			sourceTxID,
 			proof.TargetTxHeader.BlTxID,
-			leafFor(sourceAlh),
+			leafFor(sourceAlh, hashAlg),
 			proof.TargetTxHeader.BlRoot,
+			hashAlg,
 		)
 
 		if !verifies {
@@ -98,6 +107,7 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 			proof.TargetTxHeader.BlTxID,
 			proof.SourceTxHeader.BlRoot,
 			proof.TargetTxHeader.BlRoot,
+			hashAlg,
 		)
 
 		if !verfifies {
@@ -109,8 +119,9 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 		verifies := ahtree.VerifyLastInclusion(
 			proof.LastInclusionProof,
 			proof.TargetTxHeader.BlTxID,
-			leafFor(proof.TargetBlTxAlh),
+			leafFor(proof.TargetBlTxAlh, hashAlg),
 			proof.TargetTxHeader.BlRoot,
+			hashAlg,
 		)
 
 		if !verifies {
@@ -119,44 +130,48 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 	}
 
 	if sourceTxID < proof.TargetTxHeader.BlTxID {
-		return VerifyLinearProof(proof.LinearProof, proof.TargetTxHeader.BlTxID, targetTxID, proof.TargetBlTxAlh, targetAlh)
+		return VerifyLinearProof(proof.LinearProof, proof.TargetTxHeader.BlTxID, targetTxID, proof.TargetBlTxAlh, targetAlh, hashAlg)
 	}
 
-	return VerifyLinearProof(proof.LinearProof, sourceTxID, targetTxID, sourceAlh, targetAlh)
+	return VerifyLinearProof(proof.LinearProof, sourceTxID, targetTxID, sourceAlh, targetAlh, hashAlg)
 }
 
-func leafFor(d [sha256.Size]byte) [sha256.Size]byte {
+func leafFor(d [sha256.Size]byte, hashAlg hashing.Algorithm) [sha256.Size]byte {
 	var b [1 + sha256.Size]byte
 	b[0] = ahtree.LeafPrefix
 	copy(b[1:], d[:])
-	return sha256.Sum256(b[:])
+	return hashAlg.Sum(b[:])
 }
 
 type EntrySpecDigest func(kv *EntrySpec) [sha256.Size]byte
 
-func EntrySpecDigestFor(version int) (EntrySpecDigest, error) {
+func EntrySpecDigestFor(version int, hashAlg hashing.Algorithm) (EntrySpecDigest, error) {
+	if !hashAlg.Supported() {
+		return nil, ErrUnsupportedHashAlgorithm
+	}
+
 	switch version {
 	case 0:
-		return EntrySpecDigest_v0, nil
+		return func(kv *EntrySpec) [sha256.Size]byte { return EntrySpecDigest_v0(kv, hashAlg) }, nil
 	case 1:
-		return EntrySpecDigest_v1, nil
+		return func(kv *EntrySpec) [sha256.Size]byte { return EntrySpecDigest_v1(kv, hashAlg) }, nil
 	}
 
 	return nil, ErrUnsupportedTxVersion
 }
 
-func EntrySpecDigest_v0(kv *EntrySpec) [sha256.Size]byte {
+func EntrySpecDigest_v0(kv *EntrySpec, hashAlg hashing.Algorithm) [sha256.Size]byte {
 	b := make([]byte, len(kv.Key)+sha256.Size)
 
 	copy(b[:], kv.Key)
 
-	hvalue := sha256.Sum256(kv.Value)
+	hvalue := hashAlg.Sum(kv.Value)
 	copy(b[len(kv.Key):], hvalue[:])
 
-	return sha256.Sum256(b)
+	return hashAlg.Sum(b)
 }
 
-func EntrySpecDigest_v1(kv *EntrySpec) [sha256.Size]byte {
+func EntrySpecDigest_v1(kv *EntrySpec, hashAlg hashing.Algorithm) [sha256.Size]byte {
 	var mdbs []byte
 
 	if kv.Metadata != nil {
@@ -181,9 +196,9 @@ func EntrySpecDigest_v1(kv *EntrySpec) [sha256.Size]byte {
 	copy(b[i:], kv.Key)
 	i += len(kv.Key)
 
-	hvalue := sha256.Sum256(kv.Value)
+	hvalue := hashAlg.Sum(kv.Value)
 	copy(b[i:], hvalue[:])
 	i += sha256.Size
 
-	return sha256.Sum256(b[:i])
+	return hashAlg.Sum(b[:i])
 }
@@ -21,12 +21,13 @@ import (
 	"os"
 	"testing"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/stretchr/testify/require"
 )
 
 func TestVerifyLinearProofEdgeCases(t *testing.T) {
-	require.False(t, VerifyLinearProof(nil, 0, 0, sha256.Sum256(nil), sha256.Sum256(nil)))
-	require.False(t, VerifyLinearProof(&LinearProof{}, 0, 0, sha256.Sum256(nil), sha256.Sum256(nil)))
+	require.False(t, VerifyLinearProof(nil, 0, 0, sha256.Sum256(nil), sha256.Sum256(nil), hashing.SHA256))
+	require.False(t, VerifyLinearProof(&LinearProof{}, 0, 0, sha256.Sum256(nil), sha256.Sum256(nil), hashing.SHA256))
 
 	require.True(t,
 		VerifyLinearProof(
@@ -35,6 +36,7 @@ func TestVerifyLinearProofEdgeCases(t *testing.T) {
 			1,
 			sha256.Sum256(nil),
 			sha256.Sum256(nil),
+			hashing.SHA256,
 		),
 	)
 
@@ -45,6 +47,7 @@ func TestVerifyLinearProofEdgeCases(t *testing.T) {
 			2,
 			sha256.Sum256(nil),
 			sha256.Sum256(nil),
+			hashing.SHA256,
 		),
 	)
 }
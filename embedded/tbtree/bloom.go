@@ -0,0 +1,299 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrCorruptedBloomFilter = errors.New("corrupted bloom filter")
+
+const bloomFilterFilename = "bloom"
+
+const (
+	bloomBitsPerKey = 10
+	bloomHashCount  = 7
+)
+
+// bloomFilter holds every key inserted into the tree so that lookups of absent keys
+// are resolved without traversing the tree. Keys are added to the latest segment,
+// a new segment is started once it holds segmentKeys keys so the false positive
+// rate is kept bounded as the tree grows. A key may be present only if any segment
+// says so, bits are never cleared.
+type bloomFilter struct {
+	segmentKeys int
+	segments    []*bloomSegment
+
+	mutex sync.RWMutex
+}
+
+type bloomSegment struct {
+	keys int
+	bits []uint64
+}
+
+func newBloomFilter(segmentKeys int) *bloomFilter {
+	return &bloomFilter{segmentKeys: segmentKeys}
+}
+
+func newBloomSegment(segmentKeys int) *bloomSegment {
+	return &bloomSegment{
+		bits: make([]uint64, (segmentKeys*bloomBitsPerKey+63)/64),
+	}
+}
+
+func bloomHash(key []byte) (uint32, uint32) {
+	h := fnv.New64a()
+	h.Write(key)
+	s := h.Sum64()
+
+	// double hashing, the second hash must be odd to visit different bits
+	return uint32(s), uint32(s>>32) | 1
+}
+
+func (s *bloomSegment) mayContain(h1, h2 uint32) bool {
+	m := uint32(len(s.bits) * 64)
+
+	for i := uint32(0); i < bloomHashCount; i++ {
+		b := (h1 + i*h2) % m
+		if s.bits[b/64]&(1<<(b%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *bloomSegment) add(h1, h2 uint32) {
+	m := uint32(len(s.bits) * 64)
+
+	for i := uint32(0); i < bloomHashCount; i++ {
+		b := (h1 + i*h2) % m
+		s.bits[b/64] |= 1 << (b % 64)
+	}
+
+	s.keys++
+}
+
+func (bf *bloomFilter) mayContain(key []byte) bool {
+	h1, h2 := bloomHash(key)
+
+	bf.mutex.RLock()
+	defer bf.mutex.RUnlock()
+
+	return bf.mayContainHash(h1, h2)
+}
+
+func (bf *bloomFilter) mayContainHash(h1, h2 uint32) bool {
+	for i := len(bf.segments) - 1; i >= 0; i-- {
+		if bf.segments[i].mayContain(h1, h2) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (bf *bloomFilter) add(key []byte) {
+	h1, h2 := bloomHash(key)
+
+	bf.mutex.Lock()
+	defer bf.mutex.Unlock()
+
+	if bf.mayContainHash(h1, h2) {
+		// already present or a false positive which will remain as such
+		return
+	}
+
+	if len(bf.segments) == 0 || bf.segments[len(bf.segments)-1].keys >= bf.segmentKeys {
+		bf.segments = append(bf.segments, newBloomSegment(bf.segmentKeys))
+	}
+
+	bf.segments[len(bf.segments)-1].add(h1, h2)
+}
+
+// bloomFilter is persisted as [ts][segmentKeys][segmentCount]([keys][wordCount][words...])*[crc32]
+func (bf *bloomFilter) bytes(ts uint64) []byte {
+	bf.mutex.RLock()
+	defer bf.mutex.RUnlock()
+
+	size := 8 + 4 + 4 + 4
+	for _, s := range bf.segments {
+		size += 4 + 4 + len(s.bits)*8
+	}
+
+	b := make([]byte, size)
+	i := 0
+
+	binary.BigEndian.PutUint64(b[i:], ts)
+	i += 8
+
+	binary.BigEndian.PutUint32(b[i:], uint32(bf.segmentKeys))
+	i += 4
+
+	binary.BigEndian.PutUint32(b[i:], uint32(len(bf.segments)))
+	i += 4
+
+	for _, s := range bf.segments {
+		binary.BigEndian.PutUint32(b[i:], uint32(s.keys))
+		i += 4
+
+		binary.BigEndian.PutUint32(b[i:], uint32(len(s.bits)))
+		i += 4
+
+		for _, w := range s.bits {
+			binary.BigEndian.PutUint64(b[i:], w)
+			i += 8
+		}
+	}
+
+	binary.BigEndian.PutUint32(b[i:], crc32.ChecksumIEEE(b[:i]))
+
+	return b
+}
+
+func bloomFilterFrom(b []byte) (bf *bloomFilter, ts uint64, err error) {
+	if len(b) < 8+4+4+4 {
+		return nil, 0, ErrCorruptedBloomFilter
+	}
+
+	if crc32.ChecksumIEEE(b[:len(b)-4]) != binary.BigEndian.Uint32(b[len(b)-4:]) {
+		return nil, 0, ErrCorruptedBloomFilter
+	}
+
+	b = b[:len(b)-4]
+	i := 0
+
+	ts = binary.BigEndian.Uint64(b[i:])
+	i += 8
+
+	bf = newBloomFilter(int(binary.BigEndian.Uint32(b[i:])))
+	i += 4
+
+	segmentCount := int(binary.BigEndian.Uint32(b[i:]))
+	i += 4
+
+	for s := 0; s < segmentCount; s++ {
+		if len(b)-i < 8 {
+			return nil, 0, ErrCorruptedBloomFilter
+		}
+
+		segment := &bloomSegment{keys: int(binary.BigEndian.Uint32(b[i:]))}
+		i += 4
+
+		wordCount := int(binary.BigEndian.Uint32(b[i:]))
+		i += 4
+
+		if wordCount == 0 || len(b)-i < wordCount*8 {
+			return nil, 0, ErrCorruptedBloomFilter
+		}
+
+		segment.bits = make([]uint64, wordCount)
+		for w := range segment.bits {
+			segment.bits[w] = binary.BigEndian.Uint64(b[i:])
+			i += 8
+		}
+
+		bf.segments = append(bf.segments, segment)
+	}
+
+	if i != len(b) {
+		return nil, 0, ErrCorruptedBloomFilter
+	}
+
+	return bf, ts, nil
+}
+
+// loadBloomFilter reads the persisted filter of the tree. The filter is rebuilt from the tree content
+// when it's missing, corrupted, created with a different segment size or older than the tree
+func (t *TBtree) loadBloomFilter(segmentKeys int) error {
+	b, err := ioutil.ReadFile(filepath.Join(t.path, bloomFilterFilename))
+	if err == nil {
+		bf, ts, err := bloomFilterFrom(b)
+		if err == nil && bf.segmentKeys == segmentKeys && ts >= t.root.ts() {
+			t.bloom = bf
+			return nil
+		}
+
+		t.log.Warningf("Bloom filter of index '%s' can not be used, it will be rebuilt", t.path)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	t.log.Infof("Building bloom filter of index '%s' {ts=%d}...", t.path, t.root.ts())
+
+	bf := newBloomFilter(segmentKeys)
+
+	err = t.addKeysTo(bf, t.root)
+	if err != nil {
+		return err
+	}
+
+	t.bloom = bf
+
+	t.log.Infof("Bloom filter of index '%s' {ts=%d} successfully built", t.path, t.root.ts())
+
+	return nil
+}
+
+func (t *TBtree) addKeysTo(bf *bloomFilter, n node) error {
+	switch n := n.(type) {
+	case *innerNode:
+		for _, c := range n.nodes {
+			err := t.addKeysTo(bf, c)
+			if err != nil {
+				return err
+			}
+		}
+	case *nodeRef:
+		c, err := t.nodeAt(n.off, false)
+		if err != nil {
+			return err
+		}
+		return t.addKeysTo(bf, c)
+	case *leafNode:
+		for _, v := range n.values {
+			bf.add(v.key)
+		}
+	}
+
+	return nil
+}
+
+// saveBloomFilter persists the filter, it holds every key inserted up to the current timestamp of the tree
+func (t *TBtree) saveBloomFilter() error {
+	if t.bloom == nil || t.readOnly {
+		return nil
+	}
+
+	fileName := filepath.Join(t.path, bloomFilterFilename)
+	tmpFileName := fileName + ".tmp"
+
+	err := ioutil.WriteFile(tmpFileName, t.bloom.bytes(t.root.ts()), t.fileMode&0666)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFileName, fileName)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter(t *testing.T) {
+	bf := newBloomFilter(100)
+
+	for i := 0; i < 1000; i++ {
+		bf.add([]byte(fmt.Sprintf("key%d", i)))
+	}
+
+	require.Greater(t, len(bf.segments), 1)
+
+	for i := 0; i < 1000; i++ {
+		require.True(t, bf.mayContain([]byte(fmt.Sprintf("key%d", i))))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if bf.mayContain([]byte(fmt.Sprintf("absent%d", i))) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 200)
+
+	bf1, ts, err := bloomFilterFrom(bf.bytes(10))
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), ts)
+	require.Equal(t, bf.segmentKeys, bf1.segmentKeys)
+	require.Equal(t, bf.segments, bf1.segments)
+
+	b := bf.bytes(10)
+	b[20] ^= 0xFF
+
+	_, _, err = bloomFilterFrom(b)
+	require.ErrorIs(t, err, ErrCorruptedBloomFilter)
+
+	_, _, err = bloomFilterFrom(b[:10])
+	require.ErrorIs(t, err, ErrCorruptedBloomFilter)
+}
+
+func TestTBTreeBloomFilter(t *testing.T) {
+	opts := DefaultOptions().WithBloomFilterKeys(100)
+
+	tbtree, err := Open("test_tree_bloom", opts)
+	require.NoError(t, err)
+
+	defer os.RemoveAll("test_tree_bloom")
+
+	for i := 0; i < 500; i++ {
+		err = tbtree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	requireKeys := func(tbtree *TBtree) {
+		for i := 0; i < 500; i++ {
+			v, _, _, err := tbtree.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), v)
+		}
+
+		_, _, _, err := tbtree.Get([]byte("absent"))
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		_, err = tbtree.History([]byte("absent"), 0, false, 1)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		snap, err := tbtree.Snapshot()
+		require.NoError(t, err)
+
+		_, _, _, err = snap.Get([]byte("key1"))
+		require.NoError(t, err)
+
+		_, _, _, err = snap.Get([]byte("absent"))
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		// keys set into a snapshot are added to the filter as well
+		err = snap.Set([]byte("snapKey"), []byte("snapValue"))
+		require.NoError(t, err)
+
+		_, _, _, err = snap.Get([]byte("snapKey"))
+		require.NoError(t, err)
+
+		err = snap.Close()
+		require.NoError(t, err)
+	}
+
+	requireKeys(tbtree)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+
+	require.FileExists(t, filepath.Join("test_tree_bloom", bloomFilterFilename))
+
+	t.Run("persisted bloom filter is used after reopening", func(t *testing.T) {
+		tbtree, err := Open("test_tree_bloom", opts)
+		require.NoError(t, err)
+
+		requireKeys(tbtree)
+
+		err = tbtree.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("corrupted bloom filter is rebuilt after reopening", func(t *testing.T) {
+		err := ioutil.WriteFile(filepath.Join("test_tree_bloom", bloomFilterFilename), []byte("corrupted"), 0644)
+		require.NoError(t, err)
+
+		tbtree, err := Open("test_tree_bloom", opts)
+		require.NoError(t, err)
+
+		requireKeys(tbtree)
+
+		err = tbtree.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("bloom filter is rebuilt when using a different segment size", func(t *testing.T) {
+		tbtree, err := Open("test_tree_bloom", DefaultOptions().WithBloomFilterKeys(1000))
+		require.NoError(t, err)
+
+		requireKeys(tbtree)
+		require.Len(t, tbtree.bloom.segments, 1)
+
+		err = tbtree.Close()
+		require.NoError(t, err)
+	})
+}
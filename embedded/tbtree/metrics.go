@@ -37,6 +37,23 @@ var metricsCacheEvict = promauto.NewCounterVec(prometheus.CounterOpts{
 	Help: "Number of btree nodes evicted from cache",
 }, []string{"id"})
 
+var metricsCacheHitRatio = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "immudb_btree_cache_hit_ratio",
+	Help: "Ratio of btree node lookups served from cache",
+}, []string{"id"})
+
+var metricsFlushDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "immudb_btree_flush_duration_seconds",
+	Help:    "Duration of btree flushes",
+	Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+}, []string{"id"})
+
+var metricsCompactionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "immudb_btree_compaction_duration_seconds",
+	Help:    "Duration of btree compactions",
+	Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+}, []string{"id"})
+
 var metricsBtreeDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
 	Name: "immudb_btree_depth",
 	Help: "Btree depth",
@@ -53,3 +70,8 @@ var metricsBtreeLeafNodeEntries = promauto.NewHistogramVec(prometheus.HistogramO
 	Help:    "Histogram of number of entries in as single leaf btree node, calculated when visiting btree nodes",
 	Buckets: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 15, 20, 25, 30, 40, 50, 75, 100, 200, 300, 500},
 }, []string{"id"})
+
+var metricsBloomFilterNegatives = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_btree_bloom_filter_negatives",
+	Help: "Number of btree key lookups resolved by the bloom filter without visiting btree nodes",
+}, []string{"id"})
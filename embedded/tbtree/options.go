@@ -21,6 +21,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
 	"github.com/codenotary/immudb/pkg/logger"
 )
 
@@ -30,6 +31,7 @@ const DefaultSyncThld = 1_000_000
 const DefaultMaxActiveSnapshots = 100
 const DefaultRenewSnapRootAfter = time.Duration(1000) * time.Millisecond
 const DefaultCacheSize = 100_000
+const DefaultCacheEvictionPolicy = cache.DefaultEvictionPolicy
 const DefaultFileMode = os.FileMode(0755)
 const DefaultFileSize = 1 << 26 // 64Mb
 const DefaultMaxKeyLen = 1024
@@ -40,6 +42,8 @@ const DefaultNodesLogMaxOpenedFiles = 10
 const DefaultHistoryLogMaxOpenedFiles = 1
 const DefaultCommitLogMaxOpenedFiles = 1
 
+const DefaultBloomFilterKeys = 0 // bloom filter is disabled by default
+
 const MinNodeSize = 128
 const MinCacheSize = 1
 
@@ -52,14 +56,15 @@ type AppFactoryFunc func(
 type Options struct {
 	log logger.Logger
 
-	flushThld          int
-	syncThld           int
-	maxActiveSnapshots int
-	renewSnapRootAfter time.Duration
-	cacheSize          int
-	readOnly           bool
-	synced             bool
-	fileMode           os.FileMode
+	flushThld           int
+	syncThld            int
+	maxActiveSnapshots  int
+	renewSnapRootAfter  time.Duration
+	cacheSize           int
+	cacheEvictionPolicy cache.EvictionPolicy
+	readOnly            bool
+	synced              bool
+	fileMode            os.FileMode
 
 	nodesLogMaxOpenedFiles   int
 	historyLogMaxOpenedFiles int
@@ -70,6 +75,9 @@ type Options struct {
 	compactionThld        int
 	delayDuringCompaction time.Duration
 
+	// number of keys held by each bloom filter segment, bloom filter is disabled when set to 0
+	bloomFilterKeys int
+
 	// options below are only set during initialization and stored as metadata
 	maxNodeSize int
 	fileSize    int
@@ -85,12 +93,14 @@ func DefaultOptions() *Options {
 		maxActiveSnapshots:    DefaultMaxActiveSnapshots,
 		renewSnapRootAfter:    DefaultRenewSnapRootAfter,
 		cacheSize:             DefaultCacheSize,
+		cacheEvictionPolicy:   DefaultCacheEvictionPolicy,
 		readOnly:              false,
 		synced:                false,
 		fileMode:              DefaultFileMode,
 		maxKeyLen:             DefaultMaxKeyLen,
 		compactionThld:        DefaultCompactionThld,
 		delayDuringCompaction: DefaultDelayDuringCompaction,
+		bloomFilterKeys:       DefaultBloomFilterKeys,
 
 		nodesLogMaxOpenedFiles:   DefaultNodesLogMaxOpenedFiles,
 		historyLogMaxOpenedFiles: DefaultHistoryLogMaxOpenedFiles,
@@ -117,8 +127,10 @@ func validOptions(opts *Options) bool {
 		opts.maxActiveSnapshots > 0 &&
 		opts.renewSnapRootAfter >= 0 &&
 		opts.cacheSize >= MinCacheSize &&
+		cache.ValidEvictionPolicy(opts.cacheEvictionPolicy) &&
 		opts.maxKeyLen > 0 &&
 		opts.compactionThld >= 0 &&
+		opts.bloomFilterKeys >= 0 &&
 		opts.log != nil
 }
 
@@ -157,6 +169,11 @@ func (opts *Options) WithCacheSize(cacheSize int) *Options {
 	return opts
 }
 
+func (opts *Options) WithCacheEvictionPolicy(cacheEvictionPolicy cache.EvictionPolicy) *Options {
+	opts.cacheEvictionPolicy = cacheEvictionPolicy
+	return opts
+}
+
 func (opts *Options) WithReadOnly(readOnly bool) *Options {
 	opts.readOnly = readOnly
 	return opts
@@ -211,3 +228,8 @@ func (opts *Options) WithDelayDuringCompaction(delay time.Duration) *Options {
 	opts.delayDuringCompaction = delay
 	return opts
 }
+
+func (opts *Options) WithBloomFilterKeys(bloomFilterKeys int) *Options {
+	opts.bloomFilterKeys = bloomFilterKeys
+	return opts
+}
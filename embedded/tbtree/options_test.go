@@ -21,6 +21,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
 	"github.com/stretchr/testify/require"
 )
 
@@ -46,6 +47,8 @@ func TestValidOptions(t *testing.T) {
 	require.True(t, opts.WithSynced(true).synced)
 	require.Equal(t, 256, opts.WithMaxKeyLen(256).maxKeyLen)
 	require.Equal(t, time.Duration(1)*time.Millisecond, opts.WithDelayDuringCompaction(time.Duration(1)*time.Millisecond).delayDuringCompaction)
+	require.Equal(t, 1000, opts.WithBloomFilterKeys(1000).bloomFilterKeys)
+	require.Equal(t, cache.ARC, opts.WithCacheEvictionPolicy(cache.ARC).cacheEvictionPolicy)
 	require.False(t, opts.WithReadOnly(false).readOnly)
 	require.NotNil(t, opts.WithLog(DefaultOptions().log))
 
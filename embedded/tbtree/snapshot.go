@@ -57,6 +57,10 @@ func (s *Snapshot) Set(key, value []byte) error {
 		return err
 	}
 
+	if s.t.bloom != nil {
+		s.t.bloom.add(k)
+	}
+
 	if n2 == nil {
 		s.root = n1
 	} else {
@@ -91,6 +95,10 @@ func (s *Snapshot) Get(key []byte) (value []byte, ts uint64, hc uint64, err erro
 		return nil, 0, 0, ErrIllegalArguments
 	}
 
+	if !s.t.mayContain(key) {
+		return nil, 0, 0, ErrKeyNotFound
+	}
+
 	v, ts, hc, err := s.root.get(key)
 	return cp(v), ts, hc, err
 }
@@ -111,6 +119,10 @@ func (s *Snapshot) History(key []byte, offset uint64, descOrder bool, limit int)
 		return nil, ErrIllegalArguments
 	}
 
+	if !s.t.mayContain(key) {
+		return nil, ErrKeyNotFound
+	}
+
 	return s.root.history(key, offset, descOrder, limit)
 }
 
@@ -51,6 +51,7 @@ var ErrCorruptedFile = errors.New("file is corrupted")
 var ErrCorruptedCLog = errors.New("commit log is corrupted")
 var ErrCompactAlreadyInProgress = errors.New("compact already in progress")
 var ErrCompactionThresholdNotReached = errors.New("compaction threshold not yet reached")
+var ErrReadOnly = errors.New("cannot modify index when opened in read-only mode")
 
 const Version = 1
 
@@ -75,9 +76,12 @@ type TBtree struct {
 	log  logger.Logger
 
 	nLog   appendable.Appendable
-	cache  *cache.LRUCache
+	cache  cache.Cache
 	nmutex sync.Mutex // mutex for cache and file reading
 
+	cacheHits    uint64 // guarded by nmutex
+	cacheLookups uint64 // guarded by nmutex
+
 	hLog appendable.Appendable
 
 	cLog appendable.Appendable
@@ -94,6 +98,8 @@ type TBtree struct {
 	readOnly                 bool
 	synced                   bool
 	cacheSize                int
+	cacheEvictionPolicy      cache.EvictionPolicy
+	bloomFilterKeys          int
 	fileSize                 int
 	fileMode                 os.FileMode
 	maxKeyLen                int
@@ -102,9 +108,12 @@ type TBtree struct {
 	nodesLogMaxOpenedFiles   int
 	historyLogMaxOpenedFiles int
 	commitLogMaxOpenedFiles  int
+	appFactory               AppFactoryFunc
 
 	greatestKey []byte
 
+	bloom *bloomFilter
+
 	snapshots      map[uint64]*Snapshot
 	maxSnapshotID  uint64
 	lastSnapRoot   node
@@ -191,7 +200,7 @@ func Open(path string, opts *Options) (*TBtree, error) {
 
 	finfo, err := os.Stat(path)
 	if err != nil {
-		if !os.IsNotExist(err) {
+		if !os.IsNotExist(err) || opts.readOnly {
 			return nil, err
 		}
 		err = os.Mkdir(path, opts.fileMode)
@@ -283,9 +292,11 @@ func Open(path string, opts *Options) (*TBtree, error) {
 			nLog.Close()
 			cLog.Close()
 
-			err = discardSnapshots(path, snapIDs[i-1:i], opts.log)
-			if err != nil {
-				opts.log.Warningf("Discarding snapshots at '%s' returned: %v", path, err)
+			if !opts.readOnly {
+				err = discardSnapshots(path, snapIDs[i-1:i], opts.log)
+				if err != nil {
+					opts.log.Warningf("Discarding snapshots at '%s' returned: %v", path, err)
+				}
 			}
 
 			continue
@@ -293,10 +304,12 @@ func Open(path string, opts *Options) (*TBtree, error) {
 
 		opts.log.Infof("Successfully read snapshot at '%s'", snapPath)
 
-		// Discard older snapshots upon successful validation
-		err = discardSnapshots(path, snapIDs[:i-1], opts.log)
-		if err != nil {
-			opts.log.Warningf("Discarding snapshots at '%s' returned: %v", path, err)
+		// Discard older snapshots upon successful validation, they are left untouched in read-only mode
+		if !opts.readOnly {
+			err = discardSnapshots(path, snapIDs[:i-1], opts.log)
+			if err != nil {
+				opts.log.Warningf("Discarding snapshots at '%s' returned: %v", path, err)
+			}
 		}
 
 		return t, nil
@@ -412,7 +425,7 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 		}
 	}
 
-	cache, err := cache.NewLRUCache(opts.cacheSize)
+	cache, err := cache.NewCache(opts.cacheEvictionPolicy, opts.cacheSize)
 	if err != nil {
 		return nil, err
 	}
@@ -438,6 +451,9 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 		nodesLogMaxOpenedFiles:   opts.nodesLogMaxOpenedFiles,
 		historyLogMaxOpenedFiles: opts.historyLogMaxOpenedFiles,
 		commitLogMaxOpenedFiles:  opts.commitLogMaxOpenedFiles,
+		appFactory:               opts.appFactory,
+		cacheEvictionPolicy:      opts.cacheEvictionPolicy,
+		bloomFilterKeys:          opts.bloomFilterKeys,
 		greatestKey:              greatestKeyOfSize(opts.maxKeyLen),
 		readOnly:                 opts.readOnly,
 		synced:                   opts.synced,
@@ -491,6 +507,13 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 	t.committedLogSize = cLogSize
 	t.committedHLogSize = hLog.Offset()
 
+	if opts.bloomFilterKeys > 0 {
+		err = t.loadBloomFilter(opts.bloomFilterKeys)
+		if err != nil {
+			return nil, fmt.Errorf("%w: while loading index bloom filter", err)
+		}
+	}
+
 	err = t.cLog.SetOffset(cLogSize)
 	if err != nil {
 		return nil, fmt.Errorf("%w: while loading index commit log", err)
@@ -527,7 +550,10 @@ func (t *TBtree) GetOptions() *Options {
 		WithDelayDuringCompaction(t.delayDuringCompaction).
 		WithNodesLogMaxOpenedFiles(t.nodesLogMaxOpenedFiles).
 		WithHistoryLogMaxOpenedFiles(t.historyLogMaxOpenedFiles).
-		WithCommitLogMaxOpenedFiles(t.commitLogMaxOpenedFiles)
+		WithCommitLogMaxOpenedFiles(t.commitLogMaxOpenedFiles).
+		WithCacheEvictionPolicy(t.cacheEvictionPolicy).
+		WithBloomFilterKeys(t.bloomFilterKeys).
+		WithAppFactory(t.appFactory)
 }
 
 func (t *TBtree) cachePut(n node) {
@@ -540,6 +566,16 @@ func (t *TBtree) cachePut(n node) {
 	}
 }
 
+// updateCacheHitRatio must be called while holding nmutex
+func (t *TBtree) updateCacheHitRatio(hit bool) {
+	t.cacheLookups++
+	if hit {
+		t.cacheHits++
+	}
+
+	metricsCacheHitRatio.WithLabelValues(t.path).Set(float64(t.cacheHits) / float64(t.cacheLookups))
+}
+
 func (t *TBtree) nodeAt(offset int64, updateCache bool) (node, error) {
 	t.nmutex.Lock()
 	defer t.nmutex.Unlock()
@@ -550,11 +586,13 @@ func (t *TBtree) nodeAt(offset int64, updateCache bool) (node, error) {
 	v, err := t.cache.Get(offset)
 	if err == nil {
 		metricsCacheHit.WithLabelValues(t.path).Inc()
+		t.updateCacheHitRatio(true)
 		return v.(node), nil
 	}
 
 	if err == cache.ErrKeyNotFound {
 		metricsCacheMiss.WithLabelValues(t.path).Inc()
+		t.updateCacheHitRatio(false)
 
 		n, err := t.readNodeAt(offset)
 		if err != nil {
@@ -791,6 +829,10 @@ func (t *TBtree) Get(key []byte) (value []byte, ts uint64, hc uint64, err error)
 		return nil, 0, 0, ErrIllegalArguments
 	}
 
+	if !t.mayContain(key) {
+		return nil, 0, 0, ErrKeyNotFound
+	}
+
 	v, ts, hc, err := t.root.get(key)
 	return cp(v), ts, hc, err
 }
@@ -811,9 +853,24 @@ func (t *TBtree) History(key []byte, offset uint64, descOrder bool, limit int) (
 		return nil, ErrIllegalArguments
 	}
 
+	if !t.mayContain(key) {
+		return nil, ErrKeyNotFound
+	}
+
 	return t.root.history(key, offset, descOrder, limit)
 }
 
+// mayContain returns false only if the key was never inserted into the tree
+func (t *TBtree) mayContain(key []byte) bool {
+	if t.bloom == nil || t.bloom.mayContain(key) {
+		return true
+	}
+
+	metricsBloomFilterNegatives.WithLabelValues(t.path).Inc()
+
+	return false
+}
+
 func (t *TBtree) ExistKeyWith(prefix []byte, neq []byte) (bool, error) {
 	t.rwmutex.RLock()
 	defer t.rwmutex.RUnlock()
@@ -879,9 +936,43 @@ func (t *TBtree) Flush() (wN, wH int64, err error) {
 		return 0, 0, ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return 0, 0, ErrReadOnly
+	}
+
 	return t.flushTree(false)
 }
 
+// Checkpoint durably persists the current state of the tree, so it can be loaded on reopening
+// without replaying the insertions made since the last flush.
+// Nothing is written when the latest flushed state is already synced.
+func (t *TBtree) Checkpoint() error {
+	t.rwmutex.Lock()
+	defer t.rwmutex.Unlock()
+
+	if t.closed {
+		return ErrAlreadyClosed
+	}
+
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
+	if !t.root.mutated() && (t.synced || t.insertionCountSinceSync == 0) {
+		return nil
+	}
+
+	// nodes written by previous async flushes must be durable before referencing them
+	err := t.sync()
+	if err != nil {
+		return err
+	}
+
+	_, _, err = t.flushTree(true)
+
+	return err
+}
+
 type appendableWriter struct {
 	appendable.Appendable
 }
@@ -906,6 +997,12 @@ func (t *TBtree) flushTree(ensureSync bool) (wN int64, wH int64, err error) {
 		return 0, 0, nil
 	}
 
+	defer func(start time.Time) {
+		if err == nil {
+			metricsFlushDuration.WithLabelValues(t.path).Observe(time.Since(start).Seconds())
+		}
+	}(time.Now())
+
 	explicitSync := !t.synced && (ensureSync || t.insertionCountSinceSync >= t.syncThld)
 
 	if t.root.mutated() {
@@ -1008,6 +1105,12 @@ func (t *TBtree) flushTree(ensureSync bool) (wN int64, wH int64, err error) {
 	if t.synced || explicitSync {
 		t.insertionCountSinceSync = 0
 		t.log.Infof("Index '%s' {ts=%d} successfully synced", t.path, t.root.ts())
+
+		// the bloom filter is rebuilt on opening if it couldn't be persisted
+		err = t.saveBloomFilter()
+		if err != nil {
+			t.log.Warningf("Saving bloom filter of index '%s' {ts=%d} returned: %v", t.path, t.root.ts(), err)
+		}
 	}
 
 	return wN, wH, nil
@@ -1040,6 +1143,12 @@ func (t *TBtree) currentSnapshot() (*Snapshot, error) {
 }
 
 func (t *TBtree) Compact() (uint64, error) {
+	return t.CompactWithProgress(nil)
+}
+
+// CompactWithProgress compacts the index as Compact does,
+// progress is notified with the number of bytes of nodes dumped so far
+func (t *TBtree) CompactWithProgress(progress func(dumpedBytes int64)) (uint64, error) {
 	t.rwmutex.Lock()
 	defer t.rwmutex.Unlock()
 
@@ -1047,6 +1156,10 @@ func (t *TBtree) Compact() (uint64, error) {
 		return 0, ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return 0, ErrReadOnly
+	}
+
 	if t.compacting {
 		return 0, ErrCompactAlreadyInProgress
 	}
@@ -1071,17 +1184,21 @@ func (t *TBtree) Compact() (uint64, error) {
 
 	t.log.Infof("Dumping index '%s' {ts=%d}...", t.path, snap.Ts())
 
-	err = t.fullDump(snap)
+	start := time.Now()
+
+	err = t.fullDump(snap, progress)
 	if err != nil {
 		return 0, t.wrapNwarn("Dumping index '%s' {ts=%d} returned: %v", t.path, snap.Ts(), err)
 	}
 
+	metricsCompactionDuration.WithLabelValues(t.path).Observe(time.Since(start).Seconds())
+
 	t.log.Infof("Index '%s' {ts=%d} successfully dumped", t.path, snap.Ts())
 
 	return snap.Ts(), nil
 }
 
-func (t *TBtree) fullDump(snap *Snapshot) error {
+func (t *TBtree) fullDump(snap *Snapshot, progress func(dumpedBytes int64)) error {
 	metadata := appendable.NewMetadata(nil)
 	metadata.PutInt(MetaVersion, Version)
 	metadata.PutInt(MetaMaxNodeSize, t.maxNodeSize)
@@ -1093,9 +1210,16 @@ func (t *TBtree) fullDump(snap *Snapshot) error {
 		WithFileMode(t.fileMode).
 		WithMetadata(t.cLog.Metadata())
 
+	appFactory := t.appFactory
+	if appFactory == nil {
+		appFactory = func(rootPath, subPath string, opts *multiapp.Options) (appendable.Appendable, error) {
+			path := filepath.Join(rootPath, subPath)
+			return multiapp.Open(path, opts)
+		}
+	}
+
 	appendableOpts.WithFileExt("n")
-	nLogPath := filepath.Join(t.path, snapFolder(nodesFolderPrefix, snap.Ts()))
-	nLog, err := multiapp.Open(nLogPath, appendableOpts)
+	nLog, err := appFactory(t.path, snapFolder(nodesFolderPrefix, snap.Ts()), appendableOpts)
 	if err != nil {
 		return err
 	}
@@ -1103,9 +1227,12 @@ func (t *TBtree) fullDump(snap *Snapshot) error {
 		nLog.Close()
 	}()
 
+	if progress != nil {
+		nLog = &progressAppendable{Appendable: nLog, progress: progress}
+	}
+
 	appendableOpts.WithFileExt("ri")
-	cLogPath := filepath.Join(t.path, snapFolder(commitFolderPrefix, snap.Ts()))
-	cLog, err := multiapp.Open(cLogPath, appendableOpts)
+	cLog, err := appFactory(t.path, snapFolder(commitFolderPrefix, snap.Ts()), appendableOpts)
 	if err != nil {
 		return err
 	}
@@ -1116,6 +1243,78 @@ func (t *TBtree) fullDump(snap *Snapshot) error {
 	return t.fullDumpTo(snap, nLog, cLog)
 }
 
+// progressAppendable notifies the number of bytes appended so far
+type progressAppendable struct {
+	appendable.Appendable
+	appended int64
+	progress func(appended int64)
+}
+
+func (p *progressAppendable) Append(bs []byte) (off int64, n int, err error) {
+	off, n, err = p.Appendable.Append(bs)
+	p.appended += int64(n)
+	p.progress(p.appended)
+	return off, n, err
+}
+
+// CompactionEstimate describes the outcome of compacting the index
+type CompactionEstimate struct {
+	// NodesSize is the size in bytes of the nodes as currently stored
+	NodesSize int64
+	// CompactedNodesSize is the size in bytes of the nodes once compacted
+	CompactedNodesSize int64
+	// SnapshotCount is the number of snapshots stored since the last compaction
+	SnapshotCount uint64
+	// ThresholdReached tells if there are enough snapshots for the index to be compacted
+	ThresholdReached bool
+}
+
+// EstimateCompaction computes the space a compaction would reclaim without modifying the index.
+// The whole index is traversed as it is when compacting it, so it's as expensive as a compaction minus the writes
+func (t *TBtree) EstimateCompaction() (*CompactionEstimate, error) {
+	t.rwmutex.Lock()
+	defer t.rwmutex.Unlock()
+
+	if t.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	var snap *Snapshot
+
+	if t.readOnly {
+		snap = t.newSnapshot(0, t.root)
+	} else {
+		var err error
+
+		snap, err = t.currentSnapshot()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nodesSize, err := t.nLog.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &CompactionEstimate{
+		NodesSize:        nodesSize,
+		SnapshotCount:    t.snapshotCount(),
+		ThresholdReached: t.snapshotCount() >= uint64(t.compactionThld),
+	}
+
+	// snapshot traversal without lock
+	t.rwmutex.Unlock()
+	defer t.rwmutex.Lock()
+
+	_, estimate.CompactedNodesSize, _, err = snap.WriteTo(ioutil.Discard, nil, &WriteOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	return estimate, nil
+}
+
 func (t *TBtree) fullDumpTo(snapshot *Snapshot, nLog, cLog appendable.Appendable) error {
 	wopts := &WriteOpts{
 		OnlyMutated:    false,
@@ -1177,10 +1376,12 @@ func (t *TBtree) Close() error {
 
 	merrors := multierr.NewMultiErr()
 
-	_, _, err := t.flushTree(true)
-	merrors.Append(err)
+	if !t.readOnly {
+		_, _, err := t.flushTree(true)
+		merrors.Append(err)
+	}
 
-	err = t.nLog.Close()
+	err := t.nLog.Close()
 	merrors.Append(err)
 
 	err = t.hLog.Close()
@@ -1206,6 +1407,10 @@ func (t *TBtree) IncreaseTs(ts uint64) error {
 		return ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
 	root, err := t.root.setTs(ts)
 	if err != nil {
 		return err
@@ -1273,6 +1478,10 @@ func (t *TBtree) BulkInsert(kvs []*KV) error {
 		return ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
 	ts := t.root.ts() + 1
 
 	for _, kv := range kvs {
@@ -1287,6 +1496,10 @@ func (t *TBtree) BulkInsert(kvs []*KV) error {
 			return err
 		}
 
+		if t.bloom != nil {
+			t.bloom.add(k)
+		}
+
 		if n2 == nil {
 			t.root = n1
 		} else {
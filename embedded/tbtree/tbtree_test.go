@@ -32,6 +32,10 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 
 	"github.com/stretchr/testify/require"
 )
@@ -1251,3 +1255,231 @@ func BenchmarkRandomBulkInsertion(b *testing.B) {
 		tbtree.Close()
 	}
 }
+
+func TestTBTreeARCCache(t *testing.T) {
+	opts := DefaultOptions().
+		WithCacheSize(10).
+		WithCacheEvictionPolicy(cache.ARC).
+		WithFlushThld(10)
+
+	require.False(t, validOptions(DefaultOptions().WithCacheEvictionPolicy("fifo")))
+
+	tbtree, err := Open("test_tree_arc", opts)
+	require.NoError(t, err)
+
+	defer os.RemoveAll("test_tree_arc")
+
+	require.IsType(t, &cache.ARCCache{}, tbtree.cache)
+
+	for i := 0; i < 1000; i++ {
+		err = tbtree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		v, _, _, err := tbtree.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), v)
+	}
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+}
+
+func histogramSampleCount(t *testing.T, h *prometheus.HistogramVec, label string) uint64 {
+	var m dto.Metric
+
+	err := h.WithLabelValues(label).(prometheus.Histogram).Write(&m)
+	require.NoError(t, err)
+
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestTBTreeMetrics(t *testing.T) {
+	path, err := ioutil.TempDir("", "test_tree_metrics")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	tbtree, err := Open(path, DefaultOptions().WithCacheSize(10).WithCompactionThld(1))
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		err = tbtree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, _, err = tbtree.Flush()
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(1), histogramSampleCount(t, metricsFlushDuration, path))
+
+	// nothing to flush
+	_, _, err = tbtree.Flush()
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(1), histogramSampleCount(t, metricsFlushDuration, path))
+
+	for i := 0; i < 100; i++ {
+		_, _, _, err := tbtree.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+	}
+
+	ratio := testutil.ToFloat64(metricsCacheHitRatio.WithLabelValues(path))
+	require.Greater(t, ratio, float64(0))
+	require.LessOrEqual(t, ratio, float64(1))
+
+	_, err = tbtree.Compact()
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(1), histogramSampleCount(t, metricsCompactionDuration, path))
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+}
+
+func TestTBTreeCheckpoint(t *testing.T) {
+	dir := "test_tree_checkpoint"
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().WithSynced(false).WithFlushThld(100).WithSyncThld(1000)
+
+	tbtree, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	err = tbtree.Checkpoint()
+	require.NoError(t, err)
+
+	persistedTs := func() uint64 {
+		roTree, err := Open(dir, DefaultOptions().WithReadOnly(true))
+		require.NoError(t, err)
+
+		defer roTree.Close()
+
+		return roTree.Ts()
+	}
+
+	err = tbtree.Insert([]byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	// async flushes are discarded when the tree is reopened
+	_, _, err = tbtree.Flush()
+	require.NoError(t, err)
+	require.Zero(t, persistedTs())
+
+	err = tbtree.Checkpoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), persistedTs())
+
+	err = tbtree.Insert([]byte("key2"), []byte("value2"))
+	require.NoError(t, err)
+
+	err = tbtree.Checkpoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), persistedTs())
+
+	cLogSize, err := tbtree.cLog.Size()
+	require.NoError(t, err)
+
+	// nothing is written when there are no changes since the last checkpoint
+	err = tbtree.Checkpoint()
+	require.NoError(t, err)
+
+	size, err := tbtree.cLog.Size()
+	require.NoError(t, err)
+	require.Equal(t, cLogSize, size)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+
+	err = tbtree.Checkpoint()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	roTree, err := Open(dir, DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	err = roTree.Checkpoint()
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = roTree.Close()
+	require.NoError(t, err)
+}
+
+func TestTBTreeReadOnly(t *testing.T) {
+	defer os.RemoveAll("test_tree_read_only")
+
+	_, err := Open("test_tree_read_only", DefaultOptions().WithReadOnly(true))
+	require.True(t, os.IsNotExist(err))
+
+	tbtree, err := Open("test_tree_read_only", DefaultOptions())
+	require.NoError(t, err)
+
+	err = tbtree.Insert([]byte("key"), []byte("value"))
+	require.NoError(t, err)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+
+	tbtree, err = Open("test_tree_read_only", DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	v, ts, _, err := tbtree.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), v)
+	require.Equal(t, uint64(1), ts)
+
+	err = tbtree.Insert([]byte("key"), []byte("value1"))
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = tbtree.IncreaseTs(2)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	_, _, err = tbtree.Flush()
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	_, err = tbtree.Compact()
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+}
+
+func TestTBTreeEstimateCompaction(t *testing.T) {
+	path, err := ioutil.TempDir("", "test_tree_estimate_compaction")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	tbtree, err := Open(path, DefaultOptions().WithCompactionThld(2))
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		err = tbtree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		if i%10 == 0 {
+			_, _, err = tbtree.Flush()
+			require.NoError(t, err)
+		}
+	}
+
+	estimate, err := tbtree.EstimateCompaction()
+	require.NoError(t, err)
+	require.True(t, estimate.ThresholdReached)
+	require.Greater(t, estimate.SnapshotCount, uint64(2))
+	require.Greater(t, estimate.CompactedNodesSize, int64(0))
+	require.Less(t, estimate.CompactedNodesSize, estimate.NodesSize)
+
+	var dumped int64
+
+	_, err = tbtree.CompactWithProgress(func(dumpedBytes int64) {
+		require.GreaterOrEqual(t, dumpedBytes, dumped)
+		dumped = dumpedBytes
+	})
+	require.NoError(t, err)
+	require.Equal(t, estimate.CompactedNodesSize, dumped)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+
+	_, err = tbtree.EstimateCompaction()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
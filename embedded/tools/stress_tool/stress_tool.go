@@ -358,7 +358,7 @@ func main() {
 					panic(err)
 				}
 
-				entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version)
+				entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version, tx.Header().HashAlgorithm)
 				if err != nil {
 					panic(err)
 				}
@@ -377,7 +377,7 @@ func main() {
 
 						kv := &store.EntrySpec{Key: e.Key(), Value: val}
 
-						verifies := htree.VerifyInclusion(proof, entrySpecDigest(kv), tx.Header().Eh)
+						verifies := htree.VerifyInclusion(proof, entrySpecDigest(kv), tx.Header().Eh, tx.Header().HashAlgorithm)
 						if !verifies {
 							panic("kv does not verify")
 						}
@@ -0,0 +1,287 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package alert delivers tamper alerts, raised when the verification of a database fails,
+// through webhooks, email and PagerDuty.
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+
+// Kind is the kind of verification failure an alert is raised for
+type Kind string
+
+const (
+	// Corruption is raised when stored transactions don't match their digests or the linear hash chain
+	Corruption Kind = "corruption"
+	// ReplicaDivergence is raised when a transaction sent by the primary doesn't match the state of the replica
+	ReplicaDivergence Kind = "replica_divergence"
+	// Tampering is raised when the state of a database is not consistent with a previously trusted one
+	Tampering Kind = "tampering"
+	// Rollback is raised when a database is behind a previously trusted state
+	Rollback Kind = "rollback"
+	// InvalidSignature is raised when the signature of the state of a database can't be verified
+	InvalidSignature Kind = "invalid_signature"
+)
+
+// Alert reports a verification failure of the transactions FromTx to ToTx of a database,
+// evidence holds the details needed to investigate it, e.g. the mismatching digests
+type Alert struct {
+	Kind     Kind              `json:"kind"`
+	Source   string            `json:"source"`
+	DB       string            `json:"db"`
+	FromTx   uint64            `json:"from_tx"`
+	ToTx     uint64            `json:"to_tx"`
+	Message  string            `json:"message"`
+	Evidence map[string]string `json:"evidence,omitempty"`
+	RaisedAt time.Time         `json:"raised_at"`
+}
+
+// Summary returns a one line description of the alert
+func (a *Alert) Summary() string {
+	txRange := fmt.Sprintf("tx %d", a.FromTx)
+	if a.ToTx != a.FromTx {
+		txRange = fmt.Sprintf("txs %d-%d", a.FromTx, a.ToTx)
+	}
+
+	return fmt.Sprintf("immudb %s alert on database '%s' at %s: %s", a.Kind, a.DB, txRange, a.Message)
+}
+
+// evidenceKeys returns the keys of the evidence in lexicographical order
+func (a *Alert) evidenceKeys() []string {
+	keys := make([]string, 0, len(a.Evidence))
+	for k := range a.Evidence {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Alerter delivers alerts through a channel
+type Alerter interface {
+	Alert(alert *Alert) error
+}
+
+// AlerterFunc is an adapter to use ordinary functions as alerters
+type AlerterFunc func(alert *Alert) error
+
+// Alert calls f(alert)
+func (f AlerterFunc) Alert(alert *Alert) error {
+	return f(alert)
+}
+
+type multiAlerter []Alerter
+
+// Multi returns an alerter delivering alerts through all the given alerters,
+// a failure of one of them doesn't prevent the delivery through the others
+func Multi(alerters ...Alerter) Alerter {
+	return multiAlerter(alerters)
+}
+
+func (m multiAlerter) Alert(alert *Alert) error {
+	var errs []string
+
+	for _, a := range m {
+		err := a.Alert(alert)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("alert delivery failed: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+type webhookAlerter struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookAlerter returns an alerter posting alerts as json to url
+func NewWebhookAlerter(url string, timeout time.Duration) Alerter {
+	return &webhookAlerter{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *webhookAlerter) Alert(alert *Alert) error {
+	reqBody, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	return postJSON(w.httpClient, w.url, reqBody)
+}
+
+func postJSON(httpClient *http.Client, url string, body []byte) error {
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("POST %s: got unexpected response status %s", url, resp.Status)
+	}
+
+	return nil
+}
+
+// Options holds the channels alerts are delivered through, a channel is disabled when not configured
+type Options struct {
+	WebhookURL string
+
+	SMTPAddress  string
+	SMTPUsername string
+	SMTPPassword string `json:"-"`
+	EmailFrom    string
+	EmailTo      []string
+
+	PagerDutyRoutingKey string `json:"-"`
+
+	Timeout time.Duration
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		Timeout: DefaultTimeout,
+	}
+}
+
+// WithWebhookURL sets the url alerts are posted to as json
+func (o *Options) WithWebhookURL(url string) *Options {
+	o.WebhookURL = url
+	return o
+}
+
+// WithSMTPAddress sets the host:port of the SMTP server alert emails are sent through
+func (o *Options) WithSMTPAddress(address string) *Options {
+	o.SMTPAddress = address
+	return o
+}
+
+// WithSMTPCredentials sets the credentials used to authenticate to the SMTP server,
+// no authentication is performed when username is empty
+func (o *Options) WithSMTPCredentials(username, password string) *Options {
+	o.SMTPUsername = username
+	o.SMTPPassword = password
+	return o
+}
+
+// WithEmailFrom sets the sender of alert emails
+func (o *Options) WithEmailFrom(from string) *Options {
+	o.EmailFrom = from
+	return o
+}
+
+// WithEmailTo sets the recipients of alert emails
+func (o *Options) WithEmailTo(to []string) *Options {
+	o.EmailTo = to
+	return o
+}
+
+// WithPagerDutyRoutingKey sets the integration key of the PagerDuty service alerts are triggered on
+func (o *Options) WithPagerDutyRoutingKey(routingKey string) *Options {
+	o.PagerDutyRoutingKey = routingKey
+	return o
+}
+
+// WithTimeout sets the timeout of each delivery attempt
+func (o *Options) WithTimeout(timeout time.Duration) *Options {
+	o.Timeout = timeout
+	return o
+}
+
+// Enabled returns true when at least one channel is configured
+func (o *Options) Enabled() bool {
+	return o != nil && len(o.Channels()) > 0
+}
+
+// Channels returns the names of the configured channels
+func (o *Options) Channels() []string {
+	if o == nil {
+		return nil
+	}
+
+	var channels []string
+
+	if o.WebhookURL != "" {
+		channels = append(channels, "webhook")
+	}
+	if o.SMTPAddress != "" {
+		channels = append(channels, "email")
+	}
+	if o.PagerDutyRoutingKey != "" {
+		channels = append(channels, "pagerduty")
+	}
+
+	return channels
+}
+
+// Alerter returns an alerter delivering alerts through all the configured channels,
+// nil is returned when no channel is configured
+func (o *Options) Alerter() (Alerter, error) {
+	if !o.Enabled() {
+		return nil, nil
+	}
+
+	if o.Timeout <= 0 {
+		return nil, fmt.Errorf("%w: invalid alert timeout", ErrIllegalArguments)
+	}
+
+	var alerters []Alerter
+
+	if o.WebhookURL != "" {
+		alerters = append(alerters, NewWebhookAlerter(o.WebhookURL, o.Timeout))
+	}
+
+	if o.SMTPAddress != "" {
+		a, err := NewEmailAlerter(o.SMTPAddress, o.SMTPUsername, o.SMTPPassword, o.EmailFrom, o.EmailTo)
+		if err != nil {
+			return nil, err
+		}
+
+		alerters = append(alerters, a)
+	}
+
+	if o.PagerDutyRoutingKey != "" {
+		alerters = append(alerters, NewPagerDutyAlerter(o.PagerDutyRoutingKey, o.Timeout))
+	}
+
+	if len(alerters) == 1 {
+		return alerters[0], nil
+	}
+
+	return Multi(alerters...), nil
+}
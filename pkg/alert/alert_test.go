@@ -0,0 +1,137 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testAlert() *Alert {
+	return &Alert{
+		Kind:     Corruption,
+		Source:   "scrubber",
+		DB:       "defaultdb",
+		FromTx:   7,
+		ToTx:     7,
+		Message:  "tx data is corrupted",
+		Evidence: map[string]string{"error": "linear hash chain broken at tx 7"},
+		RaisedAt: time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSummary(t *testing.T) {
+	alert := testAlert()
+	require.Equal(t, "immudb corruption alert on database 'defaultdb' at tx 7: tx data is corrupted", alert.Summary())
+
+	alert.FromTx = 3
+	require.Equal(t, "immudb corruption alert on database 'defaultdb' at txs 3-7: tx data is corrupted", alert.Summary())
+}
+
+func TestWebhookAlerter(t *testing.T) {
+	var received Alert
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		err := json.NewDecoder(r.Body).Decode(&received)
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+
+	alert := testAlert()
+
+	err := NewWebhookAlerter(webhook.URL, time.Second).Alert(alert)
+	require.NoError(t, err)
+	require.Equal(t, *alert, received)
+
+	failingWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingWebhook.Close()
+
+	err = NewWebhookAlerter(failingWebhook.URL, time.Second).Alert(alert)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected response status")
+}
+
+func TestMultiAlerter(t *testing.T) {
+	var delivered int
+
+	ok := AlerterFunc(func(alert *Alert) error {
+		delivered++
+		return nil
+	})
+
+	failing := AlerterFunc(func(alert *Alert) error {
+		return errors.New("channel unavailable")
+	})
+
+	err := Multi(ok, failing, ok).Alert(testAlert())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "channel unavailable")
+	require.Equal(t, 2, delivered)
+
+	err = Multi(ok).Alert(testAlert())
+	require.NoError(t, err)
+	require.Equal(t, 3, delivered)
+}
+
+func TestOptions(t *testing.T) {
+	opts := DefaultOptions()
+	require.False(t, opts.Enabled())
+
+	alerter, err := opts.Alerter()
+	require.NoError(t, err)
+	require.Nil(t, alerter)
+
+	opts.WithWebhookURL("http://alerts.local")
+	require.True(t, opts.Enabled())
+	require.Equal(t, []string{"webhook"}, opts.Channels())
+
+	alerter, err = opts.Alerter()
+	require.NoError(t, err)
+	require.IsType(t, &webhookAlerter{}, alerter)
+
+	opts.WithSMTPAddress("smtp.local:25").
+		WithSMTPCredentials("user", "secret").
+		WithEmailFrom("immudb@local").
+		WithEmailTo([]string{"ops@local"}).
+		WithPagerDutyRoutingKey("routing-key")
+	require.Equal(t, []string{"webhook", "email", "pagerduty"}, opts.Channels())
+
+	alerter, err = opts.Alerter()
+	require.NoError(t, err)
+	require.Len(t, alerter, 3)
+
+	_, err = opts.WithEmailTo(nil).Alerter()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = opts.WithEmailTo([]string{"ops@local"}).WithSMTPAddress("smtp.local").Alerter()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = opts.WithSMTPAddress("smtp.local:25").WithTimeout(0).Alerter()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+type emailAlerter struct {
+	address string
+	auth    smtp.Auth
+	from    string
+	to      []string
+
+	sendMail sendMailFunc
+}
+
+// NewEmailAlerter returns an alerter sending alerts by email through the SMTP server at address (host:port),
+// PLAIN authentication is used when username is not empty
+func NewEmailAlerter(address, username, password, from string, to []string) (Alerter, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid SMTP address '%s'", ErrIllegalArguments, address)
+	}
+
+	if from == "" || len(to) == 0 {
+		return nil, fmt.Errorf("%w: email sender and recipients are required", ErrIllegalArguments)
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &emailAlerter{
+		address:  address,
+		auth:     auth,
+		from:     from,
+		to:       to,
+		sendMail: smtp.SendMail,
+	}, nil
+}
+
+func (e *emailAlerter) Alert(alert *Alert) error {
+	return e.sendMail(e.address, e.auth, e.from, e.to, e.message(alert))
+}
+
+func (e *emailAlerter) message(alert *Alert) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", alert.Summary())
+	fmt.Fprintf(&b, "Date: %s\r\n", alert.RaisedAt.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+
+	fmt.Fprintf(&b, "%s\r\n\r\n", alert.Message)
+	fmt.Fprintf(&b, "Kind: %s\r\n", alert.Kind)
+	fmt.Fprintf(&b, "Source: %s\r\n", alert.Source)
+	fmt.Fprintf(&b, "Database: %s\r\n", alert.DB)
+	fmt.Fprintf(&b, "Transactions: %d to %d\r\n", alert.FromTx, alert.ToTx)
+	fmt.Fprintf(&b, "Raised at: %s\r\n", alert.RaisedAt.Format(time.RFC3339))
+
+	if len(alert.Evidence) > 0 {
+		b.WriteString("\r\nEvidence:\r\n")
+
+		for _, k := range alert.evidenceKeys() {
+			fmt.Fprintf(&b, "  %s: %s\r\n", k, alert.Evidence[k])
+		}
+	}
+
+	return b.Bytes()
+}
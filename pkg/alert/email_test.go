@@ -0,0 +1,65 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"errors"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailAlerter(t *testing.T) {
+	_, err := NewEmailAlerter("smtp.local", "", "", "immudb@local", []string{"ops@local"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewEmailAlerter("smtp.local:25", "", "", "", []string{"ops@local"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	a, err := NewEmailAlerter("smtp.local:25", "user", "secret", "immudb@local", []string{"ops@local", "sec@local"})
+	require.NoError(t, err)
+
+	var sent []byte
+
+	alerter := a.(*emailAlerter)
+	alerter.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		require.Equal(t, "smtp.local:25", addr)
+		require.NotNil(t, auth)
+		require.Equal(t, "immudb@local", from)
+		require.Equal(t, []string{"ops@local", "sec@local"}, to)
+
+		sent = msg
+		return nil
+	}
+
+	err = alerter.Alert(testAlert())
+	require.NoError(t, err)
+
+	msg := string(sent)
+	require.Contains(t, msg, "To: ops@local, sec@local\r\n")
+	require.Contains(t, msg, "Subject: immudb corruption alert on database 'defaultdb' at tx 7: tx data is corrupted\r\n")
+	require.Contains(t, msg, "Transactions: 7 to 7\r\n")
+	require.Contains(t, msg, "  error: linear hash chain broken at tx 7\r\n")
+
+	alerter.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		return errors.New("connection refused")
+	}
+
+	err = alerter.Alert(testAlert())
+	require.Error(t, err)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PagerDutyEventsURL is the endpoint of the PagerDuty Events API v2
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component"`
+	Class         string            `json:"class"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyAlerter struct {
+	routingKey string
+	url        string
+	httpClient *http.Client
+}
+
+// NewPagerDutyAlerter returns an alerter triggering critical PagerDuty incidents through the Events API v2,
+// alerts raised again for the same transactions are deduplicated by PagerDuty
+func NewPagerDutyAlerter(routingKey string, timeout time.Duration) Alerter {
+	return &pagerDutyAlerter{
+		routingKey: routingKey,
+		url:        PagerDutyEventsURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *pagerDutyAlerter) Alert(alert *Alert) error {
+	details := make(map[string]string, len(alert.Evidence)+2)
+	for k, v := range alert.Evidence {
+		details[k] = v
+	}
+	details["from_tx"] = fmt.Sprintf("%d", alert.FromTx)
+	details["to_tx"] = fmt.Sprintf("%d", alert.ToTx)
+
+	reqBody, err := json.Marshal(&pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    fmt.Sprintf("immudb/%s/%s/%s/%d-%d", alert.Source, alert.DB, alert.Kind, alert.FromTx, alert.ToTx),
+		Payload: pagerDutyPayload{
+			Summary:       alert.Summary(),
+			Source:        alert.Source,
+			Severity:      "critical",
+			Timestamp:     alert.RaisedAt.Format(time.RFC3339),
+			Component:     alert.DB,
+			Class:         string(alert.Kind),
+			CustomDetails: details,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return postJSON(p.httpClient, p.url, reqBody)
+}
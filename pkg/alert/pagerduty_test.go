@@ -0,0 +1,64 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerDutyAlerter(t *testing.T) {
+	var received pagerDutyEvent
+
+	pagerDuty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewDecoder(r.Body).Decode(&received)
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer pagerDuty.Close()
+
+	a := NewPagerDutyAlerter("routing-key", time.Second)
+	a.(*pagerDutyAlerter).url = pagerDuty.URL
+
+	err := a.Alert(testAlert())
+	require.NoError(t, err)
+
+	require.Equal(t, "routing-key", received.RoutingKey)
+	require.Equal(t, "trigger", received.EventAction)
+	require.Equal(t, "immudb/scrubber/defaultdb/corruption/7-7", received.DedupKey)
+	require.Equal(t, "critical", received.Payload.Severity)
+	require.Equal(t, "2022-03-15T03:00:00Z", received.Payload.Timestamp)
+	require.Equal(t, "defaultdb", received.Payload.Component)
+	require.Equal(t, "linear hash chain broken at tx 7", received.Payload.CustomDetails["error"])
+	require.Equal(t, "7", received.Payload.CustomDetails["from_tx"])
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	a.(*pagerDutyAlerter).url = unavailable.URL
+
+	err = a.Alert(testAlert())
+	require.Error(t, err)
+}
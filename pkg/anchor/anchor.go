@@ -0,0 +1,83 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package anchor publishes digests of the state of databases to public blockchains, the receipts
+// give tamper evidence that doesn't depend on trusting the immudb server nor its operators.
+package anchor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+var (
+	ErrIllegalArguments = errors.New("illegal arguments")
+	ErrAnchorRejected   = errors.New("anchor rejected by the remote service")
+	ErrInvalidResponse  = errors.New("invalid anchoring response")
+)
+
+// Anchor publishes SHA-256 digests
+type Anchor interface {
+	// Name identifies the anchor in the receipts, e.g. "opentimestamps:https://a.pool.opentimestamps.org"
+	Name() string
+
+	Anchor(ctx context.Context, digest []byte) (*Receipt, error)
+}
+
+// Receipt proves a digest was published
+type Receipt struct {
+	Anchor string
+	Digest []byte
+
+	// Proof is specific to the anchor: an OpenTimestamps proof file or the hash of an Ethereum transaction
+	Proof []byte
+}
+
+// State is the state of a database included in an anchored digest
+type State struct {
+	Database string `json:"database"`
+	TxID     uint64 `json:"txId"`
+	Alh      []byte `json:"alh"`
+}
+
+// Digest returns the digest anchoring the states of many databases at once. States are sorted by
+// database name and each one is hashed as <db_name>0x00<tx_id><alh>, tx ids being big-endian uint64
+func Digest(states []State) [sha256.Size]byte {
+	sorted := make([]State, len(states))
+	copy(sorted, states)
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Database < sorted[j].Database })
+
+	h := sha256.New()
+
+	var txID [8]byte
+
+	for _, s := range sorted {
+		h.Write([]byte(s.Database))
+		h.Write([]byte{0})
+		binary.BigEndian.PutUint64(txID[:], s.TxID)
+		h.Write(txID[:])
+		h.Write(s.Alh)
+	}
+
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+
+	return digest
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anchor
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigest(t *testing.T) {
+	alh1 := sha256.Sum256([]byte("alh1"))
+	alh2 := sha256.Sum256([]byte("alh2"))
+
+	states := []State{
+		{Database: "db2", TxID: 7, Alh: alh2[:]},
+		{Database: "db1", TxID: 3, Alh: alh1[:]},
+	}
+
+	digest := Digest(states)
+	require.Equal(t, digest, Digest([]State{states[1], states[0]}))
+	require.Equal(t, "db2", states[0].Database)
+
+	states[0].TxID = 8
+	require.NotEqual(t, digest, Digest(states))
+}
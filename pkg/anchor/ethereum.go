@@ -0,0 +1,179 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// DefaultEthereumMethod is the signature of the contract method digests are passed to
+const DefaultEthereumMethod = "anchor(bytes32)"
+
+// maxRPCResponseSize bounds the size of the responses read from the Ethereum node
+const maxRPCResponseSize = 1 << 20
+
+// Ethereum publishes digests calling a contract method taking a bytes32 argument, e.g. one emitting
+// an event with it. Transactions are sent through eth_sendTransaction, so they are signed by the
+// node with the key of the sender account. Receipts hold the hash of the transactions
+type Ethereum struct {
+	rpcURL   string
+	from     string
+	contract string
+	selector []byte
+
+	httpClient *http.Client
+}
+
+// NewEthereum returns an anchor sending transactions from the account at address from to the
+// contract at address contract through the Ethereum node served at rpcURL
+func NewEthereum(rpcURL, from, contract, method string, timeout time.Duration) (*Ethereum, error) {
+	if rpcURL == "" || !isEthereumAddress(from) || !isEthereumAddress(contract) || method == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	return &Ethereum{
+		rpcURL:     rpcURL,
+		from:       from,
+		contract:   contract,
+		selector:   ethereumSelector(method),
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func isEthereumAddress(address string) bool {
+	if !strings.HasPrefix(address, "0x") || len(address) != 42 {
+		return false
+	}
+
+	_, err := hex.DecodeString(address[2:])
+	return err == nil
+}
+
+// ethereumSelector returns the first four bytes of the Keccak-256 hash of a method signature
+func ethereumSelector(method string) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(method))
+	return h.Sum(nil)[:4]
+}
+
+func (e *Ethereum) Name() string {
+	return "ethereum:" + e.contract
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type ethereumTransaction struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Data string `json:"data"`
+}
+
+func (e *Ethereum) Anchor(ctx context.Context, digest []byte) (*Receipt, error) {
+	if len(digest) != sha256.Size {
+		return nil, ErrIllegalArguments
+	}
+
+	// abi encoding of a single bytes32 argument is the argument itself
+	data := make([]byte, 0, len(e.selector)+len(digest))
+	data = append(data, e.selector...)
+	data = append(data, digest...)
+
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "eth_sendTransaction",
+		Params: []interface{}{ethereumTransaction{
+			From: e.from,
+			To:   e.contract,
+			Data: "0x" + hex.EncodeToString(data),
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.rpcURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected http status %s", ErrAnchorRejected, resp.Status)
+	}
+
+	var rpcResp rpcResponse
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxRPCResponseSize)).Decode(&rpcResp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("%w: %s (code %d)", ErrAnchorRejected, rpcResp.Error.Message, rpcResp.Error.Code)
+	}
+
+	var txHash string
+
+	err = json.Unmarshal(rpcResp.Result, &txHash)
+	if err != nil || !strings.HasPrefix(txHash, "0x") {
+		return nil, fmt.Errorf("%w: unexpected result %s", ErrInvalidResponse, rpcResp.Result)
+	}
+
+	proof, err := hex.DecodeString(txHash[2:])
+	if err != nil || len(proof) != sha256.Size {
+		return nil, fmt.Errorf("%w: invalid transaction hash %s", ErrInvalidResponse, txHash)
+	}
+
+	return &Receipt{
+		Anchor: e.Name(),
+		Digest: digest,
+		Proof:  proof,
+	}, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anchor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testFromAddress     = "0x00000000000000000000000000000000000000aa"
+	testContractAddress = "0x00000000000000000000000000000000000000bb"
+	testTxHash          = "0x1111111111111111111111111111111111111111111111111111111111111111"
+)
+
+func fakeEthereumNode(t *testing.T, digest []byte, result string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string                `json:"method"`
+			Params []ethereumTransaction `json:"params"`
+		}
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		require.NoError(t, err)
+		require.Equal(t, "eth_sendTransaction", req.Method)
+		require.Len(t, req.Params, 1)
+		require.Equal(t, testFromAddress, req.Params[0].From)
+		require.Equal(t, testContractAddress, req.Params[0].To)
+		require.Equal(t, "0x"+hex.EncodeToString(ethereumSelector(DefaultEthereumMethod))+hex.EncodeToString(digest), req.Params[0].Data)
+
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,` + result + `}`))
+	}))
+}
+
+func TestEthereumSelector(t *testing.T) {
+	require.Equal(t, "a9059cbb", hex.EncodeToString(ethereumSelector("transfer(address,uint256)")))
+}
+
+func TestEthereum(t *testing.T) {
+	digest := sha256.Sum256([]byte("states"))
+
+	node := fakeEthereumNode(t, digest[:], `"result":"`+testTxHash+`"`)
+	defer node.Close()
+
+	_, err := NewEthereum(node.URL, "0xaa", testContractAddress, DefaultEthereumMethod, time.Second)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewEthereum(node.URL, testFromAddress, testContractAddress, "", time.Second)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	eth, err := NewEthereum(node.URL, testFromAddress, testContractAddress, DefaultEthereumMethod, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "ethereum:"+testContractAddress, eth.Name())
+
+	receipt, err := eth.Anchor(context.Background(), digest[:])
+	require.NoError(t, err)
+	require.Equal(t, digest[:], receipt.Digest)
+	require.Equal(t, strings.TrimPrefix(testTxHash, "0x"), hex.EncodeToString(receipt.Proof))
+
+	_, err = eth.Anchor(context.Background(), []byte("not a digest"))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestEthereumRejected(t *testing.T) {
+	digest := sha256.Sum256([]byte("states"))
+
+	node := fakeEthereumNode(t, digest[:], `"error":{"code":-32000,"message":"insufficient funds"}`)
+	defer node.Close()
+
+	eth, err := NewEthereum(node.URL, testFromAddress, testContractAddress, DefaultEthereumMethod, time.Second)
+	require.NoError(t, err)
+
+	_, err = eth.Anchor(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrAnchorRejected)
+
+	invalidNode := fakeEthereumNode(t, digest[:], `"result":"pending"`)
+	defer invalidNode.Close()
+
+	eth, err = NewEthereum(invalidNode.URL, testFromAddress, testContractAddress, DefaultEthereumMethod, time.Second)
+	require.NoError(t, err)
+
+	_, err = eth.Anchor(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrInvalidResponse)
+}
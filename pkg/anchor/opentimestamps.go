@@ -0,0 +1,115 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	otsAcceptHeader = "application/vnd.opentimestamps.v1"
+
+	// maxCalendarResponseSize bounds the size of the pending timestamps read from the calendars
+	maxCalendarResponseSize = 10 << 10
+)
+
+// otsHeaderMagic starts every OpenTimestamps proof file
+var otsHeaderMagic = []byte("\x00OpenTimestamps\x00\x00Proof\x00\xbf\x89\xe2\xe8\x84\xe8\x92\x94")
+
+const (
+	otsMajorVersion = 0x01
+	otsOpSHA256     = 0x08
+)
+
+// OpenTimestamps submits digests to an OpenTimestamps calendar, which aggregates them into
+// Bitcoin transactions. Receipts are proof files holding the pending timestamp, they are
+// completed once the calendar transaction is confirmed with "ots upgrade"
+type OpenTimestamps struct {
+	calendarURL string
+	httpClient  *http.Client
+}
+
+// NewOpenTimestamps returns an anchor submitting digests to the calendar served at calendarURL,
+// e.g. https://a.pool.opentimestamps.org
+func NewOpenTimestamps(calendarURL string, timeout time.Duration) *OpenTimestamps {
+	return &OpenTimestamps{
+		calendarURL: strings.TrimSuffix(calendarURL, "/"),
+		httpClient:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (ots *OpenTimestamps) Name() string {
+	return "opentimestamps:" + ots.calendarURL
+}
+
+func (ots *OpenTimestamps) Anchor(ctx context.Context, digest []byte) (*Receipt, error) {
+	if len(digest) != sha256.Size {
+		return nil, ErrIllegalArguments
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ots.calendarURL+"/digest", bytes.NewReader(digest))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", otsAcceptHeader)
+
+	resp, err := ots.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected http status %s", ErrAnchorRejected, resp.Status)
+	}
+
+	timestamp, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCalendarResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(timestamp) == 0 {
+		return nil, fmt.Errorf("%w: empty timestamp", ErrInvalidResponse)
+	}
+
+	return &Receipt{
+		Anchor: ots.Name(),
+		Digest: digest,
+		Proof:  otsProofFile(digest, timestamp),
+	}, nil
+}
+
+// otsProofFile returns a detached timestamp file, the same format as the .ots files created by
+// "ots stamp", of the SHA-256 digest
+func otsProofFile(digest []byte, timestamp []byte) []byte {
+	var b bytes.Buffer
+
+	b.Write(otsHeaderMagic)
+	b.WriteByte(otsMajorVersion)
+	b.WriteByte(otsOpSHA256)
+	b.Write(digest)
+	b.Write(timestamp)
+
+	return b.Bytes()
+}
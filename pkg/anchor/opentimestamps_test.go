@@ -0,0 +1,78 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenTimestamps(t *testing.T) {
+	digest := sha256.Sum256([]byte("states"))
+	pending := []byte{0xf0, 0x10, 0x01, 0x02}
+
+	calendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/digest", r.URL.Path)
+		require.Equal(t, otsAcceptHeader, r.Header.Get("Accept"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, digest[:], body)
+
+		w.Write(pending)
+	}))
+	defer calendar.Close()
+
+	ots := NewOpenTimestamps(calendar.URL+"/", time.Second)
+	require.Equal(t, "opentimestamps:"+calendar.URL, ots.Name())
+
+	receipt, err := ots.Anchor(context.Background(), digest[:])
+	require.NoError(t, err)
+	require.Equal(t, ots.Name(), receipt.Anchor)
+	require.Equal(t, digest[:], receipt.Digest)
+	require.True(t, bytes.HasPrefix(receipt.Proof, otsHeaderMagic))
+	require.True(t, bytes.HasSuffix(receipt.Proof, append(digest[:], pending...)))
+
+	_, err = ots.Anchor(context.Background(), []byte("not a digest"))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestOpenTimestampsRejected(t *testing.T) {
+	digest := sha256.Sum256([]byte("states"))
+
+	calendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer calendar.Close()
+
+	_, err := NewOpenTimestamps(calendar.URL, time.Second).Anchor(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrAnchorRejected)
+
+	emptyCalendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer emptyCalendar.Close()
+
+	_, err = NewOpenTimestamps(emptyCalendar.URL, time.Second).Anchor(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrInvalidResponse)
+}
@@ -17,8 +17,10 @@ package schema
 
 import (
 	"crypto/sha256"
+	"fmt"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/htree"
 	"github.com/codenotary/immudb/embedded/store"
 )
@@ -51,6 +53,8 @@ func KVMetadataToProto(md *store.KVMetadata) *KVMetadata {
 	kvmd := &KVMetadata{
 		Deleted:       md.Deleted(),
 		NonIndexeable: md.NonIndexable(),
+		ContentType:   md.ContentType(),
+		Encoding:      md.Encoding(),
 	}
 
 	if md.IsExpirable() {
@@ -58,17 +62,27 @@ func KVMetadataToProto(md *store.KVMetadata) *KVMetadata {
 		kvmd.Expiration = &Expiration{ExpiresAt: expTime.Unix()}
 	}
 
+	headers := md.Headers()
+	if len(headers) > 0 {
+		kvmd.Headers = headers
+	}
+
 	return kvmd
 }
 
-func TxFromProto(stx *Tx) *store.Tx {
+func TxFromProto(stx *Tx) (*store.Tx, error) {
+	hashAlg, err := hashAlgorithmFromProto(stx.Header.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
 	entries := make([]*store.TxEntry, len(stx.Entries))
 
 	for i, e := range stx.Entries {
 		entries[i] = store.NewTxEntry(e.Key, KVMetadataFromProto(e.Metadata), int(e.VLen), DigestFromProto(e.HValue), 0)
 	}
 
-	tx := store.NewTxWithEntries(entries)
+	tx := store.NewTxWithEntries(entries, hashAlg)
 
 	hdr := tx.Header()
 
@@ -82,7 +96,7 @@ func TxFromProto(stx *Tx) *store.Tx {
 
 	tx.BuildHashTree()
 
-	return tx
+	return tx, nil
 }
 
 func KVMetadataFromProto(md *KVMetadata) *store.KVMetadata {
@@ -100,6 +114,13 @@ func KVMetadataFromProto(md *KVMetadata) *store.KVMetadata {
 
 	kvmd.AsNonIndexable(md.NonIndexeable)
 
+	kvmd.SetContentType(md.ContentType)
+	kvmd.SetEncoding(md.Encoding)
+
+	for k, v := range md.Headers {
+		kvmd.SetHeader(k, v)
+	}
+
 	return kvmd
 }
 
@@ -146,6 +167,8 @@ func TxHeaderToProto(hdr *store.TxHeader) *TxHeader {
 		EH:       hdr.Eh[:],
 		BlTxId:   hdr.BlTxID,
 		BlRoot:   hdr.BlRoot[:],
+
+		HashAlgorithm: int32(hdr.HashAlgorithm),
 	}
 }
 
@@ -154,7 +177,7 @@ func TxMetadataToProto(md *store.TxMetadata) *TxMetadata {
 		return nil
 	}
 
-	return &TxMetadata{}
+	return &TxMetadata{Annotations: md.Annotations(), Writer: md.Writer()}
 }
 
 func LinearProofToProto(linearProof *store.LinearProof) *LinearProof {
@@ -165,19 +188,36 @@ func LinearProofToProto(linearProof *store.LinearProof) *LinearProof {
 	}
 }
 
-func DualProofFromProto(dproof *DualProof) *store.DualProof {
+func DualProofFromProto(dproof *DualProof) (*store.DualProof, error) {
+	sourceTxHeader, err := TxHeaderFromProto(dproof.SourceTxHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	targetTxHeader, err := TxHeaderFromProto(dproof.TargetTxHeader)
+	if err != nil {
+		return nil, err
+	}
+
 	return &store.DualProof{
-		SourceTxHeader:     TxHeaderFromProto(dproof.SourceTxHeader),
-		TargetTxHeader:     TxHeaderFromProto(dproof.TargetTxHeader),
+		SourceTxHeader:     sourceTxHeader,
+		TargetTxHeader:     targetTxHeader,
 		InclusionProof:     DigestsFromProto(dproof.InclusionProof),
 		ConsistencyProof:   DigestsFromProto(dproof.ConsistencyProof),
 		TargetBlTxAlh:      DigestFromProto(dproof.TargetBlTxAlh),
 		LastInclusionProof: DigestsFromProto(dproof.LastInclusionProof),
 		LinearProof:        LinearProofFromProto(dproof.LinearProof),
-	}
+	}, nil
 }
 
-func TxHeaderFromProto(hdr *TxHeader) *store.TxHeader {
+// TxHeaderFromProto converts the header of a tx, it fails if the hash algorithm of the tx is not supported
+// as digests such as its Alh couldn't be calculated
+func TxHeaderFromProto(hdr *TxHeader) (*store.TxHeader, error) {
+	hashAlg, err := hashAlgorithmFromProto(hdr.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
 	return &store.TxHeader{
 		ID:       hdr.Id,
 		PrevAlh:  DigestFromProto(hdr.PrevAlh),
@@ -188,7 +228,17 @@ func TxHeaderFromProto(hdr *TxHeader) *store.TxHeader {
 		Eh:       DigestFromProto(hdr.EH),
 		BlTxID:   hdr.BlTxId,
 		BlRoot:   DigestFromProto(hdr.BlRoot),
+
+		HashAlgorithm: hashAlg,
+	}, nil
+}
+
+func hashAlgorithmFromProto(alg int32) (hashing.Algorithm, error) {
+	hashAlg := hashing.Algorithm(alg)
+	if !hashAlg.Supported() {
+		return hashAlg, fmt.Errorf("%w: %d", store.ErrUnsupportedHashAlgorithm, alg)
 	}
+	return hashAlg, nil
 }
 
 func TxMetadataFromProto(md *TxMetadata) *store.TxMetadata {
@@ -196,7 +246,15 @@ func TxMetadataFromProto(md *TxMetadata) *store.TxMetadata {
 		return nil
 	}
 
-	return &store.TxMetadata{}
+	txmd := store.NewTxMetadata()
+
+	for k, v := range md.Annotations {
+		txmd.WithAnnotation(k, v)
+	}
+
+	txmd.WithWriter(md.Writer)
+
+	return txmd
 }
 
 func LinearProofFromProto(lproof *LinearProof) *store.LinearProof {
@@ -207,6 +265,37 @@ func LinearProofFromProto(lproof *LinearProof) *store.LinearProof {
 	}
 }
 
+func TxInclusionProofToProto(proof *store.TxInclusionProof) *TxInclusionProof {
+	txProof := &TxInclusionProof{
+		TxHeader:       TxHeaderToProto(proof.TxHeader),
+		InclusionProof: DigestsToProto(proof.InclusionProof),
+	}
+
+	if proof.LinearProof != nil {
+		txProof.LinearProof = LinearProofToProto(proof.LinearProof)
+	}
+
+	return txProof
+}
+
+func TxInclusionProofFromProto(proof *TxInclusionProof) (*store.TxInclusionProof, error) {
+	hdr, err := TxHeaderFromProto(proof.TxHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	txProof := &store.TxInclusionProof{
+		TxHeader:       hdr,
+		InclusionProof: DigestsFromProto(proof.InclusionProof),
+	}
+
+	if proof.LinearProof != nil {
+		txProof.LinearProof = LinearProofFromProto(proof.LinearProof)
+	}
+
+	return txProof, nil
+}
+
 func DigestsToProto(terms [][sha256.Size]byte) [][]byte {
 	slicedTerms := make([][]byte, len(terms))
 
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schema
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/hashing"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxHeaderFromProto(t *testing.T) {
+	hdr, err := TxHeaderFromProto(&TxHeader{Id: 1, HashAlgorithm: int32(hashing.BLAKE3)})
+	require.NoError(t, err)
+	require.Equal(t, hashing.BLAKE3, hdr.HashAlgorithm)
+
+	_, err = TxHeaderFromProto(&TxHeader{Id: 1, HashAlgorithm: 99})
+	require.ErrorIs(t, err, store.ErrUnsupportedHashAlgorithm)
+
+	_, err = TxFromProto(&Tx{Header: &TxHeader{Id: 1, HashAlgorithm: 99}})
+	require.ErrorIs(t, err, store.ErrUnsupportedHashAlgorithm)
+
+	_, err = DualProofFromProto(&DualProof{
+		SourceTxHeader: &TxHeader{Id: 1},
+		TargetTxHeader: &TxHeader{Id: 2, HashAlgorithm: 99},
+	})
+	require.ErrorIs(t, err, store.ErrUnsupportedHashAlgorithm)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/hashing"
+	"github.com/codenotary/immudb/embedded/htree"
+	"github.com/codenotary/immudb/pkg/signer"
+)
+
+// globalStatePrefix tells apart the signed payload of a global state from the one of a database state,
+// whose payload starts with the length of the database name
+var globalStatePrefix = []byte("immudb:global-state:")
+
+// GlobalStateLeaf returns the digest the state of a database is aggregated as in the global root,
+// i.e. sha256(<db_name>0x00<tx_id><alh>), tx ids being big-endian uint64
+func GlobalStateLeaf(state *ImmutableState) [sha256.Size]byte {
+	b := make([]byte, len(state.Db)+1+8+len(state.TxHash))
+	i := copy(b, state.Db)
+	b[i] = 0
+	binary.BigEndian.PutUint64(b[i+1:], state.TxId)
+	copy(b[i+9:], state.TxHash)
+
+	return sha256.Sum256(b)
+}
+
+// GlobalStateTree sorts the states by database name and returns the Merkle tree aggregating them
+func GlobalStateTree(states []*ImmutableState) (*htree.HTree, error) {
+	if len(states) == 0 {
+		return nil, errors.New("no database to aggregate")
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Db < states[j].Db })
+
+	leaves := make([][sha256.Size]byte, len(states))
+	for i, state := range states {
+		leaves[i] = GlobalStateLeaf(state)
+	}
+
+	tree, err := htree.New(len(leaves), hashing.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tree.BuildWith(leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// ToBytes returns the payload signed by the server, i.e. <prefix><width><root>
+func (gs *GlobalState) ToBytes() []byte {
+	b := make([]byte, len(globalStatePrefix)+4+sha256.Size)
+	i := copy(b, globalStatePrefix)
+	binary.BigEndian.PutUint32(b[i:], gs.Width)
+	copy(b[i+4:], gs.Root)
+
+	return b
+}
+
+// CheckSignature checks the global state was signed by the owner of key
+func (gs *GlobalState) CheckSignature(key *ecdsa.PublicKey) (ok bool, err error) {
+	if gs.Signature == nil {
+		return false, errors.New("no signature found")
+	}
+	return signer.Verify(gs.ToBytes(), gs.Signature.Signature, key)
+}
+
+// VerifyInclusion checks the state of a database is aggregated in the global root. When the global
+// state holds the states of all the databases the root is recomputed instead of using its inclusion proof
+func (gs *GlobalState) VerifyInclusion(state *ImmutableState) bool {
+	if state == nil || len(gs.Root) != sha256.Size {
+		return false
+	}
+
+	root := DigestFromProto(gs.Root)
+
+	if gs.InclusionProof == nil {
+		if len(gs.States) != int(gs.Width) {
+			return false
+		}
+
+		states := make([]*ImmutableState, len(gs.States))
+		copy(states, gs.States)
+
+		tree, err := GlobalStateTree(states)
+		if err != nil {
+			return false
+		}
+
+		calcRoot, err := tree.Root()
+		if err != nil || calcRoot != root {
+			return false
+		}
+
+		for _, s := range gs.States {
+			if s.Db == state.Db {
+				return GlobalStateLeaf(s) == GlobalStateLeaf(state)
+			}
+		}
+
+		return false
+	}
+
+	proof := InclusionProofFromProto(gs.InclusionProof)
+	if proof.Width != int(gs.Width) {
+		return false
+	}
+
+	return htree.VerifyInclusion(proof, GlobalStateLeaf(state), root, hashing.SHA256)
+}
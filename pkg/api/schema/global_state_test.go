@@ -0,0 +1,97 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalState(t *testing.T) {
+	_, err := GlobalStateTree(nil)
+	require.Error(t, err)
+
+	var states []*ImmutableState
+	for i := 5; i > 0; i-- {
+		states = append(states, &ImmutableState{
+			Db:     fmt.Sprintf("db%d", i),
+			TxId:   uint64(i),
+			TxHash: make([]byte, 32),
+		})
+	}
+
+	tree, err := GlobalStateTree(states)
+	require.NoError(t, err)
+	require.Equal(t, "db1", states[0].Db)
+
+	root, err := tree.Root()
+	require.NoError(t, err)
+
+	gs := &GlobalState{Root: root[:], Width: uint32(len(states)), States: states}
+
+	for _, state := range states {
+		require.True(t, gs.VerifyInclusion(state))
+	}
+
+	require.False(t, gs.VerifyInclusion(&ImmutableState{Db: "db1", TxId: 2, TxHash: make([]byte, 32)}))
+	require.False(t, gs.VerifyInclusion(&ImmutableState{Db: "db6", TxId: 6, TxHash: make([]byte, 32)}))
+
+	for i, state := range states {
+		proof, err := tree.InclusionProof(i)
+		require.NoError(t, err)
+
+		dbState := &GlobalState{
+			Root:           root[:],
+			Width:          uint32(len(states)),
+			States:         []*ImmutableState{state},
+			InclusionProof: InclusionProofToProto(proof),
+		}
+
+		require.True(t, dbState.VerifyInclusion(state))
+		require.False(t, dbState.VerifyInclusion(&ImmutableState{Db: state.Db, TxId: state.TxId + 1, TxHash: state.TxHash}))
+
+		dbState.Width++
+		require.False(t, dbState.VerifyInclusion(state))
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = gs.CheckSignature(&privateKey.PublicKey)
+	require.Error(t, err)
+
+	sig, pub, err := signer.NewSignerFromPKey(rand.Reader, privateKey).Sign(gs.ToBytes())
+	require.NoError(t, err)
+
+	gs.Signature = &Signature{Signature: sig, PublicKey: pub}
+
+	ok, err := gs.CheckSignature(&privateKey.PublicKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	gs.Width--
+
+	ok, err = gs.CheckSignature(&privateKey.PublicKey)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
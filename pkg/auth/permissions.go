@@ -32,6 +32,16 @@ var maintenanceMethods = map[string]struct{}{
 	"StreamHistory":       {},
 	"TxByID":              {},
 	"TxScan":              {},
+	"TxByTime":            {},
+	"ExportTxHeaders":     {},
+	"ConsistencyProof":    {},
+	"VerifyNotarization":  {},
+	"ErasureHistory":      {},
+	"ListLegalHolds":      {},
+	"ExclusionProof":      {},
+	"VerifiableBatch":     {},
+	"SampledAudit":        {},
+	"CosignedState":       {},
 	"ExportTx":            {},
 	"ReplicateTx":         {},
 	"Count":               {},
@@ -45,9 +55,14 @@ var maintenanceMethods = map[string]struct{}{
 	"VerifiableSQLGet":    {},
 
 	// admin methods
-	"ListUsers":    {},
-	"Dump":         {},
-	"CompactIndex": {},
+	"ListUsers":        {},
+	"CompactIndex":     {},
+	"CompactDatabase":  {},
+	"CollectGarbage":   {},
+	"RebuildIndex":     {},
+	"Backup":           {},
+	"Export":           {},
+	"GetRuntimeConfig": {},
 }
 
 // PermissionSysAdmin the admin permission byte
@@ -91,6 +106,21 @@ var methodsPermissions = map[string][]uint32{
 	"StreamHistory":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"TxByID":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"TxScan":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"TxByTime":               {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"ExportTxHeaders":        {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"ConsistencyProof":       {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"Notarize":               {PermissionSysAdmin, PermissionAdmin, PermissionRW},
+	"VerifyNotarization":     {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"Erase":                  {PermissionSysAdmin, PermissionAdmin},
+	"ErasureHistory":         {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"PlaceLegalHold":         {PermissionSysAdmin, PermissionAdmin},
+	"LiftLegalHold":          {PermissionSysAdmin, PermissionAdmin},
+	"ListLegalHolds":         {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"ExclusionProof":         {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"VerifiableBatch":        {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"SampledAudit":           {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"Cosign":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"CosignedState":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"Count":                  {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"CountAll":               {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"DatabaseList":           {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
@@ -104,22 +134,47 @@ var methodsPermissions = map[string][]uint32{
 	"VerifiableSQLGet":       {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 
 	// admin methods
-	"ListUsers":        {PermissionSysAdmin, PermissionAdmin},
-	"CreateUser":       {PermissionSysAdmin, PermissionAdmin},
-	"ChangePassword":   {PermissionSysAdmin, PermissionAdmin},
-	"SetPermission":    {PermissionSysAdmin, PermissionAdmin},
-	"DeactivateUser":   {PermissionSysAdmin, PermissionAdmin},
-	"SetActiveUser":    {PermissionSysAdmin, PermissionAdmin},
-	"UpdateAuthConfig": {PermissionSysAdmin},
-	"UpdateMTLSConfig": {PermissionSysAdmin},
-	"CreateDatabase":   {PermissionSysAdmin},
-	"Dump":             {PermissionSysAdmin, PermissionAdmin},
-	"CompactIndex":     {PermissionSysAdmin, PermissionAdmin},
-	"ExportTx":         {PermissionSysAdmin, PermissionAdmin},
-	"ReplicateTx":      {PermissionSysAdmin, PermissionAdmin},
+	"ListUsers":                  {PermissionSysAdmin, PermissionAdmin},
+	"CreateUser":                 {PermissionSysAdmin, PermissionAdmin},
+	"ChangePassword":             {PermissionSysAdmin, PermissionAdmin},
+	"SetPermission":              {PermissionSysAdmin, PermissionAdmin},
+	"DeactivateUser":             {PermissionSysAdmin, PermissionAdmin},
+	"SetActiveUser":              {PermissionSysAdmin, PermissionAdmin},
+	"UpdateAuthConfig":           {PermissionSysAdmin},
+	"UpdateMTLSConfig":           {PermissionSysAdmin},
+	"CreateDatabase":             {PermissionSysAdmin},
+	"CloneDatabase":              {PermissionSysAdmin},
+	"SelectiveRestore":           {PermissionSysAdmin},
+	"SetBackupSchedule":          {PermissionSysAdmin},
+	"DeleteBackupSchedule":       {PermissionSysAdmin},
+	"ListBackupSchedules":        {PermissionSysAdmin},
+	"SetRetentionPolicy":         {PermissionSysAdmin},
+	"DeleteRetentionPolicy":      {PermissionSysAdmin},
+	"ListRetentionPolicies":      {PermissionSysAdmin},
+	"ListRetentionEnforcements":  {PermissionSysAdmin},
+	"GenerateAuditReport":        {PermissionSysAdmin},
+	"ConfigHistory":              {PermissionSysAdmin},
+	"SetVerificationSchedule":    {PermissionSysAdmin},
+	"DeleteVerificationSchedule": {PermissionSysAdmin},
+	"ListVerificationSchedules":  {PermissionSysAdmin},
+	"ListVerificationRuns":       {PermissionSysAdmin},
+	"ListSessions":               {PermissionSysAdmin},
+	"KillSession":                {PermissionSysAdmin},
+	"GetRuntimeConfig":           {PermissionSysAdmin},
+	"SetRuntimeConfig":           {PermissionSysAdmin},
+	"DatabaseUsage":              {PermissionSysAdmin},
+	"ReplicationStatus":          {PermissionSysAdmin},
+	"CompactIndex":               {PermissionSysAdmin, PermissionAdmin},
+	"CompactDatabase":            {PermissionSysAdmin, PermissionAdmin},
+	"CollectGarbage":             {PermissionSysAdmin, PermissionAdmin},
+	"RebuildIndex":               {PermissionSysAdmin, PermissionAdmin},
+	"Backup":                     {PermissionSysAdmin, PermissionAdmin},
+	"Export":                     {PermissionSysAdmin, PermissionAdmin},
+	"ExportTx":                   {PermissionSysAdmin, PermissionAdmin},
+	"ReplicateTx":                {PermissionSysAdmin, PermissionAdmin},
 }
 
-//HasPermissionForMethod checks if userPermission can access method name
+// HasPermissionForMethod checks if userPermission can access method name
 func HasPermissionForMethod(userPermission uint32, method string) bool {
 	methodPermissions, ok := methodsPermissions[method]
 	if !ok {
@@ -17,11 +17,17 @@ limitations under the License.
 package auth
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
 )
 
+// KeyPrefixUser prefixes the keys the users are stored under in the system database
+const KeyPrefixUser = 1
+
 // Permission per database
 type Permission struct {
 	Permission uint32 `json:"permission"` //permission of type auth.PermissionW
@@ -121,3 +127,38 @@ func (u *User) GrantPermission(database string, permission uint32) bool {
 	u.Permissions = append(u.Permissions, perm)
 	return true
 }
+
+// UserKey returns the key the user is stored under in the system database
+func UserKey(username []byte) []byte {
+	key := make([]byte, 1+len(username))
+	key[0] = KeyPrefixUser
+	copy(key[1:], username)
+
+	return key
+}
+
+// EntryGetter reads entries by key, as the system database does
+type EntryGetter interface {
+	Get(req *schema.KeyRequest) (*schema.Entry, error)
+}
+
+// GetUser loads the current state of the user from the system database, whether active or not
+func GetUser(sysDb EntryGetter, username []byte) (*User, error) {
+	item, err := sysDb.Get(&schema.KeyRequest{Key: UserKey(username)})
+	if err != nil {
+		return nil, err
+	}
+
+	var usr User
+
+	err = json.Unmarshal(item.Value, &usr)
+	if err != nil {
+		return nil, err
+	}
+
+	if usr.Username == SysAdminUsername {
+		usr.IsSysAdmin = true
+	}
+
+	return &usr, nil
+}
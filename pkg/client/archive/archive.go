@@ -0,0 +1,370 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package archive creates and verifies offline archives of a range of transactions.
+//
+// An archive holds the full header and entry digests of every transaction in the range,
+// followed by the state of the database at archival time and a dual proof linking the
+// last archived transaction to that state. The accumulated linear hash chain can therefore
+// be re-verified without contacting the server.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/golang/protobuf/proto"
+)
+
+const version = 1
+
+var magic = []byte("IMMUARCH")
+
+const txScanLimit = 100
+
+// Summary describes a verified archive
+type Summary struct {
+	FirstTxID uint64
+	LastTxID  uint64
+	// FirstPrevAlh is the accumulated linear hash preceding the first archived transaction,
+	// it must match the last Alh of the previous archive when archives are chained
+	FirstPrevAlh [sha256.Size]byte
+	LastAlh      [sha256.Size]byte
+	// State is the database state at archival time the last transaction was proven against
+	State *schema.ImmutableState
+}
+
+// Create downloads the transactions in the range [fromTx, toTx] and writes them into w.
+// The range is verified against the trusted state of the client before being written.
+func Create(ctx context.Context, c client.ImmuClient, fromTx, toTx uint64, w io.Writer) (*Summary, error) {
+	if c == nil || fromTx == 0 || fromTx > toTx || w == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	// ensures the server is consistent with the local trusted state
+	_, err := c.VerifiedTxByID(ctx, toTx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vtx, err := c.GetServiceClient().VerifiableTxById(ctx, &schema.VerifiableTxRequest{
+		Tx:           state.TxId,
+		ProveSinceTx: toTx,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	_, err = bw.Write(magic)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bw.WriteByte(version)
+	if err != nil {
+		return nil, err
+	}
+
+	v := newVerifier()
+
+	for txID := fromTx; txID <= toTx; {
+		txs, err := c.TxScan(ctx, &schema.TxScanRequest{InitialTx: txID, Limit: txScanLimit})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(txs.Txs) == 0 {
+			return nil, ErrCorruptedData
+		}
+
+		for _, tx := range txs.Txs {
+			if tx.Header.Id > toTx {
+				break
+			}
+
+			err = v.verifyTx(tx)
+			if err != nil {
+				return nil, err
+			}
+
+			err = writeMessage(bw, tx)
+			if err != nil {
+				return nil, err
+			}
+
+			txID++
+		}
+	}
+
+	// zero-length message marks the end of the transactions
+	err = writeMessage(bw, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeMessage(bw, state)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeMessage(bw, vtx.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.verifyState(state, vtx.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return v.summary(), nil
+}
+
+// Verify reads an archive recomputing its accumulated linear hash chain and checking
+// the last transaction is included in the archived state. When a public key is provided,
+// the signature of the state is checked as well.
+func Verify(r io.Reader, pubKey *ecdsa.PublicKey) (*Summary, error) {
+	br := bufio.NewReader(r)
+
+	hdr := make([]byte, len(magic)+1)
+
+	_, err := io.ReadFull(br, hdr)
+	if err != nil {
+		return nil, ErrInvalidFormat
+	}
+
+	if !bytes.Equal(hdr[:len(magic)], magic) {
+		return nil, ErrInvalidFormat
+	}
+
+	if hdr[len(magic)] != version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	v := newVerifier()
+
+	for {
+		tx := &schema.Tx{}
+
+		ok, err := readMessage(br, tx)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		err = v.verifyTx(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if v.txCount == 0 {
+		return nil, ErrInvalidFormat
+	}
+
+	state := &schema.ImmutableState{}
+
+	ok, err := readMessage(br, state)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidFormat
+	}
+
+	dualProof := &schema.DualProof{}
+
+	ok, err = readMessage(br, dualProof)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidFormat
+	}
+
+	err = v.verifyState(state, dualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	if pubKey != nil {
+		ok, err := state.CheckSignature(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrInvalidSignature
+		}
+	}
+
+	return v.summary(), nil
+}
+
+type verifier struct {
+	txCount uint64
+	first   *store.TxHeader
+	last    *store.TxHeader
+	state   *schema.ImmutableState
+}
+
+func newVerifier() *verifier {
+	return &verifier{}
+}
+
+func (v *verifier) verifyTx(stx *schema.Tx) error {
+	if stx.Header == nil || int(stx.Header.Nentries) != len(stx.Entries) {
+		return ErrCorruptedData
+	}
+
+	// entries hash tree is rebuilt from the entry digests
+	tx, err := schema.TxFromProto(stx)
+	if err != nil {
+		return err
+	}
+
+	if tx.Header().Eh != schema.DigestFromProto(stx.Header.EH) {
+		return ErrCorruptedData
+	}
+
+	if v.last != nil &&
+		(tx.Header().ID != v.last.ID+1 || tx.Header().PrevAlh != v.last.Alh()) {
+		return ErrCorruptedData
+	}
+
+	if v.first == nil {
+		v.first = tx.Header()
+	}
+
+	v.last = tx.Header()
+	v.txCount++
+
+	return nil
+}
+
+func (v *verifier) verifyState(state *schema.ImmutableState, dualProof *schema.DualProof) error {
+	if v.last == nil || state == nil || dualProof == nil {
+		return ErrCorruptedData
+	}
+
+	if state.TxId < v.last.ID {
+		return ErrCorruptedData
+	}
+
+	stateAlh := schema.DigestFromProto(state.TxHash)
+
+	if state.TxId == v.last.ID {
+		if stateAlh != v.last.Alh() {
+			return ErrCorruptedData
+		}
+	} else {
+		proof, err := schema.DualProofFromProto(dualProof)
+		if err != nil {
+			return err
+		}
+
+		if !store.VerifyDualProof(proof, v.last.ID, state.TxId, v.last.Alh(), stateAlh) {
+			return ErrCorruptedData
+		}
+	}
+
+	v.state = state
+
+	return nil
+}
+
+func (v *verifier) summary() *Summary {
+	return &Summary{
+		FirstTxID:    v.first.ID,
+		LastTxID:     v.last.ID,
+		FirstPrevAlh: v.first.PrevAlh,
+		LastAlh:      v.last.Alh(),
+		State:        v.state,
+	}
+}
+
+func writeMessage(w io.Writer, m proto.Message) error {
+	var b []byte
+
+	if m != nil {
+		var err error
+
+		b, err = proto.Marshal(m)
+		if err != nil {
+			return err
+		}
+	}
+
+	var lenBs [4]byte
+	binary.BigEndian.PutUint32(lenBs[:], uint32(len(b)))
+
+	_, err := w.Write(lenBs[:])
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func readMessage(r io.Reader, m proto.Message) (bool, error) {
+	var lenBs [4]byte
+
+	_, err := io.ReadFull(r, lenBs[:])
+	if err != nil {
+		return false, ErrInvalidFormat
+	}
+
+	l := binary.BigEndian.Uint32(lenBs[:])
+	if l == 0 {
+		return false, nil
+	}
+
+	b := make([]byte, l)
+
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return false, ErrInvalidFormat
+	}
+
+	err = proto.Unmarshal(b, m)
+	if err != nil {
+		return false, ErrInvalidFormat
+	}
+
+	return true, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func requireEqualSummaries(t *testing.T, expected, actual *Summary) {
+	require.Equal(t, expected.FirstTxID, actual.FirstTxID)
+	require.Equal(t, expected.LastTxID, actual.LastTxID)
+	require.Equal(t, expected.FirstPrevAlh, actual.FirstPrevAlh)
+	require.Equal(t, expected.LastAlh, actual.LastAlh)
+	require.True(t, proto.Equal(expected.State, actual.State))
+}
+
+func TestArchive(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	var firstTx, lastTx uint64
+
+	for i := 0; i < 150; i++ {
+		hdr, err := cli.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		if i == 0 {
+			firstTx = hdr.Id
+		}
+		lastTx = hdr.Id
+	}
+
+	_, err = Create(ctx, cli, 0, lastTx, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	var buf bytes.Buffer
+
+	summary, err := Create(ctx, cli, firstTx, lastTx-10, &buf)
+	require.NoError(t, err)
+	require.Equal(t, firstTx, summary.FirstTxID)
+	require.Equal(t, lastTx-10, summary.LastTxID)
+	require.Equal(t, lastTx, summary.State.TxId)
+
+	archived := make([]byte, buf.Len())
+	copy(archived, buf.Bytes())
+
+	vsummary, err := Verify(bytes.NewReader(archived), nil)
+	require.NoError(t, err)
+	requireEqualSummaries(t, summary, vsummary)
+
+	// chained archive
+	buf.Reset()
+
+	summary2, err := Create(ctx, cli, lastTx-9, lastTx, &buf)
+	require.NoError(t, err)
+	require.Equal(t, summary.LastAlh, summary2.FirstPrevAlh)
+
+	vsummary, err = Verify(bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+	requireEqualSummaries(t, summary2, vsummary)
+
+	t.Run("signature", func(t *testing.T) {
+		pk, err := signer.ParsePublicKeyFile("./../../../test/signer/ec1.pub")
+		require.NoError(t, err)
+
+		_, err = Verify(bytes.NewReader(archived), pk)
+		require.Error(t, err)
+	})
+
+	t.Run("tampering", func(t *testing.T) {
+		_, err = Verify(bytes.NewReader(archived[:5]), nil)
+		require.ErrorIs(t, err, ErrInvalidFormat)
+
+		tampered := make([]byte, len(archived))
+		copy(tampered, archived)
+		tampered[len(magic)] = 2
+
+		_, err = Verify(bytes.NewReader(tampered), nil)
+		require.ErrorIs(t, err, ErrUnsupportedVersion)
+
+		for _, pos := range []int{len(archived) / 4, len(archived) / 2, len(archived) - 10} {
+			copy(tampered, archived)
+			tampered[pos] ^= 0xff
+
+			_, err = Verify(bytes.NewReader(tampered), nil)
+			require.Error(t, err)
+		}
+
+		_, err = Verify(bytes.NewReader(archived[:len(archived)-1]), nil)
+		require.ErrorIs(t, err, ErrInvalidFormat)
+	})
+}
@@ -0,0 +1,27 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import "errors"
+
+var (
+	ErrIllegalArguments   = errors.New("illegal arguments")
+	ErrInvalidFormat      = errors.New("invalid archive format")
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+	ErrCorruptedData      = errors.New("archive data is corrupted")
+	ErrInvalidSignature   = errors.New("invalid state signature")
+)
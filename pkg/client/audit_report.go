@@ -0,0 +1,60 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/signer"
+)
+
+// GenerateAuditReport returns the report of a range of transactions of a database signed by the server,
+// the signature is checked when the public key of the server is set
+func (c *immuClient) GenerateAuditReport(ctx context.Context, req *schema.AuditReportRequest) (*schema.AuditReport, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.GenerateAuditReport(ctx, req)
+
+	c.Logger.Debugf("GenerateAuditReport finished in %s", time.Since(start))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if c.stateSignatureVerificationEnabled() {
+		if res.Signature == nil {
+			return nil, ErrAuditReportSignatureMissing
+		}
+
+		ok, err := signer.Verify(res.Report, res.Signature.Signature, c.serverSigningPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAuditReportSignatureMismatch, err)
+		}
+		if !ok {
+			return nil, ErrAuditReportSignatureMismatch
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+)
+
+// AlertKind is the kind of divergence detected by an audit
+type AlertKind string
+
+const (
+	// AlertTampering is raised when the current state of a database is not consistent with the trusted one
+	AlertTampering AlertKind = "tampering"
+	// AlertRollback is raised when a database is behind the trusted state, e.g. it was emptied or restored
+	AlertRollback AlertKind = "rollback"
+	// AlertInvalidSignature is raised when the signature of the state sent by a server can't be verified
+	AlertInvalidSignature AlertKind = "invalid_signature"
+)
+
+// Alert reports a divergence between the state of a database and the trust history of the auditor
+type Alert struct {
+	Kind          AlertKind `json:"kind"`
+	ServerID      string    `json:"server_id"`
+	ServerAddress string    `json:"server_address"`
+	DB            string    `json:"db"`
+	RaisedAt      time.Time `json:"raised_at"`
+	Message       string    `json:"message"`
+	PreviousState *State    `json:"previous_state,omitempty"`
+	CurrentState  *State    `json:"current_state,omitempty"`
+}
+
+// ErrDivergence is returned by auditors that stop on divergences when one is detected
+var ErrDivergence = errors.New("divergence detected")
+
+// DivergenceError is returned by auditors that stop on divergences, it holds the alert raised
+type DivergenceError struct {
+	Alert *Alert
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("%s of database %s detected on server %s @ %s: %s",
+		e.Alert.Kind, e.Alert.DB, e.Alert.ServerID, e.Alert.ServerAddress, e.Alert.Message)
+}
+
+func (e *DivergenceError) Unwrap() error {
+	return ErrDivergence
+}
+
+// toAlert returns the alert delivered through the alerter of the auditor, e.g. by webhook, email or to PagerDuty.
+// The previous and current states are attached as evidence
+func (a *Alert) toAlert() *alert.Alert {
+	evidence := map[string]string{
+		"server_id":      a.ServerID,
+		"server_address": a.ServerAddress,
+	}
+
+	var fromTx, toTx uint64
+
+	if a.CurrentState != nil {
+		fromTx, toTx = a.CurrentState.Tx, a.CurrentState.Tx
+
+		evidence["current_tx"] = fmt.Sprintf("%d", a.CurrentState.Tx)
+		evidence["current_hash"] = a.CurrentState.Hash
+		evidence["current_signature"] = a.CurrentState.Signature.Signature
+	}
+
+	if a.PreviousState != nil {
+		fromTx = a.PreviousState.Tx
+		if a.CurrentState == nil {
+			toTx = a.PreviousState.Tx
+		}
+
+		evidence["previous_tx"] = fmt.Sprintf("%d", a.PreviousState.Tx)
+		evidence["previous_hash"] = a.PreviousState.Hash
+		evidence["previous_signature"] = a.PreviousState.Signature.Signature
+	}
+
+	return &alert.Alert{
+		Kind:     alert.Kind(a.Kind),
+		Source:   "auditor",
+		DB:       a.DB,
+		FromTx:   fromTx,
+		ToTx:     toTx,
+		Message:  a.Message,
+		Evidence: evidence,
+		RaisedAt: a.RaisedAt,
+	}
+}
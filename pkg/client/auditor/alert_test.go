@@ -0,0 +1,213 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/cache"
+	"github.com/codenotary/immudb/pkg/client/clienttest"
+	"github.com/codenotary/immudb/pkg/client/state"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestAlertToAlert(t *testing.T) {
+	forwarded := (&Alert{
+		Kind:          AlertRollback,
+		ServerID:      "server1",
+		ServerAddress: "127.0.0.1:3322",
+		DB:            "defaultdb",
+		RaisedAt:      time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC),
+		Message:       "database is behind the previous state",
+		PreviousState: &State{Tx: 5, Hash: "hash-5"},
+		CurrentState:  &State{Tx: 3, Hash: "hash-3"},
+	}).toAlert()
+
+	require.Equal(t, alert.Rollback, forwarded.Kind)
+	require.Equal(t, "auditor", forwarded.Source)
+	require.Equal(t, "defaultdb", forwarded.DB)
+	require.Equal(t, uint64(5), forwarded.FromTx)
+	require.Equal(t, uint64(3), forwarded.ToTx)
+	require.Equal(t, "hash-5", forwarded.Evidence["previous_hash"])
+	require.Equal(t, "hash-3", forwarded.Evidence["current_hash"])
+	require.Equal(t, "server1", forwarded.Evidence["server_id"])
+
+	forwarded = (&Alert{
+		Kind:         AlertInvalidSignature,
+		DB:           "defaultdb",
+		CurrentState: &State{Tx: 3, Hash: "hash-3"},
+	}).toAlert()
+
+	require.Equal(t, alert.InvalidSignature, forwarded.Kind)
+	require.Equal(t, uint64(3), forwarded.FromTx)
+	require.Equal(t, uint64(3), forwarded.ToTx)
+}
+
+func TestDefaultAuditorRaisesRollbackAlerts(t *testing.T) {
+	defer os.RemoveAll(dirname)
+
+	currState := &schema.ImmutableState{TxId: 3, TxHash: []byte("hash-3")}
+
+	serviceClient := &clienttest.ImmuServiceClientMock{
+		HealthF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*schema.HealthResponse, error) {
+			return &schema.HealthResponse{Status: true, Version: "v1.0.0"}, nil
+		},
+		LoginF: func(ctx context.Context, in *schema.LoginRequest, opts ...grpc.CallOption) (*schema.LoginResponse, error) {
+			return &schema.LoginResponse{Token: "token"}, nil
+		},
+		LogoutF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+			return new(empty.Empty), nil
+		},
+		DatabaseListF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*schema.DatabaseListResponse, error) {
+			return &schema.DatabaseListResponse{
+				Databases: []*schema.Database{{DatabaseName: "someDB"}},
+			}, nil
+		},
+		UseDatabaseF: func(ctx context.Context, in *schema.Database, opts ...grpc.CallOption) (*schema.UseDatabaseReply, error) {
+			return &schema.UseDatabaseReply{Token: "sometoken"}, nil
+		},
+		CurrentStateF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*schema.ImmutableState, error) {
+			return currState, nil
+		},
+	}
+
+	history := cache.NewHistoryFileCache(dirname)
+
+	// the trusted state is ahead of the one of the server
+	err := history.Set("address_0", "someDB", &schema.ImmutableState{TxId: 5, TxHash: []byte("hash-5")})
+	require.NoError(t, err)
+
+	var alerts []*alert.Alert
+
+	da, err := DefaultAuditor(
+		time.Duration(0),
+		fmt.Sprintf("%s:%d", "address", 0),
+		[]grpc.DialOption{grpc.WithInsecure()},
+		"immudb",
+		"immudb",
+		nil,
+		nil,
+		AuditNotificationConfig{},
+		serviceClient,
+		state.NewUUIDProvider(serviceClient),
+		history,
+		func(string, string, bool, bool, bool, *schema.ImmutableState, *schema.ImmutableState) {},
+		logger.NewSimpleLogger("test", os.Stdout),
+		nil,
+		WithAlerter(alert.AlerterFunc(func(a *alert.Alert) error {
+			alerts = append(alerts, a)
+			return nil
+		})),
+	)
+	require.NoError(t, err)
+
+	err = da.(*defaultAuditor).audit()
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.Equal(t, alert.Rollback, alerts[0].Kind)
+	require.Equal(t, "address_0", alerts[0].Evidence["server_id"])
+	require.Equal(t, "someDB", alerts[0].DB)
+	require.Equal(t, uint64(5), alerts[0].FromTx)
+	require.Equal(t, uint64(3), alerts[0].ToTx)
+
+	// the trusted state is not overwritten
+	trusted, err := history.Get("address_0", "someDB")
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), trusted.TxId)
+
+	currState = &schema.ImmutableState{}
+
+	err = da.(*defaultAuditor).audit()
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	require.Equal(t, alert.Rollback, alerts[1].Kind)
+}
+
+func TestDefaultAuditorStopsOnDivergence(t *testing.T) {
+	defer os.RemoveAll(dirname)
+
+	serviceClient := &clienttest.ImmuServiceClientMock{
+		HealthF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*schema.HealthResponse, error) {
+			return &schema.HealthResponse{Status: true, Version: "v1.0.0"}, nil
+		},
+		LoginF: func(ctx context.Context, in *schema.LoginRequest, opts ...grpc.CallOption) (*schema.LoginResponse, error) {
+			return &schema.LoginResponse{Token: "token"}, nil
+		},
+		LogoutF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+			return new(empty.Empty), nil
+		},
+		DatabaseListF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*schema.DatabaseListResponse, error) {
+			return &schema.DatabaseListResponse{
+				Databases: []*schema.Database{{DatabaseName: "someDB"}},
+			}, nil
+		},
+		UseDatabaseF: func(ctx context.Context, in *schema.Database, opts ...grpc.CallOption) (*schema.UseDatabaseReply, error) {
+			return &schema.UseDatabaseReply{Token: "sometoken"}, nil
+		},
+		CurrentStateF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*schema.ImmutableState, error) {
+			return &schema.ImmutableState{TxId: 3, TxHash: []byte("hash-3")}, nil
+		},
+	}
+
+	history := cache.NewHistoryFileCache(dirname)
+
+	// the trusted state is ahead of the one of the server
+	err := history.Set("address_0", "someDB", &schema.ImmutableState{TxId: 5, TxHash: []byte("hash-5")})
+	require.NoError(t, err)
+
+	da, err := DefaultAuditor(
+		time.Millisecond,
+		fmt.Sprintf("%s:%d", "address", 0),
+		[]grpc.DialOption{grpc.WithInsecure()},
+		"immudb",
+		"immudb",
+		nil,
+		nil,
+		AuditNotificationConfig{},
+		serviceClient,
+		state.NewUUIDProvider(serviceClient),
+		history,
+		func(string, string, bool, bool, bool, *schema.ImmutableState, *schema.ImmutableState) {},
+		logger.NewSimpleLogger("test", os.Stdout),
+		nil,
+		WithStopOnDivergence(),
+	)
+	require.NoError(t, err)
+
+	// the auditor stops by itself
+	donec := make(chan struct{}, 1)
+
+	err = da.Run(time.Millisecond, false, make(chan struct{}), donec)
+	require.ErrorIs(t, err, ErrDivergence)
+
+	var divergence *DivergenceError
+	require.True(t, errors.As(err, &divergence))
+	require.Equal(t, AlertRollback, divergence.Alert.Kind)
+	require.Equal(t, "someDB", divergence.Alert.DB)
+	require.Equal(t, uint64(5), divergence.Alert.PreviousState.Tx)
+	require.Equal(t, uint64(3), divergence.Alert.CurrentState.Tx)
+}
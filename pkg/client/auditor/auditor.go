@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/alert"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/client"
@@ -81,6 +82,38 @@ type defaultAuditor struct {
 	updateMetrics func(string, string, bool, bool, bool, *schema.ImmutableState, *schema.ImmutableState)
 
 	monitoringHTTPAddr *string
+
+	alerter alert.Alerter
+
+	cosigner signer.Signer
+
+	stopOnDivergence bool
+}
+
+// Option customizes the default auditor
+type Option func(a *defaultAuditor)
+
+// WithAlerter sets the alerter the divergences detected by the auditor are delivered to
+func WithAlerter(alerter alert.Alerter) Option {
+	return func(a *defaultAuditor) {
+		a.alerter = alerter
+	}
+}
+
+// WithCosigner sets the signer the auditor cosigns the states it verified as consistent with,
+// the server only accepts the cosignatures of the public keys it was configured with
+func WithCosigner(cosigner signer.Signer) Option {
+	return func(a *defaultAuditor) {
+		a.cosigner = cosigner
+	}
+}
+
+// WithStopOnDivergence makes the auditor stop as soon as a divergence is detected,
+// Run returns a *DivergenceError describing it
+func WithStopOnDivergence() Option {
+	return func(a *defaultAuditor) {
+		a.stopOnDivergence = true
+	}
 }
 
 // DefaultAuditor creates initializes a default auditor implementation
@@ -98,7 +131,8 @@ func DefaultAuditor(
 	history cache.HistoryCache,
 	updateMetrics func(string, string, bool, bool, bool, *schema.ImmutableState, *schema.ImmutableState),
 	log logger.Logger,
-	monitoringHTTPAddr *string) (Auditor, error) {
+	monitoringHTTPAddr *string,
+	opts ...Option) (Auditor, error) {
 
 	password, err := auth.DecodeBase64Password(passwordBase64)
 	if err != nil {
@@ -112,7 +146,7 @@ func DefaultAuditor(
 	httpClient := &http.Client{Timeout: notificationConfig.RequestTimeout}
 	notificationConfig.PublishFunc = httpClient.Do
 
-	return &defaultAuditor{
+	a := &defaultAuditor{
 		0,
 		0,
 		log,
@@ -131,7 +165,16 @@ func DefaultAuditor(
 		slugifyRegExp,
 		updateMetrics,
 		monitoringHTTPAddr,
-	}, nil
+		nil,
+		nil,
+		false,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a, nil
 }
 
 func (a *defaultAuditor) Run(
@@ -273,7 +316,7 @@ func (a *defaultAuditor) audit() error {
 	if err := a.verifyStateSignature(serverID, state); err != nil {
 		a.logger.Errorf("audit #%d aborted: %v", a.index, err)
 		withError = true
-		return noErr
+		return a.raiseAlert(ctx, AlertInvalidSignature, "", dbName, err.Error(), nil, state)
 	}
 
 	isEmptyDB := state.TxId == 0
@@ -293,7 +336,16 @@ func (a *defaultAuditor) audit() error {
 					"but locally a previous state exists with hash %x at id %d",
 				a.index, serverID, a.serverAddress, prevState.TxHash, prevState.TxId)
 			withError = true
-			return noErr
+			return a.raiseAlert(ctx, AlertRollback, serverID, dbName, "database is empty but a previous state exists", prevState, state)
+		}
+
+		if state.TxId < prevState.TxId {
+			a.logger.Errorf(
+				"audit #%d aborted: database %s is at tx %d on server %s @ %s, "+
+					"but locally a previous state exists with hash %x at tx %d",
+				a.index, dbName, state.TxId, serverID, a.serverAddress, prevState.TxHash, prevState.TxId)
+			withError = true
+			return a.raiseAlert(ctx, AlertRollback, serverID, dbName, "database is behind the previous state", prevState, state)
 		}
 
 		vtx, err := a.serviceClient.VerifiableTxById(ctx, &schema.VerifiableTxRequest{
@@ -308,8 +360,17 @@ func (a *defaultAuditor) audit() error {
 			return noErr
 		}
 
+		dualProof, err := schema.DualProofFromProto(vtx.DualProof)
+		if err != nil {
+			a.logger.Errorf(
+				"error decoding consistency proof for previous state %d: %v",
+				prevState.TxId, err)
+			withError = true
+			return noErr
+		}
+
 		verified = store.VerifyDualProof(
-			schema.DualProofFromProto(vtx.DualProof),
+			dualProof,
 			prevState.TxId,
 			state.TxId,
 			schema.DigestFromProto(prevState.TxHash),
@@ -328,22 +389,8 @@ func (a *defaultAuditor) audit() error {
 				dbName,
 				time.Now(),
 				!verified,
-				&State{
-					Tx:   prevState.TxId,
-					Hash: base64.StdEncoding.EncodeToString(prevState.TxHash),
-					Signature: Signature{
-						Signature: base64.StdEncoding.EncodeToString(prevState.GetSignature().GetSignature()),
-						PublicKey: base64.StdEncoding.EncodeToString(prevState.GetSignature().GetPublicKey()),
-					},
-				},
-				&State{
-					Tx:   state.TxId,
-					Hash: base64.StdEncoding.EncodeToString(state.TxHash),
-					Signature: Signature{
-						Signature: base64.StdEncoding.EncodeToString(state.GetSignature().GetSignature()),
-						PublicKey: base64.StdEncoding.EncodeToString(state.GetSignature().GetPublicKey()),
-					},
-				},
+				notificationState(prevState),
+				notificationState(state),
 			)
 			if err != nil {
 				a.logger.Errorf(
@@ -365,18 +412,107 @@ func (a *defaultAuditor) audit() error {
 			"audit #%d detected possible tampering of db %s remote state (at id %d) "+
 				"so it will not overwrite the previous local state (at id %d)",
 			a.index, dbName, state.TxId, prevState.TxId)
+
+		err := a.raiseAlert(ctx, AlertTampering, serverID, dbName, "current state is not consistent with the previous state", prevState, state)
+		if err != nil {
+			return err
+		}
 	} else if prevState == nil || state.TxId != prevState.TxId {
 		if err := a.history.Set(serverID, dbName, state); err != nil {
 			a.logger.Errorf(err.Error())
 			return noErr
 		}
 	}
+
+	if checked && verified {
+		a.cosign(ctx, dbName, state)
+	}
 	a.logger.Infof("audit #%d finished in %s @ %s",
 		a.index, time.Since(start), time.Now().Format(time.RFC3339Nano))
 
 	return noErr
 }
 
+// cosign signs the state of the database verified by the audit and sends the signature to the server,
+// if the auditor has a cosigner
+func (a *defaultAuditor) cosign(ctx context.Context, dbName string, state *schema.ImmutableState) {
+	if a.cosigner == nil {
+		return
+	}
+
+	signature, publicKey, err := a.cosigner.Sign((&schema.ImmutableState{
+		Db:     dbName,
+		TxId:   state.TxId,
+		TxHash: state.TxHash,
+	}).ToBytes())
+	if err != nil {
+		a.logger.Errorf("error cosigning the state of db %s: %v", dbName, err)
+		return
+	}
+
+	_, err = a.serviceClient.Cosign(ctx, &schema.Cosignature{
+		TxId:      state.TxId,
+		TxHash:    state.TxHash,
+		Signature: &schema.Signature{Signature: signature, PublicKey: publicKey},
+	})
+	if err != nil {
+		a.logger.Errorf("error sending the cosignature of the state of db %s at tx %d: %v", dbName, state.TxId, err)
+		return
+	}
+
+	a.logger.Infof("audit #%d - state of db %s at tx %d cosigned", a.index, dbName, state.TxId)
+}
+
+// raiseAlert delivers an alert to the alerter of the auditor, if any.
+// The alert is returned as a *DivergenceError when the auditor stops on divergences, nil is returned otherwise
+func (a *defaultAuditor) raiseAlert(
+	ctx context.Context,
+	kind AlertKind,
+	serverID string,
+	db string,
+	message string,
+	prevState *schema.ImmutableState,
+	currState *schema.ImmutableState,
+) error {
+	if a.alerter == nil && !a.stopOnDivergence {
+		return nil
+	}
+
+	if serverID == "" {
+		serverID = a.getServerID(ctx)
+	}
+
+	divergence := &Alert{
+		Kind:          kind,
+		ServerID:      serverID,
+		ServerAddress: a.serverAddress,
+		DB:            db,
+		RaisedAt:      time.Now(),
+		Message:       message,
+	}
+	if prevState != nil {
+		divergence.PreviousState = notificationState(prevState)
+	}
+	if currState != nil {
+		divergence.CurrentState = notificationState(currState)
+	}
+
+	if a.alerter != nil {
+		err := a.alerter.Alert(divergence.toAlert())
+		if err != nil {
+			a.logger.Errorf("error raising %s alert for db %s: %v", kind, db, err)
+		} else {
+			a.logger.Infof("%s alert for db %s has been raised", kind, db)
+		}
+	}
+
+	if a.stopOnDivergence {
+		return &DivergenceError{Alert: divergence}
+	}
+
+	return nil
+}
+
 func (a *defaultAuditor) verifyStateSignature(
 	serverID string,
 	serverState *schema.ImmutableState,
@@ -430,6 +566,17 @@ type State struct {
 	Signature Signature `json:"signature" validate:"required"`
 }
 
+func notificationState(s *schema.ImmutableState) *State {
+	return &State{
+		Tx:   s.TxId,
+		Hash: base64.StdEncoding.EncodeToString(s.TxHash),
+		Signature: Signature{
+			Signature: base64.StdEncoding.EncodeToString(s.GetSignature().GetSignature()),
+			PublicKey: base64.StdEncoding.EncodeToString(s.GetSignature().GetPublicKey()),
+		},
+	}
+}
+
 // AuditNotificationRequest ...
 type AuditNotificationRequest struct {
 	Username      string    `json:"username" validate:"required"`
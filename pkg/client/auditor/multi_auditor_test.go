@@ -0,0 +1,67 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type auditorMock struct {
+	err  error
+	runs int
+}
+
+func (a *auditorMock) Run(interval time.Duration, singleRun bool, stopc <-chan struct{}, donec chan<- struct{}) error {
+	defer func() { donec <- struct{}{} }()
+
+	a.runs++
+
+	if !singleRun {
+		<-stopc
+	}
+
+	return a.err
+}
+
+func TestMultiAuditor(t *testing.T) {
+	a1 := &auditorMock{}
+	a2 := &auditorMock{}
+
+	donec := make(chan struct{}, 1)
+
+	err := NewMultiAuditor(a1, a2).Run(time.Second, true, nil, donec)
+	require.NoError(t, err)
+	require.Equal(t, 1, a1.runs)
+	require.Equal(t, 1, a2.runs)
+	require.Len(t, donec, 1)
+
+	<-donec
+
+	a2.err = errors.New("some error")
+
+	stopc := make(chan struct{})
+	close(stopc)
+
+	err = NewMultiAuditor(a1, a2).Run(time.Second, false, stopc, donec)
+	require.ErrorIs(t, err, a2.err)
+	require.Equal(t, 2, a1.runs)
+	require.Len(t, donec, 1)
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup streams a whole database through the client into a local file
+// which can be later restored into another database, without requiring access
+// to the filesystem of the server.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+// verificationInterval is the number of transactions after which the backed up
+// chain is proven to be consistent with the trusted state of the client
+const verificationInterval = 100
+
+// Summary describes the transactions processed by a backup or restore
+type Summary struct {
+	FirstTxID uint64
+	LastTxID  uint64
+	TxCount   uint64
+	LastAlh   [sha256.Size]byte
+}
+
+// Backup writes into w every transaction of the current database starting from fromTx
+// up to the latest committed one.
+// Each exported transaction is checked against its header and entry digests, consecutive
+// transactions must be linked through their accumulated linear hashes and the chain is
+// periodically proven to be consistent with the trusted state of the client.
+func Backup(ctx context.Context, c client.ImmuClient, w io.Writer, fromTx uint64) (*Summary, error) {
+	if c == nil || w == nil || fromTx == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+
+	var prevAlh []byte
+
+	if fromTx > 1 {
+		tx, err := c.VerifiedTxByID(ctx, fromTx-1)
+		if err != nil {
+			return nil, err
+		}
+
+		txHdr, err := schema.TxHeaderFromProto(tx.Header)
+		if err != nil {
+			return nil, err
+		}
+
+		alh := txHdr.Alh()
+		prevAlh = alh[:]
+	}
+
+	for txID := fromTx; txID <= state.TxId; txID++ {
+		payload, err := exportTx(ctx, c, txID)
+		if err != nil {
+			return nil, err
+		}
+
+		// entries are requested raw, as they are hashed by the server
+		tx, err := c.GetServiceClient().TxById(ctx, &schema.TxRequest{Tx: txID})
+		if err != nil {
+			return nil, err
+		}
+
+		hdr, err := checkExportedTx(tx, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if prevAlh != nil && !bytes.Equal(hdr.PrevAlh[:], prevAlh) {
+			return nil, ErrCorruptedData
+		}
+
+		alh := hdr.Alh()
+		if summary.TxCount%verificationInterval == verificationInterval-1 || txID == state.TxId {
+			err = verifyAlh(ctx, c, txID, alh)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = writeTx(w, txID, alh[:], payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if summary.TxCount == 0 {
+			summary.FirstTxID = txID
+		}
+
+		summary.LastTxID = txID
+		summary.LastAlh = alh
+		summary.TxCount++
+
+		prevAlh = alh[:]
+	}
+
+	return summary, nil
+}
+
+func exportTx(ctx context.Context, c client.ImmuClient, txID uint64) ([]byte, error) {
+	stream, err := c.ExportTx(ctx, &schema.TxRequest{Tx: txID})
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		payload = append(payload, chunk.Content...)
+	}
+
+	return payload, stream.CloseSend()
+}
+
+func verifyAlh(ctx context.Context, c client.ImmuClient, txID uint64, alh [sha256.Size]byte) error {
+	tx, err := c.VerifiedTxByID(ctx, txID)
+	if err != nil {
+		return err
+	}
+
+	hdr, err := schema.TxHeaderFromProto(tx.Header)
+	if err != nil {
+		return err
+	}
+
+	if hdr.Alh() != alh {
+		return ErrCorruptedData
+	}
+
+	return nil
+}
+
+// checkExportedTx validates the exported payload against the transaction returned
+// by the server, header as well as keys, metadata and value digests must match
+func checkExportedTx(tx *schema.Tx, payload []byte) (*store.TxHeader, error) {
+	if tx == nil || tx.Header == nil {
+		return nil, ErrCorruptedData
+	}
+
+	// entries hash tree is rebuilt from the entry digests
+	decodedTx, err := schema.TxFromProto(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedHdr := decodedTx.Header()
+
+	if expectedHdr.Eh != schema.DigestFromProto(tx.Header.EH) {
+		return nil, ErrCorruptedData
+	}
+
+	// the exported transaction is preceded by its length as framed by the stream sender
+	if len(payload) < 8 || binary.BigEndian.Uint64(payload) != uint64(len(payload)-8) {
+		return nil, ErrCorruptedData
+	}
+
+	i := 8
+
+	if len(payload) < i+4 {
+		return nil, ErrCorruptedData
+	}
+
+	hdrLen := int(binary.BigEndian.Uint32(payload[i:]))
+	i += 4
+
+	if len(payload) < i+hdrLen {
+		return nil, ErrCorruptedData
+	}
+
+	hdr := &store.TxHeader{}
+
+	err = hdr.ReadFrom(payload[i : i+hdrLen])
+	if err != nil {
+		return nil, ErrCorruptedData
+	}
+	i += hdrLen
+
+	if hdr.Alh() != expectedHdr.Alh() || hdr.NEntries != len(tx.Entries) {
+		return nil, ErrCorruptedData
+	}
+
+	for _, e := range tx.Entries {
+		if len(payload) < i+2 {
+			return nil, ErrCorruptedData
+		}
+
+		kLen := int(binary.BigEndian.Uint16(payload[i:]))
+		i += 2
+
+		if len(payload) < i+kLen+2 || !bytes.Equal(payload[i:i+kLen], e.Key) {
+			return nil, ErrCorruptedData
+		}
+		i += kLen
+
+		mdLen := int(binary.BigEndian.Uint16(payload[i:]))
+		i += 2
+
+		var md []byte
+
+		if e.Metadata != nil {
+			md = schema.KVMetadataFromProto(e.Metadata).Bytes()
+		}
+
+		if len(payload) < i+mdLen+4 || !bytes.Equal(payload[i:i+mdLen], md) {
+			return nil, ErrCorruptedData
+		}
+		i += mdLen
+
+		vLen := int(binary.BigEndian.Uint32(payload[i:]))
+		i += 4
+
+		if vLen != int(e.VLen) || len(payload) < i+vLen {
+			return nil, ErrCorruptedData
+		}
+
+		hVal := sha256.Sum256(payload[i : i+vLen])
+		if !bytes.Equal(hVal[:], e.HValue) {
+			return nil, ErrCorruptedData
+		}
+		i += vLen
+	}
+
+	if i != len(payload) {
+		return nil, ErrCorruptedData
+	}
+
+	return hdr, nil
+}
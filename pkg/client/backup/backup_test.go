@@ -0,0 +1,122 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 150; i++ {
+		_, err := cli.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, err = cli.Delete(ctx, &schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key0")}})
+	require.NoError(t, err)
+
+	_, err = Backup(ctx, cli, &bytes.Buffer{}, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	var buf bytes.Buffer
+
+	summary, err := Backup(ctx, cli, &buf, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), summary.FirstTxID)
+	require.Equal(t, summary.LastTxID, summary.TxCount)
+
+	firstBackup := make([]byte, buf.Len())
+	copy(firstBackup, buf.Bytes())
+
+	hdr, err := cli.Set(ctx, []byte("key150"), []byte("value150"))
+	require.NoError(t, err)
+
+	var incBuf bytes.Buffer
+
+	incSummary, err := Backup(ctx, cli, &incBuf, summary.LastTxID+1)
+	require.NoError(t, err)
+	require.Equal(t, hdr.Id, incSummary.FirstTxID)
+	require.Equal(t, uint64(1), incSummary.TxCount)
+
+	nothing, err := Backup(ctx, cli, &bytes.Buffer{}, hdr.Id+1)
+	require.NoError(t, err)
+	require.Zero(t, nothing.TxCount)
+
+	err = cli.CreateDatabase(ctx, &schema.DatabaseSettings{
+		DatabaseName:   "restoreddb",
+		Replica:        true,
+		MasterDatabase: "dummy",
+	})
+	require.NoError(t, err)
+
+	err = cli.CloseSession(ctx)
+	require.NoError(t, err)
+
+	err = cli.OpenSession(ctx, []byte(auth.SysAdminUsername), []byte(auth.SysAdminPassword), "restoreddb")
+	require.NoError(t, err)
+
+	t.Run("restore", func(t *testing.T) {
+		_, err = Restore(ctx, cli, bytes.NewReader(incBuf.Bytes()))
+		require.ErrorIs(t, err, ErrTxGap)
+
+		restored, err := Restore(ctx, cli, bytes.NewReader(firstBackup))
+		require.NoError(t, err)
+		require.Equal(t, summary, restored)
+
+		// already restored transactions are skipped
+		restored, err = Restore(ctx, cli, bytes.NewReader(append(firstBackup, incBuf.Bytes()...)))
+		require.NoError(t, err)
+		require.Equal(t, incSummary, restored)
+
+		state, err := cli.CurrentState(ctx)
+		require.NoError(t, err)
+		require.Equal(t, incSummary.LastTxID, state.TxId)
+		require.Equal(t, incSummary.LastAlh[:], state.TxHash)
+	})
+
+	t.Run("tampering", func(t *testing.T) {
+		_, err = Restore(ctx, cli, bytes.NewReader(firstBackup[:len(firstBackup)-1]))
+		require.ErrorIs(t, err, ErrMalformedFile)
+
+		tampered := make([]byte, len(firstBackup))
+		copy(tampered, firstBackup)
+		tampered[0] ^= 0xff
+
+		_, err = Restore(ctx, cli, bytes.NewReader(tampered))
+		require.ErrorIs(t, err, ErrMalformedFile)
+
+		copy(tampered, firstBackup)
+		tampered[headerSize] ^= 0xff
+
+		_, err = Restore(ctx, cli, bytes.NewReader(tampered))
+		require.ErrorIs(t, err, ErrCorruptedData)
+	})
+}
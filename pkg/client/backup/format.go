@@ -0,0 +1,85 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// the file format is the one used by the immuadmin hot-backup and hot-restore commands,
+// a sequence of records made of a fixed size header, the accumulated linear hash of the
+// transaction and the transaction as exported by the server
+const (
+	prefix            = "IMMUBACKUP"
+	latestFileVersion = 1
+)
+
+const (
+	prefixOffset     = iota
+	versionOffset    = prefixOffset + len(prefix)
+	txIdOffset       = versionOffset + 4
+	txSignSizeOffset = txIdOffset + 8
+	txSizeOffset     = txSignSizeOffset + 4
+	headerSize       = txSizeOffset + 4
+)
+
+func writeTx(w io.Writer, txID uint64, alh []byte, payload []byte) error {
+	b := make([]byte, headerSize, headerSize+len(alh)+len(payload))
+	copy(b[prefixOffset:], prefix)
+	binary.BigEndian.PutUint32(b[versionOffset:], latestFileVersion)
+	binary.BigEndian.PutUint64(b[txIdOffset:], txID)
+	binary.BigEndian.PutUint32(b[txSignSizeOffset:], uint32(len(alh)))
+	binary.BigEndian.PutUint32(b[txSizeOffset:], uint32(len(payload)))
+	b = append(b, alh...)
+	b = append(b, payload...)
+
+	_, err := w.Write(b)
+	return err
+}
+
+// readTx returns io.EOF when there are no more records
+func readTx(r io.Reader) (txID uint64, alh []byte, payload []byte, err error) {
+	hdr := make([]byte, headerSize)
+
+	_, err = io.ReadFull(r, hdr)
+	if err == io.EOF {
+		return 0, nil, nil, io.EOF
+	}
+	if err != nil {
+		return 0, nil, nil, ErrMalformedFile
+	}
+
+	if !bytes.Equal(hdr[:versionOffset], []byte(prefix)) ||
+		binary.BigEndian.Uint32(hdr[versionOffset:]) != latestFileVersion {
+		return 0, nil, nil, ErrMalformedFile
+	}
+
+	txID = binary.BigEndian.Uint64(hdr[txIdOffset:])
+	alhSize := binary.BigEndian.Uint32(hdr[txSignSizeOffset:])
+	txSize := binary.BigEndian.Uint32(hdr[txSizeOffset:])
+
+	b := make([]byte, alhSize+txSize)
+
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return 0, nil, nil, ErrMalformedFile
+	}
+
+	return txID, b[:alhSize], b[alhSize:], nil
+}
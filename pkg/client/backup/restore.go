@@ -0,0 +1,137 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+// replicationChunkSize is the maximum size of each chunk sent while replicating a transaction
+const replicationChunkSize = 1 << 20
+
+// Restore replicates the transactions read from r into the current database, which is
+// required to be a replica.
+// Transactions already present in the database are skipped once their accumulated linear
+// hash is checked to match the backed up one, the remaining ones must continue the
+// database without gaps.
+func Restore(ctx context.Context, c client.ImmuClient, r io.Reader) (*Summary, error) {
+	if c == nil || r == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+
+	lastTxID := state.TxId
+
+	var prevTxID uint64
+
+	for {
+		txID, alh, payload, err := readTx(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if prevTxID > 0 && txID != prevTxID+1 {
+			return nil, ErrTxWrongOrder
+		}
+		prevTxID = txID
+
+		if txID <= lastTxID {
+			tx, err := c.TxByID(ctx, txID)
+			if err != nil {
+				return nil, err
+			}
+
+			txHdr, err := schema.TxHeaderFromProto(tx.Header)
+			if err != nil {
+				return nil, err
+			}
+
+			expectedAlh := txHdr.Alh()
+			if !bytes.Equal(alh, expectedAlh[:]) {
+				return nil, ErrCorruptedData
+			}
+
+			continue
+		}
+
+		if txID != lastTxID+1 {
+			return nil, ErrTxGap
+		}
+
+		hdr, err := replicateTx(ctx, c, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		txHdr, err := schema.TxHeaderFromProto(hdr)
+		if err != nil {
+			return nil, err
+		}
+
+		restoredAlh := txHdr.Alh()
+		if !bytes.Equal(alh, restoredAlh[:]) {
+			return nil, ErrCorruptedData
+		}
+
+		if summary.TxCount == 0 {
+			summary.FirstTxID = txID
+		}
+
+		summary.LastTxID = txID
+		summary.LastAlh = restoredAlh
+		summary.TxCount++
+
+		lastTxID = txID
+	}
+
+	return summary, nil
+}
+
+func replicateTx(ctx context.Context, c client.ImmuClient, payload []byte) (*schema.TxHeader, error) {
+	stream, err := c.ReplicateTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(payload); i += replicationChunkSize {
+		j := i + replicationChunkSize
+		if j > len(payload) {
+			j = len(payload)
+		}
+
+		err = stream.Send(&schema.Chunk{Content: payload[i:j]})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"io"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/remotestorage"
+)
+
+// StorageWriter uploads a backup straight into an object storage, so no local copy of it is needed.
+// Both the backups written by Backup and the ones streamed by the Backup method of the client are supported.
+type StorageWriter struct {
+	upload *remotestorage.UploadWriter
+	w      io.WriteCloser // encrypts data before uploading it when client-side encryption is enabled
+}
+
+// NewStorageWriter starts uploading a backup as the object name of st. The backup is encrypted before
+// leaving the client when a key provider is set, server-side encryption is configured through opts.
+// Close must be called to complete the upload.
+func NewStorageWriter(ctx context.Context, st remotestorage.MultipartStorage, name string, opts *remotestorage.UploadOptions, provider encryption.MasterKeyProvider) (*StorageWriter, error) {
+	upload, err := remotestorage.NewUploadWriter(ctx, st, name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sw := &StorageWriter{upload: upload}
+
+	if provider != nil {
+		sw.w, err = encryption.NewStreamWriter(upload, provider)
+		if err != nil {
+			upload.Abort()
+			return nil, err
+		}
+	}
+
+	return sw, nil
+}
+
+func (sw *StorageWriter) Write(p []byte) (int, error) {
+	if sw.w != nil {
+		return sw.w.Write(p)
+	}
+
+	return sw.upload.Write(p)
+}
+
+// Close completes the upload, the backup is available in the storage once it returns without errors
+func (sw *StorageWriter) Close() error {
+	if sw.w != nil {
+		err := sw.w.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return sw.upload.Close()
+}
+
+// Abort discards the upload, i.e. when the backup fails
+func (sw *StorageWriter) Abort() error {
+	return sw.upload.Abort()
+}
+
+// NewStorageReader reads a backup uploaded with a StorageWriter, the backup is decrypted
+// when a key provider is set
+func NewStorageReader(ctx context.Context, st remotestorage.Storage, name string, provider encryption.MasterKeyProvider) (io.ReadCloser, error) {
+	if st == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	rc, err := st.Get(ctx, name, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider == nil {
+		return rc, nil
+	}
+
+	r, err := encryption.NewStreamReader(rc, provider)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	return &readCloser{Reader: r, Closer: rc}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/memory"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupToStorage(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, err := cli.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	state, err := cli.CurrentState(ctx)
+	require.NoError(t, err)
+
+	kp, err := encryption.NewStaticKeyProvider(map[string][]byte{"mk1": bytes.Repeat([]byte{1}, encryption.KeySize)}, "mk1")
+	require.NoError(t, err)
+
+	st := memory.Open()
+	opts := remotestorage.DefaultUploadOptions().WithPartSize(1024)
+
+	_, err = NewStorageReader(ctx, nil, "backup", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for _, provider := range []encryption.MasterKeyProvider{nil, kp} {
+		w, err := NewStorageWriter(ctx, st, "backup", opts, provider)
+		require.NoError(t, err)
+
+		err = cli.Backup(ctx, &schema.BackupRequest{}, w)
+		require.NoError(t, err)
+
+		err = w.Close()
+		require.NoError(t, err)
+
+		r, err := NewStorageReader(ctx, st, "backup", provider)
+		require.NoError(t, err)
+
+		manifest, err := store.ReadBackupManifest(r)
+		require.NoError(t, err)
+		require.Equal(t, state.TxId, manifest.TxID)
+		require.Equal(t, state.TxHash, manifest.Alh[:])
+
+		err = r.Close()
+		require.NoError(t, err)
+	}
+
+	t.Run("client-side encrypted backup is unreadable without the key", func(t *testing.T) {
+		rc, err := NewStorageReader(ctx, st, "backup", nil)
+		require.NoError(t, err)
+		defer rc.Close()
+
+		_, err = store.ReadBackupManifest(rc)
+		require.Error(t, err)
+	})
+
+	t.Run("aborted backup", func(t *testing.T) {
+		w, err := NewStorageWriter(ctx, st, "aborted", opts, kp)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("partial backup"))
+		require.NoError(t, err)
+
+		err = w.Abort()
+		require.NoError(t, err)
+
+		exists, err := st.Exists(ctx, "aborted")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/client/errors"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// VerifiedBatch reads the entries of keys and the headers of the transactions txs, all of them
+// proven with a single round-trip. Every transaction involved is verified to be part of the history
+// of the last committed one, which is in turn verified against the local state just once.
+func (c *immuClient) VerifiedBatch(ctx context.Context, keys [][]byte, txs []uint64) (*schema.VerifiableBatch, error) {
+	err := c.StateService.CacheLock()
+	if err != nil {
+		return nil, err
+	}
+	defer c.StateService.CacheUnlock()
+
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	state, err := c.StateService.GetState(ctx, c.Options.CurrentDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &schema.VerifiableBatchRequest{
+		Keys:         make([]*schema.KeyRequest, len(keys)),
+		Txs:          txs,
+		ProveSinceTx: state.TxId,
+	}
+
+	for i, key := range keys {
+		req.Keys[i] = &schema.KeyRequest{Key: key}
+	}
+
+	batch, err := c.ServiceClient.VerifiableBatch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(batch.Entries) != len(keys) || batch.DualProof == nil {
+		return nil, store.ErrCorruptedData
+	}
+
+	dualProof, err := schema.DualProofFromProto(batch.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	targetHdr := dualProof.TargetTxHeader
+	targetAlh := targetHdr.Alh()
+
+	if state.TxId > 0 {
+		verifies := store.VerifyDualProof(
+			dualProof,
+			state.TxId,
+			targetHdr.ID,
+			schema.DigestFromProto(state.TxHash),
+			targetAlh,
+		)
+		if !verifies {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	txHdrs := make(map[uint64]*store.TxHeader, len(batch.TxProofs))
+
+	for _, txProof := range batch.TxProofs {
+		if txProof == nil || txProof.TxHeader == nil {
+			return nil, store.ErrCorruptedData
+		}
+
+		proof, err := schema.TxInclusionProofFromProto(txProof)
+		if err != nil {
+			return nil, err
+		}
+
+		if !store.VerifyTxInclusion(proof, targetHdr) {
+			return nil, store.ErrCorruptedData
+		}
+
+		txHdrs[proof.TxHeader.ID] = proof.TxHeader
+	}
+
+	for _, txID := range txs {
+		_, ok := txHdrs[txID]
+		if !ok {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	for i, be := range batch.Entries {
+		if be == nil || be.Entry == nil || be.InclusionProof == nil {
+			return nil, store.ErrCorruptedData
+		}
+
+		var vTx uint64
+		var e *store.EntrySpec
+
+		if be.Entry.ReferencedBy == nil {
+			vTx = be.Entry.Tx
+			e = database.EncodeEntrySpec(keys[i], schema.KVMetadataFromProto(be.Entry.Metadata), be.Entry.Value)
+		} else {
+			ref := be.Entry.ReferencedBy
+			vTx = ref.Tx
+			e = database.EncodeReference(ref.Key, schema.KVMetadataFromProto(ref.Metadata), be.Entry.Key, ref.AtTx)
+		}
+
+		hdr, ok := txHdrs[vTx]
+		if !ok {
+			return nil, store.ErrCorruptedData
+		}
+
+		entrySpecDigest, err := store.EntrySpecDigestFor(hdr.Version, hdr.HashAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+
+		verifies := store.VerifyInclusion(
+			schema.InclusionProofFromProto(be.InclusionProof),
+			entrySpecDigest(e),
+			hdr.Eh,
+			hdr.HashAlgorithm)
+		if !verifies {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	newState := &schema.ImmutableState{
+		Db:        c.currentDatabase(),
+		TxId:      targetHdr.ID,
+		TxHash:    targetAlh[:],
+		Signature: batch.Signature,
+	}
+
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
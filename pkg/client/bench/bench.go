@@ -0,0 +1,379 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bench generates a configurable key-value load through the client and measures
+// the latency of every operation, meant for capacity planning of immudb deployments.
+// It also stress tests the verification of proofs, to validate deployments end-to-end under load.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+// preloadBatchSize is the number of keys set in each transaction when preloading the key space
+const preloadBatchSize = 100
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+
+// Operation is the kind of an operation issued by a benchmark
+type Operation string
+
+const (
+	Write        Operation = "write"
+	Read         Operation = "read"
+	VerifiedRead Operation = "verified read"
+)
+
+// Options of a benchmark
+type Options struct {
+	// Duration is the time the load is generated for
+	Duration time.Duration
+
+	// Concurrency is the number of workers issuing operations concurrently
+	Concurrency int
+
+	// KeySize and ValueSize are the size in bytes of the keys and values being written
+	KeySize   int
+	ValueSize int
+
+	// Keys is the number of distinct keys being written and read
+	Keys int
+
+	// ReadRatio is the fraction of the operations being reads, from 0 to 1
+	ReadRatio float64
+
+	// VerifiedReadRatio is the fraction of the reads being verified reads, from 0 to 1
+	VerifiedReadRatio float64
+
+	// Seed of the random choice of operations and keys
+	Seed int64
+}
+
+// DefaultOptions returns the options of a 10 seconds benchmark of 8 workers issuing
+// as many writes as reads of 256 bytes values over 10000 keys, none of the reads being verified
+func DefaultOptions() *Options {
+	return &Options{
+		Duration:    10 * time.Second,
+		Concurrency: 8,
+		KeySize:     16,
+		ValueSize:   256,
+		Keys:        10000,
+		ReadRatio:   0.5,
+		Seed:        time.Now().UnixNano(),
+	}
+}
+
+func (o *Options) WithDuration(duration time.Duration) *Options {
+	o.Duration = duration
+	return o
+}
+
+func (o *Options) WithConcurrency(concurrency int) *Options {
+	o.Concurrency = concurrency
+	return o
+}
+
+func (o *Options) WithKeySize(keySize int) *Options {
+	o.KeySize = keySize
+	return o
+}
+
+func (o *Options) WithValueSize(valueSize int) *Options {
+	o.ValueSize = valueSize
+	return o
+}
+
+func (o *Options) WithKeys(keys int) *Options {
+	o.Keys = keys
+	return o
+}
+
+func (o *Options) WithReadRatio(readRatio float64) *Options {
+	o.ReadRatio = readRatio
+	return o
+}
+
+func (o *Options) WithVerifiedReadRatio(verifiedReadRatio float64) *Options {
+	o.VerifiedReadRatio = verifiedReadRatio
+	return o
+}
+
+func (o *Options) WithSeed(seed int64) *Options {
+	o.Seed = seed
+	return o
+}
+
+func (o *Options) validate() error {
+	if o.Duration <= 0 ||
+		o.Concurrency <= 0 ||
+		o.ValueSize < 0 ||
+		o.Keys <= 0 ||
+		o.ReadRatio < 0 || o.ReadRatio > 1 ||
+		o.VerifiedReadRatio < 0 || o.VerifiedReadRatio > 1 {
+		return ErrIllegalArguments
+	}
+
+	if o.KeySize < len(fmt.Sprint(o.Keys-1)) {
+		return fmt.Errorf("%w: keys of %d bytes can't hold %d distinct keys", ErrIllegalArguments, o.KeySize, o.Keys)
+	}
+
+	return nil
+}
+
+// Stats of the operations of the same kind issued by a benchmark,
+// latencies only account for the operations that succeeded
+type Stats struct {
+	Operation Operation
+	Count     int
+	Errors    int
+	// Failures is the number of operations whose proofs failed to be verified, they're accounted in Errors as well
+	Failures int
+	// Throughput is the number of operations that succeeded per second
+	Throughput float64
+
+	P50, P90, P99, Max time.Duration
+}
+
+// Report of a benchmark
+type Report struct {
+	Duration time.Duration
+	Stats    []*Stats
+	// Err is the first error returned by an operation, if any
+	Err error
+}
+
+// Run preloads the key space, when reads are to be issued, and then generates the load described by opts
+// for opts.Duration or until ctx is done. Operations failing do not stop the benchmark, they are accounted in the report.
+func Run(ctx context.Context, c client.ImmuClient, opts *Options) (*Report, error) {
+	if c == nil || opts == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ReadRatio > 0 {
+		err = preload(ctx, c, opts.Keys, opts.KeySize, opts.ValueSize, opts.Seed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	results := make([]*workerResult, opts.Concurrency)
+
+	var wg sync.WaitGroup
+
+	start := time.Now()
+
+	for i := 0; i < opts.Concurrency; i++ {
+		w := &worker{
+			c:     c,
+			opts:  opts,
+			rnd:   rand.New(rand.NewSource(opts.Seed + int64(i))),
+			value: make([]byte, opts.ValueSize),
+		}
+		w.rnd.Read(w.value)
+
+		results[i] = newWorkerResult()
+
+		wg.Add(1)
+
+		go func(res *workerResult) {
+			defer wg.Done()
+			w.run(ctx, res)
+		}(results[i])
+	}
+
+	wg.Wait()
+
+	return newReport(results, time.Since(start), []Operation{Write, Read, VerifiedRead}), nil
+}
+
+// newReport merges the results of the workers, ops are reported in the given order
+// skipping the ones that were never issued
+func newReport(results []*workerResult, duration time.Duration, ops []Operation) *Report {
+	report := &Report{Duration: duration}
+
+	for _, op := range ops {
+		var latencies []time.Duration
+		stats := &Stats{Operation: op}
+
+		for _, res := range results {
+			latencies = append(latencies, res.latencies[op]...)
+			stats.Errors += res.errors[op]
+			stats.Failures += res.failures[op]
+
+			if report.Err == nil {
+				report.Err = res.err
+			}
+		}
+
+		stats.Count = len(latencies) + stats.Errors
+
+		if stats.Count == 0 {
+			continue
+		}
+
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		stats.Throughput = float64(len(latencies)) / report.Duration.Seconds()
+		stats.P50 = Percentile(latencies, 50)
+		stats.P90 = Percentile(latencies, 90)
+		stats.P99 = Percentile(latencies, 99)
+		stats.Max = Percentile(latencies, 100)
+
+		report.Stats = append(report.Stats, stats)
+	}
+
+	return report
+}
+
+// Percentile returns the p-th percentile, using the nearest-rank method, of the latencies sorted in ascending order
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(p/100*float64(len(sorted)) + 0.5)
+
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
+
+// keyFor returns the i-th key of the key space, padded to keySize bytes
+func keyFor(i, keySize int) []byte {
+	return []byte(fmt.Sprintf("%0*d", keySize, i))
+}
+
+func preload(ctx context.Context, c client.ImmuClient, keys, keySize, valueSize int, seed int64) error {
+	value := make([]byte, valueSize)
+	rand.New(rand.NewSource(seed)).Read(value)
+
+	for i := 0; i < keys; i += preloadBatchSize {
+		var kvs []*schema.KeyValue
+
+		for j := i; j < i+preloadBatchSize && j < keys; j++ {
+			kvs = append(kvs, &schema.KeyValue{Key: keyFor(j, keySize), Value: value})
+		}
+
+		_, err := c.SetAll(ctx, &schema.SetRequest{KVs: kvs})
+		if err != nil {
+			return fmt.Errorf("preloading keys: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type workerResult struct {
+	latencies map[Operation][]time.Duration
+	errors    map[Operation]int
+	failures  map[Operation]int
+	err       error
+}
+
+func newWorkerResult() *workerResult {
+	return &workerResult{
+		latencies: make(map[Operation][]time.Duration),
+		errors:    make(map[Operation]int),
+		failures:  make(map[Operation]int),
+	}
+}
+
+// fail accounts the error of an operation on subject, telling apart proofs that failed to be verified
+func (res *workerResult) fail(op Operation, subject string, err error) {
+	res.errors[op]++
+
+	if errors.Is(err, store.ErrCorruptedData) {
+		res.failures[op]++
+	}
+
+	if res.err == nil {
+		res.err = fmt.Errorf("%s of %s: %w", op, subject, err)
+	}
+}
+
+type worker struct {
+	c     client.ImmuClient
+	opts  *Options
+	rnd   *rand.Rand
+	value []byte
+}
+
+func (w *worker) run(ctx context.Context, res *workerResult) {
+	for ctx.Err() == nil {
+		op := Write
+
+		if w.rnd.Float64() < w.opts.ReadRatio {
+			op = Read
+
+			if w.rnd.Float64() < w.opts.VerifiedReadRatio {
+				op = VerifiedRead
+			}
+		}
+
+		key := keyFor(w.rnd.Intn(w.opts.Keys), w.opts.KeySize)
+
+		var err error
+
+		start := time.Now()
+
+		switch op {
+		case Write:
+			_, err = w.c.Set(ctx, key, w.value)
+		case Read:
+			_, err = w.c.Get(ctx, key)
+		case VerifiedRead:
+			_, err = w.c.VerifiedGet(ctx, key)
+		}
+
+		elapsed := time.Since(start)
+
+		if err != nil {
+			// operations interrupted by the end of the benchmark are not accounted
+			if ctx.Err() != nil {
+				return
+			}
+
+			res.fail(op, fmt.Sprintf("key %s", key), err)
+
+			continue
+		}
+
+		res.latencies[op] = append(res.latencies[op], elapsed)
+	}
+}
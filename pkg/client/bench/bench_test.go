@@ -0,0 +1,94 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	require.Zero(t, Percentile(nil, 50))
+
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	require.Equal(t, 1*time.Millisecond, Percentile(latencies, 0))
+	require.Equal(t, 50*time.Millisecond, Percentile(latencies, 50))
+	require.Equal(t, 90*time.Millisecond, Percentile(latencies, 90))
+	require.Equal(t, 99*time.Millisecond, Percentile(latencies, 99))
+	require.Equal(t, 100*time.Millisecond, Percentile(latencies, 100))
+}
+
+func TestRun(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	_, err = Run(ctx, nil, DefaultOptions())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Run(ctx, cli, DefaultOptions().WithReadRatio(1.5))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Run(ctx, cli, DefaultOptions().WithKeySize(2).WithKeys(1000))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	opts := DefaultOptions().
+		WithDuration(500 * time.Millisecond).
+		WithConcurrency(4).
+		WithKeys(50).
+		WithValueSize(32).
+		WithReadRatio(0.5).
+		WithVerifiedReadRatio(0.5).
+		WithSeed(1)
+
+	report, err := Run(ctx, cli, opts)
+	require.NoError(t, err)
+	require.NoError(t, report.Err)
+	require.Len(t, report.Stats, 3)
+
+	for i, op := range []Operation{Write, Read, VerifiedRead} {
+		stats := report.Stats[i]
+
+		require.Equal(t, op, stats.Operation)
+		require.Greater(t, stats.Count, 0)
+		require.Zero(t, stats.Errors)
+		require.Greater(t, stats.Throughput, 0.0)
+		require.LessOrEqual(t, stats.P50, stats.P90)
+		require.LessOrEqual(t, stats.P90, stats.P99)
+		require.LessOrEqual(t, stats.P99, stats.Max)
+	}
+
+	// keys were preloaded, so every key can be read
+	entry, err := cli.Get(ctx, keyFor(49, opts.KeySize))
+	require.NoError(t, err)
+	require.Len(t, entry.Value, opts.ValueSize)
+
+	report, err = Run(ctx, cli, opts.WithReadRatio(0))
+	require.NoError(t, err)
+	require.Len(t, report.Stats, 1)
+	require.Equal(t, Write, report.Stats[0].Operation)
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+// VerifiedTx is the kind of the verified reads of transactions issued by verification stress tests
+const VerifiedTx Operation = "verified tx"
+
+// VerificationOptions of a verification stress test
+type VerificationOptions struct {
+	// Duration is the time proofs are requested and verified for
+	Duration time.Duration
+
+	// Concurrency is the number of workers requesting proofs concurrently
+	Concurrency int
+
+	// KeySize and ValueSize are the size in bytes of the keys and values written before the stress test
+	KeySize   int
+	ValueSize int
+
+	// Keys is the number of distinct keys written before the stress test and then read
+	Keys int
+
+	// TxRatio is the fraction of the operations being verified reads of transactions, from 0 to 1,
+	// the other ones being verified reads of keys
+	TxRatio float64
+
+	// Seed of the random choice of operations, keys and transactions
+	Seed int64
+}
+
+// DefaultVerificationOptions returns the options of a 10 seconds stress test of 8 workers issuing
+// as many verified reads of transactions as of keys, over 1000 keys of 256 bytes values
+func DefaultVerificationOptions() *VerificationOptions {
+	return &VerificationOptions{
+		Duration:    10 * time.Second,
+		Concurrency: 8,
+		KeySize:     16,
+		ValueSize:   256,
+		Keys:        1000,
+		TxRatio:     0.5,
+		Seed:        time.Now().UnixNano(),
+	}
+}
+
+func (o *VerificationOptions) WithDuration(duration time.Duration) *VerificationOptions {
+	o.Duration = duration
+	return o
+}
+
+func (o *VerificationOptions) WithConcurrency(concurrency int) *VerificationOptions {
+	o.Concurrency = concurrency
+	return o
+}
+
+func (o *VerificationOptions) WithKeySize(keySize int) *VerificationOptions {
+	o.KeySize = keySize
+	return o
+}
+
+func (o *VerificationOptions) WithValueSize(valueSize int) *VerificationOptions {
+	o.ValueSize = valueSize
+	return o
+}
+
+func (o *VerificationOptions) WithKeys(keys int) *VerificationOptions {
+	o.Keys = keys
+	return o
+}
+
+func (o *VerificationOptions) WithTxRatio(txRatio float64) *VerificationOptions {
+	o.TxRatio = txRatio
+	return o
+}
+
+func (o *VerificationOptions) WithSeed(seed int64) *VerificationOptions {
+	o.Seed = seed
+	return o
+}
+
+func (o *VerificationOptions) validate() error {
+	if o.Duration <= 0 ||
+		o.Concurrency <= 0 ||
+		o.ValueSize < 0 ||
+		o.Keys <= 0 ||
+		o.TxRatio < 0 || o.TxRatio > 1 {
+		return ErrIllegalArguments
+	}
+
+	if o.KeySize < len(fmt.Sprint(o.Keys-1)) {
+		return fmt.Errorf("%w: keys of %d bytes can't hold %d distinct keys", ErrIllegalArguments, o.KeySize, o.Keys)
+	}
+
+	return nil
+}
+
+// Verify writes the keys described by opts and then has concurrent workers read and verify keys and transactions
+// for opts.Duration or until ctx is done, transactions being picked among all the ones of the database.
+// Proofs failing to be verified do not stop the stress test, they are accounted in the failures of the report.
+func Verify(ctx context.Context, c client.ImmuClient, opts *VerificationOptions) (*Report, error) {
+	if c == nil || opts == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = preload(ctx, c, opts.Keys, opts.KeySize, opts.ValueSize, opts.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	results := make([]*workerResult, opts.Concurrency)
+
+	var wg sync.WaitGroup
+
+	start := time.Now()
+
+	for i := 0; i < opts.Concurrency; i++ {
+		v := &verifier{
+			c:      c,
+			opts:   opts,
+			rnd:    rand.New(rand.NewSource(opts.Seed + int64(i))),
+			lastTx: state.TxId,
+		}
+
+		results[i] = newWorkerResult()
+
+		wg.Add(1)
+
+		go func(res *workerResult) {
+			defer wg.Done()
+			v.run(ctx, res)
+		}(results[i])
+	}
+
+	wg.Wait()
+
+	return newReport(results, time.Since(start), []Operation{VerifiedRead, VerifiedTx}), nil
+}
+
+type verifier struct {
+	c      client.ImmuClient
+	opts   *VerificationOptions
+	rnd    *rand.Rand
+	lastTx uint64
+}
+
+func (v *verifier) run(ctx context.Context, res *workerResult) {
+	for ctx.Err() == nil {
+		var op Operation
+		var subject string
+		var err error
+
+		start := time.Now()
+
+		if v.rnd.Float64() < v.opts.TxRatio {
+			op = VerifiedTx
+			tx := 1 + uint64(v.rnd.Int63n(int64(v.lastTx)))
+			subject = fmt.Sprintf("tx %d", tx)
+
+			_, err = v.c.VerifiedTxByID(ctx, tx)
+		} else {
+			op = VerifiedRead
+			key := keyFor(v.rnd.Intn(v.opts.Keys), v.opts.KeySize)
+			subject = fmt.Sprintf("key %s", key)
+
+			_, err = v.c.VerifiedGet(ctx, key)
+		}
+
+		elapsed := time.Since(start)
+
+		if err != nil {
+			// operations interrupted by the end of the stress test are not accounted
+			if ctx.Err() != nil {
+				return
+			}
+
+			res.fail(op, subject, err)
+
+			continue
+		}
+
+		res.latencies[op] = append(res.latencies[op], elapsed)
+	}
+}
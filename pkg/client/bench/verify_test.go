@@ -0,0 +1,91 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+type corruptedTxClient struct {
+	client.ImmuClient
+}
+
+func (c *corruptedTxClient) VerifiedTxByID(ctx context.Context, tx uint64) (*schema.Tx, error) {
+	return nil, store.ErrCorruptedData
+}
+
+func TestVerify(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	_, err = Verify(ctx, nil, DefaultVerificationOptions())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Verify(ctx, cli, DefaultVerificationOptions().WithTxRatio(-1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Verify(ctx, cli, DefaultVerificationOptions().WithKeySize(2).WithKeys(1000))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	opts := DefaultVerificationOptions().
+		WithDuration(500 * time.Millisecond).
+		WithConcurrency(4).
+		WithKeys(250).
+		WithValueSize(32).
+		WithTxRatio(0.5).
+		WithSeed(1)
+
+	report, err := Verify(ctx, cli, opts)
+	require.NoError(t, err)
+	require.NoError(t, report.Err)
+	require.Len(t, report.Stats, 2)
+
+	for i, op := range []Operation{VerifiedRead, VerifiedTx} {
+		stats := report.Stats[i]
+
+		require.Equal(t, op, stats.Operation)
+		require.Greater(t, stats.Count, 0)
+		require.Zero(t, stats.Errors)
+		require.Zero(t, stats.Failures)
+		require.Greater(t, stats.Throughput, 0.0)
+		require.LessOrEqual(t, stats.P50, stats.Max)
+	}
+
+	t.Run("proofs failing to be verified are accounted", func(t *testing.T) {
+		report, err := Verify(ctx, &corruptedTxClient{cli}, opts.WithTxRatio(1))
+		require.NoError(t, err)
+		require.ErrorIs(t, report.Err, store.ErrCorruptedData)
+		require.Len(t, report.Stats, 1)
+
+		stats := report.Stats[0]
+		require.Equal(t, VerifiedTx, stats.Operation)
+		require.Greater(t, stats.Failures, 0)
+		require.Equal(t, stats.Count, stats.Errors)
+		require.Equal(t, stats.Errors, stats.Failures)
+	})
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Checkpointer persists the id of the last transaction fully processed by a consumer
+type Checkpointer interface {
+	// Load returns the id of the last processed transaction or zero if none was processed yet
+	Load() (uint64, error)
+	// Store records txID as the last processed transaction
+	Store(txID uint64) error
+}
+
+type fileCheckpointer struct {
+	path string
+}
+
+// NewFileCheckpointer returns a Checkpointer persisting the checkpoint into the given file.
+// The file is atomically replaced on every update.
+func NewFileCheckpointer(path string) Checkpointer {
+	return &fileCheckpointer{path: path}
+}
+
+func (cp *fileCheckpointer) Load() (uint64, error) {
+	b, err := ioutil.ReadFile(cp.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if len(b) != 8 {
+		return 0, ErrCorruptedCheckpoint
+	}
+
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (cp *fileCheckpointer) Store(txID uint64) error {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], txID)
+
+	f, err := ioutil.TempFile(filepath.Dir(cp.path), filepath.Base(cp.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b[:])
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), cp.path)
+}
+
+type inMemoryCheckpointer struct {
+	txID  uint64
+	mutex sync.Mutex
+}
+
+// NewInMemoryCheckpointer returns a Checkpointer which does not survive restarts
+func NewInMemoryCheckpointer() Checkpointer {
+	return &inMemoryCheckpointer{}
+}
+
+func (cp *inMemoryCheckpointer) Load() (uint64, error) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	return cp.txID, nil
+}
+
+func (cp *inMemoryCheckpointer) Store(txID uint64) error {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	cp.txID = txID
+
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cdc provides a consumer of the transaction feed of a database
+package cdc
+
+import (
+	"context"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// Handler is invoked once per key-value entry, in commit order.
+// Returning an error stops the consumer before the transaction of the entry is checkpointed.
+type Handler func(ctx context.Context, entry *schema.Entry) error
+
+// Consumer follows the transactions committed into the current database of a client,
+// invoking a handler for each key-value entry and checkpointing every fully processed transaction.
+// After a restart, processing resumes from the transaction following the last checkpoint; entries
+// of a transaction interrupted in the middle of its processing are delivered again.
+type Consumer struct {
+	c       client.ImmuClient
+	cp      Checkpointer
+	handler Handler
+	opts    *Options
+}
+
+// NewConsumer creates a consumer for the current database of the given client
+func NewConsumer(c client.ImmuClient, cp Checkpointer, handler Handler, opts *Options) (*Consumer, error) {
+	if c == nil || cp == nil || handler == nil || opts == nil || opts.BatchSize == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	return &Consumer{
+		c:       c,
+		cp:      cp,
+		handler: handler,
+		opts:    opts,
+	}, nil
+}
+
+// Run processes the feed until the context is cancelled or an error occurs
+func (cs *Consumer) Run(ctx context.Context) error {
+	lastTxID, err := cs.cp.Load()
+	if err != nil {
+		return err
+	}
+
+	for {
+		txs, err := cs.c.TxScan(ctx, &schema.TxScanRequest{
+			InitialTx: lastTxID + 1,
+			Limit:     cs.opts.BatchSize,
+		})
+		if err != nil && ctx.Err() != nil {
+			// requests interrupted by the context fail with gRPC errors
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, tx := range txs.Txs {
+			err = cs.processTx(ctx, tx)
+			if err != nil && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				return err
+			}
+
+			lastTxID = tx.Header.Id
+
+			err = cs.cp.Store(lastTxID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(txs.Txs) == int(cs.opts.BatchSize) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cs.opts.PollInterval):
+		}
+	}
+}
+
+func (cs *Consumer) processTx(ctx context.Context, tx *schema.Tx) error {
+	for _, e := range tx.Entries {
+		if len(e.Key) == 0 || e.Key[0] != database.SetKeyPrefix {
+			// sql rows, sorted sets and internal entries are not delivered
+			continue
+		}
+
+		key := database.TrimPrefix(e.Key)
+
+		var entry *schema.Entry
+
+		if e.Metadata != nil && e.Metadata.Deleted {
+			entry = &schema.Entry{
+				Tx:       tx.Header.Id,
+				Key:      key,
+				Metadata: e.Metadata,
+			}
+		} else {
+			var err error
+
+			entry, err = cs.c.GetAt(ctx, key, tx.Header.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := cs.handler(ctx, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFileCheckpointer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cdc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cp := NewFileCheckpointer(filepath.Join(dir, "checkpoint"))
+
+	txID, err := cp.Load()
+	require.NoError(t, err)
+	require.Zero(t, txID)
+
+	err = cp.Store(10)
+	require.NoError(t, err)
+
+	txID, err = NewFileCheckpointer(filepath.Join(dir, "checkpoint")).Load()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), txID)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "checkpoint"), []byte{1}, 0644)
+	require.NoError(t, err)
+
+	_, err = cp.Load()
+	require.ErrorIs(t, err, ErrCorruptedCheckpoint)
+
+	err = NewFileCheckpointer(filepath.Join(dir, "missing", "checkpoint")).Store(1)
+	require.Error(t, err)
+}
+
+func TestConsumer(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	_, err = NewConsumer(cli, NewInMemoryCheckpointer(), nil, DefaultOptions())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for i := 0; i < 5; i++ {
+		_, err = cli.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, err = cli.SQLExec(ctx, "CREATE TABLE t1(id INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, err = cli.Delete(ctx, &schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key0")}})
+	require.NoError(t, err)
+
+	cp := NewInMemoryCheckpointer()
+
+	var received []*schema.Entry
+
+	errStop := errors.New("stop")
+
+	handler := func(ctx context.Context, entry *schema.Entry) error {
+		if len(received) == 3 {
+			return errStop
+		}
+		received = append(received, entry)
+		return nil
+	}
+
+	opts := DefaultOptions().WithBatchSize(2).WithPollInterval(10 * time.Millisecond)
+
+	cs, err := NewConsumer(cli, cp, handler, opts)
+	require.NoError(t, err)
+
+	err = cs.Run(ctx)
+	require.ErrorIs(t, err, errStop)
+	require.Len(t, received, 3)
+
+	lastTxID, err := cp.Load()
+	require.NoError(t, err)
+	require.Equal(t, received[2].Tx, lastTxID)
+
+	received = nil
+
+	handler = func(ctx context.Context, entry *schema.Entry) error {
+		received = append(received, entry)
+		return nil
+	}
+
+	cs, err = NewConsumer(cli, cp, handler, opts)
+	require.NoError(t, err)
+
+	cctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	err = cs.Run(cctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.Len(t, received, 3)
+	require.Equal(t, []byte("key3"), received[0].Key)
+	require.Equal(t, []byte("value3"), received[0].Value)
+	require.Equal(t, []byte("key4"), received[1].Key)
+	require.Equal(t, []byte("key0"), received[2].Key)
+	require.True(t, received[2].Metadata.Deleted)
+}
+
+func TestConsumerInterrupted(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	_, err = cli.Set(context.Background(), []byte("key0"), []byte("value0"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handler := func(ctx context.Context, entry *schema.Entry) error {
+		cancel()
+		// requests interrupted by the context fail with gRPC errors
+		return status.Error(codes.Canceled, "context canceled")
+	}
+
+	cs, err := NewConsumer(cli, NewInMemoryCheckpointer(), handler, DefaultOptions())
+	require.NoError(t, err)
+
+	err = cs.Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdc
+
+import "time"
+
+const DefaultPollInterval = 1 * time.Second
+const DefaultBatchSize = 100
+
+// Options to configure a Consumer
+type Options struct {
+	// PollInterval is the time to wait before checking again for new transactions once the feed was consumed
+	PollInterval time.Duration
+	// BatchSize is the maximum number of transactions fetched per request
+	BatchSize uint32
+}
+
+// DefaultOptions returns the default consumer options
+func DefaultOptions() *Options {
+	return &Options{
+		PollInterval: DefaultPollInterval,
+		BatchSize:    DefaultBatchSize,
+	}
+}
+
+// WithPollInterval sets the interval between checks for new transactions
+func (o *Options) WithPollInterval(pollInterval time.Duration) *Options {
+	o.PollInterval = pollInterval
+	return o
+}
+
+// WithBatchSize sets the maximum number of transactions fetched per request
+func (o *Options) WithBatchSize(batchSize uint32) *Options {
+	o.BatchSize = batchSize
+	return o
+}
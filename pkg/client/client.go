@@ -38,15 +38,18 @@ import (
 	"github.com/codenotary/immudb/pkg/signer"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/client/cache"
 	"github.com/codenotary/immudb/pkg/client/state"
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/proofs"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/grpclog"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"google.golang.org/grpc"
@@ -98,6 +101,27 @@ type ImmuClient interface {
 
 	DatabaseList(ctx context.Context) (*schema.DatabaseListResponse, error)
 	CreateDatabase(ctx context.Context, d *schema.DatabaseSettings) error
+	CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) error
+	SelectiveRestore(ctx context.Context, req *schema.SelectiveRestoreRequest) (*schema.SelectiveRestoreResponse, error)
+	SetBackupSchedule(ctx context.Context, sch *schema.BackupSchedule) error
+	DeleteBackupSchedule(ctx context.Context, db *schema.Database) error
+	ListBackupSchedules(ctx context.Context) (*schema.BackupScheduleList, error)
+	SetVerificationSchedule(ctx context.Context, sch *schema.VerificationSchedule) error
+	DeleteVerificationSchedule(ctx context.Context, db *schema.Database) error
+	ListVerificationSchedules(ctx context.Context) (*schema.VerificationScheduleList, error)
+	ListVerificationRuns(ctx context.Context, db *schema.Database) (*schema.VerificationRunList, error)
+	ListSessions(ctx context.Context, req *schema.ListSessionsRequest) (*schema.SessionList, error)
+	KillSession(ctx context.Context, req *schema.KillSessionRequest) (*schema.KillSessionResponse, error)
+	GetRuntimeConfig(ctx context.Context, key string) (*schema.RuntimeConfig, error)
+	SetRuntimeConfig(ctx context.Context, key, value string) (*schema.RuntimeSetting, error)
+	ReplicationStatus(ctx context.Context) (*schema.ReplicationStatusList, error)
+	DatabaseUsage(ctx context.Context, db string) (*schema.DatabaseUsageList, error)
+	SetRetentionPolicy(ctx context.Context, policy *schema.RetentionPolicy) error
+	DeleteRetentionPolicy(ctx context.Context, db *schema.Database) error
+	ListRetentionPolicies(ctx context.Context) (*schema.RetentionPolicyList, error)
+	ListRetentionEnforcements(ctx context.Context, db *schema.Database) (*schema.RetentionEnforcementList, error)
+	GenerateAuditReport(ctx context.Context, req *schema.AuditReportRequest) (*schema.AuditReport, error)
+	ConfigHistory(ctx context.Context, req *schema.ConfigHistoryRequest) (*schema.ConfigChangeList, error)
 	UseDatabase(ctx context.Context, d *schema.Database) (*schema.UseDatabaseReply, error)
 	UpdateDatabase(ctx context.Context, settings *schema.DatabaseSettings) error
 	GetDatabaseSettings(ctx context.Context) (*schema.DatabaseSettings, error)
@@ -105,6 +129,12 @@ type ImmuClient interface {
 	SetActiveUser(ctx context.Context, u *schema.SetActiveUserRequest) error
 
 	CompactIndex(ctx context.Context, req *empty.Empty) error
+	RebuildIndex(ctx context.Context, progress func(*schema.IndexRebuildProgress)) error
+	CompactDatabase(ctx context.Context, req *schema.CompactDatabaseRequest, progress func(*schema.CompactionProgress)) error
+	CollectGarbage(ctx context.Context, req *schema.CollectGarbageRequest) (*schema.CollectGarbageResponse, error)
+	Backup(ctx context.Context, req *schema.BackupRequest, w io.Writer) error
+	Export(ctx context.Context, w io.Writer) error
+	Import(ctx context.Context, databaseName string, r io.Reader) (*schema.ImmutableState, error)
 
 	CurrentState(ctx context.Context) (*schema.ImmutableState, error)
 
@@ -123,6 +153,16 @@ type ImmuClient interface {
 
 	History(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error)
 
+	SetJSON(ctx context.Context, key []byte, v interface{}) (*schema.TxHeader, error)
+	VerifiedSetJSON(ctx context.Context, key []byte, v interface{}) (*schema.TxHeader, error)
+	GetJSON(ctx context.Context, key []byte, v interface{}) (*schema.Entry, error)
+	VerifiedGetJSON(ctx context.Context, key []byte, v interface{}) (*schema.Entry, error)
+
+	SetProto(ctx context.Context, key []byte, m proto.Message) (*schema.TxHeader, error)
+	VerifiedSetProto(ctx context.Context, key []byte, m proto.Message) (*schema.TxHeader, error)
+	GetProto(ctx context.Context, key []byte, m proto.Message) (*schema.Entry, error)
+	VerifiedGetProto(ctx context.Context, key []byte, m proto.Message) (*schema.Entry, error)
+
 	ZAdd(ctx context.Context, set []byte, score float64, key []byte) (*schema.TxHeader, error)
 	VerifiedZAdd(ctx context.Context, set []byte, score float64, key []byte) (*schema.TxHeader, error)
 
@@ -135,6 +175,30 @@ type ImmuClient interface {
 	TxByID(ctx context.Context, tx uint64) (*schema.Tx, error)
 	VerifiedTxByID(ctx context.Context, tx uint64) (*schema.Tx, error)
 	TxScan(ctx context.Context, req *schema.TxScanRequest) (*schema.TxList, error)
+	TxByTime(ctx context.Context, req *schema.TxByTimeRequest) (*schema.Tx, error)
+	ExportTxHeaders(ctx context.Context, req *schema.TxHeadersRequest) (*schema.TxHeaders, error)
+	ConsistencyProof(ctx context.Context, sourceTx, targetTx uint64) (*schema.DualProof, error)
+
+	Notarize(ctx context.Context, digest []byte, hashAlgorithm string, metadata map[string]string) (*schema.Notarization, error)
+	VerifyNotarization(ctx context.Context, digest []byte) (*schema.Notarization, error)
+
+	Erase(ctx context.Context, key []byte, atTx uint64, reason string) (*schema.Erasure, error)
+	ErasureHistory(ctx context.Context, key []byte) (*schema.ErasureHistory, error)
+
+	PlaceLegalHold(ctx context.Context, req *schema.LegalHoldRequest) (*schema.LegalHold, error)
+	LiftLegalHold(ctx context.Context, req *schema.LegalHoldRequest) error
+	ListLegalHolds(ctx context.Context) (*schema.LegalHoldList, error)
+
+	VerifiedExclusion(ctx context.Context, key []byte, atTx uint64) (*schema.ExclusionProof, error)
+
+	VerifiedBatch(ctx context.Context, keys [][]byte, txs []uint64) (*schema.VerifiableBatch, error)
+	VerifiedSampledAudit(ctx context.Context, seed []byte, fromTx, toTx uint64, sampleSize int) (*schema.SampledAudit, error)
+
+	ExportProofBundle(ctx context.Context, keys [][]byte, sinceTx uint64) (*proofs.Bundle, error)
+
+	VerifiedGlobalState(ctx context.Context, database string) (*schema.GlobalState, error)
+
+	VerifiedCosignedState(ctx context.Context, txID uint64) (*schema.CosignedState, error)
 
 	Count(ctx context.Context, prefix []byte) (*schema.EntryCount, error)
 	CountAll(ctx context.Context) (*schema.EntryCount, error)
@@ -324,7 +388,7 @@ func (c *immuClient) SetupDialOptions(options *Options) []grpc.DialOption {
 	}
 	var uic []grpc.UnaryClientInterceptor
 
-	if c.serverSigningPubKey != nil {
+	if c.stateSignatureVerificationEnabled() {
 		uic = append(uic, c.SignatureVerifierInterceptor)
 	}
 	uic = append(uic, c.IllegalStateHandlerInterceptor)
@@ -546,7 +610,22 @@ func (c *immuClient) CurrentState(ctx context.Context) (*schema.ImmutableState,
 	start := time.Now()
 	defer c.Logger.Debugf("Current state finished in %s", time.Since(start))
 
-	return c.ServiceClient.CurrentState(ctx, &empty.Empty{})
+	state, err := c.ServiceClient.CurrentState(ctx, &empty.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.verifyStateSignature(state)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.StateService.CheckState(c.Options.CurrentDatabase, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
 }
 
 // Get ...
@@ -616,13 +695,18 @@ func (c *immuClient) verifiedGet(ctx context.Context, kReq *schema.KeyRequest) (
 		return nil, err
 	}
 
-	entrySpecDigest, err := store.EntrySpecDigestFor(int(vEntry.VerifiableTx.Tx.Header.Version))
+	hashAlg := hashing.Algorithm(vEntry.VerifiableTx.Tx.Header.HashAlgorithm)
+
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(vEntry.VerifiableTx.Tx.Header.Version), hashAlg)
 	if err != nil {
 		return nil, err
 	}
 
 	inclusionProof := schema.InclusionProofFromProto(vEntry.InclusionProof)
-	dualProof := schema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+	dualProof, err := schema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+	if err != nil {
+		return nil, err
+	}
 
 	var eh [sha256.Size]byte
 
@@ -660,7 +744,8 @@ func (c *immuClient) verifiedGet(ctx context.Context, kReq *schema.KeyRequest) (
 	verifies := store.VerifyInclusion(
 		inclusionProof,
 		entrySpecDigest(e),
-		eh)
+		eh,
+		hashAlg)
 	if !verifies {
 		return nil, store.ErrCorruptedData
 	}
@@ -685,14 +770,9 @@ func (c *immuClient) verifiedGet(ctx context.Context, kReq *schema.KeyRequest) (
 		Signature: vEntry.VerifiableTx.Signature,
 	}
 
-	if c.serverSigningPubKey != nil {
-		ok, err := newState.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			return nil, store.ErrCorruptedData
-		}
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
 	}
 
 	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
@@ -823,9 +903,12 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 		return nil, store.ErrCorruptedData
 	}
 
-	tx := schema.TxFromProto(verifiableTx.Tx)
+	tx, err := schema.TxFromProto(verifiableTx.Tx)
+	if err != nil {
+		return nil, err
+	}
 
-	entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version)
+	entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version, tx.Header().HashAlgorithm)
 	if err != nil {
 		return nil, err
 	}
@@ -843,7 +926,7 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 
 	e := database.EncodeEntrySpec(key, md, value)
 
-	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh)
+	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh, tx.Header().HashAlgorithm)
 	if !verifies {
 		return nil, store.ErrCorruptedData
 	}
@@ -861,8 +944,13 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 	targetAlh = tx.Header().Alh()
 
 	if state.TxId > 0 {
+		dualProof, err := schema.DualProofFromProto(verifiableTx.DualProof)
+		if err != nil {
+			return nil, err
+		}
+
 		verifies = store.VerifyDualProof(
-			schema.DualProofFromProto(verifiableTx.DualProof),
+			dualProof,
 			sourceID,
 			targetID,
 			sourceAlh,
@@ -881,14 +969,9 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 		Signature: verifiableTx.Signature,
 	}
 
-	if c.serverSigningPubKey != nil {
-		ok, err := newState.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			return nil, store.ErrCorruptedData
-		}
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
 	}
 
 	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
@@ -913,7 +996,13 @@ func (c *immuClient) SetAll(ctx context.Context, req *schema.SetRequest) (*schem
 		return nil, err
 	}
 
-	if int(txmd.Nentries) != len(req.KVs) {
+	expectedEntries := len(req.KVs)
+	if len(req.IdempotencyKey) > 0 {
+		// the idempotency key is recorded as an additional entry
+		expectedEntries++
+	}
+
+	if int(txmd.Nentries) != expectedEntries {
 		return nil, store.ErrCorruptedData
 	}
 
@@ -927,7 +1016,13 @@ func (c *immuClient) ExecAll(ctx context.Context, req *schema.ExecAllRequest) (*
 		return nil, err
 	}
 
-	if int(txhdr.Nentries) != len(req.Operations) {
+	expectedEntries := len(req.Operations)
+	if len(req.IdempotencyKey) > 0 {
+		// the idempotency key is recorded as an additional entry
+		expectedEntries++
+	}
+
+	if int(txhdr.Nentries) != expectedEntries {
 		return nil, store.ErrCorruptedData
 	}
 
@@ -1008,7 +1103,10 @@ func (c *immuClient) VerifiedTxByID(ctx context.Context, tx uint64) (*schema.Tx,
 		return nil, err
 	}
 
-	dualProof := schema.DualProofFromProto(vTx.DualProof)
+	dualProof, err := schema.DualProofFromProto(vTx.DualProof)
+	if err != nil {
+		return nil, err
+	}
 
 	var sourceID, targetID uint64
 	var sourceAlh, targetAlh [sha256.Size]byte
@@ -1045,14 +1143,9 @@ func (c *immuClient) VerifiedTxByID(ctx context.Context, tx uint64) (*schema.Tx,
 		Signature: vTx.Signature,
 	}
 
-	if c.serverSigningPubKey != nil {
-		ok, err := newState.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			return nil, store.ErrCorruptedData
-		}
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
 	}
 
 	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
@@ -1074,6 +1167,90 @@ func (c *immuClient) TxScan(ctx context.Context, req *schema.TxScanRequest) (*sc
 	return c.ServiceClient.TxScan(ctx, req)
 }
 
+// TxByTime ...
+func (c *immuClient) TxByTime(ctx context.Context, req *schema.TxByTimeRequest) (*schema.Tx, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.TxByTime(ctx, req)
+}
+
+// ExportTxHeaders ...
+func (c *immuClient) ExportTxHeaders(ctx context.Context, req *schema.TxHeadersRequest) (*schema.TxHeaders, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ExportTxHeaders(ctx, req)
+}
+
+// ConsistencyProof returns a dual proof between two transactions, it can be verified with store.VerifyDualProof
+// against the states recorded at both transactions
+func (c *immuClient) ConsistencyProof(ctx context.Context, sourceTx, targetTx uint64) (*schema.DualProof, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ConsistencyProof(ctx, &schema.ConsistencyProofRequest{
+		SourceTx: sourceTx,
+		TargetTx: targetTx,
+	})
+}
+
+// Erase overwrites the value of key set at atTx, or its current value when atTx is zero.
+// The digest of the value is kept so proofs still verify, reading the value afterwards
+// returns an entry marked as erased.
+func (c *immuClient) Erase(ctx context.Context, key []byte, atTx uint64, reason string) (*schema.Erasure, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.Erase(ctx, &schema.EraseRequest{
+		Key:    key,
+		AtTx:   atTx,
+		Reason: reason,
+	})
+}
+
+// ErasureHistory returns the recorded erasures of the values of key
+func (c *immuClient) ErasureHistory(ctx context.Context, key []byte) (*schema.ErasureHistory, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ErasureHistory(ctx, &schema.ErasureHistoryRequest{Key: key})
+}
+
+// PlaceLegalHold places a legal hold on either a key or a table, values of a held key can not be erased
+// and rows of a held table can not be deleted until the hold is lifted
+func (c *immuClient) PlaceLegalHold(ctx context.Context, req *schema.LegalHoldRequest) (*schema.LegalHold, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.PlaceLegalHold(ctx, req)
+}
+
+// LiftLegalHold lifts the legal hold placed on either a key or a table
+func (c *immuClient) LiftLegalHold(ctx context.Context, req *schema.LegalHoldRequest) error {
+	if !c.IsConnected() {
+		return errors.FromError(ErrNotConnected)
+	}
+
+	_, err := c.ServiceClient.LiftLegalHold(ctx, req)
+	return err
+}
+
+// ListLegalHolds returns the active legal holds of the selected database
+func (c *immuClient) ListLegalHolds(ctx context.Context) (*schema.LegalHoldList, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ListLegalHolds(ctx, &empty.Empty{})
+}
+
 // History ...
 func (c *immuClient) History(ctx context.Context, req *schema.HistoryRequest) (sl *schema.Entries, err error) {
 	if !c.IsConnected() {
@@ -1166,9 +1343,12 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 		return nil, store.ErrCorruptedData
 	}
 
-	tx := schema.TxFromProto(verifiableTx.Tx)
+	tx, err := schema.TxFromProto(verifiableTx.Tx)
+	if err != nil {
+		return nil, err
+	}
 
-	entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version)
+	entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version, tx.Header().HashAlgorithm)
 	if err != nil {
 		return nil, err
 	}
@@ -1180,7 +1360,7 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 
 	e := database.EncodeReference(key, nil, referencedKey, atTx)
 
-	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh)
+	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh, tx.Header().HashAlgorithm)
 	if !verifies {
 		return nil, store.ErrCorruptedData
 	}
@@ -1198,8 +1378,13 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 	targetAlh = tx.Header().Alh()
 
 	if state.TxId > 0 {
+		dualProof, err := schema.DualProofFromProto(verifiableTx.DualProof)
+		if err != nil {
+			return nil, err
+		}
+
 		verifies = store.VerifyDualProof(
-			schema.DualProofFromProto(verifiableTx.DualProof),
+			dualProof,
 			sourceID,
 			targetID,
 			sourceAlh,
@@ -1217,14 +1402,9 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 		Signature: verifiableTx.Signature,
 	}
 
-	if c.serverSigningPubKey != nil {
-		ok, err := newState.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			return nil, store.ErrCorruptedData
-		}
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
 	}
 
 	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
@@ -1317,9 +1497,12 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 		return nil, store.ErrCorruptedData
 	}
 
-	tx := schema.TxFromProto(vtx.Tx)
+	tx, err := schema.TxFromProto(vtx.Tx)
+	if err != nil {
+		return nil, err
+	}
 
-	entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version)
+	entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version, tx.Header().HashAlgorithm)
 	if err != nil {
 		return nil, err
 	}
@@ -1335,7 +1518,7 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 		return nil, err
 	}
 
-	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(ekv), tx.Header().Eh)
+	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(ekv), tx.Header().Eh, tx.Header().HashAlgorithm)
 	if !verifies {
 		return nil, store.ErrCorruptedData
 	}
@@ -1353,8 +1536,13 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 	targetAlh = tx.Header().Alh()
 
 	if state.TxId > 0 {
+		dualProof, err := schema.DualProofFromProto(vtx.DualProof)
+		if err != nil {
+			return nil, err
+		}
+
 		verifies = store.VerifyDualProof(
-			schema.DualProofFromProto(vtx.DualProof),
+			dualProof,
 			sourceID,
 			targetID,
 			sourceAlh,
@@ -1372,14 +1560,9 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 		Signature: vtx.Signature,
 	}
 
-	if c.serverSigningPubKey != nil {
-		ok, err := newState.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			return nil, store.ErrCorruptedData
-		}
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
 	}
 
 	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
@@ -1390,9 +1573,30 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 	return vtx.Tx.Header, nil
 }
 
-// Dump to be used from Immu CLI
+// Dump writes into writer an export of the selected database and returns the number of bytes written,
+// the dump can be loaded into another database with Import. See Export
 func (c *immuClient) Dump(ctx context.Context, writer io.WriteSeeker) (int64, error) {
-	return 0, errors.New("Functionality not yet supported")
+	if writer == nil {
+		return 0, errors.FromError(ErrIllegalArguments)
+	}
+
+	w := &countingWriter{w: writer}
+
+	err := c.Export(ctx, w)
+
+	return w.n, err
+}
+
+// countingWriter keeps track of the number of bytes written into w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
 }
 
 func (c *immuClient) HealthCheck(ctx context.Context) error {
@@ -1438,6 +1642,308 @@ func (c *immuClient) CreateDatabase(ctx context.Context, settings *schema.Databa
 	return err
 }
 
+// CloneDatabase creates a new database as a copy of an existing one, up to the transaction req.AtTx when it's set
+func (c *immuClient) CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	_, err := c.ServiceClient.CloneDatabase(ctx, req)
+
+	c.Logger.Debugf("CloneDatabase finished in %s", time.Since(start))
+
+	return err
+}
+
+// SelectiveRestore restores only the given key prefixes and SQL tables from backup files residing on the server
+// into a new or existing database
+func (c *immuClient) SelectiveRestore(ctx context.Context, req *schema.SelectiveRestoreRequest) (*schema.SelectiveRestoreResponse, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.SelectiveRestore(ctx, req)
+
+	c.Logger.Debugf("SelectiveRestore finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// SetBackupSchedule makes the server take backups of a database at the times set by a cron expression
+func (c *immuClient) SetBackupSchedule(ctx context.Context, sch *schema.BackupSchedule) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	_, err := c.ServiceClient.SetBackupSchedule(ctx, sch)
+
+	c.Logger.Debugf("SetBackupSchedule finished in %s", time.Since(start))
+
+	return err
+}
+
+// DeleteBackupSchedule stops the server from taking scheduled backups of a database
+func (c *immuClient) DeleteBackupSchedule(ctx context.Context, db *schema.Database) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	_, err := c.ServiceClient.DeleteBackupSchedule(ctx, db)
+
+	c.Logger.Debugf("DeleteBackupSchedule finished in %s", time.Since(start))
+
+	return err
+}
+
+// ListBackupSchedules returns the backups scheduled on the server along with the outcome of their last run
+func (c *immuClient) ListBackupSchedules(ctx context.Context) (*schema.BackupScheduleList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.ListBackupSchedules(ctx, &empty.Empty{})
+
+	c.Logger.Debugf("ListBackupSchedules finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// SetVerificationSchedule makes the server verify a database at the times set by a cron expression
+func (c *immuClient) SetVerificationSchedule(ctx context.Context, sch *schema.VerificationSchedule) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	_, err := c.ServiceClient.SetVerificationSchedule(ctx, sch)
+
+	c.Logger.Debugf("SetVerificationSchedule finished in %s", time.Since(start))
+
+	return err
+}
+
+// DeleteVerificationSchedule stops the server from verifying a database on a schedule
+func (c *immuClient) DeleteVerificationSchedule(ctx context.Context, db *schema.Database) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	_, err := c.ServiceClient.DeleteVerificationSchedule(ctx, db)
+
+	c.Logger.Debugf("DeleteVerificationSchedule finished in %s", time.Since(start))
+
+	return err
+}
+
+// ListVerificationSchedules returns the verifications scheduled on the server along with the outcome of their last run
+func (c *immuClient) ListVerificationSchedules(ctx context.Context) (*schema.VerificationScheduleList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.ListVerificationSchedules(ctx, &empty.Empty{})
+
+	c.Logger.Debugf("ListVerificationSchedules finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// ListVerificationRuns returns the results of the scheduled verifications of a database, oldest first
+func (c *immuClient) ListVerificationRuns(ctx context.Context, db *schema.Database) (*schema.VerificationRunList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.ListVerificationRuns(ctx, db)
+
+	c.Logger.Debugf("ListVerificationRuns finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// ListSessions returns the sessions opened against the server, optionally only those of a user
+func (c *immuClient) ListSessions(ctx context.Context, req *schema.ListSessionsRequest) (*schema.SessionList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.ListSessions(ctx, req)
+
+	c.Logger.Debugf("ListSessions finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// KillSession closes a session, or all the sessions of a user revoking its logins as well
+func (c *immuClient) KillSession(ctx context.Context, req *schema.KillSessionRequest) (*schema.KillSessionResponse, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.KillSession(ctx, req)
+
+	c.Logger.Debugf("KillSession finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// GetRuntimeConfig returns the options of the server that can be changed while it's running, only the one
+// named key when it's not empty
+func (c *immuClient) GetRuntimeConfig(ctx context.Context, key string) (*schema.RuntimeConfig, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.GetRuntimeConfig(ctx, &schema.GetRuntimeConfigRequest{Key: key})
+
+	c.Logger.Debugf("GetRuntimeConfig finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// SetRuntimeConfig changes an option of the running server, the change is kept across restarts
+func (c *immuClient) SetRuntimeConfig(ctx context.Context, key, value string) (*schema.RuntimeSetting, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: key, Value: value})
+
+	c.Logger.Debugf("SetRuntimeConfig finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// ReplicationStatus returns the role of each database, along with the progress of the replication of replicas
+func (c *immuClient) ReplicationStatus(ctx context.Context) (*schema.ReplicationStatusList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.ReplicationStatus(ctx, &empty.Empty{})
+
+	c.Logger.Debugf("ReplicationStatus finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// DatabaseUsage returns the storage usage of a database, or of every database when db is empty
+func (c *immuClient) DatabaseUsage(ctx context.Context, db string) (*schema.DatabaseUsageList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.DatabaseUsage(ctx, &schema.DatabaseUsageRequest{Database: db})
+
+	c.Logger.Debugf("DatabaseUsage finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// SetRetentionPolicy makes the server erase the values of a database once they are older than its rules allow
+func (c *immuClient) SetRetentionPolicy(ctx context.Context, policy *schema.RetentionPolicy) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	_, err := c.ServiceClient.SetRetentionPolicy(ctx, policy)
+
+	c.Logger.Debugf("SetRetentionPolicy finished in %s", time.Since(start))
+
+	return err
+}
+
+// DeleteRetentionPolicy stops the server from enforcing the retention policy of a database
+func (c *immuClient) DeleteRetentionPolicy(ctx context.Context, db *schema.Database) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	_, err := c.ServiceClient.DeleteRetentionPolicy(ctx, db)
+
+	c.Logger.Debugf("DeleteRetentionPolicy finished in %s", time.Since(start))
+
+	return err
+}
+
+// ListRetentionPolicies returns the retention policies set on the server along with the progress of their enforcement
+func (c *immuClient) ListRetentionPolicies(ctx context.Context) (*schema.RetentionPolicyList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.ListRetentionPolicies(ctx, &empty.Empty{})
+
+	c.Logger.Debugf("ListRetentionPolicies finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// ListRetentionEnforcements returns the enforcements of the retention policy of a database, oldest first
+func (c *immuClient) ListRetentionEnforcements(ctx context.Context, db *schema.Database) (*schema.RetentionEnforcementList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.ListRetentionEnforcements(ctx, db)
+
+	c.Logger.Debugf("ListRetentionEnforcements finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// ConfigHistory returns the changes of the settings and of the schema of a database, or of the settings of the server
+func (c *immuClient) ConfigHistory(ctx context.Context, req *schema.ConfigHistoryRequest) (*schema.ConfigChangeList, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.ConfigHistory(ctx, req)
+
+	c.Logger.Debugf("ConfigHistory finished in %s", time.Since(start))
+
+	return res, err
+}
+
 // UseDatabase create a new database by making a grpc call
 func (c *immuClient) UseDatabase(ctx context.Context, db *schema.Database) (*schema.UseDatabaseReply, error) {
 	start := time.Now()
@@ -1501,6 +2007,216 @@ func (c *immuClient) CompactIndex(ctx context.Context, req *empty.Empty) error {
 	return err
 }
 
+// RebuildIndex discards the index of the selected database and builds it again from the
+// transaction log, progress is notified until every committed transaction gets indexed
+func (c *immuClient) RebuildIndex(ctx context.Context, progress func(*schema.IndexRebuildProgress)) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return errors.FromError(ErrNotConnected)
+	}
+
+	progressClient, err := c.ServiceClient.RebuildIndex(ctx, &empty.Empty{})
+	if err != nil {
+		return errors.FromError(err)
+	}
+
+	for {
+		p, err := progressClient.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.FromError(err)
+		}
+
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	c.Logger.Debugf("RebuildIndex finished in %s", time.Since(start))
+
+	return nil
+}
+
+// CompactDatabase compacts the index of the database named in the request, the progress of the compaction is
+// notified as it goes on. The space the compaction would reclaim is notified first, it's the only notification on dry-runs
+func (c *immuClient) CompactDatabase(ctx context.Context, req *schema.CompactDatabaseRequest, progress func(*schema.CompactionProgress)) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return errors.FromError(ErrNotConnected)
+	}
+
+	progressClient, err := c.ServiceClient.CompactDatabase(ctx, req)
+	if err != nil {
+		return errors.FromError(err)
+	}
+
+	for {
+		p, err := progressClient.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.FromError(err)
+		}
+
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	c.Logger.Debugf("CompactDatabase finished in %s", time.Since(start))
+
+	return nil
+}
+
+// CollectGarbage reclaims the space used by the values of the database named in the request superseded by newer transactions,
+// the values superseded within the safety window of the database and the ones under a legal hold are kept
+func (c *immuClient) CollectGarbage(ctx context.Context, req *schema.CollectGarbageRequest) (*schema.CollectGarbageResponse, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	res, err := c.ServiceClient.CollectGarbage(ctx, req)
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+
+	c.Logger.Debugf("CollectGarbage finished in %s", time.Since(start))
+
+	return res, nil
+}
+
+// Backup writes into w a consistent copy of the selected database taken while it keeps serving
+// requests, the backup can be restored with store.RestoreBackup. Incremental backups are taken
+// when the request identifies the last transaction of a previous backup, they are restored with
+// store.RestoreIncrementalBackup on top of it
+func (c *immuClient) Backup(ctx context.Context, req *schema.BackupRequest, w io.Writer) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return errors.FromError(ErrNotConnected)
+	}
+
+	backupClient, err := c.ServiceClient.Backup(ctx, req)
+	if err != nil {
+		return errors.FromError(err)
+	}
+
+	for {
+		chunk, err := backupClient.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.FromError(err)
+		}
+
+		_, err = w.Write(chunk.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.Logger.Debugf("Backup finished in %s", time.Since(start))
+
+	return nil
+}
+
+// Export writes into w a portable copy of the selected database, which can be imported into
+// another server with Import. The export ends with a manifest holding the exported state,
+// signed when the server has a signing key, it can be read with database.ReadExportManifest
+func (c *immuClient) Export(ctx context.Context, w io.Writer) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return errors.FromError(ErrNotConnected)
+	}
+
+	exportClient, err := c.ServiceClient.Export(ctx, &empty.Empty{})
+	if err != nil {
+		return errors.FromError(err)
+	}
+
+	for {
+		chunk, err := exportClient.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.FromError(err)
+		}
+
+		_, err = w.Write(chunk.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.Logger.Debugf("Export finished in %s", time.Since(start))
+
+	return nil
+}
+
+// Import creates the database databaseName from an export read from r. The returned state is
+// the one of the exported database, the imported database is checked to match it by the server
+func (c *immuClient) Import(ctx context.Context, databaseName string, r io.Reader) (*schema.ImmutableState, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	if r == nil {
+		return nil, errors.FromError(ErrIllegalArguments)
+	}
+
+	importClient, err := c.ServiceClient.Import(ctx)
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+
+	req := &schema.ImportRequest{DatabaseName: databaseName}
+	buf := make([]byte, c.Options.StreamChunkSize)
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 || req.DatabaseName != "" {
+			req.Content = buf[:n]
+
+			if sendErr := importClient.Send(req); sendErr != nil {
+				if sendErr == io.EOF {
+					// the server closed the stream, its error is returned by CloseAndRecv
+					break
+				}
+				return nil, errors.FromError(sendErr)
+			}
+
+			req = &schema.ImportRequest{}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	state, err := importClient.CloseAndRecv()
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+
+	c.Logger.Debugf("Import finished in %s", time.Since(start))
+
+	return state, nil
+}
+
 func (c *immuClient) ChangePermission(ctx context.Context, action schema.PermissionAction, username string, database string, permissions uint32) error {
 	start := time.Now()
 
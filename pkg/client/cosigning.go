@@ -0,0 +1,126 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/codenotary/immudb/pkg/signer"
+)
+
+// VerifiedCosignedState returns the state of the selected database at txID, the last cosigned one when
+// txID is zero, along with the signatures of the auditors who verified it. The state is only returned
+// when it was cosigned by at least the threshold of trusted cosigners set in the options, its signature
+// is checked as well when the public key of the server is set.
+func (c *immuClient) VerifiedCosignedState(ctx context.Context, txID uint64) (*schema.CosignedState, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	cosigners, err := c.trustedCosigners()
+	if err != nil {
+		return nil, err
+	}
+
+	cosignedState, err := c.ServiceClient.CosignedState(ctx, &schema.CosignedStateRequest{TxId: txID})
+	if err != nil {
+		return nil, err
+	}
+
+	state := cosignedState.State
+	if state == nil || (txID > 0 && state.TxId != txID) {
+		return nil, store.ErrCorruptedData
+	}
+
+	if c.stateSignatureVerificationEnabled() {
+		if state.Signature == nil {
+			return nil, ErrStateSignatureMissing
+		}
+
+		ok, err := state.CheckSignature(c.serverSigningPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStateSignatureMismatch, err)
+		}
+		if !ok {
+			return nil, ErrStateSignatureMismatch
+		}
+	}
+
+	payload := (&schema.ImmutableState{Db: state.Db, TxId: state.TxId, TxHash: state.TxHash}).ToBytes()
+
+	// each trusted cosigner is counted once, no matter how many of its signatures are returned
+	cosigned := make([]bool, len(cosigners))
+	count := 0
+
+	for _, cosignature := range cosignedState.Cosignatures {
+		if cosignature == nil {
+			continue
+		}
+
+		pk, err := signer.UnmarshalKey(cosignature.PublicKey)
+		if err != nil {
+			continue
+		}
+
+		for i, cosigner := range cosigners {
+			if cosigned[i] || cosigner.X.Cmp(pk.X) != 0 || cosigner.Y.Cmp(pk.Y) != 0 {
+				continue
+			}
+
+			ok, err := signer.Verify(payload, cosignature.Signature, cosigner)
+			if err == nil && ok {
+				cosigned[i] = true
+				count++
+			}
+			break
+		}
+	}
+
+	if count < c.Options.CosignatureThreshold {
+		return nil, fmt.Errorf("%w: %d of %d required", ErrCosignatureThresholdNotMet, count, c.Options.CosignatureThreshold)
+	}
+
+	return cosignedState, nil
+}
+
+// trustedCosigners parses the public keys of the trusted cosigners set in the options
+func (c *immuClient) trustedCosigners() ([]*ecdsa.PublicKey, error) {
+	if len(c.Options.CosignerPubKeys) == 0 {
+		return nil, ErrCosignersNotSet
+	}
+
+	if c.Options.CosignatureThreshold <= 0 || c.Options.CosignatureThreshold > len(c.Options.CosignerPubKeys) {
+		return nil, ErrIllegalArguments
+	}
+
+	cosigners := make([]*ecdsa.PublicKey, len(c.Options.CosignerPubKeys))
+
+	for i, path := range c.Options.CosignerPubKeys {
+		pk, err := signer.ParsePublicKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cosigners[i] = pk
+	}
+
+	return cosigners, nil
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dataexport writes the rows of a SQL table or the entries of a key prefix
+// read through the client into CSV or Parquet files, so they can be loaded into
+// analytical tools without writing custom extractors.
+package dataexport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+// pageSize is the number of rows or entries requested at once, it's kept below the maximum
+// number of rows the server returns in a single request
+const pageSize = 500
+
+var (
+	ErrIllegalArguments  = errors.New("illegal arguments")
+	ErrUnsupportedFormat = errors.New("unsupported export format")
+	ErrNoPrimaryKey      = errors.New("table has no primary key")
+)
+
+// Summary describes the rows written by an export
+type Summary struct {
+	Rows int
+}
+
+// ExportTable writes into w every row of the table of the current database.
+// Rows are read in pages sorted by primary key, rows changed while the export is running
+// may or may not be exported.
+func ExportTable(ctx context.Context, c client.ImmuClient, table string, format Format, w io.Writer) (*Summary, error) {
+	if c == nil || table == "" || w == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	desc, err := c.DescribeTable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var cols []column
+	var pkCols []int
+
+	for _, row := range desc.Rows {
+		if len(row.Values) < 4 {
+			return nil, fmt.Errorf("unexpected description of table '%s'", table)
+		}
+
+		col := column{
+			name: row.Values[0].GetS(),
+			// the type of a column is described along with its maximum length, i.e. VARCHAR[256]
+			typ: strings.SplitN(row.Values[1].GetS(), "[", 2)[0],
+		}
+
+		if row.Values[3].GetS() == "PRIMARY KEY" {
+			pkCols = append(pkCols, len(cols))
+		}
+
+		cols = append(cols, col)
+	}
+
+	if len(pkCols) == 0 {
+		return nil, ErrNoPrimaryKey
+	}
+
+	rw, err := newRowWriter(format, w, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+
+	var last []*schema.SQLValue
+
+	for {
+		query, params := pageQuery(table, cols, pkCols, last)
+
+		res, err := c.SQLQuery(ctx, query, params, true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range res.Rows {
+			values := make([]interface{}, len(row.Values))
+			for i, v := range row.Values {
+				values[i] = schema.RawValue(v)
+			}
+
+			err = rw.Write(values)
+			if err != nil {
+				return nil, err
+			}
+
+			summary.Rows++
+		}
+
+		if len(res.Rows) < pageSize {
+			break
+		}
+
+		lastRow := res.Rows[len(res.Rows)-1]
+
+		last = make([]*schema.SQLValue, len(pkCols))
+		for i, c := range pkCols {
+			last[i] = lastRow.Values[c]
+		}
+	}
+
+	return summary, rw.Close()
+}
+
+// pageQuery returns the query selecting the rows following the primary key last,
+// or the first rows of the table when last is nil. Rows are sorted by primary key
+// as the primary index is scanned when no other ordering is requested.
+func pageQuery(table string, cols []column, pkCols []int, last []*schema.SQLValue) (string, map[string]interface{}) {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.name
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "SELECT %s FROM %s", strings.Join(names, ", "), table)
+
+	if last == nil {
+		fmt.Fprintf(&b, " LIMIT %d", pageSize)
+		return b.String(), nil
+	}
+
+	params := make(map[string]interface{}, len(pkCols))
+
+	// (pk0 > @pk0) OR (pk0 = @pk0 AND pk1 > @pk1) OR ...
+	var conds []string
+
+	for i := range pkCols {
+		var terms []string
+
+		for j := 0; j <= i; j++ {
+			op := "="
+			if j == i {
+				op = ">"
+			}
+			terms = append(terms, fmt.Sprintf("%s %s @pk%d", cols[pkCols[j]].name, op, j))
+		}
+
+		conds = append(conds, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	for i, v := range last {
+		params[fmt.Sprintf("pk%d", i)] = schema.RawValue(v)
+	}
+
+	fmt.Fprintf(&b, " WHERE %s LIMIT %d", strings.Join(conds, " OR "), pageSize)
+
+	return b.String(), params
+}
+
+// ExportPrefix writes into w the key, value and transaction of every entry whose key starts with prefix,
+// keys and values are exported as strings. Entries are read as of the current state of the database.
+func ExportPrefix(ctx context.Context, c client.ImmuClient, prefix []byte, format Format, w io.Writer) (*Summary, error) {
+	if c == nil || w == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rw, err := newRowWriter(format, w, []column{
+		{name: "key", typ: sql.VarcharType},
+		{name: "value", typ: sql.VarcharType},
+		{name: "tx", typ: sql.IntegerType},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+
+	var seekKey []byte
+
+	for {
+		entries, err := c.Scan(ctx, &schema.ScanRequest{
+			Prefix:  prefix,
+			SeekKey: seekKey,
+			Limit:   pageSize,
+			SinceTx: state.TxId,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		exported := 0
+
+		for _, e := range entries.Entries {
+			key := scannedKey(e)
+
+			// the entry the scan was resumed from is already exported
+			if seekKey != nil && bytes.Equal(key, seekKey) {
+				continue
+			}
+
+			err = rw.Write([]interface{}{string(key), string(e.Value), int64(e.Tx)})
+			if err != nil {
+				return nil, err
+			}
+
+			summary.Rows++
+			exported++
+		}
+
+		if len(entries.Entries) < pageSize || exported == 0 {
+			break
+		}
+
+		seekKey = scannedKey(entries.Entries[len(entries.Entries)-1])
+	}
+
+	return summary, rw.Close()
+}
+
+// scannedKey returns the key an entry was found at, references are returned resolved
+func scannedKey(e *schema.Entry) []byte {
+	if e.ReferencedBy != nil {
+		return e.ReferencedBy.Key
+	}
+	return e.Key
+}
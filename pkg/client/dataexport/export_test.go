@@ -0,0 +1,172 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestExportTable(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	_, err = cli.SQLExec(ctx, `CREATE TABLE events(
+		source VARCHAR[16],
+		id INTEGER,
+		active BOOLEAN,
+		payload BLOB,
+		PRIMARY KEY (source, id)
+	)`, nil)
+	require.NoError(t, err)
+
+	rows := 0
+
+	for _, source := range []string{"a", "b"} {
+		for i := 0; i < 3*pageSize/2; i += 50 {
+			var stmt bytes.Buffer
+			stmt.WriteString("BEGIN TRANSACTION;")
+
+			for j := i; j < i+50; j++ {
+				fmt.Fprintf(&stmt, "INSERT INTO events(source, id, active, payload) VALUES ('%s', %d, %t, x'0a0b');", source, j, j%2 == 0)
+				rows++
+			}
+
+			stmt.WriteString("COMMIT;")
+
+			_, err = cli.SQLExec(ctx, stmt.String(), nil)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = cli.SQLExec(ctx, "INSERT INTO events(source, id) VALUES ('c', 1)", nil)
+	require.NoError(t, err)
+	rows++
+
+	_, err = ExportTable(ctx, nil, "events", CSV, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = ExportTable(ctx, cli, "events", Format("xml"), &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = ExportTable(ctx, cli, "missing", CSV, &bytes.Buffer{})
+	require.Error(t, err)
+
+	var out bytes.Buffer
+
+	summary, err := ExportTable(ctx, cli, "events", CSV, &out)
+	require.NoError(t, err)
+	require.Equal(t, rows, summary.Rows)
+
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, rows+1)
+	require.Equal(t, []string{"source", "id", "active", "payload"}, records[0])
+	require.Equal(t, []string{"a", "0", "true", "0a0b"}, records[1])
+	require.Equal(t, []string{"b", "0", "true", "0a0b"}, records[1+3*pageSize/2])
+	require.Equal(t, []string{"c", "1", "", ""}, records[rows])
+
+	out.Reset()
+
+	summary, err = ExportTable(ctx, cli, "events", Parquet, &out)
+	require.NoError(t, err)
+	require.Equal(t, rows, summary.Rows)
+
+	pf, err := buffer.NewBufferFile(out.Bytes())
+	require.NoError(t, err)
+
+	pr, err := reader.NewParquetReader(pf, nil, 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	require.Equal(t, int64(rows), pr.GetNumRows())
+}
+
+func TestExportPrefix(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < pageSize+10; i++ {
+		_, err := cli.Set(ctx, []byte(fmt.Sprintf("user:%04d", i)), []byte(fmt.Sprintf("name%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, err = cli.Set(ctx, []byte("other"), []byte("value"))
+	require.NoError(t, err)
+
+	_, err = cli.SetReference(ctx, []byte("user:ref"), []byte("other"))
+	require.NoError(t, err)
+
+	_, err = ExportPrefix(ctx, cli, []byte("user:"), CSV, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	var out bytes.Buffer
+
+	summary, err := ExportPrefix(ctx, cli, []byte("user:"), CSV, &out)
+	require.NoError(t, err)
+	require.Equal(t, pageSize+11, summary.Rows)
+
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, pageSize+12)
+	require.Equal(t, []string{"key", "value", "tx"}, records[0])
+	require.Equal(t, "user:0000", records[1][0])
+	require.Equal(t, "name0", records[1][1])
+	require.Equal(t, []string{"user:ref", "value"}, records[pageSize+11][:2])
+
+	out.Reset()
+
+	summary, err = ExportPrefix(ctx, cli, []byte("user:"), Parquet, &out)
+	require.NoError(t, err)
+	require.Equal(t, pageSize+11, summary.Rows)
+
+	pf, err := buffer.NewBufferFile(out.Bytes())
+	require.NoError(t, err)
+
+	pr, err := reader.NewParquetReader(pf, nil, 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	require.Equal(t, int64(pageSize+11), pr.GetNumRows())
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("csv")
+	require.NoError(t, err)
+	require.Equal(t, CSV, f)
+
+	f, err = ParseFormat("parquet")
+	require.NoError(t, err)
+	require.Equal(t, Parquet, f)
+
+	_, err = ParseFormat("json")
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
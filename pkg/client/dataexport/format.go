@@ -0,0 +1,181 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataexport
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Format is the file format rows are exported to
+type Format string
+
+const (
+	// CSV files start with a header holding the names of the columns, NULL values are written as empty
+	// fields, BLOB values are hex encoded and timestamps are written in RFC 3339 format
+	CSV Format = "csv"
+
+	// Parquet files hold a single optional field per column, timestamps are stored as microseconds since
+	// the Unix epoch
+	Parquet Format = "parquet"
+)
+
+// ParseFormat returns the format named name
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case CSV, Parquet:
+		return Format(name), nil
+	}
+	return "", fmt.Errorf("%w: '%s'", ErrUnsupportedFormat, name)
+}
+
+type column struct {
+	name string
+	typ  sql.SQLValueType
+}
+
+// rowWriter writes rows whose values are either nil, int64, bool, string, []byte or time.Time
+type rowWriter interface {
+	Write(values []interface{}) error
+	Close() error
+}
+
+func newRowWriter(format Format, w io.Writer, cols []column) (rowWriter, error) {
+	switch format {
+	case CSV:
+		return newCSVRowWriter(w, cols)
+	case Parquet:
+		return newParquetRowWriter(w, cols)
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedFormat, format)
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func newCSVRowWriter(w io.Writer, cols []column) (*csvRowWriter, error) {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.name
+	}
+
+	err := cw.Write(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &csvRowWriter{w: cw}, nil
+}
+
+func (rw *csvRowWriter) Write(values []interface{}) error {
+	record := make([]string, len(values))
+
+	for i, v := range values {
+		switch tv := v.(type) {
+		case nil:
+		case int64:
+			record[i] = strconv.FormatInt(tv, 10)
+		case bool:
+			record[i] = strconv.FormatBool(tv)
+		case string:
+			record[i] = tv
+		case []byte:
+			record[i] = hex.EncodeToString(tv)
+		case time.Time:
+			record[i] = tv.UTC().Format(time.RFC3339Nano)
+		default:
+			return fmt.Errorf("unsupported value of type %T", v)
+		}
+	}
+
+	return rw.w.Write(record)
+}
+
+func (rw *csvRowWriter) Close() error {
+	rw.w.Flush()
+	return rw.w.Error()
+}
+
+type parquetRowWriter struct {
+	w *writer.CSVWriter
+}
+
+func newParquetRowWriter(w io.Writer, cols []column) (*parquetRowWriter, error) {
+	md := make([]string, len(cols))
+
+	for i, c := range cols {
+		var typ string
+
+		switch c.typ {
+		case sql.IntegerType:
+			typ = "type=INT64"
+		case sql.BooleanType:
+			typ = "type=BOOLEAN"
+		case sql.VarcharType:
+			typ = "type=BYTE_ARRAY, convertedtype=UTF8"
+		case sql.BLOBType:
+			typ = "type=BYTE_ARRAY"
+		case sql.TimestampType:
+			typ = "type=INT64, convertedtype=TIMESTAMP_MICROS"
+		default:
+			return nil, fmt.Errorf("unsupported type '%s' of column '%s'", c.typ, c.name)
+		}
+
+		md[i] = fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", c.name, typ)
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(md, w, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parquetRowWriter{w: pw}, nil
+}
+
+func (rw *parquetRowWriter) Write(values []interface{}) error {
+	rec := make([]interface{}, len(values))
+
+	for i, v := range values {
+		switch tv := v.(type) {
+		case nil, int64, bool, string:
+			rec[i] = tv
+		case []byte:
+			rec[i] = string(tv)
+		case time.Time:
+			rec[i] = tv.UnixNano() / int64(time.Microsecond)
+		default:
+			return fmt.Errorf("unsupported value of type %T", v)
+		}
+	}
+
+	return rw.w.Write(rec)
+}
+
+// Close writes the footer of the file
+func (rw *parquetRowWriter) Close() error {
+	return rw.w.WriteStop()
+}
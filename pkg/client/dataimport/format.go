@@ -0,0 +1,200 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Format is the file format records are imported from
+type Format string
+
+const (
+	// CSV files start with a header holding the names of the fields, all the values are imported as strings
+	CSV Format = "csv"
+
+	// JSONL files hold a JSON object per line, string values are imported as they are and any other value
+	// is imported JSON encoded, empty lines are skipped
+	JSONL Format = "jsonl"
+)
+
+// ParseFormat returns the format named name
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case CSV, JSONL:
+		return Format(name), nil
+	}
+	return "", fmt.Errorf("%w: '%s'", ErrUnsupportedFormat, name)
+}
+
+// record is a record read from the imported file
+type record interface {
+	// field returns the value of the field named name
+	field(name string) ([]byte, bool)
+	// json returns the whole record as a JSON object
+	json() ([]byte, error)
+}
+
+// recordReader reads the records of a file, io.EOF is returned once all of them were read.
+// A *RecordError is returned for records that can't be read, the next one can still be read
+type recordReader interface {
+	Read() (record, error)
+}
+
+func newRecordReader(format Format, r io.Reader) (recordReader, error) {
+	switch format {
+	case CSV:
+		return newCSVRecordReader(r)
+	case JSONL:
+		return newJSONLRecordReader(r), nil
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedFormat, format)
+}
+
+type csvRecordReader struct {
+	r      *csv.Reader
+	header map[string]int
+	names  []string
+	n      int
+}
+
+func newCSVRecordReader(r io.Reader) (*csvRecordReader, error) {
+	cr := csv.NewReader(r)
+
+	names, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: missing CSV header", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	header := make(map[string]int, len(names))
+	for i, name := range names {
+		header[name] = i
+	}
+
+	return &csvRecordReader{r: cr, header: header, names: names}, nil
+}
+
+func (r *csvRecordReader) Read() (record, error) {
+	values, err := r.r.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	if _, ok := err.(*csv.ParseError); ok {
+		// the following records can still be read
+		r.n++
+		return nil, &RecordError{Record: r.n, Err: fmt.Errorf("%w: %v", ErrInvalidRecord, err)}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.n++
+
+	return &csvRecord{header: r.header, names: r.names, values: values}, nil
+}
+
+type csvRecord struct {
+	header map[string]int
+	names  []string
+	values []string
+}
+
+func (r *csvRecord) field(name string) ([]byte, bool) {
+	i, ok := r.header[name]
+	if !ok || i >= len(r.values) {
+		return nil, false
+	}
+	return []byte(r.values[i]), true
+}
+
+func (r *csvRecord) json() ([]byte, error) {
+	obj := make(map[string]string, len(r.values))
+	for i, v := range r.values {
+		obj[r.names[i]] = v
+	}
+	return json.Marshal(obj)
+}
+
+type jsonlRecordReader struct {
+	r *bufio.Reader
+	n int
+}
+
+func newJSONLRecordReader(r io.Reader) *jsonlRecordReader {
+	return &jsonlRecordReader{r: bufio.NewReader(r)}
+}
+
+func (r *jsonlRecordReader) Read() (record, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		line = bytes.TrimSpace(line)
+
+		if len(line) == 0 {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			continue
+		}
+
+		r.n++
+
+		var obj map[string]json.RawMessage
+
+		jerr := json.Unmarshal(line, &obj)
+		if jerr != nil {
+			return nil, &RecordError{Record: r.n, Err: fmt.Errorf("%w: %v", ErrInvalidRecord, jerr)}
+		}
+
+		return &jsonlRecord{line: line, obj: obj}, nil
+	}
+}
+
+type jsonlRecord struct {
+	line []byte
+	obj  map[string]json.RawMessage
+}
+
+func (r *jsonlRecord) field(name string) ([]byte, bool) {
+	raw, ok := r.obj[name]
+	if !ok || bytes.Equal(raw, []byte("null")) {
+		return nil, false
+	}
+
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return []byte(s), true
+	}
+
+	return raw, true
+}
+
+func (r *jsonlRecord) json() ([]byte, error) {
+	return r.line, nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dataimport loads the records of CSV or JSON Lines files into key-value entries
+// set through the client in batched transactions, for one-off data loads.
+package dataimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+// DefaultBatchSize is the default number of records set in each transaction
+const DefaultBatchSize = 100
+
+var (
+	ErrIllegalArguments  = errors.New("illegal arguments")
+	ErrUnsupportedFormat = errors.New("unsupported import format")
+	ErrInvalidFile       = errors.New("invalid file")
+	ErrInvalidRecord     = errors.New("invalid record")
+)
+
+// RecordError is returned for records that can't be imported,
+// records are numbered from 1 in the order they are read, not counting the CSV header
+type RecordError struct {
+	Record int
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Record, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Options of an import
+type Options struct {
+	Format Format
+
+	// KeyField is the name of the field holding the key of each record
+	KeyField string
+
+	// ValueField is the name of the field holding the value of each record,
+	// the whole record is imported as a JSON object when empty
+	ValueField string
+
+	// BatchSize is the maximum number of records set in each transaction
+	BatchSize int
+
+	// SkipInvalid makes invalid records be skipped and reported in the summary instead of aborting the import
+	SkipInvalid bool
+
+	// Progress is called with the summary of the import after each transaction is committed
+	Progress func(summary *Summary)
+}
+
+// DefaultOptions returns the options of an import of a CSV file with records set in batches of DefaultBatchSize
+func DefaultOptions() *Options {
+	return &Options{
+		Format:    CSV,
+		KeyField:  "key",
+		BatchSize: DefaultBatchSize,
+	}
+}
+
+func (o *Options) WithFormat(format Format) *Options {
+	o.Format = format
+	return o
+}
+
+func (o *Options) WithKeyField(keyField string) *Options {
+	o.KeyField = keyField
+	return o
+}
+
+func (o *Options) WithValueField(valueField string) *Options {
+	o.ValueField = valueField
+	return o
+}
+
+func (o *Options) WithBatchSize(batchSize int) *Options {
+	o.BatchSize = batchSize
+	return o
+}
+
+func (o *Options) WithSkipInvalid(skipInvalid bool) *Options {
+	o.SkipInvalid = skipInvalid
+	return o
+}
+
+func (o *Options) WithProgress(progress func(summary *Summary)) *Options {
+	o.Progress = progress
+	return o
+}
+
+// Summary describes the records imported so far
+type Summary struct {
+	// Records is the number of records read
+	Records int
+	// Imported is the number of records set by committed transactions
+	Imported int
+	// Txs is the number of transactions committed and LastTx the last one of them
+	Txs    int
+	LastTx uint64
+	// Skipped holds the errors of the invalid records that were skipped
+	Skipped []*RecordError
+}
+
+// Import sets the records read from r into the current database, in transactions of at most opts.BatchSize records.
+// Records whose key is repeated within a batch are set in the following transaction, so the last value of a key wins.
+// The summary of the records imported so far is returned along with the error aborting the import, if any,
+// records of uncommitted batches are not imported.
+func Import(ctx context.Context, c client.ImmuClient, r io.Reader, opts *Options) (*Summary, error) {
+	if c == nil || r == nil || opts == nil || opts.KeyField == "" || opts.BatchSize <= 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	rr, err := newRecordReader(opts.Format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+
+	var batch []*schema.KeyValue
+	inBatch := make(map[string]struct{}, opts.BatchSize)
+
+	// records the current batch was read from
+	var firstInBatch, lastInBatch int
+
+	commit := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		hdr, err := c.SetAll(ctx, &schema.SetRequest{KVs: batch})
+		if err != nil {
+			return fmt.Errorf("importing records %d to %d: %w", firstInBatch, lastInBatch, err)
+		}
+
+		summary.Imported += len(batch)
+		summary.Txs++
+		summary.LastTx = hdr.Id
+
+		batch = nil
+		inBatch = make(map[string]struct{}, opts.BatchSize)
+
+		if opts.Progress != nil {
+			opts.Progress(summary)
+		}
+
+		return nil
+	}
+
+	for {
+		rec, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var recErr *RecordError
+		if err == nil || errors.As(err, &recErr) {
+			summary.Records++
+		}
+
+		var kv *schema.KeyValue
+		if err == nil {
+			kv, err = keyValueOf(rec, opts, summary.Records)
+		}
+
+		if err != nil {
+			if !opts.SkipInvalid || !errors.As(err, &recErr) {
+				return summary, err
+			}
+
+			summary.Skipped = append(summary.Skipped, recErr)
+			continue
+		}
+
+		_, duplicated := inBatch[string(kv.Key)]
+
+		if duplicated || len(batch) == opts.BatchSize {
+			err = commit()
+			if err != nil {
+				return summary, err
+			}
+		}
+
+		if len(batch) == 0 {
+			firstInBatch = summary.Records
+		}
+		lastInBatch = summary.Records
+
+		batch = append(batch, kv)
+		inBatch[string(kv.Key)] = struct{}{}
+	}
+
+	err = commit()
+	if err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+func keyValueOf(rec record, opts *Options, n int) (*schema.KeyValue, error) {
+	key, ok := rec.field(opts.KeyField)
+	if !ok || len(key) == 0 {
+		return nil, &RecordError{Record: n, Err: fmt.Errorf("%w: missing key field '%s'", ErrInvalidRecord, opts.KeyField)}
+	}
+
+	if opts.ValueField == "" {
+		value, err := rec.json()
+		if err != nil {
+			return nil, &RecordError{Record: n, Err: err}
+		}
+		return &schema.KeyValue{Key: key, Value: value}, nil
+	}
+
+	value, ok := rec.field(opts.ValueField)
+	if !ok {
+		return nil, &RecordError{Record: n, Err: fmt.Errorf("%w: missing value field '%s'", ErrInvalidRecord, opts.ValueField)}
+	}
+
+	return &schema.KeyValue{Key: key, Value: value}, nil
+}
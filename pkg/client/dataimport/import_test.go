@@ -0,0 +1,144 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataimport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("jsonl")
+	require.NoError(t, err)
+	require.Equal(t, JSONL, format)
+
+	_, err = ParseFormat("xml")
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestImportCSV(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	_, err = Import(ctx, nil, strings.NewReader(""), DefaultOptions())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Import(ctx, cli, strings.NewReader(""), DefaultOptions().WithBatchSize(0))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Import(ctx, cli, strings.NewReader(""), DefaultOptions().WithFormat(Format("xml")))
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = Import(ctx, cli, strings.NewReader(""), DefaultOptions())
+	require.ErrorIs(t, err, ErrInvalidFile)
+
+	var file bytes.Buffer
+	file.WriteString("id,name,city\n")
+
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&file, "user%d,name%d,city%d\n", i, i, i)
+	}
+
+	var progress []int
+
+	opts := DefaultOptions().
+		WithKeyField("id").
+		WithValueField("name").
+		WithBatchSize(10).
+		WithProgress(func(summary *Summary) {
+			progress = append(progress, summary.Imported)
+		})
+
+	summary, err := Import(ctx, cli, &file, opts)
+	require.NoError(t, err)
+	require.Equal(t, 25, summary.Records)
+	require.Equal(t, 25, summary.Imported)
+	require.Equal(t, 3, summary.Txs)
+	require.Empty(t, summary.Skipped)
+	require.Equal(t, []int{10, 20, 25}, progress)
+
+	entry, err := cli.Get(ctx, []byte("user7"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("name7"), entry.Value)
+	require.Equal(t, summary.LastTx-2, entry.Tx)
+
+	// whole records are imported when there is no value field
+	summary, err = Import(ctx, cli, strings.NewReader("id,name\nuser1,other\n"), DefaultOptions().WithKeyField("id"))
+	require.NoError(t, err)
+	require.Equal(t, 1, summary.Imported)
+
+	entry, err = cli.Get(ctx, []byte("user1"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"user1","name":"other"}`, string(entry.Value))
+}
+
+func TestImportJSONL(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	file := `{"k": "a", "v": "1"}
+
+{"k": "b", "v": {"nested": true}}
+not json
+{"v": "missing key"}
+{"k": "a", "v": 2}
+`
+
+	opts := DefaultOptions().WithFormat(JSONL).WithKeyField("k").WithValueField("v")
+
+	summary, err := Import(ctx, cli, strings.NewReader(file), opts)
+	require.ErrorIs(t, err, ErrInvalidRecord)
+	require.Equal(t, 3, summary.Records)
+	require.Equal(t, 0, summary.Imported)
+
+	var recErr *RecordError
+	require.True(t, errors.As(err, &recErr))
+	require.Equal(t, 3, recErr.Record)
+
+	summary, err = Import(ctx, cli, strings.NewReader(file), opts.WithSkipInvalid(true))
+	require.NoError(t, err)
+	require.Equal(t, 5, summary.Records)
+	require.Equal(t, 3, summary.Imported)
+	require.Len(t, summary.Skipped, 2)
+	require.Equal(t, 3, summary.Skipped[0].Record)
+	require.Equal(t, 4, summary.Skipped[1].Record)
+	require.ErrorIs(t, summary.Skipped[1], ErrInvalidRecord)
+
+	// the repeated key is set in its own transaction
+	require.Equal(t, 2, summary.Txs)
+
+	entry, err := cli.Get(ctx, []byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), entry.Value)
+
+	entry, err = cli.Get(ctx, []byte("b"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"nested": true}`, string(entry.Value))
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dbcopy streams a database from a running server into a new database of another one,
+// i.e. to promote a database from an environment to the next one. Data is relayed through the
+// client without being written to disk.
+package dbcopy
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+var (
+	ErrIllegalArguments = errors.New("illegal arguments")
+	ErrStateMismatch    = errors.New("copied state does not match the verified state of the source database")
+)
+
+// Copy streams the database selected on src into the new database databaseName created on dst.
+// The exported state is proven against the state already trusted by src before the export is completed
+// on dst, so the copy is not created when the proof fails. The copied state of the source database is returned.
+func Copy(srcCtx context.Context, src client.ImmuClient, dstCtx context.Context, dst client.ImmuClient, databaseName string) (*schema.ImmutableState, error) {
+	if src == nil || dst == nil || databaseName == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	srcCtx, cancelExport := context.WithCancel(srcCtx)
+	defer cancelExport()
+
+	// cancelling the import makes dst discard the partially imported database
+	dstCtx, cancel := context.WithCancel(dstCtx)
+	defer cancel()
+
+	er, ew := io.Pipe()
+
+	go func() {
+		ew.CloseWithError(src.Export(srcCtx, ew))
+	}()
+
+	ir, iw := io.Pipe()
+
+	type relayResult struct {
+		manifest *database.ExportManifest
+		err      error
+	}
+
+	relayDone := make(chan relayResult, 1)
+
+	go func() {
+		manifest, err := relay(er, iw, func(manifest *database.ExportManifest) error {
+			return verifyExportedState(srcCtx, src, manifest)
+		})
+		if err != nil {
+			cancel()
+		}
+		er.CloseWithError(err)
+		iw.CloseWithError(err)
+		relayDone <- relayResult{manifest: manifest, err: err}
+	}()
+
+	state, err := dst.Import(dstCtx, databaseName, ir)
+	ir.CloseWithError(err)
+
+	res := <-relayDone
+	if res.err != nil {
+		return nil, res.err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if state.TxId != res.manifest.TxID || !bytes.Equal(state.TxHash, res.manifest.Alh) {
+		return nil, ErrStateMismatch
+	}
+
+	return state, nil
+}
+
+// relay copies the entries of an export from r into w, the manifest is the last entry and
+// it's only copied once accepted by verify
+func relay(r io.Reader, w io.Writer, verify func(manifest *database.ExportManifest) error) (*database.ExportManifest, error) {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+
+	var manifest *database.ExportManifest
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if manifest != nil {
+			return nil, fmt.Errorf("%w: unexpected entry '%s' after the manifest", database.ErrInvalidExport, hdr.Name)
+		}
+
+		if hdr.Name != database.ExportManifestName {
+			err = tw.WriteHeader(hdr)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = io.Copy(tw, tr)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest = &database.ExportManifest{}
+
+		err = json.Unmarshal(content, manifest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", database.ErrInvalidExport, err)
+		}
+
+		err = verify(manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tw.Write(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: manifest not found", database.ErrInvalidExport)
+	}
+
+	return manifest, tw.Close()
+}
+
+// verifyExportedState checks the exported transaction is the one src proves to be consistent with
+// the state it already trusts, the trusted state is then moved forward to it
+func verifyExportedState(ctx context.Context, src client.ImmuClient, manifest *database.ExportManifest) error {
+	if manifest.TxID == 0 {
+		// nothing to be proven about an empty database
+		return nil
+	}
+
+	tx, err := src.VerifiedTxByID(ctx, manifest.TxID)
+	if err != nil {
+		return fmt.Errorf("exported state could not be verified: %w", err)
+	}
+
+	txHdr, err := schema.TxHeaderFromProto(tx.Header)
+	if err != nil {
+		return err
+	}
+
+	alh := txHdr.Alh()
+
+	if !bytes.Equal(alh[:], manifest.Alh) {
+		return ErrStateMismatch
+	}
+
+	return nil
+}
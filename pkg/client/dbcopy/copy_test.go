@@ -0,0 +1,101 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbcopy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+// tamperedSource proves a transaction other than the exported one
+type tamperedSource struct {
+	client.ImmuClient
+}
+
+func (c *tamperedSource) VerifiedTxByID(ctx context.Context, tx uint64) (*schema.Tx, error) {
+	return c.ImmuClient.VerifiedTxByID(ctx, tx-1)
+}
+
+func TestCopy(t *testing.T) {
+	src, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer src.Close()
+
+	dst, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer dst.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, err = src.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, err = Copy(ctx, nil, ctx, dst, "copydb")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Copy(ctx, src, ctx, dst, "")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("the copy is not created when the exported state can not be proven", func(t *testing.T) {
+		_, err = Copy(ctx, &tamperedSource{ImmuClient: src}, ctx, dst, "tampereddb")
+		require.ErrorIs(t, err, ErrStateMismatch)
+
+		dbs, err := dst.DatabaseList(ctx)
+		require.NoError(t, err)
+
+		for _, db := range dbs.Databases {
+			require.NotEqual(t, "tampereddb", db.DatabaseName)
+		}
+	})
+
+	srcState, err := src.CurrentState(ctx)
+	require.NoError(t, err)
+
+	state, err := Copy(ctx, src, ctx, dst, "copydb")
+	require.NoError(t, err)
+	require.Equal(t, client.DefaultDB, state.Db)
+	require.Equal(t, srcState.TxId, state.TxId)
+	require.Equal(t, srcState.TxHash, state.TxHash)
+
+	t.Run("databases are not overwritten", func(t *testing.T) {
+		_, err = Copy(ctx, src, ctx, dst, "copydb")
+		require.Error(t, err)
+	})
+
+	err = dst.CloseSession(ctx)
+	require.NoError(t, err)
+
+	err = dst.OpenSession(ctx, []byte(auth.SysAdminUsername), []byte(auth.SysAdminPassword), "copydb")
+	require.NoError(t, err)
+
+	entry, err := dst.VerifiedGet(ctx, []byte("key9"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value9"), entry.Value)
+
+	dstState, err := dst.CurrentState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, srcState.TxHash, dstState.TxHash)
+}
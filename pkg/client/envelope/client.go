@@ -0,0 +1,191 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"context"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+type encryptedClient struct {
+	client.ImmuClient
+
+	sealer *Sealer
+}
+
+// NewClient wraps an ImmuClient so that values are encrypted before being written
+// and decrypted after being read. Key-value operations not overridden here are
+// forwarded untouched to the underlying client.
+// Note verification is performed against the encrypted values as stored in the server.
+func NewClient(c client.ImmuClient, kp KeyProvider) client.ImmuClient {
+	return &encryptedClient{
+		ImmuClient: c,
+		sealer:     NewSealer(kp),
+	}
+}
+
+func (c *encryptedClient) Set(ctx context.Context, key []byte, value []byte) (*schema.TxHeader, error) {
+	sealed, err := c.sealer.Seal(ctx, key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ImmuClient.Set(ctx, key, sealed)
+}
+
+func (c *encryptedClient) VerifiedSet(ctx context.Context, key []byte, value []byte) (*schema.TxHeader, error) {
+	sealed, err := c.sealer.Seal(ctx, key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ImmuClient.VerifiedSet(ctx, key, sealed)
+}
+
+func (c *encryptedClient) ExpirableSet(ctx context.Context, key []byte, value []byte, expiresAt time.Time) (*schema.TxHeader, error) {
+	sealed, err := c.sealer.Seal(ctx, key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ImmuClient.ExpirableSet(ctx, key, sealed, expiresAt)
+}
+
+func (c *encryptedClient) SetAll(ctx context.Context, req *schema.SetRequest) (*schema.TxHeader, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	kvs := make([]*schema.KeyValue, len(req.KVs))
+
+	for i, kv := range req.KVs {
+		sealed, err := c.sealer.Seal(ctx, kv.Key, kv.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		kvs[i] = &schema.KeyValue{Key: kv.Key, Value: sealed, Metadata: kv.Metadata}
+	}
+
+	return c.ImmuClient.SetAll(ctx, &schema.SetRequest{KVs: kvs, NoWait: req.NoWait})
+}
+
+func (c *encryptedClient) Get(ctx context.Context, key []byte) (*schema.Entry, error) {
+	entry, err := c.ImmuClient.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.open(ctx, entry)
+}
+
+func (c *encryptedClient) GetSince(ctx context.Context, key []byte, tx uint64) (*schema.Entry, error) {
+	entry, err := c.ImmuClient.GetSince(ctx, key, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.open(ctx, entry)
+}
+
+func (c *encryptedClient) GetAt(ctx context.Context, key []byte, tx uint64) (*schema.Entry, error) {
+	entry, err := c.ImmuClient.GetAt(ctx, key, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.open(ctx, entry)
+}
+
+func (c *encryptedClient) VerifiedGet(ctx context.Context, key []byte) (*schema.Entry, error) {
+	entry, err := c.ImmuClient.VerifiedGet(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.open(ctx, entry)
+}
+
+func (c *encryptedClient) VerifiedGetSince(ctx context.Context, key []byte, tx uint64) (*schema.Entry, error) {
+	entry, err := c.ImmuClient.VerifiedGetSince(ctx, key, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.open(ctx, entry)
+}
+
+func (c *encryptedClient) VerifiedGetAt(ctx context.Context, key []byte, tx uint64) (*schema.Entry, error) {
+	entry, err := c.ImmuClient.VerifiedGetAt(ctx, key, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.open(ctx, entry)
+}
+
+func (c *encryptedClient) GetAll(ctx context.Context, keys [][]byte) (*schema.Entries, error) {
+	entries, err := c.ImmuClient.GetAll(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.openAll(ctx, entries)
+}
+
+func (c *encryptedClient) Scan(ctx context.Context, req *schema.ScanRequest) (*schema.Entries, error) {
+	entries, err := c.ImmuClient.Scan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.openAll(ctx, entries)
+}
+
+func (c *encryptedClient) History(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error) {
+	entries, err := c.ImmuClient.History(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.openAll(ctx, entries)
+}
+
+func (c *encryptedClient) open(ctx context.Context, entry *schema.Entry) (*schema.Entry, error) {
+	value, err := c.sealer.Open(ctx, entry.Key, entry.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Value = value
+
+	return entry, nil
+}
+
+func (c *encryptedClient) openAll(ctx context.Context, entries *schema.Entries) (*schema.Entries, error) {
+	for _, entry := range entries.Entries {
+		_, err := c.open(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
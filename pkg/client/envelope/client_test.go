@@ -0,0 +1,77 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedClient(t *testing.T) {
+	cli, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	kp, err := NewStaticKeyProvider(map[string][]byte{"k1": make([]byte, 32)}, "k1")
+	require.NoError(t, err)
+
+	ecli := NewClient(cli, kp)
+
+	ctx := context.Background()
+
+	_, err = ecli.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	hdr, err := ecli.VerifiedSet(ctx, []byte("key1"), []byte("value2"))
+	require.NoError(t, err)
+
+	_, err = ecli.SetAll(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value3")}}})
+	require.NoError(t, err)
+
+	entry, err := cli.Get(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.NotContains(t, string(entry.Value), "value2")
+
+	entry, err = ecli.Get(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), entry.Value)
+
+	entry, err = ecli.VerifiedGetAt(ctx, []byte("key1"), hdr.Id)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), entry.Value)
+
+	entries, err := ecli.History(ctx, &schema.HistoryRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+	require.Equal(t, []byte("value1"), entries.Entries[0].Value)
+	require.Equal(t, []byte("value2"), entries.Entries[1].Value)
+
+	entries, err = ecli.Scan(ctx, &schema.ScanRequest{Prefix: []byte("key")})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+	require.Equal(t, []byte("value3"), entries.Entries[1].Value)
+
+	_, err = cli.Set(ctx, []byte("plain"), []byte("value"))
+	require.NoError(t, err)
+
+	_, err = ecli.Get(ctx, []byte("plain"))
+	require.Error(t, err)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package envelope implements client-side envelope encryption of values.
+// Each value is encrypted with a fresh AES-256-GCM data key which is in turn
+// wrapped by a KeyProvider, so plain values are never seen by the server.
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+)
+
+const version = 1
+
+const dataKeySize = 32
+
+// Sealer encrypts and decrypts values using data keys wrapped by a KeyProvider
+type Sealer struct {
+	kp KeyProvider
+}
+
+// NewSealer returns a Sealer relying on the given KeyProvider
+func NewSealer(kp KeyProvider) *Sealer {
+	return &Sealer{kp: kp}
+}
+
+// Seal encrypts value, binding the result to the given key.
+// The envelope is encoded as:
+// version (1 byte) | keyID length (2 bytes) | keyID | wrapped key length (2 bytes) | wrapped key | nonce | ciphertext
+func (s *Sealer) Seal(ctx context.Context, key, value []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, wrappedKey, err := s.kp.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyID) > 0xffff || len(wrappedKey) > 0xffff {
+		return nil, ErrIllegalArguments
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 1+2+len(keyID)+2+len(wrappedKey)+aead.NonceSize(), 1+2+len(keyID)+2+len(wrappedKey)+aead.NonceSize()+len(value)+aead.Overhead())
+	i := 0
+
+	b[i] = version
+	i++
+
+	binary.BigEndian.PutUint16(b[i:], uint16(len(keyID)))
+	i += 2
+
+	copy(b[i:], keyID)
+	i += len(keyID)
+
+	binary.BigEndian.PutUint16(b[i:], uint16(len(wrappedKey)))
+	i += 2
+
+	copy(b[i:], wrappedKey)
+	i += len(wrappedKey)
+
+	nonce := b[i:]
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(b, nonce, value, key), nil
+}
+
+// Open decrypts an envelope previously created by Seal for the same key
+func (s *Sealer) Open(ctx context.Context, key, envelope []byte) ([]byte, error) {
+	if len(envelope) < 1 {
+		return nil, ErrInvalidEnvelope
+	}
+
+	if envelope[0] != version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	i := 1
+
+	if len(envelope) < i+2 {
+		return nil, ErrInvalidEnvelope
+	}
+	keyIDLen := int(binary.BigEndian.Uint16(envelope[i:]))
+	i += 2
+
+	if len(envelope) < i+keyIDLen {
+		return nil, ErrInvalidEnvelope
+	}
+	keyID := string(envelope[i : i+keyIDLen])
+	i += keyIDLen
+
+	if len(envelope) < i+2 {
+		return nil, ErrInvalidEnvelope
+	}
+	wrappedKeyLen := int(binary.BigEndian.Uint16(envelope[i:]))
+	i += 2
+
+	if len(envelope) < i+wrappedKeyLen {
+		return nil, ErrInvalidEnvelope
+	}
+	wrappedKey := envelope[i : i+wrappedKeyLen]
+	i += wrappedKeyLen
+
+	dataKey, err := s.kp.UnwrapKey(ctx, keyID, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelope) < i+aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+
+	return aead.Open(nil, envelope[i:i+aead.NonceSize()], envelope[i+aead.NonceSize():], key)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealer(t *testing.T) {
+	masterKey1 := make([]byte, 32)
+	masterKey2 := make([]byte, 32)
+	masterKey2[0] = 1
+
+	_, err := NewStaticKeyProvider(map[string][]byte{"k1": masterKey1}, "k2")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewStaticKeyProvider(map[string][]byte{"k1": {1, 2, 3}}, "k1")
+	require.Error(t, err)
+
+	kp1, err := NewStaticKeyProvider(map[string][]byte{"k1": masterKey1}, "k1")
+	require.NoError(t, err)
+
+	s1 := NewSealer(kp1)
+
+	ctx := context.Background()
+
+	sealed, err := s1.Seal(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "value1")
+
+	value, err := s1.Open(ctx, []byte("key1"), sealed)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), value)
+
+	_, err = s1.Open(ctx, []byte("key2"), sealed)
+	require.Error(t, err)
+
+	// master key rotation
+	kp2, err := NewStaticKeyProvider(map[string][]byte{"k1": masterKey1, "k2": masterKey2}, "k2")
+	require.NoError(t, err)
+
+	s2 := NewSealer(kp2)
+
+	value, err = s2.Open(ctx, []byte("key1"), sealed)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), value)
+
+	sealed2, err := s2.Seal(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	_, err = s1.Open(ctx, []byte("key1"), sealed2)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = s1.Open(ctx, []byte("key1"), nil)
+	require.ErrorIs(t, err, ErrInvalidEnvelope)
+
+	_, err = s1.Open(ctx, []byte("key1"), []byte{0})
+	require.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	for i := 1; i < len(sealed)-1; i++ {
+		_, err = s1.Open(ctx, []byte("key1"), sealed[:i])
+		require.Error(t, err)
+	}
+}
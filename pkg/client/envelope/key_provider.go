@@ -0,0 +1,100 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// KeyProvider wraps and unwraps the data keys used to encrypt values.
+// Implementations are expected to delegate to a KMS or any other facility
+// holding the master keys outside of the immudb server.
+type KeyProvider interface {
+	// WrapKey encrypts a data key returning the id of the master key being used
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrappedKey []byte, err error)
+	// UnwrapKey decrypts a data key previously wrapped with the master key identified by keyID
+	UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) (dataKey []byte, err error)
+}
+
+type staticKeyProvider struct {
+	keys         map[string]cipher.AEAD
+	currentKeyID string
+}
+
+// NewStaticKeyProvider returns a KeyProvider holding the master keys in memory.
+// New data keys are always wrapped with the master key identified by currentKeyID,
+// while the remaining ones are kept to unwrap data keys created before a rotation.
+func NewStaticKeyProvider(keys map[string][]byte, currentKeyID string) (KeyProvider, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, fmt.Errorf("%w: current master key '%s' not provided", ErrIllegalArguments, currentKeyID)
+	}
+
+	kp := &staticKeyProvider{
+		keys:         make(map[string]cipher.AEAD, len(keys)),
+		currentKeyID: currentKeyID,
+	}
+
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		kp.keys[id] = aead
+	}
+
+	return kp, nil
+}
+
+func (kp *staticKeyProvider) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	aead := kp.keys[kp.currentKeyID]
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return kp.currentKeyID, aead.Seal(nonce, nonce, dataKey, []byte(kp.currentKeyID)), nil
+}
+
+func (kp *staticKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	aead, ok := kp.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrKeyNotFound, keyID)
+	}
+
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+
+	return aead.Open(nil, wrappedKey[:aead.NonceSize()], wrappedKey[aead.NonceSize():], []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
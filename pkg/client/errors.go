@@ -18,6 +18,7 @@ package client
 
 import (
 	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/codenotary/immudb/pkg/client/state"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -29,10 +30,34 @@ var (
 	ErrAlreadyConnected   = errors.New("already connected")
 	ErrNotConnected       = errors.New("not connected")
 	ErrHealthCheckFailed  = errors.New("health check failed")
-	ErrServerStateIsOlder = errors.New("server state is older than the client one")
+	ErrServerStateIsOlder = state.ErrServerStateIsOlder
 	ErrSessionAlreadyOpen = errors.New("session already opened")
 )
 
+// Errors related to server state signature verification
+var (
+	ErrStateSignatureMissing  = errors.New("server state is not signed")
+	ErrStateSignatureMismatch = errors.New("server state signature doesn't match the provided public key")
+)
+
+// Errors related to server states not consistent with the ones already verified
+var (
+	ErrStateRollback = state.ErrStateRollback
+	ErrStateForked   = state.ErrStateForked
+)
+
+// Errors related to cosigned state verification
+var (
+	ErrCosignersNotSet            = errors.New("trusted cosigners not set")
+	ErrCosignatureThresholdNotMet = errors.New("state not cosigned by enough trusted cosigners")
+)
+
+// Errors related to audit report verification
+var (
+	ErrAuditReportSignatureMissing  = errors.New("audit report is not signed")
+	ErrAuditReportSignatureMismatch = errors.New("audit report signature doesn't match the provided public key")
+)
+
 // Server errors mapping
 var (
 	ErrSrvIllegalArguments   = status.Error(codes.InvalidArgument, "illegal arguments")
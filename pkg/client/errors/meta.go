@@ -30,4 +30,7 @@ const (
 	CodInvalidDatabaseName                           Code = "3F000"
 	CodNoSessionAuthDataProvided                     Code = "28000"
 	CodInFailedSqlTransaction                        Code = "25P02"
+	CodInsufficientResources                         Code = "53000"
+	CodFeatureNotSupported                           Code = "0A000"
+	CodObjectNotInPrerequisiteState                  Code = "55000"
 )
@@ -0,0 +1,199 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/pkg/client/errors"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// VerifiedExclusion returns the proof that key was not set up to the transaction atTx,
+// the last committed one when atTx is zero, once verified against the local state.
+// The keys set right before and after key are proven to be set as of atTx,
+// their being the closest ones is attested by the server signature.
+func (c *immuClient) VerifiedExclusion(ctx context.Context, key []byte, atTx uint64) (*schema.ExclusionProof, error) {
+	err := c.StateService.CacheLock()
+	if err != nil {
+		return nil, err
+	}
+	defer c.StateService.CacheUnlock()
+
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	state, err := c.StateService.GetState(ctx, c.Options.CurrentDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := c.ServiceClient.ExclusionProof(ctx, &schema.ExclusionProofRequest{
+		Key:          key,
+		AtTx:         atTx,
+		ProveSinceTx: state.TxId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(key, proof.Key) || (atTx > 0 && atTx != proof.AtTx) {
+		return nil, store.ErrCorruptedData
+	}
+
+	dualProof, err := schema.DualProofFromProto(proof.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	var atTxAlh [sha256.Size]byte
+
+	var sourceID, targetID uint64
+	var sourceAlh, targetAlh [sha256.Size]byte
+
+	if state.TxId <= proof.AtTx {
+		atTxAlh = dualProof.TargetTxHeader.Alh()
+
+		sourceID = state.TxId
+		sourceAlh = schema.DigestFromProto(state.TxHash)
+		targetID = proof.AtTx
+		targetAlh = atTxAlh
+	} else {
+		atTxAlh = dualProof.SourceTxHeader.Alh()
+
+		sourceID = proof.AtTx
+		sourceAlh = atTxAlh
+		targetID = state.TxId
+		targetAlh = schema.DigestFromProto(state.TxHash)
+	}
+
+	if state.TxId > 0 {
+		verifies := store.VerifyDualProof(
+			dualProof,
+			sourceID,
+			targetID,
+			sourceAlh,
+			targetAlh,
+		)
+		if !verifies {
+			return nil, store.ErrCorruptedData
+		}
+	} else if dualProof.TargetTxHeader.ID != proof.AtTx {
+		return nil, store.ErrCorruptedData
+	}
+
+	encKey := database.EncodeKey(key)
+
+	if proof.Predecessor != nil {
+		err = verifyKeyInclusion(proof.Predecessor, proof.AtTx, atTxAlh)
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Compare(proof.Predecessor.Entry.Key, encKey) >= 0 {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	if proof.Successor != nil {
+		err = verifyKeyInclusion(proof.Successor, proof.AtTx, atTxAlh)
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Compare(proof.Successor.Entry.Key, encKey) <= 0 {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	newState := &schema.ImmutableState{
+		Db:        c.currentDatabase(),
+		TxId:      targetID,
+		TxHash:    targetAlh[:],
+		Signature: proof.Signature,
+	}
+
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
+	if err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// verifyKeyInclusion verifies the key of a plain key-value was set by a transaction committed at or before atTx
+func verifyKeyInclusion(ki *schema.KeyInclusion, atTx uint64, atTxAlh [sha256.Size]byte) error {
+	if ki.Entry == nil || ki.InclusionProof == nil || ki.DualProof == nil {
+		return store.ErrCorruptedData
+	}
+
+	if len(ki.Entry.Key) == 0 || ki.Entry.Key[0] != database.SetKeyPrefix {
+		return store.ErrCorruptedData
+	}
+
+	dualProof, err := schema.DualProofFromProto(ki.DualProof)
+	if err != nil {
+		return err
+	}
+
+	hdr := dualProof.SourceTxHeader
+
+	verifies := store.VerifyDualProof(
+		dualProof,
+		hdr.ID,
+		atTx,
+		hdr.Alh(),
+		atTxAlh,
+	)
+	if !verifies {
+		return store.ErrCorruptedData
+	}
+
+	txEntryDigest, err := store.TxEntryDigestFor(hdr.Version, hdr.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	e := store.NewTxEntry(
+		ki.Entry.Key,
+		schema.KVMetadataFromProto(ki.Entry.Metadata),
+		int(ki.Entry.VLen),
+		schema.DigestFromProto(ki.Entry.HValue),
+		0,
+	)
+
+	verifies = store.VerifyInclusion(
+		schema.InclusionProofFromProto(ki.InclusionProof),
+		txEntryDigest(e),
+		hdr.Eh,
+		hdr.HashAlgorithm)
+	if !verifies {
+		return store.ErrCorruptedData
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+)
+
+// VerifiedGlobalState returns the root aggregating the state of all the databases of the server.
+// The states of all the databases are returned when database is empty, otherwise the state of
+// database is returned along with its proof of inclusion. The returned states are checked to be
+// aggregated in the root, whose signature is checked when the public key of the server is set.
+func (c *immuClient) VerifiedGlobalState(ctx context.Context, database string) (*schema.GlobalState, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	globalState, err := c.ServiceClient.GlobalState(ctx, &schema.GlobalStateRequest{Database: database})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.stateSignatureVerificationEnabled() {
+		if globalState.Signature == nil {
+			return nil, ErrStateSignatureMissing
+		}
+
+		ok, err := globalState.CheckSignature(c.serverSigningPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStateSignatureMismatch, err)
+		}
+		if !ok {
+			return nil, ErrStateSignatureMismatch
+		}
+	}
+
+	if database != "" && (len(globalState.States) != 1 || globalState.States[0].Db != database) {
+		return nil, store.ErrCorruptedData
+	}
+
+	for _, state := range globalState.States {
+		if !globalState.VerifyInclusion(state) {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	return globalState, nil
+}
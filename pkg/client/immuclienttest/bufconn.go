@@ -0,0 +1,107 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package immuclienttest provides an immudb client connected to an in-process server, meant to be used in unit tests
+package immuclienttest
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"google.golang.org/grpc"
+)
+
+type stoppable interface {
+	Stop() error
+}
+
+// BufconnClient is a fully functional ImmuClient connected through a bufconn
+// listener to an immudb server running inside the same process, data is stored on disk.
+// Transaction ids are deterministic, a new instance always assigns the same ids to the
+// same sequence of writes, and proofs are the ones computed by the real engine so
+// verified operations work as usual.
+type BufconnClient struct {
+	client.ImmuClient
+
+	srv stoppable
+	dir string
+}
+
+// NewBufconnClient starts an in-process immudb server backed by a temporary directory
+// and returns a client with an open session against the default database.
+// Close must be called to release all the resources.
+func NewBufconnClient() (*BufconnClient, error) {
+	dir, err := ioutil.TempDir("", "immuclienttest")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := server.DefaultOptions().
+		WithDir(dir).
+		WithAuth(true).
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false)
+
+	bs := servertest.NewBufconnServer(opts)
+
+	err = bs.Start()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	clientOpts := client.DefaultOptions().
+		WithDir(dir).
+		WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+
+	c := client.NewClient().WithOptions(clientOpts)
+
+	err = c.OpenSession(context.Background(), []byte(auth.SysAdminUsername), []byte(auth.SysAdminPassword), client.DefaultDB)
+	if err != nil {
+		bs.Stop()
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &BufconnClient{
+		ImmuClient: c,
+		srv:        bs,
+		dir:        dir,
+	}, nil
+}
+
+// Close closes the session and the connection, stops the in-process server and removes its data
+func (c *BufconnClient) Close() error {
+	defer os.RemoveAll(c.dir)
+
+	err := c.CloseSession(context.Background())
+	if err != nil {
+		return err
+	}
+
+	err = c.Disconnect()
+	if err != nil {
+		return err
+	}
+
+	return c.srv.Stop()
+}
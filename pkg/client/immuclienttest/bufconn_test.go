@@ -0,0 +1,60 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclienttest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufconnClient(t *testing.T) {
+	c, err := NewBufconnClient()
+	require.NoError(t, err)
+
+	dir := c.dir
+
+	ctx := context.Background()
+
+	hdr1, err := c.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	hdr2, err := c.VerifiedSet(ctx, []byte("key2"), []byte("value2"))
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id+1, hdr2.Id)
+
+	entry, err := c.VerifiedGet(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+	require.Equal(t, hdr1.Id, entry.Tx)
+
+	err = c.Close()
+	require.NoError(t, err)
+
+	_, err = os.Stat(dir)
+	require.True(t, os.IsNotExist(err))
+
+	c, err = NewBufconnClient()
+	require.NoError(t, err)
+	defer c.Close()
+
+	hdr, err := c.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, hdr.Id)
+}
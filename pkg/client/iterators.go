@@ -0,0 +1,191 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// DefaultIteratorPageSize is the number of entries fetched per request when no limit is specified
+const DefaultIteratorPageSize = 100
+
+// ScanIterator transparently fetches successive pages of a scan, using the last returned key as seek key
+type ScanIterator struct {
+	ctx context.Context
+	c   ImmuClient
+	req *schema.ScanRequest
+
+	page  []*schema.Entry
+	i     int
+	entry *schema.Entry
+	done  bool
+	err   error
+}
+
+// NewScanIterator returns an iterator over all the entries matching the scan request.
+// The limit of the request, if any, is used as page size.
+func NewScanIterator(ctx context.Context, c ImmuClient, req *schema.ScanRequest) *ScanIterator {
+	pageSize := req.Limit
+	if pageSize == 0 {
+		pageSize = DefaultIteratorPageSize
+	}
+
+	return &ScanIterator{
+		ctx: ctx,
+		c:   c,
+		req: &schema.ScanRequest{
+			SeekKey: req.SeekKey,
+			Prefix:  req.Prefix,
+			Desc:    req.Desc,
+			Limit:   pageSize,
+			SinceTx: req.SinceTx,
+			NoWait:  req.NoWait,
+		},
+	}
+}
+
+// Next advances the iterator to the next entry, fetching a new page when needed.
+// It returns false once all the entries were consumed or an error occurred.
+func (it *ScanIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.i == len(it.page) {
+		if it.done {
+			return false
+		}
+
+		entries, err := it.c.Scan(it.ctx, it.req)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = entries.Entries
+		it.i = 0
+		it.done = uint64(len(it.page)) < it.req.Limit
+
+		if len(it.page) == 0 {
+			return false
+		}
+
+		last := it.page[len(it.page)-1]
+
+		if last.ReferencedBy != nil {
+			it.req.SeekKey = last.ReferencedBy.Key
+		} else {
+			it.req.SeekKey = last.Key
+		}
+	}
+
+	it.entry = it.page[it.i]
+	it.i++
+
+	return true
+}
+
+// Entry returns the current entry
+func (it *ScanIterator) Entry() *schema.Entry {
+	return it.entry
+}
+
+// Err returns the error, if any, that stopped the iteration
+func (it *ScanIterator) Err() error {
+	return it.err
+}
+
+// HistoryIterator transparently fetches successive pages of the history of a key, advancing the offset
+type HistoryIterator struct {
+	ctx context.Context
+	c   ImmuClient
+	req *schema.HistoryRequest
+
+	page  []*schema.Entry
+	i     int
+	entry *schema.Entry
+	done  bool
+	err   error
+}
+
+// NewHistoryIterator returns an iterator over the whole history of the requested key.
+// The limit of the request, if any, is used as page size.
+func NewHistoryIterator(ctx context.Context, c ImmuClient, req *schema.HistoryRequest) *HistoryIterator {
+	pageSize := req.Limit
+	if pageSize == 0 {
+		pageSize = DefaultIteratorPageSize
+	}
+
+	return &HistoryIterator{
+		ctx: ctx,
+		c:   c,
+		req: &schema.HistoryRequest{
+			Key:     req.Key,
+			Offset:  req.Offset,
+			Limit:   pageSize,
+			Desc:    req.Desc,
+			SinceTx: req.SinceTx,
+		},
+	}
+}
+
+// Next advances the iterator to the next entry, fetching a new page when needed.
+// It returns false once all the entries were consumed or an error occurred.
+func (it *HistoryIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.i == len(it.page) {
+		if it.done {
+			return false
+		}
+
+		entries, err := it.c.History(it.ctx, it.req)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = entries.Entries
+		it.i = 0
+		it.done = len(it.page) < int(it.req.Limit)
+
+		if len(it.page) == 0 {
+			return false
+		}
+
+		it.req.Offset += uint64(len(it.page))
+	}
+
+	it.entry = it.page[it.i]
+	it.i++
+
+	return true
+}
+
+// Entry returns the current entry
+func (it *HistoryIterator) Entry() *schema.Entry {
+	return it.entry
+}
+
+// Err returns the error, if any, that stopped the iteration
+func (it *HistoryIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/pkg/client/errors"
+
+	"github.com/codenotary/immudb/embedded/hashing"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// Notarize records the digest of a document together with its metadata, the returned notarization
+// is verified against the local state before being returned.
+// A digest is notarized only once, notarizing it again returns the original notarization.
+func (c *immuClient) Notarize(ctx context.Context, digest []byte, hashAlgorithm string, metadata map[string]string) (*schema.Notarization, error) {
+	return c.verifiedNotarization(ctx, digest, func(proveSinceTx uint64) (*schema.Notarization, error) {
+		return c.ServiceClient.Notarize(ctx, &schema.NotarizeRequest{
+			Digest:        digest,
+			HashAlgorithm: hashAlgorithm,
+			Metadata:      metadata,
+			ProveSinceTx:  proveSinceTx,
+		})
+	})
+}
+
+// VerifyNotarization returns the notarization of a digest once verified against the local state
+func (c *immuClient) VerifyNotarization(ctx context.Context, digest []byte) (*schema.Notarization, error) {
+	return c.verifiedNotarization(ctx, digest, func(proveSinceTx uint64) (*schema.Notarization, error) {
+		return c.ServiceClient.VerifyNotarization(ctx, &schema.VerifyNotarizationRequest{
+			Digest:       digest,
+			ProveSinceTx: proveSinceTx,
+		})
+	})
+}
+
+func (c *immuClient) verifiedNotarization(
+	ctx context.Context,
+	digest []byte,
+	notarize func(proveSinceTx uint64) (*schema.Notarization, error)) (*schema.Notarization, error) {
+
+	err := c.StateService.CacheLock()
+	if err != nil {
+		return nil, err
+	}
+	defer c.StateService.CacheUnlock()
+
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	state, err := c.StateService.GetState(ctx, c.Options.CurrentDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	notarization, err := notarize(state.TxId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(digest, notarization.Digest) {
+		return nil, store.ErrCorruptedData
+	}
+
+	hashAlg := hashing.Algorithm(notarization.VerifiableTx.Tx.Header.HashAlgorithm)
+
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(notarization.VerifiableTx.Tx.Header.Version), hashAlg)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := database.EncodeNotarization(notarization.Digest, notarization.HashAlgorithm, notarization.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	inclusionProof := schema.InclusionProofFromProto(notarization.InclusionProof)
+	dualProof, err := schema.DualProofFromProto(notarization.VerifiableTx.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr *store.TxHeader
+
+	var sourceID, targetID uint64
+	var sourceAlh, targetAlh [sha256.Size]byte
+
+	if state.TxId <= notarization.Tx {
+		hdr = dualProof.TargetTxHeader
+
+		sourceID = state.TxId
+		sourceAlh = schema.DigestFromProto(state.TxHash)
+		targetID = notarization.Tx
+		targetAlh = dualProof.TargetTxHeader.Alh()
+	} else {
+		hdr = dualProof.SourceTxHeader
+
+		sourceID = notarization.Tx
+		sourceAlh = dualProof.SourceTxHeader.Alh()
+		targetID = state.TxId
+		targetAlh = schema.DigestFromProto(state.TxHash)
+	}
+
+	// the notarization time is the one of the transaction including the digest
+	if hdr.ID != notarization.Tx || hdr.Ts != notarization.NotarizedAt {
+		return nil, store.ErrCorruptedData
+	}
+
+	verifies := store.VerifyInclusion(
+		inclusionProof,
+		entrySpecDigest(e),
+		hdr.Eh,
+		hashAlg)
+	if !verifies {
+		return nil, store.ErrCorruptedData
+	}
+
+	if state.TxId > 0 {
+		verifies = store.VerifyDualProof(
+			dualProof,
+			sourceID,
+			targetID,
+			sourceAlh,
+			targetAlh,
+		)
+		if !verifies {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	newState := &schema.ImmutableState{
+		Db:        c.currentDatabase(),
+		TxId:      targetID,
+		TxHash:    targetAlh[:],
+		Signature: notarization.VerifiableTx.Signature,
+	}
+
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
+	if err != nil {
+		return nil, err
+	}
+
+	return notarization, nil
+}
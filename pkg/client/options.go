@@ -50,33 +50,37 @@ type Options struct {
 	Password       string
 	Database       string
 	//<--
-	Metrics             bool
-	PidPath             string
-	LogFileName         string
-	ServerSigningPubKey string
-	StreamChunkSize     int
-	HeartBeatFrequency  time.Duration
+	Metrics              bool
+	PidPath              string
+	LogFileName          string
+	ServerSigningPubKey  string
+	VerifyStateSignature bool
+	StreamChunkSize      int
+	HeartBeatFrequency   time.Duration
+	CosignerPubKeys      []string
+	CosignatureThreshold int
 }
 
 // DefaultOptions ...
 func DefaultOptions() *Options {
 	return &Options{
-		Dir:                 ".",
-		Address:             "127.0.0.1",
-		Port:                3322,
-		HealthCheckRetries:  5,
-		MTLs:                false,
-		Auth:                true,
-		MaxRecvMsgSize:      4 * 1024 * 1024, //4Mb
-		Config:              "configs/immuclient.toml",
-		DialOptions:         []grpc.DialOption{grpc.WithInsecure()},
-		PasswordReader:      c.DefaultPasswordReader,
-		Metrics:             true,
-		PidPath:             "",
-		LogFileName:         "",
-		ServerSigningPubKey: "",
-		StreamChunkSize:     stream.DefaultChunkSize,
-		HeartBeatFrequency:  time.Minute * 1,
+		Dir:                  ".",
+		Address:              "127.0.0.1",
+		Port:                 3322,
+		HealthCheckRetries:   5,
+		MTLs:                 false,
+		Auth:                 true,
+		MaxRecvMsgSize:       4 * 1024 * 1024, //4Mb
+		Config:               "configs/immuclient.toml",
+		DialOptions:          []grpc.DialOption{grpc.WithInsecure()},
+		PasswordReader:       c.DefaultPasswordReader,
+		Metrics:              true,
+		PidPath:              "",
+		LogFileName:          "",
+		ServerSigningPubKey:  "",
+		VerifyStateSignature: true,
+		StreamChunkSize:      stream.DefaultChunkSize,
+		HeartBeatFrequency:   time.Minute * 1,
 	}
 }
 
@@ -201,6 +205,21 @@ func (o *Options) WithServerSigningPubKey(serverSigningPubKey string) *Options {
 	return o
 }
 
+// WithVerifyStateSignature sets whether server state signatures are verified against the public key, enabled by default.
+// When enabled, unsigned states or signatures not matching the public key make the client calls fail
+func (o *Options) WithVerifyStateSignature(verify bool) *Options {
+	o.VerifyStateSignature = verify
+	return o
+}
+
+// WithCosignerPubKeys sets the public keys of the auditors trusted to cosign the state of the databases,
+// cosigned states are only trusted when signed by at least threshold of them
+func (o *Options) WithCosignerPubKeys(cosignerPubKeys []string, threshold int) *Options {
+	o.CosignerPubKeys = cosignerPubKeys
+	o.CosignatureThreshold = threshold
+	return o
+}
+
 // WithStreamChunkSize set the chunk size
 func (o *Options) WithStreamChunkSize(streamChunkSize int) *Options {
 	o.StreamChunkSize = streamChunkSize
@@ -43,7 +43,8 @@ func TestOptions(t *testing.T) {
 		WithUsername("some-username").
 		WithPassword("some-password").
 		WithDatabase("some-db").
-		WithStreamChunkSize(4096)
+		WithStreamChunkSize(4096).
+		WithVerifyStateSignature(false)
 
 	if op.LogFileName != "logfilename" ||
 		op.PidPath != "pidpath" ||
@@ -65,8 +66,13 @@ func TestOptions(t *testing.T) {
 		op.Password != "some-password" ||
 		op.Database != "some-db" ||
 		op.StreamChunkSize != 4096 ||
+		op.VerifyStateSignature ||
 		op.Bind() != "127.0.0.1:4321" ||
 		len(op.String()) == 0 {
 		t.Fatal("Client options fail")
 	}
+
+	if !DefaultOptions().VerifyStateSignature {
+		t.Fatal("state signature verification must be enabled by default")
+	}
 }
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/proofs"
+	"github.com/codenotary/immudb/pkg/verifier"
+)
+
+// ExportProofBundle returns the proofs of the current values of keys, all of them proven against
+// the current state of the database, so they can be verified offline by the immuverify tool.
+// The bundle includes the proof of consistency with the transaction sinceTx when it's not zero,
+// so it can be checked against a state trusted by whom the bundle is handed to.
+// References are not supported, the bundle is verified before being returned.
+func (c *immuClient) ExportProofBundle(ctx context.Context, keys [][]byte, sinceTx uint64) (*proofs.Bundle, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	// the values are read before the state so they are all included in it
+	entries := make([]*schema.Entry, len(keys))
+
+	for i, key := range keys {
+		entry, err := c.ServiceClient.Get(ctx, &schema.KeyRequest{Key: key})
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.ReferencedBy != nil {
+			return nil, fmt.Errorf("%w: key '%s' is a reference", ErrIllegalArguments, key)
+		}
+
+		entries[i] = entry
+	}
+
+	state, err := c.ServiceClient.CurrentState(ctx, &empty.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.verifyStateSignature(state)
+	if err != nil {
+		return nil, err
+	}
+
+	signedState, err := proofs.FromImmutableState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &proofs.Bundle{
+		State:   signedState,
+		Entries: make([]*proofs.EntryProof, len(keys)),
+	}
+
+	for i, entry := range entries {
+		vEntry, err := c.ServiceClient.VerifiableGet(ctx, &schema.VerifiableGetRequest{
+			KeyRequest:   &schema.KeyRequest{Key: entry.Key, AtTx: entry.Tx},
+			ProveSinceTx: state.TxId,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		dualProof, err := schema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+		if err != nil {
+			return nil, err
+		}
+
+		bundle.Entries[i] = &proofs.EntryProof{
+			Key:            vEntry.Entry.Key,
+			Value:          vEntry.Entry.Value,
+			Metadata:       proofs.FromKVMetadata(vEntry.Entry.Metadata),
+			Tx:             vEntry.Entry.Tx,
+			InclusionProof: proofs.FromInclusionProof(schema.InclusionProofFromProto(vEntry.InclusionProof)),
+			DualProof:      proofs.FromDualProof(dualProof),
+		}
+	}
+
+	if sinceTx > 0 && sinceTx != state.TxId {
+		sourceTx, targetTx := sinceTx, state.TxId
+		if sinceTx > state.TxId {
+			sourceTx, targetTx = state.TxId, sinceTx
+		}
+
+		dualProof, err := c.ServiceClient.ConsistencyProof(ctx, &schema.ConsistencyProofRequest{
+			SourceTx: sourceTx,
+			TargetTx: targetTx,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		consistencyProof, err := schema.DualProofFromProto(dualProof)
+		if err != nil {
+			return nil, err
+		}
+
+		bundle.Consistency = proofs.FromDualProof(consistencyProof)
+	}
+
+	err = verifier.VerifyBundle(bundle, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/codenotary/immudb/pkg/client/errors"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// VerifiedSampledAudit spot checks sampleSize transactions in the range [fromTx, toTx] selected out of seed.
+// The sample is recomputed locally, so the server can not choose which transactions are checked.
+// The contents of every sampled transaction are verified to match its header, which is in turn
+// verified to be part of the history of the last committed transaction, linked to the local state.
+// fromTx and toTx default to the first and to the last committed transactions when zero.
+func (c *immuClient) VerifiedSampledAudit(ctx context.Context, seed []byte, fromTx, toTx uint64, sampleSize int) (*schema.SampledAudit, error) {
+	if len(seed) == 0 || sampleSize <= 0 {
+		return nil, errors.FromError(ErrIllegalArguments)
+	}
+
+	err := c.StateService.CacheLock()
+	if err != nil {
+		return nil, err
+	}
+	defer c.StateService.CacheUnlock()
+
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	state, err := c.StateService.GetState(ctx, c.Options.CurrentDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := c.ServiceClient.SampledAudit(ctx, &schema.SampledAuditRequest{
+		Seed:         seed,
+		FromTx:       fromTx,
+		ToTx:         toTx,
+		SampleSize:   uint32(sampleSize),
+		ProveSinceTx: state.TxId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if audit.DualProof == nil || !bytes.Equal(seed, audit.Seed) {
+		return nil, store.ErrCorruptedData
+	}
+
+	dualProof, err := schema.DualProofFromProto(audit.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	targetHdr := dualProof.TargetTxHeader
+	targetAlh := targetHdr.Alh()
+
+	if state.TxId > 0 {
+		verifies := store.VerifyDualProof(
+			dualProof,
+			state.TxId,
+			targetHdr.ID,
+			schema.DigestFromProto(state.TxHash),
+			targetAlh,
+		)
+		if !verifies {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	if fromTx == 0 {
+		fromTx = 1
+	}
+
+	if toTx == 0 {
+		toTx = targetHdr.ID
+	}
+
+	if audit.FromTx != fromTx || audit.ToTx != toTx {
+		return nil, store.ErrCorruptedData
+	}
+
+	txIDs, err := database.SampleTxs(seed, fromTx, toTx, sampleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(audit.Txs) != len(txIDs) || len(audit.TxProofs) != len(txIDs) {
+		return nil, store.ErrCorruptedData
+	}
+
+	for i, txID := range txIDs {
+		stx := audit.Txs[i]
+		if stx == nil || stx.Header == nil || stx.Header.Id != txID || audit.TxProofs[i] == nil {
+			return nil, store.ErrCorruptedData
+		}
+
+		// the header is rebuilt out of the entries of the transaction so its contents are verified as well
+		tx, err := schema.TxFromProto(stx)
+		if err != nil {
+			return nil, err
+		}
+
+		proof, err := schema.TxInclusionProofFromProto(audit.TxProofs[i])
+		if err != nil {
+			return nil, err
+		}
+
+		proof.TxHeader = tx.Header()
+
+		if !store.VerifyTxInclusion(proof, targetHdr) {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	newState := &schema.ImmutableState{
+		Db:        c.currentDatabase(),
+		TxId:      targetHdr.ID,
+		TxHash:    targetAlh[:],
+		Signature: audit.Signature,
+	}
+
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
+	if err != nil {
+		return nil, err
+	}
+
+	return audit, nil
+}
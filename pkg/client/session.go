@@ -18,7 +18,6 @@ func (c *immuClient) OpenSession(ctx context.Context, user []byte, pass []byte,
 	if c.SessionID != "" {
 		return ErrSessionAlreadyOpen
 	}
-	c.Options.DialOptions = c.SetupDialOptions(c.Options)
 
 	if c.Options.ServerSigningPubKey != "" {
 		pk, e := signer.ParsePublicKeyFile(c.Options.ServerSigningPubKey)
@@ -28,6 +27,9 @@ func (c *immuClient) OpenSession(ctx context.Context, user []byte, pass []byte,
 		c.WithServerSigningPubKey(pk)
 	}
 
+	// dial options depend on the public key, e.g. to verify the state signatures
+	c.Options.DialOptions = c.SetupDialOptions(c.Options)
+
 	if c.Options.StreamChunkSize < stream.MinChunkSize {
 		return errors.New(stream.ErrChunkTooSmall).WithCode(errors.CodInvalidParameterValue)
 	}
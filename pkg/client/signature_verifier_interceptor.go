@@ -18,6 +18,7 @@ package client
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"google.golang.org/grpc"
@@ -31,15 +32,38 @@ func (c *immuClient) SignatureVerifierInterceptor(ctx context.Context, method st
 	if c.serverSigningPubKey == nil {
 		return status.Error(codes.FailedPrecondition, "public key not loaded")
 	}
+	if ris != nil {
+		return ris
+	}
 	if method == "/immudb.schema.ImmuService/CurrentState" {
-		state := reply.(*schema.ImmutableState)
-		ok, err := state.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return status.Errorf(codes.InvalidArgument, "unable to verify signature: %s", err)
-		}
-		if !ok {
-			return status.Error(codes.Canceled, "signature doesn't match provided public key")
-		}
+		return c.verifyStateSignature(reply.(*schema.ImmutableState))
+	}
+	return nil
+}
+
+// stateSignatureVerificationEnabled returns true when server states have to be verified against the public key
+func (c *immuClient) stateSignatureVerificationEnabled() bool {
+	return c.serverSigningPubKey != nil && (c.Options == nil || c.Options.VerifyStateSignature)
+}
+
+// verifyStateSignature checks the signature of the server state when verification is enabled.
+// Unsigned states are rejected as well, otherwise a server without the signing key could go unnoticed
+func (c *immuClient) verifyStateSignature(state *schema.ImmutableState) error {
+	if !c.stateSignatureVerificationEnabled() {
+		return nil
 	}
-	return ris
+
+	if state.GetSignature() == nil {
+		return ErrStateSignatureMissing
+	}
+
+	ok, err := state.CheckSignature(c.serverSigningPubKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStateSignatureMismatch, err)
+	}
+	if !ok {
+		return ErrStateSignatureMismatch
+	}
+
+	return nil
 }
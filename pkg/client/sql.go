@@ -24,6 +24,7 @@ import (
 
 	"github.com/codenotary/immudb/pkg/client/errors"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -108,13 +109,18 @@ func (c *immuClient) VerifyRow(ctx context.Context, row *schema.Row, table strin
 		return ErrIllegalArguments
 	}
 
-	entrySpecDigest, err := store.EntrySpecDigestFor(int(vEntry.VerifiableTx.Tx.Header.Version))
+	hashAlg := hashing.Algorithm(vEntry.VerifiableTx.Tx.Header.HashAlgorithm)
+
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(vEntry.VerifiableTx.Tx.Header.Version), hashAlg)
 	if err != nil {
 		return err
 	}
 
 	inclusionProof := schema.InclusionProofFromProto(vEntry.InclusionProof)
-	dualProof := schema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+	dualProof, err := schema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+	if err != nil {
+		return err
+	}
 
 	var eh [sha256.Size]byte
 
@@ -191,7 +197,8 @@ func (c *immuClient) VerifyRow(ctx context.Context, row *schema.Row, table strin
 	verifies := store.VerifyInclusion(
 		inclusionProof,
 		entrySpecDigest(e),
-		eh)
+		eh,
+		hashAlg)
 	if !verifies {
 		return store.ErrCorruptedData
 	}
@@ -216,14 +223,9 @@ func (c *immuClient) VerifyRow(ctx context.Context, row *schema.Row, table strin
 		Signature: vEntry.VerifiableTx.Signature,
 	}
 
-	if c.serverSigningPubKey != nil {
-		ok, err := newState.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return err
-		}
-		if !ok {
-			return store.ErrCorruptedData
-		}
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return err
 	}
 
 	err = c.StateService.SetState(c.currentDatabase(), newState)
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// DefaultStateHistorySize is the number of verified states remembered for each database
+const DefaultStateHistorySize = 64
+
+var (
+	// ErrServerStateIsOlder is returned when the server presents a state older than the one of the client
+	ErrServerStateIsOlder = errors.New("server state is older than the client one")
+	// ErrStateRollback is returned when the server presents a state older than the ones already verified
+	ErrStateRollback = errors.New("!IMPORTANT WARNING: server state was rolled back")
+	// ErrStateForked is returned when the server presents a state not matching the one already verified at the same transaction
+	ErrStateForked = errors.New("!IMPORTANT WARNING: server state was forked")
+)
+
+// StateConsistencyError holds the details of a state presented by the server which is not consistent
+// with the states already verified by the client, i.e. the server was rolled back or is showing
+// different views of the same database to different clients
+type StateConsistencyError struct {
+	Database  string
+	TxID      uint64
+	TxHash    []byte
+	KnownTxID uint64
+	KnownHash []byte
+	err       error
+}
+
+func (e *StateConsistencyError) Error() string {
+	return fmt.Sprintf("%s: database '%s' is at tx %d (%x) but tx %d (%x) was already verified",
+		e.err.Error(), e.Database, e.TxID, e.TxHash, e.KnownTxID, e.KnownHash)
+}
+
+func (e *StateConsistencyError) Unwrap() error {
+	return e.err
+}
+
+// Is makes a rollback match ErrServerStateIsOlder too, as a state older than the client one is a rollback
+func (e *StateConsistencyError) Is(target error) bool {
+	return target == ErrServerStateIsOlder && e.err == ErrStateRollback
+}
+
+// stateHistory keeps the most recent verified states of each database sorted by transaction
+type stateHistory struct {
+	size   int
+	states map[string][]*schema.ImmutableState
+}
+
+func newStateHistory(size int) *stateHistory {
+	return &stateHistory{
+		size:   size,
+		states: make(map[string][]*schema.ImmutableState),
+	}
+}
+
+// check returns an error when the state can not be proven consistent with all the states remembered
+// for the database. A state older than the latest one is accepted only when it is one of those remembered
+func (h *stateHistory) check(db string, state *schema.ImmutableState) error {
+	states := h.states[db]
+	if len(states) == 0 {
+		return nil
+	}
+
+	i := sort.Search(len(states), func(i int) bool { return states[i].TxId >= state.TxId })
+
+	if i < len(states) && states[i].TxId == state.TxId {
+		if !bytes.Equal(states[i].TxHash, state.TxHash) {
+			return &StateConsistencyError{
+				Database:  db,
+				TxID:      state.TxId,
+				TxHash:    state.TxHash,
+				KnownTxID: states[i].TxId,
+				KnownHash: states[i].TxHash,
+				err:       ErrStateForked,
+			}
+		}
+		return nil
+	}
+
+	if i < len(states) {
+		latest := states[len(states)-1]
+
+		return &StateConsistencyError{
+			Database:  db,
+			TxID:      state.TxId,
+			TxHash:    state.TxHash,
+			KnownTxID: latest.TxId,
+			KnownHash: latest.TxHash,
+			err:       ErrStateRollback,
+		}
+	}
+
+	return nil
+}
+
+// add remembers a state already checked, dropping the oldest one when the history is full
+func (h *stateHistory) add(db string, state *schema.ImmutableState) {
+	states := h.states[db]
+
+	if len(states) > 0 && states[len(states)-1].TxId >= state.TxId {
+		return
+	}
+
+	states = append(states, state)
+	if len(states) > h.size {
+		states = states[len(states)-h.size:]
+	}
+
+	h.states[db] = states
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/cache"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type stateProviderMock struct {
+	state *schema.ImmutableState
+}
+
+func (p *stateProviderMock) CurrentState(ctx context.Context) (*schema.ImmutableState, error) {
+	return p.state, nil
+}
+
+func immutableState(db string, txID uint64, hash byte) *schema.ImmutableState {
+	return &schema.ImmutableState{Db: db, TxId: txID, TxHash: []byte{hash}}
+}
+
+func TestStateHistory(t *testing.T) {
+	h := newStateHistory(3)
+
+	require.NoError(t, h.check("db1", immutableState("db1", 5, 5)))
+
+	for i := uint64(1); i <= 4; i++ {
+		h.add("db1", immutableState("db1", i*10, byte(i)))
+	}
+	require.Len(t, h.states["db1"], 3)
+
+	// older states are not remembered again
+	h.add("db1", immutableState("db1", 15, 15))
+	require.Len(t, h.states["db1"], 3)
+	require.Equal(t, uint64(40), h.states["db1"][2].TxId)
+
+	require.NoError(t, h.check("db1", immutableState("db1", 40, 4)))
+	require.NoError(t, h.check("db1", immutableState("db1", 20, 2)))
+	require.NoError(t, h.check("db1", immutableState("db1", 50, 5)))
+
+	// states of other databases are not related
+	require.NoError(t, h.check("db2", immutableState("db2", 1, 1)))
+
+	err := h.check("db1", immutableState("db1", 30, 1))
+	require.ErrorIs(t, err, ErrStateForked)
+	require.NotErrorIs(t, err, ErrServerStateIsOlder)
+
+	var consistencyErr *StateConsistencyError
+	require.True(t, errors.As(err, &consistencyErr))
+	require.Equal(t, "db1", consistencyErr.Database)
+	require.Equal(t, uint64(30), consistencyErr.TxID)
+	require.Equal(t, []byte{3}, consistencyErr.KnownHash)
+
+	err = h.check("db1", immutableState("db1", 35, 3))
+	require.ErrorIs(t, err, ErrStateRollback)
+	require.ErrorIs(t, err, ErrServerStateIsOlder)
+	require.True(t, errors.As(err, &consistencyErr))
+	require.Equal(t, uint64(40), consistencyErr.KnownTxID)
+
+	// states older than the ones remembered can not be proven consistent
+	require.ErrorIs(t, h.check("db1", immutableState("db1", 10, 1)), ErrStateRollback)
+}
+
+func TestStateServiceRollbackDetection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_service")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	stateProvider := &stateProviderMock{state: immutableState("db1", 10, 1)}
+	stateCache := cache.NewFileCache(dir)
+
+	ss, err := NewStateServiceWithUUID(stateCache, logger.NewSimpleLogger("test", ioutil.Discard), stateProvider, "uuid")
+	require.NoError(t, err)
+
+	err = ss.CacheLock()
+	require.NoError(t, err)
+	defer ss.CacheUnlock()
+
+	state, err := ss.GetState(context.Background(), "db1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), state.TxId)
+
+	err = ss.SetState("db1", immutableState("db1", 20, 2))
+	require.NoError(t, err)
+
+	require.NoError(t, ss.CheckState("db1", immutableState("db1", 20, 2)))
+	require.ErrorIs(t, ss.CheckState("db1", immutableState("db1", 20, 3)), ErrStateForked)
+	require.ErrorIs(t, ss.CheckState("db1", immutableState("db1", 15, 2)), ErrStateRollback)
+
+	err = ss.SetState("db1", immutableState("db1", 15, 2))
+	require.ErrorIs(t, err, ErrStateRollback)
+
+	// the local state is not replaced by an inconsistent one
+	state, err = ss.GetState(context.Background(), "db1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), state.TxId)
+
+	// a local state replaced with an older one is detected as well
+	err = stateCache.Set("uuid", "db1", immutableState("db1", 12, 2))
+	require.NoError(t, err)
+
+	_, err = ss.GetState(context.Background(), "db1")
+	require.ErrorIs(t, err, ErrStateRollback)
+}
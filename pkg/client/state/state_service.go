@@ -29,6 +29,7 @@ import (
 type StateService interface {
 	GetState(ctx context.Context, db string) (*schema.ImmutableState, error)
 	SetState(db string, state *schema.ImmutableState) error
+	CheckState(db string, state *schema.ImmutableState) error
 	CacheLock() error
 	CacheUnlock() error
 }
@@ -39,6 +40,7 @@ type stateService struct {
 	cache         cache.Cache
 	serverUUID    string
 	logger        logger.Logger
+	history       *stateHistory
 	sync.RWMutex
 }
 
@@ -62,6 +64,7 @@ func NewStateService(cache cache.Cache,
 		cache:         cache,
 		logger:        logger,
 		serverUUID:    serverUUID,
+		history:       newStateHistory(DefaultStateHistorySize),
 	}, nil
 }
 
@@ -80,6 +83,7 @@ func NewStateServiceWithUUID(cache cache.Cache,
 		cache:         cache,
 		logger:        logger,
 		serverUUID:    serverUUID,
+		history:       newStateHistory(DefaultStateHistorySize),
 	}, nil
 }
 
@@ -89,6 +93,11 @@ func (r *stateService) GetState(ctx context.Context, db string) (*schema.Immutab
 
 	state, err := r.cache.Get(r.serverUUID, db)
 	if err == nil {
+		// the local state may have been replaced with an older one as well
+		if err := r.checkState(db, state); err != nil {
+			return nil, err
+		}
+		r.history.add(db, state)
 		return state, nil
 	}
 	if err != cache.ErrPrevStateNotFound {
@@ -100,18 +109,51 @@ func (r *stateService) GetState(ctx context.Context, db string) (*schema.Immutab
 		return nil, err
 	}
 
+	if err := r.checkState(db, state); err != nil {
+		return nil, err
+	}
+
 	if err := r.cache.Set(r.serverUUID, db, state); err != nil {
 		return nil, err
 	}
+	r.history.add(db, state)
 
 	return state, nil
 }
 
+// SetState stores a verified state, it fails when the state is not consistent with the states
+// already verified for the same database
 func (r *stateService) SetState(db string, state *schema.ImmutableState) error {
 	r.Lock()
 	defer r.Unlock()
 
-	return r.cache.Set(r.serverUUID, db, state)
+	if err := r.checkState(db, state); err != nil {
+		return err
+	}
+
+	if err := r.cache.Set(r.serverUUID, db, state); err != nil {
+		return err
+	}
+	r.history.add(db, state)
+
+	return nil
+}
+
+// CheckState returns an error when a state presented by the server is older than the states already
+// verified for the same database, or it differs from the one verified at the same transaction
+func (r *stateService) CheckState(db string, state *schema.ImmutableState) error {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.checkState(db, state)
+}
+
+func (r *stateService) checkState(db string, state *schema.ImmutableState) error {
+	err := r.history.check(db, state)
+	if err != nil {
+		r.logger.Errorf("%v. The server %s may have been tampered with or may be presenting different views of the database to different clients", err, r.serverUUID)
+	}
+	return err
 }
 
 func (r *stateService) CacheLock() error {
@@ -26,6 +26,7 @@ import (
 
 	"github.com/codenotary/immudb/pkg/client/errors"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/database"
@@ -202,9 +203,12 @@ func (c *immuClient) _streamVerifiedSet(ctx context.Context, kvs []*stream.KeyVa
 		return nil, store.ErrCorruptedData
 	}
 
-	tx := schema.TxFromProto(verifiableTx.Tx)
+	tx, err := schema.TxFromProto(verifiableTx.Tx)
+	if err != nil {
+		return nil, err
+	}
 
-	entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version)
+	entrySpecDigest, err := store.EntrySpecDigestFor(tx.Header().Version, tx.Header().HashAlgorithm)
 	if err != nil {
 		return nil, err
 	}
@@ -220,7 +224,7 @@ func (c *immuClient) _streamVerifiedSet(ctx context.Context, kvs []*stream.KeyVa
 		md := tx.Entries()[i].Metadata()
 		e := database.EncodeEntrySpec(kv.Key, md, kv.Value)
 
-		verifies = store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh)
+		verifies = store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh, tx.Header().HashAlgorithm)
 		if !verifies {
 			return nil, store.ErrCorruptedData
 		}
@@ -239,8 +243,13 @@ func (c *immuClient) _streamVerifiedSet(ctx context.Context, kvs []*stream.KeyVa
 	targetAlh = tx.Header().Alh()
 
 	if state.TxId > 0 {
+		dualProof, err := schema.DualProofFromProto(verifiableTx.DualProof)
+		if err != nil {
+			return nil, err
+		}
+
 		verifies = store.VerifyDualProof(
-			schema.DualProofFromProto(verifiableTx.DualProof),
+			dualProof,
 			sourceID,
 			targetID,
 			sourceAlh,
@@ -259,14 +268,9 @@ func (c *immuClient) _streamVerifiedSet(ctx context.Context, kvs []*stream.KeyVa
 		Signature: verifiableTx.Signature,
 	}
 
-	if c.serverSigningPubKey != nil {
-		ok, err := newState.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			return nil, store.ErrCorruptedData
-		}
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
 	}
 
 	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
@@ -308,13 +312,18 @@ func (c *immuClient) _streamVerifiedGet(ctx context.Context, req *schema.Verifia
 		return nil, err
 	}
 
-	entrySpecDigest, err := store.EntrySpecDigestFor(int(vEntry.VerifiableTx.Tx.Header.Version))
+	hashAlg := hashing.Algorithm(vEntry.VerifiableTx.Tx.Header.HashAlgorithm)
+
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(vEntry.VerifiableTx.Tx.Header.Version), hashAlg)
 	if err != nil {
 		return nil, err
 	}
 
 	inclusionProof := schema.InclusionProofFromProto(vEntry.InclusionProof)
-	dualProof := schema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+	dualProof, err := schema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+	if err != nil {
+		return nil, err
+	}
 
 	var eh [sha256.Size]byte
 
@@ -347,7 +356,7 @@ func (c *immuClient) _streamVerifiedGet(ctx context.Context, req *schema.Verifia
 		targetAlh = schema.DigestFromProto(state.TxHash)
 	}
 
-	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), eh)
+	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), eh, hashAlg)
 	if !verifies {
 		return nil, store.ErrCorruptedData
 	}
@@ -372,14 +381,9 @@ func (c *immuClient) _streamVerifiedGet(ctx context.Context, req *schema.Verifia
 		Signature: vEntry.VerifiableTx.Signature,
 	}
 
-	if c.serverSigningPubKey != nil {
-		ok, err := newState.CheckSignature(c.serverSigningPubKey)
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			return nil, store.ErrCorruptedData
-		}
+	err = c.verifyStateSignature(newState)
+	if err != nil {
+		return nil, err
 	}
 
 	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
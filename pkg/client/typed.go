@@ -0,0 +1,105 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/golang/protobuf/proto"
+)
+
+// SetJSON stores the JSON encoding of v under the given key
+func (c *immuClient) SetJSON(ctx context.Context, key []byte, v interface{}) (*schema.TxHeader, error) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Set(ctx, key, value)
+}
+
+// VerifiedSetJSON stores the JSON encoding of v under the given key and verifies the inclusion of the entry
+func (c *immuClient) VerifiedSetJSON(ctx context.Context, key []byte, v interface{}) (*schema.TxHeader, error) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.VerifiedSet(ctx, key, value)
+}
+
+// GetJSON retrieves the value stored under the given key and decodes it as JSON into v
+func (c *immuClient) GetJSON(ctx context.Context, key []byte, v interface{}) (*schema.Entry, error) {
+	entry, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, json.Unmarshal(entry.Value, v)
+}
+
+// VerifiedGetJSON retrieves and verifies the value stored under the given key and decodes it as JSON into v
+func (c *immuClient) VerifiedGetJSON(ctx context.Context, key []byte, v interface{}) (*schema.Entry, error) {
+	entry, err := c.VerifiedGet(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, json.Unmarshal(entry.Value, v)
+}
+
+// SetProto stores the protobuf encoding of m under the given key
+func (c *immuClient) SetProto(ctx context.Context, key []byte, m proto.Message) (*schema.TxHeader, error) {
+	value, err := proto.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Set(ctx, key, value)
+}
+
+// VerifiedSetProto stores the protobuf encoding of m under the given key and verifies the inclusion of the entry
+func (c *immuClient) VerifiedSetProto(ctx context.Context, key []byte, m proto.Message) (*schema.TxHeader, error) {
+	value, err := proto.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.VerifiedSet(ctx, key, value)
+}
+
+// GetProto retrieves the value stored under the given key and decodes it as protobuf into m
+func (c *immuClient) GetProto(ctx context.Context, key []byte, m proto.Message) (*schema.Entry, error) {
+	entry, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, proto.Unmarshal(entry.Value, m)
+}
+
+// VerifiedGetProto retrieves and verifies the value stored under the given key and decodes it as protobuf into m
+func (c *immuClient) VerifiedGetProto(ctx context.Context, key []byte, m proto.Message) (*schema.Entry, error) {
+	entry, err := c.VerifiedGet(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, proto.Unmarshal(entry.Value, m)
+}
@@ -80,6 +80,11 @@ func (d *db) ExecAll(req *schema.ExecAllRequest) (*schema.TxHeader, error) {
 
 				e = EncodeEntrySpec(x.Kv.Key, schema.KVMetadataFromProto(x.Kv.Metadata), x.Kv.Value)
 
+				err := d.checkExpirationRetentionLock(e.Metadata)
+				if err != nil {
+					return nil, err
+				}
+
 			case *schema.Op_Ref:
 				if len(x.Ref.Key) == 0 || len(x.Ref.ReferencedKey) == 0 {
 					return nil, store.ErrIllegalArguments
@@ -184,7 +189,13 @@ func (d *db) ExecAll(req *schema.ExecAllRequest) (*schema.TxHeader, error) {
 		return entries, nil
 	}
 
-	hdr, err := d.st.CommitWith(callback, !req.NoWait)
+	txmd := schema.TxMetadataFromProto(req.TxMetadata)
+
+	if len(req.IdempotencyKey) > 0 {
+		return d.commitIdempotent(req.IdempotencyKey, txmd, callback, !req.NoWait)
+	}
+
+	hdr, err := d.st.CommitWithMetadata(txmd, callback, !req.NoWait)
 	if err != nil {
 		return nil, err
 	}
@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -169,11 +170,12 @@ func TestSetBatch(t *testing.T) {
 			require.Equal(t, value, vitem.Entry.Value)
 			require.Equal(t, entry.Tx, vitem.Entry.Tx)
 
-			tx := schema.TxFromProto(vitem.VerifiableTx.Tx)
+			tx, err := schema.TxFromProto(vitem.VerifiableTx.Tx)
+			require.NoError(t, err)
 
 			entrySpec := EncodeEntrySpec(vitem.Entry.Key, schema.KVMetadataFromProto(vitem.Entry.Metadata), vitem.Entry.Value)
 
-			entrySpecDigest, err := store.EntrySpecDigestFor(int(txhdr.Version))
+			entrySpecDigest, err := store.EntrySpecDigestFor(int(txhdr.Version), hashing.Algorithm(txhdr.HashAlgorithm))
 			require.NoError(t, err)
 			require.NotNil(t, entrySpecDigest)
 
@@ -182,6 +184,7 @@ func TestSetBatch(t *testing.T) {
 				inclusionProof,
 				entrySpecDigest(entrySpec),
 				tx.Header().Eh,
+				hashing.Algorithm(txhdr.HashAlgorithm),
 			)
 			require.True(t, verifies)
 		}
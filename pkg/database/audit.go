@@ -0,0 +1,206 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// TxAudit is the outcome of auditing a range of transactions, ToTx is lower than FromTx when none was audited
+type TxAudit struct {
+	FromTx  uint64
+	ToTx    uint64
+	Entries uint64
+	// FirstTs and LastTs are the timestamps of the first and the last transactions audited
+	FirstTs int64
+	LastTs  int64
+	// Alh is the accumulative hash of the last transaction audited
+	Alh [sha256.Size]byte
+
+	Erasures   []*schema.Erasure
+	LegalHolds []*LegalHoldChange
+}
+
+// LegalHoldChange is the placement or the lifting of a legal hold found while auditing transactions
+type LegalHoldChange struct {
+	Key    []byte
+	Table  string
+	Placed bool
+	By     string
+	Reason string
+	Tx     uint64
+	Ts     int64
+}
+
+// AuditEntryFn is called with the key, without prefix, the metadata and the value of the entries
+// of the transactions being audited, the value is nil when it's no longer stored
+type AuditEntryFn func(hdr *store.TxHeader, key []byte, md *store.KVMetadata, value []byte) error
+
+// AuditTxs verifies the transactions committed from fromTx up to toTx and collects the erasures and the legal
+// holds they record. Every transaction is read back from the transaction log, which checks its accumulative hash,
+// and must be linked to the previous one. When set, fn is called with every key-value entry of the transactions.
+// The range of transactions audited so far is returned even when the audit fails.
+func (d *db) AuditTxs(fromTx, toTx uint64, fn AuditEntryFn) (*TxAudit, error) {
+	if fromTx == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	res := &TxAudit{
+		FromTx: fromTx,
+		ToTx:   fromTx - 1,
+	}
+
+	lastTxID, _ := d.st.Alh()
+	if toTx > lastTxID {
+		toTx = lastTxID
+	}
+
+	if fromTx > toTx {
+		return res, nil
+	}
+
+	tx := d.st.NewTxHolder()
+
+	// the first tx is linked to the digest of an empty input
+	prevAlh := d.st.HashAlgorithm().Sum(nil)
+
+	if fromTx > 1 {
+		err := d.st.ReadTx(fromTx-1, tx)
+		if err != nil {
+			return res, err
+		}
+
+		prevAlh = tx.Header().Alh()
+	}
+
+	for txID := fromTx; txID <= toTx; txID++ {
+		err := d.st.ReadTx(txID, tx)
+		if err != nil {
+			return res, err
+		}
+
+		hdr := tx.Header()
+
+		if hdr.ID != txID || hdr.PrevAlh != prevAlh {
+			return res, fmt.Errorf("%w: tx %d is not linked to tx %d", store.ErrCorruptedData, txID, txID-1)
+		}
+
+		for _, e := range tx.Entries() {
+			err = d.auditEntry(res, hdr, e, fn)
+			if err != nil {
+				return res, err
+			}
+		}
+
+		if txID == fromTx {
+			res.FirstTs = hdr.Ts
+		}
+
+		prevAlh = hdr.Alh()
+
+		res.ToTx = txID
+		res.LastTs = hdr.Ts
+		res.Alh = prevAlh
+		res.Entries += uint64(hdr.NEntries)
+	}
+
+	return res, nil
+}
+
+func (d *db) auditEntry(res *TxAudit, hdr *store.TxHeader, e *store.TxEntry, fn AuditEntryFn) error {
+	key := e.Key()
+
+	switch key[0] {
+	case SetKeyPrefix:
+		{
+			if fn == nil {
+				return nil
+			}
+
+			val, err := d.st.ReadValue(e)
+			if err == store.ErrValueErased || err == store.ErrValueTruncated || err == store.ErrValueReclaimed || err == store.ErrExpiredEntry {
+				val = nil
+			} else if err != nil {
+				return err
+			}
+			if len(val) > 0 {
+				val = TrimPrefix(val)
+			}
+
+			return fn(hdr, TrimPrefix(key), e.Metadata(), val)
+		}
+	case ErasurePrefix:
+		{
+			val, err := d.st.ReadValue(e)
+			if err != nil {
+				return err
+			}
+
+			var record erasureRecord
+
+			err = json.Unmarshal(val, &record)
+			if err != nil {
+				return fmt.Errorf("%w: invalid erasure record", store.ErrCorruptedData)
+			}
+
+			res.Erasures = append(res.Erasures, &schema.Erasure{
+				Key:         TrimPrefix(key),
+				Tx:          record.Tx,
+				RequestedBy: record.RequestedBy,
+				Reason:      record.Reason,
+				RecordTx:    hdr.ID,
+				RequestedAt: hdr.Ts,
+			})
+		}
+	case LegalHoldPrefix:
+		{
+			val, err := d.st.ReadValue(e)
+			if err != nil {
+				return err
+			}
+
+			var record legalHoldRecord
+
+			err = json.Unmarshal(val, &record)
+			if err != nil {
+				return fmt.Errorf("%w: invalid legal hold record", store.ErrCorruptedData)
+			}
+
+			heldKey, table, err := decodeLegalHoldKey(key)
+			if err != nil {
+				return err
+			}
+
+			res.LegalHolds = append(res.LegalHolds, &LegalHoldChange{
+				Key:    heldKey,
+				Table:  table,
+				Placed: record.Active,
+				By:     record.By,
+				Reason: record.Reason,
+				Tx:     hdr.ID,
+				Ts:     hdr.Ts,
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTxs(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.AuditTxs(0, 1, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	hdr1, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("pii/alice"), Value: []byte("alice data")},
+		{Key: []byte("pii/bob"), Value: []byte("bob data")},
+	}})
+	require.NoError(t, err)
+
+	erasure, err := db.Erase(&schema.EraseRequest{Key: []byte("pii/alice"), Reason: "right to erasure"}, "alice")
+	require.NoError(t, err)
+
+	hold, err := db.PlaceLegalHold(&schema.LegalHoldRequest{Key: []byte("pii/bob"), Reason: "litigation"}, "admin")
+	require.NoError(t, err)
+
+	var keys []string
+	var values [][]byte
+
+	audit, err := db.AuditTxs(hdr1.Id, hold.Tx, func(hdr *store.TxHeader, key []byte, md *store.KVMetadata, value []byte) error {
+		keys = append(keys, string(key))
+		values = append(values, value)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, audit.FromTx)
+	require.Equal(t, hold.Tx, audit.ToTx)
+	require.Equal(t, uint64(4), audit.Entries)
+	require.Equal(t, hdr1.Ts, audit.FirstTs)
+
+	// only key-value entries are passed along, erased values are not
+	require.Equal(t, []string{"pii/alice", "pii/bob"}, keys)
+	require.Nil(t, values[0])
+	require.Equal(t, []byte("bob data"), values[1])
+
+	require.Len(t, audit.Erasures, 1)
+	require.Equal(t, []byte("pii/alice"), audit.Erasures[0].Key)
+	require.Equal(t, "alice", audit.Erasures[0].RequestedBy)
+	require.Equal(t, erasure.RecordTx, audit.Erasures[0].RecordTx)
+
+	require.Len(t, audit.LegalHolds, 1)
+	require.Equal(t, []byte("pii/bob"), audit.LegalHolds[0].Key)
+	require.True(t, audit.LegalHolds[0].Placed)
+	require.Equal(t, "admin", audit.LegalHolds[0].By)
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+	require.Equal(t, state.TxHash, audit.Alh[:])
+
+	// the first transaction is linked to the initial alh
+	audit, err = db.AuditTxs(1, hold.Tx, nil)
+	require.NoError(t, err)
+	require.Equal(t, hold.Tx, audit.ToTx)
+	require.Equal(t, state.TxHash, audit.Alh[:])
+
+	// ranges not starting at the first transaction are linked to the previous one
+	audit, err = db.AuditTxs(erasure.RecordTx, 0xffffffff, nil)
+	require.NoError(t, err)
+	require.Equal(t, hold.Tx, audit.ToTx)
+	require.Len(t, audit.Erasures, 1)
+
+	audit, err = db.AuditTxs(hold.Tx+1, hold.Tx+10, nil)
+	require.NoError(t, err)
+	require.Less(t, audit.ToTx, audit.FromTx)
+	require.Zero(t, audit.Entries)
+}
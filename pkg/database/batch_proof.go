@@ -0,0 +1,159 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// VerifiableBatch proves many entries and transactions at once.
+// Every transaction involved is proven to be part of the history of the last committed one,
+// which is in turn linked to req.ProveSinceTx by a single dual proof. Each transaction is read
+// and proven only once, no matter how many of the requested entries it contains.
+func (d *db) VerifiableBatch(req *schema.VerifiableBatchRequest) (*schema.VerifiableBatch, error) {
+	if req == nil || len(req.Keys)+len(req.Txs) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	if len(req.Keys)+len(req.Txs) > MaxBatchProofLen {
+		return nil, ErrMaxBatchProofLenExceeded
+	}
+
+	lastTxID, _ := d.st.Alh()
+	if lastTxID < req.ProveSinceTx {
+		return nil, ErrIllegalState
+	}
+
+	batch := &schema.VerifiableBatch{
+		Entries: make([]*schema.BatchedEntry, len(req.Keys)),
+	}
+
+	// transactions to be proven in the order they are first referred to,
+	// along with the entries to be proven at each of them
+	var txIDs []uint64
+	entriesAtTx := make(map[uint64][]int)
+
+	addTx := func(txID uint64) {
+		_, ok := entriesAtTx[txID]
+		if !ok {
+			txIDs = append(txIDs, txID)
+			entriesAtTx[txID] = nil
+		}
+	}
+
+	for i, kReq := range req.Keys {
+		e, err := d.Get(kReq)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Erased {
+			// the inclusion of an erased value can not be proven
+			return nil, store.ErrValueErased
+		}
+
+		batch.Entries[i] = &schema.BatchedEntry{Entry: e}
+
+		vTxID := e.Tx
+		if e.ReferencedBy != nil {
+			vTxID = e.ReferencedBy.Tx
+		}
+
+		addTx(vTxID)
+		entriesAtTx[vTxID] = append(entriesAtTx[vTxID], i)
+	}
+
+	// the target is taken after the entries were read so all of them are included in its history
+	targetTxID, _ := d.st.Alh()
+
+	for _, txID := range req.Txs {
+		if txID == 0 || txID > targetTxID {
+			return nil, ErrIllegalArguments
+		}
+
+		addTx(txID)
+	}
+
+	targetTx := d.st.NewTxHolder()
+
+	err := d.st.ReadTx(targetTxID, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := d.st.NewTxHolder()
+
+	for _, txID := range txIDs {
+		err = d.st.ReadTx(txID, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range entriesAtTx[txID] {
+			e := batch.Entries[i].Entry
+
+			vKey := e.Key
+			if e.ReferencedBy != nil {
+				vKey = e.ReferencedBy.Key
+			}
+
+			inclusionProof, err := tx.Proof(EncodeKey(vKey))
+			if err != nil {
+				return nil, err
+			}
+
+			batch.Entries[i].InclusionProof = schema.InclusionProofToProto(inclusionProof)
+		}
+
+		txProof, err := d.st.TxInclusionProof(tx.Header(), targetTx.Header())
+		if err != nil {
+			return nil, err
+		}
+
+		batch.TxProofs = append(batch.TxProofs, schema.TxInclusionProofToProto(txProof))
+	}
+
+	batch.DualProof, err = d.dualProofSince(req.ProveSinceTx, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// dualProofSince links targetTx to the transaction proveSinceTx,
+// targetTx is proven against itself when proveSinceTx is zero
+func (d *db) dualProofSince(proveSinceTx uint64, targetTx *store.Tx) (*schema.DualProof, error) {
+	sourceTx := targetTx
+
+	if proveSinceTx > 0 {
+		sourceTx = d.st.NewTxHolder()
+
+		err := d.st.ReadTx(proveSinceTx, sourceTx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dualProof, err := d.st.DualProof(sourceTx, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.DualProofToProto(dualProof), nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifiableBatch(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.VerifiableBatch(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.VerifiableBatch(&schema.VerifiableBatchRequest{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.VerifiableBatch(&schema.VerifiableBatchRequest{Txs: make([]uint64, MaxBatchProofLen+1)})
+	require.ErrorIs(t, err, ErrMaxBatchProofLenExceeded)
+
+	hdr1, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key1"), Value: []byte("value1")},
+		{Key: []byte("key2"), Value: []byte("value2")},
+	}})
+	require.NoError(t, err)
+
+	hdr2, err := db.SetReference(&schema.ReferenceRequest{Key: []byte("ref1"), ReferencedKey: []byte("key1")})
+	require.NoError(t, err)
+
+	hdr3, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key3"), Value: []byte("value3")}}})
+	require.NoError(t, err)
+
+	_, err = db.VerifiableBatch(&schema.VerifiableBatchRequest{Txs: []uint64{hdr1.Id}, ProveSinceTx: hdr3.Id + 1})
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	_, err = db.VerifiableBatch(&schema.VerifiableBatchRequest{Txs: []uint64{0}})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.VerifiableBatch(&schema.VerifiableBatchRequest{Txs: []uint64{hdr3.Id + 1}})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.VerifiableBatch(&schema.VerifiableBatchRequest{Keys: []*schema.KeyRequest{{Key: []byte("key4")}}})
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	keys := [][]byte{[]byte("key1"), []byte("ref1"), []byte("key2"), []byte("key3")}
+
+	req := &schema.VerifiableBatchRequest{
+		Txs:          []uint64{hdr1.Id, hdr3.Id},
+		ProveSinceTx: hdr2.Id,
+	}
+
+	for _, key := range keys {
+		req.Keys = append(req.Keys, &schema.KeyRequest{Key: key})
+	}
+
+	batch, err := db.VerifiableBatch(req)
+	require.NoError(t, err)
+	require.Len(t, batch.Entries, len(keys))
+
+	// each transaction is proven once
+	require.Len(t, batch.TxProofs, 3)
+
+	dualProof, err := schema.DualProofFromProto(batch.DualProof)
+	require.NoError(t, err)
+
+	target := dualProof.TargetTxHeader
+
+	require.Equal(t, hdr2.Id, dualProof.SourceTxHeader.ID)
+	require.Equal(t, hdr3.Id, target.ID)
+	require.True(t, store.VerifyDualProof(dualProof, hdr2.Id, hdr3.Id, dualProof.SourceTxHeader.Alh(), target.Alh()))
+
+	txHdrs := make(map[uint64]*store.TxHeader)
+
+	for _, txProof := range batch.TxProofs {
+		proof, err := schema.TxInclusionProofFromProto(txProof)
+		require.NoError(t, err)
+
+		require.True(t, store.VerifyTxInclusion(proof, target))
+
+		txHdrs[proof.TxHeader.ID] = proof.TxHeader
+	}
+
+	require.Contains(t, txHdrs, hdr1.Id)
+	require.Contains(t, txHdrs, hdr2.Id)
+	require.Contains(t, txHdrs, hdr3.Id)
+
+	for i, be := range batch.Entries {
+		var vTx uint64
+		var e *store.EntrySpec
+
+		if be.Entry.ReferencedBy == nil {
+			vTx = be.Entry.Tx
+			e = EncodeEntrySpec(keys[i], schema.KVMetadataFromProto(be.Entry.Metadata), be.Entry.Value)
+		} else {
+			ref := be.Entry.ReferencedBy
+			vTx = ref.Tx
+			e = EncodeReference(ref.Key, schema.KVMetadataFromProto(ref.Metadata), be.Entry.Key, ref.AtTx)
+		}
+
+		hdr := txHdrs[vTx]
+
+		entrySpecDigest, err := store.EntrySpecDigestFor(hdr.Version, hdr.HashAlgorithm)
+		require.NoError(t, err)
+
+		verifies := store.VerifyInclusion(schema.InclusionProofFromProto(be.InclusionProof), entrySpecDigest(e), hdr.Eh, hdr.HashAlgorithm)
+		require.True(t, verifies)
+	}
+
+	// the target is proven against itself when no state is known
+	batch, err = db.VerifiableBatch(&schema.VerifiableBatchRequest{Keys: []*schema.KeyRequest{{Key: []byte("key1")}}})
+	require.NoError(t, err)
+	require.Equal(t, hdr3.Id, batch.DualProof.SourceTxHeader.Id)
+	require.Equal(t, hdr3.Id, batch.DualProof.TargetTxHeader.Id)
+}
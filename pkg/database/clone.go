@@ -0,0 +1,67 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+// Clone creates the database described by op as a copy of src holding its transactions up to txID,
+// all of them are included when txID is zero. Data files of src are copied while it keeps serving requests.
+func Clone(src DB, op *Options, txID uint64, log logger.Logger) (DB, error) {
+	if src == nil || op == nil {
+		return nil, store.ErrIllegalArguments
+	}
+
+	log.Infof("Cloning database '%s' into '%s'...", src.GetName(), op.dbName)
+
+	dbDir := filepath.Join(op.GetDBRootPath(), op.GetDBName())
+
+	if _, dbErr := os.Stat(dbDir); dbErr == nil {
+		return nil, fmt.Errorf("Database directories already exist: %s", dbDir)
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		_, err := src.Backup(pw, nil)
+		pw.CloseWithError(err)
+	}()
+
+	manifest, err := store.RestoreBackupUpTo(dbDir, pr, op.storeOptions().WithLog(log), txID)
+	pr.CloseWithError(err)
+	if err != nil {
+		os.RemoveAll(dbDir)
+		return nil, logErr(log, "Unable to clone database: %s", err)
+	}
+
+	db, err := OpenDB(op, log)
+	if err != nil {
+		os.RemoveAll(dbDir)
+		return nil, err
+	}
+
+	log.Infof("Database '%s' successfully cloned into '%s' at tx %d", src.GetName(), op.dbName, manifest.TxID)
+
+	return db, nil
+}
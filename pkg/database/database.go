@@ -21,12 +21,15 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/logger"
@@ -34,11 +37,16 @@ import (
 
 const MaxKeyResolutionLimit = 1
 const MaxKeyScanLimit = 1000
+const MaxBatchProofLen = 1000
+
+// maxTxScanExaminedTxs bounds the work of a single tx scan when transactions are filtered out
+var maxTxScanExaminedTxs = 10 * MaxKeyScanLimit
 
 const dbInstanceName = "dbinstance"
 
 var ErrMaxKeyResolutionLimitReached = errors.New("max key resolution limit reached. It may be due to cyclic references")
 var ErrMaxKeyScanLimitExceeded = errors.New("max key scan limit exceeded")
+var ErrMaxBatchProofLenExceeded = errors.New("max number of entries and transactions of a batch proof exceeded")
 var ErrIllegalArguments = store.ErrIllegalArguments
 var ErrIllegalState = store.ErrIllegalState
 var ErrIsReplica = errors.New("database is read-only because it's a replica")
@@ -53,6 +61,9 @@ type DB interface {
 	AsReplica(asReplica bool)
 	IsReplica() bool
 
+	RetainUntil() time.Time
+	SetRetainUntil(retainUntil time.Time) error
+
 	UseTimeFunc(timeFunc store.TimeFunc) error
 
 	// State
@@ -106,13 +117,56 @@ type DB interface {
 	WaitForIndexingUpto(txID uint64, cancellation <-chan struct{}) error
 
 	TxByID(req *schema.TxRequest) (*schema.Tx, error)
+	TxByTime(req *schema.TxByTimeRequest) (*schema.Tx, error)
 	ExportTxByID(req *schema.TxRequest) ([]byte, error)
 	ReplicateTx(exportedTx []byte) (*schema.TxHeader, error)
+	// SetRepairSource sets where the damaged values detected by the corruption checker are fetched from
+	SetRepairSource(fetchTx store.TxFetcher)
 	VerifiableTxByID(req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error)
 	TxScan(req *schema.TxScanRequest) (*schema.TxList, error)
+	ExportTxHeaders(req *schema.TxHeadersRequest) (*schema.TxHeaders, error)
+	ConsistencyProof(req *schema.ConsistencyProofRequest) (*schema.DualProof, error)
+
+	// Notarization
+	Notarize(req *schema.NotarizeRequest) (*schema.Notarization, error)
+	VerifyNotarization(req *schema.VerifyNotarizationRequest) (*schema.Notarization, error)
+
+	// Erasure
+	Erase(req *schema.EraseRequest, requestedBy string) (*schema.Erasure, error)
+	ErasureHistory(req *schema.ErasureHistoryRequest) (*schema.ErasureHistory, error)
+	EnforceRetention(prefix []byte, sinceTx uint64, until time.Time, requestedBy, reason string) (*RetentionEnforcement, error)
+
+	// Legal holds
+	PlaceLegalHold(req *schema.LegalHoldRequest, placedBy string) (*schema.LegalHold, error)
+	LiftLegalHold(req *schema.LegalHoldRequest, liftedBy string) error
+	LegalHolds() (*schema.LegalHoldList, error)
+
+	// Audit
+	AuditTxs(fromTx, toTx uint64, fn AuditEntryFn) (*TxAudit, error)
+
+	// Exclusion
+	ExclusionProof(req *schema.ExclusionProofRequest) (*schema.ExclusionProof, error)
+
+	// Batched proofs
+	VerifiableBatch(req *schema.VerifiableBatchRequest) (*schema.VerifiableBatch, error)
+	SampledAudit(req *schema.SampledAuditRequest) (*schema.SampledAudit, error)
+
+	// Snapshot returns a consistent read view including every transaction committed before the call
+	Snapshot() (*Snapshot, error)
+
+	// Storage usage
+	DiskUsage() (*DiskUsage, error)
+	CountEntries(initialTx uint64) (entries uint64, lastTx uint64, err error)
 
 	// Maintenance
 	CompactIndex() error
+	CompactIndexWithProgress(progress func(dumpedBytes int64)) error
+	EstimateIndexCompaction() (*tbtree.CompactionEstimate, error)
+	CollectGarbage() (int64, error)
+	ResizeTxLogCache(size int) error
+	RebuildIndex(progress store.IndexRebuildProgress) error
+	Backup(w io.Writer, since *store.BackupManifest) (*store.BackupManifest, error)
+	Export(w io.Writer, sign func(state *schema.ImmutableState) error) (*ExportManifest, error)
 
 	Close() error
 }
@@ -127,6 +181,8 @@ type db struct {
 
 	mutex sync.RWMutex
 
+	idempotencyMutex sync.Mutex
+
 	Logger  logger.Logger
 	options *Options
 
@@ -145,6 +201,10 @@ func OpenDB(op *Options, log logger.Logger) (DB, error) {
 		name:    op.dbName,
 	}
 
+	// garbage collection checks the legal holds once the database is opened
+	dbi.mutex.Lock()
+	defer dbi.mutex.Unlock()
+
 	dbDir := dbi.path()
 
 	_, dbErr := os.Stat(dbDir)
@@ -152,12 +212,12 @@ func OpenDB(op *Options, log logger.Logger) (DB, error) {
 		return nil, fmt.Errorf("missing database directories: %s", dbDir)
 	}
 
-	dbi.st, err = store.Open(dbDir, op.GetStoreOptions().WithLog(log))
+	dbi.st, err = store.Open(dbDir, op.storeOptions().WithLog(log).WithGCHolds(dbi.legalHoldPrefixes))
 	if err != nil {
 		return nil, logErr(dbi.Logger, "Unable to open database: %s", err)
 	}
 
-	dbi.sqlEngine, err = sql.NewEngine(dbi.st, sql.DefaultOptions().WithPrefix([]byte{SQLPrefix}))
+	dbi.sqlEngine, err = sql.NewEngine(dbi.st, op.sqlOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -218,6 +278,11 @@ func (d *db) initSQLEngine() error {
 	}
 
 	if err == sql.ErrDatabaseDoesNotExist {
+		// nothing to load, the SQL database can not be created in read-only mode
+		if d.st.ReadOnly() {
+			return nil
+		}
+
 		_, _, err = d.sqlEngine.ExecPreparedStmts([]sql.SQLStmt{&sql.CreateDatabaseStmt{DB: dbInstanceName}}, nil, nil)
 		if err != nil {
 			return logErr(d.Logger, "Unable to open store: %s", err)
@@ -244,6 +309,10 @@ func NewDB(op *Options, log logger.Logger) (DB, error) {
 		name:    op.dbName,
 	}
 
+	// garbage collection checks the legal holds once the database is opened
+	dbi.mutex.Lock()
+	defer dbi.mutex.Unlock()
+
 	dbDir := filepath.Join(op.GetDBRootPath(), op.GetDBName())
 
 	if _, dbErr := os.Stat(dbDir); dbErr == nil {
@@ -254,12 +323,12 @@ func NewDB(op *Options, log logger.Logger) (DB, error) {
 		return nil, logErr(dbi.Logger, "Unable to create data folder: %s", err)
 	}
 
-	dbi.st, err = store.Open(dbDir, op.GetStoreOptions().WithLog(log))
+	dbi.st, err = store.Open(dbDir, op.storeOptions().WithLog(log).WithGCHolds(dbi.legalHoldPrefixes))
 	if err != nil {
 		return nil, logErr(dbi.Logger, "Unable to open database: %s", err)
 	}
 
-	dbi.sqlEngine, err = sql.NewEngine(dbi.st, sql.DefaultOptions().WithPrefix([]byte{SQLPrefix}))
+	dbi.sqlEngine, err = sql.NewEngine(dbi.st, op.sqlOptions())
 	if err != nil {
 		return nil, logErr(dbi.Logger, "Unable to open database: %s", err)
 	}
@@ -295,6 +364,42 @@ func (d *db) CompactIndex() error {
 	return d.st.CompactIndex()
 }
 
+// CompactIndexWithProgress compacts the index notifying the number of bytes of the index dumped so far
+func (d *db) CompactIndexWithProgress(progress func(dumpedBytes int64)) error {
+	return d.st.CompactIndexWithProgress(progress)
+}
+
+// EstimateIndexCompaction computes the space a compaction of the index would reclaim without compacting it
+func (d *db) EstimateIndexCompaction() (*tbtree.CompactionEstimate, error) {
+	return d.st.EstimateIndexCompaction()
+}
+
+// CollectGarbage reclaims the space used by superseded values, the values of keys and rows
+// under a legal hold are kept. The number of reclaimed bytes is returned
+func (d *db) CollectGarbage() (int64, error) {
+	return d.st.CollectGarbage()
+}
+
+// ResizeTxLogCache changes the number of transactions kept in the cache of the transaction log
+func (d *db) ResizeTxLogCache(size int) error {
+	return d.st.ResizeTxLogCache(size)
+}
+
+// RebuildIndex discards the index and builds it again from the transaction log
+func (d *db) RebuildIndex(progress store.IndexRebuildProgress) error {
+	return d.st.RebuildIndex(progress)
+}
+
+// Backup writes a consistent copy of the database into w while it keeps serving requests,
+// only the transactions committed after the previous backup are included when since is provided
+func (d *db) Backup(w io.Writer, since *store.BackupManifest) (*store.BackupManifest, error) {
+	if since != nil {
+		return d.st.IncrementalBackup(w, since)
+	}
+
+	return d.st.Backup(w)
+}
+
 // Set ...
 func (d *db) Set(req *schema.SetRequest) (*schema.TxHeader, error) {
 	d.mutex.RLock()
@@ -312,12 +417,18 @@ func (d *db) set(req *schema.SetRequest) (*schema.TxHeader, error) {
 		return nil, ErrIllegalArguments
 	}
 
+	if len(req.IdempotencyKey) > 0 {
+		return d.idempotentSet(req)
+	}
+
 	tx, err := d.st.NewWriteOnlyTx()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Cancel()
 
+	tx.WithMetadata(schema.TxMetadataFromProto(req.TxMetadata))
+
 	keys := make(map[[sha256.Size]byte]struct{}, len(req.KVs))
 
 	for _, kv := range req.KVs {
@@ -334,6 +445,11 @@ func (d *db) set(req *schema.SetRequest) (*schema.TxHeader, error) {
 
 		e := EncodeEntrySpec(kv.Key, schema.KVMetadataFromProto(kv.Metadata), kv.Value)
 
+		err = d.checkExpirationRetentionLock(e.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
 		err = tx.Set(e.Key, e.Metadata, e.Value)
 		if err != nil {
 			return nil, err
@@ -401,6 +517,9 @@ func (d *db) getAt(key []byte, atTx uint64, resolved int, index store.KeyIndex,
 		md = valRef.KVMetadata()
 
 		val, err = valRef.Resolve()
+		if err == store.ErrValueErased {
+			return erasedEntry(key, txID, md), nil
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -408,6 +527,9 @@ func (d *db) getAt(key []byte, atTx uint64, resolved int, index store.KeyIndex,
 		txID = atTx
 
 		md, val, err = d.readMetadataAndValue(key, atTx, tx)
+		if err == store.ErrValueErased {
+			return erasedEntry(key, txID, md), nil
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -471,6 +593,10 @@ func (d *db) readMetadataAndValue(key []byte, atTx uint64, tx *store.Tx) (*store
 	}
 
 	v, err := d.st.ReadValue(entry)
+	if err == store.ErrValueErased {
+		// metadata is kept when the value is erased
+		return entry.Metadata(), nil, err
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -561,6 +687,11 @@ func (d *db) VerifiableGet(req *schema.VerifiableGetRequest) (*schema.Verifiable
 		return nil, err
 	}
 
+	if e.Erased {
+		// the inclusion of an erased value can not be proven
+		return nil, store.ErrValueErased
+	}
+
 	tx := d.st.NewTxHolder()
 
 	var vTxID uint64
@@ -637,6 +768,11 @@ func (d *db) Delete(req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 		return nil, ErrIsReplica
 	}
 
+	err := d.st.CheckRetentionLock()
+	if err != nil {
+		return nil, err
+	}
+
 	currTxID, _ := d.st.Alh()
 
 	if req.SinceTx > currTxID {
@@ -648,7 +784,7 @@ func (d *db) Delete(req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 		waitUntilTx = currTxID
 	}
 
-	err := d.WaitForIndexingUpto(waitUntilTx, nil)
+	err = d.WaitForIndexingUpto(waitUntilTx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -659,6 +795,8 @@ func (d *db) Delete(req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 	}
 	defer tx.Cancel()
 
+	tx.WithMetadata(schema.TxMetadataFromProto(req.TxMetadata))
+
 	for _, k := range req.Keys {
 		if len(k) == 0 {
 			return nil, ErrIllegalArguments
@@ -763,6 +901,30 @@ func (d *db) TxByID(req *schema.TxRequest) (*schema.Tx, error) {
 	return schema.TxToProto(tx), nil
 }
 
+// TxByTime returns the last transaction committed at or before the requested time,
+// or the first one committed at or after it when req.Since is set
+func (d *db) TxByTime(req *schema.TxByTimeRequest) (*schema.Tx, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	ts := time.Unix(req.Ts, 0)
+
+	var txID uint64
+	var err error
+
+	if req.Since {
+		txID, err = d.st.FirstTxSince(ts)
+	} else {
+		txID, err = d.st.LastTxUntil(ts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d.TxByID(&schema.TxRequest{Tx: txID})
+}
+
 func (d *db) ExportTxByID(req *schema.TxRequest) ([]byte, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
@@ -784,9 +946,18 @@ func (d *db) ReplicateTx(exportedTx []byte) (*schema.TxHeader, error) {
 		return nil, err
 	}
 
+	err = d.applyErasures(hdr.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return schema.TxHeaderToProto(hdr), nil
 }
 
+func (d *db) SetRepairSource(fetchTx store.TxFetcher) {
+	d.st.SetRepairSource(fetchTx)
+}
+
 //VerifiableTxByID ...
 func (d *db) VerifiableTxByID(req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error) {
 	if req == nil {
@@ -840,6 +1011,40 @@ func (d *db) VerifiableTxByID(req *schema.VerifiableTxRequest) (*schema.Verifiab
 	}, nil
 }
 
+// ConsistencyProof returns a dual proof between two arbitrary transactions, no key lookup is involved
+// so auditors can verify the database only grew by appending transactions between two recorded states
+func (d *db) ConsistencyProof(req *schema.ConsistencyProofRequest) (*schema.DualProof, error) {
+	if req == nil || req.SourceTx == 0 || req.SourceTx > req.TargetTx {
+		return nil, ErrIllegalArguments
+	}
+
+	lastTxID, _ := d.st.Alh()
+	if lastTxID < req.TargetTx {
+		return nil, ErrIllegalState
+	}
+
+	sourceTx := d.st.NewTxHolder()
+
+	err := d.st.ReadTx(req.SourceTx, sourceTx)
+	if err != nil {
+		return nil, err
+	}
+
+	targetTx := d.st.NewTxHolder()
+
+	err = d.st.ReadTx(req.TargetTx, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	dualProof, err := d.st.DualProof(sourceTx, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.DualProofToProto(dualProof), nil
+}
+
 //TxScan ...
 func (d *db) TxScan(req *schema.TxScanRequest) (*schema.TxList, error) {
 	if req == nil {
@@ -863,7 +1068,7 @@ func (d *db) TxScan(req *schema.TxScanRequest) (*schema.TxList, error) {
 
 	txList := &schema.TxList{}
 
-	for i := 0; i < limit; i++ {
+	for examined := 0; len(txList.Txs) < limit; examined++ {
 		tx, err := txReader.Read()
 		if err == store.ErrNoMoreEntries {
 			break
@@ -872,12 +1077,81 @@ func (d *db) TxScan(req *schema.TxScanRequest) (*schema.TxList, error) {
 			return nil, err
 		}
 
-		txList.Txs = append(txList.Txs, schema.TxToProto(tx))
+		if hasAnnotations(tx.Header().Metadata, req.WithAnnotations) {
+			txList.Txs = append(txList.Txs, schema.TxToProto(tx))
+			continue
+		}
+
+		if examined+1 < maxTxScanExaminedTxs {
+			continue
+		}
+
+		// too many txs were filtered out, the scan can be resumed from the following one
+		if !req.Desc {
+			txList.NextTx = tx.Header().ID + 1
+		} else if tx.Header().ID > 1 {
+			txList.NextTx = tx.Header().ID - 1
+		}
+
+		break
 	}
 
 	return txList, nil
 }
 
+// hasAnnotations returns true if every given annotation is present in the tx metadata with the same value
+func hasAnnotations(md *store.TxMetadata, annotations map[string]string) bool {
+	for k, v := range annotations {
+		av, ok := md.Annotation(k)
+		if !ok || av != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ExportTxHeaders returns the canonical serialization of the headers of a range of transactions,
+// so the accumulated linear hash can be recomputed without relying on immudb verification code
+func (d *db) ExportTxHeaders(req *schema.TxHeadersRequest) (*schema.TxHeaders, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if req.Limit > MaxKeyScanLimit {
+		return nil, ErrMaxKeyScanLimitExceeded
+	}
+
+	limit := int(req.Limit)
+
+	if req.Limit == 0 {
+		limit = MaxKeyScanLimit
+	}
+
+	txReader, err := d.st.NewTxReader(req.InitialTx, false, d.st.NewTxHolder())
+	if err != nil {
+		return nil, err
+	}
+
+	txHeaders := &schema.TxHeaders{
+		HashAlgorithm: d.st.HashAlgorithm().String(),
+	}
+
+	for i := 0; i < limit; i++ {
+		tx, err := txReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		txHeaders.Headers = append(txHeaders.Headers, tx.Header().Bytes())
+	}
+
+	return txHeaders, nil
+}
+
 //History ...
 func (d *db) History(req *schema.HistoryRequest) (*schema.Entries, error) {
 	if req == nil {
@@ -912,19 +1186,80 @@ func (d *db) History(req *schema.HistoryRequest) (*schema.Entries, error) {
 
 	key := EncodeKey(req.Key)
 
-	txs, err := d.st.History(key, req.Offset, req.Desc, limit)
+	var txs []uint64
+
+	if req.SinceTime > 0 {
+		txs, err = d.historySince(key, time.Unix(req.SinceTime, 0), req.Offset, req.Desc, limit)
+	} else {
+		txs, err = d.st.History(key, req.Offset, req.Desc, limit)
+	}
 	if err != nil && err != store.ErrOffsetOutOfRange {
 		return nil, err
 	}
 
+	return d.historyEntries(req.Key, txs, d.st.NewTxHolder())
+}
+
+// historySince returns the transactions setting the versions of key committed at or after ts,
+// offset and limit are applied only over such versions
+func (d *db) historySince(key []byte, ts time.Time, offset uint64, desc bool, limit int) ([]uint64, error) {
+	sinceTx, err := d.st.FirstTxSince(ts)
+	if err == store.ErrTxNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []uint64
+	var skipped uint64
+
+	for pageOffset := uint64(0); ; pageOffset += uint64(limit) {
+		page, err := d.st.History(key, pageOffset, desc, limit)
+		if err == store.ErrOffsetOutOfRange {
+			return txs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, txID := range page {
+			if txID < sinceTx {
+				if desc {
+					// remaining versions are older
+					return txs, nil
+				}
+				continue
+			}
+
+			if skipped < offset {
+				skipped++
+				continue
+			}
+
+			txs = append(txs, txID)
+
+			if len(txs) == limit {
+				return txs, nil
+			}
+		}
+
+		if len(page) < limit {
+			return txs, nil
+		}
+	}
+}
+
+// historyEntries reads the entries of key set by each of the given transactions
+func (d *db) historyEntries(rawKey []byte, txs []uint64, tx *store.Tx) (*schema.Entries, error) {
+	key := EncodeKey(rawKey)
+
 	list := &schema.Entries{
 		Entries: make([]*schema.Entry, len(txs)),
 	}
 
-	tx := d.st.NewTxHolder()
-
 	for i, txID := range txs {
-		err = d.st.ReadTx(txID, tx)
+		err := d.st.ReadTx(txID, tx)
 		if err != nil {
 			return nil, err
 		}
@@ -935,7 +1270,7 @@ func (d *db) History(req *schema.HistoryRequest) (*schema.Entries, error) {
 		}
 
 		val, err := d.st.ReadValue(entry)
-		if err != nil && err != store.ErrExpiredEntry {
+		if err != nil && err != store.ErrExpiredEntry && err != store.ErrValueErased {
 			return nil, err
 		}
 		if len(val) > 0 {
@@ -944,10 +1279,11 @@ func (d *db) History(req *schema.HistoryRequest) (*schema.Entries, error) {
 
 		list.Entries[i] = &schema.Entry{
 			Tx:       txID,
-			Key:      req.Key,
+			Key:      rawKey,
 			Metadata: schema.KVMetadataToProto(entry.Metadata()),
 			Value:    val,
 			Expired:  err == store.ErrExpiredEntry,
+			Erased:   err == store.ErrValueErased,
 		}
 	}
 
@@ -998,6 +1334,31 @@ func (d *db) IsReplica() bool {
 	return d.options.replica
 }
 
+// RetainUntil returns the date before which data can not be deleted, erased, truncated, reclaimed nor expire (WORM compliance mode),
+// the zero time when the database is not in compliance mode
+func (d *db) RetainUntil() time.Time {
+	return d.st.RetainUntil()
+}
+
+// SetRetainUntil enables the WORM compliance mode until the given date, the date can only be extended
+func (d *db) SetRetainUntil(retainUntil time.Time) error {
+	return d.st.SetRetainUntil(retainUntil)
+}
+
+// checkExpirationRetentionLock returns ErrRetentionLocked when the entry would expire before the retention date
+func (d *db) checkExpirationRetentionLock(md *store.KVMetadata) error {
+	if md == nil || !md.IsExpirable() {
+		return nil
+	}
+
+	expiresAt, err := md.ExpirationTime()
+	if err != nil {
+		return err
+	}
+
+	return d.st.CheckRetentionLockAt(expiresAt)
+}
+
 func logErr(log logger.Logger, formattedMessage string, err error) error {
 	if err != nil {
 		log.Errorf(formattedMessage, err)
@@ -16,7 +16,9 @@ limitations under the License.
 package database
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
 	"log"
 	"os"
 	"path"
@@ -25,10 +27,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/fs"
 	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/signer"
 	"github.com/stretchr/testify/require"
 )
 
@@ -183,6 +187,36 @@ func TestOpenDB(t *testing.T) {
 	os.RemoveAll(options.GetDBRootPath())
 }
 
+func TestOpenDBReadOnly(t *testing.T) {
+	options := DefaultOption().WithDBName("db").WithDBRootPath("data_read_only")
+	db, err := NewDB(options, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+
+	defer os.RemoveAll(options.GetDBRootPath())
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value")}}})
+	require.NoError(t, err)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	roOptions := DefaultOption().WithDBName("db").WithDBRootPath("data_read_only").AsReadOnly(true)
+	require.True(t, roOptions.IsReadOnly())
+
+	roDB, err := OpenDB(roOptions, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+
+	entry, err := roDB.Get(&schema.KeyRequest{Key: []byte("key")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), entry.Value)
+
+	_, err = roDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value1")}}})
+	require.ErrorIs(t, err, store.ErrReadOnlyStore)
+
+	err = roDB.Close()
+	require.NoError(t, err)
+}
+
 func TestOpenV1_0_1_DB(t *testing.T) {
 	copier := fs.NewStandardCopier()
 	require.NoError(t, copier.CopyDir("../../test/data_v1.1.0", "data_v1.1.0"))
@@ -238,7 +272,10 @@ func TestDbSetGet(t *testing.T) {
 		require.Equal(t, uint64(i+2), txhdr.Id)
 
 		if i == 0 {
-			alh := schema.TxHeaderFromProto(txhdr).Alh()
+			hdr, err := schema.TxHeaderFromProto(txhdr)
+			require.NoError(t, err)
+			alh := hdr.Alh()
+
 			copy(trustedAlh[:], alh[:])
 			trustedIndex = 2
 		}
@@ -262,7 +299,8 @@ func TestDbSetGet(t *testing.T) {
 		require.Equal(t, kv.Value, vitem.Entry.Value)
 
 		inclusionProof := schema.InclusionProofFromProto(vitem.InclusionProof)
-		dualProof := schema.DualProofFromProto(vitem.VerifiableTx.DualProof)
+		dualProof, err := schema.DualProofFromProto(vitem.VerifiableTx.DualProof)
+		require.NoError(t, err)
 
 		var eh [sha256.Size]byte
 		var sourceID, targetID uint64
@@ -284,7 +322,7 @@ func TestDbSetGet(t *testing.T) {
 
 		entrySpec := EncodeEntrySpec(vitem.Entry.Key, schema.KVMetadataFromProto(vitem.Entry.Metadata), vitem.Entry.Value)
 
-		entrySpecDigest, err := store.EntrySpecDigestFor(int(txhdr.Version))
+		entrySpecDigest, err := store.EntrySpecDigestFor(int(txhdr.Version), hashing.Algorithm(txhdr.HashAlgorithm))
 		require.NoError(t, err)
 		require.NotNil(t, entrySpecDigest)
 
@@ -292,6 +330,7 @@ func TestDbSetGet(t *testing.T) {
 			inclusionProof,
 			entrySpecDigest(entrySpec),
 			eh,
+			hashing.Algorithm(txhdr.HashAlgorithm),
 		)
 		require.True(t, verifies)
 
@@ -456,6 +495,71 @@ func TestSafeSetGet(t *testing.T) {
 	}
 }
 
+func TestSetGetTypedMetadata(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	md := &schema.KVMetadata{
+		ContentType: "application/json",
+		Encoding:    "gzip",
+		Headers:     map[string]string{"schema": "v1"},
+	}
+
+	_, err := db.Set(&schema.SetRequest{
+		KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1"), Metadata: md}},
+	})
+	require.NoError(t, err)
+
+	entry, err := db.Get(&schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, "application/json", entry.Metadata.ContentType)
+	require.Equal(t, "gzip", entry.Metadata.Encoding)
+	require.Equal(t, map[string]string{"schema": "v1"}, entry.Metadata.Headers)
+
+	vEntry, err := db.VerifiableGet(&schema.VerifiableGetRequest{
+		KeyRequest: &schema.KeyRequest{Key: []byte("key1")},
+	})
+	require.NoError(t, err)
+
+	hdr, err := schema.TxHeaderFromProto(vEntry.VerifiableTx.Tx.Header)
+	require.NoError(t, err)
+
+	entrySpecDigest, err := store.EntrySpecDigestFor(hdr.Version, hdr.HashAlgorithm)
+	require.NoError(t, err)
+
+	e := EncodeEntrySpec([]byte("key1"), schema.KVMetadataFromProto(vEntry.Entry.Metadata), vEntry.Entry.Value)
+
+	verifies := store.VerifyInclusion(
+		schema.InclusionProofFromProto(vEntry.InclusionProof),
+		entrySpecDigest(e),
+		hdr.Eh,
+		hdr.HashAlgorithm,
+	)
+	require.True(t, verifies)
+
+	// tampering typed metadata breaks the inclusion proof
+	vEntry.Entry.Metadata.ContentType = "text/plain"
+
+	e = EncodeEntrySpec([]byte("key1"), schema.KVMetadataFromProto(vEntry.Entry.Metadata), vEntry.Entry.Value)
+
+	verifies = store.VerifyInclusion(
+		schema.InclusionProofFromProto(vEntry.InclusionProof),
+		entrySpecDigest(e),
+		hdr.Eh,
+		hdr.HashAlgorithm,
+	)
+	require.False(t, verifies)
+
+	_, err = db.Set(&schema.SetRequest{
+		KVs: []*schema.KeyValue{{
+			Key:      []byte("key2"),
+			Value:    []byte("value2"),
+			Metadata: &schema.KVMetadata{Headers: map[string]string{"": "invalid"}},
+		}},
+	})
+	require.ErrorIs(t, err, store.ErrIllegalArguments)
+}
+
 func TestSetGetAll(t *testing.T) {
 	db, closer := makeDb()
 	defer closer()
@@ -567,6 +671,510 @@ func TestTxScan(t *testing.T) {
 	}
 }
 
+func TestTxAnnotations(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.Set(&schema.SetRequest{
+		KVs:        []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}},
+		TxMetadata: &schema.TxMetadata{Annotations: map[string]string{"source": "ingestion", "batch": "1"}},
+	})
+	require.NoError(t, err)
+
+	_, err = db.Set(&schema.SetRequest{
+		KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}},
+	})
+	require.NoError(t, err)
+
+	_, err = db.ExecAll(&schema.ExecAllRequest{
+		Operations: []*schema.Op{
+			{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{Key: []byte("key3"), Value: []byte("value3")}}},
+		},
+		TxMetadata: &schema.TxMetadata{Annotations: map[string]string{"source": "ingestion", "batch": "2"}},
+	})
+	require.NoError(t, err)
+
+	hdr, err := db.Set(&schema.SetRequest{
+		KVs:            []*schema.KeyValue{{Key: []byte("key4"), Value: []byte("value4")}},
+		TxMetadata:     &schema.TxMetadata{Annotations: map[string]string{"source": "replay"}},
+		IdempotencyKey: []byte("request1"),
+	})
+	require.NoError(t, err)
+
+	_, err = db.Set(&schema.SetRequest{
+		KVs:        []*schema.KeyValue{{Key: []byte("key5"), Value: []byte("value5")}},
+		TxMetadata: &schema.TxMetadata{Annotations: map[string]string{"": "invalid"}},
+	})
+	require.ErrorIs(t, err, store.ErrIllegalArguments)
+
+	tx, err := db.TxByID(&schema.TxRequest{Tx: hdr.Id})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"source": "replay"}, tx.Header.Metadata.Annotations)
+	require.Equal(t, hdr.Metadata.Annotations, tx.Header.Metadata.Annotations)
+
+	txList, err := db.TxScan(&schema.TxScanRequest{
+		InitialTx:       1,
+		WithAnnotations: map[string]string{"source": "ingestion"},
+	})
+	require.NoError(t, err)
+	require.Len(t, txList.Txs, 2)
+	require.Equal(t, "1", txList.Txs[0].Header.Metadata.Annotations["batch"])
+	require.Equal(t, "2", txList.Txs[1].Header.Metadata.Annotations["batch"])
+
+	txList, err = db.TxScan(&schema.TxScanRequest{
+		InitialTx:       1,
+		Limit:           1,
+		Desc:            true,
+		WithAnnotations: map[string]string{"source": "ingestion"},
+	})
+	require.NoError(t, err)
+	require.Len(t, txList.Txs, 0)
+
+	txList, err = db.TxScan(&schema.TxScanRequest{
+		InitialTx:       hdr.Id,
+		Limit:           1,
+		Desc:            true,
+		WithAnnotations: map[string]string{"source": "ingestion"},
+	})
+	require.NoError(t, err)
+	require.Len(t, txList.Txs, 1)
+	require.Equal(t, "2", txList.Txs[0].Header.Metadata.Annotations["batch"])
+
+	txList, err = db.TxScan(&schema.TxScanRequest{
+		InitialTx:       1,
+		WithAnnotations: map[string]string{"source": "ingestion", "batch": "3"},
+	})
+	require.NoError(t, err)
+	require.Empty(t, txList.Txs)
+	require.Zero(t, txList.NextTx)
+
+	t.Run("scans examining too many txs should be resumable", func(t *testing.T) {
+		defer func(n int) { maxTxScanExaminedTxs = n }(maxTxScanExaminedTxs)
+		maxTxScanExaminedTxs = 2
+
+		txList, err := db.TxScan(&schema.TxScanRequest{
+			InitialTx:       1,
+			WithAnnotations: map[string]string{"source": "ingestion"},
+		})
+		require.NoError(t, err)
+		require.Len(t, txList.Txs, 1)
+		require.Equal(t, "1", txList.Txs[0].Header.Metadata.Annotations["batch"])
+		require.NotZero(t, txList.NextTx)
+
+		txList, err = db.TxScan(&schema.TxScanRequest{
+			InitialTx:       txList.NextTx,
+			WithAnnotations: map[string]string{"source": "ingestion"},
+		})
+		require.NoError(t, err)
+		require.Len(t, txList.Txs, 1)
+		require.Equal(t, "2", txList.Txs[0].Header.Metadata.Annotations["batch"])
+
+		txList, err = db.TxScan(&schema.TxScanRequest{
+			InitialTx:       hdr.Id,
+			Desc:            true,
+			WithAnnotations: map[string]string{"source": "ingestion", "batch": "1"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, txList.Txs)
+		require.Equal(t, hdr.Id-2, txList.NextTx)
+	})
+}
+
+func TestTxByTime(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	rootPath := "data_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	options := DefaultOption().WithDBRootPath(rootPath).WithDBName("db").WithCorruptionChecker(false)
+	options.storeOpts.WithTimeFunc(func() time.Time { return now })
+
+	db, closer := makeDbWith(options)
+	defer closer()
+
+	for i := 1; i <= 3; i++ {
+		now = now.Add(time.Minute)
+
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value" + strconv.Itoa(i))}}})
+		require.NoError(t, err)
+	}
+
+	_, err := db.TxByTime(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.TxByTime(&schema.TxByTimeRequest{Ts: 999})
+	require.ErrorIs(t, err, store.ErrTxNotFound)
+
+	tx, err := db.TxByTime(&schema.TxByTimeRequest{Ts: 1090})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), tx.Header.Id)
+	require.Equal(t, int64(1060), tx.Header.Ts)
+
+	tx, err = db.TxByTime(&schema.TxByTimeRequest{Ts: 1090, Since: true})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), tx.Header.Id)
+
+	_, err = db.TxByTime(&schema.TxByTimeRequest{Ts: 1200, Since: true})
+	require.ErrorIs(t, err, store.ErrTxNotFound)
+
+	t.Run("history since time", func(t *testing.T) {
+		entries, err := db.History(&schema.HistoryRequest{Key: []byte("key"), SinceTime: 1090})
+		require.NoError(t, err)
+		require.Len(t, entries.Entries, 2)
+		require.Equal(t, []byte("value2"), entries.Entries[0].Value)
+		require.Equal(t, []byte("value3"), entries.Entries[1].Value)
+
+		entries, err = db.History(&schema.HistoryRequest{Key: []byte("key"), SinceTime: 1090, Desc: true})
+		require.NoError(t, err)
+		require.Len(t, entries.Entries, 2)
+		require.Equal(t, []byte("value3"), entries.Entries[0].Value)
+		require.Equal(t, []byte("value2"), entries.Entries[1].Value)
+
+		entries, err = db.History(&schema.HistoryRequest{Key: []byte("key"), SinceTime: 1090, Offset: 1, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, entries.Entries, 1)
+		require.Equal(t, []byte("value3"), entries.Entries[0].Value)
+
+		entries, err = db.History(&schema.HistoryRequest{Key: []byte("key"), SinceTime: 1200})
+		require.NoError(t, err)
+		require.Empty(t, entries.Entries)
+	})
+}
+
+func TestBackup(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	for _, val := range kvs {
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: val.Key, Value: val.Value}}})
+		require.NoError(t, err)
+	}
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	var backup bytes.Buffer
+
+	manifest, err := db.Backup(&backup, nil)
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, manifest.TxID)
+	require.Equal(t, state.TxHash, manifest.Alh[:])
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("incremental"), Value: []byte("value")}}})
+	require.NoError(t, err)
+
+	var incBackup bytes.Buffer
+
+	incManifest, err := db.Backup(&incBackup, manifest)
+	require.NoError(t, err)
+	require.Equal(t, manifest.TxID, incManifest.SinceTxID)
+	require.Equal(t, manifest.TxID+1, incManifest.TxID)
+
+	restoredRootPath := db.GetOptions().dbRootPath + "_restored"
+	defer os.RemoveAll(restoredRootPath)
+
+	restoredManifest, err := store.RestoreBackup(filepath.Join(restoredRootPath, "db"), &backup, db.GetOptions().GetStoreOptions())
+	require.NoError(t, err)
+	require.Equal(t, manifest, restoredManifest)
+
+	restoredManifest, err = store.RestoreIncrementalBackup(filepath.Join(restoredRootPath, "db"), &incBackup, db.GetOptions().GetStoreOptions())
+	require.NoError(t, err)
+	require.Equal(t, incManifest, restoredManifest)
+
+	restoredOptions := DefaultOption().WithDBRootPath(restoredRootPath).WithDBName("db").WithCorruptionChecker(false)
+
+	restoredDB, err := OpenDB(restoredOptions, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+	defer restoredDB.Close()
+
+	for _, val := range kvs {
+		item, err := restoredDB.Get(&schema.KeyRequest{Key: val.Key, SinceTx: incManifest.TxID})
+		require.NoError(t, err)
+		require.Equal(t, val.Value, item.Value)
+	}
+
+	item, err := restoredDB.Get(&schema.KeyRequest{Key: []byte("incremental"), SinceTx: incManifest.TxID})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), item.Value)
+}
+
+func TestExportAndImport(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	for _, val := range kvs {
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: val.Key, Value: val.Value}}})
+		require.NoError(t, err)
+	}
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	sig, err := signer.NewSigner("./../../test/signer/ec3.key")
+	require.NoError(t, err)
+
+	sign := func(state *schema.ImmutableState) error {
+		signature, publicKey, err := sig.Sign(state.ToBytes())
+		state.Signature = &schema.Signature{Signature: signature, PublicKey: publicKey}
+		return err
+	}
+
+	var export bytes.Buffer
+
+	manifest, err := db.Export(&export, sign)
+	require.NoError(t, err)
+	require.Equal(t, "db", manifest.Database)
+	require.Equal(t, state.TxId, manifest.TxID)
+	require.Equal(t, state.TxHash, manifest.Alh)
+
+	readManifest, err := ReadExportManifest(bytes.NewReader(export.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, manifest, readManifest)
+
+	pk, err := signer.ParsePublicKeyFile("./../../test/signer/ec3.pub")
+	require.NoError(t, err)
+
+	ok, err := readManifest.State().CheckSignature(pk)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	importedRootPath := db.GetOptions().dbRootPath + "_imported"
+	defer os.RemoveAll(importedRootPath)
+
+	importedOptions := DefaultOption().WithDBRootPath(importedRootPath).WithDBName("imported").WithCorruptionChecker(false)
+
+	t.Run("tampered exports are rejected", func(t *testing.T) {
+		_, err := ReadExportManifest(bytes.NewReader(nil))
+		require.ErrorIs(t, err, ErrInvalidExport)
+
+		// the transaction id in the manifest is changed keeping the size of the manifest
+		tampered := append([]byte{}, export.Bytes()...)
+		i := bytes.Index(tampered, []byte(`"txId":`)) + len(`"txId":`)
+		tampered[i] = '1' + (tampered[i]-'0')%8
+
+		_, _, err = Import(importedOptions, bytes.NewReader(tampered), logger.NewSimpleLogger("immudb ", os.Stderr))
+		require.ErrorIs(t, err, ErrInvalidExport)
+
+		var unsigned bytes.Buffer
+
+		_, err = db.Export(&unsigned, nil)
+		require.NoError(t, err)
+
+		// the manifest of the export is missing
+		truncated := unsigned.Bytes()[:bytes.LastIndex(unsigned.Bytes(), []byte(ExportManifestName))-1]
+
+		_, _, err = Import(importedOptions, bytes.NewReader(truncated), logger.NewSimpleLogger("immudb ", os.Stderr))
+		require.Error(t, err)
+
+		_, err = os.Stat(filepath.Join(importedRootPath, "imported"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	importedDB, importedManifest, err := Import(importedOptions, &export, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+	defer importedDB.Close()
+
+	require.Equal(t, manifest, importedManifest)
+
+	for _, val := range kvs {
+		item, err := importedDB.Get(&schema.KeyRequest{Key: val.Key, SinceTx: manifest.TxID})
+		require.NoError(t, err)
+		require.Equal(t, val.Value, item.Value)
+	}
+
+	_, _, err = Import(importedOptions, bytes.NewReader(nil), logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.Error(t, err)
+}
+
+func TestClone(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	for _, val := range kvs {
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: val.Key, Value: val.Value}}})
+		require.NoError(t, err)
+	}
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	clonesRootPath := db.GetOptions().dbRootPath + "_clones"
+	defer os.RemoveAll(clonesRootPath)
+
+	_, err = Clone(nil, DefaultOption(), 0, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.ErrorIs(t, err, store.ErrIllegalArguments)
+
+	clonedOptions := DefaultOption().WithDBRootPath(clonesRootPath).WithDBName("cloned").WithCorruptionChecker(false)
+
+	cloned, err := Clone(db, clonedOptions, 0, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+	defer cloned.Close()
+
+	clonedState, err := cloned.CurrentState()
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, clonedState.TxId)
+	require.Equal(t, state.TxHash, clonedState.TxHash)
+
+	for _, val := range kvs {
+		item, err := cloned.Get(&schema.KeyRequest{Key: val.Key, SinceTx: state.TxId})
+		require.NoError(t, err)
+		require.Equal(t, val.Value, item.Value)
+	}
+
+	_, err = Clone(db, clonedOptions, 0, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.Error(t, err)
+
+	// the source database keeps accepting writes once cloned
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("after"), Value: []byte("clone")}}})
+	require.NoError(t, err)
+
+	_, err = cloned.Get(&schema.KeyRequest{Key: []byte("after")})
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	t.Run("clone as of a previous transaction", func(t *testing.T) {
+		atTx := state.TxId - 1
+
+		hdr, err := db.TxByID(&schema.TxRequest{Tx: atTx})
+		require.NoError(t, err)
+
+		clonedOptions := DefaultOption().WithDBRootPath(clonesRootPath).WithDBName("clonedattx").WithCorruptionChecker(false)
+
+		cloned, err := Clone(db, clonedOptions, atTx, logger.NewSimpleLogger("immudb ", os.Stderr))
+		require.NoError(t, err)
+		defer cloned.Close()
+
+		clonedState, err := cloned.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, atTx, clonedState.TxId)
+
+		txHdr, err := schema.TxHeaderFromProto(hdr.Header)
+		require.NoError(t, err)
+		require.Equal(t, txHdr.Alh(), schema.DigestFromProto(clonedState.TxHash))
+
+		lastKV := kvs[len(kvs)-1]
+
+		_, err = cloned.Get(&schema.KeyRequest{Key: lastKV.Key, SinceTx: atTx})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+
+	t.Run("clone as of a transaction not yet committed", func(t *testing.T) {
+		clonedOptions := DefaultOption().WithDBRootPath(clonesRootPath).WithDBName("clonedfuture").WithCorruptionChecker(false)
+
+		_, err := Clone(db, clonedOptions, state.TxId+10, logger.NewSimpleLogger("immudb ", os.Stderr))
+		require.ErrorIs(t, err, store.ErrIllegalArguments)
+
+		_, err = os.Stat(filepath.Join(clonesRootPath, "clonedfuture"))
+		require.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestExportTxHeaders(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	for _, val := range kvs {
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: val.Key, Value: val.Value}}})
+		require.NoError(t, err)
+	}
+
+	_, err := db.ExportTxHeaders(nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = db.ExportTxHeaders(&schema.TxHeadersRequest{
+		InitialTx: 0,
+	})
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = db.ExportTxHeaders(&schema.TxHeadersRequest{
+		InitialTx: 1,
+		Limit:     MaxKeyScanLimit + 1,
+	})
+	require.Equal(t, ErrMaxKeyScanLimitExceeded, err)
+
+	txHeaders, err := db.ExportTxHeaders(&schema.TxHeadersRequest{
+		InitialTx: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "sha256", txHeaders.HashAlgorithm)
+	require.Len(t, txHeaders.Headers, len(kvs)+1)
+
+	// the accumulated linear hash is recomputed from raw headers only
+	alh := sha256.Sum256(nil)
+
+	for i, hdr := range txHeaders.Headers {
+		require.Equal(t, uint64(i+1), binary.BigEndian.Uint64(hdr))
+		require.Equal(t, alh[:], hdr[8:40])
+
+		innerHash := sha256.Sum256(hdr[40:])
+		alh = sha256.Sum256(append(hdr[:40:40], innerHash[:]...))
+	}
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(kvs)+1), state.TxId)
+	require.Equal(t, state.TxHash, alh[:])
+
+	txHeaders, err = db.ExportTxHeaders(&schema.TxHeadersRequest{
+		InitialTx: 2,
+		Limit:     1,
+	})
+	require.NoError(t, err)
+	require.Len(t, txHeaders.Headers, 1)
+	require.Equal(t, uint64(2), binary.BigEndian.Uint64(txHeaders.Headers[0]))
+}
+
+func TestConsistencyProof(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	for _, val := range kvs {
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: val.Key, Value: val.Value}}})
+		require.NoError(t, err)
+	}
+
+	_, err := db.ConsistencyProof(nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = db.ConsistencyProof(&schema.ConsistencyProofRequest{SourceTx: 0, TargetTx: 1})
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = db.ConsistencyProof(&schema.ConsistencyProofRequest{SourceTx: 2, TargetTx: 1})
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = db.ConsistencyProof(&schema.ConsistencyProofRequest{SourceTx: 1, TargetTx: uint64(len(kvs) + 2)})
+	require.Equal(t, ErrIllegalState, err)
+
+	sourceTx, err := db.TxByID(&schema.TxRequest{Tx: 2})
+	require.NoError(t, err)
+
+	targetTx, err := db.TxByID(&schema.TxRequest{Tx: uint64(len(kvs) + 1)})
+	require.NoError(t, err)
+
+	proof, err := db.ConsistencyProof(&schema.ConsistencyProofRequest{SourceTx: 2, TargetTx: uint64(len(kvs) + 1)})
+	require.NoError(t, err)
+
+	dualProof, err := schema.DualProofFromProto(proof)
+	require.NoError(t, err)
+
+	sourceHdr, err := schema.TxHeaderFromProto(sourceTx.Header)
+	require.NoError(t, err)
+
+	targetHdr, err := schema.TxHeaderFromProto(targetTx.Header)
+	require.NoError(t, err)
+
+	verifies := store.VerifyDualProof(
+		dualProof,
+		2,
+		uint64(len(kvs)+1),
+		sourceHdr.Alh(),
+		targetHdr.Alh(),
+	)
+	require.True(t, verifies)
+
+	proof, err = db.ConsistencyProof(&schema.ConsistencyProofRequest{SourceTx: 2, TargetTx: 2})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), proof.TargetTxHeader.Id)
+}
+
 func TestHistory(t *testing.T) {
 	db, closer := makeDb()
 	defer closer()
@@ -16,7 +16,10 @@ limitations under the License.
 
 package database
 
-import "github.com/codenotary/immudb/embedded/store"
+import (
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+)
 
 //Options database instance options
 type Options struct {
@@ -27,7 +30,11 @@ type Options struct {
 
 	replica bool
 
+	readOnly bool
+
 	corruptionChecker bool
+
+	sqlDistinctLimit int
 }
 
 // DefaultOption Initialise Db Optionts to default values
@@ -36,6 +43,8 @@ func DefaultOption() *Options {
 		dbRootPath: "./data",
 		dbName:     "db_name",
 		storeOpts:  store.DefaultOptions(),
+
+		sqlDistinctLimit: sql.DefaultDistinctLimit,
 	}
 }
 
@@ -61,7 +70,8 @@ func (o *Options) GetDBRootPath() string {
 	return o.dbRootPath
 }
 
-// WithCorruptionChecker sets if corruption checker should start for this database instance
+// WithCorruptionChecker sets if corruption checker should start for this database instance.
+// The checker periodically verifies randomly selected transactions in background
 func (o *Options) WithCorruptionChecker(cc bool) *Options {
 	o.corruptionChecker = cc
 	return o
@@ -72,6 +82,30 @@ func (o *Options) GetCorruptionChecker() bool {
 	return o.corruptionChecker
 }
 
+// storeOptions returns the options used to open the store, scrubbing is enabled by the corruption checker
+func (o *Options) storeOptions() *store.Options {
+	if o.corruptionChecker && o.storeOpts.ScrubFrequency == 0 {
+		o.storeOpts.WithScrubFrequency(store.DefaultScrubFrequency)
+	}
+
+	if o.readOnly {
+		o.storeOpts.WithReadOnly(true)
+	}
+
+	return o.storeOpts
+}
+
+// sqlOptions returns the options used to open the sql engine
+func (o *Options) sqlOptions() *sql.Options {
+	sqlOpts := sql.DefaultOptions().WithPrefix([]byte{SQLPrefix})
+
+	if o.sqlDistinctLimit > 0 {
+		sqlOpts.WithDistinctLimit(o.sqlDistinctLimit)
+	}
+
+	return sqlOpts
+}
+
 // WithStoreOptions sets backing store options
 func (o *Options) WithStoreOptions(storeOpts *store.Options) *Options {
 	o.storeOpts = storeOpts
@@ -83,8 +117,31 @@ func (o *Options) GetStoreOptions() *store.Options {
 	return o.storeOpts
 }
 
+// WithSQLDistinctLimit sets the max number of rows kept in memory to resolve distinct sql queries
+func (o *Options) WithSQLDistinctLimit(distinctLimit int) *Options {
+	o.sqlDistinctLimit = distinctLimit
+	return o
+}
+
+// GetSQLDistinctLimit returns the max number of rows kept in memory to resolve distinct sql queries
+func (o *Options) GetSQLDistinctLimit() int {
+	return o.sqlDistinctLimit
+}
+
 // AsReplica sets if the database is a replica
 func (o *Options) AsReplica(replica bool) *Options {
 	o.replica = replica
 	return o
 }
+
+// AsReadOnly sets if the database files are opened without being modified,
+// so they can be safely inspected while in use by another process
+func (o *Options) AsReadOnly(readOnly bool) *Options {
+	o.readOnly = readOnly
+	return o
+}
+
+// IsReadOnly returns if the database is opened in read-only mode
+func (o *Options) IsReadOnly() bool {
+	return o.readOnly
+}
@@ -19,6 +19,7 @@ package database
 import (
 	"testing"
 
+	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/stretchr/testify/require"
 )
@@ -58,4 +59,13 @@ func TestDefaultOptions(t *testing.T) {
 	}
 
 	require.Equal(t, storeOpts, op.storeOpts)
+	require.Equal(t, store.DefaultScrubFrequency, op.storeOptions().ScrubFrequency)
+	require.Zero(t, DefaultOption().storeOptions().ScrubFrequency)
+
+	require.False(t, DefaultOption().IsReadOnly())
+	require.True(t, DefaultOption().AsReadOnly(true).storeOptions().ReadOnly)
+
+	require.Equal(t, sql.DefaultDistinctLimit, DefaultOption().GetSQLDistinctLimit())
+	require.Equal(t, 100, DefaultOption().WithSQLDistinctLimit(100).GetSQLDistinctLimit())
+	require.NotNil(t, DefaultOption().WithSQLDistinctLimit(0).sqlOptions())
 }
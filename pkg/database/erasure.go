@@ -0,0 +1,233 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+type erasureRecord struct {
+	Tx          uint64 `json:"tx"`
+	RequestedBy string `json:"requestedBy,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// EncodeErasureKey returns the key under which the erasures of the values of a key are recorded
+func EncodeErasureKey(key []byte) []byte {
+	return WrapWithPrefix(key, ErasurePrefix)
+}
+
+func erasedEntry(key []byte, txID uint64, md *store.KVMetadata) *schema.Entry {
+	return &schema.Entry{
+		Tx:       txID,
+		Key:      TrimPrefix(key),
+		Metadata: schema.KVMetadataToProto(md),
+		Erased:   true,
+	}
+}
+
+// Erase overwrites the value of a key, the digest of the value is kept so proofs still verify.
+// The erasure is recorded, together with who requested it and why, before the value is overwritten.
+func (d *db) Erase(req *schema.EraseRequest, requestedBy string) (*schema.Erasure, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if req == nil || len(req.Key) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	// checked upfront so no erasure is recorded for values which can not be erased
+	err := d.st.CheckRetentionLock()
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.checkLegalHold(req.Key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	key := EncodeKey(req.Key)
+
+	txID := req.AtTx
+
+	if txID == 0 {
+		lastTxID, _ := d.st.Alh()
+
+		err := d.st.WaitForIndexingUpto(lastTxID, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		valRef, err := d.st.Get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		txID = valRef.Tx()
+	}
+
+	tx := d.st.NewTxHolder()
+
+	err = d.st.ReadTx(txID, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.EntryOf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := json.Marshal(&erasureRecord{
+		Tx:          txID,
+		RequestedBy: requestedBy,
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	otx, err := d.st.NewWriteOnlyTx()
+	if err != nil {
+		return nil, err
+	}
+	defer otx.Cancel()
+
+	err = otx.Set(EncodeErasureKey(req.Key), nil, record)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr, err := otx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = d.st.EraseValue(txID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Logger.Infof("value of key '%s' set at tx %d erased from database '%s' as requested by '%s'", req.Key, txID, d.name, requestedBy)
+
+	return &schema.Erasure{
+		Key:         req.Key,
+		Tx:          txID,
+		RequestedBy: requestedBy,
+		Reason:      req.Reason,
+		RecordTx:    hdr.ID,
+		RequestedAt: hdr.Ts,
+	}, nil
+}
+
+// ErasureHistory returns the erasures of the values of a key, oldest first
+func (d *db) ErasureHistory(req *schema.ErasureHistoryRequest) (*schema.ErasureHistory, error) {
+	if req == nil || len(req.Key) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	lastTxID, _ := d.st.Alh()
+
+	err := d.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	key := EncodeErasureKey(req.Key)
+
+	history := &schema.ErasureHistory{}
+
+	txs, err := d.st.History(key, 0, false, MaxKeyScanLimit)
+	if err == store.ErrKeyNotFound || err == store.ErrNoMoreEntries {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := d.st.NewTxHolder()
+
+	for _, txID := range txs {
+		_, val, err := d.readMetadataAndValue(key, txID, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		var record erasureRecord
+
+		err = json.Unmarshal(val, &record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid erasure record", store.ErrCorruptedData)
+		}
+
+		history.Erasures = append(history.Erasures, &schema.Erasure{
+			Key:         req.Key,
+			Tx:          record.Tx,
+			RequestedBy: record.RequestedBy,
+			Reason:      record.Reason,
+			RecordTx:    txID,
+			RequestedAt: tx.Header().Ts,
+		})
+	}
+
+	return history, nil
+}
+
+// applyErasures erases the values whose erasure is recorded by the transaction txID,
+// it's used by replicas to apply the erasures requested on the primary database
+func (d *db) applyErasures(txID uint64) error {
+	tx := d.st.NewTxHolder()
+
+	err := d.st.ReadTx(txID, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range tx.Entries() {
+		if e.Key()[0] != ErasurePrefix {
+			continue
+		}
+
+		val, err := d.st.ReadValue(e)
+		if err != nil {
+			return err
+		}
+
+		var record erasureRecord
+
+		err = json.Unmarshal(val, &record)
+		if err != nil {
+			return fmt.Errorf("%w: invalid erasure record", store.ErrCorruptedData)
+		}
+
+		_, err = d.st.EraseValue(record.Tx, EncodeKey(TrimPrefix(e.Key())))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
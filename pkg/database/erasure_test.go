@@ -0,0 +1,120 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErasure(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	hdr1, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("personal data")}}})
+	require.NoError(t, err)
+
+	hdr2, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("more personal data")}}})
+	require.NoError(t, err)
+
+	_, err = db.Erase(nil, "alice")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Erase(&schema.EraseRequest{}, "alice")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Erase(&schema.EraseRequest{Key: []byte("key2")}, "alice")
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	_, err = db.Erase(&schema.EraseRequest{Key: []byte("key2"), AtTx: hdr1.Id}, "alice")
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	history, err := db.ErasureHistory(&schema.ErasureHistoryRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Empty(t, history.Erasures)
+
+	erasure, err := db.Erase(&schema.EraseRequest{Key: []byte("key1"), AtTx: hdr1.Id, Reason: "right to erasure"}, "alice")
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, erasure.Tx)
+	require.Equal(t, "alice", erasure.RequestedBy)
+	require.Greater(t, erasure.RecordTx, hdr2.Id)
+
+	entry, err := db.Get(&schema.KeyRequest{Key: []byte("key1"), AtTx: hdr1.Id})
+	require.NoError(t, err)
+	require.True(t, entry.Erased)
+	require.Nil(t, entry.Value)
+
+	entry, err = db.Get(&schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.False(t, entry.Erased)
+	require.Equal(t, []byte("more personal data"), entry.Value)
+
+	entries, err := db.History(&schema.HistoryRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+	require.True(t, entries.Entries[0].Erased)
+	require.False(t, entries.Entries[1].Erased)
+
+	// the current value is erased when no transaction is specified
+	erasure, err = db.Erase(&schema.EraseRequest{Key: []byte("key1")}, "bob")
+	require.NoError(t, err)
+	require.Equal(t, hdr2.Id, erasure.Tx)
+
+	entry, err = db.Get(&schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.True(t, entry.Erased)
+
+	scanned, err := db.Scan(&schema.ScanRequest{Prefix: []byte("key")})
+	require.NoError(t, err)
+	require.Len(t, scanned.Entries, 1)
+	require.True(t, scanned.Entries[0].Erased)
+
+	_, err = db.VerifiableGet(&schema.VerifiableGetRequest{KeyRequest: &schema.KeyRequest{Key: []byte("key1")}})
+	require.ErrorIs(t, err, store.ErrValueErased)
+
+	// proofs including the erased values still verify
+	vtx, err := db.VerifiableTxByID(&schema.VerifiableTxRequest{Tx: hdr1.Id, ProveSinceTx: erasure.RecordTx})
+	require.NoError(t, err)
+
+	dualProof, err := schema.DualProofFromProto(vtx.DualProof)
+	require.NoError(t, err)
+
+	sourceHdr, err := schema.TxHeaderFromProto(hdr1)
+	require.NoError(t, err)
+
+	verifies := store.VerifyDualProof(
+		dualProof,
+		hdr1.Id,
+		erasure.RecordTx,
+		sourceHdr.Alh(),
+		dualProof.TargetTxHeader.Alh(),
+	)
+	require.True(t, verifies)
+
+	history, err = db.ErasureHistory(&schema.ErasureHistoryRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Len(t, history.Erasures, 2)
+	require.Equal(t, hdr1.Id, history.Erasures[0].Tx)
+	require.Equal(t, "alice", history.Erasures[0].RequestedBy)
+	require.Equal(t, "right to erasure", history.Erasures[0].Reason)
+	require.Equal(t, hdr2.Id, history.Erasures[1].Tx)
+	require.Equal(t, "bob", history.Erasures[1].RequestedBy)
+	require.Equal(t, erasure.RequestedAt, history.Erasures[1].RequestedAt)
+}
@@ -28,4 +28,5 @@ var (
 	ErrZAddIndexMissing      = status.New(codes.InvalidArgument, "zAdd index not provided").Err()
 	ErrReferenceIndexMissing = status.New(codes.InvalidArgument, "reference index not provided").Err()
 	ErrDatabaseNotExists     = status.New(codes.NotFound, "database does not exist").Err()
+	ErrInvalidExport         = status.New(codes.InvalidArgument, "invalid export").Err()
 )
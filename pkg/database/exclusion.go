@@ -0,0 +1,221 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// ExclusionProof proves a key was not set up to the transaction req.AtTx.
+// The closest keys set before and after it as of that transaction are taken from the index
+// and proven to be set by the transactions they were set at, linked to req.AtTx by dual proofs.
+// store.ErrKeyAlreadyExists is returned when the key was set at or before req.AtTx.
+func (d *db) ExclusionProof(req *schema.ExclusionProofRequest) (*schema.ExclusionProof, error) {
+	if req == nil || len(req.Key) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	lastTxID, _ := d.st.Alh()
+	if lastTxID < req.ProveSinceTx {
+		return nil, ErrIllegalState
+	}
+
+	atTx := req.AtTx
+	if atTx == 0 {
+		atTx = lastTxID
+	}
+
+	if atTx == 0 || atTx > lastTxID {
+		return nil, ErrIllegalArguments
+	}
+
+	err := d.st.WaitForIndexingUpto(atTx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.st.SnapshotSince(atTx)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	key := EncodeKey(req.Key)
+
+	exists, err := keySetBefore(snap, key, atTx+1)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, store.ErrKeyAlreadyExists
+	}
+
+	atTxHolder := d.st.NewTxHolder()
+
+	err = d.st.ReadTx(atTx, atTxHolder)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &schema.ExclusionProof{
+		Key:  req.Key,
+		AtTx: atTx,
+	}
+
+	proof.Predecessor, err = d.closestKeyInclusion(snap, key, atTxHolder, true)
+	if err != nil {
+		return nil, err
+	}
+
+	proof.Successor, err = d.closestKeyInclusion(snap, key, atTxHolder, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceTx, targetTx *store.Tx
+
+	if req.ProveSinceTx == 0 {
+		sourceTx = atTxHolder
+		targetTx = atTxHolder
+	} else {
+		rootTx := d.st.NewTxHolder()
+
+		err = d.st.ReadTx(req.ProveSinceTx, rootTx)
+		if err != nil {
+			return nil, err
+		}
+
+		if req.ProveSinceTx <= atTx {
+			sourceTx = rootTx
+			targetTx = atTxHolder
+		} else {
+			sourceTx = atTxHolder
+			targetTx = rootTx
+		}
+	}
+
+	dualProof, err := d.st.DualProof(sourceTx, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	proof.DualProof = schema.DualProofToProto(dualProof)
+
+	return proof, nil
+}
+
+func keySetBefore(snap *store.Snapshot, key []byte, beforeTx uint64) (bool, error) {
+	r, err := snap.NewKeyReader(&store.KeyReaderSpec{
+		SeekKey:       key,
+		EndKey:        key,
+		InclusiveSeek: true,
+		InclusiveEnd:  true,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+
+	_, _, _, err = r.ReadAsBefore(beforeTx)
+	if err == store.ErrNoMoreEntries {
+		return false, nil
+	}
+	if err == nil || err == store.ErrExpiredEntry {
+		return true, nil
+	}
+
+	return false, err
+}
+
+// closestKeyInclusion proves the inclusion of the closest key set before (or after) key as of atTx,
+// nil is returned when there is no such key
+func (d *db) closestKeyInclusion(snap *store.Snapshot, key []byte, atTx *store.Tx, before bool) (*schema.KeyInclusion, error) {
+	spec := &store.KeyReaderSpec{
+		SeekKey:   key,
+		Prefix:    []byte{SetKeyPrefix},
+		DescOrder: before,
+	}
+
+	if !before {
+		// keys set by other means are stored after the plain ones
+		spec.EndKey = []byte{SetKeyPrefix + 1}
+	}
+
+	r, err := snap.NewKeyReader(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var ckey []byte
+	var ktxID uint64
+
+	for i := 0; ; i++ {
+		if i == MaxKeyScanLimit {
+			return nil, ErrMaxKeyScanLimitExceeded
+		}
+
+		ckey, _, ktxID, err = r.ReadAsBefore(atTx.Header().ID + 1)
+		if err == store.ErrNoMoreEntries {
+			return nil, nil
+		}
+		if err == store.ErrExpiredEntry {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		break
+	}
+
+	tx := d.st.NewTxHolder()
+
+	err = d.st.ReadTx(ktxID, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := tx.EntryOf(ckey)
+	if err != nil {
+		return nil, err
+	}
+
+	inclusionProof, err := tx.Proof(ckey)
+	if err != nil {
+		return nil, err
+	}
+
+	dualProof, err := d.st.DualProof(tx, atTx)
+	if err != nil {
+		return nil, err
+	}
+
+	hVal := e.HVal()
+
+	return &schema.KeyInclusion{
+		Entry: &schema.TxEntry{
+			Key:      e.Key(),
+			HValue:   hVal[:],
+			VLen:     int32(e.VLen()),
+			Metadata: schema.KVMetadataToProto(e.Metadata()),
+		},
+		InclusionProof: schema.InclusionProofToProto(inclusionProof),
+		DualProof:      schema.DualProofToProto(dualProof),
+	}, nil
+}
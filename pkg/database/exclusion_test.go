@@ -0,0 +1,93 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusionProof(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.ExclusionProof(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.ExclusionProof(&schema.ExclusionProofRequest{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	hdr1, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key1"), Value: []byte("value1")},
+		{Key: []byte("key3"), Value: []byte("value3")},
+	}})
+	require.NoError(t, err)
+
+	hdr2, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+
+	_, err = db.ExclusionProof(&schema.ExclusionProofRequest{Key: []byte("key0"), AtTx: hdr2.Id + 1})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.ExclusionProof(&schema.ExclusionProofRequest{Key: []byte("key0"), ProveSinceTx: hdr2.Id + 1})
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	_, err = db.ExclusionProof(&schema.ExclusionProofRequest{Key: []byte("key2")})
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	proof, err := db.ExclusionProof(&schema.ExclusionProofRequest{Key: []byte("key2"), AtTx: hdr1.Id, ProveSinceTx: hdr2.Id})
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, proof.AtTx)
+	require.Equal(t, hdr1.Id, proof.DualProof.SourceTxHeader.Id)
+	require.Equal(t, hdr2.Id, proof.DualProof.TargetTxHeader.Id)
+	require.Equal(t, EncodeKey([]byte("key1")), proof.Predecessor.Entry.Key)
+	require.Equal(t, EncodeKey([]byte("key3")), proof.Successor.Entry.Key)
+
+	for _, ki := range []*schema.KeyInclusion{proof.Predecessor, proof.Successor} {
+		dualProof, err := schema.DualProofFromProto(ki.DualProof)
+		require.NoError(t, err)
+
+		hdr := dualProof.SourceTxHeader
+
+		require.Equal(t, hdr1.Id, hdr.ID)
+
+		txEntryDigest, err := store.TxEntryDigestFor(hdr.Version, hdr.HashAlgorithm)
+		require.NoError(t, err)
+
+		e := store.NewTxEntry(ki.Entry.Key, schema.KVMetadataFromProto(ki.Entry.Metadata), int(ki.Entry.VLen), schema.DigestFromProto(ki.Entry.HValue), 0)
+
+		verifies := store.VerifyInclusion(schema.InclusionProofFromProto(ki.InclusionProof), txEntryDigest(e), hdr.Eh, hdr.HashAlgorithm)
+		require.True(t, verifies)
+
+		verifies = store.VerifyDualProof(dualProof, hdr.ID, hdr1.Id, hdr.Alh(), dualProof.TargetTxHeader.Alh())
+		require.True(t, verifies)
+	}
+
+	proof, err = db.ExclusionProof(&schema.ExclusionProofRequest{Key: []byte("key4")})
+	require.NoError(t, err)
+	require.Equal(t, hdr2.Id, proof.AtTx)
+	require.Equal(t, EncodeKey([]byte("key3")), proof.Predecessor.Entry.Key)
+	require.Nil(t, proof.Successor)
+
+	proof, err = db.ExclusionProof(&schema.ExclusionProofRequest{Key: []byte("key0")})
+	require.NoError(t, err)
+	require.Nil(t, proof.Predecessor)
+	require.Equal(t, EncodeKey([]byte("key1")), proof.Successor.Entry.Key)
+}
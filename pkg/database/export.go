@@ -0,0 +1,313 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+// An export is a portable copy of a database, used to move it to another server.
+// It's a tar archive holding a full backup of the database, as written by Backup,
+// followed by one last entry named "export.manifest" holding a JSON encoded ExportManifest:
+//
+//	{
+//	  "version": 1,
+//	  "database": "<name of the exported database>",
+//	  "txId": <id of the last transaction included in the export>,
+//	  "alh": "<base64 encoded accumulative hash of that transaction>",
+//	  "signature": "<base64 encoded signature, only when the server has a signing key>",
+//	  "publicKey": "<base64 encoded public key of the signing server>"
+//	}
+//
+// The signature is computed over the immutable state (database, txId, alh) as it's done for
+// the states returned by the server, so it can be checked with ImmutableState.CheckSignature.
+// Importing an export rebuilds the database from the backup and checks it matches the manifest.
+
+// ExportManifestName is the name of the last entry of an export
+const ExportManifestName = "export.manifest"
+
+const exportVersion = 1
+
+// ExportManifest describes the database state included in an export
+type ExportManifest struct {
+	Version   int    `json:"version"`
+	Database  string `json:"database"`
+	TxID      uint64 `json:"txId"`
+	Alh       []byte `json:"alh"`
+	Signature []byte `json:"signature,omitempty"`
+	PublicKey []byte `json:"publicKey,omitempty"`
+}
+
+// State returns the exported state of the database, including its signature if any
+func (m *ExportManifest) State() *schema.ImmutableState {
+	state := &schema.ImmutableState{
+		Db:     m.Database,
+		TxId:   m.TxID,
+		TxHash: m.Alh,
+	}
+
+	if len(m.Signature) > 0 {
+		state.Signature = &schema.Signature{
+			Signature: m.Signature,
+			PublicKey: m.PublicKey,
+		}
+	}
+
+	return state
+}
+
+// Export writes a portable copy of the database into w while it keeps serving requests.
+// The manifest of the export is signed with sign when it's provided.
+func (d *db) Export(w io.Writer, sign func(state *schema.ImmutableState) error) (*ExportManifest, error) {
+	pr, pw := io.Pipe()
+
+	type backupResult struct {
+		manifest *store.BackupManifest
+		err      error
+	}
+
+	backupDone := make(chan backupResult, 1)
+
+	go func() {
+		manifest, err := d.st.Backup(pw)
+		pw.CloseWithError(err)
+		backupDone <- backupResult{manifest: manifest, err: err}
+	}()
+
+	tw := tar.NewWriter(w)
+
+	err := copyTarEntries(tw, tar.NewReader(pr), nil)
+	if err != nil {
+		pr.CloseWithError(err)
+		<-backupDone
+		return nil, err
+	}
+
+	res := <-backupDone
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	manifest := &ExportManifest{
+		Version:  exportVersion,
+		Database: d.name,
+		TxID:     res.manifest.TxID,
+		Alh:      res.manifest.Alh[:],
+	}
+
+	if sign != nil {
+		state := manifest.State()
+
+		err = sign(state)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Signature = state.Signature.Signature
+		manifest.PublicKey = state.Signature.PublicKey
+	}
+
+	mb, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     ExportManifestName,
+		Mode:     0600,
+		Size:     int64(len(mb)),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tw.Write(mb)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, tw.Close()
+}
+
+// copyTarEntries copies the entries read from tr into tw, entries are handed to
+// onEntry instead when it returns true
+func copyTarEntries(tw *tar.Writer, tr *tar.Reader, onEntry func(hdr *tar.Header, r io.Reader) (bool, error)) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if onEntry != nil {
+			handled, err := onEntry(hdr, tr)
+			if err != nil {
+				return err
+			}
+			if handled {
+				continue
+			}
+		}
+
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(tw, tr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadExportManifest returns the manifest of an export
+func ReadExportManifest(r io.Reader) (*ExportManifest, error) {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: manifest not found", ErrInvalidExport)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Name == ExportManifestName {
+			return readExportManifest(tr)
+		}
+	}
+}
+
+func readExportManifest(r io.Reader) (*ExportManifest, error) {
+	var manifest ExportManifest
+
+	err := json.NewDecoder(r).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+
+	if manifest.Version != exportVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidExport, manifest.Version)
+	}
+
+	return &manifest, nil
+}
+
+// Import creates the database described by op from an export read from r.
+// The restored data is checked against the manifest of the export, which is returned
+// so its signature can be verified.
+func Import(op *Options, r io.Reader, log logger.Logger) (DB, *ExportManifest, error) {
+	if op == nil || r == nil {
+		return nil, nil, store.ErrIllegalArguments
+	}
+
+	log.Infof("Importing database '%s'...", op.dbName)
+
+	dbDir := filepath.Join(op.GetDBRootPath(), op.GetDBName())
+
+	if _, dbErr := os.Stat(dbDir); dbErr == nil {
+		return nil, nil, fmt.Errorf("Database directories already exist: %s", dbDir)
+	}
+
+	manifest, err := importBackup(dbDir, r, op.storeOptions().WithLog(log))
+	if err != nil {
+		os.RemoveAll(dbDir)
+		return nil, nil, logErr(log, "Unable to import database: %s", err)
+	}
+
+	db, err := OpenDB(op, log)
+	if err != nil {
+		os.RemoveAll(dbDir)
+		return nil, nil, err
+	}
+
+	log.Infof("Database '%s' successfully imported at tx %d", op.dbName, manifest.TxID)
+
+	return db, manifest, nil
+}
+
+// importBackup restores the backup included in an export, the manifest of the export is taken apart
+func importBackup(path string, r io.Reader, opts *store.Options) (*ExportManifest, error) {
+	pr, pw := io.Pipe()
+
+	type restoreResult struct {
+		manifest *store.BackupManifest
+		err      error
+	}
+
+	restoreDone := make(chan restoreResult, 1)
+
+	go func() {
+		manifest, err := store.RestoreBackup(path, pr, opts)
+		pr.CloseWithError(err)
+		restoreDone <- restoreResult{manifest: manifest, err: err}
+	}()
+
+	var manifest *ExportManifest
+
+	tw := tar.NewWriter(pw)
+
+	err := copyTarEntries(tw, tar.NewReader(r), func(hdr *tar.Header, r io.Reader) (bool, error) {
+		if manifest != nil {
+			return false, fmt.Errorf("%w: unexpected entry '%s' after the manifest", ErrInvalidExport, hdr.Name)
+		}
+
+		if hdr.Name != ExportManifestName {
+			return false, nil
+		}
+
+		var err error
+		manifest, err = readExportManifest(r)
+		return true, err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	pw.CloseWithError(err)
+
+	res := <-restoreDone
+	if err != nil {
+		return nil, err
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: manifest not found", ErrInvalidExport)
+	}
+
+	if manifest.TxID != res.manifest.TxID || string(manifest.Alh) != string(res.manifest.Alh[:]) {
+		return nil, fmt.Errorf("%w: restored database does not match the manifest", ErrInvalidExport)
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+var errAlreadyCommitted = errors.New("already committed")
+
+// EncodeIdempotencyKey returns the key under which the idempotency key of a request is recorded
+func EncodeIdempotencyKey(idempotencyKey []byte) []byte {
+	return WrapWithPrefix(idempotencyKey, IdempotencyKeyPrefix)
+}
+
+func (d *db) idempotentSet(req *schema.SetRequest) (*schema.TxHeader, error) {
+	keys := make(map[[sha256.Size]byte]struct{}, len(req.KVs))
+
+	entries := make([]*store.EntrySpec, len(req.KVs))
+
+	for i, kv := range req.KVs {
+		if len(kv.Key) == 0 {
+			return nil, ErrIllegalArguments
+		}
+
+		kid := sha256.Sum256(kv.Key)
+		_, ok := keys[kid]
+		if ok {
+			return nil, schema.ErrDuplicatedKeysNotSupported
+		}
+		keys[kid] = struct{}{}
+
+		entries[i] = EncodeEntrySpec(kv.Key, schema.KVMetadataFromProto(kv.Metadata), kv.Value)
+
+		err := d.checkExpirationRetentionLock(entries[i].Metadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	callback := func(txID uint64, index store.KeyIndex) ([]*store.EntrySpec, error) {
+		return entries, nil
+	}
+
+	return d.commitIdempotent(req.IdempotencyKey, schema.TxMetadataFromProto(req.TxMetadata), callback, !req.NoWait)
+}
+
+// commitIdempotent commits the entries built by the callback together with an entry recording
+// the idempotency key. If a transaction with the same idempotency key was already committed,
+// nothing is written and the header of the original transaction is returned instead.
+func (d *db) commitIdempotent(
+	idempotencyKey []byte,
+	md *store.TxMetadata,
+	callback func(txID uint64, index store.KeyIndex) ([]*store.EntrySpec, error),
+	waitForIndexing bool) (*schema.TxHeader, error) {
+
+	// idempotent commits are serialized so that the lookup of the idempotency key
+	// is always done against an index including all the previous ones
+	d.idempotencyMutex.Lock()
+	defer d.idempotencyMutex.Unlock()
+
+	lastTxID, _ := d.st.Alh()
+
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	key := EncodeIdempotencyKey(idempotencyKey)
+
+	var originalTxID uint64
+
+	hdr, err := d.st.CommitWithMetadata(md, func(txID uint64, index store.KeyIndex) ([]*store.EntrySpec, error) {
+		valRef, err := index.Get(key)
+		if err == nil {
+			originalTxID = valRef.Tx()
+			return nil, errAlreadyCommitted
+		}
+		if err != store.ErrKeyNotFound {
+			return nil, err
+		}
+
+		entries, err := callback(txID, index)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(entries, &store.EntrySpec{Key: key}), nil
+	}, waitForIndexing)
+	if err == errAlreadyCommitted {
+		tx := d.st.NewTxHolder()
+
+		err = d.st.ReadTx(originalTxID, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		return schema.TxHeaderToProto(tx.Header()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.TxHeaderToProto(hdr), nil
+}
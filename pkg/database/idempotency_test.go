@@ -0,0 +1,101 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentSet(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	req := &schema.SetRequest{
+		KVs:            []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}},
+		IdempotencyKey: []byte("req1"),
+	}
+
+	hdr1, err := db.Set(req)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), hdr1.Nentries)
+
+	hdr2, err := db.Set(req)
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, hdr2.Id)
+	require.Equal(t, hdr1.EH, hdr2.EH)
+
+	req.IdempotencyKey = []byte("req2")
+
+	hdr3, err := db.Set(req)
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id+1, hdr3.Id)
+
+	history, err := db.History(&schema.HistoryRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Len(t, history.Entries, 2)
+
+	_, err = db.Set(&schema.SetRequest{
+		KVs:            []*schema.KeyValue{{Key: []byte("key1")}, {Key: []byte("key1")}},
+		IdempotencyKey: []byte("req3"),
+	})
+	require.ErrorIs(t, err, schema.ErrDuplicatedKeysNotSupported)
+
+	_, err = db.Set(&schema.SetRequest{
+		KVs:            []*schema.KeyValue{{}},
+		IdempotencyKey: []byte("req3"),
+	})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestIdempotentExecAll(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	req := &schema.ExecAllRequest{
+		Operations: []*schema.Op{
+			{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{Key: []byte("key1"), Value: []byte("value1")}}},
+			{Operation: &schema.Op_ZAdd{ZAdd: &schema.ZAddRequest{Set: []byte("set1"), Score: 1, Key: []byte("key1"), BoundRef: true}}},
+		},
+		IdempotencyKey: []byte("req1"),
+	}
+
+	hdr1, err := db.ExecAll(req)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), hdr1.Nentries)
+
+	hdr2, err := db.ExecAll(req)
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, hdr2.Id)
+
+	// the same idempotency key is shared among Set and ExecAll requests
+	hdr3, err := db.Set(&schema.SetRequest{
+		KVs:            []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}},
+		IdempotencyKey: []byte("req1"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, hdr3.Id)
+
+	_, err = db.Get(&schema.KeyRequest{Key: []byte("key2")})
+	require.Error(t, err)
+
+	zentries, err := db.ZScan(&schema.ZScanRequest{Set: []byte("set1"), SinceTx: hdr1.Id})
+	require.NoError(t, err)
+	require.Len(t, zentries.Entries, 1)
+}
@@ -0,0 +1,362 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+var ErrLegalHold = errors.New("data is under legal hold")
+var ErrLegalHoldAlreadyPlaced = errors.New("legal hold already placed")
+var ErrLegalHoldNotFound = errors.New("legal hold not found")
+
+// LegalHoldAnnotation is the annotation of the transactions placing or lifting a legal hold
+const LegalHoldAnnotation = "legalHold"
+
+const (
+	legalHoldOnKey byte = iota
+	legalHoldOnTable
+)
+
+type legalHoldRecord struct {
+	Active bool   `json:"active"`
+	By     string `json:"by,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// EncodeLegalHoldKey returns the key under which the legal hold on a key or on a table is recorded
+func EncodeLegalHoldKey(key []byte, table string) []byte {
+	if len(key) > 0 {
+		return WrapWithPrefix(append([]byte{legalHoldOnKey}, key...), LegalHoldPrefix)
+	}
+	return WrapWithPrefix(append([]byte{legalHoldOnTable}, table...), LegalHoldPrefix)
+}
+
+func decodeLegalHoldKey(k []byte) (key []byte, table string, err error) {
+	if len(k) < 3 || k[0] != LegalHoldPrefix {
+		return nil, "", fmt.Errorf("%w: invalid legal hold key", store.ErrCorruptedData)
+	}
+
+	switch k[1] {
+	case legalHoldOnKey:
+		return k[2:], "", nil
+	case legalHoldOnTable:
+		return nil, string(k[2:]), nil
+	}
+
+	return nil, "", fmt.Errorf("%w: invalid legal hold key", store.ErrCorruptedData)
+}
+
+// PlaceLegalHold places a legal hold on either a key or a table. The values of a held key can not be erased,
+// neither on request nor by retention rules, and the rows of a held table can not be deleted until the hold is lifted.
+// Holds are recorded by transactions annotated with LegalHoldAnnotation, so placing and lifting them is never forgotten.
+func (d *db) PlaceLegalHold(req *schema.LegalHoldRequest, placedBy string) (*schema.LegalHold, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if req == nil || (len(req.Key) == 0) == (len(req.Table) == 0) {
+		return nil, ErrIllegalArguments
+	}
+
+	if len(req.Table) > 0 {
+		catalog, err := d.sqlEngine.Catalog(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = catalog.GetTableByName(dbInstanceName, req.Table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key := EncodeLegalHoldKey(req.Key, req.Table)
+
+	record, err := d.legalHold(key)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil && record.Active {
+		return nil, ErrLegalHoldAlreadyPlaced
+	}
+
+	hdr, err := d.setLegalHold(key, &legalHoldRecord{Active: true, By: placedBy, Reason: req.Reason}, "placed")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Logger.Infof("legal hold placed on %s of database '%s' by '%s'", legalHoldTarget(req.Key, req.Table), d.name, placedBy)
+
+	return &schema.LegalHold{
+		Key:      req.Key,
+		Table:    req.Table,
+		Reason:   req.Reason,
+		PlacedBy: placedBy,
+		Tx:       hdr.ID,
+		PlacedAt: hdr.Ts,
+	}, nil
+}
+
+// LiftLegalHold lifts the legal hold placed on either a key or a table, the hold is kept in the history of the database
+func (d *db) LiftLegalHold(req *schema.LegalHoldRequest, liftedBy string) error {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.isReplica() {
+		return ErrIsReplica
+	}
+
+	if req == nil || (len(req.Key) == 0) == (len(req.Table) == 0) {
+		return ErrIllegalArguments
+	}
+
+	key := EncodeLegalHoldKey(req.Key, req.Table)
+
+	record, err := d.legalHold(key)
+	if err != nil {
+		return err
+	}
+	if record == nil || !record.Active {
+		return ErrLegalHoldNotFound
+	}
+
+	_, err = d.setLegalHold(key, &legalHoldRecord{Active: false, By: liftedBy, Reason: req.Reason}, "lifted")
+	if err != nil {
+		return err
+	}
+
+	d.Logger.Infof("legal hold lifted from %s of database '%s' by '%s'", legalHoldTarget(req.Key, req.Table), d.name, liftedBy)
+
+	return nil
+}
+
+// LegalHolds returns the active legal holds, the holds on keys first
+func (d *db) LegalHolds() (*schema.LegalHoldList, error) {
+	lastTxID, _ := d.st.Alh()
+
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.st.SnapshotSince(lastTxID)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	r, err := snap.NewKeyReader(&store.KeyReaderSpec{Prefix: []byte{LegalHoldPrefix}})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	tx := d.st.NewTxHolder()
+
+	holds := &schema.LegalHoldList{}
+
+	for {
+		k, valRef, err := r.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := readLegalHoldRecord(valRef)
+		if err != nil {
+			return nil, err
+		}
+		if !record.Active {
+			continue
+		}
+
+		key, table, err := decodeLegalHoldKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		err = d.st.ReadTx(valRef.Tx(), tx)
+		if err != nil {
+			return nil, err
+		}
+
+		holds.Holds = append(holds.Holds, &schema.LegalHold{
+			Key:      key,
+			Table:    table,
+			Reason:   record.Reason,
+			PlacedBy: record.By,
+			Tx:       valRef.Tx(),
+			PlacedAt: tx.Header().Ts,
+		})
+	}
+
+	return holds, nil
+}
+
+// checkLegalHold returns ErrLegalHold when a legal hold is placed on the given key or table
+func (d *db) checkLegalHold(key []byte, table string) error {
+	record, err := d.legalHold(EncodeLegalHoldKey(key, table))
+	if err != nil {
+		return err
+	}
+
+	if record != nil && record.Active {
+		return fmt.Errorf("%w: %s is held since it was placed by '%s'", ErrLegalHold, legalHoldTarget(key, table), record.By)
+	}
+
+	return nil
+}
+
+// checkSQLLegalHolds returns ErrLegalHold when any of the statements deletes rows of a held table
+func (d *db) checkSQLLegalHolds(stmts []sql.SQLStmt) error {
+	for _, stmt := range stmts {
+		deleteStmt, ok := stmt.(*sql.DeleteFromStmt)
+		if !ok {
+			continue
+		}
+
+		err := d.checkLegalHold(nil, deleteStmt.Table())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// legalHoldPrefixes returns the prefixes of the keys under a legal hold, i.e. the held keys
+// and the rows of the held tables, so that garbage collection keeps their values
+func (d *db) legalHoldPrefixes() ([][]byte, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.st == nil || d.sqlEngine == nil {
+		// the database could not be opened
+		return nil, store.ErrAlreadyClosed
+	}
+
+	holds, err := d.LegalHolds()
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog *sql.Catalog
+	var prefixes [][]byte
+
+	for _, hold := range holds.Holds {
+		if len(hold.Key) > 0 {
+			prefixes = append(prefixes, EncodeKey(hold.Key))
+			continue
+		}
+
+		if catalog == nil {
+			catalog, err = d.sqlEngine.Catalog(nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		table, err := catalog.GetTableByName(dbInstanceName, hold.Table)
+		if err == sql.ErrTableDoesNotExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, sql.MapKey([]byte{SQLPrefix}, sql.PIndexPrefix, sql.EncodeID(table.Database().ID()), sql.EncodeID(table.ID())))
+	}
+
+	return prefixes, nil
+}
+
+// legalHold returns the last record of the legal hold stored under key, nil when no hold was ever placed
+func (d *db) legalHold(key []byte) (*legalHoldRecord, error) {
+	lastTxID, _ := d.st.Alh()
+
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	valRef, err := d.st.Get(key)
+	if err == store.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return readLegalHoldRecord(valRef)
+}
+
+func (d *db) setLegalHold(key []byte, record *legalHoldRecord, action string) (*store.TxHeader, error) {
+	val, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	otx, err := d.st.NewWriteOnlyTx()
+	if err != nil {
+		return nil, err
+	}
+	defer otx.Cancel()
+
+	otx.WithMetadata(store.NewTxMetadata().WithAnnotation(LegalHoldAnnotation, action))
+
+	err = otx.Set(key, nil, val)
+	if err != nil {
+		return nil, err
+	}
+
+	return otx.Commit()
+}
+
+func readLegalHoldRecord(valRef store.ValueRef) (*legalHoldRecord, error) {
+	val, err := valRef.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	var record legalHoldRecord
+
+	err = json.Unmarshal(val, &record)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid legal hold record", store.ErrCorruptedData)
+	}
+
+	return &record, nil
+}
+
+func legalHoldTarget(key []byte, table string) string {
+	if len(key) > 0 {
+		return fmt.Sprintf("key '%s'", key)
+	}
+	return fmt.Sprintf("table '%s'", table)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegalHoldOnKey(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	hdr, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("pii/alice"), Value: []byte("alice data")}}})
+	require.NoError(t, err)
+
+	_, err = db.PlaceLegalHold(nil, "admin")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.PlaceLegalHold(&schema.LegalHoldRequest{Key: []byte("pii/alice"), Table: "table1"}, "admin")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = db.LiftLegalHold(&schema.LegalHoldRequest{Key: []byte("pii/alice")}, "admin")
+	require.ErrorIs(t, err, ErrLegalHoldNotFound)
+
+	hold, err := db.PlaceLegalHold(&schema.LegalHoldRequest{Key: []byte("pii/alice"), Reason: "litigation"}, "admin")
+	require.NoError(t, err)
+	require.Equal(t, "admin", hold.PlacedBy)
+	require.Greater(t, hold.Tx, hdr.Id)
+
+	_, err = db.PlaceLegalHold(&schema.LegalHoldRequest{Key: []byte("pii/alice")}, "admin")
+	require.ErrorIs(t, err, ErrLegalHoldAlreadyPlaced)
+
+	holds, err := db.LegalHolds()
+	require.NoError(t, err)
+	require.Len(t, holds.Holds, 1)
+	require.Equal(t, []byte("pii/alice"), holds.Holds[0].Key)
+	require.Equal(t, "litigation", holds.Holds[0].Reason)
+	require.Equal(t, hold.Tx, holds.Holds[0].Tx)
+	require.Equal(t, hold.PlacedAt, holds.Holds[0].PlacedAt)
+
+	// held keys are kept out of the key space of the database
+	entries, err := db.Scan(&schema.ScanRequest{})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 1)
+
+	_, err = db.Erase(&schema.EraseRequest{Key: []byte("pii/alice")}, "alice")
+	require.ErrorIs(t, err, ErrLegalHold)
+
+	history, err := db.ErasureHistory(&schema.ErasureHistoryRequest{Key: []byte("pii/alice")})
+	require.NoError(t, err)
+	require.Empty(t, history.Erasures)
+
+	// retention rules keep held values and resume from them once the hold is lifted
+	res, err := db.EnforceRetention([]byte("pii/"), 0, time.Now(), "retention", "expired")
+	require.NoError(t, err)
+	require.Zero(t, res.Erased)
+	require.Equal(t, 1, res.Held)
+	require.Less(t, res.ToTx, hdr.Id)
+
+	err = db.LiftLegalHold(&schema.LegalHoldRequest{Key: []byte("pii/alice")}, "admin")
+	require.NoError(t, err)
+
+	holds, err = db.LegalHolds()
+	require.NoError(t, err)
+	require.Empty(t, holds.Holds)
+
+	res, err = db.EnforceRetention([]byte("pii/"), res.ToTx, time.Now(), "retention", "expired")
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Erased)
+	require.Zero(t, res.Held)
+
+	entry, err := db.Get(&schema.KeyRequest{Key: []byte("pii/alice")})
+	require.NoError(t, err)
+	require.True(t, entry.Erased)
+
+	// placing and lifting holds are recorded as annotated transactions
+	txs, err := db.TxScan(&schema.TxScanRequest{
+		InitialTx:       1,
+		WithAnnotations: map[string]string{LegalHoldAnnotation: "lifted"},
+	})
+	require.NoError(t, err)
+	require.Len(t, txs.Txs, 1)
+}
+
+func TestLegalHoldOnTable(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.PlaceLegalHold(&schema.LegalHoldRequest{Table: "table1"}, "admin")
+	require.ErrorIs(t, err, sql.ErrTableDoesNotExist)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE table1(id INTEGER, title VARCHAR, PRIMARY KEY id);
+		INSERT INTO table1(id, title) VALUES (1, 'title1'), (2, 'title2');
+	`}, nil)
+	require.NoError(t, err)
+
+	_, err = db.PlaceLegalHold(&schema.LegalHoldRequest{Table: "table1", Reason: "audit"}, "admin")
+	require.NoError(t, err)
+
+	holds, err := db.LegalHolds()
+	require.NoError(t, err)
+	require.Len(t, holds.Holds, 1)
+	require.Equal(t, "table1", holds.Holds[0].Table)
+	require.Nil(t, holds.Holds[0].Key)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "DELETE FROM table1 WHERE id = 1"}, nil)
+	require.ErrorIs(t, err, ErrLegalHold)
+
+	// rows can still be added to a held table
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "INSERT INTO table1(id, title) VALUES (3, 'title3')"}, nil)
+	require.NoError(t, err)
+
+	err = db.LiftLegalHold(&schema.LegalHoldRequest{Table: "table1"}, "admin")
+	require.NoError(t, err)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "DELETE FROM table1 WHERE id = 1"}, nil)
+	require.NoError(t, err)
+}
+
+func TestLegalHoldsKeptByGC(t *testing.T) {
+	now := time.Now()
+
+	options := DefaultOption().WithDBRootPath("data_legal_hold_gc").WithDBName("db").WithCorruptionChecker(false)
+	options.storeOpts.WithGCSafetyWindow(time.Hour).WithTimeFunc(func() time.Time { return now })
+
+	d, closer := makeDbWith(options)
+	defer closer()
+
+	set := func(key, value string) uint64 {
+		hdr, err := d.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(key), Value: []byte(value)}}})
+		require.NoError(t, err)
+		return hdr.Id
+	}
+
+	exec := func(stmt string) uint64 {
+		_, _, err := d.SQLExec(&schema.SQLExecRequest{Sql: stmt}, nil)
+		require.NoError(t, err)
+
+		state, err := d.CurrentState()
+		require.NoError(t, err)
+		return state.TxId
+	}
+
+	aliceTx := set("pii/alice", "alice data")
+	bobTx := set("pii/bob", "bob data")
+	set("pii/alice", "alice data 2")
+	set("pii/bob", "bob data 2")
+
+	exec("CREATE TABLE table1(id INTEGER, title VARCHAR, PRIMARY KEY id)")
+	rowTx := exec("INSERT INTO table1(id, title) VALUES (1, 'title1')")
+	exec("UPSERT INTO table1(id, title) VALUES (1, 'title2')")
+
+	_, err := d.PlaceLegalHold(&schema.LegalHoldRequest{Key: []byte("pii/alice")}, "admin")
+	require.NoError(t, err)
+
+	_, err = d.PlaceLegalHold(&schema.LegalHoldRequest{Table: "table1"}, "admin")
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+
+	_, err = d.CollectGarbage()
+	if err == store.ErrValueGCUnsupported {
+		t.Skip("punching holes is not supported by the underlying file system")
+	}
+	require.NoError(t, err)
+
+	st := d.(*db).st
+	tx := st.NewTxHolder()
+
+	readValues := func(txID uint64, prefix []byte) error {
+		err := st.ReadTx(txID, tx)
+		require.NoError(t, err)
+
+		for _, e := range tx.Entries() {
+			if !bytes.HasPrefix(e.Key(), prefix) {
+				continue
+			}
+
+			_, err = st.ReadValue(e)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	require.NoError(t, readValues(aliceTx, EncodeKey([]byte("pii/alice"))))
+	require.NoError(t, readValues(rowTx, sql.MapKey([]byte{SQLPrefix}, sql.PIndexPrefix)))
+	require.ErrorIs(t, readValues(bobTx, EncodeKey([]byte("pii/bob"))), store.ErrValueReclaimed)
+}
@@ -27,6 +27,10 @@ const (
 	SetKeyPrefix byte = iota
 	SortedSetKeyPrefix
 	SQLPrefix
+	IdempotencyKeyPrefix
+	NotarizationPrefix
+	ErasurePrefix
+	LegalHoldPrefix
 )
 
 const (
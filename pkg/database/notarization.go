@@ -0,0 +1,213 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// MaxNotarizationDigestLen is the maximum length of the digests accepted for notarization,
+// enough to hold the output of any of the usual hash functions (e.g. sha512)
+const MaxNotarizationDigestLen = 64
+
+type notarizationRecord struct {
+	HashAlgorithm string            `json:"hashAlgorithm,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// EncodeNotarizationKey returns the key under which a notarized digest is recorded
+func EncodeNotarizationKey(digest []byte) []byte {
+	return WrapWithPrefix(digest, NotarizationPrefix)
+}
+
+// EncodeNotarization returns the entry recording a notarized digest, clients use it
+// to verify the inclusion proof of a notarization
+func EncodeNotarization(digest []byte, hashAlgorithm string, metadata map[string]string) (*store.EntrySpec, error) {
+	// map keys are sorted by the json encoder, thus the encoding is deterministic
+	value, err := json.Marshal(&notarizationRecord{
+		HashAlgorithm: hashAlgorithm,
+		Metadata:      metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &store.EntrySpec{
+		Key:   EncodeNotarizationKey(digest),
+		Value: value,
+	}, nil
+}
+
+// Notarize records a digest together with its metadata and returns the proofs of its inclusion.
+// A digest is notarized only once, notarizing it again returns the original notarization.
+func (d *db) Notarize(req *schema.NotarizeRequest) (*schema.Notarization, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if req == nil || len(req.Digest) == 0 || len(req.Digest) > MaxNotarizationDigestLen {
+		return nil, ErrIllegalArguments
+	}
+
+	lastTxID, _ := d.st.Alh()
+	if lastTxID < req.ProveSinceTx {
+		return nil, ErrIllegalState
+	}
+
+	entry, err := EncodeNotarization(req.Digest, req.HashAlgorithm, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.commitNotarization(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.notarization(req.Digest, req.ProveSinceTx)
+}
+
+func (d *db) commitNotarization(entry *store.EntrySpec) error {
+	// as with idempotent commits, notarizations are serialized so that the lookup
+	// of the digest is always done against an index including all the previous ones
+	d.idempotencyMutex.Lock()
+	defer d.idempotencyMutex.Unlock()
+
+	lastTxID, _ := d.st.Alh()
+
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.st.CommitWithMetadata(nil, func(txID uint64, index store.KeyIndex) ([]*store.EntrySpec, error) {
+		_, err := index.Get(entry.Key)
+		if err == nil {
+			return nil, errAlreadyCommitted
+		}
+		if err != store.ErrKeyNotFound {
+			return nil, err
+		}
+
+		return []*store.EntrySpec{entry}, nil
+	}, true)
+	if err == errAlreadyCommitted {
+		return nil
+	}
+
+	return err
+}
+
+// VerifyNotarization returns the notarization of a digest together with the proofs of its inclusion
+func (d *db) VerifyNotarization(req *schema.VerifyNotarizationRequest) (*schema.Notarization, error) {
+	if req == nil || len(req.Digest) == 0 || len(req.Digest) > MaxNotarizationDigestLen {
+		return nil, ErrIllegalArguments
+	}
+
+	lastTxID, _ := d.st.Alh()
+	if lastTxID < req.ProveSinceTx {
+		return nil, ErrIllegalState
+	}
+
+	err := d.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.notarization(req.Digest, req.ProveSinceTx)
+}
+
+func (d *db) notarization(digest []byte, proveSinceTx uint64) (*schema.Notarization, error) {
+	key := EncodeNotarizationKey(digest)
+
+	valRef, err := d.st.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := valRef.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	var record notarizationRecord
+
+	err = json.Unmarshal(val, &record)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid notarization record", store.ErrCorruptedData)
+	}
+
+	tx := d.st.NewTxHolder()
+
+	err = d.st.ReadTx(valRef.Tx(), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	inclusionProof, err := tx.Proof(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var rootTx *store.Tx
+
+	if proveSinceTx == 0 {
+		rootTx = tx
+	} else {
+		rootTx = d.st.NewTxHolder()
+
+		err = d.st.ReadTx(proveSinceTx, rootTx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sourceTx, targetTx *store.Tx
+
+	if proveSinceTx <= valRef.Tx() {
+		sourceTx = rootTx
+		targetTx = tx
+	} else {
+		sourceTx = tx
+		targetTx = rootTx
+	}
+
+	dualProof, err := d.st.DualProof(sourceTx, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.Notarization{
+		Digest:        digest,
+		HashAlgorithm: record.HashAlgorithm,
+		Metadata:      record.Metadata,
+		Tx:            valRef.Tx(),
+		NotarizedAt:   tx.Header().Ts,
+		VerifiableTx: &schema.VerifiableTx{
+			Tx:        schema.TxToProto(tx),
+			DualProof: schema.DualProofToProto(dualProof),
+		},
+		InclusionProof: schema.InclusionProofToProto(inclusionProof),
+	}, nil
+}
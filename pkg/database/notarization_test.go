@@ -0,0 +1,109 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotarization(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	digest := sha256.Sum256([]byte("contract.pdf"))
+
+	_, err := db.Notarize(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Notarize(&schema.NotarizeRequest{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Notarize(&schema.NotarizeRequest{Digest: make([]byte, MaxNotarizationDigestLen+1)})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Notarize(&schema.NotarizeRequest{Digest: digest[:], ProveSinceTx: 100})
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	_, err = db.VerifyNotarization(&schema.VerifyNotarizationRequest{Digest: digest[:]})
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	notarization, err := db.Notarize(&schema.NotarizeRequest{
+		Digest:        digest[:],
+		HashAlgorithm: "sha256",
+		Metadata:      map[string]string{"owner": "acme", "document": "contract.pdf"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, digest[:], notarization.Digest)
+	require.Equal(t, "sha256", notarization.HashAlgorithm)
+	require.Equal(t, "acme", notarization.Metadata["owner"])
+	require.Equal(t, notarization.VerifiableTx.Tx.Header.Ts, notarization.NotarizedAt)
+
+	// notarized digests are not visible as regular keys
+	_, err = db.Get(&schema.KeyRequest{Key: digest[:]})
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	// notarizing the same digest again returns the original notarization
+	again, err := db.Notarize(&schema.NotarizeRequest{Digest: digest[:], HashAlgorithm: "sha512"})
+	require.NoError(t, err)
+	require.Equal(t, notarization.Tx, again.Tx)
+	require.Equal(t, "sha256", again.HashAlgorithm)
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	_, err = db.VerifyNotarization(&schema.VerifyNotarizationRequest{Digest: digest[:], ProveSinceTx: state.TxId + 1})
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	verified, err := db.VerifyNotarization(&schema.VerifyNotarizationRequest{Digest: digest[:], ProveSinceTx: state.TxId})
+	require.NoError(t, err)
+	require.Equal(t, notarization.Tx, verified.Tx)
+	require.Equal(t, notarization.NotarizedAt, verified.NotarizedAt)
+
+	e, err := EncodeNotarization(verified.Digest, verified.HashAlgorithm, verified.Metadata)
+	require.NoError(t, err)
+
+	dualProof, err := schema.DualProofFromProto(verified.VerifiableTx.DualProof)
+	require.NoError(t, err)
+
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(verified.VerifiableTx.Tx.Header.Version), dualProof.SourceTxHeader.HashAlgorithm)
+	require.NoError(t, err)
+
+	verifies := store.VerifyInclusion(
+		schema.InclusionProofFromProto(verified.InclusionProof),
+		entrySpecDigest(e),
+		dualProof.SourceTxHeader.Eh,
+		dualProof.SourceTxHeader.HashAlgorithm,
+	)
+	require.True(t, verifies)
+
+	verifies = store.VerifyDualProof(
+		dualProof,
+		verified.Tx,
+		state.TxId,
+		dualProof.SourceTxHeader.Alh(),
+		schema.DigestFromProto(state.TxHash),
+	)
+	require.True(t, verifies)
+}
@@ -77,6 +77,8 @@ func (d *db) SetReference(req *schema.ReferenceRequest) (*schema.TxHeader, error
 	}
 	defer tx.Cancel()
 
+	tx.WithMetadata(schema.TxMetadataFromProto(req.TxMetadata))
+
 	e := EncodeReference(req.Key, nil, req.ReferencedKey, req.AtTx)
 
 	err = tx.Set(e.Key, e.Metadata, e.Value)
@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/stretchr/testify/require"
@@ -90,7 +91,7 @@ func TestStoreReference(t *testing.T) {
 
 	entrySpec := EncodeReference([]byte(`myTag`), nil, []byte(`firstKey`), 0)
 
-	entrySpecDigest, err := store.EntrySpecDigestFor(int(txhdr.Version))
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(txhdr.Version), hashing.Algorithm(txhdr.HashAlgorithm))
 	require.NoError(t, err)
 	require.NotNil(t, entrySpecDigest)
 
@@ -98,6 +99,7 @@ func TestStoreReference(t *testing.T) {
 		inclusionProof,
 		entrySpecDigest(entrySpec),
 		eh,
+		hashing.Algorithm(txhdr.HashAlgorithm),
 	)
 	require.True(t, verifies)
 }
@@ -368,13 +370,17 @@ func TestStoreVerifiableReference(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, WrapWithPrefix([]byte(`myTag`), SetKeyPrefix), vtx.Tx.Entries[0].Key)
 
-	dualProof := schema.DualProofFromProto(vtx.DualProof)
+	dualProof, err := schema.DualProofFromProto(vtx.DualProof)
+	require.NoError(t, err)
+
+	sourceHdr, err := schema.TxHeaderFromProto(txhdr)
+	require.NoError(t, err)
 
 	verifies := store.VerifyDualProof(
 		dualProof,
 		txhdr.Id,
 		vtx.Tx.Header.Id,
-		schema.TxHeaderFromProto(txhdr).Alh(),
+		sourceHdr.Alh(),
 		dualProof.TargetTxHeader.Alh(),
 	)
 	require.True(t, verifies)
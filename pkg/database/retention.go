@@ -0,0 +1,105 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// RetentionEnforcement is the outcome of enforcing a retention rule over a range of transactions,
+// ToTx is lower than FromTx when no transaction was due. Held is the number of values kept because of a legal hold
+type RetentionEnforcement struct {
+	FromTx uint64
+	ToTx   uint64
+	Erased int
+	Held   int
+}
+
+// EnforceRetention erases the values of the keys starting with prefix set by the transactions committed
+// after sinceTx and at or before until. Every erasure is recorded as requested by requestedBy with the given reason.
+// Values no longer stored, i.e. already erased, truncated or reclaimed, are skipped.
+// Values of keys under a legal hold are kept, and ToTx stops before the first transaction setting one
+// so they are checked again once the hold is lifted.
+// The range of transactions checked so far is returned even when the enforcement fails.
+func (d *db) EnforceRetention(prefix []byte, sinceTx uint64, until time.Time, requestedBy, reason string) (*RetentionEnforcement, error) {
+	if d.IsReplica() {
+		return nil, ErrIsReplica
+	}
+
+	res := &RetentionEnforcement{
+		FromTx: sinceTx + 1,
+		ToTx:   sinceTx,
+	}
+
+	uptoTx, err := d.st.LastTxUntil(until)
+	if err == store.ErrTxNotFound {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := d.st.NewTxHolder()
+
+	held := false
+
+	for txID := sinceTx + 1; txID <= uptoTx; txID++ {
+		err = d.st.ReadTx(txID, tx)
+		if err != nil {
+			return res, err
+		}
+
+		for _, e := range tx.Entries() {
+			key := e.Key()
+
+			if key[0] != SetKeyPrefix || !bytes.HasPrefix(key[1:], prefix) || e.VLen() == 0 {
+				continue
+			}
+
+			_, err = d.st.ReadValue(e)
+			if err == store.ErrValueErased || err == store.ErrValueTruncated || err == store.ErrValueReclaimed {
+				continue
+			}
+			if err != nil && err != store.ErrExpiredEntry {
+				return res, err
+			}
+
+			_, err = d.Erase(&schema.EraseRequest{Key: TrimPrefix(key), AtTx: txID, Reason: reason}, requestedBy)
+			if errors.Is(err, ErrLegalHold) {
+				held = true
+				res.Held++
+				continue
+			}
+			if err != nil {
+				return res, err
+			}
+
+			res.Erased++
+		}
+
+		if !held {
+			res.ToTx = txID
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionLock(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE trades(id INTEGER, amount INTEGER, PRIMARY KEY id);
+		INSERT INTO trades(id, amount) VALUES (1, 100), (2, 200);
+	`}, nil)
+	require.NoError(t, err)
+
+	retainUntil := time.Now().Add(time.Hour)
+
+	err = db.SetRetainUntil(retainUntil)
+	require.NoError(t, err)
+
+	_, err = db.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key1")}})
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "DELETE FROM trades WHERE id = 1"}, nil)
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	expiringBeforeRetention := &schema.KVMetadata{
+		Expiration: &schema.Expiration{ExpiresAt: retainUntil.Add(-time.Minute).Unix()},
+	}
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key1"), Value: []byte("value1"), Metadata: expiringBeforeRetention},
+	}})
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	_, err = db.Set(&schema.SetRequest{
+		KVs: []*schema.KeyValue{
+			{Key: []byte("key1"), Value: []byte("value1"), Metadata: expiringBeforeRetention},
+		},
+		IdempotencyKey: []byte("req1"),
+	})
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	_, err = db.ExecAll(&schema.ExecAllRequest{Operations: []*schema.Op{
+		{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{Key: []byte("key1"), Value: []byte("value1"), Metadata: expiringBeforeRetention}}},
+	}})
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	// values can still be updated, also to expire once the retention date is reached
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key1"), Value: []byte("value2"), Metadata: &schema.KVMetadata{
+			Expiration: &schema.Expiration{ExpiresAt: retainUntil.Add(time.Hour).Unix()},
+		}},
+	}})
+	require.NoError(t, err)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "UPDATE trades SET amount = 150 WHERE id = 1"}, nil)
+	require.NoError(t, err)
+
+	entry, err := db.Get(&schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), entry.Value)
+
+	res, err := db.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT COUNT(*) FROM trades"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.Rows[0].Values[0].GetN())
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnforceRetention(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	hdr1, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("pii/alice"), Value: []byte("alice data")},
+		{Key: []byte("invoice/1"), Value: []byte("invoice data")},
+	}})
+	require.NoError(t, err)
+
+	_, err = db.Erase(&schema.EraseRequest{Key: []byte("pii/alice")}, "admin")
+	require.NoError(t, err)
+
+	hdr2, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("pii/bob"), Value: []byte("bob data")}}})
+	require.NoError(t, err)
+
+	// no transaction was committed before the first one
+	res, err := db.EnforceRetention([]byte("pii/"), 0, time.Unix(hdr1.Ts-1, 0), "retention", "expired")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.FromTx)
+	require.Zero(t, res.ToTx)
+	require.Zero(t, res.Erased)
+
+	res, err = db.EnforceRetention([]byte("pii/"), 0, time.Unix(hdr2.Ts, 0), "retention", "expired")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, res.ToTx, hdr2.Id)
+	// the value of alice was already erased
+	require.Equal(t, 1, res.Erased)
+
+	entry, err := db.Get(&schema.KeyRequest{Key: []byte("pii/bob")})
+	require.NoError(t, err)
+	require.True(t, entry.Erased)
+
+	entry, err = db.Get(&schema.KeyRequest{Key: []byte("invoice/1")})
+	require.NoError(t, err)
+	require.False(t, entry.Erased)
+	require.Equal(t, []byte("invoice data"), entry.Value)
+
+	history, err := db.ErasureHistory(&schema.ErasureHistoryRequest{Key: []byte("pii/bob")})
+	require.NoError(t, err)
+	require.Len(t, history.Erasures, 1)
+	require.Equal(t, hdr2.Id, history.Erasures[0].Tx)
+	require.Equal(t, "retention", history.Erasures[0].RequestedBy)
+	require.Equal(t, "expired", history.Erasures[0].Reason)
+
+	// transactions already checked are not checked again
+	next, err := db.EnforceRetention([]byte("pii/"), res.ToTx, time.Now(), "retention", "expired")
+	require.NoError(t, err)
+	require.Equal(t, res.ToTx+1, next.FromTx)
+	require.Zero(t, next.Erased)
+}
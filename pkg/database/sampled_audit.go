@@ -0,0 +1,159 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// SampleTxs deterministically selects sampleSize distinct transactions in the range [fromTx, toTx]
+// out of seed, every transaction in the range being equally likely to be selected.
+// The selection only depends on the arguments, so it can be recomputed by the auditor who chose the seed
+// and can not be biased by the server. All the transactions in the range are returned when sampleSize
+// is not lower than its length. Transactions are returned in ascending order.
+func SampleTxs(seed []byte, fromTx, toTx uint64, sampleSize int) ([]uint64, error) {
+	if len(seed) == 0 || fromTx == 0 || fromTx > toTx || sampleSize <= 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	n := toTx - fromTx + 1
+
+	if uint64(sampleSize) >= n {
+		txIDs := make([]uint64, n)
+		for i := range txIDs {
+			txIDs[i] = fromTx + uint64(i)
+		}
+		return txIDs, nil
+	}
+
+	// values at or above limit are discarded so every transaction is equally likely
+	limit := math.MaxUint64 - math.MaxUint64%n
+
+	b := make([]byte, len(seed)+24)
+	copy(b, seed)
+	binary.BigEndian.PutUint64(b[len(seed):], fromTx)
+	binary.BigEndian.PutUint64(b[len(seed)+8:], toTx)
+
+	picked := make(map[uint64]struct{}, sampleSize)
+	txIDs := make([]uint64, 0, sampleSize)
+
+	for i := uint64(0); len(txIDs) < sampleSize; i++ {
+		binary.BigEndian.PutUint64(b[len(seed)+16:], i)
+
+		h := sha256.Sum256(b)
+
+		v := binary.BigEndian.Uint64(h[:])
+		if v >= limit {
+			continue
+		}
+
+		txID := fromTx + v%n
+
+		_, ok := picked[txID]
+		if ok {
+			continue
+		}
+
+		picked[txID] = struct{}{}
+		txIDs = append(txIDs, txID)
+	}
+
+	sort.Slice(txIDs, func(i, j int) bool { return txIDs[i] < txIDs[j] })
+
+	return txIDs, nil
+}
+
+// SampledAudit proves the transactions sampled out of req.Seed in the range [req.FromTx, req.ToTx]
+// are part of the history of the last committed transaction, which is linked to req.ProveSinceTx by a dual proof.
+// The sampled transactions are returned with their entries so their contents can be checked as well.
+func (d *db) SampledAudit(req *schema.SampledAuditRequest) (*schema.SampledAudit, error) {
+	if req == nil || len(req.Seed) == 0 || req.SampleSize == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	if req.SampleSize > MaxBatchProofLen {
+		return nil, ErrMaxBatchProofLenExceeded
+	}
+
+	lastTxID, _ := d.st.Alh()
+	if lastTxID < req.ProveSinceTx {
+		return nil, ErrIllegalState
+	}
+
+	fromTx := req.FromTx
+	if fromTx == 0 {
+		fromTx = 1
+	}
+
+	toTx := req.ToTx
+	if toTx == 0 {
+		toTx = lastTxID
+	}
+
+	if toTx > lastTxID {
+		return nil, ErrIllegalArguments
+	}
+
+	txIDs, err := SampleTxs(req.Seed, fromTx, toTx, int(req.SampleSize))
+	if err != nil {
+		return nil, err
+	}
+
+	targetTx := d.st.NewTxHolder()
+
+	err = d.st.ReadTx(lastTxID, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &schema.SampledAudit{
+		Seed:     req.Seed,
+		FromTx:   fromTx,
+		ToTx:     toTx,
+		Txs:      make([]*schema.Tx, len(txIDs)),
+		TxProofs: make([]*schema.TxInclusionProof, len(txIDs)),
+	}
+
+	tx := d.st.NewTxHolder()
+
+	for i, txID := range txIDs {
+		err = d.st.ReadTx(txID, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		txProof, err := d.st.TxInclusionProof(tx.Header(), targetTx.Header())
+		if err != nil {
+			return nil, err
+		}
+
+		audit.Txs[i] = schema.TxToProto(tx)
+		audit.TxProofs[i] = schema.TxInclusionProofToProto(txProof)
+	}
+
+	audit.DualProof, err = d.dualProofSince(req.ProveSinceTx, targetTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return audit, nil
+}
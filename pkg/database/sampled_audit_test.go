@@ -0,0 +1,146 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleTxs(t *testing.T) {
+	_, err := SampleTxs(nil, 1, 10, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = SampleTxs([]byte("seed"), 0, 10, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = SampleTxs([]byte("seed"), 10, 9, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = SampleTxs([]byte("seed"), 1, 10, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	txIDs, err := SampleTxs([]byte("seed"), 5, 7, 10)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5, 6, 7}, txIDs)
+
+	txIDs, err = SampleTxs([]byte("seed"), 100, 1000, 50)
+	require.NoError(t, err)
+	require.Len(t, txIDs, 50)
+
+	for i, txID := range txIDs {
+		require.GreaterOrEqual(t, txID, uint64(100))
+		require.LessOrEqual(t, txID, uint64(1000))
+
+		if i > 0 {
+			require.Less(t, txIDs[i-1], txID)
+		}
+	}
+
+	// the selection only depends on the arguments
+	sameTxIDs, err := SampleTxs([]byte("seed"), 100, 1000, 50)
+	require.NoError(t, err)
+	require.Equal(t, txIDs, sameTxIDs)
+
+	otherTxIDs, err := SampleTxs([]byte("other seed"), 100, 1000, 50)
+	require.NoError(t, err)
+	require.NotEqual(t, txIDs, otherTxIDs)
+
+	// every transaction ends up being selected
+	selected := make(map[uint64]struct{})
+
+	for i := 0; i < 100; i++ {
+		txIDs, err := SampleTxs([]byte(fmt.Sprintf("seed%d", i)), 1, 10, 3)
+		require.NoError(t, err)
+
+		for _, txID := range txIDs {
+			selected[txID] = struct{}{}
+		}
+	}
+
+	require.Len(t, selected, 10)
+}
+
+func TestSampledAudit(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.SampledAudit(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.SampledAudit(&schema.SampledAuditRequest{SampleSize: 1})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.SampledAudit(&schema.SampledAuditRequest{Seed: []byte("seed"), SampleSize: MaxBatchProofLen + 1})
+	require.ErrorIs(t, err, ErrMaxBatchProofLenExceeded)
+
+	for i := 0; i < 20; i++ {
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(fmt.Sprintf("key%d", i)), Value: []byte("value")}}})
+		require.NoError(t, err)
+	}
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	lastTxID := state.TxId
+
+	_, err = db.SampledAudit(&schema.SampledAuditRequest{Seed: []byte("seed"), SampleSize: 1, ProveSinceTx: lastTxID + 1})
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	_, err = db.SampledAudit(&schema.SampledAuditRequest{Seed: []byte("seed"), SampleSize: 1, ToTx: lastTxID + 1})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	audit, err := db.SampledAudit(&schema.SampledAuditRequest{
+		Seed:         []byte("seed"),
+		FromTx:       3,
+		SampleSize:   5,
+		ProveSinceTx: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), audit.FromTx)
+	require.Equal(t, lastTxID, audit.ToTx)
+
+	txIDs, err := SampleTxs([]byte("seed"), 3, lastTxID, 5)
+	require.NoError(t, err)
+	require.Len(t, audit.Txs, len(txIDs))
+	require.Len(t, audit.TxProofs, len(txIDs))
+
+	dualProof, err := schema.DualProofFromProto(audit.DualProof)
+	require.NoError(t, err)
+
+	target := dualProof.TargetTxHeader
+
+	require.Equal(t, lastTxID, target.ID)
+	require.True(t, store.VerifyDualProof(dualProof, 2, lastTxID, dualProof.SourceTxHeader.Alh(), target.Alh()))
+
+	for i, txID := range txIDs {
+		tx, err := schema.TxFromProto(audit.Txs[i])
+		require.NoError(t, err)
+
+		require.Equal(t, txID, tx.Header().ID)
+
+		proof, err := schema.TxInclusionProofFromProto(audit.TxProofs[i])
+		require.NoError(t, err)
+
+		require.Equal(t, proof.TxHeader.Alh(), tx.Header().Alh())
+		require.True(t, store.VerifyTxInclusion(proof, target))
+	}
+}
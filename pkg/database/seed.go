@@ -0,0 +1,65 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+// Seed creates the database described by op from a full backup followed by the incremental backups taken after it.
+// A replica seeded from the backups of its master only needs to replicate the transactions committed after them.
+func Seed(op *Options, backups []io.Reader, log logger.Logger) (DB, error) {
+	if op == nil || len(backups) == 0 {
+		return nil, store.ErrIllegalArguments
+	}
+
+	log.Infof("Seeding database '%s' from %d backup(s)...", op.dbName, len(backups))
+
+	dbDir := filepath.Join(op.GetDBRootPath(), op.GetDBName())
+
+	if _, dbErr := os.Stat(dbDir); dbErr == nil {
+		return nil, fmt.Errorf("Database directories already exist: %s", dbDir)
+	}
+
+	stOpts := op.storeOptions().WithLog(log)
+
+	manifest, err := store.RestoreBackup(dbDir, backups[0], stOpts)
+
+	for i := 1; err == nil && i < len(backups); i++ {
+		manifest, err = store.RestoreIncrementalBackup(dbDir, backups[i], stOpts)
+	}
+	if err != nil {
+		os.RemoveAll(dbDir)
+		return nil, logErr(log, "Unable to seed database: %s", err)
+	}
+
+	db, err := OpenDB(op, log)
+	if err != nil {
+		os.RemoveAll(dbDir)
+		return nil, err
+	}
+
+	log.Infof("Database '%s' successfully seeded up to tx %d", op.dbName, manifest.TxID)
+
+	return db, nil
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// SelectiveRestoreSummary describes the data written by RestoreSelected
+type SelectiveRestoreSummary struct {
+	Entries uint64
+	Rows    uint64
+}
+
+// RestoreSelected writes into dst the entries of src whose keys start with any of the given prefixes
+// and the rows of the given SQL tables. src is usually a database restored from backups, so a single
+// dataset can be recovered into a new or existing database. Tables missing in dst are created with the
+// definition they have in src, existing entries and rows are overwritten.
+func RestoreSelected(src, dst DB, prefixes [][]byte, tables []string) (*SelectiveRestoreSummary, error) {
+	if src == nil || dst == nil || (len(prefixes) == 0 && len(tables) == 0) {
+		return nil, ErrIllegalArguments
+	}
+
+	if dst.IsReplica() {
+		return nil, ErrIsReplica
+	}
+
+	batchSize := MaxKeyScanLimit
+	if maxTxEntries := dst.GetOptions().GetStoreOptions().MaxTxEntries; maxTxEntries < batchSize {
+		batchSize = maxTxEntries
+	}
+
+	summary := &SelectiveRestoreSummary{}
+
+	for _, prefix := range prefixes {
+		err := restorePrefix(src, dst, prefix, batchSize, summary)
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	for _, table := range tables {
+		err := restoreTable(src, dst, table, batchSize, summary)
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+func restorePrefix(src, dst DB, prefix []byte, batchSize int, summary *SelectiveRestoreSummary) error {
+	var seekKey []byte
+
+	// references are set once all the entries under the prefix are restored
+	var refs []*schema.ReferenceRequest
+
+	for {
+		entries, err := src.Scan(&schema.ScanRequest{
+			Prefix:  prefix,
+			SeekKey: seekKey,
+			Limit:   uint64(batchSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		var kvs []*schema.KeyValue
+
+		for _, e := range entries.Entries {
+			if e.ReferencedBy != nil {
+				refs = append(refs, &schema.ReferenceRequest{
+					Key:           e.ReferencedBy.Key,
+					ReferencedKey: e.Key,
+				})
+				seekKey = e.ReferencedBy.Key
+				continue
+			}
+
+			kvs = append(kvs, &schema.KeyValue{
+				Key:      e.Key,
+				Value:    e.Value,
+				Metadata: e.Metadata,
+			})
+			seekKey = e.Key
+		}
+
+		if len(kvs) > 0 {
+			_, err = dst.Set(&schema.SetRequest{KVs: kvs})
+			if err != nil {
+				return err
+			}
+
+			summary.Entries += uint64(len(kvs))
+		}
+
+		if len(entries.Entries) < batchSize {
+			break
+		}
+	}
+
+	for _, ref := range refs {
+		_, err := dst.SetReference(ref)
+		if err == store.ErrKeyNotFound {
+			return fmt.Errorf("%w: key '%s' referenced by '%s' was not restored", err, ref.ReferencedKey, ref.Key)
+		}
+		if err != nil {
+			return err
+		}
+
+		summary.Entries++
+	}
+
+	return nil
+}
+
+func restoreTable(src, dst DB, table string, batchSize int, summary *SelectiveRestoreSummary) error {
+	stmts, err := sql.ParseString(fmt.Sprintf("SELECT * FROM %s", table))
+	if err != nil || len(stmts) != 1 {
+		return fmt.Errorf("%w: invalid table name '%s'", ErrIllegalArguments, table)
+	}
+
+	selStmt, ok := stmts[0].(*sql.SelectStmt)
+	if !ok {
+		return fmt.Errorf("%w: invalid table name '%s'", ErrIllegalArguments, table)
+	}
+
+	rr, err := src.SQLQueryRowReader(selStmt, nil)
+	if err != nil {
+		return err
+	}
+	defer rr.Close()
+
+	t, err := rr.Database().GetTableByName(table)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = dst.SQLExec(&schema.SQLExecRequest{Sql: tableDefinition(t)}, nil)
+	if err != nil {
+		return err
+	}
+
+	cols, err := rr.Columns()
+	if err != nil {
+		return err
+	}
+
+	colNames := make([]string, len(cols))
+	for i, c := range cols {
+		colNames[i] = c.Column
+	}
+
+	// each row is written as an entry per index of the table
+	rowsPerTx := batchSize / len(t.GetIndexes())
+	if rowsPerTx == 0 {
+		rowsPerTx = 1
+	}
+
+	var values []string
+	var params []*schema.NamedParam
+
+	flush := func() error {
+		if len(values) == 0 {
+			return nil
+		}
+
+		_, _, err := dst.SQLExec(&schema.SQLExecRequest{
+			Sql:    fmt.Sprintf("UPSERT INTO %s(%s) VALUES %s", t.Name(), strings.Join(colNames, ", "), strings.Join(values, ", ")),
+			Params: params,
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		summary.Rows += uint64(len(values))
+
+		values = nil
+		params = nil
+
+		return nil
+	}
+
+	for {
+		row, err := rr.Read()
+		if err == sql.ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		placeholders := make([]string, len(cols))
+
+		for i, c := range cols {
+			name := fmt.Sprintf("r%dc%d", len(values), i)
+			placeholders[i] = "@" + name
+
+			v := row.Values[c.Selector()]
+
+			var sqlVal *schema.SQLValue
+			if v.IsNull() {
+				sqlVal = &schema.SQLValue{Value: &schema.SQLValue_Null{}}
+			} else {
+				sqlVal = typedValueToRowValue(v)
+			}
+
+			params = append(params, &schema.NamedParam{Name: name, Value: sqlVal})
+		}
+
+		values = append(values, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
+
+		if len(values) == rowsPerTx {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// tableDefinition returns the statements creating the table and its indexes when they don't exist
+func tableDefinition(t *sql.Table) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (", t.Name())
+
+	for _, c := range t.Cols() {
+		b.WriteString(c.Name())
+		b.WriteString(" ")
+		b.WriteString(c.Type())
+
+		if c.MaxLen() > 0 {
+			fmt.Fprintf(&b, "[%d]", c.MaxLen())
+		}
+		if !c.IsNullable() {
+			b.WriteString(" NOT NULL")
+		}
+		if c.IsAutoIncremental() {
+			b.WriteString(" AUTO_INCREMENT")
+		}
+
+		b.WriteString(", ")
+	}
+
+	fmt.Fprintf(&b, "PRIMARY KEY (%s));", strings.Join(indexColNames(t.PrimaryIndex()), ", "))
+
+	for _, index := range t.GetIndexes() {
+		if index.IsPrimary() {
+			continue
+		}
+
+		unique := ""
+		if index.IsUnique() {
+			unique = "UNIQUE "
+		}
+
+		fmt.Fprintf(&b, "CREATE %sINDEX IF NOT EXISTS ON %s(%s);", unique, t.Name(), strings.Join(indexColNames(index), ", "))
+	}
+
+	return b.String()
+}
+
+func indexColNames(index *sql.Index) []string {
+	names := make([]string, len(index.Cols()))
+	for i, c := range index.Cols() {
+		names[i] = c.Name()
+	}
+	return names
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreSelected(t *testing.T) {
+	src, closeSrc := makeDb()
+	defer closeSrc()
+
+	// small transactions so data is restored in several batches
+	dstOpts := DefaultOption().
+		WithDBRootPath("data_" + strconv.FormatInt(time.Now().UnixNano(), 10)).
+		WithDBName("db").
+		WithCorruptionChecker(false)
+	dstOpts.storeOpts.WithMaxTxEntries(8)
+
+	dst, closeDst := makeDbWith(dstOpts)
+	defer closeDst()
+
+	for i := 0; i < 10; i++ {
+		_, err := src.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte(fmt.Sprintf("users/%d", i)), Value: []byte(fmt.Sprintf("user%d", i))},
+			{Key: []byte(fmt.Sprintf("orders/%d", i)), Value: []byte(fmt.Sprintf("order%d", i))},
+		}})
+		require.NoError(t, err)
+	}
+
+	_, err := src.SetReference(&schema.ReferenceRequest{Key: []byte("users/admin"), ReferencedKey: []byte("users/0")})
+	require.NoError(t, err)
+
+	_, _, err = src.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE accounts (id INTEGER AUTO_INCREMENT, name VARCHAR[32] NOT NULL, balance INTEGER, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON accounts(name);
+		CREATE TABLE audit (id INTEGER, PRIMARY KEY id);
+	`}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, _, err = src.SQLExec(&schema.SQLExecRequest{
+			Sql: "INSERT INTO accounts(name, balance) VALUES (@name, @balance); INSERT INTO audit(id) VALUES (@id)",
+			Params: []*schema.NamedParam{
+				{Name: "name", Value: &schema.SQLValue{Value: &schema.SQLValue_S{S: fmt.Sprintf("account%d", i)}}},
+				{Name: "balance", Value: &schema.SQLValue{Value: &schema.SQLValue_N{N: int64(i * 100)}}},
+				{Name: "id", Value: &schema.SQLValue{Value: &schema.SQLValue_N{N: int64(i)}}},
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	_, _, err = src.SQLExec(&schema.SQLExecRequest{Sql: "UPSERT INTO accounts(id, name, balance) VALUES (10, 'account9', NULL)"}, nil)
+	require.NoError(t, err)
+
+	_, err = dst.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("users/1"), Value: []byte("outdated")}}})
+	require.NoError(t, err)
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err = RestoreSelected(nil, dst, [][]byte{[]byte("users/")}, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = RestoreSelected(src, dst, nil, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = RestoreSelected(src, dst, nil, []string{"accounts; DROP TABLE accounts"})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("references to keys which were not restored", func(t *testing.T) {
+		_, err = RestoreSelected(src, dst, [][]byte{[]byte("users/admin")}, nil)
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+
+	summary, err := RestoreSelected(src, dst, [][]byte{[]byte("users/")}, []string{"accounts"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), summary.Entries)
+	require.Equal(t, uint64(10), summary.Rows)
+
+	entry, err := dst.Get(&schema.KeyRequest{Key: []byte("users/1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("user1"), entry.Value)
+
+	entry, err = dst.Get(&schema.KeyRequest{Key: []byte("users/admin")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("user0"), entry.Value)
+
+	_, err = dst.Get(&schema.KeyRequest{Key: []byte("orders/1")})
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	res, err := dst.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT id, name, balance FROM accounts WHERE name = 'account9'"}, nil)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1)
+	require.Equal(t, int64(10), res.Rows[0].Values[0].GetN())
+	require.IsType(t, &schema.SQLValue_Null{}, res.Rows[0].Values[2].Value)
+
+	res, err = dst.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT COUNT(*) FROM accounts"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), res.Rows[0].Values[0].GetN())
+
+	_, err = dst.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT id FROM audit"}, nil)
+	require.Error(t, err)
+
+	t.Run("restoring again overwrites the restored data", func(t *testing.T) {
+		summary, err := RestoreSelected(src, dst, nil, []string{"accounts"})
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), summary.Rows)
+
+		res, err = dst.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT COUNT(*) FROM accounts"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), res.Rows[0].Values[0].GetN())
+	})
+}
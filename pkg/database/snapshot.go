@@ -0,0 +1,135 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// Snapshot is a consistent read view of the database pinned at a transaction.
+// Writers are not blocked while the snapshot is open but it must be closed once no longer needed.
+// A snapshot is not safe for concurrent use.
+type Snapshot struct {
+	d    *db
+	snap *store.Snapshot
+	tx   *store.Tx
+}
+
+// Snapshot returns a consistent read view including every transaction committed before the call
+func (d *db) Snapshot() (*Snapshot, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	currTxID, _ := d.st.Alh()
+
+	err := d.st.WaitForIndexingUpto(currTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.st.SnapshotSince(currTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		d:    d,
+		snap: snap,
+		tx:   d.st.NewTxHolder(),
+	}, nil
+}
+
+// TxID returns the id of the last transaction included in the snapshot
+func (s *Snapshot) TxID() uint64 {
+	return s.snap.Ts()
+}
+
+// Get returns the entry of key as of the snapshot, references are resolved
+func (s *Snapshot) Get(key []byte) (*schema.Entry, error) {
+	if len(key) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	return s.d.getAt(EncodeKey(key), 0, 0, s.snap, s.tx)
+}
+
+// History returns the entries of key set up to the snapshot
+func (s *Snapshot) History(key []byte, offset uint64, desc bool, limit int) (*schema.Entries, error) {
+	if len(key) == 0 || limit < 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	if limit == 0 {
+		limit = MaxKeyScanLimit
+	}
+
+	txs, err := s.snap.History(EncodeKey(key), offset, desc, limit)
+	if err != nil && err != store.ErrOffsetOutOfRange {
+		return nil, err
+	}
+
+	return s.d.historyEntries(key, txs, s.tx)
+}
+
+// Scan calls fn with every entry whose key has the given prefix, deleted entries are skipped.
+// Scanning stops as soon as fn returns an error, which is then returned.
+func (s *Snapshot) Scan(prefix []byte, desc bool, fn func(e *schema.Entry) error) error {
+	if fn == nil {
+		return ErrIllegalArguments
+	}
+
+	r, err := s.snap.NewKeyReader(
+		&store.KeyReaderSpec{
+			Prefix:    EncodeKey(prefix),
+			DescOrder: desc,
+			Filter:    store.IgnoreDeleted,
+		})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		key, valRef, err := r.Read()
+		if err == store.ErrNoMoreEntries {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		e, err := s.d.getAt(key, valRef.Tx(), 0, s.snap, s.tx)
+		if err == store.ErrKeyNotFound {
+			// ignore deleted ones (referenced key may have been deleted)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Close releases the snapshot
+func (s *Snapshot) Close() error {
+	return s.snap.Close()
+}
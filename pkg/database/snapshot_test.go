@@ -0,0 +1,123 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	for i := 0; i < 10; i++ {
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte(fmt.Sprintf("key%d", i)), Value: []byte(fmt.Sprintf("value%d", i))},
+		}})
+		require.NoError(t, err)
+	}
+
+	_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key0"), Value: []byte("value10")}}})
+	require.NoError(t, err)
+
+	_, err = db.SetReference(&schema.ReferenceRequest{Key: []byte("ref"), ReferencedKey: []byte("key1")})
+	require.NoError(t, err)
+
+	snap, err := db.Snapshot()
+	require.NoError(t, err)
+
+	txID := snap.TxID()
+
+	// writes are not blocked and not visible through the snapshot
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key0"), Value: []byte("value11")},
+		{Key: []byte("key10"), Value: []byte("value10")},
+	}})
+	require.NoError(t, err)
+
+	_, err = db.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key2")}})
+	require.NoError(t, err)
+
+	require.Equal(t, txID, snap.TxID())
+
+	e, err := snap.Get([]byte("key0"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value10"), e.Value)
+
+	e, err = snap.Get([]byte("ref"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), e.Value)
+	require.Equal(t, []byte("key1"), e.Key)
+
+	_, err = snap.Get([]byte("key10"))
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	_, err = snap.Get(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	hist, err := snap.History([]byte("key0"), 0, false, 0)
+	require.NoError(t, err)
+	require.Len(t, hist.Entries, 2)
+	require.Equal(t, []byte("value0"), hist.Entries[0].Value)
+	require.Equal(t, []byte("value10"), hist.Entries[1].Value)
+
+	_, err = snap.History([]byte("key0"), 0, false, -1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	var keys []string
+
+	err = snap.Scan([]byte("key"), false, func(e *schema.Entry) error {
+		keys = append(keys, string(e.Key))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, 10)
+	require.Equal(t, "key0", keys[0])
+	require.Contains(t, keys, "key2")
+
+	errStop := errors.New("stop")
+
+	err = snap.Scan(nil, true, func(e *schema.Entry) error {
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+
+	err = snap.Scan(nil, false, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = snap.Close()
+	require.NoError(t, err)
+
+	snap, err = db.Snapshot()
+	require.NoError(t, err)
+	defer snap.Close()
+
+	require.Greater(t, snap.TxID(), txID)
+
+	_, err = snap.Get([]byte("key2"))
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	e, err = snap.Get([]byte("key10"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value10"), e.Value)
+}
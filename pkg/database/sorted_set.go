@@ -71,6 +71,8 @@ func (d *db) ZAdd(req *schema.ZAddRequest) (*schema.TxHeader, error) {
 	}
 	defer tx.Cancel()
 
+	tx.WithMetadata(schema.TxMetadataFromProto(req.TxMetadata))
+
 	e := EncodeZAdd(req.Set, req.Score, key, req.AtTx)
 
 	err = tx.Set(e.Key, e.Metadata, e.Value)
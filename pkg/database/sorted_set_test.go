@@ -695,13 +695,17 @@ func TestStoreVerifiableZAdd(t *testing.T) {
 	require.Equal(t, ekv.Key, vtx.Tx.Entries[0].Key)
 	require.Equal(t, int32(0), vtx.Tx.Entries[0].VLen)
 
-	dualProof := schema.DualProofFromProto(vtx.DualProof)
+	dualProof, err := schema.DualProofFromProto(vtx.DualProof)
+	require.NoError(t, err)
+
+	sourceHdr, err := schema.TxHeaderFromProto(i1)
+	require.NoError(t, err)
 
 	verifies := store.VerifyDualProof(
 		dualProof,
 		i1.Id,
 		vtx.Tx.Header.Id,
-		schema.TxHeaderFromProto(i1).Alh(),
+		sourceHdr.Alh(),
 		dualProof.TargetTxHeader.Alh(),
 	)
 	require.True(t, verifies)
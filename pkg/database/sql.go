@@ -337,10 +337,14 @@ func (d *db) SQLExec(req *schema.SQLExecRequest, tx *sql.SQLTx) (ntx *sql.SQLTx,
 		}
 	}
 
-	return d.SQLExecPrepared(stmts, req.Params, tx)
+	return d.sqlExecPrepared(stmts, req.Params, schema.TxMetadataFromProto(req.TxMetadata), tx)
 }
 
 func (d *db) SQLExecPrepared(stmts []sql.SQLStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (ntx *sql.SQLTx, ctxs []*sql.SQLTx, err error) {
+	return d.sqlExecPrepared(stmts, namedParams, nil, tx)
+}
+
+func (d *db) sqlExecPrepared(stmts []sql.SQLStmt, namedParams []*schema.NamedParam, md *store.TxMetadata, tx *sql.SQLTx) (ntx *sql.SQLTx, ctxs []*sql.SQLTx, err error) {
 	if len(stmts) == 0 {
 		return nil, nil, ErrIllegalArguments
 	}
@@ -352,13 +356,34 @@ func (d *db) SQLExecPrepared(stmts []sql.SQLStmt, namedParams []*schema.NamedPar
 		return nil, nil, ErrIsReplica
 	}
 
+	err = d.checkSQLLegalHolds(stmts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = d.checkSQLRetentionLock(stmts)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	params := make(map[string]interface{})
 
 	for _, p := range namedParams {
 		params[p.Name] = schema.RawValue(p.Value)
 	}
 
-	return d.sqlEngine.ExecPreparedStmts(stmts, params, tx)
+	return d.sqlEngine.ExecPreparedStmtsWithTxMetadata(stmts, params, md, tx)
+}
+
+// checkSQLRetentionLock returns ErrRetentionLocked when any of the statements deletes rows before the retention date
+func (d *db) checkSQLRetentionLock(stmts []sql.SQLStmt) error {
+	for _, stmt := range stmts {
+		if _, ok := stmt.(*sql.DeleteFromStmt); ok {
+			return d.st.CheckRetentionLock()
+		}
+	}
+
+	return nil
 }
 
 func (d *db) SQLQuery(req *schema.SQLQueryRequest, tx *sql.SQLTx) (*schema.SQLQueryResult, error) {
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// DiskUsage is the number of bytes the files of a database take on disk
+type DiskUsage struct {
+	TxLog int64
+	VLog  int64
+	Index int64
+	// Other accounts for the remaining files, like the commit log and the hash tree
+	Other int64
+}
+
+// Total returns the number of bytes all the files of the database take on disk
+func (u *DiskUsage) Total() int64 {
+	return u.TxLog + u.VLog + u.Index + u.Other
+}
+
+// DiskUsage walks the directory of the database adding up the size of its files
+// by the structure they belong to
+func (d *db) DiskUsage() (*DiskUsage, error) {
+	root := d.path()
+	usage := &DiskUsage{}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		dir := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+
+		switch {
+		case dir == "tx":
+			usage.TxLog += info.Size()
+		case strings.HasPrefix(dir, "val_"):
+			usage.VLog += info.Size()
+		case dir == "index":
+			usage.Index += info.Size()
+		default:
+			usage.Other += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// CountEntries returns the number of entries of the transactions committed from initialTx on,
+// along with the id of the last transaction being accounted
+func (d *db) CountEntries(initialTx uint64) (entries uint64, lastTx uint64, err error) {
+	if initialTx == 0 {
+		return 0, 0, ErrIllegalArguments
+	}
+
+	lastTx = initialTx - 1
+
+	if d.st.TxCount() < initialTx {
+		return 0, lastTx, nil
+	}
+
+	txReader, err := d.st.NewTxReader(initialTx, false, d.st.NewTxHolder())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for {
+		tx, err := txReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		entries += uint64(tx.Header().NEntries)
+		lastTx = tx.Header().ID
+	}
+
+	return entries, lastTx, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageUsage(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, _, err := db.CountEntries(0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	initialEntries, initialTx, err := db.CountEntries(1)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte{byte(i)}, Value: make([]byte, 1024)},
+			{Key: []byte{byte(i), 1}, Value: make([]byte, 1024)},
+		}})
+		require.NoError(t, err)
+	}
+
+	entries, lastTx, err := db.CountEntries(1)
+	require.NoError(t, err)
+	require.Equal(t, initialEntries+20, entries)
+	require.Equal(t, initialTx+10, lastTx)
+
+	entries, lastTx, err = db.CountEntries(initialTx + 6)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), entries)
+	require.Equal(t, initialTx+10, lastTx)
+
+	entries, lastTx, err = db.CountEntries(lastTx + 1)
+	require.NoError(t, err)
+	require.Zero(t, entries)
+	require.Equal(t, initialTx+10, lastTx)
+
+	usage, err := db.DiskUsage()
+	require.NoError(t, err)
+	require.Greater(t, usage.TxLog, int64(0))
+	require.GreaterOrEqual(t, usage.VLog, int64(20*1024))
+	require.Greater(t, usage.Other, int64(0))
+	require.Equal(t, usage.TxLog+usage.VLog+usage.Index+usage.Other, usage.Total())
+}
@@ -36,6 +36,12 @@ func mapGRPcErrorCode(code Code) codes.Code {
 		return codes.Unimplemented
 	case CodInvalidDatabaseName:
 		return codes.NotFound
+	case CodInsufficientResources:
+		return codes.ResourceExhausted
+	case CodFeatureNotSupported:
+		return codes.Unimplemented
+	case CodObjectNotInPrerequisiteState:
+		return codes.FailedPrecondition
 	default:
 		return codes.Unknown
 	}
@@ -35,6 +35,12 @@ func Test_Map(t *testing.T) {
 	require.Equal(t, codes.Internal, st)
 	st = mapGRPcErrorCode(CodUndefinedFunction)
 	require.Equal(t, codes.Unimplemented, st)
+	st = mapGRPcErrorCode(CodInsufficientResources)
+	require.Equal(t, codes.ResourceExhausted, st)
+	st = mapGRPcErrorCode(CodFeatureNotSupported)
+	require.Equal(t, codes.Unimplemented, st)
+	st = mapGRPcErrorCode(CodObjectNotInPrerequisiteState)
+	require.Equal(t, codes.FailedPrecondition, st)
 	st = mapGRPcErrorCode(Code("Unknown"))
 	require.Equal(t, codes.Unknown, st)
 }
@@ -32,6 +32,9 @@ const (
 	CodSqlserverRejectedEstablishmentOfSqlSession    Code = "08001"
 	CodInvalidTransactionInitiation                  Code = "0B000"
 	CodInFailedSqlTransaction                        Code = "25P02"
+	CodInsufficientResources                         Code = "53000"
+	CodFeatureNotSupported                           Code = "0A000"
+	CodObjectNotInPrerequisiteState                  Code = "55000"
 )
 
 var (
@@ -0,0 +1,205 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	pb "github.com/codenotary/immudb/pkg/etcd/etcdserverpb"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadata of the requests holding the auth token and the database
+const (
+	tokenMetadata    = "token"
+	databaseMetadata = "database"
+)
+
+type access int
+
+const (
+	accessRead access = iota
+	accessWrite
+)
+
+// tokens are the auth tokens issued to users, they expire when not used for ttl.
+// Only the username is kept, the user is loaded again on every request so that
+// deactivations and permission changes apply to the tokens already issued
+type tokens struct {
+	mutex  sync.Mutex
+	ttl    time.Duration
+	issued map[string]*issuedToken
+}
+
+type issuedToken struct {
+	username string
+	lastUsed time.Time
+}
+
+func newTokens(ttl time.Duration) *tokens {
+	return &tokens{
+		ttl:    ttl,
+		issued: make(map[string]*issuedToken),
+	}
+}
+
+func (t *tokens) issue(username string) (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(b)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.expire()
+	t.issued[token] = &issuedToken{username: username, lastUsed: time.Now()}
+
+	return token, nil
+}
+
+// username returns the name of the user the token was issued to, false when the token is unknown or expired
+func (t *tokens) username(token string) (string, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	issued, ok := t.issued[token]
+	if !ok || time.Since(issued.lastUsed) > t.ttl {
+		delete(t.issued, token)
+		return "", false
+	}
+
+	issued.lastUsed = time.Now()
+
+	return issued.username, true
+}
+
+// revoke drops the tokens issued to the user, returning how many were dropped
+func (t *tokens) revoke(username string) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	revoked := 0
+
+	for token, issued := range t.issued {
+		if issued.username == username {
+			delete(t.issued, token)
+			revoked++
+		}
+	}
+
+	return revoked
+}
+
+func (t *tokens) expire() {
+	for token, issued := range t.issued {
+		if time.Since(issued.lastUsed) > t.ttl {
+			delete(t.issued, token)
+		}
+	}
+}
+
+func (s *srv) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	if req.Name == "" {
+		return nil, ErrUserEmpty
+	}
+
+	usr, err := auth.GetUser(s.sysDb, []byte(req.Name))
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, ErrAuthFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !usr.Active || usr.ComparePasswords([]byte(req.Password)) != nil {
+		return nil, ErrAuthFailed
+	}
+
+	token, err := s.tokens.issue(usr.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AuthenticateResponse{Header: &pb.ResponseHeader{}, Token: token}, nil
+}
+
+// RevokeTokens invalidates the tokens issued to the user, returning how many were revoked
+func (s *srv) RevokeTokens(username string) int {
+	return s.tokens.revoke(username)
+}
+
+// session returns the user authenticated by the token of the request and the database it selects,
+// failing when the user is not allowed the access on it
+func (s *srv) session(ctx context.Context, access access) (*auth.User, database.DB, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	tokens := md.Get(tokenMetadata)
+	if len(tokens) == 0 {
+		return nil, nil, ErrUserEmpty
+	}
+
+	username, ok := s.tokens.username(tokens[0])
+	if !ok {
+		return nil, nil, ErrInvalidAuthToken
+	}
+
+	usr, err := auth.GetUser(s.sysDb, []byte(username))
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, nil, ErrInvalidAuthToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !usr.Active {
+		return nil, nil, ErrInvalidAuthToken
+	}
+
+	name := s.defaultDatabase
+	if names := md.Get(databaseMetadata); len(names) > 0 {
+		name = names[0]
+	}
+
+	db, err := s.dbList.GetByName(name)
+	if err != nil {
+		return nil, nil, ErrDatabaseNotFound
+	}
+
+	switch usr.WhichPermission(name) {
+	case auth.PermissionSysAdmin, auth.PermissionAdmin, auth.PermissionRW:
+		return usr, db, nil
+	case auth.PermissionR:
+		if access == accessRead {
+			return usr, db, nil
+		}
+	}
+
+	return nil, nil, ErrPermissionDenied
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTokens(t *testing.T) {
+	tokens := newTokens(50 * time.Millisecond)
+
+	token, err := tokens.issue("user1")
+	require.NoError(t, err)
+	require.Len(t, token, 32)
+
+	other, err := tokens.issue("user1")
+	require.NoError(t, err)
+	require.NotEqual(t, token, other)
+
+	username, ok := tokens.username(token)
+	require.True(t, ok)
+	require.Equal(t, "user1", username)
+
+	_, ok = tokens.username("unknown")
+	require.False(t, ok)
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, ok = tokens.username(token)
+	require.False(t, ok)
+
+	_, ok = tokens.username(other)
+	require.False(t, ok)
+
+	token, err = tokens.issue("user1")
+	require.NoError(t, err)
+
+	_, err = tokens.issue("user2")
+	require.NoError(t, err)
+
+	require.Equal(t, 1, tokens.revoke("user1"))
+	require.Equal(t, 0, tokens.revoke("user1"))
+
+	_, ok = tokens.username(token)
+	require.False(t, ok)
+}
+
+func putUser(t *testing.T, sysDb database.DB, usr *auth.User) {
+	value, err := json.Marshal(usr)
+	require.NoError(t, err)
+
+	_, err = sysDb.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: auth.UserKey([]byte(usr.Username)), Value: value}}})
+	require.NoError(t, err)
+}
+
+func TestSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log := logger.NewSimpleLogger("etcd", os.Stderr)
+
+	sysDb, err := database.NewDB(database.DefaultOption().WithDBRootPath(dir).WithDBName("systemdb"), log)
+	require.NoError(t, err)
+	defer sysDb.Close()
+
+	db1, err := database.NewDB(database.DefaultOption().WithDBRootPath(dir).WithDBName("db1"), log)
+	require.NoError(t, err)
+	defer db1.Close()
+
+	db2, err := database.NewDB(database.DefaultOption().WithDBRootPath(dir).WithDBName("db2"), log)
+	require.NoError(t, err)
+	defer db2.Close()
+
+	dbList := database.NewDatabaseList()
+	dbList.Append(db1)
+	dbList.Append(db2)
+
+	s := New(DatabaseList(dbList), SysDb(sysDb), DefaultDatabase("db1"))
+
+	reader := &auth.User{
+		Username: "reader",
+		Active:   true,
+		Permissions: []auth.Permission{
+			{Database: "db1", Permission: auth.PermissionR},
+		},
+	}
+	putUser(t, sysDb, reader)
+
+	token, err := s.tokens.issue(reader.Username)
+	require.NoError(t, err)
+
+	_, _, err = s.session(context.Background(), accessRead)
+	require.Equal(t, ErrUserEmpty, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tokenMetadata, "unknown"))
+	_, _, err = s.session(ctx, accessRead)
+	require.Equal(t, ErrInvalidAuthToken, err)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(tokenMetadata, token))
+
+	usr, db, err := s.session(ctx, accessRead)
+	require.NoError(t, err)
+	require.Equal(t, "reader", usr.Username)
+	require.Equal(t, "db1", db.GetName())
+
+	_, _, err = s.session(ctx, accessWrite)
+	require.Equal(t, ErrPermissionDenied, err)
+
+	// permission changes apply to the tokens already issued
+	reader.GrantPermission("db1", auth.PermissionRW)
+	putUser(t, sysDb, reader)
+
+	_, _, err = s.session(ctx, accessWrite)
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tokenMetadata, token, databaseMetadata, "db2"))
+	_, _, err = s.session(dbCtx, accessRead)
+	require.Equal(t, ErrPermissionDenied, err)
+
+	dbCtx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(tokenMetadata, token, databaseMetadata, "db3"))
+	_, _, err = s.session(dbCtx, accessRead)
+	require.Equal(t, ErrDatabaseNotFound, err)
+
+	// so does deactivation
+	reader.Active = false
+	putUser(t, sysDb, reader)
+
+	_, _, err = s.session(ctx, accessRead)
+	require.Equal(t, ErrInvalidAuthToken, err)
+
+	reader.Active = true
+	putUser(t, sysDb, reader)
+
+	_, _, err = s.session(ctx, accessRead)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, s.RevokeTokens(reader.Username))
+
+	_, _, err = s.session(ctx, accessRead)
+	require.Equal(t, ErrInvalidAuthToken, err)
+}
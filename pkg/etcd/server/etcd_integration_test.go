@@ -0,0 +1,353 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/codenotary/immudb/pkg/etcd/etcdserverpb"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func put(t *testing.T, ctx context.Context, kv pb.KVClient, key, value string) int64 {
+	resp, err := kv.Put(ctx, &pb.PutRequest{Key: []byte(key), Value: []byte(value)})
+	require.NoError(t, err)
+	return resp.Header.Revision
+}
+
+func get(t *testing.T, ctx context.Context, kv pb.KVClient, key string) *pb.KeyValue {
+	resp, err := kv.Range(ctx, &pb.RangeRequest{Key: []byte(key)})
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(resp.Kvs), 1)
+	if len(resp.Kvs) == 0 {
+		return nil
+	}
+	return resp.Kvs[0]
+}
+
+func keysOf(kvs []*pb.KeyValue) []string {
+	keys := make([]string, len(kvs))
+	for i, kv := range kvs {
+		keys[i] = string(kv.Key)
+	}
+	return keys
+}
+
+func recvWatch(t *testing.T, w pb.Watch_WatchClient) *pb.WatchResponse {
+	resp, err := w.Recv()
+	require.NoError(t, err)
+	return resp
+}
+
+func TestEtcdServer(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_etcd")
+	options := server.DefaultOptions().WithDir(td).WithEtcdServer(true).WithEtcdServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	conn, err := grpc.Dial(fmt.Sprintf("localhost:%d", bs.Server.Srv.EtcdSrv.GetPort()), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	kv := pb.NewKVClient(conn)
+	authClient := pb.NewAuthClient(conn)
+
+	bg := context.Background()
+
+	_, err = kv.Range(bg, &pb.RangeRequest{Key: []byte("k1")})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Contains(t, err.Error(), "user name is empty")
+
+	_, err = authClient.Authenticate(bg, &pb.AuthenticateRequest{Name: "immudb", Password: "wrong"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Contains(t, err.Error(), "authentication failed")
+
+	_, err = authClient.Authenticate(bg, &pb.AuthenticateRequest{Name: "nobody", Password: "immudb"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = kv.Range(metadata.AppendToOutgoingContext(bg, "token", "invalid"), &pb.RangeRequest{Key: []byte("k1")})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	authResp, err := authClient.Authenticate(bg, &pb.AuthenticateRequest{Name: "immudb", Password: "immudb"})
+	require.NoError(t, err)
+	require.NotEmpty(t, authResp.Token)
+
+	ctx := metadata.AppendToOutgoingContext(bg, "token", authResp.Token)
+
+	_, err = kv.Range(metadata.AppendToOutgoingContext(ctx, "database", "missingdb"), &pb.RangeRequest{Key: []byte("k1")})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	t.Run("put and range", func(t *testing.T) {
+		require.Nil(t, get(t, ctx, kv, "k1"))
+
+		_, err := kv.Put(ctx, &pb.PutRequest{Value: []byte("v1")})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = kv.Put(ctx, &pb.PutRequest{Key: []byte("k1"), Value: []byte("v1"), Lease: 1})
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		rev1 := put(t, ctx, kv, "k1", "v1")
+
+		kv1 := get(t, ctx, kv, "k1")
+		require.Equal(t, &pb.KeyValue{Key: []byte("k1"), Value: []byte("v1"), CreateRevision: rev1, ModRevision: rev1, Version: 1}, kv1)
+
+		resp, err := kv.Put(ctx, &pb.PutRequest{Key: []byte("k1"), Value: []byte("v2"), PrevKv: true})
+		require.NoError(t, err)
+		require.Equal(t, rev1+1, resp.Header.Revision)
+		require.Equal(t, []byte("v1"), resp.PrevKv.Value)
+
+		kv2 := get(t, ctx, kv, "k1")
+		require.Equal(t, []byte("v2"), kv2.Value)
+		require.Equal(t, rev1, kv2.CreateRevision)
+		require.Equal(t, rev1+1, kv2.ModRevision)
+		require.Equal(t, int64(2), kv2.Version)
+
+		_, err = kv.Put(ctx, &pb.PutRequest{Key: []byte("k1"), IgnoreValue: true})
+		require.NoError(t, err)
+		require.Equal(t, []byte("v2"), get(t, ctx, kv, "k1").Value)
+		require.Equal(t, int64(3), get(t, ctx, kv, "k1").Version)
+
+		_, err = kv.Put(ctx, &pb.PutRequest{Key: []byte("missing"), IgnoreValue: true})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		rangeResp, err := kv.Range(ctx, &pb.RangeRequest{Key: []byte("k1"), Revision: resp.Header.Revision + 100})
+		require.Equal(t, codes.OutOfRange, status.Code(err))
+		require.Nil(t, rangeResp)
+	})
+
+	t.Run("prefix range", func(t *testing.T) {
+		for _, k := range []string{"/cfg/c", "/cfg/a", "/cfg/b", "/cfg0", "/cf"} {
+			put(t, ctx, kv, k, "value of "+k)
+		}
+
+		resp, err := kv.Range(ctx, &pb.RangeRequest{Key: []byte("/cfg/"), RangeEnd: []byte("/cfg0")})
+		require.NoError(t, err)
+		require.Equal(t, []string{"/cfg/a", "/cfg/b", "/cfg/c"}, keysOf(resp.Kvs))
+		require.Equal(t, int64(3), resp.Count)
+		require.False(t, resp.More)
+
+		resp, err = kv.Range(ctx, &pb.RangeRequest{Key: []byte("/cfg/"), RangeEnd: []byte("/cfg0"), Limit: 2, SortOrder: pb.RangeRequest_DESCEND})
+		require.NoError(t, err)
+		require.Equal(t, []string{"/cfg/c", "/cfg/b"}, keysOf(resp.Kvs))
+		require.Equal(t, int64(3), resp.Count)
+		require.True(t, resp.More)
+
+		resp, err = kv.Range(ctx, &pb.RangeRequest{Key: []byte("/cfg/"), RangeEnd: []byte("/cfg0"), SortTarget: pb.RangeRequest_MOD})
+		require.NoError(t, err)
+		require.Equal(t, []string{"/cfg/c", "/cfg/a", "/cfg/b"}, keysOf(resp.Kvs))
+
+		resp, err = kv.Range(ctx, &pb.RangeRequest{Key: []byte("/cfg"), RangeEnd: []byte{0}, KeysOnly: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"/cfg/a", "/cfg/b", "/cfg/c", "/cfg0", "k1"}, keysOf(resp.Kvs))
+		require.Nil(t, resp.Kvs[0].Value)
+
+		resp, err = kv.Range(ctx, &pb.RangeRequest{Key: []byte("/cfg/"), RangeEnd: []byte("/cfg0"), CountOnly: true})
+		require.NoError(t, err)
+		require.Empty(t, resp.Kvs)
+		require.Equal(t, int64(3), resp.Count)
+	})
+
+	t.Run("delete range", func(t *testing.T) {
+		created := get(t, ctx, kv, "/cfg/a").CreateRevision
+
+		resp, err := kv.DeleteRange(ctx, &pb.DeleteRangeRequest{Key: []byte("/cfg/a"), RangeEnd: []byte("/cfg/c"), PrevKv: true})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), resp.Deleted)
+		require.Equal(t, []string{"/cfg/a", "/cfg/b"}, keysOf(resp.PrevKvs))
+
+		require.Nil(t, get(t, ctx, kv, "/cfg/a"))
+		require.Nil(t, get(t, ctx, kv, "/cfg/b"))
+		require.NotNil(t, get(t, ctx, kv, "/cfg/c"))
+
+		resp, err = kv.DeleteRange(ctx, &pb.DeleteRangeRequest{Key: []byte("/cfg/a")})
+		require.NoError(t, err)
+		require.Zero(t, resp.Deleted)
+
+		rev := put(t, ctx, kv, "/cfg/a", "again")
+
+		kv1 := get(t, ctx, kv, "/cfg/a")
+		require.Equal(t, int64(1), kv1.Version)
+		require.Equal(t, rev, kv1.CreateRevision)
+		require.NotEqual(t, created, kv1.CreateRevision)
+	})
+
+	t.Run("txn", func(t *testing.T) {
+		createIfMissing := &pb.TxnRequest{
+			Compare: []*pb.Compare{{
+				Key:         []byte("/lock"),
+				Target:      pb.Compare_VERSION,
+				Result:      pb.Compare_EQUAL,
+				TargetUnion: &pb.Compare_Version{Version: 0},
+			}},
+			Success: []*pb.RequestOp{
+				{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("/lock"), Value: []byte("owner1")}}},
+				{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("/lock/owner"), Value: []byte("owner1")}}},
+				{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("/lock"), RangeEnd: []byte("/lock0")}}},
+			},
+			Failure: []*pb.RequestOp{
+				{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("/lock")}}},
+			},
+		}
+
+		resp, err := kv.Txn(ctx, createIfMissing)
+		require.NoError(t, err)
+		require.True(t, resp.Succeeded)
+		require.Len(t, resp.Responses, 3)
+
+		rev := resp.Header.Revision
+
+		// the reads of the txn see its writes, at the revision they were committed at
+		ranged := resp.Responses[2].GetResponseRange()
+		require.Equal(t, rev, ranged.Header.Revision)
+		require.Equal(t, []string{"/lock", "/lock/owner"}, keysOf(ranged.Kvs))
+		require.Equal(t, rev, ranged.Kvs[0].ModRevision)
+		require.Equal(t, rev, ranged.Kvs[0].CreateRevision)
+
+		require.Equal(t, rev, get(t, ctx, kv, "/lock").ModRevision)
+		require.Equal(t, rev, get(t, ctx, kv, "/lock/owner").ModRevision)
+
+		resp, err = kv.Txn(ctx, createIfMissing)
+		require.NoError(t, err)
+		require.False(t, resp.Succeeded)
+		require.Equal(t, rev, resp.Header.Revision)
+		require.Equal(t, []byte("owner1"), resp.Responses[0].GetResponseRange().Kvs[0].Value)
+
+		resp, err = kv.Txn(ctx, &pb.TxnRequest{
+			Compare: []*pb.Compare{{
+				Key:         []byte("/lock"),
+				Target:      pb.Compare_VALUE,
+				Result:      pb.Compare_EQUAL,
+				TargetUnion: &pb.Compare_Value{Value: []byte("owner1")},
+			}},
+			Success: []*pb.RequestOp{
+				{Request: &pb.RequestOp_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{Key: []byte("/lock"), RangeEnd: []byte("/lock0")}}},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Succeeded)
+		require.Equal(t, rev+1, resp.Header.Revision)
+		require.Equal(t, int64(2), resp.Responses[0].GetResponseDeleteRange().Deleted)
+		require.Nil(t, get(t, ctx, kv, "/lock"))
+
+		_, err = kv.Txn(ctx, &pb.TxnRequest{
+			Success: []*pb.RequestOp{
+				{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("dup"), Value: []byte("v1")}}},
+				{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("dup"), Value: []byte("v2")}}},
+			},
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Nil(t, get(t, ctx, kv, "dup"))
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		_, err := kv.Compact(ctx, &pb.CompactionRequest{Revision: 1})
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		wctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		w, err := pb.NewWatchClient(conn).Watch(wctx)
+		require.NoError(t, err)
+
+		err = w.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{CreateRequest: &pb.WatchCreateRequest{
+			Key:      []byte("/w/"),
+			RangeEnd: []byte("/w0"),
+			PrevKv:   true,
+		}}})
+		require.NoError(t, err)
+
+		created := recvWatch(t, w)
+		require.True(t, created.Created)
+		id := created.WatchId
+
+		rev1 := put(t, ctx, kv, "/w/1", "v1")
+		put(t, ctx, kv, "/other", "ignored")
+		rev2 := put(t, ctx, kv, "/w/1", "v2")
+
+		_, err = kv.DeleteRange(ctx, &pb.DeleteRangeRequest{Key: []byte("/w/1")})
+		require.NoError(t, err)
+
+		resp := recvWatch(t, w)
+		require.Equal(t, id, resp.WatchId)
+		require.Len(t, resp.Events, 1)
+		require.Equal(t, pb.Event_PUT, resp.Events[0].Type)
+		require.Equal(t, &pb.KeyValue{Key: []byte("/w/1"), Value: []byte("v1"), CreateRevision: rev1, ModRevision: rev1, Version: 1}, resp.Events[0].Kv)
+		require.Nil(t, resp.Events[0].PrevKv)
+
+		resp = recvWatch(t, w)
+		require.Equal(t, pb.Event_PUT, resp.Events[0].Type)
+		require.Equal(t, rev2, resp.Events[0].Kv.ModRevision)
+		require.Equal(t, int64(2), resp.Events[0].Kv.Version)
+		require.Equal(t, []byte("v1"), resp.Events[0].PrevKv.Value)
+
+		resp = recvWatch(t, w)
+		require.Equal(t, pb.Event_DELETE, resp.Events[0].Type)
+		require.Equal(t, []byte("/w/1"), resp.Events[0].Kv.Key)
+		require.Equal(t, []byte("v2"), resp.Events[0].PrevKv.Value)
+
+		// revisions are never compacted, past ones can be watched
+		err = w.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{CreateRequest: &pb.WatchCreateRequest{
+			Key:           []byte("/w/1"),
+			StartRevision: rev2,
+			Filters:       []pb.WatchCreateRequest_FilterType{pb.WatchCreateRequest_NODELETE},
+			WatchId:       100,
+		}}})
+		require.NoError(t, err)
+
+		created = recvWatch(t, w)
+		require.True(t, created.Created)
+		require.Equal(t, int64(100), created.WatchId)
+
+		resp = recvWatch(t, w)
+		require.Equal(t, int64(100), resp.WatchId)
+		require.Equal(t, rev2, resp.Header.Revision)
+		require.Equal(t, []byte("v2"), resp.Events[0].Kv.Value)
+
+		err = w.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CancelRequest{CancelRequest: &pb.WatchCancelRequest{WatchId: id}}})
+		require.NoError(t, err)
+
+		resp = recvWatch(t, w)
+		require.True(t, resp.Canceled)
+		require.Equal(t, id, resp.WatchId)
+
+		put(t, ctx, kv, "/w/1", "v3")
+
+		resp = recvWatch(t, w)
+		require.Equal(t, int64(100), resp.WatchId)
+		require.Equal(t, []byte("v3"), resp.Events[0].Kv.Value)
+
+		require.NoError(t, w.CloseSend())
+	})
+}
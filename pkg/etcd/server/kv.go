@@ -0,0 +1,451 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	pb "github.com/codenotary/immudb/pkg/etcd/etcdserverpb"
+)
+
+func (s *srv) Range(ctx context.Context, req *pb.RangeRequest) (*pb.RangeResponse, error) {
+	var resp *pb.RangeResponse
+
+	hdr, err := s.execute(ctx, accessRead, func(t *txn) (err error) {
+		resp, err = t.rangeOp(req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header = hdr
+
+	return resp, nil
+}
+
+func (s *srv) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutResponse, error) {
+	var resp *pb.PutResponse
+
+	hdr, err := s.execute(ctx, accessWrite, func(t *txn) (err error) {
+		resp, err = t.putOp(req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header = hdr
+
+	return resp, nil
+}
+
+func (s *srv) DeleteRange(ctx context.Context, req *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	var resp *pb.DeleteRangeResponse
+
+	hdr, err := s.execute(ctx, accessWrite, func(t *txn) (err error) {
+		resp, err = t.deleteRangeOp(req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header = hdr
+
+	return resp, nil
+}
+
+func (s *srv) Txn(ctx context.Context, req *pb.TxnRequest) (*pb.TxnResponse, error) {
+	var resp *pb.TxnResponse
+
+	hdr, err := s.execute(ctx, txnAccess(req), func(t *txn) (err error) {
+		resp, err = t.txnOp(req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header = hdr
+	setHeaders(resp.Responses, hdr)
+
+	return resp, nil
+}
+
+// Compact is not supported, immudb keeps all the revisions
+func (s *srv) Compact(ctx context.Context, req *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	return nil, ErrCompactUnsupported
+}
+
+// execute runs fn on a txn of the database selected by the request, committing the writes on behalf of the user.
+// Writes are serialized so that the compares of txn requests hold when their operations are committed.
+func (s *srv) execute(ctx context.Context, access access, fn func(t *txn) error) (*pb.ResponseHeader, error) {
+	usr, db, err := s.session(ctx, access)
+	if err != nil {
+		return nil, err
+	}
+
+	if access == accessWrite {
+		l := s.writeLock(db)
+		l.Lock()
+		defer l.Unlock()
+	}
+
+	t, err := newTxn(db)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fn(t)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.commit(&schema.TxMetadata{Writer: usr.Username})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.header(), nil
+}
+
+// txnAccess returns the access needed by the operations of the txn request, whichever branch is taken
+func txnAccess(req *pb.TxnRequest) access {
+	for _, ops := range [][]*pb.RequestOp{req.Success, req.Failure} {
+		for _, op := range ops {
+			switch r := op.Request.(type) {
+			case *pb.RequestOp_RequestPut, *pb.RequestOp_RequestDeleteRange:
+				return accessWrite
+			case *pb.RequestOp_RequestTxn:
+				if txnAccess(r.RequestTxn) == accessWrite {
+					return accessWrite
+				}
+			}
+		}
+	}
+	return accessRead
+}
+
+func setHeaders(resps []*pb.ResponseOp, hdr *pb.ResponseHeader) {
+	for _, resp := range resps {
+		switch r := resp.Response.(type) {
+		case *pb.ResponseOp_ResponseRange:
+			r.ResponseRange.Header = hdr
+		case *pb.ResponseOp_ResponsePut:
+			r.ResponsePut.Header = hdr
+		case *pb.ResponseOp_ResponseDeleteRange:
+			r.ResponseDeleteRange.Header = hdr
+		case *pb.ResponseOp_ResponseTxn:
+			r.ResponseTxn.Header = hdr
+			setHeaders(r.ResponseTxn.Responses, hdr)
+		}
+	}
+}
+
+func (t *txn) rangeOp(req *pb.RangeRequest) (*pb.RangeResponse, error) {
+	if len(req.Key) == 0 {
+		return nil, ErrKeyNotProvided
+	}
+	if req.Revision > int64(t.revision) {
+		return nil, ErrFutureRevision
+	}
+	if req.Revision > 0 && req.Revision < int64(t.revision) {
+		return nil, ErrPastRevision
+	}
+
+	kvs, err := t.getRange(req.Key, req.RangeEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	kvs = filterKVs(kvs, req)
+	sortKVs(kvs, req.SortOrder, req.SortTarget)
+
+	resp := &pb.RangeResponse{Count: int64(len(kvs))}
+
+	if req.Limit > 0 && int64(len(kvs)) > req.Limit {
+		kvs = kvs[:req.Limit]
+		resp.More = true
+	}
+
+	if req.CountOnly {
+		return resp, nil
+	}
+
+	resp.Kvs = kvs
+
+	if req.KeysOnly {
+		for i, kv := range kvs {
+			resp.Kvs[i] = t.withoutValue(kv)
+		}
+	}
+
+	return resp, nil
+}
+
+func (t *txn) putOp(req *pb.PutRequest) (*pb.PutResponse, error) {
+	if len(req.Key) == 0 {
+		return nil, ErrKeyNotProvided
+	}
+	if req.Lease != 0 {
+		return nil, ErrLeaseNotFound
+	}
+	if req.IgnoreValue && len(req.Value) > 0 {
+		return nil, ErrValueProvided
+	}
+
+	prev, err := t.get(req.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	value := req.Value
+
+	if req.IgnoreValue {
+		if prev == nil {
+			return nil, ErrKeyNotFound
+		}
+		value = prev.Value
+	}
+
+	_, err = t.put(req.Key, value, prev)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.PutResponse{}
+	if req.PrevKv {
+		resp.PrevKv = prev
+	}
+
+	return resp, nil
+}
+
+func (t *txn) deleteRangeOp(req *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	if len(req.Key) == 0 {
+		return nil, ErrKeyNotProvided
+	}
+
+	kvs, err := t.getRange(req.Key, req.RangeEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kv := range kvs {
+		err = t.delete(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &pb.DeleteRangeResponse{Deleted: int64(len(kvs))}
+	if req.PrevKv {
+		resp.PrevKvs = kvs
+	}
+
+	return resp, nil
+}
+
+// txnOp runs the success operations when all the compares hold, the failure ones otherwise.
+// Operations run in order, each one seeing the writes of the previous ones.
+func (t *txn) txnOp(req *pb.TxnRequest) (*pb.TxnResponse, error) {
+	succeeded := true
+
+	for _, c := range req.Compare {
+		ok, err := t.compare(c)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			succeeded = false
+			break
+		}
+	}
+
+	ops := req.Success
+	if !succeeded {
+		ops = req.Failure
+	}
+
+	resp := &pb.TxnResponse{
+		Succeeded: succeeded,
+		Responses: make([]*pb.ResponseOp, len(ops)),
+	}
+
+	for i, op := range ops {
+		r, err := t.op(op)
+		if err != nil {
+			return nil, err
+		}
+		resp.Responses[i] = r
+	}
+
+	return resp, nil
+}
+
+func (t *txn) op(op *pb.RequestOp) (*pb.ResponseOp, error) {
+	switch r := op.Request.(type) {
+	case *pb.RequestOp_RequestRange:
+		resp, err := t.rangeOp(r.RequestRange)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.ResponseOp{Response: &pb.ResponseOp_ResponseRange{ResponseRange: resp}}, nil
+	case *pb.RequestOp_RequestPut:
+		resp, err := t.putOp(r.RequestPut)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.ResponseOp{Response: &pb.ResponseOp_ResponsePut{ResponsePut: resp}}, nil
+	case *pb.RequestOp_RequestDeleteRange:
+		resp, err := t.deleteRangeOp(r.RequestDeleteRange)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.ResponseOp{Response: &pb.ResponseOp_ResponseDeleteRange{ResponseDeleteRange: resp}}, nil
+	case *pb.RequestOp_RequestTxn:
+		resp, err := t.txnOp(r.RequestTxn)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.ResponseOp{Response: &pb.ResponseOp_ResponseTxn{ResponseTxn: resp}}, nil
+	}
+
+	return nil, ErrUnknownOperation
+}
+
+// compare holds when all the keys of its range hold it. Keys not set are compared as key-values of version 0,
+// except for value compares which never hold.
+func (t *txn) compare(c *pb.Compare) (bool, error) {
+	if len(c.Key) == 0 {
+		return false, ErrKeyNotProvided
+	}
+
+	kvs, err := t.getRange(c.Key, c.RangeEnd)
+	if err != nil {
+		return false, err
+	}
+
+	if len(kvs) == 0 {
+		if c.Target == pb.Compare_VALUE {
+			return false, nil
+		}
+		return compareKV(c, &pb.KeyValue{}), nil
+	}
+
+	for _, kv := range kvs {
+		if !compareKV(c, kv) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func compareKV(c *pb.Compare, kv *pb.KeyValue) bool {
+	var r int
+
+	switch c.Target {
+	case pb.Compare_VERSION:
+		r = compareInt(kv.Version, c.GetVersion())
+	case pb.Compare_CREATE:
+		r = compareInt(kv.CreateRevision, c.GetCreateRevision())
+	case pb.Compare_MOD:
+		r = compareInt(kv.ModRevision, c.GetModRevision())
+	case pb.Compare_VALUE:
+		r = bytes.Compare(kv.Value, c.GetValue())
+	case pb.Compare_LEASE:
+		r = compareInt(kv.Lease, c.GetLease())
+	}
+
+	switch c.Result {
+	case pb.Compare_EQUAL:
+		return r == 0
+	case pb.Compare_NOT_EQUAL:
+		return r != 0
+	case pb.Compare_GREATER:
+		return r > 0
+	case pb.Compare_LESS:
+		return r < 0
+	}
+
+	return false
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func filterKVs(kvs []*pb.KeyValue, req *pb.RangeRequest) []*pb.KeyValue {
+	filtered := kvs[:0]
+
+	for _, kv := range kvs {
+		if (req.MinModRevision > 0 && kv.ModRevision < req.MinModRevision) ||
+			(req.MaxModRevision > 0 && kv.ModRevision > req.MaxModRevision) ||
+			(req.MinCreateRevision > 0 && kv.CreateRevision < req.MinCreateRevision) ||
+			(req.MaxCreateRevision > 0 && kv.CreateRevision > req.MaxCreateRevision) {
+			continue
+		}
+		filtered = append(filtered, kv)
+	}
+
+	return filtered
+}
+
+// sortKVs sorts key-values already sorted by key, sorting by any other target being ascending by default
+func sortKVs(kvs []*pb.KeyValue, order pb.RangeRequest_SortOrder, target pb.RangeRequest_SortTarget) {
+	if order == pb.RangeRequest_NONE {
+		if target == pb.RangeRequest_KEY {
+			return
+		}
+		order = pb.RangeRequest_ASCEND
+	}
+
+	var less func(a, b *pb.KeyValue) bool
+
+	switch target {
+	case pb.RangeRequest_KEY:
+		less = func(a, b *pb.KeyValue) bool { return bytes.Compare(a.Key, b.Key) < 0 }
+	case pb.RangeRequest_VERSION:
+		less = func(a, b *pb.KeyValue) bool { return a.Version < b.Version }
+	case pb.RangeRequest_CREATE:
+		less = func(a, b *pb.KeyValue) bool { return a.CreateRevision < b.CreateRevision }
+	case pb.RangeRequest_MOD:
+		less = func(a, b *pb.KeyValue) bool { return a.ModRevision < b.ModRevision }
+	case pb.RangeRequest_VALUE:
+		less = func(a, b *pb.KeyValue) bool { return bytes.Compare(a.Value, b.Value) < 0 }
+	default:
+		return
+	}
+
+	sort.SliceStable(kvs, func(i, j int) bool {
+		if order == pb.RangeRequest_DESCEND {
+			return less(kvs[j], kvs[i])
+		}
+		return less(kvs[i], kvs[j])
+	})
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	pb "github.com/codenotary/immudb/pkg/etcd/etcdserverpb"
+)
+
+// inRange tells whether key is in the range requested by etcd clients: the key itself when rangeEnd is empty,
+// all the keys greater than or equal to key when rangeEnd is "\x00", the keys in [key, rangeEnd) otherwise
+func inRange(key, start, rangeEnd []byte) bool {
+	if len(rangeEnd) == 0 {
+		return bytes.Equal(key, start)
+	}
+	if bytes.Compare(key, start) < 0 {
+		return false
+	}
+	return isUnbounded(rangeEnd) || bytes.Compare(key, rangeEnd) < 0
+}
+
+func isUnbounded(rangeEnd []byte) bool {
+	return len(rangeEnd) == 1 && rangeEnd[0] == 0
+}
+
+// rangePrefix returns the prefix shared by all the keys of the range
+func rangePrefix(start, rangeEnd []byte) []byte {
+	if len(rangeEnd) == 0 {
+		return start
+	}
+	if isUnbounded(rangeEnd) {
+		return nil
+	}
+
+	i := 0
+	for i < len(start) && i < len(rangeEnd) && start[i] == rangeEnd[i] {
+		i++
+	}
+	return start[:i]
+}
+
+// readRange returns the current key-values of the range, sorted by key
+func readRange(db database.DB, start, rangeEnd []byte) ([]*pb.KeyValue, error) {
+	var kvs []*pb.KeyValue
+
+	if !inRange(start, start, rangeEnd) {
+		return nil, nil
+	}
+
+	// the seek key of scans is excluded, the first key of the range is read on its own
+	kv, err := readKey(db, start)
+	if err != nil {
+		return nil, err
+	}
+	if kv != nil {
+		kvs = append(kvs, kv)
+	}
+
+	if len(rangeEnd) == 0 {
+		return kvs, nil
+	}
+
+	prefix := rangePrefix(start, rangeEnd)
+	seekKey := start
+
+	for {
+		page, err := db.Scan(&schema.ScanRequest{
+			SeekKey: seekKey,
+			Prefix:  prefix,
+			Limit:   database.MaxKeyScanLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range page.Entries {
+			if !inRange(e.Key, start, rangeEnd) {
+				return kvs, nil
+			}
+			if e.ReferencedBy != nil {
+				continue
+			}
+
+			kv, err := keyValueOf(db, e)
+			if err != nil {
+				return nil, err
+			}
+			kvs = append(kvs, kv)
+		}
+
+		if len(page.Entries) < database.MaxKeyScanLimit {
+			return kvs, nil
+		}
+
+		seekKey = page.Entries[len(page.Entries)-1].Key
+	}
+}
+
+// readKey returns the current key-value of key, nil when it is not set
+func readKey(db database.DB, key []byte) (*pb.KeyValue, error) {
+	e, err := db.Get(&schema.KeyRequest{Key: key})
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// references are immudb own keys, not set through the etcd API
+	if e.ReferencedBy != nil {
+		return nil, nil
+	}
+
+	return keyValueOf(db, e)
+}
+
+func keyValueOf(db database.DB, e *schema.Entry) (*pb.KeyValue, error) {
+	rev, err := revisionsOf(db, e.Key, e.Tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.KeyValue{
+		Key:            e.Key,
+		Value:          e.Value,
+		ModRevision:    int64(e.Tx),
+		CreateRevision: int64(rev.create),
+		Version:        rev.version,
+	}, nil
+}
+
+// revisions of a key at a transaction
+type revisions struct {
+	// create is the transaction the key was created in, since it was last deleted
+	create uint64
+	// version is the number of times the key was set since it was created, 0 when the key was deleted
+	version int64
+	// prev is the version of the key before the transaction, nil when it was not set
+	prev *schema.Entry
+}
+
+// revisionsOf walks the history of key backwards from the transaction txID, the version of the key at txID
+// being followed by the ones of the same life of the key, until it was last deleted
+func revisionsOf(db database.DB, key []byte, txID uint64) (*revisions, error) {
+	rev := &revisions{}
+
+	// versions is the number of versions of the key at or before txID walked so far
+	versions := 0
+
+	for offset := uint64(0); ; offset += database.MaxKeyScanLimit {
+		page, err := db.History(&schema.HistoryRequest{
+			Key:    key,
+			Offset: offset,
+			Limit:  database.MaxKeyScanLimit,
+			Desc:   true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range page.Entries {
+			if e.Tx > txID {
+				continue
+			}
+
+			deleted := e.Metadata != nil && e.Metadata.Deleted
+			versions++
+
+			if versions == 2 && !deleted {
+				rev.prev = e
+			}
+
+			if deleted || (versions > 1 && rev.version == 0) {
+				if versions > 1 {
+					return rev, nil
+				}
+				continue
+			}
+
+			rev.create = e.Tx
+			rev.version++
+		}
+
+		if len(page.Entries) < database.MaxKeyScanLimit {
+			return rev, nil
+		}
+	}
+}
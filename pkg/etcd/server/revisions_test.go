@@ -0,0 +1,51 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInRange(t *testing.T) {
+	for _, c := range []struct {
+		key, start, rangeEnd string
+		in                   bool
+	}{
+		{"a", "a", "", true},
+		{"ab", "a", "", false},
+		{"a", "a", "\x00", true},
+		{"zzz", "a", "\x00", true},
+		{"0", "a", "\x00", false},
+		{"a/1", "a/", "a0", true},
+		{"a0", "a/", "a0", false},
+		{"a", "a/", "a0", false},
+		{"b", "a", "c", true},
+		{"c", "a", "c", false},
+		{"b", "b", "a", false},
+	} {
+		require.Equal(t, c.in, inRange([]byte(c.key), []byte(c.start), []byte(c.rangeEnd)), "%q in [%q, %q)", c.key, c.start, c.rangeEnd)
+	}
+}
+
+func TestRangePrefix(t *testing.T) {
+	require.Equal(t, []byte("key"), rangePrefix([]byte("key"), nil))
+	require.Nil(t, rangePrefix([]byte("key"), []byte{0}))
+	require.Equal(t, []byte("/cfg"), rangePrefix([]byte("/cfg/"), []byte("/cfg0")))
+	require.Empty(t, rangePrefix([]byte("a"), []byte("b")))
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server implements the subset of the etcd v3 API needed to persist configuration, so that
+// tools and clients built for etcd can use immudb databases as their store.
+//
+// The KV service is fully served but for leases and compaction: revisions are the ids of the
+// transactions of the database, a txn request is committed as a single transaction and reads
+// of past revisions are served by the Watch service only, which can start from any revision
+// since immudb keeps all of them. Clients authenticate as immudb users through the Authenticate
+// method of the Auth service; the database is the default one unless selected by the "database"
+// gRPC metadata of the requests.
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/database"
+	pb "github.com/codenotary/immudb/pkg/etcd/etcdserverpb"
+	"github.com/codenotary/immudb/pkg/logger"
+	"golang.org/x/net/netutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// errors are the ones of etcd, clients match their messages
+var (
+	ErrUserEmpty          = status.Error(codes.InvalidArgument, "etcdserver: user name is empty")
+	ErrAuthFailed         = status.Error(codes.InvalidArgument, "etcdserver: authentication failed, invalid user ID or password")
+	ErrInvalidAuthToken   = status.Error(codes.Unauthenticated, "etcdserver: invalid auth token")
+	ErrPermissionDenied   = status.Error(codes.PermissionDenied, "etcdserver: permission denied")
+	ErrDatabaseNotFound   = status.Error(codes.NotFound, "etcdserver: database not found")
+	ErrKeyNotProvided     = status.Error(codes.InvalidArgument, "etcdserver: key is not provided")
+	ErrKeyNotFound        = status.Error(codes.InvalidArgument, "etcdserver: key not found")
+	ErrValueProvided      = status.Error(codes.InvalidArgument, "etcdserver: value is provided")
+	ErrLeaseNotFound      = status.Error(codes.NotFound, "etcdserver: requested lease not found")
+	ErrFutureRevision     = status.Error(codes.OutOfRange, "etcdserver: mvcc: required revision is a future revision")
+	ErrPastRevision       = status.Error(codes.Unimplemented, "etcdserver: past revisions can only be watched")
+	ErrCompactUnsupported = status.Error(codes.Unimplemented, "etcdserver: compaction is not supported, all the revisions are kept")
+	ErrUnknownOperation   = status.Error(codes.InvalidArgument, "etcdserver: unknown operation")
+	ErrDuplicateKey       = status.Error(codes.InvalidArgument, "etcdserver: duplicate key given in txn request")
+)
+
+type srv struct {
+	m               sync.RWMutex
+	maxConnections  int
+	tlsConfig       *tls.Config
+	Logger          logger.Logger
+	Address         string
+	Port            int
+	dbList          database.DatabaseList
+	sysDb           database.DB
+	defaultDatabase string
+	tokens          *tokens
+	listener        net.Listener
+	grpcServer      *grpc.Server
+
+	// writes are serialized per database, so that txn requests are evaluated and committed atomically
+	writeLocksMutex sync.Mutex
+	writeLocks      map[string]*sync.Mutex
+}
+
+type Server interface {
+	Initialize() error
+	Serve() error
+	Stop() error
+	GetPort() int
+	RevokeTokens(username string) int
+}
+
+func New(setters ...Option) *srv {
+
+	// Default Options
+	cli := &srv{
+		maxConnections:  1000,
+		tlsConfig:       &tls.Config{},
+		Logger:          logger.NewSimpleLogger("etcdSrv", os.Stderr),
+		Address:         "",
+		Port:            2379,
+		defaultDatabase: "defaultdb",
+		tokens:          newTokens(5 * time.Minute),
+		writeLocks:      make(map[string]*sync.Mutex),
+	}
+
+	for _, setter := range setters {
+		setter(cli)
+	}
+
+	return cli
+}
+
+// Initialize initialize listener. If provided port is zero os auto assign a free one.
+// Connections are secured with TLS when certificates are configured.
+func (s *srv) Initialize() (err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", s.Address, s.Port))
+	if err != nil {
+		return err
+	}
+
+	var opts []grpc.ServerOption
+
+	if s.tlsConfig != nil && len(s.tlsConfig.Certificates) > 0 {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+
+	s.grpcServer = grpc.NewServer(opts...)
+
+	pb.RegisterKVServer(s.grpcServer, s)
+	pb.RegisterWatchServer(s.grpcServer, s)
+	pb.RegisterAuthServer(s.grpcServer, s)
+
+	return nil
+}
+
+func (s *srv) Serve() error {
+	s.m.Lock()
+	if s.listener == nil {
+		s.m.Unlock()
+		return errors.New("no listener found for etcd server")
+	}
+
+	listener := netutil.LimitListener(s.listener, s.maxConnections)
+	grpcServer := s.grpcServer
+	s.m.Unlock()
+
+	err := grpcServer.Serve(listener)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Stop closes the listener and all the connections, watches included
+func (s *srv) Stop() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+		return nil
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+func (s *srv) GetPort() int {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	if s.listener != nil {
+		return s.listener.Addr().(*net.TCPAddr).Port
+	}
+	return 0
+}
+
+// writeLock returns the lock serializing the writes on the database
+func (s *srv) writeLock(db database.DB) *sync.Mutex {
+	s.writeLocksMutex.Lock()
+	defer s.writeLocksMutex.Unlock()
+
+	l, ok := s.writeLocks[db.GetName()]
+	if !ok {
+		l = &sync.Mutex{}
+		s.writeLocks[db.GetName()] = l
+	}
+	return l
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	pb "github.com/codenotary/immudb/pkg/etcd/etcdserverpb"
+)
+
+// txn applies requests on a database. Writes are buffered so that they are committed in a single transaction,
+// the reads following them seeing the buffered values.
+type txn struct {
+	db       database.DB
+	revision uint64
+
+	writes  map[string]*write
+	written [][]byte
+
+	// kvs are the buffered key-values returned by the requests, their revisions are known once committed
+	kvs []*pendingKV
+}
+
+type write struct {
+	kv *schema.KeyValue
+	// current is the key-value read after the write, nil when the key was deleted
+	current *pb.KeyValue
+}
+
+type pendingKV struct {
+	kv      *pb.KeyValue
+	created bool
+}
+
+func newTxn(db database.DB) (*txn, error) {
+	state, err := db.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	return &txn{
+		db:       db,
+		revision: state.TxId,
+		writes:   make(map[string]*write),
+	}, nil
+}
+
+// commit writes the buffered key-values in a single transaction and sets the revisions of the key-values
+// returned by the requests. The revision of the txn is the transaction, the current one when nothing was written.
+func (t *txn) commit(md *schema.TxMetadata) error {
+	if len(t.written) == 0 {
+		return nil
+	}
+
+	kvs := make([]*schema.KeyValue, len(t.written))
+	for i, key := range t.written {
+		kvs[i] = t.writes[string(key)].kv
+	}
+
+	hdr, err := t.db.Set(&schema.SetRequest{KVs: kvs, TxMetadata: md})
+	if err != nil {
+		return err
+	}
+
+	t.revision = hdr.Id
+
+	for _, p := range t.kvs {
+		p.kv.ModRevision = int64(hdr.Id)
+		if p.created {
+			p.kv.CreateRevision = int64(hdr.Id)
+		}
+	}
+
+	return nil
+}
+
+func (t *txn) header() *pb.ResponseHeader {
+	return &pb.ResponseHeader{Revision: int64(t.revision)}
+}
+
+// get returns the key-value of key, nil when not set
+func (t *txn) get(key []byte) (*pb.KeyValue, error) {
+	if w, ok := t.writes[string(key)]; ok {
+		return w.current, nil
+	}
+	return readKey(t.db, key)
+}
+
+// getRange returns the key-values of the range, sorted by key
+func (t *txn) getRange(key, rangeEnd []byte) ([]*pb.KeyValue, error) {
+	if len(rangeEnd) == 0 {
+		kv, err := t.get(key)
+		if kv == nil || err != nil {
+			return nil, err
+		}
+		return []*pb.KeyValue{kv}, nil
+	}
+
+	kvs, err := readRange(t.db, key, rangeEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.writes) == 0 {
+		return kvs, nil
+	}
+
+	merged := kvs[:0]
+	for _, kv := range kvs {
+		if _, ok := t.writes[string(kv.Key)]; !ok {
+			merged = append(merged, kv)
+		}
+	}
+
+	for _, w := range t.writes {
+		if w.current != nil && inRange(w.current.Key, key, rangeEnd) {
+			merged = append(merged, w.current)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return bytes.Compare(merged[i].Key, merged[j].Key) < 0
+	})
+
+	return merged, nil
+}
+
+// withoutValue returns a copy of kv without the value, the revisions of a buffered key-value being set on commit
+func (t *txn) withoutValue(kv *pb.KeyValue) *pb.KeyValue {
+	c := &pb.KeyValue{
+		Key:            kv.Key,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Version:        kv.Version,
+		Lease:          kv.Lease,
+	}
+
+	if kv.ModRevision == 0 {
+		t.kvs = append(t.kvs, &pendingKV{kv: c, created: kv.CreateRevision == 0})
+	}
+
+	return c
+}
+
+// put buffers the write of value, prev being the current key-value of key
+func (t *txn) put(key, value []byte, prev *pb.KeyValue) (*pb.KeyValue, error) {
+	kv := &pb.KeyValue{Key: key, Value: value, Version: 1}
+	if prev != nil {
+		kv.CreateRevision = prev.CreateRevision
+		kv.Version = prev.Version + 1
+	}
+
+	err := t.buffer(key, &write{
+		kv:      &schema.KeyValue{Key: key, Value: value},
+		current: kv,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.kvs = append(t.kvs, &pendingKV{kv: kv, created: prev == nil})
+
+	return kv, nil
+}
+
+// delete buffers the deletion of key
+func (t *txn) delete(key []byte) error {
+	return t.buffer(key, &write{
+		kv: &schema.KeyValue{Key: key, Metadata: &schema.KVMetadata{Deleted: true}},
+	})
+}
+
+// buffer fails when the key was already written, a key is written at most once per transaction
+func (t *txn) buffer(key []byte, w *write) error {
+	if _, ok := t.writes[string(key)]; ok {
+		return ErrDuplicateKey
+	}
+
+	t.written = append(t.written, key)
+	t.writes[string(key)] = w
+
+	return nil
+}
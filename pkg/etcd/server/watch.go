@@ -0,0 +1,317 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	pb "github.com/codenotary/immudb/pkg/etcd/etcdserverpb"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+// watchStream serves the watches created on a stream, each one by its own goroutine
+type watchStream struct {
+	db     database.DB
+	stream pb.Watch_WatchServer
+	log    logger.Logger
+
+	sendMutex sync.Mutex
+
+	mutex    sync.Mutex
+	watchers map[int64]chan struct{}
+	nextID   int64
+	wg       sync.WaitGroup
+}
+
+// Watch streams the changes of the keys watched, from any revision. Watches are canceled when the stream ends.
+func (s *srv) Watch(stream pb.Watch_WatchServer) error {
+	_, db, err := s.session(stream.Context(), accessRead)
+	if err != nil {
+		return err
+	}
+
+	ws := &watchStream{
+		db:       db,
+		stream:   stream,
+		log:      s.Logger,
+		watchers: make(map[int64]chan struct{}),
+	}
+	defer ws.cancelAll()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch r := req.RequestUnion.(type) {
+		case *pb.WatchRequest_CreateRequest:
+			err = ws.create(r.CreateRequest)
+		case *pb.WatchRequest_CancelRequest:
+			err = ws.cancel(r.CancelRequest.WatchId)
+		case *pb.WatchRequest_ProgressRequest:
+			err = ws.progress()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (ws *watchStream) create(req *pb.WatchCreateRequest) error {
+	state, err := ws.db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	hdr := &pb.ResponseHeader{Revision: int64(state.TxId)}
+
+	// revisions are never compacted, watches can start from any of them
+	startTx := state.TxId + 1
+	if req.StartRevision > 0 {
+		startTx = uint64(req.StartRevision)
+	}
+
+	ws.mutex.Lock()
+
+	id := req.WatchId
+	if id == 0 {
+		for {
+			id = ws.nextID
+			ws.nextID++
+			if _, ok := ws.watchers[id]; !ok {
+				break
+			}
+		}
+	} else if _, ok := ws.watchers[id]; ok {
+		ws.mutex.Unlock()
+
+		return ws.send(&pb.WatchResponse{
+			Header:       hdr,
+			WatchId:      -1,
+			Created:      true,
+			Canceled:     true,
+			CancelReason: "mvcc: duplicate watch ID provided on the WatchStream",
+		})
+	}
+
+	cancel := make(chan struct{})
+	ws.watchers[id] = cancel
+
+	ws.mutex.Unlock()
+
+	// the created response is sent before any event of the watch
+	err = ws.send(&pb.WatchResponse{Header: hdr, WatchId: id, Created: true})
+	if err != nil {
+		return err
+	}
+
+	ws.wg.Add(1)
+	go ws.watch(id, req, startTx, cancel)
+
+	return nil
+}
+
+func (ws *watchStream) cancel(id int64) error {
+	if !ws.remove(id) {
+		return nil
+	}
+
+	state, err := ws.db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	return ws.send(&pb.WatchResponse{
+		Header:   &pb.ResponseHeader{Revision: int64(state.TxId)},
+		WatchId:  id,
+		Canceled: true,
+	})
+}
+
+func (ws *watchStream) progress() error {
+	state, err := ws.db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	return ws.send(&pb.WatchResponse{
+		Header:  &pb.ResponseHeader{Revision: int64(state.TxId)},
+		WatchId: -1,
+	})
+}
+
+// remove stops the watch, returning false if it was already stopped
+func (ws *watchStream) remove(id int64) bool {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	cancel, ok := ws.watchers[id]
+	if ok {
+		close(cancel)
+		delete(ws.watchers, id)
+	}
+
+	return ok
+}
+
+func (ws *watchStream) cancelAll() {
+	ws.mutex.Lock()
+	for id, cancel := range ws.watchers {
+		close(cancel)
+		delete(ws.watchers, id)
+	}
+	ws.mutex.Unlock()
+
+	ws.wg.Wait()
+}
+
+func (ws *watchStream) send(resp *pb.WatchResponse) error {
+	ws.sendMutex.Lock()
+	defer ws.sendMutex.Unlock()
+
+	return ws.stream.Send(resp)
+}
+
+// watch sends the events of the transactions from txID on, until the watch is canceled
+func (ws *watchStream) watch(id int64, req *pb.WatchCreateRequest, txID uint64, cancel chan struct{}) {
+	defer ws.wg.Done()
+
+	for {
+		err := ws.db.WaitForTx(txID, cancel)
+		if isCanceled(cancel) {
+			return
+		}
+
+		var events []*pb.Event
+		if err == nil {
+			events, err = eventsOf(ws.db, txID, req)
+		}
+		if err != nil {
+			ws.log.Warningf("etcd watch %d on database %s canceled: %v", id, ws.db.GetName(), err)
+
+			if ws.remove(id) {
+				ws.send(&pb.WatchResponse{
+					Header:       &pb.ResponseHeader{Revision: int64(txID - 1)},
+					WatchId:      id,
+					Canceled:     true,
+					CancelReason: err.Error(),
+				})
+			}
+			return
+		}
+
+		if len(events) > 0 {
+			err = ws.send(&pb.WatchResponse{
+				Header:  &pb.ResponseHeader{Revision: int64(txID)},
+				WatchId: id,
+				Events:  events,
+			})
+			if err != nil {
+				return
+			}
+		}
+
+		txID++
+	}
+}
+
+func isCanceled(cancel chan struct{}) bool {
+	select {
+	case <-cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// eventsOf returns the events of the transaction on the keys watched
+func eventsOf(db database.DB, txID uint64, req *pb.WatchCreateRequest) ([]*pb.Event, error) {
+	tx, err := db.TxByID(&schema.TxRequest{Tx: txID})
+	if err != nil {
+		return nil, err
+	}
+
+	noPut, noDelete := false, false
+	for _, f := range req.Filters {
+		switch f {
+		case pb.WatchCreateRequest_NOPUT:
+			noPut = true
+		case pb.WatchCreateRequest_NODELETE:
+			noDelete = true
+		}
+	}
+
+	var events []*pb.Event
+
+	for _, e := range tx.Entries {
+		if len(e.Key) == 0 || e.Key[0] != database.SetKeyPrefix {
+			continue
+		}
+
+		key := database.TrimPrefix(e.Key)
+		if !inRange(key, req.Key, req.RangeEnd) {
+			continue
+		}
+
+		deleted := e.Metadata != nil && e.Metadata.Deleted
+		if (deleted && noDelete) || (!deleted && noPut) {
+			continue
+		}
+
+		rev, err := revisionsOf(db, key, txID)
+		if err != nil {
+			return nil, err
+		}
+
+		ev := &pb.Event{Type: pb.Event_DELETE, Kv: &pb.KeyValue{Key: key, ModRevision: int64(txID)}}
+
+		if !deleted {
+			entry, err := db.Get(&schema.KeyRequest{Key: key, AtTx: txID})
+			if err != nil {
+				return nil, err
+			}
+
+			// references are immudb own keys, not set through the etcd API
+			if entry.ReferencedBy != nil {
+				continue
+			}
+
+			ev.Type = pb.Event_PUT
+			ev.Kv.Value = entry.Value
+			ev.Kv.CreateRevision = int64(rev.create)
+			ev.Kv.Version = rev.version
+		}
+
+		if req.PrevKv && rev.prev != nil {
+			ev.PrevKv, err = keyValueOf(db, rev.prev)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
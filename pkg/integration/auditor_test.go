@@ -62,6 +62,7 @@ func TestDefaultAuditorRunOnEmptyDb(t *testing.T) {
 	var clientConn *grpc.ClientConn
 	clientConn, err := grpc.Dial("add", ds...)
 	require.NoError(t, err)
+	defer clientConn.Close()
 	serviceClient := schema.NewImmuServiceClient(clientConn)
 
 	da, err := auditor.DefaultAuditor(
@@ -122,6 +123,7 @@ func TestDefaultAuditorRunOnDb(t *testing.T) {
 	cliopt.DialOptions = dialOptions
 
 	cli, _ := client.NewImmuClient(cliopt)
+	defer cli.Disconnect()
 	cli.WithTokenService(ts)
 	lresp, err := cli.Login(ctx, []byte("immudb"), []byte("immudb"))
 	require.NoError(t, err)
@@ -139,6 +141,7 @@ func TestDefaultAuditorRunOnDb(t *testing.T) {
 	var clientConn *grpc.ClientConn
 	clientConn, err = grpc.Dial("add", ds...)
 	require.NoError(t, err)
+	defer clientConn.Close()
 	serviceClient := schema.NewImmuServiceClient(clientConn)
 
 	da, err := auditor.DefaultAuditor(
@@ -188,6 +191,7 @@ func TestRepeatedAuditorRunOnDb(t *testing.T) {
 	cliopt.DialOptions = dialOptions
 
 	cli, _ := client.NewImmuClient(cliopt)
+	defer cli.Disconnect()
 	cli.WithTokenService(ts)
 	lresp, err := cli.Login(ctx, []byte("immudb"), []byte("immudb"))
 	require.NoError(t, err)
@@ -205,6 +209,7 @@ func TestRepeatedAuditorRunOnDb(t *testing.T) {
 	var clientConn *grpc.ClientConn
 	clientConn, err = grpc.Dial("add", ds...)
 	require.NoError(t, err)
+	defer clientConn.Close()
 	serviceClient := schema.NewImmuServiceClient(clientConn)
 
 	alertConfig := auditor.AuditNotificationConfig{
@@ -288,6 +293,7 @@ func testDefaultAuditorRunOnDbWithSignature(t *testing.T, pk *ecdsa.PublicKey) {
 	cliopt.DialOptions = dialOptions
 
 	cli, _ := client.NewImmuClient(cliopt)
+	defer cli.Disconnect()
 	cli.WithTokenService(ts)
 	lresp, err := cli.Login(ctx, []byte("immudb"), []byte("immudb"))
 	require.NoError(t, err)
@@ -305,6 +311,7 @@ func testDefaultAuditorRunOnDbWithSignature(t *testing.T, pk *ecdsa.PublicKey) {
 	var clientConn *grpc.ClientConn
 	clientConn, err = grpc.Dial("add", ds...)
 	require.NoError(t, err)
+	defer clientConn.Close()
 	serviceClient := schema.NewImmuServiceClient(clientConn)
 
 	da, err := auditor.DefaultAuditor(
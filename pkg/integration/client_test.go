@@ -16,8 +16,13 @@ limitations under the License.
 package integration
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"testing"
@@ -38,7 +43,10 @@ import (
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/proofs"
 	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/codenotary/immudb/pkg/verifier"
 	"github.com/stretchr/testify/require"
 
 	"google.golang.org/grpc"
@@ -238,6 +246,7 @@ func TestImmuClient(t *testing.T) {
 	client, err := ic.NewImmuClient(opts.WithServerSigningPubKey("./../../test/signer/ec1.pub"))
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 	require.NoError(t, err)
+	defer client.Disconnect()
 	resp, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
 	md := metadata.Pairs("authorization", resp.Token)
@@ -277,6 +286,7 @@ func TestImmuClientTampering(t *testing.T) {
 	opts := ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
 	client, err := ic.NewImmuClient(opts.WithServerSigningPubKey("./../../test/signer/ec1.pub"))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 	resp, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -422,6 +432,7 @@ func TestReplica(t *testing.T) {
 
 	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -468,6 +479,7 @@ func TestDatabasesSwitching(t *testing.T) {
 		ic.DefaultOptions().
 			WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
@@ -529,6 +541,7 @@ func TestDatabasesSwitchingWithInMemoryToken(t *testing.T) {
 		ic.DefaultOptions().
 			WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 	_, err = client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -595,6 +608,16 @@ func TestImmuClientDisconnect(t *testing.T) {
 	require.True(t, errors.Is(client.UpdateAuthConfig(ctx, auth.KindPassword), ic.ErrNotConnected))
 	require.True(t, errors.Is(client.UpdateMTLSConfig(ctx, false), ic.ErrNotConnected))
 	require.True(t, errors.Is(client.CompactIndex(ctx, &emptypb.Empty{}), ic.ErrNotConnected))
+	require.True(t, errors.Is(client.RebuildIndex(ctx, nil), ic.ErrNotConnected))
+	require.True(t, errors.Is(client.CompactDatabase(ctx, nil, nil), ic.ErrNotConnected))
+	_, err = client.GetRuntimeConfig(ctx, "")
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+	_, err = client.SetRuntimeConfig(ctx, "log-level", "debug")
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+	require.True(t, errors.Is(client.Backup(ctx, nil, nil), ic.ErrNotConnected))
+	require.True(t, errors.Is(client.Export(ctx, nil), ic.ErrNotConnected))
+	_, err = client.Import(ctx, "db", nil)
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
 
 	_, err = client.Login(context.TODO(), []byte("user"), []byte("passwd"))
 	require.True(t, errors.Is(err.(immuErrors.ImmuError), ic.ErrNotConnected))
@@ -662,8 +685,16 @@ func TestImmuClientDisconnect(t *testing.T) {
 	_, err = client.VerifiedZAdd(context.TODO(), []byte("set"), 1, []byte("key"))
 	require.True(t, errors.Is(err, ic.ErrNotConnected))
 
-	//_, err = client.Dump(context.TODO(), nil)
-	//require.Equal(t, ic.ErrNotConnected, err)
+	_, err = client.Dump(context.TODO(), nil)
+	require.True(t, errors.Is(err, ic.ErrIllegalArguments))
+
+	f, err := ioutil.TempFile("", "dump")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	_, err = client.Dump(context.TODO(), f)
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
 
 	_, err = client.GetSince(context.TODO(), []byte("key"), 0)
 	require.True(t, errors.Is(err, ic.ErrNotConnected))
@@ -674,6 +705,21 @@ func TestImmuClientDisconnect(t *testing.T) {
 	require.True(t, errors.Is(client.HealthCheck(context.TODO()), ic.ErrNotConnected))
 
 	require.True(t, errors.Is(client.CreateDatabase(context.TODO(), nil), ic.ErrNotConnected))
+	require.True(t, errors.Is(client.CloneDatabase(context.TODO(), nil), ic.ErrNotConnected))
+	require.True(t, errors.Is(client.SetBackupSchedule(context.TODO(), nil), ic.ErrNotConnected))
+	require.True(t, errors.Is(client.DeleteBackupSchedule(context.TODO(), nil), ic.ErrNotConnected))
+
+	_, err = client.ListBackupSchedules(context.TODO())
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+
+	require.True(t, errors.Is(client.SetRetentionPolicy(context.TODO(), nil), ic.ErrNotConnected))
+	require.True(t, errors.Is(client.DeleteRetentionPolicy(context.TODO(), nil), ic.ErrNotConnected))
+
+	_, err = client.ListRetentionPolicies(context.TODO())
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+
+	_, err = client.ListRetentionEnforcements(context.TODO(), nil)
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
 
 	_, err = client.UseDatabase(context.TODO(), nil)
 	require.True(t, errors.Is(err, ic.ErrNotConnected))
@@ -719,6 +765,7 @@ func TestWaitForHealthCheck(t *testing.T) {
 
 	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 	err = client.WaitForHealthCheck(context.TODO())
 	require.Nil(t, err)
@@ -910,7 +957,7 @@ func TestImmuClient_History(t *testing.T) {
 	client.Disconnect()
 }
 
-func TestImmuClient_SetAll(t *testing.T) {
+func TestImmuClient_RebuildIndex(t *testing.T) {
 	options := server.DefaultOptions().WithAuth(true)
 	bs := servertest.NewBufconnServer(options)
 
@@ -928,43 +975,30 @@ func TestImmuClient_SetAll(t *testing.T) {
 	md := metadata.Pairs("authorization", lr.Token)
 	ctx := metadata.NewOutgoingContext(context.Background(), md)
 
-	_, err = client.SetAll(ctx, nil)
-	require.Error(t, err)
-
-	setRequest := &schema.SetRequest{KVs: []*schema.KeyValue{}}
-	_, err = client.SetAll(ctx, setRequest)
-	require.Error(t, err)
-
-	setRequest = &schema.SetRequest{KVs: []*schema.KeyValue{
-		{Key: []byte("1,2,3"), Value: []byte("3,2,1")},
-		{Key: []byte("4,5,6"), Value: []byte("6,5,4"), Metadata: &schema.KVMetadata{NonIndexeable: true}},
-	}}
+	for i := 0; i < 10; i++ {
+		_, err = client.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
 
-	_, err = client.SetAll(ctx, setRequest)
-	require.NoError(t, err)
+	var last *schema.IndexRebuildProgress
 
-	err = client.CompactIndex(ctx, &emptypb.Empty{})
+	err = client.RebuildIndex(ctx, func(p *schema.IndexRebuildProgress) {
+		last = p
+	})
 	require.NoError(t, err)
+	require.NotNil(t, last)
+	require.Equal(t, last.TargetTx, last.IndexedTx)
 
-	for _, kv := range setRequest.KVs {
-		i, err := client.Get(ctx, kv.Key)
-
-		if kv.Metadata != nil && kv.Metadata.NonIndexeable {
-			require.Contains(t, err.Error(), "key not found")
-		} else {
-			require.NoError(t, err)
-			require.Equal(t, kv.Value, i.GetValue())
-		}
+	for i := 0; i < 10; i++ {
+		entry, err := client.Get(ctx, []byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), entry.Value)
 	}
 
-	err = client.Disconnect()
-	require.NoError(t, err)
-
-	_, err = client.SetAll(ctx, setRequest)
-	require.True(t, errors.Is(err, ic.ErrNotConnected))
+	client.Disconnect()
 }
 
-func TestImmuClient_GetAll(t *testing.T) {
+func TestImmuClient_CompactDatabase(t *testing.T) {
 	options := server.DefaultOptions().WithAuth(true)
 	bs := servertest.NewBufconnServer(options)
 
@@ -974,33 +1008,82 @@ func TestImmuClient_GetAll(t *testing.T) {
 	bs.Start()
 	defer bs.Stop()
 
-	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
+
+	ctx := context.Background()
+
+	err := client.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
-	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 
-	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	err = client.CreateDatabase(ctx, &schema.DatabaseSettings{
+		DatabaseName: "db1",
+		IndexSettings: &schema.IndexSettings{
+			FlushThreshold: 10,
+			CompactionThld: 1,
+		},
+	})
 	require.NoError(t, err)
-	md := metadata.Pairs("authorization", lr.Token)
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
 
-	_, err = client.VerifiedSet(ctx, []byte(`aaa`), []byte(`val`))
+	err = client.CreateDatabase(ctx, &schema.DatabaseSettings{
+		DatabaseName:  "db2",
+		IndexSettings: &schema.IndexSettings{CompactionThld: 100},
+	})
 	require.NoError(t, err)
 
-	entries, err := client.GetAll(ctx, [][]byte{[]byte(`aaa`), []byte(`bbb`)})
+	err = client.CloseSession(ctx)
 	require.NoError(t, err)
-	require.Len(t, entries.Entries, 1)
 
-	_, err = client.VerifiedSet(ctx, []byte(`bbb`), []byte(`val`))
+	err = client.Disconnect()
 	require.NoError(t, err)
 
-	entries, err = client.GetAll(ctx, [][]byte{[]byte(`aaa`), []byte(`bbb`)})
+	err = client.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "db1")
 	require.NoError(t, err)
-	require.Len(t, entries.Entries, 2)
+	defer client.CloseSession(ctx)
 
-	client.Disconnect()
+	for i := 0; i < 30; i++ {
+		_, err = client.Set(ctx, []byte(fmt.Sprintf("key%d", i%5)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	var progress []*schema.CompactionProgress
+
+	err = client.CompactDatabase(ctx, &schema.CompactDatabaseRequest{Database: "db1", DryRun: true}, func(p *schema.CompactionProgress) {
+		progress = append(progress, p)
+	})
+	require.NoError(t, err)
+	require.Len(t, progress, 1)
+	require.True(t, progress[0].ThresholdReached)
+	require.Greater(t, progress[0].IndexSize, int64(0))
+	require.Greater(t, progress[0].CompactedIndexSize, int64(0))
+	require.Zero(t, progress[0].DumpedBytes)
+
+	progress = nil
+
+	err = client.CompactDatabase(ctx, &schema.CompactDatabaseRequest{Database: "db1"}, func(p *schema.CompactionProgress) {
+		progress = append(progress, p)
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(progress), 2)
+
+	last := progress[len(progress)-1]
+	require.Equal(t, last.CompactedIndexSize, last.DumpedBytes)
+
+	for i := 25; i < 30; i++ {
+		entry, err := client.Get(ctx, []byte(fmt.Sprintf("key%d", i%5)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), entry.Value)
+	}
+
+	err = client.CompactDatabase(ctx, &schema.CompactDatabaseRequest{Database: "db2"}, nil)
+	require.Error(t, err)
+	require.Equal(t, immuErrors.CodObjectNotInPrerequisiteState, err.(immuErrors.ImmuError).Code())
+
+	err = client.CompactDatabase(ctx, &schema.CompactDatabaseRequest{Database: "nonexistent"}, nil)
+	require.Error(t, err)
 }
 
-func TestImmuClient_Delete(t *testing.T) {
+func TestImmuClient_CollectGarbage(t *testing.T) {
 	options := server.DefaultOptions().WithAuth(true)
 	bs := servertest.NewBufconnServer(options)
 
@@ -1010,54 +1093,52 @@ func TestImmuClient_Delete(t *testing.T) {
 	bs.Start()
 	defer bs.Stop()
 
-	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
-	client.WithTokenService(tokenservice.NewInmemoryTokenService())
-	require.NoError(t, err)
+	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
-	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	ctx := context.Background()
+
+	err := client.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
 
-	md := metadata.Pairs("authorization", lr.Token)
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	err = client.CreateDatabase(ctx, &schema.DatabaseSettings{DatabaseName: "db1", GcSafetyWindow: 1})
+	require.NoError(t, err)
 
-	_, err = client.Delete(ctx, nil)
-	require.Error(t, err)
+	err = client.CloseSession(ctx)
+	require.NoError(t, err)
 
-	deleteRequest := &schema.DeleteKeysRequest{}
-	_, err = client.Delete(ctx, deleteRequest)
-	require.Error(t, err)
+	err = client.Disconnect()
+	require.NoError(t, err)
 
-	_, err = client.Set(ctx, []byte("1,2,3"), []byte("3,2,1"))
+	err = client.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "db1")
 	require.NoError(t, err)
+	defer client.CloseSession(ctx)
 
-	i, err := client.Get(ctx, []byte("1,2,3"))
+	_, err = client.Set(ctx, []byte("key1"), []byte("value1"))
 	require.NoError(t, err)
-	require.Equal(t, []byte("3,2,1"), i.GetValue())
 
-	_, err = client.ExpirableSet(ctx, []byte("expirableKey"), []byte("expirableValue"), time.Now())
+	_, err = client.Set(ctx, []byte("key1"), []byte("value2"))
 	require.NoError(t, err)
 
-	_, err = client.Get(ctx, []byte("expirableKey"))
-	require.Error(t, err)
-	require.Contains(t, err.Error(), "key not found")
+	// commit times have a resolution of a second
+	time.Sleep(1500 * time.Millisecond)
 
-	deleteRequest.Keys = append(deleteRequest.Keys, []byte("1,2,3"))
-	_, err = client.Delete(ctx, deleteRequest)
+	res, err := client.CollectGarbage(ctx, &schema.CollectGarbageRequest{Database: "db1"})
+	if err != nil && err.(immuErrors.ImmuError).Code() == immuErrors.CodFeatureNotSupported {
+		t.Skip("punching holes is not supported by the underlying file system")
+	}
 	require.NoError(t, err)
+	require.Positive(t, res.ReclaimedBytes)
 
-	_, err = client.Get(ctx, []byte("1,2,3"))
-	require.Error(t, err)
-	require.Contains(t, err.Error(), "key not found")
+	entry, err := client.Get(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), entry.Value)
 
-	_, err = client.Delete(ctx, deleteRequest)
+	_, err = client.CollectGarbage(ctx, &schema.CollectGarbageRequest{Database: "nonexistent"})
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "key not found")
-
-	err = client.Disconnect()
-	require.NoError(t, err)
 }
 
-func TestImmuClient_ExecAllOpsOptions(t *testing.T) {
+func TestImmuClient_RuntimeConfig(t *testing.T) {
 	options := server.DefaultOptions().WithAuth(true)
 	bs := servertest.NewBufconnServer(options)
 
@@ -1067,36 +1148,37 @@ func TestImmuClient_ExecAllOpsOptions(t *testing.T) {
 	bs.Start()
 	defer bs.Stop()
 
-	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
+
+	ctx := context.Background()
+
+	err := client.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
-	client.WithTokenService(tokenservice.NewInmemoryTokenService())
-	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	defer client.CloseSession(ctx)
+
+	config, err := client.GetRuntimeConfig(ctx, "")
 	require.NoError(t, err)
-	md := metadata.Pairs("authorization", lr.Token)
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	require.NotEmpty(t, config.Settings)
 
-	aOps := &schema.ExecAllRequest{
-		Operations: []*schema.Op{
-			{
-				Operation: &schema.Op_Kv{
-					Kv: &schema.KeyValue{
-						Key:   []byte(`key`),
-						Value: []byte(`val`),
-					},
-				},
-			},
-		},
-	}
+	setting, err := client.SetRuntimeConfig(ctx, "max-replication-rate", "1024")
+	require.NoError(t, err)
+	require.Equal(t, "max-replication-rate", setting.Key)
+	require.Equal(t, "1024", setting.Value)
 
-	idx, err := client.ExecAll(ctx, aOps)
+	config, err = client.GetRuntimeConfig(ctx, "max-replication-rate")
+	require.NoError(t, err)
+	require.Len(t, config.Settings, 1)
+	require.Equal(t, "1024", config.Settings[0].Value)
 
-	require.Nil(t, err)
-	require.NotNil(t, idx)
+	_, err = client.SetRuntimeConfig(ctx, "max-replication-rate", "fast")
+	require.Error(t, err)
 
-	client.Disconnect()
+	_, err = client.GetRuntimeConfig(ctx, "unknown")
+	require.Error(t, err)
 }
 
-func TestImmuClient_Scan(t *testing.T) {
+func TestImmuClient_Backup(t *testing.T) {
 	options := server.DefaultOptions().WithAuth(true)
 	bs := servertest.NewBufconnServer(options)
 
@@ -1114,20 +1196,149 @@ func TestImmuClient_Scan(t *testing.T) {
 	md := metadata.Pairs("authorization", lr.Token)
 	ctx := metadata.NewOutgoingContext(context.Background(), md)
 
-	_, _ = client.VerifiedSet(ctx, []byte(`key1`), []byte(`val1`))
-	_, _ = client.VerifiedSet(ctx, []byte(`key1`), []byte(`val11`))
-	_, _ = client.VerifiedSet(ctx, []byte(`key3`), []byte(`val3`))
+	for i := 0; i < 10; i++ {
+		_, err = client.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
 
-	entries, err := client.Scan(ctx, &schema.ScanRequest{Prefix: []byte("key"), SinceTx: 3})
+	state, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	var backup bytes.Buffer
+
+	err = client.Backup(ctx, &schema.BackupRequest{}, &backup)
+	require.NoError(t, err)
+
+	manifest, err := store.ReadBackupManifest(bytes.NewReader(backup.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, manifest.TxID)
+	require.Equal(t, state.TxHash, manifest.Alh[:])
+
+	_, err = client.Set(ctx, []byte("key10"), []byte("value10"))
+	require.NoError(t, err)
+
+	var incBackup bytes.Buffer
+
+	err = client.Backup(ctx, &schema.BackupRequest{SinceTx: manifest.TxID, SinceAlh: manifest.Alh[:]}, &incBackup)
+	require.NoError(t, err)
+
+	err = client.Backup(ctx, &schema.BackupRequest{SinceTx: manifest.TxID, SinceAlh: []byte("invalid")}, &bytes.Buffer{})
+	require.Error(t, err)
+
+	restorePath := "data_client_backup_restored"
+	defer os.RemoveAll(restorePath)
+
+	_, err = store.RestoreBackup(restorePath, &backup, store.DefaultOptions())
+	require.NoError(t, err)
+
+	incManifest, err := store.RestoreIncrementalBackup(restorePath, &incBackup, store.DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, manifest.TxID+1, incManifest.TxID)
 
-	require.IsType(t, &schema.Entries{}, entries)
-	require.Nil(t, err)
-	require.Len(t, entries.Entries, 2)
 	client.Disconnect()
 }
 
-func TestImmuClient_TxScan(t *testing.T) {
-	options := server.DefaultOptions().WithAuth(true)
+func TestImmuClient_ExportAndImport(t *testing.T) {
+	newClient := func(dialer servertest.BuffDialer) (ic.ImmuClient, context.Context) {
+		client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(dialer), grpc.WithInsecure()}))
+		require.NoError(t, err)
+		client.WithTokenService(tokenservice.NewInmemoryTokenService())
+		lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+		require.NoError(t, err)
+		md := metadata.Pairs("authorization", lr.Token)
+		return client, metadata.NewOutgoingContext(context.Background(), md)
+	}
+
+	srcOptions := server.DefaultOptions().WithAuth(true).WithDir("data_export_src").WithSigningKey("./../../test/signer/ec1.key")
+	srcServer := servertest.NewBufconnServer(srcOptions)
+
+	defer os.RemoveAll(srcOptions.Dir)
+	defer os.Remove(".state-")
+
+	srcServer.Start()
+	defer srcServer.Stop()
+
+	dstOptions := server.DefaultOptions().WithAuth(true).WithDir("data_export_dst")
+	dstServer := servertest.NewBufconnServer(dstOptions)
+
+	defer os.RemoveAll(dstOptions.Dir)
+
+	dstServer.Start()
+	defer dstServer.Stop()
+
+	srcClient, srcCtx := newClient(srcServer.Dialer)
+	defer srcClient.Disconnect()
+
+	for i := 0; i < 10; i++ {
+		_, err := srcClient.Set(srcCtx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	state, err := srcClient.CurrentState(srcCtx)
+	require.NoError(t, err)
+
+	var export bytes.Buffer
+
+	err = srcClient.Export(srcCtx, &export)
+	require.NoError(t, err)
+
+	manifest, err := database.ReadExportManifest(bytes.NewReader(export.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, manifest.TxID)
+	require.Equal(t, state.TxHash, manifest.Alh)
+
+	dstClient, dstCtx := newClient(dstServer.Dialer)
+	defer dstClient.Disconnect()
+
+	_, err = dstClient.Import(dstCtx, "defaultdb", bytes.NewReader(export.Bytes()))
+	require.Error(t, err)
+
+	_, err = dstClient.Import(dstCtx, "moved", bytes.NewReader(export.Bytes()[:export.Len()/2]))
+	require.Error(t, err)
+
+	importedState, err := dstClient.Import(dstCtx, "moved", bytes.NewReader(export.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, "defaultdb", importedState.Db)
+	require.Equal(t, state.TxId, importedState.TxId)
+	require.Equal(t, state.TxHash, importedState.TxHash)
+
+	pk, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	ok, err := importedState.CheckSignature(pk)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	udr, err := dstClient.UseDatabase(dstCtx, &schema.Database{DatabaseName: "moved"})
+	require.NoError(t, err)
+	dstCtx = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", udr.Token))
+
+	for i := 0; i < 10; i++ {
+		entry, err := dstClient.Get(dstCtx, []byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), entry.Value)
+	}
+
+	dump, err := ioutil.TempFile("", "dump")
+	require.NoError(t, err)
+	defer os.Remove(dump.Name())
+	defer dump.Close()
+
+	n, err := srcClient.Dump(srcCtx, dump)
+	require.NoError(t, err)
+	require.Equal(t, int64(export.Len()), n)
+
+	_, err = dump.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	loadedState, err := dstClient.Import(dstCtx, "loaded", dump)
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, loadedState.TxId)
+	require.Equal(t, state.TxHash, loadedState.TxHash)
+}
+
+func TestImmuClient_CloneDatabase(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithDir("data_clone")
 	bs := servertest.NewBufconnServer(options)
 
 	defer os.RemoveAll(options.Dir)
@@ -1146,35 +1357,1015 @@ func TestImmuClient_TxScan(t *testing.T) {
 	md := metadata.Pairs("authorization", lr.Token)
 	ctx := metadata.NewOutgoingContext(context.Background(), md)
 
-	_, _ = client.Set(ctx, []byte(`key1`), []byte(`val1`))
-	_, _ = client.Set(ctx, []byte(`key1`), []byte(`val11`))
-	_, _ = client.Set(ctx, []byte(`key3`), []byte(`val3`))
+	for i := 0; i < 10; i++ {
+		_, err = client.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
 
-	txls, err := client.TxScan(ctx, &schema.TxScanRequest{
-		InitialTx: 2,
-	})
-	require.IsType(t, &schema.TxList{}, txls)
-	require.Nil(t, err)
-	require.Len(t, txls.Txs, 3)
+	state, err := client.CurrentState(ctx)
+	require.NoError(t, err)
 
-	txls, err = client.TxScan(ctx, &schema.TxScanRequest{
-		InitialTx: 4,
-		Limit:     3,
+	err = client.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "notexists", TargetDatabase: "cloned"})
+	require.Error(t, err)
+
+	err = client.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "defaultdb", TargetDatabase: "defaultdb"})
+	require.Error(t, err)
+
+	err = client.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "defaultdb", TargetDatabase: "cloned"})
+	require.NoError(t, err)
+
+	err = client.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "defaultdb", TargetDatabase: "clonedattx", AtTx: state.TxId - 1})
+	require.NoError(t, err)
+
+	useDatabase := func(dbName string) context.Context {
+		udr, err := client.UseDatabase(ctx, &schema.Database{DatabaseName: dbName})
+		require.NoError(t, err)
+		return metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", udr.Token))
+	}
+
+	clonedCtx := useDatabase("cloned")
+
+	clonedState, err := client.CurrentState(clonedCtx)
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, clonedState.TxId)
+	require.Equal(t, state.TxHash, clonedState.TxHash)
+
+	for i := 0; i < 10; i++ {
+		entry, err := client.Get(clonedCtx, []byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), entry.Value)
+	}
+
+	_, err = client.Set(clonedCtx, []byte("key0"), []byte("changed"))
+	require.NoError(t, err)
+
+	entry, err := client.Get(ctx, []byte("key0"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value0"), entry.Value)
+
+	clonedAtTxCtx := useDatabase("clonedattx")
+
+	clonedState, err = client.CurrentState(clonedAtTxCtx)
+	require.NoError(t, err)
+	require.Equal(t, state.TxId-1, clonedState.TxId)
+
+	_, err = client.Get(clonedAtTxCtx, []byte("key9"))
+	require.Error(t, err)
+}
+
+func TestImmuClient_BackupSchedules(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithDir("data_backup_schedules")
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	err = client.SetBackupSchedule(ctx, &schema.BackupSchedule{Database: "defaultdb", Cron: "0 0 * *", Target: "backups"})
+	require.Error(t, err)
+
+	err = client.SetBackupSchedule(ctx, &schema.BackupSchedule{Database: "defaultdb", Cron: "@daily", Retention: 7, Target: "backups"})
+	require.NoError(t, err)
+
+	schedules, err := client.ListBackupSchedules(ctx)
+	require.NoError(t, err)
+	require.Len(t, schedules.Schedules, 1)
+	require.Equal(t, "defaultdb", schedules.Schedules[0].Database)
+	require.Equal(t, "@daily", schedules.Schedules[0].Cron)
+	require.Equal(t, uint32(7), schedules.Schedules[0].Retention)
+	require.Equal(t, "backups", schedules.Schedules[0].Target)
+
+	err = client.DeleteBackupSchedule(ctx, &schema.Database{DatabaseName: "defaultdb"})
+	require.NoError(t, err)
+
+	err = client.DeleteBackupSchedule(ctx, &schema.Database{DatabaseName: "defaultdb"})
+	require.Error(t, err)
+
+	schedules, err = client.ListBackupSchedules(ctx)
+	require.NoError(t, err)
+	require.Empty(t, schedules.Schedules)
+}
+
+func TestImmuClient_RetentionPolicies(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithDir("data_retention_policies")
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	err = client.SetRetentionPolicy(ctx, &schema.RetentionPolicy{Database: "defaultdb"})
+	require.Error(t, err)
+
+	err = client.SetRetentionPolicy(ctx, &schema.RetentionPolicy{
+		Database: "defaultdb",
+		Rules:    []*schema.RetentionRule{{Prefix: []byte("pii/"), ErasableAfter: 7 * 365 * 24 * 3600}},
+	})
+	require.NoError(t, err)
+
+	policies, err := client.ListRetentionPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies.Policies, 1)
+	require.Equal(t, "defaultdb", policies.Policies[0].Database)
+	require.Len(t, policies.Policies[0].Rules, 1)
+	require.Equal(t, []byte("pii/"), policies.Policies[0].Rules[0].Prefix)
+
+	enforcements, err := client.ListRetentionEnforcements(ctx, &schema.Database{DatabaseName: "defaultdb"})
+	require.NoError(t, err)
+	require.Empty(t, enforcements.Enforcements)
+
+	err = client.DeleteRetentionPolicy(ctx, &schema.Database{DatabaseName: "defaultdb"})
+	require.NoError(t, err)
+
+	err = client.DeleteRetentionPolicy(ctx, &schema.Database{DatabaseName: "defaultdb"})
+	require.Error(t, err)
+
+	policies, err = client.ListRetentionPolicies(ctx)
+	require.NoError(t, err)
+	require.Empty(t, policies.Policies)
+}
+
+func TestImmuClient_SetAll(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = client.SetAll(ctx, nil)
+	require.Error(t, err)
+
+	setRequest := &schema.SetRequest{KVs: []*schema.KeyValue{}}
+	_, err = client.SetAll(ctx, setRequest)
+	require.Error(t, err)
+
+	setRequest = &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("1,2,3"), Value: []byte("3,2,1")},
+		{Key: []byte("4,5,6"), Value: []byte("6,5,4"), Metadata: &schema.KVMetadata{NonIndexeable: true}},
+	}}
+
+	_, err = client.SetAll(ctx, setRequest)
+	require.NoError(t, err)
+
+	err = client.CompactIndex(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	for _, kv := range setRequest.KVs {
+		i, err := client.Get(ctx, kv.Key)
+
+		if kv.Metadata != nil && kv.Metadata.NonIndexeable {
+			require.Contains(t, err.Error(), "key not found")
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, kv.Value, i.GetValue())
+		}
+	}
+
+	err = client.Disconnect()
+	require.NoError(t, err)
+
+	_, err = client.SetAll(ctx, setRequest)
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+}
+
+func TestImmuClient_GetAll(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = client.VerifiedSet(ctx, []byte(`aaa`), []byte(`val`))
+	require.NoError(t, err)
+
+	entries, err := client.GetAll(ctx, [][]byte{[]byte(`aaa`), []byte(`bbb`)})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 1)
+
+	_, err = client.VerifiedSet(ctx, []byte(`bbb`), []byte(`val`))
+	require.NoError(t, err)
+
+	entries, err = client.GetAll(ctx, [][]byte{[]byte(`aaa`), []byte(`bbb`)})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+
+	client.Disconnect()
+}
+
+func TestImmuClient_Delete(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	require.NoError(t, err)
+
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = client.Delete(ctx, nil)
+	require.Error(t, err)
+
+	deleteRequest := &schema.DeleteKeysRequest{}
+	_, err = client.Delete(ctx, deleteRequest)
+	require.Error(t, err)
+
+	_, err = client.Set(ctx, []byte("1,2,3"), []byte("3,2,1"))
+	require.NoError(t, err)
+
+	i, err := client.Get(ctx, []byte("1,2,3"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("3,2,1"), i.GetValue())
+
+	_, err = client.ExpirableSet(ctx, []byte("expirableKey"), []byte("expirableValue"), time.Now())
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, []byte("expirableKey"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "key not found")
+
+	deleteRequest.Keys = append(deleteRequest.Keys, []byte("1,2,3"))
+	_, err = client.Delete(ctx, deleteRequest)
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, []byte("1,2,3"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "key not found")
+
+	_, err = client.Delete(ctx, deleteRequest)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "key not found")
+
+	err = client.Disconnect()
+	require.NoError(t, err)
+}
+
+func TestImmuClient_ExecAllOpsOptions(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	aOps := &schema.ExecAllRequest{
+		Operations: []*schema.Op{
+			{
+				Operation: &schema.Op_Kv{
+					Kv: &schema.KeyValue{
+						Key:   []byte(`key`),
+						Value: []byte(`val`),
+					},
+				},
+			},
+		},
+	}
+
+	idx, err := client.ExecAll(ctx, aOps)
+
+	require.Nil(t, err)
+	require.NotNil(t, idx)
+
+	client.Disconnect()
+}
+
+func TestImmuClient_Scan(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, _ = client.VerifiedSet(ctx, []byte(`key1`), []byte(`val1`))
+	_, _ = client.VerifiedSet(ctx, []byte(`key1`), []byte(`val11`))
+	_, _ = client.VerifiedSet(ctx, []byte(`key3`), []byte(`val3`))
+
+	entries, err := client.Scan(ctx, &schema.ScanRequest{Prefix: []byte("key"), SinceTx: 3})
+
+	require.IsType(t, &schema.Entries{}, entries)
+	require.Nil(t, err)
+	require.Len(t, entries.Entries, 2)
+	client.Disconnect()
+}
+
+func TestImmuClient_TxScan(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, _ = client.Set(ctx, []byte(`key1`), []byte(`val1`))
+	_, _ = client.Set(ctx, []byte(`key1`), []byte(`val11`))
+	_, _ = client.Set(ctx, []byte(`key3`), []byte(`val3`))
+
+	txls, err := client.TxScan(ctx, &schema.TxScanRequest{
+		InitialTx: 2,
+	})
+	require.IsType(t, &schema.TxList{}, txls)
+	require.Nil(t, err)
+	require.Len(t, txls.Txs, 3)
+
+	txls, err = client.TxScan(ctx, &schema.TxScanRequest{
+		InitialTx: 4,
+		Limit:     3,
 		Desc:      true,
 	})
 	require.IsType(t, &schema.TxList{}, txls)
 	require.Nil(t, err)
 	require.Len(t, txls.Txs, 3)
 
-	txls, err = client.TxScan(ctx, &schema.TxScanRequest{
-		InitialTx: 3,
-		Limit:     1,
-		Desc:      true,
-	})
-	require.IsType(t, &schema.TxList{}, txls)
-	require.Nil(t, err)
-	require.Len(t, txls.Txs, 1)
-	require.Equal(t, database.TrimPrefix(txls.Txs[0].Entries[0].Key), []byte(`key1`))
+	txls, err = client.TxScan(ctx, &schema.TxScanRequest{
+		InitialTx: 3,
+		Limit:     1,
+		Desc:      true,
+	})
+	require.IsType(t, &schema.TxList{}, txls)
+	require.Nil(t, err)
+	require.Len(t, txls.Txs, 1)
+	require.Equal(t, database.TrimPrefix(txls.Txs[0].Entries[0].Key), []byte(`key1`))
+}
+
+func TestImmuClient_TxByTime(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	hdr, err := client.Set(ctx, []byte(`key1`), []byte(`val1`))
+	require.NoError(t, err)
+
+	tx, err := client.TxByTime(ctx, &schema.TxByTimeRequest{Ts: time.Now().Unix()})
+	require.NoError(t, err)
+	require.Equal(t, hdr.Id, tx.Header.Id)
+
+	tx, err = client.TxByTime(ctx, &schema.TxByTimeRequest{Ts: hdr.Ts, Since: true})
+	require.NoError(t, err)
+	require.LessOrEqual(t, tx.Header.Id, hdr.Id)
+	require.Equal(t, hdr.Ts, tx.Header.Ts)
+}
+
+func TestImmuClient_ExportTxHeaders(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, _ = client.Set(ctx, []byte(`key1`), []byte(`val1`))
+	_, _ = client.Set(ctx, []byte(`key2`), []byte(`val2`))
+
+	txHeaders, err := client.ExportTxHeaders(ctx, &schema.TxHeadersRequest{
+		InitialTx: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, txHeaders.Headers, 2)
+
+	tx, err := client.TxByID(ctx, 3)
+	require.NoError(t, err)
+
+	hdr := &store.TxHeader{}
+	err = hdr.ReadFrom(txHeaders.Headers[1])
+	require.NoError(t, err)
+
+	txHdr, err := schema.TxHeaderFromProto(tx.Header)
+	require.NoError(t, err)
+	require.Equal(t, txHdr.Alh(), hdr.Alh())
+}
+
+func TestImmuClient_ConsistencyProof(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, _ = client.Set(ctx, []byte(`key1`), []byte(`val1`))
+	_, _ = client.Set(ctx, []byte(`key2`), []byte(`val2`))
+
+	_, err = client.ConsistencyProof(ctx, 3, 2)
+	require.Error(t, err)
+
+	sourceTx, err := client.TxByID(ctx, 2)
+	require.NoError(t, err)
+
+	targetTx, err := client.TxByID(ctx, 3)
+	require.NoError(t, err)
+
+	proof, err := client.ConsistencyProof(ctx, 2, 3)
+	require.NoError(t, err)
+
+	dualProof, err := schema.DualProofFromProto(proof)
+	require.NoError(t, err)
+
+	sourceHdr, err := schema.TxHeaderFromProto(sourceTx.Header)
+	require.NoError(t, err)
+
+	targetHdr, err := schema.TxHeaderFromProto(targetTx.Header)
+	require.NoError(t, err)
+
+	verifies := store.VerifyDualProof(
+		dualProof,
+		2,
+		3,
+		sourceHdr.Alh(),
+		targetHdr.Alh(),
+	)
+	require.True(t, verifies)
+}
+
+func TestImmuClient_Notarization(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	digest := sha256.Sum256([]byte(`contract.pdf`))
+
+	_, err = client.VerifyNotarization(ctx, digest[:])
+	require.Error(t, err)
+
+	notarization, err := client.Notarize(ctx, digest[:], "sha256", map[string]string{"owner": "acme"})
+	require.NoError(t, err)
+	require.Equal(t, "acme", notarization.Metadata["owner"])
+
+	_, err = client.Set(ctx, []byte(`key1`), []byte(`val1`))
+	require.NoError(t, err)
+
+	verified, err := client.VerifyNotarization(ctx, digest[:])
+	require.NoError(t, err)
+	require.Equal(t, notarization.Tx, verified.Tx)
+	require.Equal(t, notarization.NotarizedAt, verified.NotarizedAt)
+	require.Equal(t, "sha256", verified.HashAlgorithm)
+}
+
+func TestImmuClient_Erase(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	hdr, err := client.Set(ctx, []byte(`key1`), []byte(`personal data`))
+	require.NoError(t, err)
+
+	erasure, err := client.Erase(ctx, []byte(`key1`), 0, "right to erasure")
+	require.NoError(t, err)
+	require.Equal(t, hdr.Id, erasure.Tx)
+	require.Equal(t, "immudb", erasure.RequestedBy)
+
+	entry, err := client.Get(ctx, []byte(`key1`))
+	require.NoError(t, err)
+	require.True(t, entry.Erased)
+	require.Empty(t, entry.Value)
+
+	_, err = client.VerifiedGet(ctx, []byte(`key1`))
+	require.Error(t, err)
+
+	_, err = client.VerifiedTxByID(ctx, hdr.Id)
+	require.NoError(t, err)
+
+	history, err := client.ErasureHistory(ctx, []byte(`key1`))
+	require.NoError(t, err)
+	require.Len(t, history.Erasures, 1)
+	require.Equal(t, "right to erasure", history.Erasures[0].Reason)
+}
+
+func TestImmuClient_LegalHold(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = client.Set(ctx, []byte(`key1`), []byte(`personal data`))
+	require.NoError(t, err)
+
+	hold, err := client.PlaceLegalHold(ctx, &schema.LegalHoldRequest{Key: []byte(`key1`), Reason: "litigation"})
+	require.NoError(t, err)
+	require.Equal(t, "immudb", hold.PlacedBy)
+
+	_, err = client.Erase(ctx, []byte(`key1`), 0, "right to erasure")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "data is under legal hold")
+
+	holds, err := client.ListLegalHolds(ctx)
+	require.NoError(t, err)
+	require.Len(t, holds.Holds, 1)
+	require.Equal(t, []byte(`key1`), holds.Holds[0].Key)
+
+	err = client.LiftLegalHold(ctx, &schema.LegalHoldRequest{Key: []byte(`key1`)})
+	require.NoError(t, err)
+
+	holds, err = client.ListLegalHolds(ctx)
+	require.NoError(t, err)
+	require.Empty(t, holds.Holds)
+
+	_, err = client.Erase(ctx, []byte(`key1`), 0, "right to erasure")
+	require.NoError(t, err)
+}
+
+func TestImmuClient_VerifiedExclusion(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	hdr1, err := client.SetAll(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte(`key1`), Value: []byte(`value1`)},
+		{Key: []byte(`key3`), Value: []byte(`value3`)},
+	}})
+	require.NoError(t, err)
+
+	hdr2, err := client.Set(ctx, []byte(`key2`), []byte(`value2`))
+	require.NoError(t, err)
+
+	proof, err := client.VerifiedExclusion(ctx, []byte(`key0`), 0)
+	require.NoError(t, err)
+	require.Equal(t, hdr2.Id, proof.AtTx)
+	require.Nil(t, proof.Predecessor)
+	require.Equal(t, []byte("\x00key1"), proof.Successor.Entry.Key)
+
+	_, err = client.VerifiedExclusion(ctx, []byte(`key2`), 0)
+	require.Error(t, err)
+
+	// the local state is already ahead of the transaction the exclusion is proven at
+	proof, err = client.VerifiedExclusion(ctx, []byte(`key2`), hdr1.Id)
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, proof.AtTx)
+	require.Equal(t, []byte("\x00key1"), proof.Predecessor.Entry.Key)
+	require.Equal(t, []byte("\x00key3"), proof.Successor.Entry.Key)
+}
+
+func TestImmuClient_VerifiedBatch(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	hdr1, err := client.SetAll(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte(`key1`), Value: []byte(`value1`)},
+		{Key: []byte(`key2`), Value: []byte(`value2`)},
+	}})
+	require.NoError(t, err)
+
+	_, err = client.SetReference(ctx, []byte(`ref1`), []byte(`key1`))
+	require.NoError(t, err)
+
+	hdr3, err := client.Set(ctx, []byte(`key3`), []byte(`value3`))
+	require.NoError(t, err)
+
+	batch, err := client.VerifiedBatch(ctx, [][]byte{[]byte(`key1`), []byte(`ref1`), []byte(`key3`), []byte(`key2`)}, []uint64{hdr1.Id})
+	require.NoError(t, err)
+	require.Len(t, batch.Entries, 4)
+	require.Equal(t, []byte(`value1`), batch.Entries[1].Entry.Value)
+	require.Len(t, batch.TxProofs, 3)
+	require.Equal(t, hdr3.Id, batch.DualProof.TargetTxHeader.Id)
+
+	state, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, hdr3.Id, state.TxId)
+
+	hdr4, err := client.Set(ctx, []byte(`key4`), []byte(`value4`))
+	require.NoError(t, err)
+
+	// proven since the local state
+	batch, err = client.VerifiedBatch(ctx, [][]byte{[]byte(`key4`)}, nil)
+	require.NoError(t, err)
+	require.Equal(t, hdr3.Id, batch.DualProof.SourceTxHeader.Id)
+	require.Equal(t, hdr4.Id, batch.DualProof.TargetTxHeader.Id)
+
+	_, err = client.VerifiedBatch(ctx, [][]byte{[]byte(`key5`)}, nil)
+	require.Error(t, err)
+
+	_, err = client.VerifiedBatch(ctx, nil, []uint64{hdr4.Id + 1})
+	require.Error(t, err)
+}
+
+func TestImmuClient_VerifiedSampledAudit(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	var lastHdr *schema.TxHeader
+
+	for i := 0; i < 10; i++ {
+		lastHdr, err = client.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(`value`))
+		require.NoError(t, err)
+	}
+
+	_, err = client.VerifiedSampledAudit(ctx, nil, 0, 0, 3)
+	require.Error(t, err)
+
+	audit, err := client.VerifiedSampledAudit(ctx, []byte(`seed`), 0, 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), audit.FromTx)
+	require.Equal(t, lastHdr.Id, audit.ToTx)
+	require.Len(t, audit.Txs, 3)
+
+	_, err = client.Set(ctx, []byte(`key10`), []byte(`value`))
+	require.NoError(t, err)
+
+	// proven since the local state
+	audit, err = client.VerifiedSampledAudit(ctx, []byte(`seed`), 2, lastHdr.Id, 20)
+	require.NoError(t, err)
+	require.Equal(t, lastHdr.Id, audit.DualProof.SourceTxHeader.Id)
+	require.Len(t, audit.Txs, int(lastHdr.Id-1))
+
+	_, err = client.VerifiedSampledAudit(ctx, []byte(`seed`), 0, lastHdr.Id+10, 3)
+	require.Error(t, err)
+}
+
+func TestImmuClient_ExportProofBundle(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = client.Set(ctx, []byte(`key1`), []byte(`value1`))
+	require.NoError(t, err)
+
+	trusted, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	_, err = client.Set(ctx, []byte(`key2`), []byte(`value2`))
+	require.NoError(t, err)
+
+	_, err = client.SetReference(ctx, []byte(`ref`), []byte(`key1`))
+	require.NoError(t, err)
+
+	_, err = client.ExportProofBundle(ctx, [][]byte{[]byte(`ref`)}, 0)
+	require.ErrorIs(t, err, ic.ErrIllegalArguments)
+
+	bundle, err := client.ExportProofBundle(ctx, [][]byte{[]byte(`key1`), []byte(`key2`)}, trusted.TxId)
+	require.NoError(t, err)
+	require.Len(t, bundle.Entries, 2)
+	require.Equal(t, []byte(`value2`), []byte(bundle.Entries[1].Value))
+	require.NotNil(t, bundle.Consistency)
+
+	encoded, err := proofs.MarshalJSON(bundle)
+	require.NoError(t, err)
+
+	decoded := &proofs.Bundle{}
+	err = proofs.UnmarshalJSON(encoded, decoded)
+	require.NoError(t, err)
+
+	err = verifier.VerifyBundle(decoded, trusted, nil)
+	require.NoError(t, err)
+}
+
+func TestImmuClient_VerifiedGlobalState(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithSigningKey("./../../test/signer/ec1.key")
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	opts := ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+	client, err := ic.NewImmuClient(opts.WithServerSigningPubKey("./../../test/signer/ec1.pub"))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = client.VerifiedGlobalState(ctx, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "global state is disabled")
+
+	bs.Server.Srv.Options.WithGlobalState(true)
+
+	err = client.CreateDatabase(ctx, &schema.DatabaseSettings{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	_, err = client.Set(ctx, []byte(`key1`), []byte(`value1`))
+	require.NoError(t, err)
+
+	globalState, err := client.VerifiedGlobalState(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, int(globalState.Width), len(globalState.States))
+	require.Nil(t, globalState.InclusionProof)
+
+	state, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	dbState, err := client.VerifiedGlobalState(ctx, state.Db)
+	require.NoError(t, err)
+	require.Equal(t, globalState.Root, dbState.Root)
+	require.Len(t, dbState.States, 1)
+	require.Equal(t, state.TxHash, dbState.States[0].TxHash)
+	require.True(t, dbState.VerifyInclusion(state))
+
+	_, err = client.VerifiedGlobalState(ctx, "db2")
+	require.Error(t, err)
+}
+
+func TestImmuClient_VerifiedCosignedState(t *testing.T) {
+	options := server.DefaultOptions().
+		WithAuth(true).
+		WithSigningKey("./../../test/signer/ec1.key").
+		WithCosigners([]string{"./../../test/signer/ec1.pub", "./../../test/signer/ec3.pub"})
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	opts := ic.DefaultOptions().
+		WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}).
+		WithServerSigningPubKey("./../../test/signer/ec1.pub")
+	client, err := ic.NewImmuClient(opts)
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = client.VerifiedCosignedState(ctx, 0)
+	require.ErrorIs(t, err, ic.ErrCosignersNotSet)
+
+	opts.WithCosignerPubKeys([]string{"./../../test/signer/ec1.pub", "./../../test/signer/ec3.pub"}, 2)
+
+	_, err = client.VerifiedCosignedState(ctx, 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cosigned state not found")
+
+	_, err = client.Set(ctx, []byte(`key1`), []byte(`value1`))
+	require.NoError(t, err)
+
+	state, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	cosign := func(privateKeyPath string, txHash []byte) error {
+		cosigner, err := signer.NewSigner(privateKeyPath)
+		require.NoError(t, err)
+
+		sig, pk, err := cosigner.Sign((&schema.ImmutableState{Db: state.Db, TxId: state.TxId, TxHash: txHash}).ToBytes())
+		require.NoError(t, err)
+
+		_, err = client.GetServiceClient().Cosign(ctx, &schema.Cosignature{
+			TxId:      state.TxId,
+			TxHash:    txHash,
+			Signature: &schema.Signature{Signature: sig, PublicKey: pk},
+		})
+		return err
+	}
+
+	err = cosign("./../../test/signer/ec3.key", make([]byte, len(state.TxHash)))
+	require.Error(t, err)
+
+	err = cosign("./../../test/signer/ec3.key", state.TxHash)
+	require.NoError(t, err)
+
+	_, err = client.VerifiedCosignedState(ctx, state.TxId)
+	require.ErrorIs(t, err, ic.ErrCosignatureThresholdNotMet)
+
+	err = cosign("./../../test/signer/ec1.key", state.TxHash)
+	require.NoError(t, err)
+
+	cosignedState, err := client.VerifiedCosignedState(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, cosignedState.State.TxId)
+	require.Equal(t, state.TxHash, cosignedState.State.TxHash)
+	require.Len(t, cosignedState.Cosignatures, 2)
 }
 
 func TestImmuClient_Logout(t *testing.T) {
@@ -1306,6 +2497,7 @@ func TestImmuClient_Count(t *testing.T) {
 
 	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -1328,6 +2520,7 @@ func TestImmuClient_CountAll(t *testing.T) {
 
 	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -1531,6 +2724,7 @@ func TestImmuClient_CurrentStateVerifiedSignature(t *testing.T) {
 	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}).
 		WithServerSigningPubKey("./../../test/signer/ec1.pub"))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	client.WithTokenService(tokenservice.NewInmemoryTokenService())
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -1703,6 +2897,7 @@ func TestImmuClient_BackupAndRestoreUX(t *testing.T) {
 	_, err = client.VerifiedGet(ctx, []byte(`key3`))
 	require.ErrorIs(t, err, ic.ErrServerStateIsOlder)
 
+	client.Disconnect()
 	bs.Stop()
 }
 
@@ -1787,3 +2982,38 @@ func (ts TokenServiceMock) WithHds(hds homedir.HomedirService) tokenservice.Toke
 func (ts TokenServiceMock) WithTokenFileName(tfn string) tokenservice.TokenService {
 	return ts
 }
+
+func TestImmuClient_ConfigHistory(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = client.SQLExec(ctx, "CREATE TABLE table1(id INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	history, err := client.ConfigHistory(ctx, &schema.ConfigHistoryRequest{Database: "defaultdb"})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 1)
+	require.Equal(t, "sql schema", history.Changes[0].Kind)
+	require.Equal(t, "immudb", history.Changes[0].ChangedBy)
+
+	history, err = client.ConfigHistory(ctx, &schema.ConfigHistoryRequest{})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 1)
+	require.Equal(t, "server settings", history.Changes[0].Kind)
+}
@@ -40,9 +40,11 @@ func TestCreateDatabase(t *testing.T) {
 
 	clientOpts := immudb.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
 	client := immudb.NewClient().WithOptions(clientOpts)
+	defer client.Disconnect()
 
 	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
+	defer client.CloseSession(context.TODO())
 
 	dbSettings := &schema.DatabaseSettings{
 		DatabaseName:            "db1",
@@ -58,6 +60,16 @@ func TestCreateDatabase(t *testing.T) {
 		VLogMaxOpenedFiles:      8,
 		TxLogMaxOpenedFiles:     4,
 		CommitLogMaxOpenedFiles: 2,
+		VLogCacheSize:           500,
+		CacheEvictionPolicy:     "arc",
+		Durability:              "group",
+		GroupCommitMaxLatency:   5,
+		MaxCommitQueueDepth:     100,
+		MaxIndexingLag:          10000,
+		BlobThreshold:           4096,
+		CompressionFormat:       "zstd",
+		CompressionLevel:        3,
+		HashAlgorithm:           "blake3",
 		IndexSettings: &schema.IndexSettings{
 			Synced:                   false,
 			FlushThreshold:           256,
@@ -68,9 +80,12 @@ func TestCreateDatabase(t *testing.T) {
 			RenewSnapRootAfter:       5000,
 			CompactionThld:           5,
 			DelayDuringCompaction:    1,
+			AutoCompactionInterval:   60000,
 			NodesLogMaxOpenedFiles:   20,
 			HistoryLogMaxOpenedFiles: 15,
 			CommitLogMaxOpenedFiles:  3,
+			IndexingWorkers:          4,
+			CheckpointInterval:       30000,
 		},
 	}
 	err = client.CreateDatabase(context.Background(), dbSettings)
@@ -94,6 +109,16 @@ func TestCreateDatabase(t *testing.T) {
 	require.Equal(t, dbSettings.VLogMaxOpenedFiles, settings.VLogMaxOpenedFiles)
 	require.Equal(t, dbSettings.TxLogMaxOpenedFiles, settings.TxLogMaxOpenedFiles)
 	require.Equal(t, dbSettings.CommitLogMaxOpenedFiles, settings.CommitLogMaxOpenedFiles)
+	require.Equal(t, dbSettings.VLogCacheSize, settings.VLogCacheSize)
+	require.Equal(t, dbSettings.CacheEvictionPolicy, settings.CacheEvictionPolicy)
+	require.Equal(t, dbSettings.Durability, settings.Durability)
+	require.Equal(t, dbSettings.GroupCommitMaxLatency, settings.GroupCommitMaxLatency)
+	require.Equal(t, dbSettings.MaxCommitQueueDepth, settings.MaxCommitQueueDepth)
+	require.Equal(t, dbSettings.MaxIndexingLag, settings.MaxIndexingLag)
+	require.Equal(t, dbSettings.BlobThreshold, settings.BlobThreshold)
+	require.Equal(t, dbSettings.CompressionFormat, settings.CompressionFormat)
+	require.Equal(t, dbSettings.CompressionLevel, settings.CompressionLevel)
+	require.Equal(t, dbSettings.HashAlgorithm, settings.HashAlgorithm)
 
 	require.Equal(t, dbSettings.IndexSettings.Synced, settings.IndexSettings.Synced)
 	require.Equal(t, dbSettings.IndexSettings.FlushThreshold, settings.IndexSettings.FlushThreshold)
@@ -104,7 +129,22 @@ func TestCreateDatabase(t *testing.T) {
 	require.Equal(t, dbSettings.IndexSettings.RenewSnapRootAfter, settings.IndexSettings.RenewSnapRootAfter)
 	require.Equal(t, dbSettings.IndexSettings.CompactionThld, settings.IndexSettings.CompactionThld)
 	require.Equal(t, dbSettings.IndexSettings.DelayDuringCompaction, settings.IndexSettings.DelayDuringCompaction)
+	require.Equal(t, dbSettings.IndexSettings.AutoCompactionInterval, settings.IndexSettings.AutoCompactionInterval)
 	require.Equal(t, dbSettings.IndexSettings.NodesLogMaxOpenedFiles, settings.IndexSettings.NodesLogMaxOpenedFiles)
 	require.Equal(t, dbSettings.IndexSettings.HistoryLogMaxOpenedFiles, settings.IndexSettings.HistoryLogMaxOpenedFiles)
 	require.Equal(t, dbSettings.IndexSettings.CommitLogMaxOpenedFiles, settings.IndexSettings.CommitLogMaxOpenedFiles)
+	require.Equal(t, dbSettings.IndexSettings.IndexingWorkers, settings.IndexSettings.IndexingWorkers)
+	require.Equal(t, dbSettings.IndexSettings.CheckpointInterval, settings.IndexSettings.CheckpointInterval)
+
+	err = client.CreateDatabase(context.Background(), &schema.DatabaseSettings{
+		DatabaseName:        "db2",
+		CacheEvictionPolicy: "fifo",
+	})
+	require.Error(t, err)
+
+	err = client.CreateDatabase(context.Background(), &schema.DatabaseSettings{
+		DatabaseName: "db3",
+		Durability:   "never",
+	})
+	require.Error(t, err)
 }
@@ -43,6 +43,7 @@ func TestGRPCError(t *testing.T) {
 	defer bs.Stop()
 
 	cli, _ := client.NewImmuClient(client.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer cli.Disconnect()
 
 	_, err := cli.Login(context.TODO(), []byte(`immudb`), []byte(`wrong`))
 
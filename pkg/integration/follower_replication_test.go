@@ -18,8 +18,10 @@ package integration
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -83,6 +85,7 @@ func TestReplication(t *testing.T) {
 	masterClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(masterPort))
 	require.NoError(t, err)
 	require.NotNil(t, masterClient)
+	defer masterClient.Disconnect()
 
 	mlr, err := masterClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -101,6 +104,7 @@ func TestReplication(t *testing.T) {
 	followerClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(followerPort))
 	require.NoError(t, err)
 	require.NotNil(t, followerClient)
+	defer followerClient.Disconnect()
 
 	flr, err := followerClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -167,6 +171,38 @@ func TestReplication(t *testing.T) {
 		_, err = followerClient.Get(fctx, []byte("key1"))
 		require.Contains(t, err.Error(), "key not found")
 	})
+
+	t.Run("replication status of replicateddb@follower", func(t *testing.T) {
+		res, err := followerClient.ReplicationStatus(fctx)
+		require.NoError(t, err)
+		require.Len(t, res.Statuses, 2)
+
+		require.Equal(t, "defaultdb", res.Statuses[0].Database)
+		require.False(t, res.Statuses[0].Replica)
+		require.False(t, res.Statuses[0].Running)
+
+		st := res.Statuses[1]
+		require.Equal(t, "replicateddb", st.Database)
+		require.True(t, st.Replica)
+		require.Equal(t, "defaultdb", st.MasterDatabase)
+		require.Equal(t, uint32(masterPort), st.MasterPort)
+		require.True(t, st.Running)
+		require.True(t, st.Connected)
+		require.NotZero(t, st.TxId)
+		require.Equal(t, st.TxId, st.MasterTxId)
+		require.Zero(t, st.Lag)
+	})
+
+	t.Run("replicateddb@follower is no longer replicated once promoted", func(t *testing.T) {
+		err = followerClient.UpdateDatabase(fctx, &schema.DatabaseSettings{DatabaseName: "replicateddb"})
+		require.NoError(t, err)
+
+		res, err := followerClient.ReplicationStatus(fctx)
+		require.NoError(t, err)
+		require.False(t, res.Statuses[1].Replica)
+		require.False(t, res.Statuses[1].Running)
+		require.Empty(t, res.Statuses[1].MasterDatabase)
+	})
 }
 
 func TestSystemDBAndDefaultDBReplication(t *testing.T) {
@@ -197,6 +233,7 @@ func TestSystemDBAndDefaultDBReplication(t *testing.T) {
 	masterClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(masterPort))
 	require.NoError(t, err)
 	require.NotNil(t, masterClient)
+	defer masterClient.Disconnect()
 
 	mlr, err := masterClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -238,6 +275,7 @@ func TestSystemDBAndDefaultDBReplication(t *testing.T) {
 	followerClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(followerPort))
 	require.NoError(t, err)
 	require.NotNil(t, followerClient)
+	defer followerClient.Disconnect()
 
 	flr, err := followerClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
@@ -264,3 +302,143 @@ func TestSystemDBAndDefaultDBReplication(t *testing.T) {
 	_, err = followerClient.Set(mctx, []byte("key2"), []byte("value2"))
 	require.Contains(t, err.Error(), "database is read-only because it's a replica")
 }
+
+func TestReplicaSeededFromBackup(t *testing.T) {
+	//init master server
+	masterServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir("master-data")
+
+	masterServer := server.DefaultServer().WithOptions(masterServerOpts).(*server.ImmuServer)
+	defer os.RemoveAll(masterServerOpts.Dir)
+
+	err := masterServer.Initialize()
+	require.NoError(t, err)
+
+	//init follower server
+	followerServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir("follower-data")
+
+	followerServer := server.DefaultServer().WithOptions(followerServerOpts).(*server.ImmuServer)
+	defer os.RemoveAll(followerServerOpts.Dir)
+
+	err = followerServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		masterServer.Start()
+	}()
+
+	go func() {
+		followerServer.Start()
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	defer func() {
+		masterServer.Stop()
+
+		time.Sleep(500 * time.Millisecond)
+
+		followerServer.Stop()
+	}()
+
+	// init master client
+	masterPort := masterServer.Listener.Addr().(*net.TCPAddr).Port
+	masterClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(masterPort))
+	require.NoError(t, err)
+	require.NotNil(t, masterClient)
+	defer masterClient.Disconnect()
+
+	mlr, err := masterClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	mmd := metadata.Pairs("authorization", mlr.Token)
+	mctx := metadata.NewOutgoingContext(context.Background(), mmd)
+
+	err = masterClient.CreateUser(mctx, []byte("follower"), []byte("follower1Pwd!"), auth.PermissionAdmin, "defaultdb")
+	require.NoError(t, err)
+
+	err = masterClient.SetActiveUser(mctx, &schema.SetActiveUserRequest{Active: true, Username: "follower"})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = masterClient.Set(mctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	// seed backup taken while the master keeps serving requests
+	seedBackup := filepath.Join(followerServerOpts.Dir, "seed.backup.tar")
+
+	f, err := os.Create(seedBackup)
+	require.NoError(t, err)
+
+	err = masterClient.Backup(mctx, &schema.BackupRequest{}, f)
+	require.NoError(t, err)
+
+	err = f.Close()
+	require.NoError(t, err)
+
+	// committed after the backup, only replicated
+	_, err = masterClient.Set(mctx, []byte("key10"), []byte("value10"))
+	require.NoError(t, err)
+
+	// init follower client
+	followerPort := followerServer.Listener.Addr().(*net.TCPAddr).Port
+	followerClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(followerPort))
+	require.NoError(t, err)
+	require.NotNil(t, followerClient)
+	defer followerClient.Disconnect()
+
+	flr, err := followerClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	fmd := metadata.Pairs("authorization", flr.Token)
+	fctx := metadata.NewOutgoingContext(context.Background(), fmd)
+
+	err = followerClient.CreateDatabase(fctx, &schema.DatabaseSettings{
+		DatabaseName:     "replicateddb",
+		Replica:          true,
+		MasterDatabase:   "defaultdb",
+		MasterAddress:    "127.0.0.1",
+		MasterPort:       uint32(masterPort),
+		FollowerUsername: "follower",
+		FollowerPassword: "follower1Pwd!",
+		SeedBackups:      []string{seedBackup},
+	})
+	require.NoError(t, err)
+
+	fdb, err := followerClient.UseDatabase(fctx, &schema.Database{DatabaseName: "replicateddb"})
+	require.NoError(t, err)
+
+	fmd = metadata.Pairs("authorization", fdb.Token)
+	fctx = metadata.NewOutgoingContext(context.Background(), fmd)
+
+	t.Run("key0 should be restored from the seed backup", func(t *testing.T) {
+		_, err = followerClient.Get(fctx, []byte("key0"))
+		require.NoError(t, err)
+	})
+
+	time.Sleep(500 * time.Millisecond)
+
+	t.Run("key10 should be replicated after the seed backup", func(t *testing.T) {
+		entry, err := followerClient.Get(fctx, []byte("key10"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("value10"), entry.Value)
+	})
+
+	mstate, err := masterClient.CurrentState(mctx)
+	require.NoError(t, err)
+
+	fstate, err := followerClient.CurrentState(fctx)
+	require.NoError(t, err)
+	require.Equal(t, mstate.TxId, fstate.TxId)
+	require.Equal(t, mstate.TxHash, fstate.TxHash)
+}
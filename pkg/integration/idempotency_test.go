@@ -0,0 +1,61 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmuClient_IdempotentWrites(t *testing.T) {
+	client, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+
+	setReq := &schema.SetRequest{
+		KVs:            []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}},
+		IdempotencyKey: []byte("set-req"),
+	}
+
+	hdr1, err := client.SetAll(ctx, setReq)
+	require.NoError(t, err)
+
+	hdr2, err := client.SetAll(ctx, setReq)
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id, hdr2.Id)
+
+	execReq := &schema.ExecAllRequest{
+		Operations: []*schema.Op{
+			{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{Key: []byte("key2"), Value: []byte("value2")}}},
+		},
+		IdempotencyKey: []byte("exec-req"),
+	}
+
+	hdr3, err := client.ExecAll(ctx, execReq)
+	require.NoError(t, err)
+	require.Equal(t, hdr1.Id+1, hdr3.Id)
+
+	hdr4, err := client.ExecAll(ctx, execReq)
+	require.NoError(t, err)
+	require.Equal(t, hdr3.Id, hdr4.Id)
+}
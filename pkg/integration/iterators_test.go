@@ -0,0 +1,90 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmuClient_Iterators(t *testing.T) {
+	client, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 25; i++ {
+		_, err = client.Set(ctx, []byte(fmt.Sprintf("key%02d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, err = client.SetReference(ctx, []byte("key99"), []byte("key00"))
+	require.NoError(t, err)
+
+	_, err = client.Set(ctx, []byte("other"), []byte("value"))
+	require.NoError(t, err)
+
+	it := ic.NewScanIterator(ctx, client, &schema.ScanRequest{Prefix: []byte("key"), Limit: 7})
+
+	n := 0
+	for it.Next() {
+		if n < 25 {
+			require.Equal(t, []byte(fmt.Sprintf("key%02d", n)), it.Entry().Key)
+		} else {
+			require.Equal(t, []byte("key99"), it.Entry().ReferencedBy.Key)
+		}
+		n++
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, 26, n)
+
+	it = ic.NewScanIterator(ctx, client, &schema.ScanRequest{Prefix: []byte("key"), Desc: true, SeekKey: []byte("key10")})
+
+	n = 0
+	for it.Next() {
+		require.Equal(t, []byte(fmt.Sprintf("key%02d", 9-n)), it.Entry().Key)
+		n++
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, 10, n)
+
+	for i := 0; i < 12; i++ {
+		_, err = client.Set(ctx, []byte("hkey"), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	hit := ic.NewHistoryIterator(ctx, client, &schema.HistoryRequest{Key: []byte("hkey"), Limit: 5})
+
+	n = 0
+	for hit.Next() {
+		require.Equal(t, []byte(fmt.Sprintf("value%d", n)), hit.Entry().Value)
+		n++
+	}
+	require.NoError(t, hit.Err())
+	require.Equal(t, 12, n)
+
+	hit = ic.NewHistoryIterator(ctx, client, &schema.HistoryRequest{Key: []byte("hkey"), Limit: -1})
+	require.False(t, hit.Next())
+	require.Error(t, hit.Err())
+}
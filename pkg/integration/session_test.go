@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
 	ic "github.com/codenotary/immudb/pkg/client"
 	"github.com/codenotary/immudb/pkg/server"
 	"github.com/codenotary/immudb/pkg/server/servertest"
@@ -44,6 +46,7 @@ func TestSession_OpenCloseSession(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
 	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
@@ -77,6 +80,7 @@ func TestSession_OpenCloseSessionMulti(t *testing.T) {
 			client := ic.NewClient().WithOptions(ic.DefaultOptions().
 				WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}).
 				WithHeartBeatFrequency(time.Millisecond * 100))
+			defer client.Disconnect()
 			if err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb"); err != nil {
 				t.Error(err)
 			}
@@ -109,6 +113,7 @@ func TestSession_OpenCloseSessionWithStateSigner(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}).WithServerSigningPubKey("./../../test/signer/ec1.pub"))
+	defer client.Disconnect()
 	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
 
@@ -147,6 +152,7 @@ func TestSession_ExpireSessions(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+			defer client.Disconnect()
 
 			err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 			require.NoError(t, err)
@@ -168,3 +174,77 @@ func TestSession_ExpireSessions(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestSession_ListKillSessions(t *testing.T) {
+	options := server.DefaultOptions().WithWebServer(false).WithPgsqlServer(false)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	newClient := func() ic.ImmuClient {
+		client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+		t.Cleanup(func() { client.Disconnect() })
+		return client
+	}
+
+	ctx := context.Background()
+
+	admin := newClient()
+	err := admin.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer admin.CloseSession(ctx)
+
+	err = admin.CreateUser(ctx, []byte("user1"), []byte("User1Password!"), auth.PermissionRW, "defaultdb")
+	require.NoError(t, err)
+
+	user := newClient()
+	err = user.OpenSession(ctx, []byte("user1"), []byte("User1Password!"), "defaultdb")
+	require.NoError(t, err)
+	defer user.CloseSession(ctx)
+
+	res, err := admin.ListSessions(ctx, &schema.ListSessionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.Sessions, 2)
+	require.Equal(t, "immudb", res.Sessions[0].User)
+	require.Equal(t, "user1", res.Sessions[1].User)
+
+	res, err = admin.ListSessions(ctx, &schema.ListSessionsRequest{User: "user1"})
+	require.NoError(t, err)
+	require.Len(t, res.Sessions, 1)
+	require.Equal(t, "defaultdb", res.Sessions[0].Database)
+	require.NotEmpty(t, res.Sessions[0].ClientAddress)
+	require.NotZero(t, res.Sessions[0].CreatedAt)
+
+	// only the system admin can manage sessions
+	_, err = user.ListSessions(ctx, &schema.ListSessionsRequest{})
+	require.Error(t, err)
+
+	_, err = user.KillSession(ctx, &schema.KillSessionRequest{SessionID: res.Sessions[0].Id})
+	require.Error(t, err)
+
+	_, err = admin.KillSession(ctx, &schema.KillSessionRequest{})
+	require.Error(t, err)
+
+	_, err = admin.KillSession(ctx, &schema.KillSessionRequest{SessionID: "unknown"})
+	require.Error(t, err)
+
+	killed, err := admin.KillSession(ctx, &schema.KillSessionRequest{User: "user1"})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), killed.Killed)
+
+	// the killed session can't be used anymore
+	_, err = user.Set(ctx, []byte("key1"), []byte("value1"))
+	require.Error(t, err)
+
+	res, err = admin.ListSessions(ctx, &schema.ListSessionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.Sessions, 1)
+
+	killed, err = admin.KillSession(ctx, &schema.KillSessionRequest{SessionID: res.Sessions[0].Id})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), killed.Killed)
+}
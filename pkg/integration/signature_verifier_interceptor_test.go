@@ -17,11 +17,15 @@ package integration
 
 import (
 	"context"
+	"io/ioutil"
+	"net"
+	"os"
 	"testing"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	ic "github.com/codenotary/immudb/pkg/client"
 	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
 	"github.com/codenotary/immudb/pkg/signer"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/require"
@@ -117,3 +121,103 @@ func TestSignatureVerifierInterceptorNoPublicKey(t *testing.T) {
 
 	require.Error(t, err)
 }
+
+func TestSignatureVerifierInterceptorMissingSignature(t *testing.T) {
+	pk, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+	c := ic.NewClient().WithServerSigningPubKey(pk)
+
+	state := &schema.ImmutableState{
+		TxId:   0,
+		TxHash: []byte(`hash`),
+	}
+
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+
+	err = c.SignatureVerifierInterceptor(context.TODO(), "/immudb.schema.ImmuService/CurrentState", &empty.Empty{}, state, nil, invoker, nil)
+	require.ErrorIs(t, err, ic.ErrStateSignatureMissing)
+}
+
+func TestStateSignatureVerification(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithSigningKey("./../../test/signer/ec1.key").WithWebServer(false).WithPgsqlServer(false)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+
+	err := bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	unsignedOptions := server.DefaultOptions().WithAuth(true).WithWebServer(false).WithPgsqlServer(false).WithDir("unsigned_data")
+	unsignedBs := servertest.NewBufconnServer(unsignedOptions)
+
+	defer os.RemoveAll(unsignedOptions.Dir)
+
+	err = unsignedBs.Start()
+	require.NoError(t, err)
+	defer unsignedBs.Stop()
+
+	stateDir, err := ioutil.TempDir("", "state_signature_verification")
+	require.NoError(t, err)
+	defer os.RemoveAll(stateDir)
+
+	openSession := func(t *testing.T, dialer func(context.Context, string) (net.Conn, error), opts *ic.Options) ic.ImmuClient {
+		client := ic.NewClient().WithOptions(opts.WithDir(stateDir).WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(dialer), grpc.WithInsecure()}))
+		t.Cleanup(func() { client.Disconnect() })
+
+		err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+		require.NoError(t, err)
+
+		_, err = client.Set(context.TODO(), []byte("key"), []byte("value"))
+		require.NoError(t, err)
+
+		return client
+	}
+
+	t.Run("matching signatures are accepted", func(t *testing.T) {
+		client := openSession(t, bs.Dialer, ic.DefaultOptions().WithServerSigningPubKey("./../../test/signer/ec1.pub"))
+		defer client.CloseSession(context.TODO())
+
+		state, err := client.CurrentState(context.TODO())
+		require.NoError(t, err)
+		require.NotNil(t, state.Signature)
+
+		_, err = client.VerifiedGet(context.TODO(), []byte("key"))
+		require.NoError(t, err)
+	})
+
+	t.Run("mismatching signatures are rejected", func(t *testing.T) {
+		client := openSession(t, bs.Dialer, ic.DefaultOptions().WithServerSigningPubKey("./../../test/signer/ec3.pub"))
+		defer client.CloseSession(context.TODO())
+
+		_, err := client.CurrentState(context.TODO())
+		require.ErrorIs(t, err, ic.ErrStateSignatureMismatch)
+
+		_, err = client.VerifiedGet(context.TODO(), []byte("key"))
+		require.ErrorIs(t, err, ic.ErrStateSignatureMismatch)
+	})
+
+	t.Run("unsigned states are rejected", func(t *testing.T) {
+		client := openSession(t, unsignedBs.Dialer, ic.DefaultOptions().WithServerSigningPubKey("./../../test/signer/ec1.pub"))
+		defer client.CloseSession(context.TODO())
+
+		_, err := client.CurrentState(context.TODO())
+		require.ErrorIs(t, err, ic.ErrStateSignatureMissing)
+
+		_, err = client.VerifiedGet(context.TODO(), []byte("key"))
+		require.ErrorIs(t, err, ic.ErrStateSignatureMissing)
+	})
+
+	t.Run("verification can be disabled", func(t *testing.T) {
+		client := openSession(t, bs.Dialer, ic.DefaultOptions().WithServerSigningPubKey("./../../test/signer/ec3.pub").WithVerifyStateSignature(false))
+		defer client.CloseSession(context.TODO())
+
+		_, err := client.CurrentState(context.TODO())
+		require.NoError(t, err)
+
+		_, err = client.VerifiedGet(context.TODO(), []byte("key"))
+		require.NoError(t, err)
+	})
+}
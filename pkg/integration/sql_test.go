@@ -48,6 +48,7 @@ func TestImmuClient_SQL(t *testing.T) {
 
 	client, err := ic.NewImmuClient(clientOpts)
 	require.NoError(t, err)
+	defer client.Disconnect()
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
 
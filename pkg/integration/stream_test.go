@@ -145,6 +145,7 @@ func TestImmuClient_SetMaxValueExceeded(t *testing.T) {
 
 	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
 
@@ -178,6 +179,7 @@ func TestImmuClient_SetMaxTxValuesExceeded(t *testing.T) {
 
 	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
 	require.NoError(t, err)
+	defer client.Disconnect()
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	require.NoError(t, err)
 
@@ -714,6 +716,7 @@ func TestImmuClient_StreamWithSignature(t *testing.T) {
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer client.Disconnect()
 	client.WithTokenService(ts)
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	if err != nil {
@@ -757,6 +760,7 @@ func TestImmuClient_StreamWithSignatureErrors(t *testing.T) {
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer client.Disconnect()
 	client.WithTokenService(ts)
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	if err != nil {
@@ -800,6 +804,7 @@ func TestImmuClient_StreamWithSignatureErrorsMissingServerKey(t *testing.T) {
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer client.Disconnect()
 	client.WithTokenService(ts)
 	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
 	if err != nil {
@@ -1212,6 +1217,7 @@ func TestImmuClient_SessionSetGetStream(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
 	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
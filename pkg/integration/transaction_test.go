@@ -41,6 +41,7 @@ func TestTransaction_SetAndGet(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
 	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
@@ -88,6 +89,7 @@ func TestTransaction_Rollback(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
 	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 
@@ -125,9 +127,11 @@ func TestTransaction_MultipleReadWriteError(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
 	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
+	defer client.CloseSession(context.TODO())
 
 	tx1, err := client.NewTx(context.TODO())
 	require.NoError(t, err)
@@ -148,6 +152,7 @@ func TestTransaction_ChangingDBOnSessionNoError(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
 	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
@@ -156,6 +161,7 @@ func TestTransaction_ChangingDBOnSessionNoError(t *testing.T) {
 	require.NoError(t, err)
 
 	client2 := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client2.Disconnect()
 	err = client2.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
 	err = client2.CreateDatabase(context.TODO(), &schema.DatabaseSettings{DatabaseName: "db2"})
@@ -200,6 +206,7 @@ func TestTransaction_MultiNoErr(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
 	ctx := context.Background()
 
@@ -280,8 +287,14 @@ func TestTransaction_MultiNoErr(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, err.(errors.ImmuError).Code(), errors.CodNoSessionAuthDataProvided)
 
+	err = client.Disconnect()
+	require.NoError(t, err)
+
 	err = client.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "defaultdb")
 	require.NoError(t, err)
+
+	err = client.CloseSession(ctx)
+	require.NoError(t, err)
 }
 
 func TestTransaction_HandlingReadConflict(t *testing.T) {
@@ -295,6 +308,7 @@ func TestTransaction_HandlingReadConflict(t *testing.T) {
 	defer bs.Stop()
 
 	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	defer client.Disconnect()
 
 	ctx := context.Background()
 
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/immuclienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmuClient_TypedValues(t *testing.T) {
+	client, err := immuclienttest.NewBufconnClient()
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+
+	type account struct {
+		Owner   string `json:"owner"`
+		Balance int    `json:"balance"`
+	}
+
+	_, err = client.SetJSON(ctx, []byte("acc1"), &account{Owner: "alice", Balance: 10})
+	require.NoError(t, err)
+
+	_, err = client.VerifiedSetJSON(ctx, []byte("acc2"), &account{Owner: "bob", Balance: 20})
+	require.NoError(t, err)
+
+	var acc account
+
+	_, err = client.GetJSON(ctx, []byte("acc1"), &acc)
+	require.NoError(t, err)
+	require.Equal(t, account{Owner: "alice", Balance: 10}, acc)
+
+	entry, err := client.VerifiedGetJSON(ctx, []byte("acc2"), &acc)
+	require.NoError(t, err)
+	require.Equal(t, []byte("acc2"), entry.Key)
+	require.Equal(t, account{Owner: "bob", Balance: 20}, acc)
+
+	_, err = client.Set(ctx, []byte("notjson"), []byte("{"))
+	require.NoError(t, err)
+
+	_, err = client.GetJSON(ctx, []byte("notjson"), &acc)
+	require.Error(t, err)
+
+	_, err = client.SetProto(ctx, []byte("db1"), &schema.Database{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	_, err = client.VerifiedSetProto(ctx, []byte("db2"), &schema.Database{DatabaseName: "db2"})
+	require.NoError(t, err)
+
+	var db schema.Database
+
+	_, err = client.GetProto(ctx, []byte("db1"), &db)
+	require.NoError(t, err)
+	require.Equal(t, "db1", db.DatabaseName)
+
+	_, err = client.VerifiedGetProto(ctx, []byte("db2"), &db)
+	require.NoError(t, err)
+	require.Equal(t, "db2", db.DatabaseName)
+
+	_, err = client.GetProto(ctx, []byte("missing"), &db)
+	require.Error(t, err)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import "sync/atomic"
+
+// levelNotSet means messages are filtered by the wrapped logger
+const levelNotSet = -1
+
+// DynamicLogger is a logger whose level can be changed while it's in use.
+// Until a level is set, messages are filtered by the level of the wrapped logger.
+type DynamicLogger struct {
+	logger     Logger
+	unfiltered Logger
+	level      int32
+}
+
+// NewDynamicLogger returns a logger writing through l whose level can be changed at runtime
+func NewDynamicLogger(l Logger) *DynamicLogger {
+	return &DynamicLogger{
+		logger:     l,
+		unfiltered: l.CloneWithLevel(LogDebug),
+		level:      levelNotSet,
+	}
+}
+
+// SetLevel changes the level of the messages written from now on
+func (l *DynamicLogger) SetLevel(level LogLevel) {
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+// ResetLevel restores the level of the wrapped logger
+func (l *DynamicLogger) ResetLevel() {
+	atomic.StoreInt32(&l.level, levelNotSet)
+}
+
+// Level returns the level set at runtime, false is returned when no level was set
+func (l *DynamicLogger) Level() (LogLevel, bool) {
+	level := atomic.LoadInt32(&l.level)
+	if level == levelNotSet {
+		return LogInfo, false
+	}
+	return LogLevel(level), true
+}
+
+// target returns the logger a message of the given level is written to, nil when it must be discarded
+func (l *DynamicLogger) target(msgLevel LogLevel) Logger {
+	level, ok := l.Level()
+	if !ok {
+		return l.logger
+	}
+	if level > msgLevel {
+		return nil
+	}
+	return l.unfiltered
+}
+
+func (l *DynamicLogger) Errorf(f string, v ...interface{}) {
+	if t := l.target(LogError); t != nil {
+		t.Errorf(f, v...)
+	}
+}
+
+func (l *DynamicLogger) Warningf(f string, v ...interface{}) {
+	if t := l.target(LogWarn); t != nil {
+		t.Warningf(f, v...)
+	}
+}
+
+func (l *DynamicLogger) Infof(f string, v ...interface{}) {
+	if t := l.target(LogInfo); t != nil {
+		t.Infof(f, v...)
+	}
+}
+
+func (l *DynamicLogger) Debugf(f string, v ...interface{}) {
+	if t := l.target(LogDebug); t != nil {
+		t.Debugf(f, v...)
+	}
+}
+
+// CloneWithLevel returns a logger of the wrapped one with a fixed level
+func (l *DynamicLogger) CloneWithLevel(level LogLevel) Logger {
+	return l.logger.CloneWithLevel(level)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamicLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := NewDynamicLogger(NewSimpleLoggerWithLevel("test-dynamic-logger", out, LogWarn))
+
+	_, ok := l.Level()
+	require.False(t, ok)
+
+	l.Infof("some info %d", 1)
+	l.Warningf("some warning %d", 1)
+	require.NotContains(t, out.String(), "some info 1")
+	require.Contains(t, out.String(), " WARNING: some warning 1")
+
+	l.SetLevel(LogDebug)
+	level, ok := l.Level()
+	require.True(t, ok)
+	require.Equal(t, LogDebug, level)
+
+	l.Debugf("some debug %d", 2)
+	require.Contains(t, out.String(), " DEBUG: some debug 2")
+
+	l.SetLevel(LogError)
+	l.Warningf("some warning %d", 3)
+	l.Errorf("some error %d", 3)
+	require.NotContains(t, out.String(), "some warning 3")
+	require.Contains(t, out.String(), " ERROR: some error 3")
+
+	l.ResetLevel()
+	l.Warningf("some warning %d", 4)
+	require.Contains(t, out.String(), " WARNING: some warning 4")
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for _, level := range []LogLevel{LogDebug, LogInfo, LogWarn, LogError} {
+		parsed, err := ParseLogLevel(level.String())
+		require.NoError(t, err)
+		require.Equal(t, level, parsed)
+	}
+
+	_, err := ParseLogLevel("verbose")
+	require.Error(t, err)
+}
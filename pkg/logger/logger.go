@@ -17,6 +17,7 @@ limitations under the License.
 package logger
 
 import (
+	"fmt"
 	"os"
 	"strings"
 )
@@ -43,15 +44,38 @@ type Logger interface {
 
 func logLevelFromEnvironment() LogLevel {
 	logLevel, _ := os.LookupEnv("LOG_LEVEL")
-	switch strings.ToLower(logLevel) {
+	level, err := ParseLogLevel(logLevel)
+	if err != nil {
+		return LogInfo
+	}
+	return level
+}
+
+// ParseLogLevel returns the level named by s, one of debug, info, warn or error
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(s) {
 	case "error":
-		return LogError
+		return LogError, nil
 	case "warn":
-		return LogWarn
+		return LogWarn, nil
 	case "info":
-		return LogInfo
+		return LogInfo, nil
 	case "debug":
-		return LogDebug
+		return LogDebug, nil
+	}
+	return LogInfo, fmt.Errorf("invalid log level '%s', expected one of debug, info, warn or error", s)
+}
+
+func (level LogLevel) String() string {
+	switch level {
+	case LogError:
+		return "error"
+	case LogWarn:
+		return "warn"
+	case LogInfo:
+		return "info"
+	case LogDebug:
+		return "debug"
 	}
-	return LogInfo
+	return fmt.Sprintf("LogLevel(%d)", level)
 }
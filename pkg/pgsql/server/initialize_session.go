@@ -22,6 +22,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/database"
 	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
 	bm "github.com/codenotary/immudb/pkg/pgsql/server/bmessages"
@@ -160,7 +161,7 @@ func (s *session) HandleStartup(dbList database.DatabaseList) (err error) {
 		if !ok || pw.GetSecret() == "" {
 			return pserr.ErrPwNotprovided
 		}
-		usr, err := s.getUser([]byte(s.username))
+		usr, err := auth.GetUser(s.sysDb, []byte(s.username))
 		if err != nil {
 			if strings.Contains(err.Error(), "key not found") {
 				return pserr.ErrUsernameNotFound
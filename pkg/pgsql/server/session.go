@@ -18,9 +18,6 @@ package server
 
 import (
 	"crypto/tls"
-	"encoding/json"
-	"github.com/codenotary/immudb/pkg/api/schema"
-	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/codenotary/immudb/pkg/pgsql/errors"
@@ -131,24 +128,3 @@ func (s *session) debugMessage(msg []byte) {
 		s.log.Debugf("write %s - %s message", string(msg[0]), pgmeta.MTypes[msg[0]])
 	}
 }
-
-func (s *session) getUser(username []byte) (*auth.User, error) {
-	key := make([]byte, 1+len(username))
-	// todo put KeyPrefixUser in a common package
-	key[0] = 1
-	copy(key[1:], username)
-
-	item, err := s.sysDb.Get(&schema.KeyRequest{Key: key})
-	if err != nil {
-		return nil, err
-	}
-
-	var usr auth.User
-
-	err = json.Unmarshal(item.Value, &usr)
-	if err != nil {
-		return nil, err
-	}
-
-	return &usr, nil
-}
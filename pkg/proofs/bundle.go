@@ -0,0 +1,99 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proofs
+
+import (
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// Bundle is a self-contained set of proofs of key-values set in a database, all of them proven
+// against the same signed state so they can be verified offline
+type Bundle struct {
+	State   *SignedState  `json:"state"`
+	Entries []*EntryProof `json:"entries"`
+	// Consistency links the state of the bundle to a state the bundle was exported for,
+	// the transaction of the latter is the source of the proof when older and the target otherwise
+	Consistency *DualProof `json:"consistency,omitempty"`
+}
+
+// EntryProof proves a key-value was set by a transaction committed at or before the state of the bundle
+type EntryProof struct {
+	Key            HexBytes        `json:"key"`
+	Value          HexBytes        `json:"value"`
+	Metadata       *KVMetadata     `json:"metadata,omitempty"`
+	Tx             uint64          `json:"tx"`
+	InclusionProof *InclusionProof `json:"inclusionProof"`
+	// DualProof links the transaction which set the key-value to the state of the bundle
+	DualProof *DualProof `json:"dualProof"`
+}
+
+// KVMetadata is the metadata of a key-value, ExpiresAt is the unix time it expires at when set
+type KVMetadata struct {
+	Deleted      bool              `json:"deleted,omitempty"`
+	ExpiresAt    int64             `json:"expiresAt,omitempty"`
+	NonIndexable bool              `json:"nonIndexable,omitempty"`
+	ContentType  string            `json:"contentType,omitempty"`
+	Encoding     string            `json:"encoding,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+}
+
+func FromKVMetadata(md *schema.KVMetadata) *KVMetadata {
+	if md == nil {
+		return nil
+	}
+
+	m := &KVMetadata{
+		Deleted:      md.Deleted,
+		NonIndexable: md.NonIndexeable,
+		ContentType:  md.ContentType,
+		Encoding:     md.Encoding,
+		Headers:      md.Headers,
+	}
+
+	if md.Expiration != nil {
+		m.ExpiresAt = md.Expiration.ExpiresAt
+	}
+
+	return m
+}
+
+func (m *KVMetadata) ToKVMetadata() *store.KVMetadata {
+	if m == nil {
+		return nil
+	}
+
+	md := store.NewKVMetadata()
+
+	md.AsDeleted(m.Deleted)
+
+	if m.ExpiresAt > 0 {
+		md.ExpiresAt(time.Unix(m.ExpiresAt, 0))
+	}
+
+	md.AsNonIndexable(m.NonIndexable)
+	md.SetContentType(m.ContentType)
+	md.SetEncoding(m.Encoding)
+
+	for k, v := range m.Headers {
+		md.SetHeader(k, v)
+	}
+
+	return md
+}
@@ -0,0 +1,539 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proofs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// cbor major types
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborSimple = 7
+)
+
+const (
+	cborFalse = 0xf4
+	cborTrue  = 0xf5
+	cborNull  = 0xf6
+)
+
+// cborField is a struct field encoded as a map entry, keyed by its json name
+type cborField struct {
+	index     int
+	key       []byte
+	omitEmpty bool
+}
+
+// cborFields returns the fields of a struct sorted by their encoded key
+func cborFields(t reflect.Type) []cborField {
+	var fields []cborField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		omitEmpty := false
+
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			opts := strings.Split(tag, ",")
+
+			if opts[0] == "-" {
+				continue
+			}
+
+			if opts[0] != "" {
+				name = opts[0]
+			}
+
+			for _, opt := range opts[1:] {
+				omitEmpty = omitEmpty || opt == "omitempty"
+			}
+		}
+
+		var key bytes.Buffer
+		writeCBORText(&key, name)
+
+		fields = append(fields, cborField{index: i, key: key.Bytes(), omitEmpty: omitEmpty})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return bytes.Compare(fields[i].key, fields[j].key) < 0
+	})
+
+	return fields
+}
+
+func writeCBORHead(buf *bytes.Buffer, major byte, arg uint64) {
+	switch {
+	case arg < 24:
+		buf.WriteByte(major<<5 | byte(arg))
+	case arg <= math.MaxUint8:
+		buf.Write([]byte{major<<5 | 24, byte(arg)})
+	case arg <= math.MaxUint16:
+		var b [3]byte
+		b[0] = major<<5 | 25
+		binary.BigEndian.PutUint16(b[1:], uint16(arg))
+		buf.Write(b[:])
+	case arg <= math.MaxUint32:
+		var b [5]byte
+		b[0] = major<<5 | 26
+		binary.BigEndian.PutUint32(b[1:], uint32(arg))
+		buf.Write(b[:])
+	default:
+		var b [9]byte
+		b[0] = major<<5 | 27
+		binary.BigEndian.PutUint64(b[1:], arg)
+		buf.Write(b[:])
+	}
+}
+
+func writeCBORText(buf *bytes.Buffer, s string) {
+	writeCBORHead(buf, cborText, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func encodeCBOR(buf *bytes.Buffer, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(cborNull)
+			return nil
+		}
+		return encodeCBOR(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(cborTrue)
+		} else {
+			buf.WriteByte(cborFalse)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		writeCBORHead(buf, cborUint, v.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		if i < 0 {
+			writeCBORHead(buf, cborNegInt, uint64(-(i + 1)))
+		} else {
+			writeCBORHead(buf, cborUint, uint64(i))
+		}
+	case reflect.String:
+		writeCBORText(buf, v.String())
+	case reflect.Array:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+		}
+
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+
+		writeCBORHead(buf, cborBytes, uint64(len(b)))
+		buf.Write(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			writeCBORHead(buf, cborBytes, uint64(v.Len()))
+			buf.Write(v.Bytes())
+			return nil
+		}
+
+		writeCBORHead(buf, cborArray, uint64(v.Len()))
+
+		for i := 0; i < v.Len(); i++ {
+			err := encodeCBOR(buf, v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+		}
+
+		type entry struct {
+			key   []byte
+			value reflect.Value
+		}
+
+		entries := make([]entry, 0, v.Len())
+
+		for _, k := range v.MapKeys() {
+			var key bytes.Buffer
+			writeCBORText(&key, k.String())
+
+			entries = append(entries, entry{key: key.Bytes(), value: v.MapIndex(k)})
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i].key, entries[j].key) < 0
+		})
+
+		writeCBORHead(buf, cborMap, uint64(len(entries)))
+
+		for _, e := range entries {
+			buf.Write(e.key)
+
+			err := encodeCBOR(buf, e.value)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		var fields []cborField
+
+		for _, f := range cborFields(v.Type()) {
+			if f.omitEmpty && isEmptyValue(v.Field(f.index)) {
+				continue
+			}
+			fields = append(fields, f)
+		}
+
+		writeCBORHead(buf, cborMap, uint64(len(fields)))
+
+		for _, f := range fields {
+			buf.Write(f.key)
+
+			err := encodeCBOR(buf, v.Field(f.index))
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+	}
+
+	return nil
+}
+
+// isEmptyValue follows the omitempty semantics of encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+type cborDecoder struct {
+	data []byte
+	off  int
+}
+
+func (d *cborDecoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidEncoding, fmt.Sprintf(format, args...), d.off)
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, d.errorf("unexpected end of data")
+	}
+
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+
+	return b, nil
+}
+
+// head reads the initial byte of a data item and its argument, which must be encoded in its shortest form
+func (d *cborDecoder) head() (major byte, arg uint64, err error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	major = b[0] >> 5
+	info := b[0] & 0x1f
+
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+
+	// floats and indefinite lengths are not used by the canonical encoding
+	if info > 27 || major == cborSimple {
+		return 0, 0, d.errorf("unsupported data item 0x%x", b[0])
+	}
+
+	size := uint64(1) << (info - 24)
+
+	b, err = d.read(size)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, x := range b {
+		arg = arg<<8 | uint64(x)
+	}
+
+	// the shortest form takes half the bytes when the argument fits
+	if arg < 24 || (size > 1 && arg>>(size*4) == 0) {
+		return 0, 0, d.errorf("non-canonical argument")
+	}
+
+	return major, arg, nil
+}
+
+func (d *cborDecoder) expect(major, expected byte) error {
+	if major != expected {
+		return d.errorf("unexpected major type %d", major)
+	}
+	return nil
+}
+
+func (d *cborDecoder) decode(v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if d.off < len(d.data) && d.data[d.off] == cborNull {
+			d.off++
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return d.decode(v.Elem())
+	}
+
+	major, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if major != cborSimple || (arg != cborFalse&0x1f && arg != cborTrue&0x1f) {
+			return d.errorf("boolean expected")
+		}
+		v.SetBool(arg == cborTrue&0x1f)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		err = d.expect(major, cborUint)
+		if err != nil {
+			return err
+		}
+
+		if v.OverflowUint(arg) {
+			return d.errorf("integer overflow")
+		}
+		v.SetUint(arg)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if major != cborUint && major != cborNegInt {
+			return d.errorf("integer expected")
+		}
+
+		if arg > math.MaxInt64 {
+			return d.errorf("integer overflow")
+		}
+
+		i := int64(arg)
+		if major == cborNegInt {
+			i = -1 - i
+		}
+
+		if v.OverflowInt(i) {
+			return d.errorf("integer overflow")
+		}
+		v.SetInt(i)
+	case reflect.String:
+		err = d.expect(major, cborText)
+		if err != nil {
+			return err
+		}
+
+		b, err := d.read(arg)
+		if err != nil {
+			return err
+		}
+
+		if !utf8.Valid(b) {
+			return d.errorf("invalid utf-8 string")
+		}
+		v.SetString(string(b))
+	case reflect.Array:
+		err = d.expect(major, cborBytes)
+		if err != nil {
+			return err
+		}
+
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+		}
+
+		if arg != uint64(v.Len()) {
+			return d.errorf("%d bytes expected", v.Len())
+		}
+
+		b, err := d.read(arg)
+		if err != nil {
+			return err
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			err = d.expect(major, cborBytes)
+			if err != nil {
+				return err
+			}
+
+			b, err := d.read(arg)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte{}, b...))
+
+			return nil
+		}
+
+		err = d.expect(major, cborArray)
+		if err != nil {
+			return err
+		}
+
+		// each item takes at least one byte
+		if arg > uint64(len(d.data)-d.off) {
+			return d.errorf("unexpected end of data")
+		}
+
+		s := reflect.MakeSlice(v.Type(), int(arg), int(arg))
+
+		for i := 0; i < int(arg); i++ {
+			err = d.decode(s.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		err = d.expect(major, cborMap)
+		if err != nil {
+			return err
+		}
+
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+		}
+
+		m := reflect.MakeMap(v.Type())
+
+		var prevKey []byte
+
+		for i := uint64(0); i < arg; i++ {
+			key, text, err := d.mapKey(prevKey)
+			if err != nil {
+				return err
+			}
+			prevKey = key
+
+			value := reflect.New(v.Type().Elem()).Elem()
+
+			err = d.decode(value)
+			if err != nil {
+				return err
+			}
+
+			m.SetMapIndex(reflect.ValueOf(text).Convert(v.Type().Key()), value)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		err = d.expect(major, cborMap)
+		if err != nil {
+			return err
+		}
+
+		fields := make(map[string]int)
+		for _, f := range cborFields(v.Type()) {
+			fields[string(f.key)] = f.index
+		}
+
+		var prevKey []byte
+
+		for i := uint64(0); i < arg; i++ {
+			key, text, err := d.mapKey(prevKey)
+			if err != nil {
+				return err
+			}
+			prevKey = key
+
+			index, ok := fields[string(key)]
+			if !ok {
+				return d.errorf("unknown field '%s'", text)
+			}
+
+			err = d.decode(v.Field(index))
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+	}
+
+	return nil
+}
+
+// mapKey reads a text map key, its encoding must sort after the one of the previous key
+func (d *cborDecoder) mapKey(prevKey []byte) (key []byte, text string, err error) {
+	start := d.off
+
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = d.expect(major, cborText)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b, err := d.read(arg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !utf8.Valid(b) {
+		return nil, "", d.errorf("invalid utf-8 string")
+	}
+
+	key = d.data[start:d.off]
+
+	if prevKey != nil && bytes.Compare(prevKey, key) >= 0 {
+		return nil, "", d.errorf("map keys not sorted or duplicated")
+	}
+
+	return key, string(b), nil
+}
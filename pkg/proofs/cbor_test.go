@@ -0,0 +1,106 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proofs
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBOREncoding(t *testing.T) {
+	// examples taken from RFC 8949, appendix A
+	for _, c := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{uint64(0), "00"},
+		{uint64(23), "17"},
+		{uint64(24), "1818"},
+		{uint64(100), "1864"},
+		{uint64(1000), "1903e8"},
+		{uint64(1000000), "1a000f4240"},
+		{uint64(1000000000000), "1b000000e8d4a51000"},
+		{int64(-1), "20"},
+		{int64(-100), "3863"},
+		{int64(-1000), "3903e7"},
+		{true, "f5"},
+		{false, "f4"},
+		{"", "60"},
+		{"IETF", "6449455446"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]uint64{1, 2, 3}, "83010203"},
+		{map[string]string{"a": "A", "b": "B", "c": "C"}, "a3616161416162614261636143"},
+		// shorter keys sort first
+		{map[string]uint64{"bb": 1, "a": 2}, "a261610262626201"},
+	} {
+		b, err := MarshalCBOR(c.value)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, hex.EncodeToString(b))
+	}
+
+	_, err := MarshalCBOR(1.5)
+	require.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestCBORDecodingIsStrict(t *testing.T) {
+	var n uint64
+
+	err := UnmarshalCBOR([]byte{0x18, 0x64}, &n)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), n)
+
+	err = UnmarshalCBOR([]byte{0x18, 0x64}, n)
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	for _, c := range []struct {
+		encoded string
+		value   interface{}
+	}{
+		// non-shortest integers
+		{"1817", new(uint64)},
+		{"190064", new(uint64)},
+		{"1a0000ffff", new(uint64)},
+		{"1b00000000ffffffff", new(uint64)},
+		// indefinite length
+		{"5f440102030400ff", new([]byte)},
+		// trailing data
+		{"0000", new(uint64)},
+		// truncated data
+		{"1903", new(uint64)},
+		{"4401", new([]byte)},
+		// overflow
+		{"1b8000000000000000", new(int64)},
+		// unexpected type
+		{"20", new(uint64)},
+		// unsorted and duplicated keys
+		{"a2616201616101", new(map[string]uint64)},
+		{"a2616101616102", new(map[string]uint64)},
+		{"a262626201616102", new(map[string]uint64)},
+		// unknown fields
+		{"a1636b657901", new(LinearProof)},
+		// digests must be 32 bytes long
+		{"4401020304", new(Digest)},
+	} {
+		encoded, err := hex.DecodeString(c.encoded)
+		require.NoError(t, err)
+
+		err = UnmarshalCBOR(encoded, c.value)
+		require.ErrorIs(t, err, ErrInvalidEncoding, c.encoded)
+	}
+}
@@ -0,0 +1,379 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package proofs defines canonical encodings of inclusion proofs, dual proofs, signed states and bundles of them,
+so they can be archived and verified by implementations not based on immudb libraries.
+
+Proofs are encoded either as JSON or as CBOR (RFC 8949), with the same field names in both:
+
+  - JSON objects hold their fields in declaration order, digests and signatures are lowercase hex strings
+  - CBOR follows the core deterministic encoding requirements (RFC 8949, section 4.2.1): integers are
+    encoded in their shortest form, lengths are definite and map keys are sorted by their encoding.
+    Digests and signatures are byte strings
+
+Decoding is strict: unknown fields, non-canonical CBOR and digests not 32 bytes long are rejected.
+*/
+package proofs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/codenotary/immudb/embedded/hashing"
+	"github.com/codenotary/immudb/embedded/htree"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+var ErrInvalidEncoding = errors.New("invalid proof encoding")
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// Digest is a 32 bytes hash value, hex encoded in JSON
+type Digest [sha256.Size]byte
+
+func (d Digest) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(d[:])), nil
+}
+
+func (d *Digest) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("%w: invalid digest '%s'", ErrInvalidEncoding, text)
+	}
+
+	copy(d[:], b)
+
+	return nil
+}
+
+// HexBytes is a variable length byte sequence, hex encoded in JSON
+type HexBytes []byte
+
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+func (b *HexBytes) UnmarshalText(text []byte) error {
+	decoded, err := hex.DecodeString(string(text))
+	if err != nil {
+		return fmt.Errorf("%w: invalid hex string '%s'", ErrInvalidEncoding, text)
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+type InclusionProof struct {
+	Leaf  uint64   `json:"leaf"`
+	Width uint64   `json:"width"`
+	Terms []Digest `json:"terms"`
+}
+
+type TxHeader struct {
+	ID            uint64            `json:"id"`
+	Ts            int64             `json:"ts"`
+	BlTxID        uint64            `json:"blTxId"`
+	BlRoot        Digest            `json:"blRoot"`
+	PrevAlh       Digest            `json:"prevAlh"`
+	Version       int               `json:"version"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	Writer        string            `json:"writer,omitempty"`
+	NEntries      uint64            `json:"nEntries"`
+	Eh            Digest            `json:"eh"`
+	HashAlgorithm string            `json:"hashAlgorithm"`
+}
+
+type LinearProof struct {
+	SourceTxID uint64   `json:"sourceTxId"`
+	TargetTxID uint64   `json:"targetTxId"`
+	Terms      []Digest `json:"terms"`
+}
+
+type DualProof struct {
+	SourceTxHeader     *TxHeader    `json:"sourceTxHeader"`
+	TargetTxHeader     *TxHeader    `json:"targetTxHeader"`
+	InclusionProof     []Digest     `json:"inclusionProof"`
+	ConsistencyProof   []Digest     `json:"consistencyProof"`
+	TargetBlTxAlh      Digest       `json:"targetBlTxAlh"`
+	LastInclusionProof []Digest     `json:"lastInclusionProof"`
+	LinearProof        *LinearProof `json:"linearProof"`
+}
+
+type Signature struct {
+	PublicKey HexBytes `json:"publicKey"`
+	Signature HexBytes `json:"signature"`
+}
+
+// SignedState is the state of a database as signed by the server, the signature is omitted
+// when the server has no signing key
+type SignedState struct {
+	Db        string     `json:"db"`
+	TxID      uint64     `json:"txId"`
+	TxHash    Digest     `json:"txHash"`
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+func FromInclusionProof(proof *htree.InclusionProof) *InclusionProof {
+	return &InclusionProof{
+		Leaf:  uint64(proof.Leaf),
+		Width: uint64(proof.Width),
+		Terms: fromDigests(proof.Terms),
+	}
+}
+
+func (p *InclusionProof) ToInclusionProof() *htree.InclusionProof {
+	return &htree.InclusionProof{
+		Leaf:  int(p.Leaf),
+		Width: int(p.Width),
+		Terms: toDigests(p.Terms),
+	}
+}
+
+func FromTxHeader(hdr *store.TxHeader) *TxHeader {
+	if hdr == nil {
+		return nil
+	}
+
+	var annotations map[string]string
+	if !hdr.Metadata.IsEmpty() {
+		annotations = hdr.Metadata.Annotations()
+	}
+
+	return &TxHeader{
+		ID:            hdr.ID,
+		Ts:            hdr.Ts,
+		BlTxID:        hdr.BlTxID,
+		BlRoot:        hdr.BlRoot,
+		PrevAlh:       hdr.PrevAlh,
+		Version:       hdr.Version,
+		Annotations:   annotations,
+		Writer:        hdr.Metadata.Writer(),
+		NEntries:      uint64(hdr.NEntries),
+		Eh:            hdr.Eh,
+		HashAlgorithm: hdr.HashAlgorithm.String(),
+	}
+}
+
+func (h *TxHeader) ToTxHeader() (*store.TxHeader, error) {
+	if h == nil {
+		return nil, nil
+	}
+
+	hashAlg, ok := hashing.AlgorithmFromName(h.HashAlgorithm)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown hash algorithm '%s'", ErrInvalidEncoding, h.HashAlgorithm)
+	}
+
+	var md *store.TxMetadata
+	if len(h.Annotations) > 0 || h.Writer != "" {
+		md = store.NewTxMetadata().WithWriter(h.Writer)
+
+		for k, v := range h.Annotations {
+			md.WithAnnotation(k, v)
+		}
+	}
+
+	return &store.TxHeader{
+		ID:            h.ID,
+		Ts:            h.Ts,
+		BlTxID:        h.BlTxID,
+		BlRoot:        h.BlRoot,
+		PrevAlh:       h.PrevAlh,
+		Version:       h.Version,
+		Metadata:      md,
+		NEntries:      int(h.NEntries),
+		Eh:            h.Eh,
+		HashAlgorithm: hashAlg,
+	}, nil
+}
+
+func FromLinearProof(proof *store.LinearProof) *LinearProof {
+	if proof == nil {
+		return nil
+	}
+
+	return &LinearProof{
+		SourceTxID: proof.SourceTxID,
+		TargetTxID: proof.TargetTxID,
+		Terms:      fromDigests(proof.Terms),
+	}
+}
+
+func (p *LinearProof) ToLinearProof() *store.LinearProof {
+	if p == nil {
+		return nil
+	}
+
+	return &store.LinearProof{
+		SourceTxID: p.SourceTxID,
+		TargetTxID: p.TargetTxID,
+		Terms:      toDigests(p.Terms),
+	}
+}
+
+func FromDualProof(proof *store.DualProof) *DualProof {
+	return &DualProof{
+		SourceTxHeader:     FromTxHeader(proof.SourceTxHeader),
+		TargetTxHeader:     FromTxHeader(proof.TargetTxHeader),
+		InclusionProof:     fromDigests(proof.InclusionProof),
+		ConsistencyProof:   fromDigests(proof.ConsistencyProof),
+		TargetBlTxAlh:      proof.TargetBlTxAlh,
+		LastInclusionProof: fromDigests(proof.LastInclusionProof),
+		LinearProof:        FromLinearProof(proof.LinearProof),
+	}
+}
+
+func (p *DualProof) ToDualProof() (*store.DualProof, error) {
+	sourceTxHeader, err := p.SourceTxHeader.ToTxHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	targetTxHeader, err := p.TargetTxHeader.ToTxHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	return &store.DualProof{
+		SourceTxHeader:     sourceTxHeader,
+		TargetTxHeader:     targetTxHeader,
+		InclusionProof:     toDigests(p.InclusionProof),
+		ConsistencyProof:   toDigests(p.ConsistencyProof),
+		TargetBlTxAlh:      p.TargetBlTxAlh,
+		LastInclusionProof: toDigests(p.LastInclusionProof),
+		LinearProof:        p.LinearProof.ToLinearProof(),
+	}, nil
+}
+
+func FromImmutableState(state *schema.ImmutableState) (*SignedState, error) {
+	if len(state.TxHash) != sha256.Size {
+		return nil, fmt.Errorf("%w: invalid tx hash length %d", ErrInvalidEncoding, len(state.TxHash))
+	}
+
+	s := &SignedState{
+		Db:   state.Db,
+		TxID: state.TxId,
+	}
+	copy(s.TxHash[:], state.TxHash)
+
+	if state.Signature != nil {
+		s.Signature = &Signature{
+			PublicKey: state.Signature.PublicKey,
+			Signature: state.Signature.Signature,
+		}
+	}
+
+	return s, nil
+}
+
+func (s *SignedState) ToImmutableState() *schema.ImmutableState {
+	state := &schema.ImmutableState{
+		Db:     s.Db,
+		TxId:   s.TxID,
+		TxHash: append([]byte{}, s.TxHash[:]...),
+	}
+
+	if s.Signature != nil {
+		state.Signature = &schema.Signature{
+			PublicKey: s.Signature.PublicKey,
+			Signature: s.Signature.Signature,
+		}
+	}
+
+	return state
+}
+
+// MarshalJSON returns the canonical json encoding of a proof or a signed state
+func MarshalJSON(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a proof or a signed state, unknown fields are rejected
+func UnmarshalJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+
+	if dec.More() {
+		return fmt.Errorf("%w: unexpected data after the encoded value", ErrInvalidEncoding)
+	}
+
+	return nil
+}
+
+// MarshalCBOR returns the canonical cbor encoding of a proof or a signed state
+func MarshalCBOR(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := encodeCBOR(&buf, reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCBOR decodes a proof or a signed state, non-canonical encodings are rejected
+func UnmarshalCBOR(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%w: a non-nil pointer is required", ErrUnsupportedType)
+	}
+
+	dec := &cborDecoder{data: data}
+
+	err := dec.decode(rv.Elem())
+	if err != nil {
+		return err
+	}
+
+	if dec.off != len(data) {
+		return fmt.Errorf("%w: unexpected data after the encoded value", ErrInvalidEncoding)
+	}
+
+	return nil
+}
+
+func fromDigests(terms [][sha256.Size]byte) []Digest {
+	digests := make([]Digest, len(terms))
+
+	for i, t := range terms {
+		digests[i] = t
+	}
+
+	return digests
+}
+
+func toDigests(digests []Digest) [][sha256.Size]byte {
+	terms := make([][sha256.Size]byte, len(digests))
+
+	for i, d := range digests {
+		terms[i] = d
+	}
+
+	return terms
+}
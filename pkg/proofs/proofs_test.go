@@ -0,0 +1,222 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proofs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/htree"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+)
+
+type encoding struct {
+	name      string
+	marshal   func(v interface{}) ([]byte, error)
+	unmarshal func(data []byte, v interface{}) error
+}
+
+var encodings = []encoding{
+	{"json", MarshalJSON, UnmarshalJSON},
+	{"cbor", MarshalCBOR, UnmarshalCBOR},
+}
+
+func openStore(t *testing.T) (*store.ImmuStore, func()) {
+	dir, err := ioutil.TempDir("", "proofs")
+	require.NoError(t, err)
+
+	st, err := store.Open(dir, store.DefaultOptions())
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		tx, err := st.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		if i%2 == 0 {
+			tx.WithMetadata(store.NewTxMetadata().WithAnnotation("index", fmt.Sprintf("%d", i)))
+		}
+
+		for j := 0; j < 3; j++ {
+			err = tx.Set([]byte(fmt.Sprintf("key%d_%d", i, j)), nil, []byte(fmt.Sprintf("value%d_%d", i, j)))
+			require.NoError(t, err)
+		}
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	return st, func() {
+		st.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestInclusionProofEncoding(t *testing.T) {
+	st, closeStore := openStore(t)
+	defer closeStore()
+
+	tx := st.NewTxHolder()
+
+	err := st.ReadTx(5, tx)
+	require.NoError(t, err)
+
+	key := []byte("key4_1")
+
+	proof, err := tx.Proof(key)
+	require.NoError(t, err)
+
+	index, err := tx.IndexOf(key)
+	require.NoError(t, err)
+
+	entryDigest, err := tx.TxEntryDigest()
+	require.NoError(t, err)
+
+	digest := entryDigest(tx.Entries()[index])
+
+	for _, enc := range encodings {
+		t.Run(enc.name, func(t *testing.T) {
+			encoded, err := enc.marshal(FromInclusionProof(proof))
+			require.NoError(t, err)
+
+			var decoded InclusionProof
+
+			err = enc.unmarshal(encoded, &decoded)
+			require.NoError(t, err)
+
+			reencoded, err := enc.marshal(&decoded)
+			require.NoError(t, err)
+			require.Equal(t, encoded, reencoded)
+
+			require.True(t, htree.VerifyInclusion(decoded.ToInclusionProof(), digest, tx.Header().Eh, tx.Header().HashAlgorithm))
+		})
+	}
+}
+
+func TestDualProofEncoding(t *testing.T) {
+	st, closeStore := openStore(t)
+	defer closeStore()
+
+	sourceTx := st.NewTxHolder()
+
+	err := st.ReadTx(3, sourceTx)
+	require.NoError(t, err)
+
+	targetTx := st.NewTxHolder()
+
+	err = st.ReadTx(10, targetTx)
+	require.NoError(t, err)
+
+	proof, err := st.DualProof(sourceTx, targetTx)
+	require.NoError(t, err)
+
+	for _, enc := range encodings {
+		t.Run(enc.name, func(t *testing.T) {
+			encoded, err := enc.marshal(FromDualProof(proof))
+			require.NoError(t, err)
+
+			var decoded DualProof
+
+			err = enc.unmarshal(encoded, &decoded)
+			require.NoError(t, err)
+
+			reencoded, err := enc.marshal(&decoded)
+			require.NoError(t, err)
+			require.Equal(t, encoded, reencoded)
+
+			dproof, err := decoded.ToDualProof()
+			require.NoError(t, err)
+
+			require.True(t, store.VerifyDualProof(dproof, 3, 10, sourceTx.Header().Alh(), targetTx.Header().Alh()))
+		})
+	}
+
+	invalidProof := FromDualProof(proof)
+	invalidProof.TargetTxHeader.HashAlgorithm = "md5"
+
+	_, err = invalidProof.ToDualProof()
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+}
+
+func TestSignedStateEncoding(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	alh := sha256.Sum256([]byte("alh"))
+
+	state := &schema.ImmutableState{Db: "defaultdb", TxId: 10, TxHash: alh[:]}
+
+	sig, pub, err := signer.NewSignerFromPKey(rand.Reader, privateKey).Sign(state.ToBytes())
+	require.NoError(t, err)
+
+	state.Signature = &schema.Signature{PublicKey: pub, Signature: sig}
+
+	for _, enc := range encodings {
+		t.Run(enc.name, func(t *testing.T) {
+			signedState, err := FromImmutableState(state)
+			require.NoError(t, err)
+
+			encoded, err := enc.marshal(signedState)
+			require.NoError(t, err)
+
+			var decoded SignedState
+
+			err = enc.unmarshal(encoded, &decoded)
+			require.NoError(t, err)
+
+			ok, err := decoded.ToImmutableState().CheckSignature(&privateKey.PublicKey)
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	}
+
+	_, err = FromImmutableState(&schema.ImmutableState{Db: "defaultdb", TxId: 10, TxHash: []byte("alh")})
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+}
+
+func TestJSONEncoding(t *testing.T) {
+	alh := sha256.Sum256([]byte("alh"))
+
+	state := &SignedState{Db: "defaultdb", TxID: 10, TxHash: alh}
+
+	encoded, err := MarshalJSON(state)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf(`{"db":"defaultdb","txId":10,"txHash":"%x"}`, alh), string(encoded))
+
+	var decoded SignedState
+
+	err = UnmarshalJSON(encoded, &decoded)
+	require.NoError(t, err)
+	require.Equal(t, state, &decoded)
+
+	err = UnmarshalJSON([]byte(`{"db":"defaultdb","txId":10,"txHash":"00"}`), &decoded)
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+
+	err = UnmarshalJSON([]byte(`{"db":"defaultdb","txId":10,"unknown":true}`), &decoded)
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+
+	err = UnmarshalJSON([]byte(`{"db":"defaultdb"}{}`), &decoded)
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+}
@@ -16,7 +16,12 @@ limitations under the License.
 
 package replication
 
-import "time"
+import (
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/throttle"
+)
 
 const DefaultChunkSize int = 64 * 1024 // 64 * 1024 64 KiB
 
@@ -30,6 +35,10 @@ type Options struct {
 	streamChunkSize int
 
 	delayer Delayer
+
+	rateLimiter *throttle.Limiter
+
+	alerter alert.Alerter
 }
 
 func DefaultOptions() *Options {
@@ -93,3 +102,16 @@ func (o *Options) WithDelayer(delayer Delayer) *Options {
 	o.delayer = delayer
 	return o
 }
+
+// WithRateLimiter sets the limiter of the throughput of replicated transactions, it's unlimited when nil
+func (o *Options) WithRateLimiter(rateLimiter *throttle.Limiter) *Options {
+	o.rateLimiter = rateLimiter
+	return o
+}
+
+// WithAlerter sets the alerter notified when transactions sent by the master don't match the replica state,
+// divergences are only logged when nil
+func (o *Options) WithAlerter(alerter alert.Alerter) *Options {
+	o.alerter = alerter
+	return o
+}
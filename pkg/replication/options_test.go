@@ -20,6 +20,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/throttle"
 	"github.com/stretchr/testify/require"
 )
 
@@ -42,6 +44,11 @@ func TestOptions(t *testing.T) {
 		WithStreamChunkSize(DefaultChunkSize).
 		WithDelayer(delayer)
 
+	limiter := throttle.NewLimiter(1 << 20)
+	opts.WithRateLimiter(limiter)
+
+	alerter := alert.NewWebhookAlerter("http://alerts.local", time.Second)
+	opts.WithAlerter(alerter)
 	require.Equal(t, "defaultdb", opts.masterDatabase)
 	require.Equal(t, "127.0.0.1", opts.masterAddress)
 	require.Equal(t, 3322, opts.masterPort)
@@ -49,6 +56,8 @@ func TestOptions(t *testing.T) {
 	require.Equal(t, "immdubPwd", opts.followerPassword)
 	require.Equal(t, DefaultChunkSize, opts.streamChunkSize)
 	require.Equal(t, delayer, opts.delayer)
+	require.Equal(t, limiter, opts.rateLimiter)
+	require.Equal(t, alerter, opts.alerter)
 
 	require.True(t, opts.Valid())
 
@@ -23,17 +23,24 @@ import (
 	"sync"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/alert"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/codenotary/immudb/pkg/stream"
+	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/grpc/metadata"
 )
 
 var ErrIllegalArguments = errors.New("illegal arguments")
 var ErrAlreadyRunning = errors.New("already running")
 var ErrAlreadyStopped = errors.New("already stopped")
+var ErrNotConnected = errors.New("not connected")
+
+// masterStateTimeout bounds the time spent retrieving the state of the master when reporting the replication status
+const masterStateTimeout = 2 * time.Second
 
 type TxReplicator struct {
 	db   database.DB
@@ -53,6 +60,12 @@ type TxReplicator struct {
 
 	nextTx uint64
 
+	// last transaction a divergence alert was raised for, so that retries don't raise it again
+	alertedTx uint64
+
+	lastError   error
+	lastErrorAt time.Time
+
 	running bool
 
 	mutex sync.Mutex
@@ -95,6 +108,9 @@ func (txr *TxReplicator) Start() error {
 
 	txr.running = true
 
+	// damaged values are repaired with the ones held by the master
+	txr.db.SetRepairSource(txr.fetchRepairTx)
+
 	go func() {
 		defer func() {
 			if txr.client != nil {
@@ -111,6 +127,7 @@ func (txr *TxReplicator) Start() error {
 				}
 
 				txr.failedAttempts++
+				txr.setLastError(err)
 
 				txr.logger.Infof("Failed to connect with '%s' for database '%s' (%d failed attempts). Reason: %v",
 					masterDB,
@@ -131,10 +148,12 @@ func (txr *TxReplicator) Start() error {
 
 			txr.logger.Debugf("Replicating transaction %d from '%s' to '%s'...", txr.nextTx, masterDB, txr.db.GetName())
 
-			bs, err := txr.fetchTX()
+			bs, err := txr.fetchTX(txr.client, txr.clientContext, txr.nextTx)
 			if err != nil {
 				txr.logger.Infof("Failed to export transaction %d from '%s' to '%s'. Reason: %v", txr.nextTx, masterDB, txr.db.GetName(), err)
 
+				txr.setLastError(err)
+
 				txr.failedAttempts++
 				if txr.failedAttempts == 3 {
 					txr.disconnect()
@@ -151,6 +170,12 @@ func (txr *TxReplicator) Start() error {
 				continue
 			}
 
+			err = txr.opts.rateLimiter.WaitN(txr.mainContext, len(bs))
+			if err != nil {
+				// replication was stopped
+				return
+			}
+
 			_, err = txr.db.ReplicateTx(bs)
 			if err != nil {
 				txr.logger.Infof("Failed to replicate transaction %d from '%s' to '%s'. Reason: %v",
@@ -159,6 +184,12 @@ func (txr *TxReplicator) Start() error {
 					masterDB,
 					err)
 
+				txr.setLastError(err)
+
+				if errors.Is(err, store.ErrTxHeaderMismatch) {
+					txr.raiseDivergenceAlert(masterDB, err)
+				}
+
 				txr.failedAttempts++
 				if txr.failedAttempts == 3 {
 					txr.disconnect()
@@ -177,7 +208,10 @@ func (txr *TxReplicator) Start() error {
 
 			txr.logger.Debugf("Transaction %d from '%s' to '%s' successfully replicated", txr.nextTx, masterDB, txr.db.GetName())
 
+			txr.mutex.Lock()
 			txr.nextTx++
+			txr.mutex.Unlock()
+
 			txr.failedAttempts = 0
 		}
 	}()
@@ -187,19 +221,110 @@ func (txr *TxReplicator) Start() error {
 	return nil
 }
 
-func fullAddress(db, address string, port int) string {
-	return fmt.Sprintf("%s@%s:%d", db, address, port)
+// raiseDivergenceAlert notifies the alerter that the transaction txr.nextTx sent by the master
+// doesn't match the state of the replica, the alert is raised once per transaction
+func (txr *TxReplicator) raiseDivergenceAlert(masterDB string, err error) {
+	txr.logger.Errorf("Transaction %d from '%s' diverges from the state of '%s': %v", txr.nextTx, masterDB, txr.db.GetName(), err)
+
+	if txr.opts.alerter == nil || txr.alertedTx == txr.nextTx {
+		return
+	}
+
+	evidence := map[string]string{
+		"master": masterDB,
+		"error":  err.Error(),
+	}
+
+	st, stErr := txr.db.CurrentState()
+	if stErr == nil {
+		evidence["replica_tx_id"] = fmt.Sprintf("%d", st.TxId)
+		evidence["replica_alh"] = fmt.Sprintf("%x", st.TxHash)
+	}
+
+	alertErr := txr.opts.alerter.Alert(&alert.Alert{
+		Kind:     alert.ReplicaDivergence,
+		Source:   "replication",
+		DB:       txr.db.GetName(),
+		FromTx:   txr.nextTx,
+		ToTx:     txr.nextTx,
+		Message:  fmt.Sprintf("transaction sent by '%s' does not match the replica", masterDB),
+		Evidence: evidence,
+		RaisedAt: time.Now(),
+	})
+	if alertErr != nil {
+		txr.logger.Errorf("Failed to raise divergence alert for database '%s': %v", txr.db.GetName(), alertErr)
+		return
+	}
+
+	txr.alertedTx = txr.nextTx
 }
 
-func (txr *TxReplicator) connect() error {
+func (txr *TxReplicator) setLastError(err error) {
 	txr.mutex.Lock()
 	defer txr.mutex.Unlock()
 
+	txr.lastError = err
+	txr.lastErrorAt = time.Now()
+}
+
+// Status describes the progress of the replication of a database
+type Status struct {
+	Running   bool
+	Connected bool
+	// last transaction replicated
+	ReplicatedTx uint64
+	// last transaction committed by the master, zero when it couldn't be retrieved
+	MasterTx    uint64
+	LastError   error
+	LastErrorAt time.Time
+}
+
+// Status returns the progress of the replication, the state of the master is retrieved
+// using the connection established for replication when there is one
+func (txr *TxReplicator) Status(ctx context.Context) *Status {
+	txr.mutex.Lock()
+	st := &Status{
+		Running:     txr.running,
+		Connected:   txr.client != nil,
+		LastError:   txr.lastError,
+		LastErrorAt: txr.lastErrorAt,
+	}
+	if txr.nextTx > 0 {
+		st.ReplicatedTx = txr.nextTx - 1
+	}
+	client, clientCtx := txr.client, txr.clientContext
+	txr.mutex.Unlock()
+
+	if client == nil {
+		return st
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, masterStateTimeout)
+	defer cancel()
+
+	// the credentials of the replication connection are kept while the caller's deadline is honored
+	md, _ := metadata.FromOutgoingContext(clientCtx)
+
+	masterState, err := client.GetServiceClient().CurrentState(metadata.NewOutgoingContext(ctx, md), &empty.Empty{})
+	if err == nil {
+		st.MasterTx = masterState.TxId
+	}
+
+	return st
+}
+
+func fullAddress(db, address string, port int) string {
+	return fmt.Sprintf("%s@%s:%d", db, address, port)
+}
+
+func (txr *TxReplicator) connect() error {
 	txr.logger.Infof("Connecting to '%s':'%d' for database '%s'...",
 		txr.opts.masterAddress,
 		txr.opts.masterPort,
 		txr.db.GetName())
 
+	// the connection is established without holding the lock so that the status of the replication
+	// can still be retrieved while the master is unreachable
 	opts := client.DefaultOptions().WithAddress(txr.opts.masterAddress).WithPort(txr.opts.masterPort)
 	client, err := client.NewImmuClient(opts)
 	if err != nil {
@@ -211,16 +336,17 @@ func (txr *TxReplicator) connect() error {
 		return err
 	}
 
-	txr.clientContext = metadata.NewOutgoingContext(txr.mainContext, metadata.Pairs("authorization", login.GetToken()))
+	clientContext := metadata.NewOutgoingContext(txr.mainContext, metadata.Pairs("authorization", login.GetToken()))
 
-	udr, err := client.UseDatabase(txr.clientContext, &schema.Database{DatabaseName: txr.opts.masterDatabase})
+	udr, err := client.UseDatabase(clientContext, &schema.Database{DatabaseName: txr.opts.masterDatabase})
 	if err != nil {
 		return err
 	}
 
-	txr.clientContext = metadata.NewOutgoingContext(txr.clientContext, metadata.Pairs("authorization", udr.GetToken()))
-
+	txr.mutex.Lock()
+	txr.clientContext = metadata.NewOutgoingContext(clientContext, metadata.Pairs("authorization", udr.GetToken()))
 	txr.client = client
+	txr.mutex.Unlock()
 
 	txr.logger.Infof("Connection to '%s':'%d' for database '%s' successfully established",
 		txr.opts.masterAddress,
@@ -244,8 +370,8 @@ func (txr *TxReplicator) disconnect() {
 	txr.logger.Infof("Disconnected from '%s':'%d' for database '%s'", txr.opts.masterAddress, txr.opts.masterPort, txr.db.GetName())
 }
 
-func (txr *TxReplicator) fetchTX() ([]byte, error) {
-	exportTxStream, err := txr.client.ExportTx(txr.clientContext, &schema.TxRequest{Tx: txr.nextTx})
+func (txr *TxReplicator) fetchTX(client client.ImmuClient, ctx context.Context, txID uint64) ([]byte, error) {
+	exportTxStream, err := client.ExportTx(ctx, &schema.TxRequest{Tx: txID})
 	if err != nil {
 		return nil, err
 	}
@@ -254,6 +380,19 @@ func (txr *TxReplicator) fetchTX() ([]byte, error) {
 	return receiver.ReadFully()
 }
 
+// fetchRepairTx exports the transaction txID from the master using the connection established for replication
+func (txr *TxReplicator) fetchRepairTx(txID uint64) ([]byte, error) {
+	txr.mutex.Lock()
+	client, ctx := txr.client, txr.clientContext
+	txr.mutex.Unlock()
+
+	if client == nil {
+		return nil, ErrNotConnected
+	}
+
+	return txr.fetchTX(client, ctx, txID)
+}
+
 func (txr *TxReplicator) Stop() error {
 	txr.mutex.Lock()
 	defer txr.mutex.Unlock()
@@ -268,6 +407,8 @@ func (txr *TxReplicator) Stop() error {
 		txr.cancelFunc()
 	}
 
+	txr.db.SetRepairSource(nil)
+
 	txr.running = false
 
 	txr.logger.Infof("Replication of database '%s' successfully stopped", txr.db.GetName())
@@ -17,9 +17,14 @@ limitations under the License.
 package replication
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/alert"
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/stretchr/testify/require"
@@ -59,3 +64,90 @@ func TestReplication(t *testing.T) {
 	err = txReplicator.Stop()
 	require.NoError(t, err)
 }
+
+func TestReplicationDivergenceAlert(t *testing.T) {
+	var alerts []*alert.Alert
+
+	rOpts := DefaultOptions().
+		WithMasterDatabase("defaultdb").
+		WithMasterAddress("127.0.0.1").
+		WithMasterPort(3322).
+		WithAlerter(alert.AlerterFunc(func(a *alert.Alert) error {
+			alerts = append(alerts, a)
+			return nil
+		}))
+
+	logger := logger.NewSimpleLogger("logger", os.Stdout)
+
+	db, err := database.NewDB(database.DefaultOption().AsReplica(true), logger)
+	require.NoError(t, err)
+
+	defer os.RemoveAll(db.GetOptions().GetDBRootPath())
+
+	txReplicator, err := NewTxReplicator(db, rOpts, logger)
+	require.NoError(t, err)
+
+	txReplicator.nextTx = 1
+
+	mismatchErr := fmt.Errorf("%w: previous accumulative linear hash of tx 1 does not match", store.ErrTxHeaderMismatch)
+
+	txReplicator.raiseDivergenceAlert("defaultdb@127.0.0.1:3322", mismatchErr)
+	require.Len(t, alerts, 1)
+	require.Equal(t, alert.ReplicaDivergence, alerts[0].Kind)
+	require.Equal(t, db.GetName(), alerts[0].DB)
+	require.Equal(t, uint64(1), alerts[0].FromTx)
+	require.Equal(t, uint64(1), alerts[0].ToTx)
+	require.Equal(t, mismatchErr.Error(), alerts[0].Evidence["error"])
+	require.Equal(t, "0", alerts[0].Evidence["replica_tx_id"])
+
+	// retries of the same transaction don't raise the alert again
+	txReplicator.raiseDivergenceAlert("defaultdb@127.0.0.1:3322", mismatchErr)
+	require.Len(t, alerts, 1)
+
+	txReplicator.nextTx = 2
+
+	txReplicator.raiseDivergenceAlert("defaultdb@127.0.0.1:3322", mismatchErr)
+	require.Len(t, alerts, 2)
+}
+
+func TestReplicationStatus(t *testing.T) {
+	rOpts := DefaultOptions().
+		WithMasterDatabase("defaultdb").
+		WithMasterAddress("127.0.0.1").
+		WithMasterPort(3322).
+		WithFollowerUsername("immudb").
+		WithFollowerPassword("immudb").
+		WithStreamChunkSize(DefaultChunkSize)
+
+	logger := logger.NewSimpleLogger("logger", os.Stdout)
+
+	db, err := database.NewDB(database.DefaultOption().AsReplica(true), logger)
+	require.NoError(t, err)
+
+	defer os.RemoveAll(db.GetOptions().GetDBRootPath())
+
+	txReplicator, err := NewTxReplicator(db, rOpts, logger)
+	require.NoError(t, err)
+
+	st := txReplicator.Status(context.Background())
+	require.False(t, st.Running)
+	require.False(t, st.Connected)
+	require.NoError(t, st.LastError)
+
+	err = txReplicator.Start()
+	require.NoError(t, err)
+
+	defer txReplicator.Stop()
+
+	// there is no master to connect to
+	require.Eventually(t, func() bool {
+		return txReplicator.Status(context.Background()).LastError != nil
+	}, 10*time.Second, 10*time.Millisecond)
+
+	st = txReplicator.Status(context.Background())
+	require.True(t, st.Running)
+	require.False(t, st.Connected)
+	require.Zero(t, st.ReplicatedTx)
+	require.Zero(t, st.MasterTx)
+	require.False(t, st.LastErrorAt.IsZero())
+}
@@ -0,0 +1,348 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// access required to run a command
+type access int
+
+const (
+	accessNone access = iota
+	accessAuthenticated
+	accessRead
+	accessWrite
+)
+
+type command struct {
+	// arity is the number of arguments including the command name, or minus the minimum number of them
+	arity  int
+	access access
+	run    func(s *session, args [][]byte) error
+}
+
+var commands = map[string]*command{
+	"PING":    {arity: -1, access: accessNone, run: (*session).ping},
+	"ECHO":    {arity: 2, access: accessNone, run: (*session).echo},
+	"QUIT":    {arity: 1, access: accessNone, run: (*session).quit},
+	"COMMAND": {arity: -1, access: accessNone, run: (*session).command},
+	"AUTH":    {arity: -2, access: accessNone, run: (*session).auth},
+	"SELECT":  {arity: 2, access: accessAuthenticated, run: (*session).selectCmd},
+	"GET":     {arity: 2, access: accessRead, run: (*session).get},
+	"MGET":    {arity: -2, access: accessRead, run: (*session).mget},
+	"SET":     {arity: -3, access: accessWrite, run: (*session).set},
+	"MSET":    {arity: -3, access: accessWrite, run: (*session).mset},
+	"SCAN":    {arity: -2, access: accessRead, run: (*session).scan},
+
+	"IMMUDB.STATE": {arity: 1, access: accessRead, run: (*session).state},
+	"IMMUDB.VGET":  {arity: -2, access: accessRead, run: (*session).verifiedGet},
+}
+
+func (s *session) ping(args [][]byte) error {
+	switch len(args) {
+	case 0:
+		s.w.simpleString("PONG")
+	case 1:
+		s.w.bulk(args[0])
+	default:
+		return fmt.Errorf("wrong number of arguments for 'ping' command")
+	}
+	return nil
+}
+
+func (s *session) echo(args [][]byte) error {
+	s.w.bulk(args[0])
+	return nil
+}
+
+func (s *session) quit(args [][]byte) error {
+	s.w.simpleString("OK")
+	return nil
+}
+
+// command replies with no command details, clients issue it to discover cluster setups
+func (s *session) command(args [][]byte) error {
+	s.w.array(0)
+	return nil
+}
+
+func (s *session) auth(args [][]byte) error {
+	switch len(args) {
+	case 1:
+		return errors.New("AUTH requires a username and a password")
+	case 2:
+	default:
+		return ErrSyntax
+	}
+
+	err := s.authenticate(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	s.w.simpleString("OK")
+	return nil
+}
+
+// selectCmd selects a database by name, not by index as Redis does
+func (s *session) selectCmd(args [][]byte) error {
+	err := s.selectDatabase(string(args[0]))
+	if err != nil {
+		return err
+	}
+
+	s.w.simpleString("OK")
+	return nil
+}
+
+func (s *session) get(args [][]byte) error {
+	value, err := s.getValue(args[0])
+	if err != nil {
+		return err
+	}
+
+	s.w.bulk(value)
+	return nil
+}
+
+func (s *session) mget(args [][]byte) error {
+	values := make([][]byte, len(args))
+
+	for i, key := range args {
+		value, err := s.getValue(key)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+
+	s.w.array(len(values))
+	for _, value := range values {
+		s.w.bulk(value)
+	}
+	return nil
+}
+
+// getValue returns the current value of key, nil when the key is not set or expired
+func (s *session) getValue(key []byte) ([]byte, error) {
+	entry, err := s.database.Get(&schema.KeyRequest{Key: key})
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Value == nil {
+		return []byte{}, nil
+	}
+	return entry.Value, nil
+}
+
+// set writes the value of a key, the expiration options are supported while the conditional ones are not
+func (s *session) set(args [][]byte) error {
+	kv := &schema.KeyValue{Key: args[0], Value: args[1]}
+
+	for i := 2; i < len(args); i++ {
+		opt := strings.ToUpper(string(args[i]))
+
+		switch opt {
+		case "EX", "PX", "EXAT", "PXAT":
+			if kv.Metadata != nil || i+1 == len(args) {
+				return ErrSyntax
+			}
+			i++
+
+			expiresAt, err := parseExpiration(opt, args[i], time.Now())
+			if err != nil {
+				return err
+			}
+
+			kv.Metadata = &schema.KVMetadata{Expiration: &schema.Expiration{ExpiresAt: expiresAt}}
+		case "NX", "XX", "GET", "KEEPTTL":
+			return fmt.Errorf("SET option %s is not supported", opt)
+		default:
+			return ErrSyntax
+		}
+	}
+
+	_, err := s.database.Set(&schema.SetRequest{KVs: []*schema.KeyValue{kv}, TxMetadata: s.txMetadata()})
+	if err != nil {
+		return err
+	}
+
+	s.w.simpleString("OK")
+	return nil
+}
+
+// parseExpiration returns the unix time in seconds the value expires at, rounded up
+func parseExpiration(opt string, arg []byte, now time.Time) (int64, error) {
+	n, err := strconv.ParseInt(string(arg), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidInt
+	}
+	if n <= 0 {
+		return 0, ErrInvalidExpire
+	}
+
+	var expiresAt time.Time
+
+	switch opt {
+	case "EX":
+		expiresAt = now.Add(time.Duration(n) * time.Second)
+	case "PX":
+		expiresAt = now.Add(time.Duration(n) * time.Millisecond)
+	case "EXAT":
+		expiresAt = time.Unix(n, 0)
+	case "PXAT":
+		expiresAt = time.Unix(n/1000, (n%1000)*int64(time.Millisecond))
+	}
+
+	if expiresAt.Before(now) && opt != "EXAT" && opt != "PXAT" {
+		// the duration overflowed
+		return 0, ErrInvalidExpire
+	}
+
+	secs := expiresAt.Unix()
+	if expiresAt.Nanosecond() > 0 {
+		secs++
+	}
+	return secs, nil
+}
+
+// mset writes all the keys in a single transaction, the last value of a repeated key wins
+func (s *session) mset(args [][]byte) error {
+	if len(args)%2 != 0 {
+		return fmt.Errorf("wrong number of arguments for 'mset' command")
+	}
+
+	kvs := make([]*schema.KeyValue, 0, len(args)/2)
+	index := make(map[string]int, len(args)/2)
+
+	for i := 0; i < len(args); i += 2 {
+		kv := &schema.KeyValue{Key: args[i], Value: args[i+1]}
+
+		if j, ok := index[string(kv.Key)]; ok {
+			kvs[j] = kv
+			continue
+		}
+
+		index[string(kv.Key)] = len(kvs)
+		kvs = append(kvs, kv)
+	}
+
+	_, err := s.database.Set(&schema.SetRequest{KVs: kvs, TxMetadata: s.txMetadata()})
+	if err != nil {
+		return err
+	}
+
+	s.w.simpleString("OK")
+	return nil
+}
+
+// scan iterates over the keys in lexicographical order. Cursors are kept by the connection, the literal
+// prefix of the pattern narrows the keys being scanned, COUNT is the number of keys scanned by each call
+func (s *session) scan(args [][]byte) error {
+	cursor, err := strconv.ParseUint(string(args[0]), 10, 64)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	var pattern []byte
+	count := 10
+	onlyStrings := true
+
+	for i := 1; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return ErrSyntax
+		}
+
+		switch strings.ToUpper(string(args[i])) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			count, err = strconv.Atoi(string(args[i+1]))
+			if err != nil {
+				return ErrInvalidInt
+			}
+			if count < 1 {
+				return ErrSyntax
+			}
+			if count > database.MaxKeyScanLimit {
+				count = database.MaxKeyScanLimit
+			}
+		case "TYPE":
+			// all the values are strings
+			onlyStrings = strings.EqualFold(string(args[i+1]), "string")
+		default:
+			return ErrSyntax
+		}
+	}
+
+	var seekKey []byte
+
+	if cursor != 0 {
+		var ok bool
+
+		seekKey, ok = s.cursors[cursor]
+		if !ok {
+			return ErrInvalidCursor
+		}
+
+		delete(s.cursors, cursor)
+	}
+
+	entries, err := s.database.Scan(&schema.ScanRequest{
+		SeekKey: seekKey,
+		Prefix:  globPrefix(pattern),
+		Limit:   uint64(count),
+	})
+	if err != nil {
+		return err
+	}
+
+	next := uint64(0)
+	if len(entries.Entries) == count {
+		next = s.newCursor(entries.Entries[count-1].Key)
+	}
+
+	var keys [][]byte
+
+	for _, e := range entries.Entries {
+		if onlyStrings && (pattern == nil || matchGlob(pattern, e.Key)) {
+			keys = append(keys, e.Key)
+		}
+	}
+
+	s.w.array(2)
+	s.w.bulk([]byte(strconv.FormatUint(next, 10)))
+	s.w.array(len(keys))
+	for _, key := range keys {
+		s.w.bulk(key)
+	}
+	return nil
+}
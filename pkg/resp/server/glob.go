@@ -0,0 +1,119 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+// matchGlob tells whether s matches the glob-style pattern of SCAN MATCH: '*' matches any sequence,
+// '?' any single byte, '[...]' any byte of the class, which can be negated with '^' and hold ranges,
+// and '\' escapes the next byte
+func matchGlob(pattern, s []byte) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+
+			pattern = pattern[1:]
+
+			negated := len(pattern) > 0 && pattern[0] == '^'
+			if negated {
+				pattern = pattern[1:]
+			}
+
+			matched := false
+
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) > 1:
+					pattern = pattern[1:]
+					matched = matched || pattern[0] == s[0]
+				case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+					lo, hi := pattern[0], pattern[2]
+					if lo > hi {
+						lo, hi = hi, lo
+					}
+					matched = matched || (s[0] >= lo && s[0] <= hi)
+					pattern = pattern[2:]
+				default:
+					matched = matched || pattern[0] == s[0]
+				}
+				pattern = pattern[1:]
+			}
+
+			if matched == negated {
+				return false
+			}
+			s = s[1:]
+
+			// an unterminated class ends the pattern
+			if len(pattern) == 0 {
+				return len(s) == 0
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			s = s[1:]
+		}
+
+		pattern = pattern[1:]
+	}
+
+	return len(s) == 0
+}
+
+// globPrefix returns the literal prefix of pattern, all the keys matching the pattern start with it
+func globPrefix(pattern []byte) []byte {
+	var prefix []byte
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return prefix
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		prefix = append(prefix, pattern[i])
+	}
+
+	return prefix
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchGlob(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"*", "", true},
+		{"*", "anything", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"*:name", "user:1:name", true},
+		{"*:name", "user:1:email", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[c-a]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{"h[\\]]llo", "h]llo", true},
+		{"h\\*llo", "h*llo", true},
+		{"h\\*llo", "hello", false},
+		{"a**b", "axxb", true},
+		{"a[b", "ab", true},
+		{"a[b", "abc", false},
+	} {
+		require.Equal(t, c.match, matchGlob([]byte(c.pattern), []byte(c.s)), "pattern %q on %q", c.pattern, c.s)
+	}
+}
+
+func TestGlobPrefix(t *testing.T) {
+	require.Nil(t, globPrefix(nil))
+	require.Nil(t, globPrefix([]byte("*")))
+	require.Equal(t, []byte("user:"), globPrefix([]byte("user:*")))
+	require.Equal(t, []byte("user:1"), globPrefix([]byte("user:1")))
+	require.Equal(t, []byte("h"), globPrefix([]byte("h?llo")))
+	require.Equal(t, []byte("h*llo"), globPrefix([]byte("h\\*llo")))
+	require.Equal(t, []byte("a"), globPrefix([]byte("a[bc]")))
+}
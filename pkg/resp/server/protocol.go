@@ -0,0 +1,212 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxArgs is the maximum number of arguments of a command
+	maxArgs = 1024 * 1024
+	// maxBulkLength is the maximum size in bytes of an argument
+	maxBulkLength = 32 * 1024 * 1024
+	// maxInlineLength is the maximum size in bytes of an inline command
+	maxInlineLength = 64 * 1024
+)
+
+var ErrProtocol = errors.New("protocol error")
+
+// reader reads the commands sent by clients, either as arrays of bulk strings or inline
+type reader struct {
+	r *bufio.Reader
+}
+
+func newReader(r io.Reader) *reader {
+	return &reader{r: bufio.NewReader(r)}
+}
+
+// buffered tells whether more commands were already received, i.e. the client is pipelining
+func (r *reader) buffered() bool {
+	return r.r.Buffered() > 0
+}
+
+// readCommand returns the name and arguments of the next command, empty for blank inline commands
+func (r *reader) readCommand() ([][]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 || line[0] != '*' {
+		return bytes.Fields(line), nil
+	}
+
+	n, err := parseLength(line[1:], maxArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([][]byte, 0, n)
+
+	for i := 0; i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got '%s'", ErrProtocol, printable(line))
+		}
+
+		size, err := parseLength(line[1:], maxBulkLength)
+		if err != nil {
+			return nil, err
+		}
+
+		arg := make([]byte, size+2)
+
+		_, err = io.ReadFull(r.r, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if arg[size] != '\r' || arg[size+1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", ErrProtocol)
+		}
+
+		args = append(args, arg[:size])
+	}
+
+	return args, nil
+}
+
+func (r *reader) readLine() ([]byte, error) {
+	var line []byte
+
+	for {
+		chunk, isPrefix, err := r.r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+
+		line = append(line, chunk...)
+
+		if len(line) > maxInlineLength {
+			return nil, fmt.Errorf("%w: too big inline request", ErrProtocol)
+		}
+
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
+
+func parseLength(b []byte, max int) (int, error) {
+	n, err := strconv.Atoi(string(b))
+	if err != nil || n < 0 || n > max {
+		return 0, fmt.Errorf("%w: invalid length '%s'", ErrProtocol, printable(b))
+	}
+	return n, nil
+}
+
+func printable(b []byte) string {
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	return strconv.Quote(string(b))
+}
+
+// writer buffers the replies of the commands until they are flushed
+type writer struct {
+	w *bufio.Writer
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{w: bufio.NewWriter(w)}
+}
+
+func (w *writer) simpleString(s string) {
+	w.w.WriteString("+" + s + "\r\n")
+}
+
+// error writes err as an error reply, prefixed by the generic ERR code unless it's a replyError
+func (w *writer) error(err error) {
+	msg := err.Error()
+
+	var rerr *replyError
+	if !errors.As(err, &rerr) {
+		msg = "ERR " + msg
+	}
+
+	// error replies are single lines
+	msg = strings.NewReplacer("\r", " ", "\n", " ").Replace(msg)
+
+	w.w.WriteString("-" + msg + "\r\n")
+}
+
+func (w *writer) integer(n int64) {
+	w.w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+// bulk writes b as a bulk string, as a null one when b is nil
+func (w *writer) bulk(b []byte) {
+	if b == nil {
+		w.w.WriteString("$-1\r\n")
+		return
+	}
+
+	w.w.WriteString("$" + strconv.Itoa(len(b)) + "\r\n")
+	w.w.Write(b)
+	w.w.WriteString("\r\n")
+}
+
+// array writes the header of an array of n elements, the elements are written afterwards
+func (w *writer) array(n int) {
+	w.w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+func (w *writer) flush() error {
+	return w.w.Flush()
+}
+
+// replyError is an error replied with its own code instead of the generic ERR one
+type replyError struct {
+	code string
+	msg  string
+}
+
+func (e *replyError) Error() string {
+	return e.code + " " + e.msg
+}
+
+var (
+	ErrNoAuth        = &replyError{code: "NOAUTH", msg: "Authentication required."}
+	ErrWrongPass     = &replyError{code: "WRONGPASS", msg: "invalid username-password pair or user is disabled."}
+	ErrNoPerm        = &replyError{code: "NOPERM", msg: "this user has no permissions to run this command on the selected database"}
+	ErrSyntax        = errors.New("syntax error")
+	ErrInvalidInt    = errors.New("value is not an integer or out of range")
+	ErrInvalidExpire = errors.New("invalid expire time")
+	ErrNoDatabase    = errors.New("please select a database first")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	r := newReader(strings.NewReader("*2\r\n$3\r\nGET\r\n$2\r\nk1\r\nPING  hello\r\n\r\n*1\r\n$0\r\n\r\n"))
+
+	args, err := r.readCommand()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("GET"), []byte("k1")}, args)
+	require.True(t, r.buffered())
+
+	args, err = r.readCommand()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("PING"), []byte("hello")}, args)
+
+	args, err = r.readCommand()
+	require.NoError(t, err)
+	require.Empty(t, args)
+
+	args, err = r.readCommand()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{}}, args)
+
+	_, err = r.readCommand()
+	require.Equal(t, io.EOF, err)
+
+	for _, malformed := range []string{
+		"*x\r\n",
+		"*-1\r\n",
+		"*1\r\n:1\r\n",
+		"*1\r\n$-1\r\n",
+		"*1\r\n$2\r\nabcd",
+		"*1\r\n$999999999999\r\n",
+		strings.Repeat("a", maxInlineLength+1) + "\r\n",
+	} {
+		_, err = newReader(strings.NewReader(malformed)).readCommand()
+		require.True(t, errors.Is(err, ErrProtocol), "%q: %v", malformed, err)
+	}
+
+	_, err = newReader(strings.NewReader("*2\r\n$3\r\nGET\r\n")).readCommand()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestWriter(t *testing.T) {
+	var b bytes.Buffer
+
+	w := newWriter(&b)
+	w.simpleString("OK")
+	w.error(errors.New("some\r\nerror"))
+	w.error(ErrNoAuth)
+	w.integer(-3)
+	w.array(3)
+	w.bulk([]byte("value"))
+	w.bulk([]byte{})
+	w.bulk(nil)
+
+	require.Zero(t, b.Len())
+	require.NoError(t, w.flush())
+
+	require.Equal(t, "+OK\r\n"+
+		"-ERR some  error\r\n"+
+		"-NOAUTH Authentication required.\r\n"+
+		":-3\r\n"+
+		"*3\r\n$5\r\nvalue\r\n$0\r\n\r\n$-1\r\n", b.String())
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/proofs"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/codenotary/immudb/pkg/verifier"
+	"github.com/stretchr/testify/require"
+)
+
+// respError is an error reply
+type respError string
+
+type respClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, port int) *respClient {
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	require.NoError(t, err)
+
+	return &respClient{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *respClient) send(t *testing.T, args ...string) {
+	cmd := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		cmd += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	_, err := c.conn.Write([]byte(cmd))
+	require.NoError(t, err)
+}
+
+func (c *respClient) do(t *testing.T, args ...string) interface{} {
+	c.send(t, args...)
+	return c.reply(t)
+}
+
+// reply returns the next reply: a string for simple strings, a respError, an int64,
+// a []byte for bulk strings, nil for null ones, or a []interface{} for arrays
+func (c *respClient) reply(t *testing.T) interface{} {
+	line, err := c.r.ReadString('\n')
+	require.NoError(t, err)
+
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:]
+	case '-':
+		return respError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		require.NoError(t, err)
+		return n
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		require.NoError(t, err)
+		if n < 0 {
+			return nil
+		}
+		b := make([]byte, n+2)
+		_, err = io.ReadFull(c.r, b)
+		require.NoError(t, err)
+		return b[:n]
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		require.NoError(t, err)
+		elems := make([]interface{}, n)
+		for i := range elems {
+			elems[i] = c.reply(t)
+		}
+		return elems
+	}
+
+	require.Failf(t, "unexpected reply", "%q", line)
+	return nil
+}
+
+func TestRESPServer(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_resp")
+	options := server.DefaultOptions().WithDir(td).WithRESPServer(true).WithRESPServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	c := dial(t, bs.Server.Srv.RESPSrv.GetPort())
+	defer c.conn.Close()
+
+	require.Equal(t, "PONG", c.do(t, "PING"))
+	require.Equal(t, []byte("hello"), c.do(t, "ECHO", "hello"))
+	require.Equal(t, respError("NOAUTH Authentication required."), c.do(t, "GET", "k1"))
+	require.Contains(t, c.do(t, "AUTH", "immudb"), "requires a username")
+	require.Equal(t, respError("WRONGPASS invalid username-password pair or user is disabled."), c.do(t, "AUTH", "immudb", "wrong"))
+	require.Equal(t, respError("WRONGPASS invalid username-password pair or user is disabled."), c.do(t, "AUTH", "nobody", "immudb"))
+	require.Equal(t, "OK", c.do(t, "AUTH", "immudb", "immudb"))
+
+	require.Contains(t, c.do(t, "FLUSHALL"), "ERR unknown command")
+	require.Contains(t, c.do(t, "GET"), "ERR wrong number of arguments")
+	require.Contains(t, c.do(t, "SELECT", "missingdb"), "does not exist")
+	require.Equal(t, "OK", c.do(t, "SELECT", "defaultdb"))
+
+	require.Equal(t, "OK", c.do(t, "SET", "k1", "v1"))
+	require.Equal(t, []byte("v1"), c.do(t, "GET", "k1"))
+	require.Nil(t, c.do(t, "GET", "missing"))
+
+	require.Equal(t, "OK", c.do(t, "SET", "empty", ""))
+	require.Equal(t, []byte{}, c.do(t, "GET", "empty"))
+
+	require.Equal(t, "OK", c.do(t, "set", "k2", "v2", "ex", "3600"))
+	require.Equal(t, []byte("v2"), c.do(t, "GET", "k2"))
+	require.Contains(t, c.do(t, "SET", "k2", "v2", "NX"), "not supported")
+	require.Contains(t, c.do(t, "SET", "k2", "v2", "EX"), "syntax error")
+	require.Contains(t, c.do(t, "SET", "k2", "v2", "EX", "-1"), "invalid expire time")
+	require.Contains(t, c.do(t, "SET", "k2", "v2", "EX", "1", "PX", "1"), "syntax error")
+
+	require.Equal(t, "OK", c.do(t, "MSET", "user:1", "a", "user:2", "b", "user:1", "c"))
+	require.Contains(t, c.do(t, "MSET", "user:3", "a", "user:4"), "wrong number of arguments")
+	require.Equal(t, []interface{}{[]byte("c"), nil, []byte("b")}, c.do(t, "MGET", "user:1", "missing", "user:2"))
+
+	t.Run("pipelined commands are all replied", func(t *testing.T) {
+		_, err := c.conn.Write([]byte("*3\r\n$3\r\nSET\r\n$6\r\nuser:3\r\n$1\r\nd\r\n*2\r\n$3\r\nGET\r\n$6\r\nuser:3\r\nPING\r\n"))
+		require.NoError(t, err)
+
+		require.Equal(t, "OK", c.reply(t))
+		require.Equal(t, []byte("d"), c.reply(t))
+		require.Equal(t, "PONG", c.reply(t))
+	})
+
+	t.Run("scan iterates over the keys matching the pattern", func(t *testing.T) {
+		var keys []string
+
+		cursor := "0"
+		for {
+			reply := c.do(t, "SCAN", cursor, "MATCH", "user:*", "COUNT", "2").([]interface{})
+			require.Len(t, reply, 2)
+
+			for _, key := range reply[1].([]interface{}) {
+				keys = append(keys, string(key.([]byte)))
+			}
+
+			cursor = string(reply[0].([]byte))
+			if cursor == "0" {
+				break
+			}
+		}
+
+		sort.Strings(keys)
+		require.Equal(t, []string{"user:1", "user:2", "user:3"}, keys)
+
+		reply := c.do(t, "SCAN", "0", "MATCH", "*1").([]interface{})
+		require.Equal(t, []byte("0"), reply[0])
+		require.Len(t, reply[1], 2)
+
+		reply = c.do(t, "SCAN", "0", "TYPE", "hash").([]interface{})
+		require.Empty(t, reply[1])
+
+		require.Contains(t, c.do(t, "SCAN", "12345"), "invalid cursor")
+		require.Contains(t, c.do(t, "SCAN", "0", "COUNT", "0"), "syntax error")
+		require.Contains(t, c.do(t, "SCAN", "0", "MATCH"), "syntax error")
+	})
+
+	t.Run("verified reads return bundles verifiable offline", func(t *testing.T) {
+		var state proofs.SignedState
+
+		data := c.do(t, "IMMUDB.STATE").([]byte)
+		require.NoError(t, proofs.UnmarshalJSON(data, &state))
+		require.Equal(t, "defaultdb", state.Db)
+		require.NotZero(t, state.TxID)
+
+		require.Equal(t, "OK", c.do(t, "SET", "k1", "v1.1"))
+		require.Equal(t, "OK", c.do(t, "SET", "k3", "v3"))
+
+		reply := c.do(t, "IMMUDB.VGET", "k1", "SINCETX", strconv.FormatUint(state.TxID, 10)).([]interface{})
+		require.Len(t, reply, 2)
+		require.Equal(t, []byte("v1.1"), reply[0])
+
+		var bundle proofs.Bundle
+		require.NoError(t, proofs.UnmarshalJSON(reply[1].([]byte), &bundle))
+		require.Len(t, bundle.Entries, 1)
+		require.Equal(t, []byte("k1"), []byte(bundle.Entries[0].Key))
+		require.NotNil(t, bundle.Consistency)
+
+		require.NoError(t, verifier.VerifyBundle(&bundle, state.ToImmutableState(), nil))
+
+		reply = c.do(t, "IMMUDB.VGET", "k1").([]interface{})
+
+		var latest proofs.Bundle
+		require.NoError(t, proofs.UnmarshalJSON(reply[1].([]byte), &latest))
+		require.Nil(t, latest.Consistency)
+		require.NoError(t, verifier.VerifyBundle(&latest, nil, nil))
+
+		require.Nil(t, c.do(t, "IMMUDB.VGET", "missing"))
+		require.Contains(t, c.do(t, "IMMUDB.VGET", "k1", "SINCETX"), "syntax error")
+		require.Contains(t, c.do(t, "IMMUDB.VGET", "k1", "SINCETX", "x"), "not an integer")
+	})
+
+	t.Run("connections are authenticated independently", func(t *testing.T) {
+		other := dial(t, bs.Server.Srv.RESPSrv.GetPort())
+		defer other.conn.Close()
+
+		require.Equal(t, respError("NOAUTH Authentication required."), other.do(t, "SELECT", "defaultdb"))
+		require.Equal(t, respError("NOAUTH Authentication required."), other.do(t, "IMMUDB.STATE"))
+	})
+
+	require.Equal(t, "OK", c.do(t, "QUIT"))
+
+	_, err := c.r.ReadByte()
+	require.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server implements a listener speaking a subset of RESP, the protocol of Redis,
+// so that Redis clients can read and write the keys of immudb databases.
+//
+// Clients authenticate with AUTH username password and are then on the default database,
+// SELECT switches to another one by name. The supported commands are PING, ECHO, QUIT, AUTH, SELECT,
+// GET, SET, MGET, MSET and SCAN, along with the immudb specific ones:
+//
+//	IMMUDB.STATE               the signed state of the database, as JSON
+//	IMMUDB.VGET key [SINCETX tx] the value of key and the proof bundle of its inclusion in the state
+//	                           of the database, as JSON, verifiable offline with immuclient proof verify
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"golang.org/x/net/netutil"
+)
+
+// StateSigner signs the states of the databases
+type StateSigner interface {
+	Sign(state *schema.ImmutableState) error
+}
+
+type srv struct {
+	m               sync.RWMutex
+	running         bool
+	maxConnections  int
+	tlsConfig       *tls.Config
+	Logger          logger.Logger
+	Address         string
+	Port            int
+	dbList          database.DatabaseList
+	sysDb           database.DB
+	defaultDatabase string
+	signer          StateSigner
+	listener        net.Listener
+}
+
+type Server interface {
+	Initialize() error
+	Serve() error
+	Stop() error
+	GetPort() int
+}
+
+func New(setters ...Option) *srv {
+
+	// Default Options
+	cli := &srv{
+		running:         true,
+		maxConnections:  1000,
+		tlsConfig:       &tls.Config{},
+		Logger:          logger.NewSimpleLogger("respSrv", os.Stderr),
+		Address:         "",
+		Port:            6379,
+		defaultDatabase: "defaultdb",
+	}
+
+	for _, setter := range setters {
+		setter(cli)
+	}
+
+	return cli
+}
+
+// Initialize initialize listener. If provided port is zero os auto assign a free one.
+// Connections are secured with TLS when certificates are configured.
+func (s *srv) Initialize() (err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", s.Address, s.Port))
+	if err != nil {
+		return err
+	}
+
+	if s.tlsConfig != nil && len(s.tlsConfig.Certificates) > 0 {
+		s.listener = tls.NewListener(s.listener, s.tlsConfig)
+	}
+
+	return nil
+}
+
+func (s *srv) Serve() error {
+	s.m.Lock()
+	if s.listener == nil {
+		s.m.Unlock()
+		return errors.New("no listener found for resp server")
+	}
+
+	s.listener = netutil.LimitListener(s.listener, s.maxConnections)
+	listener := s.listener
+	s.m.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+
+		s.m.RLock()
+		running := s.running
+		s.m.RUnlock()
+
+		if !running {
+			if conn != nil {
+				conn.Close()
+			}
+			return nil
+		}
+
+		if err != nil {
+			s.Logger.Errorf("%v", err)
+			continue
+		}
+
+		go s.handleRequest(conn)
+	}
+}
+
+func (s *srv) Stop() (err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.running = false
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+func (s *srv) GetPort() int {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	if s.listener != nil {
+		return s.listener.Addr().(*net.TCPAddr).Port
+	}
+	return 0
+}
+
+func (s *srv) handleRequest(conn net.Conn) {
+	defer conn.Close()
+
+	sess := newSession(conn, s)
+
+	err := sess.serve()
+	if err != nil {
+		s.Logger.Debugf("resp connection from %s closed: %v", conn.RemoteAddr(), err)
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+// maxCursors is the maximum number of scans a connection can keep open, the oldest ones are discarded
+const maxCursors = 1024
+
+type session struct {
+	r               *reader
+	w               *writer
+	log             logger.Logger
+	dbList          database.DatabaseList
+	sysDb           database.DB
+	defaultDatabase string
+	signer          StateSigner
+	user            *auth.User
+	database        database.DB
+	// cursors holds the last key returned by each open scan
+	cursors    map[uint64][]byte
+	lastCursor uint64
+}
+
+func newSession(c net.Conn, s *srv) *session {
+	return &session{
+		r:               newReader(c),
+		w:               newWriter(c),
+		log:             s.Logger,
+		dbList:          s.dbList,
+		sysDb:           s.sysDb,
+		defaultDatabase: s.defaultDatabase,
+		signer:          s.signer,
+		cursors:         make(map[uint64][]byte),
+	}
+}
+
+// serve runs the commands sent by the client until the connection is closed or QUIT is received.
+// Replies are flushed once no more pipelined commands are pending.
+func (s *session) serve() error {
+	for {
+		args, err := s.r.readCommand()
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, ErrProtocol) {
+			s.w.error(err)
+			s.w.flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.dispatch(args)
+
+		if quit || !s.r.buffered() {
+			err = s.w.flush()
+			if err != nil {
+				return err
+			}
+		}
+
+		if quit {
+			return nil
+		}
+	}
+}
+
+// dispatch runs the command, replying with an error when it's unknown, malformed or not allowed
+func (s *session) dispatch(args [][]byte) (quit bool) {
+	name := strings.ToUpper(string(args[0]))
+
+	cmd, ok := commands[name]
+	if !ok {
+		s.w.error(fmt.Errorf("unknown command %s", printable(args[0])))
+		return false
+	}
+
+	if (cmd.arity > 0 && len(args) != cmd.arity) || len(args) < -cmd.arity {
+		s.w.error(fmt.Errorf("wrong number of arguments for '%s' command", strings.ToLower(name)))
+		return false
+	}
+
+	err := s.checkAccess(cmd.access)
+	if err == nil {
+		err = cmd.run(s, args[1:])
+	}
+	if err != nil {
+		s.log.Debugf("resp command %s failed: %v", name, err)
+		s.w.error(err)
+	}
+
+	return name == "QUIT"
+}
+
+// checkAccess fails when the user is not authenticated or is not allowed the access on the selected database
+func (s *session) checkAccess(access access) error {
+	if access == accessNone {
+		return nil
+	}
+
+	if s.user == nil {
+		return ErrNoAuth
+	}
+
+	// the user is loaded again so that deactivations and permission changes apply to open connections
+	usr, err := auth.GetUser(s.sysDb, []byte(s.user.Username))
+	if errors.Is(err, store.ErrKeyNotFound) || (err == nil && !usr.Active) {
+		s.user = nil
+		s.database = nil
+		s.resetCursors()
+
+		return ErrNoAuth
+	}
+	if err != nil {
+		return err
+	}
+
+	s.user = usr
+
+	if access == accessAuthenticated {
+		return nil
+	}
+
+	if s.database == nil {
+		return ErrNoDatabase
+	}
+
+	switch s.user.WhichPermission(s.database.GetName()) {
+	case auth.PermissionSysAdmin, auth.PermissionAdmin, auth.PermissionRW:
+		return nil
+	case auth.PermissionR:
+		if access == accessRead {
+			return nil
+		}
+	}
+
+	return ErrNoPerm
+}
+
+// authenticate logs the user in and selects the default database, if the user is allowed to access it
+func (s *session) authenticate(username, password []byte) error {
+	usr, err := auth.GetUser(s.sysDb, username)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return ErrWrongPass
+	}
+	if err != nil {
+		return err
+	}
+
+	if !usr.Active || usr.ComparePasswords(password) != nil {
+		return ErrWrongPass
+	}
+
+	s.user = usr
+	s.database = nil
+	s.resetCursors()
+
+	if s.defaultDatabase != "" {
+		err = s.selectDatabase(s.defaultDatabase)
+		if err != nil {
+			s.log.Debugf("default database not selected for user %s: %v", usr.Username, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *session) selectDatabase(name string) error {
+	db, err := s.dbList.GetByName(name)
+	if errors.Is(err, database.ErrDatabaseNotExists) {
+		return fmt.Errorf("database '%s' does not exist", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.user.WhichPermission(name) == auth.PermissionNone {
+		return ErrNoPerm
+	}
+
+	s.database = db
+	s.resetCursors()
+
+	return nil
+}
+
+// txMetadata records the authenticated user as the writer of the transactions
+func (s *session) txMetadata() *schema.TxMetadata {
+	return &schema.TxMetadata{Writer: s.user.Username}
+}
+
+// newCursor returns the cursor of a scan to be resumed after lastKey
+func (s *session) newCursor(lastKey []byte) uint64 {
+	s.lastCursor++
+	s.cursors[s.lastCursor] = lastKey
+
+	if s.lastCursor > maxCursors {
+		delete(s.cursors, s.lastCursor-maxCursors)
+	}
+
+	return s.lastCursor
+}
+
+func (s *session) resetCursors() {
+	s.cursors = make(map[uint64][]byte)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func putUser(t *testing.T, sysDb database.DB, usr *auth.User) {
+	value, err := json.Marshal(usr)
+	require.NoError(t, err)
+
+	_, err = sysDb.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: auth.UserKey([]byte(usr.Username)), Value: value}}})
+	require.NoError(t, err)
+}
+
+func TestSessionCheckAccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log := logger.NewSimpleLogger("resp", os.Stderr)
+
+	sysDb, err := database.NewDB(database.DefaultOption().WithDBRootPath(dir).WithDBName("systemdb"), log)
+	require.NoError(t, err)
+	defer sysDb.Close()
+
+	db1, err := database.NewDB(database.DefaultOption().WithDBRootPath(dir).WithDBName("db1"), log)
+	require.NoError(t, err)
+	defer db1.Close()
+
+	db2, err := database.NewDB(database.DefaultOption().WithDBRootPath(dir).WithDBName("db2"), log)
+	require.NoError(t, err)
+	defer db2.Close()
+
+	dbList := database.NewDatabaseList()
+	dbList.Append(db1)
+	dbList.Append(db2)
+
+	s := &session{log: log, dbList: dbList, sysDb: sysDb, cursors: make(map[uint64][]byte)}
+
+	require.NoError(t, s.checkAccess(accessNone))
+	require.Equal(t, ErrNoAuth, s.checkAccess(accessAuthenticated))
+	require.Equal(t, ErrNoAuth, s.checkAccess(accessRead))
+
+	reader := &auth.User{
+		Username: "reader",
+		Active:   true,
+		Permissions: []auth.Permission{
+			{Database: "db1", Permission: auth.PermissionR},
+		},
+	}
+	putUser(t, sysDb, reader)
+
+	s.user = reader
+
+	require.NoError(t, s.checkAccess(accessAuthenticated))
+	require.Equal(t, ErrNoDatabase, s.checkAccess(accessRead))
+
+	require.Equal(t, ErrNoPerm, s.selectDatabase("db2"))
+	require.Error(t, s.selectDatabase("db3"))
+
+	s.newCursor([]byte("key"))
+	require.NoError(t, s.selectDatabase("db1"))
+	require.Empty(t, s.cursors)
+
+	require.NoError(t, s.checkAccess(accessRead))
+	require.Equal(t, ErrNoPerm, s.checkAccess(accessWrite))
+
+	// permission changes apply to the open connection
+	reader.GrantPermission("db2", auth.PermissionRW)
+	putUser(t, sysDb, reader)
+
+	require.NoError(t, s.checkAccess(accessAuthenticated))
+	require.NoError(t, s.selectDatabase("db2"))
+	require.NoError(t, s.checkAccess(accessWrite))
+
+	// so does deactivation
+	reader.Active = false
+	putUser(t, sysDb, reader)
+
+	require.Equal(t, ErrNoAuth, s.checkAccess(accessRead))
+	require.Nil(t, s.user)
+	require.Nil(t, s.database)
+
+	s.user = &auth.User{Username: "unknown"}
+	require.Equal(t, ErrNoAuth, s.checkAccess(accessAuthenticated))
+
+	putUser(t, sysDb, &auth.User{Username: auth.SysAdminUsername, Active: true})
+
+	s.user = &auth.User{Username: auth.SysAdminUsername}
+	require.NoError(t, s.checkAccess(accessAuthenticated))
+	require.True(t, s.user.IsSysAdmin)
+	require.NoError(t, s.selectDatabase("db2"))
+	require.NoError(t, s.checkAccess(accessWrite))
+}
+
+func TestSessionCursors(t *testing.T) {
+	s := &session{cursors: make(map[uint64][]byte)}
+
+	first := s.newCursor([]byte("k0"))
+
+	for i := 0; i < maxCursors; i++ {
+		s.newCursor([]byte("k"))
+	}
+
+	require.Len(t, s.cursors, maxCursors)
+	require.NotContains(t, s.cursors, first)
+}
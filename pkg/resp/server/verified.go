@@ -0,0 +1,175 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/proofs"
+)
+
+// state replies with the current state of the database, signed when the server has a signing key,
+// encoded as the state of the proof bundles
+func (s *session) state(args [][]byte) error {
+	state, err := s.signedState()
+	if err != nil {
+		return err
+	}
+
+	data, err := proofs.MarshalJSON(state)
+	if err != nil {
+		return err
+	}
+
+	s.w.bulk(data)
+	return nil
+}
+
+// verifiedGet replies with the value of a key and the proof bundle of its inclusion in the current state
+// of the database, as verified by immuclient proof verify. The bundle proves the consistency of that state
+// with the transaction provided by SINCETX, the one of a state the client already trusts.
+// A nil value is replied when the key is not set.
+func (s *session) verifiedGet(args [][]byte) error {
+	var sinceTx uint64
+
+	if len(args) > 1 {
+		if len(args) != 3 || !strings.EqualFold(string(args[1]), "SINCETX") {
+			return ErrSyntax
+		}
+
+		var err error
+
+		sinceTx, err = strconv.ParseUint(string(args[2]), 10, 64)
+		if err != nil {
+			return ErrInvalidInt
+		}
+	}
+
+	bundle, err := s.proofBundle(args[0], sinceTx)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		s.w.bulk(nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := proofs.MarshalJSON(bundle)
+	if err != nil {
+		return err
+	}
+
+	value := bundle.Entries[0].Value
+	if value == nil {
+		value = []byte{}
+	}
+
+	s.w.array(2)
+	s.w.bulk(value)
+	s.w.bulk(data)
+	return nil
+}
+
+func (s *session) signedState() (*proofs.SignedState, error) {
+	state, err := s.database.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	state.Db = s.database.GetName()
+
+	if s.signer != nil {
+		err = s.signer.Sign(state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return proofs.FromImmutableState(state)
+}
+
+// proofBundle returns the proof of the current value of key, built as the client does when exporting bundles
+func (s *session) proofBundle(key []byte, sinceTx uint64) (*proofs.Bundle, error) {
+	// the value is read before the state so it's included in it
+	entry, err := s.database.Get(&schema.KeyRequest{Key: key})
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.ReferencedBy != nil {
+		return nil, fmt.Errorf("key %s is a reference, references are not supported", printable(key))
+	}
+
+	state, err := s.signedState()
+	if err != nil {
+		return nil, err
+	}
+
+	vEntry, err := s.database.VerifiableGet(&schema.VerifiableGetRequest{
+		KeyRequest:   &schema.KeyRequest{Key: entry.Key, AtTx: entry.Tx},
+		ProveSinceTx: state.TxID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dualProof, err := schema.DualProofFromProto(vEntry.VerifiableTx.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &proofs.Bundle{
+		State: state,
+		Entries: []*proofs.EntryProof{{
+			Key:            vEntry.Entry.Key,
+			Value:          vEntry.Entry.Value,
+			Metadata:       proofs.FromKVMetadata(vEntry.Entry.Metadata),
+			Tx:             vEntry.Entry.Tx,
+			InclusionProof: proofs.FromInclusionProof(schema.InclusionProofFromProto(vEntry.InclusionProof)),
+			DualProof:      proofs.FromDualProof(dualProof),
+		}},
+	}
+
+	if sinceTx > 0 && sinceTx != state.TxID {
+		sourceTx, targetTx := sinceTx, state.TxID
+		if sinceTx > state.TxID {
+			sourceTx, targetTx = state.TxID, sinceTx
+		}
+
+		consistencyProof, err := s.database.ConsistencyProof(&schema.ConsistencyProofRequest{
+			SourceTx: sourceTx,
+			TargetTx: targetTx,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		dualProof, err := schema.DualProofFromProto(consistencyProof)
+		if err != nil {
+			return nil, err
+		}
+
+		bundle.Consistency = proofs.FromDualProof(dualProof)
+	}
+
+	return bundle, nil
+}
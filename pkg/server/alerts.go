@@ -0,0 +1,58 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/alert"
+)
+
+// corruptionAlerter returns the handler raising alerts for the corruptions detected by the scrubber of the database db
+func (s *ImmuServer) corruptionAlerter(db string) store.CorruptionHandler {
+	return func(txID uint64, err error) {
+		s.raiseAlert(&alert.Alert{
+			Kind:    alert.Corruption,
+			Source:  "scrubber",
+			DB:      db,
+			FromTx:  txID,
+			ToTx:    txID,
+			Message: fmt.Sprintf("verification of tx %d failed", txID),
+			Evidence: map[string]string{
+				"error": err.Error(),
+			},
+			RaisedAt: time.Now(),
+		})
+	}
+}
+
+// raiseAlert delivers an alert through the configured channels, delivery failures are logged
+func (s *ImmuServer) raiseAlert(a *alert.Alert) {
+	if s.alerter == nil {
+		return
+	}
+
+	err := s.alerter.Alert(a)
+	if err != nil {
+		s.Logger.Errorf("unable to raise %s alert for database '%s': %v", a.Kind, a.DB, err)
+		return
+	}
+
+	s.Logger.Infof("%s alert for database '%s' raised", a.Kind, a.DB)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerAlerts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_alerts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithAlertOptions(alert.DefaultOptions().WithSMTPAddress("smtp.local"))
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.ErrorIs(t, err, alert.ErrIllegalArguments)
+
+	serverOptions.WithAlertOptions(alert.DefaultOptions().WithWebhookURL("http://alerts.local"))
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	require.NotNil(t, s.alerter)
+
+	var alerts []*alert.Alert
+
+	s.alerter = alert.AlerterFunc(func(a *alert.Alert) error {
+		alerts = append(alerts, a)
+		return nil
+	})
+
+	corruptionErr := fmt.Errorf("%w: linear hash chain broken at tx 3", store.ErrorCorruptedTxData)
+
+	s.corruptionAlerter(DefaultDBName)(3, corruptionErr)
+	require.Len(t, alerts, 1)
+	require.Equal(t, alert.Corruption, alerts[0].Kind)
+	require.Equal(t, DefaultDBName, alerts[0].DB)
+	require.Equal(t, uint64(3), alerts[0].FromTx)
+	require.Equal(t, uint64(3), alerts[0].ToTx)
+	require.Equal(t, corruptionErr.Error(), alerts[0].Evidence["error"])
+
+	// delivery failures don't prevent later alerts
+	s.alerter = alert.AlerterFunc(func(a *alert.Alert) error {
+		return errors.New("channel unavailable")
+	})
+
+	s.corruptionAlerter(DefaultDBName)(4, corruptionErr)
+	require.Len(t, alerts, 1)
+
+	dbOpts := s.databaseOptionsFrom(s.defaultDBOptions(DefaultDBName))
+	require.NotNil(t, dbOpts.GetStoreOptions().CorruptionHandler)
+}
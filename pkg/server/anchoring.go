@@ -0,0 +1,273 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/anchor"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+const stateAnchorRequestTimeout = time.Minute
+
+// stateAnchor is the receipt of an anchored digest as stored in the system database, states are
+// kept along with it so the digest can be recomputed with anchor.Digest
+type stateAnchor struct {
+	Anchor     string         `json:"anchor"`
+	Digest     []byte         `json:"digest"`
+	States     []anchor.State `json:"states"`
+	Proof      []byte         `json:"proof"`
+	AnchoredAt time.Time      `json:"anchoredAt"`
+}
+
+// stateAnchorer publishes, at the times set by its schedule, a single digest of the state of all
+// the databases. The digest is not published again to an anchor while no database changes
+type stateAnchorer struct {
+	anchors []anchor.Anchor
+	cron    *cronSchedule
+
+	now      func() time.Time
+	newTimer func(d time.Duration) *time.Timer
+	states   func() []*schema.ImmutableState
+	store    func(sa *stateAnchor) error
+	notify   func(anchorName string, err error)
+
+	// last digest published to each anchor
+	lastDigest map[string][]byte
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func newStateAnchorer(
+	opts *AnchoringOptions,
+	states func() []*schema.ImmutableState,
+	store func(sa *stateAnchor) error,
+	notify func(anchorName string, err error),
+) (*stateAnchorer, error) {
+	sa := &stateAnchorer{
+		now:        time.Now,
+		newTimer:   time.NewTimer,
+		states:     states,
+		store:      store,
+		notify:     notify,
+		lastDigest: make(map[string][]byte),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+
+	if !opts.enabled() {
+		return sa, nil
+	}
+
+	cron, err := parseCron(opts.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	sa.cron = cron
+
+	for _, calendar := range opts.OpenTimestampsCalendars {
+		sa.anchors = append(sa.anchors, anchor.NewOpenTimestamps(calendar, stateAnchorRequestTimeout))
+	}
+
+	if opts.EthereumRPCURL != "" {
+		eth, err := anchor.NewEthereum(opts.EthereumRPCURL, opts.EthereumFrom, opts.EthereumContract, opts.EthereumMethod, stateAnchorRequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		sa.anchors = append(sa.anchors, eth)
+	}
+
+	return sa, nil
+}
+
+func (sa *stateAnchorer) enabled() bool {
+	return sa != nil && len(sa.anchors) > 0
+}
+
+// seed marks a digest as the last one published to an anchor
+func (sa *stateAnchorer) seed(a *stateAnchor) {
+	if _, ok := sa.lastDigest[a.Anchor]; !ok {
+		sa.lastDigest[a.Anchor] = a.Digest
+	}
+}
+
+// anchorAll publishes the digest of the current states to each anchor, failures are notified
+// and retried on the next run
+func (sa *stateAnchorer) anchorAll(ctx context.Context, at time.Time) {
+	var states []anchor.State
+
+	for _, s := range sa.states() {
+		states = append(states, anchor.State{Database: s.Db, TxID: s.TxId, Alh: s.TxHash})
+	}
+
+	digest := anchor.Digest(states)
+
+	for _, a := range sa.anchors {
+		select {
+		case <-sa.done:
+			return
+		default:
+		}
+
+		if bytes.Equal(sa.lastDigest[a.Name()], digest[:]) {
+			continue
+		}
+
+		receipt, err := a.Anchor(ctx, digest[:])
+		if err == nil {
+			err = sa.store(&stateAnchor{
+				Anchor:     receipt.Anchor,
+				Digest:     receipt.Digest,
+				States:     states,
+				Proof:      receipt.Proof,
+				AnchoredAt: at,
+			})
+		}
+		if err != nil {
+			sa.notify(a.Name(), err)
+			continue
+		}
+
+		sa.lastDigest[a.Name()] = digest[:]
+	}
+}
+
+func (sa *stateAnchorer) start() {
+	if !sa.enabled() {
+		return
+	}
+
+	sa.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			<-sa.done
+			cancel()
+		}()
+
+		go func() {
+			defer close(sa.stopped)
+
+			for {
+				next := sa.cron.next(sa.now())
+				if next.IsZero() {
+					return
+				}
+
+				timer := sa.newTimer(next.Sub(sa.now()))
+
+				select {
+				case <-sa.done:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+
+				sa.anchorAll(ctx, sa.now())
+			}
+		}()
+	})
+}
+
+// stop cancels the publication in progress, if any
+func (sa *stateAnchorer) stop() {
+	if !sa.enabled() {
+		return
+	}
+
+	sa.stopOnce.Do(func() {
+		close(sa.done)
+
+		started := true
+		sa.startOnce.Do(func() { started = false })
+
+		if started {
+			<-sa.stopped
+		}
+	})
+}
+
+// stateAnchorKey sorts the receipts by time, i.e. <prefix><anchored_at><anchor>
+func stateAnchorKey(sa *stateAnchor) []byte {
+	key := make([]byte, 1+8+len(sa.Anchor))
+	key[0] = KeyPrefixStateAnchor
+	binary.BigEndian.PutUint64(key[1:], uint64(sa.AnchoredAt.UnixNano()))
+	copy(key[9:], sa.Anchor)
+	return key
+}
+
+func (s *ImmuServer) storeStateAnchor(sa *stateAnchor) error {
+	serializedAnchor, err := json.Marshal(sa)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: stateAnchorKey(sa), Value: serializedAnchor}}})
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Infof("state of %d databases anchored to '%s'", len(sa.States), sa.Anchor)
+
+	return nil
+}
+
+func (s *ImmuServer) notifyStateAnchorFailure(anchorName string, err error) {
+	s.Logger.Warningf("unable to anchor the state of the databases to '%s': %v", anchorName, err)
+}
+
+// loadStateAnchors registers into the anchorer the last digest published to each anchor,
+// so it's not published again after a restart
+func (s *ImmuServer) loadStateAnchors() error {
+	if !s.stateAnchorer.enabled() {
+		return nil
+	}
+
+	// receipts are sorted by time, the last ones hold the last digest published to each anchor
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: []byte{KeyPrefixStateAnchor},
+		Desc:   true,
+		Limit:  uint64(len(s.stateAnchorer.anchors)),
+		NoWait: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries.Entries {
+		var sa stateAnchor
+
+		err = json.Unmarshal(e.Value, &sa)
+		if err != nil {
+			return err
+		}
+
+		s.stateAnchorer.seed(&sa)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/anchor"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAnchor struct {
+	name     string
+	err      error
+	requests int
+}
+
+func (a *fakeAnchor) Name() string {
+	return a.name
+}
+
+func (a *fakeAnchor) Anchor(ctx context.Context, digest []byte) (*anchor.Receipt, error) {
+	a.requests++
+
+	if a.err != nil {
+		return nil, a.err
+	}
+
+	return &anchor.Receipt{Anchor: a.name, Digest: digest, Proof: []byte("receipt")}, nil
+}
+
+func TestNewStateAnchorer(t *testing.T) {
+	sa, err := newStateAnchorer(DefaultAnchoringOptions(), nil, nil, nil)
+	require.NoError(t, err)
+	require.False(t, sa.enabled())
+
+	_, err = newStateAnchorer(DefaultAnchoringOptions().
+		WithSchedule("@never").
+		WithOpenTimestampsCalendars([]string{"https://a.pool.opentimestamps.org"}), nil, nil, nil)
+	require.ErrorIs(t, err, ErrInvalidCronExpression)
+
+	_, err = newStateAnchorer(DefaultAnchoringOptions().
+		WithEthereumRPCURL("http://localhost:8545").
+		WithEthereumFrom("invalid"), nil, nil, nil)
+	require.ErrorIs(t, err, anchor.ErrIllegalArguments)
+
+	sa, err = newStateAnchorer(DefaultAnchoringOptions().
+		WithOpenTimestampsCalendars([]string{"https://a.pool.opentimestamps.org", "https://b.pool.opentimestamps.org"}).
+		WithEthereumRPCURL("http://localhost:8545").
+		WithEthereumFrom("0x00000000000000000000000000000000000000aa").
+		WithEthereumContract("0x00000000000000000000000000000000000000bb"), nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, sa.anchors, 3)
+	require.Equal(t, "ethereum:0x00000000000000000000000000000000000000bb", sa.anchors[2].Name())
+}
+
+func TestStateAnchorerAnchorAll(t *testing.T) {
+	states := []*schema.ImmutableState{
+		{Db: "db1", TxId: 1, TxHash: []byte("alh1")},
+		{Db: "db2", TxId: 3, TxHash: []byte("alh3")},
+	}
+
+	var stored []*stateAnchor
+	var failures []string
+
+	sa, err := newStateAnchorer(
+		DefaultAnchoringOptions(),
+		func() []*schema.ImmutableState { return states },
+		func(a *stateAnchor) error {
+			stored = append(stored, a)
+			return nil
+		},
+		func(anchorName string, err error) {
+			failures = append(failures, anchorName)
+		},
+	)
+	require.NoError(t, err)
+
+	a1 := &fakeAnchor{name: "anchor1"}
+	a2 := &fakeAnchor{name: "anchor2", err: errors.New("insufficient funds")}
+	sa.anchors = []anchor.Anchor{a1, a2}
+
+	at := time.Date(2022, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	sa.anchorAll(context.Background(), at)
+	require.Len(t, stored, 1)
+	require.Equal(t, "anchor1", stored[0].Anchor)
+	require.Equal(t, at, stored[0].AnchoredAt)
+	require.Len(t, stored[0].States, 2)
+	require.Equal(t, []string{"anchor2"}, failures)
+
+	digest := anchor.Digest(stored[0].States)
+	require.Equal(t, digest[:], stored[0].Digest)
+
+	// unchanged states are not anchored again, failed ones are retried
+	a2.err = nil
+	sa.anchorAll(context.Background(), at.Add(24*time.Hour))
+	require.Equal(t, 1, a1.requests)
+	require.Equal(t, 2, a2.requests)
+	require.Len(t, stored, 2)
+
+	states[0] = &schema.ImmutableState{Db: "db1", TxId: 2, TxHash: []byte("alh2")}
+	sa.anchorAll(context.Background(), at.Add(48*time.Hour))
+	require.Equal(t, 2, a1.requests)
+	require.Equal(t, 3, a2.requests)
+	require.Len(t, stored, 4)
+}
+
+func TestStateAnchorerStartStop(t *testing.T) {
+	var sa *stateAnchorer
+	sa.start()
+	sa.stop()
+
+	anchored := make(chan struct{}, 1)
+
+	sa, err := newStateAnchorer(
+		DefaultAnchoringOptions(),
+		func() []*schema.ImmutableState {
+			return []*schema.ImmutableState{{Db: "db1", TxId: 1, TxHash: []byte("alh1")}}
+		},
+		func(a *stateAnchor) error {
+			select {
+			case anchored <- struct{}{}:
+			default:
+			}
+			return nil
+		},
+		func(anchorName string, err error) {},
+	)
+	require.NoError(t, err)
+
+	sa.cron, err = parseCron("* * * * *")
+	require.NoError(t, err)
+	sa.anchors = []anchor.Anchor{&fakeAnchor{name: "anchor1"}}
+
+	// the first run is due right away
+	timers := 0
+	sa.newTimer = func(d time.Duration) *time.Timer {
+		timers++
+		if timers == 1 {
+			return time.NewTimer(0)
+		}
+		return time.NewTimer(time.Hour)
+	}
+
+	sa.start()
+
+	select {
+	case <-anchored:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "state not anchored")
+	}
+
+	sa.stop()
+	sa.stop()
+}
+
+func TestServerStateAnchors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_state_anchors")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithAnchoringOptions(DefaultAnchoringOptions().
+			WithOpenTimestampsCalendars([]string{"https://a.pool.opentimestamps.org"}))
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	a := &fakeAnchor{name: s.stateAnchorer.anchors[0].Name()}
+	s.stateAnchorer.anchors = []anchor.Anchor{a}
+
+	at := time.Date(2022, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	s.stateAnchorer.anchorAll(context.Background(), at)
+	require.Equal(t, 1, a.requests)
+
+	entry, err := s.sysDB.Get(&schema.KeyRequest{Key: stateAnchorKey(&stateAnchor{Anchor: a.name, AnchoredAt: at})})
+	require.NoError(t, err)
+
+	var sa stateAnchor
+	err = json.Unmarshal(entry.Value, &sa)
+	require.NoError(t, err)
+	require.Equal(t, []byte("receipt"), sa.Proof)
+	require.Len(t, sa.States, s.dbList.Length())
+	require.Equal(t, DefaultDBName, sa.States[0].Database)
+
+	// digests already anchored are not published again after a restart
+	err = s.CloseDatabases()
+	require.NoError(t, err)
+
+	err = s.Listener.Close()
+	require.NoError(t, err)
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	a = &fakeAnchor{name: a.name}
+	s.stateAnchorer.anchors = []anchor.Anchor{a}
+
+	s.stateAnchorer.anchorAll(context.Background(), at.Add(24*time.Hour))
+	require.Zero(t, a.requests)
+}
@@ -0,0 +1,496 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// auditReport is the JSON document of an audit report, it covers a range of transactions of a database
+// along with the actions of the admins recorded in the system database while they were committed
+type auditReport struct {
+	Database     string     `json:"database"`
+	GeneratedAt  time.Time  `json:"generatedAt"`
+	GeneratedBy  string     `json:"generatedBy"`
+	FromTx       uint64     `json:"fromTx"`
+	ToTx         uint64     `json:"toTx"`
+	From         *time.Time `json:"from,omitempty"`
+	Until        *time.Time `json:"until,omitempty"`
+	Transactions uint64     `json:"transactions"`
+	Entries      uint64     `json:"entries"`
+	TxHash       string     `json:"txHash,omitempty"`
+
+	Verifications []*auditVerification `json:"verifications"`
+	Checkpoints   []*auditCheckpoint   `json:"checkpoints"`
+	Erasures      []*auditErasure      `json:"erasures"`
+	LegalHolds    []*auditLegalHold    `json:"legalHolds"`
+	AdminActions  []*auditAdminAction  `json:"adminActions"`
+}
+
+// auditVerification is the outcome of verifying that the transactions of a database are intact and linked
+type auditVerification struct {
+	Database string `json:"database"`
+	FromTx   uint64 `json:"fromTx"`
+	ToTx     uint64 `json:"toTx"`
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// auditCheckpoint is the state of the database cosigned by an auditor, Verified is set when it matches the database
+type auditCheckpoint struct {
+	TxID       uint64    `json:"txId"`
+	TxHash     string    `json:"txHash"`
+	Cosigner   string    `json:"cosigner"`
+	CosignedAt time.Time `json:"cosignedAt"`
+	Verified   bool      `json:"verified"`
+}
+
+type auditErasure struct {
+	Key         string    `json:"key"`
+	Tx          uint64    `json:"tx"`
+	RequestedBy string    `json:"requestedBy"`
+	Reason      string    `json:"reason,omitempty"`
+	RecordTx    uint64    `json:"recordTx"`
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+type auditLegalHold struct {
+	Key    string    `json:"key,omitempty"`
+	Table  string    `json:"table,omitempty"`
+	Action string    `json:"action"`
+	By     string    `json:"by"`
+	Reason string    `json:"reason,omitempty"`
+	Tx     uint64    `json:"tx"`
+	At     time.Time `json:"at"`
+}
+
+// auditAdminAction is a change made by an admin as recorded in the system database, Tx is of the system database
+type auditAdminAction struct {
+	Action string    `json:"action"`
+	Target string    `json:"target"`
+	By     string    `json:"by,omitempty"`
+	Tx     uint64    `json:"tx"`
+	At     time.Time `json:"at"`
+}
+
+// recordAuthor holds who set a record of the system database, records name it differently
+type recordAuthor struct {
+	CreatedBy string `json:"createdBy"`
+	UpdatedBy string `json:"updatedBy"`
+	SetBy     string `json:"setBy"`
+}
+
+// GenerateAuditReport returns a report of a range of transactions of a database signed by the server. The report
+// lists the number of transactions, the outcome of verifying them, the states cosigned by auditors, the erasures,
+// the legal holds and the actions of the admins recorded while the transactions were committed
+func (s *ImmuServer) GenerateAuditReport(ctx context.Context, req *schema.AuditReportRequest) (*schema.AuditReport, error) {
+	s.Logger.Debugf("generateauditreport")
+
+	if req == nil || req.Database == "" || (req.SinceTx > 0 && req.Since > 0) || (req.UntilTx > 0 && req.Until > 0) {
+		return nil, ErrIllegalArguments
+	}
+
+	generatedBy, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.StateSigner == nil {
+		return nil, ErrAuditReportSigningDisabled
+	}
+
+	db, err := s.dbList.GetByName(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.auditReport(db, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	report.GeneratedBy = generatedBy
+
+	serializedReport, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.AuditReport{Report: serializedReport}
+
+	err = s.StateSigner.SignAuditReport(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Rendered {
+		res.Rendered = report.render()
+	}
+
+	s.Logger.Infof("audit report of database '%s' on txs %d-%d generated by '%s'", req.Database, report.FromTx, report.ToTx, generatedBy)
+
+	return res, nil
+}
+
+func (s *ImmuServer) auditReport(db database.DB, req *schema.AuditReportRequest, now time.Time) (*auditReport, error) {
+	fromTx, toTx, err := auditTxRange(db, req.SinceTx, req.UntilTx, req.Since, req.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, auditErr := db.AuditTxs(fromTx, toTx, nil)
+	if audit == nil {
+		return nil, auditErr
+	}
+
+	report := &auditReport{
+		Database:     req.Database,
+		GeneratedAt:  now,
+		FromTx:       audit.FromTx,
+		ToTx:         audit.ToTx,
+		Transactions: audit.ToTx + 1 - audit.FromTx,
+		Entries:      audit.Entries,
+	}
+
+	report.Verifications = append(report.Verifications, auditVerificationOf(req.Database, audit, auditErr))
+
+	if audit.ToTx >= audit.FromTx {
+		from := time.Unix(audit.FirstTs, 0)
+		until := time.Unix(audit.LastTs, 0)
+
+		report.From = &from
+		report.Until = &until
+		report.TxHash = hex.EncodeToString(audit.Alh[:])
+	}
+
+	// the actions of the admins are looked up while the transactions were committed, or in the requested period
+	if req.Since > 0 {
+		from := time.Unix(req.Since, 0)
+		report.From = &from
+	}
+	if req.Until > 0 {
+		until := time.Unix(req.Until, 0)
+		report.Until = &until
+	}
+
+	for _, e := range audit.Erasures {
+		report.Erasures = append(report.Erasures, &auditErasure{
+			Key:         string(e.Key),
+			Tx:          e.Tx,
+			RequestedBy: e.RequestedBy,
+			Reason:      e.Reason,
+			RecordTx:    e.RecordTx,
+			RequestedAt: time.Unix(e.RequestedAt, 0),
+		})
+	}
+
+	for _, h := range audit.LegalHolds {
+		action := "lifted"
+		if h.Placed {
+			action = "placed"
+		}
+
+		report.LegalHolds = append(report.LegalHolds, &auditLegalHold{
+			Key:    string(h.Key),
+			Table:  h.Table,
+			Action: action,
+			By:     h.By,
+			Reason: h.Reason,
+			Tx:     h.Tx,
+			At:     time.Unix(h.Ts, 0),
+		})
+	}
+
+	if audit.ToTx >= audit.FromTx {
+		report.Checkpoints, err = s.auditCheckpoints(db, audit.FromTx, audit.ToTx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if report.From != nil && report.Until != nil {
+		err = s.auditAdminActions(report)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// auditTxRange returns the range of transactions committed in the requested range, either of transactions
+// or of time, the range is empty when no transaction was committed in it
+func auditTxRange(db database.DB, sinceTx, untilTx uint64, since, until int64) (fromTx uint64, toTx uint64, err error) {
+	fromTx = 1
+	toTx = math.MaxUint64
+
+	if sinceTx > 0 {
+		fromTx = sinceTx
+	}
+	if untilTx > 0 {
+		toTx = untilTx
+	}
+
+	if since > 0 {
+		tx, err := db.TxByTime(&schema.TxByTimeRequest{Ts: since, Since: true})
+		if errors.Is(err, store.ErrTxNotFound) {
+			return 1, 0, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		fromTx = tx.Header.Id
+	}
+
+	if until > 0 {
+		tx, err := db.TxByTime(&schema.TxByTimeRequest{Ts: until})
+		if errors.Is(err, store.ErrTxNotFound) {
+			return 1, 0, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		toTx = tx.Header.Id
+	}
+
+	return fromTx, toTx, nil
+}
+
+func auditVerificationOf(dbName string, audit *database.TxAudit, err error) *auditVerification {
+	verification := &auditVerification{
+		Database: dbName,
+		FromTx:   audit.FromTx,
+		ToTx:     audit.ToTx,
+		Verified: err == nil,
+	}
+
+	if err != nil {
+		verification.Error = err.Error()
+	}
+
+	return verification
+}
+
+// auditCheckpoints returns the states of the database cosigned by auditors in the range of transactions,
+// each one verified against the state of the database
+func (s *ImmuServer) auditCheckpoints(db database.DB, fromTx, toTx uint64) ([]*auditCheckpoint, error) {
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix:  cosignaturePrefix(db.GetName(), 0),
+		SeekKey: cosignaturePrefix(db.GetName(), fromTx),
+		NoWait:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoints []*auditCheckpoint
+
+	for _, e := range entries.Entries {
+		txID := binary.BigEndian.Uint64(e.Key[len(e.Key)-sha256.Size-8:])
+
+		if txID < fromTx {
+			continue
+		}
+		if txID > toTx {
+			break
+		}
+
+		var c cosignature
+
+		err = json.Unmarshal(e.Value, &c)
+		if err != nil {
+			return nil, err
+		}
+
+		tx, err := db.TxByID(&schema.TxRequest{Tx: txID})
+		if err != nil {
+			return nil, err
+		}
+
+		txHdr, err := schema.TxHeaderFromProto(tx.Header)
+		if err != nil {
+			return nil, err
+		}
+
+		alh := txHdr.Alh()
+		cosigner := sha256.Sum256(c.PublicKey)
+
+		checkpoints = append(checkpoints, &auditCheckpoint{
+			TxID:       txID,
+			TxHash:     hex.EncodeToString(c.TxHash),
+			Cosigner:   hex.EncodeToString(cosigner[:]),
+			CosignedAt: c.CosignedAt,
+			Verified:   bytes.Equal(alh[:], c.TxHash),
+		})
+	}
+
+	return checkpoints, nil
+}
+
+// auditAdminActions adds to the report the actions of the admins on the database and on the users recorded in
+// the system database during the period of the report, the transactions of the system database are verified as well
+func (s *ImmuServer) auditAdminActions(report *auditReport) error {
+	fromTx, toTx, err := auditTxRange(s.sysDB, 0, 0, report.From.Unix(), report.Until.Unix())
+	if err != nil {
+		return err
+	}
+
+	dbKey := []byte(report.Database)
+
+	audit, err := s.sysDB.AuditTxs(fromTx, toTx, func(hdr *store.TxHeader, key []byte, md *store.KVMetadata, value []byte) error {
+		if len(key) == 0 {
+			return nil
+		}
+
+		var action, target string
+
+		switch key[0] {
+		case KeyPrefixUser:
+			action, target = "user set", string(key[1:])
+		case KeyPrefixDBSettings:
+			action = "database settings set"
+		case KeyPrefixBackupSchedule:
+			action = "backup schedule set"
+		case KeyPrefixRetentionPolicy:
+			action = "retention policy set"
+		case KeyPrefixWORMActivation:
+			action = "WORM compliance mode set"
+		default:
+			return nil
+		}
+
+		if key[0] == KeyPrefixWORMActivation {
+			if !bytes.HasPrefix(key[1:], append(dbKey, 0)) {
+				return nil
+			}
+			target = report.Database
+		} else if key[0] != KeyPrefixUser {
+			if !bytes.Equal(key[1:], dbKey) {
+				return nil
+			}
+			target = report.Database
+		}
+
+		var author recordAuthor
+
+		if md != nil && md.Deleted() {
+			action = strings.TrimSuffix(action, " set") + " deleted"
+		} else if value != nil && json.Unmarshal(value, &author) != nil {
+			// the action is reported without its author when the record can not be read
+			author = recordAuthor{}
+		}
+
+		by := author.UpdatedBy
+		if by == "" {
+			by = author.SetBy
+		}
+		if by == "" {
+			by = author.CreatedBy
+		}
+
+		report.AdminActions = append(report.AdminActions, &auditAdminAction{
+			Action: action,
+			Target: target,
+			By:     by,
+			Tx:     hdr.ID,
+			At:     time.Unix(hdr.Ts, 0),
+		})
+
+		return nil
+	})
+	if audit == nil {
+		return err
+	}
+
+	report.Verifications = append(report.Verifications, auditVerificationOf(SystemDBName, audit, err))
+
+	return nil
+}
+
+// render returns the report as plain text
+func (r *auditReport) render() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Audit report of database '%s'\n", r.Database)
+	fmt.Fprintf(&b, "Generated at %s by '%s'\n\n", r.GeneratedAt.UTC().Format(time.RFC3339), r.GeneratedBy)
+
+	if r.Transactions == 0 {
+		fmt.Fprintf(&b, "No transaction in the period\n")
+	} else {
+		fmt.Fprintf(&b, "Transactions %d-%d: %d transaction(s), %d entry(ies)\n", r.FromTx, r.ToTx, r.Transactions, r.Entries)
+		fmt.Fprintf(&b, "State at tx %d: %s\n", r.ToTx, r.TxHash)
+	}
+	if r.From != nil && r.Until != nil {
+		fmt.Fprintf(&b, "Period: %s - %s\n", r.From.UTC().Format(time.RFC3339), r.Until.UTC().Format(time.RFC3339))
+	}
+
+	fmt.Fprintf(&b, "\nVerification\n")
+	for _, v := range r.Verifications {
+		outcome := "verified"
+		if !v.Verified {
+			outcome = "FAILED: " + v.Error
+		}
+		fmt.Fprintf(&b, "  database '%s', txs %d-%d: %s\n", v.Database, v.FromTx, v.ToTx, outcome)
+	}
+
+	fmt.Fprintf(&b, "\nAuditor checkpoints: %d\n", len(r.Checkpoints))
+	for _, c := range r.Checkpoints {
+		outcome := "matches"
+		if !c.Verified {
+			outcome = "DOES NOT MATCH"
+		}
+		fmt.Fprintf(&b, "  tx %d cosigned by %s at %s: %s\n", c.TxID, c.Cosigner, c.CosignedAt.UTC().Format(time.RFC3339), outcome)
+	}
+
+	fmt.Fprintf(&b, "\nErasures: %d\n", len(r.Erasures))
+	for _, e := range r.Erasures {
+		fmt.Fprintf(&b, "  %s value of key '%s' set at tx %d erased as requested by '%s': %s\n",
+			e.RequestedAt.UTC().Format(time.RFC3339), e.Key, e.Tx, e.RequestedBy, e.Reason)
+	}
+
+	fmt.Fprintf(&b, "\nLegal holds: %d\n", len(r.LegalHolds))
+	for _, h := range r.LegalHolds {
+		target := fmt.Sprintf("key '%s'", h.Key)
+		if h.Table != "" {
+			target = fmt.Sprintf("table '%s'", h.Table)
+		}
+		fmt.Fprintf(&b, "  %s hold on %s %s by '%s': %s\n", h.At.UTC().Format(time.RFC3339), target, h.Action, h.By, h.Reason)
+	}
+
+	fmt.Fprintf(&b, "\nAdmin actions: %d\n", len(r.AdminActions))
+	for _, a := range r.AdminActions {
+		fmt.Fprintf(&b, "  %s %s '%s' by '%s'\n", a.At.UTC().Format(time.RFC3339), a.Action, a.Target, a.By)
+	}
+
+	return b.String()
+}
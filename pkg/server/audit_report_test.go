@@ -0,0 +1,179 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerAuditReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_audit_report")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithSigningKey("./../../test/signer/ec1.key")
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.GenerateAuditReport(ctx, &schema.AuditReportRequest{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.GenerateAuditReport(ctx, &schema.AuditReportRequest{Database: "auditdb", SinceTx: 1, Since: 1})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "auditdb"})
+	require.NoError(t, err)
+
+	ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "auditdb"})
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	hdr, err := s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("pii/alice"), Value: []byte("alice data")},
+		{Key: []byte("pii/bob"), Value: []byte("bob data")},
+	}})
+	require.NoError(t, err)
+
+	// the admin action is recorded in the system database while transactions are committed on the database
+	_, err = s.SetRetentionPolicy(ctx, &schema.RetentionPolicy{
+		Database: "auditdb",
+		Rules:    []*schema.RetentionRule{{Prefix: []byte("logs/"), ErasableAfter: 3600}},
+	})
+	require.NoError(t, err)
+
+	_, err = s.Erase(dbCtx, &schema.EraseRequest{Key: []byte("pii/alice"), Reason: "right to erasure"})
+	require.NoError(t, err)
+
+	_, err = s.PlaceLegalHold(dbCtx, &schema.LegalHoldRequest{Key: []byte("pii/bob"), Reason: "litigation"})
+	require.NoError(t, err)
+
+	state, err := s.CurrentState(dbCtx, nil)
+	require.NoError(t, err)
+
+	// the state is checkpointed by an auditor
+	cosigner, err := signer.NewSigner("./../../test/signer/ec3.key")
+	require.NoError(t, err)
+
+	pk, err := signer.ParsePublicKeyFile("./../../test/signer/ec3.pub")
+	require.NoError(t, err)
+	s.cosigners = append(s.cosigners, pk)
+
+	sig, cosignerPK, err := cosigner.Sign((&schema.ImmutableState{Db: "auditdb", TxId: state.TxId, TxHash: state.TxHash}).ToBytes())
+	require.NoError(t, err)
+
+	_, err = s.Cosign(dbCtx, &schema.Cosignature{
+		TxId:      state.TxId,
+		TxHash:    state.TxHash,
+		Signature: &schema.Signature{Signature: sig, PublicKey: cosignerPK},
+	})
+	require.NoError(t, err)
+
+	res, err := s.GenerateAuditReport(ctx, &schema.AuditReportRequest{Database: "auditdb", SinceTx: hdr.Id, Rendered: true})
+	require.NoError(t, err)
+	require.NotNil(t, res.Signature)
+	require.Contains(t, res.Rendered, "Audit report of database 'auditdb'")
+
+	serverPK, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	ok, err := signer.Verify(res.Report, res.Signature.Signature, serverPK)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var report auditReport
+
+	err = json.Unmarshal(res.Report, &report)
+	require.NoError(t, err)
+	require.Equal(t, "auditdb", report.Database)
+	require.Equal(t, auth.SysAdminUsername, report.GeneratedBy)
+	require.Equal(t, hdr.Id, report.FromTx)
+	require.Equal(t, state.TxId, report.ToTx)
+	require.Equal(t, uint64(3), report.Transactions)
+	require.Equal(t, uint64(4), report.Entries)
+	require.Equal(t, hex.EncodeToString(state.TxHash), report.TxHash)
+
+	require.Len(t, report.Verifications, 2)
+	require.Equal(t, "auditdb", report.Verifications[0].Database)
+	require.True(t, report.Verifications[0].Verified)
+	require.Equal(t, SystemDBName, report.Verifications[1].Database)
+	require.True(t, report.Verifications[1].Verified)
+
+	require.Len(t, report.Checkpoints, 1)
+	require.Equal(t, state.TxId, report.Checkpoints[0].TxID)
+	require.True(t, report.Checkpoints[0].Verified)
+
+	require.Len(t, report.Erasures, 1)
+	require.Equal(t, "pii/alice", report.Erasures[0].Key)
+	require.Equal(t, auth.SysAdminUsername, report.Erasures[0].RequestedBy)
+
+	require.Len(t, report.LegalHolds, 1)
+	require.Equal(t, "placed", report.LegalHolds[0].Action)
+
+	found := false
+	for _, a := range report.AdminActions {
+		if a.Action == "retention policy set" && a.Target == "auditdb" {
+			require.Equal(t, auth.SysAdminUsername, a.By)
+			found = true
+		}
+	}
+	require.True(t, found)
+
+	// no transaction is committed in the future
+	res, err = s.GenerateAuditReport(ctx, &schema.AuditReportRequest{Database: "auditdb", Since: report.GeneratedAt.Unix() + 3600})
+	require.NoError(t, err)
+
+	err = json.Unmarshal(res.Report, &report)
+	require.NoError(t, err)
+	require.Zero(t, report.Transactions)
+	require.Empty(t, res.Rendered)
+
+	s.StateSigner = nil
+
+	_, err = s.GenerateAuditReport(ctx, &schema.AuditReportRequest{Database: "auditdb"})
+	require.ErrorIs(t, err, ErrAuditReportSigningDisabled)
+}
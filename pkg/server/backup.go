@@ -0,0 +1,90 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/throttle"
+)
+
+// Backup streams a consistent copy of the selected database while it keeps serving requests,
+// the backup is pinned at the last transaction committed when it starts. Only the transactions
+// committed after the one identified in the request are included in incremental backups
+func (s *ImmuServer) Backup(req *schema.BackupRequest, backupServer schema.ImmuService_BackupServer) error {
+	if req == nil || backupServer == nil {
+		return ErrIllegalArguments
+	}
+
+	var since *store.BackupManifest
+
+	if req.SinceTx > 0 {
+		if len(req.SinceAlh) != sha256.Size {
+			return ErrIllegalArguments
+		}
+
+		since = &store.BackupManifest{TxID: req.SinceTx}
+		copy(since.Alh[:], req.SinceAlh)
+	}
+
+	db, err := s.getDBFromCtx(backupServer.Context(), "Backup")
+	if err != nil {
+		return err
+	}
+
+	w := throttle.NewWriter(backupServer.Context(), &chunkWriter{
+		send:      backupServer.Send,
+		chunkSize: s.Options.StreamChunkSize,
+	}, s.backupLimiter)
+
+	manifest, err := db.Backup(w, since)
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Infof("backup of database '%s' completed at tx %d", db.GetName(), manifest.TxID)
+
+	return nil
+}
+
+// chunkWriter sends written data as chunks of up to chunkSize bytes
+type chunkWriter struct {
+	send      func(*schema.Chunk) error
+	chunkSize int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := 0
+
+	for n < len(p) {
+		size := len(p) - n
+		if size > w.chunkSize {
+			size = w.chunkSize
+		}
+
+		err := w.send(&schema.Chunk{Content: p[n : n+size]})
+		if err != nil {
+			return n, err
+		}
+
+		n += size
+	}
+
+	return n, nil
+}
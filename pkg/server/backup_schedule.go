@@ -0,0 +1,613 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/throttle"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// scheduled backups are named as the ones taken with immuadmin, i.e. <db_name>_<timestamp>.backup.tar.gz
+const (
+	scheduledBackupTimeFormat = "2006-01-02_15-04-05"
+	scheduledBackupExtension  = ".backup.tar.gz"
+	encryptedBackupExtension  = ".enc"
+)
+
+const scheduledBackupNotificationTimeout = 10 * time.Second
+
+// backupSchedule is the setting of a scheduled backup as stored in the system database
+type backupSchedule struct {
+	Database        string `json:"database"`
+	Cron            string `json:"cron"`
+	Retention       int    `json:"retention"`
+	Target          string `json:"target"`
+	NotificationURL string `json:"notificationURL"`
+
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type scheduledBackup struct {
+	backupSchedule
+
+	cron      *cronSchedule
+	nextRunAt time.Time
+	lastRunAt time.Time
+	lastError error
+}
+
+// backupScheduler takes backups of databases at the times set by their schedules.
+// Backups are taken one at a time, runs missed while a previous backup was in progress are skipped
+type backupScheduler struct {
+	backups map[string]*scheduledBackup
+	mutex   sync.Mutex
+
+	now    func() time.Time
+	run    func(sch *backupSchedule, at time.Time) error
+	notify func(sch *backupSchedule, err error)
+
+	wakeup    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func newBackupScheduler(run func(sch *backupSchedule, at time.Time) error, notify func(sch *backupSchedule, err error)) *backupScheduler {
+	return &backupScheduler{
+		backups: make(map[string]*scheduledBackup),
+		now:     time.Now,
+		run:     run,
+		notify:  notify,
+		wakeup:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// set adds or replaces the schedule of a database
+func (bs *backupScheduler) set(sch *backupSchedule) error {
+	cron, err := parseCron(sch.Cron)
+	if err != nil {
+		return err
+	}
+
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	b := &scheduledBackup{
+		backupSchedule: *sch,
+		cron:           cron,
+		nextRunAt:      cron.next(bs.now()),
+	}
+
+	if prev, ok := bs.backups[sch.Database]; ok {
+		b.lastRunAt = prev.lastRunAt
+		b.lastError = prev.lastError
+	}
+
+	bs.backups[sch.Database] = b
+
+	bs.signal()
+
+	return nil
+}
+
+func (bs *backupScheduler) remove(database string) bool {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	_, ok := bs.backups[database]
+	delete(bs.backups, database)
+
+	return ok
+}
+
+// list returns a copy of the scheduled backups sorted by database name
+func (bs *backupScheduler) list() []scheduledBackup {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	backups := make([]scheduledBackup, 0, len(bs.backups))
+	for _, b := range bs.backups {
+		backups = append(backups, *b)
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Database < backups[j].Database })
+
+	return backups
+}
+
+func (bs *backupScheduler) signal() {
+	select {
+	case bs.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// runDue takes the backups whose time has come and returns when the next one is due,
+// a zero time is returned when there are no scheduled backups
+func (bs *backupScheduler) runDue() time.Time {
+	now := bs.now()
+
+	var due []scheduledBackup
+
+	bs.mutex.Lock()
+	for _, b := range bs.backups {
+		if !b.nextRunAt.IsZero() && !b.nextRunAt.After(now) {
+			due = append(due, *b)
+			b.nextRunAt = b.cron.next(now)
+		}
+	}
+	bs.mutex.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].Database < due[j].Database })
+
+	for _, b := range due {
+		select {
+		case <-bs.done:
+			return time.Time{}
+		default:
+		}
+
+		err := bs.run(&b.backupSchedule, bs.now())
+		if err != nil {
+			bs.notify(&b.backupSchedule, err)
+		}
+
+		bs.mutex.Lock()
+		// the schedule may have been removed while the backup was running
+		if curr, ok := bs.backups[b.Database]; ok {
+			curr.lastRunAt = now
+			curr.lastError = err
+		}
+		bs.mutex.Unlock()
+	}
+
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	var next time.Time
+	for _, b := range bs.backups {
+		if !b.nextRunAt.IsZero() && (next.IsZero() || b.nextRunAt.Before(next)) {
+			next = b.nextRunAt
+		}
+	}
+
+	return next
+}
+
+func (bs *backupScheduler) start() {
+	if bs == nil {
+		return
+	}
+
+	bs.startOnce.Do(func() {
+		go func() {
+			defer close(bs.stopped)
+
+			for {
+				next := bs.runDue()
+
+				var timeout <-chan time.Time
+				if !next.IsZero() {
+					timer := time.NewTimer(next.Sub(bs.now()))
+					defer timer.Stop()
+					timeout = timer.C
+				}
+
+				select {
+				case <-bs.done:
+					return
+				case <-bs.wakeup:
+				case <-timeout:
+				}
+			}
+		}()
+	})
+}
+
+// stop waits for the backup in progress, if any, to be completed
+func (bs *backupScheduler) stop() {
+	if bs == nil {
+		return
+	}
+
+	bs.stopOnce.Do(func() {
+		close(bs.done)
+
+		started := true
+		bs.startOnce.Do(func() { started = false })
+
+		if started {
+			<-bs.stopped
+		}
+	})
+}
+
+func backupScheduleKey(database string) []byte {
+	key := make([]byte, 1+len(database))
+	key[0] = KeyPrefixBackupSchedule
+	copy(key[1:], database)
+	return key
+}
+
+// loadBackupSchedules registers into the scheduler the schedules stored in the system database
+func (s *ImmuServer) loadBackupSchedules() error {
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: []byte{KeyPrefixBackupSchedule},
+		NoWait: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries.Entries {
+		var sch backupSchedule
+
+		err = json.Unmarshal(e.Value, &sch)
+		if err != nil {
+			return err
+		}
+
+		err = s.backupScheduler.set(&sch)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkBackupScheduleAccess returns the logged in user when it's allowed to manage scheduled backups
+func (s *ImmuServer) checkBackupScheduleAccess(ctx context.Context) (string, error) {
+	if !s.Options.GetAuth() {
+		return "", fmt.Errorf("this command is available only with authentication on")
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not get loggedin user data")
+	}
+
+	if !user.IsSysAdmin {
+		return "", fmt.Errorf("logged In user does not have permissions for this operation")
+	}
+
+	return user.Username, nil
+}
+
+// SetBackupSchedule adds or replaces the schedule of backups of a database, backups are taken by the server
+// into a folder of its own file system
+func (s *ImmuServer) SetBackupSchedule(ctx context.Context, req *schema.BackupSchedule) (*empty.Empty, error) {
+	s.Logger.Debugf("setbackupschedule")
+
+	if req == nil || req.Target == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	username, err := s.checkBackupScheduleAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Database == SystemDBName {
+		return nil, ErrReservedDatabase
+	}
+
+	if s.dbList.GetId(req.Database) < 0 {
+		return nil, fmt.Errorf("database '%s' does not exist", req.Database)
+	}
+
+	if s.remoteStorage != nil {
+		// backups are taken from local data files
+		return nil, store.ErrBackupUnsupported
+	}
+
+	_, err = parseCron(req.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.NotificationURL != "" {
+		u, err := url.Parse(req.NotificationURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("%w: invalid notification url '%s'", ErrIllegalArguments, req.NotificationURL)
+		}
+	}
+
+	sch := &backupSchedule{
+		Database:        req.Database,
+		Cron:            req.Cron,
+		Retention:       int(req.Retention),
+		Target:          req.Target,
+		NotificationURL: req.NotificationURL,
+		CreatedBy:       username,
+		CreatedAt:       time.Now(),
+	}
+
+	serializedSchedule, err := json.Marshal(sch)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: backupScheduleKey(sch.Database), Value: serializedSchedule}}})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.backupScheduler.set(sch)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("backups of database '%s' scheduled at '%s'", sch.Database, sch.Cron)
+
+	return &empty.Empty{}, nil
+}
+
+// DeleteBackupSchedule stops taking scheduled backups of a database, backups already taken are kept
+func (s *ImmuServer) DeleteBackupSchedule(ctx context.Context, req *schema.Database) (*empty.Empty, error) {
+	s.Logger.Debugf("deletebackupschedule")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := s.checkBackupScheduleAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.backupScheduler.remove(req.DatabaseName) {
+		return nil, ErrBackupScheduleNotFound
+	}
+
+	_, err = s.sysDB.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{backupScheduleKey(req.DatabaseName)}})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("scheduled backups of database '%s' removed", req.DatabaseName)
+
+	return &empty.Empty{}, nil
+}
+
+// ListBackupSchedules returns the schedules of backups along with the outcome of their last run
+func (s *ImmuServer) ListBackupSchedules(ctx context.Context, _ *empty.Empty) (*schema.BackupScheduleList, error) {
+	s.Logger.Debugf("listbackupschedules")
+
+	_, err := s.checkBackupScheduleAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.BackupScheduleList{}
+
+	for _, b := range s.backupScheduler.list() {
+		sch := &schema.BackupSchedule{
+			Database:        b.Database,
+			Cron:            b.Cron,
+			Retention:       uint32(b.Retention),
+			Target:          b.Target,
+			NotificationURL: b.NotificationURL,
+		}
+
+		if !b.lastRunAt.IsZero() {
+			sch.LastRunAt = b.lastRunAt.Unix()
+		}
+		if b.lastError != nil {
+			sch.LastError = b.lastError.Error()
+		}
+
+		res.Schedules = append(res.Schedules, sch)
+	}
+
+	return res, nil
+}
+
+// runScheduledBackup writes a compressed backup of the database into the target folder and removes
+// the backups exceeding the retention. Backups are written into a temporary file until completed
+// and they are encrypted when a backup key provider is configured
+func (s *ImmuServer) runScheduledBackup(sch *backupSchedule, at time.Time) error {
+	dbID := s.dbList.GetId(sch.Database)
+	if dbID < 0 {
+		return fmt.Errorf("database '%s' does not exist", sch.Database)
+	}
+
+	db := s.dbList.GetByIndex(dbID)
+
+	err := os.MkdirAll(sch.Target, store.DefaultFileMode)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(sch.Target, sch.Database+"_"+at.Format(scheduledBackupTimeFormat)+scheduledBackupExtension)
+	if s.Options.BackupKeyProvider != nil {
+		name += encryptedBackupExtension
+	}
+	tmpName := name + ".tmp"
+
+	f, err := os.OpenFile(tmpName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = writeScheduledBackup(f, s.Options.BackupKeyProvider, func(w *gzip.Writer) error {
+		manifest, err := db.Backup(throttle.NewWriter(context.Background(), w, s.backupLimiter), nil)
+		if err == nil {
+			s.Logger.Infof("scheduled backup of database '%s' completed at tx %d", sch.Database, manifest.TxID)
+		}
+		return err
+	})
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	err = os.Rename(tmpName, name)
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return pruneScheduledBackups(sch.Target, sch.Database, sch.Retention)
+}
+
+// writeScheduledBackup compresses the backup and then encrypts it when provider is set
+func writeScheduledBackup(f *os.File, provider encryption.MasterKeyProvider, backup func(w *gzip.Writer) error) error {
+	defer f.Close()
+
+	w := io.Writer(f)
+
+	var ew io.WriteCloser
+	if provider != nil {
+		var err error
+
+		ew, err = encryption.NewStreamWriter(f, provider)
+		if err != nil {
+			return err
+		}
+
+		w = ew
+	}
+
+	gw := gzip.NewWriter(w)
+
+	err := backup(gw)
+	if err != nil {
+		return err
+	}
+
+	err = gw.Close()
+	if err != nil {
+		return err
+	}
+
+	if ew != nil {
+		err = ew.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.Sync()
+}
+
+// pruneScheduledBackups removes the oldest backups of the database so that at most retention
+// of them are kept, all backups are kept when retention is not positive
+func pruneScheduledBackups(target, database string, retention int) error {
+	if retention <= 0 {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(target)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+
+	for _, f := range files {
+		// both encrypted and plain backups are retained, i.e. when encryption is enabled afterwards
+		name := strings.TrimSuffix(f.Name(), encryptedBackupExtension)
+
+		if f.IsDir() || !strings.HasPrefix(name, database+"_") || !strings.HasSuffix(name, scheduledBackupExtension) {
+			continue
+		}
+
+		// the timestamp tells backups of this database apart from the ones of databases sharing its prefix
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, database+"_"), scheduledBackupExtension)
+		if _, err := time.Parse(scheduledBackupTimeFormat, ts); err != nil {
+			continue
+		}
+
+		backups = append(backups, f.Name())
+	}
+
+	// timestamps sort chronologically
+	sort.Strings(backups)
+
+	for i := 0; i < len(backups)-retention; i++ {
+		err = os.Remove(filepath.Join(target, backups[i]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type backupFailureNotification struct {
+	Database string    `json:"database"`
+	Target   string    `json:"target"`
+	Error    string    `json:"error"`
+	Time     time.Time `json:"time"`
+}
+
+// notifyBackupFailure logs the failure of a scheduled backup and posts it to the notification url of the schedule
+func (s *ImmuServer) notifyBackupFailure(sch *backupSchedule, backupErr error) {
+	s.Logger.Errorf("scheduled backup of database '%s' failed: %v", sch.Database, backupErr)
+
+	if sch.NotificationURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(&backupFailureNotification{
+		Database: sch.Database,
+		Target:   sch.Target,
+		Error:    backupErr.Error(),
+		Time:     time.Now(),
+	})
+	if err != nil {
+		s.Logger.Warningf("unable to notify failed backup of database '%s': %v", sch.Database, err)
+		return
+	}
+
+	client := &http.Client{Timeout: scheduledBackupNotificationTimeout}
+
+	resp, err := client.Post(sch.NotificationURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		s.Logger.Warningf("unable to notify failed backup of database '%s': %v", sch.Database, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		s.Logger.Warningf("unable to notify failed backup of database '%s': %s", sch.Database, resp.Status)
+	}
+}
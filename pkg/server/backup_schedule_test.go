@@ -0,0 +1,388 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBackupSchedulerRunDue(t *testing.T) {
+	now := time.Date(2022, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	var runs []string
+	var failures []string
+
+	bs := newBackupScheduler(
+		func(sch *backupSchedule, at time.Time) error {
+			runs = append(runs, sch.Database)
+			if sch.Database == "db2" {
+				return errors.New("disk full")
+			}
+			return nil
+		},
+		func(sch *backupSchedule, err error) {
+			failures = append(failures, sch.Database)
+		},
+	)
+	bs.now = func() time.Time { return now }
+
+	require.Zero(t, bs.runDue())
+
+	err := bs.set(&backupSchedule{Database: "db1", Cron: "@hourly"})
+	require.NoError(t, err)
+
+	err = bs.set(&backupSchedule{Database: "db2", Cron: "*/15 * * * *"})
+	require.NoError(t, err)
+
+	err = bs.set(&backupSchedule{Database: "db3", Cron: "invalid"})
+	require.ErrorIs(t, err, ErrInvalidCronExpression)
+
+	next := bs.runDue()
+	require.Equal(t, now.Add(15*time.Minute), next)
+	require.Empty(t, runs)
+
+	now = next
+	require.Equal(t, now.Add(15*time.Minute), bs.runDue())
+	require.Equal(t, []string{"db2"}, runs)
+	require.Equal(t, []string{"db2"}, failures)
+
+	now = now.Add(15 * time.Minute)
+	require.Equal(t, now.Add(15*time.Minute), bs.runDue())
+	require.Equal(t, []string{"db2", "db1", "db2"}, runs)
+	require.Equal(t, []string{"db2", "db2"}, failures)
+
+	backups := bs.list()
+	require.Len(t, backups, 2)
+	require.Equal(t, "db1", backups[0].Database)
+	require.Equal(t, now, backups[0].lastRunAt)
+	require.NoError(t, backups[0].lastError)
+	require.Equal(t, "db2", backups[1].Database)
+	require.EqualError(t, backups[1].lastError, "disk full")
+
+	// the outcome of the last run is kept when a schedule is replaced
+	err = bs.set(&backupSchedule{Database: "db2", Cron: "@daily"})
+	require.NoError(t, err)
+	require.EqualError(t, bs.list()[1].lastError, "disk full")
+
+	require.True(t, bs.remove("db2"))
+	require.False(t, bs.remove("db2"))
+	require.Len(t, bs.list(), 1)
+
+	require.True(t, bs.remove("db1"))
+	require.Zero(t, bs.runDue())
+}
+
+func TestBackupSchedulerStartStop(t *testing.T) {
+	var bs *backupScheduler
+	bs.start()
+	bs.stop()
+
+	bs = newBackupScheduler(nil, nil)
+	bs.stop()
+	bs.start()
+
+	ran := make(chan struct{}, 1)
+
+	bs = newBackupScheduler(
+		func(sch *backupSchedule, at time.Time) error {
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+			return nil
+		},
+		func(sch *backupSchedule, err error) {},
+	)
+	var offset int64
+	bs.now = func() time.Time { return time.Now().Add(time.Duration(atomic.LoadInt64(&offset))) }
+
+	bs.start()
+
+	err := bs.set(&backupSchedule{Database: "db1", Cron: "* * * * *"})
+	require.NoError(t, err)
+
+	// the backup becomes due and the scheduler is woken up
+	atomic.StoreInt64(&offset, int64(time.Hour))
+	bs.signal()
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "scheduled backup not taken")
+	}
+
+	bs.stop()
+	bs.stop()
+}
+
+func TestPruneScheduledBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backups")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	names := []string{
+		"db_2022-03-15_10-00-00.backup.tar.gz",
+		"db_2022-03-15_11-00-00.backup.tar.gz.enc",
+		"db_2022-03-15_12-00-00.backup.tar.gz",
+		"db_other_2022-03-15_09-00-00.backup.tar.gz",
+		"db_2022-03-15_13-00-00.backup.tar.gz.tmp",
+		"db_2022-03-15_14-00-00.backup.tar.gz.enc.tmp",
+		"notes.txt",
+	}
+	for _, name := range names {
+		err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0600)
+		require.NoError(t, err)
+	}
+
+	err = pruneScheduledBackups(dir, "db", 0)
+	require.NoError(t, err)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, len(names))
+
+	err = pruneScheduledBackups(dir, "db", 2)
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, names[0]))
+	require.True(t, os.IsNotExist(err))
+
+	for _, name := range names[1:] {
+		_, err = os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+	}
+
+	err = pruneScheduledBackups(filepath.Join(dir, "missing"), "db", 2)
+	require.Error(t, err)
+}
+
+func TestServerBackupSchedules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_backup_schedules")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "backups")
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.SetBackupSchedule(context.Background(), &schema.BackupSchedule{Database: DefaultDBName, Cron: "@daily", Target: target})
+	require.Error(t, err)
+
+	_, err = s.SetBackupSchedule(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetBackupSchedule(ctx, &schema.BackupSchedule{Database: DefaultDBName, Cron: "@daily"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetBackupSchedule(ctx, &schema.BackupSchedule{Database: SystemDBName, Cron: "@daily", Target: target})
+	require.ErrorIs(t, err, ErrReservedDatabase)
+
+	_, err = s.SetBackupSchedule(ctx, &schema.BackupSchedule{Database: "missing", Cron: "@daily", Target: target})
+	require.Error(t, err)
+
+	_, err = s.SetBackupSchedule(ctx, &schema.BackupSchedule{Database: DefaultDBName, Cron: "@never", Target: target})
+	require.ErrorIs(t, err, ErrInvalidCronExpression)
+
+	_, err = s.SetBackupSchedule(ctx, &schema.BackupSchedule{Database: DefaultDBName, Cron: "@daily", Target: target, NotificationURL: "ftp://host"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetBackupSchedule(ctx, &schema.BackupSchedule{Database: DefaultDBName, Cron: "0 3 * * *", Retention: 2, Target: target})
+	require.NoError(t, err)
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	schedules, err := s.ListBackupSchedules(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, schedules.Schedules, 1)
+	require.Equal(t, DefaultDBName, schedules.Schedules[0].Database)
+	require.Equal(t, "0 3 * * *", schedules.Schedules[0].Cron)
+	require.Equal(t, uint32(2), schedules.Schedules[0].Retention)
+	require.Zero(t, schedules.Schedules[0].LastRunAt)
+
+	sch := &s.backupScheduler.list()[0].backupSchedule
+	require.Equal(t, auth.SysAdminUsername, sch.CreatedBy)
+
+	at := time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		err = s.runScheduledBackup(sch, at.Add(time.Duration(i)*24*time.Hour))
+		require.NoError(t, err)
+	}
+
+	files, err := ioutil.ReadDir(target)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, DefaultDBName+"_2022-03-16_03-00-00.backup.tar.gz", files[0].Name())
+	require.Equal(t, DefaultDBName+"_2022-03-17_03-00-00.backup.tar.gz", files[1].Name())
+
+	// schedules are loaded again when the server is restarted
+	err = s.CloseDatabases()
+	require.NoError(t, err)
+
+	err = s.Listener.Close()
+	require.NoError(t, err)
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	lr, err = s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	schedules, err = s.ListBackupSchedules(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, schedules.Schedules, 1)
+
+	_, err = s.DeleteBackupSchedule(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.NoError(t, err)
+
+	_, err = s.DeleteBackupSchedule(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.ErrorIs(t, err, ErrBackupScheduleNotFound)
+
+	schedules, err = s.ListBackupSchedules(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Empty(t, schedules.Schedules)
+
+	err = s.loadBackupSchedules()
+	require.NoError(t, err)
+	require.Empty(t, s.backupScheduler.list())
+}
+
+func TestServerEncryptedScheduledBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_encrypted_backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "backups")
+
+	provider, err := encryption.NewPassphraseKeyProvider("backup passphrase")
+	require.NoError(t, err)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithBackupKeyProvider(provider)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	at := time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC)
+
+	err = s.runScheduledBackup(&backupSchedule{Database: DefaultDBName, Target: target}, at)
+	require.NoError(t, err)
+
+	backup := filepath.Join(target, DefaultDBName+"_2022-03-15_03-00-00.backup.tar.gz.enc")
+
+	f, err := os.Open(backup)
+	require.NoError(t, err)
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	require.True(t, encryption.IsEncryptedStream(br))
+
+	wrongProvider, err := encryption.NewPassphraseKeyProvider("wrong passphrase")
+	require.NoError(t, err)
+
+	_, err = encryption.NewStreamReader(br, wrongProvider)
+	require.ErrorIs(t, err, encryption.ErrWrongKey)
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	r, err := encryption.NewStreamReader(f, provider)
+	require.NoError(t, err)
+
+	gr, err := gzip.NewReader(r)
+	require.NoError(t, err)
+
+	manifest, err := store.ReadBackupManifest(gr)
+	require.NoError(t, err)
+	require.False(t, manifest.IsIncremental())
+}
+
+func TestServerBackupFailureNotification(t *testing.T) {
+	notifications := make(chan backupFailureNotification, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n backupFailureNotification
+		err := json.NewDecoder(r.Body).Decode(&n)
+		require.NoError(t, err)
+		notifications <- n
+	}))
+	defer srv.Close()
+
+	s := DefaultServer()
+
+	s.notifyBackupFailure(&backupSchedule{Database: "db1"}, errors.New("disk full"))
+
+	s.notifyBackupFailure(&backupSchedule{Database: "db1", Target: "/backups", NotificationURL: srv.URL}, errors.New("disk full"))
+
+	n := <-notifications
+	require.Equal(t, "db1", n.Database)
+	require.Equal(t, "/backups", n.Target)
+	require.Equal(t, "disk full", n.Error)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+)
+
+// compactionProgressInterval is the minimum time between two progress notifications of a compaction
+const compactionProgressInterval = time.Second
+
+// CompactDatabase compacts the index of a database while streaming the progress of the compaction.
+// The space the compaction reclaims is estimated and notified first, that's all that is done on dry-runs
+func (s *ImmuServer) CompactDatabase(req *schema.CompactDatabaseRequest, progressServer schema.ImmuService_CompactDatabaseServer) error {
+	if req == nil || progressServer == nil {
+		return ErrIllegalArguments
+	}
+
+	s.Logger.Debugf("compactdatabase %s", req.Database)
+
+	if !s.Options.GetAuth() {
+		return ErrAuthMustBeEnabled
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(progressServer.Context())
+	if err != nil {
+		return fmt.Errorf("could not get loggedin user data")
+	}
+
+	//if the requesting user has admin permission on this database
+	if !user.IsSysAdmin && !user.HasPermission(req.Database, auth.PermissionAdmin) {
+		return fmt.Errorf("you do not have permission on this database")
+	}
+
+	db, err := s.dbList.GetByName(req.Database)
+	if err != nil {
+		return err
+	}
+
+	estimate, err := db.EstimateIndexCompaction()
+	if err != nil {
+		return err
+	}
+
+	progress := &schema.CompactionProgress{
+		IndexSize:          estimate.NodesSize,
+		CompactedIndexSize: estimate.CompactedNodesSize,
+		Snapshots:          estimate.SnapshotCount,
+		ThresholdReached:   estimate.ThresholdReached,
+	}
+
+	err = progressServer.Send(progress)
+	if err != nil || req.DryRun {
+		return err
+	}
+
+	s.Logger.Infof("Compaction of the index of database '%s' requested by %s", req.Database, user.Username)
+
+	var sendErr error
+	var lastSentAt time.Time
+
+	err = db.CompactIndexWithProgress(func(dumpedBytes int64) {
+		progress.DumpedBytes = dumpedBytes
+
+		if sendErr != nil || time.Since(lastSentAt) < compactionProgressInterval {
+			return
+		}
+
+		sendErr = progressServer.Send(progress)
+		lastSentAt = time.Now()
+	})
+	if err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	// the last notification accounts for the whole compacted index
+	return progressServer.Send(progress)
+}
+
+// CollectGarbage reclaims the space used by the values of a database superseded by newer transactions,
+// the values superseded within the safety window of the database and the ones under a legal hold are kept
+func (s *ImmuServer) CollectGarbage(ctx context.Context, req *schema.CollectGarbageRequest) (*schema.CollectGarbageResponse, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	s.Logger.Debugf("collectgarbage %s", req.Database)
+
+	if !s.Options.GetAuth() {
+		return nil, ErrAuthMustBeEnabled
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get loggedin user data")
+	}
+
+	//if the requesting user has admin permission on this database
+	if !user.IsSysAdmin && !user.HasPermission(req.Database, auth.PermissionAdmin) {
+		return nil, fmt.Errorf("you do not have permission on this database")
+	}
+
+	db, err := s.dbList.GetByName(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Garbage collection of database '%s' requested by %s", req.Database, user.Username)
+
+	reclaimed, err := db.CollectGarbage()
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.CollectGarbageResponse{ReclaimedBytes: reclaimed}, nil
+}
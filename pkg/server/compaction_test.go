@@ -0,0 +1,110 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerCollectGarbage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_gc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{
+		DatabaseName:   "gcdb",
+		GcFrequency:    uint64(time.Hour.Milliseconds()),
+		GcSafetyWindow: uint64(time.Minute.Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "gcdb"})
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	settings, err := s.GetDatabaseSettings(dbCtx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(time.Hour.Milliseconds()), settings.GcFrequency)
+	require.Equal(t, uint64(time.Minute.Milliseconds()), settings.GcSafetyWindow)
+
+	db, err := s.dbList.GetByName("gcdb")
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, db.GetOptions().GetStoreOptions().GCFrequency)
+	require.Equal(t, time.Minute, db.GetOptions().GetStoreOptions().GCSafetyWindow)
+
+	_, err = s.UpdateDatabase(ctx, &schema.DatabaseSettings{DatabaseName: "gcdb", GcSafetyWindow: uint64(time.Hour.Milliseconds())})
+	require.NoError(t, err)
+
+	settings, err = s.GetDatabaseSettings(dbCtx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(time.Hour.Milliseconds()), settings.GcFrequency)
+	require.Equal(t, uint64(time.Hour.Milliseconds()), settings.GcSafetyWindow)
+
+	for i := 0; i < 2; i++ {
+		_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+		require.NoError(t, err)
+	}
+
+	_, err = s.CollectGarbage(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.CollectGarbage(ctx, &schema.CollectGarbageRequest{Database: "nonexistent"})
+	require.ErrorIs(t, err, database.ErrDatabaseNotExists)
+
+	// values superseded within the safety window are kept
+	res, err := s.CollectGarbage(ctx, &schema.CollectGarbageRequest{Database: "gcdb"})
+	if err == store.ErrValueGCUnsupported {
+		t.Skip("punching holes is not supported by the underlying file system")
+	}
+	require.NoError(t, err)
+	require.Zero(t, res.ReclaimedBytes)
+}
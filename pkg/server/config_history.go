@@ -0,0 +1,275 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+const (
+	configChangeDatabaseSettings = "database settings"
+	configChangeServerSettings   = "server settings"
+	configChangeSQLSchema        = "sql schema"
+	configChangeRuntimeSetting   = "runtime setting"
+)
+
+// configChange is the record of a change of the settings of the server or of a database, or of the schema
+// of a database, as stored in the system database
+type configChange struct {
+	Database  string    `json:"database"`
+	Kind      string    `json:"kind"`
+	Change    string    `json:"change"`
+	ChangedBy string    `json:"changedBy"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// serverSettings are the settings of the server recorded when they change, secrets are left out
+type serverSettings struct {
+	Address              string   `json:"address"`
+	Port                 int      `json:"port"`
+	TLS                  bool     `json:"tls"`
+	Auth                 bool     `json:"auth"`
+	Maintenance          bool     `json:"maintenance"`
+	Synced               bool     `json:"synced"`
+	DevMode              bool     `json:"devMode"`
+	DefaultDatabase      string   `json:"defaultDatabase"`
+	MaxRecvMsgSize       int      `json:"maxRecvMsgSize"`
+	MetricsServer        bool     `json:"metricsServer"`
+	WebServer            bool     `json:"webServer"`
+	PgsqlServer          bool     `json:"pgsqlServer"`
+	RESPServer           bool     `json:"respServer"`
+	EtcdServer           bool     `json:"etcdServer"`
+	TokenExpiryTimeMin   int      `json:"tokenExpiryTimeMin"`
+	SigningKey           string   `json:"signingKey,omitempty"`
+	ReplicaOf            string   `json:"replicaOf,omitempty"`
+	S3Storage            bool     `json:"s3Storage"`
+	MaxMemory            int64    `json:"maxMemory"`
+	ArchiveDir           string   `json:"archiveDir,omitempty"`
+	BackupEncryption     bool     `json:"backupEncryption"`
+	MaxBackupRate        int64    `json:"maxBackupRate"`
+	MaxReplicationRate   int64    `json:"maxReplicationRate"`
+	TimestampAuthorities []string `json:"timestampAuthorities,omitempty"`
+	TimestampInterval    string   `json:"timestampInterval"`
+	RetentionInterval    string   `json:"retentionInterval"`
+	Cosigners            []string `json:"cosigners,omitempty"`
+	GlobalState          bool     `json:"globalState"`
+}
+
+func serverSettingsOf(o *Options) *serverSettings {
+	settings := &serverSettings{
+		Address:              o.Address,
+		Port:                 o.Port,
+		TLS:                  o.TLSConfig != nil,
+		Auth:                 o.auth,
+		Maintenance:          o.maintenance,
+		Synced:               o.synced,
+		DevMode:              o.DevMode,
+		DefaultDatabase:      o.defaultDBName,
+		MaxRecvMsgSize:       o.MaxRecvMsgSize,
+		MetricsServer:        o.MetricsServer,
+		WebServer:            o.WebServer,
+		PgsqlServer:          o.PgsqlServer,
+		RESPServer:           o.RESPServer,
+		EtcdServer:           o.EtcdServer,
+		TokenExpiryTimeMin:   o.TokenExpiryTimeMin,
+		SigningKey:           o.SigningKey,
+		S3Storage:            o.RemoteStorageOptions != nil && o.RemoteStorageOptions.S3Storage,
+		MaxMemory:            o.MaxMemory,
+		ArchiveDir:           o.ArchiveDir,
+		BackupEncryption:     o.BackupKeyProvider != nil,
+		MaxBackupRate:        o.MaxBackupRate,
+		MaxReplicationRate:   o.MaxReplicationRate,
+		TimestampAuthorities: o.TimestampAuthorities,
+		TimestampInterval:    o.TimestampInterval.String(),
+		RetentionInterval:    o.RetentionInterval.String(),
+		Cosigners:            o.Cosigners,
+		GlobalState:          o.GlobalState,
+	}
+
+	if o.ReplicationOptions != nil {
+		settings.ReplicaOf = fmt.Sprintf("%s:%d", o.ReplicationOptions.MasterAddress, o.ReplicationOptions.MasterPort)
+	}
+
+	return settings
+}
+
+// configChangePrefix returns the prefix of the changes of a database, of the server settings when db is empty
+func configChangePrefix(db string) []byte {
+	key := make([]byte, 1+len(db)+1, 1+len(db)+1+8)
+	key[0] = KeyPrefixConfigChange
+	i := 1 + copy(key[1:], db)
+	key[i] = 0
+	return key
+}
+
+// configChangeKey sorts the changes of a database by time, i.e. <prefix><db_name>0x00<changed_at>
+func configChangeKey(c *configChange) []byte {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(c.ChangedAt.UnixNano()))
+	return append(configChangePrefix(c.Database), ts[:]...)
+}
+
+// recordConfigChange records in the system database a change made by the logged in user. Entries of the
+// system database can not be modified, so the history of the changes can not be tampered with
+func (s *ImmuServer) recordConfigChange(ctx context.Context, database, kind, change string) error {
+	c := &configChange{
+		Database:  database,
+		Kind:      kind,
+		Change:    change,
+		ChangedAt: time.Now(),
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err == nil {
+		c.ChangedBy = user.Username
+	}
+
+	serializedChange, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: configChangeKey(c), Value: serializedChange}}})
+	return err
+}
+
+// recordDBSettingsChange records the settings of a database as set by the logged in user, the credentials
+// used to replicate the database are left out
+func (s *ImmuServer) recordDBSettingsChange(ctx context.Context, opts *dbOptions) error {
+	settings := *opts
+	settings.FollowerPassword = ""
+
+	serializedSettings, err := json.Marshal(&settings)
+	if err != nil {
+		return err
+	}
+
+	return s.recordConfigChange(ctx, opts.Database, configChangeDatabaseSettings, string(serializedSettings))
+}
+
+// recordServerSettings records the settings of the server when they differ from the last ones recorded,
+// so the history holds every restart of the server with a different configuration
+func (s *ImmuServer) recordServerSettings() error {
+	serializedSettings, err := json.Marshal(serverSettingsOf(s.Options))
+	if err != nil {
+		return err
+	}
+
+	changes, err := s.configChanges("", 0, true)
+	if err != nil {
+		return err
+	}
+
+	// changes of the runtime settings are recorded along with the ones of the server settings
+	for _, c := range changes {
+		if c.Kind != configChangeServerSettings {
+			continue
+		}
+		if c.Change == string(serializedSettings) {
+			return nil
+		}
+		break
+	}
+
+	return s.recordConfigChange(context.Background(), "", configChangeServerSettings, string(serializedSettings))
+}
+
+// isSchemaChange returns true when a SQL request holds statements changing the schema of a database
+func isSchemaChange(sqlStmts string) bool {
+	stmts, err := sql.ParseString(sqlStmts)
+	if err != nil {
+		return false
+	}
+
+	for _, stmt := range stmts {
+		switch stmt.(type) {
+		case *sql.CreateTableStmt, *sql.CreateIndexStmt, *sql.AddColumnStmt:
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordSchemaChange records the SQL statements changing the schema of a database, as executed by the logged in user
+func (s *ImmuServer) recordSchemaChange(ctx context.Context, database string, sqlStmts string) error {
+	return s.recordConfigChange(ctx, database, configChangeSQLSchema, strings.TrimSpace(sqlStmts))
+}
+
+func (s *ImmuServer) configChanges(database string, limit uint64, desc bool) ([]*schema.ConfigChange, error) {
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: configChangePrefix(database),
+		Limit:  limit,
+		Desc:   desc,
+		NoWait: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*schema.ConfigChange, len(entries.Entries))
+
+	for i, e := range entries.Entries {
+		var c configChange
+
+		err = json.Unmarshal(e.Value, &c)
+		if err != nil {
+			return nil, err
+		}
+
+		changes[i] = &schema.ConfigChange{
+			Database:  c.Database,
+			Kind:      c.Kind,
+			Change:    c.Change,
+			ChangedBy: c.ChangedBy,
+			ChangedAt: c.ChangedAt.Unix(),
+			Tx:        e.Tx,
+		}
+	}
+
+	return changes, nil
+}
+
+// ConfigHistory returns the changes of the settings and of the schema of a database recorded in the system
+// database, or the changes of the settings of the server when no database is set
+func (s *ImmuServer) ConfigHistory(ctx context.Context, req *schema.ConfigHistoryRequest) (*schema.ConfigChangeList, error) {
+	s.Logger.Debugf("confighistory")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.configChanges(req.Database, req.Limit, req.Desc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.ConfigChangeList{Changes: changes}, nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestConfigChangeKey(t *testing.T) {
+	require.True(t, bytes.HasPrefix(configChangeKey(&configChange{Database: "db1"}), configChangePrefix("db1")))
+	require.False(t, bytes.HasPrefix(configChangePrefix("db10"), configChangePrefix("db1")))
+	require.False(t, bytes.HasPrefix(configChangePrefix("db1"), configChangePrefix("")))
+}
+
+func TestServerConfigHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_config_history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.ConfigHistory(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	// the settings of the server are recorded on startup
+	history, err := s.ConfigHistory(ctx, &schema.ConfigHistoryRequest{})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 1)
+	require.Equal(t, configChangeServerSettings, history.Changes[0].Kind)
+	require.Empty(t, history.Changes[0].ChangedBy)
+
+	var settings serverSettings
+	err = json.Unmarshal([]byte(history.Changes[0].Change), &settings)
+	require.NoError(t, err)
+	require.True(t, settings.Auth)
+
+	// settings are recorded again only when they change
+	err = s.recordServerSettings()
+	require.NoError(t, err)
+
+	s.Options.MaxMemory = 1 << 30
+
+	err = s.recordServerSettings()
+	require.NoError(t, err)
+
+	history, err = s.ConfigHistory(ctx, &schema.ConfigHistoryRequest{Desc: true})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 2)
+
+	err = json.Unmarshal([]byte(history.Changes[0].Change), &settings)
+	require.NoError(t, err)
+	require.Equal(t, int64(1<<30), settings.MaxMemory)
+
+	// credentials of replicas are not recorded
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{
+		DatabaseName:     "replicadb",
+		Replica:          true,
+		MasterDatabase:   "masterdb",
+		FollowerUsername: "follower",
+		FollowerPassword: "secret",
+	})
+	require.NoError(t, err)
+
+	history, err = s.ConfigHistory(ctx, &schema.ConfigHistoryRequest{Database: "replicadb"})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 1)
+	require.Equal(t, configChangeDatabaseSettings, history.Changes[0].Kind)
+	require.Contains(t, history.Changes[0].Change, `"followerUsername":"follower"`)
+	require.NotContains(t, history.Changes[0].Change, "secret")
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	_, err = s.UpdateDatabase(ctx, &schema.DatabaseSettings{DatabaseName: "db1", ExcludeCommitTime: true})
+	require.NoError(t, err)
+
+	ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	ddl := "CREATE TABLE table1(id INTEGER, PRIMARY KEY id);"
+
+	_, err = s.SQLExec(dbCtx, &schema.SQLExecRequest{Sql: ddl})
+	require.NoError(t, err)
+
+	// changes of the data are not recorded
+	_, err = s.SQLExec(dbCtx, &schema.SQLExecRequest{Sql: "INSERT INTO table1(id) VALUES (1);"})
+	require.NoError(t, err)
+
+	_, err = s.SQLExec(dbCtx, &schema.SQLExecRequest{Sql: ddl})
+	require.Error(t, err)
+
+	history, err = s.ConfigHistory(ctx, &schema.ConfigHistoryRequest{Database: "db1"})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 3)
+
+	for _, c := range history.Changes {
+		require.Equal(t, "db1", c.Database)
+		require.Equal(t, auth.SysAdminUsername, c.ChangedBy)
+		require.NotZero(t, c.Tx)
+	}
+
+	require.Equal(t, configChangeDatabaseSettings, history.Changes[0].Kind)
+	require.Contains(t, history.Changes[0].Change, `"excludeCommitTime":false`)
+
+	require.Equal(t, configChangeDatabaseSettings, history.Changes[1].Kind)
+	require.Contains(t, history.Changes[1].Change, `"excludeCommitTime":true`)
+
+	require.Equal(t, configChangeSQLSchema, history.Changes[2].Kind)
+	require.Equal(t, ddl, history.Changes[2].Change)
+
+	history, err = s.ConfigHistory(ctx, &schema.ConfigHistoryRequest{Database: "db1", Limit: 1, Desc: true})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 1)
+	require.Equal(t, configChangeSQLSchema, history.Changes[0].Kind)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// cosignature is the signature of the state of a database by a cosigner as stored in the system database
+type cosignature struct {
+	TxHash     []byte    `json:"txHash"`
+	Signature  []byte    `json:"signature"`
+	PublicKey  []byte    `json:"publicKey"`
+	CosignedAt time.Time `json:"cosignedAt"`
+}
+
+// cosignatureKey sorts the cosignatures of a database by tx, i.e. <prefix><db_name>0x00<tx_id><sha256(public_key)>
+func cosignatureKey(db string, txID uint64, publicKey []byte) []byte {
+	key := cosignaturePrefix(db, txID)
+	if publicKey == nil {
+		return key
+	}
+
+	pkDigest := sha256.Sum256(publicKey)
+	return append(key, pkDigest[:]...)
+}
+
+// cosignaturePrefix returns the prefix of the cosignatures of a database, of a single tx when txID is not zero
+func cosignaturePrefix(db string, txID uint64) []byte {
+	key := make([]byte, 1+len(db)+1, 1+len(db)+1+8+sha256.Size)
+	key[0] = KeyPrefixCosignature
+	i := 1 + copy(key[1:], db)
+	key[i] = 0
+
+	if txID == 0 {
+		return key
+	}
+
+	var tx [8]byte
+	binary.BigEndian.PutUint64(tx[:], txID)
+	return append(key, tx[:]...)
+}
+
+func (s *ImmuServer) isCosigner(pk *ecdsa.PublicKey) bool {
+	for _, c := range s.cosigners {
+		if c.X.Cmp(pk.X) == 0 && c.Y.Cmp(pk.Y) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Cosign stores the signature of the state of the selected database by an auditor who verified it.
+// Only the signatures of the cosigners set on the server are accepted, the signed state must match
+// the one of the database at the transaction
+func (s *ImmuServer) Cosign(ctx context.Context, req *schema.Cosignature) (*empty.Empty, error) {
+	if req == nil || req.TxId == 0 || req.Signature == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if len(s.cosigners) == 0 {
+		return nil, ErrCosigningDisabled
+	}
+
+	db, err := s.getDBFromCtx(ctx, "Cosign")
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := signer.UnmarshalKey(req.Signature.PublicKey)
+	if err != nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if !s.isCosigner(pk) {
+		return nil, ErrNotCosigner
+	}
+
+	tx, err := db.TxByID(&schema.TxRequest{Tx: req.TxId})
+	if err != nil {
+		return nil, err
+	}
+
+	hdr, err := schema.TxHeaderFromProto(tx.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	alh := hdr.Alh()
+	if !bytes.Equal(alh[:], req.TxHash) {
+		return nil, ErrInvalidCosignature
+	}
+
+	state := &schema.ImmutableState{Db: db.GetName(), TxId: req.TxId, TxHash: req.TxHash}
+
+	ok, err := signer.Verify(state.ToBytes(), req.Signature.Signature, pk)
+	if err != nil || !ok {
+		return nil, ErrInvalidCosignature
+	}
+
+	serializedCosignature, err := json.Marshal(&cosignature{
+		TxHash:     req.TxHash,
+		Signature:  req.Signature.Signature,
+		PublicKey:  req.Signature.PublicKey,
+		CosignedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{
+		Key:   cosignatureKey(db.GetName(), req.TxId, req.Signature.PublicKey),
+		Value: serializedCosignature,
+	}}})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("state of database '%s' at tx %d cosigned", db.GetName(), req.TxId)
+
+	return &empty.Empty{}, nil
+}
+
+// CosignedState returns the state of the selected database at a transaction, the last cosigned one
+// when not set, along with the signatures of the auditors who verified it
+func (s *ImmuServer) CosignedState(ctx context.Context, req *schema.CosignedStateRequest) (*schema.CosignedState, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(ctx, "CosignedState")
+	if err != nil {
+		return nil, err
+	}
+
+	// cosignatures are sorted by tx, the first ones of a descending scan are of the last cosigned tx
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: cosignaturePrefix(db.GetName(), req.TxId),
+		Desc:   true,
+		NoWait: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var state *schema.ImmutableState
+	var cosignatures []*schema.Signature
+
+	for _, e := range entries.Entries {
+		txID := binary.BigEndian.Uint64(e.Key[len(e.Key)-sha256.Size-8:])
+
+		if state != nil && state.TxId != txID {
+			break
+		}
+
+		var c cosignature
+
+		err = json.Unmarshal(e.Value, &c)
+		if err != nil {
+			return nil, err
+		}
+
+		if state == nil {
+			state = &schema.ImmutableState{Db: db.GetName(), TxId: txID, TxHash: c.TxHash}
+		}
+
+		cosignatures = append(cosignatures, &schema.Signature{Signature: c.Signature, PublicKey: c.PublicKey})
+	}
+
+	if state == nil {
+		return nil, ErrCosignedStateNotFound
+	}
+
+	if s.StateSigner != nil {
+		err = s.StateSigner.Sign(state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &schema.CosignedState{State: state, Cosignatures: cosignatures}, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosignatureKey(t *testing.T) {
+	require.True(t, bytes.HasPrefix(cosignatureKey("db1", 2, []byte("pk")), cosignaturePrefix("db1", 2)))
+	require.True(t, bytes.HasPrefix(cosignaturePrefix("db1", 2), cosignaturePrefix("db1", 0)))
+	require.False(t, bytes.HasPrefix(cosignaturePrefix("db10", 0), cosignaturePrefix("db1", 0)))
+
+	// the cosignatures of a database are sorted by tx
+	require.Equal(t, -1, bytes.Compare(cosignatureKey("db1", 2, []byte("pk2")), cosignatureKey("db1", 256, []byte("pk1"))))
+}
+
+func TestServerCosigning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_cosigning")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAuth(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithSigningKey("./../../test/signer/ec1.key")
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	ctx := context.Background()
+
+	cosigner, err := signer.NewSigner("./../../test/signer/ec3.key")
+	require.NoError(t, err)
+
+	hdr, err := s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	state, err := s.CurrentState(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, hdr.Id, state.TxId)
+
+	cosignature := func(txHash []byte) *schema.Cosignature {
+		sig, pk, err := cosigner.Sign((&schema.ImmutableState{Db: state.Db, TxId: state.TxId, TxHash: txHash}).ToBytes())
+		require.NoError(t, err)
+
+		return &schema.Cosignature{
+			TxId:      state.TxId,
+			TxHash:    txHash,
+			Signature: &schema.Signature{Signature: sig, PublicKey: pk},
+		}
+	}
+
+	_, err = s.Cosign(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.Cosign(ctx, cosignature(state.TxHash))
+	require.ErrorIs(t, err, ErrCosigningDisabled)
+
+	pk, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+	s.cosigners = append(s.cosigners, pk)
+
+	_, err = s.Cosign(ctx, cosignature(state.TxHash))
+	require.ErrorIs(t, err, ErrNotCosigner)
+
+	pk, err = signer.ParsePublicKeyFile("./../../test/signer/ec3.pub")
+	require.NoError(t, err)
+	s.cosigners = append(s.cosigners, pk)
+
+	_, err = s.Cosign(ctx, cosignature(make([]byte, len(state.TxHash))))
+	require.ErrorIs(t, err, ErrInvalidCosignature)
+
+	invalid := cosignature(state.TxHash)
+	invalid.Signature.Signature[len(invalid.Signature.Signature)-1]++
+
+	_, err = s.Cosign(ctx, invalid)
+	require.ErrorIs(t, err, ErrInvalidCosignature)
+
+	_, err = s.CosignedState(ctx, &schema.CosignedStateRequest{})
+	require.ErrorIs(t, err, ErrCosignedStateNotFound)
+
+	_, err = s.Cosign(ctx, cosignature(state.TxHash))
+	require.NoError(t, err)
+
+	// cosigning the same state again replaces the previous cosignature
+	_, err = s.Cosign(ctx, cosignature(state.TxHash))
+	require.NoError(t, err)
+
+	cosignedState, err := s.CosignedState(ctx, &schema.CosignedStateRequest{})
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, cosignedState.State.TxId)
+	require.Equal(t, state.TxHash, cosignedState.State.TxHash)
+	require.NotNil(t, cosignedState.State.Signature)
+	require.Len(t, cosignedState.Cosignatures, 1)
+
+	ok, err := signer.Verify(cosignedState.State.ToBytes(), cosignedState.Cosignatures[0].Signature, pk)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = s.CosignedState(ctx, &schema.CosignedStateRequest{TxId: state.TxId + 1})
+	require.ErrorIs(t, err, ErrCosignedStateNotFound)
+}
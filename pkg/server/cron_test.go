@@ -0,0 +1,75 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@never",
+	} {
+		_, err := parseCron(expr)
+		require.ErrorIs(t, err, ErrInvalidCronExpression, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2022, time.March, 15, 10, 30, 20, 0, time.UTC) // tuesday
+
+	for _, c := range []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2022, time.March, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2022, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2022, time.March, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2022, time.March, 16, 3, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2022, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2022, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2022, time.March, 20, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2022, time.March, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * mon-fri", time.Date(2022, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 jun,dec *", time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2022, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		// day of month or day of week when both are restricted
+		{"0 0 20 * wed", time.Date(2022, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		cron, err := parseCron(c.expr)
+		require.NoError(t, err, c.expr)
+		require.Equal(t, c.next, cron.next(from), c.expr)
+	}
+}
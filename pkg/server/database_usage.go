@@ -0,0 +1,157 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// entryCount is the number of entries written by the transactions of a database up to lastTx
+type entryCount struct {
+	entries uint64
+	lastTx  uint64
+}
+
+// countEntries returns the number of entries written by all the transactions of db, only the transactions
+// committed since the previous count are read
+func (s *ImmuServer) countEntries(db database.DB) (uint64, error) {
+	s.entryCountsMutex.Lock()
+	defer s.entryCountsMutex.Unlock()
+
+	if s.entryCounts == nil {
+		s.entryCounts = make(map[string]*entryCount)
+	}
+
+	state, err := db.CurrentState()
+	if err != nil {
+		return 0, err
+	}
+
+	count, ok := s.entryCounts[db.GetName()]
+	if !ok || count.lastTx > state.TxId {
+		// the database may have been replaced by a new one with the same name
+		count = &entryCount{}
+		s.entryCounts[db.GetName()] = count
+	}
+
+	entries, lastTx, err := db.CountEntries(count.lastTx + 1)
+	if err != nil {
+		return 0, err
+	}
+
+	count.entries += entries
+	count.lastTx = lastTx
+
+	return count.entries, nil
+}
+
+func (s *ImmuServer) databaseUsage(db database.DB, now time.Time) (*schema.DatabaseUsage, error) {
+	diskUsage, err := db.DiskUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	txCount, err := db.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.countEntries(db)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &schema.DatabaseUsage{
+		Database:   db.GetName(),
+		TxLogSize:  diskUsage.TxLog,
+		VLogSize:   diskUsage.VLog,
+		IndexSize:  diskUsage.Index,
+		OtherSize:  diskUsage.Other,
+		TotalSize:  diskUsage.Total(),
+		TxCount:    txCount,
+		EntryCount: entries,
+	}
+
+	if txCount == 0 {
+		return usage, nil
+	}
+
+	firstTx, err := db.TxByID(&schema.TxRequest{Tx: 1})
+	if err != nil {
+		return nil, err
+	}
+	usage.Since = firstTx.Header.Ts
+
+	days := now.Sub(time.Unix(usage.Since, 0)).Hours() / 24
+	if days > 0 {
+		usage.BytesPerDay = float64(usage.TotalSize) / days
+		usage.TxsPerDay = float64(usage.TxCount) / days
+		usage.EntriesPerDay = float64(usage.EntryCount) / days
+	}
+
+	return usage, nil
+}
+
+// DatabaseUsage returns the bytes on disk, the number of transactions and entries and the average growth
+// of a database, or of every database when none is specified
+func (s *ImmuServer) DatabaseUsage(ctx context.Context, req *schema.DatabaseUsageRequest) (*schema.DatabaseUsageList, error) {
+	s.Logger.Debugf("databaseusage")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbs []database.DB
+
+	if req.Database != "" {
+		db, err := s.dbList.GetByName(req.Database)
+		if err != nil {
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	} else {
+		for i := 0; i < s.dbList.Length(); i++ {
+			db := s.dbList.GetByIndex(int64(i))
+			if db.GetName() == SystemDBName {
+				continue
+			}
+			dbs = append(dbs, db)
+		}
+	}
+
+	res := &schema.DatabaseUsageList{}
+	now := time.Now()
+
+	for _, db := range dbs {
+		usage, err := s.databaseUsage(db, now)
+		if err != nil {
+			return nil, err
+		}
+		res.Usages = append(res.Usages, usage)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerDatabaseUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_database_usage")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.DatabaseUsage(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.DatabaseUsage(context.Background(), &schema.DatabaseUsageRequest{})
+	require.Error(t, err)
+
+	_, err = s.DatabaseUsage(ctx, &schema.DatabaseUsageRequest{Database: "unknown"})
+	require.Error(t, err)
+
+	db, err := s.dbList.GetByName(DefaultDBName)
+	require.NoError(t, err)
+
+	initialEntries, err := s.countEntries(db)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte{byte(i)}, Value: make([]byte, 512)},
+			{Key: []byte{byte(i), 1}, Value: make([]byte, 512)},
+		}})
+		require.NoError(t, err)
+	}
+
+	usages, err := s.DatabaseUsage(ctx, &schema.DatabaseUsageRequest{})
+	require.NoError(t, err)
+	require.Len(t, usages.Usages, 1)
+
+	usage := usages.Usages[0]
+	require.Equal(t, DefaultDBName, usage.Database)
+	require.Equal(t, initialEntries+10, usage.EntryCount)
+	require.GreaterOrEqual(t, usage.VLogSize, int64(10*512))
+	require.Greater(t, usage.TxLogSize, int64(0))
+	require.Equal(t, usage.TxLogSize+usage.VLogSize+usage.IndexSize+usage.OtherSize, usage.TotalSize)
+	require.NotZero(t, usage.Since)
+	require.Greater(t, usage.BytesPerDay, 0.0)
+	require.Greater(t, usage.TxsPerDay, 0.0)
+	require.Greater(t, usage.EntriesPerDay, 0.0)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value")}}})
+	require.NoError(t, err)
+
+	usages, err = s.DatabaseUsage(ctx, &schema.DatabaseUsageRequest{Database: DefaultDBName})
+	require.NoError(t, err)
+	require.Len(t, usages.Usages, 1)
+	require.Equal(t, usage.EntryCount+1, usages.Usages[0].EntryCount)
+	require.Equal(t, usage.TxCount+1, usages.Usages[0].TxCount)
+}
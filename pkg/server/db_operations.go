@@ -42,6 +42,10 @@ func (s *ImmuServer) Set(ctx context.Context, kv *schema.SetRequest) (*schema.Tx
 		return nil, err
 	}
 
+	if kv != nil {
+		kv.TxMetadata = s.withWriter(ctx, kv.TxMetadata)
+	}
+
 	return db.Set(kv)
 }
 
@@ -56,15 +60,22 @@ func (s *ImmuServer) VerifiableSet(ctx context.Context, req *schema.VerifiableSe
 		return nil, err
 	}
 
+	if req != nil && req.SetRequest != nil {
+		req.SetRequest.TxMetadata = s.withWriter(ctx, req.SetRequest.TxMetadata)
+	}
+
 	vtx, err := db.VerifiableSet(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if s.StateSigner != nil {
-		hdr := schema.TxHeaderFromProto(vtx.DualProof.TargetTxHeader)
-		alh := hdr.Alh()
+		hdr, err := schema.TxHeaderFromProto(vtx.DualProof.TargetTxHeader)
+		if err != nil {
+			return nil, err
+		}
 
+		alh := hdr.Alh()
 		newState := &schema.ImmutableState{
 			Db:     db.GetOptions().GetDBName(),
 			TxId:   hdr.ID,
@@ -105,9 +116,12 @@ func (s *ImmuServer) VerifiableGet(ctx context.Context, req *schema.VerifiableGe
 	}
 
 	if s.StateSigner != nil {
-		hdr := schema.TxHeaderFromProto(vEntry.VerifiableTx.DualProof.TargetTxHeader)
-		alh := hdr.Alh()
+		hdr, err := schema.TxHeaderFromProto(vEntry.VerifiableTx.DualProof.TargetTxHeader)
+		if err != nil {
+			return nil, err
+		}
 
+		alh := hdr.Alh()
 		newState := &schema.ImmutableState{
 			Db:     db.GetOptions().GetDBName(),
 			TxId:   hdr.ID,
@@ -168,9 +182,12 @@ func (s *ImmuServer) VerifiableTxById(ctx context.Context, req *schema.Verifiabl
 	}
 
 	if s.StateSigner != nil {
-		hdr := schema.TxHeaderFromProto(vtx.DualProof.TargetTxHeader)
-		alh := hdr.Alh()
+		hdr, err := schema.TxHeaderFromProto(vtx.DualProof.TargetTxHeader)
+		if err != nil {
+			return nil, err
+		}
 
+		alh := hdr.Alh()
 		newState := &schema.ImmutableState{
 			Db:     db.GetOptions().GetDBName(),
 			TxId:   hdr.ID,
@@ -198,6 +215,275 @@ func (s *ImmuServer) TxScan(ctx context.Context, req *schema.TxScanRequest) (*sc
 	return db.TxScan(req)
 }
 
+// TxByTime ...
+func (s *ImmuServer) TxByTime(ctx context.Context, req *schema.TxByTimeRequest) (*schema.Tx, error) {
+	db, err := s.getDBFromCtx(ctx, "TxByTime")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.TxByTime(req)
+}
+
+// ExportTxHeaders ...
+func (s *ImmuServer) ExportTxHeaders(ctx context.Context, req *schema.TxHeadersRequest) (*schema.TxHeaders, error) {
+	db, err := s.getDBFromCtx(ctx, "ExportTxHeaders")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExportTxHeaders(req)
+}
+
+// ConsistencyProof ...
+func (s *ImmuServer) ConsistencyProof(ctx context.Context, req *schema.ConsistencyProofRequest) (*schema.DualProof, error) {
+	db, err := s.getDBFromCtx(ctx, "ConsistencyProof")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ConsistencyProof(req)
+}
+
+// Notarize ...
+func (s *ImmuServer) Notarize(ctx context.Context, req *schema.NotarizeRequest) (*schema.Notarization, error) {
+	db, err := s.getDBFromCtx(ctx, "Notarize")
+	if err != nil {
+		return nil, err
+	}
+
+	notarization, err := db.Notarize(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return notarization, s.signNotarization(db.GetOptions().GetDBName(), notarization)
+}
+
+// VerifyNotarization ...
+func (s *ImmuServer) VerifyNotarization(ctx context.Context, req *schema.VerifyNotarizationRequest) (*schema.Notarization, error) {
+	db, err := s.getDBFromCtx(ctx, "VerifyNotarization")
+	if err != nil {
+		return nil, err
+	}
+
+	notarization, err := db.VerifyNotarization(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return notarization, s.signNotarization(db.GetOptions().GetDBName(), notarization)
+}
+
+// Erase ...
+func (s *ImmuServer) Erase(ctx context.Context, req *schema.EraseRequest) (*schema.Erasure, error) {
+	db, err := s.getDBFromCtx(ctx, "Erase")
+	if err != nil {
+		return nil, err
+	}
+
+	requestedBy, err := s.loggedInUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Erase(req, requestedBy)
+}
+
+// ErasureHistory ...
+func (s *ImmuServer) ErasureHistory(ctx context.Context, req *schema.ErasureHistoryRequest) (*schema.ErasureHistory, error) {
+	db, err := s.getDBFromCtx(ctx, "ErasureHistory")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ErasureHistory(req)
+}
+
+// PlaceLegalHold ...
+func (s *ImmuServer) PlaceLegalHold(ctx context.Context, req *schema.LegalHoldRequest) (*schema.LegalHold, error) {
+	db, err := s.getDBFromCtx(ctx, "PlaceLegalHold")
+	if err != nil {
+		return nil, err
+	}
+
+	placedBy, err := s.loggedInUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.PlaceLegalHold(req, placedBy)
+}
+
+// LiftLegalHold ...
+func (s *ImmuServer) LiftLegalHold(ctx context.Context, req *schema.LegalHoldRequest) (*empty.Empty, error) {
+	db, err := s.getDBFromCtx(ctx, "LiftLegalHold")
+	if err != nil {
+		return nil, err
+	}
+
+	liftedBy, err := s.loggedInUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, db.LiftLegalHold(req, liftedBy)
+}
+
+// ListLegalHolds ...
+func (s *ImmuServer) ListLegalHolds(ctx context.Context, _ *empty.Empty) (*schema.LegalHoldList, error) {
+	db, err := s.getDBFromCtx(ctx, "ListLegalHolds")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.LegalHolds()
+}
+
+// loggedInUsername returns the name of the logged in user, empty when authentication is disabled
+func (s *ImmuServer) loggedInUsername(ctx context.Context) (string, error) {
+	if !s.Options.GetAuth() {
+		return "", nil
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Username, nil
+}
+
+// ExclusionProof ...
+func (s *ImmuServer) ExclusionProof(ctx context.Context, req *schema.ExclusionProofRequest) (*schema.ExclusionProof, error) {
+	db, err := s.getDBFromCtx(ctx, "ExclusionProof")
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := db.ExclusionProof(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.StateSigner != nil {
+		hdr, err := schema.TxHeaderFromProto(proof.DualProof.TargetTxHeader)
+		if err != nil {
+			return nil, err
+		}
+
+		alh := hdr.Alh()
+		newState := &schema.ImmutableState{
+			Db:     db.GetName(),
+			TxId:   hdr.ID,
+			TxHash: alh[:],
+		}
+
+		err = s.StateSigner.Sign(newState)
+		if err != nil {
+			return nil, err
+		}
+
+		proof.Signature = newState.Signature
+	}
+
+	return proof, nil
+}
+
+// VerifiableBatch ...
+func (s *ImmuServer) VerifiableBatch(ctx context.Context, req *schema.VerifiableBatchRequest) (*schema.VerifiableBatch, error) {
+	db, err := s.getDBFromCtx(ctx, "VerifiableBatch")
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := db.VerifiableBatch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	batch.Signature, err = s.signTargetState(db.GetName(), batch.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// SampledAudit ...
+func (s *ImmuServer) SampledAudit(ctx context.Context, req *schema.SampledAuditRequest) (*schema.SampledAudit, error) {
+	db, err := s.getDBFromCtx(ctx, "SampledAudit")
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := db.SampledAudit(req)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Signature, err = s.signTargetState(db.GetName(), audit.DualProof)
+	if err != nil {
+		return nil, err
+	}
+
+	return audit, nil
+}
+
+// signTargetState signs the state of the database as of the target transaction of dualProof,
+// nil is returned when there is no state signer
+func (s *ImmuServer) signTargetState(dbName string, dualProof *schema.DualProof) (*schema.Signature, error) {
+	if s.StateSigner == nil {
+		return nil, nil
+	}
+
+	hdr, err := schema.TxHeaderFromProto(dualProof.TargetTxHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	alh := hdr.Alh()
+	newState := &schema.ImmutableState{
+		Db:     dbName,
+		TxId:   hdr.ID,
+		TxHash: alh[:],
+	}
+
+	err = s.StateSigner.Sign(newState)
+	if err != nil {
+		return nil, err
+	}
+
+	return newState.Signature, nil
+}
+
+func (s *ImmuServer) signNotarization(dbName string, notarization *schema.Notarization) error {
+	if s.StateSigner == nil {
+		return nil
+	}
+
+	hdr, err := schema.TxHeaderFromProto(notarization.VerifiableTx.DualProof.TargetTxHeader)
+	if err != nil {
+		return err
+	}
+
+	alh := hdr.Alh()
+	newState := &schema.ImmutableState{
+		Db:     dbName,
+		TxId:   hdr.ID,
+		TxHash: alh[:],
+	}
+
+	err = s.StateSigner.Sign(newState)
+	if err != nil {
+		return err
+	}
+
+	notarization.VerifiableTx.Signature = newState.Signature
+
+	return nil
+}
+
 // History ...
 func (s *ImmuServer) History(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error) {
 	db, err := s.getDBFromCtx(ctx, "History")
@@ -219,6 +505,10 @@ func (s *ImmuServer) SetReference(ctx context.Context, req *schema.ReferenceRequ
 		return nil, err
 	}
 
+	if req != nil {
+		req.TxMetadata = s.withWriter(ctx, req.TxMetadata)
+	}
+
 	return db.SetReference(req)
 }
 
@@ -233,15 +523,22 @@ func (s *ImmuServer) VerifiableSetReference(ctx context.Context, req *schema.Ver
 		return nil, err
 	}
 
+	if req != nil && req.ReferenceRequest != nil {
+		req.ReferenceRequest.TxMetadata = s.withWriter(ctx, req.ReferenceRequest.TxMetadata)
+	}
+
 	vtx, err := db.VerifiableSetReference(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if s.StateSigner != nil {
-		hdr := schema.TxHeaderFromProto(vtx.DualProof.TargetTxHeader)
-		alh := hdr.Alh()
+		hdr, err := schema.TxHeaderFromProto(vtx.DualProof.TargetTxHeader)
+		if err != nil {
+			return nil, err
+		}
 
+		alh := hdr.Alh()
 		newState := &schema.ImmutableState{
 			Db:     db.GetOptions().GetDBName(),
 			TxId:   hdr.ID,
@@ -270,6 +567,10 @@ func (s *ImmuServer) ZAdd(ctx context.Context, req *schema.ZAddRequest) (*schema
 		return nil, err
 	}
 
+	if req != nil {
+		req.TxMetadata = s.withWriter(ctx, req.TxMetadata)
+	}
+
 	return db.ZAdd(req)
 }
 
@@ -294,15 +595,22 @@ func (s *ImmuServer) VerifiableZAdd(ctx context.Context, req *schema.VerifiableZ
 		return nil, err
 	}
 
+	if req != nil && req.ZAddRequest != nil {
+		req.ZAddRequest.TxMetadata = s.withWriter(ctx, req.ZAddRequest.TxMetadata)
+	}
+
 	vtx, err := db.VerifiableZAdd(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if s.StateSigner != nil {
-		hdr := schema.TxHeaderFromProto(vtx.DualProof.TargetTxHeader)
-		alh := hdr.Alh()
+		hdr, err := schema.TxHeaderFromProto(vtx.DualProof.TargetTxHeader)
+		if err != nil {
+			return nil, err
+		}
 
+		alh := hdr.Alh()
 		newState := &schema.ImmutableState{
 			Db:     db.GetOptions().GetDBName(),
 			TxId:   hdr.ID,
@@ -330,6 +638,26 @@ func (s *ImmuServer) CompactIndex(ctx context.Context, _ *empty.Empty) (*empty.E
 	return &empty.Empty{}, err
 }
 
+// RebuildIndex discards the index of the selected database and builds it again from
+// the transaction log, progress is streamed until every committed transaction is indexed
+func (s *ImmuServer) RebuildIndex(_ *empty.Empty, progressServer schema.ImmuService_RebuildIndexServer) error {
+	if progressServer == nil {
+		return ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(progressServer.Context(), "RebuildIndex")
+	if err != nil {
+		return err
+	}
+
+	return db.RebuildIndex(func(indexedTxID, targetTxID uint64) error {
+		return progressServer.Send(&schema.IndexRebuildProgress{
+			IndexedTx: indexedTxID,
+			TargetTx:  targetTxID,
+		})
+	})
+}
+
 // GetAll ...
 func (s *ImmuServer) GetAll(ctx context.Context, req *schema.KeyListRequest) (*schema.Entries, error) {
 	if req == nil {
@@ -354,6 +682,8 @@ func (s *ImmuServer) Delete(ctx context.Context, req *schema.DeleteKeysRequest)
 		return nil, err
 	}
 
+	req.TxMetadata = s.withWriter(ctx, req.TxMetadata)
+
 	return db.Delete(req)
 }
 
@@ -367,5 +697,9 @@ func (s *ImmuServer) ExecAll(ctx context.Context, req *schema.ExecAllRequest) (*
 		return nil, err
 	}
 
+	if req != nil {
+		req.TxMetadata = s.withWriter(ctx, req.TxMetadata)
+	}
+
 	return db.ExecAll(req)
 }
@@ -21,6 +21,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -46,6 +49,11 @@ type dbOptions struct {
 	MaxValueLen  int `json:"maxValueLen"`  // permanent
 	MaxTxEntries int `json:"maxTxEntries"` // permanent
 
+	CompressionFormat string `json:"compressionFormat"` // permanent
+	CompressionLevel  int    `json:"compressionLevel"`  // permanent
+
+	HashAlgorithm string `json:"hashAlgorithm"` // permanent
+
 	ExcludeCommitTime bool `json:"excludeCommitTime"`
 
 	MaxConcurrency   int `json:"maxConcurrency"`
@@ -56,6 +64,22 @@ type dbOptions struct {
 	TxLogMaxOpenedFiles     int `json:"txLogMaxOpenedFiles"`
 	CommitLogMaxOpenedFiles int `json:"commitLogMaxOpenedFiles"`
 
+	VLogCacheSize       int    `json:"vLogCacheSize"`
+	CacheEvictionPolicy string `json:"cacheEvictionPolicy"`
+
+	Durability            string `json:"durability"`
+	GroupCommitMaxLatency int64  `json:"groupCommitMaxLatency"` // ms
+
+	MaxCommitQueueDepth int `json:"maxCommitQueueDepth"` // 0 means unlimited
+	MaxIndexingLag      int `json:"maxIndexingLag"`      // 0 means unlimited
+
+	BlobThreshold int `json:"blobThreshold"` // 0 means blob storage is disabled
+
+	WORMRetainUntil int64 `json:"wormRetainUntil"` // unix time, 0 means compliance mode is disabled
+
+	GCFrequency    int64 `json:"gcFrequency"`    // ms, 0 means value garbage collection is disabled
+	GCSafetyWindow int64 `json:"gcSafetyWindow"` // ms
+
 	IndexOptions *indexOptions `json:"indexOptions"`
 
 	CreatedBy string    `json:"createdBy"`
@@ -73,10 +97,13 @@ type indexOptions struct {
 	MaxActiveSnapshots       int   `json:"maxActiveSnapshots"`
 	RenewSnapRootAfter       int64 `json:"renewSnapRootAfter"` // ms
 	CompactionThld           int   `json:"compactionThld"`
-	DelayDuringCompaction    int64 `json:"delayDuringCompaction"` // ms
+	DelayDuringCompaction    int64 `json:"delayDuringCompaction"`  // ms
+	AutoCompactionInterval   int64 `json:"autoCompactionInterval"` // ms
+	CheckpointInterval       int64 `json:"checkpointInterval"`     // ms
 	NodesLogMaxOpenedFiles   int   `json:"nodesLogMaxOpenedFiles"`
 	HistoryLogMaxOpenedFiles int   `json:"historyLogMaxOpenedFiles"`
 	CommitLogMaxOpenedFiles  int   `json:"commitLogMaxOpenedFiles"`
+	IndexingWorkers          int   `json:"indexingWorkers"`
 }
 
 const DefaultMaxValueLen = 1 << 25   //32Mb
@@ -95,6 +122,11 @@ func (s *ImmuServer) defaultDBOptions(database string) *dbOptions {
 		MaxValueLen:  DefaultMaxValueLen,
 		MaxTxEntries: store.DefaultMaxTxEntries,
 
+		CompressionFormat: appendable.CompressionFormatName(store.DefaultCompressionFormat),
+		CompressionLevel:  store.DefaultCompressionLevel,
+
+		HashAlgorithm: store.DefaultHashAlgorithm.String(),
+
 		ExcludeCommitTime: false,
 
 		MaxConcurrency:          store.DefaultMaxConcurrency,
@@ -104,6 +136,14 @@ func (s *ImmuServer) defaultDBOptions(database string) *dbOptions {
 		TxLogMaxOpenedFiles:     store.DefaultTxLogMaxOpenedFiles,
 		CommitLogMaxOpenedFiles: store.DefaultCommitLogMaxOpenedFiles,
 
+		VLogCacheSize:       store.DefaultVLogCacheSize,
+		CacheEvictionPolicy: string(cache.DefaultEvictionPolicy),
+
+		Durability:            string(store.DefaultDurability),
+		GroupCommitMaxLatency: store.DefaultGroupCommitMaxLatency.Milliseconds(),
+
+		GCSafetyWindow: store.DefaultGCSafetyWindow.Milliseconds(),
+
 		IndexOptions: s.defaultIndexOptions(),
 
 		CreatedAt: time.Now(),
@@ -121,18 +161,35 @@ func (s *ImmuServer) defaultIndexOptions() *indexOptions {
 		RenewSnapRootAfter:       tbtree.DefaultRenewSnapRootAfter.Milliseconds(),
 		CompactionThld:           tbtree.DefaultCompactionThld,
 		DelayDuringCompaction:    tbtree.DefaultDelayDuringCompaction.Milliseconds(),
+		AutoCompactionInterval:   0,
+		CheckpointInterval:       0,
 		NodesLogMaxOpenedFiles:   tbtree.DefaultNodesLogMaxOpenedFiles,
 		HistoryLogMaxOpenedFiles: tbtree.DefaultHistoryLogMaxOpenedFiles,
 		CommitLogMaxOpenedFiles:  tbtree.DefaultCommitLogMaxOpenedFiles,
+		IndexingWorkers:          store.DefaultIndexingWorkers,
 	}
 }
 
 func (s *ImmuServer) databaseOptionsFrom(opts *dbOptions) *database.Options {
-	return database.DefaultOption().
+	stOpts := s.storeOptionsForDB(opts.Database, s.remoteStorage, opts.storeOptions())
+	if s.alerter != nil {
+		stOpts.WithCorruptionHandler(s.corruptionAlerter(opts.Database))
+	}
+
+	dbOpts := database.DefaultOption().
 		WithDBName(opts.Database).
 		WithDBRootPath(s.Options.Dir).
-		WithStoreOptions(s.storeOptionsForDB(opts.Database, s.remoteStorage, opts.storeOptions())).
+		WithStoreOptions(stOpts).
 		AsReplica(opts.Replica)
+
+	if txLogCacheSize := s.runtimeTxLogCacheSize(); txLogCacheSize > 0 {
+		stOpts.WithTxLogCacheSize(txLogCacheSize)
+	}
+
+	// the budget is evenly split among loaded databases, system database and the one being opened
+	s.memoryBudget.applyTo(dbOpts, s.dbList.Length()+2)
+
+	return dbOpts
 }
 
 func (opts *dbOptions) storeOptions() *store.Options {
@@ -148,9 +205,16 @@ func (opts *dbOptions) storeOptions() *store.Options {
 			WithRenewSnapRootAfter(time.Millisecond * time.Duration(opts.IndexOptions.RenewSnapRootAfter)).
 			WithCompactionThld(opts.IndexOptions.CompactionThld).
 			WithDelayDuringCompaction(time.Millisecond * time.Duration(opts.IndexOptions.DelayDuringCompaction)).
+			WithAutoCompactionInterval(time.Millisecond * time.Duration(opts.IndexOptions.AutoCompactionInterval)).
+			WithCheckpointInterval(time.Millisecond * time.Duration(opts.IndexOptions.CheckpointInterval)).
 			WithNodesLogMaxOpenedFiles(opts.IndexOptions.NodesLogMaxOpenedFiles).
 			WithHistoryLogMaxOpenedFiles(opts.IndexOptions.HistoryLogMaxOpenedFiles).
 			WithCommitLogMaxOpenedFiles(opts.IndexOptions.CommitLogMaxOpenedFiles)
+
+		if opts.IndexOptions.IndexingWorkers > 0 {
+			// databases created by previous versions do not have the number of workers stored
+			indexOpts.WithIndexingWorkers(opts.IndexOptions.IndexingWorkers)
+		}
 	}
 
 	stOpts := store.DefaultOptions().
@@ -165,9 +229,52 @@ func (opts *dbOptions) storeOptions() *store.Options {
 		WithVLogMaxOpenedFiles(opts.VLogMaxOpenedFiles).
 		WithTxLogMaxOpenedFiles(opts.TxLogMaxOpenedFiles).
 		WithCommitLogMaxOpenedFiles(opts.CommitLogMaxOpenedFiles).
+		WithVLogCacheSize(opts.VLogCacheSize).
+		WithMaxCommitQueueDepth(opts.MaxCommitQueueDepth).
+		WithMaxIndexingLag(opts.MaxIndexingLag).
+		WithBlobThreshold(opts.BlobThreshold).
 		WithMaxLinearProofLen(0). // fixed no limitation, it may be customized in the future
 		WithIndexOptions(indexOpts)
 
+	if opts.CacheEvictionPolicy != "" {
+		// databases created by previous versions do not have a policy stored
+		stOpts.WithCacheEvictionPolicy(cache.EvictionPolicy(opts.CacheEvictionPolicy))
+	}
+
+	if opts.Durability != "" {
+		// databases created by previous versions do not have a durability mode stored
+		stOpts.WithDurability(store.DurabilityMode(opts.Durability))
+	}
+
+	if opts.CompressionFormat != "" {
+		// databases created by previous versions do not have a compression format stored
+		compressionFormat, _ := appendable.CompressionFormatFromName(opts.CompressionFormat)
+
+		stOpts.WithCompressionFormat(compressionFormat).
+			WithCompresionLevel(opts.CompressionLevel)
+	}
+
+	if opts.HashAlgorithm != "" {
+		// databases created by previous versions do not have a hash algorithm stored
+		hashAlg, _ := hashing.AlgorithmFromName(opts.HashAlgorithm)
+		stOpts.WithHashAlgorithm(hashAlg)
+	}
+
+	if opts.GroupCommitMaxLatency > 0 {
+		stOpts.WithGroupCommitMaxLatency(time.Millisecond * time.Duration(opts.GroupCommitMaxLatency))
+	}
+
+	if opts.WORMRetainUntil > 0 {
+		stOpts.WithRetainUntil(time.Unix(opts.WORMRetainUntil, 0))
+	}
+
+	stOpts.WithGCFrequency(time.Millisecond * time.Duration(opts.GCFrequency))
+
+	if opts.GCSafetyWindow > 0 {
+		// databases created by previous versions do not have a safety window stored
+		stOpts.WithGCSafetyWindow(time.Millisecond * time.Duration(opts.GCSafetyWindow))
+	}
+
 	if opts.ExcludeCommitTime {
 		stOpts.WithTimeFunc(func() time.Time { return time.Unix(0, 0) })
 	} else {
@@ -193,6 +300,11 @@ func (opts *dbOptions) databaseSettings() *schema.DatabaseSettings {
 		MaxValueLen:  uint32(opts.MaxValueLen),
 		MaxTxEntries: uint32(opts.MaxTxEntries),
 
+		CompressionFormat: opts.CompressionFormat,
+		CompressionLevel:  int32(opts.CompressionLevel),
+
+		HashAlgorithm: opts.HashAlgorithm,
+
 		ExcludeCommitTime: opts.ExcludeCommitTime,
 
 		MaxConcurrency:   uint32(opts.MaxConcurrency),
@@ -203,6 +315,22 @@ func (opts *dbOptions) databaseSettings() *schema.DatabaseSettings {
 		TxLogMaxOpenedFiles:     uint32(opts.TxLogMaxOpenedFiles),
 		CommitLogMaxOpenedFiles: uint32(opts.CommitLogMaxOpenedFiles),
 
+		VLogCacheSize:       uint32(opts.VLogCacheSize),
+		CacheEvictionPolicy: opts.CacheEvictionPolicy,
+
+		Durability:            opts.Durability,
+		GroupCommitMaxLatency: uint32(opts.GroupCommitMaxLatency),
+
+		MaxCommitQueueDepth: uint32(opts.MaxCommitQueueDepth),
+		MaxIndexingLag:      uint32(opts.MaxIndexingLag),
+
+		BlobThreshold: uint32(opts.BlobThreshold),
+
+		WormRetainUntil: opts.WORMRetainUntil,
+
+		GcFrequency:    uint64(opts.GCFrequency),
+		GcSafetyWindow: uint64(opts.GCSafetyWindow),
+
 		IndexSettings: &schema.IndexSettings{
 			Synced:                   opts.IndexOptions.Synced,
 			FlushThreshold:           uint32(opts.IndexOptions.FlushThreshold),
@@ -213,9 +341,12 @@ func (opts *dbOptions) databaseSettings() *schema.DatabaseSettings {
 			RenewSnapRootAfter:       uint64(opts.IndexOptions.RenewSnapRootAfter),
 			CompactionThld:           uint32(opts.IndexOptions.CompactionThld),
 			DelayDuringCompaction:    uint32(opts.IndexOptions.DelayDuringCompaction),
+			AutoCompactionInterval:   uint64(opts.IndexOptions.AutoCompactionInterval),
+			CheckpointInterval:       uint64(opts.IndexOptions.CheckpointInterval),
 			NodesLogMaxOpenedFiles:   uint32(opts.IndexOptions.NodesLogMaxOpenedFiles),
 			HistoryLogMaxOpenedFiles: uint32(opts.IndexOptions.HistoryLogMaxOpenedFiles),
 			CommitLogMaxOpenedFiles:  uint32(opts.IndexOptions.CommitLogMaxOpenedFiles),
+			IndexingWorkers:          uint32(opts.IndexOptions.IndexingWorkers),
 		},
 	}
 }
@@ -237,7 +368,15 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 		return fmt.Errorf("%w: invalid replication options for database '%s'", ErrIllegalArguments, opts.Database)
 	}
 
+	if !settings.Replica && len(settings.SeedBackups) > 0 {
+		return fmt.Errorf("%w: only replicas can be seeded from backups ('%s')", ErrIllegalArguments, opts.Database)
+	}
+
 	if existentDB {
+		if len(settings.SeedBackups) > 0 {
+			return fmt.Errorf("%w: seed backups can only be set at database creation ('%s')", ErrIllegalArguments, opts.Database)
+		}
+
 		// permanent settings can not be changed after database is created
 		// in the future, some settings may turn into non-permanent
 
@@ -258,6 +397,14 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 				"max number of entries per transaction", opts.Database)
 		}
 
+		if settings.CompressionFormat != "" || settings.CompressionLevel != 0 {
+			return fmt.Errorf("%w: %s can not be changed after database creation ('%s')", ErrIllegalArguments, "compression", opts.Database)
+		}
+
+		if settings.HashAlgorithm != "" {
+			return fmt.Errorf("%w: %s can not be changed after database creation ('%s')", ErrIllegalArguments, "hash algorithm", opts.Database)
+		}
+
 		if settings.IndexSettings != nil && settings.IndexSettings.MaxNodeSize > 0 {
 			return fmt.Errorf("%w: %s can not be changed after database creation ('%s')", ErrIllegalArguments, "max node size", opts.Database)
 		}
@@ -279,6 +426,23 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 	conditionalSet(settings.MaxValueLen > 0, func() { opts.MaxValueLen = int(settings.MaxValueLen) })
 	conditionalSet(settings.MaxTxEntries > 0, func() { opts.MaxTxEntries = int(settings.MaxTxEntries) })
 
+	if settings.CompressionFormat != "" {
+		_, ok := appendable.CompressionFormatFromName(settings.CompressionFormat)
+		if !ok {
+			return fmt.Errorf("%w: unsupported compression format '%s' ('%s')", ErrIllegalArguments, settings.CompressionFormat, opts.Database)
+		}
+		opts.CompressionFormat = settings.CompressionFormat
+	}
+	conditionalSet(settings.CompressionLevel != 0, func() { opts.CompressionLevel = int(settings.CompressionLevel) })
+
+	if settings.HashAlgorithm != "" {
+		_, ok := hashing.AlgorithmFromName(settings.HashAlgorithm)
+		if !ok {
+			return fmt.Errorf("%w: unsupported hash algorithm '%s' ('%s')", ErrIllegalArguments, settings.HashAlgorithm, opts.Database)
+		}
+		opts.HashAlgorithm = settings.HashAlgorithm
+	}
+
 	// store options
 	opts.ExcludeCommitTime = settings.ExcludeCommitTime
 
@@ -290,6 +454,47 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 	conditionalSet(settings.TxLogMaxOpenedFiles > 0, func() { opts.TxLogMaxOpenedFiles = int(settings.TxLogMaxOpenedFiles) })
 	conditionalSet(settings.CommitLogMaxOpenedFiles > 0, func() { opts.CommitLogMaxOpenedFiles = int(settings.CommitLogMaxOpenedFiles) })
 
+	conditionalSet(settings.VLogCacheSize > 0, func() { opts.VLogCacheSize = int(settings.VLogCacheSize) })
+
+	if settings.CacheEvictionPolicy != "" {
+		if !cache.ValidEvictionPolicy(cache.EvictionPolicy(settings.CacheEvictionPolicy)) {
+			return fmt.Errorf("%w: unsupported cache eviction policy '%s' for database '%s'",
+				ErrIllegalArguments, settings.CacheEvictionPolicy, opts.Database)
+		}
+		opts.CacheEvictionPolicy = settings.CacheEvictionPolicy
+	}
+
+	if settings.Durability != "" {
+		if !store.ValidDurabilityMode(store.DurabilityMode(settings.Durability)) {
+			return fmt.Errorf("%w: unsupported durability mode '%s' for database '%s'",
+				ErrIllegalArguments, settings.Durability, opts.Database)
+		}
+		opts.Durability = settings.Durability
+	}
+
+	conditionalSet(settings.GroupCommitMaxLatency > 0, func() { opts.GroupCommitMaxLatency = int64(settings.GroupCommitMaxLatency) })
+
+	conditionalSet(settings.MaxCommitQueueDepth > 0, func() { opts.MaxCommitQueueDepth = int(settings.MaxCommitQueueDepth) })
+	conditionalSet(settings.MaxIndexingLag > 0, func() { opts.MaxIndexingLag = int(settings.MaxIndexingLag) })
+
+	conditionalSet(settings.BlobThreshold > 0, func() { opts.BlobThreshold = int(settings.BlobThreshold) })
+
+	conditionalSet(settings.GcFrequency > 0, func() { opts.GCFrequency = int64(settings.GcFrequency) })
+	conditionalSet(settings.GcSafetyWindow > 0, func() { opts.GCSafetyWindow = int64(settings.GcSafetyWindow) })
+
+	// compliance mode can neither be disabled nor shortened once enabled
+	if settings.WormRetainUntil != 0 {
+		if settings.WormRetainUntil < opts.WORMRetainUntil {
+			return fmt.Errorf("%w: WORM retention date can only be extended ('%s')", ErrIllegalArguments, opts.Database)
+		}
+
+		if settings.WormRetainUntil <= time.Now().Unix() {
+			return fmt.Errorf("%w: WORM retention date must be in the future ('%s')", ErrIllegalArguments, opts.Database)
+		}
+
+		opts.WORMRetainUntil = settings.WormRetainUntil
+	}
+
 	// index options
 	if settings.IndexSettings != nil {
 		if opts.IndexOptions == nil {
@@ -322,6 +527,9 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 		conditionalSet(settings.IndexSettings.DelayDuringCompaction > 0, func() {
 			opts.IndexOptions.DelayDuringCompaction = int64(settings.IndexSettings.DelayDuringCompaction)
 		})
+		conditionalSet(settings.IndexSettings.AutoCompactionInterval > 0, func() {
+			opts.IndexOptions.AutoCompactionInterval = int64(settings.IndexSettings.AutoCompactionInterval)
+		})
 		conditionalSet(settings.IndexSettings.NodesLogMaxOpenedFiles > 0, func() {
 			opts.IndexOptions.NodesLogMaxOpenedFiles = int(settings.IndexSettings.NodesLogMaxOpenedFiles)
 		})
@@ -331,6 +539,12 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 		conditionalSet(settings.IndexSettings.CommitLogMaxOpenedFiles > 0, func() {
 			opts.IndexOptions.CommitLogMaxOpenedFiles = int(settings.IndexSettings.CommitLogMaxOpenedFiles)
 		})
+		conditionalSet(settings.IndexSettings.CheckpointInterval > 0, func() {
+			opts.IndexOptions.CheckpointInterval = int64(settings.IndexSettings.CheckpointInterval)
+		})
+		conditionalSet(settings.IndexSettings.IndexingWorkers > 0, func() {
+			opts.IndexOptions.IndexingWorkers = int(settings.IndexSettings.IndexingWorkers)
+		})
 	}
 
 	return nil
@@ -17,7 +17,10 @@ limitations under the License.
 package server
 
 import (
+	stdErrors "errors"
+
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/errors"
@@ -27,32 +30,57 @@ import (
 )
 
 var (
-	ErrIllegalArguments            = status.Error(codes.InvalidArgument, database.ErrIllegalArguments.Error())
-	ErrIllegalState                = status.Error(codes.InvalidArgument, database.ErrIllegalState.Error())
-	ErrEmptyAdminPassword          = status.Error(codes.InvalidArgument, "Admin password cannot be empty")
-	ErrUserNotActive               = "user is not active"
-	ErrInvalidUsernameOrPassword   = "invalid user name or password"
-	ErrAuthDisabled                = "server is running with authentication disabled, please enable authentication to login"
-	ErrAuthMustBeEnabled           = status.Error(codes.InvalidArgument, "authentication must be on")
-	ErrAuthMustBeDisabled          = status.Error(codes.InvalidArgument, "authentication must be disabled when restoring systemdb")
-	ErrNotAllowedInMaintenanceMode = status.Error(codes.InvalidArgument, "operation not allowed in maintenance mode")
-	ErrReservedDatabase            = errors.New("database is reserved")
-	ErrPermissionDenied            = errors.New("permission denied")
-	ErrNotSupported                = errors.New("operation not supported")
-	ErrNotLoggedIn                 = auth.ErrNotLoggedIn
-	ErrReplicationInProgress       = errors.New("replication already in progress")
-	ErrReplicatorNotNeeded         = errors.New("replicator is not needed")
-	ErrReplicationNotInProgress    = errors.New("replication is not in progress")
-	ErrSessionAlreadyPresent       = errors.New("session already present").WithCode(errors.CodInternalError)
-	ErrSessionNotFound             = errors.New("session not found").WithCode(errors.CodSqlserverRejectedEstablishmentOfSqlSession)
-	ErrOngoingReadWriteTx          = sessions.ErrOngoingReadWriteTx
-	ErrNoSessionIDPresent          = errors.New("no sessionID provided")
-	ErrTxNotProperlyClosed         = errors.New("tx not properly closed")
-	ErrReadWriteTxNotOngoing       = errors.New("read write transaction not ongoing")
-	ErrTxReadConflict              = errors.New(store.ErrTxReadConflict.Error()).WithCode(errors.CodInFailedSqlTransaction)
+	ErrIllegalArguments             = status.Error(codes.InvalidArgument, database.ErrIllegalArguments.Error())
+	ErrIllegalState                 = status.Error(codes.InvalidArgument, database.ErrIllegalState.Error())
+	ErrEmptyAdminPassword           = status.Error(codes.InvalidArgument, "Admin password cannot be empty")
+	ErrUserNotActive                = "user is not active"
+	ErrInvalidUsernameOrPassword    = "invalid user name or password"
+	ErrAuthDisabled                 = "server is running with authentication disabled, please enable authentication to login"
+	ErrAuthMustBeEnabled            = status.Error(codes.InvalidArgument, "authentication must be on")
+	ErrAuthMustBeDisabled           = status.Error(codes.InvalidArgument, "authentication must be disabled when restoring systemdb")
+	ErrNotAllowedInMaintenanceMode  = status.Error(codes.InvalidArgument, "operation not allowed in maintenance mode")
+	ErrReservedDatabase             = errors.New("database is reserved")
+	ErrPermissionDenied             = errors.New("permission denied")
+	ErrNotSupported                 = errors.New("operation not supported")
+	ErrNotLoggedIn                  = auth.ErrNotLoggedIn
+	ErrReplicationInProgress        = errors.New("replication already in progress")
+	ErrReplicatorNotNeeded          = errors.New("replicator is not needed")
+	ErrReplicationNotInProgress     = errors.New("replication is not in progress")
+	ErrSessionAlreadyPresent        = errors.New("session already present").WithCode(errors.CodInternalError)
+	ErrSessionNotFound              = errors.New("session not found").WithCode(errors.CodSqlserverRejectedEstablishmentOfSqlSession)
+	ErrOngoingReadWriteTx           = sessions.ErrOngoingReadWriteTx
+	ErrNoSessionIDPresent           = errors.New("no sessionID provided")
+	ErrTxNotProperlyClosed          = errors.New("tx not properly closed")
+	ErrReadWriteTxNotOngoing        = errors.New("read write transaction not ongoing")
+	ErrTxReadConflict               = errors.New(store.ErrTxReadConflict.Error()).WithCode(errors.CodInFailedSqlTransaction)
+	ErrBackupScheduleNotFound       = errors.New("backup schedule not found")
+	ErrRetentionPolicyNotFound      = errors.New("retention policy not found")
+	ErrBackupEncrypted              = errors.New("backup is encrypted but no backup encryption key is set")
+	ErrGlobalStateDisabled          = errors.New("global state is disabled on the server")
+	ErrCosigningDisabled            = errors.New("cosigning is disabled on the server")
+	ErrNotCosigner                  = errors.New("public key is not of a cosigner")
+	ErrInvalidCosignature           = errors.New("cosignature does not match the state of the database")
+	ErrCosignedStateNotFound        = errors.New("cosigned state not found")
+	ErrAuditReportSigningDisabled   = errors.New("audit reports can not be signed, no signing key is set on the server")
+	ErrVerificationScheduleNotFound = errors.New("verification schedule not found")
+	ErrVerificationFailed           = errors.New("verification failed")
 )
 
 func mapServerError(err error) error {
+	if stdErrors.Is(err, store.ErrWriteBackpressure) {
+		// throttled writes are retryable, clients are hinted how long to wait before retrying
+		return errors.New(err.Error()).
+			WithCode(errors.CodInsufficientResources).
+			WithRetryDelay(int32(store.WriteRetryDelay.Milliseconds()))
+	}
+	if stdErrors.Is(err, tbtree.ErrCompactionThresholdNotReached) || stdErrors.Is(err, store.ErrRetentionLocked) {
+		// nothing failed, the operation can not be carried out until the database reaches the required state
+		return errors.New(err.Error()).WithCode(errors.CodObjectNotInPrerequisiteState)
+	}
+	if stdErrors.Is(err, store.ErrValueGCUnsupported) {
+		return errors.New(err.Error()).WithCode(errors.CodFeatureNotSupported)
+	}
+
 	switch err {
 	case store.ErrIllegalState:
 		return ErrIllegalState
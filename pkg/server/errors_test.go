@@ -18,10 +18,16 @@ package server
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
+	immuerrors "github.com/codenotary/immudb/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestMapServerError(t *testing.T) {
@@ -35,3 +41,40 @@ func TestMapServerError(t *testing.T) {
 	err = mapServerError(someError)
 	assert.Equal(t, someError, err)
 }
+
+func TestMapServerErrorBackpressure(t *testing.T) {
+	err := mapServerError(fmt.Errorf("%w: commit queue depth limit (10) reached", store.ErrWriteBackpressure))
+
+	immuErr, ok := err.(immuerrors.Error)
+	require.True(t, ok)
+	require.Equal(t, immuerrors.CodInsufficientResources, immuErr.Code())
+	require.Equal(t, int32(store.WriteRetryDelay.Milliseconds()), immuErr.RetryDelay())
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+}
+
+func TestMapServerErrorPrerequisiteState(t *testing.T) {
+	for _, e := range []error{
+		tbtree.ErrCompactionThresholdNotReached,
+		fmt.Errorf("%w: values are retained until 2030-01-01T00:00:00Z", store.ErrRetentionLocked),
+	} {
+		err := mapServerError(e)
+
+		immuErr, ok := err.(immuerrors.Error)
+		require.True(t, ok)
+		require.Equal(t, immuerrors.CodObjectNotInPrerequisiteState, immuErr.Code())
+		require.Equal(t, e.Error(), immuErr.Error())
+
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.FailedPrecondition, st.Code())
+	}
+
+	err := mapServerError(store.ErrValueGCUnsupported)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.Unimplemented, st.Code())
+}
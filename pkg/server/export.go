@@ -0,0 +1,129 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/throttle"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// Export streams a portable copy of the selected database, which can be imported into another server.
+// The manifest of the export is signed when the server has a signing key.
+func (s *ImmuServer) Export(_ *empty.Empty, exportServer schema.ImmuService_ExportServer) error {
+	if exportServer == nil {
+		return ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(exportServer.Context(), "Export")
+	if err != nil {
+		return err
+	}
+
+	w := throttle.NewWriter(exportServer.Context(), &chunkWriter{
+		send:      exportServer.Send,
+		chunkSize: s.Options.StreamChunkSize,
+	}, s.backupLimiter)
+
+	var sign func(state *schema.ImmutableState) error
+	if s.StateSigner != nil {
+		sign = s.StateSigner.Sign
+	}
+
+	manifest, err := db.Export(w, sign)
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Infof("export of database '%s' completed at tx %d", db.GetName(), manifest.TxID)
+
+	return nil
+}
+
+// Import creates a new database from an export, the name of the database is taken from the first message.
+// The state of the exported database is returned once the imported one is checked against it.
+func (s *ImmuServer) Import(importServer schema.ImmuService_ImportServer) error {
+	if importServer == nil {
+		return ErrIllegalArguments
+	}
+
+	req, err := importServer.Recv()
+	if err != nil {
+		return err
+	}
+
+	err = s.checkNewDatabase(importServer.Context(), req.DatabaseName)
+	if err != nil {
+		return err
+	}
+
+	if s.remoteStorage != nil {
+		// the data of an export is restored into local files
+		return store.ErrBackupUnsupported
+	}
+
+	dbOpts := s.defaultDBOptions(req.DatabaseName)
+
+	r := &importReader{recv: importServer.Recv, buf: req.Content}
+
+	db, manifest, err := database.Import(s.databaseOptionsFrom(dbOpts), r, s.Logger)
+	if err != nil {
+		return err
+	}
+
+	err = s.saveDBOptions(dbOpts)
+	if err != nil {
+		db.Close()
+		os.RemoveAll(filepath.Join(s.Options.Dir, req.DatabaseName))
+		return err
+	}
+
+	s.dbList.Append(db)
+	s.multidbmode = true
+
+	return importServer.SendAndClose(manifest.State())
+}
+
+// importReader reads the content of the received messages
+type importReader struct {
+	recv func() (*schema.ImportRequest, error)
+	buf  []byte
+}
+
+func (r *importReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		req, err := r.recv()
+		if err != nil {
+			return 0, err
+		}
+
+		r.buf = req.Content
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+var _ io.Reader = (*importReader)(nil)
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// GlobalState returns the Merkle root aggregating the current state of all the databases, signed
+// by the server so a single value attests the state of the whole server. The states of all the
+// databases are returned to the system admin, other users get the state of a database they have
+// access to along with its proof of inclusion in the root
+func (s *ImmuServer) GlobalState(ctx context.Context, req *schema.GlobalStateRequest) (*schema.GlobalState, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if !s.Options.GlobalState {
+		return nil, ErrGlobalStateDisabled
+	}
+
+	if s.Options.GetAuth() {
+		_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not get loggedin user data")
+		}
+
+		if !user.IsSysAdmin && (req.Database == "" || user.WhichPermission(req.Database) == auth.PermissionNone) {
+			return nil, ErrPermissionDenied
+		}
+	}
+
+	states := s.currentStates()
+
+	tree, err := schema.GlobalStateTree(states)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := tree.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	globalState := &schema.GlobalState{
+		Root:  root[:],
+		Width: uint32(len(states)),
+	}
+
+	if req.Database == "" {
+		globalState.States = states
+	} else {
+		i := 0
+		for i < len(states) && states[i].Db != req.Database {
+			i++
+		}
+		if i == len(states) {
+			return nil, database.ErrDatabaseNotExists
+		}
+
+		proof, err := tree.InclusionProof(i)
+		if err != nil {
+			return nil, err
+		}
+
+		globalState.States = []*schema.ImmutableState{states[i]}
+		globalState.InclusionProof = schema.InclusionProofToProto(proof)
+	}
+
+	if s.StateSigner != nil {
+		err = s.StateSigner.SignGlobalState(globalState)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return globalState, nil
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// percentage of the per-database memory share assigned to each kind of buffer
+const (
+	memoryBudgetCacheShare  = 50 // tx log, value and index caches
+	memoryBudgetCommitShare = 30 // pre-allocated commit buffers
+	memoryBudgetSQLShare    = 20 // sql in-memory working areas
+)
+
+// percentage of the memory budget at which load is shed and at which it's accepted again
+const (
+	memoryBudgetSheddingThld = 90
+	memoryBudgetRecoveryThld = 80
+)
+
+const memoryBudgetSamplingInterval = time.Second
+
+// estimated size of the fixed part of each entry in a commit buffer
+const txEntryOverhead = 64
+
+// MemoryRetryDelay is the suggested delay before retrying a request rejected with ErrMemoryBudgetExceeded
+const MemoryRetryDelay = 500 * time.Millisecond
+
+var ErrMemoryBudgetExceeded = errors.New("memory budget exceeded").
+	WithCode(errors.CodInsufficientResources).
+	WithRetryDelay(int32(MemoryRetryDelay.Milliseconds()))
+
+// methods still served while shedding load, they are required to keep or release sessions
+var memoryBudgetExemptMethods = map[string]struct{}{
+	"/immudb.schema.ImmuService/Health":       {},
+	"/immudb.schema.ImmuService/Login":        {},
+	"/immudb.schema.ImmuService/Logout":       {},
+	"/immudb.schema.ImmuService/OpenSession":  {},
+	"/immudb.schema.ImmuService/CloseSession": {},
+	"/immudb.schema.ImmuService/KeepAlive":    {},
+	"/immudb.schema.ImmuService/Rollback":     {},
+}
+
+// memoryBudget sizes database buffers according to a global limit and sheds load when the
+// memory used by the process gets close to it. A zero limit disables enforcement
+type memoryBudget struct {
+	limit uint64
+
+	readUsage  func() uint64
+	freeMemory func()
+
+	usage    uint64
+	shedding bool
+	mutex    sync.RWMutex
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+}
+
+func newMemoryBudget(limit uint64) *memoryBudget {
+	return &memoryBudget{
+		limit:      limit,
+		readUsage:  processMemoryUsage,
+		freeMemory: debug.FreeOSMemory,
+		done:       make(chan struct{}),
+	}
+}
+
+// processMemoryUsage returns the memory obtained from the OS and not yet returned to it
+func processMemoryUsage() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return m.Sys - m.HeapReleased
+}
+
+func (mb *memoryBudget) enabled() bool {
+	return mb != nil && mb.limit > 0
+}
+
+// sample updates current usage, memory is released to the OS before starting to shed load
+func (mb *memoryBudget) sample() {
+	if !mb.enabled() {
+		return
+	}
+
+	usage := mb.readUsage()
+
+	mb.mutex.RLock()
+	shedding := mb.shedding
+	mb.mutex.RUnlock()
+
+	if !shedding && usage*100 >= mb.limit*memoryBudgetSheddingThld {
+		mb.freeMemory()
+		usage = mb.readUsage()
+	}
+
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	mb.usage = usage
+
+	if usage*100 >= mb.limit*memoryBudgetSheddingThld {
+		mb.shedding = true
+	} else if usage*100 < mb.limit*memoryBudgetRecoveryThld {
+		mb.shedding = false
+	}
+
+	Metrics.MemoryBudgetGauge.Set(float64(mb.limit))
+	Metrics.MemoryUsageGauge.Set(float64(usage))
+}
+
+func (mb *memoryBudget) start() {
+	if !mb.enabled() {
+		return
+	}
+
+	mb.startOnce.Do(func() {
+		mb.sample()
+
+		go func() {
+			ticker := time.NewTicker(memoryBudgetSamplingInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-mb.done:
+					return
+				case <-ticker.C:
+					mb.sample()
+				}
+			}
+		}()
+	})
+}
+
+func (mb *memoryBudget) stop() {
+	if mb == nil {
+		return
+	}
+
+	mb.stopOnce.Do(func() { close(mb.done) })
+}
+
+func (mb *memoryBudget) exceeded() bool {
+	if !mb.enabled() {
+		return false
+	}
+
+	mb.mutex.RLock()
+	defer mb.mutex.RUnlock()
+
+	return mb.shedding
+}
+
+func (mb *memoryBudget) admit(method string) error {
+	if !mb.exceeded() {
+		return nil
+	}
+
+	if _, exempt := memoryBudgetExemptMethods[method]; exempt {
+		return nil
+	}
+
+	Metrics.MemoryShedRequestsCounter.Inc()
+
+	return ErrMemoryBudgetExceeded
+}
+
+// applyTo reduces cache sizes, concurrency and sql working areas of a database so its buffers
+// fit into an even share of the budget, settings lower than the computed ones are kept
+func (mb *memoryBudget) applyTo(dbOpts *database.Options, dbCount int) {
+	if !mb.enabled() {
+		return
+	}
+
+	if dbCount < 1 {
+		dbCount = 1
+	}
+
+	share := mb.limit / uint64(dbCount)
+
+	stOpts := dbOpts.GetStoreOptions()
+
+	txSize := uint64(stOpts.MaxTxEntries) * uint64(stOpts.MaxKeyLen+txEntryOverhead)
+
+	// commit buffers: one pre-allocated tx per concurrent commit plus one used by the indexer
+	commitBudget := share * memoryBudgetCommitShare / 100
+
+	commitBuffers := commitBudget / txSize
+	if commitBuffers > 0 {
+		commitBuffers--
+	}
+
+	stOpts.WithMaxConcurrency(fitInto(stOpts.MaxConcurrency, commitBuffers))
+
+	// caches: half for the index, the rest for tx log and value log caches
+	cacheBudget := share * memoryBudgetCacheShare / 100
+
+	stOpts.IndexOpts.WithCacheSize(fitInto(stOpts.IndexOpts.CacheSize, cacheBudget*50/100/uint64(stOpts.IndexOpts.MaxNodeSize)))
+	stOpts.WithTxLogCacheSize(fitInto(stOpts.TxLogCacheSize, cacheBudget*30/100/txSize))
+
+	if stOpts.VLogCacheSize > 0 {
+		stOpts.WithVLogCacheSize(fitInto(stOpts.VLogCacheSize, cacheBudget*20/100/uint64(stOpts.MaxValueLen)))
+	}
+
+	sqlBudget := share * memoryBudgetSQLShare / 100
+	dbOpts.WithSQLDistinctLimit(fitInto(dbOpts.GetSQLDistinctLimit(), sqlBudget/uint64(stOpts.MaxValueLen)))
+}
+
+// fitInto returns the lowest between the configured value and the one fitting into the budget,
+// but never less than one
+func fitInto(configured int, fitting uint64) int {
+	if configured > 0 && uint64(configured) <= fitting {
+		return configured
+	}
+
+	if fitting < 1 {
+		return 1
+	}
+
+	return int(fitting)
+}
+
+func (s *ImmuServer) MemoryBudgetInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.memoryBudget.admit(info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *ImmuServer) MemoryBudgetStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.memoryBudget.admit(info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/database"
+	immuerrors "github.com/codenotary/immudb/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestMemoryBudgetDisabled(t *testing.T) {
+	var mb *memoryBudget
+
+	require.False(t, mb.enabled())
+	require.False(t, mb.exceeded())
+	require.NoError(t, mb.admit("/immudb.schema.ImmuService/Set"))
+
+	mb.start()
+	mb.stop()
+
+	dbOpts := database.DefaultOption()
+	mb.applyTo(dbOpts, 1)
+	require.Equal(t, store.DefaultMaxConcurrency, dbOpts.GetStoreOptions().MaxConcurrency)
+	require.Equal(t, sql.DefaultDistinctLimit, dbOpts.GetSQLDistinctLimit())
+
+	mb = newMemoryBudget(0)
+	require.False(t, mb.enabled())
+
+	mb.sample()
+	require.False(t, mb.exceeded())
+}
+
+func TestMemoryBudgetShedding(t *testing.T) {
+	usage := uint64(0)
+	freed := 0
+
+	mb := newMemoryBudget(1000)
+	mb.readUsage = func() uint64 { return usage }
+	mb.freeMemory = func() {
+		freed++
+		usage -= 50
+	}
+
+	usage = 500
+	mb.sample()
+	require.False(t, mb.exceeded())
+	require.Zero(t, freed)
+
+	// releasing memory to the OS is enough to stay within the budget
+	usage = 920
+	mb.sample()
+	require.False(t, mb.exceeded())
+	require.Equal(t, 1, freed)
+
+	usage = 990
+	mb.sample()
+	require.True(t, mb.exceeded())
+	require.Equal(t, 2, freed)
+
+	err := mb.admit("/immudb.schema.ImmuService/Set")
+	require.ErrorIs(t, err, ErrMemoryBudgetExceeded)
+
+	immuErr, ok := err.(immuerrors.Error)
+	require.True(t, ok)
+	require.Equal(t, immuerrors.CodInsufficientResources, immuErr.Code())
+	require.Equal(t, int32(MemoryRetryDelay.Milliseconds()), immuErr.RetryDelay())
+
+	require.NoError(t, mb.admit("/immudb.schema.ImmuService/Health"))
+	require.NoError(t, mb.admit("/immudb.schema.ImmuService/CloseSession"))
+
+	// load is accepted again only once usage goes below the recovery threshold
+	usage = 850
+	mb.sample()
+	require.True(t, mb.exceeded())
+	require.Equal(t, 2, freed)
+
+	usage = 700
+	mb.sample()
+	require.False(t, mb.exceeded())
+	require.NoError(t, mb.admit("/immudb.schema.ImmuService/Set"))
+
+	mb.start()
+	mb.stop()
+	mb.stop()
+}
+
+func TestMemoryBudgetInterceptors(t *testing.T) {
+	s := DefaultServer()
+	s.memoryBudget = newMemoryBudget(1000)
+	s.memoryBudget.readUsage = func() uint64 { return 1000 }
+	s.memoryBudget.freeMemory = func() {}
+
+	unaryHandler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+	streamHandler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	resp, err := s.MemoryBudgetInterceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/Set"}, unaryHandler)
+	require.NoError(t, err)
+	require.Equal(t, "req", resp)
+
+	s.memoryBudget.sample()
+
+	_, err = s.MemoryBudgetInterceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/Set"}, unaryHandler)
+	require.ErrorIs(t, err, ErrMemoryBudgetExceeded)
+
+	err = s.MemoryBudgetStreamInterceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/immudb.schema.ImmuService/streamSet"}, streamHandler)
+	require.ErrorIs(t, err, ErrMemoryBudgetExceeded)
+
+	resp, err = s.MemoryBudgetInterceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/Login"}, unaryHandler)
+	require.NoError(t, err)
+	require.Equal(t, "req", resp)
+}
+
+func TestMemoryBudgetDatabaseSizing(t *testing.T) {
+	stOpts := store.DefaultOptions()
+	txSize := uint64(stOpts.MaxTxEntries * (stOpts.MaxKeyLen + txEntryOverhead))
+
+	t.Run("large budget keeps configured settings", func(t *testing.T) {
+		mb := newMemoryBudget(1 << 40)
+
+		dbOpts := database.DefaultOption().WithStoreOptions(store.DefaultOptions())
+		mb.applyTo(dbOpts, 2)
+
+		stOpts := dbOpts.GetStoreOptions()
+		require.Equal(t, store.DefaultMaxConcurrency, stOpts.MaxConcurrency)
+		require.Equal(t, store.DefaultIndexOptions().CacheSize, stOpts.IndexOpts.CacheSize)
+		require.Equal(t, store.DefaultTxLogCacheSize, stOpts.TxLogCacheSize)
+		require.Equal(t, sql.DefaultDistinctLimit, dbOpts.GetSQLDistinctLimit())
+	})
+
+	t.Run("buffers are reduced to fit into the budget", func(t *testing.T) {
+		limit := uint64(256 << 20)
+		mb := newMemoryBudget(limit)
+
+		dbOpts := database.DefaultOption().WithStoreOptions(store.DefaultOptions().WithVLogCacheSize(1 << 20))
+		mb.applyTo(dbOpts, 4)
+
+		share := limit / 4
+		stOpts := dbOpts.GetStoreOptions()
+
+		require.Equal(t, int(share*memoryBudgetCommitShare/100/txSize)-1, stOpts.MaxConcurrency)
+		require.Less(t, stOpts.MaxConcurrency, store.DefaultMaxConcurrency)
+
+		require.Less(t, stOpts.IndexOpts.CacheSize, store.DefaultIndexOptions().CacheSize)
+		require.LessOrEqual(t, uint64(stOpts.IndexOpts.CacheSize*stOpts.IndexOpts.MaxNodeSize), share*memoryBudgetCacheShare/100/2)
+
+		require.LessOrEqual(t, uint64(stOpts.TxLogCacheSize)*txSize, share*memoryBudgetCacheShare/100)
+		require.LessOrEqual(t, uint64(stOpts.VLogCacheSize*stOpts.MaxValueLen), share*memoryBudgetCacheShare/100)
+
+		require.Less(t, dbOpts.GetSQLDistinctLimit(), sql.DefaultDistinctLimit)
+		require.LessOrEqual(t, uint64(dbOpts.GetSQLDistinctLimit()*stOpts.MaxValueLen), share*memoryBudgetSQLShare/100)
+	})
+
+	t.Run("tiny budget leaves minimal buffers", func(t *testing.T) {
+		mb := newMemoryBudget(1)
+
+		dbOpts := database.DefaultOption().WithStoreOptions(store.DefaultOptions())
+		mb.applyTo(dbOpts, 0)
+
+		stOpts := dbOpts.GetStoreOptions()
+		require.Equal(t, 1, stOpts.MaxConcurrency)
+		require.Equal(t, 1, stOpts.IndexOpts.CacheSize)
+		require.Equal(t, 1, stOpts.TxLogCacheSize)
+		require.Zero(t, stOpts.VLogCacheSize)
+		require.Equal(t, 1, dbOpts.GetSQLDistinctLimit())
+	})
+}
+
+func TestServerWithMemoryBudget(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithPort(0).
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithMaxMemory(64 << 20).
+		WithDir(t.TempDir())
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	require.True(t, s.memoryBudget.enabled())
+
+	defaultDB := s.dbList.GetByIndex(defaultDbIndex)
+	require.Less(t, defaultDB.GetOptions().GetStoreOptions().MaxConcurrency, store.DefaultMaxConcurrency)
+
+	serverOptions = DefaultOptions().
+		WithMetricsServer(false).
+		WithMaxMemory(-1).
+		WithDir(t.TempDir())
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
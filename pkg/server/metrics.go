@@ -53,8 +53,15 @@ type MetricsCollection struct {
 	computeDBEntries func() map[string]float64
 	DBEntriesGauges  *prometheus.GaugeVec
 
+	computeActiveSessions func() map[string]float64
+	ActiveSessionsGauges  *prometheus.GaugeVec
+
 	RPCsPerClientCounters        *prometheus.CounterVec
 	LastMessageAtPerClientGauges *prometheus.GaugeVec
+
+	MemoryBudgetGauge         prometheus.Gauge
+	MemoryUsageGauge          prometheus.Gauge
+	MemoryShedRequestsCounter prometheus.Counter
 }
 
 var metricsNamespace = "immudb"
@@ -93,6 +100,11 @@ func (mc *MetricsCollection) WithComputeDBEntries(f func() map[string]float64) {
 	mc.computeDBEntries = f
 }
 
+// WithComputeActiveSessions ...
+func (mc *MetricsCollection) WithComputeActiveSessions(f func() map[string]float64) {
+	mc.computeActiveSessions = f
+}
+
 // UpdateDBMetrics ...
 func (mc *MetricsCollection) UpdateDBMetrics() {
 	if mc.computeDBSizes != nil {
@@ -105,6 +117,13 @@ func (mc *MetricsCollection) UpdateDBMetrics() {
 			mc.DBEntriesGauges.WithLabelValues(db).Set(nbEntries)
 		}
 	}
+	if mc.computeActiveSessions != nil {
+		// databases whose sessions were all closed must not keep reporting them
+		mc.ActiveSessionsGauges.Reset()
+		for db, nbSessions := range mc.computeActiveSessions() {
+			mc.ActiveSessionsGauges.WithLabelValues(db).Set(nbSessions)
+		}
+	}
 }
 
 // Metrics immudb Prometheus metrics collection
@@ -133,6 +152,14 @@ var Metrics = MetricsCollection{
 		},
 		[]string{"db"},
 	),
+	ActiveSessionsGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "active_sessions",
+			Help:      "Number of sessions currently opened against the database.",
+		},
+		[]string{"db"},
+	),
 	LastMessageAtPerClientGauges: promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: metricsNamespace,
@@ -141,10 +168,32 @@ var Metrics = MetricsCollection{
 		},
 		[]string{"ip"},
 	),
+	MemoryBudgetGauge: promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "memory_budget_bytes",
+			Help:      "Memory budget of the server process in bytes.",
+		},
+	),
+	MemoryUsageGauge: promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "memory_usage_bytes",
+			Help:      "Memory used by the server process in bytes, as accounted by the memory budget.",
+		},
+	),
+	MemoryShedRequestsCounter: promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "memory_shed_requests_total",
+			Help:      "Number of requests rejected because the memory budget was exceeded.",
+		},
+	),
 }
 
 // StartMetrics listens and servers the HTTP metrics server in a new goroutine.
 // The server is then returned and can be stopped using Close().
+// The published states of the databases are served by statesHandler, when not nil.
 func StartMetrics(
 	updateInterval time.Duration,
 	addr string,
@@ -152,11 +201,14 @@ func StartMetrics(
 	uptimeCounter func() float64,
 	computeDBSizes func() map[string]float64,
 	computeDBEntries func() map[string]float64,
+	computeActiveSessions func() map[string]float64,
+	statesHandler http.Handler,
 ) *http.Server {
 
 	Metrics.WithUptimeCounter(uptimeCounter)
 	Metrics.WithComputeDBSizes(computeDBSizes)
 	Metrics.WithComputeDBEntries(computeDBEntries)
+	Metrics.WithComputeActiveSessions(computeActiveSessions)
 
 	go func() {
 		Metrics.UpdateDBMetrics()
@@ -172,6 +224,10 @@ func StartMetrics(
 	mux.HandleFunc("/readyz", corsHandlerFunc(ImmudbHealthHandlerFunc()))
 	mux.HandleFunc("/livez", corsHandlerFunc(ImmudbHealthHandlerFunc()))
 	mux.HandleFunc("/version", corsHandlerFunc(ImmudbVersionHandlerFunc))
+	if statesHandler != nil {
+		mux.Handle(statePublicationPath, corsHandler(statesHandler))
+		mux.Handle(statePublicationPath+"/", corsHandler(statesHandler))
+	}
 	server := &http.Server{Addr: addr, Handler: mux}
 
 	go func() {
@@ -122,3 +122,17 @@ func (s *ImmuServer) metricFuncComputeDBEntries() (nbEntriesPerDB map[string]flo
 
 	return
 }
+
+func (s *ImmuServer) metricFuncComputeActiveSessions() map[string]float64 {
+	activeSessions := make(map[string]float64)
+
+	if s.SessManager == nil {
+		return activeSessions
+	}
+
+	for db, nbSessions := range s.SessManager.SessionCountPerDatabase() {
+		activeSessions[db] = float64(nbSessions)
+	}
+
+	return activeSessions
+}
@@ -25,8 +25,10 @@ import (
 	"testing"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/server/sessions"
 	"github.com/stretchr/testify/require"
 )
 
@@ -169,3 +171,24 @@ func TestMetricFuncComputeDBSizes(t *testing.T) {
 	s.sysDB = nil
 	s.metricFuncComputeDBSizes()
 }
+
+func TestMetricFuncComputeActiveSessions(t *testing.T) {
+	s := ImmuServer{}
+
+	require.Empty(t, s.metricFuncComputeActiveSessions())
+
+	sessManager, err := sessions.NewManager(sessions.DefaultOptions())
+	require.NoError(t, err)
+
+	s.SessManager = sessManager
+
+	db1 := dbMock{getNameF: func() string { return "db1" }}
+	db2 := dbMock{getNameF: func() string { return "db2" }}
+
+	for _, db := range []database.DB{db1, db1, db2} {
+		_, err = sessManager.NewSession(&auth.User{Username: "user"}, db)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, map[string]float64{"db1": 2, "db2": 1}, s.metricFuncComputeActiveSessions())
+}
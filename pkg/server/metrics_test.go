@@ -27,6 +27,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/peer"
@@ -40,6 +41,8 @@ func TestStartMetrics(t *testing.T) {
 		func() float64 { return 0 },
 		func() map[string]float64 { return make(map[string]float64) },
 		func() map[string]float64 { return make(map[string]float64) },
+		func() map[string]float64 { return make(map[string]float64) },
+		nil,
 	)
 	time.Sleep(200 * time.Millisecond)
 	defer server.Close()
@@ -59,6 +62,8 @@ func TestStartMetricsFail(t *testing.T) {
 		func() float64 { return 0 },
 		func() map[string]float64 { return make(map[string]float64) },
 		func() map[string]float64 { return make(map[string]float64) },
+		func() map[string]float64 { return make(map[string]float64) },
+		nil,
 	)
 	time.Sleep(200 * time.Millisecond)
 	defer server.Close()
@@ -123,6 +128,14 @@ func TestMetricsCollection_UpdateDBMetrics(t *testing.T) {
 			},
 			[]string{"db"},
 		),
+		ActiveSessionsGauges: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Name:      "active_sessions",
+				Help:      "Number of sessions currently opened against the database.",
+			},
+			[]string{"db"},
+		),
 	}
 
 	// update before injecting the funcs, to catch the fast-exit execution path
@@ -134,10 +147,23 @@ func TestMetricsCollection_UpdateDBMetrics(t *testing.T) {
 	mc.computeDBEntries = func() map[string]float64 {
 		return map[string]float64{"db1": 10, "db2": 20}
 	}
+	mc.computeActiveSessions = func() map[string]float64 {
+		return map[string]float64{"db1": 2}
+	}
 
 	// update after injecting the funcs, to catch the normal execution path
 	mc.UpdateDBMetrics()
 
+	require.Equal(t, 2.0, testutil.ToFloat64(mc.ActiveSessionsGauges.WithLabelValues("db1")))
+
+	// gauges of databases without sessions are dropped
+	mc.computeActiveSessions = func() map[string]float64 {
+		return map[string]float64{}
+	}
+	mc.UpdateDBMetrics()
+
+	require.Zero(t, testutil.CollectAndCount(mc.ActiveSessionsGauges))
+
 	assert.IsType(t, MetricsCollection{}, mc)
 }
 
@@ -22,7 +22,11 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/anchor"
 	"github.com/codenotary/immudb/pkg/server/sessions"
 
 	"github.com/codenotary/immudb/pkg/stream"
@@ -65,8 +69,25 @@ type Options struct {
 	TokenExpiryTimeMin   int
 	PgsqlServer          bool
 	PgsqlServerPort      int
+	RESPServer           bool
+	RESPServerPort       int
+	EtcdServer           bool
+	EtcdServerPort       int
 	ReplicationOptions   *ReplicationOptions
 	SessionsOptions      *sessions.Options
+	MaxMemory            int64
+	ArchiveDir           string
+	BackupKeyProvider    encryption.MasterKeyProvider `json:"-"`
+	MaxBackupRate        int64
+	MaxReplicationRate   int64
+	TimestampAuthorities []string
+	TimestampInterval    time.Duration
+	RetentionInterval    time.Duration
+	AnchoringOptions     *AnchoringOptions
+	AlertOptions         *alert.Options
+	StatePublication     *StatePublicationOptions
+	GlobalState          bool
+	Cosigners            []string
 }
 
 type RemoteStorageOptions struct {
@@ -77,6 +98,29 @@ type RemoteStorageOptions struct {
 	S3BucketName  string
 	S3Location    string
 	S3PathPrefix  string
+	S3CacheDir    string
+	S3CacheSize   int64
+}
+
+// AnchoringOptions sets where the digest of the state of the databases is published, anchoring
+// is disabled when neither OpenTimestamps calendars nor an Ethereum node are set
+type AnchoringOptions struct {
+	Schedule                string
+	OpenTimestampsCalendars []string
+	EthereumRPCURL          string
+	EthereumFrom            string
+	EthereumContract        string
+	EthereumMethod          string
+}
+
+// StatePublicationOptions sets how often the signed state of the databases is refreshed at the /states
+// endpoint of the metrics server and where else it's pushed to, publication is disabled unless enabled
+type StatePublicationOptions struct {
+	Enabled            bool
+	Interval           time.Duration
+	GitDir             string
+	GitPush            bool
+	TransparencyLogURL string
 }
 
 type ReplicationOptions struct {
@@ -117,7 +161,16 @@ func DefaultOptions() *Options {
 		TokenExpiryTimeMin:   1440,
 		PgsqlServer:          false,
 		PgsqlServerPort:      5432,
+		RESPServer:           false,
+		RESPServerPort:       6379,
+		EtcdServer:           false,
+		EtcdServerPort:       2379,
 		SessionsOptions:      sessions.DefaultOptions(),
+		TimestampInterval:    time.Hour,
+		RetentionInterval:    time.Hour,
+		AnchoringOptions:     DefaultAnchoringOptions(),
+		AlertOptions:         alert.DefaultOptions(),
+		StatePublication:     DefaultStatePublicationOptions(),
 	}
 }
 
@@ -127,6 +180,19 @@ func DefaultRemoteStorageOptions() *RemoteStorageOptions {
 	}
 }
 
+func DefaultAnchoringOptions() *AnchoringOptions {
+	return &AnchoringOptions{
+		Schedule:       "@daily",
+		EthereumMethod: anchor.DefaultEthereumMethod,
+	}
+}
+
+func DefaultStatePublicationOptions() *StatePublicationOptions {
+	return &StatePublicationOptions{
+		Interval: time.Minute,
+	}
+}
+
 // WithDir sets dir
 func (o *Options) WithDir(dir string) *Options {
 	o.Dir = dir
@@ -254,9 +320,55 @@ func (o *Options) String() string {
 	opts = append(opts, rightPad("Default database", o.defaultDBName))
 	opts = append(opts, rightPad("Maintenance mode", o.maintenance))
 	opts = append(opts, rightPad("Synced mode", o.synced))
+	if o.MaxMemory > 0 {
+		opts = append(opts, rightPad("Max memory", o.MaxMemory))
+	}
 	if o.SigningKey != "" {
 		opts = append(opts, rightPad("Signing key", o.SigningKey))
 	}
+	if o.ArchiveDir != "" {
+		opts = append(opts, rightPad("Archive dir", o.ArchiveDir))
+	}
+	if o.BackupKeyProvider != nil {
+		opts = append(opts, rightPad("Backup encryption", true))
+	}
+	if o.MaxBackupRate > 0 {
+		opts = append(opts, rightPad("Max backup rate", o.MaxBackupRate))
+	}
+	if o.MaxReplicationRate > 0 {
+		opts = append(opts, rightPad("Max replication rate", o.MaxReplicationRate))
+	}
+	if len(o.TimestampAuthorities) > 0 {
+		opts = append(opts, rightPad("Timestamping", fmt.Sprintf("every %s by %s", o.TimestampInterval, strings.Join(o.TimestampAuthorities, ", "))))
+	}
+	if len(o.Cosigners) > 0 {
+		opts = append(opts, rightPad("Cosigners", len(o.Cosigners)))
+	}
+	if o.GlobalState {
+		opts = append(opts, rightPad("Global state", true))
+	}
+	if o.StatePublication.enabled() {
+		opts = append(opts, rightPad("State publication", fmt.Sprintf("every %s at %s", o.StatePublication.Interval, statePublicationPath)))
+		if o.StatePublication.GitDir != "" {
+			opts = append(opts, rightPad("   git", o.StatePublication.GitDir))
+		}
+		if o.StatePublication.TransparencyLogURL != "" {
+			opts = append(opts, rightPad("   log", o.StatePublication.TransparencyLogURL))
+		}
+	}
+	if o.AnchoringOptions.enabled() {
+		opts = append(opts, rightPad("Anchoring", o.AnchoringOptions.Schedule))
+		for _, calendar := range o.AnchoringOptions.OpenTimestampsCalendars {
+			opts = append(opts, rightPad("   opentimestamps", calendar))
+		}
+		if o.AnchoringOptions.EthereumRPCURL != "" {
+			opts = append(opts, rightPad("   ethereum", o.AnchoringOptions.EthereumRPCURL))
+			opts = append(opts, rightPad("   contract", o.AnchoringOptions.EthereumContract))
+		}
+	}
+	if o.AlertOptions.Enabled() {
+		opts = append(opts, rightPad("Alerts", strings.Join(o.AlertOptions.Channels(), ", ")))
+	}
 	if o.RemoteStorageOptions.S3Storage {
 		opts = append(opts, "S3 storage")
 		opts = append(opts, rightPad("   endpoint", o.RemoteStorageOptions.S3Endpoint))
@@ -265,6 +377,10 @@ func (o *Options) String() string {
 			opts = append(opts, rightPad("   location", o.RemoteStorageOptions.S3Location))
 		}
 		opts = append(opts, rightPad("   prefix", o.RemoteStorageOptions.S3PathPrefix))
+		if o.RemoteStorageOptions.S3CacheSize > 0 {
+			opts = append(opts, rightPad("   cache dir", o.RemoteStorageOptions.S3CacheDir))
+			opts = append(opts, rightPad("   cache size", o.RemoteStorageOptions.S3CacheSize))
+		}
 	}
 	if o.AdminPassword == auth.SysAdminPassword {
 		opts = append(opts, "----------------------------------------")
@@ -375,6 +491,30 @@ func (o *Options) WithPgsqlServerPort(port int) *Options {
 	return o
 }
 
+// WithRESPServer enable or disable the server speaking the protocol of Redis
+func (o *Options) WithRESPServer(enable bool) *Options {
+	o.RESPServer = enable
+	return o
+}
+
+// WithRESPServerPort sets the port of the server speaking the protocol of Redis
+func (o *Options) WithRESPServerPort(port int) *Options {
+	o.RESPServerPort = port
+	return o
+}
+
+// WithEtcdServer enable or disable the server compatible with the etcd v3 API
+func (o *Options) WithEtcdServer(enable bool) *Options {
+	o.EtcdServer = enable
+	return o
+}
+
+// WithEtcdServerPort sets the port of the server compatible with the etcd v3 API
+func (o *Options) WithEtcdServerPort(port int) *Options {
+	o.EtcdServerPort = port
+	return o
+}
+
 func (o *Options) WithRemoteStorageOptions(remoteStorageOptions *RemoteStorageOptions) *Options {
 	o.RemoteStorageOptions = remoteStorageOptions
 	return o
@@ -390,6 +530,91 @@ func (o *Options) WithSessionOptions(options *sessions.Options) *Options {
 	return o
 }
 
+// WithMaxMemory sets the memory budget in bytes of the server process (0 means unlimited).
+// Database buffers are sized to fit into it and requests are rejected while the budget is exceeded
+func (o *Options) WithMaxMemory(maxMemory int64) *Options {
+	o.MaxMemory = maxMemory
+	return o
+}
+
+// WithArchiveDir sets the folder where sealed segments of the transaction and value logs
+// of every database are archived, archiving is disabled when empty
+func (o *Options) WithArchiveDir(archiveDir string) *Options {
+	o.ArchiveDir = archiveDir
+	return o
+}
+
+// WithBackupKeyProvider sets the provider of the key used to encrypt the backups scheduled on the server,
+// backups are not encrypted when it's nil
+func (o *Options) WithBackupKeyProvider(provider encryption.MasterKeyProvider) *Options {
+	o.BackupKeyProvider = provider
+	return o
+}
+
+// WithMaxBackupRate sets the bytes per second read by backups and exports taken from the server,
+// shared by all of them (0 means unlimited)
+func (o *Options) WithMaxBackupRate(bytesPerSec int64) *Options {
+	o.MaxBackupRate = bytesPerSec
+	return o
+}
+
+// WithMaxReplicationRate sets the bytes per second of the transactions sent to replicas and
+// received by the replicas running on the server, shared by all of them (0 means unlimited)
+func (o *Options) WithMaxReplicationRate(bytesPerSec int64) *Options {
+	o.MaxReplicationRate = bytesPerSec
+	return o
+}
+
+// WithTimestampAuthorities sets the urls of the RFC 3161 Time Stamping Authorities the state of the databases
+// is periodically submitted to, timestamping is disabled when empty
+func (o *Options) WithTimestampAuthorities(urls []string) *Options {
+	o.TimestampAuthorities = urls
+	return o
+}
+
+// WithTimestampInterval sets how often the state of the databases is timestamped
+func (o *Options) WithTimestampInterval(interval time.Duration) *Options {
+	o.TimestampInterval = interval
+	return o
+}
+
+// WithRetentionInterval sets how often the retention policies of the databases are enforced
+func (o *Options) WithRetentionInterval(interval time.Duration) *Options {
+	o.RetentionInterval = interval
+	return o
+}
+
+// WithAnchoringOptions sets where and when the state of the databases is anchored
+func (o *Options) WithAnchoringOptions(anchoringOptions *AnchoringOptions) *Options {
+	o.AnchoringOptions = anchoringOptions
+	return o
+}
+
+// WithStatePublicationOptions sets how and where the signed state of the databases is published
+func (o *Options) WithStatePublicationOptions(statePublication *StatePublicationOptions) *Options {
+	o.StatePublication = statePublication
+	return o
+}
+
+// WithGlobalState sets whether the server serves the signed Merkle root aggregating the state of all its databases
+func (o *Options) WithGlobalState(globalState bool) *Options {
+	o.GlobalState = globalState
+	return o
+}
+
+// WithCosigners sets the paths to the public keys of the auditors allowed to cosign the state of the databases,
+// cosigning is disabled when empty
+func (o *Options) WithCosigners(publicKeyPaths []string) *Options {
+	o.Cosigners = publicKeyPaths
+	return o
+}
+
+// WithAlertOptions sets the channels alerts are delivered through when the verification of a database fails
+func (o *Options) WithAlertOptions(alertOptions *alert.Options) *Options {
+	o.AlertOptions = alertOptions
+	return o
+}
+
 // RemoteStorageOptions
 
 func (opts *RemoteStorageOptions) WithS3Storage(S3Storage bool) *RemoteStorageOptions {
@@ -427,6 +652,90 @@ func (opts *RemoteStorageOptions) WithS3PathPrefix(s3PathPrefix string) *RemoteS
 	return opts
 }
 
+func (opts *RemoteStorageOptions) WithS3CacheDir(s3CacheDir string) *RemoteStorageOptions {
+	opts.S3CacheDir = s3CacheDir
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithS3CacheSize(s3CacheSize int64) *RemoteStorageOptions {
+	opts.S3CacheSize = s3CacheSize
+	return opts
+}
+
+// AnchoringOptions
+
+func (opts *AnchoringOptions) enabled() bool {
+	return opts != nil && (len(opts.OpenTimestampsCalendars) > 0 || opts.EthereumRPCURL != "")
+}
+
+// WithSchedule sets the cron expression of the times the state of the databases is anchored at
+func (opts *AnchoringOptions) WithSchedule(schedule string) *AnchoringOptions {
+	opts.Schedule = schedule
+	return opts
+}
+
+func (opts *AnchoringOptions) WithOpenTimestampsCalendars(calendars []string) *AnchoringOptions {
+	opts.OpenTimestampsCalendars = calendars
+	return opts
+}
+
+// WithEthereumRPCURL sets the node transactions are sent through, the sender account must be unlocked on it
+func (opts *AnchoringOptions) WithEthereumRPCURL(rpcURL string) *AnchoringOptions {
+	opts.EthereumRPCURL = rpcURL
+	return opts
+}
+
+func (opts *AnchoringOptions) WithEthereumFrom(from string) *AnchoringOptions {
+	opts.EthereumFrom = from
+	return opts
+}
+
+func (opts *AnchoringOptions) WithEthereumContract(contract string) *AnchoringOptions {
+	opts.EthereumContract = contract
+	return opts
+}
+
+// WithEthereumMethod sets the signature of the contract method taking the digest as a bytes32 argument
+func (opts *AnchoringOptions) WithEthereumMethod(method string) *AnchoringOptions {
+	opts.EthereumMethod = method
+	return opts
+}
+
+// StatePublicationOptions
+
+func (opts *StatePublicationOptions) enabled() bool {
+	return opts != nil && opts.Enabled
+}
+
+func (opts *StatePublicationOptions) WithEnabled(enabled bool) *StatePublicationOptions {
+	opts.Enabled = enabled
+	return opts
+}
+
+// WithInterval sets how often the state of the databases is refreshed and pushed to the sinks
+func (opts *StatePublicationOptions) WithInterval(interval time.Duration) *StatePublicationOptions {
+	opts.Interval = interval
+	return opts
+}
+
+// WithGitDir sets the git working tree the state of each database is written to and committed in
+func (opts *StatePublicationOptions) WithGitDir(dir string) *StatePublicationOptions {
+	opts.GitDir = dir
+	return opts
+}
+
+// WithGitPush sets whether the commits of the states are pushed to the upstream of the git working tree
+func (opts *StatePublicationOptions) WithGitPush(push bool) *StatePublicationOptions {
+	opts.GitPush = push
+	return opts
+}
+
+// WithTransparencyLogURL sets the url the changed states are posted to as json
+func (opts *StatePublicationOptions) WithTransparencyLogURL(url string) *StatePublicationOptions {
+	opts.TransparencyLogURL = url
+	return opts
+}
+
 // ReplicationOptions
 
 func (opts *ReplicationOptions) WithMasterAddress(masterAddress string) *ReplicationOptions {
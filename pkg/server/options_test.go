@@ -19,7 +19,9 @@ package server
 import (
 	"crypto/tls"
 	"testing"
+	"time"
 
+	"github.com/codenotary/immudb/pkg/alert"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/stream"
 
@@ -50,7 +52,8 @@ func TestOptions(t *testing.T) {
 		op.WebBind() != "0.0.0.0:8080" ||
 		op.MetricsBind() != "0.0.0.0:9497" ||
 		op.PgsqlServer ||
-		op.PgsqlServerPort != 5432 {
+		op.PgsqlServerPort != 5432 ||
+		op.MaxMemory != 0 {
 		t.Errorf("database default options mismatch")
 	}
 }
@@ -69,7 +72,12 @@ func TestSetOptions(t *testing.T) {
 		WithWebServer(false).
 		WithTLS(tlsConfig).
 		WithPgsqlServer(true).
-		WithPgsqlServerPort(123456)
+		WithPgsqlServerPort(123456).
+		WithMaxMemory(1 << 30).
+		WithMaxBackupRate(1 << 20).
+		WithMaxReplicationRate(1 << 21).
+		WithTimestampAuthorities([]string{"http://tsa.local"}).
+		WithTimestampInterval(time.Minute)
 
 	if op.GetAuth() != false ||
 		op.Dir != "immudb_dir" ||
@@ -94,7 +102,12 @@ func TestSetOptions(t *testing.T) {
 		op.TLSConfig != tlsConfig ||
 		op.TokenExpiryTimeMin != 52 ||
 		!op.PgsqlServer ||
-		op.PgsqlServerPort != 123456 {
+		op.PgsqlServerPort != 123456 ||
+		op.MaxMemory != 1<<30 ||
+		op.MaxBackupRate != 1<<20 ||
+		op.MaxReplicationRate != 1<<21 ||
+		len(op.TimestampAuthorities) != 1 ||
+		op.TimestampInterval != time.Minute {
 		t.Errorf("database default options mismatch")
 	}
 }
@@ -165,3 +178,37 @@ Superadmin default credentials
 
 	assert.Equal(t, expected, op.String())
 }
+
+func TestAnchoringOptions(t *testing.T) {
+	opts := DefaultAnchoringOptions()
+	assert.False(t, opts.enabled())
+	assert.Equal(t, "@daily", opts.Schedule)
+
+	opts.WithSchedule("@hourly").
+		WithOpenTimestampsCalendars([]string{"https://a.pool.opentimestamps.org"}).
+		WithEthereumRPCURL("http://localhost:8545").
+		WithEthereumFrom("0x00000000000000000000000000000000000000aa").
+		WithEthereumContract("0x00000000000000000000000000000000000000bb").
+		WithEthereumMethod("notarize(bytes32)")
+
+	assert.True(t, opts.enabled())
+	assert.Equal(t, "@hourly", opts.Schedule)
+	assert.Equal(t, "notarize(bytes32)", opts.EthereumMethod)
+
+	s := DefaultOptions().WithAnchoringOptions(opts).String()
+	assert.Contains(t, s, "https://a.pool.opentimestamps.org")
+	assert.Contains(t, s, "http://localhost:8545")
+}
+
+func TestAlertOptions(t *testing.T) {
+	s := DefaultOptions().String()
+	assert.NotContains(t, s, "Alerts")
+
+	opts := alert.DefaultOptions().
+		WithWebhookURL("http://alerts.local").
+		WithPagerDutyRoutingKey("routing-key")
+
+	s = DefaultOptions().WithAlertOptions(opts).String()
+	assert.Contains(t, s, "webhook, pagerduty")
+	assert.NotContains(t, s, "routing-key")
+}
@@ -19,6 +19,7 @@ import (
 
 var (
 	ErrRemoteStorageDoesNotMatch = errors.New("remote storage does not match local files")
+	ErrArchiveUnsupported        = errors.New("archiving segments is unsupported when remote storage is used")
 )
 
 func (s *ImmuServer) createRemoteStorageInstance() (remotestorage.Storage, error) {
@@ -129,6 +130,12 @@ func (s *ImmuServer) storeOptionsForDB(name string, remoteStorage remotestorage.
 				string(filepath.Separator), "/",
 			)
 
+			if s.Options.RemoteStorageOptions.S3CacheDir != "" {
+				remoteAppOpts.
+					WithCachePath(filepath.Join(s.Options.RemoteStorageOptions.S3CacheDir, filepath.FromSlash(s3Path))).
+					WithCacheSize(s.Options.RemoteStorageOptions.S3CacheSize)
+			}
+
 			return remoteapp.Open(
 				filepath.Join(rootPath, subPath),
 				s3Path,
@@ -138,6 +145,8 @@ func (s *ImmuServer) storeOptionsForDB(name string, remoteStorage remotestorage.
 		}).
 			WithFileSize(1 << 20).       // Reduce file size for better cache granularity
 			WithCompactionDisabled(true) // Disable index compaction
+	} else if s.Options.ArchiveDir != "" {
+		stOpts.WithArchive(store.NewDirArchive(filepath.Join(s.Options.ArchiveDir, name), stOpts.FileMode))
 	}
 
 	return stOpts
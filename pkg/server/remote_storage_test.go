@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/remotestorage"
@@ -375,3 +376,15 @@ func TestRemoteStorageUsedForNewDB(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, exists)
 }
+
+func TestStoreOptionsForDBWithArchiveDir(t *testing.T) {
+	s := DefaultServer()
+	s.Options.WithArchiveDir("archive")
+
+	stOpts := s.storeOptionsForDB("db1", nil, store.DefaultOptions())
+	require.Equal(t, store.NewDirArchive(filepath.Join("archive", "db1"), stOpts.FileMode), stOpts.Archive)
+
+	// segments uploaded to the remote storage are not archived
+	stOpts = s.storeOptionsForDB("db1", memory.Open(), store.DefaultOptions())
+	require.Nil(t, stOpts.Archive)
+}
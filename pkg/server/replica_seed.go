@@ -0,0 +1,100 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// seedDatabase creates the database from backup files residing on the server, i.e. the ones taken with immuadmin
+// or by scheduled backups of the master, so a new replica only replicates the transactions committed after them
+func (s *ImmuServer) seedDatabase(dbOpts *dbOptions, files []string) (database.DB, error) {
+	if s.remoteStorage != nil {
+		// seeded data files are restored into local files
+		return nil, store.ErrBackupUnsupported
+	}
+
+	backups := make([]io.Reader, len(files))
+
+	for i, file := range files {
+		r, err := openSeedBackup(file, s.Options.BackupKeyProvider)
+		if err != nil {
+			return nil, fmt.Errorf("seed backup %s is not readable: %w", file, err)
+		}
+		defer r.Close()
+
+		backups[i] = r
+	}
+
+	return database.Seed(s.databaseOptionsFrom(dbOpts), backups, s.Logger)
+}
+
+// openSeedBackup returns a reader of the backup archive, decrypting and decompressing it when needed
+func openSeedBackup(path string, provider encryption.MasterKeyProvider) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(f)
+
+	if encryption.IsEncryptedStream(br) {
+		if provider == nil {
+			f.Close()
+			return nil, ErrBackupEncrypted
+		}
+
+		dr, err := encryption.NewStreamReader(br, provider)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		br = bufio.NewReader(dr)
+	}
+
+	magic, err := br.Peek(2)
+	if err != nil || !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return &seedBackupReader{Reader: br, f: f}, nil
+	}
+
+	gr, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &seedBackupReader{Reader: gr, f: f}, nil
+}
+
+type seedBackupReader struct {
+	io.Reader
+	f *os.File
+}
+
+func (r *seedBackupReader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func writeSeedBackup(t *testing.T, db database.DB, path string, since *store.BackupManifest) *store.BackupManifest {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	manifest, err := db.Backup(f, since)
+	require.NoError(t, err)
+
+	return manifest
+}
+
+func TestServerSeedReplica(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_seed_replica")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	defaultDB, err := s.dbList.GetByName(DefaultDBName)
+	require.NoError(t, err)
+
+	_, err = defaultDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	fullBackup := filepath.Join(dir, "full.backup.tar")
+	manifest := writeSeedBackup(t, defaultDB, fullBackup, nil)
+
+	_, err = defaultDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+
+	incrementalBackup := filepath.Join(dir, "incremental.backup.tar")
+	writeSeedBackup(t, defaultDB, incrementalBackup, manifest)
+
+	t.Run("seed backups are only accepted by new replicas", func(t *testing.T) {
+		_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{
+			DatabaseName: "seeded",
+			SeedBackups:  []string{fullBackup},
+		})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "replica"})
+		require.NoError(t, err)
+
+		_, err = s.UpdateDatabase(ctx, &schema.DatabaseSettings{
+			DatabaseName: "replica",
+			Replica:      true,
+			SeedBackups:  []string{fullBackup},
+		})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("seeding fails without leaving the database behind", func(t *testing.T) {
+		_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{
+			DatabaseName: "seeded",
+			Replica:      true,
+			SeedBackups:  []string{filepath.Join(dir, "missing.backup.tar")},
+		})
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{
+			DatabaseName: "seeded",
+			Replica:      true,
+			SeedBackups:  []string{incrementalBackup},
+		})
+		require.ErrorIs(t, err, store.ErrInvalidBackup)
+
+		require.Less(t, s.dbList.GetId("seeded"), int64(0))
+
+		_, err = os.Stat(filepath.Join(serverOptions.Dir, "seeded"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("replica seeded from a full backup and the incremental one taken after it", func(t *testing.T) {
+		_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{
+			DatabaseName: "seeded",
+			Replica:      true,
+			SeedBackups:  []string{fullBackup, incrementalBackup},
+		})
+		require.NoError(t, err)
+
+		seededDB, err := s.dbList.GetByName("seeded")
+		require.NoError(t, err)
+		require.True(t, seededDB.IsReplica())
+
+		expectedState, err := defaultDB.CurrentState()
+		require.NoError(t, err)
+
+		state, err := seededDB.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, expectedState.TxId, state.TxId)
+		require.Equal(t, expectedState.TxHash, state.TxHash)
+
+		err = seededDB.WaitForIndexingUpto(state.TxId, nil)
+		require.NoError(t, err)
+
+		entry, err := seededDB.Get(&schema.KeyRequest{Key: []byte("key2")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value2"), entry.Value)
+	})
+}
+
+func TestOpenEncryptedSeedBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_encrypted_seed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "backups")
+
+	provider, err := encryption.NewPassphraseKeyProvider("backup passphrase")
+	require.NoError(t, err)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithBackupKeyProvider(provider)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	at := time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC)
+
+	err = s.runScheduledBackup(&backupSchedule{Database: DefaultDBName, Target: target}, at)
+	require.NoError(t, err)
+
+	backup := filepath.Join(target, DefaultDBName+"_2022-03-15_03-00-00.backup.tar.gz.enc")
+
+	_, err = openSeedBackup(backup, nil)
+	require.ErrorIs(t, err, ErrBackupEncrypted)
+
+	r, err := openSeedBackup(backup, provider)
+	require.NoError(t, err)
+	defer r.Close()
+
+	manifest, err := store.ReadBackupManifest(r)
+	require.NoError(t, err)
+	require.False(t, manifest.IsIncremental())
+}
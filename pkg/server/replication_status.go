@@ -0,0 +1,112 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/replication"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+func (s *ImmuServer) replicatorFor(db string) *replication.TxReplicator {
+	s.replicationMutex.Lock()
+	defer s.replicationMutex.Unlock()
+
+	return s.replicators[db]
+}
+
+// replicationOptionsOf returns the options of a database, the ones of the default database are not stored
+// but taken from the replication options of the server
+func (s *ImmuServer) replicationOptionsOf(db string) (*dbOptions, error) {
+	if db != s.Options.GetDefaultDBName() {
+		return s.loadDBOptions(db, false)
+	}
+
+	dbOpts := s.defaultDBOptions(db)
+
+	if dbOpts.Replica {
+		dbOpts.MasterDatabase = db
+		dbOpts.MasterAddress = s.Options.ReplicationOptions.MasterAddress
+		dbOpts.MasterPort = s.Options.ReplicationOptions.MasterPort
+	}
+
+	return dbOpts, nil
+}
+
+// ReplicationStatus returns the role of each database along with the progress of the replication of replicas
+func (s *ImmuServer) ReplicationStatus(ctx context.Context, _ *empty.Empty) (*schema.ReplicationStatusList, error) {
+	s.Logger.Debugf("replicationstatus")
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.ReplicationStatusList{}
+
+	for i := 0; i < s.dbList.Length(); i++ {
+		db := s.dbList.GetByIndex(int64(i))
+		if db.GetName() == SystemDBName {
+			continue
+		}
+
+		dbOpts, err := s.replicationOptionsOf(db.GetName())
+		if err != nil {
+			return nil, err
+		}
+
+		st := &schema.ReplicationStatus{
+			Database: db.GetName(),
+			Replica:  dbOpts.Replica,
+		}
+
+		state, err := db.CurrentState()
+		if err != nil {
+			return nil, err
+		}
+		st.TxId = state.TxId
+
+		if dbOpts.Replica {
+			st.MasterDatabase = dbOpts.MasterDatabase
+			st.MasterAddress = dbOpts.MasterAddress
+			st.MasterPort = uint32(dbOpts.MasterPort)
+		}
+
+		if replicator := s.replicatorFor(db.GetName()); replicator != nil {
+			rst := replicator.Status(ctx)
+
+			st.Running = rst.Running
+			st.Connected = rst.Connected
+			st.MasterTxId = rst.MasterTx
+
+			if rst.MasterTx > st.TxId {
+				st.Lag = rst.MasterTx - st.TxId
+			}
+
+			if rst.LastError != nil {
+				st.LastError = rst.LastError.Error()
+				st.LastErrorAt = rst.LastErrorAt.Unix()
+			}
+		}
+
+		res.Statuses = append(res.Statuses, st)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,585 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// retentionRule is a rule of a retention policy as stored in the system database,
+// EnforcedUptoTx is the last transaction whose values have been checked against the rule
+type retentionRule struct {
+	Prefix         []byte `json:"prefix"`
+	ErasableAfter  int64  `json:"erasableAfter"`
+	EnforcedUptoTx uint64 `json:"enforcedUptoTx"`
+}
+
+// retentionPolicy is the set of retention rules of a database as stored in the system database
+type retentionPolicy struct {
+	Database string          `json:"database"`
+	Rules    []retentionRule `json:"rules"`
+
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// retentionEnforcement is the record of the enforcement of a retention rule as stored in the system database
+type retentionEnforcement struct {
+	Database      string    `json:"database"`
+	Prefix        []byte    `json:"prefix"`
+	ErasableAfter int64     `json:"erasableAfter"`
+	FromTx        uint64    `json:"fromTx"`
+	ToTx          uint64    `json:"toTx"`
+	Erased        int       `json:"erased"`
+	Error         string    `json:"error,omitempty"`
+	EnforcedAt    time.Time `json:"enforcedAt"`
+}
+
+type enforcedPolicy struct {
+	retentionPolicy
+
+	lastRunAt time.Time
+	lastError error
+}
+
+// retentionEnforcer periodically erases the values which are due according to the retention policies.
+// Transactions already checked against a rule are not checked again, so each run only goes
+// through the transactions committed since the previous one
+type retentionEnforcer struct {
+	policies map[string]*enforcedPolicy
+	mutex    sync.Mutex
+
+	interval time.Duration
+
+	now     func() time.Time
+	enforce func(policy *retentionPolicy, rule *retentionRule, now time.Time) (uint64, error)
+	store   func(policy *retentionPolicy) error
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func newRetentionEnforcer(
+	interval time.Duration,
+	enforce func(policy *retentionPolicy, rule *retentionRule, now time.Time) (uint64, error),
+	store func(policy *retentionPolicy) error,
+) *retentionEnforcer {
+	return &retentionEnforcer{
+		policies: make(map[string]*enforcedPolicy),
+		interval: interval,
+		now:      time.Now,
+		enforce:  enforce,
+		store:    store,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// set adds or replaces the policy of a database and stores it. Rules already enforced on the same prefix
+// keep their progress, values erased under a previous rule are not restored
+func (re *retentionEnforcer) set(policy *retentionPolicy) error {
+	re.mutex.Lock()
+	defer re.mutex.Unlock()
+
+	p := &enforcedPolicy{retentionPolicy: *policy}
+	p.Rules = make([]retentionRule, len(policy.Rules))
+	copy(p.Rules, policy.Rules)
+
+	if prev, ok := re.policies[policy.Database]; ok {
+		p.lastRunAt = prev.lastRunAt
+		p.lastError = prev.lastError
+
+		for i := range p.Rules {
+			for _, prevRule := range prev.Rules {
+				if bytes.Equal(p.Rules[i].Prefix, prevRule.Prefix) {
+					p.Rules[i].EnforcedUptoTx = prevRule.EnforcedUptoTx
+				}
+			}
+		}
+	}
+
+	if re.store != nil {
+		err := re.store(&p.retentionPolicy)
+		if err != nil {
+			return err
+		}
+	}
+
+	re.policies[policy.Database] = p
+
+	return nil
+}
+
+// load registers a policy as stored in the system database
+func (re *retentionEnforcer) load(policy *retentionPolicy) {
+	re.mutex.Lock()
+	defer re.mutex.Unlock()
+
+	p := &enforcedPolicy{retentionPolicy: *policy}
+	p.Rules = make([]retentionRule, len(policy.Rules))
+	copy(p.Rules, policy.Rules)
+
+	re.policies[policy.Database] = p
+}
+
+func (re *retentionEnforcer) remove(database string) bool {
+	re.mutex.Lock()
+	defer re.mutex.Unlock()
+
+	_, ok := re.policies[database]
+	delete(re.policies, database)
+
+	return ok
+}
+
+// list returns a copy of the policies sorted by database name
+func (re *retentionEnforcer) list() []enforcedPolicy {
+	re.mutex.Lock()
+	defer re.mutex.Unlock()
+
+	policies := make([]enforcedPolicy, 0, len(re.policies))
+	for _, p := range re.policies {
+		cp := *p
+		cp.Rules = make([]retentionRule, len(p.Rules))
+		copy(cp.Rules, p.Rules)
+
+		policies = append(policies, cp)
+	}
+
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Database < policies[j].Database })
+
+	return policies
+}
+
+// checkpoint records the progress of a rule, the policy may have been replaced or removed in the meantime
+func (re *retentionEnforcer) checkpoint(database string, prefix []byte, enforcedUptoTx uint64) error {
+	re.mutex.Lock()
+	defer re.mutex.Unlock()
+
+	p, ok := re.policies[database]
+	if !ok {
+		return nil
+	}
+
+	for i := range p.Rules {
+		if bytes.Equal(p.Rules[i].Prefix, prefix) && p.Rules[i].EnforcedUptoTx < enforcedUptoTx {
+			p.Rules[i].EnforcedUptoTx = enforcedUptoTx
+
+			if re.store != nil {
+				return re.store(&p.retentionPolicy)
+			}
+		}
+	}
+
+	return nil
+}
+
+// enforceAll enforces every rule of every policy, failed rules are enforced again on the next run
+func (re *retentionEnforcer) enforceAll() {
+	now := re.now()
+
+	for _, p := range re.list() {
+		var lastErr error
+
+		for _, rule := range p.Rules {
+			select {
+			case <-re.done:
+				return
+			default:
+			}
+
+			enforcedUptoTx, err := re.enforce(&p.retentionPolicy, &rule, now)
+			if err != nil {
+				lastErr = err
+			}
+
+			err = re.checkpoint(p.Database, rule.Prefix, enforcedUptoTx)
+			if err != nil {
+				lastErr = err
+			}
+		}
+
+		re.mutex.Lock()
+		if curr, ok := re.policies[p.Database]; ok {
+			curr.lastRunAt = now
+			curr.lastError = lastErr
+		}
+		re.mutex.Unlock()
+	}
+}
+
+func (re *retentionEnforcer) start() {
+	if re == nil {
+		return
+	}
+
+	re.startOnce.Do(func() {
+		go func() {
+			defer close(re.stopped)
+
+			ticker := time.NewTicker(re.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-re.done:
+					return
+				case <-ticker.C:
+				}
+
+				re.enforceAll()
+			}
+		}()
+	})
+}
+
+// stop waits for the enforcement in progress, if any, to be completed
+func (re *retentionEnforcer) stop() {
+	if re == nil {
+		return
+	}
+
+	re.stopOnce.Do(func() {
+		close(re.done)
+
+		started := true
+		re.startOnce.Do(func() { started = false })
+
+		if started {
+			<-re.stopped
+		}
+	})
+}
+
+func retentionPolicyKey(database string) []byte {
+	key := make([]byte, 1+len(database))
+	key[0] = KeyPrefixRetentionPolicy
+	copy(key[1:], database)
+	return key
+}
+
+// retentionEnforcementKey sorts the enforcements of a database by time, i.e. <prefix><db_name>0x00<enforced_at><rule_prefix>
+func retentionEnforcementKey(e *retentionEnforcement) []byte {
+	key := make([]byte, 1+len(e.Database)+1+8+len(e.Prefix))
+	key[0] = KeyPrefixRetentionEnforcement
+	i := 1 + copy(key[1:], e.Database)
+	key[i] = 0
+	binary.BigEndian.PutUint64(key[i+1:], uint64(e.EnforcedAt.UnixNano()))
+	copy(key[i+9:], e.Prefix)
+	return key
+}
+
+func (s *ImmuServer) storeRetentionPolicy(policy *retentionPolicy) error {
+	serializedPolicy, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: retentionPolicyKey(policy.Database), Value: serializedPolicy}}})
+	return err
+}
+
+// loadRetentionPolicies registers into the enforcer the policies stored in the system database
+func (s *ImmuServer) loadRetentionPolicies() error {
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: []byte{KeyPrefixRetentionPolicy},
+		NoWait: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries.Entries {
+		var policy retentionPolicy
+
+		err = json.Unmarshal(e.Value, &policy)
+		if err != nil {
+			return err
+		}
+
+		s.retentionEnforcer.load(&policy)
+	}
+
+	return nil
+}
+
+// enforceRetentionRule erases the values of the database which are due according to the rule and records
+// the enforcement in the system database. The last transaction checked against the rule is returned
+func (s *ImmuServer) enforceRetentionRule(policy *retentionPolicy, rule *retentionRule, now time.Time) (uint64, error) {
+	dbID := s.dbList.GetId(policy.Database)
+	if dbID < 0 {
+		return rule.EnforcedUptoTx, fmt.Errorf("database '%s' does not exist", policy.Database)
+	}
+
+	db := s.dbList.GetByIndex(dbID)
+
+	if db.IsReplica() {
+		// replicas apply the erasures made on their primary database
+		return rule.EnforcedUptoTx, nil
+	}
+
+	if db.RetainUntil().After(now) {
+		// databases in compliance mode are only erased once their retention date is reached
+		return rule.EnforcedUptoTx, nil
+	}
+
+	erasableAfter := time.Duration(rule.ErasableAfter) * time.Second
+	reason := fmt.Sprintf("retention policy: values under prefix '%s' are erasable after %s", rule.Prefix, erasableAfter)
+
+	res, err := db.EnforceRetention(rule.Prefix, rule.EnforcedUptoTx, now.Add(-erasableAfter), policy.CreatedBy, reason)
+	if res == nil {
+		s.Logger.Errorf("unable to enforce retention policy of database '%s' on prefix '%s': %v", policy.Database, rule.Prefix, err)
+		return rule.EnforcedUptoTx, err
+	}
+
+	if err == nil && res.ToTx < res.FromTx && res.Erased == 0 {
+		// no transaction became due since the last run, or the due ones are still under a legal hold
+		return res.ToTx, nil
+	}
+
+	if res.Held > 0 {
+		s.Logger.Infof("retention policy of database '%s' on prefix '%s' kept %d values under legal hold", policy.Database, rule.Prefix, res.Held)
+	}
+
+	enforcement := &retentionEnforcement{
+		Database:      policy.Database,
+		Prefix:        rule.Prefix,
+		ErasableAfter: rule.ErasableAfter,
+		FromTx:        res.FromTx,
+		ToTx:          res.ToTx,
+		Erased:        res.Erased,
+		EnforcedAt:    now,
+	}
+
+	if err != nil {
+		enforcement.Error = err.Error()
+		s.Logger.Errorf("retention policy of database '%s' on prefix '%s' failed after erasing %d values: %v", policy.Database, rule.Prefix, res.Erased, err)
+	} else {
+		s.Logger.Infof("retention policy of database '%s' on prefix '%s' enforced on txs %d-%d, %d values erased", policy.Database, rule.Prefix, res.FromTx, res.ToTx, res.Erased)
+	}
+
+	serializedEnforcement, serr := json.Marshal(enforcement)
+	if serr == nil {
+		_, serr = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: retentionEnforcementKey(enforcement), Value: serializedEnforcement}}})
+	}
+	if serr != nil {
+		// the progress is not recorded so the transactions are checked again on the next run
+		s.Logger.Errorf("unable to record the enforcement of the retention policy of database '%s': %v", policy.Database, serr)
+		return rule.EnforcedUptoTx, serr
+	}
+
+	return res.ToTx, err
+}
+
+// checkSysAdminAccess returns the logged in user when it's the system admin, i.e. to manage retention policies or generate audit reports
+func (s *ImmuServer) checkSysAdminAccess(ctx context.Context) (string, error) {
+	if !s.Options.GetAuth() {
+		return "", fmt.Errorf("this command is available only with authentication on")
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not get loggedin user data")
+	}
+
+	if !user.IsSysAdmin {
+		return "", fmt.Errorf("logged In user does not have permissions for this operation")
+	}
+
+	return user.Username, nil
+}
+
+// SetRetentionPolicy adds or replaces the retention policy of a database. The values of the keys under
+// the prefix of a rule are erased once they are older than the rule allows, transaction headers and
+// digests are kept forever so proofs still verify
+func (s *ImmuServer) SetRetentionPolicy(ctx context.Context, req *schema.RetentionPolicy) (*empty.Empty, error) {
+	s.Logger.Debugf("setretentionpolicy")
+
+	if req == nil || len(req.Rules) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	username, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Database == SystemDBName {
+		return nil, ErrReservedDatabase
+	}
+
+	if s.dbList.GetId(req.Database) < 0 {
+		return nil, fmt.Errorf("database '%s' does not exist", req.Database)
+	}
+
+	policy := &retentionPolicy{
+		Database:  req.Database,
+		CreatedBy: username,
+		CreatedAt: time.Now(),
+	}
+
+	for i, rule := range req.Rules {
+		if rule == nil || rule.ErasableAfter <= 0 {
+			return nil, fmt.Errorf("%w: rules must set when values are erasable", ErrIllegalArguments)
+		}
+
+		for _, prev := range req.Rules[:i] {
+			if bytes.Equal(prev.Prefix, rule.Prefix) {
+				return nil, fmt.Errorf("%w: duplicated rule on prefix '%s'", ErrIllegalArguments, rule.Prefix)
+			}
+		}
+
+		policy.Rules = append(policy.Rules, retentionRule{
+			Prefix:        rule.Prefix,
+			ErasableAfter: rule.ErasableAfter,
+		})
+	}
+
+	err = s.retentionEnforcer.set(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("retention policy of database '%s' set with %d rules", policy.Database, len(policy.Rules))
+
+	return &empty.Empty{}, nil
+}
+
+// DeleteRetentionPolicy stops enforcing the retention policy of a database, erased values are not restored
+func (s *ImmuServer) DeleteRetentionPolicy(ctx context.Context, req *schema.Database) (*empty.Empty, error) {
+	s.Logger.Debugf("deleteretentionpolicy")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.retentionEnforcer.remove(req.DatabaseName) {
+		return nil, ErrRetentionPolicyNotFound
+	}
+
+	_, err = s.sysDB.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{retentionPolicyKey(req.DatabaseName)}})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("retention policy of database '%s' removed", req.DatabaseName)
+
+	return &empty.Empty{}, nil
+}
+
+// ListRetentionPolicies returns the retention policies along with the progress and outcome of their enforcement
+func (s *ImmuServer) ListRetentionPolicies(ctx context.Context, _ *empty.Empty) (*schema.RetentionPolicyList, error) {
+	s.Logger.Debugf("listretentionpolicies")
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.RetentionPolicyList{}
+
+	for _, p := range s.retentionEnforcer.list() {
+		policy := &schema.RetentionPolicy{Database: p.Database}
+
+		for _, rule := range p.Rules {
+			policy.Rules = append(policy.Rules, &schema.RetentionRule{
+				Prefix:         rule.Prefix,
+				ErasableAfter:  rule.ErasableAfter,
+				EnforcedUptoTx: rule.EnforcedUptoTx,
+			})
+		}
+
+		if !p.lastRunAt.IsZero() {
+			policy.LastRunAt = p.lastRunAt.Unix()
+		}
+		if p.lastError != nil {
+			policy.LastError = p.lastError.Error()
+		}
+
+		res.Policies = append(res.Policies, policy)
+	}
+
+	return res, nil
+}
+
+// ListRetentionEnforcements returns the enforcements of the retention policy of a database recorded
+// in the system database, oldest first
+func (s *ImmuServer) ListRetentionEnforcements(ctx context.Context, req *schema.Database) (*schema.RetentionEnforcementList, error) {
+	s.Logger.Debugf("listretentionenforcements")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, 1+len(req.DatabaseName)+1)
+	prefix[0] = KeyPrefixRetentionEnforcement
+	copy(prefix[1:], req.DatabaseName)
+
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: prefix,
+		NoWait: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.RetentionEnforcementList{}
+
+	for _, e := range entries.Entries {
+		var enforcement retentionEnforcement
+
+		err = json.Unmarshal(e.Value, &enforcement)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Enforcements = append(res.Enforcements, &schema.RetentionEnforcement{
+			Database:      enforcement.Database,
+			Prefix:        enforcement.Prefix,
+			ErasableAfter: enforcement.ErasableAfter,
+			FromTx:        enforcement.FromTx,
+			ToTx:          enforcement.ToTx,
+			Erased:        uint64(enforcement.Erased),
+			Error:         enforcement.Error,
+			EnforcedAt:    enforcement.EnforcedAt.Unix(),
+		})
+	}
+
+	return res, nil
+}
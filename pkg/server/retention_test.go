@@ -0,0 +1,283 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRetentionEnforcer(t *testing.T) {
+	now := time.Date(2022, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	var enforced []string
+	stored := make(map[string]retentionPolicy)
+
+	re := newRetentionEnforcer(
+		time.Hour,
+		func(policy *retentionPolicy, rule *retentionRule, at time.Time) (uint64, error) {
+			require.Equal(t, now, at)
+
+			enforced = append(enforced, policy.Database+":"+string(rule.Prefix))
+			if policy.Database == "db2" {
+				return rule.EnforcedUptoTx, errors.New("disk full")
+			}
+			return rule.EnforcedUptoTx + 10, nil
+		},
+		func(policy *retentionPolicy) error {
+			stored[policy.Database] = *policy
+			return nil
+		},
+	)
+	re.now = func() time.Time { return now }
+
+	re.enforceAll()
+	require.Empty(t, enforced)
+
+	err := re.set(&retentionPolicy{Database: "db1", Rules: []retentionRule{{Prefix: []byte("a/"), ErasableAfter: 60}, {Prefix: []byte("b/"), ErasableAfter: 60}}})
+	require.NoError(t, err)
+
+	err = re.set(&retentionPolicy{Database: "db2", Rules: []retentionRule{{Prefix: []byte("c/"), ErasableAfter: 60}}})
+	require.NoError(t, err)
+
+	re.enforceAll()
+	require.Equal(t, []string{"db1:a/", "db1:b/", "db2:c/"}, enforced)
+
+	policies := re.list()
+	require.Len(t, policies, 2)
+	require.Equal(t, uint64(10), policies[0].Rules[0].EnforcedUptoTx)
+	require.Equal(t, uint64(10), policies[0].Rules[1].EnforcedUptoTx)
+	require.Equal(t, now, policies[0].lastRunAt)
+	require.NoError(t, policies[0].lastError)
+	require.Zero(t, policies[1].Rules[0].EnforcedUptoTx)
+	require.EqualError(t, policies[1].lastError, "disk full")
+
+	// the progress is stored along with the policy
+	require.Equal(t, uint64(10), stored["db1"].Rules[1].EnforcedUptoTx)
+
+	// rules on the same prefix keep their progress when the policy is replaced
+	err = re.set(&retentionPolicy{Database: "db1", Rules: []retentionRule{{Prefix: []byte("b/"), ErasableAfter: 120}, {Prefix: []byte("d/"), ErasableAfter: 60}}})
+	require.NoError(t, err)
+
+	policies = re.list()
+	require.Equal(t, uint64(10), policies[0].Rules[0].EnforcedUptoTx)
+	require.Equal(t, int64(120), policies[0].Rules[0].ErasableAfter)
+	require.Zero(t, policies[0].Rules[1].EnforcedUptoTx)
+	require.Equal(t, now, policies[0].lastRunAt)
+	require.Len(t, stored["db1"].Rules, 2)
+
+	require.True(t, re.remove("db2"))
+	require.False(t, re.remove("db2"))
+	require.Len(t, re.list(), 1)
+
+	// checkpoints of removed policies are discarded
+	err = re.checkpoint("db2", []byte("c/"), 20)
+	require.NoError(t, err)
+	require.Len(t, re.list(), 1)
+}
+
+func TestRetentionEnforcerStartStop(t *testing.T) {
+	var re *retentionEnforcer
+	re.start()
+	re.stop()
+
+	re = newRetentionEnforcer(time.Hour, nil, nil)
+	re.stop()
+	re.start()
+
+	enforced := make(chan struct{}, 1)
+
+	re = newRetentionEnforcer(
+		10*time.Millisecond,
+		func(policy *retentionPolicy, rule *retentionRule, now time.Time) (uint64, error) {
+			select {
+			case enforced <- struct{}{}:
+			default:
+			}
+			return 0, nil
+		},
+		nil,
+	)
+
+	err := re.set(&retentionPolicy{Database: "db1", Rules: []retentionRule{{ErasableAfter: 60}}})
+	require.NoError(t, err)
+
+	re.start()
+
+	select {
+	case <-enforced:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "retention policy not enforced")
+	}
+
+	re.stop()
+	re.stop()
+}
+
+func TestServerRetentionPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_retention_policies")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	rules := []*schema.RetentionRule{{Prefix: []byte("pii/"), ErasableAfter: 3600}}
+
+	_, err = s.SetRetentionPolicy(context.Background(), &schema.RetentionPolicy{Database: DefaultDBName, Rules: rules})
+	require.Error(t, err)
+
+	_, err = s.SetRetentionPolicy(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRetentionPolicy(ctx, &schema.RetentionPolicy{Database: DefaultDBName})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRetentionPolicy(ctx, &schema.RetentionPolicy{Database: DefaultDBName, Rules: []*schema.RetentionRule{{Prefix: []byte("pii/")}}})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRetentionPolicy(ctx, &schema.RetentionPolicy{Database: DefaultDBName, Rules: append(rules, rules[0])})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRetentionPolicy(ctx, &schema.RetentionPolicy{Database: SystemDBName, Rules: rules})
+	require.ErrorIs(t, err, ErrReservedDatabase)
+
+	_, err = s.SetRetentionPolicy(ctx, &schema.RetentionPolicy{Database: "missing", Rules: rules})
+	require.Error(t, err)
+
+	hdr, err := s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("pii/alice"), Value: []byte("alice data")},
+		{Key: []byte("invoice/1"), Value: []byte("invoice data")},
+	}})
+	require.NoError(t, err)
+
+	_, err = s.SetRetentionPolicy(ctx, &schema.RetentionPolicy{Database: DefaultDBName, Rules: rules})
+	require.NoError(t, err)
+
+	// values are not due yet
+	s.retentionEnforcer.enforceAll()
+
+	entry, err := s.Get(ctx, &schema.KeyRequest{Key: []byte("pii/alice")})
+	require.NoError(t, err)
+	require.False(t, entry.Erased)
+
+	enforcements, err := s.ListRetentionEnforcements(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.NoError(t, err)
+	require.Empty(t, enforcements.Enforcements)
+
+	s.retentionEnforcer.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	s.retentionEnforcer.enforceAll()
+
+	entry, err = s.Get(ctx, &schema.KeyRequest{Key: []byte("pii/alice")})
+	require.NoError(t, err)
+	require.True(t, entry.Erased)
+
+	entry, err = s.Get(ctx, &schema.KeyRequest{Key: []byte("invoice/1")})
+	require.NoError(t, err)
+	require.False(t, entry.Erased)
+
+	history, err := s.ErasureHistory(ctx, &schema.ErasureHistoryRequest{Key: []byte("pii/alice")})
+	require.NoError(t, err)
+	require.Len(t, history.Erasures, 1)
+	require.Equal(t, auth.SysAdminUsername, history.Erasures[0].RequestedBy)
+
+	policies, err := s.ListRetentionPolicies(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, policies.Policies, 1)
+	require.Equal(t, DefaultDBName, policies.Policies[0].Database)
+	require.NotZero(t, policies.Policies[0].LastRunAt)
+	require.Empty(t, policies.Policies[0].LastError)
+	require.Len(t, policies.Policies[0].Rules, 1)
+	require.GreaterOrEqual(t, policies.Policies[0].Rules[0].EnforcedUptoTx, hdr.Id)
+
+	enforcements, err = s.ListRetentionEnforcements(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.NoError(t, err)
+	require.Len(t, enforcements.Enforcements, 1)
+	require.Equal(t, DefaultDBName, enforcements.Enforcements[0].Database)
+	require.Equal(t, []byte("pii/"), enforcements.Enforcements[0].Prefix)
+	require.Equal(t, uint64(1), enforcements.Enforcements[0].FromTx)
+	require.Equal(t, uint64(1), enforcements.Enforcements[0].Erased)
+	require.Empty(t, enforcements.Enforcements[0].Error)
+
+	// policies and their progress are loaded again when the server is restarted
+	err = s.CloseDatabases()
+	require.NoError(t, err)
+
+	err = s.Listener.Close()
+	require.NoError(t, err)
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	lr, err = s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	reloaded, err := s.ListRetentionPolicies(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, reloaded.Policies, 1)
+	require.Equal(t, policies.Policies[0].Rules[0].EnforcedUptoTx, reloaded.Policies[0].Rules[0].EnforcedUptoTx)
+
+	_, err = s.DeleteRetentionPolicy(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.NoError(t, err)
+
+	_, err = s.DeleteRetentionPolicy(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.ErrorIs(t, err, ErrRetentionPolicyNotFound)
+
+	reloaded, err = s.ListRetentionPolicies(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Empty(t, reloaded.Policies)
+
+	// enforcements are kept after the policy is removed
+	enforcements, err = s.ListRetentionEnforcements(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.NoError(t, err)
+	require.Len(t, enforcements.Enforcements, 1)
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/throttle"
+)
+
+// runtimeSettingDefault restores the value an option had when the server was started
+const runtimeSettingDefault = "default"
+
+// runtimeSetting is an option of the server that can be changed while it's running
+type runtimeSetting struct {
+	description string
+	get         func(s *ImmuServer) string
+	// set must be called holding runtimeMutex
+	set func(s *ImmuServer, value string) error
+}
+
+var runtimeSettings = map[string]*runtimeSetting{
+	"log-level": {
+		description: "level of the messages logged: debug, info, warn or error. Set to default to use the level the server was started with",
+		get:         (*ImmuServer).logLevel,
+		set:         (*ImmuServer).setLogLevel,
+	},
+	"max-backup-rate": {
+		description: "max bytes per second read by backups and exports, 0 means unlimited",
+		get: func(s *ImmuServer) string {
+			return strconv.FormatInt(s.backupLimiter.BytesPerSec(), 10)
+		},
+		set: func(s *ImmuServer, value string) error {
+			return setRate(s.backupLimiter, value)
+		},
+	},
+	"max-replication-rate": {
+		description: "max bytes per second sent to replicas, 0 means unlimited",
+		get: func(s *ImmuServer) string {
+			return strconv.FormatInt(s.replicationLimiter.BytesPerSec(), 10)
+		},
+		set: func(s *ImmuServer, value string) error {
+			return setRate(s.replicationLimiter, value)
+		},
+	},
+	"txlog-cache-size": {
+		description: "number of transactions cached per database, 0 means the size set for each database. " +
+			"Databases loaded later on are still bound to the memory budget",
+		get: func(s *ImmuServer) string {
+			return strconv.Itoa(s.runtimeTxLogCacheSize())
+		},
+		set: (*ImmuServer).setTxLogCacheSize,
+	},
+}
+
+func runtimeSettingKey(key string) []byte {
+	k := make([]byte, 1+len(key))
+	k[0] = KeyPrefixRuntimeSetting
+	copy(k[1:], key)
+	return k
+}
+
+func (s *ImmuServer) logLevel() string {
+	l, ok := s.Logger.(*logger.DynamicLogger)
+	if !ok {
+		return runtimeSettingDefault
+	}
+
+	level, ok := l.Level()
+	if !ok {
+		return runtimeSettingDefault
+	}
+
+	return level.String()
+}
+
+func (s *ImmuServer) setLogLevel(value string) error {
+	l, ok := s.Logger.(*logger.DynamicLogger)
+	if !ok {
+		return fmt.Errorf("%w: the level of the logger can not be changed", ErrIllegalArguments)
+	}
+
+	if value == runtimeSettingDefault {
+		l.ResetLevel()
+		return nil
+	}
+
+	level, err := logger.ParseLogLevel(value)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIllegalArguments, err)
+	}
+
+	l.SetLevel(level)
+
+	return nil
+}
+
+func setRate(limiter *throttle.Limiter, value string) error {
+	bytesPerSec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || bytesPerSec < 0 {
+		return fmt.Errorf("%w: invalid rate '%s', expected bytes per second", ErrIllegalArguments, value)
+	}
+
+	limiter.SetBytesPerSec(bytesPerSec)
+
+	return nil
+}
+
+// runtimeTxLogCacheSize returns the size of the cache of the transaction log set at runtime, 0 when not set
+func (s *ImmuServer) runtimeTxLogCacheSize() int {
+	s.runtimeMutex.Lock()
+	defer s.runtimeMutex.Unlock()
+
+	return s.txLogCacheSize
+}
+
+// setTxLogCacheSize resizes the cache of the transaction log of the loaded databases, the ones loaded
+// later on are opened with the same size. Databases keep their current size when the size is unset
+func (s *ImmuServer) setTxLogCacheSize(value string) error {
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		return fmt.Errorf("%w: invalid cache size '%s'", ErrIllegalArguments, value)
+	}
+
+	s.txLogCacheSize = size
+
+	if size == 0 {
+		return nil
+	}
+
+	err = s.sysDB.ResizeTxLogCache(size)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < s.dbList.Length(); i++ {
+		err = s.dbList.GetByIndex(int64(i)).ResizeTxLogCache(size)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadRuntimeSettings applies the options changed at runtime stored in the system database
+func (s *ImmuServer) loadRuntimeSettings() error {
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: []byte{KeyPrefixRuntimeSetting},
+		NoWait: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.runtimeMutex.Lock()
+	defer s.runtimeMutex.Unlock()
+
+	for _, e := range entries.Entries {
+		key := string(e.Key[1:])
+
+		setting, ok := runtimeSettings[key]
+		if !ok {
+			s.Logger.Warningf("Unknown runtime setting '%s' ignored", key)
+			continue
+		}
+
+		err = setting.set(s, string(e.Value))
+		if err != nil {
+			return fmt.Errorf("runtime setting '%s': %w", key, err)
+		}
+
+		s.Logger.Infof("Runtime setting '%s' set to '%s'", key, e.Value)
+	}
+
+	return nil
+}
+
+func (s *ImmuServer) runtimeSettingOf(key string) *schema.RuntimeSetting {
+	setting := runtimeSettings[key]
+
+	return &schema.RuntimeSetting{
+		Key:         key,
+		Value:       setting.get(s),
+		Description: setting.description,
+	}
+}
+
+// GetRuntimeConfig returns the options of the server that can be changed while it's running, only the
+// requested one when a key is set
+func (s *ImmuServer) GetRuntimeConfig(ctx context.Context, req *schema.GetRuntimeConfigRequest) (*schema.RuntimeConfig, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	s.Logger.Debugf("getruntimeconfig %s", req.Key)
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Key != "" {
+		if _, ok := runtimeSettings[req.Key]; !ok {
+			return nil, fmt.Errorf("%w: unknown option '%s'", ErrIllegalArguments, req.Key)
+		}
+
+		return &schema.RuntimeConfig{Settings: []*schema.RuntimeSetting{s.runtimeSettingOf(req.Key)}}, nil
+	}
+
+	keys := make([]string, 0, len(runtimeSettings))
+	for key := range runtimeSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	config := &schema.RuntimeConfig{}
+
+	for _, key := range keys {
+		config.Settings = append(config.Settings, s.runtimeSettingOf(key))
+	}
+
+	return config, nil
+}
+
+// SetRuntimeConfig changes an option of the running server. The change is stored in the system database,
+// so it's kept across restarts, and recorded in the history of the changes of the server settings
+func (s *ImmuServer) SetRuntimeConfig(ctx context.Context, req *schema.SetRuntimeConfigRequest) (*schema.RuntimeSetting, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	s.Logger.Debugf("setruntimeconfig %s", req.Key)
+
+	admin, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	setting, ok := runtimeSettings[req.Key]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown option '%s'", ErrIllegalArguments, req.Key)
+	}
+
+	err = s.setRuntimeSetting(ctx, setting, req.Key, req.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Runtime setting '%s' set to '%s' by %s", req.Key, req.Value, admin)
+
+	return s.runtimeSettingOf(req.Key), nil
+}
+
+func (s *ImmuServer) setRuntimeSetting(ctx context.Context, setting *runtimeSetting, key, value string) error {
+	s.runtimeMutex.Lock()
+	defer s.runtimeMutex.Unlock()
+
+	err := setting.set(s, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: runtimeSettingKey(key), Value: []byte(value)}}})
+	if err != nil {
+		return err
+	}
+
+	return s.recordConfigChange(ctx, "", configChangeRuntimeSetting, fmt.Sprintf("%s=%s", key, value))
+}
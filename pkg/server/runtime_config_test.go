@@ -0,0 +1,143 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerRuntimeConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_runtime_config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	startServer := func() (*ImmuServer, context.Context) {
+		s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+		err := s.Initialize()
+		require.NoError(t, err)
+
+		lr, err := s.Login(context.Background(), &schema.LoginRequest{
+			User:     []byte(auth.SysAdminUsername),
+			Password: []byte(auth.SysAdminPassword),
+		})
+		require.NoError(t, err)
+
+		return s, metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+	}
+
+	s, ctx := startServer()
+
+	_, err = s.GetRuntimeConfig(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.GetRuntimeConfig(context.Background(), &schema.GetRuntimeConfigRequest{})
+	require.Error(t, err)
+
+	config, err := s.GetRuntimeConfig(ctx, &schema.GetRuntimeConfigRequest{})
+	require.NoError(t, err)
+	require.Len(t, config.Settings, len(runtimeSettings))
+	require.Equal(t, "log-level", config.Settings[0].Key)
+	require.Equal(t, runtimeSettingDefault, config.Settings[0].Value)
+	require.NotEmpty(t, config.Settings[0].Description)
+
+	_, err = s.GetRuntimeConfig(ctx, &schema.GetRuntimeConfigRequest{Key: "unknown"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRuntimeConfig(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: "unknown", Value: "1"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: "log-level", Value: "verbose"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: "max-backup-rate", Value: "-1"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: "txlog-cache-size", Value: "many"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	setting, err := s.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: "log-level", Value: "debug"})
+	require.NoError(t, err)
+	require.Equal(t, "debug", setting.Value)
+
+	level, ok := s.Logger.(*logger.DynamicLogger).Level()
+	require.True(t, ok)
+	require.Equal(t, logger.LogDebug, level)
+
+	_, err = s.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: "max-backup-rate", Value: "1048576"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1<<20), s.backupLimiter.BytesPerSec())
+
+	_, err = s.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: "txlog-cache-size", Value: "50"})
+	require.NoError(t, err)
+
+	// databases loaded from now on are opened with the same size
+	require.Equal(t, 50, s.databaseOptionsFrom(s.defaultDBOptions("db1")).GetStoreOptions().TxLogCacheSize)
+
+	history, err := s.ConfigHistory(ctx, &schema.ConfigHistoryRequest{Desc: true})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 4)
+	require.Equal(t, configChangeRuntimeSetting, history.Changes[0].Kind)
+	require.Equal(t, "txlog-cache-size=50", history.Changes[0].Change)
+	require.Equal(t, auth.SysAdminUsername, history.Changes[0].ChangedBy)
+
+	s.CloseDatabases()
+	s.Listener.Close()
+
+	// changes are kept across restarts
+	s, ctx = startServer()
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	config, err = s.GetRuntimeConfig(ctx, &schema.GetRuntimeConfigRequest{Key: "log-level"})
+	require.NoError(t, err)
+	require.Len(t, config.Settings, 1)
+	require.Equal(t, "debug", config.Settings[0].Value)
+
+	require.Equal(t, int64(1<<20), s.backupLimiter.BytesPerSec())
+	require.Equal(t, 50, s.runtimeTxLogCacheSize())
+
+	// server settings did not change, so they are not recorded again
+	history, err = s.ConfigHistory(ctx, &schema.ConfigHistoryRequest{})
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 4)
+
+	_, err = s.SetRuntimeConfig(ctx, &schema.SetRuntimeConfigRequest{Key: "log-level", Value: runtimeSettingDefault})
+	require.NoError(t, err)
+
+	_, ok = s.Logger.(*logger.DynamicLogger).Level()
+	require.False(t, ok)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// SelectiveRestore restores the entries under the given key prefixes and the rows of the given SQL tables
+// from backup files residing on the server into a new or existing database. Backups are restored into a
+// temporary database, so no other data is written into the target one.
+func (s *ImmuServer) SelectiveRestore(ctx context.Context, req *schema.SelectiveRestoreRequest) (*schema.SelectiveRestoreResponse, error) {
+	s.Logger.Debugf("selectiverestore")
+
+	if req == nil || req.Database == "" || len(req.Backups) == 0 || (len(req.Prefixes) == 0 && len(req.Tables) == 0) {
+		return nil, ErrIllegalArguments
+	}
+
+	if !s.Options.GetAuth() {
+		return nil, fmt.Errorf("this command is available only with authentication on")
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get loggedin user data")
+	}
+
+	if !user.IsSysAdmin {
+		return nil, fmt.Errorf("logged In user does not have permissions for this operation")
+	}
+
+	if req.Database == SystemDBName {
+		return nil, ErrReservedDatabase
+	}
+
+	if s.remoteStorage != nil {
+		// backups are restored into local files
+		return nil, store.ErrBackupUnsupported
+	}
+
+	dbID := s.dbList.GetId(req.Database)
+	if dbID >= 0 && s.dbList.GetByIndex(dbID).IsReplica() {
+		return nil, database.ErrIsReplica
+	}
+
+	tmpDir, err := ioutil.TempDir("", "immudb_selective_restore")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	backups := make([]io.Reader, len(req.Backups))
+
+	for i, file := range req.Backups {
+		r, err := openSeedBackup(file, s.Options.BackupKeyProvider)
+		if err != nil {
+			return nil, fmt.Errorf("backup %s is not readable: %w", file, err)
+		}
+		defer r.Close()
+
+		backups[i] = r
+	}
+
+	srcOpts := s.databaseOptionsFrom(s.defaultDBOptions(req.Database)).WithDBRootPath(tmpDir)
+
+	src, err := database.Seed(srcOpts, backups, s.Logger)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	if dbID < 0 {
+		_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: req.Database})
+		if err != nil {
+			return nil, err
+		}
+
+		dbID = s.dbList.GetId(req.Database)
+	}
+
+	summary, err := database.RestoreSelected(src, s.dbList.GetByIndex(dbID), req.Prefixes, req.Tables)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("%d entries and %d rows restored into database '%s'", summary.Entries, summary.Rows, req.Database)
+
+	return &schema.SelectiveRestoreResponse{
+		Entries: summary.Entries,
+		Rows:    summary.Rows,
+	}, nil
+}
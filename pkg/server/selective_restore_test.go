@@ -0,0 +1,141 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerSelectiveRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_selective_restore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	defaultDB, err := s.dbList.GetByName(DefaultDBName)
+	require.NoError(t, err)
+
+	_, err = defaultDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("users/1"), Value: []byte("user1")},
+		{Key: []byte("orders/1"), Value: []byte("order1")},
+	}})
+	require.NoError(t, err)
+
+	_, _, err = defaultDB.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE accounts (id INTEGER, name VARCHAR, PRIMARY KEY id);
+		INSERT INTO accounts(id, name) VALUES (1, 'account1'), (2, 'account2');
+	`}, nil)
+	require.NoError(t, err)
+
+	backup := filepath.Join(dir, "full.backup.tar")
+	writeSeedBackup(t, defaultDB, backup, nil)
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err = s.SelectiveRestore(ctx, &schema.SelectiveRestoreRequest{Database: "restored", Backups: []string{backup}})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.SelectiveRestore(ctx, &schema.SelectiveRestoreRequest{
+			Database: SystemDBName,
+			Backups:  []string{backup},
+			Tables:   []string{"accounts"},
+		})
+		require.ErrorIs(t, err, ErrReservedDatabase)
+
+		_, err = s.SelectiveRestore(ctx, &schema.SelectiveRestoreRequest{
+			Database: "restored",
+			Backups:  []string{filepath.Join(dir, "missing.backup.tar")},
+			Tables:   []string{"accounts"},
+		})
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		require.Less(t, s.dbList.GetId("restored"), int64(0))
+	})
+
+	t.Run("selected data restored into a new database", func(t *testing.T) {
+		res, err := s.SelectiveRestore(ctx, &schema.SelectiveRestoreRequest{
+			Database: "restored",
+			Backups:  []string{backup},
+			Prefixes: [][]byte{[]byte("users/")},
+			Tables:   []string{"accounts"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), res.Entries)
+		require.Equal(t, uint64(2), res.Rows)
+
+		restoredDB, err := s.dbList.GetByName("restored")
+		require.NoError(t, err)
+
+		entry, err := restoredDB.Get(&schema.KeyRequest{Key: []byte("users/1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("user1"), entry.Value)
+
+		_, err = restoredDB.Get(&schema.KeyRequest{Key: []byte("orders/1")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+		qres, err := restoredDB.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT name FROM accounts WHERE id = 2"}, nil)
+		require.NoError(t, err)
+		require.Len(t, qres.Rows, 1)
+		require.Equal(t, "account2", qres.Rows[0].Values[0].GetS())
+	})
+
+	t.Run("selected data restored into an existing database", func(t *testing.T) {
+		_, err = defaultDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("orders/1"), Value: []byte("deleted by mistake")}}})
+		require.NoError(t, err)
+
+		res, err := s.SelectiveRestore(ctx, &schema.SelectiveRestoreRequest{
+			Database: DefaultDBName,
+			Backups:  []string{backup},
+			Prefixes: [][]byte{[]byte("orders/")},
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), res.Entries)
+
+		entry, err := defaultDB.Get(&schema.KeyRequest{Key: []byte("orders/1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("order1"), entry.Value)
+	})
+}
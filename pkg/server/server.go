@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -36,9 +37,12 @@ import (
 	"github.com/codenotary/immudb/pkg/errors"
 	"github.com/codenotary/immudb/pkg/replication"
 
+	etcdsrv "github.com/codenotary/immudb/pkg/etcd/server"
 	pgsqlsrv "github.com/codenotary/immudb/pkg/pgsql/server"
+	respsrv "github.com/codenotary/immudb/pkg/resp/server"
 
 	"github.com/codenotary/immudb/pkg/stream"
+	"github.com/codenotary/immudb/pkg/throttle"
 
 	"github.com/codenotary/immudb/pkg/database"
 
@@ -60,9 +64,31 @@ import (
 
 const (
 	//KeyPrefixUser All user keys in the key/value store are prefixed by this keys to distinguish them from keys that have other purposes
-	KeyPrefixUser = iota + 1
+	KeyPrefixUser = auth.KeyPrefixUser + iota
 	//KeyPrefixDBSettings is used for entries related to database settings
 	KeyPrefixDBSettings
+	//KeyPrefixBackupSchedule is used for entries holding the schedule of backups of a database
+	KeyPrefixBackupSchedule
+	//KeyPrefixStateTimestamp is used for entries holding the timestamp tokens of the state of a database
+	KeyPrefixStateTimestamp
+	//KeyPrefixStateAnchor is used for entries holding the receipts of the state of the databases anchored to public chains
+	KeyPrefixStateAnchor
+	//KeyPrefixRetentionPolicy is used for entries holding the retention policy of a database
+	KeyPrefixRetentionPolicy
+	//KeyPrefixRetentionEnforcement is used for entries recording the enforcement of the retention policies
+	KeyPrefixRetentionEnforcement
+	//KeyPrefixCosignature is used for entries holding the signatures of the state of a database by auditors
+	KeyPrefixCosignature
+	//KeyPrefixWORMActivation is used for entries recording the WORM compliance mode being enabled or extended on a database
+	KeyPrefixWORMActivation
+	//KeyPrefixConfigChange is used for entries recording the changes of the settings of the server and of the settings and schema of a database
+	KeyPrefixConfigChange
+	//KeyPrefixVerificationSchedule is used for entries holding the schedule of verifications of a database
+	KeyPrefixVerificationSchedule
+	//KeyPrefixVerificationRun is used for entries recording the results of the verifications of a database
+	KeyPrefixVerificationRun
+	//KeyPrefixRuntimeSetting is used for entries holding the options of the server changed while it was running
+	KeyPrefixRuntimeSetting
 )
 
 var startedAt time.Time
@@ -76,6 +102,11 @@ var immudbTextLogo = " _                               _ _     \n" +
 
 // Initialize initializes dependencies, set up multi database capabilities and stats
 func (s *ImmuServer) Initialize() error {
+	// the log level can be changed at runtime, all the components share the same logger
+	if _, ok := s.Logger.(*logger.DynamicLogger); !ok {
+		s.Logger = logger.NewDynamicLogger(s.Logger)
+	}
+
 	_, err := fmt.Fprintf(os.Stdout, "%s\n%s\n%s\n\n", immudbTextLogo, version.VersionStr(), s.Options)
 	logErr(s.Logger, "Error printing immudb config: %v", err)
 
@@ -103,21 +134,46 @@ func (s *ImmuServer) Initialize() error {
 		return logErr(s.Logger, "Unable to create data dir: %v", err)
 	}
 
+	if s.Options.MaxMemory < 0 {
+		return logErr(s.Logger, "%v", ErrIllegalArguments)
+	}
+	s.memoryBudget = newMemoryBudget(uint64(s.Options.MaxMemory))
+
+	if s.Options.MaxBackupRate < 0 || s.Options.MaxReplicationRate < 0 {
+		return logErr(s.Logger, "%v", ErrIllegalArguments)
+	}
+	s.backupLimiter = throttle.NewAdjustableLimiter(s.Options.MaxBackupRate)
+	s.replicationLimiter = throttle.NewAdjustableLimiter(s.Options.MaxReplicationRate)
+
 	remoteStorage, err := s.createRemoteStorageInstance()
 	if err != nil {
 		return logErr(s.Logger, "Unable to open remote storage: %v", err)
 	}
 	s.remoteStorage = remoteStorage
 
+	if remoteStorage != nil && s.Options.ArchiveDir != "" {
+		return logErr(s.Logger, "Unable to archive segments: %v", ErrArchiveUnsupported)
+	}
+
 	err = s.initializeRemoteStorage(remoteStorage)
 	if err != nil {
 		return logErr(s.Logger, "Unable to initialize remote storage: %v", err)
 	}
 
+	s.alerter, err = s.Options.AlertOptions.Alerter()
+	if err != nil {
+		return logErr(s.Logger, "Unable to configure alerts: %v", err)
+	}
+
 	if err = s.loadSystemDatabase(dataDir, remoteStorage, adminPassword); err != nil {
 		return logErr(s.Logger, "Unable to load system database: %v", err)
 	}
 
+	// settings changed at runtime apply to the databases loaded from now on
+	if err = s.loadRuntimeSettings(); err != nil {
+		return logErr(s.Logger, "Unable to load runtime settings: %v", err)
+	}
+
 	if err = s.loadDefaultDatabase(dataDir, remoteStorage); err != nil {
 		return logErr(s.Logger, "Unable to load default database: %v", err)
 	}
@@ -135,6 +191,72 @@ func (s *ImmuServer) Initialize() error {
 		if err = s.loadUserDatabases(dataDir, remoteStorage); err != nil {
 			return logErr(s.Logger, "Unable load databases: %v", err)
 		}
+
+		if err = s.recordServerSettings(); err != nil {
+			return logErr(s.Logger, "Unable to record server settings: %v", err)
+		}
+	}
+
+	s.backupScheduler = newBackupScheduler(s.runScheduledBackup, s.notifyBackupFailure)
+	if err = s.loadBackupSchedules(); err != nil {
+		return logErr(s.Logger, "Unable to load backup schedules: %v", err)
+	}
+
+	s.verificationScheduler = newVerificationScheduler(s.runScheduledVerification)
+	if err = s.loadVerificationSchedules(); err != nil {
+		return logErr(s.Logger, "Unable to load verification schedules: %v", err)
+	}
+
+	if s.Options.RetentionInterval <= 0 {
+		return logErr(s.Logger, "%v", ErrIllegalArguments)
+	}
+	s.retentionEnforcer = newRetentionEnforcer(s.Options.RetentionInterval, s.enforceRetentionRule, s.storeRetentionPolicy)
+	if err = s.loadRetentionPolicies(); err != nil {
+		return logErr(s.Logger, "Unable to load retention policies: %v", err)
+	}
+
+	if len(s.Options.TimestampAuthorities) > 0 && s.Options.TimestampInterval <= 0 {
+		return logErr(s.Logger, "%v", ErrIllegalArguments)
+	}
+	s.stateTimestamper = newStateTimestamper(
+		s.Options.TimestampAuthorities,
+		s.Options.TimestampInterval,
+		s.currentStates,
+		s.storeStateTimestamp,
+		s.notifyStateTimestampFailure,
+	)
+	if err = s.loadStateTimestamps(); err != nil {
+		return logErr(s.Logger, "Unable to load state timestamps: %v", err)
+	}
+
+	s.stateAnchorer, err = newStateAnchorer(
+		s.Options.AnchoringOptions,
+		s.currentStates,
+		s.storeStateAnchor,
+		s.notifyStateAnchorFailure,
+	)
+	if err != nil {
+		return logErr(s.Logger, "Unable to configure anchoring: %v", err)
+	}
+	if err = s.loadStateAnchors(); err != nil {
+		return logErr(s.Logger, "Unable to load state anchors: %v", err)
+	}
+
+	if s.Options.StatePublication.enabled() && s.Options.StatePublication.Interval <= 0 {
+		return logErr(s.Logger, "%v", ErrIllegalArguments)
+	}
+	s.statePublisher = newStatePublisher(
+		s.Options.StatePublication,
+		s.signedStates,
+		s.notifyStatePublicationFailure,
+	)
+
+	for _, path := range s.Options.Cosigners {
+		pk, err := signer.ParsePublicKeyFile(path)
+		if err != nil {
+			return logErr(s.Logger, "Unable to load the public key of a cosigner: %v", err)
+		}
+		s.cosigners = append(s.cosigners, pk)
 	}
 
 	s.multidbmode = s.mandatoryAuth()
@@ -204,6 +326,7 @@ func (s *ImmuServer) Initialize() error {
 
 	uis := []grpc.UnaryServerInterceptor{
 		ErrorMapper, // converts errors in gRPC ones. Need to be the first
+		s.MemoryBudgetInterceptor,
 		s.KeepAliveSessionInterceptor,
 		uuidContext.UUIDContextSetter,
 		grpc_prometheus.UnaryServerInterceptor,
@@ -212,6 +335,7 @@ func (s *ImmuServer) Initialize() error {
 	}
 	sss := []grpc.StreamServerInterceptor{
 		ErrorMapperStream, // converts errors in gRPC ones. Need to be the first
+		s.MemoryBudgetStreamInterceptor,
 		s.KeepALiveSessionStreamInterceptor,
 		uuidContext.UUIDStreamContextSetter,
 		grpc_prometheus.StreamServerInterceptor,
@@ -240,6 +364,37 @@ func (s *ImmuServer) Initialize() error {
 		}
 	}
 
+	s.RESPSrv = respsrv.New(
+		respsrv.Address(s.Options.Address),
+		respsrv.Port(s.Options.RESPServerPort),
+		respsrv.DatabaseList(s.dbList),
+		respsrv.SysDb(s.sysDB),
+		respsrv.DefaultDatabase(s.Options.GetDefaultDBName()),
+		respsrv.Signer(s.StateSigner),
+		respsrv.TlsConfig(s.Options.TLSConfig),
+		respsrv.Logger(s.Logger),
+	)
+	if s.Options.RESPServer {
+		if err = s.RESPSrv.Initialize(); err != nil {
+			return err
+		}
+	}
+
+	s.EtcdSrv = etcdsrv.New(
+		etcdsrv.Address(s.Options.Address),
+		etcdsrv.Port(s.Options.EtcdServerPort),
+		etcdsrv.DatabaseList(s.dbList),
+		etcdsrv.SysDb(s.sysDB),
+		etcdsrv.DefaultDatabase(s.Options.GetDefaultDBName()),
+		etcdsrv.TlsConfig(s.Options.TLSConfig),
+		etcdsrv.Logger(s.Logger),
+	)
+	if s.Options.EtcdServer {
+		if err = s.EtcdSrv.Initialize(); err != nil {
+			return err
+		}
+	}
+
 	return err
 }
 
@@ -271,6 +426,20 @@ func (s *ImmuServer) Start() (err error) {
 		}
 	}()
 
+	s.memoryBudget.start()
+
+	s.backupScheduler.start()
+
+	s.verificationScheduler.start()
+
+	s.retentionEnforcer.start()
+
+	s.stateTimestamper.start()
+
+	s.stateAnchorer.start()
+
+	s.statePublisher.start()
+
 	go func() {
 		if err = s.SessManager.StartSessionsGuard(); err != nil {
 			log.Fatal(err)
@@ -288,6 +457,24 @@ func (s *ImmuServer) Start() (err error) {
 		}()
 	}
 
+	if s.Options.RESPServer {
+		go func() {
+			s.Logger.Infof("resp server is running at port %d", s.Options.RESPServerPort)
+			if err := s.RESPSrv.Serve(); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	if s.Options.EtcdServer {
+		go func() {
+			s.Logger.Infof("etcd server is running at port %d", s.Options.EtcdServerPort)
+			if err := s.EtcdSrv.Serve(); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
 	if s.Options.WebServer {
 		if err := s.setUpWebServer(); err != nil {
 			log.Fatal(fmt.Sprintf("Failed to setup web API/console server: %v", err))
@@ -333,10 +520,20 @@ func (s *ImmuServer) setUpMetricsServer() error {
 		s.metricFuncServerUptimeCounter,
 		s.metricFuncComputeDBSizes,
 		s.metricFuncComputeDBEntries,
+		s.metricFuncComputeActiveSessions,
+		s.statesHandler(),
 	)
 	return nil
 }
 
+// statesHandler returns the handler of the published states, nil when publication is disabled
+func (s *ImmuServer) statesHandler() http.Handler {
+	if !s.statePublisher.enabled() {
+		return nil
+	}
+	return s.statePublisher
+}
+
 func (s *ImmuServer) setUpWebServer() error {
 	server, err := StartWebServer(
 		s.Options.WebBind(),
@@ -569,7 +766,9 @@ func (s *ImmuServer) startReplicationFor(db database.DB, dbOpts *dbOptions) erro
 		WithMasterPort(dbOpts.MasterPort).
 		WithFollowerUsername(dbOpts.FollowerUsername).
 		WithFollowerPassword(dbOpts.FollowerPassword).
-		WithStreamChunkSize(s.Options.StreamChunkSize)
+		WithStreamChunkSize(s.Options.StreamChunkSize).
+		WithRateLimiter(s.replicationLimiter).
+		WithAlerter(s.alerter)
 
 	f, err := replication.NewTxReplicator(db, replicatorOpts, s.Logger)
 	if err != nil {
@@ -633,8 +832,34 @@ func (s *ImmuServer) Stop() error {
 
 	s.SessManager.StopSessionsGuard()
 
+	s.memoryBudget.stop()
+
+	s.backupScheduler.stop()
+
+	s.verificationScheduler.stop()
+
+	s.retentionEnforcer.stop()
+
+	s.stateTimestamper.stop()
+
+	s.stateAnchorer.stop()
+
+	s.statePublisher.stop()
+
 	s.stopReplication()
 
+	if s.Options.RESPServer {
+		if err := s.RESPSrv.Stop(); err != nil {
+			s.Logger.Warningf("Error stopping resp server. Reason: %v", err)
+		}
+	}
+
+	if s.Options.EtcdServer {
+		if err := s.EtcdSrv.Stop(); err != nil {
+			s.Logger.Warningf("Error stopping etcd server. Reason: %v", err)
+		}
+	}
+
 	return s.CloseDatabases()
 }
 
@@ -737,54 +962,127 @@ func (s *ImmuServer) CreateDatabaseWith(ctx context.Context, req *schema.Databas
 		return nil, ErrIllegalArguments
 	}
 
-	if s.Options.GetMaintenance() {
-		return nil, ErrNotAllowedInMaintenanceMode
+	err := s.checkNewDatabase(ctx, req.DatabaseName)
+	if err != nil {
+		return nil, err
 	}
 
-	if !s.Options.GetAuth() {
-		return nil, fmt.Errorf("this command is available only with authentication on")
+	dbOpts := s.defaultDBOptions(req.DatabaseName)
+
+	err = s.overwriteWith(dbOpts, req, false)
+	if err != nil {
+		return nil, err
 	}
 
-	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	var db database.DB
+
+	if len(req.SeedBackups) > 0 {
+		// replication continues from the last transaction restored from the backups
+		db, err = s.seedDatabase(dbOpts, req.SeedBackups)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.saveDBOptions(dbOpts)
 	if err != nil {
-		return nil, fmt.Errorf("could not get loggedin user data")
+		if db != nil {
+			db.Close()
+			os.RemoveAll(filepath.Join(s.Options.Dir, req.DatabaseName))
+		}
+		return nil, err
 	}
 
-	if !user.IsSysAdmin {
-		return nil, fmt.Errorf("logged In user does not have permissions for this operation")
+	if db == nil {
+		db, err = database.NewDB(s.databaseOptionsFrom(dbOpts), s.Logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if dbOpts.WORMRetainUntil > 0 {
+		err = s.recordWORMActivation(ctx, dbOpts.Database, 0, dbOpts.WORMRetainUntil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.recordDBSettingsChange(ctx, dbOpts)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.DatabaseName == SystemDBName {
-		return nil, fmt.Errorf("this database name is reserved")
+	s.dbList.Append(db)
+	s.multidbmode = true
+
+	db.AsReplica(dbOpts.Replica)
+
+	err = s.startReplicationFor(db, dbOpts)
+	if err != nil && err != ErrReplicatorNotNeeded {
+		s.Logger.Errorf("Error starting replication for database '%s'. Reason: %v", db.GetName(), err)
 	}
 
-	if strings.ToLower(req.DatabaseName) != req.DatabaseName {
-		return nil, fmt.Errorf("provide a lowercase database name")
+	return &empty.Empty{}, nil
+}
+
+// CloneDatabase creates a new database as a copy of an existing one, optionally as of a given transaction.
+// The new database takes the settings of the source one but it's not replicated.
+func (s *ImmuServer) CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) (*empty.Empty, error) {
+	s.Logger.Debugf("clonedatabase")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
 	}
 
-	req.DatabaseName = strings.ToLower(req.DatabaseName)
-	if err = isValidDBName(req.DatabaseName); err != nil {
+	err := s.checkNewDatabase(ctx, req.TargetDatabase)
+	if err != nil {
 		return nil, err
 	}
 
-	//check if database exists
-	if s.dbList.GetId(req.GetDatabaseName()) >= 0 {
-		return nil, fmt.Errorf("database '%s' already exists", req.GetDatabaseName())
+	srcID := s.dbList.GetId(req.SourceDatabase)
+	if req.SourceDatabase == SystemDBName || srcID < 0 {
+		return nil, fmt.Errorf("database '%s' does not exist", req.SourceDatabase)
 	}
 
-	dbOpts := s.defaultDBOptions(req.DatabaseName)
+	if s.remoteStorage != nil {
+		// data files of the clone are copied into local files
+		return nil, store.ErrBackupUnsupported
+	}
 
-	err = s.overwriteWith(dbOpts, req, false)
+	srcOpts, err := s.loadDBOptions(req.SourceDatabase, false)
+	if err == store.ErrKeyNotFound && req.SourceDatabase == s.Options.GetDefaultDBName() {
+		// settings of the default database are not stored
+		srcOpts, err = s.defaultDBOptions(req.SourceDatabase), nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.saveDBOptions(dbOpts)
+	defaultOpts := s.defaultDBOptions(req.TargetDatabase)
+
+	dbOpts := *srcOpts
+	dbOpts.Database = defaultOpts.Database
+	dbOpts.Replica = defaultOpts.Replica
+	dbOpts.MasterDatabase = defaultOpts.MasterDatabase
+	dbOpts.MasterAddress = defaultOpts.MasterAddress
+	dbOpts.MasterPort = defaultOpts.MasterPort
+	dbOpts.FollowerUsername = defaultOpts.FollowerUsername
+	dbOpts.FollowerPassword = defaultOpts.FollowerPassword
+	dbOpts.CreatedAt = defaultOpts.CreatedAt
+
+	db, err := database.Clone(s.dbList.GetByIndex(srcID), s.databaseOptionsFrom(&dbOpts), req.AtTx, s.Logger)
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := database.NewDB(s.databaseOptionsFrom(dbOpts), s.Logger)
+	err = s.saveDBOptions(&dbOpts)
+	if err != nil {
+		db.Close()
+		os.RemoveAll(filepath.Join(s.Options.Dir, req.TargetDatabase))
+		return nil, err
+	}
+
+	err = s.recordDBSettingsChange(ctx, &dbOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -792,14 +1090,46 @@ func (s *ImmuServer) CreateDatabaseWith(ctx context.Context, req *schema.Databas
 	s.dbList.Append(db)
 	s.multidbmode = true
 
-	db.AsReplica(dbOpts.Replica)
+	return &empty.Empty{}, nil
+}
 
-	err = s.startReplicationFor(db, dbOpts)
-	if err != nil && err != ErrReplicatorNotNeeded {
-		s.Logger.Errorf("Error starting replication for database '%s'. Reason: %v", db.GetName(), err)
+// checkNewDatabase checks the logged in user is allowed to create a database named dbName
+func (s *ImmuServer) checkNewDatabase(ctx context.Context, dbName string) error {
+	if s.Options.GetMaintenance() {
+		return ErrNotAllowedInMaintenanceMode
 	}
 
-	return &empty.Empty{}, nil
+	if !s.Options.GetAuth() {
+		return fmt.Errorf("this command is available only with authentication on")
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get loggedin user data")
+	}
+
+	if !user.IsSysAdmin {
+		return fmt.Errorf("logged In user does not have permissions for this operation")
+	}
+
+	if dbName == SystemDBName {
+		return fmt.Errorf("this database name is reserved")
+	}
+
+	if strings.ToLower(dbName) != dbName {
+		return fmt.Errorf("provide a lowercase database name")
+	}
+
+	if err = isValidDBName(dbName); err != nil {
+		return err
+	}
+
+	//check if database exists
+	if s.dbList.GetId(dbName) >= 0 {
+		return fmt.Errorf("database '%s' already exists", dbName)
+	}
+
+	return nil
 }
 
 // UpdateDatabase Updates database settings
@@ -850,6 +1180,8 @@ func (s *ImmuServer) UpdateDatabase(ctx context.Context, req *schema.DatabaseSet
 		return nil, err
 	}
 
+	prevWORMRetainUntil := dbOpts.WORMRetainUntil
+
 	err = s.overwriteWith(dbOpts, req, true)
 	if err != nil {
 		return nil, err
@@ -862,6 +1194,23 @@ func (s *ImmuServer) UpdateDatabase(ctx context.Context, req *schema.DatabaseSet
 		return nil, err
 	}
 
+	err = s.recordDBSettingsChange(ctx, dbOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if dbOpts.WORMRetainUntil != prevWORMRetainUntil {
+		err = db.SetRetainUntil(time.Unix(dbOpts.WORMRetainUntil, 0))
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.recordWORMActivation(ctx, dbOpts.Database, prevWORMRetainUntil, dbOpts.WORMRetainUntil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	db.AsReplica(dbOpts.Replica)
 
 	err = s.startReplicationFor(db, dbOpts)
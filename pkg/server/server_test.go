@@ -219,6 +219,20 @@ func TestServerCreateDatabase(t *testing.T) {
 	_, err = s.CreateDatabaseWith(ctx, dbSettings)
 	require.ErrorIs(t, err, ErrIllegalArguments)
 
+	dbSettings = &schema.DatabaseSettings{
+		DatabaseName:      "lisbon",
+		CompressionFormat: "snappy",
+	}
+	_, err = s.CreateDatabaseWith(ctx, dbSettings)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	dbSettings = &schema.DatabaseSettings{
+		DatabaseName:  "lisbon",
+		HashAlgorithm: "md5",
+	}
+	_, err = s.CreateDatabaseWith(ctx, dbSettings)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
 	newdb := &schema.Database{
 		DatabaseName: "lisbon",
 	}
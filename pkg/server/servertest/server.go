@@ -98,6 +98,22 @@ func (bs *bufconnServer) Start() error {
 		}()
 	}
 
+	if bs.Options.RESPServer {
+		go func() {
+			if err := bs.Server.Srv.RESPSrv.Serve(); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	if bs.Options.EtcdServer {
+		go func() {
+			if err := bs.Server.Srv.EtcdSrv.Serve(); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
 	return nil
 }
 
@@ -110,6 +126,12 @@ func (bs *bufconnServer) Stop() error {
 	if err := bs.Server.Srv.PgsqlSrv.Stop(); err != nil {
 		return err
 	}
+	if err := bs.Server.Srv.RESPSrv.Stop(); err != nil {
+		return err
+	}
+	if err := bs.Server.Srv.EtcdSrv.Stop(); err != nil {
+		return err
+	}
 
 	bs.GrpcServer.Stop()
 
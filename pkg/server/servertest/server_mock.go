@@ -208,6 +208,70 @@ func (s *ServerMock) TxScan(ctx context.Context, req *schema.TxScanRequest) (*sc
 	return s.Srv.TxScan(ctx, req)
 }
 
+func (s *ServerMock) TxByTime(ctx context.Context, req *schema.TxByTimeRequest) (*schema.Tx, error) {
+	return s.Srv.TxByTime(ctx, req)
+}
+
+func (s *ServerMock) ExportTxHeaders(ctx context.Context, req *schema.TxHeadersRequest) (*schema.TxHeaders, error) {
+	return s.Srv.ExportTxHeaders(ctx, req)
+}
+
+func (s *ServerMock) ConsistencyProof(ctx context.Context, req *schema.ConsistencyProofRequest) (*schema.DualProof, error) {
+	return s.Srv.ConsistencyProof(ctx, req)
+}
+
+func (s *ServerMock) Notarize(ctx context.Context, req *schema.NotarizeRequest) (*schema.Notarization, error) {
+	return s.Srv.Notarize(ctx, req)
+}
+
+func (s *ServerMock) VerifyNotarization(ctx context.Context, req *schema.VerifyNotarizationRequest) (*schema.Notarization, error) {
+	return s.Srv.VerifyNotarization(ctx, req)
+}
+
+func (s *ServerMock) Erase(ctx context.Context, req *schema.EraseRequest) (*schema.Erasure, error) {
+	return s.Srv.Erase(ctx, req)
+}
+
+func (s *ServerMock) ErasureHistory(ctx context.Context, req *schema.ErasureHistoryRequest) (*schema.ErasureHistory, error) {
+	return s.Srv.ErasureHistory(ctx, req)
+}
+
+func (s *ServerMock) PlaceLegalHold(ctx context.Context, req *schema.LegalHoldRequest) (*schema.LegalHold, error) {
+	return s.Srv.PlaceLegalHold(ctx, req)
+}
+
+func (s *ServerMock) LiftLegalHold(ctx context.Context, req *schema.LegalHoldRequest) (*empty.Empty, error) {
+	return s.Srv.LiftLegalHold(ctx, req)
+}
+
+func (s *ServerMock) ListLegalHolds(ctx context.Context, req *empty.Empty) (*schema.LegalHoldList, error) {
+	return s.Srv.ListLegalHolds(ctx, req)
+}
+
+func (s *ServerMock) ExclusionProof(ctx context.Context, req *schema.ExclusionProofRequest) (*schema.ExclusionProof, error) {
+	return s.Srv.ExclusionProof(ctx, req)
+}
+
+func (s *ServerMock) VerifiableBatch(ctx context.Context, req *schema.VerifiableBatchRequest) (*schema.VerifiableBatch, error) {
+	return s.Srv.VerifiableBatch(ctx, req)
+}
+
+func (s *ServerMock) SampledAudit(ctx context.Context, req *schema.SampledAuditRequest) (*schema.SampledAudit, error) {
+	return s.Srv.SampledAudit(ctx, req)
+}
+
+func (s *ServerMock) GlobalState(ctx context.Context, req *schema.GlobalStateRequest) (*schema.GlobalState, error) {
+	return s.Srv.GlobalState(ctx, req)
+}
+
+func (s *ServerMock) Cosign(ctx context.Context, req *schema.Cosignature) (*empty.Empty, error) {
+	return s.Srv.Cosign(ctx, req)
+}
+
+func (s *ServerMock) CosignedState(ctx context.Context, req *schema.CosignedStateRequest) (*schema.CosignedState, error) {
+	return s.Srv.CosignedState(ctx, req)
+}
+
 func (s *ServerMock) History(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error) {
 	return s.Srv.History(ctx, req)
 }
@@ -268,6 +332,90 @@ func (s *ServerMock) CreateDatabaseWith(ctx context.Context, req *schema.Databas
 	return s.Srv.CreateDatabaseWith(ctx, req)
 }
 
+func (s *ServerMock) CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) (*empty.Empty, error) {
+	return s.Srv.CloneDatabase(ctx, req)
+}
+
+func (s *ServerMock) SelectiveRestore(ctx context.Context, req *schema.SelectiveRestoreRequest) (*schema.SelectiveRestoreResponse, error) {
+	return s.Srv.SelectiveRestore(ctx, req)
+}
+
+func (s *ServerMock) SetBackupSchedule(ctx context.Context, req *schema.BackupSchedule) (*empty.Empty, error) {
+	return s.Srv.SetBackupSchedule(ctx, req)
+}
+
+func (s *ServerMock) DeleteBackupSchedule(ctx context.Context, req *schema.Database) (*empty.Empty, error) {
+	return s.Srv.DeleteBackupSchedule(ctx, req)
+}
+
+func (s *ServerMock) ListBackupSchedules(ctx context.Context, req *empty.Empty) (*schema.BackupScheduleList, error) {
+	return s.Srv.ListBackupSchedules(ctx, req)
+}
+
+func (s *ServerMock) SetRetentionPolicy(ctx context.Context, req *schema.RetentionPolicy) (*empty.Empty, error) {
+	return s.Srv.SetRetentionPolicy(ctx, req)
+}
+
+func (s *ServerMock) DeleteRetentionPolicy(ctx context.Context, req *schema.Database) (*empty.Empty, error) {
+	return s.Srv.DeleteRetentionPolicy(ctx, req)
+}
+
+func (s *ServerMock) ListRetentionPolicies(ctx context.Context, req *empty.Empty) (*schema.RetentionPolicyList, error) {
+	return s.Srv.ListRetentionPolicies(ctx, req)
+}
+
+func (s *ServerMock) ListRetentionEnforcements(ctx context.Context, req *schema.Database) (*schema.RetentionEnforcementList, error) {
+	return s.Srv.ListRetentionEnforcements(ctx, req)
+}
+
+func (s *ServerMock) GenerateAuditReport(ctx context.Context, req *schema.AuditReportRequest) (*schema.AuditReport, error) {
+	return s.Srv.GenerateAuditReport(ctx, req)
+}
+
+func (s *ServerMock) ConfigHistory(ctx context.Context, req *schema.ConfigHistoryRequest) (*schema.ConfigChangeList, error) {
+	return s.Srv.ConfigHistory(ctx, req)
+}
+
+func (s *ServerMock) SetVerificationSchedule(ctx context.Context, req *schema.VerificationSchedule) (*empty.Empty, error) {
+	return s.Srv.SetVerificationSchedule(ctx, req)
+}
+
+func (s *ServerMock) DeleteVerificationSchedule(ctx context.Context, req *schema.Database) (*empty.Empty, error) {
+	return s.Srv.DeleteVerificationSchedule(ctx, req)
+}
+
+func (s *ServerMock) ListVerificationSchedules(ctx context.Context, req *empty.Empty) (*schema.VerificationScheduleList, error) {
+	return s.Srv.ListVerificationSchedules(ctx, req)
+}
+
+func (s *ServerMock) ListSessions(ctx context.Context, req *schema.ListSessionsRequest) (*schema.SessionList, error) {
+	return s.Srv.ListSessions(ctx, req)
+}
+
+func (s *ServerMock) KillSession(ctx context.Context, req *schema.KillSessionRequest) (*schema.KillSessionResponse, error) {
+	return s.Srv.KillSession(ctx, req)
+}
+
+func (s *ServerMock) GetRuntimeConfig(ctx context.Context, req *schema.GetRuntimeConfigRequest) (*schema.RuntimeConfig, error) {
+	return s.Srv.GetRuntimeConfig(ctx, req)
+}
+
+func (s *ServerMock) SetRuntimeConfig(ctx context.Context, req *schema.SetRuntimeConfigRequest) (*schema.RuntimeSetting, error) {
+	return s.Srv.SetRuntimeConfig(ctx, req)
+}
+
+func (s *ServerMock) ReplicationStatus(ctx context.Context, req *empty.Empty) (*schema.ReplicationStatusList, error) {
+	return s.Srv.ReplicationStatus(ctx, req)
+}
+
+func (s *ServerMock) DatabaseUsage(ctx context.Context, req *schema.DatabaseUsageRequest) (*schema.DatabaseUsageList, error) {
+	return s.Srv.DatabaseUsage(ctx, req)
+}
+
+func (s *ServerMock) ListVerificationRuns(ctx context.Context, req *schema.Database) (*schema.VerificationRunList, error) {
+	return s.Srv.ListVerificationRuns(ctx, req)
+}
+
 func (s *ServerMock) DatabaseList(ctx context.Context, req *empty.Empty) (*schema.DatabaseListResponse, error) {
 	return s.Srv.DatabaseList(ctx, req)
 }
@@ -288,6 +436,30 @@ func (s *ServerMock) CompactIndex(ctx context.Context, req *empty.Empty) (*empty
 	return s.Srv.CompactIndex(ctx, req)
 }
 
+func (s *ServerMock) RebuildIndex(req *empty.Empty, progressServer schema.ImmuService_RebuildIndexServer) error {
+	return s.Srv.RebuildIndex(req, progressServer)
+}
+
+func (s *ServerMock) CompactDatabase(req *schema.CompactDatabaseRequest, progressServer schema.ImmuService_CompactDatabaseServer) error {
+	return s.Srv.CompactDatabase(req, progressServer)
+}
+
+func (s *ServerMock) CollectGarbage(ctx context.Context, req *schema.CollectGarbageRequest) (*schema.CollectGarbageResponse, error) {
+	return s.Srv.CollectGarbage(ctx, req)
+}
+
+func (s *ServerMock) Backup(req *schema.BackupRequest, backupServer schema.ImmuService_BackupServer) error {
+	return s.Srv.Backup(req, backupServer)
+}
+
+func (s *ServerMock) Export(req *empty.Empty, exportServer schema.ImmuService_ExportServer) error {
+	return s.Srv.Export(req, exportServer)
+}
+
+func (s *ServerMock) Import(importServer schema.ImmuService_ImportServer) error {
+	return s.Srv.Import(importServer)
+}
+
 func (s *ServerMock) ChangePermission(ctx context.Context, req *schema.ChangePermissionRequest) (*empty.Empty, error) {
 	return s.Srv.ChangePermission(ctx, req)
 }
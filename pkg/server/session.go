@@ -9,6 +9,7 @@ import (
 	"github.com/codenotary/immudb/pkg/server/sessions"
 	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -53,6 +54,10 @@ func (s *ImmuServer) OpenSession(ctx context.Context, r *schema.OpenSessionReque
 		return nil, err
 	}
 
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		session.SetClientAddress(p.Addr.String())
+	}
+
 	return &schema.OpenSessionResponse{
 		SessionID:  session.GetID(),
 		ServerUUID: s.UUID.String(),
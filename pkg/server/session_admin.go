@@ -0,0 +1,127 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/server/sessions"
+)
+
+func sessionInfo(sess *sessions.Session) *schema.SessionInfo {
+	info := &schema.SessionInfo{
+		Id:             sess.GetID(),
+		ClientAddress:  sess.GetClientAddress(),
+		CreatedAt:      sess.GetCreationTime().Unix(),
+		LastActivityAt: sess.GetLastActivityTime().Unix(),
+	}
+
+	if user := sess.GetUser(); user != nil {
+		info.User = user.Username
+	}
+	if db := sess.GetDatabase(); db != nil {
+		info.Database = db.GetName()
+	}
+
+	return info
+}
+
+// ListSessions returns the sessions currently opened against the server, oldest first
+func (s *ImmuServer) ListSessions(ctx context.Context, req *schema.ListSessionsRequest) (*schema.SessionList, error) {
+	s.Logger.Debugf("listsessions")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.SessionList{}
+
+	for _, sess := range s.SessManager.ListSessions() {
+		info := sessionInfo(sess)
+
+		if req.User != "" && info.User != req.User {
+			continue
+		}
+
+		res.Sessions = append(res.Sessions, info)
+	}
+
+	return res, nil
+}
+
+// KillSession closes either a single session or all the sessions of a user, rolling back their ongoing transactions.
+// When killing the sessions of a user its logins are revoked as well, so tokens issued to it are no longer accepted
+func (s *ImmuServer) KillSession(ctx context.Context, req *schema.KillSessionRequest) (*schema.KillSessionResponse, error) {
+	if req == nil || (req.SessionID == "") == (req.User == "") {
+		return nil, ErrIllegalArguments
+	}
+
+	s.Logger.Debugf("killsession %s%s", req.SessionID, req.User)
+
+	admin, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.SessionID != "" {
+		err = s.SessManager.DeleteSession(req.SessionID)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Logger.Infof("session %s killed by %s", req.SessionID, admin)
+
+		return &schema.KillSessionResponse{Killed: 1}, nil
+	}
+
+	var killed uint32
+
+	for _, sess := range s.SessManager.ListSessions() {
+		if user := sess.GetUser(); user == nil || user.Username != req.User {
+			continue
+		}
+
+		err = s.SessManager.DeleteSession(sess.GetID())
+		if err == sessions.ErrSessionNotFound {
+			// the session was closed meanwhile
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		killed++
+	}
+
+	if s.Options.EtcdServer {
+		killed += uint32(s.EtcdSrv.RevokeTokens(req.User))
+	}
+
+	s.removeUserFromLoginList(req.User)
+	auth.DropTokenKeys(req.User)
+
+	s.Logger.Infof("%d session(s) of user %s killed and its logins revoked by %s", killed, req.User, admin)
+
+	return &schema.KillSessionResponse{Killed: killed}, nil
+}
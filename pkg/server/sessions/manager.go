@@ -26,6 +26,7 @@ import (
 	"github.com/rs/xid"
 	"math"
 	"os"
+	"sort"
 	"sync"
 	"time"
 )
@@ -53,7 +54,9 @@ type Manager interface {
 	StartSessionsGuard() error
 	StopSessionsGuard() error
 	GetSession(sessionID string) (*Session, error)
+	ListSessions() []*Session
 	SessionCount() int
+	SessionCountPerDatabase() map[string]int
 	GetTransactionFromContext(ctx context.Context) (transactions.Transaction, error)
 	GetSessionFromContext(ctx context.Context) (*Session, error)
 	DeleteTransaction(transactions.Transaction) error
@@ -115,6 +118,23 @@ func (sm *manager) GetSession(sessionID string) (*Session, error) {
 	return sm.sessions[sessionID], nil
 }
 
+// ListSessions returns the sessions currently opened, oldest first
+func (sm *manager) ListSessions() []*Session {
+	sm.sessionMux.RLock()
+	defer sm.sessionMux.RUnlock()
+
+	sessions := make([]*Session, 0, len(sm.sessions))
+	for _, sess := range sm.sessions {
+		sessions = append(sessions, sess)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].GetCreationTime().Before(sessions[j].GetCreationTime())
+	})
+
+	return sessions
+}
+
 func (sm *manager) DeleteSession(sessionID string) error {
 	sm.sessionMux.Lock()
 	defer sm.sessionMux.Unlock()
@@ -147,6 +167,20 @@ func (sm *manager) SessionCount() int {
 	return len(sm.sessions)
 }
 
+// SessionCountPerDatabase returns the number of sessions opened against each database
+func (sm *manager) SessionCountPerDatabase() map[string]int {
+	sm.sessionMux.RLock()
+	defer sm.sessionMux.RUnlock()
+
+	count := make(map[string]int)
+	for _, sess := range sm.sessions {
+		if db := sess.GetDatabase(); db != nil {
+			count[db.GetName()]++
+		}
+	}
+	return count
+}
+
 func (sm *manager) StartSessionsGuard() error {
 	sm.guardMux.Lock()
 	if sm.IsRunning() {
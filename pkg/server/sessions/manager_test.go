@@ -94,6 +94,14 @@ func TestManager_ExpireSessions(t *testing.T) {
 
 	wg.Wait()
 	require.Equal(t, SESS_NUMBER, m.SessionCount())
+	// sessions not bound to any database are not accounted per database
+	require.Empty(t, m.SessionCountPerDatabase())
+
+	listed := m.ListSessions()
+	require.Len(t, listed, SESS_NUMBER)
+	for i := 1; i < len(listed); i++ {
+		require.False(t, listed[i].GetCreationTime().Before(listed[i-1].GetCreationTime()))
+	}
 
 	activeDone := make(chan bool)
 	infiniteDone := make(chan bool)
@@ -47,6 +47,7 @@ type Session struct {
 	creationTime       time.Time
 	lastActivityTime   time.Time
 	readWriteTxOngoing bool
+	clientAddress      string
 	transactions       map[string]transactions.Transaction
 	log                logger.Logger
 }
@@ -228,6 +229,18 @@ func (s *Session) GetCreationTime() time.Time {
 	return s.creationTime
 }
 
+func (s *Session) GetClientAddress() string {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.clientAddress
+}
+
+func (s *Session) SetClientAddress(addr string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.clientAddress = addr
+}
+
 func (s *Session) GetReadWriteTxOngoing() bool {
 	s.mux.RLock()
 	defer s.mux.RUnlock()
@@ -34,9 +34,12 @@ func (s *ImmuServer) VerifiableSQLGet(ctx context.Context, req *schema.Verifiabl
 	}
 
 	if s.StateSigner != nil {
-		hdr := schema.TxHeaderFromProto(ventry.VerifiableTx.DualProof.TargetTxHeader)
-		alh := hdr.Alh()
+		hdr, err := schema.TxHeaderFromProto(ventry.VerifiableTx.DualProof.TargetTxHeader)
+		if err != nil {
+			return nil, err
+		}
 
+		alh := hdr.Alh()
 		newState := &schema.ImmutableState{
 			Db:     db.GetOptions().GetDBName(),
 			TxId:   hdr.ID,
@@ -64,6 +67,10 @@ func (s *ImmuServer) SQLExec(ctx context.Context, req *schema.SQLExecRequest) (*
 		return nil, err
 	}
 
+	if req != nil {
+		req.TxMetadata = s.withWriter(ctx, req.TxMetadata)
+	}
+
 	tx, ctxs, err := db.SQLExec(req, nil)
 	if err != nil {
 		return nil, err
@@ -74,6 +81,10 @@ func (s *ImmuServer) SQLExec(ctx context.Context, req *schema.SQLExecRequest) (*
 		err = ErrTxNotProperlyClosed
 	}
 
+	if err == nil && len(ctxs) > 0 && isSchemaChange(req.Sql) {
+		err = s.recordSchemaChange(ctx, db.GetName(), req.Sql)
+	}
+
 	res := &schema.SQLExecResult{
 		Txs:       make([]*schema.CommittedSQLTx, len(ctxs)),
 		OngoingTx: tx != nil && !tx.Closed(),
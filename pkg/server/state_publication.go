@@ -0,0 +1,430 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+const statePublicationRequestTimeout = 30 * time.Second
+
+// statePublicationPath is the path of the metrics server the published states are served at,
+// the state of a single database is served at <statePublicationPath>/<db_name>
+const statePublicationPath = "/states"
+
+// statePublicationSink receives the states of the databases changed since they were last published to it
+type statePublicationSink interface {
+	Name() string
+	Publish(ctx context.Context, states []*schema.ImmutableState) error
+}
+
+type publishedDatabase struct {
+	sink     string
+	database string
+}
+
+// publishedStates is the document the states are served and posted as, each state is encoded
+// as the /db/state endpoint of the API does so it can be used as the trusted state of immuverify
+type publishedStates struct {
+	PublishedAt time.Time         `json:"publishedAt"`
+	States      []json.RawMessage `json:"states"`
+}
+
+// statePublisher periodically collects the signed state of the databases, serves the latest ones
+// over HTTP without authentication and pushes the changed ones to its sinks
+type statePublisher struct {
+	interval time.Duration
+	sinks    []statePublicationSink
+
+	now    func() time.Time
+	states func() []*schema.ImmutableState
+	notify func(sink string, err error)
+
+	mu          sync.RWMutex
+	latest      map[string]*schema.ImmutableState
+	publishedAt time.Time
+
+	// last tx published to each sink
+	lastTx map[publishedDatabase]uint64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func newStatePublisher(
+	opts *StatePublicationOptions,
+	states func() []*schema.ImmutableState,
+	notify func(sink string, err error),
+) *statePublisher {
+	sp := &statePublisher{
+		now:     time.Now,
+		states:  states,
+		notify:  notify,
+		latest:  make(map[string]*schema.ImmutableState),
+		lastTx:  make(map[publishedDatabase]uint64),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	if !opts.enabled() {
+		return sp
+	}
+
+	sp.interval = opts.Interval
+
+	if opts.GitDir != "" {
+		sp.sinks = append(sp.sinks, &gitStateSink{dir: opts.GitDir, push: opts.GitPush})
+	}
+
+	if opts.TransparencyLogURL != "" {
+		sp.sinks = append(sp.sinks, &transparencyLogStateSink{
+			url:        opts.TransparencyLogURL,
+			httpClient: &http.Client{Timeout: statePublicationRequestTimeout},
+		})
+	}
+
+	return sp
+}
+
+func (sp *statePublisher) enabled() bool {
+	return sp != nil && sp.interval > 0
+}
+
+// publishAll refreshes the states served and pushes the changed ones to each sink, failures are
+// notified and retried on the next run
+func (sp *statePublisher) publishAll(ctx context.Context) {
+	states := sp.states()
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Db < states[j].Db })
+
+	latest := make(map[string]*schema.ImmutableState, len(states))
+	for _, state := range states {
+		latest[state.Db] = state
+	}
+
+	sp.mu.Lock()
+	sp.latest = latest
+	sp.publishedAt = sp.now()
+	sp.mu.Unlock()
+
+	for _, sink := range sp.sinks {
+		select {
+		case <-sp.done:
+			return
+		default:
+		}
+
+		var changed []*schema.ImmutableState
+
+		for _, state := range states {
+			if state.TxId > sp.lastTx[publishedDatabase{sink: sink.Name(), database: state.Db}] {
+				changed = append(changed, state)
+			}
+		}
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		err := sink.Publish(ctx, changed)
+		if err != nil {
+			sp.notify(sink.Name(), err)
+			continue
+		}
+
+		for _, state := range changed {
+			sp.lastTx[publishedDatabase{sink: sink.Name(), database: state.Db}] = state.TxId
+		}
+	}
+}
+
+// ServeHTTP serves the latest states published, or the one of the database named by the last
+// element of the path
+func (sp *statePublisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	sp.mu.RLock()
+	latest := sp.latest
+	publishedAt := sp.publishedAt
+	sp.mu.RUnlock()
+
+	if publishedAt.IsZero() {
+		http.Error(w, "states not published yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	dbName := strings.Trim(strings.TrimPrefix(r.URL.Path, statePublicationPath), "/")
+
+	if dbName != "" {
+		state, ok := latest[dbName]
+		if !ok {
+			http.Error(w, fmt.Sprintf("database '%s' does not exist", dbName), http.StatusNotFound)
+			return
+		}
+
+		body, err := marshalState(state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+		return
+	}
+
+	states := make([]*schema.ImmutableState, 0, len(latest))
+	for _, state := range latest {
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Db < states[j].Db })
+
+	body, err := marshalPublishedStates(publishedAt, states)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (sp *statePublisher) start() {
+	if !sp.enabled() {
+		return
+	}
+
+	sp.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			<-sp.done
+			cancel()
+		}()
+
+		go func() {
+			defer close(sp.stopped)
+
+			ticker := time.NewTicker(sp.interval)
+			defer ticker.Stop()
+
+			for {
+				sp.publishAll(ctx)
+
+				select {
+				case <-sp.done:
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	})
+}
+
+// stop cancels the publication in progress, if any
+func (sp *statePublisher) stop() {
+	if !sp.enabled() {
+		return
+	}
+
+	sp.stopOnce.Do(func() {
+		close(sp.done)
+
+		started := true
+		sp.startOnce.Do(func() { started = false })
+
+		if started {
+			<-sp.stopped
+		}
+	})
+}
+
+func marshalState(state *schema.ImmutableState) ([]byte, error) {
+	var b bytes.Buffer
+
+	err := (&jsonpb.Marshaler{Indent: "  "}).Marshal(&b, state)
+	if err != nil {
+		return nil, err
+	}
+
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+func marshalPublishedStates(publishedAt time.Time, states []*schema.ImmutableState) ([]byte, error) {
+	doc := publishedStates{
+		PublishedAt: publishedAt,
+		States:      make([]json.RawMessage, len(states)),
+	}
+
+	for i, state := range states {
+		var b bytes.Buffer
+
+		err := (&jsonpb.Marshaler{}).Marshal(&b, state)
+		if err != nil {
+			return nil, err
+		}
+
+		doc.States[i] = b.Bytes()
+	}
+
+	return json.Marshal(&doc)
+}
+
+// gitStateSink writes the state of each database to <dir>/<db_name>.json and commits the changes,
+// so the history of the repository is the history of the states. dir must be a git working tree,
+// the commits are pushed to its upstream when push is set
+type gitStateSink struct {
+	dir  string
+	push bool
+}
+
+func (g *gitStateSink) Name() string {
+	return "git:" + g.dir
+}
+
+func (g *gitStateSink) Publish(ctx context.Context, states []*schema.ImmutableState) error {
+	var files []string
+	var dbs []string
+
+	for _, state := range states {
+		body, err := marshalState(state)
+		if err != nil {
+			return err
+		}
+
+		file := state.Db + ".json"
+
+		err = ioutil.WriteFile(filepath.Join(g.dir, file), body, 0644)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, file)
+		dbs = append(dbs, fmt.Sprintf("%s@%d", state.Db, state.TxId))
+	}
+
+	err := g.git(ctx, append([]string{"add", "--"}, files...)...)
+	if err != nil {
+		return err
+	}
+
+	err = g.git(ctx, "commit", "--allow-empty", "-m", "State of "+strings.Join(dbs, ", "))
+	if err != nil {
+		return err
+	}
+
+	if !g.push {
+		return nil
+	}
+
+	return g.git(ctx, "push")
+}
+
+func (g *gitStateSink) git(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", g.dir}, args...)...)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s: %v: %s", args[0], err, bytes.TrimSpace(out))
+	}
+
+	return nil
+}
+
+// transparencyLogStateSink posts the states as json to the submission endpoint of a transparency log
+type transparencyLogStateSink struct {
+	url        string
+	httpClient *http.Client
+}
+
+func (t *transparencyLogStateSink) Name() string {
+	return t.url
+}
+
+func (t *transparencyLogStateSink) Publish(ctx context.Context, states []*schema.ImmutableState) error {
+	body, err := marshalPublishedStates(time.Now(), states)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("POST %s: got unexpected response status %s", t.url, resp.Status)
+	}
+
+	return nil
+}
+
+// signedStates returns the current state of the databases signed by the server, if it has a signing key
+func (s *ImmuServer) signedStates() []*schema.ImmutableState {
+	states := s.currentStates()
+
+	if s.StateSigner == nil {
+		return states
+	}
+
+	signed := states[:0]
+
+	for _, state := range states {
+		err := s.StateSigner.Sign(state)
+		if err != nil {
+			s.Logger.Warningf("unable to sign the state of database '%s': %v", state.Db, err)
+			continue
+		}
+
+		signed = append(signed, state)
+	}
+
+	return signed
+}
+
+func (s *ImmuServer) notifyStatePublicationFailure(sink string, err error) {
+	s.Logger.Warningf("unable to publish the state of the databases to '%s': %v", sink, err)
+}
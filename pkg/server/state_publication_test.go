@@ -0,0 +1,318 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStateSink struct {
+	name      string
+	err       error
+	published [][]*schema.ImmutableState
+}
+
+func (f *fakeStateSink) Name() string {
+	return f.name
+}
+
+func (f *fakeStateSink) Publish(ctx context.Context, states []*schema.ImmutableState) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.published = append(f.published, states)
+	return nil
+}
+
+func TestStatePublisherPublishAll(t *testing.T) {
+	states := []*schema.ImmutableState{
+		{Db: "db2", TxId: 3, TxHash: []byte("alh3")},
+		{Db: "db1", TxId: 1, TxHash: []byte("alh1")},
+	}
+
+	var failures []string
+
+	sp := newStatePublisher(
+		DefaultStatePublicationOptions(),
+		func() []*schema.ImmutableState { return states },
+		func(sink string, err error) { failures = append(failures, sink) },
+	)
+	require.False(t, sp.enabled())
+
+	sp = newStatePublisher(
+		DefaultStatePublicationOptions().WithEnabled(true),
+		func() []*schema.ImmutableState { return states },
+		func(sink string, err error) { failures = append(failures, sink) },
+	)
+	require.True(t, sp.enabled())
+	require.Empty(t, sp.sinks)
+
+	sink1 := &fakeStateSink{name: "sink1"}
+	sink2 := &fakeStateSink{name: "sink2", err: errors.New("unavailable")}
+	sp.sinks = []statePublicationSink{sink1, sink2}
+
+	sp.publishAll(context.Background())
+	require.Len(t, sink1.published, 1)
+	require.Len(t, sink1.published[0], 2)
+	require.Equal(t, "db1", sink1.published[0][0].Db)
+	require.Equal(t, "db2", sink1.published[0][1].Db)
+	require.Equal(t, []string{"sink2"}, failures)
+
+	// unchanged states are not published again, failed ones are retried
+	sink2.err = nil
+	sp.publishAll(context.Background())
+	require.Len(t, sink1.published, 1)
+	require.Len(t, sink2.published, 1)
+	require.Len(t, sink2.published[0], 2)
+
+	states[1] = &schema.ImmutableState{Db: "db1", TxId: 2, TxHash: []byte("alh2")}
+	sp.publishAll(context.Background())
+	require.Len(t, sink1.published, 2)
+	require.Len(t, sink1.published[1], 1)
+	require.Equal(t, uint64(2), sink1.published[1][0].TxId)
+}
+
+func TestStatePublisherServeHTTP(t *testing.T) {
+	states := []*schema.ImmutableState{
+		{Db: "db2", TxId: 3, TxHash: []byte("alh3")},
+		{Db: "db1", TxId: 1, TxHash: []byte("alh1")},
+	}
+
+	sp := newStatePublisher(
+		DefaultStatePublicationOptions().WithEnabled(true),
+		func() []*schema.ImmutableState { return states },
+		func(sink string, err error) {},
+	)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		sp.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	require.Equal(t, http.StatusServiceUnavailable, get("/states").Code)
+
+	sp.publishAll(context.Background())
+
+	w := get("/states")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc publishedStates
+	err := json.Unmarshal(w.Body.Bytes(), &doc)
+	require.NoError(t, err)
+	require.False(t, doc.PublishedAt.IsZero())
+	require.Len(t, doc.States, 2)
+
+	state := &schema.ImmutableState{}
+	err = jsonpb.UnmarshalString(string(doc.States[0]), state)
+	require.NoError(t, err)
+	require.Equal(t, "db1", state.Db)
+
+	w = get("/states/db2")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	state = &schema.ImmutableState{}
+	err = jsonpb.Unmarshal(w.Body, state)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), state.TxId)
+	require.Equal(t, []byte("alh3"), state.TxHash)
+
+	require.Equal(t, http.StatusNotFound, get("/states/db3").Code)
+
+	w = httptest.NewRecorder()
+	sp.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/states", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestStatePublisherStartStop(t *testing.T) {
+	var sp *statePublisher
+	sp.start()
+	sp.stop()
+
+	published := make(chan struct{}, 1)
+
+	sp = newStatePublisher(
+		DefaultStatePublicationOptions().WithEnabled(true).WithInterval(time.Millisecond),
+		func() []*schema.ImmutableState {
+			select {
+			case published <- struct{}{}:
+			default:
+			}
+			return nil
+		},
+		func(sink string, err error) {},
+	)
+
+	sp.start()
+
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "states not published")
+	}
+
+	sp.stop()
+	sp.stop()
+}
+
+func TestTransparencyLogStateSink(t *testing.T) {
+	var received publishedStates
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		err := json.NewDecoder(r.Body).Decode(&received)
+		require.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	sp := newStatePublisher(
+		DefaultStatePublicationOptions().WithEnabled(true).WithTransparencyLogURL(ts.URL),
+		nil,
+		nil,
+	)
+	require.Len(t, sp.sinks, 1)
+
+	err := sp.sinks[0].Publish(context.Background(), []*schema.ImmutableState{{Db: "db1", TxId: 1, TxHash: []byte("alh1")}})
+	require.NoError(t, err)
+	require.Len(t, received.States, 1)
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	sink := &transparencyLogStateSink{url: notFound.URL, httpClient: http.DefaultClient}
+
+	err = sink.Publish(context.Background(), []*schema.ImmutableState{{Db: "db1", TxId: 1}})
+	require.Error(t, err)
+}
+
+func TestGitStateSink(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir, err := ioutil.TempDir("", "state_publication_git")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sink := &gitStateSink{dir: dir}
+
+	err = sink.Publish(context.Background(), []*schema.ImmutableState{{Db: "db1", TxId: 1}})
+	require.Error(t, err)
+
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "immudb@localhost"},
+		{"config", "user.name", "immudb"},
+	} {
+		err = sink.git(context.Background(), args...)
+		require.NoError(t, err)
+	}
+
+	err = sink.Publish(context.Background(), []*schema.ImmutableState{
+		{Db: "db1", TxId: 1, TxHash: []byte("alh1")},
+		{Db: "db2", TxId: 2, TxHash: []byte("alh2")},
+	})
+	require.NoError(t, err)
+
+	f, err := os.Open(filepath.Join(dir, "db2.json"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	state := &schema.ImmutableState{}
+	err = jsonpb.Unmarshal(f, state)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), state.TxId)
+
+	out, err := exec.Command("git", "-C", dir, "log", "--format=%s").Output()
+	require.NoError(t, err)
+	require.Equal(t, "State of db1@1, db2@2", strings.TrimSpace(string(out)))
+}
+
+func TestServerStatePublication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_state_publication")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithSigningKey("./../../test/signer/ec1.key").
+		WithStatePublicationOptions(DefaultStatePublicationOptions().WithEnabled(true).WithInterval(0))
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = s.CloseDatabases()
+	require.NoError(t, err)
+
+	serverOptions.StatePublication.WithInterval(time.Hour)
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	require.NotNil(t, s.statesHandler())
+
+	db := s.dbList.GetByIndex(defaultDbIndex)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	s.statePublisher.publishAll(context.Background())
+
+	w := httptest.NewRecorder()
+	s.statesHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/states/"+DefaultDBName, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	state := &schema.ImmutableState{}
+	err = jsonpb.Unmarshal(w.Body, state)
+	require.NoError(t, err)
+	require.Equal(t, DefaultDBName, state.Db)
+	require.NotNil(t, state.Signature)
+
+	publicKey, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	ok, err := state.CheckSignature(publicKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -24,6 +24,8 @@ import (
 
 type StateSigner interface {
 	Sign(state *schema.ImmutableState) error
+	SignGlobalState(state *schema.GlobalState) error
+	SignAuditReport(report *schema.AuditReport) error
 }
 
 type stateSigner struct {
@@ -50,3 +52,35 @@ func (sts *stateSigner) Sign(state *schema.ImmutableState) error {
 
 	return err
 }
+
+// SignGlobalState signs the root aggregating the state of all the databases
+func (sts *stateSigner) SignGlobalState(state *schema.GlobalState) error {
+	if state == nil {
+		return store.ErrIllegalArguments
+	}
+
+	signature, publicKey, err := sts.Signer.Sign(state.ToBytes())
+
+	state.Signature = &schema.Signature{
+		Signature: signature,
+		PublicKey: publicKey,
+	}
+
+	return err
+}
+
+// SignAuditReport signs the JSON document of an audit report
+func (sts *stateSigner) SignAuditReport(report *schema.AuditReport) error {
+	if report == nil || len(report.Report) == 0 {
+		return store.ErrIllegalArguments
+	}
+
+	signature, publicKey, err := sts.Signer.Sign(report.Report)
+
+	report.Signature = &schema.Signature{
+		Signature: signature,
+		PublicKey: publicKey,
+	}
+
+	return err
+}
@@ -46,3 +46,23 @@ func TestStateSigner_Err(t *testing.T) {
 	err := stSigner.Sign(nil)
 	assert.Error(t, store.ErrIllegalArguments, err)
 }
+
+func TestStateSigner_SignAuditReport(t *testing.T) {
+	s, _ := signer.NewSigner("./../../test/signer/ec3.key")
+	stSigner := NewStateSigner(s)
+
+	err := stSigner.SignAuditReport(&schema.AuditReport{})
+	assert.ErrorIs(t, err, store.ErrIllegalArguments)
+
+	report := &schema.AuditReport{Report: []byte(`{"database":"defaultdb"}`)}
+	err = stSigner.SignAuditReport(report)
+	assert.NoError(t, err)
+	assert.NotNil(t, report.Signature)
+
+	pk, err := signer.ParsePublicKeyFile("./../../test/signer/ec3.pub")
+	assert.NoError(t, err)
+
+	ok, err := signer.Verify(report.Report, report.Signature.Signature, pk)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -42,6 +42,12 @@ func (s *ImmuServer) ExportTx(req *schema.TxRequest, txsServer schema.ImmuServic
 		return err
 	}
 
+	// the throughput of the transactions sent to replicas is shared among all of them
+	err = s.replicationLimiter.WaitN(txsServer.Context(), len(bs))
+	if err != nil {
+		return err
+	}
+
 	sender := s.StreamServiceFactory.NewMsgSender(txsServer)
 
 	err = sender.Send(bytes.NewReader(bs), len(bs))
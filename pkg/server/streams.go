@@ -100,7 +100,7 @@ func (s *ImmuServer) StreamSet(str schema.ImmuService_StreamSetServer) error {
 		kvs = append(kvs, &schema.KeyValue{Key: key, Value: value})
 	}
 
-	txMeta, err := db.Set(&schema.SetRequest{KVs: kvs})
+	txMeta, err := db.Set(&schema.SetRequest{KVs: kvs, TxMetadata: s.withWriter(str.Context(), nil)})
 	if err == store.ErrorMaxValueLenExceeded {
 		return errors.Wrap(err, stream.ErrMaxValueLenExceeded)
 	}
@@ -131,9 +131,12 @@ func (s *ImmuServer) StreamVerifiableGet(req *schema.VerifiableGetRequest, str s
 	}
 
 	if s.StateSigner != nil {
-		hdr := schema.TxHeaderFromProto(vEntry.VerifiableTx.DualProof.TargetTxHeader)
-		alh := hdr.Alh()
+		hdr, err := schema.TxHeaderFromProto(vEntry.VerifiableTx.DualProof.TargetTxHeader)
+		if err != nil {
+			return err
+		}
 
+		alh := hdr.Alh()
 		newState := &schema.ImmutableState{
 			Db:     db.GetOptions().GetDBName(),
 			TxId:   hdr.ID,
@@ -252,7 +255,7 @@ func (s *ImmuServer) StreamVerifiableSet(str schema.ImmuService_StreamVerifiable
 	}
 
 	vSetReq := schema.VerifiableSetRequest{
-		SetRequest:   &schema.SetRequest{KVs: kvs},
+		SetRequest:   &schema.SetRequest{KVs: kvs, TxMetadata: s.withWriter(str.Context(), nil)},
 		ProveSinceTx: proveSinceTx,
 	}
 	verifiableTx, err := db.VerifiableSet(&vSetReq)
@@ -266,9 +269,12 @@ func (s *ImmuServer) StreamVerifiableSet(str schema.ImmuService_StreamVerifiable
 	}
 
 	if s.StateSigner != nil {
-		hdr := schema.TxHeaderFromProto(verifiableTx.DualProof.TargetTxHeader)
-		alh := hdr.Alh()
+		hdr, err := schema.TxHeaderFromProto(verifiableTx.DualProof.TargetTxHeader)
+		if err != nil {
+			return err
+		}
 
+		alh := hdr.Alh()
 		newState := &schema.ImmutableState{
 			Db:     db.GetOptions().GetDBName(),
 			TxId:   hdr.ID,
@@ -467,7 +473,7 @@ func (s *ImmuServer) StreamExecAll(str schema.ImmuService_StreamExecAllServer) e
 		}
 	}
 
-	txMeta, err := db.ExecAll(&schema.ExecAllRequest{Operations: sops})
+	txMeta, err := db.ExecAll(&schema.ExecAllRequest{Operations: sops, TxMetadata: s.withWriter(str.Context(), nil)})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerMaintenanceThrottling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_throttling")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithMaxBackupRate(-1)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	serverOptions.
+		WithMaxBackupRate(1 << 20).
+		WithMaxReplicationRate(2 << 20)
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	require.Equal(t, int64(1<<20), s.backupLimiter.BytesPerSec())
+	require.Equal(t, int64(2<<20), s.replicationLimiter.BytesPerSec())
+
+	// the bandwidth of the next 200ms is already taken by other backups
+	err = s.backupLimiter.WaitN(context.Background(), (1<<20)+(1<<20)/5)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	err = s.runScheduledBackup(&backupSchedule{Database: DefaultDBName, Target: filepath.Join(dir, "backups")}, start)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
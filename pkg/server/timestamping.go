@@ -0,0 +1,301 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/timestamp"
+)
+
+const stateTimestampRequestTimeout = 30 * time.Second
+
+// stateTimestamp is a timestamp token of the state of a database as stored in the system database
+type stateTimestamp struct {
+	Database  string    `json:"database"`
+	TxID      uint64    `json:"txId"`
+	Alh       []byte    `json:"alh"`
+	Authority string    `json:"authority"`
+	GenTime   time.Time `json:"genTime"`
+	Token     []byte    `json:"token"`
+}
+
+// timestampAuthority issues timestamp tokens of digests, i.e. a timestamp.Client
+type timestampAuthority interface {
+	URL() string
+	Timestamp(ctx context.Context, digest []byte) (*timestamp.Token, error)
+}
+
+type timestampedDatabase struct {
+	authority string
+	database  string
+}
+
+// stateTimestamper periodically submits the Alh of the databases to the TSAs.
+// States already timestamped by an authority are not submitted again to it
+type stateTimestamper struct {
+	authorities []timestampAuthority
+	interval    time.Duration
+
+	states func() []*schema.ImmutableState
+	store  func(ts *stateTimestamp) error
+	notify func(authority string, state *schema.ImmutableState, err error)
+
+	// last tx timestamped by each authority
+	lastTx map[timestampedDatabase]uint64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func newStateTimestamper(
+	urls []string,
+	interval time.Duration,
+	states func() []*schema.ImmutableState,
+	store func(ts *stateTimestamp) error,
+	notify func(authority string, state *schema.ImmutableState, err error),
+) *stateTimestamper {
+	authorities := make([]timestampAuthority, len(urls))
+	for i, url := range urls {
+		authorities[i] = timestamp.NewClient(url, stateTimestampRequestTimeout)
+	}
+
+	return &stateTimestamper{
+		authorities: authorities,
+		interval:    interval,
+		states:      states,
+		store:       store,
+		notify:      notify,
+		lastTx:      make(map[timestampedDatabase]uint64),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+}
+
+// seed marks a state as already timestamped by an authority
+func (st *stateTimestamper) seed(ts *stateTimestamp) {
+	k := timestampedDatabase{authority: ts.Authority, database: ts.Database}
+
+	if st.lastTx[k] < ts.TxID {
+		st.lastTx[k] = ts.TxID
+	}
+}
+
+func (st *stateTimestamper) enabled() bool {
+	return st != nil && len(st.authorities) > 0
+}
+
+// timestampAll submits the current state of every database to each authority, failures are
+// notified and retried on the next run
+func (st *stateTimestamper) timestampAll(ctx context.Context) {
+	states := st.states()
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Db < states[j].Db })
+
+	for _, state := range states {
+		if state.TxId == 0 {
+			continue
+		}
+
+		for _, tsa := range st.authorities {
+			select {
+			case <-st.done:
+				return
+			default:
+			}
+
+			k := timestampedDatabase{authority: tsa.URL(), database: state.Db}
+
+			if st.lastTx[k] >= state.TxId {
+				continue
+			}
+
+			err := st.timestamp(ctx, tsa, state)
+			if err != nil {
+				st.notify(tsa.URL(), state, err)
+				continue
+			}
+
+			st.lastTx[k] = state.TxId
+		}
+	}
+}
+
+func (st *stateTimestamper) timestamp(ctx context.Context, tsa timestampAuthority, state *schema.ImmutableState) error {
+	token, err := tsa.Timestamp(ctx, state.TxHash)
+	if err != nil {
+		return err
+	}
+
+	return st.store(&stateTimestamp{
+		Database:  state.Db,
+		TxID:      state.TxId,
+		Alh:       state.TxHash,
+		Authority: tsa.URL(),
+		GenTime:   token.GenTime,
+		Token:     token.Raw,
+	})
+}
+
+func (st *stateTimestamper) start() {
+	if !st.enabled() {
+		return
+	}
+
+	st.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			<-st.done
+			cancel()
+		}()
+
+		go func() {
+			defer close(st.stopped)
+
+			ticker := time.NewTicker(st.interval)
+			defer ticker.Stop()
+
+			for {
+				st.timestampAll(ctx)
+
+				select {
+				case <-st.done:
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	})
+}
+
+// stop cancels the requests in progress, if any
+func (st *stateTimestamper) stop() {
+	if !st.enabled() {
+		return
+	}
+
+	st.stopOnce.Do(func() {
+		close(st.done)
+
+		started := true
+		st.startOnce.Do(func() { started = false })
+
+		if started {
+			<-st.stopped
+		}
+	})
+}
+
+// stateTimestampKey sorts the tokens of a database by tx, i.e. <prefix><db_name>0x00<tx_id><authority>
+func stateTimestampKey(ts *stateTimestamp) []byte {
+	key := make([]byte, 1+len(ts.Database)+1+8+len(ts.Authority))
+	key[0] = KeyPrefixStateTimestamp
+	i := 1 + copy(key[1:], ts.Database)
+	key[i] = 0
+	binary.BigEndian.PutUint64(key[i+1:], ts.TxID)
+	copy(key[i+9:], ts.Authority)
+	return key
+}
+
+// currentStates returns the state of the databases, replicas included
+func (s *ImmuServer) currentStates() []*schema.ImmutableState {
+	var states []*schema.ImmutableState
+
+	for i := 0; i < s.dbList.Length(); i++ {
+		db := s.dbList.GetByIndex(int64(i))
+
+		state, err := db.CurrentState()
+		if err != nil {
+			s.Logger.Warningf("unable to get the state of database '%s': %v", db.GetName(), err)
+			continue
+		}
+
+		state.Db = db.GetName()
+		states = append(states, state)
+	}
+
+	return states
+}
+
+func (s *ImmuServer) storeStateTimestamp(ts *stateTimestamp) error {
+	serializedTimestamp, err := json.Marshal(ts)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: stateTimestampKey(ts), Value: serializedTimestamp}}})
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Infof("state of database '%s' at tx %d timestamped by '%s' at %s", ts.Database, ts.TxID, ts.Authority, ts.GenTime.Format(time.RFC3339))
+
+	return nil
+}
+
+func (s *ImmuServer) notifyStateTimestampFailure(authority string, state *schema.ImmutableState, err error) {
+	s.Logger.Warningf("unable to timestamp the state of database '%s' at tx %d by '%s': %v", state.Db, state.TxId, authority, err)
+}
+
+// loadStateTimestamps registers into the timestamper the last states timestamped by each authority,
+// so they are not submitted again after a restart
+func (s *ImmuServer) loadStateTimestamps() error {
+	if !s.stateTimestamper.enabled() {
+		return nil
+	}
+
+	for i := 0; i < s.dbList.Length(); i++ {
+		database := s.dbList.GetByIndex(int64(i)).GetName()
+
+		prefix := make([]byte, 1+len(database)+1)
+		prefix[0] = KeyPrefixStateTimestamp
+		copy(prefix[1:], database)
+
+		// tokens are sorted by tx, the last ones hold the last state timestamped by each authority
+		entries, err := s.sysDB.Scan(&schema.ScanRequest{
+			Prefix: prefix,
+			Desc:   true,
+			Limit:  uint64(len(s.stateTimestamper.authorities)),
+			NoWait: true,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries.Entries {
+			var ts stateTimestamp
+
+			err = json.Unmarshal(e.Value, &ts)
+			if err != nil {
+				return err
+			}
+
+			s.stateTimestamper.seed(&ts)
+		}
+	}
+
+	return nil
+}
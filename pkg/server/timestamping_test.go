@@ -0,0 +1,223 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/timestamp"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTimestampAuthority struct {
+	url      string
+	err      error
+	requests int
+}
+
+func (tsa *fakeTimestampAuthority) URL() string {
+	return tsa.url
+}
+
+func (tsa *fakeTimestampAuthority) Timestamp(ctx context.Context, digest []byte) (*timestamp.Token, error) {
+	tsa.requests++
+
+	if tsa.err != nil {
+		return nil, tsa.err
+	}
+
+	return &timestamp.Token{
+		Raw:           append([]byte("token:"), digest...),
+		HashedMessage: digest,
+		GenTime:       time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func TestStateTimestamperTimestampAll(t *testing.T) {
+	states := []*schema.ImmutableState{
+		{Db: "db2", TxId: 3, TxHash: []byte("alh3")},
+		{Db: "db1", TxId: 1, TxHash: []byte("alh1")},
+		{Db: "empty"},
+	}
+
+	var stored []*stateTimestamp
+	var failures []string
+
+	st := newStateTimestamper(
+		nil,
+		time.Hour,
+		func() []*schema.ImmutableState { return states },
+		func(ts *stateTimestamp) error {
+			stored = append(stored, ts)
+			return nil
+		},
+		func(authority string, state *schema.ImmutableState, err error) {
+			failures = append(failures, authority+"/"+state.Db)
+		},
+	)
+	require.False(t, st.enabled())
+
+	tsa1 := &fakeTimestampAuthority{url: "http://tsa1"}
+	tsa2 := &fakeTimestampAuthority{url: "http://tsa2", err: errors.New("unavailable")}
+	st.authorities = []timestampAuthority{tsa1, tsa2}
+	require.True(t, st.enabled())
+
+	st.timestampAll(context.Background())
+	require.Len(t, stored, 2)
+	require.Equal(t, "db1", stored[0].Database)
+	require.Equal(t, "db2", stored[1].Database)
+	require.Equal(t, uint64(3), stored[1].TxID)
+	require.Equal(t, []byte("alh3"), stored[1].Alh)
+	require.Equal(t, "http://tsa1", stored[1].Authority)
+	require.Equal(t, []byte("token:alh3"), stored[1].Token)
+	require.Equal(t, []string{"http://tsa2/db1", "http://tsa2/db2"}, failures)
+
+	// unchanged states are not submitted again, failed ones are retried
+	tsa2.err = nil
+	st.timestampAll(context.Background())
+	require.Equal(t, 2, tsa1.requests)
+	require.Equal(t, 4, tsa2.requests)
+	require.Len(t, stored, 4)
+
+	states[1] = &schema.ImmutableState{Db: "db1", TxId: 2, TxHash: []byte("alh2")}
+	st.timestampAll(context.Background())
+	require.Equal(t, 3, tsa1.requests)
+	require.Equal(t, 5, tsa2.requests)
+	require.Len(t, stored, 6)
+}
+
+func TestStateTimestamperStartStop(t *testing.T) {
+	var st *stateTimestamper
+	st.start()
+	st.stop()
+
+	timestamped := make(chan struct{}, 1)
+
+	st = newStateTimestamper(
+		nil,
+		time.Millisecond,
+		func() []*schema.ImmutableState {
+			return []*schema.ImmutableState{{Db: "db1", TxId: 1, TxHash: []byte("alh1")}}
+		},
+		func(ts *stateTimestamp) error {
+			select {
+			case timestamped <- struct{}{}:
+			default:
+			}
+			return nil
+		},
+		func(authority string, state *schema.ImmutableState, err error) {},
+	)
+	st.authorities = []timestampAuthority{&fakeTimestampAuthority{url: "http://tsa1"}}
+
+	st.start()
+
+	select {
+	case <-timestamped:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "state not timestamped")
+	}
+
+	st.stop()
+	st.stop()
+}
+
+func TestServerStateTimestamps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_state_timestamps")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithTimestampAuthorities([]string{"http://tsa1"}).
+		WithTimestampInterval(0)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = s.CloseDatabases()
+	require.NoError(t, err)
+
+	serverOptions.WithTimestampInterval(time.Hour)
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	db := s.dbList.GetByIndex(defaultDbIndex)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	tsa := &fakeTimestampAuthority{url: "http://tsa1"}
+	s.stateTimestamper.authorities = []timestampAuthority{tsa}
+
+	s.stateTimestamper.timestampAll(context.Background())
+	require.Equal(t, 1, tsa.requests)
+
+	entry, err := s.sysDB.Get(&schema.KeyRequest{Key: stateTimestampKey(&stateTimestamp{
+		Database:  DefaultDBName,
+		TxID:      state.TxId,
+		Authority: tsa.url,
+	})})
+	require.NoError(t, err)
+
+	var ts stateTimestamp
+	err = json.Unmarshal(entry.Value, &ts)
+	require.NoError(t, err)
+	require.Equal(t, DefaultDBName, ts.Database)
+	require.Equal(t, state.TxHash, ts.Alh)
+	require.Equal(t, append([]byte("token:"), state.TxHash...), ts.Token)
+
+	// states already timestamped are not submitted again after a restart
+	err = s.CloseDatabases()
+	require.NoError(t, err)
+
+	err = s.Listener.Close()
+	require.NoError(t, err)
+
+	s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	tsa = &fakeTimestampAuthority{url: "http://tsa1"}
+	s.stateTimestamper.authorities = []timestampAuthority{tsa}
+
+	s.stateTimestamper.timestampAll(context.Background())
+	require.Zero(t, tsa.requests)
+}
@@ -103,6 +103,8 @@ func (s *ImmuServer) TxSQLExec(ctx context.Context, request *schema.SQLExecReque
 		return new(empty.Empty), ErrReadWriteTxNotOngoing
 	}
 
+	request.TxMetadata = s.withWriter(ctx, request.TxMetadata)
+
 	return new(empty.Empty), tx.SQLExec(request)
 }
 
@@ -17,6 +17,7 @@ limitations under the License.
 package server
 
 import (
+	"crypto/ecdsa"
 	"github.com/codenotary/immudb/pkg/server/sessions"
 	"math"
 	"net"
@@ -25,9 +26,13 @@ import (
 	"sync"
 
 	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/pkg/alert"
+	etcdsrv "github.com/codenotary/immudb/pkg/etcd/server"
 	pgsqlsrv "github.com/codenotary/immudb/pkg/pgsql/server"
 	"github.com/codenotary/immudb/pkg/replication"
+	respsrv "github.com/codenotary/immudb/pkg/resp/server"
 	"github.com/codenotary/immudb/pkg/stream"
+	"github.com/codenotary/immudb/pkg/throttle"
 
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/rs/xid"
@@ -58,6 +63,10 @@ type ImmuServer struct {
 	replicators      map[string]*replication.TxReplicator
 	replicationMutex sync.Mutex
 
+	// entries written to each database, counted incrementally when its usage is reported
+	entryCounts      map[string]*entryCount
+	entryCountsMutex sync.Mutex
+
 	Logger      logger.Logger
 	Options     *Options
 	Listener    net.Listener
@@ -76,10 +85,41 @@ type ImmuServer struct {
 	StateSigner          StateSigner
 	StreamServiceFactory stream.ServiceFactory
 	PgsqlSrv             pgsqlsrv.Server
+	RESPSrv              respsrv.Server
+	EtcdSrv              etcdsrv.Server
 
 	remoteStorage remotestorage.Storage
 
 	SessManager sessions.Manager
+
+	memoryBudget *memoryBudget
+
+	backupScheduler *backupScheduler
+
+	verificationScheduler *verificationScheduler
+
+	retentionEnforcer *retentionEnforcer
+
+	stateTimestamper *stateTimestamper
+
+	stateAnchorer *stateAnchorer
+
+	statePublisher *statePublisher
+
+	// public keys of the auditors allowed to cosign the state of the databases
+	cosigners []*ecdsa.PublicKey
+
+	// delivers alerts raised when the verification of a database fails, nil when no channel is configured
+	alerter alert.Alerter
+
+	// limiters of the maintenance traffic, their rate can be changed at runtime
+	backupLimiter      *throttle.Limiter
+	replicationLimiter *throttle.Limiter
+
+	// runtimeMutex serializes the changes of the options of the server made at runtime
+	runtimeMutex sync.Mutex
+	// txLogCacheSize overrides the size of the cache of the transaction log of every database, 0 when not set
+	txLogCacheSize int
 }
 
 // DefaultServer ...
@@ -542,18 +542,7 @@ func (s *ImmuServer) getValidatedUser(username []byte, password []byte) (*auth.U
 
 // getUser returns userdata (username,hashed password, permission, active) from username
 func (s *ImmuServer) getUser(username []byte, includeDeactivated bool) (*auth.User, error) {
-	key := make([]byte, 1+len(username))
-	key[0] = KeyPrefixUser
-	copy(key[1:], username)
-
-	item, err := s.sysDB.Get(&schema.KeyRequest{Key: key})
-	if err != nil {
-		return nil, err
-	}
-
-	var usr auth.User
-
-	err = json.Unmarshal(item.Value, &usr)
+	usr, err := auth.GetUser(s.sysDB, username)
 	if err != nil {
 		return nil, err
 	}
@@ -564,7 +553,7 @@ func (s *ImmuServer) getUser(username []byte, includeDeactivated bool) (*auth.Us
 		}
 	}
 
-	return &usr, nil
+	return usr, nil
 }
 
 func (s *ImmuServer) saveUser(user *auth.User) error {
@@ -573,11 +562,7 @@ func (s *ImmuServer) saveUser(user *auth.User) error {
 		return logErr(s.Logger, "error saving user: %v", err)
 	}
 
-	userKey := make([]byte, 1+len(user.Username))
-	userKey[0] = KeyPrefixUser
-	copy(userKey[1:], []byte(user.Username))
-
-	userKV := &schema.KeyValue{Key: userKey, Value: userData}
+	userKV := &schema.KeyValue{Key: auth.UserKey([]byte(user.Username)), Value: userData}
 	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{userKV}})
 
 	time.Sleep(time.Duration(10) * time.Millisecond)
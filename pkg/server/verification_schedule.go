@@ -0,0 +1,566 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// verificationSchedule is the setting of the scheduled verification of a database as stored in the system database,
+// all the transactions are verified on each run unless SampleSize is set
+type verificationSchedule struct {
+	Database   string `json:"database"`
+	Cron       string `json:"cron"`
+	SampleSize int    `json:"sampleSize"`
+
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// verificationRun is the result of a verification run as stored in the system database
+type verificationRun struct {
+	Database   string        `json:"database"`
+	SampleSize int           `json:"sampleSize"`
+	FromTx     uint64        `json:"fromTx"`
+	ToTx       uint64        `json:"toTx"`
+	Checked    uint64        `json:"checked"`
+	Verified   bool          `json:"verified"`
+	Error      string        `json:"error,omitempty"`
+	StartedAt  time.Time     `json:"startedAt"`
+	Duration   time.Duration `json:"duration"`
+}
+
+type scheduledVerification struct {
+	verificationSchedule
+
+	cron      *cronSchedule
+	nextRunAt time.Time
+	lastRunAt time.Time
+	lastError error
+}
+
+// verificationScheduler verifies databases at the times set by their schedules.
+// Verifications are run one at a time, runs missed while a previous verification was in progress are skipped
+type verificationScheduler struct {
+	verifications map[string]*scheduledVerification
+	mutex         sync.Mutex
+
+	now func() time.Time
+	run func(sch *verificationSchedule, at time.Time) error
+
+	wakeup    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func newVerificationScheduler(run func(sch *verificationSchedule, at time.Time) error) *verificationScheduler {
+	return &verificationScheduler{
+		verifications: make(map[string]*scheduledVerification),
+		now:           time.Now,
+		run:           run,
+		wakeup:        make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// set adds or replaces the schedule of a database
+func (vs *verificationScheduler) set(sch *verificationSchedule) error {
+	cron, err := parseCron(sch.Cron)
+	if err != nil {
+		return err
+	}
+
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	v := &scheduledVerification{
+		verificationSchedule: *sch,
+		cron:                 cron,
+		nextRunAt:            cron.next(vs.now()),
+	}
+
+	if prev, ok := vs.verifications[sch.Database]; ok {
+		v.lastRunAt = prev.lastRunAt
+		v.lastError = prev.lastError
+	}
+
+	vs.verifications[sch.Database] = v
+
+	vs.signal()
+
+	return nil
+}
+
+func (vs *verificationScheduler) remove(database string) bool {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	_, ok := vs.verifications[database]
+	delete(vs.verifications, database)
+
+	return ok
+}
+
+// list returns a copy of the scheduled verifications sorted by database name
+func (vs *verificationScheduler) list() []scheduledVerification {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	verifications := make([]scheduledVerification, 0, len(vs.verifications))
+	for _, v := range vs.verifications {
+		verifications = append(verifications, *v)
+	}
+
+	sort.Slice(verifications, func(i, j int) bool { return verifications[i].Database < verifications[j].Database })
+
+	return verifications
+}
+
+func (vs *verificationScheduler) signal() {
+	select {
+	case vs.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// runDue runs the verifications whose time has come and returns when the next one is due,
+// a zero time is returned when there are no scheduled verifications
+func (vs *verificationScheduler) runDue() time.Time {
+	now := vs.now()
+
+	var due []scheduledVerification
+
+	vs.mutex.Lock()
+	for _, v := range vs.verifications {
+		if !v.nextRunAt.IsZero() && !v.nextRunAt.After(now) {
+			due = append(due, *v)
+			v.nextRunAt = v.cron.next(now)
+		}
+	}
+	vs.mutex.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].Database < due[j].Database })
+
+	for _, v := range due {
+		select {
+		case <-vs.done:
+			return time.Time{}
+		default:
+		}
+
+		err := vs.run(&v.verificationSchedule, vs.now())
+
+		vs.mutex.Lock()
+		// the schedule may have been removed while the verification was running
+		if curr, ok := vs.verifications[v.Database]; ok {
+			curr.lastRunAt = now
+			curr.lastError = err
+		}
+		vs.mutex.Unlock()
+	}
+
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	var next time.Time
+	for _, v := range vs.verifications {
+		if !v.nextRunAt.IsZero() && (next.IsZero() || v.nextRunAt.Before(next)) {
+			next = v.nextRunAt
+		}
+	}
+
+	return next
+}
+
+func (vs *verificationScheduler) start() {
+	if vs == nil {
+		return
+	}
+
+	vs.startOnce.Do(func() {
+		go func() {
+			defer close(vs.stopped)
+
+			for {
+				next := vs.runDue()
+
+				var timeout <-chan time.Time
+				if !next.IsZero() {
+					timer := time.NewTimer(next.Sub(vs.now()))
+					defer timer.Stop()
+					timeout = timer.C
+				}
+
+				select {
+				case <-vs.done:
+					return
+				case <-vs.wakeup:
+				case <-timeout:
+				}
+			}
+		}()
+	})
+}
+
+// stop waits for the verification in progress, if any, to be completed
+func (vs *verificationScheduler) stop() {
+	if vs == nil {
+		return
+	}
+
+	vs.stopOnce.Do(func() {
+		close(vs.done)
+
+		started := true
+		vs.startOnce.Do(func() { started = false })
+
+		if started {
+			<-vs.stopped
+		}
+	})
+}
+
+func verificationScheduleKey(database string) []byte {
+	key := make([]byte, 1+len(database))
+	key[0] = KeyPrefixVerificationSchedule
+	copy(key[1:], database)
+	return key
+}
+
+// verificationRunPrefix returns the prefix of the verification runs of a database
+func verificationRunPrefix(database string) []byte {
+	key := make([]byte, 1+len(database)+1, 1+len(database)+1+8)
+	key[0] = KeyPrefixVerificationRun
+	i := 1 + copy(key[1:], database)
+	key[i] = 0
+	return key
+}
+
+// verificationRunKey sorts the runs of a database by time, i.e. <prefix><db_name>0x00<started_at>
+func verificationRunKey(run *verificationRun) []byte {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(run.StartedAt.UnixNano()))
+	return append(verificationRunPrefix(run.Database), ts[:]...)
+}
+
+// verifyDatabase checks the transactions of the database are stored as committed and linked to each other.
+// All of them are checked when sampleSize is not positive or not lower than the number of transactions,
+// otherwise sampleSize transactions picked at random are checked along with their link to the previous one
+func verifyDatabase(db database.DB, sampleSize int, rnd *rand.Rand) *verificationRun {
+	run := &verificationRun{
+		Database:   db.GetName(),
+		SampleSize: sampleSize,
+	}
+
+	state, err := db.CurrentState()
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+
+	if sampleSize <= 0 || uint64(sampleSize) >= state.TxId {
+		run.SampleSize = 0
+
+		audit, err := db.AuditTxs(1, state.TxId, nil)
+		if audit != nil {
+			run.FromTx = audit.FromTx
+			run.ToTx = audit.ToTx
+			run.Checked = audit.ToTx + 1 - audit.FromTx
+		}
+		if err != nil {
+			run.Error = err.Error()
+			return run
+		}
+
+		run.Verified = true
+		return run
+	}
+
+	picked := make(map[uint64]struct{}, sampleSize)
+	for len(picked) < sampleSize {
+		picked[1+uint64(rnd.Int63n(int64(state.TxId)))] = struct{}{}
+	}
+
+	txIDs := make([]uint64, 0, sampleSize)
+	for txID := range picked {
+		txIDs = append(txIDs, txID)
+	}
+
+	sort.Slice(txIDs, func(i, j int) bool { return txIDs[i] < txIDs[j] })
+
+	run.FromTx = txIDs[0]
+	run.ToTx = txIDs[len(txIDs)-1]
+
+	for _, txID := range txIDs {
+		_, err := db.AuditTxs(txID, txID, nil)
+		if err != nil {
+			run.Error = fmt.Sprintf("verification of tx %d failed: %v", txID, err)
+			return run
+		}
+
+		run.Checked++
+	}
+
+	run.Verified = true
+	return run
+}
+
+// loadVerificationSchedules registers into the scheduler the schedules stored in the system database
+func (s *ImmuServer) loadVerificationSchedules() error {
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: []byte{KeyPrefixVerificationSchedule},
+		NoWait: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries.Entries {
+		var sch verificationSchedule
+
+		err = json.Unmarshal(e.Value, &sch)
+		if err != nil {
+			return err
+		}
+
+		err = s.verificationScheduler.set(&sch)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetVerificationSchedule adds or replaces the schedule of the verifications of a database, the result of
+// every run is kept in the system database
+func (s *ImmuServer) SetVerificationSchedule(ctx context.Context, req *schema.VerificationSchedule) (*empty.Empty, error) {
+	s.Logger.Debugf("setverificationschedule")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	username, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.dbList.GetId(req.Database) < 0 {
+		return nil, fmt.Errorf("database '%s' does not exist", req.Database)
+	}
+
+	_, err = parseCron(req.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	sch := &verificationSchedule{
+		Database:   req.Database,
+		Cron:       req.Cron,
+		SampleSize: int(req.SampleSize),
+		CreatedBy:  username,
+		CreatedAt:  time.Now(),
+	}
+
+	serializedSchedule, err := json.Marshal(sch)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: verificationScheduleKey(sch.Database), Value: serializedSchedule}}})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.verificationScheduler.set(sch)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("verifications of database '%s' scheduled at '%s'", sch.Database, sch.Cron)
+
+	return &empty.Empty{}, nil
+}
+
+// DeleteVerificationSchedule stops verifying a database on a schedule, the results of past runs are kept
+func (s *ImmuServer) DeleteVerificationSchedule(ctx context.Context, req *schema.Database) (*empty.Empty, error) {
+	s.Logger.Debugf("deleteverificationschedule")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.verificationScheduler.remove(req.DatabaseName) {
+		return nil, ErrVerificationScheduleNotFound
+	}
+
+	_, err = s.sysDB.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{verificationScheduleKey(req.DatabaseName)}})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("scheduled verifications of database '%s' removed", req.DatabaseName)
+
+	return &empty.Empty{}, nil
+}
+
+// ListVerificationSchedules returns the schedules of verifications along with the outcome of their last run
+func (s *ImmuServer) ListVerificationSchedules(ctx context.Context, _ *empty.Empty) (*schema.VerificationScheduleList, error) {
+	s.Logger.Debugf("listverificationschedules")
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.VerificationScheduleList{}
+
+	for _, v := range s.verificationScheduler.list() {
+		sch := &schema.VerificationSchedule{
+			Database:   v.Database,
+			Cron:       v.Cron,
+			SampleSize: uint32(v.SampleSize),
+		}
+
+		if !v.lastRunAt.IsZero() {
+			sch.LastRunAt = v.lastRunAt.Unix()
+		}
+		if v.lastError != nil {
+			sch.LastError = v.lastError.Error()
+		}
+
+		res.Schedules = append(res.Schedules, sch)
+	}
+
+	return res, nil
+}
+
+// ListVerificationRuns returns the results of the verifications of a database, oldest first
+func (s *ImmuServer) ListVerificationRuns(ctx context.Context, req *schema.Database) (*schema.VerificationRunList, error) {
+	s.Logger.Debugf("listverificationruns")
+
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := s.checkSysAdminAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{
+		Prefix: verificationRunPrefix(req.DatabaseName),
+		NoWait: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.VerificationRunList{}
+
+	for _, e := range entries.Entries {
+		var run verificationRun
+
+		err = json.Unmarshal(e.Value, &run)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Runs = append(res.Runs, &schema.VerificationRun{
+			Database:   run.Database,
+			SampleSize: uint32(run.SampleSize),
+			FromTx:     run.FromTx,
+			ToTx:       run.ToTx,
+			Checked:    run.Checked,
+			Verified:   run.Verified,
+			Error:      run.Error,
+			StartedAt:  run.StartedAt.Unix(),
+			Duration:   run.Duration.Milliseconds(),
+		})
+	}
+
+	return res, nil
+}
+
+// runScheduledVerification verifies the database and records the result of the run in the system database,
+// an alert is raised when the verification fails
+func (s *ImmuServer) runScheduledVerification(sch *verificationSchedule, at time.Time) error {
+	dbID := s.dbList.GetId(sch.Database)
+	if dbID < 0 {
+		return fmt.Errorf("database '%s' does not exist", sch.Database)
+	}
+
+	db := s.dbList.GetByIndex(dbID)
+
+	run := verifyDatabase(db, sch.SampleSize, rand.New(rand.NewSource(at.UnixNano())))
+	run.StartedAt = at
+	run.Duration = time.Since(at)
+
+	serializedRun, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: verificationRunKey(run), Value: serializedRun}}})
+	if err != nil {
+		return err
+	}
+
+	if !run.Verified {
+		s.Logger.Errorf("scheduled verification of database '%s' failed: %s", sch.Database, run.Error)
+
+		s.raiseAlert(&alert.Alert{
+			Kind:    alert.Corruption,
+			Source:  "scheduled verification",
+			DB:      sch.Database,
+			FromTx:  run.FromTx,
+			ToTx:    run.ToTx,
+			Message: fmt.Sprintf("verification of txs %d-%d failed", run.FromTx, run.ToTx),
+			Evidence: map[string]string{
+				"error": run.Error,
+			},
+			RaisedAt: time.Now(),
+		})
+
+		return fmt.Errorf("%w: %s", ErrVerificationFailed, run.Error)
+	}
+
+	s.Logger.Infof("scheduled verification of database '%s' completed, %d txs verified", sch.Database, run.Checked)
+
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestVerificationSchedulerRunDue(t *testing.T) {
+	now := time.Date(2022, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	var runs []string
+
+	vs := newVerificationScheduler(func(sch *verificationSchedule, at time.Time) error {
+		runs = append(runs, sch.Database)
+		if sch.Database == "db2" {
+			return errors.New("tx 3 is corrupted")
+		}
+		return nil
+	})
+	vs.now = func() time.Time { return now }
+
+	require.Zero(t, vs.runDue())
+
+	err := vs.set(&verificationSchedule{Database: "db1", Cron: "@hourly"})
+	require.NoError(t, err)
+
+	err = vs.set(&verificationSchedule{Database: "db2", Cron: "*/15 * * * *", SampleSize: 10})
+	require.NoError(t, err)
+
+	err = vs.set(&verificationSchedule{Database: "db3", Cron: "invalid"})
+	require.ErrorIs(t, err, ErrInvalidCronExpression)
+
+	next := vs.runDue()
+	require.Equal(t, now.Add(15*time.Minute), next)
+	require.Empty(t, runs)
+
+	now = next
+	vs.runDue()
+	now = now.Add(15 * time.Minute)
+	require.Equal(t, now.Add(15*time.Minute), vs.runDue())
+	require.Equal(t, []string{"db2", "db1", "db2"}, runs)
+
+	verifications := vs.list()
+	require.Len(t, verifications, 2)
+	require.Equal(t, "db1", verifications[0].Database)
+	require.Equal(t, now, verifications[0].lastRunAt)
+	require.NoError(t, verifications[0].lastError)
+	require.Equal(t, "db2", verifications[1].Database)
+	require.EqualError(t, verifications[1].lastError, "tx 3 is corrupted")
+
+	require.True(t, vs.remove("db2"))
+	require.False(t, vs.remove("db2"))
+
+	require.True(t, vs.remove("db1"))
+	require.Zero(t, vs.runDue())
+
+	var nilScheduler *verificationScheduler
+	nilScheduler.start()
+	nilScheduler.stop()
+}
+
+func TestServerVerificationSchedule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_verification_schedule")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		s.Listener.Close()
+		s.CloseDatabases()
+	})
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	for i := 0; i < 10; i++ {
+		_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(fmt.Sprintf("key%d", i)), Value: []byte("value")}}})
+		require.NoError(t, err)
+	}
+
+	state, err := s.CurrentState(ctx, &empty.Empty{})
+	require.NoError(t, err)
+
+	_, err = s.SetVerificationSchedule(ctx, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.SetVerificationSchedule(ctx, &schema.VerificationSchedule{Database: "unknown", Cron: "@daily"})
+	require.Error(t, err)
+
+	_, err = s.SetVerificationSchedule(ctx, &schema.VerificationSchedule{Database: DefaultDBName, Cron: "invalid"})
+	require.ErrorIs(t, err, ErrInvalidCronExpression)
+
+	_, err = s.SetVerificationSchedule(ctx, &schema.VerificationSchedule{Database: DefaultDBName, Cron: "@daily", SampleSize: 5})
+	require.NoError(t, err)
+
+	schedules, err := s.ListVerificationSchedules(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Len(t, schedules.Schedules, 1)
+	require.Equal(t, uint32(5), schedules.Schedules[0].SampleSize)
+	require.Zero(t, schedules.Schedules[0].LastRunAt)
+
+	sch := &s.verificationScheduler.list()[0].verificationSchedule
+
+	err = s.runScheduledVerification(sch, time.Now())
+	require.NoError(t, err)
+
+	// all the transactions are verified when the sample is not lower than their number
+	err = s.runScheduledVerification(&verificationSchedule{Database: DefaultDBName, SampleSize: 1000}, time.Now())
+	require.NoError(t, err)
+
+	err = s.runScheduledVerification(&verificationSchedule{Database: "unknown"}, time.Now())
+	require.Error(t, err)
+
+	runs, err := s.ListVerificationRuns(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.NoError(t, err)
+	require.Len(t, runs.Runs, 2)
+
+	require.Equal(t, uint32(5), runs.Runs[0].SampleSize)
+	require.Equal(t, uint64(5), runs.Runs[0].Checked)
+	require.True(t, runs.Runs[0].Verified)
+	require.GreaterOrEqual(t, runs.Runs[0].FromTx, uint64(1))
+	require.LessOrEqual(t, runs.Runs[0].ToTx, state.TxId)
+
+	require.Zero(t, runs.Runs[1].SampleSize)
+	require.Equal(t, uint64(1), runs.Runs[1].FromTx)
+	require.Equal(t, state.TxId, runs.Runs[1].ToTx)
+	require.Equal(t, state.TxId, runs.Runs[1].Checked)
+	require.True(t, runs.Runs[1].Verified)
+	require.Empty(t, runs.Runs[1].Error)
+
+	_, err = s.DeleteVerificationSchedule(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.NoError(t, err)
+
+	_, err = s.DeleteVerificationSchedule(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.ErrorIs(t, err, ErrVerificationScheduleNotFound)
+
+	// the schedules are loaded back on restart
+	_, err = s.SetVerificationSchedule(ctx, &schema.VerificationSchedule{Database: DefaultDBName, Cron: "@hourly"})
+	require.NoError(t, err)
+
+	s.verificationScheduler = newVerificationScheduler(s.runScheduledVerification)
+
+	err = s.loadVerificationSchedules()
+	require.NoError(t, err)
+	require.Len(t, s.verificationScheduler.list(), 1)
+	require.Equal(t, "@hourly", s.verificationScheduler.list()[0].Cron)
+
+	// past results are kept when the schedule is removed
+	runs, err = s.ListVerificationRuns(ctx, &schema.Database{DatabaseName: DefaultDBName})
+	require.NoError(t, err)
+	require.Len(t, runs.Runs, 2)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// wormActivation is the record of the WORM compliance mode being enabled or extended on a database as
+// stored in the system database, PreviousRetainUntil is zero when the compliance mode is enabled
+type wormActivation struct {
+	Database            string    `json:"database"`
+	RetainUntil         int64     `json:"retainUntil"`
+	PreviousRetainUntil int64     `json:"previousRetainUntil"`
+	SetBy               string    `json:"setBy"`
+	SetAt               time.Time `json:"setAt"`
+}
+
+// wormActivationKey sorts the activations of a database by time, i.e. <prefix><db_name>0x00<set_at>
+func wormActivationKey(a *wormActivation) []byte {
+	key := make([]byte, 1+len(a.Database)+1+8)
+	key[0] = KeyPrefixWORMActivation
+	i := 1 + copy(key[1:], a.Database)
+	key[i] = 0
+	binary.BigEndian.PutUint64(key[i+1:], uint64(a.SetAt.UnixNano()))
+	return key
+}
+
+// recordWORMActivation records in the system database who enabled or extended the compliance mode of a database.
+// Entries of the system database can not be modified, so the record can not be tampered with
+func (s *ImmuServer) recordWORMActivation(ctx context.Context, database string, prevRetainUntil, retainUntil int64) error {
+	activation := &wormActivation{
+		Database:            database,
+		RetainUntil:         retainUntil,
+		PreviousRetainUntil: prevRetainUntil,
+		SetAt:               time.Now(),
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err == nil {
+		activation.SetBy = user.Username
+	}
+
+	serializedActivation, err := json.Marshal(activation)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: wormActivationKey(activation), Value: serializedActivation}}})
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Infof("WORM compliance mode of database '%s' set until %s by '%s'",
+		database, time.Unix(retainUntil, 0).UTC().Format(time.RFC3339), activation.SetBy)
+
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerWORMComplianceMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_worm")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	retainUntil := time.Now().Add(time.Hour).Unix()
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "wormdb", WormRetainUntil: time.Now().Add(-time.Hour).Unix()})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "wormdb", WormRetainUntil: retainUntil})
+	require.NoError(t, err)
+
+	ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "wormdb"})
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	settings, err := s.GetDatabaseSettings(dbCtx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, retainUntil, settings.WormRetainUntil)
+
+	_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("pii/alice"), Value: []byte("alice data")}}})
+	require.NoError(t, err)
+
+	_, err = s.Erase(dbCtx, &schema.EraseRequest{Key: []byte("pii/alice"), Reason: "right to be forgotten"})
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	history, err := s.ErasureHistory(dbCtx, &schema.ErasureHistoryRequest{Key: []byte("pii/alice")})
+	require.NoError(t, err)
+	require.Empty(t, history.Erasures)
+
+	// not even the system administrator can delete data or let it expire before the retention date
+	_, err = s.Delete(dbCtx, &schema.DeleteKeysRequest{Keys: [][]byte{[]byte("pii/alice")}})
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{
+		Key:      []byte("pii/alice"),
+		Value:    []byte("alice data"),
+		Metadata: &schema.KVMetadata{Expiration: &schema.Expiration{ExpiresAt: retainUntil - 60}},
+	}}})
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	_, err = s.SQLExec(dbCtx, &schema.SQLExecRequest{Sql: `
+		CREATE TABLE trades(id INTEGER, PRIMARY KEY id);
+		INSERT INTO trades(id) VALUES (1);
+	`})
+	require.NoError(t, err)
+
+	_, err = s.SQLExec(dbCtx, &schema.SQLExecRequest{Sql: "DELETE FROM trades"})
+	require.ErrorIs(t, err, store.ErrRetentionLocked)
+
+	// the compliance mode can neither be disabled nor shortened
+	_, err = s.UpdateDatabase(ctx, &schema.DatabaseSettings{DatabaseName: "wormdb", WormRetainUntil: retainUntil - 60})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.UpdateDatabase(ctx, &schema.DatabaseSettings{DatabaseName: "wormdb"})
+	require.NoError(t, err)
+
+	settings, err = s.GetDatabaseSettings(dbCtx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, retainUntil, settings.WormRetainUntil)
+
+	_, err = s.UpdateDatabase(ctx, &schema.DatabaseSettings{DatabaseName: "wormdb", WormRetainUntil: retainUntil + 7200})
+	require.NoError(t, err)
+
+	db, err := s.dbList.GetByName("wormdb")
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(retainUntil+7200, 0), db.RetainUntil())
+
+	// retention policies are not enforced before the retention date
+	_, err = s.SetRetentionPolicy(ctx, &schema.RetentionPolicy{
+		Database: "wormdb",
+		Rules:    []*schema.RetentionRule{{Prefix: []byte("pii/"), ErasableAfter: 60}},
+	})
+	require.NoError(t, err)
+
+	s.retentionEnforcer.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	s.retentionEnforcer.enforceAll()
+
+	entry, err := s.Get(dbCtx, &schema.KeyRequest{Key: []byte("pii/alice")})
+	require.NoError(t, err)
+	require.False(t, entry.Erased)
+
+	enforcements, err := s.ListRetentionEnforcements(ctx, &schema.Database{DatabaseName: "wormdb"})
+	require.NoError(t, err)
+	require.Empty(t, enforcements.Enforcements)
+
+	// enabling and extending the compliance mode are recorded
+	entries, err := s.sysDB.Scan(&schema.ScanRequest{Prefix: []byte{KeyPrefixWORMActivation}, NoWait: true})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+
+	var activation wormActivation
+
+	err = json.Unmarshal(entries.Entries[1].Value, &activation)
+	require.NoError(t, err)
+	require.Equal(t, "wormdb", activation.Database)
+	require.Equal(t, retainUntil, activation.PreviousRetainUntil)
+	require.Equal(t, retainUntil+7200, activation.RetainUntil)
+	require.Equal(t, auth.SysAdminUsername, activation.SetBy)
+}
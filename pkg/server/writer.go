@@ -0,0 +1,46 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// withWriter attributes a transaction to the logged in user, the writer is part of the tx header
+// and thus covered by the tx hash. Any writer sent by the client is replaced, so a transaction can
+// not be attributed to a different user
+func (s *ImmuServer) withWriter(ctx context.Context, md *schema.TxMetadata) *schema.TxMetadata {
+	var writer string
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err == nil {
+		writer = user.Username
+	}
+
+	if md == nil {
+		if writer == "" {
+			return nil
+		}
+		md = &schema.TxMetadata{}
+	}
+
+	md.Writer = writer
+
+	return md
+}
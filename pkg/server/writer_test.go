@@ -0,0 +1,118 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerTxWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server_tx_writer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverOptions := DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.CloseDatabases()
+	defer s.Listener.Close()
+
+	require.Nil(t, s.withWriter(context.Background(), nil))
+	require.Empty(t, s.withWriter(context.Background(), &schema.TxMetadata{Writer: "someone"}).Writer)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	requireWriter := func(hdr *schema.TxHeader, annotations map[string]string) {
+		tx, err := s.TxById(ctx, &schema.TxRequest{Tx: hdr.Id})
+		require.NoError(t, err)
+		require.Equal(t, auth.SysAdminUsername, tx.Header.Metadata.Writer)
+		if len(annotations) == 0 {
+			require.Empty(t, tx.Header.Metadata.Annotations)
+		} else {
+			require.Equal(t, annotations, tx.Header.Metadata.Annotations)
+		}
+	}
+
+	// the writer set by the client is replaced by the logged in user
+	hdr, err := s.Set(ctx, &schema.SetRequest{
+		KVs:        []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}},
+		TxMetadata: &schema.TxMetadata{Annotations: map[string]string{"source": "test"}, Writer: "someone"},
+	})
+	require.NoError(t, err)
+	requireWriter(hdr, map[string]string{"source": "test"})
+
+	hdr, err = s.SetReference(ctx, &schema.ReferenceRequest{Key: []byte("ref1"), ReferencedKey: []byte("key1")})
+	require.NoError(t, err)
+	requireWriter(hdr, nil)
+
+	hdr, err = s.ZAdd(ctx, &schema.ZAddRequest{Set: []byte("set1"), Score: 1, Key: []byte("key1")})
+	require.NoError(t, err)
+	requireWriter(hdr, nil)
+
+	hdr, err = s.ExecAll(ctx, &schema.ExecAllRequest{Operations: []*schema.Op{
+		{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{Key: []byte("key2"), Value: []byte("value2")}}},
+	}})
+	require.NoError(t, err)
+	requireWriter(hdr, nil)
+
+	hdr, err = s.Delete(ctx, &schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key2")}})
+	require.NoError(t, err)
+	requireWriter(hdr, nil)
+
+	for _, stmt := range []string{
+		"CREATE TABLE table1(id INTEGER, PRIMARY KEY id);",
+		"INSERT INTO table1(id) VALUES (1);",
+	} {
+		res, err := s.SQLExec(ctx, &schema.SQLExecRequest{Sql: stmt})
+		require.NoError(t, err)
+		require.Len(t, res.Txs, 1)
+		requireWriter(res.Txs[0].Header, nil)
+	}
+
+	// the writer does not take space from the annotations
+	annotations := map[string]string{"k": string(bytes.Repeat([]byte("v"), 252))}
+
+	hdr, err = s.Set(ctx, &schema.SetRequest{
+		KVs:        []*schema.KeyValue{{Key: []byte("key3"), Value: []byte("value3")}},
+		TxMetadata: &schema.TxMetadata{Annotations: annotations},
+	})
+	require.NoError(t, err)
+	requireWriter(hdr, annotations)
+}
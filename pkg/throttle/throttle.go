@@ -0,0 +1,162 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package throttle limits the throughput of maintenance traffic, i.e. backups and replication,
+// so it doesn't starve the requests served on the same hardware.
+package throttle
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxBurst is the time unused bandwidth is accumulated for
+const maxBurst = time.Second
+
+// Limiter limits the rate of the bytes reserved by all its users. A nil Limiter, or one whose rate is 0, has no limit.
+type Limiter struct {
+	bytesPerSec int64
+
+	// next is the time the bytes reserved so far are transferred by
+	next time.Time
+	now  func() time.Time
+
+	mutex sync.Mutex
+}
+
+// NewLimiter returns a limiter of bytesPerSec bytes per second, nil is returned when bytesPerSec is not positive
+func NewLimiter(bytesPerSec int64) *Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+
+	return &Limiter{
+		bytesPerSec: bytesPerSec,
+		now:         time.Now,
+	}
+}
+
+// NewAdjustableLimiter returns a limiter of bytesPerSec bytes per second whose rate can be changed later on,
+// it has no limit while the rate is not positive
+func NewAdjustableLimiter(bytesPerSec int64) *Limiter {
+	if bytesPerSec < 0 {
+		bytesPerSec = 0
+	}
+
+	return &Limiter{
+		bytesPerSec: bytesPerSec,
+		now:         time.Now,
+	}
+}
+
+// BytesPerSec returns the rate of the limiter, 0 means unlimited
+func (l *Limiter) BytesPerSec() int64 {
+	if l == nil {
+		return 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.bytesPerSec
+}
+
+// SetBytesPerSec changes the rate of the limiter, a rate not positive removes the limit.
+// Transfers already reserved are not rescheduled.
+func (l *Limiter) SetBytesPerSec(bytesPerSec int64) {
+	if bytesPerSec < 0 {
+		bytesPerSec = 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.bytesPerSec = bytesPerSec
+}
+
+// WaitN blocks until n bytes can be transferred without exceeding the rate or ctx is done.
+// Transfers larger than the rate are allowed, the following ones are delayed accordingly.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	delay := l.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve books the transfer of n bytes and returns how long to wait before starting it
+func (l *Limiter) reserve(n int) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.bytesPerSec == 0 {
+		return 0
+	}
+
+	now := l.now()
+
+	if earliest := now.Add(-maxBurst); l.next.Before(earliest) {
+		l.next = earliest
+	}
+
+	delay := l.next.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.bytesPerSec))
+
+	return delay
+}
+
+type writer struct {
+	ctx     context.Context
+	w       io.Writer
+	limiter *Limiter
+}
+
+// NewWriter returns a writer of w whose throughput is limited by limiter, w is returned when limiter is nil
+func NewWriter(ctx context.Context, w io.Writer, limiter *Limiter) io.Writer {
+	if limiter == nil {
+		return w
+	}
+
+	return &writer{ctx: ctx, w: w, limiter: limiter}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	err := w.limiter.WaitN(w.ctx, len(p))
+	if err != nil {
+		return 0, err
+	}
+
+	return w.w.Write(p)
+}
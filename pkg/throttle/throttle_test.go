@@ -0,0 +1,127 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnlimited(t *testing.T) {
+	require.Nil(t, NewLimiter(0))
+	require.Nil(t, NewLimiter(-1))
+
+	var l *Limiter
+	require.Equal(t, int64(0), l.BytesPerSec())
+	require.NoError(t, l.WaitN(context.Background(), 1<<30))
+
+	var b bytes.Buffer
+	require.Equal(t, &b, NewWriter(context.Background(), &b, nil))
+}
+
+func TestLimiterReserve(t *testing.T) {
+	l := NewLimiter(1000)
+	require.Equal(t, int64(1000), l.BytesPerSec())
+
+	now := time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	// bandwidth unused up to a second is available right away
+	require.Equal(t, time.Duration(0), l.reserve(500))
+	require.Equal(t, time.Duration(0), l.reserve(500))
+
+	require.Equal(t, time.Duration(0), l.reserve(2000))
+	require.Equal(t, 2*time.Second, l.reserve(100))
+	require.Equal(t, 2100*time.Millisecond, l.reserve(100))
+
+	// bandwidth is not accumulated for more than a second
+	now = now.Add(time.Hour)
+
+	require.Equal(t, time.Duration(0), l.reserve(1000))
+	require.Equal(t, time.Duration(0), l.reserve(1))
+	require.Equal(t, time.Millisecond, l.reserve(1))
+}
+
+func TestAdjustableLimiter(t *testing.T) {
+	l := NewAdjustableLimiter(-1)
+	require.NotNil(t, l)
+	require.Equal(t, int64(0), l.BytesPerSec())
+
+	now := time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	require.Equal(t, time.Duration(0), l.reserve(1<<30))
+
+	l.SetBytesPerSec(1000)
+	require.Equal(t, int64(1000), l.BytesPerSec())
+
+	require.Equal(t, time.Duration(0), l.reserve(2000))
+	require.Equal(t, time.Second, l.reserve(100))
+
+	l.SetBytesPerSec(0)
+	require.Equal(t, time.Duration(0), l.reserve(1<<30))
+}
+
+func TestLimiterWaitN(t *testing.T) {
+	l := NewLimiter(10000)
+
+	// a second of bandwidth plus the one of the next 100ms
+	require.NoError(t, l.WaitN(context.Background(), 11000))
+
+	start := time.Now()
+	require.NoError(t, l.WaitN(context.Background(), 1))
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// the next second is reserved
+	require.NoError(t, l.WaitN(context.Background(), 10000))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.ErrorIs(t, l.WaitN(ctx, 1), context.Canceled)
+}
+
+func TestWriter(t *testing.T) {
+	l := NewLimiter(10000)
+
+	var b bytes.Buffer
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := NewWriter(ctx, &b, l)
+
+	n, err := w.Write(make([]byte, 11000))
+	require.NoError(t, err)
+	require.Equal(t, 11000, n)
+
+	start := time.Now()
+
+	n, err = w.Write(make([]byte, 100))
+	require.NoError(t, err)
+	require.Equal(t, 100, n)
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	cancel()
+
+	_, err = w.Write(make([]byte, 100))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 11100, b.Len())
+}
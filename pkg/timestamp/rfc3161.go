@@ -0,0 +1,308 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package timestamp obtains RFC 3161 timestamp tokens of digests from Time Stamping Authorities (TSAs),
+// the tokens prove to third parties that a digest, e.g. the Alh of a database, existed at a given time.
+package timestamp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+const (
+	requestContentType  = "application/timestamp-query"
+	responseContentType = "application/timestamp-reply"
+
+	// maxResponseSize bounds the size of the responses read from the TSAs, tokens are a few KBs
+	maxResponseSize = 1 << 20
+)
+
+var (
+	ErrIllegalArguments   = errors.New("illegal arguments")
+	ErrRequestRejected    = errors.New("timestamp request rejected by the authority")
+	ErrInvalidResponse    = errors.New("invalid timestamp response")
+	ErrInvalidToken       = errors.New("invalid timestamp token")
+	ErrMismatchingImprint = errors.New("timestamp token doesn't match the digest")
+	ErrMismatchingNonce   = errors.New("timestamp token doesn't match the request nonce")
+)
+
+var (
+	oidSHA256     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+)
+
+// PKIStatus values of the responses, as defined in RFC 3161
+const (
+	statusGranted         = 0
+	statusGrantedWithMods = 1
+)
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type messageImprint struct {
+	HashAlgorithm algorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+}
+
+// pkiStatusInfo is the status of a response, StatusString being a PKIFreeText: a sequence of UTF8Strings
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []asn1.RawValue `asn1:"optional"`
+	FailInfo     asn1.BitString  `asn1:"optional"`
+}
+
+// text returns the UTF8Strings of the free text of the status
+func (s *pkiStatusInfo) text() []string {
+	var text []string
+	for _, v := range s.StatusString {
+		if v.Class == asn1.ClassUniversal && v.Tag == asn1.TagUTF8String {
+			text = append(text, string(v.Bytes))
+		}
+	}
+	return text
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,tag:0"`
+}
+
+// signedData holds the fields of a CMS SignedData preceding the certificates and signer infos
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapsulatedContentInfo
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional,default:false"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,explicit,tag:0"`
+}
+
+// Token is a timestamp token issued by a TSA
+type Token struct {
+	// Raw is the DER encoded token, a CMS SignedData verifiable e.g. with "openssl ts -verify"
+	Raw []byte
+
+	HashedMessage []byte
+	GenTime       time.Time
+	SerialNumber  *big.Int
+	Policy        asn1.ObjectIdentifier
+}
+
+// ParseToken parses a DER encoded timestamp token, the CMS signature of the TSA is not verified
+func ParseToken(raw []byte) (*Token, error) {
+	tst, err := parseTSTInfo(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{
+		Raw:           raw,
+		HashedMessage: tst.MessageImprint.HashedMessage,
+		GenTime:       tst.GenTime,
+		SerialNumber:  tst.SerialNumber,
+		Policy:        tst.Policy,
+	}, nil
+}
+
+func parseTSTInfo(raw []byte) (*tstInfo, error) {
+	var ci contentInfo
+
+	_, err := asn1.Unmarshal(raw, &ci)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("%w: unexpected content type %v", ErrInvalidToken, ci.ContentType)
+	}
+
+	var sd signedData
+
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("%w: unexpected encapsulated content type %v", ErrInvalidToken, sd.EncapContentInfo.EContentType)
+	}
+
+	var tst tstInfo
+
+	_, err = asn1.Unmarshal(sd.EncapContentInfo.EContent, &tst)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return &tst, nil
+}
+
+// Client requests timestamp tokens to a TSA over HTTP
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the TSA served at url
+func NewClient(url string, timeout time.Duration) *Client {
+	return &Client{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// URL returns the address of the TSA
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Timestamp requests a token of a SHA-256 digest, the token is checked to match the digest and
+// the nonce of the request
+func (c *Client) Timestamp(ctx context.Context, digest []byte) (*Token, error) {
+	if len(digest) != sha256.Size {
+		return nil, ErrIllegalArguments
+	}
+
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: algorithmIdentifier{
+				Algorithm:  oidSHA256,
+				Parameters: asn1.NullRawValue,
+			},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", requestContentType)
+	httpReq.Header.Set("Accept", responseContentType)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected http status %s", ErrRequestRejected, httpResp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(httpResp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseResponse(body, digest, nonce)
+}
+
+func parseResponse(body []byte, digest []byte, nonce *big.Int) (*Token, error) {
+	var resp timeStampResp
+
+	_, err := asn1.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	if resp.Status.Status != statusGranted && resp.Status.Status != statusGrantedWithMods {
+		return nil, fmt.Errorf("%w: status %d %v", ErrRequestRejected, resp.Status.Status, resp.Status.text())
+	}
+
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, fmt.Errorf("%w: missing token", ErrInvalidResponse)
+	}
+
+	tst, err := parseTSTInfo(resp.TimeStampToken.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tst.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) ||
+		!bytes.Equal(tst.MessageImprint.HashedMessage, digest) {
+		return nil, ErrMismatchingImprint
+	}
+
+	if tst.Nonce == nil || tst.Nonce.Cmp(nonce) != 0 {
+		return nil, ErrMismatchingNonce
+	}
+
+	return &Token{
+		Raw:           resp.TimeStampToken.FullBytes,
+		HashedMessage: tst.MessageImprint.HashedMessage,
+		GenTime:       tst.GenTime,
+		SerialNumber:  tst.SerialNumber,
+		Policy:        tst.Policy,
+	}, nil
+}
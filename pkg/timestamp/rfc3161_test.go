@@ -0,0 +1,182 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timestamp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fullSignedData is the complete CMS SignedData, without certificates, sent by the fake TSA
+type fullSignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapsulatedContentInfo
+	SignerInfos      asn1.RawValue
+}
+
+var emptySet = asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+
+func marshalToken(t *testing.T, tst tstInfo) []byte {
+	tstBs, err := asn1.Marshal(tst)
+	require.NoError(t, err)
+
+	sdBs, err := asn1.Marshal(fullSignedData{
+		Version:          3,
+		DigestAlgorithms: emptySet,
+		EncapContentInfo: encapsulatedContentInfo{
+			EContentType: oidTSTInfo,
+			EContent:     tstBs,
+		},
+		SignerInfos: emptySet,
+	})
+	require.NoError(t, err)
+
+	tokenBs, err := asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sdBs},
+	})
+	require.NoError(t, err)
+
+	return tokenBs
+}
+
+// fakeTSA grants the requests with tokens generated at genTime, tamper changes the token before it's sent
+func fakeTSA(t *testing.T, genTime time.Time, tamper func(tst *tstInfo)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, requestContentType, r.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req timeStampReq
+		_, err = asn1.Unmarshal(body, &req)
+		require.NoError(t, err)
+
+		tst := tstInfo{
+			Version:        1,
+			Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+			MessageImprint: req.MessageImprint,
+			SerialNumber:   big.NewInt(42),
+			GenTime:        genTime,
+			Nonce:          req.Nonce,
+		}
+		if tamper != nil {
+			tamper(&tst)
+		}
+
+		resp, err := asn1.Marshal(timeStampResp{
+			Status:         pkiStatusInfo{Status: statusGranted},
+			TimeStampToken: asn1.RawValue{FullBytes: marshalToken(t, tst)},
+		})
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", responseContentType)
+		w.Write(resp)
+	}))
+}
+
+func TestTimestamp(t *testing.T) {
+	genTime := time.Date(2022, time.March, 15, 3, 0, 0, 0, time.UTC)
+
+	tsa := fakeTSA(t, genTime, nil)
+	defer tsa.Close()
+
+	c := NewClient(tsa.URL, time.Second)
+	require.Equal(t, tsa.URL, c.URL())
+
+	digest := sha256.Sum256([]byte("alh"))
+
+	token, err := c.Timestamp(context.Background(), digest[:])
+	require.NoError(t, err)
+	require.Equal(t, digest[:], token.HashedMessage)
+	require.True(t, genTime.Equal(token.GenTime))
+	require.Equal(t, int64(42), token.SerialNumber.Int64())
+
+	parsed, err := ParseToken(token.Raw)
+	require.NoError(t, err)
+	require.Equal(t, token, parsed)
+
+	_, err = c.Timestamp(context.Background(), []byte("not a digest"))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = ParseToken([]byte("not a token"))
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTimestampMismatchingToken(t *testing.T) {
+	digest := sha256.Sum256([]byte("alh"))
+
+	tsa := fakeTSA(t, time.Now(), func(tst *tstInfo) {
+		tst.MessageImprint.HashedMessage = make([]byte, sha256.Size)
+	})
+	defer tsa.Close()
+
+	_, err := NewClient(tsa.URL, time.Second).Timestamp(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrMismatchingImprint)
+
+	replayingTSA := fakeTSA(t, time.Now(), func(tst *tstInfo) {
+		tst.Nonce = big.NewInt(1)
+	})
+	defer replayingTSA.Close()
+
+	_, err = NewClient(replayingTSA.URL, time.Second).Timestamp(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrMismatchingNonce)
+}
+
+func TestTimestampRejected(t *testing.T) {
+	digest := sha256.Sum256([]byte("alh"))
+
+	tsa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := asn1.Marshal(timeStampResp{
+			Status: pkiStatusInfo{Status: 2, StatusString: []asn1.RawValue{{Tag: asn1.TagUTF8String, Bytes: []byte("bad request")}}},
+		})
+		require.NoError(t, err)
+
+		w.Write(resp)
+	}))
+	defer tsa.Close()
+
+	_, err := NewClient(tsa.URL, time.Second).Timestamp(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrRequestRejected)
+	require.Contains(t, err.Error(), "bad request")
+
+	unavailableTSA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailableTSA.Close()
+
+	_, err = NewClient(unavailableTSA.URL, time.Second).Timestamp(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrRequestRejected)
+
+	invalidTSA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not a response"))
+	}))
+	defer invalidTSA.Close()
+
+	_, err = NewClient(invalidTSA.URL, time.Second).Timestamp(context.Background(), digest[:])
+	require.ErrorIs(t, err, ErrInvalidResponse)
+}
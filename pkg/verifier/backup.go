@@ -0,0 +1,233 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/encryption"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+var ErrBackupChain = errors.New("backups do not form a chain starting with a full backup")
+var ErrBackupStateMismatch = errors.New("backup does not match the trusted state")
+var ErrBackupEncrypted = errors.New("backup is encrypted, the key used to encrypt it must be provided")
+
+// OpenBackupFile returns a reader of the backup archive, decrypting and decompressing it when needed.
+// Encrypted archives can't be read without the provider of the key used to encrypt them.
+func OpenBackupFile(path string, provider encryption.MasterKeyProvider) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(f)
+
+	if encryption.IsEncryptedStream(br) {
+		if provider == nil {
+			f.Close()
+			return nil, ErrBackupEncrypted
+		}
+
+		dr, err := encryption.NewStreamReader(br, provider)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		br = bufio.NewReader(dr)
+	}
+
+	magic, err := br.Peek(2)
+	if err != nil || !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return &backupReader{Reader: br, f: f}, nil
+	}
+
+	gr, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &backupReader{Reader: gr, f: f}, nil
+}
+
+type backupReader struct {
+	io.Reader
+	f *os.File
+}
+
+func (r *backupReader) Close() error {
+	return r.f.Close()
+}
+
+// ReadBackupFile checks the whole backup archive is readable, including the checksums of compressed archives
+// and the authentication of encrypted ones, and returns its manifest
+func ReadBackupFile(path string, provider encryption.MasterKeyProvider) (*store.BackupManifest, error) {
+	r, err := OpenBackupFile(path, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.Copy(ioutil.Discard, tr); err != nil {
+			return nil, err
+		}
+	}
+	// the end of the compressed stream is where its checksum is verified
+	if _, err = io.Copy(ioutil.Discard, r); err != nil {
+		return nil, err
+	}
+
+	mr, err := OpenBackupFile(path, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer mr.Close()
+
+	return store.ReadBackupManifest(mr)
+}
+
+// ReadBackupChain returns the manifests of a full backup followed by the incremental backups taken after it
+func ReadBackupChain(files []string, provider encryption.MasterKeyProvider) ([]*store.BackupManifest, error) {
+	manifests := make([]*store.BackupManifest, len(files))
+
+	for i, file := range files {
+		manifest, err := ReadBackupFile(file, provider)
+		if err != nil {
+			return nil, fmt.Errorf("backup %s is not readable: %w", file, err)
+		}
+
+		if i == 0 && manifest.IsIncremental() ||
+			i > 0 && (manifest.SinceTxID != manifests[i-1].TxID || manifest.SinceAlh != manifests[i-1].Alh) {
+			return nil, fmt.Errorf("backup %s: %w", file, ErrBackupChain)
+		}
+
+		manifests[i] = manifest
+	}
+
+	return manifests, nil
+}
+
+// RestoreBackups restores into path a full backup followed by the incremental backups taken after it,
+// up to the transaction upToTx or up to the last backed up one when it's zero
+func RestoreBackups(path string, files []string, provider encryption.MasterKeyProvider, manifests []*store.BackupManifest, upToTx uint64) (*store.BackupManifest, error) {
+	var restored *store.BackupManifest
+
+	for i, file := range files {
+		if upToTx > 0 && manifests[i].SinceTxID >= upToTx {
+			break
+		}
+
+		// transactions are restored up to the last one in the backup unless upToTx falls in it
+		txID := upToTx
+		if txID > manifests[i].TxID {
+			txID = 0
+		}
+
+		r, err := OpenBackupFile(file, provider)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			restored, err = store.RestoreBackupUpTo(path, r, store.DefaultOptions(), txID)
+		} else {
+			restored, err = store.RestoreIncrementalBackupUpTo(path, r, store.DefaultOptions(), txID)
+		}
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error restoring backup %s: %v", file, err)
+		}
+	}
+
+	return restored, nil
+}
+
+// VerifyBackups restores the backups into a temporary directory and checks every restored transaction,
+// the restored database must hold the transaction of the trusted state when provided
+func VerifyBackups(files []string, provider encryption.MasterKeyProvider, trusted *schema.ImmutableState) (*store.BackupManifest, error) {
+	manifests, err := ReadBackupChain(files, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := manifests[len(manifests)-1]
+
+	if trusted != nil && trusted.TxId > manifest.TxID {
+		return nil, fmt.Errorf("%w: the backups end at transaction %d before the trusted state at transaction %d",
+			ErrBackupStateMismatch, manifest.TxID, trusted.TxId)
+	}
+
+	dir, err := ioutil.TempDir("", "immudb_verify_backup")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "db")
+
+	if _, err = RestoreBackups(path, files, provider, manifests, 0); err != nil {
+		return nil, err
+	}
+
+	st, err := store.Open(path, store.DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	tx := st.NewTxHolder()
+
+	for txID := uint64(1); txID <= manifest.TxID; txID++ {
+		if err = st.CheckTx(txID, tx); err != nil {
+			return nil, fmt.Errorf("error verifying transaction %d: %w", txID, err)
+		}
+	}
+
+	if trusted == nil || trusted.TxId == 0 {
+		return manifest, nil
+	}
+
+	if err = st.ReadTx(trusted.TxId, tx); err != nil {
+		return nil, err
+	}
+	if tx.Header().Alh() != schema.DigestFromProto(trusted.TxHash) {
+		return nil, fmt.Errorf("%w: transaction %d", ErrBackupStateMismatch, trusted.TxId)
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package verifier checks proof bundles and backups against a trusted state without connecting to any server,
+so they can be handed to auditors together with the state they trust and the public key of the server.
+*/
+package verifier
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/proofs"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+var ErrVerificationFailed = errors.New("proof verification failed")
+var ErrInvalidStateSignature = errors.New("invalid signature of the trusted state")
+var ErrStateMismatch = errors.New("proofs do not match the trusted state")
+
+// ReadTrustedState reads the state at path, the state is the JSON document returned by the /db/state endpoint
+// of the server. Its signature is checked when publicKeyPath is provided.
+func ReadTrustedState(path, publicKeyPath string) (*schema.ImmutableState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	state := &schema.ImmutableState{}
+	if err = jsonpb.Unmarshal(f, state); err != nil {
+		return nil, fmt.Errorf("error reading trusted state %s: %v", path, err)
+	}
+
+	if publicKeyPath == "" {
+		return state, nil
+	}
+
+	pk, err := signer.ParsePublicKeyFile(publicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStateSignature(state, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// ReadBundle reads a bundle encoded either as JSON or as CBOR, JSON documents start with an object
+func ReadBundle(path string) (*proofs.Bundle, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &proofs.Bundle{}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = proofs.UnmarshalJSON(data, bundle)
+	} else {
+		err = proofs.UnmarshalCBOR(data, bundle)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle %s: %w", path, err)
+	}
+
+	if bundle.State == nil {
+		return nil, errors.New("the bundle has no state")
+	}
+
+	return bundle, nil
+}
+
+func checkStateSignature(state *schema.ImmutableState, publicKey *ecdsa.PublicKey) error {
+	if state.Signature == nil {
+		return fmt.Errorf("%w: the state is not signed", ErrInvalidStateSignature)
+	}
+
+	ok, err := state.CheckSignature(publicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidStateSignature, err)
+	}
+	if !ok {
+		return ErrInvalidStateSignature
+	}
+
+	return nil
+}
+
+// VerifyBundle checks every entry of the bundle is included in the state of the bundle, whose signature
+// is checked when publicKey is provided. The state of the bundle is checked to be consistent with
+// the trusted state when provided, the consistency proof of the bundle is required unless both states
+// are at the same transaction.
+func VerifyBundle(bundle *proofs.Bundle, trusted *schema.ImmutableState, publicKey *ecdsa.PublicKey) error {
+	if bundle == nil || bundle.State == nil {
+		return fmt.Errorf("%w: the bundle has no state", ErrVerificationFailed)
+	}
+
+	state := bundle.State.ToImmutableState()
+
+	if publicKey != nil {
+		err := checkStateSignature(state, publicKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, e := range bundle.Entries {
+		err := verifyEntry(e, bundle.State)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+
+	if trusted == nil || trusted.TxId == 0 {
+		return nil
+	}
+
+	if trusted.Db != "" && trusted.Db != state.Db {
+		return fmt.Errorf("%w: the bundle is of database '%s' instead of '%s'", ErrStateMismatch, state.Db, trusted.Db)
+	}
+
+	trustedAlh := schema.DigestFromProto(trusted.TxHash)
+
+	if trusted.TxId == bundle.State.TxID {
+		if trustedAlh != bundle.State.TxHash {
+			return fmt.Errorf("%w: transaction %d", ErrStateMismatch, trusted.TxId)
+		}
+
+		return nil
+	}
+
+	if bundle.Consistency == nil {
+		return fmt.Errorf("%w: the bundle has no proof of consistency with transaction %d", ErrStateMismatch, trusted.TxId)
+	}
+
+	dualProof, err := bundle.Consistency.ToDualProof()
+	if err != nil {
+		return err
+	}
+
+	var verifies bool
+
+	if trusted.TxId < bundle.State.TxID {
+		verifies = store.VerifyDualProof(dualProof, trusted.TxId, bundle.State.TxID, trustedAlh, bundle.State.TxHash)
+	} else {
+		verifies = store.VerifyDualProof(dualProof, bundle.State.TxID, trusted.TxId, bundle.State.TxHash, trustedAlh)
+	}
+	if !verifies {
+		return fmt.Errorf("%w: transactions %d and %d", ErrStateMismatch, trusted.TxId, bundle.State.TxID)
+	}
+
+	return nil
+}
+
+func verifyEntry(e *proofs.EntryProof, state *proofs.SignedState) error {
+	if e == nil || e.InclusionProof == nil || e.DualProof == nil {
+		return fmt.Errorf("%w: incomplete entry", ErrVerificationFailed)
+	}
+
+	dualProof, err := e.DualProof.ToDualProof()
+	if err != nil {
+		return err
+	}
+
+	hdr := dualProof.SourceTxHeader
+	if hdr == nil {
+		return fmt.Errorf("%w: incomplete entry", ErrVerificationFailed)
+	}
+
+	verifies := store.VerifyDualProof(dualProof, e.Tx, state.TxID, hdr.Alh(), state.TxHash)
+	if !verifies {
+		return fmt.Errorf("%w: transaction %d is not consistent with the state", ErrVerificationFailed, e.Tx)
+	}
+
+	entrySpecDigest, err := store.EntrySpecDigestFor(hdr.Version, hdr.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	kv := database.EncodeEntrySpec(e.Key, e.Metadata.ToKVMetadata(), e.Value)
+
+	verifies = store.VerifyInclusion(e.InclusionProof.ToInclusionProof(), entrySpecDigest(kv), hdr.Eh, hdr.HashAlgorithm)
+	if !verifies {
+		return fmt.Errorf("%w: key '%s' is not included in transaction %d", ErrVerificationFailed, e.Key, e.Tx)
+	}
+
+	return nil
+}
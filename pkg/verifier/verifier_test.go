@@ -0,0 +1,190 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verifier
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/proofs"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func commit(t *testing.T, st *store.ImmuStore, key string, md *store.KVMetadata, value string) *store.TxHeader {
+	tx, err := st.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	kv := database.EncodeEntrySpec([]byte(key), md, []byte(value))
+
+	err = tx.Set(kv.Key, kv.Metadata, kv.Value)
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+
+	return hdr
+}
+
+func entryProof(t *testing.T, st *store.ImmuStore, key string, md *schema.KVMetadata, value string, txID, stateTxID uint64) *proofs.EntryProof {
+	tx := st.NewTxHolder()
+	err := st.ReadTx(txID, tx)
+	require.NoError(t, err)
+
+	stateTx := st.NewTxHolder()
+	err = st.ReadTx(stateTxID, stateTx)
+	require.NoError(t, err)
+
+	inclusionProof, err := tx.Proof(database.EncodeKey([]byte(key)))
+	require.NoError(t, err)
+
+	dualProof, err := st.DualProof(tx, stateTx)
+	require.NoError(t, err)
+
+	return &proofs.EntryProof{
+		Key:            []byte(key),
+		Value:          []byte(value),
+		Metadata:       proofs.FromKVMetadata(md),
+		Tx:             txID,
+		InclusionProof: proofs.FromInclusionProof(inclusionProof),
+		DualProof:      proofs.FromDualProof(dualProof),
+	}
+}
+
+func TestVerifyBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verifier")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	st, err := store.Open(dir, store.DefaultOptions())
+	require.NoError(t, err)
+	defer st.Close()
+
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	md := &schema.KVMetadata{
+		Expiration:  &schema.Expiration{ExpiresAt: expiresAt},
+		ContentType: "application/json",
+	}
+
+	hdr1 := commit(t, st, "key1", nil, "value1")
+	hdr2 := commit(t, st, "key2", schema.KVMetadataFromProto(md), `{"value":2}`)
+	hdr3 := commit(t, st, "key3", nil, "value3")
+
+	alh2 := hdr2.Alh()
+
+	bundle := &proofs.Bundle{
+		State: &proofs.SignedState{Db: "defaultdb", TxID: hdr2.ID, TxHash: alh2},
+		Entries: []*proofs.EntryProof{
+			entryProof(t, st, "key1", nil, "value1", hdr1.ID, hdr2.ID),
+			entryProof(t, st, "key2", md, `{"value":2}`, hdr2.ID, hdr2.ID),
+		},
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	state := bundle.State.ToImmutableState()
+
+	sig, pub, err := signer.NewSignerFromPKey(rand.Reader, privateKey).Sign(state.ToBytes())
+	require.NoError(t, err)
+
+	err = VerifyBundle(bundle, nil, &privateKey.PublicKey)
+	require.ErrorIs(t, err, ErrInvalidStateSignature)
+
+	bundle.State.Signature = &proofs.Signature{PublicKey: pub, Signature: sig}
+
+	// the bundle is verified once decoded
+	encoded, err := proofs.MarshalCBOR(bundle)
+	require.NoError(t, err)
+
+	decoded := &proofs.Bundle{}
+
+	err = proofs.UnmarshalCBOR(encoded, decoded)
+	require.NoError(t, err)
+
+	err = VerifyBundle(decoded, nil, &privateKey.PublicKey)
+	require.NoError(t, err)
+
+	t.Run("tampered entries are detected", func(t *testing.T) {
+		decoded.Entries[0].Value = []byte("tampered")
+
+		err = VerifyBundle(decoded, nil, nil)
+		require.ErrorIs(t, err, ErrVerificationFailed)
+
+		decoded.Entries[0].Value = []byte("value1")
+		decoded.Entries[1].Metadata.ContentType = "text/plain"
+
+		err = VerifyBundle(decoded, nil, nil)
+		require.ErrorIs(t, err, ErrVerificationFailed)
+
+		decoded.Entries[1].Metadata.ContentType = "application/json"
+		decoded.Entries[1].Tx = hdr1.ID
+
+		err = VerifyBundle(decoded, nil, nil)
+		require.ErrorIs(t, err, ErrVerificationFailed)
+
+		decoded.Entries[1].Tx = hdr2.ID
+	})
+
+	t.Run("the bundle is checked against the trusted state", func(t *testing.T) {
+		err = VerifyBundle(bundle, &schema.ImmutableState{Db: "defaultdb", TxId: hdr2.ID, TxHash: alh2[:]}, nil)
+		require.NoError(t, err)
+
+		err = VerifyBundle(bundle, &schema.ImmutableState{Db: "otherdb", TxId: hdr2.ID, TxHash: alh2[:]}, nil)
+		require.ErrorIs(t, err, ErrStateMismatch)
+
+		alh1 := hdr1.Alh()
+		alh3 := hdr3.Alh()
+
+		err = VerifyBundle(bundle, &schema.ImmutableState{Db: "defaultdb", TxId: hdr2.ID, TxHash: alh1[:]}, nil)
+		require.ErrorIs(t, err, ErrStateMismatch)
+
+		trusted := &schema.ImmutableState{Db: "defaultdb", TxId: hdr3.ID, TxHash: alh3[:]}
+
+		err = VerifyBundle(bundle, trusted, nil)
+		require.ErrorIs(t, err, ErrStateMismatch)
+
+		tx2 := st.NewTxHolder()
+		err = st.ReadTx(hdr2.ID, tx2)
+		require.NoError(t, err)
+
+		tx3 := st.NewTxHolder()
+		err = st.ReadTx(hdr3.ID, tx3)
+		require.NoError(t, err)
+
+		dualProof, err := st.DualProof(tx2, tx3)
+		require.NoError(t, err)
+
+		bundle.Consistency = proofs.FromDualProof(dualProof)
+
+		err = VerifyBundle(bundle, trusted, nil)
+		require.NoError(t, err)
+
+		// the consistency proof does not link the bundle to an older state
+		err = VerifyBundle(bundle, &schema.ImmutableState{Db: "defaultdb", TxId: hdr1.ID, TxHash: alh1[:]}, nil)
+		require.ErrorIs(t, err, ErrStateMismatch)
+	})
+}